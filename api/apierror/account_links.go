@@ -0,0 +1,45 @@
+package apierror
+
+import (
+	"net/http"
+)
+
+func AccountLinkNotFound() Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "not found",
+		longMessage:  "No account link was found with this id.",
+		code:         AccountLinkNotFoundCode,
+	})
+}
+
+func AccountLinkExpired() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Account link expired",
+		longMessage:  "This account link has expired. Start a new one to link your accounts.",
+		code:         AccountLinkExpiredCode,
+	})
+}
+
+func AccountLinkNotVerified() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Account link not verified",
+		longMessage:  "Both accounts need to be verified before they can be linked.",
+		code:         AccountLinkNotVerifiedCode,
+	})
+}
+
+func AccountLinkNotAllowed() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "Account link not allowed",
+		longMessage:  "These accounts cannot be linked. Please contact support for assistance.",
+		code:         AccountLinkNotAllowedCode,
+	})
+}
+
+func AccountLinkAlreadyCompleted() Error {
+	return New(http.StatusConflict, &mainError{
+		shortMessage: "Account link already completed",
+		longMessage:  "This account link has already been completed or canceled.",
+		code:         AccountLinkAlreadyCompletedCode,
+	})
+}
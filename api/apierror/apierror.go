@@ -182,6 +182,7 @@ func (m *mainErrors) ToErrorResponses() []ErrorResponse {
 			ShortMessage: err.ShortMessage(),
 			LongMessage:  err.LongMessage(),
 			Code:         err.Code(),
+			DocURL:       DocURL(err.Code()),
 			Meta:         err.Meta(),
 		}
 
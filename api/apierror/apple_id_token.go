@@ -0,0 +1,13 @@
+package apierror
+
+import (
+	"net/http"
+)
+
+func AppleIDTokenInvalid() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Apple identity token is invalid",
+		longMessage:  "The provided Apple identity token is invalid. Make sure you're using a valid token generated by Sign in with Apple.",
+		code:         AppleIDTokenInvalidCode,
+	})
+}
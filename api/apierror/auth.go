@@ -240,3 +240,13 @@ func InvalidHandshake(reason string) Error {
 		code:         InvalidHandshakeCode,
 	})
 }
+
+// HandshakeBindingMismatch signifies an error when a handshake token is
+// received from another device than the one it was issued to.
+func HandshakeBindingMismatch() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "handshake token replayed",
+		longMessage:  "The handshake token was issued to another device.",
+		code:         HandshakeBindingMismatchCode,
+	})
+}
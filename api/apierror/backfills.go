@@ -0,0 +1,36 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// BackfillNotFound signifies an error when no backfill exists with the
+// given ID.
+func BackfillNotFound(id string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "Backfill not found",
+		longMessage:  fmt.Sprintf("No backfill was found with id %s", id),
+		code:         BackfillNotFoundCode,
+	})
+}
+
+// BackfillAlreadyActive signifies an error when a backfill of the task is
+// already running or paused.
+func BackfillAlreadyActive(task, id string) Error {
+	return New(http.StatusConflict, &mainError{
+		shortMessage: "Backfill already active",
+		longMessage:  fmt.Sprintf("Backfill %s of task %s has to complete before the task can be backfilled again.", id, task),
+		code:         BackfillAlreadyActiveCode,
+	})
+}
+
+// BackfillInvalidStatus signifies an error when the backfill can't be
+// paused or resumed from its current status.
+func BackfillInvalidStatus(id, status string) Error {
+	return New(http.StatusConflict, &mainError{
+		shortMessage: "Invalid backfill status",
+		longMessage:  fmt.Sprintf("Backfill %s can't be changed while it's %s.", id, status),
+		code:         BackfillInvalidStatusCode,
+	})
+}
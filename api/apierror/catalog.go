@@ -0,0 +1,41 @@
+package apierror
+
+//go:generate go run gen_catalog.go
+
+// docsBaseURL is where each error code is documented.
+const docsBaseURL = "https://clerk.com/docs/errors/"
+
+// CatalogEntry describes one of the errors the APIs respond with. The
+// dynamic parts of the messages are in braces, e.g. "No user was found with
+// id {userID}".
+type CatalogEntry struct {
+	Code         string
+	HTTPStatus   int
+	ShortMessage string
+	LongMessage  string
+}
+
+// DocURL returns the URL of the documentation of the error.
+func (e CatalogEntry) DocURL() string {
+	return DocURL(e.Code)
+}
+
+// Catalog returns the errors the APIs respond with, ordered by code. An
+// error code may appear more than once, if it's used with different
+// statuses or messages.
+//
+// The catalog is generated from the constructors of this package, run
+// `go generate` after adding or changing one.
+func Catalog() []CatalogEntry {
+	entries := make([]CatalogEntry, len(catalog))
+	copy(entries, catalog)
+	return entries
+}
+
+// DocURL returns the URL of the documentation of the given error code.
+func DocURL(code string) string {
+	if code == "" {
+		return ""
+	}
+	return docsBaseURL + code
+}
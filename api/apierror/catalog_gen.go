@@ -0,0 +1,447 @@
+// Code generated by gen_catalog.go; DO NOT EDIT.
+
+package apierror
+
+import "net/http"
+
+var catalog = []CatalogEntry{
+	{Code: AccountLinkAlreadyCompletedCode, HTTPStatus: http.StatusConflict, ShortMessage: "Account link already completed", LongMessage: "This account link has already been completed or canceled."},
+	{Code: AccountLinkExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Account link expired", LongMessage: "This account link has expired. Start a new one to link your accounts."},
+	{Code: AccountLinkNotAllowedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Account link not allowed", LongMessage: "These accounts cannot be linked. Please contact support for assistance."},
+	{Code: AccountLinkNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No account link was found with this id."},
+	{Code: AccountLinkNotVerifiedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Account link not verified", LongMessage: "Both accounts need to be verified before they can be linked."},
+	{Code: AccountTransferInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid account transfer", LongMessage: "There is no account to transfer"},
+	{Code: UnauthorizedActionForSessionCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Unauthorized action for session", LongMessage: "Not authorized to perform requested action on session {sessionID}"},
+	{Code: ActiveApplicationDeletionNotAllowedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot delete active application", LongMessage: "The selected application cannot be deleted because it had production activity in the last month. If you are sure you want to delete it, please contact support."},
+	{Code: ActiveProductionInstanceDeletionNotAllowedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot delete active production instance", LongMessage: "The selected production instance cannot be deleted because it had activity in the last month. If you are certain you want to delete it, please contact support."},
+	{Code: ActorTokenAlreadyUsedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "actor token has already been used", LongMessage: "This actor token has already been used. Each token can only be used once."},
+	{Code: ActorTokenCannotBeRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot revoke", LongMessage: "Actor token cannot be revoked because its status is {status}. Only pending tokens can be revoked."},
+	{Code: ActorTokenCannotBeUsedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "actor token cannot be used", LongMessage: "This actor token cannot be used anymore. Please request a new one."},
+	{Code: ActorTokenNotInSignInCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not in sign in", LongMessage: "Actor tokens can only be used during sign in."},
+	{Code: ActorTokenRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "actor token has been revoked", LongMessage: "This actor token has been revoked and cannot be used anymore."},
+	{Code: ActorTokenSubjectNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "user not found", LongMessage: "The user of the actor token no longer exists. Please request a new one."},
+	{Code: AlreadyAMemberInOrganizationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already a member", LongMessage: "User {userID} is already a member of the organization."},
+	{Code: APIVersionInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid API version", LongMessage: "Invalid Clerk API version: {reason}"},
+	{Code: AppleIDTokenInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Apple identity token is invalid", LongMessage: "The provided Apple identity token is invalid. Make sure you're using a valid token generated by Sign in with Apple."},
+	{Code: ApplicationAlreadyBelongsToOrganizationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already belongs to organization", LongMessage: "Application already belongs to the selected organization."},
+	{Code: ApplicationAlreadyBelongsToUserCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already belongs to user", LongMessage: "Application already belongs to the given user."},
+	{Code: AuthenticationInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid authentication", LongMessage: "Unable to authenticate the request, you need to supply an active session"},
+	{Code: AuthorizationHeaderFormatInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid Authorization header format", LongMessage: "Invalid Authorization header format. Must be \"Bearer <YOUR_API_KEY>\""},
+	{Code: AuthorizationInvalidCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Unauthorized request", LongMessage: "You are not authorized to delete system application {applicationID}"},
+	{Code: AuthorizationInvalidCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Unauthorized request", LongMessage: "You are not authorized to perform this request"},
+	{Code: AuthorizationInvalidCode, HTTPStatus: http.StatusForbidden, ShortMessage: "unauthorized request", LongMessage: "You need to be a member of organization {organizationID}, in order to move application {applicationID}."},
+	{Code: BackfillAlreadyActiveCode, HTTPStatus: http.StatusConflict, ShortMessage: "Backfill already active", LongMessage: "Backfill {id} of task {task} has to complete before the task can be backfilled again."},
+	{Code: BackfillInvalidStatusCode, HTTPStatus: http.StatusConflict, ShortMessage: "Invalid backfill status", LongMessage: "Backfill {id} can't be changed while it's {status}."},
+	{Code: BackfillNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Backfill not found", LongMessage: "No backfill was found with id {id}"},
+	{Code: BackupCodesNotAvailableCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Backup codes not available", LongMessage: "In order to use backup codes, you have to enable any other Multi-factor method"},
+	{Code: BadRequestCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Bad request", LongMessage: "Bad request"},
+	{Code: BillingCheckoutSessionAlreadyProcessedCode, HTTPStatus: http.StatusConflict, ShortMessage: "Checkout session already processed", LongMessage: "Checkout session ID {checkoutSessionID} already processed"},
+	{Code: BillingCheckoutSessionNotCompletedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Checkout session not completed", LongMessage: "Checkout session ID {checkoutSessionID} not completed"},
+	{Code: BillingCheckoutSessionNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Checkout session ID not found", LongMessage: "Checkout session ID {checkoutSessionID} not found"},
+	{Code: BillingPlanAlreadyActiveCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Plan already active", LongMessage: "The requested plan is already active"},
+	{Code: BlockedCountryCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Country blocked", LongMessage: "Phone numbers from this country ({iso3166.CountryName}) are not allowed."},
+	{Code: BreaksInstanceInvariantCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Breaks instance invariant", LongMessage: "{invariantDescription} - This invariant is determined by your user settings"},
+	{Code: BulkSizeExceededCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "bulk size exceeded", LongMessage: "Parameters exceed the maximum allowed bulk processing size of {constants.MaxBulkSize}."},
+	{Code: CannotDetectIPCode, HTTPStatus: http.StatusServiceUnavailable, ShortMessage: "{msg}", LongMessage: "{msg}"},
+	{Code: CannotSetUnlimitedSeatsForUserApplicationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not allowed to set unlimited seats", LongMessage: "Cannot set unlimited seats for user applications."},
+	{Code: CannotUnsetUnlimitedSeatsForOrganizationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not allowed to unset unlimited seats", LongMessage: "Cannot unset unlimited seats for organizations."},
+	{Code: CannotUpdateGivenDomainCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not allowed to update domain", LongMessage: "Domain {domain} cannot be updated."},
+	{Code: CannotUpdateUserLimitsOnProductionCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Cannot update user limits on production instances.", LongMessage: ""},
+	{Code: CaptchaInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid token", LongMessage: ""},
+	{Code: CaptchaNotEnabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "CAPTCHA not enabled", LongMessage: "Bot detection can be applied only for production instances which have enabled CAPTCHA."},
+	{Code: CaptchaNotSupportedByClient, HTTPStatus: http.StatusBadRequest, ShortMessage: "Cannot perform CAPTCHA challenge.", LongMessage: "This application requires a Bot Protection challenge, which is only supported by standard web browser environments. It seems you are using a non-standard client (e.g. native/mobile). Please contact {support}."},
+	{Code: CaptchaRequiredCode, HTTPStatus: http.StatusForbidden, ShortMessage: "CAPTCHA required", LongMessage: "This sign up requires a CAPTCHA challenge to be solved. Please solve the challenge and try again."},
+	{Code: CheckoutLockedCode, HTTPStatus: http.StatusLocked, ShortMessage: "Checkout is still processing", LongMessage: "Checkout is still processing for application ID {appID}"},
+	{Code: CheckoutSessionMismatchCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Checkout session ID mismatch", LongMessage: "Application ID {appID} has no matching checkout session ID {checkoutSessionID}"},
+	{Code: ClerkKeyInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "The provided Clerk Secret Key is invalid. Make sure that your Clerk Secret Key is correct.", LongMessage: ""},
+	{Code: ClientNotFoundCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "No client found", LongMessage: "This request is expecting a client and did not find one"},
+	{Code: ClientStateInvalid, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid action", LongMessage: "We were unable to complete {action} for this Client. {resolution}"},
+	{Code: ConflictCode, HTTPStatus: http.StatusConflict, ShortMessage: "Conflict", LongMessage: "Conflict"},
+	{Code: CookieInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{invalidCookieMessage}", LongMessage: ""},
+	{Code: CookieInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{invalidCookieMessage}", LongMessage: "The client's rotating key does not match the given one {token}"},
+	{Code: CookieInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{invalidCookieMessage}", LongMessage: "The token is missing the following claims:{claims}"},
+	{Code: InvalidCSRFTokenCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Invalid or missing CSRF token", LongMessage: "To protect against CSRF attacks, the given request must include a valid CSRF token."},
+	{Code: CustomTemplateRequiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Custom template required", LongMessage: "Only custom templates can be used for this operation - {slug} is a built-in template"},
+	{Code: CustomTemplatesNotAvailableCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Custom templates not available", LongMessage: "Custom templates are not available, you can only use built-in templates"},
+	{Code: DeleteLinkedIdentificationDisallowedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Deleting a linked email address is not allowed", LongMessage: "This email address is linked to one or more Connected Accounts. Remove the Connected Account before deleting this email address."},
+	{Code: DevBrowserUnauthenticatedCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Browser unauthenticated", LongMessage: "Unable to authenticate this browser for your development instance. Check your Clerk cookies and try again. If the issue persists reach out to support@clerk.com."},
+	{Code: DevMonthlySMSLimitExceededCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Development monthly SMS limit exceeded", LongMessage: "Operation cannot be completed because the monthly limit for SMS messages in development ({limit}) has been reached."},
+	{Code: DevelopmentInstanceMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Development instance missing", LongMessage: "No development instance found for application_id: {appID}"},
+	{Code: DeviceAttestationChallengeInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Device attestation challenge is invalid", LongMessage: "The device attestation challenge is invalid, expired or has already been used. Request a new challenge and try again."},
+	{Code: DeviceAttestationFailedCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Device attestation failed", LongMessage: "The {platform} device attestation could not be verified: {reason}."},
+	{Code: DeviceAttestationNotConfiguredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Device attestation not configured", LongMessage: "Device attestation for {platform} is not configured for this instance. Set up the native application settings in the Clerk Dashboard."},
+	{Code: DeviceAttestationRequiredCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Device attestation required", LongMessage: "This application requires a valid device attestation. Request a new challenge from /v1/client/attestation/challenge and attest the device with App Attest or Play Integrity before retrying."},
+	{Code: DomainUpdateForbiddenCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Domain update was forbidden", LongMessage: "Domain can be only updated for production instances"},
+	{Code: DPoPProofInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid DPoP proof", LongMessage: "The secret key is bound to a key pair. Requests must include a DPoP header with a proof signed by its private key."},
+	{Code: DuplicateRecordCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "duplicate allowlist identifier", LongMessage: "the identifier {identifier} already exists"},
+	{Code: DuplicateRecordCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "duplicate blocklist identifier", LongMessage: "the identifier {identifier} already exists"},
+	{Code: DuplicateRecordCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "duplicate feature flag", LongMessage: "A feature flag with key {key} already exists"},
+	{Code: DuplicateRecordCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "duplicate ip restriction rule", LongMessage: "a {ruleType} rule for {value} already exists"},
+	{Code: DuplicateRecordCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{shortMessage}", LongMessage: "There are already pending invitations for the following email addresses: {emailAddresses}"},
+	{Code: EmailDomainNotFoundCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "email domain not found", LongMessage: "Email domain {domain} wasn't found."},
+	{Code: EnhancedEmailDeliverabilityProhibitedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Enhanced email deliverability mode is only compatible with email codes (OTP)", LongMessage: "Ensure that either enhanced email deliverability is disabled or you only have email codes (OTP) enabled."},
+	{Code: EntitlementAlreadyAssociatedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already associated", LongMessage: "The given entitlement is already associated with the product."},
+	{Code: ExternalAccountEmailAddressVerificationRequiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Email address verification required", LongMessage: "Your associated email address is required to be verified, because it was initially created as unverified."},
+	{Code: ExternalAccountMissingRefreshTokenCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Missing refresh token", LongMessage: "We cannot refresh your OAuth access token because the server didn't provide a refresh token. Please re-connect your account."},
+	{Code: ExternalAccountNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Invalid external account", LongMessage: "The External Account was not found."},
+	{Code: FeatureNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "not enabled", LongMessage: "This feature is not enabled on this instance"},
+	{Code: FeatureNotImplementedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "not implemented", LongMessage: "Feature `{feature}` is not available yet"},
+	{Code: FeatureRequiresPSUCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "not a Progressive Sign Up instance", LongMessage: "{feature} can only be used in instances that migrated to Progressive Sign Up (https://clerk.com/docs/upgrade-guides/progressive-sign-up)"},
+	{Code: FormAlreadyExistsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "The {param} already exists. Please try another.", LongMessage: ""},
+	{Code: FormIncorrectCodeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is incorrect", LongMessage: "Incorrect code"},
+	{Code: FormConditionalParamDisallowedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is not allowed", LongMessage: "`{notAllowedParam}` isn't allowed when `{existingParam}` is present."},
+	{Code: FormConditionalParamDisallowedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is not allowed", LongMessage: "`{param}` isn't allowed when `{leftCondition}` is {rightCondition}."},
+	{Code: FormConditionalParamMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is missing", LongMessage: "`{missingParam}` is required when `{conditionalParam}` is present."},
+	{Code: FormConditionalParamMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is missing", LongMessage: "`{param}` is required when `{leftCondition}` is `{rightCondition}`."},
+	{Code: FormConditionalParamValueDisallowedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{value} is not allowed", LongMessage: "`{value}` isn't allowed for `{param}` when {leftCondition} is {rightCondition}."},
+	{Code: FormDataMissing, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "missing data", LongMessage: "Supplied data doesn't match user requirements set for this instance"},
+	{Code: FormNotAllowedToDisableDefaultSecondFactorCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "The default second factor method can only be changed by assigning another method as the default.", LongMessage: ""},
+	{Code: FormEmailAddressBlockedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "disposable email address not allowed", LongMessage: "Disposable email addresses are not allowed. Please choose a permanent one or contact support."},
+	{Code: FormIdentifierExistsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "That {param} is taken. Please try another.", LongMessage: ""},
+	{Code: FormIdentifierNotFoundCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Couldn't find your account.", LongMessage: ""},
+	{Code: FormIncorrectSignatureCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is incorrect", LongMessage: "Incorrect signature"},
+	{Code: FormInvalidOriginCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be a valid origin such as my-app://localhost, chrome-extension://mnhbilbfebpbokpjjamapdecdgieldho, or capacitor://localhost:3000"},
+	{Code: FormParamDuplicateCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "duplicate values", LongMessage: "{value} contains duplicate values"},
+	{Code: FormParamDuplicateCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is duplicate", LongMessage: "{param} included multiple times. There should only be one."},
+	{Code: FormParamDuplicateCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{param} is included more than the maximum of 100 times.", LongMessage: "{param} is included more than the maximum of 100 times."},
+	{Code: FormParamExceedsAllowedSizeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "The given {param} exceeds the maximum allowed size of {maxByteSize} bytes ({value} KB).", LongMessage: ""},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid email addresses", LongMessage: "The following email addresses are invalid: {invalidEmailAddresses}"},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be a valid email address local part."},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be a valid email address."},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be a valid phone number according to E.164 international standard."},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be a valid web3 wallet address that starts with 0x and contains 40 hexadecimal characters."},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{param} must be either a valid email address, a valid phone number according to E.164 international standard or a valid web3 wallet."},
+	{Code: FormParamFormatInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{msg}", LongMessage: ""},
+	{Code: FormInvalidDateCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Date values must be given in Unix millisecond timestamp format with day precision.", LongMessage: ""},
+	{Code: FormInvalidEntitlementKeyCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid key format", LongMessage: "{value}"},
+	{Code: FormInvalidTimeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid format", LongMessage: "{param} must contain a datetime specified in RFC3339 format (e.g. `2022-10-20T10:00:27.645Z`)."},
+	{Code: FormParameterMaxLengthExceededCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "exceeds maximum length", LongMessage: "{param} should not exceed {max} characters."},
+	{Code: FormParameterMinLengthExceededCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "does not reach minimum length", LongMessage: "{param} must be at least {min} characters long."},
+	{Code: FormParamMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Image file missing", LongMessage: "There was no image file present in the request"},
+	{Code: FormParamMissingCode, HTTPStatus: http.StatusForbidden, ShortMessage: "not allowed", LongMessage: "Dashboard mutations are not allowed during impersonation"},
+	{Code: FormParamMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "at least one parameter must be provided", LongMessage: "at least one of `{paramNames}` must be provided"},
+	{Code: FormParamMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is missing", LongMessage: "{param} must be included."},
+	{Code: FormParamNilCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Enter {customText}.", LongMessage: ""},
+	{Code: FormParamTypeInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "`{param}` must be a `{paramType}`."},
+	{Code: FormParamUnknownCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is unknown", LongMessage: "{param} is not a valid parameter for this request."},
+	{Code: FormParamValueDisabled, HTTPStatus: http.StatusBadRequest, ShortMessage: "is disabled", LongMessage: "{value} is disabled. Please verify you're using the correct instance, or see our docs to learn how to enable this value."},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid application name", LongMessage: "The application name {name} is invalid: {reason}"},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid character encoding", LongMessage: "{param} contains invalid UTF-8 characters"},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{sanitizedField} is invalid"},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{value} does not match one of the allowed values for parameter {param}"},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "{value} does not match the allowed values for parameter {param}. Allowed values: {allowed}"},
+	{Code: FormParamValueInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{metadataType} must be a valid key-value object. To reset the {metadataType}, use an empty object (\"{}\").", LongMessage: ""},
+	{Code: FormParameterValueTooLargeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Value too large", LongMessage: "The value of {param} can't be greater than {max}"},
+	{Code: FormPasswordDigestInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "The provided {param} is not a valid {hasher} password hash.", LongMessage: ""},
+	{Code: FormPasswordIncorrectCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Password is incorrect. Try again, or use another method.", LongMessage: ""},
+	{Code: FormPasswordLengthTooLongCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must be less than {maxLen} characters.", LongMessage: ""},
+	{Code: FormPasswordLengthTooShortCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must be {minLen} characters or more.", LongMessage: ""},
+	{Code: FormPasswordNoLowercaseCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must contain at least one lowercase character.", LongMessage: ""},
+	{Code: FormPasswordNoNumberCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must contain at least one number.", LongMessage: ""},
+	{Code: FormPasswordNoSpecialCharCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must contain at least one of the following special characters: {allowedSpecialChars}.", LongMessage: ""},
+	{Code: FormPasswordNoUppercaseCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords must contain at least one uppercase character.", LongMessage: ""},
+	{Code: FormPasswordNotStrongEnoughCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Given password is not strong enough.", LongMessage: ""},
+	{Code: FormPasswordPwnedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Password has been found in an online data breach. For account safety, please {action}.", LongMessage: ""},
+	{Code: FormPasswordSizeInBytesExceededCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Maximum size in bytes exceeded", LongMessage: ""},
+	{Code: FormPasswordValidationFailedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Passwords validation failed. Try again.", LongMessage: ""},
+	{Code: FormPhoneNumberCountryNotAllowedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "country not allowed", LongMessage: "{param} must belong to one of the following countries: {allowedCountries}."},
+	{Code: FormResourceNotFoundCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is missing", LongMessage: "The resource associated with the supplied {param} was not found."},
+	{Code: FormInvalidSessionInactivityTimeoutCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "Session inactivity timeout must be lower than maximum session lifetime."},
+	{Code: FormUsernameInvalidCharacterCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{param} can only contain letters, numbers and '_' or '-'.", LongMessage: ""},
+	{Code: FormUsernameInvalidLengthCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{param} must be between {min} and {max} characters long.", LongMessage: ""},
+	{Code: FormUsernameNeedsNonNumberCharCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{param} must contain one non-number character.", LongMessage: ""},
+	{Code: FormIdentificationNeededCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is unverified", LongMessage: "This identification needs to be verified before you can perform this action."},
+	{Code: GatewayTimeoutCode, HTTPStatus: http.StatusGatewayTimeout, ShortMessage: "Gateway Timeout", LongMessage: "A request to a 3rd party service timed out"},
+	{Code: GoogleOneTapTokenInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Google One Tap token is invalid", LongMessage: "The provided Google One Tap token is invalid. Make sure you're using a valid token generated by Google."},
+	{Code: HandshakeBindingMismatchCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "handshake token replayed", LongMessage: "The handshake token was issued to another device."},
+	{Code: HomeURLTakenCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Domain already in use", LongMessage: "The {homeURL} root domain is already in use by another application."},
+	{Code: HostInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid host", LongMessage: "We were unable to attribute this request to an instance running on Clerk. Make sure that your Clerk Publishable Key is correct."},
+	{Code: IdempotencyKeyInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Idempotency key invalid", LongMessage: "The Idempotency-Key header must be at most {maxLength} characters long."},
+	{Code: IdempotencyKeyReusedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Idempotency key reused", LongMessage: "This idempotency key was already used for a request with different parameters. Use a new key for every distinct request."},
+	{Code: IdempotencyRequestInProgressCode, HTTPStatus: http.StatusConflict, ShortMessage: "Request in progress", LongMessage: "A request with the same idempotency key is still being processed. Retry once it completes."},
+	{Code: IdentificationClaimsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Identification claimed by another user", LongMessage: "One or more identifiers on this sign up have since been connected to a different User. Please sign up again."},
+	{Code: IdentificationCreateSecondFactorUnverified, HTTPStatus: http.StatusBadRequest, ShortMessage: "Create failed", LongMessage: "Unverified identifications cannot be a second factor"},
+	{Code: IdentificationDeletionFailedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Deletion failed", LongMessage: "You cannot delete your last identification."},
+	{Code: IdentificationSetFor2FAFailedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Update failed", LongMessage: "You cannot set your last identification as second factor."},
+	{Code: IdentificationUpdateSecondFactorUnverified, HTTPStatus: http.StatusBadRequest, ShortMessage: "Update failed", LongMessage: "Cannot update second factor attributes for unverified identification"},
+	{Code: IdentifierAlreadySignedInCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "You're already signed in", LongMessage: ""},
+	{Code: ImageNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Image not found", LongMessage: ""},
+	{Code: ImageTooLargeCode, HTTPStatus: http.StatusRequestEntityTooLarge, ShortMessage: "Image too large", LongMessage: "The image being uploaded is more than 10MB. Please choose a smaller one."},
+	{Code: InactiveSubscriptionCode, HTTPStatus: http.StatusGone, ShortMessage: "Inactive subscription", LongMessage: "Subscription {id} is not active or trialling"},
+	{Code: IncorrectPasswordCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "incorrect password", LongMessage: "The provided password is not the one the user has set"},
+	{Code: InfiniteRedirectLoopCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Infinite redirect loop detected", LongMessage: "Infinite redirect loop detected. That usually means that we were not able to determine the auth state for this request."},
+	{Code: InstanceKeyRequiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Key required", LongMessage: "Please generate at least one instance key"},
+	{Code: InstanceMaintenanceModeCode, HTTPStatus: http.StatusServiceUnavailable, ShortMessage: "Application under maintenance", LongMessage: "{banner}"},
+	{Code: InstanceTypeInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "This request isn't valid for this instance type.", LongMessage: ""},
+	{Code: IntegrationOauthFailureCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Integration oauth flow could not be completed", LongMessage: "Could not obtain an oauth token necessary for the current integration"},
+	{Code: IntegrationProvisioningFailedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Integration provisioning failed", LongMessage: "Failed to provision Vercel project_id: {projectID} for integration_id: {integrationID}"},
+	{Code: IntegrationTokenMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Integration token missing", LongMessage: "No corresponding third party tokens found for integration_id: {integrationID}"},
+	{Code: IntegrationUserInfoErrorCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "User info retrieval error", LongMessage: "Could not retrieve user info for integration_id: {integrationID}"},
+	{Code: InternalClerkErrorCode, HTTPStatus: http.StatusInternalServerError, ShortMessage: "Oops, an unexpected error occurred", LongMessage: "There was an internal error on our servers. We've been notified and are working on fixing it."},
+	{Code: InvalidActionForSessionCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid action for user session", LongMessage: "Unable to {action} session {sessionID}"},
+	{Code: InvalidCaptchaWidgetTypeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Invalid captcha widget type", LongMessage: "{longmsg}"},
+	{Code: InvalidHandshakeCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid handshake", LongMessage: "The handshake request is invalid: {reason}"},
+	{Code: InvalidPlan, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Invalid plan", LongMessage: "Plan {planID} can't be selected for {resourceType} {resourceID}"},
+	{Code: InvalidProxyConfigurationCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "{msg}", LongMessage: "Clerk Frontend API cannot be accessed through the proxy URL. Make sure your proxy is configured correctly."},
+	{Code: InvalidQueryParameterValueCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{param}is invalid", LongMessage: "{value} does not match one of the allowed values for parameter {param}"},
+	{Code: InvalidRedirectURLCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Redirect url invalid", LongMessage: "The provided redirect url is not in a valid format"},
+	{Code: InvalidSessionTokenCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid session token", LongMessage: "The token provided could not be successfully verified"},
+	{Code: InvalidSubscriptionPlanSwitchCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Unsupported plan features", LongMessage: "Some application features are not supported in your new plan. Stay with your current plan to avoid breaking changes."},
+	{Code: InvalidTemplateBodyCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Invalid template body", LongMessage: "This template body is invalid and cannot be rendered successfully, please check for syntax errors"},
+	{Code: InvitationAccountAlreadyExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "account exists", LongMessage: "An account already exists for this invitation. Sign in instead."},
+	{Code: InvitationIdentificationNotExistCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "identification not found", LongMessage: "This invitation refers to a non-existing identification."},
+	{Code: InvitationAlreadyAcceptedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invitation is already accepted, try signing in instead.", LongMessage: ""},
+	{Code: InvitationAlreadyRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invitation is already revoked.", LongMessage: ""},
+	{Code: InvitationsNotSupportedInInstanceCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invitations are only supported on instances that accept email addresses.", LongMessage: ""},
+	{Code: JWTTemplateReservedClaimCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "reserved claim used", LongMessage: "You can't use the reserved claim: '{claim}'"},
+	{Code: KnownHostingDomainCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Known hosting domain", LongMessage: "The {domain} domain cannot be used to deploy production apps."},
+	{Code: LastInstanceKeyCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Cannot delete last key for instance", LongMessage: "Cannot delete last key for instance {instanceID}"},
+	{Code: LastRequiredIdentificationDeletionFailedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Deleting your last {sanitizedIdentType} is prohibited", LongMessage: "You are required to maintain at least one {sanitizedIdentType} in your account at all times"},
+	{Code: MaintenanceModeCode, HTTPStatus: http.StatusServiceUnavailable, ShortMessage: "System under maintenance", LongMessage: "We are currently undergoing maintenance and only essential operations are permitted. We will be back shortly."},
+	{Code: MalformedPublishableKeyCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Malformed publishable key", LongMessage: "Ensure the provided publishable key ({key}) is the one displayed in Dashboard"},
+	{Code: MalformedRequestParametersCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Malformed request parameters", LongMessage: "The request parameters are malformed and could not be parsed"},
+	{Code: OAuthMisconfiguredProviderCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Misconfigured OAuth provider", LongMessage: "Misconfigured OAuth provider. Please make sure you have set it correctly"},
+	{Code: MissingOrganizationPermissionCode, HTTPStatus: http.StatusForbidden, ShortMessage: "missing permission", LongMessage: "Current user is missing an organization permission."},
+	{Code: MissingQueryParameterCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Missing query parameter", LongMessage: "Either of the following query parameters must be provided: {params}."},
+	{Code: MissingQueryParameterCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Missing query parameter '{param}'", LongMessage: "The query parameter '{param}' is missing from the request. Please consult the API documentation for more information."},
+	{Code: MultipleAuthorizationHeaderValuesCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Multiple 'Authorization' header values", LongMessage: "Setting multiple values in the 'Authorization' header is forbidden"},
+	{Code: MultipleOriginHeaderValuesCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Multiple 'Origin' header values", LongMessage: "Setting multiple values in the 'Origin' header is forbidden"},
+	{Code: NativeWebhooksEnabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Native webhook delivery is already enabled for the current instance.", LongMessage: ""},
+	{Code: NativeWebhooksNotEnabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Native webhook delivery is not enabled for the current instance.", LongMessage: ""},
+	{Code: NetworkNotAllowedAccessCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Access not allowed.", LongMessage: "Access to this application is not allowed from your network or location."},
+	{Code: NoBillingAccountConnectedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "no billing account", LongMessage: "No billing account is connected to the given instance. Please go via the connect flow first."},
+	{Code: NoPasskeysFoundForUserCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "User has no passkeys", LongMessage: "User has no passkeys registered for this account"},
+	{Code: NoPasswordSetCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "no password set", LongMessage: "This user does not have a password set for their account"},
+	{Code: NoPrimaryEmailAddressCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "no primary email address", LongMessage: "This user does not have a primary email address to send the email to"},
+	{Code: NoSecondFactorsForStrategyCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "no second factors", LongMessage: "No second factors were found for strategy {strategy}."},
+	{Code: NotAMemberInOrganizationCode, HTTPStatus: http.StatusForbidden, ShortMessage: "not a member", LongMessage: "Current user is not a member of the organization. Only organization members can perform this action."},
+	{Code: IdentifierNotAllowedAccessCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Access not allowed.", LongMessage: "{who} {verb} not allowed to access this application."},
+	{Code: NotAnAdminInOrganizationCode, HTTPStatus: http.StatusForbidden, ShortMessage: "not an administrator", LongMessage: "{who} is not an administrator in the organization. Only administrators can perform this action."},
+	{Code: InstanceNotLiveCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Instance is not live yet", LongMessage: "This instance is not live yet. This operation is only available for live instances."},
+	{Code: OAuthAccessDeniedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Access denied to {providerName} account", LongMessage: "You did not grant access to your {providerName} account"},
+	{Code: OAuthAccountAlreadyConnectedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Already connected", LongMessage: "Another account is already connected for this particular provider ({providerTitle})"},
+	{Code: OAuthCallbackInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid OAuth callback", LongMessage: "invalid form for oauth_callback"},
+	{Code: OAuthConfigMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{provider} OAuth keys are missing", LongMessage: "The application does not have {provider} OAuth keys set in its settings."},
+	{Code: OAuthFetchUserErrorCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Fetch user error", LongMessage: "{err}"},
+	{Code: OAuthFetchUserErrorCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "unable to fetch user info", LongMessage: "Unable to fetch user info. Check if access token is present and valid."},
+	{Code: OAuthFetchUserForbiddenErrorCode, HTTPStatus: http.StatusForbidden, ShortMessage: "unable to fetch user info", LongMessage: "Unable to fetch user info. User is not allowed access to this application."},
+	{Code: OAuthIdentificationClaimedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Identification claimed by another user", LongMessage: "The email address associated with this OAuth account is already claimed by another user."},
+	{Code: OAuthMissingAccessTokenCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Missing OAuth access token", LongMessage: "OAuth access token is missing"},
+	{Code: OAuthMissingRefreshTokenCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Cannot refresh OAuth access token", LongMessage: "The current access token has expired and we cannot refresh it, because the authorization server hasn't provided us with a refresh token"},
+	{Code: OauthNonAuthenticatableProviderCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{providerTitle} OAuth is not supported for authentication.", LongMessage: "{providerTitle} OAuth is not supported for authentication. Please contact us if you think this error should not appear."},
+	{Code: OAuthProviderNotEnabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{providerTitle} OAuth provider not enabled", LongMessage: "Single-sign on with {providerTitle} OAuth provider is not enabled in the instance settings."},
+	{Code: OAuthSharedCredentialsNotSupportedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Shared credentials not supported", LongMessage: "Shared credentials are no longer supported for this provider. Please update via the Clerk Dashboard."},
+	{Code: OAuthTokenExchangeErrorCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Token exchange error", LongMessage: "{err}"},
+	{Code: OAuthTokenProviderNotEnabledCode, HTTPStatus: http.StatusNotFound, ShortMessage: "OAuth provider not enabled", LongMessage: "Single-sign on for this OAuth provider is not enabled in the instance settings."},
+	{Code: OauthTokenRetrievalErrorCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Token retrieval failed", LongMessage: "Failed to retrieve a new access token from the OAuth provider"},
+	{Code: OAuthUnsupportedProviderCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "{providerTitle} OAuth is not supported.", LongMessage: "{providerTitle} OAuth is not supported. Please contact us if you think this error should not appear."},
+	{Code: APIOperationDeprecatedCode, HTTPStatus: http.StatusGone, ShortMessage: "endpoint is deprecated and pending removal", LongMessage: "{message}"},
+	{Code: OperationNotAllowedOnPrimaryDomainCode, HTTPStatus: http.StatusForbidden, ShortMessage: "operation not allowed", LongMessage: "This operation is not allowed on a primary domain. Try again with a satellite domain of the instance."},
+	{Code: OperationNotAllowedOnSatelliteDomainCode, HTTPStatus: http.StatusForbidden, ShortMessage: "operation not allowed", LongMessage: "This operation is not allowed on a satellite domain. Try again using the primary domain of your instance."},
+	{Code: OrganizationAdminDeleteNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "admin delete not enabled", LongMessage: "Deletion by admin is not enabled for this organization."},
+	{Code: OrganizationAPIKeyNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No organization API key was found with id {apiKeyID}"},
+	{Code: OrganizationAPIKeyOutOfScopeCode, HTTPStatus: http.StatusForbidden, ShortMessage: "out of scope", LongMessage: "Organization API keys can only access the organization they were issued for."},
+	{Code: OrganizationCreatorNotFoundCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "creator not found", LongMessage: "No users found with id {userID}."},
+	{Code: OrganizationDomainAlreadyExistsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "organizaton domain already exists", LongMessage: "This domain is already used by another organization."},
+	{Code: OrganizationDomainBlockedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "blocked email domain", LongMessage: "This is a blocked email provider domain. Please use a different one."},
+	{Code: OrganizationDomainCommonCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "common email domain", LongMessage: "This is a common email provider domain. Please use a different one."},
+	{Code: OrganizationDomainEnrollmentModeNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization enrollment mode not enabled", LongMessage: "Enrollment mode {enrollmentMode} is not enabled for this instances's organizations."},
+	{Code: OrganizationDomainMismatchCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Organization domain mismatch", LongMessage: "The provided email address doesn't match the organization domain name."},
+	{Code: OrganizationDomainQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization domains quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} domains per organization."},
+	{Code: OrganizationDomainsNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization domains not enabled", LongMessage: "This instance does not have domains enabled for organizations."},
+	{Code: OrganizationHierarchyCycleCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "organization hierarchy cycle", LongMessage: "An organization can't be the parent of itself or of any of its ancestors."},
+	{Code: OrganizationHierarchyTooDeepCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "organization hierarchy too deep", LongMessage: "Organizations can be nested up to {maxDepth} levels deep."},
+	{Code: OrganizationInstancePermissionsQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "custom organization permissions for instance quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} organization permissions per instance."},
+	{Code: OrganizationInstanceRolesQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization roles for instance quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} organization roles per instance."},
+	{Code: OrganizationInvitationAlreadyAcceptedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invitation has already been accepted", LongMessage: "This invitation has already been accepted. Sign in instead."},
+	{Code: OrganizationInvitationEmailNotVerifiedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "email address not verified", LongMessage: "You need to verify the email address {emailAddress} before accepting this invitation."},
+	{Code: OrganizationInvitationExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invitation has expired", LongMessage: "This invitation has expired and cannot be used anymore. Ask for a new invitation instead."},
+	{Code: OrganizationInvitationIdentificationAlreadyExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "email address already exists", LongMessage: "The email address in this invitation already exists. If it belongs to you, try signing in instead."},
+	{Code: OrganizationInvitationIdentificationNotExistCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "identification not found", LongMessage: "This invitation refers to a non-existing identification."},
+	{Code: OrganizationInvitationNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No invitation found with id {invitationID}."},
+	{Code: OrganizationInvitationNotPendingCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not pending", LongMessage: "The organization invitation is not in the \"pending\" status."},
+	{Code: OrganizationInvitationNotUniqueCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "organization invitation not unique", LongMessage: "Organizations cannot have duplicate pending invitations for an email address."},
+	{Code: OrganizationInvitationRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invitation has been revoked", LongMessage: "This invitation has been revoked and cannot be used anymore."},
+	{Code: OrganizationInvitationToDeletedOrganizationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "organization invitation to deleted organization", LongMessage: "This invitation refers to an organization that has been deleted."},
+	{Code: OrganizationMembershipExportNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No membership export found with id {exportID}."},
+	{Code: OrganizationMembershipPlanQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization membership quota exceeded", LongMessage: "You have reached the limit of {maxAllowed} organization memberships allowed by the subscription plan. Please upgrade your subscription to add more."},
+	{Code: OrganizationMembershipQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization membership quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} organization memberships, including outstanding invitations."},
+	{Code: OrganizationMembershipRequiredCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization membership required", LongMessage: "Only members of an organization are allowed to sign in to this application."},
+	{Code: OrganizationMissingCreatorRolePermissionsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "missing permissions for creator role", LongMessage: "The creator role must contain the following permissions: {permKeys}"},
+	{Code: OrganizationNotEnabledInInstanceCode, HTTPStatus: http.StatusForbidden, ShortMessage: "access denied", LongMessage: "The organizations feature is not enabled for this instance. You can enable it at https://dashboard.clerk.com."},
+	{Code: OrganizationParentNotFoundCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "parent organization not found", LongMessage: "No organization found in this instance with the given parent organization id."},
+	{Code: OrganizationQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} organizations. You can remove the organization limit by upgrading to a paid plan or using a production instance."},
+	{Code: OrganizationRoleAssignedToMembersCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "role is assigned to organization members", LongMessage: "The organization role is currently assigned to one or more organization members."},
+	{Code: OrganizationRoleUsedAsDefaultCreatorRoleCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "role is used as the creator role", LongMessage: "The organization role cannot be deleted as it is currently used as the creator role."},
+	{Code: OrganizationRoleUsedAsDomainDefaultRoleCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "role is used as the domain default role", LongMessage: "The organization role cannot be deleted as it is currently used as the default domain role."},
+	{Code: OrganizationRoleExistsInInvitationsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "role exists in pending organization invitations", LongMessage: "The organization role exists in one or more pending organization invitations. Please revoke these invitations to proceed."},
+	{Code: OrganizationRolePermissionAssociationExistsCode, HTTPStatus: http.StatusConflict, ShortMessage: "permission already assigned to role", LongMessage: "This organization permission is already associated to this organization role."},
+	{Code: OrganizationRolePermissionAssociationNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "permission not assigned to role", LongMessage: "This organization permission is not associated with the organization role."},
+	{Code: OrganizationSuggestionAlreadyAcceptedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "suggestion has already been accepted", LongMessage: "This organization suggestion has already been accepted."},
+	{Code: OrganizationSystemPermissionNotModifiableCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization system permission cannot be modified", LongMessage: "This organization permission cannot be modified because it is a system permission."},
+	{Code: OrganizationUnlimitedMembershipsRequiredCode, HTTPStatus: http.StatusForbidden, ShortMessage: "organization has limited memberships", LongMessage: "This feature is not supported because organization membership is limited. You can remove the limit by upgrading your subscription plan."},
+	{Code: OrganizationMinimumPermissionsNeededCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "minimum organization permissions needed", LongMessage: "There has to be at least one organization member with the minimum required permissions"},
+	{Code: OriginAndAuthorizationHeadersSetCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Setting both the 'Origin' and 'Authorization' headers is forbidden", LongMessage: "For security purposes, only one of the 'Origin' and 'Authorization' headers should be provided, but not both. In browser contexts, the 'Origin' header is set automatically by the browser. In native application contexts (e.g. mobile apps), set the 'Authorization' header."},
+	{Code: OriginInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid HTTP Origin header", LongMessage: "The Request HTTP Origin header must be equal to or a subdomain of the requesting URL."},
+	{Code: OriginHeaderMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Origin header missing", LongMessage: "This request requires an Origin header to be set, but it is missing"},
+	{Code: PasskeyAuthenticationFailureCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "authentication failed", LongMessage: "Passkey authentication failed"},
+	{Code: PasskeyIdentificationNotVerifiedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "passkey identification not verified", LongMessage: "Passkey identification not verified. Registration is incomplete."},
+	{Code: PasskeyInvalidPublicKeyCredentialCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "Invalid passkey public key credential"},
+	{Code: PasskeyInvalidVerificationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid verification", LongMessage: "Passkey verification contains invalid nonce"},
+	{Code: PasskeyNotRegisteredCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not registered", LongMessage: "Passkey is not registered."},
+	{Code: PasskeyQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "passkey quota exceeded", LongMessage: "You have reached your limit of {maxAllowed} passkeys per account."},
+	{Code: PasskeyRegistrationFailureCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Passkey registration failed", LongMessage: "Passkey registration flow could not be completed"},
+	{Code: PasswordRequiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "password required", LongMessage: "Settings for this instance require a password to be set. Cannot remove the user's password."},
+	{Code: PhoneNumberRejectedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Phone number not supported", LongMessage: "Verification codes can't be sent to this phone number. Please use a mobile phone number instead."},
+	{Code: PricingPlanAlreadyExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Plan already exists", LongMessage: ""},
+	{Code: PrimaryDomainAlreadyExistsCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "primary domain already exists", LongMessage: "Currently, only a single primary domain is supported and the current instance already has one. All new domains need to be set a satellites."},
+	{Code: PrimaryEmailAddressChangeExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "confirmation token expired", LongMessage: "This primary email address change has expired. Please change your primary email address again."},
+	{Code: PrimaryEmailAddressChangeInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid confirmation token", LongMessage: "This primary email address change confirmation token is invalid or has already been used."},
+	{Code: PrimaryIdentificationNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Identification not found", LongMessage: "No primary identification was found for user {userID}"},
+	{Code: ProductAlreadySubscribedCode, HTTPStatus: http.StatusConflict, ShortMessage: "Product already subscribed", LongMessage: "Product {productID} is already enabled for the current subscription."},
+	{Code: ProductNotSupportedBySubscriptionPlanCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Product not supported by subscription plan", LongMessage: "The product {productID} is not compatible with the current subscription plan"},
+	{Code: ProductionInstanceExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "You can only have one production instance.", LongMessage: ""},
+	{Code: ProxyRequestInvalidSecretKeyCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "invalid secret key", LongMessage: "The secret key given with this proxy request is invalid."},
+	{Code: ProxyRequestMissingSecretKeyCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "missing secret key", LongMessage: "When using a proxy, it's required to also pass the instance secret key in the Clerk-Secret-Key header."},
+	{Code: QuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Quota exceeded", LongMessage: "Quota exceeded, you have reached your limit."},
+	{Code: RateLimitExceededCode, HTTPStatus: http.StatusTooManyRequests, ShortMessage: "Rate limit exceeded", LongMessage: "Too many requests, retry after {retryAfter} seconds."},
+	{Code: OAuthRedirectURIMismatch, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid redirect uri configuration in {providerName}", LongMessage: "Your {providerName} account configuration is invalid. Make sure you register this endpoint in the list of allowed callback URLs."},
+	{Code: RefreshTokenInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid refresh token", LongMessage: "The refresh token provided is not valid for this session"},
+	{Code: RefreshTokenReusedCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Refresh token reused", LongMessage: "The refresh token provided was already used. All sessions of this client have been revoked, please sign in again."},
+	{Code: RequestBodyInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Request body invalid", LongMessage: "The request body is invalid. Please consult the API documentation for more information."},
+	{Code: RequestBodyInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "unsupported image type", LongMessage: "'{imageType}' images are not currently supported. Please consult the API documentation for more information."},
+	{Code: RequestHeaderMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid request headers", LongMessage: "{longMessage}"},
+	{Code: RequestInvalidForEnvironmentCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid request for environment", LongMessage: "Request only valid for {envTypesAsString} instances."},
+	{Code: RequestInvalidForInstanceCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid request for instance", LongMessage: "This request is not valid for your instance. Modify your instance settings to use this request."},
+	{Code: RequestTimeoutCode, HTTPStatus: http.StatusServiceUnavailable, ShortMessage: "Request Timeout", LongMessage: "The request took too long to complete. Please try again."},
+	{Code: RequiredVariableMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "should contain {{{requiredVariable}}} variable", LongMessage: "Body should contain the {{{requiredVariable}}} variable"},
+	{Code: ReservedDomainCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Domain reserved by Clerk", LongMessage: "The {domain} domain is reserved by Clerk."},
+	{Code: ReservedSubdomainCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Reserved subdomain", LongMessage: "The {subdomain} subdomain is reserved by Clerk."},
+	{Code: ResourceForbiddenCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Sign up forbidden", LongMessage: "Access to this sign up is forbidden"},
+	{Code: ResourceForbiddenCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Update operations are not allowed on older sign ins", LongMessage: ""},
+	{Code: ResourceForbiddenCode, HTTPStatus: http.StatusForbidden, ShortMessage: "belongs to different user", LongMessage: "The given identification belongs to a different user."},
+	{Code: ResourceForbiddenCode, HTTPStatus: http.StatusForbidden, ShortMessage: "forbidden", LongMessage: "Resource forbidden"},
+	{Code: ResourceInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Resource invalid", LongMessage: "{msg}"},
+	{Code: ResourceMismatchCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Redirect url mismatch", LongMessage: "The current redirect url passed in the sign in or sign up request does not match an authorized redirect URI for this instance. Review authorized redirect urls for your instance. {val}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Application not found", LongMessage: "No application was found with id {appID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Client not found", LongMessage: "No client was found with id {clientID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Domain not found", LongMessage: "No domain was found with {id}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "IP restriction rule not found", LongMessage: "No IP restriction rule was found with id {ruleID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Identifier not found", LongMessage: "No identifier was found with id {identifierID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Instance not found", LongMessage: "No instance was found with id {instanceID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Integration not found", LongMessage: "No integration was found with id {integrationID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Integration not found", LongMessage: "No integration with type {integrationType} found for instance_id: {instanceID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "JWT template not found", LongMessage: "No JWT template exists with {attribute}: {val}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Redirect url not found", LongMessage: "No RedirectURL exists with {attribute}: {val}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Resource not found", LongMessage: "No resource was found for ID {resourceID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Session not found", LongMessage: "No session was found with id {sessionID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Sign up not found", LongMessage: "No sign up was found with id {id}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Template not found", LongMessage: "No template was found with slug {slug}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "URL not found", LongMessage: "The URL was not found"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "feature flag not found", LongMessage: "No feature flag was found with key {key}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "Given organization not found."},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No invitation was found with id {invitationID}."},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No sign in was found with id {signInID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No signing key was found with id {signingKeyID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No user was found with id {userID}"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "Organization permission not found"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "Organization role not found"},
+	{Code: ResourceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "Resource not found"},
+	{Code: RevokedInvitationCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "The invitation was revoked.", LongMessage: ""},
+	{Code: SAMLConnectionActiveNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No active SAML Connection found with id {connectionID}."},
+	{Code: SAMLConnectionCantBeActivatedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "SAML Connection can't be activated", LongMessage: "You have to provide the {missingFields} before you are able to activate this connection."},
+	{Code: SAMLConnectionDomainNotVerifiedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "domain is not verified", LongMessage: "{domain} must be a verified domain of the organization."},
+	{Code: SAMLNotEnabledCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "SAML SSO not enabled", LongMessage: "SAML SSO is not enabled for this email address."},
+	{Code: SAMLEmailAddressDomainMismatchCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Email address domain mismatch", LongMessage: "The email address domain of the provider's account does not match the domain of the connection."},
+	{Code: SAMLEmailAddressDomainReservedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "email address domain is used for SAML SSO", LongMessage: "You can't use this email address, as SAML SSO is enabled for the specific domain."},
+	{Code: SAMLFailedToFetchIDPMetadataCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Failed to fetch IdP metadata", LongMessage: "We failed to fetch the IdP metadata. If the error persists, please provide the IdP configuration data explicitly."},
+	{Code: SAMLFailedToParseIDPMetadataCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Failed to parse IdP metadata", LongMessage: "We failed to parse the IdP metadata. If the error persists, please provide the IdP configuration data explicitly."},
+	{Code: SAMLLogoutNotSupportedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "SAML single logout not supported", LongMessage: "The SAML Connection {connectionID} has no IdP single logout URL configured."},
+	{Code: SAMLLogoutRequestInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid SAML logout request", LongMessage: "The SAML logout request is invalid."},
+	{Code: SAMLLogoutResponseInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid SAML logout response", LongMessage: "The SAML logout response is invalid."},
+	{Code: SAMLResponseInvalidCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Invalid SAML response", LongMessage: "The SAML response is invalid."},
+	{Code: SAMLResponseRelayStateMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "RelayState parameter missing", LongMessage: "The RelayState parameter is missing from the SAML Response. Contact your IdP administrator for resolution."},
+	{Code: SAMLSignInConnectionMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "No SAML Connection for this sign-in", LongMessage: "The current sign-in does not have a corresponding SAML Connection."},
+	{Code: SAMLSignUpConnectionMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "No SAML Connection for this sign-up", LongMessage: "The current sign-up does not have a corresponding SAML Connection."},
+	{Code: SAMLUserAttributeMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "SAML SSO user attribute missing", LongMessage: "This account does not have an associated '{attrName}' attribute. Contact your IdP administrator for resolution."},
+	{Code: SCIMGroupMembersRequiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Group members required", LongMessage: "Groups must be created with at least one member, which becomes the creator of the organization."},
+	{Code: SCIMInvalidFilterCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid filter", LongMessage: "The filter {filter} is invalid: {reason}."},
+	{Code: SCIMInvalidPathCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid path", LongMessage: "The attribute path {path} is not supported."},
+	{Code: SCIMInvalidSyntaxCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid syntax", LongMessage: "The request body is not a valid SCIM message."},
+	{Code: SCIMInvalidValueCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid value", LongMessage: "The value of {attribute} is invalid."},
+	{Code: ScopedTokenNotAllowedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "not allowed", LongMessage: "The JWT template doesn't allow {value} in {param}."},
+	{Code: ScopedTokensNotEnabledCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "scoped tokens not enabled", LongMessage: "The JWT template {templateName} doesn't allow any scopes. Add allowed scopes to the template to mint scoped tokens from it."},
+	{Code: ServiceAccountInvalidClientCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "invalid client", LongMessage: "The client credentials are missing or invalid."},
+	{Code: ServiceAccountJWTTemplateMissingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "JWT template missing", LongMessage: "The JWT template of this service account no longer exists. Assign a different template to the service account."},
+	{Code: ServiceAccountUnsupportedGrantCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "unsupported grant type", LongMessage: "The grant type {grantType} is not supported for service accounts."},
+	{Code: SessionCreationNotAllowedCode, HTTPStatus: http.StatusConflict, ShortMessage: "unable to create session", LongMessage: "Unable to create new session when an impersonation session is present. Please sign out first."},
+	{Code: SessionExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Session already exists", LongMessage: "You're currently in single session mode. You can only be signed into one account at a time."},
+	{Code: MissingSessionLifetimeSettingCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Missing session lifetime settings", LongMessage: "You must enable at least one of the session lifetime settings"},
+	{Code: SessionTokenTemplateNotDeletableCode, HTTPStatus: http.StatusForbidden, ShortMessage: "session token template cannot be deleted", LongMessage: "This template cannot be deleted because it's a session token template"},
+	{Code: SignInBlockedByRiskCode, HTTPStatus: http.StatusForbidden, ShortMessage: "sign in blocked", LongMessage: "This sign in attempt was blocked because it looks suspicious. Please contact the application administrator."},
+	{Code: SignInEmailLinkNotSameClientCode, HTTPStatus: http.StatusForbidden, ShortMessage: "email link sign in cannot be completed", LongMessage: "Email link sign in cannot be completed because it originates from a different client"},
+	{Code: SignInIdentificationOrUserDeletedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "identification or user deleted", LongMessage: "Either the user or the selected identification were deleted. Please start over."},
+	{Code: SignInNoIdentificationForUserCode, HTTPStatus: http.StatusNotFound, ShortMessage: "no identification for user", LongMessage: "The given token doesn't have an associated identification for the user who created it."},
+	{Code: SignInTokenAlreadyUsedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "sign in token has already been used", LongMessage: "This sign in token has already been used as many times as it allows."},
+	{Code: SignInTokenCannotBeRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot revoke", LongMessage: "Sign in token cannot be revoked because its status is {status}. Only pending tokens can be revoked."},
+	{Code: SignInTokenCannotBeUsedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "sign in token cannot be used", LongMessage: "This sign in token cannot be used anymore. Please request a new one."},
+	{Code: SignInTokenNotInSignInCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not in sign in", LongMessage: "Sign in tokens can only be used during sign in."},
+	{Code: SignInTokenRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "sign in token has been revoked", LongMessage: "This sign in token has been revoked and cannot be used anymore."},
+	{Code: SignUpBlockedAsBotCode, HTTPStatus: http.StatusForbidden, ShortMessage: "sign up blocked", LongMessage: "This sign up was blocked because it looks automated. Please contact the application administrator."},
+	{Code: SignUpCannotBeUpdatedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "Sign up cannot be updated", LongMessage: "This sign up has reached a terminal state and cannot be updated"},
+	{Code: SignUpEmailLinkNotSameClientCode, HTTPStatus: http.StatusForbidden, ShortMessage: "email link sign up cannot be completed", LongMessage: "Email link sign up cannot be completed because it originates from a different client"},
+	{Code: SignUpOutdatedVerificationCode, HTTPStatus: http.StatusGone, ShortMessage: "Outdated verification", LongMessage: "There is a more recent verification pending for this signup. Try attempting the verification again."},
+	{Code: SignedOutCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "Signed out", LongMessage: "You are signed out"},
+	{Code: SigningKeyAlreadyRevokedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already revoked", LongMessage: "This signing key has already been revoked."},
+	{Code: SigningKeyRevokeLastKeyCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot revoke the only signing key", LongMessage: "This is the only signing key of the instance. Rotate the signing keys before revoking it."},
+	{Code: SMSTemplateMaxLengthExceededCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Message length exceeded", LongMessage: "{longMessage}"},
+	{Code: StrategyForUserInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Invalid verification strategy", LongMessage: "The verification strategy is not valid for this account"},
+	{Code: SvixAppCreateErrorCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Svix app creation failed", LongMessage: "Could not create a Svix app with name {name} at this time. Please contact us if this error persists."},
+	{Code: SvixAppExistsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Only one Svix app is allowed per instance.", LongMessage: ""},
+	{Code: SvixAppMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "No Svix apps are associated with the current instance.", LongMessage: ""},
+	{Code: SvixEndpointNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Webhook endpoint not found", LongMessage: "No webhook endpoint was found with the given id for the current instance."},
+	{Code: SvixEndpointVerificationFailedCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "could not be verified", LongMessage: "The webhook endpoint URL did not respond to the verification challenge: {reason}."},
+	{Code: SyncNonceAlreadyConsumedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "sync nonce already consumed", LongMessage: "The given sync nonce has already been consumed and cannot be re-used."},
+	{Code: TemplateDeletionRestrictedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Template deletion restricted", LongMessage: "Template with slug {slug} can't be deleted"},
+	{Code: TemplateRevertRestrictedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Template revert restricted", LongMessage: "Template with slug {slug} can't be reverted"},
+	{Code: TemplateTypeUnsupportedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Template type not supported", LongMessage: "Template type {templateType} is not supported"},
+	{Code: TemplateVariableUnknownCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "contains unknown {{{variable}}} variable", LongMessage: "The {{{variable}}} variable is not available for this template"},
+	{Code: TicketExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "ticket has expired", LongMessage: "This ticket has expired and cannot be used anymore."},
+	{Code: TicketInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "ticket is invalid", LongMessage: "This ticket is invalid. Make sure you're using a valid ticket generated by Clerk."},
+	{Code: TooManyRequestsCode, HTTPStatus: http.StatusTooManyRequests, ShortMessage: "Too many requests", LongMessage: "Too many requests, retry later"},
+	{Code: TooManyUnverifiedIdentificationsCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "too many unverified contacts", LongMessage: "There are too many unverified contacts for this user."},
+	{Code: TOTPAlreadyEnabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "TOTP already enabled", LongMessage: "TOTP is already enabled on your account"},
+	{Code: TOTPDisabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "TOTP is disabled", LongMessage: "This user does not have TOTP enabled in their account"},
+	{Code: IncorrectTOTPCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "incorrect TOTP", LongMessage: "The provided TOTP code is incorrect"},
+	{Code: InvalidLengthTOTPCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "invalid length", LongMessage: "The provided TOTP code must be 6 characters long."},
+	{Code: TransferPaidAppToAccountWithNoPaymentMethodCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot transfer paid application, missing payment method", LongMessage: "The selected account doesn't have any payment methods associated with it."},
+	{Code: TransferPaidAppToFreeAccountCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "cannot transfer paid application, missing billing info", LongMessage: "Paid applications can only be transferred to personal workspaces or organizations with billing info. Add the necessary billing info and try again."},
+	{Code: TrustedDeviceNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "not found", LongMessage: "No trusted device was found with id {trustedDeviceID}"},
+	{Code: UnsupportedContentTypeCode, HTTPStatus: http.StatusUnsupportedMediaType, ShortMessage: "Content-Type is unsupported", LongMessage: "Content-Type {actual} is unsupported. You should use {expected} instead."},
+	{Code: UnsupportedIntegrationTypeCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "Unsupported integration type", LongMessage: "Unsupported integration type: {integrationType}"},
+	{Code: UnsupportedSubscriptionPlanFeaturesCode, HTTPStatus: http.StatusPaymentRequired, ShortMessage: "Unsupported plan features", LongMessage: "Some features are not supported in your current plan. Upgrade your subscription to unlock them."},
+	{Code: UpdatingUserPasswordDeprecatedCode, HTTPStatus: http.StatusForbidden, ShortMessage: "deprecated feature", LongMessage: "Password is not a valid parameter and can only be updated via /v1/me/change_password"},
+	{Code: URLBasedSessionSyncingDisabledCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "URL-based session syncing is disabled for this instance", LongMessage: "This is a development instance operating with legacy, third-party cookies. To enable URL-based session syncing refer to https://clerk.com/docs/upgrade-guides/url-based-session-syncing."},
+	{Code: DPoPNonceRequiredCode, HTTPStatus: http.StatusUnauthorized, ShortMessage: "DPoP nonce required", LongMessage: "The DPoP proof must include the nonce returned in the DPoP-Nonce response header."},
+	{Code: UserBulkImportNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "User bulk import not found", LongMessage: "No user bulk import was found with id {id}"},
+	{Code: UserBulkImportTooLargeCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "Too many users", LongMessage: "A bulk import can contain up to {maxUsers} users."},
+	{Code: UserCreateOrganizationNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "create organization not enabled", LongMessage: "Organization creation is not enabled for this user"},
+	{Code: UserDeleteSelfNotEnabledCode, HTTPStatus: http.StatusForbidden, ShortMessage: "delete self not enabled", LongMessage: "Self deletion is not enabled for this user"},
+	{Code: UserExportNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "User export not found", LongMessage: "No user export was found with id {id}"},
+	{Code: UserQuotaExceededCode, HTTPStatus: http.StatusForbidden, ShortMessage: "user quota exceeded", LongMessage: "{longMessage}"},
+	{Code: InvalidUserSettingsCode, HTTPStatus: http.StatusConflict, ShortMessage: "invalid auth configuration", LongMessage: "The authentication settings are invalid."},
+	{Code: VerificationAlreadyVerifiedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "already verified", LongMessage: "This verification has already been verified."},
+	{Code: VerificationCooldownCode, HTTPStatus: http.StatusTooManyRequests, ShortMessage: "too many failed attempts", LongMessage: "Too many failed verification attempts. Please wait before trying again."},
+	{Code: VerificationExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "expired", LongMessage: "This verification has expired. You must create a new one."},
+	{Code: VerificationFailedCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "failed", LongMessage: "Too many failed attempts. You have to try again with the same or another method."},
+	{Code: VerificationLinkTokenExpiredCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "expired link token", LongMessage: "Verification link token has expired"},
+	{Code: VerificationInvalidLinkTokenCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid link token", LongMessage: "Verification link token is invalid"},
+	{Code: VerificationInvalidLinkTokenSourceCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "invalid link token source", LongMessage: "Verification link token source is invalid"},
+	{Code: VerificationMissingCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "is missing", LongMessage: "This strategy requires verification preparation before attempting to validate it."},
+	{Code: VerificationNotSentCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "not sent", LongMessage: "You need to send a verification code before attempting to verify."},
+	{Code: VerificationStatusUnknownCode, HTTPStatus: http.StatusInternalServerError, ShortMessage: "Unknown verification status", LongMessage: "Found unknown verification status {status}"},
+	{Code: VerificationStrategyInvalidCode, HTTPStatus: http.StatusBadRequest, ShortMessage: "has invalid strategy", LongMessage: "The strategy is not valid for the current verification."},
+	{Code: WebhookEventReplayNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Webhook event replay not found", LongMessage: "No webhook event replay was found with the given id for the current instance."},
+	{Code: WebhookFailedDeliveryNotFoundCode, HTTPStatus: http.StatusNotFound, ShortMessage: "Failed webhook delivery not found", LongMessage: "No failed webhook delivery was found with the given id for the current instance."},
+	{Code: WebhookReplayRangeInvalidCode, HTTPStatus: http.StatusUnprocessableEntity, ShortMessage: "is invalid", LongMessage: "The events to replay can't be selected: {reason}."},
+}
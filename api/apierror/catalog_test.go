@@ -0,0 +1,32 @@
+package apierror
+
+import (
+	"net/http"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog(t *testing.T) {
+	t.Parallel()
+
+	entries := Catalog()
+	assert.NotEmpty(t, entries)
+	assert.True(t, sort.SliceIsSorted(entries, func(i, j int) bool {
+		return entries[i].Code < entries[j].Code
+	}))
+
+	for _, entry := range entries {
+		assert.NotEmpty(t, entry.Code)
+		assert.NotEmpty(t, http.StatusText(entry.HTTPStatus), entry.Code)
+		assert.Equal(t, "https://clerk.com/docs/errors/"+entry.Code, entry.DocURL())
+	}
+
+	assert.Contains(t, entries, CatalogEntry{
+		Code:         ResourceNotFoundCode,
+		HTTPStatus:   http.StatusNotFound,
+		ShortMessage: "not found",
+		LongMessage:  "No user was found with id {userID}",
+	})
+}
@@ -0,0 +1,48 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DeviceAttestationRequired signifies that the instance requires a device
+// attestation for the requested operation, but none was provided.
+func DeviceAttestationRequired() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Device attestation required",
+		longMessage:  "This application requires a valid device attestation. Request a new challenge from /v1/client/attestation/challenge and attest the device with App Attest or Play Integrity before retrying.",
+		code:         DeviceAttestationRequiredCode,
+	})
+}
+
+// DeviceAttestationFailed signifies that the provided device attestation
+// could not be verified.
+func DeviceAttestationFailed(platform, reason string) Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Device attestation failed",
+		longMessage:  fmt.Sprintf("The %s device attestation could not be verified: %s.", platform, reason),
+		code:         DeviceAttestationFailedCode,
+		meta:         &deviceAttestationMeta{Platform: platform, Reason: reason},
+	})
+}
+
+// DeviceAttestationChallengeInvalid signifies that the challenge used for
+// the attestation is unknown, expired or has already been used.
+func DeviceAttestationChallengeInvalid() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Device attestation challenge is invalid",
+		longMessage:  "The device attestation challenge is invalid, expired or has already been used. Request a new challenge and try again.",
+		code:         DeviceAttestationChallengeInvalidCode,
+	})
+}
+
+// DeviceAttestationNotConfigured signifies that an attestation was provided
+// for a platform that hasn't been configured for the instance.
+func DeviceAttestationNotConfigured(platform string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Device attestation not configured",
+		longMessage:  fmt.Sprintf("Device attestation for %s is not configured for this instance. Set up the native application settings in the Clerk Dashboard.", platform),
+		code:         DeviceAttestationNotConfiguredCode,
+		meta:         &deviceAttestationMeta{Platform: platform},
+	})
+}
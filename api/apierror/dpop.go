@@ -0,0 +1,26 @@
+package apierror
+
+import (
+	"net/http"
+)
+
+// InvalidDPoPProof signifies an error when a request authenticated with a secret key
+// which is bound to a key pair doesn't carry a valid DPoP proof.
+func InvalidDPoPProof(cause error) Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Invalid DPoP proof",
+		longMessage:  "The secret key is bound to a key pair. Requests must include a DPoP header with a proof signed by its private key.",
+		code:         DPoPProofInvalidCode,
+		cause:        cause,
+	})
+}
+
+// DPoPNonceRequired signifies an error when a DPoP proof doesn't include the nonce
+// the server issued. The nonce to use is returned in the DPoP-Nonce header.
+func DPoPNonceRequired() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "DPoP nonce required",
+		longMessage:  "The DPoP proof must include the nonce returned in the DPoP-Nonce response header.",
+		code:         DPoPNonceRequiredCode,
+	})
+}
@@ -0,0 +1,21 @@
+package apierror
+
+import (
+	"net/http"
+)
+
+func DuplicateFeatureFlag(key string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "duplicate feature flag",
+		longMessage:  "A feature flag with key " + key + " already exists",
+		code:         DuplicateRecordCode,
+	})
+}
+
+func FeatureFlagNotFound(key string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "feature flag not found",
+		longMessage:  "No feature flag was found with key " + key,
+		code:         ResourceNotFoundCode,
+	})
+}
@@ -523,6 +523,15 @@ func FormInvalidPhoneNumber(param string) Error {
 	})
 }
 
+func FormPhoneNumberCountryNotAllowed(param string, allowedCountries []string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "country not allowed",
+		longMessage:  fmt.Sprintf("%s must belong to one of the following countries: %s.", param, strings.Join(allowedCountries, ", ")),
+		code:         FormPhoneNumberCountryNotAllowedCode,
+		meta:         &formAllowedCountries{formParameter: formParameter{Name: param}, AllowedCountries: allowedCountries},
+	})
+}
+
 func FormInvalidIdentifier(param string) Error {
 	return New(http.StatusUnprocessableEntity, &mainError{
 		shortMessage: "is invalid",
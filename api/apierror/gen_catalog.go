@@ -0,0 +1,306 @@
+//go:build ignore
+
+// gen_catalog generates the catalog of errors, by going through the
+// constructors of the package which return a single error with a static HTTP
+// status, e.g.
+//
+//	func UserNotFound(userID string) Error {
+//		return New(http.StatusNotFound, &mainError{
+//			shortMessage: "not found",
+//			longMessage:  "No user was found with id " + userID,
+//			code:         ResourceNotFoundCode,
+//		})
+//	}
+//
+// The dynamic parts of the messages are replaced by the names of the values
+// they're derived from in braces, e.g. "No user was found with id {userID}".
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const output = "catalog_gen.go"
+
+var verbRegexp = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+type entry struct {
+	code         string
+	codeExpr     string
+	status       string
+	shortMessage string
+	longMessage  string
+}
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info fs.FileInfo) bool {
+		name := info.Name()
+		return !strings.HasSuffix(name, "_test.go") && name != output && name != "gen_catalog.go"
+	}, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["apierror"]
+	if !ok {
+		log.Fatal("gen_catalog: package apierror not found")
+	}
+
+	codes := map[string]string{}
+	for _, file := range pkg.Files {
+		collectCodes(file, codes)
+	}
+
+	seen := map[entry]bool{}
+	var entries []entry
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !fn.Name.IsExported() || fn.Body == nil {
+				continue
+			}
+			ast.Inspect(fn.Body, func(node ast.Node) bool {
+				e, ok := entryFromCall(fset, node, codes)
+				if ok && !seen[e] {
+					seen[e] = true
+					entries = append(entries, e)
+				}
+				return true
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.code != b.code {
+			return a.code < b.code
+		}
+		if a.status != b.status {
+			return a.status < b.status
+		}
+		if a.shortMessage != b.shortMessage {
+			return a.shortMessage < b.shortMessage
+		}
+		return a.longMessage < b.longMessage
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_catalog.go; DO NOT EDIT.\n\n")
+	buf.WriteString("package apierror\n\n")
+	buf.WriteString("import \"net/http\"\n\n")
+	buf.WriteString("var catalog = []CatalogEntry{\n")
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "\t{Code: %s, HTTPStatus: %s, ShortMessage: %s, LongMessage: %s},\n",
+			e.codeExpr, e.status, strconv.Quote(e.shortMessage), strconv.Quote(e.longMessage))
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// collectCodes collects the values of the string constants of the file.
+func collectCodes(file *ast.File, codes map[string]string) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for i, name := range value.Names {
+				if i >= len(value.Values) {
+					continue
+				}
+				lit, ok := value.Values[i].(*ast.BasicLit)
+				if !ok || lit.Kind != token.STRING {
+					continue
+				}
+				if code, err := strconv.Unquote(lit.Value); err == nil {
+					codes[name.Name] = code
+				}
+			}
+		}
+	}
+}
+
+// entryFromCall returns the entry for calls like New(http.StatusX, &mainError{...}).
+func entryFromCall(fset *token.FileSet, node ast.Node, codes map[string]string) (entry, bool) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok || len(call.Args) != 2 {
+		return entry{}, false
+	}
+	if fun, ok := call.Fun.(*ast.Ident); !ok || fun.Name != "New" {
+		return entry{}, false
+	}
+
+	status, ok := call.Args[0].(*ast.SelectorExpr)
+	if !ok {
+		// the status is only known at runtime
+		return entry{}, false
+	}
+	if pkg, ok := status.X.(*ast.Ident); !ok || pkg.Name != "http" {
+		return entry{}, false
+	}
+
+	unary, ok := call.Args[1].(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return entry{}, false
+	}
+	lit, ok := unary.X.(*ast.CompositeLit)
+	if !ok {
+		return entry{}, false
+	}
+	if typ, ok := lit.Type.(*ast.Ident); !ok || typ.Name != "mainError" {
+		return entry{}, false
+	}
+
+	e := entry{status: "http." + status.Sel.Name}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		switch key.Name {
+		case "shortMessage":
+			e.shortMessage = template(fset, kv.Value)
+		case "longMessage":
+			e.longMessage = template(fset, kv.Value)
+		case "code":
+			switch value := kv.Value.(type) {
+			case *ast.Ident:
+				e.code, ok = codes[value.Name]
+				if !ok {
+					return entry{}, false
+				}
+				e.codeExpr = value.Name
+			case *ast.BasicLit:
+				code, err := strconv.Unquote(value.Value)
+				if err != nil {
+					return entry{}, false
+				}
+				e.code = code
+				e.codeExpr = value.Value
+			}
+		}
+	}
+	if e.code == "" {
+		log.Printf("gen_catalog: skipping error without a code at %s", fset.Position(call.Pos()))
+		return entry{}, false
+	}
+	return e, true
+}
+
+// template returns the message that the given expression produces, with the
+// dynamic parts in braces.
+func template(fset *token.FileSet, expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.STRING {
+			if value, err := strconv.Unquote(e.Value); err == nil {
+				return value
+			}
+		}
+	case *ast.ParenExpr:
+		return template(fset, e.X)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return template(fset, e.X) + template(fset, e.Y)
+		}
+	case *ast.CallExpr:
+		if isSprintf(e) && len(e.Args) > 0 {
+			if format, ok := e.Args[0].(*ast.BasicLit); ok && format.Kind == token.STRING {
+				return formatTemplate(fset, format, e.Args[1:])
+			}
+		}
+	}
+	return "{" + placeholder(fset, expr) + "}"
+}
+
+// placeholder returns the name of the value the given expression is derived
+// from, e.g. names for strings.Join(names, ", ").
+func placeholder(fset *token.FileSet, expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return source(fset, e)
+	case *ast.CallExpr:
+		if len(e.Args) > 0 {
+			return placeholder(fset, e.Args[0])
+		}
+		if fun, ok := e.Fun.(*ast.SelectorExpr); ok {
+			return placeholder(fset, fun.X)
+		}
+	case *ast.IndexExpr:
+		return placeholder(fset, e.X)
+	case *ast.StarExpr:
+		return placeholder(fset, e.X)
+	case *ast.UnaryExpr:
+		return placeholder(fset, e.X)
+	case *ast.ParenExpr:
+		return placeholder(fset, e.X)
+	}
+	return "value"
+}
+
+func isSprintf(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Sprintf" {
+		return false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Name == "fmt"
+}
+
+func formatTemplate(fset *token.FileSet, format *ast.BasicLit, args []ast.Expr) string {
+	value, err := strconv.Unquote(format.Value)
+	if err != nil {
+		return "{value}"
+	}
+	i := 0
+	return verbRegexp.ReplaceAllStringFunc(value, func(verb string) string {
+		if verb == "%%" {
+			return "%"
+		}
+		if i >= len(args) {
+			return verb
+		}
+		arg := args[i]
+		i++
+		return template(fset, arg)
+	})
+}
+
+func source(fset *token.FileSet, expr ast.Expr) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return "?"
+	}
+	return buf.String()
+}
@@ -0,0 +1,36 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// IdempotencyKeyInvalid signifies that the Idempotency-Key header of the
+// request is longer than the given size.
+func IdempotencyKeyInvalid(maxLength int) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Idempotency key invalid",
+		longMessage:  fmt.Sprintf("The Idempotency-Key header must be at most %d characters long.", maxLength),
+		code:         IdempotencyKeyInvalidCode,
+	})
+}
+
+// IdempotencyKeyReused signifies that the idempotency key was already used
+// for a request with different parameters.
+func IdempotencyKeyReused() Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "Idempotency key reused",
+		longMessage:  "This idempotency key was already used for a request with different parameters. Use a new key for every distinct request.",
+		code:         IdempotencyKeyReusedCode,
+	})
+}
+
+// IdempotencyRequestInProgress signifies that a request with the same
+// idempotency key is still being processed.
+func IdempotencyRequestInProgress() Error {
+	return New(http.StatusConflict, &mainError{
+		shortMessage: "Request in progress",
+		longMessage:  "A request with the same idempotency key is still being processed. Retry once it completes.",
+		code:         IdempotencyRequestInProgressCode,
+	})
+}
@@ -87,3 +87,33 @@ func CannotDeleteActiveProductionInstance() Error {
 		code: ActiveProductionInstanceDeletionNotAllowedCode,
 	})
 }
+
+// SigningKeyNotFound signifies an error when no signing key with the given
+// ID was found for the instance
+func SigningKeyNotFound(signingKeyID string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "not found",
+		longMessage:  "No signing key was found with id " + signingKeyID,
+		code:         ResourceNotFoundCode,
+	})
+}
+
+// SigningKeyAlreadyRevoked signifies an error when revoking a signing key
+// which has already been revoked
+func SigningKeyAlreadyRevoked() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "already revoked",
+		longMessage:  "This signing key has already been revoked.",
+		code:         SigningKeyAlreadyRevokedCode,
+	})
+}
+
+// SigningKeyRevokeLastKey signifies an error when revoking the only signing
+// key of an instance, which would leave it unable to sign tokens
+func SigningKeyRevokeLastKey() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "cannot revoke the only signing key",
+		longMessage:  "This is the only signing key of the instance. Rotate the signing keys before revoking it.",
+		code:         SigningKeyRevokeLastKeyCode,
+	})
+}
@@ -1,6 +1,7 @@
 package apierror
 
 import (
+	"fmt"
 	"net/http"
 
 	"clerk/pkg/clerkerrors"
@@ -24,6 +25,21 @@ func TooManyRequests() Error {
 	})
 }
 
+type rateLimitMeta struct {
+	RetryAfter int `json:"retry_after"`
+}
+
+// RateLimitExceeded signifies that the client sent too many requests and
+// should retry after the given number of seconds.
+func RateLimitExceeded(retryAfter int) Error {
+	return New(http.StatusTooManyRequests, &mainError{
+		shortMessage: "Rate limit exceeded",
+		longMessage:  fmt.Sprintf("Too many requests, retry after %d seconds.", retryAfter),
+		code:         RateLimitExceededCode,
+		meta:         &rateLimitMeta{RetryAfter: retryAfter},
+	})
+}
+
 // 403 - quota exceeded
 func QuotaExceeded() Error {
 	return New(http.StatusForbidden, &mainError{
@@ -0,0 +1,32 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+func DuplicateIPRestrictionRule(ruleType, value string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "duplicate ip restriction rule",
+		longMessage:  fmt.Sprintf("a %s rule for %s already exists", ruleType, value),
+		code:         DuplicateRecordCode,
+	})
+}
+
+func IPRestrictionRuleNotFound(ruleID string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "IP restriction rule not found",
+		longMessage:  "No IP restriction rule was found with id " + ruleID,
+		code:         ResourceNotFoundCode,
+	})
+}
+
+// 403 - The request comes from an IP address, network or country which the
+// instance has blocked.
+func NetworkNotAllowedAccess() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "Access not allowed.",
+		longMessage:  "Access to this application is not allowed from your network or location.",
+		code:         NetworkNotAllowedAccessCode,
+	})
+}
@@ -31,3 +31,24 @@ func SessionTokenTemplateNotDeletable() Error {
 		code:         SessionTokenTemplateNotDeletableCode,
 	})
 }
+
+// ScopedTokensNotEnabled denotes an error when a scoped token is requested from
+// a template which doesn't allow any scopes.
+func ScopedTokensNotEnabled(templateName string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "scoped tokens not enabled",
+		longMessage:  fmt.Sprintf("The JWT template %s doesn't allow any scopes. Add allowed scopes to the template to mint scoped tokens from it.", templateName),
+		code:         ScopedTokensNotEnabledCode,
+	})
+}
+
+// ScopedTokenNotAllowed denotes an error when a scoped token asks for a scope
+// or a claim which its template doesn't allow.
+func ScopedTokenNotAllowed(param, value string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "not allowed",
+		longMessage:  fmt.Sprintf("The JWT template doesn't allow %s in %s.", value, param),
+		code:         ScopedTokenNotAllowedCode,
+		meta:         &formParameter{Name: param},
+	})
+}
@@ -23,6 +23,7 @@ const (
 	FormParamValueDisabled                         = "form_param_value_disabled"
 	FormPasswordValidationFailedCode               = "form_password_validation_failed"
 	FormEmailAddressBlockedCode                    = "form_email_address_blocked"
+	FormPhoneNumberCountryNotAllowedCode           = "form_phone_number_country_not_allowed"
 	FormParamTypeInvalidCode                       = "form_param_type_invalid"
 	FormParamMissingCode                           = "form_param_missing"
 	FormPasswordIncorrectCode                      = "form_password_incorrect"
@@ -65,6 +66,8 @@ const (
 	SessionExistsCode                              = "session_exists"
 	UnauthorizedActionForSessionCode               = "action_for_session_not_authorized"
 	InvalidSessionTokenCode                        = "invalid_session_token"
+	RefreshTokenInvalidCode                        = "refresh_token_invalid"
+	RefreshTokenReusedCode                         = "refresh_token_reused"
 	IdentifierAlreadySignedInCode                  = "identifier_already_signed_in"
 	AccountTransferInvalidCode                     = "account_transfer_invalid"
 	ClientStateInvalid                             = "client_state_invalid"
@@ -81,7 +84,10 @@ const (
 	VerificationInvalidLinkTokenCode               = "verification_link_token_invalid"
 	VerificationInvalidLinkTokenSourceCode         = "verification_link_token_source_invalid"
 	VerificationLinkTokenExpiredCode               = "verification_link_token_expired"
+	VerificationCooldownCode                       = "verification_cooldown"
 	ProductionInstanceExistsCode                   = "production_instance_exists"
+	SigningKeyAlreadyRevokedCode                   = "signing_key_already_revoked"
+	SigningKeyRevokeLastKeyCode                    = "signing_key_revoke_last_key"
 	InstanceTypeInvalidCode                        = "instance_type_invalid"
 	InstanceNotLiveCode                            = "not_live"
 	IntegrationOauthFailureCode                    = "integration_oauth_failure"
@@ -102,6 +108,13 @@ const (
 	SvixAppCreateErrorCode                         = "svix_app_create_error"
 	SvixAppExistsCode                              = "svix_app_exists"
 	SvixAppMissingCode                             = "svix_app_missing"
+	SvixEndpointNotFoundCode                       = "svix_endpoint_not_found"
+	SvixEndpointVerificationFailedCode             = "svix_endpoint_verification_failed"
+	NativeWebhooksEnabledCode                      = "native_webhooks_enabled"
+	NativeWebhooksNotEnabledCode                   = "native_webhooks_not_enabled"
+	WebhookFailedDeliveryNotFoundCode              = "webhook_failed_delivery_not_found"
+	WebhookEventReplayNotFoundCode                 = "webhook_event_replay_not_found"
+	WebhookReplayRangeInvalidCode                  = "webhook_replay_range_invalid"
 	SignedOutCode                                  = "signed_out"
 	UnsupportedIntegrationTypeCode                 = "unsupported_integration_type"
 	AuthorizationHeaderFormatInvalidCode           = "authorization_header_format_invalid"
@@ -152,8 +165,10 @@ const (
 	DuplicateRecordCode            = "duplicate_record"
 	IdentifierNotAllowedAccessCode = "not_allowed_access"
 	BlockedCountryCode             = "blocked_country_code"
+	NetworkNotAllowedAccessCode    = "network_not_allowed_access"
 
-	MaintenanceModeCode = "maintenance_mode"
+	MaintenanceModeCode         = "maintenance_mode"
+	InstanceMaintenanceModeCode = "instance_maintenance_mode"
 
 	// Backoffice
 	CannotSetUnlimitedSeatsForUserApplicationCode = "cannot_set_unlimited_seats_for_user"
@@ -178,9 +193,11 @@ const (
 	CustomTemplateRequiredCode       = "custom_template_required"
 	CustomTemplatesNotAvailableCode  = "custom_templates_not_available"
 	RequiredVariableMissingCode      = "required_variable_missing"
+	TemplateVariableUnknownCode      = "template_variable_unknown"
 	InvalidTemplateBodyCode          = "invalid_template_body"
 	SMSTemplateMaxLengthExceededCode = "sms_max_length_exceeded"
 	DevMonthlySMSLimitExceededCode   = "dev_monthly_sms_limit_exceeded"
+	PhoneNumberRejectedCode          = "phone_number_rejected"
 
 	InvitationsNotSupportedInInstanceCode = "invitations_not_supported"
 	InvitationAccountAlreadyExistsCode    = "invitation_account_exists"
@@ -196,6 +213,7 @@ const (
 
 	LastInstanceKeyCode                         = "last_instance_key"
 	TooManyRequestsCode                         = "too_many_requests"
+	RateLimitExceededCode                       = "rate_limit_exceeded"
 	QuotaExceededCode                           = "quota_exceeded"
 	BadRequestCode                              = "bad_request"
 	ConflictCode                                = "conflict"
@@ -223,10 +241,13 @@ const (
 	OrganizationInvitationNotFoundCode                    = "organization_invitation_not_found"
 	OrganizationCreatorNotFoundCode                       = "organization_creator_not_found"
 	OrganizationInvitationRevokedCode                     = "organization_invitation_revoked_code"
+	OrganizationInvitationExpiredCode                     = "organization_invitation_expired"
 	OrganizationInvitationAlreadyAcceptedCode             = "organization_invitation_already_accepted"
 	OrganizationInvitationIdentificationNotExistCode      = "organization_invitation_identification_not_exist"
 	OrganizationInvitationIdentificationAlreadyExistsCode = "organization_invitation_identification_already_exists"
 	OrganizationInvitationNotUniqueCode                   = "organization_invitation_not_unique"
+	OrganizationInvitationEmailNotVerifiedCode            = "organization_invitation_email_not_verified"
+	OrganizationMembershipRequiredCode                    = "organization_membership_required"
 	OrganizationSuggestionAlreadyAcceptedCode             = "organization_suggestion_already_accepted"
 	OrganizationNotEnabledInInstanceCode                  = "organization_not_enabled_in_instance"
 	OrganizationInvitationToDeletedOrganizationCode       = "organization_invitation_to_deleted_organization"
@@ -249,6 +270,10 @@ const (
 	OrganizationRolePermissionAssociationNotFoundCode     = "organization_role_permission_association_not_found"
 	OrganizationInstanceRolesQuotaExceededCode            = "organization_instance_roles_quota_exceeded"
 	OrganizationInstancePermissionsQuotaExceededCode      = "organization_instance_permissions_quota_exceeded"
+	OrganizationMembershipExportNotFoundCode              = "organization_membership_export_not_found"
+	OrganizationParentNotFoundCode                        = "organization_parent_not_found"
+	OrganizationHierarchyCycleCode                        = "organization_hierarchy_cycle"
+	OrganizationHierarchyTooDeepCode                      = "organization_hierarchy_too_deep"
 
 	FeatureNotEnabledCode     = "feature_not_enabled"
 	FeatureNotImplementedCode = "feature_not_implemented"
@@ -257,6 +282,7 @@ const (
 	SignInNoIdentificationForUserCode     = "sign_in_no_identification_for_user"
 	SignInIdentificationOrUserDeletedCode = "sign_in_identification_or_user_deleted"
 	SignInEmailLinkNotSameClientCode      = "sign_in_email_link_not_same_client"
+	SignInBlockedByRiskCode               = "sign_in_blocked_by_risk"
 
 	SignInTokenRevokedCode         = "sign_in_token_revoked_code"
 	SignInTokenAlreadyUsedCode     = "sign_in_token_already_used_code"
@@ -275,6 +301,7 @@ const (
 	TicketInvalidCode = "ticket_invalid_code"
 
 	GatewayTimeoutCode = "gateway_timeout"
+	RequestTimeoutCode = "request_timeout"
 
 	ReservedDomainCode     = "reserved_domain"
 	KnownHostingDomainCode = "known_hosting_domain"
@@ -290,6 +317,8 @@ const (
 	NoPasswordSetCode     = "no_password_set"
 	IncorrectPasswordCode = "incorrect_password"
 
+	NoPrimaryEmailAddressCode = "no_primary_email_address"
+
 	// verify TOTP (BAPI)
 	TOTPDisabledCode      = "totp_disabled"
 	IncorrectTOTPCode     = "totp_incorrect_code"
@@ -304,6 +333,8 @@ const (
 	CaptchaInvalidCode          = "captcha_invalid"
 	CaptchaNotEnabledCode       = "captcha_not_enabled"
 	CaptchaNotSupportedByClient = "captcha_not_supported_by_client"
+	CaptchaRequiredCode         = "captcha_required"
+	SignUpBlockedAsBotCode      = "sign_up_blocked_as_bot"
 
 	// user and org actions settings
 	UserDeleteSelfNotEnabledCode         = "user_delete_self_not_enabled"
@@ -313,12 +344,17 @@ const (
 
 	UserLockedCode = "user_locked"
 
+	// primary email address change confirmation
+	PrimaryEmailAddressChangeInvalidCode = "primary_email_address_change_invalid"
+	PrimaryEmailAddressChangeExpiredCode = "primary_email_address_change_expired"
+
 	// entitlements
 	FormInvalidEntitlementKeyCode    = "form_param_invalid_entitlement_key"
 	EntitlementAlreadyAssociatedCode = "entitlement_already_associated"
 
 	// handshake
-	InvalidHandshakeCode = "invalid_handshake"
+	InvalidHandshakeCode         = "invalid_handshake"
+	HandshakeBindingMismatchCode = "handshake_binding_mismatch"
 
 	CannotDetectIPCode = "cannot_detect_ip"
 
@@ -337,6 +373,8 @@ const (
 const (
 	JWTTemplateReservedClaimCode         = "jwt_template_reserved_claim"
 	SessionTokenTemplateNotDeletableCode = "session_token_jwt_template"
+	ScopedTokensNotEnabledCode           = "scoped_tokens_not_enabled"
+	ScopedTokenNotAllowedCode            = "scoped_token_not_allowed"
 )
 
 // OAuth related
@@ -389,12 +427,16 @@ const (
 	SAMLUserAttributeMissingCode       = "saml_user_attribute_missing"
 	SAMLEmailAddressDomainMismatchCode = "saml_email_address_domain_mismatch"
 	SAMLConnectionActiveNotFoundCode   = "saml_connection_active_not_found"
+	SAMLLogoutNotSupportedCode         = "saml_logout_not_supported"
+	SAMLLogoutRequestInvalidCode       = "saml_logout_request_invalid"
+	SAMLLogoutResponseInvalidCode      = "saml_logout_response_invalid"
 
 	// BAPI
-	SAMLConnectionCantBeActivatedCode  = "saml_connection_cant_be_activated"
-	SAMLFailedToFetchIDPMetadataCode   = "saml_failed_to_fetch_idp_metadata"
-	SAMLFailedToParseIDPMetadataCode   = "saml_failed_to_parse_idp_metadata"
-	SAMLEmailAddressDomainReservedCode = "saml_email_address_domain_reserved"
+	SAMLConnectionCantBeActivatedCode   = "saml_connection_cant_be_activated"
+	SAMLFailedToFetchIDPMetadataCode    = "saml_failed_to_fetch_idp_metadata"
+	SAMLFailedToParseIDPMetadataCode    = "saml_failed_to_parse_idp_metadata"
+	SAMLEmailAddressDomainReservedCode  = "saml_email_address_domain_reserved"
+	SAMLConnectionDomainNotVerifiedCode = "saml_connection_domain_not_verified"
 )
 
 // Endpoint Deprecations
@@ -412,3 +454,86 @@ const (
 const (
 	GoogleOneTapTokenInvalidCode = "google_one_tap_token_invalid"
 )
+
+// Sign in with Apple
+// nolint:gosec
+const (
+	AppleIDTokenInvalidCode = "apple_id_token_invalid"
+)
+
+// Device attestation
+const (
+	DeviceAttestationRequiredCode         = "device_attestation_required"
+	DeviceAttestationFailedCode           = "device_attestation_failed"
+	DeviceAttestationChallengeInvalidCode = "device_attestation_challenge_invalid"
+	DeviceAttestationNotConfiguredCode    = "device_attestation_not_configured"
+)
+
+// Account links
+const (
+	AccountLinkNotFoundCode         = "account_link_not_found"
+	AccountLinkExpiredCode          = "account_link_expired"
+	AccountLinkNotVerifiedCode      = "account_link_not_verified"
+	AccountLinkNotAllowedCode       = "account_link_not_allowed"
+	AccountLinkAlreadyCompletedCode = "account_link_already_completed"
+)
+
+// Service accounts
+//
+// nolint:gosec
+const (
+	ServiceAccountInvalidClientCode      = "service_account_invalid_client"
+	ServiceAccountUnsupportedGrantCode   = "service_account_unsupported_grant_type"
+	ServiceAccountJWTTemplateMissingCode = "service_account_jwt_template_missing"
+)
+
+// Organization API keys
+const (
+	OrganizationAPIKeyNotFoundCode   = "organization_api_key_not_found"
+	OrganizationAPIKeyOutOfScopeCode = "organization_api_key_out_of_scope"
+)
+
+// User bulk imports
+const (
+	UserBulkImportNotFoundCode = "user_bulk_import_not_found"
+	UserBulkImportTooLargeCode = "user_bulk_import_too_large"
+)
+
+// SCIM
+const (
+	SCIMInvalidFilterCode        = "scim_invalid_filter"
+	SCIMInvalidPathCode          = "scim_invalid_path"
+	SCIMInvalidValueCode         = "scim_invalid_value"
+	SCIMInvalidSyntaxCode        = "scim_invalid_syntax"
+	SCIMGroupMembersRequiredCode = "scim_group_members_required"
+)
+
+// Trusted devices
+const (
+	TrustedDeviceNotFoundCode = "trusted_device_not_found"
+)
+
+// User exports
+const (
+	UserExportNotFoundCode = "user_export_not_found"
+)
+
+// DPoP
+const (
+	DPoPProofInvalidCode  = "dpop_proof_invalid"
+	DPoPNonceRequiredCode = "use_dpop_nonce"
+)
+
+// Idempotency keys
+const (
+	IdempotencyKeyInvalidCode        = "idempotency_key_invalid"
+	IdempotencyKeyReusedCode         = "idempotency_key_reused"
+	IdempotencyRequestInProgressCode = "idempotency_request_in_progress"
+)
+
+// Backfills
+const (
+	BackfillNotFoundCode      = "backfill_not_found"
+	BackfillAlreadyActiveCode = "backfill_already_active"
+	BackfillInvalidStatusCode = "backfill_invalid_status"
+)
@@ -11,3 +11,17 @@ func SystemUnderMaintenance() Error {
 		code:         MaintenanceModeCode,
 	})
 }
+
+// InstanceUnderMaintenance is returned while the maintenance mode of the
+// instance is on. The banner of the instance is used as the long message, if
+// there's one.
+func InstanceUnderMaintenance(banner string) Error {
+	if banner == "" {
+		banner = "This application is currently undergoing maintenance and only essential operations are permitted. Please try again later."
+	}
+	return New(http.StatusServiceUnavailable, &mainError{
+		shortMessage: "Application under maintenance",
+		longMessage:  banner,
+		code:         InstanceMaintenanceModeCode,
+	})
+}
@@ -26,6 +26,11 @@ type formInvalidEmailAddresses struct {
 	EmailAddresses []string `json:"email_addresses"`
 }
 
+type formAllowedCountries struct {
+	formParameter
+	AllowedCountries []string `json:"allowed_countries"`
+}
+
 type missingPermissions struct {
 	Permissions []string `json:"permissions"`
 }
@@ -63,3 +68,8 @@ type oauthTokenWalletMeta struct {
 type devLimits struct {
 	DevMonthlySMSLimit int `json:"dev_monthly_sms_limit"`
 }
+
+type deviceAttestationMeta struct {
+	Platform string `json:"platform,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
@@ -10,3 +10,13 @@ func GatewayTimeout() Error {
 		code:         GatewayTimeoutCode,
 	})
 }
+
+// RequestTimeout signifies an error when a request takes longer than the
+// server allows.
+func RequestTimeout() Error {
+	return New(http.StatusServiceUnavailable, &mainError{
+		shortMessage: "Request Timeout",
+		longMessage:  "The request took too long to complete. Please try again.",
+		code:         RequestTimeoutCode,
+	})
+}
@@ -0,0 +1,26 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// OrganizationAPIKeyNotFound signifies an error when the organization API
+// key doesn't exist.
+func OrganizationAPIKeyNotFound(apiKeyID string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "not found",
+		longMessage:  fmt.Sprintf("No organization API key was found with id %s", apiKeyID),
+		code:         OrganizationAPIKeyNotFoundCode,
+	})
+}
+
+// OrganizationAPIKeyOutOfScope signifies an error when an organization API
+// key is used to access anything but the organization it was issued for.
+func OrganizationAPIKeyOutOfScope() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "out of scope",
+		longMessage:  "Organization API keys can only access the organization they were issued for.",
+		code:         OrganizationAPIKeyOutOfScopeCode,
+	})
+}
@@ -80,6 +80,14 @@ func OrganizationInvitationRevoked() Error {
 	})
 }
 
+func OrganizationInvitationExpired() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "invitation has expired",
+		longMessage:  "This invitation has expired and cannot be used anymore. Ask for a new invitation instead.",
+		code:         OrganizationInvitationExpiredCode,
+	})
+}
+
 func OrganizationInvitationAlreadyAccepted() Error {
 	return New(http.StatusBadRequest, &mainError{
 		shortMessage: "invitation has already been accepted",
@@ -112,6 +120,24 @@ func OrganizationInvitationNotUnique() Error {
 	})
 }
 
+// 403 - The user accepting the invitation hasn't verified the invited email address.
+func OrganizationInvitationEmailNotVerified(emailAddress string) Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "email address not verified",
+		longMessage:  fmt.Sprintf("You need to verify the email address %s before accepting this invitation.", emailAddress),
+		code:         OrganizationInvitationEmailNotVerifiedCode,
+	})
+}
+
+// 403 - The instance only allows sessions for members of its organizations.
+func OrganizationMembershipRequired() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "organization membership required",
+		longMessage:  "Only members of an organization are allowed to sign in to this application.",
+		code:         OrganizationMembershipRequiredCode,
+	})
+}
+
 func OrganizationSuggestionAlreadyAccepted() Error {
 	return New(http.StatusBadRequest, &mainError{
 		shortMessage: "suggestion has already been accepted",
@@ -350,3 +376,39 @@ func OrganizationInstancePermissionsQuotaExceeded(maxAllowed int) Error {
 		code:         OrganizationInstancePermissionsQuotaExceededCode,
 	})
 }
+
+// 404 - Membership export not found
+func OrganizationMembershipExportNotFound(exportID string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "not found",
+		longMessage:  fmt.Sprintf("No membership export found with id %s.", exportID),
+		code:         OrganizationMembershipExportNotFoundCode,
+	})
+}
+
+func OrganizationParentNotFound(param string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "parent organization not found",
+		longMessage:  "No organization found in this instance with the given parent organization id.",
+		code:         OrganizationParentNotFoundCode,
+		meta:         &formParameter{Name: param},
+	})
+}
+
+func OrganizationHierarchyCycle(param string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "organization hierarchy cycle",
+		longMessage:  "An organization can't be the parent of itself or of any of its ancestors.",
+		code:         OrganizationHierarchyCycleCode,
+		meta:         &formParameter{Name: param},
+	})
+}
+
+func OrganizationHierarchyTooDeep(param string, maxDepth int) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "organization hierarchy too deep",
+		longMessage:  fmt.Sprintf("Organizations can be nested up to %d levels deep.", maxDepth),
+		code:         OrganizationHierarchyTooDeepCode,
+		meta:         &formParameter{Name: param},
+	})
+}
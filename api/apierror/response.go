@@ -21,6 +21,7 @@ type ErrorResponse struct {
 	ShortMessage string      `json:"message"`
 	LongMessage  string      `json:"long_message"`
 	Code         string      `json:"code"`
+	DocURL       string      `json:"doc_url,omitempty"`
 	Meta         interface{} `json:"meta,omitempty"`
 	Cause        []string    `json:"cause,omitempty"`
 }
@@ -33,6 +34,7 @@ func ToResponse(ctx context.Context, e Error) Response {
 			ShortMessage: err.ShortMessage(),
 			LongMessage:  err.LongMessage(),
 			Code:         err.Code(),
+			DocURL:       DocURL(err.Code()),
 			Meta:         err.Meta(),
 		}
 
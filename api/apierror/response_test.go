@@ -43,3 +43,12 @@ func TestToResponse_includesTraceIDIfPresent(t *testing.T) {
 	response = ToResponse(ctx, err)
 	assert.Equal(t, "dummy-trace", response.ClerkTraceID)
 }
+
+func TestToResponse_includesDocURL(t *testing.T) {
+	t.Parallel()
+
+	response := ToResponse(context.Background(), UserNotFound("user_1"))
+	assert.Len(t, response.Errors, 1)
+	assert.Equal(t, ResourceNotFoundCode, response.Errors[0].Code)
+	assert.Equal(t, "https://clerk.com/docs/errors/"+ResourceNotFoundCode, response.Errors[0].DocURL)
+}
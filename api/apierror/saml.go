@@ -105,3 +105,41 @@ func SAMLEmailAddressDomainReserved() Error {
 		code:         SAMLEmailAddressDomainReservedCode,
 	})
 }
+
+// SAMLConnectionDomainNotVerified signifies an error when an organization
+// sets up a SAML connection for a domain which isn't one of its verified
+// domains.
+func SAMLConnectionDomainNotVerified(param, domain string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "domain is not verified",
+		longMessage:  fmt.Sprintf("%s must be a verified domain of the organization.", domain),
+		code:         SAMLConnectionDomainNotVerifiedCode,
+		meta:         &formParameter{Name: param},
+	})
+}
+
+func SAMLLogoutNotSupported(connectionID string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "SAML single logout not supported",
+		longMessage:  fmt.Sprintf("The SAML Connection %s has no IdP single logout URL configured.", connectionID),
+		code:         SAMLLogoutNotSupportedCode,
+	})
+}
+
+func SAMLLogoutRequestInvalid(err error) Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Invalid SAML logout request",
+		longMessage:  "The SAML logout request is invalid.",
+		code:         SAMLLogoutRequestInvalidCode,
+		cause:        clerkerrors.Wrap(err, 1),
+	})
+}
+
+func SAMLLogoutResponseInvalid(err error) Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Invalid SAML logout response",
+		longMessage:  "The SAML logout response is invalid.",
+		code:         SAMLLogoutResponseInvalidCode,
+		cause:        clerkerrors.Wrap(err, 1),
+	})
+}
@@ -0,0 +1,57 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SCIMInvalidFilter signifies an error when the filter of a SCIM request
+// can't be parsed or isn't supported.
+func SCIMInvalidFilter(filter, reason string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Invalid filter",
+		longMessage:  fmt.Sprintf("The filter %q is invalid: %s.", filter, reason),
+		code:         SCIMInvalidFilterCode,
+	})
+}
+
+// SCIMInvalidPath signifies an error when a SCIM PATCH operation targets an
+// attribute which doesn't exist or can't be modified.
+func SCIMInvalidPath(path string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Invalid path",
+		longMessage:  fmt.Sprintf("The attribute path %q is not supported.", path),
+		code:         SCIMInvalidPathCode,
+	})
+}
+
+// SCIMInvalidValue signifies an error when the value given for a SCIM
+// attribute has the wrong type or is missing.
+func SCIMInvalidValue(attribute string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Invalid value",
+		longMessage:  fmt.Sprintf("The value of %q is invalid.", attribute),
+		code:         SCIMInvalidValueCode,
+	})
+}
+
+// SCIMInvalidSyntax signifies an error when the body of a SCIM request
+// can't be parsed.
+func SCIMInvalidSyntax() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Invalid syntax",
+		longMessage:  "The request body is not a valid SCIM message.",
+		code:         SCIMInvalidSyntaxCode,
+	})
+}
+
+// SCIMGroupMembersRequired signifies an error when a SCIM group is created
+// without any members. Groups are backed by organizations, which need a
+// creator.
+func SCIMGroupMembersRequired() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Group members required",
+		longMessage:  "Groups must be created with at least one member, which becomes the creator of the organization.",
+		code:         SCIMGroupMembersRequiredCode,
+	})
+}
@@ -0,0 +1,37 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ServiceAccountInvalidClient signifies an error when the client credentials
+// of a service account are missing or invalid.
+func ServiceAccountInvalidClient() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "invalid client",
+		longMessage:  "The client credentials are missing or invalid.",
+		code:         ServiceAccountInvalidClientCode,
+	})
+}
+
+// ServiceAccountUnsupportedGrant signifies an error when a service account
+// requests a token with a grant type other than client credentials.
+func ServiceAccountUnsupportedGrant(grantType string) Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "unsupported grant type",
+		longMessage:  fmt.Sprintf("The grant type %q is not supported for service accounts.", grantType),
+		code:         ServiceAccountUnsupportedGrantCode,
+		meta:         &formParameter{Name: "grant_type"},
+	})
+}
+
+// ServiceAccountJWTTemplateMissing signifies an error when the JWT template
+// of a service account no longer exists.
+func ServiceAccountJWTTemplateMissing() Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "JWT template missing",
+		longMessage:  "The JWT template of this service account no longer exists. Assign a different template to the service account.",
+		code:         ServiceAccountJWTTemplateMissingCode,
+	})
+}
@@ -42,6 +42,27 @@ func InvalidSessionToken() Error {
 		})
 }
 
+// RefreshTokenInvalid signifies an error when the refresh token doesn't
+// belong to the session.
+func RefreshTokenInvalid() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Invalid refresh token",
+		longMessage:  "The refresh token provided is not valid for this session",
+		code:         RefreshTokenInvalidCode,
+	})
+}
+
+// RefreshTokenReused signifies an error when a refresh token which was
+// already rotated is used again, in which case the sessions of the client
+// are revoked.
+func RefreshTokenReused() Error {
+	return New(http.StatusUnauthorized, &mainError{
+		shortMessage: "Refresh token reused",
+		longMessage:  "The refresh token provided was already used. All sessions of this client have been revoked, please sign in again.",
+		code:         RefreshTokenReusedCode,
+	})
+}
+
 func MissingConfigurableSessionLifetimeOption() Error {
 	return New(http.StatusUnprocessableEntity,
 		&mainError{
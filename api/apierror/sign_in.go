@@ -120,3 +120,13 @@ func SignInEmailLinkNotSameClient() Error {
 		code:         SignInEmailLinkNotSameClientCode,
 	})
 }
+
+// SignInBlockedByRisk indicates that the sign-in attempt was blocked, because
+// it deviates too much from the previous activity of the user.
+func SignInBlockedByRisk() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "sign in blocked",
+		longMessage:  "This sign in attempt was blocked because it looks suspicious. Please contact the application administrator.",
+		code:         SignInBlockedByRiskCode,
+	})
+}
@@ -16,7 +16,7 @@ func SignInTokenRevoked() Error {
 func SignInTokenAlreadyUsed() Error {
 	return New(http.StatusBadRequest, &mainError{
 		shortMessage: "sign in token has already been used",
-		longMessage:  "This sign in token has already been used. Each token can only be used once.",
+		longMessage:  "This sign in token has already been used as many times as it allows.",
 		code:         SignInTokenAlreadyUsedCode,
 	})
 }
@@ -59,6 +59,26 @@ func CaptchaInvalid() Error {
 	})
 }
 
+// CaptchaRequired indicates that the sign-up looks automated, so the client
+// has to solve a CAPTCHA challenge and try again.
+func CaptchaRequired() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "CAPTCHA required",
+		longMessage:  "This sign up requires a CAPTCHA challenge to be solved. Please solve the challenge and try again.",
+		code:         CaptchaRequiredCode,
+	})
+}
+
+// SignUpBlockedAsBot indicates that the sign-up was blocked, because it's
+// most likely made by a bot.
+func SignUpBlockedAsBot() Error {
+	return New(http.StatusForbidden, &mainError{
+		shortMessage: "sign up blocked",
+		longMessage:  "This sign up was blocked because it looks automated. Please contact the application administrator.",
+		code:         SignUpBlockedAsBotCode,
+	})
+}
+
 func SignUpOutdatedVerification() Error {
 	return New(http.StatusGone, &mainError{
 		shortMessage: "Outdated verification",
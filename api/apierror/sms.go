@@ -16,3 +16,13 @@ func DevMonthlySMSLimitExceeded(limit int) Error {
 		},
 	})
 }
+
+// PhoneNumberRejected signifies an error when a one-time code can't be sent to a phone number, because
+// the carrier lookup found it unreachable or of a line type the instance doesn't allow.
+func PhoneNumberRejected() Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "Phone number not supported",
+		longMessage:  "Verification codes can't be sent to this phone number. Please use a mobile phone number instead.",
+		code:         PhoneNumberRejectedCode,
+	})
+}
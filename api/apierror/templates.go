@@ -68,6 +68,15 @@ func RequiredVariableMissing(requiredVariable string) Error {
 	})
 }
 
+func TemplateVariableUnknown(param, variable string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: fmt.Sprintf("contains unknown {{%s}} variable", variable),
+		longMessage:  fmt.Sprintf("The {{%s}} variable is not available for this template", variable),
+		code:         TemplateVariableUnknownCode,
+		meta:         &formParameter{Name: param},
+	})
+}
+
 func InvalidTemplateBody() Error {
 	return New(http.StatusUnprocessableEntity, &mainError{
 		shortMessage: "Invalid template body",
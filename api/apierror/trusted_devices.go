@@ -0,0 +1,16 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// TrustedDeviceNotFound signifies an error when the user has no trusted
+// device with the given id.
+func TrustedDeviceNotFound(trustedDeviceID string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "not found",
+		longMessage:  fmt.Sprintf("No trusted device was found with id %s", trustedDeviceID),
+		code:         TrustedDeviceNotFoundCode,
+	})
+}
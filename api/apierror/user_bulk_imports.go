@@ -0,0 +1,27 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// UserBulkImportNotFound signifies an error when no bulk import with the
+// given ID exists in the instance.
+func UserBulkImportNotFound(id string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "User bulk import not found",
+		longMessage:  fmt.Sprintf("No user bulk import was found with id %s", id),
+		code:         UserBulkImportNotFoundCode,
+	})
+}
+
+// UserBulkImportTooLarge signifies an error when a bulk import contains
+// more users than can be imported at once.
+func UserBulkImportTooLarge(maxUsers int) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "Too many users",
+		longMessage:  fmt.Sprintf("A bulk import can contain up to %d users.", maxUsers),
+		code:         UserBulkImportTooLargeCode,
+		meta:         &formParameter{Name: "users"},
+	})
+}
@@ -0,0 +1,16 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// UserExportNotFound signifies an error when the user has no export with
+// the given ID.
+func UserExportNotFound(id string) Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "User export not found",
+		longMessage:  fmt.Sprintf("No user export was found with id %s", id),
+		code:         UserExportNotFoundCode,
+	})
+}
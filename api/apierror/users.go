@@ -50,6 +50,14 @@ func NoPasswordSet() Error {
 	})
 }
 
+func NoPrimaryEmailAddress() Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "no primary email address",
+		longMessage:  "This user does not have a primary email address to send the email to",
+		code:         NoPrimaryEmailAddressCode,
+	})
+}
+
 func IncorrectPassword() Error {
 	return New(http.StatusUnprocessableEntity, &mainError{
 		shortMessage: "incorrect password",
@@ -121,3 +129,19 @@ func UserCreateOrgNotEnabled() Error {
 		code:         UserCreateOrganizationNotEnabledCode,
 	})
 }
+
+func PrimaryEmailAddressChangeInvalid() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "invalid confirmation token",
+		longMessage:  "This primary email address change confirmation token is invalid or has already been used.",
+		code:         PrimaryEmailAddressChangeInvalidCode,
+	})
+}
+
+func PrimaryEmailAddressChangeExpired() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "confirmation token expired",
+		longMessage:  "This primary email address change has expired. Please change your primary email address again.",
+		code:         PrimaryEmailAddressChangeExpiredCode,
+	})
+}
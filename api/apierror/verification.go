@@ -1,6 +1,9 @@
 package apierror
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 // VerificationAlreadyVerified signifies an error when verification has already been verified
 func VerificationAlreadyVerified() Error {
@@ -94,3 +97,18 @@ func VerificationInvalidLinkTokenSource() Error {
 		code:         VerificationInvalidLinkTokenSourceCode,
 	})
 }
+
+type verificationCooldownMeta struct {
+	CooldownExpiresAt int64 `json:"cooldown_expires_at"`
+}
+
+// VerificationCooldown means that the verification can't be attempted until
+// the given time, because of too many failed attempts.
+func VerificationCooldown(expiresAt time.Time) Error {
+	return New(http.StatusTooManyRequests, &mainError{
+		shortMessage: "too many failed attempts",
+		longMessage:  "Too many failed verification attempts. Please wait before trying again.",
+		code:         VerificationCooldownCode,
+		meta:         &verificationCooldownMeta{CooldownExpiresAt: expiresAt.UnixMilli()},
+	})
+}
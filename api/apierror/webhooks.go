@@ -26,3 +26,59 @@ func SvixAppCreateError(name string) Error {
 		code:         SvixAppCreateErrorCode,
 	})
 }
+
+func SvixEndpointNotFound() Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "Webhook endpoint not found",
+		longMessage:  "No webhook endpoint was found with the given id for the current instance.",
+		code:         SvixEndpointNotFoundCode,
+	})
+}
+
+func SvixEndpointVerificationFailed(param, reason string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "could not be verified",
+		longMessage:  fmt.Sprintf("The webhook endpoint URL did not respond to the verification challenge: %s.", reason),
+		code:         SvixEndpointVerificationFailedCode,
+		meta:         &formParameter{Name: param},
+	})
+}
+
+func NativeWebhooksAlreadyEnabled() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Native webhook delivery is already enabled for the current instance.",
+		code:         NativeWebhooksEnabledCode,
+	})
+}
+
+func NativeWebhooksNotEnabled() Error {
+	return New(http.StatusBadRequest, &mainError{
+		shortMessage: "Native webhook delivery is not enabled for the current instance.",
+		code:         NativeWebhooksNotEnabledCode,
+	})
+}
+
+func WebhookFailedDeliveryNotFound() Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "Failed webhook delivery not found",
+		longMessage:  "No failed webhook delivery was found with the given id for the current instance.",
+		code:         WebhookFailedDeliveryNotFoundCode,
+	})
+}
+
+func WebhookEventReplayNotFound() Error {
+	return New(http.StatusNotFound, &mainError{
+		shortMessage: "Webhook event replay not found",
+		longMessage:  "No webhook event replay was found with the given id for the current instance.",
+		code:         WebhookEventReplayNotFoundCode,
+	})
+}
+
+func WebhookReplayRangeInvalid(param, reason string) Error {
+	return New(http.StatusUnprocessableEntity, &mainError{
+		shortMessage: "is invalid",
+		longMessage:  fmt.Sprintf("The events to replay can't be selected: %s.", reason),
+		code:         WebhookReplayRangeInvalidCode,
+		meta:         &formParameter{Name: param},
+	})
+}
@@ -10,7 +10,10 @@ import (
 	"clerk/api/bapi/v1/externalapp"
 	"clerk/api/bapi/v1/internalapi"
 	"clerk/api/bapi/v1/router"
+	"clerk/api/middleware/pipeline"
+	"clerk/api/shared/instance_metrics"
 	"clerk/api/shared/jwt"
+	"clerk/api/shared/serviceconfig"
 	"clerk/api/shared/sso"
 	"clerk/pkg/apiversioning"
 	"clerk/pkg/billing"
@@ -18,12 +21,15 @@ import (
 	"clerk/pkg/externalapis/svix"
 	"clerk/pkg/handlers"
 	"clerk/pkg/pubsub"
+	"clerk/pkg/readreplica"
 	"clerk/pkg/sentry"
 	"clerk/pkg/storage/google"
+	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 
 	"cloud.google.com/go/profiler"
+	"github.com/jonboulle/clockwork"
 	"github.com/stripe/stripe-go/v72"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -35,6 +41,11 @@ func main() {
 		panic(fmt.Sprintf("Missing Environment Variables: %+v", missingEnvVars))
 	}
 
+	cfg, err := serviceconfig.LoadBAPI()
+	if err != nil {
+		panic(err)
+	}
+
 	apiversioning.RegisterAllVersions()
 
 	sso.RegisterOAuthProviders()
@@ -44,10 +55,10 @@ func main() {
 	logger := log.New()
 	defer logger.Flush()
 
-	err := sentry.Init(
-		cenv.Get(cenv.SentryURL),
-		cenv.Get(cenv.ClerkEnv),
-		cenv.Get(cenv.SentryIgnoredStatusCodes),
+	err = sentry.Init(
+		cfg.SentryURL,
+		cfg.Env,
+		cfg.SentryIgnoredStatusCodes,
 	)
 	if err != nil {
 		logger.Error("failed starting Sentry: %s", err)
@@ -55,38 +66,40 @@ func main() {
 		defer sentry.Flush()
 	}
 
-	if cenv.IsEnabled(cenv.GoogleCloudProfiler) {
-		err = profiler.Start(profiler.Config{Service: cenv.Get(cenv.ClerkServiceIdentifier)})
+	if cfg.GoogleCloudProfiler {
+		err = profiler.Start(profiler.Config{Service: cfg.ServiceIdentifier})
 		if err != nil {
 			logger.Error("profiler: start: %s", err)
 		}
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
+	if cfg.DatadogTracer {
 		//GitCommitSHA is not a required env var, but if it's available use it
 		tracerOpts := []tracer.StartOption{
-			tracer.WithEnv(cenv.Get(cenv.ClerkEnv)),
-			tracer.WithService(cenv.Get(cenv.ClerkServiceIdentifier)),
+			tracer.WithEnv(cfg.Env),
+			tracer.WithService(cfg.ServiceIdentifier),
 		}
-		if cenv.IsSet(cenv.GitCommitSHA) {
-			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cenv.Get(cenv.GitCommitSHA)))
+		if cfg.GitCommitSHA != "" {
+			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cfg.GitCommitSHA))
 		}
 		tracer.Start(tracerOpts...)
 		defer tracer.Stop()
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDebugMode) {
+	if cfg.DebugMode {
 		boil.DebugMode = true
 		boil.DebugWriter = logger.Writer()
 	}
 
-	storageClient, err := google.NewClient(context.Background(), cenv.Get(cenv.GoogleStorageBucket))
+	storageClient, err := google.NewClient(context.Background(), cfg.GoogleStorageBucket)
 	if err != nil {
 		panic(err)
 	}
 
 	pubsubEventsTopic := pubsub.EventsTopic()
-	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic))
+	// repositories are created once and shared by every service
+	repositories := repository.NewRegistry(clockwork.NewRealClock())
+	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic), clerk.WithRepositories(repositories))
 
 	defer func() {
 		err := deps.SegmentClient().Close()
@@ -103,11 +116,11 @@ func main() {
 	jwt.RegisterServiceVendors(deps.Clock())
 
 	// Initialize Stripe - This must come BEFORE the Gue worker initialization
-	stripe.Key = cenv.Get(cenv.StripeSecretKey)
+	stripe.Key = cfg.StripeSecretKey
 	paymentProvider := billing.NewStripePaymentProvider(deps.GueClient())
 
 	// Initialize the Stripe billing connector
-	stripeConnectorConfig := billing.StripeConnectorConfig{SecretKey: cenv.Get(cenv.BillingStripeSecretKey)}
+	stripeConnectorConfig := billing.StripeConnectorConfig{SecretKey: cfg.BillingStripeSecretKey}
 	billingConnector, err := billing.NewStripeConnector(stripeConnectorConfig)
 	if err != nil {
 		panic(err)
@@ -115,17 +128,21 @@ func main() {
 
 	commonHandlers := handlers.NewCommon(deps.DB())
 	svixClient := svix.NewClient(&svix.ClientOptions{
-		APIToken: cenv.Get(cenv.SvixAPIToken),
+		APIToken: cfg.SvixAPIToken,
 	})
 
 	// Client for external app requests, like proxy config health check
 	externalAppClient := externalapp.NewClient(&http.Client{Timeout: 1 * time.Second})
 
 	// Client for making requests to BAPI internal endpoints
-	internalClient := internalapi.NewClient(cenv.Get(cenv.ClerkServerAPI), nil)
+	internalClient := internalapi.NewClient(cfg.ServerAPI, nil)
+
+	// reads which can tolerate a lagging replica go to it, while it's caught up
+	db := readreplica.New(deps.Clock(), deps.DB(), deps.ReadOnlyDB())
 
 	r := router.New(
 		deps,
+		db,
 		commonHandlers,
 		svixClient,
 		billingConnector,
@@ -135,16 +152,14 @@ func main() {
 	)
 
 	// Start the HTTP server.
-	port := cenv.Get(cenv.Port)
-	ctxTimeout := cenv.GetInt(cenv.ContextTimeoutSeconds)
-	writeTimeout := ctxTimeout + 2 // write timeout should be longer than context timeout
-
-	server := http.Server{
-		Addr:         ":" + port,
-		Handler:      http.TimeoutHandler(r.BuildRoutes(), time.Duration(ctxTimeout)*time.Second, ""),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: time.Duration(writeTimeout) * time.Second,
-	}
+	go serviceconfig.WatchTunables(context.Background())
+	// counts of hot paths, like issued session tokens, are aggregated in
+	// memory and flushed periodically
+	go instance_metrics.RunFlusher(context.Background(), deps)
+	go db.Run(context.Background())
+
+	port := cfg.Port
+	server := pipeline.NewServer(port, r.BuildRoutes())
 
 	logger.Info("Up on port %s (pid=%d)", port, os.Getpid())
 	logger.Fatal(server.ListenAndServe())
@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/types"
 )
 
@@ -44,8 +45,8 @@ func NewService(deps clerk.Deps) *Service {
 		db:             deps.DB(),
 		validator:      validator.New(),
 		eventService:   events.NewService(deps),
-		actorTokenRepo: repository.NewActorToken(),
-		userRepo:       repository.NewUsers(),
+		actorTokenRepo: deps.Repositories().ActorToken,
+		userRepo:       deps.Repositories().Users,
 	}
 }
 
@@ -60,6 +61,7 @@ type CreateParams struct {
 	Actor                       json.RawMessage `json:"actor" form:"actor" validate:"required"`
 	ExpiresInSeconds            *int            `json:"expires_in_seconds" form:"expires_in_seconds" validate:"omitempty,numeric,gte=1"`
 	SessionMaxDurationInSeconds *int            `json:"session_max_duration_in_seconds" form:"session_max_duration_in_seconds" validate:"omitempty,numeric,gte=1"`
+	Reason                      *string         `json:"reason" form:"reason" validate:"omitempty,max=1000"`
 }
 
 func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
@@ -103,6 +105,7 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.A
 			Status:                      constants.StatusPending,
 			InstanceID:                  env.Instance.ID,
 			SessionMaxDurationInSeconds: sessionMaxDurationInSeconds,
+			Reason:                      null.StringFromPtr(params.Reason),
 		},
 	}
 
@@ -16,11 +16,11 @@ import (
 	"clerk/pkg/emailaddress"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/pkg/usersettings/clerk/names"
+	"clerk/pkg/web3"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/param"
-	"clerk/utils/validate"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/volatiletech/null/v8"
@@ -45,8 +45,8 @@ func NewService(deps clerk.Deps) *Service {
 		validator:      validator.New(),
 		comms:          comms.NewService(deps),
 		invitations:    invitations.NewService(deps),
-		allowlistRepo:  repository.NewAllowlist(),
-		invitationRepo: repository.NewInvitations(),
+		allowlistRepo:  deps.Repositories().Allowlist,
+		invitationRepo: deps.Repositories().Invitations,
 	}
 }
 
@@ -113,7 +113,7 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.A
 	}
 
 	if params.Notify {
-		if emailaddress.IsDomainWhitelist(params.Identifier) || validate.Web3Wallet(params.Identifier, "") == nil {
+		if emailaddress.IsDomainWhitelist(params.Identifier) || isWeb3Wallet(params.Identifier) {
 			return nil, apierror.FormParameterNotAllowedConditionally(param.AllowlistNotify.Name, param.AllowlistIdentifier.Name, "an email domain or a web3 wallet")
 		}
 	}
@@ -222,3 +222,8 @@ func (s *Service) Delete(ctx context.Context, identifierID string) (*serialize.D
 
 	return serialize.DeletedObject(identifierID, serialize.AllowlistIdentifierObjectName), nil
 }
+
+func isWeb3Wallet(identifier string) bool {
+	_, ok := web3.ChainForAddress(identifier)
+	return ok
+}
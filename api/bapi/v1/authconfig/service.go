@@ -55,6 +55,9 @@ type UpdateParams struct {
 	ProgressiveSignUp           *bool   `json:"progressive_sign_up" form:"progressive_sign_up"`
 	TestMode                    *bool   `json:"test_mode" form:"test_mode"`
 	EnhancedEmailDeliverability *bool   `json:"enhanced_email_deliverability" form:"enhanced_email_deliverability"`
+	ReuseIdPEmailVerification   *bool   `json:"reuse_idp_email_verification" form:"reuse_idp_email_verification"`
+	TrustedDevices              *bool   `json:"trusted_devices" form:"trusted_devices"`
+	TrustedDeviceLifetimeInDays *int    `json:"trusted_device_lifetime_in_days" form:"trusted_device_lifetime_in_days" validate:"omitempty,gte=1,lte=365"`
 }
 
 // Update the auth_config of the instance
@@ -112,6 +115,21 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) (*serialize.A
 			authConfigColumnsToUpdate.Insert(sqbmodel.AuthConfigColumns.UserSettings)
 		}
 
+		if params.ReuseIdPEmailVerification != nil {
+			authConfig.UserSettings.SignIn.ReuseIdPEmailVerification = *params.ReuseIdPEmailVerification
+			authConfigColumnsToUpdate.Insert(sqbmodel.AuthConfigColumns.UserSettings)
+		}
+
+		if params.TrustedDevices != nil {
+			authConfig.UserSettings.SignIn.TrustedDevices.Enabled = *params.TrustedDevices
+			authConfigColumnsToUpdate.Insert(sqbmodel.AuthConfigColumns.UserSettings)
+		}
+
+		if params.TrustedDeviceLifetimeInDays != nil {
+			authConfig.UserSettings.SignIn.TrustedDevices.LifetimeInDays = *params.TrustedDeviceLifetimeInDays
+			authConfigColumnsToUpdate.Insert(sqbmodel.AuthConfigColumns.UserSettings)
+		}
+
 		if params.TestMode != nil {
 			authConfig.TestMode = *params.TestMode
 			authConfigColumnsToUpdate.Insert(sqbmodel.AuthConfigColumns.TestMode)
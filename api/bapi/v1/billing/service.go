@@ -26,7 +26,7 @@ func NewService(deps clerk.Deps, billingConnector billing.Connector) *Service {
 	s := &Service{
 		db:               deps.DB(),
 		billingConnector: billingConnector,
-		instanceRepo:     repository.NewInstances(),
+		instanceRepo:     deps.Repositories().Instances,
 		eventHandlers:    make(map[string]func(context.Context, billing.Event) apierror.Error),
 	}
 	s.registerEventHandlers()
@@ -4,8 +4,11 @@ import (
 	"context"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/images"
+	"clerk/api/shared/webhooks"
 	"clerk/pkg/cenv"
 	"clerk/pkg/jobs"
+	"clerk/pkg/storage"
 	"clerk/repository"
 	"clerk/utils/database"
 
@@ -14,18 +17,26 @@ import (
 )
 
 type Service struct {
-	clock           clockwork.Clock
-	db              database.Database
-	gueClient       *gue.Client
-	applicationRepo *repository.Applications
+	clock            clockwork.Clock
+	db               database.Database
+	gueClient        *gue.Client
+	imageService     *images.Service
+	applicationRepo  *repository.Applications
+	organizationRepo *repository.Organization
+	userRepo         *repository.Users
+	webhookEventRepo *repository.WebhookEvents
 }
 
-func NewService(clock clockwork.Clock, db database.Database, gueClient *gue.Client) *Service {
+func NewService(clock clockwork.Clock, db database.Database, gueClient *gue.Client, storageClient storage.ReadWriter) *Service {
 	return &Service{
-		clock:           clock,
-		db:              db,
-		gueClient:       gueClient,
-		applicationRepo: repository.NewApplications(),
+		clock:            clock,
+		db:               db,
+		gueClient:        gueClient,
+		imageService:     images.NewService(clock, storageClient),
+		applicationRepo:  repository.NewApplications(),
+		organizationRepo: repository.NewOrganization(),
+		userRepo:         repository.NewUsers(),
+		webhookEventRepo: repository.NewWebhookEvents(),
 	}
 }
 
@@ -91,6 +102,56 @@ func (s *Service) OrphanOrganizations(ctx context.Context, limit int) apierror.E
 	return nil
 }
 
+const (
+	defaultDeletedUsersLimit = 100
+)
+
+// DeletedUsers schedules the purge of deleted users whose retention window
+// has expired.
+func (s *Service) DeletedUsers(ctx context.Context, limit int) apierror.Error {
+	if limit == 0 {
+		limit = defaultDeletedUsersLimit
+	}
+	userIDs, err := s.userRepo.FindAllIDsDeletedWithPurgeBefore(ctx, s.db, s.clock.Now().UTC(), limit)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if len(userIDs) > 0 {
+		err = jobs.PurgeDeletedUsers(ctx, s.gueClient, jobs.PurgeDeletedUsersArgs{
+			UserIDs: userIDs,
+		})
+		if err != nil {
+			return apierror.Unexpected(err)
+		}
+	}
+	return nil
+}
+
+const (
+	defaultDeletedOrganizationsLimit = 100
+)
+
+// DeletedOrganizations schedules the purge of organizations pending deletion
+// whose grace period has expired.
+func (s *Service) DeletedOrganizations(ctx context.Context, limit int) apierror.Error {
+	if limit == 0 {
+		limit = defaultDeletedOrganizationsLimit
+	}
+	organizationIDs, err := s.organizationRepo.FindAllIDsDeletedWithPurgeBefore(ctx, s.db, s.clock.Now().UTC(), limit)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if len(organizationIDs) > 0 {
+		err = jobs.PurgeDeletedOrganizations(ctx, s.gueClient, jobs.PurgeDeletedOrganizationsArgs{
+			OrganizationIDs: organizationIDs,
+		})
+		if err != nil {
+			return apierror.Unexpected(err)
+		}
+	}
+	return nil
+}
+
 // ExpiredOAuthTokens deletes expired OAuth application tokens asynchronously.
 func (s *Service) ExpiredOAuthTokens(ctx context.Context) apierror.Error {
 	err := jobs.CleanupExpiredOAuthTokens(
@@ -102,3 +163,55 @@ func (s *Service) ExpiredOAuthTokens(ctx context.Context) apierror.Error {
 	}
 	return nil
 }
+
+const (
+	defaultExpiredOrganizationInvitationsLimit = 500
+)
+
+// ExpiredOrganizationInvitations schedules the job which expires the pending
+// organization invitations whose expiry has passed.
+func (s *Service) ExpiredOrganizationInvitations(ctx context.Context, limit int) apierror.Error {
+	if limit == 0 {
+		limit = defaultExpiredOrganizationInvitationsLimit
+	}
+	err := jobs.ExpireOrganizationInvitations(ctx, s.gueClient, jobs.ExpireOrganizationInvitationsArgs{
+		Limit: limit,
+	})
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+const (
+	defaultExpiredWebhookEventsLimit = 10000
+)
+
+// ExpiredWebhookEvents deletes the recorded webhook events which are older
+// than the retention window, along with their replay markers.
+func (s *Service) ExpiredWebhookEvents(ctx context.Context, limit int) apierror.Error {
+	if limit == 0 {
+		limit = defaultExpiredWebhookEventsLimit
+	}
+	cutoff := s.clock.Now().UTC().Add(-webhooks.EventRetention)
+	if _, err := s.webhookEventRepo.DeleteCreatedBefore(ctx, s.db, cutoff, limit); err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+const (
+	defaultPendingImageUploadsLimit = 500
+)
+
+// PendingImageUploads deletes the pending images whose direct uploads were
+// never completed, along with anything that was uploaded for them.
+func (s *Service) PendingImageUploads(ctx context.Context, limit int) apierror.Error {
+	if limit == 0 {
+		limit = defaultPendingImageUploadsLimit
+	}
+	if _, err := s.imageService.SweepPendingUploads(ctx, s.db, limit); err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/clients"
 	"clerk/api/shared/cookies"
+	"clerk/api/shared/signing_keys"
 	"clerk/model"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
@@ -30,6 +31,7 @@ type Service struct {
 	cookieService     *cookies.Service
 	clientService     *clients.Service
 	clientDataService *client_data.Service
+	signingKeyService *signing_keys.Service
 
 	// repositories
 	clientRepo *repository.Clients
@@ -43,7 +45,8 @@ func NewService(deps clerk.Deps) *Service {
 		cookieService:     cookies.NewService(deps),
 		clientService:     clients.NewService(deps),
 		clientDataService: client_data.NewService(deps),
-		clientRepo:        repository.NewClients(),
+		signingKeyService: signing_keys.NewService(deps),
+		clientRepo:        deps.Repositories().Clients,
 	}
 }
 
@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"errors"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/handshakebinding"
+	"clerk/pkg/jwt"
+	pkiutils "clerk/utils/pki"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type VerifyHandshakeParams struct {
+	Token string `json:"token" form:"token" validate:"required"`
+	// UserAgent is the user agent of the request the token was received
+	// with, which bound tokens are checked against
+	UserAgent string `json:"user_agent" form:"user_agent"`
+}
+
+func (p VerifyHandshakeParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	return nil
+}
+
+// handshakeClaims are the claims of the handshake tokens FAPI issues.
+type handshakeClaims struct {
+	Handshake []string                  `json:"handshake"`
+	Binding   *handshakebinding.Binding `json:"binding,omitempty"`
+}
+
+// VerifyHandshake verifies the given handshake token on behalf of the
+// backend which received it, and returns the cookies it carries. Tokens
+// bound to another device than the one of the request which brought them
+// are rejected, since they have been replayed.
+func (s *Service) VerifyHandshake(ctx context.Context, params VerifyHandshakeParams) (*serialize.HandshakeResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.signingKeyService.Verifiable(ctx, s.db, env.Instance)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	var claims *handshakeClaims
+	for _, key := range keys {
+		publicKey, err := pkiutils.LoadPublicKey([]byte(key.PublicKey))
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+		var verified handshakeClaims
+		if err := jwt.Verify(params.Token, publicKey, &verified, s.clock, key.Algorithm); err == nil {
+			claims = &verified
+			break
+		}
+	}
+	if claims == nil {
+		return nil, apierror.InvalidHandshake("invalid token")
+	}
+
+	err = claims.Binding.Verify(handshakebinding.Client{UserAgent: params.UserAgent})
+	if errors.Is(err, handshakebinding.ErrUserAgentMismatch) {
+		return nil, apierror.HandshakeBindingMismatch()
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.Handshake(claims.Handshake), nil
+}
@@ -54,3 +54,13 @@ func (h *HTTP) Verify(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 
 	return h.service.Verify(r.Context(), params)
 }
+
+// POST /v1/clients/handshake/verify
+func (h *HTTP) VerifyHandshake(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := VerifyHandshakeParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.VerifyHandshake(r.Context(), params)
+}
@@ -40,7 +40,7 @@ func NewService(deps clerk.Deps) *Service {
 		emailService:       emails.NewService(deps),
 		smsService:         sms.NewService(deps),
 		templateSvc:        shtemplates.NewService(deps.Clock()),
-		identificationRepo: repository.NewIdentification(),
+		identificationRepo: deps.Repositories().Identification,
 	}
 }
 
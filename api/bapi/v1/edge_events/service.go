@@ -5,7 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"clerk/api/shared/environment"
 	sharedEvents "clerk/api/shared/events"
+	"clerk/api/shared/instance_metrics"
 	"clerk/model"
 	"clerk/pkg/events"
 	"clerk/pkg/sentry"
@@ -25,7 +27,7 @@ type Service struct {
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		db:            deps.DB(),
-		instanceRepo:  *repository.NewInstances(),
+		instanceRepo:  *deps.Repositories().Instances,
 		eventsService: sharedEvents.NewService(deps),
 	}
 }
@@ -33,6 +35,11 @@ func NewService(deps clerk.Deps) *Service {
 type MessageData struct {
 	EventTypeName string         `json:"eventTypeName"`
 	Session       *model.Session `json:"session"`
+
+	// TokensIssued is the number of session tokens the edge issued for the
+	// session since its previous message. Edges which don't report it send
+	// a message for every token they count.
+	TokensIssued int64 `json:"tokensIssued,omitempty"`
 }
 
 func (s *Service) HandleMessage(ctx context.Context, msg pubsub.Message) error {
@@ -51,6 +58,12 @@ func (s *Service) HandleMessage(ctx context.Context, msg pubsub.Message) error {
 			return nil
 		}
 
+		// counted before the event is sent, since messages which fail are
+		// redelivered and the tokens have been issued regardless
+		if msg.DeliveryAttempt == nil || *msg.DeliveryAttempt <= 1 {
+			instance_metrics.Count(data.Session.InstanceID, instance_metrics.TokensIssued, "", max(data.TokensIssued, 1))
+		}
+
 		if err := s.edgeSessionTokenCreated(ctx, data.Session); err != nil {
 			// Note: DeliveryAttempt will be `nil` if the associated pubsub subscription has no dead letter topic assigned.
 			// We should assume that it has one. See https://cloud.google.com/pubsub/docs/handling-failures#track-delivery-attempts for details.
@@ -63,6 +76,9 @@ func (s *Service) HandleMessage(ctx context.Context, msg pubsub.Message) error {
 			return err
 		}
 		return nil
+	case environment.InvalidatedEventTypeName:
+		// handled by the FAPI environment caches
+		return nil
 	default:
 		sentry.CaptureException(ctx, fmt.Errorf("edge_events/service: unsupported eventType: %q", data.EventTypeName))
 		return nil
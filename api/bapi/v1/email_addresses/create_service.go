@@ -54,13 +54,18 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.E
 
 	var emailAddressResponse *serialize.EmailAddressResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		newIdentification, apiErr := s.createEmailAddress(ctx, tx, user, params)
 		if err != nil {
 			return true, apiErr
 		}
 
 		// send event
-		if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
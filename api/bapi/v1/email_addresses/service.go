@@ -51,9 +51,9 @@ func NewService(deps clerk.Deps) *Service {
 		serializableService:      serializable.NewService(deps.Clock()),
 		shIdentificationsService: identifications.NewService(deps),
 		userProfileService:       user_profile.NewService(deps.Clock()),
-		userRepo:                 repository.NewUsers(),
-		identificationRepo:       repository.NewIdentification(),
-		verificationRepo:         repository.NewVerification(),
+		userRepo:                 deps.Repositories().Users,
+		identificationRepo:       deps.Repositories().Identification,
+		verificationRepo:         deps.Repositories().Verification,
 	}
 }
 
@@ -74,13 +74,14 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
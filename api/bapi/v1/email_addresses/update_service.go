@@ -46,6 +46,11 @@ func (s *Service) Update(ctx context.Context, emailAddressID string, params Upda
 
 	var emailAddressResponse *serialize.EmailAddressResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		updatedUser, updatedIdent, performedUpdate, err := s.updateEmailAddress(ctx, tx, user, emailAddress, params)
 		if err != nil {
 			return true, err
@@ -53,7 +58,7 @@ func (s *Service) Update(ctx context.Context, emailAddressID string, params Upda
 
 		// send event if something changed
 		if performedUpdate {
-			if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, updatedUser); err != nil {
+			if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, updatedUser); err != nil {
 				return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", updatedUser, env.Instance.ID, err)
 			}
 		}
@@ -4,9 +4,10 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/organization_api_keys"
 	"clerk/pkg/constants"
 	clerkstrings "clerk/pkg/strings"
-	"clerk/utils/database"
+	"clerk/utils/clerk"
 	"clerk/utils/url"
 )
 
@@ -14,19 +15,30 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
-		service: NewService(db),
+		service: NewService(deps),
 	}
 }
 
 // Middleware /v1
-func (h *HTTP) SetEnvironmentFromHeader(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+func (h *HTTP) SetEnvironmentFromHeader(w http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
 	secretKey, err := url.BearerAuthHeader(r)
 	if err != nil {
 		return nil, err
 	}
 
+	if organization_api_keys.IsSecret(secretKey) {
+		newCtx, err := h.service.SetEnvironmentFromOrganizationAPIKey(r.Context(), secretKey)
+		if err != nil {
+			return nil, err
+		}
+		if key, _ := organization_api_keys.FromContext(newCtx); !organization_api_keys.AllowsPath(key, r.URL.Path) {
+			return nil, apierror.OrganizationAPIKeyOutOfScope()
+		}
+		return r.WithContext(newCtx), nil
+	}
+
 	// Add secret key prefix in case it is missing (legacy key)
 	if secretKey != "" {
 		secretKey = clerkstrings.AddPrefixIfNeeded(secretKey, constants.SecretKeyPrefix)
@@ -37,5 +49,9 @@ func (h *HTTP) SetEnvironmentFromHeader(_ http.ResponseWriter, r *http.Request)
 		return nil, err
 	}
 
+	if err := h.service.VerifyProofOfPossession(newCtx, w, r); err != nil {
+		return nil, err
+	}
+
 	return r.WithContext(newCtx), nil
 }
@@ -2,32 +2,55 @@ package environment
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/debug_logging"
+	"clerk/api/shared/dpop"
 	"clerk/api/shared/environment"
+	"clerk/api/shared/organization_api_keys"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/sentryenv"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cache"
 	ctxenv "clerk/pkg/ctx/environment"
 	"clerk/pkg/ctxkeys"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 )
 
 type Service struct {
-	db database.Database
+	cache cache.Cache
+	clock clockwork.Clock
+	db    database.Database
 
 	// services
-	environmentService *environment.Service
+	debugLoggingService *debug_logging.Service
+	environmentService  *environment.Service
 
 	// repositories
-	instanceKeysRepo *repository.InstanceKeys
+	instanceKeysRepo       *repository.InstanceKeys
+	organizationAPIKeyRepo *repository.OrganizationAPIKeys
 }
 
-func NewService(db database.Database) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:                 db,
-		environmentService: environment.NewService(),
-		instanceKeysRepo:   repository.NewInstanceKeys(),
+		cache:                  deps.Cache(),
+		clock:                  deps.Clock(),
+		db:                     deps.DB(),
+		debugLoggingService:    debug_logging.NewService(deps),
+		environmentService:     environment.NewService(),
+		instanceKeysRepo:       deps.Repositories().InstanceKeys,
+		organizationAPIKeyRepo: deps.Repositories().OrganizationAPIKeys,
 	}
 }
 
@@ -41,18 +64,106 @@ func (s *Service) SetEnvironmentFromKey(ctx context.Context, secretKey string) (
 		return ctx, apierror.InvalidClerkSecretKey()
 	}
 
-	env, err := s.environmentService.Load(ctx, s.db, key.InstanceID)
+	ctx = context.WithValue(ctx, ctxkeys.InstanceKey, key)
+
+	return s.setEnvironment(ctx, key.InstanceID)
+}
+
+// SetEnvironmentFromOrganizationAPIKey loads the environment of the instance
+// the organization API key belongs to into the context, along with the key
+// itself, which restricts the request to its organization.
+func (s *Service) SetEnvironmentFromOrganizationAPIKey(ctx context.Context, secret string) (context.Context, apierror.Error) {
+	key, err := s.organizationAPIKeyRepo.QueryBySecretDigest(ctx, s.db, organization_api_keys.Digest(secret))
 	if err != nil {
 		return ctx, apierror.Unexpected(err)
+	} else if key == nil {
+		return ctx, apierror.InvalidClerkSecretKey()
 	}
 
-	log.AddToLogLine(ctx, log.InstanceID, env.Instance.ID)
-	log.AddToLogLine(ctx, log.EnvironmentType, env.Instance.EnvironmentType)
-	log.AddToLogLine(ctx, log.DomainName, env.Domain.Name)
+	ctx = organization_api_keys.NewContext(ctx, key)
+	s.recordOrganizationAPIKeyUse(ctx, key)
 
-	sentryenv.EnrichScope(ctx, env)
+	return s.setEnvironment(ctx, key.InstanceID)
+}
 
-	ctx = context.WithValue(ctx, ctxkeys.InstanceKey, key)
+// recordOrganizationAPIKeyUse updates the last use of the key, at most once
+// every few minutes. Failing to do so doesn't fail the request.
+func (s *Service) recordOrganizationAPIKeyUse(ctx context.Context, key *model.OrganizationAPIKey) {
+	now := s.clock.Now().UTC()
+	if !organization_api_keys.ShouldRecordUse(key, now) {
+		return
+	}
+
+	key.LastUsedAt = null.TimeFrom(now)
+	err := s.organizationAPIKeyRepo.Update(ctx, s.db, key, sqbmodel.OrganizationAPIKeyColumns.LastUsedAt)
+	if err != nil {
+		log.Warning(ctx, "environment/recordOrganizationAPIKeyUse: updating last use of organization API key %s: %s", key.ID, err)
+	}
+}
+
+// VerifyProofOfPossession makes sure that requests authenticated with a
+// secret key which is bound to a key pair carry a valid DPoP proof. Keys
+// which aren't bound are left alone.
+//
+// The nonce the next proof must include is always returned in the response
+// headers, so that clients can pick it up from any response.
+func (s *Service) VerifyProofOfPossession(ctx context.Context, w http.ResponseWriter, r *http.Request) apierror.Error {
+	key, ok := ctx.Value(ctxkeys.InstanceKey).(*model.InstanceKey)
+	if !ok || !key.DpopPublicKey.Valid {
+		return nil
+	}
+
+	publicKey, err := dpop.ParsePublicKey(key.DpopPublicKey.String)
+	if err != nil {
+		return apierror.Unexpected(fmt.Errorf("environment/verifyProofOfPossession: parsing public key of instance key %s: %w", key.ID, err))
+	}
+
+	now := s.clock.Now().UTC()
+	w.Header().Set(dpop.NonceHeaderName, dpop.Nonce(key.Secret, now))
+
+	proof, err := dpop.Verify(r.Header.Get(dpop.HeaderName), dpop.VerifyParams{
+		Method:      r.Method,
+		URL:         &url.URL{Host: r.Host, Path: r.URL.Path},
+		PublicKey:   publicKey,
+		AccessToken: key.Secret,
+		Now:         now,
+	})
+	if err != nil {
+		return apierror.InvalidDPoPProof(err)
+	}
+	if !dpop.ValidNonce(key.Secret, proof.Nonce, now) {
+		return apierror.DPoPNonceRequired()
+	}
+
+	// Make sure the proof hasn't been used already, by claiming its id in a
+	// single step, so that concurrent requests can't both use it. Proofs are
+	// only accepted within their issued at window, so there's no need to
+	// remember them for longer than that.
+	cacheKey := fmt.Sprintf("dpop:%s:%s", key.ID, proof.ID)
+	claimed, err := s.cache.SetNX(ctx, cacheKey, proof.ID, 2*dpop.MaxProofAge)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if !claimed {
+		return apierror.InvalidDPoPProof(fmt.Errorf("dpop: proof %s already used", proof.ID))
+	}
+
+	return nil
+}
+
+func (s *Service) setEnvironment(ctx context.Context, instanceID string) (context.Context, apierror.Error) {
+	env, err := s.environmentService.Load(ctx, s.db, instanceID)
+	if err != nil {
+		return ctx, apierror.Unexpected(err)
+	}
+
+	requestlog.Add(ctx, requestlog.InstanceID, env.Instance.ID)
+	requestlog.Add(ctx, requestlog.EnvironmentType, env.Instance.EnvironmentType)
+	requestlog.Add(ctx, requestlog.DomainName, env.Domain.Name)
+	s.debugLoggingService.WithDebugLogging(ctx, env.Instance)
+
+	sentryenv.EnrichScope(ctx, env)
 
-	return ctxenv.NewContext(ctx, env), nil
+	ctx = ctxenv.NewContext(ctx, env)
+	return phone_profiles.NewContext(ctx, env.Instance), nil
 }
@@ -6,6 +6,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/clerkhttp"
+	"clerk/pkg/readreplica"
 	"clerk/utils/clerk"
 )
 
@@ -13,8 +14,8 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(deps clerk.Deps) *HTTP {
-	return &HTTP{service: NewService(deps)}
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
+	return &HTTP{service: NewService(deps, db)}
 }
 
 // GET /v1/organization_permissions
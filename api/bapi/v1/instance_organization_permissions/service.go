@@ -8,22 +8,22 @@ import (
 	"clerk/api/shared/pagination"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/readreplica"
 	"clerk/pkg/set"
 	"clerk/repository"
 	"clerk/utils/clerk"
-	"clerk/utils/database"
 )
 
 type Service struct {
-	db database.Database
+	db *readreplica.Database
 
 	permissionRepo *repository.Permission
 }
 
-func NewService(deps clerk.Deps) *Service {
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
 	return &Service{
-		db:             deps.DB(),
-		permissionRepo: repository.NewPermission(),
+		db:             db,
+		permissionRepo: deps.Repositories().Permission,
 	}
 }
 
@@ -64,12 +64,13 @@ func (s *Service) List(ctx context.Context, params ListParams) (*serialize.Pagin
 		return nil, apiErr
 	}
 
-	orgPermissions, err := s.permissionRepo.FindAllByInstanceWithModifiers(ctx, s.db, env.Instance.ID, mods, params.pagination)
+	db := s.db.ReadReplica()
+	orgPermissions, err := s.permissionRepo.FindAllByInstanceWithModifiers(ctx, db, env.Instance.ID, mods, params.pagination)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
-	totalCount, err := s.permissionRepo.CountByInstanceWithModifiers(ctx, s.db, env.Instance.ID, mods)
+	totalCount, err := s.permissionRepo.CountByInstanceWithModifiers(ctx, db, env.Instance.ID, mods)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -9,17 +9,22 @@ import (
 	"clerk/api/bapi/v1/internalapi"
 	"clerk/pkg/clerkhttp"
 	"clerk/utils/clerk"
+	"clerk/utils/url"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type HTTP struct {
-	service       *Service
-	domainService *domains.Service
+	service        *Service
+	domainService  *domains.Service
+	metricsService *MetricsService
 }
 
 func NewHTTP(deps clerk.Deps, externalAppClient *externalapp.Client, internalClient *internalapi.Client) *HTTP {
 	return &HTTP{
-		service:       NewService(deps),
-		domainService: domains.NewService(deps, externalAppClient, internalClient),
+		service:        NewService(deps),
+		domainService:  domains.NewService(deps, externalAppClient, internalClient),
+		metricsService: NewMetricsService(deps),
 	}
 }
 
@@ -63,6 +68,26 @@ func (h *HTTP) CreateDemoInstance(w http.ResponseWriter, r *http.Request) (inter
 	return response, nil
 }
 
+// GET /v1/public/instances/{instanceID}/metrics
+func (h *HTTP) ExportMetrics(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	token, apiErr := url.BearerAuthHeader(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	metrics, apiErr := h.metricsService.Export(r.Context(), chi.URLParam(r, "instanceID"), token)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, err := w.Write([]byte(metrics))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return nil, nil
+}
+
 // PATCH /v1/instance/organization_settings
 func (h *HTTP) UpdateOrganizationSettings(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	params := UpdateOrganizationSettingsParams{}
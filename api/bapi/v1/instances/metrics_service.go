@@ -0,0 +1,52 @@
+package instances
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/instance_metrics"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type MetricsService struct {
+	db database.Database
+
+	// services
+	instanceMetricsService *instance_metrics.Service
+
+	// repositories
+	instanceRepo *repository.Instances
+}
+
+func NewMetricsService(deps clerk.Deps) *MetricsService {
+	return &MetricsService{
+		db:                     deps.DB(),
+		instanceMetricsService: instance_metrics.NewService(deps),
+		instanceRepo:           deps.Repositories().Instances,
+	}
+}
+
+// Export returns the metrics of the given instance in the Prometheus text
+// format, provided that token is the metrics token of the instance.
+func (s *MetricsService) Export(ctx context.Context, instanceID, token string) (string, apierror.Error) {
+	instance, err := s.instanceRepo.QueryByID(ctx, s.db, instanceID)
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	// Don't reveal whether the instance exists
+	if instance == nil || !instance_metrics.VerifyToken(instance, token) {
+		return "", apierror.InvalidAuthentication()
+	}
+
+	if apiErr := s.instanceMetricsService.CheckRateLimit(ctx, instance.ID); apiErr != nil {
+		return "", apiErr
+	}
+
+	metrics, err := s.instanceMetricsService.Export(ctx, s.db, instance.ID)
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	return metrics, nil
+}
@@ -5,12 +5,15 @@ import (
 	"math"
 	netURL "net/url"
 	"regexp"
+	"slices"
+	"strconv"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/api/shared/domains"
 	"clerk/api/shared/edgereplication"
 	"clerk/api/shared/organizations"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/validators"
 	"clerk/model"
 	"clerk/model/sqbmodel"
@@ -20,6 +23,7 @@ import (
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/generate"
+	"clerk/pkg/handshakebinding"
 	"clerk/pkg/oauth"
 	"clerk/pkg/oauth/provider"
 	"clerk/pkg/set"
@@ -46,6 +50,7 @@ type Service struct {
 	displayConfigRepo    *repository.DisplayConfig
 	domainRepo           *repository.Domain
 	instanceRepo         *repository.Instances
+	organizationRepo     *repository.Organization
 	permissionRepo       *repository.Permission
 	roleRepo             *repository.Role
 	subscriptionPlanRepo *repository.SubscriptionPlans
@@ -54,24 +59,27 @@ type Service struct {
 	domainService          *domains.Service
 	organizationsService   *organizations.Service
 	edgeReplicationService *edgereplication.Service
+	signingKeyService      *signing_keys.Service
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		db:                   deps.DB(),
 		gueClient:            deps.GueClient(),
-		authConfigRepo:       repository.NewAuthConfig(),
-		displayConfigRepo:    repository.NewDisplayConfig(),
-		domainRepo:           repository.NewDomain(),
-		instanceRepo:         repository.NewInstances(),
-		permissionRepo:       repository.NewPermission(),
-		roleRepo:             repository.NewRole(),
-		subscriptionPlanRepo: repository.NewSubscriptionPlans(),
+		authConfigRepo:       deps.Repositories().AuthConfig,
+		displayConfigRepo:    deps.Repositories().DisplayConfig,
+		domainRepo:           deps.Repositories().Domain,
+		instanceRepo:         deps.Repositories().Instances,
+		organizationRepo:     deps.Repositories().Organization,
+		permissionRepo:       deps.Repositories().Permission,
+		roleRepo:             deps.Repositories().Role,
+		subscriptionPlanRepo: deps.Repositories().SubscriptionPlans,
 		validator:            validator.New(),
 
 		domainService:          domains.NewService(deps),
 		organizationsService:   organizations.NewService(deps),
 		edgeReplicationService: edgereplication.NewService(deps.GueClient(), cenv.GetBool(cenv.FlagReplicateInstanceToEdgeJobsEnabled)),
+		signingKeyService:      signing_keys.NewService(deps),
 	}
 }
 
@@ -88,6 +96,14 @@ type UpdateInstanceParams struct {
 	CookielessDev *bool `json:"cookieless_dev" form:"cookieless_dev"`
 
 	URLBasedSessionSyncing *bool `json:"url_based_session_syncing" form:"url_based_session_syncing"`
+
+	// HandshakeTokenBinding is how strictly handshake tokens are bound to
+	// the device they were issued to. See handshakebinding.Strictnesses.
+	HandshakeTokenBinding *string `json:"handshake_token_binding" form:"handshake_token_binding"`
+
+	// UserDeletionRetentionDays is how long deleted users can be restored
+	// for, before they're purged. Zero deletes users right away.
+	UserDeletionRetentionDays *int `json:"user_deletion_retention_days" form:"user_deletion_retention_days"`
 }
 
 func validateURL(URL string, paramName string) apierror.Error {
@@ -200,6 +216,26 @@ func (s *Service) Update(ctx context.Context, params UpdateInstanceParams) apier
 		authConfigColumns.Insert(sqbmodel.AuthConfigColumns.SessionSettings)
 	}
 
+	if params.HandshakeTokenBinding != nil {
+		if !slices.Contains(handshakebinding.Strictnesses, *params.HandshakeTokenBinding) {
+			return apierror.FormInvalidParameterValue("handshake_token_binding", *params.HandshakeTokenBinding)
+		}
+		env.AuthConfig.SessionSettings.HandshakeTokenBinding = *params.HandshakeTokenBinding
+		authConfigColumns.Insert(sqbmodel.AuthConfigColumns.SessionSettings)
+	}
+
+	if params.UserDeletionRetentionDays != nil {
+		days := *params.UserDeletionRetentionDays
+		if days < 0 {
+			return apierror.FormInvalidParameterValue("user_deletion_retention_days", strconv.Itoa(days))
+		}
+		if days > constants.MaxUserDeletionRetentionDays {
+			return apierror.FormParameterValueTooLarge("user_deletion_retention_days", constants.MaxUserDeletionRetentionDays)
+		}
+		env.Instance.UserDeletionRetentionDays = days
+		instanceColumns.Insert(sqbmodel.InstanceColumns.UserDeletionRetentionDays)
+	}
+
 	if params.DevelopmentOrigin != nil {
 		if env.Instance.IsDevelopment() {
 			err := validateURL(*params.DevelopmentOrigin, "development_origin")
@@ -356,7 +392,11 @@ func (s *Service) CreateDemoInstance(ctx context.Context) (*serialize.DemoDevIns
 		return nil, apierror.Unexpected(txerr)
 	}
 
-	return serialize.DemoDevInstance(stack.Instance, stack.Domain, stack.InstanceKey), nil
+	signingKey, err := s.signingKeyService.Current(ctx, s.db, stack.Instance)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.DemoDevInstance(stack.Instance, stack.Domain, stack.InstanceKey, signingKey), nil
 }
 
 type UpdateOrganizationSettingsParams struct {
@@ -367,6 +407,32 @@ type UpdateOrganizationSettingsParams struct {
 	DomainsEnrollmentModes []string `json:"domains_enrollment_modes" form:"domains_enrollment_modes"`
 	CreatorRoleID          *string  `json:"creator_role_id" form:"creator_role_id"`
 	DomainsDefaultRoleID   *string  `json:"domains_default_role_id" form:"domains_default_role_id"`
+	// InvitationsRequireVerifiedEmail requires users to own a verified email
+	// address matching the invitation in order to accept it.
+	InvitationsRequireVerifiedEmail *bool `json:"invitations_require_verified_email" form:"invitations_require_verified_email"`
+	// InvitationsExpireInDays is how long new invitations stay pending
+	// before they expire. Pass 0 for invitations which never expire.
+	InvitationsExpireInDays *int `json:"invitations_expire_in_days" form:"invitations_expire_in_days" validate:"omitempty,gte=0"`
+	// DeletionGracePeriodDays is how long deleted organizations can be
+	// restored for, before they're purged. Zero deletes organizations right
+	// away.
+	DeletionGracePeriodDays *int `json:"deletion_grace_period_days" form:"deletion_grace_period_days" validate:"omitempty,gte=0"`
+	// MembersOnlyEnabled only allows users who are members of an
+	// organization to sign in.
+	MembersOnlyEnabled *bool `json:"members_only_enabled" form:"members_only_enabled"`
+	// MembersOnlyAllowedOrganizationIDs narrows down the organizations
+	// whose members are allowed to sign in. Pass an empty list to allow
+	// members of any organization.
+	MembersOnlyAllowedOrganizationIDs *[]string `json:"members_only_allowed_organization_ids" form:"members_only_allowed_organization_ids"`
+	// HierarchyInheritDomains makes the verified domains of an organization
+	// also enroll users to the organizations nested under it.
+	HierarchyInheritDomains *bool `json:"hierarchy_inherit_domains" form:"hierarchy_inherit_domains"`
+	// HierarchyInheritRoles gives members of an organization their role in
+	// the organizations nested under it, unless they're members there too.
+	HierarchyInheritRoles *bool `json:"hierarchy_inherit_roles" form:"hierarchy_inherit_roles"`
+	// HierarchyMembershipVisibility controls whether members of an
+	// organization can see the members of the organizations nested under it.
+	HierarchyMembershipVisibility *string `json:"hierarchy_membership_visibility" form:"hierarchy_membership_visibility"`
 }
 
 func (p UpdateOrganizationSettingsParams) validate(validator *validator.Validate) apierror.Error {
@@ -378,12 +444,24 @@ func (p UpdateOrganizationSettingsParams) validate(validator *validator.Validate
 		return apierror.FormParameterValueTooLarge("max_allowed_memberships", *p.MaxAllowedMemberships)
 	}
 
+	if p.InvitationsExpireInDays != nil && *p.InvitationsExpireInDays > organizations.MaxInvitationExpiresInDays {
+		return apierror.FormParameterValueTooLarge("invitations_expire_in_days", organizations.MaxInvitationExpiresInDays)
+	}
+
+	if p.DeletionGracePeriodDays != nil && *p.DeletionGracePeriodDays > organizations.MaxDeletionGracePeriodDays {
+		return apierror.FormParameterValueTooLarge("deletion_grace_period_days", organizations.MaxDeletionGracePeriodDays)
+	}
+
 	for _, mode := range p.DomainsEnrollmentModes {
 		if !constants.OrganizationDomainEnrollmentModes.Contains(mode) {
 			return apierror.FormInvalidParameterValueWithAllowed("domains_enrollment_modes", mode, constants.OrganizationDomainEnrollmentModes.Array())
 		}
 	}
 
+	if p.HierarchyMembershipVisibility != nil && !constants.OrganizationMembershipVisibilities.Contains(*p.HierarchyMembershipVisibility) {
+		return apierror.FormInvalidParameterValueWithAllowed("hierarchy_membership_visibility", *p.HierarchyMembershipVisibility, constants.OrganizationMembershipVisibilities.Array())
+	}
+
 	// If you try to enable the domains feature, you are also required to provide the default role ID
 	if p.DomainsEnabled != nil && *p.DomainsEnabled && (p.DomainsDefaultRoleID == nil || *p.DomainsDefaultRoleID == "") {
 		return apierror.FormMissingConditionalParameterOnExistence("domains_default_role_id", "domains_enabled")
@@ -426,6 +504,43 @@ func (s *Service) UpdateOrganizationSettings(ctx context.Context, params UpdateO
 		}
 	}
 
+	if params.InvitationsRequireVerifiedEmail != nil {
+		authConfig.OrganizationSettings.Invitations.RequireVerifiedEmail = *params.InvitationsRequireVerifiedEmail
+	}
+
+	if params.InvitationsExpireInDays != nil {
+		// invitations which were already sent keep their expiry
+		authConfig.OrganizationSettings.Invitations.ExpiresInDays = nil
+		if *params.InvitationsExpireInDays > 0 {
+			authConfig.OrganizationSettings.Invitations.ExpiresInDays = params.InvitationsExpireInDays
+		}
+	}
+
+	if params.DeletionGracePeriodDays != nil {
+		// organizations which are already pending deletion keep their purge time
+		authConfig.OrganizationSettings.Deletion.GracePeriodDays = *params.DeletionGracePeriodDays
+	}
+
+	if params.MembersOnlyEnabled != nil {
+		authConfig.OrganizationSettings.MembersOnly.Enabled = *params.MembersOnlyEnabled
+	}
+
+	if params.MembersOnlyAllowedOrganizationIDs != nil {
+		authConfig.OrganizationSettings.MembersOnly.AllowedOrganizationIDs = set.New[string](*params.MembersOnlyAllowedOrganizationIDs...).Array()
+	}
+
+	if params.HierarchyInheritDomains != nil {
+		authConfig.OrganizationSettings.Hierarchy.InheritDomains = *params.HierarchyInheritDomains
+	}
+
+	if params.HierarchyInheritRoles != nil {
+		authConfig.OrganizationSettings.Hierarchy.InheritRoles = *params.HierarchyInheritRoles
+	}
+
+	if params.HierarchyMembershipVisibility != nil {
+		authConfig.OrganizationSettings.Hierarchy.MembershipVisibility = *params.HierarchyMembershipVisibility
+	}
+
 	if len(params.DomainsEnrollmentModes) > 0 {
 		// Make sure to also include the default 'manual_invitation' mode always
 		enrollmentModes := set.New(constants.EnrollmentModeManualInvitation)
@@ -501,6 +616,18 @@ func (s *Service) validateUpdateOrganizationSettingsParams(ctx context.Context,
 		}
 	}
 
+	if params.MembersOnlyAllowedOrganizationIDs != nil {
+		for _, organizationID := range *params.MembersOnlyAllowedOrganizationIDs {
+			exists, err := s.organizationRepo.ExistsByIDAndInstance(ctx, s.db, organizationID, env.Instance.ID)
+			if err != nil {
+				return apierror.Unexpected(err)
+			}
+			if !exists {
+				return apierror.FormInvalidParameterValue("members_only_allowed_organization_ids", organizationID)
+			}
+		}
+	}
+
 	if params.CreatorRoleID != nil {
 		return s.validateCreatorRolePermissions(ctx, *params.CreatorRoleID, env.Instance.ID)
 	}
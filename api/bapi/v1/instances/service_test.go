@@ -0,0 +1,43 @@
+package instances
+
+import (
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/organizations"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOrganizationSettingsParamsDeletionGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	tests := []struct {
+		name            string
+		gracePeriodDays int
+		wantCode        string
+	}{
+		{name: "purged right away", gracePeriodDays: 0},
+		{name: "longest grace period", gracePeriodDays: organizations.MaxDeletionGracePeriodDays},
+		{name: "too long", gracePeriodDays: organizations.MaxDeletionGracePeriodDays + 1, wantCode: apierror.FormParameterValueTooLargeCode},
+		{name: "negative", gracePeriodDays: -1, wantCode: apierror.FormParamValueInvalidCode},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			params := UpdateOrganizationSettingsParams{DeletionGracePeriodDays: &tt.gracePeriodDays}
+			apiErr := params.validate(v)
+			if tt.wantCode == "" {
+				assert.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			assert.Equal(t, tt.wantCode, apiErr.Errors()[0].Code())
+		})
+	}
+}
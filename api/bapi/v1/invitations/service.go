@@ -49,7 +49,7 @@ func NewService(deps clerk.Deps) *Service {
 		comms:           comms.NewService(deps),
 		invitations:     invitations.NewService(deps),
 		validators:      validators.NewService(),
-		invitationsRepo: repository.NewInvitations(),
+		invitationsRepo: deps.Repositories().Invitations,
 	}
 }
 
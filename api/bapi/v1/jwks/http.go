@@ -4,19 +4,28 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/signing_keys"
 	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
 )
 
-type HTTP struct{}
+type HTTP struct {
+	db                database.Database
+	signingKeyService *signing_keys.Service
+}
 
-func NewHTTP() *HTTP {
-	return &HTTP{}
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		db:                deps.DB(),
+		signingKeyService: signing_keys.NewService(deps),
+	}
 }
 
 func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	env := environment.FromContext(r.Context())
 
-	jwks, err := env.Instance.JWKS()
+	jwks, err := h.signingKeyService.JWKS(r.Context(), h.db, env.Instance)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
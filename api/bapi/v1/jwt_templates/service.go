@@ -3,6 +3,7 @@ package jwt_templates
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/jwt"
+	"clerk/pkg/scopedtokens"
 	"clerk/pkg/set"
 	cstrings "clerk/pkg/strings"
 	"clerk/repository"
@@ -38,6 +40,11 @@ var (
 const claimsMaxSizeInBytes = 2048
 const reservedNamePrefix = "integration_"
 
+const (
+	paramAllowedScopes = "allowed_scopes"
+	paramAllowedClaims = "allowed_claims"
+)
+
 type Service struct {
 	db        database.Database
 	clock     clockwork.Clock
@@ -70,6 +77,11 @@ type CreateUpdateParams struct {
 	CustomSigningKey bool            `json:"custom_signing_key" form:"custom_signing_key"`
 	SigningKey       *string         `json:"signing_key" form:"signing_key" validate:"omitempty,required_if=CustomSigningKey true"`
 	SigningAlgorithm *string         `json:"signing_algorithm" form:"signing_algorithm" validate:"omitempty,required_if=CustomSigningKey true"`
+	// AllowedScopes and AllowedClaims are what the scoped tokens minted
+	// from the template may carry. Templates without allowed scopes can't
+	// mint scoped tokens.
+	AllowedScopes []string `json:"allowed_scopes" form:"allowed_scopes"`
+	AllowedClaims []string `json:"allowed_claims" form:"allowed_claims"`
 }
 
 func (params CreateUpdateParams) validate(validator *validator.Validate) apierror.Error {
@@ -109,6 +121,18 @@ func (params CreateUpdateParams) validate(validator *validator.Validate) apierro
 		}
 	}
 
+	allowlist := scopedtokens.Allowlist{Scopes: params.AllowedScopes, Claims: params.AllowedClaims}
+	if err := allowlist.Validate(); err != nil {
+		var rejection *scopedtokens.RejectionError
+		if !errors.As(err, &rejection) {
+			return apierror.Unexpected(err)
+		}
+		if errors.Is(err, scopedtokens.ErrReservedClaim) {
+			return apierror.JWTTemplateReservedClaim(paramAllowedClaims, rejection.Value)
+		}
+		return apierror.FormInvalidParameterValue(paramAllowedScopes, rejection.Value)
+	}
+
 	// ensure that someone can't use 'clerk' as audience value
 	aud, ok := claims["aud"]
 	if !ok {
@@ -211,6 +235,8 @@ func (s *Service) Create(ctx context.Context, params CreateUpdateParams) (*seria
 		Claims:           types.JSON(params.Claims),
 		SigningAlgorithm: instance.KeyAlgorithm,
 		SigningKey:       null.NewString("", false),
+		AllowedScopes:    types.StringArray(params.AllowedScopes),
+		AllowedClaims:    types.StringArray(params.AllowedClaims),
 	}}
 
 	if params.Lifetime != nil {
@@ -288,6 +314,8 @@ func (s *Service) Update(ctx context.Context, templateID string, params CreateUp
 
 	tmpl.Name = params.Name
 	tmpl.Claims = types.JSON(params.Claims)
+	tmpl.AllowedScopes = types.StringArray(params.AllowedScopes)
+	tmpl.AllowedClaims = types.StringArray(params.AllowedClaims)
 
 	if params.Lifetime != nil {
 		tmpl.Lifetime = *params.Lifetime
@@ -2,11 +2,14 @@ package messaging
 
 import (
 	"errors"
+	"io"
 	"net/http"
 
 	"clerk/api/apierror"
 	"clerk/pkg/constants"
 	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
 )
 
 var (
@@ -42,3 +45,17 @@ func (h *HTTP) TwilioSMSStatusCallback(_ http.ResponseWriter, r *http.Request) (
 
 	return nil, h.service.TwilioSMSStatusCallback(ctx, params, signature, traceIDEncoded)
 }
+
+// POST /v1/events/email_status/{provider}
+func (h *HTTP) EmailStatusCallback(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	// Protects against a malicious client streaming us an endless request body
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	provider := chi.URLParam(r, "provider")
+	token := r.URL.Query().Get("token")
+	return nil, h.service.EmailStatusCallback(r.Context(), provider, token, body)
+}
@@ -2,12 +2,15 @@ package messaging
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/emails"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/constants"
+	"clerk/pkg/emailprovider"
 	"clerk/pkg/externalapis/twilio"
 	"clerk/pkg/jobs"
 	clerksentry "clerk/pkg/sentry"
@@ -30,6 +33,9 @@ type Service struct {
 	gueClient *gue.Client
 	validator *validator.Validate
 
+	// services
+	emailService *emails.Service
+
 	// repositories
 	instanceRepo       *repository.Instances
 	smsCountryTierRepo *repository.SMSCountryTiers
@@ -43,9 +49,11 @@ func NewService(deps clerk.Deps) *Service {
 		gueClient: deps.GueClient(),
 		validator: validator.New(),
 
-		instanceRepo:       repository.NewInstances(),
-		smsCountryTierRepo: repository.NewSMSCountryTiers(),
-		smsMessageRepo:     repository.NewSMSMessage(),
+		emailService: emails.NewService(deps),
+
+		instanceRepo:       deps.Repositories().Instances,
+		smsCountryTierRepo: deps.Repositories().SMSCountryTiers,
+		smsMessageRepo:     deps.Repositories().SMSMessage,
 	}
 }
 
@@ -195,3 +203,16 @@ func (s *Service) reportUsage(ctx context.Context, tx database.Tx, smsMessage *m
 		Day:          s.clock.Now().UTC(),
 	}, jobs.WithTx(tx))
 }
+
+// EmailStatusCallback records the delivery events of a status webhook of an
+// email provider against the emails they're about.
+func (s *Service) EmailStatusCallback(ctx context.Context, provider, token string, body []byte) apierror.Error {
+	err := s.emailService.RecordDeliveryStatus(ctx, provider, token, body)
+	if errors.Is(err, emails.ErrInvalidWebhookToken) || errors.Is(err, emailprovider.ErrUnknownProvider) {
+		return apierror.ResourceNotFound()
+	} else if err != nil {
+		clerksentry.CaptureException(ctx, fmt.Errorf("emails/status_callback: %s: %w", provider, err))
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
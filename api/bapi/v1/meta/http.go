@@ -0,0 +1,25 @@
+package meta
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+)
+
+type HTTP struct{}
+
+func NewHTTP() *HTTP {
+	return &HTTP{}
+}
+
+// GET /v1/meta/errors
+func (h *HTTP) Errors(_ http.ResponseWriter, _ *http.Request) (interface{}, apierror.Error) {
+	entries := apierror.Catalog()
+
+	responseData := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		responseData[i] = serialize.ErrorCatalogEntry(entry)
+	}
+	return serialize.Paginated(responseData, int64(len(entries))), nil
+}
@@ -6,6 +6,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/clerkhttp"
+	"clerk/pkg/readreplica"
 	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
@@ -15,9 +16,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(deps clerk.Deps) *HTTP {
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
 	return &HTTP{
-		service: NewService(deps),
+		service: NewService(deps, db),
 	}
 }
 
@@ -2,49 +2,50 @@ package oauth_applications
 
 import (
 	"context"
-	"net/url"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/oauth_applications"
 	"clerk/api/shared/pagination"
-	"clerk/model"
-	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
-	"clerk/pkg/hash"
-	"clerk/pkg/oauth2idp"
-	"clerk/pkg/rand"
+	"clerk/pkg/readreplica"
 	"clerk/repository"
 	"clerk/utils/clerk"
-	"clerk/utils/database"
 
 	"github.com/go-playground/validator/v10"
 )
 
 const (
 	paramCallbackURL = "callback_url"
+	paramScopes      = "scopes"
 )
 
 type Service struct {
-	db        database.Database
+	db        *readreplica.Database
 	validator *validator.Validate
 
+	// services
+	oauthApplicationService *oauth_applications.Service
+
 	// repositories
 	oauthApplicationsRepo *repository.OAuthApplications
 }
 
-func NewService(deps clerk.Deps) *Service {
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
 	return &Service{
-		db:                    deps.DB(),
-		validator:             validator.New(),
-		oauthApplicationsRepo: repository.NewOAuthApplications(),
+		db:                      db,
+		validator:               validator.New(),
+		oauthApplicationService: oauth_applications.NewService(deps),
+		oauthApplicationsRepo:   deps.Repositories().OAuthApplications,
 	}
 }
 
 type CreateParams struct {
-	Name        string `json:"name" form:"name" validate:"required,max=256"`
-	CallbackURL string `json:"callback_url" form:"callback_url" validate:"required,max=1024"`
-	Public      *bool  `json:"public" form:"public"`
-	Scopes      string `json:"scopes" form:"scopes" validate:"max=1024"`
+	Name                 string `json:"name" form:"name" validate:"required,max=256"`
+	CallbackURL          string `json:"callback_url" form:"callback_url" validate:"required,max=1024"`
+	Public               *bool  `json:"public" form:"public"`
+	Scopes               string `json:"scopes" form:"scopes" validate:"max=1024"`
+	ConsentScreenEnabled *bool  `json:"consent_screen_enabled" form:"consent_screen_enabled"`
 }
 
 func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
@@ -52,16 +53,10 @@ func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
 		return apierror.FormValidationFailed(err)
 	}
 
-	url, err := url.ParseRequestURI(p.CallbackURL)
-	if err != nil || url.Scheme == "" || url.Host == "" {
-		return apierror.FormInvalidTypeParameter(paramCallbackURL, "valid url")
-	}
-
-	if _, err := oauth2idp.ParseScopes(p.Scopes, oauth2idp.FormattedAvailableScopes()); err != nil {
-		return apierror.FormInvalidParameterValueWithAllowed("scopes", p.Scopes, oauth2idp.AvailableScopes.Array())
+	if err := oauth_applications.ValidateCallbackURL(paramCallbackURL, p.CallbackURL); err != nil {
+		return err
 	}
-
-	return nil
+	return oauth_applications.ValidateScopes(paramScopes, p.Scopes)
 }
 
 func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.OAuthApplicationResponse, apierror.Error) {
@@ -69,35 +64,14 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.O
 		return nil, err
 	}
 
-	clientID, err := rand.AlphanumExtended(16)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
-	clientSecret, err := rand.AlphanumExtended(32)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
-	clientSecretHash, err := hash.GenerateBcryptHash(clientSecret)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
-	scopes, _ := oauth2idp.ParseScopes(params.Scopes, oauth2idp.FormattedAvailableScopes())
-
 	env := environment.FromContext(ctx)
-	oauthApplication := &model.OAuthApplication{
-		OauthApplication: &sqbmodel.OauthApplication{
-			InstanceID:       env.Instance.ID,
-			Name:             params.Name,
-			ClientID:         clientID,
-			ClientSecretHash: clientSecretHash,
-			CallbackURL:      params.CallbackURL,
-			Scopes:           scopes,
-			Public:           params.Public != nil && *params.Public,
-		},
-		ClientSecret: clientSecret,
-	}
-
-	err = s.oauthApplicationsRepo.Insert(ctx, s.db, oauthApplication)
+	oauthApplication, err := s.oauthApplicationService.Create(ctx, s.db, env.Instance.ID, oauth_applications.CreateParams{
+		Name:                 params.Name,
+		CallbackURL:          params.CallbackURL,
+		Scopes:               params.Scopes,
+		Public:               params.Public != nil && *params.Public,
+		ConsentScreenEnabled: params.ConsentScreenEnabled != nil && *params.ConsentScreenEnabled,
+	})
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -118,9 +92,10 @@ func (s *Service) Read(ctx context.Context, oauthApplicationID string) (*seriali
 }
 
 type UpdateParams struct {
-	Name        string `json:"name" form:"name" validate:"max=256"`
-	CallbackURL string `json:"callback_url" form:"callback_url" validate:"max=1024"`
-	Scopes      string `json:"scopes" form:"scopes" validate:"max=1024"`
+	Name                 string `json:"name" form:"name" validate:"max=256"`
+	CallbackURL          string `json:"callback_url" form:"callback_url" validate:"max=1024"`
+	Scopes               string `json:"scopes" form:"scopes" validate:"max=1024"`
+	ConsentScreenEnabled *bool  `json:"consent_screen_enabled" form:"consent_screen_enabled"`
 }
 
 func (p UpdateParams) validate(validator *validator.Validate) apierror.Error {
@@ -129,17 +104,11 @@ func (p UpdateParams) validate(validator *validator.Validate) apierror.Error {
 	}
 
 	if p.CallbackURL != "" {
-		url, err := url.ParseRequestURI(p.CallbackURL)
-		if err != nil || url.Scheme == "" || url.Host == "" {
-			return apierror.FormInvalidTypeParameter(paramCallbackURL, "valid url")
+		if err := oauth_applications.ValidateCallbackURL(paramCallbackURL, p.CallbackURL); err != nil {
+			return err
 		}
 	}
-
-	if _, err := oauth2idp.ParseScopes(p.Scopes, oauth2idp.FormattedAvailableScopes()); err != nil {
-		return apierror.FormInvalidParameterValueWithAllowed("scopes", p.Scopes, oauth2idp.AvailableScopes.Array())
-	}
-
-	return nil
+	return oauth_applications.ValidateScopes(paramScopes, p.Scopes)
 }
 
 func (s *Service) Update(ctx context.Context, oauthApplicationID string, params UpdateParams) (*serialize.OAuthApplicationResponse, apierror.Error) {
@@ -156,27 +125,12 @@ func (s *Service) Update(ctx context.Context, oauthApplicationID string, params
 		return nil, apierror.ResourceNotFound()
 	}
 
-	updatedColumns := []string{}
-
-	if params.Name != "" {
-		oa.Name = params.Name
-		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.Name)
-	}
-	if params.CallbackURL != "" {
-		oa.CallbackURL = params.CallbackURL
-		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.CallbackURL)
-	}
-	if params.Scopes != "" {
-		oa.Scopes, _ = oauth2idp.ParseScopes(params.Scopes, oauth2idp.FormattedAvailableScopes())
-		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.Scopes)
-	}
-
-	err = s.oauthApplicationsRepo.Update(
-		ctx,
-		s.db,
-		oa,
-		updatedColumns...,
-	)
+	err = s.oauthApplicationService.Update(ctx, s.db, oa, oauth_applications.UpdateParams{
+		Name:                 params.Name,
+		CallbackURL:          params.CallbackURL,
+		Scopes:               params.Scopes,
+		ConsentScreenEnabled: params.ConsentScreenEnabled,
+	})
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -200,12 +154,13 @@ func (s *Service) Delete(ctx context.Context, oauthApplicationID string) (*seria
 
 func (s *Service) List(ctx context.Context, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
-	oauthApplications, err := s.oauthApplicationsRepo.FindAllByInstance(ctx, s.db, env.Instance.ID, paginationParams)
+	db := s.db.ReadReplica()
+	oauthApplications, err := s.oauthApplicationsRepo.FindAllByInstance(ctx, db, env.Instance.ID, paginationParams)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
-	totalCount, err := s.oauthApplicationsRepo.CountByInstance(ctx, s.db, env.Instance.ID)
+	totalCount, err := s.oauthApplicationsRepo.CountByInstance(ctx, db, env.Instance.ID)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -228,18 +183,7 @@ func (s *Service) RotateSecret(ctx context.Context, oauthApplicationID string) (
 		return nil, apierror.ResourceNotFound()
 	}
 
-	clientSecret, err := rand.AlphanumExtended(32)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
-	clientSecretHash, err := hash.GenerateBcryptHash(clientSecret)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
-	oa.ClientSecretHash = clientSecretHash
-	oa.ClientSecret = clientSecret
-
-	err = s.oauthApplicationsRepo.Update(ctx, s.db, oa, sqbmodel.OauthApplicationColumns.ClientSecretHash)
+	err = s.oauthApplicationService.RotateSecret(ctx, s.db, oa)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
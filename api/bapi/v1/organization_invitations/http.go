@@ -4,9 +4,11 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/dryrun"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/constants"
+	"clerk/pkg/readreplica"
 	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
@@ -16,9 +18,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(deps clerk.Deps) *HTTP {
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
 	return &HTTP{
-		service: NewService(deps),
+		service: NewService(deps, db),
 	}
 }
 
@@ -41,6 +43,32 @@ func (h *HTTP) CreateBulk(_ http.ResponseWriter, r *http.Request) (any, apierror
 	return h.service.CreateBulk(r.Context(), chi.URLParam(r, "organizationID"), params)
 }
 
+// POST /v1/organizations/{organizationID}/invitations/bulk_revoke
+func (h *HTTP) BulkRevoke(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	dryRun, err := dryrun.FromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := BulkRevokeParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return h.service.BulkRevokeDryRun(r.Context(), chi.URLParam(r, "organizationID"), params)
+	}
+	return h.service.BulkRevoke(r.Context(), chi.URLParam(r, "organizationID"), params)
+}
+
+// POST /v1/organizations/{organizationID}/invitations/bulk_resend
+func (h *HTTP) BulkResend(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	params := BulkResendParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+	return h.service.BulkResend(r.Context(), chi.URLParam(r, "organizationID"), params)
+}
+
 // GET /v1/organizations/{organizationID}/invitations
 func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	paginationParams, err := pagination.NewFromRequest(r)
@@ -48,9 +76,15 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
+	keyset, err := pagination.KeysetFromRequest(r, paginationParams.Limit)
+	if err != nil {
+		return nil, err
+	}
+
 	params := ListParams{
 		OrganizationID: chi.URLParam(r, "organizationID"),
 		Statuses:       r.URL.Query()["status"],
+		keyset:         keyset,
 	}
 	return h.service.List(r.Context(), params, paginationParams)
 }
@@ -75,6 +109,18 @@ func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	return h.service.Read(r.Context(), chi.URLParam(r, "organizationID"), chi.URLParam(r, "invitationID"))
 }
 
+// POST /v1/organizations/{organizationID}/invitations/{invitationID}/extend
+func (h *HTTP) Extend(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	params := ExtendParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+	params.OrganizationID = chi.URLParam(r, "organizationID")
+	params.InvitationID = chi.URLParam(r, "invitationID")
+
+	return h.service.Extend(r.Context(), params)
+}
+
 // POST /v1/organizations/{organizationID}/invitations/{invitationID}/revoke
 func (h *HTTP) Revoke(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	params := RevokeParams{}
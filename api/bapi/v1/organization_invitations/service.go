@@ -12,6 +12,7 @@ import (
 	"clerk/pkg/clerkerrors"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/readreplica"
 	"clerk/pkg/set"
 	"clerk/repository"
 	"clerk/utils/clerk"
@@ -21,7 +22,7 @@ import (
 )
 
 type Service struct {
-	db        database.Database
+	db        *readreplica.Database
 	validator *validator.Validate
 
 	// services
@@ -33,14 +34,14 @@ type Service struct {
 	userRepo                    *repository.Users
 }
 
-func NewService(deps clerk.Deps) *Service {
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
 	return &Service{
-		db:                          deps.DB(),
+		db:                          db,
 		validator:                   validator.New(),
 		organizationsService:        organizations.NewService(deps),
-		organizationsRepo:           repository.NewOrganization(),
-		organizationInvitationsRepo: repository.NewOrganizationInvitation(),
-		userRepo:                    repository.NewUsers(),
+		organizationsRepo:           deps.Repositories().Organization,
+		organizationInvitationsRepo: deps.Repositories().OrganizationInvitation,
+		userRepo:                    deps.Repositories().Users,
 	}
 }
 
@@ -161,16 +162,13 @@ func (s *Service) CreateBulk(ctx context.Context, organizationID string, params
 		return nil, apierror.Unexpected(txErr)
 	}
 
-	paginated := make([]any, len(invitations))
-	for i, invitation := range invitations {
-		paginated[i] = serialize.OrganizationInvitationBAPI(invitation)
-	}
-	return serialize.Paginated(paginated, int64(len(paginated))), nil
+	return toPaginated(invitations), nil
 }
 
 type ListParams struct {
 	OrganizationID string   `json:"-" form:"-"`
 	Statuses       []string `json:"-" form:"-"`
+	keyset         *pagination.KeysetParams
 }
 
 func (p ListParams) validate() apierror.Error {
@@ -189,12 +187,19 @@ func (s *Service) List(ctx context.Context, params ListParams, paginationParams
 		return nil, apiErr
 	}
 
-	invitations, apiErr := s.organizationsService.ListInvitations(ctx, s.db, env.Instance.ID, params.OrganizationID, params.Statuses, paginationParams)
+	db := s.db.ReadReplica()
+	var invitations []*model.OrganizationInvitationSerializable
+	var apiErr apierror.Error
+	if params.keyset != nil {
+		invitations, apiErr = s.organizationsService.ListInvitationsAfter(ctx, db, env.Instance.ID, params.OrganizationID, params.Statuses, *params.keyset)
+	} else {
+		invitations, apiErr = s.organizationsService.ListInvitations(ctx, db, env.Instance.ID, params.OrganizationID, params.Statuses, paginationParams)
+	}
 	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	totalCount, err := s.organizationInvitationsRepo.CountNonOrgDomainByOrganizationAndStatus(ctx, s.db, params.OrganizationID, params.Statuses)
+	totalCount, err := s.organizationInvitationsRepo.CountNonOrgDomainByOrganizationAndStatus(ctx, db, params.OrganizationID, params.Statuses)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -203,6 +208,16 @@ func (s *Service) List(ctx context.Context, params ListParams, paginationParams
 	for i, invitation := range invitations {
 		responseData[i] = serialize.OrganizationInvitationBAPI(invitation)
 	}
+
+	if params.keyset != nil {
+		var nextCursor *string
+		if len(invitations) > 0 {
+			last := invitations[len(invitations)-1]
+			nextCursor = params.keyset.NextCursor(len(invitations), last.OrganizationInvitation.CreatedAt, last.OrganizationInvitation.ID)
+		}
+		return serialize.CursorPaginated(responseData, totalCount, nextCursor), nil
+	}
+
 	return serialize.Paginated(responseData, totalCount), nil
 }
 
@@ -259,3 +274,167 @@ func (s *Service) Revoke(ctx context.Context, params RevokeParams) (*serialize.O
 
 	return serialize.OrganizationInvitationBAPI(invitation), nil
 }
+
+type ExtendParams struct {
+	// ExpiresInDays is counted from now. It defaults to how long
+	// invitations last in the instance.
+	ExpiresInDays  *int   `json:"expires_in_days" form:"expires_in_days" validate:"omitempty,gte=1"`
+	OrganizationID string `json:"-"`
+	InvitationID   string `json:"-"`
+}
+
+func (p *ExtendParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	if p.ExpiresInDays != nil && *p.ExpiresInDays > organizations.MaxInvitationExpiresInDays {
+		return apierror.FormParameterValueTooLarge("expires_in_days", organizations.MaxInvitationExpiresInDays)
+	}
+	return nil
+}
+
+// Extend pushes back the expiry of a pending invitation.
+func (s *Service) Extend(ctx context.Context, params ExtendParams) (*serialize.OrganizationInvitationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	invitation, apiErr := s.organizationsService.ExtendInvitation(
+		ctx,
+		s.db,
+		organizations.ExtendInvitationParams{
+			OrganizationID: params.OrganizationID,
+			InvitationID:   params.InvitationID,
+			ExpiresInDays:  params.ExpiresInDays,
+		},
+		env.AuthConfig.OrganizationSettings,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return serialize.OrganizationInvitationBAPI(invitation), nil
+}
+
+type BulkRevokeParams struct {
+	InvitationIDs    []string `json:"invitation_ids" form:"invitation_ids" validate:"required,min=1"`
+	RequestingUserID string   `json:"requesting_user_id" form:"requesting_user_id" validate:"required"`
+}
+
+func (p *BulkRevokeParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	return nil
+}
+
+func (s *Service) BulkRevoke(ctx context.Context, organizationID string, params BulkRevokeParams) (*serialize.PaginatedResponse, apierror.Error) {
+	invitations, apiErr := s.bulkRevoke(ctx, organizationID, params, false)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return toPaginated(invitations), nil
+}
+
+// BulkRevokeDryRun goes through the revocation of the invitations without
+// revoking them, and returns what would have been revoked.
+func (s *Service) BulkRevokeDryRun(ctx context.Context, organizationID string, params BulkRevokeParams) (*serialize.DryRunResponse, apierror.Error) {
+	invitations, apiErr := s.bulkRevoke(ctx, organizationID, params, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.DryRun(toPaginated(invitations), map[string]int64{
+		"organization_invitations": int64(len(invitations)),
+	}), nil
+}
+
+func (s *Service) bulkRevoke(ctx context.Context, organizationID string, params BulkRevokeParams, dryRun bool) ([]*model.OrganizationInvitationSerializable, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	var invitations []*model.OrganizationInvitationSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		invitations, err = s.organizationsService.RevokeInvitations(
+			ctx,
+			tx,
+			organizations.BulkInvitationsParams{
+				OrganizationID:   organizationID,
+				InvitationIDs:    params.InvitationIDs,
+				RequestingUserID: params.RequestingUserID,
+			},
+			env.Instance,
+		)
+		if err != nil {
+			return true, err
+		}
+		return dryRun, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return invitations, nil
+}
+
+type BulkResendParams struct {
+	InvitationIDs    []string `json:"invitation_ids" form:"invitation_ids" validate:"required,min=1"`
+	RequestingUserID string   `json:"requesting_user_id" form:"requesting_user_id" validate:"required"`
+	RedirectURL      *string  `json:"redirect_url" form:"redirect_url"`
+}
+
+func (p *BulkResendParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	return nil
+}
+
+func (s *Service) BulkResend(ctx context.Context, organizationID string, params BulkResendParams) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	var invitations []*model.OrganizationInvitationSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		invitations, err = s.organizationsService.ResendInvitations(
+			ctx,
+			tx,
+			organizations.BulkInvitationsParams{
+				OrganizationID:   organizationID,
+				InvitationIDs:    params.InvitationIDs,
+				RequestingUserID: params.RequestingUserID,
+			},
+			params.RedirectURL,
+			env,
+		)
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return toPaginated(invitations), nil
+}
+
+func toPaginated(invitations []*model.OrganizationInvitationSerializable) *serialize.PaginatedResponse {
+	paginated := make([]any, len(invitations))
+	for i, invitation := range invitations {
+		paginated[i] = serialize.OrganizationInvitationBAPI(invitation)
+	}
+	return serialize.Paginated(paginated, int64(len(paginated)))
+}
@@ -0,0 +1,43 @@
+package organization_invitations
+
+import (
+	"testing"
+
+	"clerk/api/apierror"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkRevokeParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	assert.Nil(t, (&BulkRevokeParams{InvitationIDs: []string{"orginv_1"}, RequestingUserID: "user_1"}).validate(v))
+
+	for _, params := range []BulkRevokeParams{
+		{RequestingUserID: "user_1"},
+		{InvitationIDs: []string{"orginv_1"}},
+	} {
+		apiErr := params.validate(v)
+		require.NotNil(t, apiErr)
+		assert.Equal(t, apierror.FormParamMissingCode, apiErr.Errors()[0].Code())
+	}
+
+	// an empty list is given, but is still not valid
+	apiErr := (&BulkRevokeParams{InvitationIDs: []string{}, RequestingUserID: "user_1"}).validate(v)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestBulkResendParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	assert.Nil(t, (&BulkResendParams{InvitationIDs: []string{"orginv_1"}, RequestingUserID: "user_1"}).validate(v))
+
+	apiErr := (&BulkResendParams{RequestingUserID: "user_1"}).validate(v)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamMissingCode, apiErr.Errors()[0].Code())
+}
@@ -0,0 +1,61 @@
+package organization_membership_requests
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/clerkhttp"
+	"clerk/pkg/readreplica"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
+	return &HTTP{
+		service: NewService(deps, db),
+	}
+}
+
+// GET /v1/organizations/{organizationID}/membership_requests
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := ListParams{
+		OrganizationID: chi.URLParam(r, "organizationID"),
+		Statuses:       r.URL.Query()["status"],
+	}
+	return h.service.List(r.Context(), params, paginationParams)
+}
+
+// POST /v1/organizations/{organizationID}/membership_requests/{requestID}/accept
+func (h *HTTP) Accept(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := ReviewParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+	params.OrganizationID = chi.URLParam(r, "organizationID")
+	params.RequestID = chi.URLParam(r, "requestID")
+
+	return h.service.Accept(r.Context(), params)
+}
+
+// POST /v1/organizations/{organizationID}/membership_requests/{requestID}/reject
+func (h *HTTP) Reject(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := ReviewParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+	params.OrganizationID = chi.URLParam(r, "organizationID")
+	params.RequestID = chi.URLParam(r, "requestID")
+
+	return h.service.Reject(r.Context(), params)
+}
@@ -0,0 +1,117 @@
+package organization_membership_requests
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/organization_membership_requests"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/readreplica"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type Service struct {
+	db *readreplica.Database
+
+	// services
+	membershipRequestsService *organization_membership_requests.Service
+}
+
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
+	return &Service{
+		db:                        db,
+		membershipRequestsService: organization_membership_requests.NewService(deps),
+	}
+}
+
+type ListParams struct {
+	OrganizationID string
+	Statuses       []string
+}
+
+func (s *Service) List(ctx context.Context, params ListParams, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	membershipRequests, count, apiErr := s.membershipRequestsService.List(ctx, s.db.ReadReplica(), organization_membership_requests.ListParams{
+		OrganizationID: params.OrganizationID,
+		Statuses:       params.Statuses,
+	}, paginationParams)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	response := make([]interface{}, len(membershipRequests))
+	for i, membershipRequest := range membershipRequests {
+		response[i] = serialize.OrganizationMembershipRequest(membershipRequest)
+	}
+	return serialize.Paginated(response, count), nil
+}
+
+type ReviewParams struct {
+	// RequestingUserID is the member on whose behalf the request is
+	// reviewed. If given, they need to be able to manage the members of the
+	// organization.
+	RequestingUserID *string `json:"requesting_user_id" form:"requesting_user_id"`
+	OrganizationID   string  `json:"-"`
+	RequestID        string  `json:"-"`
+}
+
+func (p ReviewParams) toReviewParams() organization_membership_requests.ReviewParams {
+	params := organization_membership_requests.ReviewParams{
+		OrganizationID: p.OrganizationID,
+		RequestID:      p.RequestID,
+	}
+	if p.RequestingUserID != nil {
+		params.RequestingUserID = *p.RequestingUserID
+	}
+	return params
+}
+
+// Accept approves the pending membership request, which adds the user who
+// made it to the organization.
+func (s *Service) Accept(ctx context.Context, params ReviewParams) (*serialize.OrganizationMembershipRequestResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var membershipRequest *model.OrganizationMembershipRequestSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var apiErr apierror.Error
+		membershipRequest, apiErr = s.membershipRequestsService.Accept(ctx, tx, env, params.toReviewParams())
+		if apiErr != nil {
+			return true, apiErr
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.OrganizationMembershipRequest(membershipRequest), nil
+}
+
+// Reject rejects the pending membership request.
+func (s *Service) Reject(ctx context.Context, params ReviewParams) (*serialize.OrganizationMembershipRequestResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var membershipRequest *model.OrganizationMembershipRequestSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var apiErr apierror.Error
+		membershipRequest, apiErr = s.membershipRequestsService.Reject(ctx, tx, env, params.toReviewParams())
+		if apiErr != nil {
+			return true, apiErr
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.OrganizationMembershipRequest(membershipRequest), nil
+}
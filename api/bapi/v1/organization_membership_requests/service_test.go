@@ -0,0 +1,23 @@
+package organization_membership_requests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToReviewParams(t *testing.T) {
+	t.Parallel()
+
+	params := ReviewParams{OrganizationID: "org_1", RequestID: "orgreq_1"}
+	reviewParams := params.toReviewParams()
+	assert.Equal(t, "org_1", reviewParams.OrganizationID)
+	assert.Equal(t, "orgreq_1", reviewParams.RequestID)
+	// without a requesting user, the request is reviewed on behalf of the
+	// instance
+	assert.Empty(t, reviewParams.RequestingUserID)
+
+	requestingUserID := "user_1"
+	params.RequestingUserID = &requestingUserID
+	assert.Equal(t, "user_1", params.toReviewParams().RequestingUserID)
+}
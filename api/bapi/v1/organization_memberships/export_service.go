@@ -0,0 +1,270 @@
+package organization_memberships
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/phone_profiles"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/jobs"
+	clerktime "clerk/pkg/time"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/database"
+	"clerk/utils/validate"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Statuses of a membership export.
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// exportDownloadURLLifetime is for how long the download URL of a completed
+// export is valid. A new URL is signed every time the export is read.
+const exportDownloadURLLifetime = 15 * time.Minute
+
+// exportEmailDownloadURLLifetime is for how long the download URL which is
+// sent by email is valid. It can't be signed again when the email is
+// opened, so it outlives the ones returned by the API.
+const exportEmailDownloadURLLifetime = 24 * time.Hour
+
+// exportMembershipsPageSize is the number of memberships that are loaded at
+// once while writing an export.
+const exportMembershipsPageSize = 500
+
+// exportCSVHeader are the columns of the exported CSV file.
+var exportCSVHeader = []string{"user_id", "identifier", "role", "joined_at"}
+
+// ExportParams are the options of a membership export.
+type ExportParams struct {
+	// NotifyEmailAddress receives an email with a link to the export once
+	// it has completed. The organizationMembershipExport.completed webhook
+	// is sent either way.
+	NotifyEmailAddress *string `json:"notify_email_address" form:"notify_email_address"`
+}
+
+func (p ExportParams) validate() apierror.Error {
+	if p.NotifyEmailAddress == nil {
+		return nil
+	}
+	return validate.EmailAddress(*p.NotifyEmailAddress, "notify_email_address")
+}
+
+// CreateExport enqueues the job which writes all the memberships of the
+// organization into a CSV file. Listing the memberships of organizations
+// with that many members takes longer than a request is allowed to.
+func (s *Service) CreateExport(ctx context.Context, organizationID string, params ExportParams) (*serialize.OrganizationMembershipExportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	export := &model.OrganizationMembershipExport{OrganizationMembershipExport: &sqbmodel.OrganizationMembershipExport{
+		InstanceID:         env.Instance.ID,
+		OrganizationID:     organizationID,
+		Status:             ExportStatusPending,
+		NotifyEmailAddress: null.StringFromPtr(params.NotifyEmailAddress),
+	}}
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if err := s.exportRepo.Insert(ctx, tx, export); err != nil {
+			return true, err
+		}
+
+		err := jobs.ExportOrganizationMemberships(ctx, s.gueClient, jobs.ExportOrganizationMembershipsArgs{
+			OrganizationMembershipExportID: export.ID,
+		}, jobs.WithTx(tx))
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.OrganizationMembershipExport(export, "", 0), nil
+}
+
+// ReadExport returns the progress of a membership export of the
+// organization and, once it has completed, a signed URL to download it from.
+func (s *Service) ReadExport(ctx context.Context, organizationID, exportID string) (*serialize.OrganizationMembershipExportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	export, err := s.exportRepo.QueryByIDAndInstance(ctx, s.db, exportID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if export == nil || export.OrganizationID != organizationID {
+		return nil, apierror.OrganizationMembershipExportNotFound(exportID)
+	}
+
+	if export.Status != ExportStatusCompleted {
+		return serialize.OrganizationMembershipExport(export, "", 0), nil
+	}
+
+	expireAt := s.clock.Now().UTC().Add(exportDownloadURLLifetime)
+	downloadURL, err := s.storage.SignedURL(ctx, export.StoragePath.String, expireAt)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.OrganizationMembershipExport(export, downloadURL, clerktime.UnixMilli(expireAt)), nil
+}
+
+// ProcessExport writes the memberships of the organization of the export
+// into a CSV file on storage and notifies that it's ready. It is run by the
+// export_organization_memberships job.
+func (s *Service) ProcessExport(ctx context.Context, exportID string) error {
+	export, err := s.exportRepo.FindByID(ctx, s.db, exportID)
+	if err != nil {
+		return fmt.Errorf("organizationMemberships/processExport: fetching export %s: %w", exportID, err)
+	}
+	if export.Status != ExportStatusPending {
+		return nil
+	}
+
+	env, err := s.envService.Load(ctx, s.db, export.InstanceID)
+	if err != nil {
+		return fmt.Errorf("organizationMemberships/processExport: loading environment of instance %s: %w", export.InstanceID, err)
+	}
+	ctx = environment.NewContext(ctx, env)
+	ctx = phone_profiles.NewContext(ctx, env.Instance)
+
+	organization, err := s.organizationRepo.QueryByIDAndInstance(ctx, s.db, export.OrganizationID, env.Instance.ID)
+	if err != nil {
+		return fmt.Errorf("organizationMemberships/processExport: fetching organization %s: %w", export.OrganizationID, err)
+	}
+	if organization == nil {
+		// the organization was deleted in the meantime, so there's nothing
+		// to export
+		export.Status = ExportStatusFailed
+		return s.exportRepo.Update(ctx, s.db, export, sqbmodel.OrganizationMembershipExportColumns.Status)
+	}
+
+	// The file is streamed to storage as it's written, so that exports of
+	// large organizations are never held in memory as a whole.
+	path := fmt.Sprintf("organization_membership_exports/%s/%s.csv", export.InstanceID, export.ID)
+	reader, writer := io.Pipe()
+	rowCounts := make(chan int, 1)
+	go func() {
+		rowCount, err := s.writeExportCSV(ctx, env, organization.ID, writer)
+		rowCounts <- rowCount
+		writer.CloseWithError(err)
+	}()
+	if _, err := s.storage.Write(ctx, path, reader); err != nil {
+		// stop writing, in case storage gave up before reading everything
+		reader.CloseWithError(err)
+		return fmt.Errorf("organizationMemberships/processExport: uploading export %s: %w", export.ID, err)
+	}
+	rowCount := <-rowCounts
+
+	export.Status = ExportStatusCompleted
+	export.StoragePath = null.StringFrom(path)
+	export.RowCount = null.IntFrom(rowCount)
+	export.CompletedAt = null.TimeFrom(s.clock.Now().UTC())
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.exportRepo.Update(ctx, tx, export,
+			sqbmodel.OrganizationMembershipExportColumns.Status,
+			sqbmodel.OrganizationMembershipExportColumns.StoragePath,
+			sqbmodel.OrganizationMembershipExportColumns.RowCount,
+			sqbmodel.OrganizationMembershipExportColumns.CompletedAt,
+		)
+		if err != nil {
+			return true, err
+		}
+
+		err = s.notifyExportCompleted(ctx, tx, env, organization, export)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return fmt.Errorf("organizationMemberships/processExport: completing export %s: %w", export.ID, txErr)
+	}
+	return nil
+}
+
+// writeExportCSV writes a row for each member of the organization, and
+// returns the number of rows written.
+func (s *Service) writeExportCSV(ctx context.Context, env *model.Env, organizationID string, out io.Writer) (int, error) {
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	csvWriter := csv.NewWriter(out)
+	if err := csvWriter.Write(exportCSVHeader); err != nil {
+		return 0, err
+	}
+
+	rowCount := 0
+	// keyset pagination, so that memberships which are created or deleted
+	// during the export don't shift the pages
+	keyset := pagination.KeysetParams{Limit: exportMembershipsPageSize}
+	for {
+		memberships, err := s.organizationMembershipsRepo.FindAllByOrganizationWithModifiersAfter(ctx, s.db, env.Instance.ID, organizationID, repository.OrganizationMembershipsFindAllModifiers{}, keyset)
+		if err != nil {
+			return rowCount, err
+		}
+
+		for _, membership := range memberships {
+			var identifier string
+			if membership.User.User != nil {
+				identifier, err = s.organizationsService.MemberIdentifier(ctx, s.db, userSettings, membership.User.User)
+				if err != nil {
+					return rowCount, err
+				}
+			}
+
+			err := csvWriter.Write([]string{
+				membership.UserID,
+				identifier,
+				membership.Role.Key,
+				membership.CreatedAt.UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				return rowCount, err
+			}
+			rowCount++
+		}
+
+		if len(memberships) < exportMembershipsPageSize {
+			break
+		}
+		last := memberships[len(memberships)-1]
+		keyset.After = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	csvWriter.Flush()
+	return rowCount, csvWriter.Error()
+}
+
+// notifyExportCompleted sends the organizationMembershipExport.completed
+// webhook and, if requested, an email with a link to the export.
+func (s *Service) notifyExportCompleted(ctx context.Context, tx database.Tx, env *model.Env, organization *model.Organization, export *model.OrganizationMembershipExport) error {
+	err := s.eventsService.OrganizationMembershipExportCompleted(ctx, tx, env.Instance, serialize.OrganizationMembershipExport(export, "", 0))
+	if err != nil {
+		return fmt.Errorf("sending completed event: %w", err)
+	}
+
+	if !export.NotifyEmailAddress.Valid {
+		return nil
+	}
+
+	expireAt := s.clock.Now().UTC().Add(exportEmailDownloadURLLifetime)
+	downloadURL, err := s.storage.SignedURL(ctx, export.StoragePath.String, expireAt)
+	if err != nil {
+		return fmt.Errorf("signing download url: %w", err)
+	}
+	return s.commsService.SendOrganizationMembershipExportReadyEmail(ctx, tx, env, comms.EmailOrganizationMembershipExportReady{
+		Organization: organization,
+		EmailAddress: export.NotifyEmailAddress.String,
+		DownloadURL:  downloadURL,
+		ExpireAt:     expireAt,
+	})
+}
@@ -0,0 +1,64 @@
+package organization_memberships
+
+import (
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestExportParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	// the notification email is optional
+	assert.Nil(t, ExportParams{}.validate())
+
+	emailAddress := "admin@example.com"
+	assert.Nil(t, ExportParams{NotifyEmailAddress: &emailAddress}.validate())
+
+	emailAddress = "not an email address"
+	apiErr := ExportParams{NotifyEmailAddress: &emailAddress}.validate()
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamFormatInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestSerializeOrganizationMembershipExport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	export := &model.OrganizationMembershipExport{OrganizationMembershipExport: &sqbmodel.OrganizationMembershipExport{
+		ID:             "orgmemexp_1",
+		OrganizationID: "org_1",
+		Status:         ExportStatusPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}}
+
+	// pending exports have nothing to download yet
+	response := serialize.OrganizationMembershipExport(export, "", 0)
+	assert.Equal(t, serialize.ObjectOrganizationMembershipExport, response.Object)
+	assert.Equal(t, "org_1", response.OrganizationID)
+	assert.Nil(t, response.RowCount)
+	assert.Nil(t, response.DownloadURL)
+	assert.Nil(t, response.ExpireAt)
+	assert.Nil(t, response.CompletedAt)
+
+	export.Status = ExportStatusCompleted
+	export.RowCount = null.IntFrom(1200)
+	export.CompletedAt = null.TimeFrom(now.Add(time.Minute))
+	expireAt := now.Add(exportDownloadURLLifetime).UnixMilli()
+	response = serialize.OrganizationMembershipExport(export, "https://storage.example.com/orgmemexp_1.csv", expireAt)
+	require.NotNil(t, response.RowCount)
+	assert.Equal(t, 1200, *response.RowCount)
+	require.NotNil(t, response.DownloadURL)
+	assert.Equal(t, "https://storage.example.com/orgmemexp_1.csv", *response.DownloadURL)
+	assert.Equal(t, expireAt, *response.ExpireAt)
+	assert.Equal(t, now.Add(time.Minute).UnixMilli(), *response.CompletedAt)
+}
@@ -6,6 +6,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/clerkhttp"
+	"clerk/pkg/readreplica"
 	"clerk/repository"
 	"clerk/utils/clerk"
 
@@ -16,9 +17,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(deps clerk.Deps) *HTTP {
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
 	return &HTTP{
-		service: NewService(deps),
+		service: NewService(deps, db),
 	}
 }
 
@@ -31,10 +32,16 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
+	keyset, err := pagination.KeysetFromRequest(r, paginationParams.Limit)
+	if err != nil {
+		return nil, err
+	}
+
 	params := ListParams{
 		OrganizationID:                          chi.URLParam(r, "organizationID"),
 		OrganizationMembershipsFindAllModifiers: toReadAllMods(r),
 		orderBy:                                 r.URL.Query().Get("order_by"),
+		keyset:                                  keyset,
 	}
 
 	return h.service.List(ctx, params, paginationParams)
@@ -64,6 +71,21 @@ func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.service.Create(r.Context(), params)
 }
 
+// POST /v1/organizations/{organizationID}/memberships/export
+func (h *HTTP) CreateExport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := ExportParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateExport(r.Context(), chi.URLParam(r, "organizationID"), params)
+}
+
+// GET /v1/organizations/{organizationID}/memberships/exports/{exportID}
+func (h *HTTP) ReadExport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadExport(r.Context(), chi.URLParam(r, "organizationID"), chi.URLParam(r, "exportID"))
+}
+
 // PATCH /v1/organizations/{organizationID}/memberships/{userID}
 func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	params := UpdateParams{}
@@ -6,6 +6,8 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	shenvironment "clerk/api/shared/environment"
 	"clerk/api/shared/events"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/orgdomain"
@@ -14,31 +16,50 @@ import (
 	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/metadata"
+	"clerk/pkg/readreplica"
 	"clerk/pkg/set"
+	"clerk/pkg/storage"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
 )
 
 type Service struct {
-	db database.Database
+	clock     clockwork.Clock
+	db        *readreplica.Database
+	gueClient *gue.Client
+	storage   storage.ReadWriter
 
 	// services
+	commsService         *comms.Service
+	envService           *shenvironment.Service
 	eventsService        *events.Service
 	organizationsService *organizations.Service
 	orgDomainService     *orgdomain.Service
 
 	// repositories
+	exportRepo                  *repository.OrganizationMembershipExports
+	organizationRepo            *repository.Organization
 	organizationMembershipsRepo *repository.OrganizationMembership
 }
 
-func NewService(deps clerk.Deps) *Service {
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
 	return &Service{
-		db:                          deps.DB(),
+		clock:                       deps.Clock(),
+		db:                          db,
+		gueClient:                   deps.GueClient(),
+		storage:                     deps.StorageClient(),
+		commsService:                comms.NewService(deps),
+		envService:                  shenvironment.NewService(),
 		eventsService:               events.NewService(deps),
 		organizationsService:        organizations.NewService(deps),
 		orgDomainService:            orgdomain.NewService(deps.Clock()),
-		organizationMembershipsRepo: repository.NewOrganizationMembership(),
+		exportRepo:                  deps.Repositories().OrganizationMembershipExports,
+		organizationRepo:            deps.Repositories().Organization,
+		organizationMembershipsRepo: deps.Repositories().OrganizationMembership,
 	}
 }
 
@@ -55,9 +76,21 @@ var validOrderByFields = set.New(
 type ListParams struct {
 	OrganizationID string
 	orderBy        string
+	keyset         *pagination.KeysetParams
 	repository.OrganizationMembershipsFindAllModifiers
 }
 
+func (params ListParams) validate() apierror.Error {
+	if params.keyset == nil {
+		return nil
+	}
+	// Keyset pagination comes with its own, stable order
+	if params.orderBy != "" {
+		return apierror.FormParameterNotAllowedIfAnotherParameterIsPresent("order_by", "cursor")
+	}
+	return nil
+}
+
 func (params ListParams) convertToOrganizationMembershipMods() (repository.OrganizationMembershipsFindAllModifiers, apierror.Error) {
 	var mods repository.OrganizationMembershipsFindAllModifiers
 	mods.EmailAddresses = params.EmailAddresses
@@ -84,30 +117,52 @@ func (params ListParams) convertToOrganizationMembershipMods() (repository.Organ
 func (s *Service) List(ctx context.Context, params ListParams, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
 	mods, apiErr := params.convertToOrganizationMembershipMods()
 	if apiErr != nil {
 		return nil, apiErr
 	}
 
-	membershipsResponse, err := s.organizationMembershipsRepo.FindAllByOrganizationWithModifiers(ctx, s.db, env.Instance.ID, params.OrganizationID, mods, paginationParams)
+	db := s.db.ReadReplica()
+	var membershipsResponse []*model.OrganizationMembershipWithDeps
+	var err error
+	if params.keyset != nil {
+		membershipsResponse, err = s.organizationMembershipsRepo.FindAllByOrganizationWithModifiersAfter(ctx, db, env.Instance.ID, params.OrganizationID, mods, *params.keyset)
+	} else {
+		membershipsResponse, err = s.organizationMembershipsRepo.FindAllByOrganizationWithModifiers(ctx, db, env.Instance.ID, params.OrganizationID, mods, paginationParams)
+	}
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
-	totalCount, err := s.organizationMembershipsRepo.CountByOrganizationWithModifiers(ctx, s.db, env.Instance.ID, params.OrganizationID, mods)
+	totalCount, err := s.organizationMembershipsRepo.CountByOrganizationWithModifiers(ctx, db, env.Instance.ID, params.OrganizationID, mods)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
-	responseData := make([]interface{}, len(membershipsResponse))
-	for i, orgMembership := range membershipsResponse {
-		membership, err := s.organizationsService.ConvertToSerializable(ctx, s.db, orgMembership)
-		if err != nil {
-			return nil, apierror.Unexpected(err)
-		}
+	// The Backend API doesn't serialize the counts of the organization
+	memberships, err := s.organizationsService.ConvertAllToSerializable(ctx, db, membershipsResponse, organizations.WithoutCounts())
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responseData := make([]interface{}, len(memberships))
+	for i, membership := range memberships {
 		responseData[i] = serialize.OrganizationMembershipBAPI(ctx, membership)
 	}
 
+	if params.keyset != nil {
+		var nextCursor *string
+		if len(membershipsResponse) > 0 {
+			last := membershipsResponse[len(membershipsResponse)-1]
+			nextCursor = params.keyset.NextCursor(len(membershipsResponse), last.CreatedAt, last.ID)
+		}
+		return serialize.CursorPaginated(responseData, totalCount, nextCursor), nil
+	}
+
 	return serialize.Paginated(responseData, totalCount), apiErr
 }
 
@@ -191,29 +246,16 @@ type UpdateMetadataParams struct {
 	UserID          string          `json:"-"`
 }
 
+// UpdateMetadata merges the given public and private metadata into the
+// existing ones of the membership, in the same way as it's done for users.
+// The membership is locked while merging, so that concurrent updates don't
+// overwrite each other's keys.
 func (s *Service) UpdateMetadata(ctx context.Context, params UpdateMetadataParams) (*serialize.OrganizationMembershipResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 
-	membership, err := s.organizationMembershipsRepo.QueryByOrganizationAndUser(ctx, s.db, params.OrganizationID, params.UserID)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	} else if membership == nil {
-		return nil, apierror.ResourceNotFound()
-	}
-	orgMembership := &membership.OrganizationMembership
-
-	merged, mergeErr := metadata.Merge(orgMembership.Metadata(), metadata.Metadata{
-		Public:  params.PublicMetadata,
-		Private: params.PrivateMetadata,
-	})
-	if mergeErr != nil {
-		return nil, mergeErr
-	}
-	orgMembership.SetMetadata(merged)
-
 	var serializable *model.OrganizationMembershipSerializable
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
-		err := s.organizationMembershipsRepo.UpdateMetadata(ctx, tx, orgMembership)
+		membership, err := mergeMetadata(ctx, tx, s.organizationMembershipsRepo, params)
 		if err != nil {
 			return true, err
 		}
@@ -230,12 +272,48 @@ func (s *Service) UpdateMetadata(ctx context.Context, params UpdateMetadataParam
 		return false, nil
 	})
 	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
 		return nil, apierror.Unexpected(txErr)
 	}
 
 	return serialize.OrganizationMembershipBAPI(ctx, serializable), nil
 }
 
+// membershipMetadataStore reads and writes the metadata of organization
+// memberships. It's implemented by repository.OrganizationMembership.
+type membershipMetadataStore interface {
+	QueryByOrganizationAndUserForUpdate(ctx context.Context, exec database.Executor, organizationID, userID string) (*model.OrganizationMembershipWithDeps, error)
+	UpdateMetadata(ctx context.Context, exec database.Executor, membership *model.OrganizationMembership) error
+}
+
+// mergeMetadata merges the metadata of the params into the existing ones of
+// the membership, which stays locked until the transaction ends.
+func mergeMetadata(ctx context.Context, tx database.Tx, store membershipMetadataStore, params UpdateMetadataParams) (*model.OrganizationMembershipWithDeps, error) {
+	membership, err := store.QueryByOrganizationAndUserForUpdate(ctx, tx, params.OrganizationID, params.UserID)
+	if err != nil {
+		return nil, err
+	} else if membership == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	orgMembership := &membership.OrganizationMembership
+
+	merged, mergeErr := metadata.Merge(orgMembership.Metadata(), metadata.Metadata{
+		Public:  params.PublicMetadata,
+		Private: params.PrivateMetadata,
+	})
+	if mergeErr != nil {
+		return nil, mergeErr
+	}
+	orgMembership.SetMetadata(merged)
+
+	if err := store.UpdateMetadata(ctx, tx, orgMembership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
 func (s *Service) Delete(ctx context.Context, organizationID, userID string) (*serialize.OrganizationMembershipResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 
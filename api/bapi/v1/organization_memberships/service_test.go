@@ -0,0 +1,68 @@
+package organization_memberships
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/utils/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/types"
+)
+
+type fakeMembershipMetadataStore struct {
+	membership *model.OrganizationMembershipWithDeps
+	updated    []*model.OrganizationMembership
+}
+
+func (f *fakeMembershipMetadataStore) QueryByOrganizationAndUserForUpdate(_ context.Context, _ database.Executor, organizationID, userID string) (*model.OrganizationMembershipWithDeps, error) {
+	if f.membership == nil || f.membership.OrganizationMembership.OrganizationID != organizationID || f.membership.OrganizationMembership.UserID != userID {
+		return nil, nil
+	}
+	return f.membership, nil
+}
+
+func (f *fakeMembershipMetadataStore) UpdateMetadata(_ context.Context, _ database.Executor, membership *model.OrganizationMembership) error {
+	f.updated = append(f.updated, membership)
+	return nil
+}
+
+func TestMergeMetadata(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeMembershipMetadataStore{membership: &model.OrganizationMembershipWithDeps{
+		OrganizationMembership: model.OrganizationMembership{OrganizationMembership: &sqbmodel.OrganizationMembership{
+			ID:              "orgmem_1",
+			OrganizationID:  "org_1",
+			UserID:          "user_1",
+			PublicMetadata:  types.JSON(`{"plan":"pro"}`),
+			PrivateMetadata: types.JSON(`{}`),
+		}},
+	}}
+
+	// the keys of the params are added to the existing ones
+	membership, err := mergeMetadata(context.Background(), nil, store, UpdateMetadataParams{
+		OrganizationID: "org_1",
+		UserID:         "user_1",
+		PublicMetadata: json.RawMessage(`{"seats":5}`),
+	})
+	require.NoError(t, err)
+	require.Len(t, store.updated, 1)
+	assert.Same(t, &membership.OrganizationMembership, store.updated[0])
+	assert.JSONEq(t, `{"plan":"pro","seats":5}`, string(store.updated[0].PublicMetadata))
+
+	_, err = mergeMetadata(context.Background(), nil, store, UpdateMetadataParams{
+		OrganizationID: "org_1",
+		UserID:         "user_2",
+		PublicMetadata: json.RawMessage(`{"seats":5}`),
+	})
+	apiErr, isAPIErr := apierror.As(err)
+	require.True(t, isAPIErr)
+	assert.Equal(t, apierror.ResourceNotFoundCode, apiErr.Errors()[0].Code())
+	assert.Len(t, store.updated, 1)
+}
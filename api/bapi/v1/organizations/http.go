@@ -5,10 +5,12 @@ import (
 	"strconv"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/dryrun"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/readreplica"
 	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
@@ -18,9 +20,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(deps clerk.Deps) *HTTP {
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
 	return &HTTP{
-		service: NewService(deps),
+		service: NewService(deps, db),
 	}
 }
 
@@ -79,9 +81,23 @@ func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 
 // DELETE /v1/organizations/{organizationID}
 func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
-	return h.service.Delete(r.Context(), DeleteParams{
+	dryRun, err := dryrun.FromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := DeleteParams{
 		OrganizationID: chi.URLParam(r, "organizationID"),
-	})
+	}
+	if dryRun {
+		return h.service.DeleteDryRun(r.Context(), params)
+	}
+	return h.service.Delete(r.Context(), params)
+}
+
+// POST /v1/organizations/{organizationID}/restore
+func (h *HTTP) Restore(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Restore(r.Context(), chi.URLParam(r, "organizationID"))
 }
 
 // PATCH /v1/organizations/{organizationID}
@@ -125,6 +141,28 @@ func (h *HTTP) UpdateLogo(_ http.ResponseWriter, r *http.Request) (interface{},
 	return h.service.UpdateLogo(ctx, params, env.Instance)
 }
 
+// CreateLogoUpload handles requests to
+// POST /v1/organizations/{organizationID}/logo/uploads
+func (h *HTTP) CreateLogoUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := CreateLogoUploadParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateLogoUpload(r.Context(), chi.URLParam(r, "organizationID"), params)
+}
+
+// CompleteLogoUpload handles requests to
+// POST /v1/organizations/{organizationID}/logo/uploads/{imageID}/complete
+func (h *HTTP) CompleteLogoUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := CompleteLogoUploadParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CompleteLogoUpload(r.Context(), chi.URLParam(r, "organizationID"), chi.URLParam(r, "imageID"), params)
+}
+
 func (h *HTTP) DeleteLogo(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
 	return h.service.DeleteLogo(r.Context(), chi.URLParam(r, "organizationID"))
 }
@@ -13,6 +13,7 @@ import (
 	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/metadata"
+	"clerk/pkg/readreplica"
 	sentryclerk "clerk/pkg/sentry"
 	"clerk/pkg/set"
 	"clerk/repository"
@@ -21,11 +22,12 @@ import (
 	"clerk/utils/validate"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/types"
 )
 
 type Service struct {
-	db        database.Database
+	db        *readreplica.Database
 	validator *validator.Validate
 
 	// services
@@ -38,15 +40,15 @@ type Service struct {
 	usersRepo         *repository.Users
 }
 
-func NewService(deps clerk.Deps) *Service {
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
 	return &Service{
-		db:                   deps.DB(),
+		db:                   db,
 		validator:            validator.New(),
 		eventsService:        events.NewService(deps),
 		organizationsService: organizations.NewService(deps),
 		orgLogosService:      organizations.NewLogosService(deps),
-		organizationsRepo:    repository.NewOrganization(),
-		usersRepo:            repository.NewUsers(),
+		organizationsRepo:    deps.Repositories().Organization,
+		usersRepo:            deps.Repositories().Users,
 	}
 }
 
@@ -128,13 +130,14 @@ func (s *Service) List(ctx context.Context, params ListParams, paginationParams
 
 	// Retrieve organizations
 	env := environment.FromContext(ctx)
-	orgsWithMembers, err := s.organizationsRepo.FindAllByInstanceWithMembersCount(ctx, s.db, env.Instance.ID, findAllParams, paginationParams)
+	db := s.db.ReadReplica()
+	orgsWithMembers, err := s.organizationsRepo.FindAllByInstanceWithMembersCount(ctx, db, env.Instance.ID, findAllParams, paginationParams)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
 	// Retrieve organization count
-	totalCount, err := s.organizationsRepo.CountByInstanceWithModifiers(ctx, s.db, env.Instance.ID, findAllParams)
+	totalCount, err := s.organizationsRepo.CountByInstanceWithModifiers(ctx, db, env.Instance.ID, findAllParams)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -159,6 +162,7 @@ type CreateParams struct {
 	MaxAllowedMemberships *int             `json:"max_allowed_memberships" form:"max_allowed_memberships" validate:"omitempty,numeric,gte=0"`
 	PublicMetadata        *json.RawMessage `json:"public_metadata" form:"public_metadata"`
 	PrivateMetadata       *json.RawMessage `json:"private_metadata" form:"private_metadata"`
+	ParentOrganizationID  *string          `json:"parent_organization_id" form:"parent_organization_id"`
 }
 
 func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
@@ -213,6 +217,9 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.O
 	if params.MaxAllowedMemberships != nil {
 		organization.MaxAllowedMemberships = *params.MaxAllowedMemberships
 	}
+	if params.ParentOrganizationID != nil && *params.ParentOrganizationID != "" {
+		organization.ParentOrganizationID = null.StringFrom(*params.ParentOrganizationID)
+	}
 
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		if err := s.organizationsService.Create(ctx, tx, organizations.CreateParams{
@@ -249,7 +256,12 @@ func (s *Service) Read(ctx context.Context, idOrSlug string) (*serialize.Organiz
 	if org == nil {
 		return nil, apierror.ResourceNotFound()
 	}
-	return serialize.OrganizationBAPI(ctx, org), nil
+
+	childrenCount, err := s.organizationsRepo.CountByParent(ctx, s.db, org.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.OrganizationBAPI(ctx, org, serialize.WithChildrenCount(int(childrenCount))), nil
 }
 
 type UpdateParams struct {
@@ -260,6 +272,9 @@ type UpdateParams struct {
 	OrganizationID        string
 	PublicMetadata        *json.RawMessage `json:"public_metadata" form:"public_metadata"`
 	PrivateMetadata       *json.RawMessage `json:"private_metadata" form:"private_metadata"`
+	// ParentOrganizationID nests the organization under another one. Pass
+	// an empty string to make it a root organization again.
+	ParentOrganizationID *string `json:"parent_organization_id" form:"parent_organization_id"`
 }
 
 func (s *Service) Update(ctx context.Context, params UpdateParams) (*serialize.OrganizationResponse, apierror.Error) {
@@ -276,6 +291,7 @@ func (s *Service) Update(ctx context.Context, params UpdateParams) (*serialize.O
 			OrganizationID:        params.OrganizationID,
 			PublicMetadata:        params.PublicMetadata,
 			PrivateMetadata:       params.PrivateMetadata,
+			ParentOrganizationID:  params.ParentOrganizationID,
 			Instance:              env.Instance,
 			Subscription:          env.Subscription,
 		})
@@ -295,20 +311,41 @@ type DeleteParams struct {
 }
 
 func (s *Service) Delete(ctx context.Context, params DeleteParams) (*serialize.DeletedObjectResponse, apierror.Error) {
+	response, _, apiErr := s.delete(ctx, params, false)
+	return response, apiErr
+}
+
+// DeleteDryRun goes through the deletion of the organization without
+// deleting anything, and returns what would have been deleted.
+func (s *Service) DeleteDryRun(ctx context.Context, params DeleteParams) (*serialize.DryRunResponse, apierror.Error) {
+	response, affected, apiErr := s.delete(ctx, params, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.DryRun(response, affected), nil
+}
+
+func (s *Service) delete(ctx context.Context, params DeleteParams, dryRun bool) (*serialize.DeletedObjectResponse, map[string]int64, apierror.Error) {
 	env := environment.FromContext(ctx)
 
 	// Ensure organization exists
 	org, err := s.organizationsRepo.QueryByIDAndInstance(ctx, s.db, params.OrganizationID, env.Instance.ID)
 	if err != nil {
-		return nil, apierror.Unexpected(err)
+		return nil, nil, apierror.Unexpected(err)
 	}
 	if org == nil {
-		return nil, apierror.ResourceNotFound()
+		return nil, nil, apierror.ResourceNotFound()
 	}
 
 	var response *serialize.DeletedObjectResponse
+	var affected map[string]int64
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		var err error
+		affected, err = s.organizationsService.CountDeletedWithOrganization(ctx, tx, org.ID)
+		if err != nil {
+			return true, err
+		}
+
 		response, err = s.organizationsService.Delete(ctx, tx, organizations.DeleteParams{
 			Organization: org,
 			Env:          env,
@@ -316,12 +353,35 @@ func (s *Service) Delete(ctx context.Context, params DeleteParams) (*serialize.D
 		if err != nil {
 			return true, err
 		}
+		return dryRun, nil
+	})
+	if txErr != nil {
+		return nil, nil, apierror.Unexpected(txErr)
+	}
+	return response, affected, nil
+}
+
+// Restore restores an organization which is pending deletion, as long as
+// it hasn't been purged yet.
+func (s *Service) Restore(ctx context.Context, organizationID string) (*serialize.OrganizationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var org *model.Organization
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var apiErr apierror.Error
+		org, apiErr = s.organizationsService.Restore(ctx, tx, env, organizationID)
+		if apiErr != nil {
+			return true, apiErr
+		}
 		return false, nil
 	})
 	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
 		return nil, apierror.Unexpected(txErr)
 	}
-	return response, nil
+	return serialize.OrganizationBAPI(ctx, org), nil
 }
 
 func (s *Service) UpdateLogo(
@@ -346,6 +406,44 @@ func (s *Service) UpdateLogo(
 	return serialize.OrganizationBAPI(ctx, org), nil
 }
 
+type CreateLogoUploadParams struct {
+	ContentType    string `json:"content_type" form:"content_type"`
+	Filename       string `json:"filename" form:"filename"`
+	UploaderUserID string `json:"uploader_user_id" form:"uploader_user_id"`
+}
+
+// CreateLogoUpload returns a signed URL the new logo of the organization can
+// be uploaded to, directly to storage.
+func (s *Service) CreateLogoUpload(ctx context.Context, organizationID string, params CreateLogoUploadParams) (*serialize.ImageUploadResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if params.ContentType == "" {
+		return nil, apierror.FormMissingParameter("content_type")
+	}
+
+	return s.orgLogosService.CreateUpload(ctx, s.db, organizations.CreateLogoUploadParams{
+		OrganizationID: organizationID,
+		Filename:       params.Filename,
+		ContentType:    params.ContentType,
+		UploaderUserID: params.UploaderUserID,
+	}, env.Instance)
+}
+
+type CompleteLogoUploadParams struct {
+	UploaderUserID string `json:"uploader_user_id" form:"uploader_user_id"`
+}
+
+// CompleteLogoUpload sets the image uploaded through a signed upload URL as
+// the logo of the organization.
+func (s *Service) CompleteLogoUpload(ctx context.Context, organizationID, imageID string, params CompleteLogoUploadParams) (*serialize.OrganizationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.UpdateLogo(ctx, organizations.UpdateLogoParams{
+		OrganizationID:  organizationID,
+		UploaderUserID:  params.UploaderUserID,
+		UploadedImageID: imageID,
+	}, env.Instance)
+}
+
 func (s *Service) DeleteLogo(ctx context.Context, organizationID string) (*serialize.OrganizationResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 
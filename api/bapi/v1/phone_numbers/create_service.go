@@ -6,6 +6,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/phone_profiles"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/constants"
@@ -45,6 +46,12 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.P
 		return nil, apierror.UserNotFound(params.UserID)
 	}
 
+	phoneNumber, apiErr := phone_profiles.ForInstance(env.Instance).Sanitize(params.PhoneNumber, param.PhoneNumber.Name)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	params.PhoneNumber = phoneNumber
+
 	// validate all form elements separately.
 	if apiErr := s.validateCreateParams(ctx, s.db, env.Instance, userSettings, user, params); apiErr != nil {
 		return nil, apiErr
@@ -52,13 +59,18 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.P
 
 	var phoneNumberResponse *serialize.PhoneNumberResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		newIdentification, apiErr := s.createPhoneNumber(ctx, tx, user, params)
 		if err != nil {
 			return true, apiErr
 		}
 
 		// send event
-		if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -67,7 +79,7 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.P
 			return true, fmt.Errorf("user/update: serializing identification %+v: %w", newIdentification, err)
 		}
 
-		phoneNumberResponse = serialize.IdentificationPhoneNumber(phoneNumberSerializable)
+		phoneNumberResponse = serialize.IdentificationPhoneNumber(phoneNumberSerializable, serialize.WithPhoneNumberProfile(phoneProfile))
 
 		return false, nil
 	})
@@ -5,6 +5,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/phone_profiles"
 	"clerk/pkg/ctx/environment"
 )
 
@@ -22,5 +23,5 @@ func (s *Service) Read(ctx context.Context, phoneNumberID string) (*serialize.Ph
 		return nil, apierror.Unexpected(err)
 	}
 
-	return serialize.IdentificationPhoneNumber(phoneNumberSerializable), nil
+	return serialize.IdentificationPhoneNumber(phoneNumberSerializable, serialize.WithPhoneNumberProfile(phone_profiles.ForInstance(env.Instance))), nil
 }
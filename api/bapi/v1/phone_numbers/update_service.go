@@ -7,6 +7,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/api/shared/phone_numbers"
+	"clerk/api/shared/phone_profiles"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/constants"
@@ -48,6 +49,11 @@ func (s *Service) Update(ctx context.Context, phoneNumberID string, params Updat
 
 	var phoneNumberResponse *serialize.PhoneNumberResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		updatedUser, updatedIdent, performedUpdate, err := s.updatePhoneNumber(ctx, tx, user, phoneNumber, params)
 		if err != nil {
 			return true, err
@@ -55,12 +61,12 @@ func (s *Service) Update(ctx context.Context, phoneNumberID string, params Updat
 
 		// send event if something changed
 		if performedUpdate {
-			if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, updatedUser); err != nil {
+			if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, updatedUser); err != nil {
 				return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", updatedUser, env.Instance.ID, err)
 			}
 		}
 
-		phoneNumberResponse = serialize.IdentificationPhoneNumber(updatedIdent)
+		phoneNumberResponse = serialize.IdentificationPhoneNumber(updatedIdent, serialize.WithPhoneNumberProfile(phone_profiles.ForInstance(env.Instance)))
 
 		return false, nil
 	})
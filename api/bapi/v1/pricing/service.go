@@ -39,9 +39,9 @@ func NewService(deps clerk.Deps, paymentProvider clerkbilling.PaymentProvider) *
 		gueClient:             deps.GueClient(),
 		paymentProvider:       paymentProvider,
 		cachedPaymentProvider: clerkbilling.NewCachedPaymentProvider(deps.Clock(), deps.DB(), paymentProvider),
-		plansRepo:             repository.NewSubscriptionPlans(),
-		pricesRepo:            repository.NewSubscriptionPrices(),
-		subscriptionRepo:      repository.NewSubscriptions(),
+		plansRepo:             deps.Repositories().SubscriptionPlans,
+		pricesRepo:            deps.Repositories().SubscriptionPrices,
+		subscriptionRepo:      deps.Repositories().Subscriptions,
 	}
 }
 
@@ -28,8 +28,10 @@ import (
 	"clerk/api/bapi/v1/jwks"
 	"clerk/api/bapi/v1/jwt_templates"
 	"clerk/api/bapi/v1/messaging"
+	"clerk/api/bapi/v1/meta"
 	"clerk/api/bapi/v1/oauth_applications"
 	"clerk/api/bapi/v1/organization_invitations"
+	"clerk/api/bapi/v1/organization_membership_requests"
 	"clerk/api/bapi/v1/organization_memberships"
 	"clerk/api/bapi/v1/organizations"
 	"clerk/api/bapi/v1/phone_numbers"
@@ -37,6 +39,8 @@ import (
 	"clerk/api/bapi/v1/redirect_urls"
 	"clerk/api/bapi/v1/saml_connections"
 	"clerk/api/bapi/v1/scheduler"
+	"clerk/api/bapi/v1/scim"
+	"clerk/api/bapi/v1/service_accounts"
 	"clerk/api/bapi/v1/sessions"
 	"clerk/api/bapi/v1/sign_in_tokens"
 	"clerk/api/bapi/v1/sign_ups"
@@ -48,24 +52,28 @@ import (
 	"clerk/api/bapi/v1/users"
 	"clerk/api/bapi/v1/webhooks"
 	"clerk/api/middleware"
+	"clerk/api/middleware/pipeline"
+	"clerk/api/shared/featureflags"
+	"clerk/api/shared/idempotency"
+	"clerk/api/shared/ratelimit"
 	apiVersioningMiddleware "clerk/pkg/apiversioning/middleware"
 	clerkbilling "clerk/pkg/billing"
 	"clerk/pkg/cenv"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/externalapis/svix"
 	"clerk/pkg/handlers"
+	"clerk/pkg/readreplica"
 	"clerk/pkg/usersettings/clerk/names"
 	"clerk/utils/clerk"
 
-	sentry "github.com/getsentry/sentry-go/http"
 	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
-	chitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-chi/chi.v5"
 )
 
 // Router is responsible for request routing in server API
 type Router struct {
-	deps clerk.Deps
+	deps        clerk.Deps
+	rateLimit   *ratelimit.Service
+	idempotency *idempotency.Service
 
 	// handlers
 	common *handlers.Common
@@ -92,14 +100,18 @@ type Router struct {
 	jwks              *jwks.HTTP
 	jwtTemplates      *jwt_templates.HTTP
 	messaging         *messaging.HTTP
+	meta              *meta.HTTP
 	orgInvitations    *organization_invitations.HTTP
 	orgMemberships    *organization_memberships.HTTP
+	orgMemberRequests *organization_membership_requests.HTTP
 	organizations     *organizations.HTTP
 	supportOps        *supportOps.HTTP
 	phoneNumbers      *phone_numbers.HTTP
 	proxyChecks       *proxy_checks.HTTP
 	redirectURLs      *redirect_urls.HTTP
 	samlConnections   *saml_connections.HTTP
+	scim              *scim.HTTP
+	serviceAccounts   *service_accounts.HTTP
 	sessions          *sessions.HTTP
 	signInTokens      *sign_in_tokens.HTTP
 	signUps           *sign_ups.HTTP
@@ -116,6 +128,7 @@ type Router struct {
 // New builds a new router
 func New(
 	deps clerk.Deps,
+	db *readreplica.Database,
 	common *handlers.Common,
 	svixClient *svix.Client,
 	billingConnector clerkbilling.Connector,
@@ -124,12 +137,14 @@ func New(
 	internalClient *internalapi.Client,
 ) *Router {
 	return &Router{
-		deps:       deps,
-		common:     common,
-		allowlist:  allowlist.NewHTTP(deps),
-		authConfig: authconfig.NewHTTP(deps.DB(), deps.GueClient()),
-		billing:    billing.NewHTTP(deps, billingConnector),
-		blocklist:  blocklist.NewHTTP(deps.DB()),
+		deps:        deps,
+		rateLimit:   ratelimit.NewService(deps),
+		idempotency: idempotency.NewService(deps),
+		common:      common,
+		allowlist:   allowlist.NewHTTP(deps),
+		authConfig:  authconfig.NewHTTP(deps.DB(), deps.GueClient()),
+		billing:     billing.NewHTTP(deps, billingConnector),
+		blocklist:   blocklist.NewHTTP(deps.DB()),
 		scheduler: scheduler.NewHTTP(
 			deps,
 			paymentProvider,
@@ -139,35 +154,39 @@ func New(
 		comms:             comms.NewHTTP(deps),
 		domains:           domains.NewHTTP(deps, externalAppClient, internalClient),
 		engineering:       engineering.NewHTTP(deps.Cache()),
-		environment:       environment.NewHTTP(deps.DB()),
+		environment:       environment.NewHTTP(deps),
 		emailAddresses:    email_addresses.NewHTTP(deps),
 		features:          features.NewHTTP(deps.DB()),
 		actorTokens:       actor_tokens.NewHTTP(deps),
 		instances:         instances.NewHTTP(deps, externalAppClient, internalClient),
-		instanceOrgPerm:   instance_organization_permissions.NewHTTP(deps),
-		instanceOrgRoles:  instance_organization_roles.NewHTTP(deps),
+		instanceOrgPerm:   instance_organization_permissions.NewHTTP(deps, db),
+		instanceOrgRoles:  instance_organization_roles.NewHTTP(deps, db),
 		interstitial:      interstitial.NewHTTP(),
 		invitations:       invitations.NewHTTP(deps),
-		jwks:              jwks.NewHTTP(),
+		jwks:              jwks.NewHTTP(deps),
 		jwtTemplates:      jwt_templates.NewHTTP(deps.DB(), deps.GueClient(), deps.Clock()),
 		messaging:         messaging.NewHTTP(deps),
-		orgInvitations:    organization_invitations.NewHTTP(deps),
-		orgMemberships:    organization_memberships.NewHTTP(deps),
-		organizations:     organizations.NewHTTP(deps),
+		meta:              meta.NewHTTP(),
+		orgInvitations:    organization_invitations.NewHTTP(deps, db),
+		orgMemberships:    organization_memberships.NewHTTP(deps, db),
+		orgMemberRequests: organization_membership_requests.NewHTTP(deps, db),
+		organizations:     organizations.NewHTTP(deps, db),
 		phoneNumbers:      phone_numbers.NewHTTP(deps),
 		supportOps:        supportOps.NewHTTP(deps),
 		proxyChecks:       proxy_checks.NewHTTP(deps.Clock(), deps.DB(), deps.GueClient(), externalAppClient, internalClient),
 		redirectURLs:      redirect_urls.NewHTTP(deps.DB(), deps.Clock()),
 		samlConnections:   saml_connections.NewHTTP(deps),
+		scim:              scim.NewHTTP(deps, db),
+		serviceAccounts:   service_accounts.NewHTTP(deps),
 		sessions:          sessions.NewHTTP(deps),
-		signInTokens:      sign_in_tokens.NewHTTP(deps.Clock(), deps.DB()),
+		signInTokens:      sign_in_tokens.NewHTTP(deps),
 		signUps:           sign_ups.NewHTTP(deps),
-		templates:         templates.NewHTTP(deps.Clock(), deps.DB()),
+		templates:         templates.NewHTTP(deps),
 		testingTokens:     testing_tokens.NewHTTP(deps.Clock()),
 		tokens:            tokens.NewHTTP(deps),
 		users:             users.NewHTTP(deps),
-		webhooks:          webhooks.NewHTTP(deps.DB(), svixClient),
-		oauthApplications: oauth_applications.NewHTTP(deps),
+		webhooks:          webhooks.NewHTTP(deps, svixClient),
+		oauthApplications: oauth_applications.NewHTTP(deps, db),
 		edgeEventsService: edge_events.NewHTTP(deps),
 		smsCountryTiers:   smscountrytiers.NewHTTP(deps),
 	}
@@ -176,33 +195,26 @@ func New(
 // BuildRoutes returns a mux with all routes for the server API
 func (router *Router) BuildRoutes() *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Recover)
-
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
-		r.Use(chitrace.Middleware(chitrace.WithServiceName(cenv.Get(cenv.ClerkServiceIdentifier))))
-	}
-
-	// report panics to Sentry and re-panic. Also, populate a Sentry Hub
-	// into the context.
-	//
-	// NOTE: This must come after middleware.Recover and before
-	// middleware.SetTraceID
-	r.Use(sentry.New(sentry.Options{Repanic: true}).Handle)
-
-	r.Use(middleware.SetTraceID)
-	r.Use(clerkhttp.Middleware(middleware.SetMaintenanceAndRecoveryMode))
-	r.Use(middleware.SetResponseTypeToJSON)
-	r.Use(clerkhttp.Middleware(parseForm))
-	r.Use(clerkhttp.Middleware(validateCharSet))
-	r.Use(middleware.Log(func() sql.DBStats {
-		return router.deps.DB().Conn().Stats()
-	}))
-	r.Use(chimw.StripSlashes)
-	r.Use(clerkhttp.Middleware(checkRequestAllowedDuringMaintenance))
+	pipeline.Apply(r, pipeline.Policy{
+		TracerServiceName: cenv.Get(cenv.ClerkServiceIdentifier),
+		MaintenanceMode:   true,
+		DBStats: func() sql.DBStats {
+			return router.deps.DB().Conn().Stats()
+		},
+		FeatureFlags: featureflags.NewService(router.deps).Flags,
+		BeforeLog: []pipeline.Middleware{
+			clerkhttp.Middleware(parseForm),
+			clerkhttp.Middleware(validateCharSet),
+		},
+		BeforeRouting: []pipeline.Middleware{
+			clerkhttp.Middleware(checkRequestAllowedDuringMaintenance),
+		},
+	})
 
 	// Public routes
 	r.Method(http.MethodGet, "/v1/health", router.common.Health())
 	r.Method(http.MethodHead, "/v1/health", router.common.Health())
+	r.Method(http.MethodGet, "/v1/meta/errors", clerkhttp.Handler(router.meta.Errors))
 
 	r.Route("/v1/public", func(r chi.Router) {
 		r.Group(func(r chi.Router) {
@@ -212,11 +224,13 @@ func (router *Router) BuildRoutes() *chi.Mux {
 		})
 
 		r.Method(http.MethodPost, "/demo_instance", clerkhttp.Handler(router.instances.CreateDemoInstance))
+		r.Method(http.MethodGet, "/instances/{instanceID}/metrics", clerkhttp.Handler(router.instances.ExportMetrics))
 	})
 
 	// incoming webhooks / events
 	r.Route("/v1/events", func(r chi.Router) {
 		r.Method(http.MethodPost, "/twilio_sms_status", clerkhttp.Handler(router.messaging.TwilioSMSStatusCallback))
+		r.Method(http.MethodPost, "/email_status/{provider}", clerkhttp.Handler(router.messaging.EmailStatusCallback))
 		r.Method(http.MethodPost, "/stripe", clerkhttp.Handler(router.billing.StripeWebhook))
 	})
 
@@ -248,7 +262,12 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodPost, "/cleanup/dead_sessions_job", clerkhttp.Handler(router.scheduler.DeadSessionsJob))
 			r.Method(http.MethodPost, "/cleanup/orphan_applications", clerkhttp.Handler(router.scheduler.OrphanApplications))
 			r.Method(http.MethodPost, "/cleanup/orphan_organizations", clerkhttp.Handler(router.scheduler.OrphanOrganizations))
+			r.Method(http.MethodPost, "/cleanup/deleted_users", clerkhttp.Handler(router.scheduler.DeletedUsers))
+			r.Method(http.MethodPost, "/cleanup/deleted_organizations", clerkhttp.Handler(router.scheduler.DeletedOrganizations))
 			r.Method(http.MethodPost, "/cleanup/expired_oauth_tokens", clerkhttp.Handler(router.scheduler.ExpiredOAuthTokens))
+			r.Method(http.MethodPost, "/cleanup/expired_webhook_events", clerkhttp.Handler(router.scheduler.ExpiredWebhookEvents))
+			r.Method(http.MethodPost, "/cleanup/expired_organization_invitations", clerkhttp.Handler(router.scheduler.ExpiredOrganizationInvitations))
+			r.Method(http.MethodPost, "/cleanup/pending_image_uploads", clerkhttp.Handler(router.scheduler.PendingImageUploads))
 			r.Method(http.MethodPost, "/stripe/usage_report_jobs", clerkhttp.Handler(router.scheduler.StripeUsageReportJobs))
 			r.Method(http.MethodPost, "/stripe/sync_plans", clerkhttp.Handler(router.scheduler.SyncStripePlans))
 			r.Method(http.MethodPost, "/stripe/refresh_cache_responses", clerkhttp.Handler(router.scheduler.StripeRefreshCacheResponses))
@@ -257,6 +276,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodPost, "/email_domain_reports/populate_disposable", clerkhttp.Handler(router.scheduler.PopulateDisposableEmailDomains))
 			r.Method(http.MethodPost, "/email_domain_reports/populate_common", clerkhttp.Handler(router.scheduler.PopulateCommonEmailDomains))
 			r.Method(http.MethodPost, "/hype_stats", clerkhttp.Handler(router.scheduler.CreateHypeStats))
+			r.Method(http.MethodPost, "/analytics/sign_up_funnel", clerkhttp.Handler(router.scheduler.AggregateSignUpFunnel))
 			r.Method(http.MethodPost, "/webauthn/refresh_authenticator_data", clerkhttp.Handler(router.scheduler.RefreshWebAuthnAuthenticatorData))
 
 			r.Route("/engineering-ops", func(r chi.Router) {
@@ -286,6 +306,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 	r.Route("/v1", func(r chi.Router) {
 		r.Use(clerkhttp.Middleware(router.environment.SetEnvironmentFromHeader))
 		r.Use(clerkhttp.Middleware(middleware.EnsureEnvNotPendingDeletion))
+		r.Use(clerkhttp.Middleware(middleware.RateLimit(router.rateLimit, ratelimit.BAPI, "bapi")))
 		r.Use(clerkhttp.Middleware(logClerkSDKVersion))
 		r.Use(clerkhttp.Middleware(apiVersioningMiddleware.SetAPIVersionFromHeader))
 
@@ -293,6 +314,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 
 		r.Route("/clients", func(r chi.Router) {
 			r.Method(http.MethodPost, "/verify", clerkhttp.Handler(router.clients.Verify))
+			r.Method(http.MethodPost, "/handshake/verify", clerkhttp.Handler(router.clients.VerifyHandshake))
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.clients.ReadAll))
 			r.Route("/{clientID}", func(r chi.Router) {
 				r.Group(func(r chi.Router) {
@@ -334,6 +356,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 				r.Method(http.MethodPut, "/", clerkhttp.Handler(router.templates.Upsert))
 				r.Method(http.MethodPost, "/revert", clerkhttp.Handler(router.templates.Revert))
 				r.Method(http.MethodPost, "/preview", clerkhttp.Handler(router.templates.Preview))
+				r.Method(http.MethodPost, "/test", clerkhttp.Handler(router.templates.Test))
 				r.Method(http.MethodPost, "/toggle_delivery", clerkhttp.Handler(router.templates.ToggleDelivery))
 				r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.templates.Delete))
 			})
@@ -369,7 +392,16 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.users.List))
 			r.Method(http.MethodGet, "/count", clerkhttp.Handler(router.users.Count))
 
-			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.users.Create))
+			r.With(middleware.Idempotency(router.idempotency)).Method(http.MethodPost, "/", clerkhttp.Handler(router.users.Create))
+
+			r.Route("/bulk", func(r chi.Router) {
+				r.Method(http.MethodPost, "/", clerkhttp.Handler(router.users.CreateBulkImport))
+				r.Method(http.MethodGet, "/{bulkImportID}", clerkhttp.Handler(router.users.ReadBulkImport))
+			})
+
+			// Deleted users aren't in the instance anymore, the service looks
+			// them up on its own.
+			r.Method(http.MethodPost, "/{userID}/restore", clerkhttp.Handler(router.users.Restore))
 
 			r.Route("/{userID}", func(r chi.Router) {
 				r.Use(clerkhttp.Middleware(router.users.CheckUserInInstance))
@@ -383,6 +415,8 @@ func (router *Router) BuildRoutes() *chi.Mux {
 					r.Method(http.MethodPost, "/unban", clerkhttp.Handler(router.users.Unban))
 				})
 
+				r.Method(http.MethodPost, "/merge", clerkhttp.Handler(router.users.Merge))
+
 				r.Method(http.MethodPost, "/lock", clerkhttp.Handler(router.users.Lock))
 				r.Method(http.MethodPost, "/unlock", clerkhttp.Handler(router.users.Unlock))
 
@@ -390,13 +424,23 @@ func (router *Router) BuildRoutes() *chi.Mux {
 
 				r.Method(http.MethodPost, "/profile_image", clerkhttp.Handler(router.users.UpdateProfileImage))
 				r.Method(http.MethodDelete, "/profile_image", clerkhttp.Handler(router.users.DeleteProfileImage))
+				r.Method(http.MethodPost, "/profile_image/uploads", clerkhttp.Handler(router.users.CreateProfileImageUpload))
+				r.Method(http.MethodPost, "/profile_image/uploads/{imageID}/complete", clerkhttp.Handler(router.users.CompleteProfileImageUpload))
 
 				r.Method(http.MethodGet, "/oauth_access_tokens/{provider}", clerkhttp.Handler(router.users.ListOAuthAccessTokens))
+				r.Method(http.MethodGet, "/legal_acceptances", clerkhttp.Handler(router.users.ListLegalAcceptances))
+
+				r.Method(http.MethodPost, "/export", clerkhttp.Handler(router.users.CreateExport))
+				r.Method(http.MethodGet, "/exports/{exportID}", clerkhttp.Handler(router.users.ReadExport))
 
 				r.Method(http.MethodPost, "/verify_password", clerkhttp.Handler(router.users.VerifyPassword))
 				r.Method(http.MethodPost, "/verify_totp", clerkhttp.Handler(router.users.VerifyTOTP))
+				r.Method(http.MethodPost, "/password_reset_link", clerkhttp.Handler(router.users.CreatePasswordResetLink))
 
+				r.Method(http.MethodGet, "/mfa", clerkhttp.Handler(router.users.ReadMFA))
 				r.Method(http.MethodDelete, "/mfa", clerkhttp.Handler(router.users.DisableMFA))
+				r.Method(http.MethodDelete, "/totp", clerkhttp.Handler(router.users.DeleteTOTP))
+				r.Method(http.MethodDelete, "/backup_codes", clerkhttp.Handler(router.users.DeleteBackupCodes))
 
 				r.Group(func(r chi.Router) {
 					r.Use(clerkhttp.Middleware(router.organizations.CheckOrganizationsEnabled))
@@ -410,6 +454,31 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodPost, "/svix", clerkhttp.Handler(router.webhooks.CreateSvix))
 			r.Method(http.MethodDelete, "/svix", clerkhttp.Handler(router.webhooks.DeleteSvix))
 			r.Method(http.MethodPost, "/svix_url", clerkhttp.Handler(router.webhooks.CreateSvixURL))
+			r.Method(http.MethodPost, "/native", clerkhttp.Handler(router.webhooks.EnableNativeDelivery))
+			r.Method(http.MethodDelete, "/native", clerkhttp.Handler(router.webhooks.DisableNativeDelivery))
+			r.Method(http.MethodGet, "/event_types", clerkhttp.Handler(router.webhooks.ReadEventTypes))
+
+			r.Route("/endpoints", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ReadAllEndpoints))
+				r.Method(http.MethodPost, "/", clerkhttp.Handler(router.webhooks.CreateEndpoint))
+				r.Route("/{endpointID}", func(r chi.Router) {
+					r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ReadEndpoint))
+					r.Method(http.MethodPut, "/", clerkhttp.Handler(router.webhooks.UpdateEndpoint))
+					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.webhooks.DeleteEndpoint))
+					r.Method(http.MethodGet, "/secret", clerkhttp.Handler(router.webhooks.ReadEndpointSecret))
+				})
+			})
+
+			r.Route("/failed_deliveries", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ListFailedDeliveries))
+				r.Method(http.MethodPost, "/{failedDeliveryID}/replay", clerkhttp.Handler(router.webhooks.ReplayFailedDelivery))
+			})
+
+			r.Route("/events", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ListEvents))
+				r.With(middleware.Idempotency(router.idempotency)).Method(http.MethodPost, "/replay", clerkhttp.Handler(router.webhooks.ReplayEvents))
+			})
+			r.Method(http.MethodGet, "/event_replays/{eventReplayID}", clerkhttp.Handler(router.webhooks.ReadEventReplay))
 		})
 
 		r.Route("/allowlist_identifiers", func(r chi.Router) {
@@ -429,7 +498,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 		})
 
 		r.Route("/invitations", func(r chi.Router) {
-			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.invitations.Create))
+			r.With(middleware.Idempotency(router.idempotency)).Method(http.MethodPost, "/", clerkhttp.Handler(router.invitations.Create))
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.invitations.ReadAll))
 			r.Route("/{invitationID}", func(r chi.Router) {
 				r.Method(http.MethodPost, "/revoke", clerkhttp.Handler(router.invitations.Revoke))
@@ -439,13 +508,16 @@ func (router *Router) BuildRoutes() *chi.Mux {
 		r.Route("/organizations", func(r chi.Router) {
 			r.Use(clerkhttp.Middleware(router.organizations.CheckOrganizationsEnabled))
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.organizations.List))
-			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.organizations.Create))
+			r.With(middleware.Idempotency(router.idempotency)).Method(http.MethodPost, "/", clerkhttp.Handler(router.organizations.Create))
 
 			r.Route("/{organizationID}", func(r chi.Router) {
 				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.organizations.Read))
 				r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.organizations.Delete))
+				r.Method(http.MethodPost, "/restore", clerkhttp.Handler(router.organizations.Restore))
 				r.Method(http.MethodPut, "/logo", clerkhttp.Handler(router.organizations.UpdateLogo))
 				r.Method(http.MethodDelete, "/logo", clerkhttp.Handler(router.organizations.DeleteLogo))
+				r.Method(http.MethodPost, "/logo/uploads", clerkhttp.Handler(router.organizations.CreateLogoUpload))
+				r.Method(http.MethodPost, "/logo/uploads/{imageID}/complete", clerkhttp.Handler(router.organizations.CompleteLogoUpload))
 
 				r.Group(func(r chi.Router) {
 					r.Use(clerkhttp.Middleware(router.organizations.EnsureOrganizationExists))
@@ -458,6 +530,8 @@ func (router *Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.orgInvitations.Create))
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgInvitations.List))
 						r.Method(http.MethodPost, "/bulk", clerkhttp.Handler(router.orgInvitations.CreateBulk))
+						r.Method(http.MethodPost, "/bulk_revoke", clerkhttp.Handler(router.orgInvitations.BulkRevoke))
+						r.Method(http.MethodPost, "/bulk_resend", clerkhttp.Handler(router.orgInvitations.BulkResend))
 
 						r.Group(func(r chi.Router) {
 							r.Use(middleware.Deprecated)
@@ -466,6 +540,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 
 						r.Route("/{invitationID}", func(r chi.Router) {
 							r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgInvitations.Read))
+							r.Method(http.MethodPost, "/extend", clerkhttp.Handler(router.orgInvitations.Extend))
 							r.Method(http.MethodPost, "/revoke", clerkhttp.Handler(router.orgInvitations.Revoke))
 						})
 					})
@@ -473,6 +548,8 @@ func (router *Router) BuildRoutes() *chi.Mux {
 					r.Route("/memberships", func(r chi.Router) {
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgMemberships.List))
 						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.orgMemberships.Create))
+						r.Method(http.MethodPost, "/export", clerkhttp.Handler(router.orgMemberships.CreateExport))
+						r.Method(http.MethodGet, "/exports/{exportID}", clerkhttp.Handler(router.orgMemberships.ReadExport))
 
 						r.Route("/{userID}", func(r chi.Router) {
 							r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.orgMemberships.Update))
@@ -480,6 +557,27 @@ func (router *Router) BuildRoutes() *chi.Mux {
 							r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.orgMemberships.Delete))
 						})
 					})
+
+					r.Route("/membership_requests", func(r chi.Router) {
+						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgMemberRequests.List))
+						r.Method(http.MethodPost, "/{requestID}/accept", clerkhttp.Handler(router.orgMemberRequests.Accept))
+						r.Method(http.MethodPost, "/{requestID}/reject", clerkhttp.Handler(router.orgMemberRequests.Reject))
+					})
+
+					r.Route("/webhooks", func(r chi.Router) {
+						r.Method(http.MethodGet, "/event_types", clerkhttp.Handler(router.webhooks.ReadOrganizationEventTypes))
+
+						r.Route("/endpoints", func(r chi.Router) {
+							r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ReadAllOrganizationEndpoints))
+							r.Method(http.MethodPost, "/", clerkhttp.Handler(router.webhooks.CreateOrganizationEndpoint))
+							r.Route("/{endpointID}", func(r chi.Router) {
+								r.Method(http.MethodGet, "/", clerkhttp.Handler(router.webhooks.ReadOrganizationEndpoint))
+								r.Method(http.MethodPut, "/", clerkhttp.Handler(router.webhooks.UpdateOrganizationEndpoint))
+								r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.webhooks.DeleteOrganizationEndpoint))
+								r.Method(http.MethodGet, "/secret", clerkhttp.Handler(router.webhooks.ReadOrganizationEndpointSecret))
+							})
+						})
+					})
 				})
 			})
 		})
@@ -498,6 +596,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.signInTokens.Create))
 
 			r.Route("/{signInTokenID}", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.signInTokens.Read))
 				r.Method(http.MethodPost, "/revoke", clerkhttp.Handler(router.signInTokens.Revoke))
 			})
 		})
@@ -533,6 +632,7 @@ func (router *Router) BuildRoutes() *chi.Mux {
 
 		r.Route("/tokens", func(r chi.Router) {
 			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.tokens.CreateFromTemplate))
+			r.Method(http.MethodPost, "/scoped", clerkhttp.Handler(router.tokens.CreateScoped))
 		})
 
 		r.Route("/beta_features", func(r chi.Router) {
@@ -573,6 +673,18 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			})
 		})
 
+		r.Route("/service_accounts", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.serviceAccounts.List))
+			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.serviceAccounts.Create))
+
+			r.Route("/{serviceAccountID}", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.serviceAccounts.Read))
+				r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.serviceAccounts.Update))
+				r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.serviceAccounts.Delete))
+				r.Method(http.MethodPost, "/rotate_secret", clerkhttp.Handler(router.serviceAccounts.RotateSecret))
+			})
+		})
+
 		r.Route("/proxy_checks", func(r chi.Router) {
 			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.proxyChecks.Create))
 		})
@@ -591,6 +703,38 @@ func (router *Router) BuildRoutes() *chi.Mux {
 			})
 		})
 
+		// SCIM 2.0 provisioning, for identity providers to manage users and
+		// groups. It's available on the same plans as SAML SSO.
+		r.Route("/scim/v2", func(r chi.Router) {
+			r.Use(clerkhttp.Middleware(router.features.CheckSupportedByPlan(clerkbilling.Features.SAML)))
+
+			r.Method(http.MethodGet, "/ServiceProviderConfig", clerkhttp.Handler(router.scim.ServiceProviderConfig))
+
+			r.Route("/Users", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.scim.ListUsers))
+				r.Method(http.MethodPost, "/", clerkhttp.Handler(router.scim.CreateUser))
+
+				r.Route("/{userID}", func(r chi.Router) {
+					r.Method(http.MethodGet, "/", clerkhttp.Handler(router.scim.ReadUser))
+					r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.scim.PatchUser))
+					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.scim.DeleteUser))
+				})
+			})
+
+			r.Route("/Groups", func(r chi.Router) {
+				r.Use(clerkhttp.Middleware(router.organizations.CheckOrganizationsEnabled))
+
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.scim.ListGroups))
+				r.Method(http.MethodPost, "/", clerkhttp.Handler(router.scim.CreateGroup))
+
+				r.Route("/{groupID}", func(r chi.Router) {
+					r.Method(http.MethodGet, "/", clerkhttp.Handler(router.scim.ReadGroup))
+					r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.scim.PatchGroup))
+					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.scim.DeleteGroup))
+				})
+			})
+		})
+
 		r.Route("/organization_roles", func(r chi.Router) {
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.instanceOrgRoles.List))
 		})
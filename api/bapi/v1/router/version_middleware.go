@@ -4,8 +4,8 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/ctx/sdkversion"
-	"clerk/utils/log"
 )
 
 const (
@@ -18,7 +18,7 @@ func logClerkSDKVersion(_ http.ResponseWriter, r *http.Request) (*http.Request,
 	ctx := r.Context()
 	version := readVersion(r)
 	ctx = sdkversion.NewContext(ctx, sdkversion.SDKVersion(version))
-	log.AddToLogLine(ctx, log.ClerkSDKVersion, version)
+	requestlog.Add(ctx, requestlog.ClerkSDKVersion, version)
 
 	return r.WithContext(ctx), nil
 }
@@ -6,8 +6,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/clerkhttp"
-	"clerk/utils/log"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -37,8 +37,8 @@ func TestLogClerkSDKVersion(t *testing.T) {
 			t.Parallel()
 			var actualVersion string
 			fn := http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
-				logLine, _ := log.GetLogLine(r.Context())
-				actualVersion = logLine.ValueFromContext(log.ClerkSDKVersion).(string)
+				entry, _ := requestlog.FromContext(r.Context())
+				actualVersion = entry.Fields()[requestlog.ClerkSDKVersion].(string)
 			})
 
 			handlerToTest := clerkhttp.Middleware(logClerkSDKVersion)
@@ -48,8 +48,7 @@ func TestLogClerkSDKVersion(t *testing.T) {
 				req.Header.Set(key, val)
 			}
 
-			logLine := log.NewCanonicalLine(req, &loggableResponseRecorder{ResponseRecorder: httptest.NewRecorder()})
-			ctx := log.AddLogLineToContext(context.Background(), logLine)
+			ctx, _ := requestlog.NewContext(context.Background())
 			req = req.WithContext(ctx)
 
 			handlerToTest(fn).ServeHTTP(httptest.NewRecorder(), req)
@@ -57,19 +56,3 @@ func TestLogClerkSDKVersion(t *testing.T) {
 		})
 	}
 }
-
-type loggableResponseRecorder struct {
-	*httptest.ResponseRecorder
-}
-
-func (l *loggableResponseRecorder) ResponseSize() int64 {
-	return int64(l.ResponseRecorder.Body.Len())
-}
-
-func (l *loggableResponseRecorder) ResponseBytes() []byte {
-	return l.ResponseRecorder.Body.Bytes()
-}
-
-func (l *loggableResponseRecorder) StatusCode() int {
-	return l.ResponseRecorder.Code
-}
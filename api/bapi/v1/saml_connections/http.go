@@ -5,6 +5,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/shared/pagination"
+	shsamlconnections "clerk/api/shared/saml_connections"
 	"clerk/pkg/clerkhttp"
 	"clerk/utils/clerk"
 
@@ -21,7 +22,7 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 
 // POST /v1/saml_connections
 func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
-	params := CreateParams{}
+	params := shsamlconnections.CreateParams{}
 	if err := clerkhttp.Decode(r, &params); err != nil {
 		return nil, err
 	}
@@ -31,7 +32,7 @@ func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 
 // PATCH /v1/saml_connections/{samlConnectionID}
 func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
-	params := UpdateParams{}
+	params := shsamlconnections.UpdateParams{}
 	if err := clerkhttp.Decode(r, &params); err != nil {
 		return nil, err
 	}
@@ -46,10 +47,11 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
-	params := ListParams{
-		pagination: paginationParams,
-		query:      clerkhttp.GetOptionalQueryParam(r, "query"),
-		orderBy:    clerkhttp.GetOptionalQueryParam(r, "order_by"),
+	params := shsamlconnections.ListParams{
+		Pagination:     paginationParams,
+		Query:          clerkhttp.GetOptionalQueryParam(r, "query"),
+		OrderBy:        clerkhttp.GetOptionalQueryParam(r, "order_by"),
+		OrganizationID: clerkhttp.GetOptionalQueryParam(r, "organization_id"),
 	}
 
 	return h.service.List(r.Context(), params)
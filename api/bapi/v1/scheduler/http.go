@@ -9,6 +9,7 @@ import (
 	"clerk/api/bapi/v1/dnschecks"
 	"clerk/api/bapi/v1/pricing"
 	"clerk/api/shared/emailquality"
+	"clerk/api/shared/sign_up_funnel"
 	clerkbilling "clerk/pkg/billing"
 	"clerk/pkg/cenv"
 	"clerk/pkg/clerkhttp"
@@ -27,6 +28,7 @@ type HTTP struct {
 	dnsService          *dnschecks.Service
 	schedulerService    *Service
 	emailQualityService *emailquality.EmailQuality
+	signUpFunnelService *sign_up_funnel.Service
 }
 
 func NewHTTP(
@@ -37,10 +39,11 @@ func NewHTTP(
 	return &HTTP{
 		gueClient:           deps.GueClient(),
 		pricingService:      pricing.NewService(deps, paymentProvider),
-		cleanupService:      cleanup.NewService(deps.Clock(), deps.DB(), deps.GueClient()),
+		cleanupService:      cleanup.NewService(deps.Clock(), deps.DB(), deps.GueClient(), deps.StorageClient()),
 		dnsService:          dnschecks.NewService(deps.DB(), dnsResolver, deps.GueClient(), deps.CloudflareIPRangeClient(), deps.CertCheckHostHealthHTTPClient()),
 		schedulerService:    NewService(deps.GueClient()),
 		emailQualityService: deps.EmailQualityChecker(),
+		signUpFunnelService: sign_up_funnel.NewService(deps),
 	}
 }
 
@@ -92,6 +95,26 @@ func (h *HTTP) OrphanOrganizations(w http.ResponseWriter, r *http.Request) (inte
 	return nil, nil
 }
 
+// POST /v1/internal/cleanup/deleted_users
+func (h *HTTP) DeletedUsers(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.cleanupService.DeletedUsers(r.Context(), getLimit(r)); err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// POST /v1/internal/cleanup/deleted_organizations
+func (h *HTTP) DeletedOrganizations(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.cleanupService.DeletedOrganizations(r.Context(), getLimit(r)); err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
 // POST /v1/internal/cleanup/expired_oauth_tokens
 func (h *HTTP) ExpiredOAuthTokens(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	err := h.cleanupService.ExpiredOAuthTokens(r.Context())
@@ -103,6 +126,36 @@ func (h *HTTP) ExpiredOAuthTokens(w http.ResponseWriter, r *http.Request) (inter
 	return nil, nil
 }
 
+// POST /v1/internal/cleanup/expired_webhook_events
+func (h *HTTP) ExpiredWebhookEvents(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.cleanupService.ExpiredWebhookEvents(r.Context(), getLimit(r)); err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// POST /v1/internal/cleanup/expired_organization_invitations
+func (h *HTTP) ExpiredOrganizationInvitations(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.cleanupService.ExpiredOrganizationInvitations(r.Context(), getLimit(r)); err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// POST /v1/internal/cleanup/pending_image_uploads
+func (h *HTTP) PendingImageUploads(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.cleanupService.PendingImageUploads(r.Context(), getLimit(r)); err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
 // POST /v1/internal/stripe/usage_report_jobs
 func (h *HTTP) StripeUsageReportJobs(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	if err := h.pricingService.CreateUsageReportJobs(r.Context()); err != nil {
@@ -205,6 +258,16 @@ func (h *HTTP) CreateHypeStats(w http.ResponseWriter, r *http.Request) (interfac
 	return nil, nil
 }
 
+// POST /v1/internal/analytics/sign_up_funnel
+func (h *HTTP) AggregateSignUpFunnel(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if err := h.signUpFunnelService.EnqueueAggregations(r.Context()); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
 // POST /v1/internal/stripe/refresh_cache_responses
 func (h *HTTP) StripeRefreshCacheResponses(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	if err := h.pricingService.RefreshCacheResponses(r.Context()); err != nil {
@@ -0,0 +1,314 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"clerk/api/apierror"
+)
+
+// Filter is a parsed SCIM filter expression, see RFC 7644 section 3.4.2.2.
+type Filter interface {
+	isFilter()
+}
+
+// Comparison compares an attribute against a value. Value is nil for the
+// "pr" (present) operator.
+type Comparison struct {
+	Attribute string
+	Operator  string
+	Value     interface{}
+}
+
+// Logical combines two filters with "and" or "or".
+type Logical struct {
+	Operator    string
+	Left, Right Filter
+}
+
+// Not negates a filter.
+type Not struct {
+	Filter Filter
+}
+
+func (Comparison) isFilter() {}
+func (Logical) isFilter()    {}
+func (Not) isFilter()        {}
+
+const (
+	opEqual      = "eq"
+	opNotEqual   = "ne"
+	opContains   = "co"
+	opStartsWith = "sw"
+	opEndsWith   = "ew"
+	opGreater    = "gt"
+	opGreaterEq  = "ge"
+	opLess       = "lt"
+	opLessEq     = "le"
+	opPresent    = "pr"
+	opAnd        = "and"
+	opOr         = "or"
+	opNot        = "not"
+)
+
+var comparisonOperators = map[string]bool{
+	opEqual: true, opNotEqual: true, opContains: true, opStartsWith: true, opEndsWith: true,
+	opGreater: true, opGreaterEq: true, opLess: true, opLessEq: true,
+}
+
+// ParseFilter parses a SCIM filter expression. Attribute names are case
+// insensitive, so they are returned in lowercase, without any schema URN
+// prefix.
+func ParseFilter(filter string) (Filter, apierror.Error) {
+	tokens, err := tokenizeFilter(filter)
+	if err != nil {
+		return nil, apierror.SCIMInvalidFilter(filter, err.Error())
+	}
+
+	p := &filterParser{tokens: tokens}
+	parsed, err := p.parseOr()
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected %q", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return nil, apierror.SCIMInvalidFilter(filter, err.Error())
+	}
+	return parsed, nil
+}
+
+type filterTokenKind int
+
+const (
+	tokenWord filterTokenKind = iota
+	tokenString
+	tokenOpenParen
+	tokenCloseParen
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string
+}
+
+func tokenizeFilter(filter string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(filter); {
+		switch c := filter[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{kind: tokenOpenParen, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{kind: tokenCloseParen, text: ")"})
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(filter) && filter[end] != '"'; end++ {
+				if filter[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(filter) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			var value string
+			if err := json.Unmarshal([]byte(filter[i:end+1]), &value); err != nil {
+				return nil, fmt.Errorf("invalid string %s", filter[i:end+1])
+			}
+			tokens = append(tokens, filterToken{kind: tokenString, text: value})
+			i = end + 1
+		case c == '[' || c == ']':
+			return nil, fmt.Errorf("complex attribute filters are not supported")
+		default:
+			end := len(filter)
+			if n := strings.IndexAny(filter[i:], " \t()\"[]"); n >= 0 {
+				end = i + n
+			}
+			tokens = append(tokens, filterToken{kind: tokenWord, text: filter[i:end]})
+			i = end
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+	return tokens, nil
+}
+
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peekWord(word string) bool {
+	return p.pos < len(p.tokens) &&
+		p.tokens[p.pos].kind == tokenWord &&
+		strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord(opOr) {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Operator: opOr, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekWord(opAnd) {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = Logical{Operator: opAnd, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseTerm() (Filter, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of filter")
+	}
+
+	if p.peekWord(opNot) {
+		p.pos++
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOpenParen {
+			return nil, fmt.Errorf("expected ( after not")
+		}
+		inner, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Filter: inner}, nil
+	}
+
+	if p.tokens[p.pos].kind == tokenOpenParen {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenCloseParen {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (Filter, error) {
+	attr := p.tokens[p.pos]
+	if attr.kind != tokenWord {
+		return nil, fmt.Errorf("expected attribute, got %q", attr.text)
+	}
+	p.pos++
+
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenWord {
+		return nil, fmt.Errorf("expected operator after %s", attr.text)
+	}
+	operator := strings.ToLower(p.tokens[p.pos].text)
+	p.pos++
+
+	comparison := Comparison{Attribute: normalizeAttribute(attr.text), Operator: operator}
+	if operator == opPresent {
+		return comparison, nil
+	}
+	if !comparisonOperators[operator] {
+		return nil, fmt.Errorf("unknown operator %s", operator)
+	}
+
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("expected value after %s %s", attr.text, operator)
+	}
+	value := p.tokens[p.pos]
+	p.pos++
+
+	switch value.kind {
+	case tokenString:
+		comparison.Value = value.text
+	case tokenWord:
+		var v interface{}
+		if err := json.Unmarshal([]byte(strings.ToLower(value.text)), &v); err != nil {
+			return nil, fmt.Errorf("invalid value %s", value.text)
+		}
+		comparison.Value = v
+	default:
+		return nil, fmt.Errorf("expected value after %s %s", attr.text, operator)
+	}
+	return comparison, nil
+}
+
+// normalizeAttribute lowercases the attribute path and strips the schema
+// URN prefix, e.g. "urn:ietf:params:scim:schemas:core:2.0:User:userName"
+// becomes "username".
+func normalizeAttribute(attr string) string {
+	if strings.HasPrefix(strings.ToLower(attr), "urn:") {
+		if i := strings.LastIndex(attr, ":"); i >= 0 {
+			attr = attr[i+1:]
+		}
+	}
+	return strings.ToLower(attr)
+}
+
+// EqualityValues returns the values each attribute of the filter must be
+// equal to. Only filters which can be answered with exact lookups are
+// supported: "eq" comparisons on distinct attributes joined with "and",
+// and "eq" comparisons on the same attribute joined with "or".
+func EqualityValues(filter Filter) (map[string][]string, error) {
+	switch f := filter.(type) {
+	case Comparison:
+		value, ok := f.Value.(string)
+		if f.Operator != opEqual || !ok {
+			return nil, fmt.Errorf("only eq comparisons with string values are supported")
+		}
+		return map[string][]string{f.Attribute: {value}}, nil
+
+	case Logical:
+		left, err := EqualityValues(f.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := EqualityValues(f.Right)
+		if err != nil {
+			return nil, err
+		}
+
+		if f.Operator == opAnd {
+			for attr, values := range right {
+				if _, exists := left[attr]; exists {
+					return nil, fmt.Errorf("%s can only be compared once with and", attr)
+				}
+				left[attr] = values
+			}
+			return left, nil
+		}
+
+		if len(left) != 1 || len(right) != 1 {
+			return nil, fmt.Errorf("or is only supported between comparisons of the same attribute")
+		}
+		for attr, values := range right {
+			if _, exists := left[attr]; !exists {
+				return nil, fmt.Errorf("or is only supported between comparisons of the same attribute")
+			}
+			left[attr] = append(left[attr], values...)
+		}
+		return left, nil
+	}
+	return nil, fmt.Errorf("not is not supported")
+}
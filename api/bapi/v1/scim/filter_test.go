@@ -0,0 +1,109 @@
+package scim
+
+import (
+	"testing"
+
+	"clerk/api/apierror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter  string
+		want    Filter
+		wantErr bool
+	}{
+		{
+			filter: `userName eq "Jane@Clerk.dev"`,
+			want:   Comparison{Attribute: "username", Operator: opEqual, Value: "Jane@Clerk.dev"},
+		},
+		{
+			filter: `urn:ietf:params:scim:schemas:core:2.0:User:externalId EQ "ext_1"`,
+			want:   Comparison{Attribute: "externalid", Operator: opEqual, Value: "ext_1"},
+		},
+		{
+			filter: `title pr and (active eq true or not (emails.value ew "@clerk.dev"))`,
+			want: Logical{
+				Operator: opAnd,
+				Left:     Comparison{Attribute: "title", Operator: opPresent},
+				Right: Logical{
+					Operator: opOr,
+					Left:     Comparison{Attribute: "active", Operator: opEqual, Value: true},
+					Right:    Not{Filter: Comparison{Attribute: "emails.value", Operator: opEndsWith, Value: "@clerk.dev"}},
+				},
+			},
+		},
+		{
+			filter: `displayName eq "say \"hi\""`,
+			want:   Comparison{Attribute: "displayname", Operator: opEqual, Value: `say "hi"`},
+		},
+		{filter: ``, wantErr: true},
+		{filter: `userName eq`, wantErr: true},
+		{filter: `userName like "jane"`, wantErr: true},
+		{filter: `userName eq "jane`, wantErr: true},
+		{filter: `(userName eq "jane"`, wantErr: true},
+		{filter: `emails[type eq "work"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+
+			filter, apiErr := ParseFilter(tt.filter)
+			if tt.wantErr {
+				require.NotNil(t, apiErr)
+				assert.Equal(t, apierror.SCIMInvalidFilterCode, apiErr.Errors()[0].Code())
+				return
+			}
+			require.Nil(t, apiErr)
+			assert.Equal(t, tt.want, filter)
+		})
+	}
+}
+
+func TestEqualityValues(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter  string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			filter: `userName eq "jane" and externalId eq "ext_1"`,
+			want:   map[string][]string{"username": {"jane"}, "externalid": {"ext_1"}},
+		},
+		{
+			filter: `value eq "user_1" or value eq "user_2"`,
+			want:   map[string][]string{"value": {"user_1", "user_2"}},
+		},
+		{filter: `userName eq "jane" or externalId eq "ext_1"`, wantErr: true},
+		{filter: `userName eq "jane" and userName eq "john"`, wantErr: true},
+		{filter: `userName sw "ja"`, wantErr: true},
+		{filter: `active eq true`, wantErr: true},
+		{filter: `not (userName eq "jane")`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.filter, func(t *testing.T) {
+			t.Parallel()
+
+			filter, apiErr := ParseFilter(tt.filter)
+			require.Nil(t, apiErr)
+
+			values, err := EqualityValues(filter)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, values)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/pkg/readreplica"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	contentType = "application/scim+json"
+	pathPrefix  = "/scim/v2"
+)
+
+// HTTP is the http layer for the SCIM 2.0 provisioning endpoints.
+//
+// SCIM clients expect SCIM messages, so handlers write their responses
+// themselves, both on success and on error, instead of returning them to
+// clerkhttp.Handler.
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps, db *readreplica.Database) *HTTP {
+	return &HTTP{service: NewService(deps, db)}
+}
+
+// GET /v1/scim/v2/ServiceProviderConfig
+func (h *HTTP) ServiceProviderConfig(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return respond(w, r, http.StatusOK, h.service.ServiceProviderConfig(), nil)
+}
+
+// GET /v1/scim/v2/Users
+func (h *HTTP) ListUsers(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params, apiErr := toListParams(r)
+	if apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.ListUsers(r.Context(), baseURL(r), params)
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// POST /v1/scim/v2/Users
+func (h *HTTP) CreateUser(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateUserParams
+	if apiErr := decode(r, &params); apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.CreateUser(r.Context(), baseURL(r), params)
+	return respond(w, r, http.StatusCreated, response, apiErr)
+}
+
+// GET /v1/scim/v2/Users/{userID}
+func (h *HTTP) ReadUser(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	response, apiErr := h.service.ReadUser(r.Context(), baseURL(r), chi.URLParam(r, "userID"))
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// PATCH /v1/scim/v2/Users/{userID}
+func (h *HTTP) PatchUser(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params PatchRequest
+	if apiErr := decode(r, &params); apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.PatchUser(r.Context(), baseURL(r), chi.URLParam(r, "userID"), params.Operations)
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// DELETE /v1/scim/v2/Users/{userID}
+func (h *HTTP) DeleteUser(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	apiErr := h.service.DeleteUser(r.Context(), chi.URLParam(r, "userID"))
+	return respond(w, r, http.StatusNoContent, nil, apiErr)
+}
+
+// GET /v1/scim/v2/Groups
+func (h *HTTP) ListGroups(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params, apiErr := toListParams(r)
+	if apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.ListGroups(r.Context(), baseURL(r), params)
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// POST /v1/scim/v2/Groups
+func (h *HTTP) CreateGroup(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateGroupParams
+	if apiErr := decode(r, &params); apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.CreateGroup(r.Context(), baseURL(r), params)
+	return respond(w, r, http.StatusCreated, response, apiErr)
+}
+
+// GET /v1/scim/v2/Groups/{groupID}
+func (h *HTTP) ReadGroup(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	response, apiErr := h.service.ReadGroup(r.Context(), baseURL(r), chi.URLParam(r, "groupID"), excludeMembers(r))
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// PATCH /v1/scim/v2/Groups/{groupID}
+func (h *HTTP) PatchGroup(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params PatchRequest
+	if apiErr := decode(r, &params); apiErr != nil {
+		return respond(w, r, 0, nil, apiErr)
+	}
+	response, apiErr := h.service.PatchGroup(r.Context(), baseURL(r), chi.URLParam(r, "groupID"), params.Operations)
+	return respond(w, r, http.StatusOK, response, apiErr)
+}
+
+// DELETE /v1/scim/v2/Groups/{groupID}
+func (h *HTTP) DeleteGroup(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	apiErr := h.service.DeleteGroup(r.Context(), chi.URLParam(r, "groupID"))
+	return respond(w, r, http.StatusNoContent, nil, apiErr)
+}
+
+// toListParams reads the filter and the one-based pagination parameters of
+// a SCIM list request.
+func toListParams(r *http.Request) (ListParams, apierror.Error) {
+	params := ListParams{
+		Filter:         r.URL.Query().Get("filter"),
+		StartIndex:     1,
+		Count:          100,
+		ExcludeMembers: excludeMembers(r),
+	}
+
+	if startIndex := r.URL.Query().Get("startIndex"); startIndex != "" {
+		v, err := strconv.Atoi(startIndex)
+		if err != nil {
+			return params, apierror.FormInvalidTypeParameter("startIndex", "integer")
+		}
+		// Values less than one are interpreted as one.
+		if v > 1 {
+			params.StartIndex = v
+		}
+	}
+
+	if count := r.URL.Query().Get("count"); count != "" {
+		v, err := strconv.Atoi(count)
+		if err != nil {
+			return params, apierror.FormInvalidTypeParameter("count", "integer")
+		}
+		if v < 1 {
+			return params, apierror.FormInvalidParameterValue("count", count)
+		}
+		// Larger pages are capped, clients follow totalResults.
+		if v > maxResults {
+			v = maxResults
+		}
+		params.Count = v
+	}
+	return params, nil
+}
+
+func excludeMembers(r *http.Request) bool {
+	for _, attribute := range strings.Split(r.URL.Query().Get("excludedAttributes"), ",") {
+		if normalizeAttribute(strings.TrimSpace(attribute)) == "members" {
+			return true
+		}
+	}
+	return false
+}
+
+func decode(r *http.Request, v interface{}) apierror.Error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return apierror.SCIMInvalidSyntax()
+	}
+	return nil
+}
+
+// baseURL returns the URL under which the SCIM resources are served, to
+// build the locations of the resources.
+func baseURL(r *http.Request) string {
+	path := r.URL.Path
+	if i := strings.Index(path, pathPrefix); i >= 0 {
+		path = path[:i+len(pathPrefix)]
+	}
+	return "https://" + r.Host + path
+}
+
+// respond writes the response, or the error as a SCIM error message.
+func respond(w http.ResponseWriter, r *http.Request, status int, response interface{}, apiErr apierror.Error) (interface{}, apierror.Error) {
+	w.Header().Set("Content-Type", contentType)
+
+	if apiErr != nil {
+		status = apiErr.HTTPCode()
+		response = toSCIMError(apiErr)
+	}
+
+	w.WriteHeader(status)
+	if status == http.StatusNoContent {
+		return nil, nil
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Warning(r.Context(), "scim/respond: writing response: %s", err)
+	}
+	return nil, nil
+}
+
+// scimTypes maps error codes to the SCIM error types of RFC 7644 section
+// 3.12.
+var scimTypes = map[string]string{
+	apierror.SCIMInvalidFilterCode:    "invalidFilter",
+	apierror.SCIMInvalidPathCode:      "invalidPath",
+	apierror.SCIMInvalidValueCode:     "invalidValue",
+	apierror.SCIMInvalidSyntaxCode:    "invalidSyntax",
+	apierror.FormIdentifierExistsCode: "uniqueness",
+}
+
+func toSCIMError(apiErr apierror.Error) *serialize.SCIMErrorResponse {
+	var scimType, detail string
+	if errs := apiErr.Errors(); len(errs) > 0 {
+		scimType = scimTypes[errs[0].Code()]
+		detail = errs[0].LongMessage()
+		if detail == "" {
+			detail = errs[0].ShortMessage()
+		}
+	}
+	return serialize.SCIMError(apiErr.HTTPCode(), scimType, detail)
+}
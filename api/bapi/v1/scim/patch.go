@@ -0,0 +1,255 @@
+package scim
+
+import (
+	"encoding/json"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/bapi/v1/users"
+	clerkjson "clerk/pkg/json"
+)
+
+const (
+	patchOpAdd     = "add"
+	patchOpReplace = "replace"
+	patchOpRemove  = "remove"
+)
+
+// PatchRequest is a SCIM PATCH request, see RFC 7644 section 3.5.2.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// userPatch holds the changes of a PATCH request on a user. Activation is
+// kept apart from the update params, as it maps to banning the user.
+type userPatch struct {
+	params users.UpdateParams
+	active *bool
+}
+
+// toUserPatch maps the operations of a PATCH request on a SCIM User onto the
+// params of users.Service.Update. The userName is only mutable for instances
+// which have usernames, as it is the primary email address otherwise.
+func toUserPatch(operations []PatchOperation, usernameEnabled bool) (*userPatch, apierror.Error) {
+	patch := &userPatch{}
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != patchOpAdd && op != patchOpReplace && op != patchOpRemove {
+			return nil, apierror.SCIMInvalidValue("op")
+		}
+
+		// Without a path, the value holds the attributes to change.
+		if operation.Path == "" {
+			if op == patchOpRemove {
+				return nil, apierror.SCIMInvalidPath(operation.Path)
+			}
+			var attributes map[string]json.RawMessage
+			if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+				return nil, apierror.SCIMInvalidValue("value")
+			}
+			for path, value := range attributes {
+				if apiErr := patch.apply(op, path, value, usernameEnabled); apiErr != nil {
+					return nil, apiErr
+				}
+			}
+			continue
+		}
+
+		if apiErr := patch.apply(op, operation.Path, operation.Value, usernameEnabled); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return patch, nil
+}
+
+func (p *userPatch) apply(op, path string, value json.RawMessage, usernameEnabled bool) apierror.Error {
+	var apiErr apierror.Error
+	switch normalizeAttribute(path) {
+	case "active":
+		if op == patchOpRemove {
+			return apierror.SCIMInvalidPath(path)
+		}
+		active, ok := parseBool(value)
+		if !ok {
+			return apierror.SCIMInvalidValue(path)
+		}
+		p.active = &active
+	case "username":
+		if !usernameEnabled {
+			return apierror.SCIMInvalidPath(path)
+		}
+		p.params.Username, apiErr = patchString(op, path, value)
+	case "externalid":
+		p.params.ExternalID, apiErr = patchString(op, path, value)
+	case "name.givenname":
+		p.params.FirstName, apiErr = patchString(op, path, value)
+	case "name.familyname":
+		p.params.LastName, apiErr = patchString(op, path, value)
+	case "name":
+		if op == patchOpRemove {
+			p.params.FirstName = clerkjson.StringFromPtr(nil)
+			p.params.LastName = clerkjson.StringFromPtr(nil)
+			return nil
+		}
+		var name struct {
+			GivenName  *string `json:"givenName"`
+			FamilyName *string `json:"familyName"`
+		}
+		if err := json.Unmarshal(value, &name); err != nil {
+			return apierror.SCIMInvalidValue(path)
+		}
+		if name.GivenName != nil {
+			p.params.FirstName = clerkjson.StringFrom(*name.GivenName)
+		}
+		if name.FamilyName != nil {
+			p.params.LastName = clerkjson.StringFrom(*name.FamilyName)
+		}
+	default:
+		return apierror.SCIMInvalidPath(path)
+	}
+	return apiErr
+}
+
+// groupPatch holds the changes of a PATCH request on a group.
+type groupPatch struct {
+	displayName   *string
+	addMembers    []string
+	removeMembers []string
+}
+
+// toGroupPatch maps the operations of a PATCH request on a SCIM Group onto
+// changes to the organization and its memberships. Members can be removed
+// either by listing them in the value, or with a filter in the path, e.g.
+// members[value eq "user_123"].
+func toGroupPatch(operations []PatchOperation) (*groupPatch, apierror.Error) {
+	patch := &groupPatch{}
+	for _, operation := range operations {
+		op := strings.ToLower(operation.Op)
+		if op != patchOpAdd && op != patchOpReplace && op != patchOpRemove {
+			return nil, apierror.SCIMInvalidValue("op")
+		}
+
+		if operation.Path == "" {
+			if op == patchOpRemove {
+				return nil, apierror.SCIMInvalidPath(operation.Path)
+			}
+			var attributes map[string]json.RawMessage
+			if err := json.Unmarshal(operation.Value, &attributes); err != nil {
+				return nil, apierror.SCIMInvalidValue("value")
+			}
+			for path, value := range attributes {
+				if apiErr := patch.apply(op, path, value); apiErr != nil {
+					return nil, apiErr
+				}
+			}
+			continue
+		}
+
+		if apiErr := patch.apply(op, operation.Path, operation.Value); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return patch, nil
+}
+
+func (p *groupPatch) apply(op, path string, value json.RawMessage) apierror.Error {
+	attribute, valueFilter, hasFilter := strings.Cut(path, "[")
+	if hasFilter {
+		if normalizeAttribute(attribute) != "members" || op != patchOpRemove || !strings.HasSuffix(valueFilter, "]") {
+			return apierror.SCIMInvalidPath(path)
+		}
+		userIDs, apiErr := memberValuesFromFilter(strings.TrimSuffix(valueFilter, "]"))
+		if apiErr != nil {
+			return apiErr
+		}
+		p.removeMembers = append(p.removeMembers, userIDs...)
+		return nil
+	}
+
+	switch normalizeAttribute(path) {
+	case "displayname":
+		var displayName string
+		if op == patchOpRemove || json.Unmarshal(value, &displayName) != nil || displayName == "" {
+			return apierror.SCIMInvalidValue(path)
+		}
+		p.displayName = &displayName
+	case "members":
+		// Replacing all members at once isn't supported, since the
+		// organization must keep at least one admin at all times.
+		if op == patchOpReplace {
+			return apierror.SCIMInvalidPath(path)
+		}
+		var members []struct {
+			Value string `json:"value"`
+		}
+		if err := json.Unmarshal(value, &members); err != nil {
+			return apierror.SCIMInvalidValue(path)
+		}
+		for _, member := range members {
+			if op == patchOpAdd {
+				p.addMembers = append(p.addMembers, member.Value)
+			} else {
+				p.removeMembers = append(p.removeMembers, member.Value)
+			}
+		}
+	default:
+		return apierror.SCIMInvalidPath(path)
+	}
+	return nil
+}
+
+// memberValuesFromFilter returns the user IDs matched by the value filter of
+// a members path.
+func memberValuesFromFilter(filter string) ([]string, apierror.Error) {
+	parsed, apiErr := ParseFilter(filter)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	values, err := EqualityValues(parsed)
+	if err != nil {
+		return nil, apierror.SCIMInvalidFilter(filter, err.Error())
+	}
+	userIDs, ok := values["value"]
+	if !ok || len(values) != 1 {
+		return nil, apierror.SCIMInvalidFilter(filter, "members can only be filtered by value")
+	}
+	return userIDs, nil
+}
+
+func patchString(op, path string, value json.RawMessage) (clerkjson.String, apierror.Error) {
+	if op == patchOpRemove {
+		return clerkjson.StringFromPtr(nil), nil
+	}
+	var s *string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return clerkjson.String{}, apierror.SCIMInvalidValue(path)
+	}
+	return clerkjson.StringFromPtr(s), nil
+}
+
+// parseBool parses a boolean, which some identity providers send as a
+// string, e.g. "False".
+func parseBool(value json.RawMessage) (bool, bool) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, true
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, false
+	}
+	switch strings.ToLower(s) {
+	case "true":
+		return true, true
+	case "false":
+		return false, true
+	}
+	return false, false
+}
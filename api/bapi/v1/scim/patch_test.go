@@ -0,0 +1,72 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"clerk/api/apierror"
+	clerkjson "clerk/pkg/json"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToUserPatch(t *testing.T) {
+	t.Parallel()
+
+	patch, apiErr := toUserPatch([]PatchOperation{
+		{Op: "Replace", Path: "active", Value: json.RawMessage(`"False"`)},
+		{Op: "replace", Path: "name.givenName", Value: json.RawMessage(`"Jane"`)},
+		{Op: "remove", Path: "externalId"},
+		{Op: "add", Value: json.RawMessage(`{"userName":"jane","name":{"familyName":"Doe"}}`)},
+	}, true)
+	require.Nil(t, apiErr)
+
+	require.NotNil(t, patch.active)
+	assert.False(t, *patch.active)
+	assert.Equal(t, clerkjson.StringFrom("Jane"), patch.params.FirstName)
+	assert.Equal(t, clerkjson.StringFrom("Doe"), patch.params.LastName)
+	assert.Equal(t, clerkjson.StringFrom("jane"), patch.params.Username)
+	assert.Equal(t, clerkjson.StringFromPtr(nil), patch.params.ExternalID)
+
+	_, apiErr = toUserPatch([]PatchOperation{
+		{Op: "replace", Path: "userName", Value: json.RawMessage(`"jane@clerk.dev"`)},
+	}, false)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SCIMInvalidPathCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = toUserPatch([]PatchOperation{
+		{Op: "move", Path: "active", Value: json.RawMessage(`true`)},
+	}, false)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SCIMInvalidValueCode, apiErr.Errors()[0].Code())
+}
+
+func TestToGroupPatch(t *testing.T) {
+	t.Parallel()
+
+	patch, apiErr := toGroupPatch([]PatchOperation{
+		{Op: "replace", Path: "displayName", Value: json.RawMessage(`"Engineering"`)},
+		{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"user_1"},{"value":"user_2"}]`)},
+		{Op: "remove", Path: `members[value eq "user_3"]`},
+		{Op: "remove", Path: "members", Value: json.RawMessage(`[{"value":"user_4"}]`)},
+	})
+	require.Nil(t, apiErr)
+
+	require.NotNil(t, patch.displayName)
+	assert.Equal(t, "Engineering", *patch.displayName)
+	assert.Equal(t, []string{"user_1", "user_2"}, patch.addMembers)
+	assert.Equal(t, []string{"user_3", "user_4"}, patch.removeMembers)
+
+	_, apiErr = toGroupPatch([]PatchOperation{
+		{Op: "replace", Path: "members", Value: json.RawMessage(`[]`)},
+	})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SCIMInvalidPathCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = toGroupPatch([]PatchOperation{
+		{Op: "remove", Path: `members[display eq "Jane"]`},
+	})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SCIMInvalidFilterCode, apiErr.Errors()[0].Code())
+}
@@ -0,0 +1,506 @@
+package scim
+
+import (
+	"context"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/bapi/v1/organization_memberships"
+	"clerk/api/bapi/v1/organizations"
+	"clerk/api/bapi/v1/users"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/serializable"
+	shusers "clerk/api/shared/users"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/readreplica"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/pkg/usersettings/clerk/names"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+// maxResults is the largest page of resources which can be requested at
+// once.
+const maxResults = 500
+
+// Service implements the SCIM 2.0 Users and Groups resources on top of the
+// users and organizations of the instance. Groups are backed by
+// organizations and their members by organization memberships.
+type Service struct {
+	db database.Database
+
+	// services
+	orgMembershipsService *organization_memberships.Service
+	orgsService           *organizations.Service
+	serializableService   *serializable.Service
+	shUsersService        *shusers.Service
+	usersService          *users.Service
+
+	// repositories
+	orgMembershipsRepo *repository.OrganizationMembership
+	orgsRepo           *repository.Organization
+	userRepo           *repository.Users
+}
+
+func NewService(deps clerk.Deps, db *readreplica.Database) *Service {
+	return &Service{
+		db:                    deps.DB(),
+		orgMembershipsService: organization_memberships.NewService(deps, db),
+		orgsService:           organizations.NewService(deps, db),
+		serializableService:   serializable.NewService(deps.Clock()),
+		shUsersService:        shusers.NewService(deps),
+		usersService:          users.NewService(deps),
+		orgMembershipsRepo:    deps.Repositories().OrganizationMembership,
+		orgsRepo:              deps.Repositories().Organization,
+		userRepo:              deps.Repositories().Users,
+	}
+}
+
+// ListParams holds the parameters of a SCIM list request. StartIndex is
+// one-based.
+type ListParams struct {
+	Filter         string
+	StartIndex     int
+	Count          int
+	ExcludeMembers bool
+}
+
+func (p ListParams) pagination() pagination.Params {
+	return pagination.Params{Limit: p.Count, Offset: p.StartIndex - 1}
+}
+
+func (p ListParams) equalityValues() (map[string][]string, apierror.Error) {
+	if p.Filter == "" {
+		return map[string][]string{}, nil
+	}
+	filter, apiErr := ParseFilter(p.Filter)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	values, err := EqualityValues(filter)
+	if err != nil {
+		return nil, apierror.SCIMInvalidFilter(p.Filter, err.Error())
+	}
+	return values, nil
+}
+
+func (s *Service) ServiceProviderConfig() *serialize.SCIMServiceProviderConfigResponse {
+	return serialize.SCIMServiceProviderConfig(maxResults)
+}
+
+// ListUsers returns the users which match the filter of the params. Users
+// can be filtered by id, externalId, userName and email address.
+func (s *Service) ListUsers(ctx context.Context, baseURL string, params ListParams) (*serialize.SCIMListResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	values, apiErr := params.equalityValues()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var mods repository.UsersFindAllModifiers
+	for attribute, attributeValues := range values {
+		switch attribute {
+		case "id":
+			mods.UserIDs = repository.NewParamsWithExclusion(attributeValues...)
+		case "externalid":
+			mods.ExternalIDs = repository.NewParamsWithExclusion(attributeValues...)
+		case "username":
+			if !userSettings.GetAttribute(names.Username).Base().Enabled {
+				mods.EmailAddresses = append(mods.EmailAddresses, toLower(attributeValues)...)
+				continue
+			}
+			mods.Usernames = toLower(attributeValues)
+		case "emails", "emails.value":
+			mods.EmailAddresses = append(mods.EmailAddresses, toLower(attributeValues)...)
+		default:
+			return nil, apierror.SCIMInvalidFilter(params.Filter, "filtering by "+attribute+" is not supported")
+		}
+	}
+
+	foundUsers, err := s.userRepo.FindAllWithModifiers(ctx, s.db, env.Instance.ID, mods, params.pagination())
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	totalCount, err := s.userRepo.CountByModifiers(ctx, s.db, env.Instance.ID, mods)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	userSerializables, err := s.serializableService.ConvertUsers(ctx, s.db, userSettings, foundUsers)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	resources := make([]interface{}, len(userSerializables))
+	for i, userSerializable := range userSerializables {
+		resources[i] = serialize.SCIMUser(serialize.UserToServerAPI(ctx, userSerializable), userLocation(baseURL, userSerializable.ID))
+	}
+	return serialize.SCIMList(resources, totalCount, params.StartIndex), nil
+}
+
+func (s *Service) ReadUser(ctx context.Context, baseURL, userID string) (*serialize.SCIMUserResponse, apierror.Error) {
+	user, apiErr := s.usersService.ReadServerAPI(ctx, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.SCIMUser(user, userLocation(baseURL, user.ID)), nil
+}
+
+// CreateUserParams is the SCIM User resource of a create request.
+type CreateUserParams struct {
+	UserName   string             `json:"userName"`
+	ExternalID *string            `json:"externalId"`
+	Name       serialize.SCIMName `json:"name"`
+	Emails     []struct {
+		Value   string `json:"value"`
+		Primary bool   `json:"primary"`
+	} `json:"emails"`
+	Active *bool `json:"active"`
+}
+
+// toCreateParams maps a SCIM User onto the params of users.Service.Create.
+// The userName becomes the username of the user, or an email address for
+// instances without usernames. The primary email address goes first, so
+// that it becomes the primary email address of the user.
+func (p CreateUserParams) toCreateParams(usernameEnabled bool) (users.CreateParams, apierror.Error) {
+	skipPassword := true
+	params := users.CreateParams{
+		ExternalID:              p.ExternalID,
+		FirstName:               p.Name.GivenName,
+		LastName:                p.Name.FamilyName,
+		SkipPasswordRequirement: &skipPassword,
+	}
+
+	for _, email := range p.Emails {
+		if email.Primary {
+			params.EmailAddresses = append([]string{email.Value}, params.EmailAddresses...)
+		} else {
+			params.EmailAddresses = append(params.EmailAddresses, email.Value)
+		}
+	}
+
+	if p.UserName == "" {
+		return params, apierror.SCIMInvalidValue("userName")
+	}
+	if usernameEnabled {
+		params.Username = &p.UserName
+		return params, nil
+	}
+	if !strings.Contains(p.UserName, "@") {
+		return params, apierror.SCIMInvalidValue("userName")
+	}
+	for _, email := range params.EmailAddresses {
+		if strings.EqualFold(email, p.UserName) {
+			return params, nil
+		}
+	}
+	params.EmailAddresses = append([]string{p.UserName}, params.EmailAddresses...)
+	return params, nil
+}
+
+// CreateUser creates a user. Users which are provisioned as inactive are
+// banned right after they're created.
+func (s *Service) CreateUser(ctx context.Context, baseURL string, params CreateUserParams) (*serialize.SCIMUserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	createParams, apiErr := params.toCreateParams(userSettings.GetAttribute(names.Username).Base().Enabled)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	created, apiErr := s.usersService.Create(ctx, createParams)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	user := created.(*serialize.UserResponse)
+
+	if params.Active != nil && !*params.Active {
+		user, apiErr = s.usersService.Ban(ctx, user.ID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return serialize.SCIMUser(user, userLocation(baseURL, user.ID)), nil
+}
+
+// PatchUser applies the operations of a PATCH request to the user.
+// Deactivating a user bans them, and activating them lifts the ban.
+func (s *Service) PatchUser(ctx context.Context, baseURL, userID string, operations []PatchOperation) (*serialize.SCIMUserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	patch, apiErr := toUserPatch(operations, userSettings.GetAttribute(names.Username).Base().Enabled)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	user, apiErr := s.usersService.Update(ctx, userID, patch.params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if patch.active != nil && *patch.active == user.Banned {
+		if *patch.active {
+			user, apiErr = s.usersService.Unban(ctx, userID)
+		} else {
+			user, apiErr = s.usersService.Ban(ctx, userID)
+		}
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return serialize.SCIMUser(user, userLocation(baseURL, user.ID)), nil
+}
+
+func (s *Service) DeleteUser(ctx context.Context, userID string) apierror.Error {
+	env := environment.FromContext(ctx)
+	if apiErr := s.usersService.CheckUserInInstance(ctx, userID); apiErr != nil {
+		return apiErr
+	}
+	_, apiErr := s.shUsersService.Delete(ctx, env, userID)
+	return apiErr
+}
+
+// ListGroups returns the groups which match the filter of the params.
+// Groups can be filtered by id and displayName.
+func (s *Service) ListGroups(ctx context.Context, baseURL string, params ListParams) (*serialize.SCIMListResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	values, apiErr := params.equalityValues()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var orgs []*model.Organization
+	var totalCount int64
+	switch {
+	case len(values) == 0:
+		orgsWithMembers, err := s.orgsRepo.FindAllByInstanceWithMembersCount(ctx, s.db, env.Instance.ID, repository.OrganizationsFindAllModifiers{}, params.pagination())
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		for _, orgWithMembers := range orgsWithMembers {
+			org := orgWithMembers.Organization
+			orgs = append(orgs, &org)
+		}
+		totalCount, err = s.orgsRepo.CountByInstanceWithModifiers(ctx, s.db, env.Instance.ID, repository.OrganizationsFindAllModifiers{})
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+	case len(values) == 1 && values["id"] != nil:
+		for _, orgID := range values["id"] {
+			org, err := s.orgsRepo.QueryByIDAndInstance(ctx, s.db, orgID, env.Instance.ID)
+			if err != nil {
+				return nil, apierror.Unexpected(err)
+			} else if org != nil {
+				orgs = append(orgs, org)
+			}
+		}
+		totalCount = int64(len(orgs))
+
+	case len(values) == 1 && values["displayname"] != nil:
+		// Names are searched with a fuzzy query, so only exact matches
+		// are kept.
+		for _, displayName := range values["displayname"] {
+			orgsWithMembers, err := s.orgsRepo.FindAllByInstanceWithMembersCount(ctx, s.db, env.Instance.ID, repository.OrganizationsFindAllModifiers{Query: displayName}, pagination.Params{Limit: maxResults})
+			if err != nil {
+				return nil, apierror.Unexpected(err)
+			}
+			for _, orgWithMembers := range orgsWithMembers {
+				if orgWithMembers.Organization.Name == displayName {
+					org := orgWithMembers.Organization
+					orgs = append(orgs, &org)
+				}
+			}
+		}
+		totalCount = int64(len(orgs))
+
+	default:
+		return nil, apierror.SCIMInvalidFilter(params.Filter, "groups can only be filtered by id or displayName")
+	}
+
+	resources := make([]interface{}, len(orgs))
+	for i, org := range orgs {
+		group, apiErr := s.toGroup(ctx, baseURL, org, params.ExcludeMembers)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		resources[i] = group
+	}
+	return serialize.SCIMList(resources, totalCount, params.StartIndex), nil
+}
+
+func (s *Service) ReadGroup(ctx context.Context, baseURL, groupID string, excludeMembers bool) (*serialize.SCIMGroupResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	org, err := s.orgsRepo.QueryByIDAndInstance(ctx, s.db, groupID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if org == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	return s.toGroup(ctx, baseURL, org, excludeMembers)
+}
+
+type groupMemberParam struct {
+	Value string `json:"value"`
+}
+
+// CreateGroupParams is the SCIM Group resource of a create request.
+type CreateGroupParams struct {
+	DisplayName string             `json:"displayName"`
+	Members     []groupMemberParam `json:"members"`
+}
+
+// CreateGroup creates an organization for the group. The first member
+// becomes the creator of the organization, the rest of the members join it
+// with the default role for new members.
+func (s *Service) CreateGroup(ctx context.Context, baseURL string, params CreateGroupParams) (*serialize.SCIMGroupResponse, apierror.Error) {
+	if params.DisplayName == "" {
+		return nil, apierror.SCIMInvalidValue("displayName")
+	}
+	if len(params.Members) == 0 {
+		return nil, apierror.SCIMGroupMembersRequired()
+	}
+
+	org, apiErr := s.orgsService.Create(ctx, organizations.CreateParams{
+		Name:      params.DisplayName,
+		CreatedBy: params.Members[0].Value,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	addMembers := make([]string, 0, len(params.Members)-1)
+	for _, member := range params.Members[1:] {
+		addMembers = append(addMembers, member.Value)
+	}
+	if apiErr := s.addMembers(ctx, org.ID, addMembers); apiErr != nil {
+		return nil, apiErr
+	}
+	return s.ReadGroup(ctx, baseURL, org.ID, false)
+}
+
+// PatchGroup applies the operations of a PATCH request to the group.
+// Adding existing members or removing non-members is a no-op.
+func (s *Service) PatchGroup(ctx context.Context, baseURL, groupID string, operations []PatchOperation) (*serialize.SCIMGroupResponse, apierror.Error) {
+	patch, apiErr := toGroupPatch(operations)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if apiErr := s.orgsService.EnsureOrganizationExists(ctx, groupID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if patch.displayName != nil {
+		_, apiErr := s.orgsService.Update(ctx, organizations.UpdateParams{
+			OrganizationID: groupID,
+			Name:           patch.displayName,
+		})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	if apiErr := s.addMembers(ctx, groupID, patch.addMembers); apiErr != nil {
+		return nil, apiErr
+	}
+	for _, userID := range patch.removeMembers {
+		isMember, err := s.orgMembershipsRepo.ExistsByOrganizationAndUser(ctx, s.db, groupID, userID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		} else if !isMember {
+			continue
+		}
+		if _, apiErr := s.orgMembershipsService.Delete(ctx, groupID, userID); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return s.ReadGroup(ctx, baseURL, groupID, false)
+}
+
+func (s *Service) DeleteGroup(ctx context.Context, groupID string) apierror.Error {
+	_, apiErr := s.orgsService.Delete(ctx, organizations.DeleteParams{OrganizationID: groupID})
+	return apiErr
+}
+
+// addMembers adds the users to the organization with the default role for
+// members who join through a verified domain, which every instance has.
+func (s *Service) addMembers(ctx context.Context, orgID string, userIDs []string) apierror.Error {
+	env := environment.FromContext(ctx)
+	for _, userID := range userIDs {
+		isMember, err := s.orgMembershipsRepo.ExistsByOrganizationAndUser(ctx, s.db, orgID, userID)
+		if err != nil {
+			return apierror.Unexpected(err)
+		} else if isMember {
+			continue
+		}
+
+		_, apiErr := s.orgMembershipsService.Create(ctx, organization_memberships.CreateParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+			Role:           env.AuthConfig.OrganizationSettings.Domains.DefaultRole,
+		})
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+	return nil
+}
+
+func (s *Service) toGroup(ctx context.Context, baseURL string, org *model.Organization, excludeMembers bool) (*serialize.SCIMGroupResponse, apierror.Error) {
+	var members []serialize.SCIMGroupMember
+	if !excludeMembers {
+		var apiErr apierror.Error
+		members, apiErr = s.groupMembers(ctx, org.ID)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return serialize.SCIMGroup(serialize.OrganizationBAPI(ctx, org), members, groupLocation(baseURL, org.ID)), nil
+}
+
+// groupMembers returns all the members of the organization, fetching them
+// a page at a time.
+func (s *Service) groupMembers(ctx context.Context, orgID string) ([]serialize.SCIMGroupMember, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var members []serialize.SCIMGroupMember
+	for offset := 0; ; offset += maxResults {
+		memberships, err := s.orgMembershipsRepo.FindAllByOrganizationWithModifiers(ctx, s.db, env.Instance.ID, orgID, repository.OrganizationMembershipsFindAllModifiers{}, pagination.Params{Limit: maxResults, Offset: offset})
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		for _, membership := range memberships {
+			members = append(members, serialize.SCIMGroupMember{Value: membership.UserID})
+		}
+		if len(memberships) < maxResults {
+			return members, nil
+		}
+	}
+}
+
+func userLocation(baseURL, userID string) string {
+	return baseURL + "/Users/" + userID
+}
+
+func groupLocation(baseURL, groupID string) string {
+	return baseURL + "/Groups/" + groupID
+}
+
+func toLower(values []string) []string {
+	lowered := make([]string, len(values))
+	for i, value := range values {
+		lowered[i] = strings.ToLower(value)
+	}
+	return lowered
+}
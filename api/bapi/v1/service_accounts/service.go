@@ -0,0 +1,249 @@
+package service_accounts
+
+import (
+	"context"
+	"encoding/json"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/service_accounts"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/metadata"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/volatiletech/sqlboiler/v4/types"
+)
+
+const paramJWTTemplateID = "jwt_template_id"
+
+type Service struct {
+	db        database.Database
+	validator *validator.Validate
+
+	// repositories
+	jwtTemplateRepo     *repository.JWTTemplate
+	serviceAccountsRepo *repository.ServiceAccounts
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                  deps.DB(),
+		validator:           validator.New(),
+		jwtTemplateRepo:     deps.Repositories().JWTTemplate,
+		serviceAccountsRepo: deps.Repositories().ServiceAccounts,
+	}
+}
+
+type CreateParams struct {
+	Name           string           `json:"name" form:"name" validate:"required,max=256"`
+	JWTTemplateID  string           `json:"jwt_template_id" form:"jwt_template_id" validate:"required"`
+	PublicMetadata *json.RawMessage `json:"public_metadata" form:"public_metadata"`
+}
+
+func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	if p.PublicMetadata != nil {
+		return metadata.Validate(metadata.Metadata{Public: *p.PublicMetadata})
+	}
+	return nil
+}
+
+func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.ServiceAccountResponse, apierror.Error) {
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	env := environment.FromContext(ctx)
+	if apiErr := s.ensureJWTTemplateExists(ctx, env.Instance.ID, params.JWTTemplateID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	clientID, err := service_accounts.GenerateClientID()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	clientSecret, clientSecretHash, err := service_accounts.GenerateClientSecret()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	publicMetadata := types.JSON("{}")
+	if params.PublicMetadata != nil {
+		publicMetadata = types.JSON(*params.PublicMetadata)
+	}
+
+	serviceAccount := &model.ServiceAccount{
+		ServiceAccount: &sqbmodel.ServiceAccount{
+			InstanceID:       env.Instance.ID,
+			Name:             params.Name,
+			ClientID:         clientID,
+			ClientSecretHash: clientSecretHash,
+			JWTTemplateID:    params.JWTTemplateID,
+			PublicMetadata:   publicMetadata,
+		},
+		ClientSecret: clientSecret,
+	}
+
+	err = s.serviceAccountsRepo.Insert(ctx, s.db, serviceAccount)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.ServiceAccount(serviceAccount, env.Domain), nil
+}
+
+func (s *Service) Read(ctx context.Context, serviceAccountID string) (*serialize.ServiceAccountResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	serviceAccount, err := s.serviceAccountsRepo.QueryByIDAndInstance(ctx, s.db, serviceAccountID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if serviceAccount == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	return serialize.ServiceAccount(serviceAccount, env.Domain), nil
+}
+
+type UpdateParams struct {
+	Name           *string          `json:"name" form:"name" validate:"omitempty,max=256"`
+	JWTTemplateID  *string          `json:"jwt_template_id" form:"jwt_template_id"`
+	PublicMetadata *json.RawMessage `json:"public_metadata" form:"public_metadata"`
+}
+
+func (p UpdateParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	if p.Name != nil && *p.Name == "" {
+		return apierror.FormInvalidParameterValue("name", *p.Name)
+	}
+	if p.JWTTemplateID != nil && *p.JWTTemplateID == "" {
+		return apierror.FormInvalidParameterValue(paramJWTTemplateID, *p.JWTTemplateID)
+	}
+	if p.PublicMetadata != nil {
+		return metadata.Validate(metadata.Metadata{Public: *p.PublicMetadata})
+	}
+	return nil
+}
+
+func (s *Service) Update(ctx context.Context, serviceAccountID string, params UpdateParams) (*serialize.ServiceAccountResponse, apierror.Error) {
+	if err := params.validate(s.validator); err != nil {
+		return nil, err
+	}
+
+	env := environment.FromContext(ctx)
+	serviceAccount, err := s.serviceAccountsRepo.QueryByIDAndInstance(ctx, s.db, serviceAccountID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if serviceAccount == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	updatedColumns := []string{}
+
+	if params.Name != nil {
+		serviceAccount.Name = *params.Name
+		updatedColumns = append(updatedColumns, sqbmodel.ServiceAccountColumns.Name)
+	}
+	if params.JWTTemplateID != nil {
+		if apiErr := s.ensureJWTTemplateExists(ctx, env.Instance.ID, *params.JWTTemplateID); apiErr != nil {
+			return nil, apiErr
+		}
+		serviceAccount.JWTTemplateID = *params.JWTTemplateID
+		updatedColumns = append(updatedColumns, sqbmodel.ServiceAccountColumns.JWTTemplateID)
+	}
+	if params.PublicMetadata != nil {
+		serviceAccount.PublicMetadata = types.JSON(*params.PublicMetadata)
+		updatedColumns = append(updatedColumns, sqbmodel.ServiceAccountColumns.PublicMetadata)
+	}
+
+	if len(updatedColumns) > 0 {
+		err = s.serviceAccountsRepo.Update(ctx, s.db, serviceAccount, updatedColumns...)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
+	return serialize.ServiceAccount(serviceAccount, env.Domain), nil
+}
+
+func (s *Service) Delete(ctx context.Context, serviceAccountID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	rowsAff, err := s.serviceAccountsRepo.DeleteByIDAndInstance(ctx, s.db, serviceAccountID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if rowsAff == 0 {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	return serialize.DeletedObject(serviceAccountID, serialize.ObjectServiceAccount), nil
+}
+
+func (s *Service) List(ctx context.Context, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	serviceAccounts, err := s.serviceAccountsRepo.FindAllByInstance(ctx, s.db, env.Instance.ID, paginationParams)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	totalCount, err := s.serviceAccountsRepo.CountByInstance(ctx, s.db, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]interface{}, len(serviceAccounts))
+	for i, serviceAccount := range serviceAccounts {
+		responses[i] = serialize.ServiceAccount(serviceAccount, env.Domain)
+	}
+
+	return serialize.Paginated(responses, totalCount), nil
+}
+
+// RotateSecret generates a new client secret for the service account. The
+// previous secret stops working immediately, while tokens already issued
+// remain valid until they expire.
+func (s *Service) RotateSecret(ctx context.Context, serviceAccountID string) (*serialize.ServiceAccountResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	serviceAccount, err := s.serviceAccountsRepo.QueryByIDAndInstance(ctx, s.db, serviceAccountID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if serviceAccount == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	clientSecret, clientSecretHash, err := service_accounts.GenerateClientSecret()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	serviceAccount.ClientSecretHash = clientSecretHash
+	serviceAccount.ClientSecret = clientSecret
+
+	err = s.serviceAccountsRepo.Update(ctx, s.db, serviceAccount, sqbmodel.ServiceAccountColumns.ClientSecretHash)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.ServiceAccount(serviceAccount, env.Domain), nil
+}
+
+func (s *Service) ensureJWTTemplateExists(ctx context.Context, instanceID, jwtTemplateID string) apierror.Error {
+	jwtTemplate, err := s.jwtTemplateRepo.QueryByIDAndInstance(ctx, s.db, jwtTemplateID, instanceID)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if jwtTemplate == nil {
+		return apierror.JWTTemplateNotFound("id", jwtTemplateID)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package sessions
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	"clerk/api/apierror"
 	"clerk/api/shared/pagination"
@@ -34,6 +35,7 @@ func (h *HTTP) ReadAll(_ http.ResponseWriter, r *http.Request) (interface{}, api
 		clientID: clerkhttp.GetOptionalQueryParam(r, "client_id"),
 		userID:   clerkhttp.GetOptionalQueryParam(r, "user_id"),
 		status:   clerkhttp.GetOptionalQueryParam(r, "status"),
+		orderBy:  clerkhttp.GetOptionalQueryParam(r, "order_by"),
 	}
 
 	paginationParams, err := pagination.NewFromRequest(r)
@@ -41,6 +43,13 @@ func (h *HTTP) ReadAll(_ http.ResponseWriter, r *http.Request) (interface{}, api
 		return nil, err
 	}
 
+	if params.createdAtAfter, err = parseTimestamp(r, "created_at_after"); err != nil {
+		return nil, err
+	}
+	if params.createdAtBefore, err = parseTimestamp(r, "created_at_before"); err != nil {
+		return nil, err
+	}
+
 	if r.URL.Query().Get(param.Paginated.Name) == "true" {
 		return h.service.ReadAllPaginated(r.Context(), params, paginationParams)
 	}
@@ -76,6 +85,20 @@ func (h *HTTP) Verify(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.service.Verify(r.Context(), params)
 }
 
+// parseTimestamp returns the Unix millisecond timestamp of the given query
+// parameter, or nil if it's missing.
+func parseTimestamp(r *http.Request, name string) (*int64, apierror.Error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, apierror.FormInvalidTypeParameter(name, "integer")
+	}
+	return &timestamp, nil
+}
+
 func deprecatedEndpoint(ctx context.Context, allowlistKey string) bool {
 	if !cenv.IsSet(allowlistKey) {
 		return false
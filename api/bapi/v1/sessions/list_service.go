@@ -2,21 +2,25 @@ package sessions
 
 import (
 	"context"
+	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/client_data"
 	"clerk/api/shared/pagination"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
-	"clerk/repository"
 	"clerk/utils/database"
 )
 
 type readAllParams struct {
-	clientID *string
-	userID   *string
-	status   *string
+	clientID        *string
+	userID          *string
+	status          *string
+	createdAtAfter  *int64
+	createdAtBefore *int64
+	orderBy         *string
 }
 
 func (r readAllParams) validate(ctx context.Context) apierror.Error {
@@ -28,16 +32,29 @@ func (r readAllParams) validate(ctx context.Context) apierror.Error {
 	if r.status != nil && !constants.SessionStatuses.Contains(*r.status) {
 		return apierror.FormInvalidParameterValueWithAllowed("status", *r.status, constants.SessionStatuses.Array())
 	}
+	if r.createdAtAfter != nil && r.createdAtBefore != nil && *r.createdAtAfter >= *r.createdAtBefore {
+		return apierror.FormInvalidDate("created_at_before")
+	}
 
 	return nil
 }
 
-func (r readAllParams) convertToSessionMods() repository.SessionsFindAllModifiers {
-	return repository.SessionsFindAllModifiers{
+func (r readAllParams) toListSessionsParams() client_data.ListSessionsParams {
+	params := client_data.ListSessionsParams{
 		ClientID: r.clientID,
 		UserID:   r.userID,
 		Status:   r.status,
+		OrderBy:  r.orderBy,
+	}
+	if r.createdAtAfter != nil {
+		createdAtAfter := time.UnixMilli(*r.createdAtAfter).UTC()
+		params.CreatedAtAfter = &createdAtAfter
+	}
+	if r.createdAtBefore != nil {
+		createdAtBefore := time.UnixMilli(*r.createdAtBefore).UTC()
+		params.CreatedAtBefore = &createdAtBefore
 	}
+	return params
 }
 
 // ReadAllPaginated calls ReadAll to get a list of sessions based on the passed parameters
@@ -53,11 +70,11 @@ func (s *Service) ReadAllPaginated(ctx context.Context, readParams readAllParams
 			return true, apiErr
 		}
 		var err error
-		totalCount, err = s.sessionsRepo.CountByInstanceWithModifiers(
+		totalCount, err = s.clientDataService.CountSessions(
 			ctx,
 			tx,
 			env.Instance.ID,
-			readParams.convertToSessionMods(),
+			readParams.toListSessionsParams(),
 		)
 		if err != nil {
 			return true, err
@@ -90,10 +107,11 @@ func (s *Service) readAll(ctx context.Context, exec database.Executor, readParam
 		return nil, apiErr
 	}
 
-	findAllParams := readParams.convertToSessionMods()
-
-	sessions, err := s.sessionsRepo.FindAllWithModifiers(ctx, exec, env.Instance.ID, findAllParams, pagination)
+	sessions, err := s.clientDataService.ListSessions(ctx, exec, env.Instance.ID, readParams.toListSessionsParams(), pagination)
 	if err != nil {
+		if apiErr, isAPIError := apierror.As(err); isAPIError {
+			return nil, apiErr
+		}
 		return nil, apierror.Unexpected(err)
 	}
 
@@ -0,0 +1,92 @@
+package sessions
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadAllParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1", ApplicationID: "app_1"}}}
+	ctx := environment.NewContext(context.Background(), env)
+
+	userID, status, invalidStatus := "user_1", constants.SESSActive, "unknown"
+	earlier, later := int64(1704067200000), int64(1704153600000)
+	tests := []struct {
+		name     string
+		params   readAllParams
+		wantCode string
+	}{
+		{name: "user", params: readAllParams{userID: &userID}},
+		{name: "status", params: readAllParams{userID: &userID, status: &status}},
+		{name: "created at range", params: readAllParams{userID: &userID, createdAtAfter: &earlier, createdAtBefore: &later}},
+		{name: "unknown status", params: readAllParams{userID: &userID, status: &invalidStatus}, wantCode: apierror.FormParamValueInvalidCode},
+		{name: "reversed range", params: readAllParams{userID: &userID, createdAtAfter: &later, createdAtBefore: &earlier}, wantCode: apierror.FormInvalidDateCode},
+		{name: "empty range", params: readAllParams{userID: &userID, createdAtAfter: &earlier, createdAtBefore: &earlier}, wantCode: apierror.FormInvalidDateCode},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiErr := tt.params.validate(ctx)
+			if tt.wantCode == "" {
+				assert.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			assert.Equal(t, tt.wantCode, apiErr.Errors()[0].Code())
+		})
+	}
+}
+
+func TestReadAllParamsToListSessionsParams(t *testing.T) {
+	t.Parallel()
+
+	clientID, orderBy := "client_1", "-touched_at"
+	createdAtAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	createdAtAfterMilli := createdAtAfter.UnixMilli()
+
+	params := readAllParams{
+		clientID:       &clientID,
+		orderBy:        &orderBy,
+		createdAtAfter: &createdAtAfterMilli,
+	}.toListSessionsParams()
+	assert.Equal(t, &clientID, params.ClientID)
+	assert.Nil(t, params.UserID)
+	assert.Equal(t, &orderBy, params.OrderBy)
+	require.NotNil(t, params.CreatedAtAfter)
+	assert.Equal(t, createdAtAfter, *params.CreatedAtAfter)
+	assert.Nil(t, params.CreatedAtBefore)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/v1/sessions?created_at_after=1704067200000&created_at_before=yesterday", nil)
+
+	timestamp, apiErr := parseTimestamp(r, "created_at_after")
+	require.Nil(t, apiErr)
+	require.NotNil(t, timestamp)
+	assert.Equal(t, int64(1704067200000), *timestamp)
+
+	timestamp, apiErr = parseTimestamp(r, "missing")
+	assert.Nil(t, apiErr)
+	assert.Nil(t, timestamp)
+
+	_, apiErr = parseTimestamp(r, "created_at_before")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamTypeInvalidCode, apiErr.Errors()[0].Code())
+}
@@ -6,6 +6,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/client_data"
 	"clerk/api/shared/cookies"
 	"clerk/api/shared/events"
 	"clerk/api/shared/jwt"
@@ -31,10 +32,11 @@ type Service struct {
 	validator *validator.Validate
 
 	// services
-	cookieService  *cookies.Service
-	eventService   *events.Service
-	jwtService     *jwt.Service
-	sessionService *sessions.Service
+	clientDataService *client_data.Service
+	cookieService     *cookies.Service
+	eventService      *events.Service
+	jwtService        *jwt.Service
+	sessionService    *sessions.Service
 
 	// repositories
 	sessionsRepo *repository.Sessions
@@ -42,14 +44,15 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		clock:          deps.Clock(),
-		db:             deps.DB(),
-		validator:      validator.New(),
-		cookieService:  cookies.NewService(deps),
-		eventService:   events.NewService(deps),
-		jwtService:     jwt.NewService(deps.Clock()),
-		sessionService: sessions.NewService(deps),
-		sessionsRepo:   repository.NewSessions(deps.Clock()),
+		clock:             deps.Clock(),
+		db:                deps.DB(),
+		validator:         validator.New(),
+		clientDataService: client_data.NewService(deps),
+		cookieService:     cookies.NewService(deps),
+		eventService:      events.NewService(deps),
+		jwtService:        jwt.NewService(deps),
+		sessionService:    sessions.NewService(deps),
+		sessionsRepo:      deps.Repositories().Sessions,
 	}
 }
 
@@ -5,19 +5,18 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/pkg/clerkhttp"
-	"clerk/utils/database"
+	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/jonboulle/clockwork"
 )
 
 type HTTP struct {
 	signInTokensService *Service
 }
 
-func NewHTTP(clock clockwork.Clock, db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
-		signInTokensService: NewService(clock, db),
+		signInTokensService: NewService(deps),
 	}
 }
 
@@ -31,6 +30,12 @@ func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.signInTokensService.Create(r.Context(), params)
 }
 
+// GET /v1/sign_in_tokens/{signInTokenID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	signInTokenID := chi.URLParam(r, "signInTokenID")
+	return h.signInTokensService.Read(r.Context(), signInTokenID)
+}
+
 // POST /v1/sign_in_tokens/{signInTokenID}/revoke
 func (h *HTTP) Revoke(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	signInTokenID := chi.URLParam(r, "signInTokenID")
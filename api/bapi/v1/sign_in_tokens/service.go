@@ -2,53 +2,85 @@ package sign_in_tokens
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/comms"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ticket"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/param"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 )
 
+// maxSignInTokenUses is the maximum number of times a sign in token can be
+// allowed to be used.
+const maxSignInTokenUses = 100
+
 type Service struct {
 	clock     clockwork.Clock
 	db        database.Database
 	validator *validator.Validate
 
+	// services
+	commsService *comms.Service
+
 	// repositories
-	signInTokensRepo *repository.SignInToken
-	usersRepo        *repository.Users
+	identificationRepo *repository.Identification
+	redirectURLsRepo   *repository.RedirectUrls
+	signInTokensRepo   *repository.SignInToken
+	usersRepo          *repository.Users
 }
 
-func NewService(clock clockwork.Clock, db database.Database) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		clock:            clock,
-		db:               db,
-		validator:        validator.New(),
-		signInTokensRepo: repository.NewSignInToken(),
-		usersRepo:        repository.NewUsers(),
+		clock:              deps.Clock(),
+		db:                 deps.DB(),
+		validator:          validator.New(),
+		commsService:       comms.NewService(deps),
+		identificationRepo: deps.Repositories().Identification,
+		redirectURLsRepo:   deps.Repositories().RedirectUrls,
+		signInTokensRepo:   deps.Repositories().SignInToken,
+		usersRepo:          deps.Repositories().Users,
 	}
 }
 
 type CreateParams struct {
-	UserID           string `json:"user_id" form:"user_id" validate:"required"`
-	ExpiresInSeconds *int   `json:"expires_in_seconds" form:"expires_in_seconds" validate:"omitempty,numeric,gte=1"`
+	UserID           string  `json:"user_id" form:"user_id" validate:"required"`
+	ExpiresInSeconds *int    `json:"expires_in_seconds" form:"expires_in_seconds" validate:"omitempty,numeric,gte=1"`
+	MaxUses          *int    `json:"max_uses" form:"max_uses" validate:"omitempty,numeric,gte=1"`
+	RedirectURL      *string `json:"redirect_url" form:"redirect_url"`
+	// Notify emails the sign in link to the primary email address of the
+	// user.
+	Notify bool `json:"notify" form:"notify"`
 }
 
 func (p CreateParams) validate(validator *validator.Validate) apierror.Error {
 	if err := validator.Struct(p); err != nil {
 		return apierror.FormValidationFailed(err)
 	}
-	return nil
+
+	var apiErrs apierror.Error
+	if p.MaxUses != nil && *p.MaxUses > maxSignInTokenUses {
+		apiErrs = apierror.Combine(apiErrs, apierror.FormParameterValueTooLarge("max_uses", maxSignInTokenUses))
+	}
+	if p.RedirectURL != nil {
+		if _, err := url.ParseRequestURI(*p.RedirectURL); err != nil {
+			apiErrs = apierror.Combine(apiErrs, apierror.FormInvalidTypeParameter(param.RedirectURL.Name, "valid url"))
+		}
+	}
+	return apiErrs
 }
 
 // Create creates a new sign in token for the given user.
@@ -59,27 +91,54 @@ func (s *Service) Create(ctx context.Context, createParams CreateParams) (*seria
 		return nil, err
 	}
 
-	userExists, err := s.usersRepo.ExistsByIDAndInstance(ctx, s.db, createParams.UserID, env.Instance.ID)
+	user, err := s.usersRepo.QueryByIDAndInstance(ctx, s.db, createParams.UserID, env.Instance.ID)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
-
-	if !userExists {
+	if user == nil {
 		return nil, apierror.UserNotFound(createParams.UserID)
 	}
 
+	if createParams.RedirectURL != nil {
+		apiErr := s.checkRedirectURLAllowed(ctx, env.Instance, *createParams.RedirectURL)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	var primaryEmailAddress *model.Identification
+	if createParams.Notify {
+		if user.PrimaryEmailAddressID.Valid {
+			primaryEmailAddress, err = s.identificationRepo.QueryByIDAndUser(ctx, s.db, env.Instance.ID, user.PrimaryEmailAddressID.String, user.ID)
+			if err != nil {
+				return nil, apierror.Unexpected(err)
+			}
+		}
+		if primaryEmailAddress == nil {
+			return nil, apierror.NoPrimaryEmailAddress()
+		}
+	}
+
 	signInToken := &model.SignInToken{
 		SignInToken: &sqbmodel.SignInToken{
-			InstanceID: env.Instance.ID,
-			UserID:     createParams.UserID,
-			Status:     constants.StatusPending,
+			InstanceID:  env.Instance.ID,
+			UserID:      user.ID,
+			Status:      constants.StatusPending,
+			MaxUses:     1,
+			RedirectURL: null.StringFromPtr(createParams.RedirectURL),
 		},
 	}
+	if createParams.MaxUses != nil {
+		signInToken.MaxUses = *createParams.MaxUses
+	}
+	if createParams.ExpiresInSeconds != nil {
+		signInToken.ExpireAt = null.TimeFrom(s.clock.Now().UTC().Add(time.Duration(*createParams.ExpiresInSeconds) * time.Second))
+	}
 
 	var ticketToken string
 	var ticketURL *url.URL
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
-		if err := s.signInTokensRepo.Insert(ctx, s.db, signInToken); err != nil {
+		if err := s.signInTokensRepo.Insert(ctx, tx, signInToken); err != nil {
 			return true, err
 		}
 
@@ -89,6 +148,7 @@ func (s *Service) Create(ctx context.Context, createParams CreateParams) (*seria
 				SourceType:       constants.OSTSignInToken,
 				SourceID:         signInToken.ID,
 				ExpiresInSeconds: createParams.ExpiresInSeconds,
+				RedirectURL:      createParams.RedirectURL,
 			},
 			env.Instance,
 			s.clock,
@@ -97,18 +157,23 @@ func (s *Service) Create(ctx context.Context, createParams CreateParams) (*seria
 			return true, err
 		}
 
-		signInURL := env.DisplayConfig.Paths.SignInURL(
-			env.Instance.Origin(env.Domain, nil),
-			env.Domain.AccountsURL(),
-		)
-		ticketURL, err = url.Parse(signInURL)
+		ticketURL, err = signInTokenURL(env, ticketToken, createParams.RedirectURL)
 		if err != nil {
 			return true, err
 		}
-		q := ticketURL.Query()
-		q.Add(param.ClerkTicket, ticketToken)
-		ticketURL.RawQuery = q.Encode()
 
+		if primaryEmailAddress == nil {
+			return false, nil
+		}
+		var ttl time.Duration
+		if signInToken.ExpireAt.Valid {
+			ttl = signInToken.ExpireAt.Time.Sub(s.clock.Now().UTC())
+		}
+		err = s.commsService.SendMagicLinkSignInEmail(ctx, tx, primaryEmailAddress, ticketURL.String(), ttl,
+			constants.OSTSignInToken, signInToken.ID, env, nil)
+		if err != nil {
+			return true, fmt.Errorf("signInTokens/create: sending email for %s: %w", signInToken.ID, err)
+		}
 		return false, nil
 	})
 	if txErr != nil {
@@ -118,6 +183,64 @@ func (s *Service) Create(ctx context.Context, createParams CreateParams) (*seria
 	return serialize.SignInToken(signInToken, ticketURL, ticketToken), nil
 }
 
+// checkRedirectURLAllowed makes sure that sign in tokens can only redirect
+// to the redirect URLs of production instances. Development instances
+// accept any URL, like in OAuth flows.
+func (s *Service) checkRedirectURLAllowed(ctx context.Context, instance *model.Instance, redirectURL string) apierror.Error {
+	if instance.IsDevelopmentOrStaging() {
+		return nil
+	}
+
+	exists, err := s.redirectURLsRepo.ExistsByURLAndInstance(ctx, s.db, redirectURL, instance.ID)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if !exists {
+		return apierror.RedirectURLMismatch(redirectURL)
+	}
+	return nil
+}
+
+// signInTokenURL returns the URL which signs in with the ticket. That's the
+// redirect URL of the token if there's one, so that custom flows can pick up
+// the ticket, or the sign in page of the instance otherwise.
+func signInTokenURL(env *model.Env, ticketToken string, redirectURL *string) (*url.URL, error) {
+	linkURL := env.DisplayConfig.Paths.SignInURL(
+		env.Instance.Origin(env.Domain, nil),
+		env.Domain.AccountsURL(),
+	)
+	if redirectURL != nil {
+		linkURL = *redirectURL
+	}
+
+	ticketURL, err := url.Parse(linkURL)
+	if err != nil {
+		return nil, err
+	}
+	q := ticketURL.Query()
+	q.Add(param.ClerkTicket, ticketToken)
+	if redirectURL != nil {
+		q.Add(param.ClerkStatus, "sign_in")
+	}
+	ticketURL.RawQuery = q.Encode()
+	return ticketURL, nil
+}
+
+// Read returns the sign in token along with how many times it's been used.
+func (s *Service) Read(ctx context.Context, signInTokenID string) (*serialize.SignInTokenResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	signInToken, err := s.signInTokensRepo.QueryByIDAndInstance(ctx, s.db, signInTokenID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if signInToken == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	return serialize.SignInToken(signInToken, nil, ""), nil
+}
+
 func (s *Service) Revoke(ctx context.Context, signInTokenID string) (*serialize.SignInTokenResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 
@@ -46,7 +46,7 @@ func NewService(deps clerk.Deps) *Service {
 		clientDataService: *client_data.NewService(deps),
 		sessionService:    sessions.NewService(deps),
 		signUpService:     sign_up.NewService(deps),
-		signUpRepo:        repository.NewSignUp(),
+		signUpRepo:        deps.Repositories().SignUp,
 	}
 }
 
@@ -160,7 +160,10 @@ func (s *Service) Update(ctx context.Context, signUpID string, params *UpdatePar
 	}
 
 	if session != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, session); err != nil {
+		if err := s.sessionService.Activate(ctx, env, session); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, apiErr
+			}
 			return nil, apierror.Unexpected(err)
 		}
 	}
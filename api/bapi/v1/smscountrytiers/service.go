@@ -20,7 +20,7 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		smsCountryTiersRepo: repository.NewSMSCountryTiers(),
+		smsCountryTiersRepo: deps.Repositories().SMSCountryTiers,
 		db:                  deps.DB(),
 	}
 }
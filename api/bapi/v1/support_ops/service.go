@@ -36,12 +36,12 @@ func NewService(deps clerk.Deps) *Service {
 		clock: deps.Clock(),
 		db:    deps.ReadOnlyDB(),
 
-		users:             repository.NewUsers(),
-		applications:      repository.NewApplications(),
-		instances:         repository.NewInstances(),
-		domains:           repository.NewDomain(),
-		dnschecks:         repository.NewDNSChecks(),
-		subscriptionPlans: repository.NewSubscriptionPlans(),
+		users:             deps.Repositories().Users,
+		applications:      deps.Repositories().Applications,
+		instances:         deps.Repositories().Instances,
+		domains:           deps.Repositories().Domain,
+		dnschecks:         deps.Repositories().DNSChecks,
+		subscriptionPlans: deps.Repositories().SubscriptionPlans,
 	}
 }
 
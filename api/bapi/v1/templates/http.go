@@ -3,12 +3,11 @@ package templates
 import (
 	"net/http"
 
-	"github.com/jonboulle/clockwork"
-
 	"clerk/api/apierror"
 	"clerk/pkg/cenv"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/constants"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/param"
 
@@ -23,10 +22,10 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(clock clockwork.Clock, db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
-		db:      db,
-		service: NewService(clock, db),
+		db:      deps.DB(),
+		service: NewService(deps),
 	}
 }
 
@@ -94,6 +93,19 @@ func (h *HTTP) Preview(_ http.ResponseWriter, r *http.Request) (interface{}, api
 	return h.service.Preview(r.Context(), params)
 }
 
+// POST /v1/templates/{template_type}/{slug}/test
+func (h *HTTP) Test(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := TestParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	params.TemplateType = chi.URLParam(r, "template_type")
+	params.Slug = chi.URLParam(r, "slug")
+
+	return h.service.Test(r.Context(), params)
+}
+
 // POST /v1/templates/{template_type}/{slug}/toggle_delivery
 func (h *HTTP) ToggleDelivery(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	params := ToggleDeliveryParams{}
@@ -7,11 +7,12 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/jonboulle/clockwork"
 	"github.com/volatiletech/null/v8"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/emails"
+	"clerk/api/shared/sms"
 	shtemplates "clerk/api/shared/templates"
 	"clerk/model"
 	"clerk/model/sqbmodel"
@@ -21,6 +22,7 @@ import (
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/templates"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/validate"
 )
@@ -35,7 +37,9 @@ type Service struct {
 	validator *validator.Validate
 
 	// services
-	templateSvc *shtemplates.Service
+	emailService *emails.Service
+	smsService   *sms.Service
+	templateSvc  *shtemplates.Service
 
 	// repositories
 	domainRepo            *repository.Domain
@@ -43,14 +47,16 @@ type Service struct {
 	templateRepo          *repository.Templates
 }
 
-func NewService(clock clockwork.Clock, db database.Database) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:                    db,
+		db:                    deps.DB(),
 		validator:             validator.New(),
-		templateSvc:           shtemplates.NewService(clock),
-		domainRepo:            repository.NewDomain(),
-		subscriptionPlansRepo: repository.NewSubscriptionPlans(),
-		templateRepo:          repository.NewTemplates(),
+		emailService:          emails.NewService(deps),
+		smsService:            sms.NewService(deps),
+		templateSvc:           shtemplates.NewService(deps.Clock()),
+		domainRepo:            deps.Repositories().Domain,
+		subscriptionPlansRepo: deps.Repositories().SubscriptionPlans,
+		templateRepo:          deps.Repositories().Templates,
 	}
 }
 
@@ -213,6 +219,13 @@ func (s *Service) Upsert(ctx context.Context, params UpsertParams) (*serialize.T
 		return nil, apiErr
 	}
 
+	if apiErr = apierror.Combine(
+		validateAvailableVariables(currentTemplate, "subject", params.Subject),
+		validateAvailableVariables(currentTemplate, "body", params.Body),
+	); apiErr != nil {
+		return nil, apiErr
+	}
+
 	if !cenv.GetBool(cenv.FlagAllowCustomTemplateCreation) && currentTemplate == nil {
 		return nil, apierror.CustomTemplatesNotAvailable()
 	}
@@ -293,6 +306,109 @@ func (s *Service) Preview(ctx context.Context, params PreviewParams) (*serialize
 	return result, nil
 }
 
+type TestParams struct {
+	Subject      *string `json:"subject" form:"subject"`
+	Body         *string `json:"body" form:"body"`
+	EmailAddress *string `json:"email_address" form:"email_address"`
+	PhoneNumber  *string `json:"phone_number" form:"phone_number"`
+	TemplateType string  `json:"-" form:"-" validate:"oneof=email sms"`
+	Slug         string  `json:"-" form:"-"`
+}
+
+func (p TestParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+
+	switch constants.TemplateType(p.TemplateType) {
+	case constants.TTEmail:
+		if p.EmailAddress == nil {
+			return apierror.FormMissingParameter("email_address")
+		}
+		return validate.EmailAddress(*p.EmailAddress, "email_address")
+	case constants.TTSMS:
+		if p.PhoneNumber == nil {
+			return apierror.FormMissingParameter("phone_number")
+		}
+		return validate.PhoneNumber(*p.PhoneNumber, "phone_number")
+	}
+	return nil
+}
+
+// Test sends a template, rendered with sample data, to the given email address
+// or phone number. The subject and body default to the ones of the current
+// template, so that both saved and unsaved changes can be tried out.
+func (s *Service) Test(ctx context.Context, params TestParams) (*serialize.TemplatePreviewResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	currentTemplate, err := s.templateRepo.QueryCurrentByTemplateTypeAndSlug(ctx, s.db, env.Instance.ID, params.TemplateType, params.Slug)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if currentTemplate == nil {
+		return nil, apierror.TemplateNotFound(params.Slug)
+	}
+
+	upsertParams := UpsertParams{
+		Name:             currentTemplate.Name,
+		Subject:          currentTemplate.Subject.String,
+		Body:             currentTemplate.Body,
+		Markup:           &currentTemplate.Markup,
+		TemplateType:     params.TemplateType,
+		Slug:             params.Slug,
+		FromEmailName:    currentTemplate.FromEmailName.Ptr(),
+		ReplyToEmailName: currentTemplate.ReplyToEmailName.Ptr(),
+	}
+	if params.Subject != nil {
+		upsertParams.Subject = *params.Subject
+	}
+	if params.Body != nil {
+		upsertParams.Body = *params.Body
+	}
+
+	if apiErr := apierror.Combine(
+		validateAvailableVariables(currentTemplate, "subject", upsertParams.Subject),
+		validateAvailableVariables(currentTemplate, "body", upsertParams.Body),
+	); apiErr != nil {
+		return nil, apiErr
+	}
+
+	template := newTemplateFromParams(upsertParams, currentTemplate, env.Instance.ID)
+	replaceMetadataVariablesForPreview(template)
+
+	preview, apiErr := s.previewTemplate(ctx, env, template)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if template.IsSMS() {
+			smsData, err := s.renderSMS(ctx, env, template, params.PhoneNumber)
+			if err != nil {
+				return true, err
+			}
+			_, err = s.smsService.Send(ctx, tx, smsData, env)
+			return err != nil, err
+		}
+
+		emailData, err := s.renderEmail(ctx, env, template, params.EmailAddress)
+		if err != nil {
+			return true, err
+		}
+		_, err = s.emailService.Send(ctx, tx, emailData, env)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return preview, nil
+}
+
 // Delete deletes a custom template
 func (s *Service) Delete(ctx context.Context, templateType, slug string) (*serialize.DeletedObjectResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
@@ -458,7 +574,8 @@ func (s *Service) previewTemplate(ctx context.Context, env *model.Env, template
 	}
 }
 
-func (s *Service) previewEmail(ctx context.Context, env *model.Env, template *model.Template) (*serialize.TemplatePreviewResponse, error) {
+// renderEmail renders the email template with sample data.
+func (s *Service) renderEmail(ctx context.Context, env *model.Env, template *model.Template, emailAddress *string) (*model.EmailData, error) {
 	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
 	if err != nil {
 		return nil, err
@@ -470,11 +587,17 @@ func (s *Service) previewEmail(ctx context.Context, env *model.Env, template *mo
 	if !ok {
 		renderer = templates.CustomEmail{}
 	}
-	emailData, err := templates.RenderEmail(ctx, renderer.PreviewData(commonEmailData), template, fromEmailName, nil, nil)
+	return templates.RenderEmail(ctx, renderer.PreviewData(commonEmailData), template, fromEmailName, nil, emailAddress)
+}
+
+func (s *Service) previewEmail(ctx context.Context, env *model.Env, template *model.Template) (*serialize.TemplatePreviewResponse, error) {
+	emailData, err := s.renderEmail(ctx, env, template, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	fromEmailName := s.templateSvc.FromEmailName(template, env.Instance)
+
 	fromEmailAddress, err := s.getEmailAddress(ctx, env.Instance, fromEmailName)
 	if err != nil {
 		return nil, err
@@ -499,7 +622,8 @@ func (s *Service) previewEmail(ctx context.Context, env *model.Env, template *mo
 	return templatePreviewResponse, nil
 }
 
-func (s *Service) previewSMS(ctx context.Context, env *model.Env, template *model.Template) (*serialize.TemplatePreviewResponse, error) {
+// renderSMS renders the SMS template with sample data.
+func (s *Service) renderSMS(ctx context.Context, env *model.Env, template *model.Template, phoneNumber *string) (*model.SMSMessageData, error) {
 	commonSMSData, err := s.templateSvc.GetCommonSMSData(ctx, env)
 	if err != nil {
 		return nil, err
@@ -509,7 +633,11 @@ func (s *Service) previewSMS(ctx context.Context, env *model.Env, template *mode
 	if !ok {
 		renderer = templates.CustomSMS{}
 	}
-	smsData, err := templates.RenderSMS(renderer.PreviewData(commonSMSData), template, nil, nil)
+	return templates.RenderSMS(renderer.PreviewData(commonSMSData), template, nil, phoneNumber)
+}
+
+func (s *Service) previewSMS(ctx context.Context, env *model.Env, template *model.Template) (*serialize.TemplatePreviewResponse, error) {
+	smsData, err := s.renderSMS(ctx, env, template, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,133 @@
+package templates
+
+import (
+	"regexp"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/templates"
+)
+
+var (
+	expressionRegexp = regexp.MustCompile(`\{\{\{?~?\s*(.*?)\s*~?\}?\}\}`)
+	variableRegexp   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z0-9_]+)*$`)
+)
+
+// literals look like variables, but aren't.
+var literals = map[string]bool{
+	"else":      true,
+	"this":      true,
+	"true":      true,
+	"false":     true,
+	"null":      true,
+	"undefined": true,
+}
+
+// blockHelpers are the helpers which change the context of the expressions
+// inside them, so their variables can't be resolved against the variables
+// of the template.
+var blockHelpers = map[string]bool{
+	"each": true,
+	"with": true,
+}
+
+// templateVariables returns the variables the given content refers to,
+// in order of appearance. Literals, helpers and expressions inside blocks
+// which change the context are skipped.
+func templateVariables(content string) []string {
+	var (
+		variables []string
+		depth     int
+	)
+	for _, match := range expressionRegexp.FindAllStringSubmatch(content, -1) {
+		expression := match[1]
+
+		switch {
+		case expression == "" || strings.HasPrefix(expression, "!"):
+			// comment
+			continue
+		case strings.HasPrefix(expression, "/"):
+			if depth > 0 && blockHelpers[strings.TrimSpace(expression[1:])] {
+				depth--
+			}
+			continue
+		case strings.HasPrefix(expression, "#"), strings.HasPrefix(expression, "^"):
+			fields := strings.Fields(expression[1:])
+			if len(fields) == 0 {
+				continue
+			}
+			if depth == 0 {
+				variables = appendVariables(variables, fields[1:])
+			}
+			if blockHelpers[fields[0]] {
+				depth++
+			}
+			continue
+		case depth > 0:
+			continue
+		}
+
+		fields := strings.Fields(expression)
+		if fields[0] == "else" {
+			// chained block, e.g. {{else if condition}}
+			fields = fields[1:]
+		}
+		if len(fields) > 1 {
+			// helper call, only its arguments can be variables
+			fields = fields[1:]
+		}
+		variables = appendVariables(variables, fields)
+	}
+	return variables
+}
+
+func appendVariables(variables []string, fields []string) []string {
+	for _, field := range fields {
+		if literals[field] || !variableRegexp.MatchString(field) {
+			continue
+		}
+		variables = append(variables, field)
+	}
+	return variables
+}
+
+// validateAvailableVariables makes sure that the given content only refers to
+// variables which are available for the template. Variables nested under an
+// available one, like the keys of metadata, are allowed as well, and so are
+// the ones the current template already refers to.
+func validateAvailableVariables(currentTemplate *model.Template, param string, content string) apierror.Error {
+	if currentTemplate == nil {
+		return nil
+	}
+	availableVariables := templates.GetAvailableVariables(currentTemplate)
+	if len(availableVariables) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, current := range []string{currentTemplate.Subject.String, currentTemplate.Body, currentTemplate.Markup} {
+		for _, variable := range templateVariables(current) {
+			seen[variable] = true
+		}
+	}
+
+	var apiErrs apierror.Error
+	for _, variable := range templateVariables(content) {
+		if seen[variable] || isAvailable(variable, availableVariables) {
+			continue
+		}
+		seen[variable] = true
+		apiErrs = apierror.Combine(apiErrs, apierror.TemplateVariableUnknown(param, variable))
+	}
+	return apiErrs
+}
+
+func isAvailable(variable string, availableVariables []string) bool {
+	for _, available := range availableVariables {
+		if variable == available || strings.HasPrefix(variable, available+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateVariables(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "plain and unescaped variables",
+			content:  "Hi {{ user.first_name }}, your code is {{{otp_code}}}",
+			expected: []string{"user.first_name", "otp_code"},
+		},
+		{
+			name:     "conditionals",
+			content:  "{{#if app.logo_url}}<img src=\"{{app.logo_url}}\">{{else if app.name}}{{app.name}}{{else}}Welcome{{/if}}",
+			expected: []string{"app.logo_url", "app.logo_url", "app.name", "app.name"},
+		},
+		{
+			name:     "helpers and literals",
+			content:  "{{format_date requested_at \"short\"}} {{!-- comment --}} {{lookup true}}",
+			expected: []string{"requested_at"},
+		},
+		{
+			name:     "blocks which change the context",
+			content:  "{{#each organizations}}{{name}}{{/each}} {{app.name}}",
+			expected: []string{"organizations", "app.name"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.expected, templateVariables(tc.content))
+		})
+	}
+}
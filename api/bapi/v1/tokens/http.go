@@ -27,3 +27,13 @@ func (h *HTTP) CreateFromTemplate(_ http.ResponseWriter, r *http.Request) (inter
 
 	return h.service.CreateFromTemplate(r.Context(), params)
 }
+
+// POST /v1/tokens/scoped
+func (h *HTTP) CreateScoped(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := CreateScopedParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateScoped(r.Context(), params)
+}
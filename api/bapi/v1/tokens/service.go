@@ -3,13 +3,17 @@ package tokens
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/api/shared/events"
 	"clerk/api/shared/jwt"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/scopedtokens"
 	sentryclerk "clerk/pkg/sentry"
+	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
 
@@ -22,14 +26,17 @@ type Service struct {
 
 	eventService *events.Service
 	jwtService   *jwt.Service
+
+	jwtTemplateRepo *repository.JWTTemplate
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:           deps.DB(),
-		validator:    validator.New(),
-		eventService: events.NewService(deps),
-		jwtService:   jwt.NewService(deps.Clock()),
+		db:              deps.DB(),
+		validator:       validator.New(),
+		eventService:    events.NewService(deps),
+		jwtService:      jwt.NewService(deps),
+		jwtTemplateRepo: deps.Repositories().JWTTemplate,
 	}
 }
 
@@ -77,3 +84,99 @@ func (s *Service) CreateFromTemplate(ctx context.Context, params CreateFromTempl
 
 	return serialize.Token(token), nil
 }
+
+const (
+	paramScopes           = "scopes"
+	paramClaims           = "claims"
+	paramExpiresInSeconds = "expires_in_seconds"
+)
+
+type CreateScopedParams struct {
+	TemplateName     string         `json:"template" form:"template" validate:"required"`
+	Scopes           []string       `json:"scopes" form:"scopes" validate:"required"`
+	Claims           map[string]any `json:"claims" form:"claims"`
+	ExpiresInSeconds *int           `json:"expires_in_seconds" form:"expires_in_seconds"`
+}
+
+func (p CreateScopedParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(p); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+
+	return nil
+}
+
+// CreateScoped mints a short-lived machine-to-machine token, which carries
+// the requested scopes and claims. The JWT template of the token allowlists
+// what tokens can carry, so that a backend can only delegate the access the
+// template was set up for.
+func (s *Service) CreateScoped(ctx context.Context, params CreateScopedParams) (*serialize.ScopedTokenResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	jwtTemplate, err := s.jwtTemplateRepo.QueryByNameAndInstance(ctx, s.db, params.TemplateName, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if jwtTemplate == nil {
+		return nil, apierror.JWTTemplateNotFound("name", params.TemplateName)
+	}
+	if jwtTemplate.SessionTokenTemplate() || len(jwtTemplate.AllowedScopes) == 0 {
+		return nil, apierror.ScopedTokensNotEnabled(jwtTemplate.Name)
+	}
+
+	allowlist := scopedtokens.Allowlist{Scopes: jwtTemplate.AllowedScopes, Claims: jwtTemplate.AllowedClaims}
+	err = allowlist.Check(scopedtokens.Request{Scopes: params.Scopes, Claims: params.Claims})
+	if err != nil {
+		return nil, toScopedTokenAPIError(err)
+	}
+
+	var requestedLifetime *time.Duration
+	if params.ExpiresInSeconds != nil {
+		lifetime := time.Duration(*params.ExpiresInSeconds) * time.Second
+		requestedLifetime = &lifetime
+	}
+	lifetime, err := scopedtokens.Lifetime(requestedLifetime, time.Duration(jwtTemplate.Lifetime)*time.Second)
+	if err != nil {
+		return nil, apierror.FormInvalidParameterValue(paramExpiresInSeconds, strconv.Itoa(*params.ExpiresInSeconds))
+	}
+
+	token, expiresAt, err := s.jwtService.CreateScoped(ctx, s.db, jwt.CreateScopedParams{
+		Env:         env,
+		JWTTemplate: jwtTemplate,
+		Scopes:      params.Scopes,
+		Claims:      params.Claims,
+		Lifetime:    lifetime,
+	})
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.ScopedToken(token, scopedtokens.ParseScopes(scopedtokens.FormatScopes(params.Scopes)), expiresAt), nil
+}
+
+// toScopedTokenAPIError returns the API error for a request which its
+// template's allowlist rejected.
+func toScopedTokenAPIError(err error) apierror.Error {
+	if errors.Is(err, scopedtokens.ErrNoScopes) {
+		return apierror.FormMissingParameter(paramScopes)
+	}
+
+	var rejection *scopedtokens.RejectionError
+	if !errors.As(err, &rejection) {
+		return apierror.Unexpected(err)
+	}
+	switch {
+	case errors.Is(err, scopedtokens.ErrInvalidScope):
+		return apierror.FormInvalidParameterValue(paramScopes, rejection.Value)
+	case errors.Is(err, scopedtokens.ErrScopeNotAllowed):
+		return apierror.ScopedTokenNotAllowed(paramScopes, rejection.Value)
+	case errors.Is(err, scopedtokens.ErrReservedClaim):
+		return apierror.JWTTemplateReservedClaim(paramClaims, rejection.Value)
+	default:
+		return apierror.ScopedTokenNotAllowed(paramClaims, rejection.Value)
+	}
+}
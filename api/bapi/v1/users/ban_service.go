@@ -13,7 +13,8 @@ import (
 )
 
 // Ban marks the given user as banned. This terminates their active sessions (marks them as revoked)
-// and prevents them from signing in again.
+// and prevents them from signing in again. The backchannel logout URIs of the instance are notified of
+// the revoked sessions.
 func (s *Service) Ban(ctx context.Context, userID string) (*serialize.UserResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
@@ -39,12 +40,21 @@ func (s *Service) Ban(ctx context.Context, userID string) (*serialize.UserRespon
 			return nil, apierror.Unexpected(err)
 		}
 	}
+	err = s.backchannelLogoutService.Enqueue(ctx, s.db, env.Instance, client_data.ToSessionModels(activeSessions)...)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
 
 	// Ban the user
 	var userResponse *serialize.UserResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		user.Banned = true
-		err := s.userRepo.UpdateBanned(ctx, tx, user)
+		err = s.userRepo.UpdateBanned(ctx, tx, user)
 		if err != nil {
 			return true, err
 		}
@@ -55,7 +65,7 @@ func (s *Service) Ban(ctx context.Context, userID string) (*serialize.UserRespon
 		}
 		userResponse = serialize.UserToServerAPI(ctx, userSerializable)
 
-		if err = s.eventService.UserUpdated(ctx, tx, env.Instance, userResponse); err != nil {
+		if err = s.eventService.UserUpdated(ctx, tx, env.Instance, userResponse, previousUser); err != nil {
 			return true, err
 		}
 
@@ -81,6 +91,11 @@ func (s *Service) Unban(ctx context.Context, userID string) (*serialize.UserResp
 
 	var userResponse *serialize.UserResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		user.Banned = false
 		err = s.userRepo.UpdateBanned(ctx, s.db, user)
 		if err != nil {
@@ -93,7 +108,7 @@ func (s *Service) Unban(ctx context.Context, userID string) (*serialize.UserResp
 		}
 		userResponse = serialize.UserToServerAPI(ctx, userSerializable)
 
-		if err = s.eventService.UserUpdated(ctx, tx, env.Instance, userResponse); err != nil {
+		if err = s.eventService.UserUpdated(ctx, tx, env.Instance, userResponse, previousUser); err != nil {
 			return true, err
 		}
 		return false, nil
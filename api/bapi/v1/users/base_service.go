@@ -6,38 +6,57 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/backchannel_logout"
 	"clerk/api/shared/client_data"
+	"clerk/api/shared/comms"
+	shenvironment "clerk/api/shared/environment"
 	"clerk/api/shared/events"
+	"clerk/api/shared/externalaccount"
+	"clerk/api/shared/legal"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/pagination"
 	"clerk/api/shared/serializable"
+	"clerk/api/shared/trusted_devices"
 	userlockout "clerk/api/shared/user_lockout"
+	"clerk/api/shared/user_profile"
 	"clerk/api/shared/users"
 	"clerk/api/shared/validators"
 	"clerk/model"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/storage"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
 
 	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
 )
 
 // Service contains the business logic of all operations specific to users in server API.
 type Service struct {
-	db    database.Database
-	clock clockwork.Clock
+	db        database.Database
+	clock     clockwork.Clock
+	gueClient *gue.Client
+	storage   storage.ReadWriter
 
 	// services
-	clientDataService   *client_data.Service
-	eventService        *events.Service
-	orgsService         *organizations.Service
-	serializableService *serializable.Service
-	shUsersService      *users.Service
-	userCreateService   *users.CreateService
-	userLockoutService  *userlockout.Service
-	validatorService    *validators.Service
+	backchannelLogoutService *backchannel_logout.Service
+	clientDataService        *client_data.Service
+	commsService             *comms.Service
+	envService               *shenvironment.Service
+	eventService             *events.Service
+	externalAccountService   *externalaccount.Service
+	legalService             *legal.Service
+	mergeService             *users.MergeService
+	orgsService              *organizations.Service
+	serializableService      *serializable.Service
+	shUsersService           *users.Service
+	trustedDeviceService     *trusted_devices.Service
+	userCreateService        *users.CreateService
+	userLockoutService       *userlockout.Service
+	userProfileService       *user_profile.Service
+	validatorService         *validators.Service
 
 	// repositories
 	externalAccountRepo *repository.ExternalAccount
@@ -47,27 +66,43 @@ type Service struct {
 	userRepo            *repository.Users
 	verRepo             *repository.Verification
 	backupCodeRepo      *repository.BackupCode
+	bulkImportRepo      *repository.UserBulkImports
+	exportRepo          *repository.UserExports
+	signInTokenRepo     *repository.SignInToken
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:                  deps.DB(),
-		clock:               deps.Clock(),
-		clientDataService:   client_data.NewService(deps),
-		eventService:        events.NewService(deps),
-		orgsService:         organizations.NewService(deps),
-		validatorService:    validators.NewService(),
-		serializableService: serializable.NewService(deps.Clock()),
-		shUsersService:      users.NewService(deps),
-		userCreateService:   users.NewCreateService(deps.Clock()),
-		userLockoutService:  userlockout.NewService(deps),
-		externalAccountRepo: repository.NewExternalAccount(),
-		identRepo:           repository.NewIdentification(),
-		orgMembershipsRepo:  repository.NewOrganizationMembership(),
-		totpRepo:            repository.NewTOTP(),
-		userRepo:            repository.NewUsers(),
-		verRepo:             repository.NewVerification(),
-		backupCodeRepo:      repository.NewBackupCode(),
+		db:                       deps.DB(),
+		clock:                    deps.Clock(),
+		gueClient:                deps.GueClient(),
+		storage:                  deps.StorageClient(),
+		backchannelLogoutService: backchannel_logout.NewService(deps),
+		clientDataService:        client_data.NewService(deps),
+		commsService:             comms.NewService(deps),
+		envService:               shenvironment.NewService(),
+		eventService:             events.NewService(deps),
+		externalAccountService:   externalaccount.NewService(deps),
+		legalService:             legal.NewService(),
+		mergeService:             users.NewMergeService(deps),
+		orgsService:              organizations.NewService(deps),
+		validatorService:         validators.NewService(),
+		serializableService:      serializable.NewService(deps.Clock()),
+		shUsersService:           users.NewService(deps),
+		trustedDeviceService:     trusted_devices.NewService(deps),
+		userCreateService:        users.NewCreateService(deps),
+		userLockoutService:       userlockout.NewService(deps),
+		userProfileService:       user_profile.NewService(deps.Clock()),
+		externalAccountRepo:      deps.Repositories().ExternalAccount,
+		identRepo:                deps.Repositories().Identification,
+		orgMembershipsRepo:       deps.Repositories().OrganizationMembership,
+		totpRepo:                 deps.Repositories().TOTP,
+		userRepo:                 deps.Repositories().Users,
+		verRepo:                  deps.Repositories().Verification,
+		backupCodeRepo:           deps.Repositories().BackupCode,
+		bulkImportRepo:           deps.Repositories().UserBulkImports,
+		exportRepo:               deps.Repositories().UserExports,
+		signInTokenRepo:          deps.Repositories().SignInToken,
 	}
 }
 
@@ -98,7 +133,8 @@ func (s *Service) ListOrganizationMemberships(
 	paginationParams pagination.Params,
 ) (*serialize.PaginatedResponse, apierror.Error) {
 	memberships, apiErr := s.orgsService.ListMemberships(ctx, s.db, organizations.ListMembershipsParams{
-		UserID: &params.UserID,
+		UserID:     &params.UserID,
+		SkipCounts: true,
 	}, paginationParams)
 	if apiErr != nil {
 		return nil, apiErr
@@ -121,13 +157,14 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
@@ -0,0 +1,496 @@
+package users
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/phone_profiles"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cenv"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/jobs"
+	"clerk/pkg/sealing"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// maxBulkImportUsers is the maximum number of users a single bulk
+	// import can contain.
+	maxBulkImportUsers = 10000
+
+	// bulkImportBatchSize is the number of users which are created in the
+	// same transaction.
+	bulkImportBatchSize = 100
+)
+
+// Statuses of a user bulk import.
+const (
+	BulkImportStatusPending    = "pending"
+	BulkImportStatusProcessing = "processing"
+	BulkImportStatusCompleted  = "completed"
+)
+
+// BulkImportParams is the JSON payload of a user bulk import.
+type BulkImportParams struct {
+	Users []CreateParams `json:"users"`
+}
+
+// bulkImportMultiValueSeparator separates the values of CSV columns that
+// accept more than one value, e.g. the email addresses of a user.
+const bulkImportMultiValueSeparator = ";"
+
+// ParseBulkImportCSV reads the users of a bulk import from CSV. The first
+// record is the header, and its columns are named after the parameters of
+// CreateParams.
+func ParseBulkImportCSV(r io.Reader) ([]CreateParams, apierror.Error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	} else if err != nil {
+		return nil, apierror.FormInvalidParameterFormat("users", "The CSV payload could not be parsed.")
+	}
+	for _, column := range header {
+		if !bulkImportCSVColumns[column] {
+			return nil, apierror.FormUnknownParameter(column)
+		}
+	}
+
+	var users []CreateParams
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, apierror.FormInvalidParameterFormat("users", "The CSV payload could not be parsed.")
+		}
+		if len(users) == maxBulkImportUsers {
+			return nil, apierror.UserBulkImportTooLarge(maxBulkImportUsers)
+		}
+
+		user, apiErr := bulkImportUserFromCSV(header, record)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+var bulkImportCSVColumns = map[string]bool{
+	"external_id":               true,
+	"email_address":             true,
+	"phone_number":              true,
+	"web3_wallet":               true,
+	"username":                  true,
+	"password":                  true,
+	"password_digest":           true,
+	"password_hasher":           true,
+	"first_name":                true,
+	"last_name":                 true,
+	"public_metadata":           true,
+	"private_metadata":          true,
+	"unsafe_metadata":           true,
+	"skip_password_requirement": true,
+	"skip_password_checks":      true,
+	"totp_secret":               true,
+	"backup_codes":              true,
+	"created_at":                true,
+}
+
+func bulkImportUserFromCSV(header, record []string) (CreateParams, apierror.Error) {
+	var user CreateParams
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+
+		switch column {
+		case "external_id":
+			user.ExternalID = &value
+		case "email_address":
+			user.EmailAddresses = strings.Split(value, bulkImportMultiValueSeparator)
+		case "phone_number":
+			user.PhoneNumbers = strings.Split(value, bulkImportMultiValueSeparator)
+		case "web3_wallet":
+			user.Web3Wallets = strings.Split(value, bulkImportMultiValueSeparator)
+		case "username":
+			user.Username = &value
+		case "password":
+			user.Password = &value
+		case "password_digest":
+			user.PasswordDigest = &value
+		case "password_hasher":
+			user.PasswordHasher = &value
+		case "first_name":
+			user.FirstName = &value
+		case "last_name":
+			user.LastName = &value
+		case "public_metadata", "private_metadata", "unsafe_metadata":
+			if !json.Valid([]byte(value)) {
+				return user, apierror.FormInvalidParameterFormat(column, "Must be a valid JSON object.")
+			}
+			metadata := json.RawMessage(value)
+			switch column {
+			case "public_metadata":
+				user.PublicMetadata = &metadata
+			case "private_metadata":
+				user.PrivateMetadata = &metadata
+			default:
+				user.UnsafeMetadata = &metadata
+			}
+		case "skip_password_requirement", "skip_password_checks":
+			skip, err := strconv.ParseBool(value)
+			if err != nil {
+				return user, apierror.FormInvalidTypeParameter(column, "boolean")
+			}
+			if column == "skip_password_requirement" {
+				user.SkipPasswordRequirement = &skip
+			} else {
+				user.SkipPasswordChecks = &skip
+			}
+		case "totp_secret":
+			user.TOTPSecret = &value
+		case "backup_codes":
+			user.BackupCodes = strings.Split(value, bulkImportMultiValueSeparator)
+		case "created_at":
+			user.CreatedAt = &value
+		}
+	}
+	return user, nil
+}
+
+// CreateBulkImport stores the users to import and enqueues the job which
+// creates them. The users are validated while the job runs, so that a
+// single invalid user doesn't fail the whole import.
+//
+// The users carry secrets, such as passwords and TOTP secrets, so they're
+// sealed while they're stored, and dropped once the import completes.
+func (s *Service) CreateBulkImport(ctx context.Context, users []CreateParams) (*serialize.UserBulkImportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if len(users) == 0 {
+		return nil, apierror.FormMissingParameter("users")
+	}
+	if len(users) > maxBulkImportUsers {
+		return nil, apierror.UserBulkImportTooLarge(maxBulkImportUsers)
+	}
+
+	payload, err := sealBulkImportUsers(env.Instance.ID, users)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	bulkImport := &model.UserBulkImport{UserBulkImport: &sqbmodel.UserBulkImport{
+		InstanceID: env.Instance.ID,
+		Status:     BulkImportStatusPending,
+		TotalCount: len(users),
+		Payload:    payload,
+	}}
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if err := s.bulkImportRepo.Insert(ctx, tx, bulkImport); err != nil {
+			return true, err
+		}
+
+		err := jobs.ImportUsers(ctx, s.gueClient, jobs.ImportUsersArgs{
+			BulkImportID: bulkImport.ID,
+		}, jobs.WithTx(tx))
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return toBulkImportResponse(bulkImport)
+}
+
+// bulkImportKey returns the key which seals the users of bulk imports.
+func bulkImportKey() (*sealing.Key, error) {
+	key, err := sealing.ParseKey(cenv.Get(cenv.UserBulkImportPayloadKey))
+	if err != nil {
+		return nil, fmt.Errorf("users/bulkImportKey: %w", err)
+	}
+	return key, nil
+}
+
+// sealBulkImportUsers returns the payload of a bulk import of the given
+// instance, which is the sealed users as a JSON string. The payload can
+// only be opened for the same instance.
+func sealBulkImportUsers(instanceID string, users []CreateParams) ([]byte, error) {
+	key, err := bulkImportKey()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(users)
+	if err != nil {
+		return nil, fmt.Errorf("users/sealBulkImportUsers: encoding users: %w", err)
+	}
+	sealed, err := key.Seal(plaintext, []byte(instanceID))
+	if err != nil {
+		return nil, fmt.Errorf("users/sealBulkImportUsers: %w", err)
+	}
+	return json.Marshal(sealed)
+}
+
+// openBulkImportUsers returns the users sealed in the payload of a bulk
+// import.
+func openBulkImportUsers(bulkImport *model.UserBulkImport) ([]CreateParams, error) {
+	key, err := bulkImportKey()
+	if err != nil {
+		return nil, err
+	}
+	var sealed string
+	if err := json.Unmarshal(bulkImport.Payload, &sealed); err != nil {
+		return nil, fmt.Errorf("users/openBulkImportUsers: decoding payload of bulk import %s: %w", bulkImport.ID, err)
+	}
+	plaintext, err := key.Open(sealed, []byte(bulkImport.InstanceID))
+	if err != nil {
+		return nil, fmt.Errorf("users/openBulkImportUsers: opening payload of bulk import %s: %w", bulkImport.ID, err)
+	}
+	var users []CreateParams
+	if err := json.Unmarshal(plaintext, &users); err != nil {
+		return nil, fmt.Errorf("users/openBulkImportUsers: decoding users of bulk import %s: %w", bulkImport.ID, err)
+	}
+	return users, nil
+}
+
+// ReadBulkImport returns the progress of a bulk import of the instance.
+func (s *Service) ReadBulkImport(ctx context.Context, bulkImportID string) (*serialize.UserBulkImportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	bulkImport, err := s.bulkImportRepo.QueryByIDAndInstance(ctx, s.db, bulkImportID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if bulkImport == nil {
+		return nil, apierror.UserBulkImportNotFound(bulkImportID)
+	}
+
+	return toBulkImportResponse(bulkImport)
+}
+
+func toBulkImportResponse(bulkImport *model.UserBulkImport) (*serialize.UserBulkImportResponse, apierror.Error) {
+	response, err := serialize.UserBulkImport(bulkImport)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+// ProcessBulkImport creates the users of a bulk import in batches. It is
+// run by the import_users job, and resumes from the last recorded row if the
+// job is retried.
+//
+// The progress of the import is recorded in the same transaction as the
+// users it accounts for, so that a retried job never creates a user twice,
+// even one without any unique identifier.
+func (s *Service) ProcessBulkImport(ctx context.Context, bulkImportID string) error {
+	bulkImport, err := s.bulkImportRepo.FindByID(ctx, s.db, bulkImportID)
+	if err != nil {
+		return fmt.Errorf("users/processBulkImport: fetching bulk import %s: %w", bulkImportID, err)
+	}
+	if bulkImport.Status == BulkImportStatusCompleted {
+		return nil
+	}
+
+	env, err := s.envService.Load(ctx, s.db, bulkImport.InstanceID)
+	if err != nil {
+		return fmt.Errorf("users/processBulkImport: loading environment of instance %s: %w", bulkImport.InstanceID, err)
+	}
+	ctx = environment.NewContext(ctx, env)
+	ctx = phone_profiles.NewContext(ctx, env.Instance)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	users, err := openBulkImportUsers(bulkImport)
+	if err != nil {
+		return fmt.Errorf("users/processBulkImport: %w", err)
+	}
+	rowErrors := make([]serialize.UserBulkImportRowError, 0)
+	if len(bulkImport.Errors) > 0 {
+		if err := json.Unmarshal(bulkImport.Errors, &rowErrors); err != nil {
+			return fmt.Errorf("users/processBulkImport: decoding errors of bulk import %s: %w", bulkImport.ID, err)
+		}
+	}
+
+	for bulkImport.ProcessedCount < len(users) {
+		start := bulkImport.ProcessedCount
+		end := min(start+bulkImportBatchSize, len(users))
+		rowErrors, err = s.importBatch(ctx, env, userSettings, bulkImport, rowErrors, start, users[start:end])
+		if err != nil {
+			return fmt.Errorf("users/processBulkImport: importing rows %d to %d of bulk import %s: %w", start, end, bulkImport.ID, err)
+		}
+	}
+
+	// The users are no longer needed, and their secrets shouldn't outlive
+	// the import.
+	bulkImport.Status = BulkImportStatusCompleted
+	bulkImport.CompletedAt = null.TimeFrom(s.clock.Now().UTC())
+	bulkImport.Payload = nil
+	err = s.bulkImportRepo.Update(ctx, s.db, bulkImport,
+		sqbmodel.UserBulkImportColumns.Status,
+		sqbmodel.UserBulkImportColumns.CompletedAt,
+		sqbmodel.UserBulkImportColumns.Payload,
+	)
+	if err != nil {
+		return fmt.Errorf("users/processBulkImport: completing bulk import %s: %w", bulkImport.ID, err)
+	}
+	return nil
+}
+
+var bulkImportProgressColumns = []string{
+	sqbmodel.UserBulkImportColumns.Status,
+	sqbmodel.UserBulkImportColumns.ProcessedCount,
+	sqbmodel.UserBulkImportColumns.SucceededCount,
+	sqbmodel.UserBulkImportColumns.FailedCount,
+	sqbmodel.UserBulkImportColumns.Errors,
+}
+
+// withProgress returns a copy of the bulk import which records that all
+// rows before processed were handled, succeeded more of them created a user,
+// and the rest failed with rowErrors.
+func withProgress(bulkImport *model.UserBulkImport, processed, succeeded int, rowErrors []serialize.UserBulkImportRowError) (*model.UserBulkImport, error) {
+	encodedErrors, err := json.Marshal(rowErrors)
+	if err != nil {
+		return nil, fmt.Errorf("encoding errors of bulk import %s: %w", bulkImport.ID, err)
+	}
+
+	next := *bulkImport.UserBulkImport
+	next.Status = BulkImportStatusProcessing
+	next.ProcessedCount = processed
+	next.SucceededCount += succeeded
+	next.FailedCount = len(rowErrors)
+	next.Errors = encodedErrors
+	return &model.UserBulkImport{UserBulkImport: &next}, nil
+}
+
+// importBatch creates the given users, which start at row offset of the
+// bulk import, in a single transaction along with the progress of the
+// import. It returns the errors of the import so far, including the ones
+// of the users which couldn't be created.
+func (s *Service) importBatch(
+	ctx context.Context,
+	env *model.Env,
+	userSettings *usersettings.UserSettings,
+	bulkImport *model.UserBulkImport,
+	rowErrors []serialize.UserBulkImportRowError,
+	offset int,
+	users []CreateParams,
+) ([]serialize.UserBulkImportRowError, error) {
+	invalid := make(map[int]apierror.Error)
+	for i := range users {
+		params, apiErr := s.prepareCreateParams(ctx, env, userSettings, users[i])
+		if apiErr != nil {
+			invalid[i] = apiErr
+			continue
+		}
+		users[i] = params
+	}
+
+	batchErrors := slices.Clone(rowErrors)
+	for i := range users {
+		if apiErr, ok := invalid[i]; ok {
+			batchErrors = append(batchErrors, toBulkImportRowError(ctx, offset+i, apiErr))
+		}
+	}
+	next, err := withProgress(bulkImport, offset+len(users), len(users)-len(invalid), batchErrors)
+	if err != nil {
+		return nil, err
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		for i := range users {
+			if _, ok := invalid[i]; ok {
+				continue
+			}
+			if _, err := s.createAndNotify(ctx, tx, env, userSettings, users[i]); err != nil {
+				return true, err
+			}
+		}
+		err := s.bulkImportRepo.Update(ctx, tx, next, bulkImportProgressColumns...)
+		return err != nil, err
+	})
+	if txErr == nil {
+		bulkImport.UserBulkImport = next.UserBulkImport
+		return batchErrors, nil
+	}
+
+	// One of the users couldn't be created, e.g. because another user of
+	// the batch has the same identifier. Retry them one by one, so that
+	// only the users which fail are left out.
+	log.Warning(ctx, "users/importBatch: creating batch at row %d: %s", offset, txErr)
+	for i := range users {
+		if rowErrors, err = s.importRow(ctx, env, userSettings, bulkImport, rowErrors, offset+i, users[i], invalid[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rowErrors, nil
+}
+
+// importRow creates the user at the given row of the bulk import, along
+// with the progress of the import, unless the user is invalid.
+func (s *Service) importRow(
+	ctx context.Context,
+	env *model.Env,
+	userSettings *usersettings.UserSettings,
+	bulkImport *model.UserBulkImport,
+	rowErrors []serialize.UserBulkImportRowError,
+	row int,
+	user CreateParams,
+	invalid apierror.Error,
+) ([]serialize.UserBulkImportRowError, error) {
+	if invalid == nil {
+		next, err := withProgress(bulkImport, row+1, 1, rowErrors)
+		if err != nil {
+			return nil, err
+		}
+		txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+			if _, err := s.createAndNotify(ctx, tx, env, userSettings, user); err != nil {
+				return true, err
+			}
+			err := s.bulkImportRepo.Update(ctx, tx, next, bulkImportProgressColumns...)
+			return err != nil, err
+		})
+		if txErr == nil {
+			bulkImport.UserBulkImport = next.UserBulkImport
+			return rowErrors, nil
+		}
+		invalid = toCreateAPIError(txErr)
+	}
+
+	// No user was created, so recording the error on its own is safe to
+	// repeat.
+	rowErrors = append(slices.Clone(rowErrors), toBulkImportRowError(ctx, row, invalid))
+	next, err := withProgress(bulkImport, row+1, 0, rowErrors)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.bulkImportRepo.Update(ctx, s.db, next, bulkImportProgressColumns...); err != nil {
+		return nil, fmt.Errorf("recording error of row %d: %w", row, err)
+	}
+	bulkImport.UserBulkImport = next.UserBulkImport
+	return rowErrors, nil
+}
+
+func toBulkImportRowError(ctx context.Context, row int, apiErr apierror.Error) serialize.UserBulkImportRowError {
+	return serialize.UserBulkImportRowError{
+		Row:    row,
+		Errors: apierror.ToResponse(ctx, apiErr).Errors,
+	}
+}
@@ -0,0 +1,121 @@
+package users
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBulkImportCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		csv      string
+		want     []CreateParams
+		wantCode string
+	}{
+		{
+			name: "empty payload",
+			csv:  "",
+		},
+		{
+			name: "multiple values and metadata",
+			csv: "external_id,email_address,first_name,public_metadata,skip_password_checks\n" +
+				`ext_1,a@clerk.dev;b@clerk.dev,Jane,"{""plan"":""pro""}",true` + "\n" +
+				"ext_2,,,,\n",
+			want: []CreateParams{
+				{
+					ExternalID:         strPtr("ext_1"),
+					EmailAddresses:     []string{"a@clerk.dev", "b@clerk.dev"},
+					FirstName:          strPtr("Jane"),
+					PublicMetadata:     rawPtr(`{"plan":"pro"}`),
+					SkipPasswordChecks: boolPtr(true),
+				},
+				{
+					ExternalID: strPtr("ext_2"),
+				},
+			},
+		},
+		{
+			name:     "unknown column",
+			csv:      "email_address,nickname\na@clerk.dev,jj\n",
+			wantCode: apierror.FormParamUnknownCode,
+		},
+		{
+			name:     "invalid metadata",
+			csv:      "private_metadata\n{nope\n",
+			wantCode: apierror.FormParamFormatInvalidCode,
+		},
+		{
+			name:     "invalid boolean",
+			csv:      "skip_password_requirement\nmaybe\n",
+			wantCode: apierror.FormParamTypeInvalidCode,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			users, apiErr := ParseBulkImportCSV(strings.NewReader(tt.csv))
+			if tt.wantCode != "" {
+				require.NotNil(t, apiErr)
+				assert.Equal(t, tt.wantCode, apiErr.Errors()[0].Code())
+				return
+			}
+
+			require.Nil(t, apiErr)
+			assert.Equal(t, tt.want, users)
+		})
+	}
+}
+
+func TestWithProgress(t *testing.T) {
+	t.Parallel()
+
+	bulkImport := &model.UserBulkImport{UserBulkImport: &sqbmodel.UserBulkImport{
+		ID:             "bui_1",
+		Status:         BulkImportStatusPending,
+		TotalCount:     250,
+		ProcessedCount: 100,
+		SucceededCount: 99,
+		FailedCount:    1,
+	}}
+	rowErrors := []serialize.UserBulkImportRowError{{Row: 3}, {Row: 150}}
+
+	next, err := withProgress(bulkImport, 200, 99, rowErrors)
+	require.NoError(t, err)
+	assert.Equal(t, BulkImportStatusProcessing, next.Status)
+	assert.Equal(t, 200, next.ProcessedCount)
+	assert.Equal(t, 198, next.SucceededCount)
+	assert.Equal(t, 2, next.FailedCount)
+	assert.JSONEq(t, `[{"row":3,"errors":null},{"row":150,"errors":null}]`, string(next.Errors))
+
+	// The bulk import itself is only updated once the progress is stored.
+	assert.Equal(t, BulkImportStatusPending, bulkImport.Status)
+	assert.Equal(t, 100, bulkImport.ProcessedCount)
+	assert.Equal(t, 99, bulkImport.SucceededCount)
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func rawPtr(s string) *json.RawMessage {
+	raw := json.RawMessage(s)
+	return &raw
+}
@@ -9,6 +9,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/phone_profiles"
 	"clerk/api/shared/users"
 	"clerk/model"
 	"clerk/model/sqbmodel"
@@ -18,7 +19,6 @@ import (
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/hash"
 	"clerk/pkg/metadata"
-	"clerk/pkg/phonenumber"
 	"clerk/pkg/set"
 	"clerk/pkg/time"
 	usersettings "clerk/pkg/usersettings/clerk"
@@ -75,6 +75,27 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (interface{},
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 
+	params, apiErr := s.prepareCreateParams(ctx, env, userSettings, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var userResponse *serialize.UserResponse
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		userResponse, err = s.createAndNotify(ctx, tx, env, userSettings, params)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, toCreateAPIError(txErr)
+	}
+
+	return userResponse, nil
+}
+
+// prepareCreateParams sanitizes the given params and validates them against
+// the user settings of the instance.
+func (s *Service) prepareCreateParams(ctx context.Context, env *model.Env, userSettings *usersettings.UserSettings, params CreateParams) (CreateParams, apierror.Error) {
 	var sanitizedEmailAddresses []string
 	for _, emailAddress := range params.EmailAddresses {
 		sanitizedEmailAddress := strings.TrimSpace(strings.ToLower(emailAddress))
@@ -82,11 +103,12 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (interface{},
 	}
 	params.EmailAddresses = sanitizedEmailAddresses
 
+	phoneProfile := phone_profiles.ForInstance(env.Instance)
 	var sanitizedPhoneNumbers []string
 	for _, phoneNum := range params.PhoneNumbers {
-		sanitizedPhoneNumber, err := phonenumber.Sanitize(strings.TrimSpace(phoneNum))
-		if err != nil {
-			return nil, apierror.FormInvalidPhoneNumber(param.PhoneNumber.Name)
+		sanitizedPhoneNumber, apiErr := phoneProfile.Sanitize(phoneNum, param.PhoneNumber.Name)
+		if apiErr != nil {
+			return params, apiErr
 		}
 		sanitizedPhoneNumbers = append(sanitizedPhoneNumbers, sanitizedPhoneNumber)
 	}
@@ -100,47 +122,50 @@ func (s *Service) Create(ctx context.Context, params CreateParams) (interface{},
 	params.Web3Wallets = sanitizedWeb3Wallets
 
 	if params.Username != nil {
-		*params.Username = strings.ToLower(*params.Username)
+		username := strings.ToLower(*params.Username)
+		params.Username = &username
 	}
 
 	// validate all form elements separately.
 	if apiErr := s.validateCreateParams(ctx, env.Instance.ID, params, userSettings); apiErr != nil {
-		return nil, apiErr
+		return params, apiErr
 	}
 
-	var userResponse *serialize.UserResponse
-	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
-		iUser, err := s.createUser(ctx, tx, env, params)
-		if errors.Is(err, hash.ErrPasswordTooLong) {
-			return true, apierror.FormInvalidPasswordSizeInBytesExceeded(param.Password.Name)
-		} else if err != nil {
-			return true, err
-		}
-
-		userSerializable, err := s.serializableService.ConvertUser(ctx, tx, userSettings, iUser)
-		if err != nil {
-			return true, apierror.Unexpected(err)
-		}
+	return params, nil
+}
 
-		userResponse = serialize.UserToServerAPI(ctx, userSerializable)
+// createAndNotify creates the user described by the already prepared params
+// and sends the user.created event.
+func (s *Service) createAndNotify(ctx context.Context, tx database.Tx, env *model.Env, userSettings *usersettings.UserSettings, params CreateParams) (*serialize.UserResponse, error) {
+	iUser, err := s.createUser(ctx, tx, env, params)
+	if errors.Is(err, hash.ErrPasswordTooLong) {
+		return nil, apierror.FormInvalidPasswordSizeInBytesExceeded(param.Password.Name)
+	} else if err != nil {
+		return nil, err
+	}
 
-		if err = s.eventService.UserCreated(ctx, tx, env.Instance, userSerializable); err != nil {
-			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", iUser, env.Instance.ID, err)
-		}
+	userSerializable, err := s.serializableService.ConvertUser(ctx, tx, userSettings, iUser)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
 
-		return false, nil
-	})
-	if txErr != nil {
-		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
-			return nil, apiErr
-		}
-		if clerkerrors.IsUniqueConstraintViolation(txErr, clerkerrors.UniqueExternalID) {
-			return nil, apierror.FormIdentifierExists(param.ExternalID.Name)
-		}
-		return nil, apierror.Unexpected(txErr)
+	if err = s.eventService.UserCreated(ctx, tx, env.Instance, userSerializable); err != nil {
+		return nil, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", iUser, env.Instance.ID, err)
 	}
 
-	return userResponse, nil
+	return serialize.UserToServerAPI(ctx, userSerializable), nil
+}
+
+// toCreateAPIError converts an error of the transaction which creates a user
+// to an API error.
+func toCreateAPIError(txErr error) apierror.Error {
+	if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+		return apiErr
+	}
+	if clerkerrors.IsUniqueConstraintViolation(txErr, clerkerrors.UniqueExternalID) {
+		return apierror.FormIdentifierExists(param.ExternalID.Name)
+	}
+	return apierror.Unexpected(txErr)
 }
 
 func (s *Service) validateCreateParams(ctx context.Context, instanceID string, params CreateParams, userSettings *usersettings.UserSettings) apierror.Error {
@@ -24,6 +24,11 @@ func (s *Service) DisableMFA(ctx context.Context, userID string) apierror.Error
 	}
 
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		if err = s.identRepo.RestoreSecondFactorByUser(ctx, tx, userID); err != nil {
 			return true, err
 		}
@@ -36,7 +41,7 @@ func (s *Service) DisableMFA(ctx context.Context, userID string) apierror.Error
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/disableMFA: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -0,0 +1,244 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/organizations"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/phone_profiles"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/jobs"
+	clerktime "clerk/pkg/time"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Statuses of a user export.
+const (
+	ExportStatusPending   = "pending"
+	ExportStatusCompleted = "completed"
+	ExportStatusFailed    = "failed"
+)
+
+// Formats of a user export.
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// exportDownloadURLLifetime is for how long the download URL of a completed
+// export is valid. A new URL is signed every time the export is read.
+const exportDownloadURLLifetime = 15 * time.Minute
+
+// exportMembershipsPageSize is the number of organization memberships that
+// are loaded at once while assembling an export.
+const exportMembershipsPageSize = 500
+
+// ExportParams are the options of a user export.
+type ExportParams struct {
+	Format string `json:"format" form:"format"`
+}
+
+// CreateExport enqueues the job which assembles an export of all the data
+// of the user, for data portability requests.
+func (s *Service) CreateExport(ctx context.Context, userID string, params ExportParams) (*serialize.UserExportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if params.Format == "" {
+		params.Format = ExportFormatJSON
+	}
+	if params.Format != ExportFormatJSON && params.Format != ExportFormatZIP {
+		return nil, apierror.FormInvalidParameterValue("format", params.Format)
+	}
+
+	export := &model.UserExport{UserExport: &sqbmodel.UserExport{
+		InstanceID: env.Instance.ID,
+		UserID:     userID,
+		Status:     ExportStatusPending,
+		Format:     params.Format,
+	}}
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if err := s.exportRepo.Insert(ctx, tx, export); err != nil {
+			return true, err
+		}
+
+		err := jobs.ExportUser(ctx, s.gueClient, jobs.ExportUserArgs{
+			UserExportID: export.ID,
+		}, jobs.WithTx(tx))
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.UserExport(export, "", 0), nil
+}
+
+// ReadExport returns the progress of an export of the user and, once it
+// has completed, a signed URL to download it from.
+func (s *Service) ReadExport(ctx context.Context, userID, exportID string) (*serialize.UserExportResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	export, err := s.exportRepo.QueryByIDAndInstance(ctx, s.db, exportID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if export == nil || export.UserID != userID {
+		return nil, apierror.UserExportNotFound(exportID)
+	}
+
+	if export.Status != ExportStatusCompleted {
+		return serialize.UserExport(export, "", 0), nil
+	}
+
+	expireAt := s.clock.Now().UTC().Add(exportDownloadURLLifetime)
+	downloadURL, err := s.storage.SignedURL(ctx, export.StoragePath.String, expireAt)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.UserExport(export, downloadURL, clerktime.UnixMilli(expireAt)), nil
+}
+
+// ProcessExport assembles the export of a user and uploads it to storage.
+// It is run by the export_user job.
+func (s *Service) ProcessExport(ctx context.Context, exportID string) error {
+	export, err := s.exportRepo.FindByID(ctx, s.db, exportID)
+	if err != nil {
+		return fmt.Errorf("users/processExport: fetching export %s: %w", exportID, err)
+	}
+	if export.Status != ExportStatusPending {
+		return nil
+	}
+
+	env, err := s.envService.Load(ctx, s.db, export.InstanceID)
+	if err != nil {
+		return fmt.Errorf("users/processExport: loading environment of instance %s: %w", export.InstanceID, err)
+	}
+	ctx = environment.NewContext(ctx, env)
+	ctx = phone_profiles.NewContext(ctx, env.Instance)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, export.UserID, env.Instance.ID)
+	if err != nil {
+		return fmt.Errorf("users/processExport: fetching user %s: %w", export.UserID, err)
+	}
+	if user == nil {
+		// the user was deleted in the meantime, so there's nothing to export
+		export.Status = ExportStatusFailed
+		return s.exportRepo.Update(ctx, s.db, export, sqbmodel.UserExportColumns.Status)
+	}
+
+	document, err := s.exportDocument(ctx, env, user)
+	if err != nil {
+		return fmt.Errorf("users/processExport: assembling export %s: %w", export.ID, err)
+	}
+
+	content, err := encodeExport(document, export.Format)
+	if err != nil {
+		return fmt.Errorf("users/processExport: encoding export %s: %w", export.ID, err)
+	}
+
+	path := fmt.Sprintf("user_exports/%s/%s.%s", export.InstanceID, export.ID, export.Format)
+	if _, err := s.storage.Write(ctx, path, bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("users/processExport: uploading export %s: %w", export.ID, err)
+	}
+
+	export.Status = ExportStatusCompleted
+	export.StoragePath = null.StringFrom(path)
+	export.CompletedAt = null.TimeFrom(s.clock.Now().UTC())
+	err = s.exportRepo.Update(ctx, s.db, export,
+		sqbmodel.UserExportColumns.Status,
+		sqbmodel.UserExportColumns.StoragePath,
+		sqbmodel.UserExportColumns.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("users/processExport: completing export %s: %w", export.ID, err)
+	}
+	return nil
+}
+
+// exportDocument collects the user, along with their identifications,
+// external accounts and metadata, their sessions and their organization
+// memberships.
+func (s *Service) exportDocument(ctx context.Context, env *model.Env, user *model.User) (*serialize.UserExportDocumentResponse, error) {
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	userSerializable, err := s.serializableService.ConvertUser(ctx, s.db, userSettings, user)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.clientDataService.FindAllUserSessions(ctx, env.Instance.ID, user.ID, nil)
+	if err != nil {
+		return nil, err
+	}
+	sessionResponses := make([]*serialize.SessionServerResponse, len(sessions))
+	for i, session := range sessions {
+		sessionResponses[i] = serialize.SessionToServerAPI(s.clock, session.ToSessionModel())
+	}
+
+	membershipResponses := make([]*serialize.OrganizationMembershipResponse, 0)
+	for offset := 0; ; offset += exportMembershipsPageSize {
+		memberships, apiErr := s.orgsService.ListMemberships(ctx, s.db, organizations.ListMembershipsParams{
+			UserID:     &user.ID,
+			SkipCounts: true,
+		}, pagination.Params{Limit: exportMembershipsPageSize, Offset: offset})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		for _, membership := range memberships {
+			membershipResponses = append(membershipResponses, serialize.OrganizationMembershipBAPI(ctx, membership))
+		}
+		if len(memberships) < exportMembershipsPageSize {
+			break
+		}
+	}
+
+	return &serialize.UserExportDocumentResponse{
+		User:                    serialize.UserToServerAPI(ctx, userSerializable),
+		Sessions:                sessionResponses,
+		OrganizationMemberships: membershipResponses,
+		ExportedAt:              clerktime.UnixMilli(s.clock.Now().UTC()),
+	}, nil
+}
+
+// encodeExport returns the document as a single JSON file, or as a ZIP
+// archive with one JSON file per section.
+func encodeExport(document *serialize.UserExportDocumentResponse, format string) ([]byte, error) {
+	if format == ExportFormatJSON {
+		return json.Marshal(document)
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, section := range []struct {
+		name    string
+		content interface{}
+	}{
+		{name: "user.json", content: document.User},
+		{name: "sessions.json", content: document.Sessions},
+		{name: "organization_memberships.json", content: document.OrganizationMemberships},
+	} {
+		file, err := archive.Create(section.name)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.NewEncoder(file).Encode(section.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
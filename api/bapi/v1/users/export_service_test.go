@@ -0,0 +1,110 @@
+package users
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestEncodeExport(t *testing.T) {
+	t.Parallel()
+
+	document := &serialize.UserExportDocumentResponse{
+		User:                    &serialize.UserResponse{ID: "user_1"},
+		Sessions:                []*serialize.SessionServerResponse{{ID: "sess_1"}},
+		OrganizationMemberships: []*serialize.OrganizationMembershipResponse{},
+		ExportedAt:              1700000000000,
+	}
+
+	content, err := encodeExport(document, ExportFormatJSON)
+	require.NoError(t, err)
+	var decoded map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Len(t, decoded, 4)
+	for _, section := range []string{"user", "sessions", "organization_memberships"} {
+		assert.Contains(t, decoded, section)
+	}
+	assert.JSONEq(t, "1700000000000", string(decoded["exported_at"]))
+
+	// ZIP exports have one file per section
+	content, err = encodeExport(document, ExportFormatZIP)
+	require.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+		files[file.Name] = string(body)
+	}
+	require.Len(t, files, 3)
+	var user serialize.UserResponse
+	require.NoError(t, json.Unmarshal([]byte(files["user.json"]), &user))
+	assert.Equal(t, "user_1", user.ID)
+	var sessions []serialize.SessionServerResponse
+	require.NoError(t, json.Unmarshal([]byte(files["sessions.json"]), &sessions))
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "sess_1", sessions[0].ID)
+	assert.JSONEq(t, "[]", files["organization_memberships.json"])
+}
+
+func TestCreateExportInvalidFormat(t *testing.T) {
+	t.Parallel()
+
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}}
+	ctx := environment.NewContext(context.Background(), env)
+
+	// the format is validated before anything is stored
+	_, apiErr := (&Service{}).CreateExport(ctx, "user_1", ExportParams{Format: "xml"})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestSerializeUserExport(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	export := &model.UserExport{UserExport: &sqbmodel.UserExport{
+		ID:        "uexp_1",
+		UserID:    "user_1",
+		Status:    ExportStatusPending,
+		Format:    ExportFormatZIP,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+
+	// pending exports have nothing to download yet
+	response := serialize.UserExport(export, "", 0)
+	assert.Equal(t, serialize.ObjectUserExport, response.Object)
+	assert.Equal(t, ExportFormatZIP, response.Format)
+	assert.Nil(t, response.DownloadURL)
+	assert.Nil(t, response.ExpireAt)
+	assert.Nil(t, response.CompletedAt)
+
+	export.Status = ExportStatusCompleted
+	export.CompletedAt = null.TimeFrom(now.Add(time.Minute))
+	expireAt := now.Add(exportDownloadURLLifetime).UnixMilli()
+	response = serialize.UserExport(export, "https://storage.example.com/uexp_1.zip", expireAt)
+	require.NotNil(t, response.DownloadURL)
+	assert.Equal(t, "https://storage.example.com/uexp_1.zip", *response.DownloadURL)
+	assert.Equal(t, expireAt, *response.ExpireAt)
+	assert.Equal(t, now.Add(time.Minute).UnixMilli(), *response.CompletedAt)
+}
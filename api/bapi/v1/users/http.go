@@ -1,10 +1,13 @@
 package users
 
 import (
+	"mime"
 	"net/http"
 	"unicode/utf8"
 
 	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/dryrun"
 	"clerk/api/shared/pagination"
 	"clerk/api/shared/serializable"
 	"clerk/api/shared/users"
@@ -13,6 +16,7 @@ import (
 	"clerk/pkg/uploads"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
+	"clerk/utils/log"
 	"clerk/utils/param"
 
 	"github.com/go-chi/chi/v5"
@@ -37,7 +41,7 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
 		db:                   deps.DB(),
 		clock:                deps.Clock(),
-		listService:          NewListService(deps.Clock(), deps.ReadOnlyDB()),
+		listService:          NewListService(deps),
 		serializableService:  serializable.NewService(deps.Clock()),
 		service:              NewService(deps),
 		shUsersService:       users.NewService(deps),
@@ -53,7 +57,7 @@ func (h *HTTP) CheckUserInInstance(_ http.ResponseWriter, r *http.Request) (*htt
 }
 
 // GET /v1/users
-func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+func (h *HTTP) List(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	params := toReadAllParams(r)
 
 	if params.query != "" && utf8.RuneCountInString(params.query) < 3 {
@@ -67,7 +71,23 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
-	return h.listService.ReadAll(r.Context(), params, pagination)
+	opts, err := userOptionsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// The list is streamed to the response, to keep memory usage in check
+	// for large pages. Errors can only be returned to the client until the
+	// first user has been written.
+	w.Header().Set("Content-Type", "application/json")
+	stream := serialize.NewArrayStream(w)
+	if apiErr := h.listService.StreamAll(r.Context(), stream, params, pagination, opts); apiErr != nil {
+		if !stream.Started() {
+			return nil, apiErr
+		}
+		log.Error(r.Context(), "users/List: streaming users: %s", apiErr)
+	}
+	return nil, nil
 }
 
 // GET /v1/users/count
@@ -86,6 +106,7 @@ func toReadAllParams(r *http.Request) readAllParams {
 		web3Wallets:       r.URL.Query()["web3_wallet"],
 		lastActiveAtSince: r.URL.Query()["last_active_at_since"],
 		query:             r.URL.Query().Get("query"),
+		metadataFilters:   r.URL.Query(),
 	}
 }
 
@@ -99,17 +120,69 @@ func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.service.Create(r.Context(), params)
 }
 
+// POST /v1/users/bulk
+func (h *HTTP) CreateBulkImport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var users []CreateParams
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		var apiErr apierror.Error
+		users, apiErr = ParseBulkImportCSV(r.Body)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	} else {
+		params := BulkImportParams{}
+		if err := clerkhttp.Decode(r, &params); err != nil {
+			return nil, err
+		}
+		users = params.Users
+	}
+
+	return h.service.CreateBulkImport(r.Context(), users)
+}
+
+// GET /v1/users/bulk/{bulkImportID}
+func (h *HTTP) ReadBulkImport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadBulkImport(r.Context(), chi.URLParam(r, "bulkImportID"))
+}
+
+// POST /v1/users/{userID}/export
+func (h *HTTP) CreateExport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := ExportParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateExport(r.Context(), chi.URLParam(r, "userID"), params)
+}
+
+// GET /v1/users/{userID}/exports/{exportID}
+func (h *HTTP) ReadExport(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadExport(r.Context(), chi.URLParam(r, "userID"), chi.URLParam(r, "exportID"))
+}
+
 // GET /v1/users/{userID}
 func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
-	return h.service.Read(r.Context(), userID)
+	opts, err := userOptionsFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.Read(r.Context(), userID, opts)
 }
 
 // DELETE /v1/users/{userID}
 func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	dryRun, apiErr := dryrun.FromRequest(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
 	userID := chi.URLParam(r, "userID")
 	ctx := r.Context()
 	env := environment.FromContext(ctx)
+	if dryRun {
+		return h.shUsersService.DeleteDryRun(ctx, env, userID)
+	}
 	return h.shUsersService.Delete(ctx, env, userID)
 }
 
@@ -151,6 +224,21 @@ func (h *HTTP) UpdateProfileImage(w http.ResponseWriter, r *http.Request) (inter
 	return h.service.UpdateProfileImage(r.Context(), userID, filePart)
 }
 
+// POST /v1/users/{userID}/profile_image/uploads
+func (h *HTTP) CreateProfileImageUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := CreateProfileImageUploadParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateProfileImageUpload(r.Context(), chi.URLParam(r, "userID"), params)
+}
+
+// POST /v1/users/{userID}/profile_image/uploads/{imageID}/complete
+func (h *HTTP) CompleteProfileImageUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.CompleteProfileImageUpload(r.Context(), chi.URLParam(r, "userID"), chi.URLParam(r, "imageID"))
+}
+
 // DELETE /v1/users/{userID}/profile_image
 func (h *HTTP) DeleteProfileImage(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
@@ -168,6 +256,12 @@ func (h *HTTP) ListOAuthAccessTokens(_ http.ResponseWriter, r *http.Request) (in
 	return h.service.ListOAuthAccessTokens(r.Context(), userID, providerID)
 }
 
+// GET /v1/users/{userID}/legal_acceptances
+func (h *HTTP) ListLegalAcceptances(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	userID := chi.URLParam(r, "userID")
+	return h.service.ListLegalAcceptances(r.Context(), userID)
+}
+
 // POST /v1/users/{userID}/verify_password
 func (h *HTTP) VerifyPassword(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
@@ -188,6 +282,16 @@ func (h *HTTP) VerifyPassword(_ http.ResponseWriter, r *http.Request) (interface
 	}{true}, nil
 }
 
+// POST /v1/users/{userID}/password_reset_link
+func (h *HTTP) CreatePasswordResetLink(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := CreatePasswordResetLinkParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreatePasswordResetLink(r.Context(), chi.URLParam(r, "userID"), params)
+}
+
 // POST /v1/users/{userID}/verify_totp
 func (h *HTTP) VerifyTOTP(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
@@ -223,12 +327,44 @@ func (h *HTTP) DisableMFA(_ http.ResponseWriter, r *http.Request) (interface{},
 	}{userID}, nil
 }
 
+// DELETE /v1/users/{userID}/totp
+func (h *HTTP) DeleteTOTP(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.DeleteTOTP(r.Context(), chi.URLParam(r, "userID"))
+}
+
+// DELETE /v1/users/{userID}/backup_codes
+func (h *HTTP) DeleteBackupCodes(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.DeleteBackupCodes(r.Context(), chi.URLParam(r, "userID"))
+}
+
+// GET /v1/users/{userID}/mfa
+func (h *HTTP) ReadMFA(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadMFA(r.Context(), chi.URLParam(r, "userID"))
+}
+
 // POST /v1/users/{userID}/ban
 func (h *HTTP) Ban(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
 	return h.service.Ban(r.Context(), userID)
 }
 
+// POST /v1/users/{userID}/restore
+func (h *HTTP) Restore(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	userID := chi.URLParam(r, "userID")
+	return h.service.Restore(r.Context(), userID)
+}
+
+// POST /v1/users/{userID}/merge
+func (h *HTTP) Merge(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := MergeParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	userID := chi.URLParam(r, "userID")
+	return h.service.Merge(r.Context(), userID, params)
+}
+
 // POST /v1/users/{userID}/unban
 func (h *HTTP) Unban(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	userID := chi.URLParam(r, "userID")
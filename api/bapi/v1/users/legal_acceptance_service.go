@@ -0,0 +1,38 @@
+package users
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+)
+
+// ListLegalAcceptances returns the history of legal document acceptances of
+// the given user, most recent first.
+func (s *Service) ListLegalAcceptances(ctx context.Context, userID string) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	acceptances, err := s.legalService.History(ctx, s.db, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serializeLegalAcceptances(acceptances), nil
+}
+
+func serializeLegalAcceptances(acceptances []*model.LegalAcceptance) *serialize.PaginatedResponse {
+	data := make([]any, len(acceptances))
+	for i, acceptance := range acceptances {
+		data[i] = serialize.LegalAcceptance(acceptance)
+	}
+	return serialize.Paginated(data, int64(len(acceptances)))
+}
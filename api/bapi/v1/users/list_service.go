@@ -2,12 +2,14 @@ package users
 
 import (
 	"context"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/organizations"
 	"clerk/api/shared/pagination"
 	"clerk/api/shared/serializable"
 	"clerk/model/sqbmodel"
@@ -15,22 +17,23 @@ import (
 	"clerk/pkg/set"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
-
-	"github.com/jonboulle/clockwork"
 )
 
 type ListService struct {
 	db                  database.Database
+	orgsService         *organizations.Service
 	serializableService *serializable.Service
 	userRepo            *repository.Users
 }
 
-func NewListService(clock clockwork.Clock, db database.Database) *ListService {
+func NewListService(deps clerk.Deps) *ListService {
 	return &ListService{
-		db:                  db,
-		serializableService: serializable.NewService(clock),
-		userRepo:            repository.NewUsers(),
+		db:                  deps.ReadOnlyDB(),
+		orgsService:         organizations.NewService(deps),
+		serializableService: serializable.NewService(deps.Clock()),
+		userRepo:            deps.Repositories().Users,
 	}
 }
 
@@ -45,6 +48,7 @@ type readAllParams struct {
 	lastActiveAtSince []string
 	query             string
 	orderBy           string
+	metadataFilters   url.Values
 }
 
 var validUsersOrderByFields = set.New(
@@ -85,6 +89,12 @@ func (r readAllParams) convertToUserMods() (repository.UsersFindAllModifiers, ap
 	mods.Web3Wallets = r.web3Wallets
 	mods.Query = r.query
 
+	metadataFilters, apiErr := parseMetadataFilters(r.metadataFilters)
+	if apiErr != nil {
+		return mods, apiErr
+	}
+	mods.MetadataFilters = metadataFilters
+
 	if len(r.lastActiveAtSince) > 0 && r.lastActiveAtSince[0] != "" {
 		v, err := strconv.ParseInt(r.lastActiveAtSince[0], 10, 64)
 		if err != nil {
@@ -109,8 +119,21 @@ func (r *readAllParams) normalize() {
 	r.emailAddresses = emails
 }
 
-// ReadAll returns all users for the given instance.
-func (s *ListService) ReadAll(ctx context.Context, readParams readAllParams, pagination pagination.Params) ([]*serialize.UserResponse, apierror.Error) {
+// streamChunkSize is the number of users which are converted and written
+// to the response at a time, while streaming the list of users.
+const streamChunkSize = 100
+
+// StreamAll writes all users for the given instance to the stream.
+//
+// Users are serialized in chunks, so that the memory needed for large
+// pages doesn't grow with the size of the page. Any error that occurs
+// before the first chunk is written can still be returned to the client,
+// so every check is performed up-front.
+//
+// Only the sections of the user response which opts selects are loaded
+// and serialized. When users are searched for, every user carries the
+// identifier which matched the query.
+func (s *ListService) StreamAll(ctx context.Context, stream *serialize.ArrayStream, readParams readAllParams, pagination pagination.Params, opts serialize.UserOptions) apierror.Error {
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 
@@ -118,25 +141,38 @@ func (s *ListService) ReadAll(ctx context.Context, readParams readAllParams, pag
 
 	findAllParams, apierr := readParams.convertToUserMods()
 	if apierr != nil {
-		return nil, apierr
+		return apierr
 	}
 
 	users, err := s.userRepo.FindAllWithModifiers(ctx, s.db, env.Instance.ID, findAllParams, pagination)
 	if err != nil {
-		return nil, apierror.Unexpected(err)
+		return apierror.Unexpected(err)
 	}
+	opts.SearchQuery = readParams.query
 
-	userSerializables, err := s.serializableService.ConvertUsers(ctx, s.db, userSettings, users)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
+	for start := 0; start < len(users); start += streamChunkSize {
+		end := start + streamChunkSize
+		if end > len(users) {
+			end = len(users)
+		}
 
-	userResponses := make([]*serialize.UserResponse, len(users))
-	for i, userSerializable := range userSerializables {
-		userResponses[i] = serialize.UserToServerAPI(ctx, userSerializable)
+		userSerializables, err := s.serializableService.ConvertUsersWithOptions(ctx, s.db, userSettings, users[start:end], opts)
+		if err != nil {
+			return apierror.Unexpected(err)
+		}
+		if apiErr := loadOrganizationMemberships(ctx, s.db, s.orgsService, opts, userSerializables); apiErr != nil {
+			return apiErr
+		}
+
+		if err := serialize.UsersToServerAPIStream(ctx, stream, userSerializables, opts); err != nil {
+			return apierror.Unexpected(err)
+		}
 	}
 
-	return userResponses, nil
+	if err := stream.Close(); err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
 }
 
 // CountAll returns the total count of users in the given instance given
@@ -0,0 +1,179 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/client_data"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/ctx/environment"
+	cevents "clerk/pkg/events"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+type MergeParams struct {
+	SourceUserID string `json:"source_user_id" form:"source_user_id"`
+}
+
+// Merge moves everything the source user owns over to the given user and
+// deletes the source user afterwards. Identifications, along with their
+// external accounts, organization memberships and sessions change hands in
+// a single transaction.
+//
+// The primary identifiers of the target user are kept intact, the ones it
+// lacks are taken over from the source user.
+func (s *Service) Merge(ctx context.Context, userID string, params MergeParams) (*serialize.UserMergeResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	if params.SourceUserID == "" {
+		return nil, apierror.FormMissingParameter("source_user_id")
+	}
+	if params.SourceUserID == userID {
+		return nil, apierror.FormInvalidParameterValue("source_user_id", params.SourceUserID)
+	}
+
+	target, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if target == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	source, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, params.SourceUserID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if source == nil {
+		return nil, apierror.UserNotFound(params.SourceUserID)
+	}
+
+	// Sessions don't necessarily live in the database, so they can't be
+	// rolled back along with the rest. They're moved before the source user
+	// is deleted, as that would delete its sessions too, and moved back if
+	// the merge fails.
+	var movedSessions []*client_data.Session
+	var response *serialize.UserMergeResponse
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousTarget, err := s.shUsersService.SnapshotUser(ctx, tx, userSettings, target)
+		if err != nil {
+			return true, err
+		}
+
+		result, err := s.mergeService.Merge(ctx, tx, target, source)
+		if err != nil {
+			return true, err
+		}
+
+		if source.ProfileImagePublicURL.Valid {
+			if err := s.shUsersService.EnqueueCleanupImageJob(ctx, tx, source.ProfileImagePublicURL.String); err != nil {
+				return true, err
+			}
+		}
+
+		deleted := serialize.DeletedObject(source.ID, serialize.UserObjectName)
+		if err := s.eventService.UserDeleted(ctx, tx, env.Instance, deleted); err != nil {
+			return true, fmt.Errorf("users/merge: send event %s for user %s: %w", cevents.EventTypes.UserDeleted, source.ID, err)
+		}
+
+		userSerializable, err := s.shUsersService.SendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousTarget, target)
+		if err != nil {
+			return true, err
+		}
+
+		sessions, err := s.clientDataService.FindAllUserSessions(ctx, env.Instance.ID, source.ID, nil)
+		if err != nil {
+			return true, fmt.Errorf("users/merge: fetching sessions of user %s: %w", source.ID, err)
+		}
+		movedSessions, err = moveSessions(ctx, s.clientDataService, sessions, target.ID)
+		if err != nil {
+			return true, err
+		}
+
+		rowsDeleted, err := s.userRepo.DeleteByID(ctx, tx, source.ID)
+		if err != nil {
+			return true, fmt.Errorf("users/merge: deleting user %s: %w", source.ID, err)
+		}
+		if rowsDeleted == 0 {
+			// the user was deleted while it was being merged
+			return true, apierror.UserNotFound(source.ID)
+		}
+
+		sessionIDs := make([]string, len(movedSessions))
+		for i, session := range movedSessions {
+			sessionIDs[i] = session.ID
+		}
+		response = serialize.UserMerge(serialize.UserToServerAPI(ctx, userSerializable), serialize.UserMergeReport{
+			MergedUserID:              source.ID,
+			IdentificationIDs:         result.IdentificationIDs,
+			ExternalAccountIDs:        result.ExternalAccountIDs,
+			OrganizationMembershipIDs: result.OrganizationMembershipIDs,
+			SkippedOrganizationIDs:    result.SkippedOrganizationIDs,
+			SessionIDs:                sessionIDs,
+			PrimaryIdentifiers:        result.PrimaryIdentifiers,
+		})
+		return false, nil
+	})
+	if txErr != nil {
+		if err := moveSessionsBack(ctx, s.clientDataService, movedSessions, source.ID); err != nil {
+			log.Error(ctx, "users/merge: %s", err)
+		}
+		return nil, mergeError(txErr, target.ID)
+	}
+
+	return response, nil
+}
+
+// sessionUserUpdater changes the user a session belongs to. It's implemented
+// by client_data.Service.
+type sessionUserUpdater interface {
+	UpdateSessionUserID(ctx context.Context, session *client_data.Session) error
+}
+
+// moveSessions moves the sessions to the given user, and returns the ones
+// which were moved, even if moving the rest failed.
+func moveSessions(ctx context.Context, updater sessionUserUpdater, sessions []*client_data.Session, userID string) ([]*client_data.Session, error) {
+	moved := make([]*client_data.Session, 0, len(sessions))
+	for _, session := range sessions {
+		previousUserID := session.UserID
+		session.UserID = userID
+		if err := updater.UpdateSessionUserID(ctx, session); err != nil {
+			session.UserID = previousUserID
+			return moved, fmt.Errorf("users/merge: moving session %s to user %s: %w", session.ID, userID, err)
+		}
+		moved = append(moved, session)
+	}
+	return moved, nil
+}
+
+// moveSessionsBack returns the sessions which were moved by a merge which
+// failed to the user they belonged to. Every session is attempted, and the
+// ones which couldn't be moved back are reported.
+func moveSessionsBack(ctx context.Context, updater sessionUserUpdater, sessions []*client_data.Session, userID string) error {
+	var failed []string
+	for _, session := range sessions {
+		session.UserID = userID
+		if err := updater.UpdateSessionUserID(ctx, session); err != nil {
+			failed = append(failed, session.ID)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("users/merge: moving sessions %v back to user %s", failed, userID)
+	}
+	return nil
+}
+
+// mergeError maps the errors of a failed merge into the given user.
+func mergeError(err error, userID string) apierror.Error {
+	if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+		return apiErr
+	}
+	if clerkerrors.IsUniqueConstraintViolation(err, clerkerrors.UniqueOrganizationIDUserID) {
+		// the user joined an organization while it was being merged
+		return apierror.AlreadyAMemberOfOrganization(userID)
+	}
+	return apierror.Unexpected(err)
+}
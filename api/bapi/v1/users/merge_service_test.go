@@ -0,0 +1,87 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/client_data"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSessionUpdater records the user of every session it updates, and fails
+// for the sessions it's told to.
+type fakeSessionUpdater struct {
+	users  map[string]string
+	failed map[string]bool
+}
+
+func (u *fakeSessionUpdater) UpdateSessionUserID(_ context.Context, session *client_data.Session) error {
+	if u.failed[session.ID] {
+		return errors.New("edge unavailable")
+	}
+	u.users[session.ID] = session.UserID
+	return nil
+}
+
+func newSessions(userID string, ids ...string) []*client_data.Session {
+	sessions := make([]*client_data.Session, len(ids))
+	for i, id := range ids {
+		sessions[i] = &client_data.Session{ID: id, UserID: userID}
+	}
+	return sessions
+}
+
+func TestMoveSessions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	updater := &fakeSessionUpdater{users: map[string]string{}}
+	moved, err := moveSessions(ctx, updater, newSessions("user_source", "sess_1", "sess_2"), "user_target")
+	require.NoError(t, err)
+	assert.Len(t, moved, 2)
+	assert.Equal(t, map[string]string{"sess_1": "user_target", "sess_2": "user_target"}, updater.users)
+}
+
+func TestMoveSessionsCompensatesFailures(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	updater := &fakeSessionUpdater{users: map[string]string{}, failed: map[string]bool{"sess_2": true}}
+	sessions := newSessions("user_source", "sess_1", "sess_2", "sess_3")
+
+	moved, err := moveSessions(ctx, updater, sessions, "user_target")
+	require.Error(t, err)
+	require.Len(t, moved, 1)
+	assert.Equal(t, "sess_1", moved[0].ID)
+	assert.Equal(t, "user_source", sessions[1].UserID)
+	assert.NotContains(t, updater.users, "sess_3")
+
+	// the merge is rolled back, so the moved sessions go back
+	require.NoError(t, moveSessionsBack(ctx, updater, moved, "user_source"))
+	assert.Equal(t, map[string]string{"sess_1": "user_source"}, updater.users)
+}
+
+func TestMoveSessionsBackReportsFailures(t *testing.T) {
+	t.Parallel()
+
+	updater := &fakeSessionUpdater{users: map[string]string{}, failed: map[string]bool{"sess_1": true}}
+	err := moveSessionsBack(context.Background(), updater, newSessions("user_target", "sess_1", "sess_2"), "user_source")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sess_1")
+	assert.Equal(t, map[string]string{"sess_2": "user_source"}, updater.users)
+}
+
+func TestMergeError(t *testing.T) {
+	t.Parallel()
+
+	apiErr := mergeError(apierror.UserNotFound("user_source"), "user_target")
+	assert.Equal(t, http.StatusNotFound, apiErr.HTTPCode())
+
+	apiErr = mergeError(errors.New("connection reset"), "user_target")
+	assert.Equal(t, http.StatusInternalServerError, apiErr.HTTPCode())
+}
@@ -0,0 +1,125 @@
+package users
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+)
+
+// Metadata filters are query parameters like public_metadata.plan=pro, which
+// match users whose public metadata has "pro" under the "plan" key. Nested
+// keys are separated by dots and repeated parameters match any of their
+// values. metadata is short for public_metadata.
+var metadataFilterPrefixes = map[string]string{
+	"metadata.":        sqbmodel.UserColumns.PublicMetadata,
+	"public_metadata.": sqbmodel.UserColumns.PublicMetadata,
+	"unsafe_metadata.": sqbmodel.UserColumns.UnsafeMetadata,
+}
+
+// Guardrails on metadata filters, so that a single request can't make the
+// containment queries arbitrarily expensive.
+const (
+	maxMetadataFilters       = 5
+	maxMetadataFilterValues  = 10
+	maxMetadataKeyDepth      = 3
+	maxMetadataFilterKeySize = 64
+)
+
+var metadataKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseMetadataFilters returns the metadata filters of the query, sorted by
+// their parameter names.
+func parseMetadataFilters(query url.Values) ([]repository.MetadataFilter, apierror.Error) {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var filters []repository.MetadataFilter
+	for _, name := range names {
+		column, path, ok := splitMetadataFilterParam(name)
+		if !ok {
+			continue
+		}
+		if len(filters) == maxMetadataFilters {
+			return nil, apierror.FormInvalidParameterFormat(name, "At most "+strconv.Itoa(maxMetadataFilters)+" metadata keys can be filtered on at once.")
+		}
+		values := query[name]
+		if len(values) > maxMetadataFilterValues {
+			return nil, apierror.FormInvalidParameterFormat(name, "At most "+strconv.Itoa(maxMetadataFilterValues)+" values can be given for a metadata key.")
+		}
+		if apiErr := validateMetadataPath(name, path); apiErr != nil {
+			return nil, apiErr
+		}
+
+		filter := repository.MetadataFilter{Column: column}
+		for _, value := range values {
+			for _, scalar := range metadataScalars(value) {
+				document, err := containmentDocument(path, scalar)
+				if err != nil {
+					return nil, apierror.Unexpected(err)
+				}
+				filter.Documents = append(filter.Documents, document)
+			}
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+func splitMetadataFilterParam(name string) (string, []string, bool) {
+	for prefix, column := range metadataFilterPrefixes {
+		if key, ok := strings.CutPrefix(name, prefix); ok {
+			return column, strings.Split(key, "."), true
+		}
+	}
+	return "", nil, false
+}
+
+func validateMetadataPath(name string, path []string) apierror.Error {
+	if len(path) > maxMetadataKeyDepth {
+		return apierror.FormInvalidParameterFormat(name, "Metadata keys can be nested at most "+strconv.Itoa(maxMetadataKeyDepth)+" levels deep.")
+	}
+	for _, key := range path {
+		if len(key) > maxMetadataFilterKeySize || !metadataKeyRegexp.MatchString(key) {
+			return apierror.FormInvalidParameterFormat(name, "Metadata keys can only contain letters, numbers, underscores and dashes.")
+		}
+	}
+	return nil
+}
+
+// metadataScalars returns the JSON values the query value can match. Query
+// values are always strings, but numbers and booleans in the metadata should
+// match too, so both are tried for values which look like them.
+func metadataScalars(value string) []any {
+	scalars := []any{value}
+	var number float64
+	if value == "true" || value == "false" {
+		scalars = append(scalars, value == "true")
+	} else if err := json.Unmarshal([]byte(value), &number); err == nil {
+		scalars = append(scalars, number)
+	}
+	return scalars
+}
+
+// containmentDocument nests the value under the path, e.g. {"a":{"b":value}}
+// for a.b.
+func containmentDocument(path []string, value any) (string, error) {
+	document := value
+	for i := len(path) - 1; i >= 0; i-- {
+		document = map[string]any{path[i]: document}
+	}
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
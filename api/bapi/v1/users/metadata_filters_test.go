@@ -0,0 +1,76 @@
+package users
+
+import (
+	"net/url"
+	"testing"
+
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetadataFilters(t *testing.T) {
+	t.Parallel()
+
+	query, err := url.ParseQuery("metadata.plan=pro&metadata.plan=team&unsafe_metadata.billing.seats=5&unsafe_metadata.beta=true&email_address=a@example.com")
+	require.NoError(t, err)
+
+	filters, apiErr := parseMetadataFilters(query)
+	require.Nil(t, apiErr)
+	assert.Equal(t, []repository.MetadataFilter{
+		{
+			Column:    sqbmodel.UserColumns.PublicMetadata,
+			Documents: []string{`{"plan":"pro"}`, `{"plan":"team"}`},
+		},
+		{
+			Column:    sqbmodel.UserColumns.UnsafeMetadata,
+			Documents: []string{`{"beta":"true"}`, `{"beta":true}`},
+		},
+		{
+			Column:    sqbmodel.UserColumns.UnsafeMetadata,
+			Documents: []string{`{"billing":{"seats":"5"}}`, `{"billing":{"seats":5}}`},
+		},
+	}, filters)
+}
+
+func TestParseMetadataFiltersGuardrails(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "too many keys",
+			query: "metadata.a=1&metadata.b=1&metadata.c=1&metadata.d=1&metadata.e=1&metadata.f=1",
+		},
+		{
+			name:  "too many values",
+			query: "metadata.a=1&metadata.a=2&metadata.a=3&metadata.a=4&metadata.a=5&metadata.a=6&metadata.a=7&metadata.a=8&metadata.a=9&metadata.a=10&metadata.a=11",
+		},
+		{
+			name:  "too deep",
+			query: "metadata.a.b.c.d=1",
+		},
+		{
+			name:  "invalid key",
+			query: "metadata.a%22b=1",
+		},
+		{
+			name:  "empty key",
+			query: "metadata.=1",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			query, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+
+			_, apiErr := parseMetadataFilters(query)
+			assert.NotNil(t, apiErr)
+		})
+	}
+}
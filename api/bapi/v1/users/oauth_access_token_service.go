@@ -4,17 +4,15 @@ import (
 	"context"
 	"errors"
 	"strings"
-	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
-	"clerk/api/shared/sso"
+	"clerk/api/shared/externalaccount"
 	"clerk/model"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/oauth"
 	log "clerk/utils/log"
 
-	"github.com/volatiletech/null/v8"
 	"golang.org/x/oauth2"
 )
 
@@ -40,7 +38,8 @@ func (s *Service) ListOAuthAccessTokensPaginated(ctx context.Context, userID, pr
 // tokens).
 //
 // If the access token we have in the database has expired, a new one will be
-// issued and returned.
+// issued and returned. Tokens are also refreshed in the background before they
+// expire, so this rarely has to wait for the provider.
 func (s *Service) ListOAuthAccessTokens(ctx context.Context, userID, providerID string) ([]*serialize.OAuthAccessTokenResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 	if !strings.HasPrefix(providerID, "oauth_") {
@@ -64,7 +63,7 @@ func (s *Service) ListOAuthAccessTokens(ctx context.Context, userID, providerID
 		}
 	} else {
 		for idx, account := range accounts {
-			token, scopes, apiErr := s.getToken(ctx, account, provider.ID())
+			token, scopes, apiErr := s.getToken(ctx, account)
 			if apiErr != nil {
 				return nil, apiErr
 			}
@@ -76,53 +75,16 @@ func (s *Service) ListOAuthAccessTokens(ctx context.Context, userID, providerID
 	return response, nil
 }
 
-// package oauth2 transparently refreshes the token if it's expired. However,
-// there's not a convenient way to fetch the new token.
-// See https://github.com/golang/oauth2/issues/84.
-func (s *Service) getToken(ctx context.Context,
-	account *model.ExternalAccount, provider string,
-) (string, []string, apierror.Error) {
+func (s *Service) getToken(ctx context.Context, account *model.ExternalAccount) (string, []string, apierror.Error) {
 	env := environment.FromContext(ctx)
-	oauthConfig, err := sso.ActiveOauthConfigForProvider(ctx, s.db, env.AuthConfig.ID, provider)
-	if err != nil {
-		return "", nil, apierror.OAuthTokenProviderNotEnabled()
-	}
-
-	currentToken := oauth2.Token{
-		AccessToken:  account.AccessToken,
-		RefreshToken: account.RefreshToken.String,
-
-		// if this is a zero value, the same token will be returned
-		Expiry: account.AccessTokenExpiration.Time,
-
-		// TODO(oauth): this should be persisted to and retrieved from
-		// the database instead. Otherwise it's not bullet-proof since
-		// some tokens may be of other types (e.g. MAC)
-		TokenType: "bearer",
-	}
 
-	if !currentToken.Expiry.IsZero() {
-		// provide some leeway to reduce the chance of the user receiving an
-		// expired token due to clock skews. Note that package oauth2 already
-		// adds a 10s leeway.
-		currentToken.Expiry = currentToken.Expiry.Add(-15 * time.Minute)
-	}
-
-	config := oauthConfig.ToConfig("", account.ApprovedScopes).OAuth2
-	src := config.TokenSource(ctx, &currentToken)
-
-	// this actually goes and refreshes the token, if necessary
-	newToken, err := src.Token()
+	token, scopes, err := s.externalAccountService.FreshTokenForRequest(ctx, env.AuthConfig.ID, account)
 	if err != nil {
-		if currentToken.RefreshToken == "" {
-			account.LastFailedTokenRetrievalAt = null.TimeFrom(s.clock.Now().UTC())
-			if err = s.externalAccountRepo.UpdateLastFailedTokenRetrievalAt(ctx, s.db, account); err != nil {
-				return "", nil, apierror.Unexpected(err)
-			}
+		if errors.Is(err, externalaccount.ErrOAuthProviderNotEnabled) {
+			return "", nil, apierror.OAuthTokenProviderNotEnabled()
+		} else if errors.Is(err, externalaccount.ErrMissingRefreshToken) {
 			return "", nil, apierror.OAuthMissingRefreshToken()
-		}
-
-		if strings.Contains(err.Error(), "oauth2: server response missing access_token") {
+		} else if errors.Is(err, externalaccount.ErrMissingAccessToken) {
 			return "", nil, apierror.OAuthMissingAccessToken()
 		}
 
@@ -134,25 +96,5 @@ func (s *Service) getToken(ctx context.Context,
 
 		return "", nil, apierror.Unexpected(err)
 	}
-
-	if newToken.AccessToken != currentToken.AccessToken {
-		account.AccessToken = newToken.AccessToken
-
-		if newToken.Expiry.IsZero() {
-			account.AccessTokenExpiration = null.Time{}
-		} else {
-			account.AccessTokenExpiration = null.TimeFrom(newToken.Expiry.UTC())
-		}
-
-		if newToken.RefreshToken != "" {
-			account.RefreshToken = null.StringFrom(newToken.RefreshToken)
-		}
-
-		err := s.externalAccountRepo.Update(ctx, s.db, account)
-		if err != nil {
-			return "", nil, apierror.Unexpected(err)
-		}
-	}
-
-	return newToken.AccessToken, config.Scopes, nil
+	return token, scopes, nil
 }
@@ -0,0 +1,146 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ticket"
+	clerktime "clerk/pkg/time"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/pkg/usersettings/clerk/names"
+	"clerk/utils/database"
+	"clerk/utils/param"
+)
+
+// defaultPasswordResetLinkLifetime is for how long a password reset link
+// is valid, unless expires_in_seconds says otherwise.
+const defaultPasswordResetLinkLifetime = time.Hour
+
+type CreatePasswordResetLinkParams struct {
+	ExpiresInSeconds *int `json:"expires_in_seconds" form:"expires_in_seconds"`
+	// Notify emails the link to the primary email address of the user.
+	Notify bool `json:"notify" form:"notify"`
+}
+
+func (p CreatePasswordResetLinkParams) validate() apierror.Error {
+	if p.ExpiresInSeconds != nil && *p.ExpiresInSeconds < 1 {
+		return apierror.FormInvalidParameterValue("expires_in_seconds", strconv.Itoa(*p.ExpiresInSeconds))
+	}
+	return nil
+}
+
+func (p CreatePasswordResetLinkParams) lifetime() time.Duration {
+	if p.ExpiresInSeconds == nil {
+		return defaultPasswordResetLinkLifetime
+	}
+	return time.Duration(*p.ExpiresInSeconds) * time.Second
+}
+
+// CreatePasswordResetLink creates a single-use sign in ticket for the user,
+// which signs them in only after they've set a new password. The link is
+// returned, and optionally emailed to the user, so that backend integrations
+// don't have to go through the forgot password flow of FAPI on the user's
+// behalf.
+func (s *Service) CreatePasswordResetLink(ctx context.Context, userID string, params CreatePasswordResetLinkParams) (*serialize.PasswordResetLinkResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+	if !userSettings.GetAttribute(names.Password).Base().Enabled {
+		return nil, apierror.FeatureNotEnabled()
+	}
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	var primaryEmailAddress *string
+	if params.Notify {
+		primaryEmailAddress, err = s.userProfileService.GetPrimaryEmailAddress(ctx, s.db, user)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		if primaryEmailAddress == nil {
+			return nil, apierror.NoPrimaryEmailAddress()
+		}
+	}
+
+	signInToken := &model.SignInToken{SignInToken: &sqbmodel.SignInToken{
+		InstanceID: env.Instance.ID,
+		UserID:     user.ID,
+		Status:     constants.StatusPending,
+	}}
+	lifetime := params.lifetime()
+	expiresInSeconds := int(lifetime.Seconds())
+	expireAt := s.clock.Now().UTC().Add(lifetime)
+
+	var ticketToken string
+	var resetURL *url.URL
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if err := s.signInTokenRepo.Insert(ctx, tx, signInToken); err != nil {
+			return true, err
+		}
+
+		ticketToken, err = ticket.Generate(
+			ticket.Claims{
+				InstanceID:       env.Instance.ID,
+				SourceType:       constants.OSTPasswordResetToken,
+				SourceID:         signInToken.ID,
+				ExpiresInSeconds: &expiresInSeconds,
+			},
+			env.Instance,
+			s.clock,
+		)
+		if err != nil {
+			return true, err
+		}
+
+		resetURL, err = url.Parse(env.DisplayConfig.Paths.SignInURL(
+			env.Instance.Origin(env.Domain, nil),
+			env.Domain.AccountsURL(),
+		))
+		if err != nil {
+			return true, err
+		}
+		q := resetURL.Query()
+		q.Add(param.ClerkTicket, ticketToken)
+		resetURL.RawQuery = q.Encode()
+
+		if primaryEmailAddress == nil {
+			return false, nil
+		}
+		err = s.commsService.SendPasswordResetLinkEmail(ctx, tx, env, comms.EmailPasswordResetLink{
+			GreetingName: strings.TrimSpace(fmt.Sprintf("%s %s",
+				user.FirstName.String, user.LastName.String)),
+			PrimaryEmailAddress: *primaryEmailAddress,
+			ResetURL:            resetURL.String(),
+			ExpireAt:            expireAt,
+		})
+		if err != nil {
+			return true, fmt.Errorf("user/createPasswordResetLink: sending email to user %s: %w", user.ID, err)
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.PasswordResetLink(signInToken, resetURL, ticketToken, clerktime.UnixMilli(expireAt), params.Notify), nil
+}
@@ -0,0 +1,60 @@
+package users
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePasswordResetLinkParams(t *testing.T) {
+	t.Parallel()
+
+	params := CreatePasswordResetLinkParams{}
+	assert.Nil(t, params.validate())
+	assert.Equal(t, defaultPasswordResetLinkLifetime, params.lifetime())
+
+	expiresInSeconds := 600
+	params.ExpiresInSeconds = &expiresInSeconds
+	assert.Nil(t, params.validate())
+	assert.Equal(t, 10*time.Minute, params.lifetime())
+
+	for _, invalid := range []int{0, -1} {
+		invalid := invalid
+		params.ExpiresInSeconds = &invalid
+		apiErr := params.validate()
+		require.NotNil(t, apiErr)
+		assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+	}
+}
+
+func TestSerializePasswordResetLink(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signInToken := &model.SignInToken{SignInToken: &sqbmodel.SignInToken{
+		ID:        "sit_1",
+		UserID:    "user_1",
+		CreatedAt: now,
+	}}
+	resetURL, err := url.Parse("https://accounts.example.com/sign-in?__clerk_ticket=ticket_1")
+	require.NoError(t, err)
+	expireAt := now.Add(defaultPasswordResetLinkLifetime).UnixMilli()
+
+	response := serialize.PasswordResetLink(signInToken, resetURL, "ticket_1", expireAt, true)
+	assert.Equal(t, serialize.PasswordResetLinkObjectName, response.Object)
+	assert.Equal(t, "sit_1", response.ID)
+	assert.Equal(t, "user_1", response.UserID)
+	assert.Equal(t, "ticket_1", response.Token)
+	assert.Equal(t, resetURL.String(), response.URL)
+	assert.True(t, response.EmailSent)
+	assert.Equal(t, expireAt, response.ExpireAt)
+	assert.Equal(t, now.UnixMilli(), response.CreatedAt)
+}
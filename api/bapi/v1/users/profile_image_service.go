@@ -44,6 +44,79 @@ func (s *Service) UpdateProfileImage(ctx context.Context, userID string, filePar
 	return serialize.UserToServerAPI(ctx, userSerializable), nil
 }
 
+type CreateProfileImageUploadParams struct {
+	ContentType string `json:"content_type" form:"content_type"`
+	Filename    string `json:"filename" form:"filename"`
+}
+
+func (p CreateProfileImageUploadParams) validate() apierror.Error {
+	if p.ContentType == "" {
+		return apierror.FormMissingParameter("content_type")
+	}
+	return nil
+}
+
+// CreateProfileImageUpload returns a signed URL the new profile image of the
+// user can be uploaded to, directly to storage.
+func (s *Service) CreateProfileImageUpload(ctx context.Context, userID string, params CreateProfileImageUploadParams) (*serialize.ImageUploadResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	return s.shUsersService.CreateProfileImageUpload(ctx, user.ID, params.Filename, params.ContentType)
+}
+
+// CompleteProfileImageUpload sets the image uploaded through a signed upload
+// URL as the user's profile image.
+func (s *Service) CompleteProfileImageUpload(ctx context.Context, userID, imageID string) (*serialize.UserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	_, apiErr := s.shUsersService.UpdateProfileImage(
+		ctx,
+		users.UpdateProfileImageParams{
+			UserID:          user.ID,
+			UploadedImageID: imageID,
+		},
+		env.Instance,
+		userSettings,
+	)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// respond with updated user
+	user, err = s.userRepo.FindByID(ctx, s.db, userID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	userSerializable, err := s.serializableService.ConvertUser(ctx, s.db, userSettings, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.UserToServerAPI(ctx, userSerializable), nil
+}
+
 // DeleteProfileImage deletes the user's profile image
 func (s *Service) DeleteProfileImage(ctx context.Context, userID string) (*serialize.UserResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
@@ -0,0 +1,47 @@
+package users
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+	usersettings "clerk/pkg/usersettings/clerk"
+)
+
+// ReadMFA returns the second factors the user has set up, along with the
+// usage of their backup codes, e.g. for compliance reporting.
+func (s *Service) ReadMFA(ctx context.Context, userID string) (*serialize.UserMFAResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	mfa := serialize.UserMFA{UserID: user.ID}
+
+	mfa.PhoneCodeEnabled, err = s.userProfileService.HasTwoFactorPhoneCodeEnabled(ctx, s.db, userSettings, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	mfa.TOTPEnabled, err = s.userProfileService.HasTwoFactorTOTPEnabled(ctx, s.db, userSettings, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	if userSettings.SecondFactors().Contains(constants.VSBackupCode) {
+		mfa.BackupCode, err = s.backupCodeRepo.QueryByUser(ctx, s.db, user.ID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
+	return serialize.UserMFAPosture(mfa), nil
+}
@@ -5,13 +5,37 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/model"
+	apiversioningcontext "clerk/pkg/apiversioning/context"
 	"clerk/pkg/ctx/environment"
 	usersettings "clerk/pkg/usersettings/clerk"
 )
 
-// Read returns the user that is already loaded in the context.
+// Read returns the user that is already loaded in the context, in the shape
+// of the requested API version and with the sections of the response which
+// opts selects.
 // Keep in mind that the SetUser must be called before using this.
-func (s *Service) Read(ctx context.Context, userID string) (*serialize.UserResponse, apierror.Error) {
+func (s *Service) Read(ctx context.Context, userID string, opts serialize.UserOptions) (any, apierror.Error) {
+	userSerializable, apiErr := s.readSerializable(ctx, userID, opts)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	v, _ := apiversioningcontext.FromContext(ctx)
+	return serialize.UserToServerAPIForVersion(ctx, v, userSerializable, opts), nil
+}
+
+// ReadServerAPI returns the user in the latest shape of the server API,
+// regardless of the requested API version.
+func (s *Service) ReadServerAPI(ctx context.Context, userID string) (*serialize.UserResponse, apierror.Error) {
+	userSerializable, apiErr := s.readSerializable(ctx, userID, serialize.DefaultUserOptions)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.UserToServerAPI(ctx, userSerializable), nil
+}
+
+func (s *Service) readSerializable(ctx context.Context, userID string, opts serialize.UserOptions) (*model.UserSerializable, apierror.Error) {
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 
@@ -22,10 +46,12 @@ func (s *Service) Read(ctx context.Context, userID string) (*serialize.UserRespo
 		return nil, apierror.UserNotFound(userID)
 	}
 
-	userSerializable, err := s.serializableService.ConvertUser(ctx, s.db, userSettings, user)
+	userSerializables, err := s.serializableService.ConvertUsersWithOptions(ctx, s.db, userSettings, []*model.User{user}, opts)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
-
-	return serialize.UserToServerAPI(ctx, userSerializable), nil
+	if apiErr := loadOrganizationMemberships(ctx, s.db, s.orgsService, opts, userSerializables); apiErr != nil {
+		return nil, apiErr
+	}
+	return userSerializables[0], nil
 }
@@ -0,0 +1,187 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	"clerk/api/shared/events"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+)
+
+// Second factors which can be reset, as they appear in the notification
+// email.
+const (
+	mfaResetMethodTOTP       = "totp"
+	mfaResetMethodBackupCode = "backup_code"
+)
+
+// DeleteTOTP removes the authenticator app of the user, so that users who
+// lost access to it can sign in again. Backup codes are removed as well if
+// the user is left without any second factor, like in FAPI.
+func (s *Service) DeleteTOTP(ctx context.Context, userID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	existingTOTP, err := s.totpRepo.QueryByUser(ctx, s.db, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if existingTOTP == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
+		err = deleteTOTP(ctx, tx, s.totpRepo, s.backupCodeRepo, s.userProfileService, userSettings, user.ID)
+		if err != nil {
+			return true, err
+		}
+
+		return s.notifyMFAReset(ctx, tx, env, userSettings, previousUser, user, mfaResetMethodTOTP)
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.DeletedObject(existingTOTP.ID, serialize.TOTPObjectName), nil
+}
+
+// userRecordsDeleter deletes the records of a user. It's implemented by the
+// repositories of second factors.
+type userRecordsDeleter interface {
+	DeleteByUser(ctx context.Context, exec database.Executor, userID string) error
+}
+
+// twoFactorChecker reports whether a user has any second factor enabled.
+// It's implemented by user_profile.Service.
+type twoFactorChecker interface {
+	HasTwoFactorEnabled(ctx context.Context, exec database.Executor, userSettings *usersettings.UserSettings, userID string) (bool, error)
+}
+
+// deleteTOTP deletes the TOTP of the user, along with their backup codes if
+// they're left without any second factor, since backup codes are only of
+// use next to another second factor.
+func deleteTOTP(
+	ctx context.Context,
+	tx database.Tx,
+	totps, backupCodes userRecordsDeleter,
+	twoFactor twoFactorChecker,
+	userSettings *usersettings.UserSettings,
+	userID string,
+) error {
+	if err := totps.DeleteByUser(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	hasTwoFactorEnabled, err := twoFactor.HasTwoFactorEnabled(ctx, tx, userSettings, userID)
+	if err != nil {
+		return err
+	}
+	if !hasTwoFactorEnabled {
+		return backupCodes.DeleteByUser(ctx, tx, userID)
+	}
+	return nil
+}
+
+// DeleteBackupCodes removes the backup codes of the user, e.g. when they
+// might have leaked. Users can generate new ones from their profile.
+func (s *Service) DeleteBackupCodes(ctx context.Context, userID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	existingBackupCode, err := s.backupCodeRepo.QueryByUser(ctx, s.db, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if existingBackupCode == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
+		if err := s.backupCodeRepo.DeleteByUser(ctx, tx, user.ID); err != nil {
+			return true, err
+		}
+
+		return s.notifyMFAReset(ctx, tx, env, userSettings, previousUser, user, mfaResetMethodBackupCode)
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.DeletedObject(existingBackupCode.ID, serialize.BackupCodeObjectName), nil
+}
+
+// notifyMFAReset revokes the trusted devices of the user, which would
+// otherwise keep skipping the second factor that was reset, touches the user,
+// since their calculated two-factor properties changed, sends them the
+// notification email and triggers a user.updated event.
+func (s *Service) notifyMFAReset(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	userSettings *usersettings.UserSettings,
+	previousUser events.UserSnapshot,
+	user *model.User,
+	method string,
+) (bool, error) {
+	if err := s.trustedDeviceService.RevokeAllForUser(ctx, tx, env.Instance.ID, user.ID); err != nil {
+		return true, err
+	}
+
+	if err := s.userRepo.UpdateUpdatedAtByID(ctx, tx, user.ID); err != nil {
+		return true, err
+	}
+
+	primaryEmailAddress, err := s.userProfileService.GetPrimaryEmailAddress(ctx, tx, user)
+	if err != nil {
+		return true, err
+	}
+	if primaryEmailAddress != nil {
+		err = s.commsService.SendMFAResetEmail(ctx, tx, env, comms.EmailMFAReset{
+			GreetingName: strings.TrimSpace(fmt.Sprintf("%s %s",
+				user.FirstName.String, user.LastName.String)),
+			PrimaryEmailAddress: *primaryEmailAddress,
+			Method:              method,
+		})
+		if err != nil {
+			return true, fmt.Errorf("user/notifyMFAReset: sending email to user %s: %w", user.ID, err)
+		}
+	}
+
+	if err := s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
+		return true, fmt.Errorf("user/notifyMFAReset: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
+	}
+	return false, nil
+}
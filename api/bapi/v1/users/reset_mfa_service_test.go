@@ -0,0 +1,54 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUserRecordsDeleter struct {
+	deletedUserIDs []string
+}
+
+func (d *fakeUserRecordsDeleter) DeleteByUser(_ context.Context, _ database.Executor, userID string) error {
+	d.deletedUserIDs = append(d.deletedUserIDs, userID)
+	return nil
+}
+
+type fakeTwoFactorChecker struct {
+	enabled bool
+	err     error
+}
+
+func (c fakeTwoFactorChecker) HasTwoFactorEnabled(context.Context, database.Executor, *usersettings.UserSettings, string) (bool, error) {
+	return c.enabled, c.err
+}
+
+func TestDeleteTOTP(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// users who still have e.g. SMS codes keep their backup codes
+	totps, backupCodes := &fakeUserRecordsDeleter{}, &fakeUserRecordsDeleter{}
+	require.NoError(t, deleteTOTP(ctx, nil, totps, backupCodes, fakeTwoFactorChecker{enabled: true}, nil, "user_1"))
+	assert.Equal(t, []string{"user_1"}, totps.deletedUserIDs)
+	assert.Empty(t, backupCodes.deletedUserIDs)
+
+	// users left without a second factor lose their backup codes too
+	totps, backupCodes = &fakeUserRecordsDeleter{}, &fakeUserRecordsDeleter{}
+	require.NoError(t, deleteTOTP(ctx, nil, totps, backupCodes, fakeTwoFactorChecker{}, nil, "user_1"))
+	assert.Equal(t, []string{"user_1"}, totps.deletedUserIDs)
+	assert.Equal(t, []string{"user_1"}, backupCodes.deletedUserIDs)
+
+	// backup codes are kept when there's no telling whether they're needed
+	totps, backupCodes = &fakeUserRecordsDeleter{}, &fakeUserRecordsDeleter{}
+	err := deleteTOTP(ctx, nil, totps, backupCodes, fakeTwoFactorChecker{err: errors.New("db unavailable")}, nil, "user_1")
+	require.Error(t, err)
+	assert.Empty(t, backupCodes.deletedUserIDs)
+}
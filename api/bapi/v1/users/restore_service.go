@@ -0,0 +1,21 @@
+package users
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/pkg/ctx/environment"
+)
+
+// Restore restores a deleted user, as long as it hasn't been purged yet.
+func (s *Service) Restore(ctx context.Context, userID string) (*serialize.UserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	userSerializable, apiErr := s.shUsersService.Restore(ctx, env, userID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return serialize.UserToServerAPI(ctx, userSerializable), nil
+}
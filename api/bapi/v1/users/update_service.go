@@ -107,6 +107,11 @@ func (s *Service) UpdateMetadata(
 		return nil, apierror.UserNotFound(userID)
 	}
 
+	previousUser, err := s.serializableService.SnapshotUser(ctx, s.db, userSettings, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
 	merged, mergeErr := metadata.Merge(user.Metadata(), metadata.Metadata{
 		Public:  params.PublicMetadata,
 		Private: params.PrivateMetadata,
@@ -123,7 +128,7 @@ func (s *Service) UpdateMetadata(
 			return true, apierror.Unexpected(err)
 		}
 
-		err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user)
+		err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user)
 		if err != nil {
 			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
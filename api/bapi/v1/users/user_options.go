@@ -0,0 +1,101 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/organizations"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/utils/database"
+)
+
+const (
+	paramFields  = "fields"
+	paramInclude = "include"
+)
+
+// maxOrganizationMembershipsPerUser is the number of organization
+// memberships which are included in a user response, when requested.
+const maxOrganizationMembershipsPerUser = 100
+
+// userOptionsFromRequest returns the sections of the user response which
+// the request asks for. The fields parameter limits the response to the
+// given sections, while include adds sections to the default ones. Both
+// accept comma separated or repeated values.
+func userOptionsFromRequest(r *http.Request) (serialize.UserOptions, apierror.Error) {
+	query := r.URL.Query()
+
+	opts := serialize.DefaultUserOptions
+	if fields := splitQueryValues(query[paramFields]); len(fields) > 0 {
+		opts = serialize.UserOptions{}
+		for _, field := range fields {
+			section, apiErr := parseUserSection(paramFields, field)
+			if apiErr != nil {
+				return opts, apiErr
+			}
+			opts = opts.With(section)
+		}
+	}
+
+	for _, include := range splitQueryValues(query[paramInclude]) {
+		section, apiErr := parseUserSection(paramInclude, include)
+		if apiErr != nil {
+			return opts, apiErr
+		}
+		opts = opts.With(section)
+	}
+	return opts, nil
+}
+
+func parseUserSection(paramName, value string) (serialize.UserSection, apierror.Error) {
+	allowed := make([]string, len(serialize.UserSections))
+	for i, section := range serialize.UserSections {
+		if string(section) == value {
+			return section, nil
+		}
+		allowed[i] = string(section)
+	}
+	return "", apierror.FormInvalidParameterValueWithAllowed(paramName, value, allowed)
+}
+
+func splitQueryValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// loadOrganizationMemberships loads the organization memberships of the
+// users, if they're part of the response.
+func loadOrganizationMemberships(
+	ctx context.Context,
+	exec database.Executor,
+	orgsService *organizations.Service,
+	opts serialize.UserOptions,
+	users []*model.UserSerializable,
+) apierror.Error {
+	if !opts.OrganizationMemberships {
+		return nil
+	}
+
+	for _, user := range users {
+		memberships, apiErr := orgsService.ListMemberships(ctx, exec, organizations.ListMembershipsParams{
+			UserID:     &user.ID,
+			SkipCounts: true,
+		}, pagination.Params{Limit: maxOrganizationMembershipsPerUser})
+		if apiErr != nil {
+			return apiErr
+		}
+		user.OrganizationMemberships = memberships
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package users
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"clerk/api/serialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserOptionsFromRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  serialize.UserOptions
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  serialize.DefaultUserOptions,
+		},
+		{
+			name:  "fields limits the sections",
+			query: "fields=web3_wallets",
+			want:  serialize.UserOptions{Web3Wallets: true},
+		},
+		{
+			name:  "fields accepts comma separated and repeated values",
+			query: "fields=web3_wallets,%20saml_accounts&fields=external_accounts",
+			want:  serialize.UserOptions{Web3Wallets: true, SAMLAccounts: true, ExternalAccounts: true},
+		},
+		{
+			name:  "include adds to the defaults",
+			query: "include=organization_memberships",
+			want:  serialize.DefaultUserOptions.With(serialize.UserSectionOrganizationMemberships),
+		},
+		{
+			name:  "include adds to fields",
+			query: "fields=web3_wallets&include=organization_memberships",
+			want:  serialize.UserOptions{Web3Wallets: true, OrganizationMemberships: true},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("GET", "/v1/users?"+tc.query, nil)
+			opts, apiErr := userOptionsFromRequest(r)
+			require.Nil(t, apiErr)
+			assert.Equal(t, tc.want, opts)
+		})
+	}
+}
+
+func TestUserOptionsFromRequest_UnknownSection(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/v1/users?include=passwords", nil)
+	_, apiErr := userOptionsFromRequest(r)
+	require.NotNil(t, apiErr)
+}
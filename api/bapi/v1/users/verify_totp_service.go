@@ -10,6 +10,7 @@ import (
 	"clerk/pkg/totp"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/strategies"
 )
 
 type VerifyTOTPParams struct {
@@ -33,14 +34,11 @@ func (s *Service) VerifyTOTP(ctx context.Context, userID, code string) (string,
 		return "", apierror.Unexpected(err)
 	}
 	if currentBackupCodes != nil {
-		updatedCodes, err := backup_codes.Consume(currentBackupCodes.Codes, code)
+		err := strategies.ConsumeBackupCode(ctx, s.db, s.clock, s.backupCodeRepo, currentBackupCodes, code)
 		if err == nil {
-			currentBackupCodes.Codes = updatedCodes
-			err = s.backupCodeRepo.UpdateCodes(ctx, s.db, currentBackupCodes)
-			if err != nil {
-				return "", apierror.Unexpected(err)
-			}
 			return "backup_code", nil
+		} else if !errors.Is(err, backup_codes.ErrInvalidCode) {
+			return "", apierror.Unexpected(err)
 		}
 	}
 
@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/webhooks"
+	"clerk/pkg/ctx/environment"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type EndpointParams struct {
+	URL         string   `json:"url" form:"url" validate:"required,url"`
+	Description string   `json:"description" form:"description" validate:"max=256"`
+	EventTypes  []string `json:"event_types" form:"event_types"`
+	Disabled    bool     `json:"disabled" form:"disabled"`
+}
+
+func (params EndpointParams) validate(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(params); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	return webhooks.ValidateEventTypes("event_types", params.EventTypes)
+}
+
+func (params EndpointParams) validateForOrganization(validator *validator.Validate) apierror.Error {
+	if err := validator.Struct(params); err != nil {
+		return apierror.FormValidationFailed(err)
+	}
+	return webhooks.ValidateOrganizationEventTypes("event_types", params.EventTypes)
+}
+
+func (params EndpointParams) toSharedParams() webhooks.EndpointParams {
+	return webhooks.EndpointParams{
+		URL:         params.URL,
+		Description: params.Description,
+		EventTypes:  params.EventTypes,
+		Disabled:    params.Disabled,
+	}
+}
+
+// ReadEventTypes returns the catalog of event types webhook endpoints can
+// subscribe to.
+func (s *Service) ReadEventTypes() *serialize.WebhookEventTypesResponse {
+	return serialize.WebhookEventTypes(webhooks.EventTypeNames())
+}
+
+func (s *Service) ReadAllEndpoints(ctx context.Context) ([]*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ListEndpoints(ctx, env.Instance)
+}
+
+func (s *Service) ReadEndpoint(ctx context.Context, endpointID string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReadEndpoint(ctx, env.Instance, endpointID)
+}
+
+func (s *Service) CreateEndpoint(ctx context.Context, params EndpointParams) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return s.webhookService.CreateEndpoint(ctx, env.Instance, params.toSharedParams(), "url")
+}
+
+func (s *Service) UpdateEndpoint(ctx context.Context, endpointID string, params EndpointParams) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return s.webhookService.UpdateEndpoint(ctx, env.Instance, endpointID, params.toSharedParams(), "url")
+}
+
+func (s *Service) DeleteEndpoint(ctx context.Context, endpointID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.DeleteEndpoint(ctx, env.Instance, endpointID)
+}
+
+func (s *Service) ReadEndpointSecret(ctx context.Context, endpointID string) (*serialize.WebhookEndpointSecretResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReadEndpointSecret(ctx, env.Instance, endpointID)
+}
+
+// ReadOrganizationEventTypes returns the catalog of event types the webhook
+// endpoints of organizations can subscribe to.
+func (s *Service) ReadOrganizationEventTypes() *serialize.WebhookEventTypesResponse {
+	return serialize.WebhookEventTypes(webhooks.OrganizationEventTypeNames())
+}
+
+func (s *Service) ReadAllOrganizationEndpoints(ctx context.Context, organizationID string) ([]*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ListOrganizationEndpoints(ctx, env.Instance, organizationID)
+}
+
+func (s *Service) ReadOrganizationEndpoint(ctx context.Context, organizationID, endpointID string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReadOrganizationEndpoint(ctx, env.Instance, organizationID, endpointID)
+}
+
+func (s *Service) CreateOrganizationEndpoint(ctx context.Context, organizationID string, params EndpointParams) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validateForOrganization(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return s.webhookService.CreateOrganizationEndpoint(ctx, env.Instance, organizationID, params.toSharedParams(), "url")
+}
+
+func (s *Service) UpdateOrganizationEndpoint(ctx context.Context, organizationID, endpointID string, params EndpointParams) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validateForOrganization(s.validator); apiErr != nil {
+		return nil, apiErr
+	}
+
+	return s.webhookService.UpdateOrganizationEndpoint(ctx, env.Instance, organizationID, endpointID, params.toSharedParams(), "url")
+}
+
+func (s *Service) DeleteOrganizationEndpoint(ctx context.Context, organizationID, endpointID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.DeleteOrganizationEndpoint(ctx, env.Instance, organizationID, endpointID)
+}
+
+func (s *Service) ReadOrganizationEndpointSecret(ctx context.Context, organizationID, endpointID string) (*serialize.WebhookEndpointSecretResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReadOrganizationEndpointSecret(ctx, env.Instance, organizationID, endpointID)
+}
@@ -0,0 +1,36 @@
+package webhooks
+
+import (
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/pkg/events"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpointParamsValidateForOrganization(t *testing.T) {
+	t.Parallel()
+
+	v := validator.New()
+	params := EndpointParams{
+		URL:        "https://example.com/webhooks",
+		EventTypes: []string{events.EventTypes.OrganizationMembershipCreated.Name},
+	}
+	assert.Nil(t, params.validateForOrganization(v))
+
+	// instance endpoints can subscribe to user events, organization ones
+	// can't
+	params.EventTypes = []string{events.EventTypes.UserCreated.Name}
+	assert.Nil(t, params.validate(v))
+	apiErr := params.validateForOrganization(v)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+
+	params = EndpointParams{}
+	apiErr = params.validateForOrganization(v)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamMissingCode, apiErr.Errors()[0].Code())
+}
@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/webhooks"
+	"clerk/pkg/ctx/environment"
+)
+
+const (
+	paramStartAt    = "start_at"
+	paramEndAt      = "end_at"
+	paramEventTypes = "event_types"
+
+	// the event types are given as a repeated query parameter when
+	// listing events
+	paramEventType = "event_type"
+)
+
+// EventsParams select recorded events by their type and the time range
+// they were emitted in, given as Unix millisecond timestamps.
+type EventsParams struct {
+	StartAt    *int64   `json:"start_at" form:"start_at"`
+	EndAt      *int64   `json:"end_at" form:"end_at"`
+	EventTypes []string `json:"event_types" form:"event_types"`
+}
+
+func (params EventsParams) toSharedParams() webhooks.ReplayParams {
+	sharedParams := webhooks.ReplayParams{EventTypes: params.EventTypes}
+	if params.StartAt != nil {
+		sharedParams.StartAt = time.UnixMilli(*params.StartAt).UTC()
+	}
+	if params.EndAt != nil {
+		sharedParams.EndAt = time.UnixMilli(*params.EndAt).UTC()
+	}
+	return sharedParams
+}
+
+// ListEvents returns the events of the instance which were recorded within
+// the retention window, latest first.
+func (s *Service) ListEvents(ctx context.Context, params EventsParams, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	sharedParams := params.toSharedParams()
+	if apiErr := webhooks.ValidateEventTypes(paramEventType, sharedParams.EventTypes); apiErr != nil {
+		return nil, apiErr
+	}
+	return s.webhookService.ListEvents(ctx, env.Instance, sharedParams, paginationParams)
+}
+
+// ReplayEvents requests the events of the given range to be delivered again
+// to the webhooks of the instance.
+func (s *Service) ReplayEvents(ctx context.Context, params EventsParams) (*serialize.WebhookEventReplayResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	sharedParams := params.toSharedParams()
+	if apiErr := sharedParams.Validate(s.clock.Now().UTC(), paramStartAt, paramEndAt, paramEventTypes); apiErr != nil {
+		return nil, apiErr
+	}
+	return s.webhookService.ReplayEvents(ctx, env.Instance, sharedParams)
+}
+
+func (s *Service) ReadEventReplay(ctx context.Context, replayID string) (*serialize.WebhookEventReplayResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReadEventReplay(ctx, env.Instance, replayID)
+}
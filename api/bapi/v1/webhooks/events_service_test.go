@@ -0,0 +1,30 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventsParamsToSharedParams(t *testing.T) {
+	t.Parallel()
+
+	// open ranges stay open
+	sharedParams := EventsParams{}.toSharedParams()
+	assert.True(t, sharedParams.StartAt.IsZero())
+	assert.True(t, sharedParams.EndAt.IsZero())
+	assert.Empty(t, sharedParams.EventTypes)
+
+	startAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	endAt := startAt.Add(time.Hour)
+	startAtMilli, endAtMilli := startAt.UnixMilli(), endAt.UnixMilli()
+	sharedParams = EventsParams{
+		StartAt:    &startAtMilli,
+		EndAt:      &endAtMilli,
+		EventTypes: []string{"user.created"},
+	}.toSharedParams()
+	assert.Equal(t, startAt, sharedParams.StartAt)
+	assert.Equal(t, endAt, sharedParams.EndAt)
+	assert.Equal(t, []string{"user.created"}, sharedParams.EventTypes)
+}
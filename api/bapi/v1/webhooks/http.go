@@ -2,10 +2,22 @@ package webhooks
 
 import (
 	"net/http"
+	"strconv"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/clerkhttp"
 	"clerk/pkg/externalapis/svix"
-	"clerk/utils/database"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	endpointID       = "endpointID"
+	eventReplayID    = "eventReplayID"
+	failedDeliveryID = "failedDeliveryID"
+	organizationID   = "organizationID"
 )
 
 // HTTP is the http layer for all requests related to webhooks in server API.
@@ -15,9 +27,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database, svixClient *svix.Client) *HTTP {
+func NewHTTP(deps clerk.Deps, svixClient *svix.Client) *HTTP {
 	return &HTTP{
-		service: NewService(db, svixClient),
+		service: NewService(deps, svixClient),
 	}
 }
 
@@ -40,3 +52,175 @@ func (h *HTTP) DeleteSvix(w http.ResponseWriter, r *http.Request) (interface{},
 func (h *HTTP) CreateSvixURL(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	return h.service.CreateSvixURL(r.Context())
 }
+
+// GET /v1/webhooks/event_types
+func (h *HTTP) ReadEventTypes(_ http.ResponseWriter, _ *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadEventTypes(), nil
+}
+
+// GET /v1/webhooks/endpoints
+func (h *HTTP) ReadAllEndpoints(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadAllEndpoints(r.Context())
+}
+
+// GET /v1/webhooks/endpoints/{endpointID}
+func (h *HTTP) ReadEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadEndpoint(r.Context(), chi.URLParam(r, endpointID))
+}
+
+// POST /v1/webhooks/endpoints
+func (h *HTTP) CreateEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := EndpointParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateEndpoint(r.Context(), params)
+}
+
+// PUT /v1/webhooks/endpoints/{endpointID}
+func (h *HTTP) UpdateEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := EndpointParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.UpdateEndpoint(r.Context(), chi.URLParam(r, endpointID), params)
+}
+
+// DELETE /v1/webhooks/endpoints/{endpointID}
+func (h *HTTP) DeleteEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.DeleteEndpoint(r.Context(), chi.URLParam(r, endpointID))
+}
+
+// GET /v1/webhooks/endpoints/{endpointID}/secret
+func (h *HTTP) ReadEndpointSecret(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadEndpointSecret(r.Context(), chi.URLParam(r, endpointID))
+}
+
+// GET /v1/organizations/{organizationID}/webhooks/event_types
+func (h *HTTP) ReadOrganizationEventTypes(_ http.ResponseWriter, _ *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadOrganizationEventTypes(), nil
+}
+
+// GET /v1/organizations/{organizationID}/webhooks/endpoints
+func (h *HTTP) ReadAllOrganizationEndpoints(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadAllOrganizationEndpoints(r.Context(), chi.URLParam(r, organizationID))
+}
+
+// GET /v1/organizations/{organizationID}/webhooks/endpoints/{endpointID}
+func (h *HTTP) ReadOrganizationEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadOrganizationEndpoint(r.Context(), chi.URLParam(r, organizationID), chi.URLParam(r, endpointID))
+}
+
+// POST /v1/organizations/{organizationID}/webhooks/endpoints
+func (h *HTTP) CreateOrganizationEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := EndpointParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.CreateOrganizationEndpoint(r.Context(), chi.URLParam(r, organizationID), params)
+}
+
+// PUT /v1/organizations/{organizationID}/webhooks/endpoints/{endpointID}
+func (h *HTTP) UpdateOrganizationEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := EndpointParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.UpdateOrganizationEndpoint(r.Context(), chi.URLParam(r, organizationID), chi.URLParam(r, endpointID), params)
+}
+
+// DELETE /v1/organizations/{organizationID}/webhooks/endpoints/{endpointID}
+func (h *HTTP) DeleteOrganizationEndpoint(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.DeleteOrganizationEndpoint(r.Context(), chi.URLParam(r, organizationID), chi.URLParam(r, endpointID))
+}
+
+// GET /v1/organizations/{organizationID}/webhooks/endpoints/{endpointID}/secret
+func (h *HTTP) ReadOrganizationEndpointSecret(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadOrganizationEndpointSecret(r.Context(), chi.URLParam(r, organizationID), chi.URLParam(r, endpointID))
+}
+
+// POST /v1/webhooks/native
+func (h *HTTP) EnableNativeDelivery(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	err := h.service.EnableNativeDelivery(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// DELETE /v1/webhooks/native
+func (h *HTTP) DisableNativeDelivery(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	err := h.service.DisableNativeDelivery(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+// GET /v1/webhooks/failed_deliveries
+func (h *HTTP) ListFailedDeliveries(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.service.ListFailedDeliveries(r.Context(), paginationParams)
+}
+
+// POST /v1/webhooks/failed_deliveries/{failedDeliveryID}/replay
+func (h *HTTP) ReplayFailedDelivery(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReplayFailedDelivery(r.Context(), chi.URLParam(r, failedDeliveryID))
+}
+
+// GET /v1/webhooks/events
+func (h *HTTP) ListEvents(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	params := EventsParams{EventTypes: r.URL.Query()[paramEventType]}
+	if params.StartAt, err = parseTimestamp(r, paramStartAt); err != nil {
+		return nil, err
+	}
+	if params.EndAt, err = parseTimestamp(r, paramEndAt); err != nil {
+		return nil, err
+	}
+
+	return h.service.ListEvents(r.Context(), params, paginationParams)
+}
+
+// POST /v1/webhooks/events/replay
+func (h *HTTP) ReplayEvents(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params := EventsParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.ReplayEvents(r.Context(), params)
+}
+
+// GET /v1/webhooks/event_replays/{eventReplayID}
+func (h *HTTP) ReadEventReplay(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadEventReplay(r.Context(), chi.URLParam(r, eventReplayID))
+}
+
+// parseTimestamp returns the Unix millisecond timestamp of the given query
+// parameter, or nil if it's missing.
+func parseTimestamp(r *http.Request, name string) (*int64, apierror.Error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	timestamp, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, apierror.FormInvalidTypeParameter(name, "integer")
+	}
+	return &timestamp, nil
+}
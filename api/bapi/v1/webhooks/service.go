@@ -5,21 +5,30 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
 	"clerk/api/shared/webhooks"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/externalapis/svix"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jonboulle/clockwork"
 )
 
 type Service struct {
+	clock          clockwork.Clock
 	db             database.Database
+	validator      *validator.Validate
 	webhookService *webhooks.Service
 }
 
-func NewService(db database.Database, svixClient *svix.Client) *Service {
+func NewService(deps clerk.Deps, svixClient *svix.Client) *Service {
 	return &Service{
-		db:             db,
-		webhookService: webhooks.NewService(svixClient),
+		clock:          deps.Clock(),
+		db:             deps.DB(),
+		validator:      validator.New(),
+		webhookService: webhooks.NewService(deps, svixClient),
 	}
 }
 
@@ -61,3 +70,45 @@ func (s *Service) CreateSvixURL(ctx context.Context) (*serialize.SvixURLResponse
 	env := environment.FromContext(ctx)
 	return s.webhookService.CreateSvixURL(env.Instance)
 }
+
+func (s *Service) EnableNativeDelivery(ctx context.Context) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.webhookService.EnableNativeDelivery(ctx, tx, env.Instance)
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return apiErr
+		}
+		return apierror.Unexpected(txErr)
+	}
+	return nil
+}
+
+func (s *Service) DisableNativeDelivery(ctx context.Context) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.webhookService.DisableNativeDelivery(ctx, tx, env.Instance)
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return apiErr
+		}
+		return apierror.Unexpected(txErr)
+	}
+	return nil
+}
+
+func (s *Service) ListFailedDeliveries(ctx context.Context, params pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ListFailedDeliveries(ctx, env.Instance, params)
+}
+
+func (s *Service) ReplayFailedDelivery(ctx context.Context, failedDeliveryID string) (*serialize.WebhookFailedDeliveryResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return s.webhookService.ReplayFailedDelivery(ctx, env.Instance, failedDeliveryID)
+}
@@ -3,13 +3,12 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
-	"strings"
-	"time"
 
 	"clerk/api/dapi/v1/router"
+	"clerk/api/middleware/pipeline"
 	"clerk/api/shared/jwt"
+	"clerk/api/shared/serviceconfig"
 	"clerk/api/shared/sso"
 	"clerk/pkg/apiversioning"
 	"clerk/pkg/billing"
@@ -20,14 +19,15 @@ import (
 	"clerk/pkg/pubsub"
 	sdkutils "clerk/pkg/sdk"
 	"clerk/pkg/sentry"
-	"clerk/pkg/set"
 	"clerk/pkg/storage/google"
 	"clerk/pkg/vercel"
+	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 
 	"cloud.google.com/go/profiler"
 	sdk "github.com/clerk/clerk-sdk-go/v2"
+	"github.com/jonboulle/clockwork"
 	"github.com/stripe/stripe-go/v72"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -49,6 +49,11 @@ func main() {
 		panic(err)
 	}
 
+	cfg, err := serviceconfig.LoadDAPI()
+	if err != nil {
+		panic(err)
+	}
+
 	apiversioning.RegisterAllVersions()
 
 	sso.RegisterOAuthProviders()
@@ -58,10 +63,10 @@ func main() {
 	logger := log.New()
 	defer logger.Flush()
 
-	err := sentry.Init(
-		cenv.Get(cenv.SentryURL),
-		cenv.Get(cenv.ClerkEnv),
-		cenv.Get(cenv.SentryIgnoredStatusCodes),
+	err = sentry.Init(
+		cfg.SentryURL,
+		cfg.Env,
+		cfg.SentryIgnoredStatusCodes,
 	)
 	if err != nil {
 		logger.Error("failed starting Sentry: %s", err)
@@ -69,38 +74,40 @@ func main() {
 		defer sentry.Flush()
 	}
 
-	if cenv.IsEnabled(cenv.GoogleCloudProfiler) {
-		err = profiler.Start(profiler.Config{Service: cenv.Get(cenv.ClerkServiceIdentifier)})
+	if cfg.GoogleCloudProfiler {
+		err = profiler.Start(profiler.Config{Service: cfg.ServiceIdentifier})
 		if err != nil {
 			logger.Error("profiler: start: %s", err)
 		}
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDebugMode) {
+	if cfg.DebugMode {
 		boil.DebugMode = true
 		boil.DebugWriter = logger.Writer()
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
+	if cfg.DatadogTracer {
 		//GitCommitSHA is not a required env var, but if it's available use it
 		tracerOpts := []tracer.StartOption{
-			tracer.WithEnv(cenv.Get(cenv.ClerkEnv)),
-			tracer.WithService(cenv.Get(cenv.ClerkServiceIdentifier)),
+			tracer.WithEnv(cfg.Env),
+			tracer.WithService(cfg.ServiceIdentifier),
 		}
-		if cenv.IsSet(cenv.GitCommitSHA) {
-			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cenv.Get(cenv.GitCommitSHA)))
+		if cfg.GitCommitSHA != "" {
+			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cfg.GitCommitSHA))
 		}
 		tracer.Start(tracerOpts...)
 		defer tracer.Stop()
 	}
 
-	storageClient, err := google.NewClient(context.Background(), cenv.Get(cenv.GoogleStorageBucket))
+	storageClient, err := google.NewClient(context.Background(), cfg.GoogleStorageBucket)
 	if err != nil {
 		panic(err)
 	}
 
 	pubsubEventsTopic := pubsub.EventsTopic()
-	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic))
+	// repositories are created once and shared by every service
+	repositories := repository.NewRegistry(clockwork.NewRealClock())
+	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic), clerk.WithRepositories(repositories))
 
 	defer func() {
 		err := deps.SegmentClient().Close()
@@ -117,48 +124,50 @@ func main() {
 	jwt.RegisterServiceVendors(deps.Clock())
 
 	// Initialize Stripe
-	stripe.Key = cenv.Get(cenv.StripeSecretKey)
+	stripe.Key = cfg.StripeSecretKey
 	paymentProvider := billing.NewStripePaymentProvider(deps.GueClient())
 
 	// Initialize billing connector for Stripe
-	stripeConnectorConfig := billing.StripeConnectorConfig{SecretKey: cenv.Get(cenv.BillingStripeSecretKey)}
+	stripeConnectorConfig := billing.StripeConnectorConfig{SecretKey: cfg.BillingStripeSecretKey}
 	billingConnector, err := billing.NewStripeConnector(stripeConnectorConfig)
 	if err != nil {
 		panic(err)
 	}
 
 	svixClient := svix.NewClient(&svix.ClientOptions{
-		APIToken: cenv.Get(cenv.SvixAPIToken),
+		APIToken: cfg.SvixAPIToken,
 	})
 
 	clerkImagesClient := clerkimages.NewClient(
-		cenv.Get(cenv.ClerkImageServiceAPIKey),
-		cenv.Get(cenv.ClerkImageServiceURL),
+		cfg.ImageServiceAPIKey,
+		cfg.ImageServiceURL,
 	)
 
 	vercelClient := vercel.NewClient(deps.DB(), deps.Clock(), nil)
 
 	commonHandlers := handlers.NewCommon(deps.DB())
 
-	// NOTE: Currently our Go SDK does not support a dynamic matcher against AZP making it hard to support
-	// Vercel Preview deployments.
-	//
-	// As a workaround until Go SDK V2 is released, we temporarily disabled the AZP check for staging
-	// Dashboard. For more information please refer to the following slack conversation.
+	// NOTE: The staging Dashboard is also served from Vercel Preview deployments, whose origins
+	// can't be listed upfront, so the AZP check is skipped for staging. For more information
+	// please refer to the following slack conversation.
 	//
 	// https://clerkinc.slack.com/archives/C06FGDX7MRD/p1706523543427249?thread_ts=1706470714.542809&cid=C06FGDX7MRD
-	authorizedParties := []string{}
+	var authorizedParties []string
 	if cenv.IsProduction() || cenv.IsDevelopment() {
-		authorizedParties = set.New(strings.Split(cenv.Get(cenv.ClerkDashboardAZP), ",")...).Array()
+		var err error
+		authorizedParties, err = pipeline.AuthorizedParties(cfg.DashboardAZP)
+		if err != nil {
+			panic(fmt.Errorf("%s: %w", cenv.ClerkDashboardAZP, err))
+		}
 	}
 
 	// Configuration for SDK clients per customer instance. Clerk impersonates a Clerk customer.
-	sdkConfigConstructor := sdkutils.NewConfigConstructor(cenv.Get(cenv.ClerkServerAPI), cenv.IsEnabled(cenv.ClerkDatadogTracer))
+	sdkConfigConstructor := sdkutils.NewConfigConstructor(cfg.ServerAPI, cfg.DatadogTracer)
 
 	// Configuration for SDK clients for the Clerk instance. Clerk is also a Clerk customer.
 	dapiSDKClientConfig := &sdk.ClientConfig{}
-	dapiSDKClientConfig.Key = sdk.String(cenv.Get(cenv.ClerkDashboardAPIKey))
-	dapiSDKClientConfig.URL = sdk.String(cenv.Get(cenv.ClerkServerAPI))
+	dapiSDKClientConfig.Key = sdk.String(cfg.DashboardAPIKey)
+	dapiSDKClientConfig.URL = sdk.String(cfg.ServerAPI)
 
 	router := router.NewRouter(
 		deps,
@@ -174,16 +183,10 @@ func main() {
 	)
 
 	// Start the HTTP server.
-	port := cenv.Get(cenv.Port)
-	ctxTimeout := cenv.GetInt(cenv.ContextTimeoutSeconds)
-	writeTimeout := ctxTimeout + 2 // write timeout should be longer than context timeout
-
-	server := http.Server{
-		Addr:         ":" + port,
-		Handler:      http.TimeoutHandler(router.BuildRoutes(), time.Duration(ctxTimeout)*time.Second, ""),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: time.Duration(writeTimeout) * time.Second,
-	}
+	go serviceconfig.WatchTunables(context.Background())
+
+	port := cfg.Port
+	server := pipeline.NewServer(port, router.BuildRoutes())
 
 	logger.Info("Up on port %s (pid=%d)", port, os.Getpid())
 	logger.Fatal(server.ListenAndServe())
@@ -3,6 +3,7 @@ package serializable
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"clerk/api/shared/user_profile"
 	"clerk/model"
@@ -41,6 +42,7 @@ type RowUserSerializable struct {
 	ImageURL            string
 	Username            *string
 	Locked              bool
+	LockedUntil         *time.Time
 	Identifier          string
 }
 
@@ -58,7 +60,12 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Database, user
 
 	for i, user := range users {
 		responses[i] = &RowUserSerializable{User: user}
-		responses[i].Locked = user.LockoutStatus(s.clock, userSettings.AttackProtection.UserLockout).Locked
+		lockoutStatus := user.LockoutStatus(s.clock, userSettings.AttackProtection.UserLockout)
+		responses[i].Locked = lockoutStatus.Locked
+		if lockoutStatus.LockoutExpiresIn != nil {
+			lockedUntil := s.clock.Now().UTC().Add(*lockoutStatus.LockoutExpiresIn)
+			responses[i].LockedUntil = &lockedUntil
+		}
 
 		imageURL, err := s.userProfileService.GetImageURL(user)
 		if err != nil {
@@ -0,0 +1,29 @@
+package serialize
+
+import "clerk/api/shared/backchannel_logout"
+
+const BackchannelLogoutTestObjectName = "backchannel_logout_test"
+
+type BackchannelLogoutTestResponse struct {
+	Object     string  `json:"object"`
+	URI        string  `json:"uri"`
+	Succeeded  bool    `json:"succeeded"`
+	StatusCode *int    `json:"status_code"`
+	Error      *string `json:"error"`
+}
+
+func BackchannelLogoutTest(result *backchannel_logout.TestResult) *BackchannelLogoutTestResponse {
+	response := &BackchannelLogoutTestResponse{
+		Object:    BackchannelLogoutTestObjectName,
+		URI:       result.URI,
+		Succeeded: result.Err == nil,
+	}
+	if result.StatusCode != 0 {
+		response.StatusCode = &result.StatusCode
+	}
+	if result.Err != nil {
+		errMessage := result.Err.Error()
+		response.Error = &errMessage
+	}
+	return response
+}
@@ -25,6 +25,7 @@ type UserResponse struct {
 	LastSignInAt        *int64  `json:"last_sign_in_at"`
 	Banned              bool    `json:"banned"`
 	Locked              bool    `json:"locked"`
+	LockedUntil         *int64  `json:"locked_until"`
 	CreatedAt           int64   `json:"created_at"`
 }
 
@@ -66,6 +67,11 @@ func User(user *serializable.RowUserSerializable) *UserResponse {
 		userResStruct.Name = &name
 	}
 
+	if user.LockedUntil != nil {
+		lockedUntil := time.UnixMilli(*user.LockedUntil)
+		userResStruct.LockedUntil = &lockedUntil
+	}
+
 	if user.User.LastSignInAt.Valid {
 		lastSignIn := time.UnixMilli(user.User.LastSignInAt.Time)
 		userResStruct.LastSignInAt = &lastSignIn
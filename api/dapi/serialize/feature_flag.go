@@ -0,0 +1,35 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectFeatureFlag is the name for feature flag objects.
+const ObjectFeatureFlag = "feature_flag"
+
+type FeatureFlagResponse struct {
+	Object         string   `json:"object"`
+	ID             string   `json:"id"`
+	Key            string   `json:"key"`
+	Enabled        bool     `json:"enabled"`
+	InstanceIDs    []string `json:"instance_ids"`
+	ApplicationIDs []string `json:"application_ids"`
+	Percentage     int      `json:"percentage"`
+	CreatedAt      int64    `json:"created_at"`
+	UpdatedAt      int64    `json:"updated_at"`
+}
+
+func FeatureFlag(flag *model.FeatureFlag) *FeatureFlagResponse {
+	return &FeatureFlagResponse{
+		Object:         ObjectFeatureFlag,
+		ID:             flag.ID,
+		Key:            flag.Key,
+		Enabled:        flag.Enabled,
+		InstanceIDs:    flag.InstanceIds,
+		ApplicationIDs: flag.ApplicationIds,
+		Percentage:     flag.Percentage,
+		CreatedAt:      time.UnixMilli(flag.CreatedAt),
+		UpdatedAt:      time.UnixMilli(flag.UpdatedAt),
+	}
+}
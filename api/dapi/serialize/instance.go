@@ -4,6 +4,9 @@ import (
 	"context"
 
 	"clerk/api/serialize"
+	"clerk/api/shared/attestation"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/whatsapp"
 	"clerk/model"
 	"clerk/pkg/constants"
 	clerktime "clerk/pkg/time"
@@ -33,6 +36,32 @@ type InstanceResponse struct {
 	HasUsers               bool                                           `json:"has_users"`
 	BlockedCountryCodes    []string                                       `json:"blocked_country_codes"`
 	DevMonthlySMSLimit     *int                                           `json:"dev_monthly_sms_limit"`
+	PhoneNumberProfile     *PhoneNumberProfileResponse                    `json:"phone_number_profile"`
+	AttestationEnforcement string                                         `json:"attestation_enforcement"`
+	BackchannelLogoutURIs  []string                                       `json:"backchannel_logout_uris"`
+	PhoneCodeChannel       *PhoneCodeChannelResponse                      `json:"phone_code_channel"`
+	PhoneLookup            *PhoneLookupResponse                           `json:"phone_lookup"`
+	EmailProvider          *string                                        `json:"email_provider"`
+	MaintenanceMode        bool                                           `json:"maintenance_mode"`
+	MaintenanceBanner      *string                                        `json:"maintenance_banner"`
+}
+
+type PhoneCodeChannelResponse struct {
+	Channel                  string  `json:"channel"`
+	WhatsAppProvider         *string `json:"whatsapp_provider"`
+	WhatsAppTemplate         *string `json:"whatsapp_template"`
+	WhatsAppTemplateLanguage *string `json:"whatsapp_template_language"`
+}
+
+type PhoneLookupResponse struct {
+	Provider         *string  `json:"provider"`
+	BlockedLineTypes []string `json:"blocked_line_types"`
+}
+
+type PhoneNumberProfileResponse struct {
+	AllowedCountries []string `json:"allowed_countries"`
+	DefaultRegion    *string  `json:"default_region"`
+	Format           string   `json:"format"`
 }
 
 type InstancesResponse []*InstanceResponse
@@ -63,6 +92,14 @@ func Instance(
 		APIVersion:             env.Instance.APIVersion,
 		BlockedCountryCodes:    env.Instance.Communication.BlockedCountryCodes,
 		DevMonthlySMSLimit:     getDevMonthlySMSLimit(env.Instance),
+		PhoneNumberProfile:     phoneNumberProfile(env.Instance),
+		AttestationEnforcement: string(attestation.EnforcementForInstance(env.Instance)),
+		BackchannelLogoutURIs:  backchannelLogoutURIs(env.Instance),
+		PhoneCodeChannel:       phoneCodeChannel(env.Instance),
+		PhoneLookup:            phoneLookup(env.Instance),
+		EmailProvider:          env.Instance.Communication.EmailProvider.Ptr(),
+		MaintenanceMode:        env.Instance.MaintenanceMode,
+		MaintenanceBanner:      env.Instance.MaintenanceBanner.Ptr(),
 	}
 
 	if env.Instance.ExternalBillingAccountID.Valid {
@@ -91,6 +128,46 @@ func Instance(
 	return response
 }
 
+func phoneNumberProfile(instance *model.Instance) *PhoneNumberProfileResponse {
+	profile := phone_profiles.ForInstance(instance)
+
+	response := &PhoneNumberProfileResponse{
+		AllowedCountries: profile.AllowedCountries,
+		Format:           profile.Format,
+	}
+	if profile.DefaultRegion != "" {
+		response.DefaultRegion = &profile.DefaultRegion
+	}
+	if response.AllowedCountries == nil {
+		response.AllowedCountries = make([]string, 0)
+	}
+	return response
+}
+
+func phoneCodeChannel(instance *model.Instance) *PhoneCodeChannelResponse {
+	response := &PhoneCodeChannelResponse{
+		Channel:                  whatsapp.ChannelSMS,
+		WhatsAppProvider:         instance.Communication.WhatsAppProvider.Ptr(),
+		WhatsAppTemplate:         instance.Communication.WhatsAppTemplate.Ptr(),
+		WhatsAppTemplateLanguage: instance.Communication.WhatsAppTemplateLanguage.Ptr(),
+	}
+	if whatsapp.IsPreferred(instance) {
+		response.Channel = whatsapp.ChannelWhatsApp
+	}
+	return response
+}
+
+func phoneLookup(instance *model.Instance) *PhoneLookupResponse {
+	response := &PhoneLookupResponse{
+		Provider:         instance.Communication.PhoneLookupProvider.Ptr(),
+		BlockedLineTypes: instance.Communication.PhoneLookupBlockedLineTypes,
+	}
+	if response.BlockedLineTypes == nil {
+		response.BlockedLineTypes = []string{}
+	}
+	return response
+}
+
 func getDevMonthlySMSLimit(instance *model.Instance) *int {
 	if instance.IsProduction() {
 		return nil
@@ -100,3 +177,10 @@ func getDevMonthlySMSLimit(instance *model.Instance) *int {
 
 	return &limit
 }
+
+func backchannelLogoutURIs(instance *model.Instance) []string {
+	if len(instance.BackchannelLogoutURIs) == 0 {
+		return []string{}
+	}
+	return instance.BackchannelLogoutURIs
+}
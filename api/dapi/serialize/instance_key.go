@@ -1,6 +1,7 @@
 package serialize
 
 import (
+	"clerk/api/shared/signing_keys"
 	"clerk/model"
 	clerkstrings "clerk/pkg/strings"
 	"clerk/pkg/time"
@@ -12,8 +13,10 @@ type InstanceKeyResponse struct {
 	Name       string `json:"name"`
 	Secret     string `json:"secret" logger:"redact"`
 	InstanceID string `json:"instance_id"`
-	CreatedAt  int64  `json:"created_at"`
-	UpdatedAt  int64  `json:"updated_at"`
+	// DPoPPublicKey is the public key the instance key is bound to, if any.
+	DPoPPublicKey *string `json:"dpop_public_key,omitempty"`
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
 }
 
 type instanceKeysResponse struct {
@@ -42,12 +45,13 @@ type InstanceKeysResponse struct {
 
 func InstanceKey(key *model.InstanceKey, obfuscate bool) *InstanceKeyResponse {
 	instanceKeyResponse := &InstanceKeyResponse{
-		ID:         key.ID,
-		Object:     "instance_key",
-		Name:       key.Name,
-		InstanceID: key.InstanceID,
-		CreatedAt:  time.UnixMilli(key.CreatedAt),
-		UpdatedAt:  time.UnixMilli(key.UpdatedAt),
+		ID:            key.ID,
+		Object:        "instance_key",
+		Name:          key.Name,
+		InstanceID:    key.InstanceID,
+		DPoPPublicKey: key.DpopPublicKey.Ptr(),
+		CreatedAt:     time.UnixMilli(key.CreatedAt),
+		UpdatedAt:     time.UnixMilli(key.UpdatedAt),
 	}
 
 	secret := key.LegacyFormat()
@@ -63,13 +67,14 @@ func InstanceKey(key *model.InstanceKey, obfuscate bool) *InstanceKeyResponse {
 
 func SecretKey(key *model.InstanceKey, obfuscate bool) *InstanceKeyResponse {
 	response := &InstanceKeyResponse{
-		ID:         key.ID,
-		Object:     "secret_key",
-		Name:       key.Name,
-		Secret:     key.Secret,
-		InstanceID: key.InstanceID,
-		CreatedAt:  time.UnixMilli(key.CreatedAt),
-		UpdatedAt:  time.UnixMilli(key.UpdatedAt),
+		ID:            key.ID,
+		Object:        "secret_key",
+		Name:          key.Name,
+		Secret:        key.Secret,
+		InstanceID:    key.InstanceID,
+		DPoPPublicKey: key.DpopPublicKey.Ptr(),
+		CreatedAt:     time.UnixMilli(key.CreatedAt),
+		UpdatedAt:     time.UnixMilli(key.UpdatedAt),
 	}
 	if obfuscate {
 		response.Secret = clerkstrings.Obfuscate(key.Secret)
@@ -77,18 +82,22 @@ func SecretKey(key *model.InstanceKey, obfuscate bool) *InstanceKeyResponse {
 	return response
 }
 
-func InstanceKeyPublic(instance *model.Instance) *InstanceKeyResponse {
+// InstanceKeyPublic returns the public key of the key the instance currently
+// signs its tokens with.
+func InstanceKeyPublic(instance *model.Instance, signingKey signing_keys.Key) *InstanceKeyResponse {
 	return &InstanceKeyResponse{
 		Object:     "public_key",
-		Secret:     instance.PublicKeyForEdge(),
+		Secret:     signingKey.PublicKeyForEdge(),
 		InstanceID: instance.ID,
 	}
 }
 
-func InstanceKeyPublicPEM(instance *model.Instance) *InstanceKeyResponse {
+// InstanceKeyPublicPEM is like InstanceKeyPublic, with the public key in PEM
+// format.
+func InstanceKeyPublicPEM(instance *model.Instance, signingKey signing_keys.Key) *InstanceKeyResponse {
 	return &InstanceKeyResponse{
 		Object:     "public_key_pem",
-		Secret:     instance.PublicKey,
+		Secret:     signingKey.PublicKey,
 		InstanceID: instance.ID,
 	}
 }
@@ -102,8 +111,9 @@ func InstanceFAPIKeyV2(instance *model.Instance, domain *model.Domain) *Instance
 }
 
 // Assumes apps have their Instances relation loaded, and those in turn
-// have their Domains and InstanceKeys relations loaded.
-func InstanceKeys(apps []*model.Application) []InstanceKeysResponse {
+// have their Domains and InstanceKeys relations loaded. The signing keys are
+// the current keys of the instances, by instance ID.
+func InstanceKeys(apps []*model.Application, signingKeys map[string]signing_keys.Key) []InstanceKeysResponse {
 	resp := make([]InstanceKeysResponse, len(apps))
 
 	for i, app := range apps {
@@ -118,14 +128,15 @@ func InstanceKeys(apps []*model.Application) []InstanceKeysResponse {
 			ins := model.Instance{Instance: instance}
 			dmn := model.Domain{Domain: instance.R.Domains[0]}
 			key := model.InstanceKey{InstanceKey: instance.R.InstanceKeys[0]}
+			signingKey := signingKeys[instance.ID]
 
 			elem.Instances[j] = instanceKeysResponse{
 				InstanceID:      instance.ID,
 				EnvironmentType: instance.EnvironmentType,
 				FAPIKey:         dmn.FapiHost(),
 				BAPIKey:         key.LegacyFormat(),
-				JWTPublicKey:    ins.PublicKeyForEdge(),
-				JWTPublicKeyPEM: ins.PublicKey,
+				JWTPublicKey:    signingKey.PublicKeyForEdge(),
+				JWTPublicKeyPEM: signingKey.PublicKey,
 				PublishableKey:  ins.PublishableKey(&dmn),
 				SecretKey:       key.Secret,
 			}
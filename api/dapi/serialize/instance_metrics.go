@@ -0,0 +1,15 @@
+package serialize
+
+const InstanceMetricsTokenObjectName = "instance_metrics_token"
+
+type InstanceMetricsTokenResponse struct {
+	Object string `json:"object"`
+	Token  string `json:"token"`
+}
+
+func InstanceMetricsToken(token string) *InstanceMetricsTokenResponse {
+	return &InstanceMetricsTokenResponse{
+		Object: InstanceMetricsTokenObjectName,
+		Token:  token,
+	}
+}
@@ -0,0 +1,31 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectIPRestrictionRule is the name for IP restriction rule objects.
+const ObjectIPRestrictionRule = "ip_restriction_rule"
+
+type IPRestrictionRuleResponse struct {
+	Object     string `json:"object"`
+	ID         string `json:"id"`
+	InstanceID string `json:"instance_id"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	CreatedAt  int64  `json:"created_at"`
+	UpdatedAt  int64  `json:"updated_at"`
+}
+
+func IPRestrictionRule(rule *model.IPRestrictionRule) *IPRestrictionRuleResponse {
+	return &IPRestrictionRuleResponse{
+		Object:     ObjectIPRestrictionRule,
+		ID:         rule.ID,
+		InstanceID: rule.InstanceID,
+		Type:       rule.RuleType,
+		Value:      rule.Value,
+		CreatedAt:  time.UnixMilli(rule.CreatedAt),
+		UpdatedAt:  time.UnixMilli(rule.UpdatedAt),
+	}
+}
@@ -0,0 +1,46 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectOrganizationAPIKey is the name for organization API key objects.
+const ObjectOrganizationAPIKey = "organization_api_key"
+
+type OrganizationAPIKeyResponse struct {
+	Object         string `json:"object"`
+	ID             string `json:"id"`
+	InstanceID     string `json:"instance_id"`
+	OrganizationID string `json:"organization_id"`
+	Name           string `json:"name"`
+	Secret         string `json:"secret,omitempty" logger:"omit"`
+	SecretHint     string `json:"secret_hint"`
+	LastUsedAt     *int64 `json:"last_used_at"`
+	CreatedAt      int64  `json:"created_at"`
+	UpdatedAt      int64  `json:"updated_at"`
+}
+
+// OrganizationAPIKey serializes the given organization API key. The secret
+// is only included right after it was issued or rotated, as only its digest
+// is stored.
+func OrganizationAPIKey(key *model.OrganizationAPIKey) *OrganizationAPIKeyResponse {
+	response := &OrganizationAPIKeyResponse{
+		Object:         ObjectOrganizationAPIKey,
+		ID:             key.ID,
+		InstanceID:     key.InstanceID,
+		OrganizationID: key.OrganizationID,
+		Name:           key.Name,
+		Secret:         key.Secret,
+		SecretHint:     key.SecretHint,
+		CreatedAt:      time.UnixMilli(key.CreatedAt),
+		UpdatedAt:      time.UnixMilli(key.UpdatedAt),
+	}
+
+	if key.LastUsedAt.Valid {
+		lastUsedAt := time.UnixMilli(key.LastUsedAt.Time)
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
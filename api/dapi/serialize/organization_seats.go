@@ -0,0 +1,36 @@
+package serialize
+
+import (
+	"clerk/api/shared/organization_seats"
+	clerktime "clerk/pkg/time"
+)
+
+const OrganizationSeatsObjectName = "organization_seats"
+
+type OrganizationSeatsResponse struct {
+	Object          string `json:"object"`
+	OrganizationID  string `json:"organization_id"`
+	Used            int64  `json:"used"`
+	Limit           *int   `json:"limit"`
+	BilledQuantity  *int64 `json:"billed_quantity"`
+	LimitExceeded   bool   `json:"limit_exceeded"`
+	LimitExceededAt *int64 `json:"limit_exceeded_at"`
+}
+
+func OrganizationSeats(seats *organization_seats.Seats) *OrganizationSeatsResponse {
+	response := &OrganizationSeatsResponse{
+		Object:         OrganizationSeatsObjectName,
+		OrganizationID: seats.OrganizationID,
+		Used:           seats.Used,
+		BilledQuantity: seats.BilledQuantity,
+		LimitExceeded:  seats.LimitExceeded(),
+	}
+	if seats.Limit > 0 {
+		response.Limit = &seats.Limit
+	}
+	if seats.LimitExceededAt.Valid {
+		limitExceededAt := clerktime.UnixMilli(seats.LimitExceededAt.Time)
+		response.LimitExceededAt = &limitExceededAt
+	}
+	return response
+}
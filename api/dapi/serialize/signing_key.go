@@ -0,0 +1,39 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+type SigningKeyResponse struct {
+	ID         string `json:"id"`
+	Object     string `json:"object"`
+	KID        string `json:"kid"`
+	Algorithm  string `json:"algorithm"`
+	PublicKey  string `json:"public_key"`
+	ActiveFrom int64  `json:"active_from"`
+	ExpiresAt  *int64 `json:"expires_at"`
+	RevokedAt  *int64 `json:"revoked_at"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+func SigningKey(key *model.InstanceSigningKey) *SigningKeyResponse {
+	response := &SigningKeyResponse{
+		ID:         key.ID,
+		Object:     "signing_key",
+		KID:        key.Kid,
+		Algorithm:  key.Algorithm,
+		PublicKey:  key.PublicKey,
+		ActiveFrom: time.UnixMilli(key.ActiveFrom),
+		CreatedAt:  time.UnixMilli(key.CreatedAt),
+	}
+	if key.ExpiresAt.Valid {
+		expiresAt := time.UnixMilli(key.ExpiresAt.Time)
+		response.ExpiresAt = &expiresAt
+	}
+	if key.RevokedAt.Valid {
+		revokedAt := time.UnixMilli(key.RevokedAt.Time)
+		response.RevokedAt = &revokedAt
+	}
+	return response
+}
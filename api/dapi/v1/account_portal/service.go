@@ -40,10 +40,10 @@ func NewService(deps clerk.Deps, sdkConfigConstructor sdk.ConfigConstructor) *Se
 	return &Service{
 		db:                     deps.DB(),
 		gueClient:              deps.GueClient(),
-		accountPortalRepo:      repository.NewAccountPortal(),
-		displayConfigRepo:      repository.NewDisplayConfig(),
+		accountPortalRepo:      deps.Repositories().AccountPortal,
+		displayConfigRepo:      deps.Repositories().DisplayConfig,
 		sharedDomainService:    domains.NewService(deps),
-		domainRepo:             repository.NewDomain(),
+		domainRepo:             deps.Repositories().Domain,
 		dashboardDomainService: dashboardDomains.NewService(deps, sdkConfigConstructor),
 	}
 }
@@ -72,3 +72,19 @@ func (h *HTTP) LatestActivity(_ http.ResponseWriter, r *http.Request) (interface
 	instanceID := chi.URLParam(r, "instanceID")
 	return h.service.LatestActivity(r.Context(), instanceID, 10)
 }
+
+// GET /instances/{instanceID}/analytics/sign_up_funnel
+func (h *HTTP) SignUpFunnel(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	instanceID := chi.URLParam(r, "instanceID")
+	since, err := time.Parse(isoDateFmt, r.FormValue("since"))
+	if err != nil {
+		since = h.clock.Now().UTC().AddDate(0, 0, -30)
+	}
+
+	until, err := time.Parse(isoDateFmt, r.FormValue("until"))
+	if err != nil {
+		until = h.clock.Now().UTC()
+	}
+
+	return h.service.SignUpFunnel(r.Context(), instanceID, since, until)
+}
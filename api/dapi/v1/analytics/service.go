@@ -28,6 +28,7 @@ type Service struct {
 	userRepo                  *repository.Users
 	signinRepo                *repository.SignIn
 	signupRepo                *repository.SignUp
+	signUpFunnelMetricRepo    *repository.SignUpFunnelMetrics
 }
 
 func NewService(clock clockwork.Clock, db database.Database) *Service {
@@ -42,6 +43,7 @@ func NewService(clock clockwork.Clock, db database.Database) *Service {
 		userRepo:                  repository.NewUsers(),
 		signinRepo:                repository.NewSignIn(),
 		signupRepo:                repository.NewSignUp(),
+		signUpFunnelMetricRepo:    repository.NewSignUpFunnelMetrics(),
 	}
 }
 
@@ -238,3 +240,54 @@ func (s *Service) getUserPrimaryIdentifier(ctx context.Context, exec database.Ex
 
 	return ""
 }
+
+// SignUpFunnelStages are the number of sign-ups which reached each stage of
+// the funnel.
+type SignUpFunnelStages struct {
+	Created    int64 `json:"created"`
+	Identified int64 `json:"identified"`
+	Verified   int64 `json:"verified"`
+	Converted  int64 `json:"converted"`
+	Abandoned  int64 `json:"abandoned"`
+}
+
+func (s *SignUpFunnelStages) add(metric *model.SignUpFunnelMetric) {
+	s.Created += metric.Created
+	s.Identified += metric.Identified
+	s.Verified += metric.Verified
+	s.Converted += metric.Converted
+	s.Abandoned += metric.Abandoned
+}
+
+type SignUpFunnelDay struct {
+	Day string `json:"day"`
+	SignUpFunnelStages
+}
+
+type SignUpFunnel struct {
+	Totals SignUpFunnelStages `json:"totals"`
+	Days   []SignUpFunnelDay  `json:"days"`
+}
+
+// SignUpFunnel returns the funnel of the sign-ups created between the given
+// days, in total and per day. The funnel is aggregated daily, so the
+// current day is never included.
+func (s *Service) SignUpFunnel(
+	ctx context.Context,
+	instanceID string,
+	since time.Time,
+	until time.Time,
+) (*SignUpFunnel, apierror.Error) {
+	metrics, err := s.signUpFunnelMetricRepo.FindAllByInstanceAndRange(ctx, s.db, instanceID, since, until)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	funnel := &SignUpFunnel{Days: make([]SignUpFunnelDay, len(metrics))}
+	for i, metric := range metrics {
+		funnel.Days[i].Day = metric.Day.Format(isoDateFmt)
+		funnel.Days[i].add(metric)
+		funnel.Totals.add(metric)
+	}
+	return funnel, nil
+}
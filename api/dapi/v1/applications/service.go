@@ -108,7 +108,7 @@ func NewService(
 		applicationDeleter:   applications.NewDeleter(deps),
 		applicationService:   applications.NewService(),
 		pricingService:       pricing.NewService(deps, paymentProvider),
-		imageService:         images.NewService(deps.StorageClient()),
+		imageService:         images.NewService(deps.Clock(), deps.StorageClient()),
 		sharedPricingService: shpricing.NewService(deps.DB(), deps.GueClient(), deps.Clock(), paymentProvider),
 		sharedDomainService:  domains.NewService(deps),
 		subscriptionService:  subscriptions.NewService(deps, paymentProvider),
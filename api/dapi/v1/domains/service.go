@@ -52,7 +52,7 @@ func NewService(
 		gueClient:                 deps.GueClient(),
 		sdkConfigConstructor:      sdkConfigConstructor,
 		domainsService:            domains.NewService(deps),
-		serializableDomainService: serializable.NewDomainService(),
+		serializableDomainService: serializable.NewDomainService(deps),
 		domainRepo:                repository.NewDomain(),
 		instanceRepo:              repository.NewInstances(),
 		dnsChecksRepo:             repository.NewDNSChecks(),
@@ -338,6 +338,11 @@ func (s *Service) RetryDNS(
 		return apierror.Unexpected(txErr)
 	}
 
+	// so that the domain shows up as being checked right away
+	if err := s.serializableDomainService.InvalidateChecks(ctx, domainID); err != nil {
+		return apierror.Unexpected(err)
+	}
+
 	return nil
 }
 
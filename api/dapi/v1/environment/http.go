@@ -4,7 +4,8 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
-	"clerk/utils/database"
+	"clerk/pkg/clerkhttp"
+	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -13,9 +14,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
-		service: NewService(db),
+		service: NewService(deps),
 	}
 }
 
@@ -24,3 +25,18 @@ func (h *HTTP) LoadEnvFromInstance(_ http.ResponseWriter, r *http.Request) (*htt
 	newCtx, apiErr := h.service.LoadEnvFromInstance(r.Context(), instanceID)
 	return r.WithContext(newCtx), apiErr
 }
+
+// Middleware /instances/{instanceID}
+// InvalidateCachedEnvAfterUpdate evicts the environment of the instance from
+// the FAPI caches after every write to its settings.
+func (h *HTTP) InvalidateCachedEnvAfterUpdate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		next.ServeHTTP(w, r)
+		if !clerkhttp.IsMutationMethod(r.Method) {
+			return
+		}
+
+		h.service.InvalidateCachedEnv(ctx, chi.URLParam(r, "instanceID"))
+	})
+}
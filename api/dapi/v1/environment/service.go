@@ -5,19 +5,26 @@ import (
 
 	"clerk/api/apierror"
 	shenvironment "clerk/api/shared/environment"
+	"clerk/api/shared/phone_profiles"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/sentry"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 )
 
 type Service struct {
-	db                 database.Database
+	db database.Database
+
+	// services
 	environmentService *shenvironment.Service
+	envInvalidator     *shenvironment.Invalidator
 }
 
-func NewService(db database.Database) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:                 db,
+		db:                 deps.DB(),
 		environmentService: shenvironment.NewService(),
+		envInvalidator:     shenvironment.NewInvalidator(deps),
 	}
 }
 
@@ -26,5 +33,15 @@ func (s *Service) LoadEnvFromInstance(ctx context.Context, instanceID string) (c
 	if err != nil {
 		return ctx, apierror.Unexpected(err)
 	}
-	return environment.NewContext(ctx, env), nil
+	ctx = environment.NewContext(ctx, env)
+	return phone_profiles.NewContext(ctx, env.Instance), nil
+}
+
+// InvalidateCachedEnv notifies FAPI that the environment of the instance
+// changed. Failures are only reported, as the cached environments expire on
+// their own shortly after.
+func (s *Service) InvalidateCachedEnv(ctx context.Context, instanceID string) {
+	if err := s.envInvalidator.Invalidate(ctx, instanceID); err != nil {
+		sentry.CaptureException(ctx, err)
+	}
 }
@@ -47,11 +47,11 @@ func NewService(deps clerk.Deps, paymentProvider billing.PaymentProvider) *Servi
 		gueClient:               deps.GueClient(),
 		paymentProvider:         paymentProvider,
 		usageService:            usage.NewService(deps.Clock(), deps.DB(), deps.GueClient(), paymentProvider),
-		billingAccountsRepo:     repository.NewBillingAccounts(),
-		stripeUsageRepo:         repository.NewStripeUsageReports(),
-		subscriptionRepo:        repository.NewSubscriptions(),
-		subscriptionPlanRepo:    repository.NewSubscriptionPlans(),
-		subscriptionProductRepo: repository.NewSubscriptionProduct(),
+		billingAccountsRepo:     deps.Repositories().BillingAccounts,
+		stripeUsageRepo:         deps.Repositories().StripeUsageReports,
+		subscriptionRepo:        deps.Repositories().Subscriptions,
+		subscriptionPlanRepo:    deps.Repositories().SubscriptionPlans,
+		subscriptionProductRepo: deps.Repositories().SubscriptionProduct,
 	}
 }
 
@@ -1,6 +1,7 @@
 package feature_flags
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"clerk/api/apierror"
@@ -24,3 +25,31 @@ func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	instanceID := chi.URLParam(r, "instanceID")
 	return h.service.Read(r.Context(), instanceID)
 }
+
+// GET /feature_flags
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.List(r.Context())
+}
+
+// POST /feature_flags
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.Create(r.Context(), params)
+}
+
+// PATCH /feature_flags/{key}
+func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params UpdateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.Update(r.Context(), chi.URLParam(r, "key"), params)
+}
+
+// DELETE /feature_flags/{key}
+func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Delete(r.Context(), chi.URLParam(r, "key"))
+}
@@ -2,9 +2,18 @@ package feature_flags
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"clerk/api/apierror"
+	"clerk/api/dapi/serialize"
+	sharedserialize "clerk/api/serialize"
+	"clerk/api/shared/featureflags"
+	"clerk/model"
+	"clerk/model/sqbmodel"
 	"clerk/pkg/cenv"
+	"clerk/pkg/clerkerrors"
+	clerkfeatureflags "clerk/pkg/featureflags"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
@@ -13,14 +22,20 @@ import (
 type Service struct {
 	db database.Database
 
+	// services
+	featureFlagService *featureflags.Service
+
 	// repositories
 	applicationRepo *repository.Applications
+	featureFlagRepo *repository.FeatureFlags
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:              deps.DB(),
-		applicationRepo: repository.NewApplications(),
+		db:                 deps.DB(),
+		featureFlagService: featureflags.NewService(deps),
+		applicationRepo:    deps.Repositories().Applications,
+		featureFlagRepo:    deps.Repositories().FeatureFlags,
 	}
 }
 
@@ -55,3 +70,138 @@ func (s *Service) Read(ctx context.Context, instanceID string) (*FeatureFlags, a
 		EnableEmailLinkRequireSameClient:    cenv.GetBool(cenv.FlagEnableEmailLinkRequireSameClient),
 	}, nil
 }
+
+// List returns all the feature flags evaluated through the database, along
+// with their targeting.
+func (s *Service) List(ctx context.Context) ([]*serialize.FeatureFlagResponse, apierror.Error) {
+	flags, err := s.featureFlagRepo.FindAll(ctx, s.db)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.FeatureFlagResponse, len(flags))
+	for i, flag := range flags {
+		responses[i] = serialize.FeatureFlag(flag)
+	}
+	return responses, nil
+}
+
+type CreateParams struct {
+	Key            string   `json:"key"`
+	Enabled        bool     `json:"enabled"`
+	InstanceIDs    []string `json:"instance_ids"`
+	ApplicationIDs []string `json:"application_ids"`
+	Percentage     int      `json:"percentage"`
+}
+
+// Create adds a feature flag. It takes effect on all APIs as soon as it's
+// created.
+func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.FeatureFlagResponse, apierror.Error) {
+	flag := &model.FeatureFlag{FeatureFlag: &sqbmodel.FeatureFlag{
+		Key:            params.Key,
+		Enabled:        params.Enabled,
+		InstanceIds:    nonNil(params.InstanceIDs),
+		ApplicationIds: nonNil(params.ApplicationIDs),
+		Percentage:     params.Percentage,
+	}}
+	if apiErr := validate(flag); apiErr != nil {
+		return nil, apiErr
+	}
+
+	err := s.featureFlagRepo.Insert(ctx, s.db, flag)
+	if clerkerrors.IsUniqueConstraintViolation(err, clerkerrors.UniqueFeatureFlagKey) {
+		return nil, apierror.DuplicateFeatureFlag(params.Key)
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	s.featureFlagService.ClearCache(ctx)
+	return serialize.FeatureFlag(flag), nil
+}
+
+type UpdateParams struct {
+	Enabled        *bool     `json:"enabled"`
+	InstanceIDs    *[]string `json:"instance_ids"`
+	ApplicationIDs *[]string `json:"application_ids"`
+	Percentage     *int      `json:"percentage"`
+}
+
+// Update changes the targeting of the feature flag with the given key. The
+// lists of instances and applications are replaced as a whole.
+func (s *Service) Update(ctx context.Context, key string, params UpdateParams) (*serialize.FeatureFlagResponse, apierror.Error) {
+	flag, err := s.featureFlagRepo.QueryByKey(ctx, s.db, key)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if flag == nil {
+		return nil, apierror.FeatureFlagNotFound(key)
+	}
+
+	var columnsToUpdate []string
+	if params.Enabled != nil {
+		flag.Enabled = *params.Enabled
+		columnsToUpdate = append(columnsToUpdate, sqbmodel.FeatureFlagColumns.Enabled)
+	}
+	if params.InstanceIDs != nil {
+		flag.InstanceIds = nonNil(*params.InstanceIDs)
+		columnsToUpdate = append(columnsToUpdate, sqbmodel.FeatureFlagColumns.InstanceIds)
+	}
+	if params.ApplicationIDs != nil {
+		flag.ApplicationIds = nonNil(*params.ApplicationIDs)
+		columnsToUpdate = append(columnsToUpdate, sqbmodel.FeatureFlagColumns.ApplicationIds)
+	}
+	if params.Percentage != nil {
+		flag.Percentage = *params.Percentage
+		columnsToUpdate = append(columnsToUpdate, sqbmodel.FeatureFlagColumns.Percentage)
+	}
+	if apiErr := validate(flag); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if len(columnsToUpdate) > 0 {
+		if err := s.featureFlagRepo.Update(ctx, s.db, flag, columnsToUpdate...); err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		s.featureFlagService.ClearCache(ctx)
+	}
+	return serialize.FeatureFlag(flag), nil
+}
+
+// Delete removes the feature flag with the given key, which turns it off
+// everywhere.
+func (s *Service) Delete(ctx context.Context, key string) (*sharedserialize.DeletedObjectResponse, apierror.Error) {
+	flag, err := s.featureFlagRepo.QueryByKey(ctx, s.db, key)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if flag == nil {
+		return nil, apierror.FeatureFlagNotFound(key)
+	}
+
+	if err := s.featureFlagRepo.DeleteByID(ctx, s.db, flag.ID); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	s.featureFlagService.ClearCache(ctx)
+	return sharedserialize.DeletedObject(flag.ID, serialize.ObjectFeatureFlag), nil
+}
+
+func validate(flag *model.FeatureFlag) apierror.Error {
+	err := featureflags.ToFlag(flag).Validate()
+	switch {
+	case errors.Is(err, clerkfeatureflags.ErrInvalidKey):
+		return apierror.FormInvalidParameterValue("key", flag.Key)
+	case errors.Is(err, clerkfeatureflags.ErrInvalidPercentage):
+		return apierror.FormInvalidParameterValue("percentage", strconv.Itoa(flag.Percentage))
+	case err != nil:
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// nonNil stores missing lists as empty ones, since the columns are not
+// nullable.
+func nonNil(ids []string) []string {
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
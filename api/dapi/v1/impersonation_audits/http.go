@@ -0,0 +1,35 @@
+package impersonation_audits
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/impersonation_audits"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type HTTP struct {
+	db database.Database
+
+	impersonationAuditService *impersonation_audits.Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		db:                        deps.DB(),
+		impersonationAuditService: impersonation_audits.NewService(deps.Clock()),
+	}
+}
+
+// GET /instances/{instanceID}/impersonation_audits
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	params, apiErr := impersonation_audits.ListParamsFromRequest(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	env := environment.FromContext(r.Context())
+	return h.impersonationAuditService.ListResponse(r.Context(), h.db, env.Instance.ID, params)
+}
@@ -0,0 +1,135 @@
+package instance_audit_logs
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/instance_audit_logs"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/clerkhttp"
+	"clerk/pkg/ctx/environment"
+	sdkutils "clerk/pkg/sdk"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// requests with larger bodies are recorded without their body
+const maxRequestSize = 64 * 1024
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/audit_logs
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.service.List(r.Context(), instance_audit_logs.ListParams{
+		ActorID:      queryParam(r, "actor_id"),
+		ResourceType: queryParam(r, "resource_type"),
+		Pagination:   paginationParams,
+	})
+}
+
+// RecordMutations is a middleware which keeps an audit log of every
+// successful write to the configuration of an instance.
+func (h *HTTP) RecordMutations(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !clerkhttp.IsMutationMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// the body is read ahead of the handler, so put it back for it
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			body = nil
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		if len(body) > maxRequestSize {
+			body = nil
+		}
+
+		// taken before the handler runs, as it may change the environment
+		// in place
+		before := instance_audit_logs.Snapshot(environment.FromContext(r.Context()))
+
+		rw := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		if rw.statusCode < 200 || rw.statusCode >= 300 {
+			return
+		}
+
+		ctx := r.Context()
+		claims, hasClaims := sdkutils.GetActiveSession(ctx)
+		if !hasClaims {
+			return
+		}
+
+		// the route pattern is only known once the request has been routed
+		pattern := r.URL.Path
+		if routeCtx := chi.RouteContext(ctx); routeCtx != nil {
+			pattern = routeCtx.RoutePattern()
+		}
+
+		h.service.Record(ctx, instance_audit_logs.RecordParams{
+			InstanceID:   chi.URLParam(r, "instanceID"),
+			ActorID:      claims.Subject,
+			Action:       r.Method + " " + pattern,
+			ResourceType: resourceType(pattern),
+			Path:         r.URL.Path,
+			Before:       before,
+			Request:      body,
+		})
+	})
+}
+
+// resourceType returns the first segment of the route pattern after the
+// instance, e.g. "user_settings" for
+// "/instances/{instanceID}/user_settings/social/{providerID}". Writes to the
+// instance itself have an "instances" resource type.
+func resourceType(pattern string) string {
+	_, rest, found := strings.Cut(pattern, "{instanceID}")
+	if !found {
+		return "instances"
+	}
+	segment, _, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+	if segment == "" {
+		return "instances"
+	}
+	return segment
+}
+
+func queryParam(r *http.Request, name string) *string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// statusResponseWriter keeps the status code of the response, so that only
+// successful writes are recorded.
+type statusResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+}
+
+func (rw *statusResponseWriter) WriteHeader(statusCode int) {
+	rw.statusCode = statusCode
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
@@ -0,0 +1,64 @@
+package instance_audit_logs
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	shenvironment "clerk/api/shared/environment"
+	"clerk/api/shared/instance_audit_logs"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+type Service struct {
+	db database.Database
+
+	envService              *shenvironment.Service
+	instanceAuditLogService *instance_audit_logs.Service
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                      deps.DB(),
+		envService:              shenvironment.NewService(),
+		instanceAuditLogService: instance_audit_logs.NewService(deps.Clock()),
+	}
+}
+
+// List returns the audit logs of the instance, optionally filtered by the
+// dashboard user who made the changes or by the kind of configuration that
+// was changed.
+func (s *Service) List(ctx context.Context, params instance_audit_logs.ListParams) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	auditLogs, totalCount, err := s.instanceAuditLogService.List(ctx, s.db, env.Instance.ID, params)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]interface{}, len(auditLogs))
+	for i, auditLog := range auditLogs {
+		responses[i] = serialize.AuditLog(auditLog)
+	}
+	return serialize.Paginated(responses, totalCount), nil
+}
+
+// Record keeps the audit log of a change made to the configuration of an
+// instance. The snapshot after the change is taken from the freshly loaded
+// environment of the instance. The change has already been made by then, so
+// failing to record it is logged instead of failing the request.
+func (s *Service) Record(ctx context.Context, params instance_audit_logs.RecordParams) {
+	env, err := s.envService.Load(ctx, s.db, params.InstanceID)
+	if err != nil {
+		log.Error(ctx, "dapi/instance_audit_logs: loading environment of instance %s: %v", params.InstanceID, err)
+		return
+	}
+	params.After = instance_audit_logs.Snapshot(env)
+
+	if err := s.instanceAuditLogService.Record(ctx, s.db, params); err != nil {
+		log.Error(ctx, "dapi/instance_audit_logs: %v", err)
+	}
+}
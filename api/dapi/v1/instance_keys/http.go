@@ -5,7 +5,7 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
-	"clerk/utils/database"
+	"clerk/utils/clerk"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -14,9 +14,9 @@ type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps) *HTTP {
 	return &HTTP{
-		service: NewService(db),
+		service: NewService(deps),
 	}
 }
 
@@ -50,6 +50,19 @@ func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.service.Create(ctx, &inputKey)
 }
 
+// PATCH /instances/{instanceID}/instance_keys/{keyID}
+func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	instanceID := chi.URLParam(r, "instanceID")
+	instanceKeyID := chi.URLParam(r, "instanceKeyID")
+
+	var params UpdateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	return h.service.Update(r.Context(), instanceID, instanceKeyID, params)
+}
+
 // DELETE /instances/{instanceID}/instance_keys/{keyID}
 func (h *HTTP) Delete(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	instanceID := chi.URLParam(r, "instanceID")
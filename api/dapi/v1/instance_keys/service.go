@@ -5,30 +5,40 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/dapi/serialize"
+	"clerk/api/shared/dpop"
+	"clerk/api/shared/signing_keys"
 	"clerk/model"
+	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctx/validator"
 	"clerk/pkg/generate"
 	sdkutils "clerk/pkg/sdk"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
 )
 
 type Service struct {
 	db database.Database
 
+	// services
+	signingKeyService *signing_keys.Service
+
 	// repositories
 	appRepo      *repository.Applications
 	instanceRepo *repository.Instances
 	keyRepo      *repository.InstanceKeys
 }
 
-func NewService(db database.Database) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		db:           db,
-		appRepo:      repository.NewApplications(),
-		instanceRepo: repository.NewInstances(),
-		keyRepo:      repository.NewInstanceKeys(),
+		db:                deps.DB(),
+		signingKeyService: signing_keys.NewService(deps),
+		appRepo:           deps.Repositories().Applications,
+		instanceRepo:      deps.Repositories().Instances,
+		keyRepo:           deps.Repositories().InstanceKeys,
 	}
 }
 
@@ -40,7 +50,18 @@ func (s *Service) ListAll(ctx context.Context) (any, apierror.Error) {
 		return nil, apierror.Unexpected(err)
 	}
 
-	return serialize.InstanceKeys(apps), nil
+	signingKeys := make(map[string]signing_keys.Key)
+	for _, app := range apps {
+		for _, instance := range app.R.Instances {
+			signingKey, err := s.signingKeyService.Current(ctx, s.db, &model.Instance{Instance: instance})
+			if err != nil {
+				return nil, apierror.Unexpected(err)
+			}
+			signingKeys[instance.ID] = signingKey
+		}
+	}
+
+	return serialize.InstanceKeys(apps, signingKeys), nil
 }
 
 // List returns all instance keys for given instance
@@ -57,11 +78,16 @@ func (s *Service) List(ctx context.Context, instanceID string) ([]*serialize.Ins
 		responses[i] = serialize.InstanceKey(key, true)
 	}
 
-	// Include instance public key in the response
-	responses = append(responses, serialize.InstanceKeyPublic(env.Instance))
+	signingKey, err := s.signingKeyService.Current(ctx, s.db, env.Instance)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	// Include the public key of the current signing key in the response
+	responses = append(responses, serialize.InstanceKeyPublic(env.Instance, signingKey))
 
-	// Include instance public key in the response in PEM format as well
-	responses = append(responses, serialize.InstanceKeyPublicPEM(env.Instance))
+	// Include the same public key in PEM format as well
+	responses = append(responses, serialize.InstanceKeyPublicPEM(env.Instance, signingKey))
 
 	// Include FAPIv2 key
 	responses = append(responses, serialize.InstanceFAPIKeyV2(env.Instance, env.Domain))
@@ -126,6 +152,46 @@ func (s *Service) Create(ctx context.Context, instanceKey *InstanceKey) (*serial
 	return serialize.SecretKey(newKey, false), nil
 }
 
+type UpdateParams struct {
+	// DPoPPublicKey is the public key, in JWK format, the instance key is
+	// bound to. An empty value unbinds it.
+	DPoPPublicKey *string `json:"dpop_public_key"`
+}
+
+// Update updates the given instance key
+func (s *Service) Update(ctx context.Context, instanceID, instanceKeyID string, params UpdateParams) (*serialize.InstanceKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	instanceKey, err := s.keyRepo.QueryByIDAndInstance(ctx, s.db, instanceKeyID, instanceID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if instanceKey == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	var columnsToUpdate []string
+	if params.DPoPPublicKey != nil {
+		if *params.DPoPPublicKey != "" {
+			if _, err := dpop.ParsePublicKey(*params.DPoPPublicKey); err != nil {
+				return nil, apierror.FormInvalidParameterFormat("dpop_public_key", "Must be an asymmetric public key in JWK format")
+			}
+		}
+		instanceKey.DpopPublicKey = null.NewString(*params.DPoPPublicKey, *params.DPoPPublicKey != "")
+		columnsToUpdate = append(columnsToUpdate, sqbmodel.InstanceKeyColumns.DpopPublicKey)
+	}
+
+	if len(columnsToUpdate) > 0 {
+		if err := s.keyRepo.Update(ctx, s.db, instanceKey, columnsToUpdate...); err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
+	if env.Instance.UsesKimaKeys() {
+		return serialize.SecretKey(instanceKey, sdkutils.ActorHasLimitedAccess(ctx)), nil
+	}
+	return serialize.InstanceKey(instanceKey, true), nil
+}
+
 // Delete deletes the given instance key
 func (s *Service) Delete(ctx context.Context, instanceID, instanceKeyID string) apierror.Error {
 	// Start transaction to be able to SELECT instance for UPDATE
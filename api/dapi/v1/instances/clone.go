@@ -0,0 +1,279 @@
+package instances
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/apierror"
+	"clerk/api/dapi/serialize"
+	"clerk/api/shared/sso"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/externalapis/segment"
+	"clerk/pkg/generate"
+	"clerk/pkg/segment/dapi"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Clone creates a new development instance for the application of the
+// instance in context, with the configuration of the latter. Along with the
+// auth config and its user settings, the new instance gets copies of the
+// instance's custom templates, organization roles & permissions and redirect
+// URLs.
+//
+// OAuth configs are copied without their client secrets, so the SSO
+// providers of the new instance use the shared development credentials
+// until new secrets are provided for them.
+func (s *Service) Clone(ctx context.Context) (*serialize.InstanceResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	source := env.Instance
+
+	var newInstance *model.Instance
+	txErr := s.db.PerformTxWithEmitter(ctx, s.gueClient, func(txEmitter database.TxEmitter) (bool, error) {
+		var err error
+		// development instances are given a random domain, so there's
+		// neither a domain nor a home origin to pass
+		newInstance, _, err = generate.Instance(
+			ctx,
+			txEmitter,
+			s.gueClient,
+			env.Application,
+			"",
+			null.StringFromPtr(nil),
+			source,
+			constants.ETDevelopment,
+			null.StringFromPtr(nil),
+			null.JSONFromPtr(nil),
+			defaultKeyAlgorithm(),
+		)
+		if err != nil {
+			return true, err
+		}
+
+		newAuthConfig, err := s.authConfigRepo.FindByID(ctx, txEmitter, newInstance.ActiveAuthConfigID)
+		if err != nil {
+			return true, err
+		}
+
+		if err := s.cloneSSOProviders(ctx, txEmitter, env.AuthConfig, newInstance, newAuthConfig); err != nil {
+			return true, err
+		}
+		if err := s.cloneTemplates(ctx, txEmitter, source, newInstance); err != nil {
+			return true, err
+		}
+		if err := s.cloneRolesAndPermissions(ctx, txEmitter, source, newInstance); err != nil {
+			return true, err
+		}
+		if err := s.cloneRedirectURLs(ctx, txEmitter, source, newInstance); err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	err := s.createAvatarSettings(ctx, newInstance)
+	if err != nil {
+		// Not fatal if we fail to update Clerk image service, so we only log and continue
+		log.Error(ctx, err)
+	}
+
+	dapi.EnqueueSegmentEvent(ctx, s.gueClient, dapi.SegmentParams{EventName: segment.APIDashboardInstanceCloned, ApplicationID: env.Application.ID, InstanceID: newInstance.ID})
+
+	return s.fetchModelsAndBuildResponse(ctx, newInstance.ID)
+}
+
+// cloneSSOProviders enables the SSO providers of the source auth config for
+// the new instance, with the shared development credentials. The OAuth
+// configs of the source are copied without their secrets, so that they can
+// be activated once the secrets are provided.
+func (s *Service) cloneSSOProviders(ctx context.Context, tx database.Tx, sourceAuthConfig *model.AuthConfig, newInstance *model.Instance, newAuthConfig *model.AuthConfig) error {
+	providers, err := s.enabledSSOProviderRepo.FindAllByAuthConfigID(ctx, tx, sourceAuthConfig.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching sso providers of auth config %s: %w", sourceAuthConfig.ID, err)
+	}
+
+	for _, provider := range providers {
+		if err := sso.EnableDevConfiguration(ctx, tx, newAuthConfig, provider.Provider); err != nil {
+			return fmt.Errorf("clone: enabling sso provider %s for instance %s: %w", provider.Provider, newInstance.ID, err)
+		}
+
+		if provider.UsesSharedDevConfig() {
+			continue
+		}
+
+		oauthConfig, err := s.oauthConfigRepo.FindByIDAndType(ctx, tx, provider.ActiveOauthConfigID.String, provider.Provider)
+		if err != nil {
+			return fmt.Errorf("clone: fetching oauth config %s: %w", provider.ActiveOauthConfigID.String, err)
+		}
+
+		newOAuthConfig := &model.OauthConfig{OauthConfig: &sqbmodel.OauthConfig{
+			InstanceID:       newInstance.ID,
+			Type:             oauthConfig.Type,
+			DefaultScopes:    oauthConfig.DefaultScopes,
+			AuthURL:          oauthConfig.AuthURL,
+			TokenURL:         oauthConfig.TokenURL,
+			ClientID:         oauthConfig.ClientID,
+			ProviderSettings: oauthConfig.ProviderSettings,
+		}}
+		if err := s.oauthConfigRepo.Insert(ctx, tx, newOAuthConfig); err != nil {
+			return fmt.Errorf("clone: inserting oauth config %s for instance %s: %w", oauthConfig.Type, newInstance.ID, err)
+		}
+	}
+	return nil
+}
+
+// cloneTemplates copies the templates the source instance has customized.
+// The rest of the templates are shared by all instances.
+func (s *Service) cloneTemplates(ctx context.Context, tx database.Tx, source, newInstance *model.Instance) error {
+	for _, templateType := range []constants.TemplateType{constants.TTEmail, constants.TTSMS} {
+		templates, err := s.templateRepo.FindAllByTemplateType(ctx, tx, source.ID, templateType)
+		if err != nil {
+			return fmt.Errorf("clone: fetching %s templates of instance %s: %w", templateType, source.ID, err)
+		}
+
+		for _, template := range templates {
+			if !template.IsUserTemplate() || template.InstanceID != source.ID {
+				continue
+			}
+
+			newTemplate := &model.Template{Template: &sqbmodel.Template{
+				InstanceID:       newInstance.ID,
+				ParentID:         template.ParentID,
+				ResourceType:     template.ResourceType,
+				TemplateType:     template.TemplateType,
+				Slug:             template.Slug,
+				Name:             template.Name,
+				Subject:          template.Subject,
+				Markup:           template.Markup,
+				Body:             template.Body,
+				FromEmailName:    template.FromEmailName,
+				ReplyToEmailName: template.ReplyToEmailName,
+				DeliveredByClerk: template.DeliveredByClerk,
+			}}
+			if err := s.templateRepo.Insert(ctx, tx, newTemplate); err != nil {
+				return fmt.Errorf("clone: inserting template %s for instance %s: %w", template.Slug, newInstance.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// cloneRolesAndPermissions copies the organization roles & permissions of
+// the source instance, along with the permissions of each role. Roles and
+// permissions the new instance already has, like the default ones, are left
+// as is.
+func (s *Service) cloneRolesAndPermissions(ctx context.Context, tx database.Tx, source, newInstance *model.Instance) error {
+	existingPermissions, err := s.permissionRepo.FindAllByInstance(ctx, tx, newInstance.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching permissions of instance %s: %w", newInstance.ID, err)
+	}
+	permissionIDsByKey := make(map[string]string, len(existingPermissions))
+	for _, permission := range existingPermissions {
+		permissionIDsByKey[permission.Key] = permission.ID
+	}
+
+	sourcePermissions, err := s.permissionRepo.FindAllByInstance(ctx, tx, source.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching permissions of instance %s: %w", source.ID, err)
+	}
+	for _, permission := range sourcePermissions {
+		if _, exists := permissionIDsByKey[permission.Key]; exists {
+			continue
+		}
+		newPermission := &model.Permission{Permission: &sqbmodel.Permission{
+			InstanceID:  newInstance.ID,
+			Name:        permission.Name,
+			Key:         permission.Key,
+			Description: permission.Description,
+			Type:        permission.Type,
+		}}
+		if err := s.permissionRepo.Insert(ctx, tx, newPermission); err != nil {
+			return fmt.Errorf("clone: inserting permission %s for instance %s: %w", permission.Key, newInstance.ID, err)
+		}
+		permissionIDsByKey[newPermission.Key] = newPermission.ID
+	}
+
+	existingRoles, err := s.roleRepo.FindAllByInstance(ctx, tx, newInstance.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching roles of instance %s: %w", newInstance.ID, err)
+	}
+	existingRoleKeys := make(map[string]bool, len(existingRoles))
+	for _, role := range existingRoles {
+		existingRoleKeys[role.Key] = true
+	}
+
+	sourceRoles, err := s.roleRepo.FindAllByInstance(ctx, tx, source.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching roles of instance %s: %w", source.ID, err)
+	}
+	for _, role := range sourceRoles {
+		if existingRoleKeys[role.Key] {
+			continue
+		}
+		newRole := &model.Role{Role: &sqbmodel.Role{
+			InstanceID:  newInstance.ID,
+			Name:        role.Name,
+			Key:         role.Key,
+			Description: role.Description,
+		}}
+		if err := s.roleRepo.Insert(ctx, tx, newRole); err != nil {
+			return fmt.Errorf("clone: inserting role %s for instance %s: %w", role.Key, newInstance.ID, err)
+		}
+
+		rolePermissions, err := s.permissionRepo.FindAllByRole(ctx, tx, role.ID)
+		if err != nil {
+			return fmt.Errorf("clone: fetching permissions of role %s: %w", role.ID, err)
+		}
+		if len(rolePermissions) == 0 {
+			continue
+		}
+		newRolePermissions := cloneRolePermissions(newInstance.ID, newRole.ID, rolePermissions, permissionIDsByKey)
+		if err := s.rolePermissionRepo.InsertBulk(ctx, tx, newRolePermissions); err != nil {
+			return fmt.Errorf("clone: inserting permissions of role %s for instance %s: %w", role.Key, newInstance.ID, err)
+		}
+	}
+	return nil
+}
+
+// cloneRolePermissions returns the permissions of a cloned role. Permissions
+// are matched by key, as the ones of the new instance have different IDs than
+// the ones of the source instance.
+func cloneRolePermissions(instanceID, roleID string, permissions []*model.Permission, permissionIDsByKey map[string]string) []*model.RolePermission {
+	rolePermissions := make([]*model.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		rolePermissions = append(rolePermissions, &model.RolePermission{RolePermission: &sqbmodel.RolePermission{
+			InstanceID:   instanceID,
+			RoleID:       roleID,
+			PermissionID: permissionIDsByKey[permission.Key],
+		}})
+	}
+	return rolePermissions
+}
+
+func (s *Service) cloneRedirectURLs(ctx context.Context, tx database.Tx, source, newInstance *model.Instance) error {
+	redirectURLs, err := s.redirectURLRepo.FindAllByInstance(ctx, tx, source.ID)
+	if err != nil {
+		return fmt.Errorf("clone: fetching redirect urls of instance %s: %w", source.ID, err)
+	}
+
+	for _, redirectURL := range redirectURLs {
+		newRedirectURL := &model.RedirectURL{RedirectURL: &sqbmodel.RedirectURL{
+			InstanceID: newInstance.ID,
+			URL:        redirectURL.URL,
+		}}
+		if err := s.redirectURLRepo.Insert(ctx, tx, newRedirectURL); err != nil {
+			return fmt.Errorf("clone: inserting redirect url %s for instance %s: %w", redirectURL.URL, newInstance.ID, err)
+		}
+	}
+	return nil
+}
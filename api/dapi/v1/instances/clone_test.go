@@ -0,0 +1,37 @@
+package instances
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloneRolePermissions(t *testing.T) {
+	t.Parallel()
+
+	// the permissions of the role in the source instance
+	permissions := []*model.Permission{
+		{Permission: &sqbmodel.Permission{ID: "perm_src_1", InstanceID: "ins_src", Key: "org:sys_profile:read"}},
+		{Permission: &sqbmodel.Permission{ID: "perm_src_2", InstanceID: "ins_src", Key: "org:invoices:read"}},
+	}
+	// the permissions of the new instance, default or cloned
+	permissionIDsByKey := map[string]string{
+		"org:sys_profile:read": "perm_new_1",
+		"org:invoices:read":    "perm_new_2",
+		"org:invoices:write":   "perm_new_3",
+	}
+
+	rolePermissions := cloneRolePermissions("ins_new", "role_new", permissions, permissionIDsByKey)
+	require.Len(t, rolePermissions, 2)
+	for i, wantPermissionID := range []string{"perm_new_1", "perm_new_2"} {
+		assert.Equal(t, "ins_new", rolePermissions[i].InstanceID)
+		assert.Equal(t, "role_new", rolePermissions[i].RoleID)
+		assert.Equal(t, wantPermissionID, rolePermissions[i].PermissionID)
+	}
+
+	assert.Empty(t, cloneRolePermissions("ins_new", "role_new", nil, permissionIDsByKey))
+}
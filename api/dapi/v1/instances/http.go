@@ -94,6 +94,11 @@ func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	return h.service.Read(r.Context(), instanceID)
 }
 
+// POST /instances/{instanceID}/clone
+func (h *HTTP) Clone(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Clone(r.Context())
+}
+
 // DELETE /instances/{instanceID}
 func (h *HTTP) Delete(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	instanceID := chi.URLParam(r, "instanceID")
@@ -154,7 +159,17 @@ func (h *HTTP) UpdateSettings(w http.ResponseWriter, r *http.Request) (interface
 }
 
 type updateCommunicationParams struct {
-	BlockedCountryCodes *[]string `json:"blocked_country_codes" form:"blocked_country_codes"`
+	BlockedCountryCodes         *[]string `json:"blocked_country_codes" form:"blocked_country_codes"`
+	PhoneNumberAllowedCountries *[]string `json:"phone_number_allowed_countries" form:"phone_number_allowed_countries"`
+	PhoneNumberDefaultRegion    *string   `json:"phone_number_default_region" form:"phone_number_default_region"`
+	PhoneNumberFormat           *string   `json:"phone_number_format" form:"phone_number_format"`
+	PhoneCodeChannel            *string   `json:"phone_code_channel" form:"phone_code_channel"`
+	WhatsAppProvider            *string   `json:"whatsapp_provider" form:"whatsapp_provider"`
+	WhatsAppTemplate            *string   `json:"whatsapp_template" form:"whatsapp_template"`
+	WhatsAppTemplateLanguage    *string   `json:"whatsapp_template_language" form:"whatsapp_template_language"`
+	PhoneLookupProvider         *string   `json:"phone_lookup_provider" form:"phone_lookup_provider"`
+	PhoneLookupBlockedLineTypes *[]string `json:"phone_lookup_blocked_line_types" form:"phone_lookup_blocked_line_types"`
+	EmailProvider               *string   `json:"email_provider" form:"email_provider"`
 }
 
 // PATCH /instances/{instanceID}/communication
@@ -174,6 +189,107 @@ func (h *HTTP) UpdateCommunication(w http.ResponseWriter, r *http.Request) (inte
 	return nil, nil
 }
 
+type updateAttestationParams struct {
+	Enforcement string `json:"enforcement" form:"enforcement"`
+}
+
+// PATCH /instances/{instanceID}/attestation
+func (h *HTTP) UpdateAttestation(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params updateAttestationParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	apiErr := h.service.UpdateAttestation(r.Context(), params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+type updateBackchannelLogoutParams struct {
+	URIs []string `json:"uris" form:"uris"`
+}
+
+// PATCH /instances/{instanceID}/backchannel_logout
+func (h *HTTP) UpdateBackchannelLogout(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params updateBackchannelLogoutParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	apiErr := h.service.UpdateBackchannelLogout(r.Context(), params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+type updateMaintenanceModeParams struct {
+	Enabled bool    `json:"enabled" form:"enabled"`
+	Banner  *string `json:"banner" form:"banner"`
+}
+
+// PATCH /instances/{instanceID}/maintenance
+func (h *HTTP) UpdateMaintenanceMode(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params updateMaintenanceModeParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	apiErr := h.service.UpdateMaintenanceMode(r.Context(), params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
+type testBackchannelLogoutParams struct {
+	URI string `json:"uri" form:"uri"`
+}
+
+// POST /instances/{instanceID}/backchannel_logout/test
+func (h *HTTP) TestBackchannelLogout(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params testBackchannelLogoutParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	return h.service.TestBackchannelLogout(r.Context(), params)
+}
+
+// POST /instances/{instanceID}/metrics_token
+func (h *HTTP) CreateMetricsToken(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	response, apiErr := h.service.CreateMetricsToken(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	return response, nil
+}
+
+// DELETE /instances/{instanceID}/metrics_token
+func (h *HTTP) RevokeMetricsToken(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	apiErr := h.service.RevokeMetricsToken(r.Context())
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
+
 // POST /instances/{instanceID}/change_domain
 func (h *HTTP) UpdateHomeURL(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	type updateHomeURLParams struct {
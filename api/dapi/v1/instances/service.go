@@ -13,11 +13,17 @@ import (
 	"clerk/api/dapi/v1/domains"
 	sharedserialize "clerk/api/serialize"
 	shapplications "clerk/api/shared/applications"
+	"clerk/api/shared/attestation"
+	"clerk/api/shared/backchannel_logout"
 	shdomains "clerk/api/shared/domains"
 	"clerk/api/shared/edgereplication"
 	shenvironment "clerk/api/shared/environment"
 	"clerk/api/shared/features"
+	"clerk/api/shared/instance_metrics"
 	"clerk/api/shared/instances"
+	"clerk/api/shared/phone_lookup"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/whatsapp"
 	"clerk/model"
 	"clerk/model/sqbmodel_extensions"
 	"clerk/pkg/apiversioning"
@@ -25,7 +31,9 @@ import (
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/emailprovider"
 	"clerk/pkg/externalapis/clerkimages"
+	"clerk/pkg/externalapis/phonelookup"
 	"clerk/pkg/externalapis/segment"
 	"clerk/pkg/externalapis/svix"
 	"clerk/pkg/generate"
@@ -37,6 +45,7 @@ import (
 	sentryclerk "clerk/pkg/sentry"
 	"clerk/pkg/set"
 	"clerk/pkg/validators"
+	clerkwhatsapp "clerk/pkg/whatsapp"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
@@ -62,13 +71,15 @@ type Service struct {
 	clerkImagesClient *clerkimages.Client
 
 	// services
-	applicationService     *shapplications.Service
-	envService             *shenvironment.Service
-	featureService         *features.Service
-	domainService          *domains.Service
-	sharedDomainService    *shdomains.Service
-	instanceService        *instances.Service
-	edgeReplicationService *edgereplication.Service
+	applicationService       *shapplications.Service
+	backchannelLogoutService *backchannel_logout.Service
+	envService               *shenvironment.Service
+	featureService           *features.Service
+	domainService            *domains.Service
+	sharedDomainService      *shdomains.Service
+	instanceService          *instances.Service
+	instanceMetricsService   *instance_metrics.Service
+	edgeReplicationService   *edgereplication.Service
 
 	// repositories
 	appRepo                *repository.Applications
@@ -79,41 +90,55 @@ type Service struct {
 	imageRepo              *repository.Images
 	instanceRepo           *repository.Instances
 	instanceKeysRepo       *repository.InstanceKeys
+	oauthConfigRepo        *repository.OauthConfig
+	permissionRepo         *repository.Permission
+	redirectURLRepo        *repository.RedirectUrls
+	roleRepo               *repository.Role
+	rolePermissionRepo     *repository.RolePermission
 	smsCountryTierRepo     *repository.SMSCountryTiers
 	subscriptionRepo       *repository.Subscriptions
 	subscriptionPlansRepo  *repository.SubscriptionPlans
+	templateRepo           *repository.Templates
 	userRepo               *repository.Users
 	displayConfigRepo      *repository.DisplayConfig
 }
 
 func NewService(deps clerk.Deps, svixClient *svix.Client, clerkImagesClient *clerkimages.Client, sdkConfigConstructor sdkutils.ConfigConstructor) *Service {
 	return &Service{
-		clock:                  deps.Clock(),
-		db:                     deps.DB(),
-		gueClient:              deps.GueClient(),
-		sdkConfigConstructor:   sdkConfigConstructor,
-		svixClient:             svixClient,
-		clerkImagesClient:      clerkImagesClient,
-		applicationService:     shapplications.NewService(),
-		envService:             shenvironment.NewService(),
-		featureService:         features.NewService(deps.DB(), deps.GueClient()),
-		domainService:          domains.NewService(deps, sdkConfigConstructor),
-		sharedDomainService:    shdomains.NewService(deps),
-		instanceService:        instances.NewService(deps.DB(), deps.GueClient()),
-		edgeReplicationService: edgereplication.NewService(deps.GueClient(), cenv.GetBool(cenv.FlagReplicateInstanceToEdgeJobsEnabled)),
-		appRepo:                repository.NewApplications(),
-		authConfigRepo:         repository.NewAuthConfig(),
-		dnsChecksRepo:          repository.NewDNSChecks(),
-		domainRepo:             repository.NewDomain(),
-		enabledSSOProviderRepo: repository.NewEnabledSSOProviders(),
-		imageRepo:              repository.NewImages(),
-		instanceRepo:           repository.NewInstances(),
-		instanceKeysRepo:       repository.NewInstanceKeys(),
-		smsCountryTierRepo:     repository.NewSMSCountryTiers(),
-		subscriptionRepo:       repository.NewSubscriptions(),
-		subscriptionPlansRepo:  repository.NewSubscriptionPlans(),
-		userRepo:               repository.NewUsers(),
-		displayConfigRepo:      repository.NewDisplayConfig(),
+		clock:                    deps.Clock(),
+		db:                       deps.DB(),
+		gueClient:                deps.GueClient(),
+		sdkConfigConstructor:     sdkConfigConstructor,
+		svixClient:               svixClient,
+		clerkImagesClient:        clerkImagesClient,
+		applicationService:       shapplications.NewService(),
+		backchannelLogoutService: backchannel_logout.NewService(deps),
+		envService:               shenvironment.NewService(),
+		featureService:           features.NewService(deps.DB(), deps.GueClient()),
+		domainService:            domains.NewService(deps, sdkConfigConstructor),
+		sharedDomainService:      shdomains.NewService(deps),
+		instanceService:          instances.NewService(deps.DB(), deps.GueClient()),
+		instanceMetricsService:   instance_metrics.NewService(deps),
+		edgeReplicationService:   edgereplication.NewService(deps.GueClient(), cenv.GetBool(cenv.FlagReplicateInstanceToEdgeJobsEnabled)),
+		appRepo:                  deps.Repositories().Applications,
+		authConfigRepo:           deps.Repositories().AuthConfig,
+		dnsChecksRepo:            deps.Repositories().DNSChecks,
+		domainRepo:               deps.Repositories().Domain,
+		enabledSSOProviderRepo:   deps.Repositories().EnabledSSOProviders,
+		imageRepo:                deps.Repositories().Images,
+		instanceRepo:             deps.Repositories().Instances,
+		instanceKeysRepo:         deps.Repositories().InstanceKeys,
+		oauthConfigRepo:          deps.Repositories().OauthConfig,
+		permissionRepo:           deps.Repositories().Permission,
+		redirectURLRepo:          deps.Repositories().RedirectUrls,
+		roleRepo:                 deps.Repositories().Role,
+		rolePermissionRepo:       deps.Repositories().RolePermission,
+		smsCountryTierRepo:       deps.Repositories().SMSCountryTiers,
+		subscriptionRepo:         deps.Repositories().Subscriptions,
+		subscriptionPlansRepo:    deps.Repositories().SubscriptionPlans,
+		templateRepo:             deps.Repositories().Templates,
+		userRepo:                 deps.Repositories().Users,
+		displayConfigRepo:        deps.Repositories().DisplayConfig,
 	}
 }
 
@@ -469,6 +494,271 @@ func (s *Service) UpdateCommunication(ctx context.Context, params updateCommunic
 		}
 	}
 
+	if params.PhoneNumberAllowedCountries != nil || params.PhoneNumberDefaultRegion != nil || params.PhoneNumberFormat != nil {
+		apiErr := s.updatePhoneNumberProfile(ctx, env.Instance, params)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
+	if params.PhoneCodeChannel != nil || params.WhatsAppProvider != nil || params.WhatsAppTemplate != nil || params.WhatsAppTemplateLanguage != nil {
+		apiErr := s.updatePhoneCodeChannel(ctx, env.Instance, params)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
+	if params.PhoneLookupProvider != nil || params.PhoneLookupBlockedLineTypes != nil {
+		apiErr := s.updatePhoneLookup(ctx, env.Instance, params)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
+	if params.EmailProvider != nil {
+		apiErr := s.updateEmailProvider(ctx, env.Instance, *params.EmailProvider)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
+	return nil
+}
+
+var (
+	phoneCodeChannels = []string{whatsapp.ChannelSMS, whatsapp.ChannelWhatsApp}
+	whatsAppProviders = []string{clerkwhatsapp.ProviderTwilio, clerkwhatsapp.ProviderMeta}
+)
+
+// updatePhoneCodeChannel updates the channel over which one-time codes are
+// sent to phone numbers of the instance, along with the approved WhatsApp
+// template they're sent with. Empty values reset the WhatsApp settings.
+func (s *Service) updatePhoneCodeChannel(ctx context.Context, instance *model.Instance, params updateCommunicationParams) apierror.Error {
+	if params.PhoneCodeChannel != nil {
+		if !slices.Contains(phoneCodeChannels, *params.PhoneCodeChannel) {
+			return apierror.FormInvalidParameterValueWithAllowed("phone_code_channel", *params.PhoneCodeChannel, phoneCodeChannels)
+		}
+		instance.Communication.PhoneCodeChannel = null.StringFrom(*params.PhoneCodeChannel)
+	}
+
+	if params.WhatsAppProvider != nil {
+		if *params.WhatsAppProvider == "" {
+			instance.Communication.WhatsAppProvider = null.StringFromPtr(nil)
+		} else if !slices.Contains(whatsAppProviders, *params.WhatsAppProvider) {
+			return apierror.FormInvalidParameterValueWithAllowed("whatsapp_provider", *params.WhatsAppProvider, whatsAppProviders)
+		} else {
+			instance.Communication.WhatsAppProvider = null.StringFrom(*params.WhatsAppProvider)
+		}
+	}
+
+	if params.WhatsAppTemplate != nil {
+		instance.Communication.WhatsAppTemplate = null.NewString(*params.WhatsAppTemplate, *params.WhatsAppTemplate != "")
+	}
+	if params.WhatsAppTemplateLanguage != nil {
+		instance.Communication.WhatsAppTemplateLanguage = null.NewString(*params.WhatsAppTemplateLanguage, *params.WhatsAppTemplateLanguage != "")
+	}
+
+	// WhatsApp only allows approved templates, so codes can't be sent
+	// without one
+	if instance.Communication.PhoneCodeChannel.String == whatsapp.ChannelWhatsApp && !instance.Communication.WhatsAppTemplate.Valid {
+		return apierror.FormMissingParameter("whatsapp_template")
+	}
+
+	err := s.instanceRepo.UpdateCommunication(ctx, s.db, instance)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// updateEmailProvider updates the provider the emails of the instance are
+// sent through. The other configured providers are still failed over to.
+// An empty provider resets it to the default.
+func (s *Service) updateEmailProvider(ctx context.Context, instance *model.Instance, provider string) apierror.Error {
+	if provider == "" {
+		instance.Communication.EmailProvider = null.StringFromPtr(nil)
+	} else if !slices.Contains(emailprovider.Providers, provider) {
+		return apierror.FormInvalidParameterValueWithAllowed("email_provider", provider, emailprovider.Providers)
+	} else {
+		instance.Communication.EmailProvider = null.StringFrom(provider)
+	}
+
+	err := s.instanceRepo.UpdateCommunication(ctx, s.db, instance)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// updatePhoneLookup updates the carrier lookup provider phone numbers of the
+// instance are checked with before one-time codes are sent to them, and the
+// line types which are rejected. An empty provider turns lookups off.
+func (s *Service) updatePhoneLookup(ctx context.Context, instance *model.Instance, params updateCommunicationParams) apierror.Error {
+	if params.PhoneLookupProvider != nil {
+		if *params.PhoneLookupProvider == "" {
+			instance.Communication.PhoneLookupProvider = null.StringFromPtr(nil)
+		} else if !slices.Contains(phone_lookup.Providers, *params.PhoneLookupProvider) {
+			return apierror.FormInvalidParameterValueWithAllowed("phone_lookup_provider", *params.PhoneLookupProvider, phone_lookup.Providers)
+		} else {
+			instance.Communication.PhoneLookupProvider = null.StringFrom(*params.PhoneLookupProvider)
+		}
+	}
+
+	if params.PhoneLookupBlockedLineTypes != nil {
+		blockedLineTypes := make([]string, 0, len(*params.PhoneLookupBlockedLineTypes))
+		for _, lineType := range *params.PhoneLookupBlockedLineTypes {
+			if !slices.Contains(phonelookup.LineTypes, lineType) {
+				return apierror.FormInvalidParameterValueWithAllowed("phone_lookup_blocked_line_types", lineType, phonelookup.LineTypes)
+			}
+			blockedLineTypes = append(blockedLineTypes, lineType)
+		}
+		slices.Sort(blockedLineTypes)
+		instance.Communication.PhoneLookupBlockedLineTypes = slices.Compact(blockedLineTypes)
+	}
+
+	err := s.instanceRepo.UpdateCommunication(ctx, s.db, instance)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// updatePhoneNumberProfile updates the phone number validation profile of
+// the instance. Phone numbers that are already stored are not affected, the
+// profile only applies to phone numbers added from now on.
+func (s *Service) updatePhoneNumberProfile(ctx context.Context, instance *model.Instance, params updateCommunicationParams) apierror.Error {
+	countryCodes, err := s.smsCountryTierRepo.CountryCodes(ctx, s.db)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	countryCodeSet := set.New(countryCodes...)
+
+	if params.PhoneNumberAllowedCountries != nil {
+		allowedCountries := make([]string, 0, len(*params.PhoneNumberAllowedCountries))
+		for _, country := range *params.PhoneNumberAllowedCountries {
+			if !countryCodeSet.Contains(country) {
+				return apierror.FormInvalidParameterValue("phone_number_allowed_countries", country)
+			}
+			allowedCountries = append(allowedCountries, country)
+		}
+		slices.Sort(allowedCountries)
+		instance.Communication.PhoneNumberAllowedCountries = slices.Compact(allowedCountries)
+	}
+
+	if params.PhoneNumberDefaultRegion != nil {
+		if *params.PhoneNumberDefaultRegion == "" {
+			instance.Communication.PhoneNumberDefaultRegion = null.StringFromPtr(nil)
+		} else if !countryCodeSet.Contains(*params.PhoneNumberDefaultRegion) {
+			return apierror.FormInvalidParameterValue("phone_number_default_region", *params.PhoneNumberDefaultRegion)
+		} else {
+			instance.Communication.PhoneNumberDefaultRegion = null.StringFrom(*params.PhoneNumberDefaultRegion)
+		}
+	}
+
+	if params.PhoneNumberFormat != nil {
+		if !phone_profiles.IsValidFormat(*params.PhoneNumberFormat) {
+			return apierror.FormInvalidParameterValueWithAllowed("phone_number_format", *params.PhoneNumberFormat, phone_profiles.Formats)
+		}
+		instance.Communication.PhoneNumberFormat = null.StringFrom(*params.PhoneNumberFormat)
+	}
+
+	err = s.instanceRepo.UpdateCommunication(ctx, s.db, instance)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// UpdateAttestation updates the device attestation enforcement level of the
+// instance. Clients which were already created keep their attestation status.
+func (s *Service) UpdateAttestation(ctx context.Context, params updateAttestationParams) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	if !attestation.IsValidEnforcement(params.Enforcement) {
+		return apierror.FormInvalidParameterValueWithAllowed("enforcement", params.Enforcement, attestation.Enforcements)
+	}
+
+	env.Instance.AttestationEnforcement = params.Enforcement
+	err := s.instanceRepo.UpdateAttestationEnforcement(ctx, s.db, env.Instance)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// UpdateBackchannelLogout replaces the backchannel logout URIs of the
+// instance, which are notified whenever a session of the instance is revoked.
+// An empty list turns backchannel logout off.
+func (s *Service) UpdateBackchannelLogout(ctx context.Context, params updateBackchannelLogoutParams) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	if apiErr := backchannel_logout.ValidateURIs("uris", params.URIs); apiErr != nil {
+		return apiErr
+	}
+
+	err := s.backchannelLogoutService.UpdateURIs(ctx, s.db, env.Instance, params.URIs)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+// UpdateMaintenanceMode puts the instance under maintenance, or brings it
+// back. While under maintenance, FAPI rejects everything apart from reads
+// and session token refreshes.
+func (s *Service) UpdateMaintenanceMode(ctx context.Context, params updateMaintenanceModeParams) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	if apiErr := instances.ValidateMaintenanceBanner("banner", params.Banner); apiErr != nil {
+		return apiErr
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.instanceService.UpdateMaintenanceMode(ctx, tx, env.Instance, params.Enabled, params.Banner)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return apierror.Unexpected(txErr)
+	}
+	return nil
+}
+
+// TestBackchannelLogout sends a logout token for a made up session to the
+// given URI and reports how the receiver responded. The URI doesn't have to
+// be configured for the instance yet.
+func (s *Service) TestBackchannelLogout(ctx context.Context, params testBackchannelLogoutParams) (*serialize.BackchannelLogoutTestResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := backchannel_logout.ValidateURIs("uri", []string{params.URI}); apiErr != nil {
+		return nil, apiErr
+	}
+
+	result, err := s.backchannelLogoutService.Test(ctx, env.Instance, params.URI)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.BackchannelLogoutTest(result), nil
+}
+
+// CreateMetricsToken generates a new token for scraping the metrics of the
+// instance. Any previous token stops working immediately.
+func (s *Service) CreateMetricsToken(ctx context.Context) (*serialize.InstanceMetricsTokenResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	token, err := s.instanceMetricsService.CreateToken(ctx, s.db, env.Instance)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.InstanceMetricsToken(token), nil
+}
+
+// RevokeMetricsToken disables metrics scraping for the instance.
+func (s *Service) RevokeMetricsToken(ctx context.Context) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	if err := s.instanceMetricsService.RevokeToken(ctx, s.db, env.Instance); err != nil {
+		return apierror.Unexpected(err)
+	}
 	return nil
 }
 
@@ -45,10 +45,10 @@ type Service struct {
 func NewService(deps clerk.Deps, vercelClient *vercel.Client, jwksClient *jwks.Client) *Service {
 	return &Service{
 		deps:               deps,
-		appRepo:            repository.NewApplications(),
-		appIntegrationRepo: repository.NewApplicationIntegrations(),
-		appOwnershipRepo:   repository.NewApplicationOwnerships(),
-		integrationRepo:    repository.NewIntegrations(),
+		appRepo:            deps.Repositories().Applications,
+		appIntegrationRepo: deps.Repositories().ApplicationIntegrations,
+		appOwnershipRepo:   deps.Repositories().ApplicationOwnerships,
+		integrationRepo:    deps.Repositories().Integrations,
 		clientService:      clients.NewService(deps, jwksClient),
 		vercelClient:       vercelClient,
 	}
@@ -0,0 +1,40 @@
+package ip_restriction_rules
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/ip_restriction_rules
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.List(r.Context())
+}
+
+// POST /instances/{instanceID}/ip_restriction_rules
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.Create(r.Context(), params)
+}
+
+// DELETE /instances/{instanceID}/ip_restriction_rules/{ruleID}
+func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Delete(r.Context(), chi.URLParam(r, "ruleID"))
+}
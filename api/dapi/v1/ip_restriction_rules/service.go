@@ -0,0 +1,110 @@
+package ip_restriction_rules
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/dapi/serialize"
+	sharedserialize "clerk/api/serialize"
+	"clerk/api/shared/restrictions"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/ctx/environment"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type Service struct {
+	db database.Database
+
+	// services
+	restrictionService *restrictions.Service
+
+	// repositories
+	ipRestrictionRuleRepo *repository.IPRestrictionRules
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                    deps.DB(),
+		restrictionService:    restrictions.NewService(deps),
+		ipRestrictionRuleRepo: deps.Repositories().IPRestrictionRules,
+	}
+}
+
+// List returns all IP restriction rules of the instance.
+func (s *Service) List(ctx context.Context) ([]*serialize.IPRestrictionRuleResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	rules, err := s.ipRestrictionRuleRepo.FindAllByInstance(ctx, s.db, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.IPRestrictionRuleResponse, len(rules))
+	for i, rule := range rules {
+		responses[i] = serialize.IPRestrictionRule(rule)
+	}
+	return responses, nil
+}
+
+type CreateParams struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (p CreateParams) normalize() (CreateParams, apierror.Error) {
+	if !restrictions.IPRuleTypes.Contains(p.Type) {
+		return p, apierror.FormInvalidParameterValueWithAllowed("type", p.Type, restrictions.IPRuleTypes.Array())
+	}
+	value, err := restrictions.NormalizeIPRuleValue(p.Type, p.Value)
+	if err != nil {
+		return p, apierror.FormInvalidParameterValue("value", p.Value)
+	}
+	p.Value = value
+	return p, nil
+}
+
+// Create adds a rule which blocks sign-ins and sign-ups coming from the given
+// IP range, autonomous system or country.
+func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.IPRestrictionRuleResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	params, apiErr := params.normalize()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	rule := &model.IPRestrictionRule{IPRestrictionRule: &sqbmodel.IPRestrictionRule{
+		InstanceID: env.Instance.ID,
+		RuleType:   params.Type,
+		Value:      params.Value,
+	}}
+	err := s.ipRestrictionRuleRepo.Insert(ctx, s.db, rule)
+	if clerkerrors.IsUniqueConstraintViolation(err, clerkerrors.UniqueIPRestrictionRule) {
+		return nil, apierror.DuplicateIPRestrictionRule(params.Type, params.Value)
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	s.restrictionService.ClearIPRulesCache(ctx, env.Instance.ID)
+	return serialize.IPRestrictionRule(rule), nil
+}
+
+// Delete removes the given rule of the instance.
+func (s *Service) Delete(ctx context.Context, ruleID string) (*sharedserialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	deleted, err := s.ipRestrictionRuleRepo.DeleteByIDAndInstance(ctx, s.db, ruleID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if deleted == 0 {
+		return nil, apierror.IPRestrictionRuleNotFound(ruleID)
+	}
+
+	s.restrictionService.ClearIPRulesCache(ctx, env.Instance.ID)
+	return sharedserialize.DeletedObject(ruleID, serialize.ObjectIPRestrictionRule), nil
+}
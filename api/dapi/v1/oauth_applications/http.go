@@ -0,0 +1,64 @@
+package oauth_applications
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/oauth_applications
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	return h.service.List(r.Context(), paginationParams)
+}
+
+// POST /instances/{instanceID}/oauth_applications
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.Create(r.Context(), params)
+}
+
+// GET /instances/{instanceID}/oauth_applications/{oauthApplicationID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Read(r.Context(), chi.URLParam(r, "oauthApplicationID"))
+}
+
+// PATCH /instances/{instanceID}/oauth_applications/{oauthApplicationID}
+func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params UpdateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.Update(r.Context(), chi.URLParam(r, "oauthApplicationID"), params)
+}
+
+// DELETE /instances/{instanceID}/oauth_applications/{oauthApplicationID}
+func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Delete(r.Context(), chi.URLParam(r, "oauthApplicationID"))
+}
+
+// POST /instances/{instanceID}/oauth_applications/{oauthApplicationID}/rotate_secret
+func (h *HTTP) RotateSecret(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.RotateSecret(r.Context(), chi.URLParam(r, "oauthApplicationID"))
+}
@@ -0,0 +1,193 @@
+package oauth_applications
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/oauth_applications"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctx/validator"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+const (
+	paramCallbackURL = "callback_url"
+	paramScopes      = "scopes"
+)
+
+type Service struct {
+	db database.Database
+
+	// services
+	oauthApplicationService *oauth_applications.Service
+
+	// repositories
+	oauthApplicationsRepo *repository.OAuthApplications
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                      deps.DB(),
+		oauthApplicationService: oauth_applications.NewService(deps),
+		oauthApplicationsRepo:   deps.Repositories().OAuthApplications,
+	}
+}
+
+// List returns a page of the OAuth applications which use the instance as
+// their identity provider.
+func (s *Service) List(ctx context.Context, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	oauthApplications, err := s.oauthApplicationsRepo.FindAllByInstance(ctx, s.db, env.Instance.ID, paginationParams)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	totalCount, err := s.oauthApplicationsRepo.CountByInstance(ctx, s.db, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]interface{}, len(oauthApplications))
+	for i, oa := range oauthApplications {
+		responses[i] = serialize.OAuthApplication(oa, env.Domain)
+	}
+	return serialize.Paginated(responses, totalCount), nil
+}
+
+type CreateParams struct {
+	Name                 string `json:"name" validate:"required,max=256"`
+	CallbackURL          string `json:"callback_url" validate:"required,max=1024"`
+	Public               bool   `json:"public"`
+	Scopes               string `json:"scopes" validate:"max=1024"`
+	ConsentScreenEnabled bool   `json:"consent_screen_enabled"`
+}
+
+// Create registers a new OAuth application for the instance. The response
+// is the only one which includes the client secret.
+func (s *Service) Create(ctx context.Context, params CreateParams) (*serialize.OAuthApplicationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	validate := validator.FromContext(ctx)
+	if err := validate.Struct(params); err != nil {
+		return nil, apierror.FormValidationFailed(err)
+	}
+	if apiErr := oauth_applications.ValidateCallbackURL(paramCallbackURL, params.CallbackURL); apiErr != nil {
+		return nil, apiErr
+	}
+	if apiErr := oauth_applications.ValidateScopes(paramScopes, params.Scopes); apiErr != nil {
+		return nil, apiErr
+	}
+
+	oa, err := s.oauthApplicationService.Create(ctx, s.db, env.Instance.ID, oauth_applications.CreateParams{
+		Name:                 params.Name,
+		CallbackURL:          params.CallbackURL,
+		Scopes:               params.Scopes,
+		Public:               params.Public,
+		ConsentScreenEnabled: params.ConsentScreenEnabled,
+	})
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.OAuthApplication(oa, env.Domain), nil
+}
+
+// Read returns the OAuth application of the instance with the given id.
+func (s *Service) Read(ctx context.Context, oauthApplicationID string) (*serialize.OAuthApplicationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	oa, apiErr := s.fetch(ctx, env.Instance.ID, oauthApplicationID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.OAuthApplication(oa, env.Domain), nil
+}
+
+type UpdateParams struct {
+	Name                 *string `json:"name" validate:"omitempty,max=256"`
+	CallbackURL          *string `json:"callback_url" validate:"omitempty,max=1024"`
+	Scopes               *string `json:"scopes" validate:"omitempty,max=1024"`
+	ConsentScreenEnabled *bool   `json:"consent_screen_enabled"`
+}
+
+// Update changes the given attributes of the OAuth application.
+func (s *Service) Update(ctx context.Context, oauthApplicationID string, params UpdateParams) (*serialize.OAuthApplicationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	validate := validator.FromContext(ctx)
+	if err := validate.Struct(params); err != nil {
+		return nil, apierror.FormValidationFailed(err)
+	}
+	updateParams := oauth_applications.UpdateParams{ConsentScreenEnabled: params.ConsentScreenEnabled}
+	if params.Name != nil {
+		updateParams.Name = *params.Name
+	}
+	if params.CallbackURL != nil {
+		if apiErr := oauth_applications.ValidateCallbackURL(paramCallbackURL, *params.CallbackURL); apiErr != nil {
+			return nil, apiErr
+		}
+		updateParams.CallbackURL = *params.CallbackURL
+	}
+	if params.Scopes != nil {
+		if apiErr := oauth_applications.ValidateScopes(paramScopes, *params.Scopes); apiErr != nil {
+			return nil, apiErr
+		}
+		updateParams.Scopes = *params.Scopes
+	}
+
+	oa, apiErr := s.fetch(ctx, env.Instance.ID, oauthApplicationID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := s.oauthApplicationService.Update(ctx, s.db, oa, updateParams); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.OAuthApplication(oa, env.Domain), nil
+}
+
+// Delete removes the OAuth application, which invalidates its credentials.
+func (s *Service) Delete(ctx context.Context, oauthApplicationID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	rowsAff, err := s.oauthApplicationsRepo.DeleteByIDAndInstance(ctx, s.db, oauthApplicationID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if rowsAff == 0 {
+		return nil, apierror.ResourceNotFound()
+	}
+	return serialize.DeletedObject(oauthApplicationID, serialize.ObjectOAuthApplication), nil
+}
+
+// RotateSecret replaces the client secret of the OAuth application. The
+// response includes the new secret.
+func (s *Service) RotateSecret(ctx context.Context, oauthApplicationID string) (*serialize.OAuthApplicationResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	oa, apiErr := s.fetch(ctx, env.Instance.ID, oauthApplicationID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := s.oauthApplicationService.RotateSecret(ctx, s.db, oa); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.OAuthApplication(oa, env.Domain), nil
+}
+
+func (s *Service) fetch(ctx context.Context, instanceID, oauthApplicationID string) (*model.OAuthApplication, apierror.Error) {
+	oa, err := s.oauthApplicationsRepo.QueryByIDAndInstance(ctx, s.db, oauthApplicationID, instanceID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if oa == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	return oa, nil
+}
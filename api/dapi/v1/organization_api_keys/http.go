@@ -0,0 +1,52 @@
+package organization_api_keys
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/organizations/{organizationID}/api_keys
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.List(r.Context(), chi.URLParam(r, "organizationID"))
+}
+
+// POST /instances/{instanceID}/organizations/{organizationID}/api_keys
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params CreateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	return h.service.Create(r.Context(), chi.URLParam(r, "organizationID"), params)
+}
+
+// POST /instances/{instanceID}/organizations/{organizationID}/api_keys/{apiKeyID}/rotate
+func (h *HTTP) Rotate(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Rotate(r.Context(), chi.URLParam(r, "organizationID"), chi.URLParam(r, "apiKeyID"))
+}
+
+// DELETE /instances/{instanceID}/organizations/{organizationID}/api_keys/{apiKeyID}
+func (h *HTTP) Delete(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	err := h.service.Delete(r.Context(), chi.URLParam(r, "organizationID"), chi.URLParam(r, "apiKeyID"))
+	if err != nil {
+		return nil, err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, nil
+}
@@ -0,0 +1,158 @@
+package organization_api_keys
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/dapi/serialize"
+	"clerk/api/shared/organization_api_keys"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctx/validator"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+type Service struct {
+	db database.Database
+
+	// repositories
+	apiKeyRepo        *repository.OrganizationAPIKeys
+	organizationsRepo *repository.Organization
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                deps.DB(),
+		apiKeyRepo:        deps.Repositories().OrganizationAPIKeys,
+		organizationsRepo: deps.Repositories().Organization,
+	}
+}
+
+// List returns all API keys of the given organization
+func (s *Service) List(ctx context.Context, organizationID string) ([]*serialize.OrganizationAPIKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := s.ensureOrganizationExists(ctx, env.Instance.ID, organizationID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	apiKeys, err := s.apiKeyRepo.FindAllByOrganizationAndInstance(ctx, s.db, organizationID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.OrganizationAPIKeyResponse, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		responses[i] = serialize.OrganizationAPIKey(apiKey)
+	}
+	return responses, nil
+}
+
+type CreateParams struct {
+	Name string `json:"name" validate:"required,min=1,max=255"`
+}
+
+// Create issues a new API key for the given organization. The response is
+// the only place the secret of the key is ever revealed.
+func (s *Service) Create(ctx context.Context, organizationID string, params CreateParams) (*serialize.OrganizationAPIKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	validate := validator.FromContext(ctx)
+	if err := validate.Struct(params); err != nil {
+		return nil, apierror.FormValidationFailed(err)
+	}
+
+	if apiErr := s.ensureOrganizationExists(ctx, env.Instance.ID, organizationID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	secret, secretDigest, secretHint, err := organization_api_keys.GenerateSecret()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	apiKey := &model.OrganizationAPIKey{OrganizationAPIKey: &sqbmodel.OrganizationAPIKey{
+		InstanceID:     env.Instance.ID,
+		OrganizationID: organizationID,
+		Name:           params.Name,
+		SecretDigest:   secretDigest,
+		SecretHint:     secretHint,
+	}}
+	if err := s.apiKeyRepo.Insert(ctx, s.db, apiKey); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	apiKey.Secret = secret
+
+	log.Info(ctx, "organization_api_keys: key %s issued for organization %s", apiKey.ID, organizationID)
+	return serialize.OrganizationAPIKey(apiKey), nil
+}
+
+// Rotate replaces the secret of the given API key with a new one. The old
+// secret stops working immediately.
+func (s *Service) Rotate(ctx context.Context, organizationID, apiKeyID string) (*serialize.OrganizationAPIKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	apiKey, apiErr := s.fetchAPIKey(ctx, env.Instance.ID, organizationID, apiKeyID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	secret, secretDigest, secretHint, err := organization_api_keys.GenerateSecret()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	apiKey.SecretDigest = secretDigest
+	apiKey.SecretHint = secretHint
+	err = s.apiKeyRepo.Update(ctx, s.db, apiKey,
+		sqbmodel.OrganizationAPIKeyColumns.SecretDigest,
+		sqbmodel.OrganizationAPIKeyColumns.SecretHint,
+	)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	apiKey.Secret = secret
+
+	log.Info(ctx, "organization_api_keys: key %s of organization %s rotated", apiKey.ID, organizationID)
+	return serialize.OrganizationAPIKey(apiKey), nil
+}
+
+// Delete revokes the given API key
+func (s *Service) Delete(ctx context.Context, organizationID, apiKeyID string) apierror.Error {
+	env := environment.FromContext(ctx)
+
+	if _, apiErr := s.fetchAPIKey(ctx, env.Instance.ID, organizationID, apiKeyID); apiErr != nil {
+		return apiErr
+	}
+
+	if err := s.apiKeyRepo.DeleteByIDAndInstance(ctx, s.db, apiKeyID, env.Instance.ID); err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+func (s *Service) fetchAPIKey(ctx context.Context, instanceID, organizationID, apiKeyID string) (*model.OrganizationAPIKey, apierror.Error) {
+	apiKey, err := s.apiKeyRepo.QueryByIDAndInstance(ctx, s.db, apiKeyID, instanceID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if apiKey == nil || apiKey.OrganizationID != organizationID {
+		return nil, apierror.OrganizationAPIKeyNotFound(apiKeyID)
+	}
+	return apiKey, nil
+}
+
+func (s *Service) ensureOrganizationExists(ctx context.Context, instanceID, organizationID string) apierror.Error {
+	org, err := s.organizationsRepo.QueryByIDAndInstance(ctx, s.db, organizationID, instanceID)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if org == nil {
+		return apierror.OrganizationNotFound()
+	}
+	return nil
+}
@@ -36,6 +36,7 @@ type Service struct {
 	eventsService *events.Service
 
 	// repositories
+	orgMemberRepo         *repository.OrganizationMembership
 	permissionRepo        *repository.Permission
 	subscriptionPlansRepo *repository.SubscriptionPlans
 }
@@ -45,8 +46,9 @@ func NewService(deps clerk.Deps) *Service {
 		db:                    deps.DB(),
 		validator:             validator.New(),
 		eventsService:         events.NewService(deps),
-		permissionRepo:        repository.NewPermission(),
-		subscriptionPlansRepo: repository.NewSubscriptionPlans(),
+		orgMemberRepo:         deps.Repositories().OrganizationMembership,
+		permissionRepo:        deps.Repositories().Permission,
+		subscriptionPlansRepo: deps.Repositories().SubscriptionPlans,
 	}
 }
 
@@ -95,9 +97,21 @@ func (s *Service) List(ctx context.Context, instanceID string, params ListParams
 		return nil, apierror.Unexpected(err)
 	}
 
+	permissionIDs := make([]string, len(orgPermissions))
+	for i, orgPermission := range orgPermissions {
+		permissionIDs[i] = orgPermission.ID
+	}
+	membersCountByPermission, err := s.orgMemberRepo.CountByInstanceGroupedByPermission(ctx, s.db, instanceID, permissionIDs...)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
 	responses := make([]any, len(orgPermissions))
 	for i, orgPermission := range orgPermissions {
-		responses[i] = serialize.Permission(orgPermission)
+		responses[i] = serialize.Permission(
+			orgPermission,
+			serialize.WithPermissionMembersCount(membersCountByPermission[orgPermission.ID]),
+		)
 	}
 
 	return serialize.Paginated(responses, totalCount), nil
@@ -202,7 +216,15 @@ func (s *Service) Read(ctx context.Context, instanceID, permissionID string) (*s
 		return nil, apierror.ResourceNotFound()
 	}
 
-	return serialize.Permission(orgPermission), nil
+	membersCountByPermission, err := s.orgMemberRepo.CountByInstanceGroupedByPermission(ctx, s.db, instanceID, orgPermission.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.Permission(
+		orgPermission,
+		serialize.WithPermissionMembersCount(membersCountByPermission[orgPermission.ID]),
+	), nil
 }
 
 type UpdateParams struct {
@@ -58,13 +58,13 @@ func NewService(deps clerk.Deps) *Service {
 		eventsService:         events.NewService(deps),
 		serializableService:   serializable.NewService(deps.Clock()),
 		organizationsService:  organizations.NewService(deps),
-		authConfigRepo:        repository.NewAuthConfig(),
-		orgInvitationRepo:     repository.NewOrganizationInvitation(),
-		orgMemberRepo:         repository.NewOrganizationMembership(),
-		permissionRepo:        repository.NewPermission(),
-		roleRepo:              repository.NewRole(),
-		rolePermissionRepo:    repository.NewRolePermission(),
-		subscriptionPlansRepo: repository.NewSubscriptionPlans(),
+		authConfigRepo:        deps.Repositories().AuthConfig,
+		orgInvitationRepo:     deps.Repositories().OrganizationInvitation,
+		orgMemberRepo:         deps.Repositories().OrganizationMembership,
+		permissionRepo:        deps.Repositories().Permission,
+		roleRepo:              deps.Repositories().Role,
+		rolePermissionRepo:    deps.Repositories().RolePermission,
+		subscriptionPlansRepo: deps.Repositories().SubscriptionPlans,
 	}
 }
 
@@ -113,9 +113,22 @@ func (s *Service) List(ctx context.Context, instanceID string, params ListParams
 		return nil, apierror.Unexpected(err)
 	}
 
+	roleKeys := make([]string, len(orgRoles))
+	for i, orgRole := range orgRoles {
+		roleKeys[i] = orgRole.Role.Key
+	}
+	membersCountByRole, err := s.orgMemberRepo.CountByInstanceGroupedByRole(ctx, s.db, instanceID, roleKeys...)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
 	responses := make([]any, len(orgRoles))
 	for i, orgRole := range orgRoles {
-		responses[i] = serialize.Role(orgRole.Role, orgRole.Permissions)
+		responses[i] = serialize.Role(
+			orgRole.Role,
+			orgRole.Permissions,
+			serialize.WithRoleMembersCount(membersCountByRole[orgRole.Role.Key]),
+		)
 	}
 
 	return serialize.Paginated(responses, totalCount), nil
@@ -225,7 +238,16 @@ func (s *Service) Read(ctx context.Context, instanceID, roleID string) (*seriali
 		return nil, apierror.Unexpected(err)
 	}
 
-	return serialize.Role(roleSerializable.Role, roleSerializable.Permissions), nil
+	membersCountByRole, err := s.orgMemberRepo.CountByInstanceGroupedByRole(ctx, s.db, instanceID, orgRole.Key)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return serialize.Role(
+		roleSerializable.Role,
+		roleSerializable.Permissions,
+		serialize.WithRoleMembersCount(membersCountByRole[orgRole.Key]),
+	), nil
 }
 
 type UpdateParams struct {
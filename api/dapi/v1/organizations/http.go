@@ -83,6 +83,14 @@ func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apie
 	return h.service.Delete(r.Context(), organizationID, instanceID)
 }
 
+// POST /instances/{instanceID}/organizations/{organizationID}/restore
+func (h *HTTP) Restore(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	instanceID := chi.URLParam(r, "instanceID")
+	organizationID := chi.URLParam(r, "organizationID")
+
+	return h.service.Restore(r.Context(), organizationID, instanceID)
+}
+
 // PATCH /instances/{instanceID}/organizations/{organizationID}/metadata
 func (h *HTTP) UpdateMetadata(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	instanceID := chi.URLParam(r, "instanceID")
@@ -165,6 +173,12 @@ func (h *HTTP) CurrentSubscription(_ http.ResponseWriter, r *http.Request) (inte
 	return h.service.CurrentSubscription(r.Context(), organizationID)
 }
 
+// GET /organizations/{organizationID}/billing/seats
+func (h *HTTP) BillingSeats(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	organizationID := chi.URLParam(r, "organizationID")
+	return h.service.ReadBillingSeats(r.Context(), organizationID)
+}
+
 // GET /organization/{organizationID}/memberships
 func (h *HTTP) ListMemberships(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	instanceID := chi.URLParam(r, "instanceID")
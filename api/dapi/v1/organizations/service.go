@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
+	"net/http"
 	"time"
 
 	"clerk/api/apierror"
 	dapiserialize "clerk/api/dapi/serialize"
 	"clerk/api/dapi/v1/subscriptions"
 	"clerk/api/serialize"
+	"clerk/api/shared/organization_seats"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/pagination"
 	"clerk/api/shared/pricing"
@@ -40,9 +42,10 @@ type Service struct {
 	newSDKConfig    sdkutils.ConfigConstructor
 	paymentProvider clerkbilling.PaymentProvider
 
-	billingService      *pricing.Service
-	organizationService *organizations.Service
-	subscriptionService *subscriptions.Service
+	billingService           *pricing.Service
+	organizationSeatsService *organization_seats.Service
+	organizationService      *organizations.Service
+	subscriptionService      *subscriptions.Service
 
 	applicationOwnershipRepo   *repository.ApplicationOwnerships
 	authConfigRepo             *repository.AuthConfig
@@ -60,15 +63,16 @@ func NewService(deps clerk.Deps, newSDKConfig sdkutils.ConfigConstructor, paymen
 		newSDKConfig:               newSDKConfig,
 		paymentProvider:            clerkbilling.NewCachedPaymentProvider(deps.Clock(), deps.DB(), paymentProvider),
 		billingService:             pricing.NewService(deps.DB(), deps.GueClient(), deps.Clock(), paymentProvider),
+		organizationSeatsService:   organization_seats.NewService(deps, paymentProvider),
 		organizationService:        organizations.NewService(deps),
 		subscriptionService:        subscriptions.NewService(deps, paymentProvider),
-		applicationOwnershipRepo:   repository.NewApplicationOwnerships(),
-		authConfigRepo:             repository.NewAuthConfig(),
-		organizationsRepo:          repository.NewOrganization(),
-		organizationMembershipRepo: repository.NewOrganizationMembership(),
-		subscriptionRepo:           repository.NewSubscriptions(),
-		subscriptionPlanRepo:       repository.NewSubscriptionPlans(),
-		subscriptionProductRepo:    repository.NewSubscriptionProduct(),
+		applicationOwnershipRepo:   deps.Repositories().ApplicationOwnerships,
+		authConfigRepo:             deps.Repositories().AuthConfig,
+		organizationsRepo:          deps.Repositories().Organization,
+		organizationMembershipRepo: deps.Repositories().OrganizationMembership,
+		subscriptionRepo:           deps.Repositories().Subscriptions,
+		subscriptionPlanRepo:       deps.Repositories().SubscriptionPlans,
+		subscriptionProductRepo:    deps.Repositories().SubscriptionProduct,
 	}
 }
 
@@ -194,6 +198,28 @@ func (s *Service) Delete(ctx context.Context, organizationID, instanceID string)
 	return organizationResponse, sdkutils.ToAPIError(err)
 }
 
+// Restore restores an organization which is pending deletion. The SDK
+// doesn't support restoring organizations, so the request is made through
+// its backend directly.
+func (s *Service) Restore(ctx context.Context, organizationID, instanceID string) (*sdk.Organization, apierror.Error) {
+	config, apiErr := sdkutils.NewConfigForInstance(ctx, s.newSDKConfig, s.db, instanceID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	path, err := sdk.JoinPath("organizations", organizationID, "restore")
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	req := sdk.NewAPIRequest(http.MethodPost, path)
+
+	response := &sdk.Organization{}
+	if err := sdk.NewBackend(&config.BackendConfig).Call(ctx, req, response); err != nil {
+		return nil, sdkutils.ToAPIError(err)
+	}
+	return response, nil
+}
+
 func (s *Service) UpdateMetadata(ctx context.Context, organizationID, instanceID string, params organization.UpdateMetadataParams) (*sdk.Organization, apierror.Error) {
 	sdkClient, apiErr := s.newOrganizationSDKClientForInstance(ctx, instanceID)
 	if apiErr != nil {
@@ -388,6 +414,24 @@ func (s *Service) ReadSubscription(ctx context.Context, organizationID string) (
 	), nil
 }
 
+// ReadBillingSeats returns the seat usage of the organization and the seat
+// quantity it's billed for.
+func (s *Service) ReadBillingSeats(ctx context.Context, organizationID string) (*dapiserialize.OrganizationSeatsResponse, apierror.Error) {
+	organization, err := s.organizationsRepo.QueryByID(ctx, s.db, organizationID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if organization == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	seats, err := s.organizationSeatsService.Read(ctx, s.db, organization)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return dapiserialize.OrganizationSeats(seats), nil
+}
+
 func (s *Service) ListPlans(ctx context.Context, organizationID string) ([]*serialize.SubscriptionPlanWithPricesResponse, apierror.Error) {
 	subscription, err := s.subscriptionRepo.QueryByResourceID(ctx, s.db, organizationID)
 	if err != nil {
@@ -62,15 +62,15 @@ func NewService(deps clerk.Deps, paymentProvider clerkbilling.PaymentProvider) *
 		environmentService:      environment.NewService(),
 		featureService:          features.NewService(deps.DB(), deps.GueClient()),
 		usageService:            usage.NewService(deps.Clock(), deps.DB(), deps.GueClient(), paymentProvider),
-		appRepo:                 repository.NewApplications(),
-		instanceRepo:            repository.NewInstances(),
-		organizationRepo:        repository.NewOrganization(),
-		plansRepo:               repository.NewSubscriptionPlans(),
-		subscriptionMetricsRepo: repository.NewSubscriptionMetrics(),
-		subscriptionPricesRepo:  repository.NewSubscriptionPrices(),
-		subscriptionRepo:        repository.NewSubscriptions(),
-		subscriptionPlanRepo:    repository.NewSubscriptionPlans(),
-		subscriptionProductRepo: repository.NewSubscriptionProduct(),
+		appRepo:                 deps.Repositories().Applications,
+		instanceRepo:            deps.Repositories().Instances,
+		organizationRepo:        deps.Repositories().Organization,
+		plansRepo:               deps.Repositories().SubscriptionPlans,
+		subscriptionMetricsRepo: deps.Repositories().SubscriptionMetrics,
+		subscriptionPricesRepo:  deps.Repositories().SubscriptionPrices,
+		subscriptionRepo:        deps.Repositories().Subscriptions,
+		subscriptionPlanRepo:    deps.Repositories().SubscriptionPlans,
+		subscriptionProductRepo: deps.Repositories().SubscriptionProduct,
 	}
 }
 
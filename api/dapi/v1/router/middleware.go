@@ -5,6 +5,7 @@ import (
 
 	"clerk/api/apierror"
 	shorigin "clerk/api/shared/origin"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/user_profile"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/clerkvalidator"
@@ -16,7 +17,6 @@ import (
 	"clerk/pkg/set"
 	"clerk/repository"
 	"clerk/utils/database"
-	"clerk/utils/log"
 
 	"github.com/go-chi/cors"
 	"github.com/jonboulle/clockwork"
@@ -59,7 +59,7 @@ func addLoggedInUserToLog(_ http.ResponseWriter, r *http.Request) (*http.Request
 	claims, hasClaims := sdkutils.GetActiveSession(ctx)
 
 	if hasClaims {
-		log.AddToLogLine(ctx, log.UserID, claims.Subject)
+		requestlog.Add(ctx, requestlog.UserID, claims.Subject)
 	}
 	return r, nil
 }
@@ -17,11 +17,16 @@ import (
 	"clerk/api/dapi/v1/environment"
 	"clerk/api/dapi/v1/events"
 	"clerk/api/dapi/v1/feature_flags"
+	"clerk/api/dapi/v1/impersonation_audits"
+	"clerk/api/dapi/v1/instance_audit_logs"
 	"clerk/api/dapi/v1/instance_keys"
 	"clerk/api/dapi/v1/instances"
 	"clerk/api/dapi/v1/integrations"
+	"clerk/api/dapi/v1/ip_restriction_rules"
 	"clerk/api/dapi/v1/jwt_services"
 	"clerk/api/dapi/v1/jwt_templates"
+	"clerk/api/dapi/v1/oauth_applications"
+	"clerk/api/dapi/v1/organization_api_keys"
 	"clerk/api/dapi/v1/organization_permissions"
 	"clerk/api/dapi/v1/organization_roles"
 	"clerk/api/dapi/v1/organizations"
@@ -29,15 +34,16 @@ import (
 	"clerk/api/dapi/v1/pricing"
 	"clerk/api/dapi/v1/redirect_urls"
 	"clerk/api/dapi/v1/saml_connections"
+	"clerk/api/dapi/v1/signing_keys"
 	"clerk/api/dapi/v1/subscriptions"
 	"clerk/api/dapi/v1/system_config"
 	"clerk/api/dapi/v1/templates"
 	"clerk/api/dapi/v1/user_settings"
 	"clerk/api/dapi/v1/users"
 	"clerk/api/dapi/v1/webhooks"
-	"clerk/api/middleware"
+	"clerk/api/middleware/pipeline"
+	"clerk/api/shared/featureflags"
 	clerkbilling "clerk/pkg/billing"
-	"clerk/pkg/cenv"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/externalapis/clerkimages"
 	"clerk/pkg/externalapis/svix"
@@ -47,12 +53,8 @@ import (
 	"clerk/utils/clerk"
 
 	sdk "github.com/clerk/clerk-sdk-go/v2"
-	sdkhttp "github.com/clerk/clerk-sdk-go/v2/http"
 	"github.com/clerk/clerk-sdk-go/v2/jwks"
-	sentry "github.com/getsentry/sentry-go/http"
 	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
-	chitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-chi/chi.v5"
 )
 
 // Router -
@@ -79,14 +81,20 @@ type Router struct {
 	environment          *environment.HTTP
 	events               *events.HTTP
 	featureFlags         *feature_flags.HTTP
+	impersonationAudits  *impersonation_audits.HTTP
+	instanceAuditLogs    *instance_audit_logs.HTTP
 	instances            *instances.HTTP
 	integrations         *integrations.HTTP
+	ipRestrictionRules   *ip_restriction_rules.HTTP
 	jwtTemplates         *jwt_templates.HTTP
 	keys                 *instance_keys.HTTP
+	oauthApplications    *oauth_applications.HTTP
 	samlConnections      *saml_connections.HTTP
+	signingKeys          *signing_keys.HTTP
 	subscriptions        *subscriptions.HTTP
 	systemConfig         *system_config.HTTP
 	organizations        *organizations.HTTP
+	organizationAPIKeys  *organization_api_keys.HTTP
 	organizationPerms    *organization_permissions.HTTP
 	organizationRoles    *organization_roles.HTTP
 	organizationSettings *organizationsettings.HTTP
@@ -129,17 +137,23 @@ func NewRouter(
 		clients:              clients.NewHTTP(deps, jwksClient),
 		displayConfig:        display_config.NewHTTP(deps.DB(), deps.GueClient(), clerkImagesClient),
 		domains:              domains.NewHTTP(deps, sdkConfigConstructor),
-		environment:          environment.NewHTTP(deps.DB()),
+		environment:          environment.NewHTTP(deps),
 		events:               events.NewHTTP(deps, paymentProvider),
 		featureFlags:         feature_flags.NewHTTP(deps),
+		impersonationAudits:  impersonation_audits.NewHTTP(deps),
+		instanceAuditLogs:    instance_audit_logs.NewHTTP(deps),
 		instances:            instances.NewHTTP(deps, svixClient, clerkImagesClient, sdkConfigConstructor),
 		integrations:         integrations.NewHTTP(deps, vercelClient, jwksClient),
+		ipRestrictionRules:   ip_restriction_rules.NewHTTP(deps),
 		jwtTemplates:         jwt_templates.NewHTTP(deps.DB(), sdkConfigConstructor),
-		keys:                 instance_keys.NewHTTP(deps.DB()),
+		keys:                 instance_keys.NewHTTP(deps),
+		oauthApplications:    oauth_applications.NewHTTP(deps),
 		samlConnections:      saml_connections.NewHTTP(deps.DB(), sdkConfigConstructor),
+		signingKeys:          signing_keys.NewHTTP(deps),
 		subscriptions:        subscriptions.NewHTTP(deps, paymentProvider),
 		systemConfig:         system_config.NewHTTP(deps.DB()),
 		organizations:        organizations.NewHTTP(deps, sdkConfigConstructor, paymentProvider),
+		organizationAPIKeys:  organization_api_keys.NewHTTP(deps),
 		organizationPerms:    organization_permissions.NewHTTP(deps),
 		organizationRoles:    organization_roles.NewHTTP(deps),
 		organizationSettings: organizationsettings.NewHTTP(deps.DB(), sdkConfigConstructor),
@@ -149,37 +163,24 @@ func NewRouter(
 		users:                users.NewHTTP(deps, dapiSDKClientConfig, sdkConfigConstructor),
 		userSettings:         user_settings.NewHTTP(deps.DB(), deps.GueClient(), sdkConfigConstructor),
 		jwtServices:          jwt_services.NewHTTP(deps.DB()),
-		webhooks:             webhooks.NewHTTP(deps.DB(), svixClient),
+		webhooks:             webhooks.NewHTTP(deps, svixClient),
 	}
 }
 
 // BuildRoutes builds a router for the dashboard
 func (router Router) BuildRoutes() *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Recover)
-
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
-		r.Use(chitrace.Middleware())
-	}
-
-	// report panics to Sentry and re-panic. Also, populate a Sentry Hub
-	// into the context.
-	//
-	// NOTE: This must come after middleware.Recover and before
-	// middleware.SetTraceID
-	r.Use(sentry.New(sentry.Options{Repanic: true}).Handle)
-
-	r.Use(middleware.SetTraceID)
-	r.Use(clerkhttp.Middleware(middleware.SetMaintenanceAndRecoveryMode))
-	r.Use(middleware.Log(func() sql.DBStats {
-		return router.deps.DB().Conn().Stats()
-	}))
-
-	r.Use(middleware.StripV1)
-	r.Use(chimw.StripSlashes)
-	r.Use(middleware.SetResponseTypeToJSON)
-
-	r.Use(clerkhttp.Middleware(checkRequestAllowedDuringMaintenance))
+	pipeline.Apply(r, pipeline.Policy{
+		MaintenanceMode: true,
+		DBStats: func() sql.DBStats {
+			return router.deps.DB().Conn().Stats()
+		},
+		FeatureFlags: featureflags.NewService(router.deps).Flags,
+		StripV1:      true,
+		BeforeRouting: []pipeline.Middleware{
+			clerkhttp.Middleware(checkRequestAllowedDuringMaintenance),
+		},
+	})
 
 	r.Method(http.MethodGet, "/health", router.common.Health())
 	r.Method(http.MethodHead, "/health", router.common.Health())
@@ -213,7 +214,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 
 					// Authenticated routes
 					r.Group(func(r chi.Router) {
-						r.Use(sdkhttp.RequireHeaderAuthorization(sdkhttp.JWKSClient(router.jwksClient), sdkhttp.AuthorizedPartyMatches(router.authorizedParties...)))
+						r.Use(pipeline.RequireSession(router.jwksClient, router.authorizedParties))
 						r.Use(clerkhttp.Middleware(restrictUpdatesOnImpersonationSessions))
 						r.Use(clerkhttp.Middleware(injectJSONValidator))
 						r.Use(clerkhttp.Middleware(router.integrations.CheckIntegrationOwner))
@@ -231,7 +232,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 
 		// Authenticated routes
 		r.Group(func(r chi.Router) {
-			r.Use(sdkhttp.RequireHeaderAuthorization(sdkhttp.JWKSClient(router.jwksClient), sdkhttp.AuthorizedPartyMatches(router.authorizedParties...)))
+			r.Use(pipeline.RequireSession(router.jwksClient, router.authorizedParties))
 			r.Use(clerkhttp.Middleware(addLoggedInUserToLog))
 			r.Use(clerkhttp.Middleware(restrictUpdatesOnImpersonationSessions))
 			r.Use(clerkhttp.Middleware(injectJSONValidator))
@@ -239,6 +240,16 @@ func (router Router) BuildRoutes() *chi.Mux {
 			r.Method(http.MethodPut, "/preferences", clerkhttp.Handler(router.users.SetPreferences))
 			r.Method(http.MethodGet, "/instance_keys", clerkhttp.Handler(router.keys.ListAll))
 
+			r.Route("/feature_flags", func(r chi.Router) {
+				r.Use(clerkhttp.Middleware(ensureStaffMode(router.deps.Clock(), router.deps.DB())))
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.featureFlags.List))
+				r.Method(http.MethodPost, "/", clerkhttp.Handler(router.featureFlags.Create))
+				r.Route("/{key}", func(r chi.Router) {
+					r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.featureFlags.Update))
+					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.featureFlags.Delete))
+				})
+			})
+
 			r.Route("/organizations/{organizationID}", func(r chi.Router) {
 				r.Use(clerkhttp.Middleware(router.organizations.CheckOrganizationAdmin))
 				r.Method(http.MethodPost, "/checkout/{planID}/session", clerkhttp.Handler(router.pricing.CheckoutOrganizationSessionRedirect))
@@ -247,6 +258,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 				r.Method(http.MethodGet, "/subscription", clerkhttp.Handler(router.organizations.Subscription))
 				r.Method(http.MethodGet, "/subscription_plans", clerkhttp.Handler(router.organizations.ListPlans))
 				r.Method(http.MethodGet, "/current_subscription", clerkhttp.Handler(router.organizations.CurrentSubscription))
+				r.Method(http.MethodGet, "/billing/seats", clerkhttp.Handler(router.organizations.BillingSeats))
 
 				r.Group(func(r chi.Router) {
 					r.Use(clerkhttp.Middleware(router.subscriptions.NewPricingCheckoutEnabled))
@@ -311,7 +323,10 @@ func (router Router) BuildRoutes() *chi.Mux {
 					r.Use(clerkhttp.Middleware(router.instances.EnsureApplicationNotPendingDeletion))
 					r.Use(clerkhttp.Middleware(router.instances.CheckInstanceOwner))
 					r.Use(clerkhttp.Middleware(router.environment.LoadEnvFromInstance))
+					// registered before the grace period refresh, so that it runs after it
+					r.Use(router.environment.InvalidateCachedEnvAfterUpdate)
 					r.Use(router.pricing.RefreshGracePeriodFeaturesAfterUpdate)
+					r.Use(router.instanceAuditLogs.RecordMutations)
 
 					r.Route("/bff", func(r chi.Router) {
 						r.Method(http.MethodGet, "/api_keys", clerkhttp.Handler(router.bff.APIKeys))
@@ -321,7 +336,14 @@ func (router Router) BuildRoutes() *chi.Mux {
 					r.Method(http.MethodGet, "/", clerkhttp.Handler(router.instances.Read))
 					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.instances.Delete))
 					r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.instances.UpdateSettings))
+					r.Method(http.MethodPost, "/clone", clerkhttp.Handler(router.instances.Clone))
 					r.Method(http.MethodPatch, "/communication", clerkhttp.Handler(router.instances.UpdateCommunication))
+					r.Method(http.MethodPatch, "/attestation", clerkhttp.Handler(router.instances.UpdateAttestation))
+					r.Method(http.MethodPatch, "/backchannel_logout", clerkhttp.Handler(router.instances.UpdateBackchannelLogout))
+					r.Method(http.MethodPost, "/backchannel_logout/test", clerkhttp.Handler(router.instances.TestBackchannelLogout))
+					r.Method(http.MethodPatch, "/maintenance", clerkhttp.Handler(router.instances.UpdateMaintenanceMode))
+					r.Method(http.MethodPost, "/metrics_token", clerkhttp.Handler(router.instances.CreateMetricsToken))
+					r.Method(http.MethodDelete, "/metrics_token", clerkhttp.Handler(router.instances.RevokeMetricsToken))
 					r.Method(http.MethodPost, "/change_domain", clerkhttp.Handler(router.instances.UpdateHomeURL))
 					r.Method(http.MethodPatch, "/patch_me_password", clerkhttp.Handler(router.instances.UpdatePatchMePassword))
 					r.Method(http.MethodPut, "/api_versions", clerkhttp.Handler(router.instances.UpdateAPIVersion))
@@ -362,6 +384,8 @@ func (router Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodPatch, "/sessions", clerkhttp.Handler(router.userSettings.UpdateUserSettingsSessions))
 						r.Method(http.MethodPatch, "/social/{providerID}", clerkhttp.Handler(router.userSettings.UpdateUserSettingsSocial))
 						r.Method(http.MethodPatch, "/restrictions", clerkhttp.Handler(router.userSettings.UpdateRestrictions))
+						r.Method(http.MethodGet, "/user_lockout", clerkhttp.Handler(router.userSettings.ReadUserLockout))
+						r.Method(http.MethodPatch, "/user_lockout", clerkhttp.Handler(router.userSettings.UpdateUserLockout))
 
 						// TODO(haris: 10/06/2022): Temporally endpoint to migrate an instance to PSU mode. Should be removed after
 						r.Method(http.MethodPatch, "/psu", clerkhttp.Handler(router.userSettings.SwitchToPSU))
@@ -377,6 +401,9 @@ func (router Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.displayConfig.Update))
 					})
 
+					r.Method(http.MethodGet, "/impersonation_audits", clerkhttp.Handler(router.impersonationAudits.List))
+					r.Method(http.MethodGet, "/audit_logs", clerkhttp.Handler(router.instanceAuditLogs.List))
+
 					r.Route("/jwt_services", func(r chi.Router) {
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.jwtServices.Read))
 						r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.jwtServices.Update))
@@ -404,20 +431,45 @@ func (router Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodDelete, "/{identifierID}", clerkhttp.Handler(router.blocklists.Delete))
 					})
 
+					r.Route("/ip_restriction_rules", func(r chi.Router) {
+						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.ipRestrictionRules.List))
+						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.ipRestrictionRules.Create))
+						r.Method(http.MethodDelete, "/{ruleID}", clerkhttp.Handler(router.ipRestrictionRules.Delete))
+					})
+
 					r.Route("/instance_keys", func(r chi.Router) {
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.keys.List))
 						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.keys.Create))
 						r.Route("/{instanceKeyID}", func(r chi.Router) {
 							r.Method(http.MethodGet, "/", clerkhttp.Handler(router.keys.Read))
+							r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.keys.Update))
 							r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.keys.Delete))
 						})
 					})
 
+					r.Route("/signing_keys", func(r chi.Router) {
+						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.signingKeys.List))
+						r.Method(http.MethodPost, "/rotate", clerkhttp.Handler(router.signingKeys.Rotate))
+						r.Method(http.MethodPost, "/{signingKeyID}/revoke", clerkhttp.Handler(router.signingKeys.Revoke))
+					})
+
 					r.Route("/integrations", func(r chi.Router) {
 						r.Method(http.MethodGet, "/{integrationType}", clerkhttp.Handler(router.integrations.ReadByType))
 						r.Method(http.MethodPut, "/{integrationType}", clerkhttp.Handler(router.integrations.UpsertByType))
 					})
 
+					r.Route("/oauth_applications", func(r chi.Router) {
+						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.oauthApplications.List))
+						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.oauthApplications.Create))
+
+						r.Route("/{oauthApplicationID}", func(r chi.Router) {
+							r.Method(http.MethodGet, "/", clerkhttp.Handler(router.oauthApplications.Read))
+							r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.oauthApplications.Update))
+							r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.oauthApplications.Delete))
+							r.Method(http.MethodPost, "/rotate_secret", clerkhttp.Handler(router.oauthApplications.RotateSecret))
+						})
+					})
+
 					r.Route("/jwt_templates", func(r chi.Router) {
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.jwtTemplates.ReadAll))
 						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.jwtTemplates.Create))
@@ -436,6 +488,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodGet, "/{organizationIDorSlug}", clerkhttp.Handler(router.organizations.Read))
 						r.Method(http.MethodPatch, "/{organizationID}", clerkhttp.Handler(router.organizations.Update))
 						r.Method(http.MethodDelete, "/{organizationID}", clerkhttp.Handler(router.organizations.Delete))
+						r.Method(http.MethodPost, "/{organizationID}/restore", clerkhttp.Handler(router.organizations.Restore))
 						r.Method(http.MethodPatch, "/{organizationID}/metadata", clerkhttp.Handler(router.organizations.UpdateMetadata))
 						r.Method(http.MethodPost, "/{organizationID}/logo", clerkhttp.Handler(router.organizations.UpdateLogo))
 						r.Method(http.MethodDelete, "/{organizationID}/logo", clerkhttp.Handler(router.organizations.DeleteLogo))
@@ -447,6 +500,13 @@ func (router Router) BuildRoutes() *chi.Mux {
 							r.Method(http.MethodPatch, "/{userID}", clerkhttp.Handler(router.organizations.UpdateMembership))
 							r.Method(http.MethodDelete, "/{userID}", clerkhttp.Handler(router.organizations.DeleteMemebership))
 						})
+
+						r.Route("/{organizationID}/api_keys", func(r chi.Router) {
+							r.Method(http.MethodGet, "/", clerkhttp.Handler(router.organizationAPIKeys.List))
+							r.Method(http.MethodPost, "/", clerkhttp.Handler(router.organizationAPIKeys.Create))
+							r.Method(http.MethodPost, "/{apiKeyID}/rotate", clerkhttp.Handler(router.organizationAPIKeys.Rotate))
+							r.Method(http.MethodDelete, "/{apiKeyID}", clerkhttp.Handler(router.organizationAPIKeys.Delete))
+						})
 					})
 
 					r.Route("/organization_roles", func(r chi.Router) {
@@ -491,6 +551,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 							r.Method(http.MethodPut, "/", clerkhttp.Handler(router.templates.Upsert))
 							r.Method(http.MethodPost, "/revert", clerkhttp.Handler(router.templates.Revert))
 							r.Method(http.MethodPost, "/preview", clerkhttp.Handler(router.templates.Preview))
+							r.Method(http.MethodPost, "/test", clerkhttp.Handler(router.templates.Test))
 							r.Method(http.MethodPost, "/toggle_delivery", clerkhttp.Handler(router.templates.ToggleDelivery))
 							r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.templates.Delete))
 						})
@@ -540,6 +601,7 @@ func (router Router) BuildRoutes() *chi.Mux {
 						r.Method(http.MethodGet, "/user_activity/{kind}", clerkhttp.Handler(router.analytics.UserActivity))
 						r.Method(http.MethodGet, "/monthly_metrics", clerkhttp.Handler(router.analytics.MonthlyMetrics))
 						r.Method(http.MethodGet, "/latest_activity", clerkhttp.Handler(router.analytics.LatestActivity))
+						r.Method(http.MethodGet, "/sign_up_funnel", clerkhttp.Handler(router.analytics.SignUpFunnel))
 					})
 
 					r.Route("/feature_flags", func(r chi.Router) {
@@ -0,0 +1,35 @@
+package signing_keys
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/signing_keys
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.List(r.Context())
+}
+
+// POST /instances/{instanceID}/signing_keys/rotate
+func (h *HTTP) Rotate(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Rotate(r.Context())
+}
+
+// POST /instances/{instanceID}/signing_keys/{signingKeyID}/revoke
+func (h *HTTP) Revoke(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.Revoke(r.Context(), chi.URLParam(r, "signingKeyID"))
+}
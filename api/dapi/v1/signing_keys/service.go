@@ -0,0 +1,128 @@
+package signing_keys
+
+import (
+	"context"
+	"errors"
+
+	"clerk/api/apierror"
+	"clerk/api/dapi/serialize"
+	"clerk/api/shared/edgecache"
+	"clerk/api/shared/edgereplication"
+	"clerk/api/shared/signing_keys"
+	"clerk/model"
+	"clerk/pkg/cenv"
+	"clerk/pkg/ctx/environment"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/vgarvardt/gue/v2"
+)
+
+type Service struct {
+	db        database.Database
+	gueClient *gue.Client
+
+	// services
+	edgeReplicationService *edgereplication.Service
+	signingKeyService      *signing_keys.Service
+
+	// repositories
+	domainRepo *repository.Domain
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                     deps.DB(),
+		gueClient:              deps.GueClient(),
+		edgeReplicationService: edgereplication.NewService(deps.GueClient(), cenv.GetBool(cenv.FlagReplicateInstanceToEdgeJobsEnabled)),
+		signingKeyService:      signing_keys.NewService(deps),
+		domainRepo:             deps.Repositories().Domain,
+	}
+}
+
+// List returns the signing keys of the instance which are published in its
+// JWKS.
+func (s *Service) List(ctx context.Context) ([]*serialize.SigningKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	keys, err := s.signingKeyService.List(ctx, s.db, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.SigningKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = serialize.SigningKey(key)
+	}
+	return responses, nil
+}
+
+// Rotate creates a new signing key for the instance, which replaces the
+// current one after the activation delay.
+func (s *Service) Rotate(ctx context.Context) (*serialize.SigningKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var newKey *model.InstanceSigningKey
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		newKey, err = s.signingKeyService.Rotate(ctx, tx, env.Instance)
+		if err != nil {
+			return true, err
+		}
+
+		err = s.publish(ctx, tx, env.Instance)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.SigningKey(newKey), nil
+}
+
+// Revoke revokes the given signing key of the instance.
+func (s *Service) Revoke(ctx context.Context, signingKeyID string) (*serialize.SigningKeyResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var key *model.InstanceSigningKey
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		key, err = s.signingKeyService.Revoke(ctx, tx, env.Instance, signingKeyID)
+		if errors.Is(err, signing_keys.ErrKeyNotFound) {
+			return true, apierror.SigningKeyNotFound(signingKeyID)
+		} else if errors.Is(err, signing_keys.ErrAlreadyRevoked) {
+			return true, apierror.SigningKeyAlreadyRevoked()
+		} else if errors.Is(err, signing_keys.ErrLastKey) {
+			return true, apierror.SigningKeyRevokeLastKey()
+		} else if err != nil {
+			return true, err
+		}
+
+		err = s.publish(ctx, tx, env.Instance)
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.SigningKey(key), nil
+}
+
+// publish makes sure that the change of the signing keys reaches the cached
+// JWKS of every domain of the instance and the edge.
+func (s *Service) publish(ctx context.Context, tx database.Tx, instance *model.Instance) error {
+	domains, err := s.domainRepo.FindAllByInstanceID(ctx, tx, instance.ID)
+	if err != nil {
+		return err
+	}
+	for _, domain := range domains {
+		if err := edgecache.PurgeJWKS(ctx, s.gueClient, tx, domain); err != nil {
+			return err
+		}
+	}
+	return s.edgeReplicationService.EnqueuePutInstance(ctx, tx, instance.ID)
+}
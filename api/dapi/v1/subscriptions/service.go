@@ -54,17 +54,17 @@ func NewService(deps clerk.Deps, paymentProvider clerkbilling.PaymentProvider) *
 		gueClient:                  deps.GueClient(),
 		paymentProvider:            clerkbilling.NewCachedPaymentProvider(deps.Clock(), deps.DB(), paymentProvider),
 		billingService:             pricing.NewService(deps.DB(), deps.GueClient(), deps.Clock(), paymentProvider),
-		billingAccountRepo:         repository.NewBillingAccounts(),
-		applicationRepo:            repository.NewApplications(),
-		dailyAggregationRepo:       repository.NewDailyAggregations(),
-		dailyUniqueActiveUsersRepo: repository.NewDailyUniqueActiveUsers(),
-		instanceRepo:               repository.NewInstances(),
-		organizationRepo:           repository.NewOrganization(),
-		subscriptionRepo:           repository.NewSubscriptions(),
-		subscriptionPlanRepo:       repository.NewSubscriptionPlans(),
-		subscriptionPriceRepo:      repository.NewSubscriptionPrices(),
-		subscriptionProductRepo:    repository.NewSubscriptionProduct(),
-		userRepo:                   repository.NewUsers(),
+		billingAccountRepo:         deps.Repositories().BillingAccounts,
+		applicationRepo:            deps.Repositories().Applications,
+		dailyAggregationRepo:       deps.Repositories().DailyAggregations,
+		dailyUniqueActiveUsersRepo: deps.Repositories().DailyUniqueActiveUsers,
+		instanceRepo:               deps.Repositories().Instances,
+		organizationRepo:           deps.Repositories().Organization,
+		subscriptionRepo:           deps.Repositories().Subscriptions,
+		subscriptionPlanRepo:       deps.Repositories().SubscriptionPlans,
+		subscriptionPriceRepo:      deps.Repositories().SubscriptionPrices,
+		subscriptionProductRepo:    deps.Repositories().SubscriptionProduct,
+		userRepo:                   deps.Repositories().Users,
 	}
 }
 
@@ -79,6 +79,20 @@ func (h *HTTP) Preview(_ http.ResponseWriter, r *http.Request) (interface{}, api
 	return h.service.Preview(r.Context(), instanceID, &params)
 }
 
+// POST /instances/{instanceID}/templates/{template_type}/{slug}/test
+func (h *HTTP) Test(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params TestParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+
+	instanceID := chi.URLParam(r, "instanceID")
+	params.TemplateType = sdk.TemplateType(chi.URLParam(r, "template_type"))
+	params.Slug = chi.URLParam(r, "slug")
+	return h.service.Test(r.Context(), instanceID, &params)
+}
+
 // DELETE /instances/{instanceID}/templates/{template_type}/{slug}
 func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	instanceID := chi.URLParam(r, "instanceID")
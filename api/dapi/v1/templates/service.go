@@ -2,6 +2,7 @@ package templates
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"clerk/api/apierror"
@@ -93,6 +94,40 @@ func (s *Service) Preview(ctx context.Context, instanceID string, params *templa
 	return response, nil
 }
 
+// TestParams are the parameters for sending a template, rendered with sample
+// data, to an email address or phone number.
+type TestParams struct {
+	sdk.APIParams
+	Subject      *string          `json:"subject,omitempty"`
+	Body         *string          `json:"body,omitempty"`
+	EmailAddress *string          `json:"email_address,omitempty"`
+	PhoneNumber  *string          `json:"phone_number,omitempty"`
+	TemplateType sdk.TemplateType `json:"-"`
+	Slug         string           `json:"-"`
+}
+
+// Test sends a test of the template. The SDK doesn't support sending tests,
+// so the request is made through its backend directly.
+func (s *Service) Test(ctx context.Context, instanceID string, params *TestParams) (*sdk.TemplatePreview, apierror.Error) {
+	config, apiErr := sdkutils.NewConfigForInstance(ctx, s.newSDKConfig, s.db, instanceID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	path, err := sdk.JoinPath("templates", string(params.TemplateType), params.Slug, "test")
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	req := sdk.NewAPIRequest(http.MethodPost, path)
+	req.SetParams(params)
+
+	response := &sdk.TemplatePreview{}
+	if err := sdk.NewBackend(&config.BackendConfig).Call(ctx, req, response); err != nil {
+		return nil, sdkutils.ToAPIError(err)
+	}
+	return response, nil
+}
+
 func (s *Service) Delete(ctx context.Context, instanceID string, params *template.DeleteParams) (*sdk.DeletedResource, apierror.Error) {
 	sdkClient, apiErr := s.newSDKClientForInstance(ctx, instanceID)
 	if apiErr != nil {
@@ -90,3 +90,18 @@ func (h *HTTP) UpdateRestrictions(_ http.ResponseWriter, r *http.Request) (inter
 func (h HTTP) SwitchToPSU(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	return h.service.SwitchToPSU(r.Context())
 }
+
+// GET /instances/{instanceID}/user_settings/user_lockout
+func (h *HTTP) ReadUserLockout(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	return h.service.ReadUserLockoutSettings(r.Context())
+}
+
+// PATCH /instances/{instanceID}/user_settings/user_lockout
+func (h *HTTP) UpdateUserLockout(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	var params UpdateUserLockoutParams
+	err := json.NewDecoder(r.Body).Decode(&params)
+	if err != nil {
+		return nil, apierror.InvalidRequestBody(err)
+	}
+	return h.service.UpdateUserLockoutSettings(r.Context(), params)
+}
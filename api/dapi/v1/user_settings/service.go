@@ -5,18 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 
 	"clerk/api/apierror"
 	"clerk/api/shared/auth_config"
 	"clerk/api/shared/sso"
+	"clerk/api/shared/user_profile"
 	"clerk/api/shared/validators"
 	"clerk/model"
+	"clerk/pkg/anomaly"
 	"clerk/pkg/billing"
+	"clerk/pkg/botdetection"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/externalapis/hibp"
 	"clerk/pkg/maps"
 	"clerk/pkg/oauth"
 	"clerk/pkg/oauth/provider"
@@ -382,6 +387,8 @@ func toUserSettingsResponse(
 		CustomActionRequired: userSettings.SignUp.CustomActionRequired,
 		Progressive:          userSettings.SignUp.Progressive,
 		DisableHIBP:          userSettings.PasswordSettings.DisableHIBP,
+		LegalConsent:         userSettings.SignUp.LegalConsent,
+		CustomFields:         userSettings.SignUp.CustomFields,
 	}
 
 	if userSettings.SignUp.CaptchaEnabled {
@@ -410,6 +417,7 @@ func toUserSettingsResponse(
 	response.Actions = userSettings.Actions
 	response.AttackProtection = userSettings.AttackProtection
 	response.PasskeySettings = userSettings.PasskeySettings
+	response.IdentifierPriority = user_profile.IdentifierPriority(userSettings)
 
 	return response
 }
@@ -438,6 +446,30 @@ func (s *Service) UpdateUserSettings(ctx context.Context, instanceID string, pat
 		return nil, valErr
 	}
 
+	// Legal consent requires a document version for users to accept
+	valErr = validators.ValidateLegalConsentSetting(userSettings)
+	if valErr != nil {
+		return nil, valErr
+	}
+
+	// Custom sign-up fields must have a valid schema
+	valErr = validators.ValidateSignUpCustomFields(userSettings)
+	if valErr != nil {
+		return nil, valErr
+	}
+
+	// Identifier priority may only reference known identifier types
+	valErr = validators.ValidateIdentifierPriority(userSettings)
+	if valErr != nil {
+		return nil, valErr
+	}
+
+	// Passkeys must be available to the instance, and usable to sign in if required
+	valErr = validators.ValidatePasskeySetting(env.Instance, userSettings)
+	if valErr != nil {
+		return nil, valErr
+	}
+
 	// Magic links cannot be enabled for instances with enhanced email deliverability
 	valErr = validators.ValidateEnhancedEmailDeliverability(
 		env.Instance.Communication.EnhancedEmailDeliverability,
@@ -459,11 +491,18 @@ func (s *Service) UpdateUserSettings(ctx context.Context, instanceID string, pat
 		return nil, apierror.ResourceInvalid(strings.Join(validationErrs.Messages(), ", "))
 	}
 
-	apiErr := s.validateAttackProtection(env.Application, userSettings.AttackProtection)
+	apiErr := s.validateAttackProtection(env.Application, env.Instance, userSettings.AttackProtection)
 	if apiErr != nil {
 		return nil, apiErr
 	}
 
+	// an empty policy blocks breached passwords, like it did before
+	// instances could choose
+	breachPolicy := userSettings.PasswordSettings.BreachPolicy
+	if breachPolicy != "" && !slices.Contains(hibp.Policies, breachPolicy) {
+		return nil, apierror.InvalidUserSettings()
+	}
+
 	// Validate user settings against the application's effective plans
 	if apiErr := s.validateFeaturesForInstance(ctx, s.db, billing.UserSettingsFeatures(userSettings), env.Instance, env.Subscription); apiErr != nil {
 		return nil, apiErr
@@ -498,13 +537,47 @@ func (s *Service) UpdateUserSettings(ctx context.Context, instanceID string, pat
 	return &response, nil
 }
 
-func (s *Service) validateAttackProtection(application *model.Application, settings usersettingsmodel.AttackProtectionSettings) apierror.Error {
+func (s *Service) validateAttackProtection(application *model.Application, instance *model.Instance, settings usersettingsmodel.AttackProtectionSettings) apierror.Error {
 	if !settings.PII.Enabled && !cenv.IsBeforeCutoff(cenv.PIIProtectionEnabledCutoffEpochTime, application.CreatedAt) {
 		return apierror.InvalidUserSettings()
 	}
+
+	anomalies := settings.SignInAnomalies
+	if !isValidRiskThreshold(anomalies.StepUpThreshold) || !isValidRiskThreshold(anomalies.BlockThreshold) {
+		return apierror.InvalidUserSettings()
+	}
+	// stepping up attempts which are blocked anyway makes no sense
+	if anomalies.StepUpThreshold > 0 && anomalies.BlockThreshold > 0 && anomalies.StepUpThreshold > anomalies.BlockThreshold {
+		return apierror.InvalidUserSettings()
+	}
+
+	botDetection := settings.BotDetection
+	if botDetection.Enabled {
+		if !slices.Contains(botdetection.Providers, botDetection.Provider) {
+			return apierror.InvalidUserSettings()
+		}
+		// challenges are bound to the domain of production instances, like
+		// CAPTCHA
+		if botDetection.Provider != botdetection.ProviderHeuristic && !instance.IsProduction() {
+			return apierror.CaptchaNotEnabled()
+		}
+	}
+	if !isValidRiskThreshold(botDetection.ChallengeThreshold) || !isValidRiskThreshold(botDetection.BlockThreshold) {
+		return apierror.InvalidUserSettings()
+	}
+	// challenging sign-ups which are blocked anyway makes no sense
+	if botDetection.ChallengeThreshold > 0 && botDetection.BlockThreshold > 0 && botDetection.ChallengeThreshold > botDetection.BlockThreshold {
+		return apierror.InvalidUserSettings()
+	}
 	return nil
 }
 
+// isValidRiskThreshold reports whether the threshold is a risk score, or zero
+// for disabled. Bot scores have the same range.
+func isValidRiskThreshold(threshold int) bool {
+	return threshold >= 0 && threshold <= anomaly.MaxScore
+}
+
 func setZeroValuesForDisabledAttributes(userSettings *usersettingsmodel.UserSettings) *usersettingsmodel.UserSettings {
 	attributes := userSettings.Attributes
 
@@ -36,3 +36,21 @@ func TestSetZeroValuesForDisabledAttributes(t *testing.T) {
 	assert.True(t, cleanedUpUserSettings.Attributes.PhoneNumber.UsedForFirstFactor)
 	assert.Equal(t, []string{"random"}, cleanedUpUserSettings.Attributes.PhoneNumber.FirstFactors)
 }
+
+func TestUpdateUserLockoutParams(t *testing.T) {
+	t.Parallel()
+
+	maxAttempts, duration := 5, 60
+	params := UpdateUserLockoutParams{MaxAttempts: &maxAttempts, DurationInMinutes: &duration}
+	assert.Nil(t, params.validate())
+
+	tooMany, negative := MaximumUserLockoutMaxAttempts+1, -1
+	assert.NotNil(t, UpdateUserLockoutParams{MaxAttempts: &tooMany}.validate())
+	assert.NotNil(t, UpdateUserLockoutParams{DurationInMinutes: &negative}.validate())
+
+	settings := model.UserLockoutSettings{DurationInMinutes: &duration}
+	autoUnlock := false
+	UpdateUserLockoutParams{MaxAttempts: &maxAttempts, AutoUnlock: &autoUnlock}.apply(&settings)
+	assert.Equal(t, &maxAttempts, settings.MaxAttempts)
+	assert.Nil(t, settings.DurationInMinutes)
+}
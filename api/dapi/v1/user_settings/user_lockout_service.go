@@ -0,0 +1,119 @@
+package user_settings
+
+import (
+	"context"
+	"strconv"
+
+	"clerk/api/apierror"
+	"clerk/pkg/ctx/environment"
+	usersettingsmodel "clerk/pkg/usersettings/model"
+	"clerk/utils/database"
+)
+
+// The bounds of the user lockout thresholds.
+const (
+	MaximumUserLockoutMaxAttempts       = 100
+	MaximumUserLockoutDurationInMinutes = 30 * 24 * 60 // 30 days
+)
+
+// UserLockoutSettingsResponse holds the thresholds after which users are
+// locked out of their accounts.
+type UserLockoutSettingsResponse struct {
+	Enabled     bool `json:"enabled"`
+	MaxAttempts int  `json:"max_attempts"`
+	// AutoUnlock is whether locked users are unlocked once the lockout
+	// duration has passed. Otherwise, they stay locked until they're
+	// unlocked from the Backend API.
+	AutoUnlock        bool `json:"auto_unlock"`
+	DurationInMinutes *int `json:"duration_in_minutes"`
+}
+
+func toUserLockoutSettingsResponse(settings usersettingsmodel.UserLockoutSettings) *UserLockoutSettingsResponse {
+	return &UserLockoutSettingsResponse{
+		Enabled:           settings.Enabled,
+		MaxAttempts:       settings.GetMaxAttempts(),
+		AutoUnlock:        settings.DurationInMinutes != nil,
+		DurationInMinutes: settings.DurationInMinutes,
+	}
+}
+
+// UpdateUserLockoutParams holds the user lockout thresholds to change.
+// Parameters which are omitted are left as they are.
+type UpdateUserLockoutParams struct {
+	Enabled           *bool `json:"enabled"`
+	MaxAttempts       *int  `json:"max_attempts"`
+	AutoUnlock        *bool `json:"auto_unlock"`
+	DurationInMinutes *int  `json:"duration_in_minutes"`
+}
+
+func (p UpdateUserLockoutParams) validate() apierror.Error {
+	var errs apierror.Error
+	if p.MaxAttempts != nil {
+		errs = apierror.Combine(errs, validateBound("max_attempts", *p.MaxAttempts, MaximumUserLockoutMaxAttempts))
+	}
+	if p.DurationInMinutes != nil {
+		errs = apierror.Combine(errs, validateBound("duration_in_minutes", *p.DurationInMinutes, MaximumUserLockoutDurationInMinutes))
+	}
+	return errs
+}
+
+func validateBound(param string, value, maximum int) apierror.Error {
+	if value < 1 {
+		return apierror.FormInvalidParameterValue(param, strconv.Itoa(value))
+	}
+	if value > maximum {
+		return apierror.FormParameterValueTooLarge(param, maximum)
+	}
+	return nil
+}
+
+// apply changes the given settings according to the params.
+func (p UpdateUserLockoutParams) apply(settings *usersettingsmodel.UserLockoutSettings) {
+	if p.Enabled != nil {
+		settings.Enabled = *p.Enabled
+	}
+	if p.MaxAttempts != nil {
+		settings.MaxAttempts = p.MaxAttempts
+	}
+	if p.DurationInMinutes != nil {
+		settings.DurationInMinutes = p.DurationInMinutes
+	}
+	// users who are locked indefinitely have no lockout duration
+	if p.AutoUnlock != nil && !*p.AutoUnlock {
+		settings.DurationInMinutes = nil
+	}
+}
+
+// ReadUserLockoutSettings returns the user lockout thresholds of the instance.
+func (s *Service) ReadUserLockoutSettings(ctx context.Context) (*UserLockoutSettingsResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	return toUserLockoutSettingsResponse(env.AuthConfig.UserSettings.AttackProtection.UserLockout), nil
+}
+
+// UpdateUserLockoutSettings changes the user lockout thresholds of the
+// instance. The lockout status of users is computed from the settings, so
+// the changes apply to users who are already locked as well.
+func (s *Service) UpdateUserLockoutSettings(ctx context.Context, params UpdateUserLockoutParams) (*UserLockoutSettingsResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	userLockout := &env.AuthConfig.UserSettings.AttackProtection.UserLockout
+	params.apply(userLockout)
+	// a lockout which expires needs to know when
+	if params.AutoUnlock != nil && *params.AutoUnlock && userLockout.DurationInMinutes == nil {
+		return nil, apierror.FormMissingParameter("duration_in_minutes")
+	}
+
+	txErr := s.db.PerformTxWithEmitter(ctx, s.gueClient, func(txEmitter database.TxEmitter) (bool, error) {
+		err := s.authConfigRepo.UpdateUserSettings(ctx, txEmitter, env.AuthConfig)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return toUserLockoutSettingsResponse(*userLockout), nil
+}
@@ -5,16 +5,16 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/pkg/externalapis/svix"
-	"clerk/utils/database"
+	"clerk/utils/clerk"
 )
 
 type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database, svixClient *svix.Client) *HTTP {
+func NewHTTP(deps clerk.Deps, svixClient *svix.Client) *HTTP {
 	return &HTTP{
-		service: NewService(db, svixClient),
+		service: NewService(deps, svixClient),
 	}
 }
 
@@ -8,6 +8,7 @@ import (
 	"clerk/api/shared/webhooks"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/externalapis/svix"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 )
 
@@ -18,10 +19,10 @@ type Service struct {
 	webhookService *webhooks.Service
 }
 
-func NewService(db database.Database, svixClient *svix.Client) *Service {
+func NewService(deps clerk.Deps, svixClient *svix.Client) *Service {
 	return &Service{
-		db:             db,
-		webhookService: webhooks.NewService(svixClient),
+		db:             deps.DB(),
+		webhookService: webhooks.NewService(deps, svixClient),
 	}
 }
 
@@ -3,12 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"os"
-	"time"
 
 	"clerk/api/fapi/v1/router"
+	"clerk/api/middleware/pipeline"
+	"clerk/api/shared/environment"
+	"clerk/api/shared/instance_metrics"
 	"clerk/api/shared/jwt"
+	"clerk/api/shared/serviceconfig"
 	"clerk/api/shared/sso"
 	"clerk/pkg/apiversioning"
 	clerkbilling "clerk/pkg/billing"
@@ -18,10 +20,12 @@ import (
 	"clerk/pkg/pubsub"
 	"clerk/pkg/sentry"
 	"clerk/pkg/storage/google"
+	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 
 	"cloud.google.com/go/profiler"
+	"github.com/jonboulle/clockwork"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
@@ -43,6 +47,11 @@ func main() {
 		panic(err)
 	}
 
+	cfg, err := serviceconfig.LoadFAPI()
+	if err != nil {
+		panic(err)
+	}
+
 	apiversioning.RegisterAllVersions()
 
 	sso.RegisterOAuthProviders()
@@ -52,10 +61,10 @@ func main() {
 	logger := log.New()
 	defer logger.Flush()
 
-	err := sentry.Init(
-		cenv.Get(cenv.SentryURL),
-		cenv.Get(cenv.ClerkEnv),
-		cenv.Get(cenv.SentryIgnoredStatusCodes),
+	err = sentry.Init(
+		cfg.SentryURL,
+		cfg.Env,
+		cfg.SentryIgnoredStatusCodes,
 	)
 	if err != nil {
 		logger.Error("failed starting Sentry: %s", err)
@@ -63,38 +72,40 @@ func main() {
 		defer sentry.Flush()
 	}
 
-	if cenv.IsEnabled(cenv.GoogleCloudProfiler) {
-		err = profiler.Start(profiler.Config{Service: cenv.Get(cenv.ClerkServiceIdentifier)})
+	if cfg.GoogleCloudProfiler {
+		err = profiler.Start(profiler.Config{Service: cfg.ServiceIdentifier})
 		if err != nil {
 			logger.Error("profiler: start: %s", err)
 		}
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
+	if cfg.DatadogTracer {
 		//GitCommitSHA is not a required env var, but if it's available use it
 		tracerOpts := []tracer.StartOption{
-			tracer.WithEnv(cenv.Get(cenv.ClerkEnv)),
-			tracer.WithService(cenv.Get(cenv.ClerkServiceIdentifier)),
+			tracer.WithEnv(cfg.Env),
+			tracer.WithService(cfg.ServiceIdentifier),
 		}
-		if cenv.IsSet(cenv.GitCommitSHA) {
-			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cenv.Get(cenv.GitCommitSHA)))
+		if cfg.GitCommitSHA != "" {
+			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cfg.GitCommitSHA))
 		}
 		tracer.Start(tracerOpts...)
 		defer tracer.Stop()
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDebugMode) {
+	if cfg.DebugMode {
 		boil.DebugMode = true
 		boil.DebugWriter = logger.Writer()
 	}
 
-	storageClient, err := google.NewClient(context.Background(), cenv.Get(cenv.GoogleStorageBucket))
+	storageClient, err := google.NewClient(context.Background(), cfg.GoogleStorageBucket)
 	if err != nil {
 		panic(err)
 	}
 
 	pubsubEventsTopic := pubsub.EventsTopic()
-	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic))
+	// repositories are created once and shared by every service
+	repositories := repository.NewRegistry(clockwork.NewRealClock())
+	deps := clerk.NewDeps(logger, clerk.WithStorageClient(storageClient), clerk.WithPubsubEventTopic(pubsubEventsTopic), clerk.WithRepositories(repositories))
 
 	defer func() {
 		err := deps.SegmentClient().Close()
@@ -114,8 +125,8 @@ func main() {
 	commonHandlers := handlers.NewCommon(deps.DB())
 
 	captchaClientPool, err := turnstile.NewClientPool(turnstile.WithKeys(
-		cenv.Get(cenv.CloudflareTurnstileSecretKeyInvisible),
-		cenv.Get(cenv.CloudflareTurnstileSecretKeyManaged),
+		cfg.CloudflareTurnstileSecretKeyInvisible,
+		cfg.CloudflareTurnstileSecretKeyManaged,
 	))
 	if err != nil {
 		panic(err)
@@ -124,24 +135,34 @@ func main() {
 	paymentProvider := clerkbilling.NewStripePaymentProvider(deps.GueClient())
 
 	// Initialize billing connector for Stripe
-	stripeConnectorConfig := clerkbilling.StripeConnectorConfig{SecretKey: cenv.Get(cenv.BillingStripeSecretKey)}
+	stripeConnectorConfig := clerkbilling.StripeConnectorConfig{SecretKey: cfg.BillingStripeSecretKey}
 	billingConnector, err := clerkbilling.NewStripeConnector(stripeConnectorConfig)
 	if err != nil {
 		panic(err)
 	}
 
-	r := router.New(deps, captchaClientPool, commonHandlers, billingConnector, paymentProvider)
+	// The environments are cached in memory and evicted whenever DAPI
+	// changes the settings of their instance.
+	envCache := environment.NewCache(deps.Clock())
+	envSubscription, err := pubsub.EnvironmentInvalidationsSubscription(context.Background())
+	if err != nil {
+		panic(err)
+	}
+	go func() {
+		if err := envCache.Receive(context.Background(), envSubscription); err != nil {
+			logger.Error("environment cache: receiving invalidations: %s", err)
+		}
+	}()
 
-	port := cenv.Get(cenv.Port)
-	ctxTimeout := cenv.GetInt(cenv.ContextTimeoutSeconds)
-	writeTimeout := ctxTimeout + 2 // write timeout should be longer than context timeout
+	r := router.New(deps, envCache, captchaClientPool, commonHandlers, billingConnector, paymentProvider)
 
-	server := http.Server{
-		Addr:         ":" + port,
-		Handler:      http.TimeoutHandler(r.BuildRoutes(), time.Duration(ctxTimeout)*time.Second, ""),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: time.Duration(writeTimeout) * time.Second,
-	}
+	go serviceconfig.WatchTunables(context.Background())
+	// counts of hot paths, like issued session tokens, are aggregated in
+	// memory and flushed periodically
+	go instance_metrics.RunFlusher(context.Background(), deps)
+
+	port := cfg.Port
+	server := pipeline.NewServer(port, r.BuildRoutes())
 
 	logger.Info("Up on port %s (pid=%d)", port, os.Getpid())
 	logger.Fatal(server.ListenAndServe())
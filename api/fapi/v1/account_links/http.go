@@ -0,0 +1,116 @@
+package account_links
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/fapi/v1/wrapper"
+	"clerk/model"
+	"clerk/pkg/ctx/requesting_user"
+	"clerk/pkg/ctxkeys"
+	"clerk/utils/clerk"
+	"clerk/utils/form"
+	"clerk/utils/param"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+	wrapper *wrapper.Wrapper
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+		wrapper: wrapper.NewWrapper(deps),
+	}
+}
+
+// POST /v1/me/account_links
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	formErrs := form.Check(r.Form, param.NewList(param.NewSet(param.Identifier), param.NewSet()))
+	if formErrs != nil {
+		return nil, h.wrapper.WrapError(ctx, formErrs, client)
+	}
+
+	resp, err := h.service.Create(ctx, user, *form.GetString(r.Form, param.Identifier.Name))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
+
+// GET /v1/me/account_links/{accountLinkID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	resp, err := h.service.Read(ctx, user, chi.URLParam(r, "accountLinkID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
+
+// POST /v1/me/account_links/{accountLinkID}/attempt_verification
+func (h *HTTP) AttemptVerification(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	formErrs := form.Check(r.Form, param.NewList(param.NewSet(param.Code), param.NewSet()))
+	if formErrs != nil {
+		return nil, h.wrapper.WrapError(ctx, formErrs, client)
+	}
+
+	resp, err := h.service.AttemptVerification(ctx, client, user, chi.URLParam(r, "accountLinkID"), *form.GetString(r.Form, param.Code.Name))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
+
+// POST /v1/me/account_links/{accountLinkID}/complete
+func (h *HTTP) Complete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	if formErrs := form.CheckEmpty(r.Form); formErrs != nil {
+		return nil, h.wrapper.WrapError(ctx, formErrs, client)
+	}
+
+	resp, err := h.service.Complete(ctx, user, chi.URLParam(r, "accountLinkID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
+
+// POST /v1/me/account_links/{accountLinkID}/cancel
+func (h *HTTP) Cancel(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	if formErrs := form.CheckEmpty(r.Form); formErrs != nil {
+		return nil, h.wrapper.WrapError(ctx, formErrs, client)
+	}
+
+	resp, err := h.service.Cancel(ctx, user, chi.URLParam(r, "accountLinkID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
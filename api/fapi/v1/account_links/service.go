@@ -0,0 +1,652 @@
+package account_links
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	"clerk/api/shared/strategies"
+	"clerk/api/shared/users"
+	"clerk/api/shared/verifications"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+	"clerk/utils/param"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// Account links have to be completed within this time frame, otherwise
+	// the user needs to start over.
+	accountLinkTTL = 15 * time.Minute
+
+	// Users can only start a limited amount of account links per day, and a
+	// user can only be the target of a limited amount of account links per
+	// day.
+	rateLimitWindow           = 24 * time.Hour
+	maxAccountLinksPerSource  = 3
+	maxAccountLinksPerTarget  = 3
+	maxIdentificationsToMerge = 20
+)
+
+// Statuses of an account link, as exposed to the client
+const (
+	statusNeedsSourceVerification = "needs_source_verification"
+	statusNeedsTargetVerification = "needs_target_verification"
+	statusVerified                = "verified"
+	statusCompleted               = "completed"
+	statusCanceled                = "canceled"
+	statusExpired                 = "expired"
+)
+
+// Statuses of an account link, as stored in the database
+const (
+	dbStatusPending   = "pending"
+	dbStatusCompleted = "completed"
+	dbStatusCanceled  = "canceled"
+)
+
+type Service struct {
+	deps  clerk.Deps
+	clock clockwork.Clock
+	db    database.Database
+
+	// services
+	commsService        *comms.Service
+	mergeService        *users.MergeService
+	usersService        *users.Service
+	verificationService *verifications.Service
+
+	// repositories
+	accountLinkRepo    *repository.AccountLinks
+	identificationRepo *repository.Identification
+	userRepo           *repository.Users
+	verificationRepo   *repository.Verification
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		deps:                deps,
+		clock:               deps.Clock(),
+		db:                  deps.DB(),
+		commsService:        comms.NewService(deps),
+		mergeService:        users.NewMergeService(deps),
+		usersService:        users.NewService(deps),
+		verificationService: verifications.NewService(deps.Clock()),
+		accountLinkRepo:     deps.Repositories().AccountLinks,
+		identificationRepo:  deps.Repositories().Identification,
+		userRepo:            deps.Repositories().Users,
+		verificationRepo:    deps.Repositories().Verification,
+	}
+}
+
+// Create starts linking the requesting user with the user that owns the
+// given identifier. Before anything is merged, both users need to verify
+// their primary identifier, starting with the requesting user.
+//
+// The identifier is only looked up once the requesting user is verified,
+// and the account link goes on the same way whether it belongs to another
+// user or not, so that account links can't be used to find out who has an
+// account.
+func (s *Service) Create(ctx context.Context, source *model.User, identifier string) (*serialize.AccountLinkResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	identifier = strings.TrimSpace(identifier)
+	if identifier == "" {
+		return nil, apierror.FormMissingParameter(param.Identifier.Name)
+	}
+
+	if !source.PrimaryEmailAddressID.Valid && !source.PrimaryPhoneNumberID.Valid {
+		// the requesting user has to verify itself first
+		return nil, apierror.AccountLinkNotAllowed()
+	}
+
+	apiErr := s.checkSourceRateLimit(ctx, source)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	var accountLink *model.AccountLink
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		accountLink = &model.AccountLink{AccountLink: &sqbmodel.AccountLink{
+			InstanceID:       env.Instance.ID,
+			SourceUserID:     source.ID,
+			TargetIdentifier: identifier,
+			Status:           dbStatusPending,
+			ExpireAt:         s.clock.Now().UTC().Add(accountLinkTTL),
+		}}
+		if err := s.accountLinkRepo.Insert(ctx, tx, accountLink); err != nil {
+			return true, fmt.Errorf("accountLinks/create: inserting account link for user %s: %w", source.ID, err)
+		}
+
+		verification, err := s.prepareVerification(ctx, tx, env, source)
+		if err != nil {
+			return true, err
+		}
+
+		accountLink.SourceVerificationID = null.StringFrom(verification.ID)
+		if err := s.accountLinkRepo.UpdateSourceVerificationID(ctx, tx, accountLink); err != nil {
+			return true, fmt.Errorf("accountLinks/create: updating source verification of %s: %w", accountLink.ID, err)
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return s.toResponse(ctx, s.db, accountLink)
+}
+
+// Read returns the account link with the given id, if it was started by
+// the requesting user.
+func (s *Service) Read(ctx context.Context, source *model.User, accountLinkID string) (*serialize.AccountLinkResponse, apierror.Error) {
+	accountLink, apiErr := s.fetchAccountLink(ctx, s.db, source, accountLinkID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return s.toResponse(ctx, s.db, accountLink)
+}
+
+// AttemptVerification attempts the verification of the next account of the
+// link. Once the requesting user is verified, the verification of the other
+// account is prepared.
+func (s *Service) AttemptVerification(ctx context.Context, client *model.Client, source *model.User, accountLinkID, code string) (*serialize.AccountLinkResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	var accountLink *model.AccountLink
+	var attemptor strategies.Attemptor
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var apiErr apierror.Error
+		accountLink, apiErr = s.fetchPendingAccountLink(ctx, tx, source, accountLinkID)
+		if apiErr != nil {
+			return true, apiErr
+		}
+
+		status, err := s.status(ctx, tx, accountLink)
+		if err != nil {
+			return true, err
+		}
+
+		var verificationID string
+		switch status {
+		case statusNeedsSourceVerification:
+			verificationID = accountLink.SourceVerificationID.String
+		case statusNeedsTargetVerification:
+			if !accountLink.TargetVerificationID.Valid {
+				// no other account can be linked, so no code was sent,
+				// and any code is as wrong as a mistyped one
+				return true, apierror.FormIncorrectCode(param.Code.Name)
+			}
+			verificationID = accountLink.TargetVerificationID.String
+		default:
+			return true, apierror.VerificationAlreadyVerified()
+		}
+
+		verification, err := s.verificationRepo.FindByID(ctx, tx, verificationID)
+		if err != nil {
+			return true, fmt.Errorf("accountLinks/attempt: fetching verification %s: %w", verificationID, err)
+		}
+
+		attemptor = newAttemptor(s.deps, verification, code)
+		_, err = strategies.AttemptVerification(ctx, tx, attemptor, s.verificationRepo, client.ID)
+		if errors.Is(err, strategies.ErrInvalidCode) {
+			// Commit, so that the failed attempt is counted
+			return false, err
+		} else if err != nil {
+			return true, err
+		}
+
+		if status == statusNeedsSourceVerification {
+			if err := s.prepareTarget(ctx, tx, env, source, accountLink); err != nil {
+				return true, err
+			}
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		if attemptor != nil {
+			return nil, attemptor.ToAPIError(txErr)
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return s.toResponse(ctx, s.db, accountLink)
+}
+
+// Complete merges the other account into the requesting user. Both users
+// are notified and the other account is deleted.
+func (s *Service) Complete(ctx context.Context, source *model.User, accountLinkID string) (*serialize.AccountLinkResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	var accountLink *model.AccountLink
+	var target *model.User
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var apiErr apierror.Error
+		accountLink, apiErr = s.fetchPendingAccountLink(ctx, tx, source, accountLinkID)
+		if apiErr != nil {
+			return true, apiErr
+		}
+
+		status, err := s.status(ctx, tx, accountLink)
+		if err != nil {
+			return true, err
+		}
+		if status != statusVerified {
+			return true, apierror.AccountLinkNotVerified()
+		}
+
+		target, err = s.userRepo.FindByIDAndInstance(ctx, tx, accountLink.TargetUserID.String, env.Instance.ID)
+		if err != nil {
+			return true, fmt.Errorf("accountLinks/complete: fetching target user %s: %w", accountLink.TargetUserID.String, err)
+		}
+
+		// Collect the addresses to notify before the identifications change hands
+		sourceEmailAddress, err := s.primaryEmailAddress(ctx, tx, source)
+		if err != nil {
+			return true, err
+		}
+		targetEmailAddress, err := s.primaryEmailAddress(ctx, tx, target)
+		if err != nil {
+			return true, err
+		}
+		targetIdentifier, err := s.primaryIdentifier(ctx, tx, target)
+		if err != nil {
+			return true, err
+		}
+		sourceIdentifier, err := s.primaryIdentifier(ctx, tx, source)
+		if err != nil {
+			return true, err
+		}
+
+		previousSource, err := s.usersService.SnapshotUser(ctx, tx, userSettings, source)
+		if err != nil {
+			return true, err
+		}
+
+		if _, err := s.mergeService.Merge(ctx, tx, source, target); err != nil {
+			return true, err
+		}
+
+		// The merged account is now empty, delete it along with its
+		// sessions, so that a failure leaves both accounts as they were.
+		if err := s.usersService.DeleteMerged(ctx, tx, env, target); err != nil {
+			return true, err
+		}
+
+		accountLink.Status = dbStatusCompleted
+		if err := s.accountLinkRepo.UpdateStatus(ctx, tx, accountLink); err != nil {
+			return true, fmt.Errorf("accountLinks/complete: updating status of %s: %w", accountLink.ID, err)
+		}
+
+		if _, err := s.usersService.SendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousSource, source); err != nil {
+			return true, err
+		}
+
+		if sourceEmailAddress != nil {
+			err := s.commsService.SendAccountsLinkedEmail(ctx, tx, env, comms.EmailAccountsLinked{
+				EmailAddress:     *sourceEmailAddress,
+				LinkedIdentifier: targetIdentifier,
+			})
+			if err != nil {
+				return true, fmt.Errorf("accountLinks/complete: notifying user %s: %w", source.ID, err)
+			}
+		}
+		if targetEmailAddress != nil {
+			err := s.commsService.SendAccountsLinkedEmail(ctx, tx, env, comms.EmailAccountsLinked{
+				EmailAddress:     *targetEmailAddress,
+				LinkedIdentifier: sourceIdentifier,
+			})
+			if err != nil {
+				return true, fmt.Errorf("accountLinks/complete: notifying user %s: %w", target.ID, err)
+			}
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return s.toResponse(ctx, s.db, accountLink)
+}
+
+// Cancel cancels a pending account link.
+func (s *Service) Cancel(ctx context.Context, source *model.User, accountLinkID string) (*serialize.AccountLinkResponse, apierror.Error) {
+	accountLink, apiErr := s.fetchPendingAccountLink(ctx, s.db, source, accountLinkID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	accountLink.Status = dbStatusCanceled
+	if err := s.accountLinkRepo.UpdateStatus(ctx, s.db, accountLink); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return s.toResponse(ctx, s.db, accountLink)
+}
+
+// prepareTarget looks up the user which owns the identifier of the account
+// link, now that the requesting user is verified, and sends it a code. If
+// the identifier doesn't belong to another user, or the link isn't allowed,
+// no code is sent and the reason is only logged, so that the requesting user
+// can't tell it apart from a code that hasn't arrived yet.
+func (s *Service) prepareTarget(ctx context.Context, tx database.Tx, env *model.Env, source *model.User, accountLink *model.AccountLink) error {
+	target, err := s.findTarget(ctx, tx, env.Instance.ID, source, accountLink.TargetIdentifier)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		log.Info(ctx, "accountLinks: no other user for the identifier of account link %s", accountLink.ID)
+		return nil
+	}
+
+	allowed, err := s.targetWithinRateLimit(ctx, tx, target)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		log.Warning(ctx, "accountLinks: rejecting link of user %s with user %s: too many account links", source.ID, target.ID)
+		return nil
+	}
+
+	allowed, err = s.checkAbuseHeuristics(ctx, tx, env, source, target)
+	if err != nil || !allowed {
+		return err
+	}
+
+	targetVerification, err := s.prepareVerification(ctx, tx, env, target)
+	if err != nil {
+		return err
+	}
+
+	accountLink.TargetUserID = null.StringFrom(target.ID)
+	accountLink.TargetVerificationID = null.StringFrom(targetVerification.ID)
+	if err := s.accountLinkRepo.UpdateTarget(ctx, tx, accountLink); err != nil {
+		return fmt.Errorf("accountLinks/prepareTarget: updating target of %s: %w", accountLink.ID, err)
+	}
+	return nil
+}
+
+// findTarget returns the other user which owns the identifier, or nil if
+// there's none.
+func (s *Service) findTarget(ctx context.Context, exec database.Executor, instanceID string, source *model.User, identifier string) (*model.User, error) {
+	ident, err := s.identificationRepo.QueryClaimedVerifiedByInstanceAndIdentifierAndType(ctx, exec, instanceID, identifier, identifierType(identifier))
+	if err != nil {
+		return nil, fmt.Errorf("accountLinks/findTarget: fetching identification: %w", err)
+	}
+	if ident == nil || !ident.UserID.Valid || ident.UserID.String == source.ID {
+		return nil, nil
+	}
+
+	target, err := s.userRepo.QueryByIDAndInstance(ctx, exec, ident.UserID.String, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("accountLinks/findTarget: fetching user %s: %w", ident.UserID.String, err)
+	}
+	return target, nil
+}
+
+// identifierType returns the type of identification the identifier of an
+// account link is looked up as.
+func identifierType(identifier string) string {
+	if strings.Contains(identifier, "@") {
+		return constants.ITEmailAddress
+	}
+	return constants.ITPhoneNumber
+}
+
+func (s *Service) checkSourceRateLimit(ctx context.Context, source *model.User) apierror.Error {
+	since := s.clock.Now().UTC().Add(-rateLimitWindow)
+
+	sourceCount, err := s.accountLinkRepo.CountBySourceUserCreatedSince(ctx, s.db, source.ID, since)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if sourceCount >= maxAccountLinksPerSource {
+		return apierror.TooManyRequests()
+	}
+	return nil
+}
+
+// targetWithinRateLimit reports whether the user can be the target of
+// another account link.
+func (s *Service) targetWithinRateLimit(ctx context.Context, exec database.Executor, target *model.User) (bool, error) {
+	since := s.clock.Now().UTC().Add(-rateLimitWindow)
+
+	targetCount, err := s.accountLinkRepo.CountByTargetUserCreatedSince(ctx, exec, target.ID, since)
+	if err != nil {
+		return false, fmt.Errorf("accountLinks: counting account links of user %s: %w", target.ID, err)
+	}
+	return targetCount < maxAccountLinksPerTarget, nil
+}
+
+// checkAbuseHeuristics reports whether the account link is allowed, or
+// looks like an attempt to take over or launder an account. The reason is
+// only logged, so that it cannot be used to probe the other account.
+func (s *Service) checkAbuseHeuristics(ctx context.Context, exec database.Executor, env *model.Env, source, target *model.User) (bool, error) {
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	reject := func(reason string) (bool, error) {
+		log.Warning(ctx, "accountLinks: rejecting link of user %s with user %s: %s", source.ID, target.ID, reason)
+		return false, nil
+	}
+
+	if source.Banned || target.Banned {
+		return reject("banned user")
+	}
+
+	if userSettings.UserLockoutEnabled() {
+		if source.LockoutStatus(s.clock, userSettings.AttackProtection.UserLockout).Locked ||
+			target.LockoutStatus(s.clock, userSettings.AttackProtection.UserLockout).Locked {
+			return reject("locked user")
+		}
+	}
+
+	identifications, err := s.identificationRepo.FindAllByUsers(ctx, exec, []string{target.ID})
+	if err != nil {
+		return false, fmt.Errorf("accountLinks: fetching identifications of user %s: %w", target.ID, err)
+	}
+	if len(identifications) > maxIdentificationsToMerge {
+		return reject("too many identifications")
+	}
+	for _, ident := range identifications {
+		// Enterprise accounts are managed by the identity provider and
+		// cannot change owners.
+		if ident.IsSAML() {
+			return reject("enterprise account")
+		}
+	}
+
+	if !target.PrimaryEmailAddressID.Valid && !target.PrimaryPhoneNumberID.Valid {
+		return reject("target has no verifiable primary identifier")
+	}
+	if !source.PrimaryEmailAddressID.Valid && !source.PrimaryPhoneNumberID.Valid {
+		return reject("source has no verifiable primary identifier")
+	}
+	return true, nil
+}
+
+func (s *Service) fetchAccountLink(ctx context.Context, exec database.Executor, source *model.User, accountLinkID string) (*model.AccountLink, apierror.Error) {
+	accountLink, err := s.accountLinkRepo.QueryByIDAndSourceUser(ctx, exec, accountLinkID, source.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if accountLink == nil {
+		return nil, apierror.AccountLinkNotFound()
+	}
+	return accountLink, nil
+}
+
+func (s *Service) fetchPendingAccountLink(ctx context.Context, exec database.Executor, source *model.User, accountLinkID string) (*model.AccountLink, apierror.Error) {
+	accountLink, apiErr := s.fetchAccountLink(ctx, exec, source, accountLinkID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if accountLink.Status != dbStatusPending {
+		return nil, apierror.AccountLinkAlreadyCompleted()
+	}
+	if s.clock.Now().UTC().After(accountLink.ExpireAt) {
+		return nil, apierror.AccountLinkExpired()
+	}
+	return accountLink, nil
+}
+
+// prepareVerification sends a one-time code to the primary identifier of
+// the given user, preferring the email address over the phone number.
+func (s *Service) prepareVerification(ctx context.Context, tx database.Tx, env *model.Env, user *model.User) (*model.Verification, error) {
+	identificationID := user.PrimaryEmailAddressID
+	if !identificationID.Valid {
+		identificationID = user.PrimaryPhoneNumberID
+	}
+
+	identification, err := s.identificationRepo.FindByID(ctx, tx, identificationID.String)
+	if err != nil {
+		return nil, fmt.Errorf("accountLinks/prepareVerification: fetching primary identification of user %s: %w", user.ID, err)
+	}
+
+	var preparer strategies.Preparer
+	if identification.IsEmailAddress() {
+		preparer = strategies.NewEmailCodePreparer(s.deps, env, identification, constants.OSTUser, user.ID)
+	} else {
+		preparer = strategies.NewPhoneCodePreparer(s.deps, env, identification, constants.OSTUser, user.ID)
+	}
+	return preparer.Prepare(ctx, tx)
+}
+
+func newAttemptor(deps clerk.Deps, verification *model.Verification, code string) strategies.Attemptor {
+	if verification.Strategy == constants.VSPhoneCode {
+		return strategies.NewPhoneCodeAttemptor(deps, verification, code)
+	}
+	return strategies.NewEmailCodeAttemptor(deps, verification, code)
+}
+
+// status computes the status of the account link, as exposed to the client.
+func (s *Service) status(ctx context.Context, exec database.Executor, accountLink *model.AccountLink) (string, error) {
+	switch {
+	case accountLink.Status == dbStatusCompleted:
+		return statusCompleted, nil
+	case accountLink.Status == dbStatusCanceled:
+		return statusCanceled, nil
+	case s.clock.Now().UTC().After(accountLink.ExpireAt):
+		return statusExpired, nil
+	}
+
+	for _, verificationID := range []null.String{accountLink.SourceVerificationID, accountLink.TargetVerificationID} {
+		if !verificationID.Valid {
+			return statusNeedsTargetVerification, nil
+		}
+		verification, err := s.verificationService.VerificationWithStatus(ctx, exec, verificationID.String)
+		if err != nil {
+			return "", err
+		}
+		if verification == nil || verification.Status != constants.VERVerified {
+			if verificationID == accountLink.SourceVerificationID {
+				return statusNeedsSourceVerification, nil
+			}
+			return statusNeedsTargetVerification, nil
+		}
+	}
+	return statusVerified, nil
+}
+
+func (s *Service) primaryEmailAddress(ctx context.Context, exec database.Executor, user *model.User) (*string, error) {
+	if !user.PrimaryEmailAddressID.Valid {
+		return nil, nil
+	}
+	ident, err := s.identificationRepo.FindByID(ctx, exec, user.PrimaryEmailAddressID.String)
+	if err != nil {
+		return nil, fmt.Errorf("accountLinks: fetching primary email address of user %s: %w", user.ID, err)
+	}
+	return ident.Identifier.Ptr(), nil
+}
+
+func (s *Service) primaryIdentifier(ctx context.Context, exec database.Executor, user *model.User) (string, error) {
+	identificationID := user.PrimaryEmailAddressID
+	if !identificationID.Valid {
+		identificationID = user.PrimaryPhoneNumberID
+	}
+	if !identificationID.Valid {
+		return "", nil
+	}
+	ident, err := s.identificationRepo.FindByID(ctx, exec, identificationID.String)
+	if err != nil {
+		return "", fmt.Errorf("accountLinks: fetching primary identifier of user %s: %w", user.ID, err)
+	}
+	return ident.Identifier.String, nil
+}
+
+func (s *Service) toResponse(ctx context.Context, exec database.Executor, accountLink *model.AccountLink) (*serialize.AccountLinkResponse, apierror.Error) {
+	status, err := s.status(ctx, exec, accountLink)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	var sourceVerification, targetVerification *model.VerificationWithStatus
+	if accountLink.SourceVerificationID.Valid {
+		sourceVerification, err = s.verificationService.VerificationWithStatus(ctx, exec, accountLink.SourceVerificationID.String)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+	if accountLink.TargetVerificationID.Valid && exposesTargetVerification(status) {
+		targetVerification, err = s.verificationService.VerificationWithStatus(ctx, exec, accountLink.TargetVerificationID.String)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
+	var preview *serialize.AccountLinkPreview
+	if status == statusVerified {
+		source, err := s.userRepo.FindByID(ctx, exec, accountLink.SourceUserID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		target, err := s.userRepo.FindByID(ctx, exec, accountLink.TargetUserID.String)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		mergePreview, err := s.mergeService.Preview(ctx, exec, source, target)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		preview = &serialize.AccountLinkPreview{
+			EmailAddresses:         mergePreview.EmailAddresses,
+			PhoneNumbers:           mergePreview.PhoneNumbers,
+			Web3Wallets:            mergePreview.Web3Wallets,
+			ExternalAccounts:       mergePreview.ExternalAccounts,
+			OrganizationIDs:        mergePreview.OrganizationIDs,
+			SkippedOrganizationIDs: mergePreview.SkippedOrganizationIDs,
+		}
+	}
+
+	return serialize.AccountLink(accountLink, status, sourceVerification, targetVerification, preview), nil
+}
+
+// exposesTargetVerification reports whether the verification of the other
+// account is included in responses with the given status. It's left out
+// until it succeeds, as it would otherwise tell whether a code was sent, and
+// over which channel, before the other account proves it's on board.
+func exposesTargetVerification(status string) bool {
+	return status == statusVerified || status == statusCompleted
+}
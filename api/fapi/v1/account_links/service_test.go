@@ -0,0 +1,29 @@
+package account_links
+
+import (
+	"testing"
+
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentifierType(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, constants.ITEmailAddress, identifierType("jane@clerk.dev"))
+	assert.Equal(t, constants.ITPhoneNumber, identifierType("+15555550100"))
+}
+
+func TestExposesTargetVerification(t *testing.T) {
+	t.Parallel()
+
+	// Until the other account is verified, responses look the same whether
+	// a code was sent to it or not.
+	for _, status := range []string{statusNeedsSourceVerification, statusNeedsTargetVerification, statusCanceled, statusExpired} {
+		assert.False(t, exposesTargetVerification(status), status)
+	}
+	for _, status := range []string{statusVerified, statusCompleted} {
+		assert.True(t, exposesTargetVerification(status), status)
+	}
+}
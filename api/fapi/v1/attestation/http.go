@@ -0,0 +1,50 @@
+package attestation
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/attestation"
+	"clerk/utils/clerk"
+	"clerk/utils/form"
+)
+
+// Headers carrying the device attestation of native clients
+const (
+	headerAttestationPlatform  = "X-Clerk-Attestation-Platform"
+	headerAttestationToken     = "X-Clerk-Attestation-Token"
+	headerAttestationKeyID     = "X-Clerk-Attestation-Key-Id"
+	headerAttestationChallenge = "X-Clerk-Attestation-Challenge"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// POST /v1/client/attestation/challenge
+func (h *HTTP) CreateChallenge(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if formErrs := form.CheckEmpty(r.Form); formErrs != nil {
+		return nil, formErrs
+	}
+	return h.service.CreateChallenge(r.Context())
+}
+
+// Middleware /v1/client, /v1/client/sign_ins, /v1/client/sign_ups
+func (h *HTTP) VerifyDeviceAttestation(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+	ctx, err := h.service.Verify(r.Context(), attestation.VerifyParams{
+		Platform:  r.Header.Get(headerAttestationPlatform),
+		Token:     r.Header.Get(headerAttestationToken),
+		KeyID:     r.Header.Get(headerAttestationKeyID),
+		Challenge: r.Header.Get(headerAttestationChallenge),
+	})
+	if err != nil {
+		return r, err
+	}
+	return r.WithContext(ctx), nil
+}
@@ -0,0 +1,74 @@
+package attestation
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/attestation"
+	"clerk/api/shared/client_data"
+	"clerk/model"
+	"clerk/pkg/ctx/client_type"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctxkeys"
+	"clerk/utils/clerk"
+
+	"github.com/volatiletech/null/v8"
+)
+
+type Service struct {
+	attestationService *attestation.Service
+	clientDataService  *client_data.Service
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		attestationService: attestation.NewService(deps),
+		clientDataService:  client_data.NewService(deps),
+	}
+}
+
+// CreateChallenge issues a new single-use challenge for the native client to
+// attest with.
+func (s *Service) CreateChallenge(ctx context.Context) (*serialize.DeviceAttestationChallengeResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	challenge, expireAt, err := s.attestationService.CreateChallenge(ctx, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.DeviceAttestationChallenge(challenge, expireAt), nil
+}
+
+// Verify verifies the device attestation of native clients and records the
+// outcome in the context. If the request already carries a client, its
+// attestation status is updated as well. Browser clients are not affected,
+// unless the instance requires attestation: the client type is chosen by the
+// caller, so it can't be used to skip attestation once it's required.
+func (s *Service) Verify(ctx context.Context, params attestation.VerifyParams) (context.Context, apierror.Error) {
+	env := environment.FromContext(ctx)
+	if client_type.FromContext(ctx) != client_type.Native &&
+		attestation.EnforcementForInstance(env.Instance) != attestation.EnforcementRequired {
+		return ctx, nil
+	}
+
+	status, apiErr := s.attestationService.Verify(ctx, env.Instance, params)
+	if apiErr != nil {
+		return ctx, apiErr
+	}
+	if status == "" {
+		return ctx, nil
+	}
+
+	client, _ := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	if client != nil && client.AttestationStatus.String != string(status) {
+		cdsClient := client_data.NewClientFromClientModel(client)
+		cdsClient.AttestationStatus = null.StringFrom(string(status))
+		if err := s.clientDataService.UpdateClientAttestationStatus(ctx, env.Instance.ID, cdsClient); err != nil {
+			return ctx, apierror.Unexpected(err)
+		}
+		cdsClient.CopyToClientModel(client)
+	}
+
+	return attestation.NewContext(ctx, status), nil
+}
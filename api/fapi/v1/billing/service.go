@@ -43,9 +43,9 @@ func NewService(deps clerk.Deps, billingConnector billing.Connector, paymentProv
 		billingConnector:           billingConnector,
 		paymentProvider:            paymentProvider,
 		serializer:                 serializer.NewFactory(),
-		billingCheckoutSessionRepo: repository.NewBillingCheckoutSession(),
-		billingPlanRepo:            repository.NewBillingPlans(),
-		billingSubscriptionRepo:    repository.NewBillingSubscriptions(),
+		billingCheckoutSessionRepo: deps.Repositories().BillingCheckoutSession,
+		billingPlanRepo:            deps.Repositories().BillingPlans,
+		billingSubscriptionRepo:    deps.Repositories().BillingSubscriptions,
 	}
 }
 
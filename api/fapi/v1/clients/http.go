@@ -9,6 +9,7 @@ import (
 	fapicookies "clerk/api/fapi/v1/cookies"
 	"clerk/api/fapi/v1/dev_browser"
 	"clerk/api/fapi/v1/wrapper"
+	"clerk/api/shared/requestlog"
 	"clerk/model"
 	"clerk/pkg/cache"
 	"clerk/pkg/clerkhttp"
@@ -151,6 +152,12 @@ func (h *HTTP) Read(w http.ResponseWriter, r *http.Request) (interface{}, apierr
 	return h.wrapper.WrapResponse(ctx, client, nil)
 }
 
+// GET /v1/client/ping
+func (h *HTTP) Ping(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	w.Header().Set("Cache-Control", "no-store")
+	return h.clientService.Ping(r.Context())
+}
+
 // PUT /v1/client
 // POST /v1/client
 func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
@@ -168,8 +175,8 @@ func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (interface{}, apie
 	}
 
 	// add new client to the logline so that it's included in our logs
-	log.AddToLogLine(ctx, log.ClientID, newClient.ID)
-	log.AddToLogLine(ctx, log.RotatingToken, newClient.RotatingToken)
+	requestlog.Add(ctx, requestlog.ClientID, newClient.ID)
+	requestlog.Add(ctx, requestlog.RotatingToken, newClient.RotatingToken)
 
 	return h.cookieSetter.RespondWithCookie(ctx, w, r, newClient, nil, nil)
 }
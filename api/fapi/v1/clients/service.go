@@ -13,13 +13,17 @@ import (
 	fapicookies "clerk/api/fapi/v1/cookies"
 	"clerk/api/fapi/v1/tokens"
 	"clerk/api/serialize"
+	"clerk/api/shared/attestation"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/clients"
 	sharedcookies "clerk/api/shared/cookies"
 	"clerk/api/shared/events"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/session_activities"
+	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_in"
 	"clerk/api/shared/sign_up"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/token"
 	"clerk/model"
 	"clerk/model/sqbmodel"
@@ -46,7 +50,6 @@ import (
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
-	"clerk/utils/log"
 	pkiutils "clerk/utils/pki"
 	urlUtils "clerk/utils/url"
 
@@ -72,6 +75,7 @@ type Service struct {
 	tokenService             *token.Service
 	tokensService            *tokens.Service
 	sessionActivitiesService *session_activities.Service
+	signingKeyService        *signing_keys.Service
 
 	// repositories
 	domainRepo        *repository.Domain
@@ -97,10 +101,11 @@ func NewService(deps clerk.Deps) *Service {
 		tokenService:             token.NewService(),
 		tokensService:            tokens.NewService(deps),
 		sessionActivitiesService: session_activities.NewService(),
-		domainRepo:               repository.NewDomain(),
-		signInRepo:               repository.NewSignIn(),
-		signUpRepo:               repository.NewSignUp(),
-		syncNonceRepo:            repository.NewSyncNonces(),
+		signingKeyService:        signing_keys.NewService(deps),
+		domainRepo:               deps.Repositories().Domain,
+		signInRepo:               deps.Repositories().SignIn,
+		signUpRepo:               deps.Repositories().SignUp,
+		syncNonceRepo:            deps.Repositories().SyncNonces,
 		clientDataService:        client_data.NewService(deps),
 	}
 }
@@ -129,8 +134,8 @@ func (s *Service) SetRequestingClient(ctx context.Context, rotatingTokenNonce st
 		clientID, _ = jwt["id"].(string)
 		rotatingToken, _ = jwt["rotating_token"].(string)
 	}
-	log.AddToLogLine(ctx, log.ClientID, clientID)
-	log.AddToLogLine(ctx, log.RotatingToken, rotatingToken)
+	requestlog.Add(ctx, requestlog.ClientID, clientID)
+	requestlog.Add(ctx, requestlog.RotatingToken, rotatingToken)
 
 	// Ignore client DB errors on purpose and treat them as a 401 response
 	client, _ := s.getRequestingClient(ctx, clientID, rotatingToken, rotatingTokenNonce, env.Instance.ID)
@@ -419,9 +424,16 @@ func (s *Service) Read(ctx context.Context) (*serialize.ClientResponseClientAPI,
 		return nil, apierror.Unexpected(err)
 	}
 	for i, sessionWithUser := range clientWithSessions.CurrentSessions {
+		// native clients get the tokens of sessions with refresh tokens in
+		// exchange for them only
+		if sessions.RequiresRefreshToken(ctx, sessionWithUser.Session) {
+			continue
+		}
 		clientWithSessions.CurrentSessions[i].Token, err = token.GenerateSessionToken(
 			ctx,
 			s.clock,
+			s.cache,
+			s.signingKeyService,
 			s.db,
 			env,
 			sessionWithUser.Session,
@@ -440,6 +452,50 @@ func (s *Service) Read(ctx context.Context) (*serialize.ClientResponseClientAPI,
 	return response, nil
 }
 
+// Ping returns the status and lifetime of the sessions of the current
+// client. It's the lightweight alternative to Read for heartbeats, so it
+// neither loads the users of the sessions nor generates session tokens.
+func (s *Service) Ping(ctx context.Context) (*serialize.ClientPingResponse, apierror.Error) {
+	client, apierr := s.GetClientFromContext(ctx)
+	if apierr != nil {
+		return nil, nil
+	}
+
+	env := environment.FromContext(ctx)
+
+	var currentSessions []*client_data.Session
+	var err error
+	if cenv.IsEnabled(cenv.FlagRemoveExpiredSessions) {
+		currentSessions, err = s.clientDataService.FindAllCurrentSessionsByClientsWithoutExpiredSessions(ctx, env.Instance.ID, []string{client.ID})
+	} else {
+		currentSessions, err = s.clientDataService.FindAllCurrentSessionsByClients(ctx, env.Instance.ID, []string{client.ID})
+	}
+	if err != nil {
+		return nil, apierror.Unexpected(fmt.Errorf("clients/ping: get client's %s current sessions: %w", client.ID, err))
+	}
+
+	sessions := make([]*model.Session, len(currentSessions))
+	for i, currentSession := range currentSessions {
+		sessions[i] = currentSession.ToSessionModel()
+	}
+	return serialize.ClientPing(s.clock, client, sessions, lastActiveSession(s.clock, sessions)), nil
+}
+
+// lastActiveSession returns the active session which was touched last, which
+// is picked the same way as for the full client.
+func lastActiveSession(clock clockwork.Clock, sessions []*model.Session) *model.Session {
+	var last *model.Session
+	for _, session := range sessions {
+		if session.GetStatus(clock) != constants.SESSActive {
+			continue
+		}
+		if last == nil || session.TouchedAt.After(last.TouchedAt) {
+			last = session
+		}
+	}
+	return last
+}
+
 // Delete ends all sessions of the current client
 func (s *Service) Delete(ctx context.Context) (*serialize.ClientResponseClientAPI, apierror.Error) {
 	client, _ := s.GetClientFromContext(ctx)
@@ -499,6 +555,9 @@ func (s *Service) CreateWithDevBrowser(ctx context.Context, instance *model.Inst
 		InstanceID:    instance.ID,
 		RotatingToken: rotatingToken,
 	}})
+	if status, ok := attestation.FromContext(ctx); ok {
+		client.AttestationStatus = null.StringFrom(string(status))
+	}
 
 	if err := s.clientDataService.CreateClient(ctx, instance.ID, client); err != nil {
 		return nil, err
@@ -913,7 +972,8 @@ func (s *Service) IsRequestEligibleForHandshake(clerkJSVersion string) bool {
 }
 
 // Generate Handshake token and set in response cookies for production instances or
-// in redirect url query params for development instances
+// in redirect url query params for development instances. The token is bound to the
+// requesting device, if the handshake_token_binding setting of the instance asks for it.
 func (s *Service) SetHandshakeTokenInResponse(ctx context.Context,
 	w http.ResponseWriter, client *model.Client, redirectURL string,
 	clientType client_type.ClientType, clerkJSVersion string) (string, apierror.Error) {
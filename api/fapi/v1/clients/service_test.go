@@ -0,0 +1,58 @@
+package clients
+
+import (
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingSessions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(now)
+	newSession := func(id, status string, touchedAt time.Time) *model.Session {
+		return &model.Session{Session: &sqbmodel.Session{
+			ID:        id,
+			Status:    status,
+			TouchedAt: touchedAt,
+			ExpireAt:  now.Add(7 * 24 * time.Hour),
+			AbandonAt: now.Add(30 * 24 * time.Hour),
+		}}
+	}
+
+	// sessions which aren't active are never the last active one, even if
+	// they were touched last
+	sessions := []*model.Session{
+		newSession("sess_1", constants.SESSActive, now.Add(-time.Hour)),
+		newSession("sess_2", constants.SESSActive, now.Add(-time.Minute)),
+		newSession("sess_3", constants.SESSEnded, now),
+	}
+	last := lastActiveSession(clock, sessions)
+	require.NotNil(t, last)
+	assert.Equal(t, "sess_2", last.ID)
+	assert.Nil(t, lastActiveSession(clock, sessions[2:]))
+
+	client := &model.Client{Client: &sqbmodel.Client{ID: "client_1", UpdatedAt: now}}
+	response := serialize.ClientPing(clock, client, sessions, last)
+	assert.Equal(t, serialize.ObjectClientPing, response.Object)
+	require.NotNil(t, response.LastActiveSessionID)
+	assert.Equal(t, "sess_2", *response.LastActiveSessionID)
+	require.Len(t, response.Sessions, 3)
+	assert.Equal(t, constants.SESSEnded, response.Sessions[2].Status)
+	assert.Equal(t, now.Add(-time.Minute).UnixMilli(), response.Sessions[1].LastActiveAt)
+	assert.Equal(t, now.Add(7*24*time.Hour).UnixMilli(), response.Sessions[1].ExpireAt)
+
+	// clients without active sessions have no last active session
+	response = serialize.ClientPing(clock, client, nil, nil)
+	assert.Nil(t, response.LastActiveSessionID)
+	assert.Empty(t, response.Sessions)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"clerk/api/shared/trusted_devices"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	oven "clerk/pkg/cookies"
@@ -139,3 +140,20 @@ func NewHandshakeClear(ctx context.Context) *http.Cookie {
 		Domain:  env.Domain.Name,
 	}
 }
+
+// NewTrustedDevice returns the cookie which carries the token of a device
+// the user has trusted to skip the second factor of their sign-ins.
+func NewTrustedDevice(ctx context.Context, value string, expires time.Time) *http.Cookie {
+	env := environment.FromContext(ctx)
+
+	return &http.Cookie{
+		Name:     trusted_devices.CookieName,
+		Value:    value,
+		Path:     "/",
+		Domain:   sanitizeDomain(clientCookieDomain(env.Domain)),
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   env.Instance.IsProduction(),
+		SameSite: determineSameSite(env.Instance.ID),
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"strings"
 
 	"clerk/api/shared/client_data"
+	"clerk/api/shared/signing_keys"
 	"clerk/pkg/cookies"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctx/request_info"
 	"clerk/pkg/ctx/requesting_session"
+	"clerk/pkg/handshakebinding"
 	"clerk/pkg/jwt"
 	"clerk/pkg/psl"
 	"clerk/utils/clerk"
@@ -28,6 +31,7 @@ type Service struct {
 	clock clockwork.Clock
 
 	clientDataService *client_data.Service
+	signingKeyService *signing_keys.Service
 }
 
 func NewService(deps clerk.Deps) *Service {
@@ -35,6 +39,7 @@ func NewService(deps clerk.Deps) *Service {
 		db:                deps.DB(),
 		clock:             deps.Clock(),
 		clientDataService: client_data.NewService(deps),
+		signingKeyService: signing_keys.NewService(deps),
 	}
 }
 
@@ -157,7 +162,8 @@ func (s *Service) ClientUatDomain(env *model.Env, targetURL *url.URL) (string, e
 }
 
 type handshakeClaims struct {
-	Handshake []string `json:"handshake"`
+	Handshake []string                  `json:"handshake"`
+	Binding   *handshakebinding.Binding `json:"binding,omitempty"`
 }
 
 // Create an encoded client handshake cookie, containing an encoded new session token
@@ -188,6 +194,7 @@ func (s *Service) CreateEncodedHandshakeCookie(ctx context.Context, extraCookies
 
 	tokenPayload := &handshakeClaims{
 		Handshake: setCookieDirectives,
+		Binding:   handshakeBinding(ctx, env.AuthConfig.SessionSettings.HandshakeTokenBinding),
 	}
 
 	jwtCat := jwt.ClerkSessionTokenCategory
@@ -195,11 +202,16 @@ func (s *Service) CreateEncodedHandshakeCookie(ctx context.Context, extraCookies
 		jwtCat = jwt.ClerkIgnoreTokenCategory
 	}
 
+	signingKey, err := s.signingKeyService.Current(ctx, s.db, env.Instance)
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := jwt.GenerateToken(
-		env.Instance.PrivateKey,
+		signingKey.PrivateKey,
 		tokenPayload,
-		env.Instance.KeyAlgorithm,
-		jwt.WithKID(env.Instance.ID),
+		signingKey.Algorithm,
+		jwt.WithKID(signingKey.KID),
 		jwt.WithCategory(jwtCat),
 	)
 	if err != nil {
@@ -209,6 +221,19 @@ func (s *Service) CreateEncodedHandshakeCookie(ctx context.Context, extraCookies
 	return NewHandshake(ctx, token), nil
 }
 
+// handshakeBinding returns the binding of a handshake token issued for the
+// current request, for the strictness setting of the instance, so that the
+// token can't be replayed from another device.
+func handshakeBinding(ctx context.Context, strictness string) *handshakebinding.Binding {
+	requestInfo := request_info.FromContext(ctx)
+	if requestInfo == nil {
+		return nil
+	}
+	return handshakebinding.New(strictness, handshakebinding.Client{
+		UserAgent: requestInfo.UserAgent,
+	})
+}
+
 // Returns a cookie with default values for most attributes.
 func newDefault(name, value, domain string, sameSiteMode http.SameSite) *http.Cookie {
 	return &http.Cookie{
@@ -13,6 +13,7 @@ import (
 	"clerk/api/serialize"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/clients"
+	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_in"
 	"clerk/api/shared/sign_up"
 	"clerk/model"
@@ -57,8 +58,8 @@ func NewCookieSetter(deps clerk.Deps) *CookieSetter {
 		signInService:     sign_in.NewService(deps),
 		signUpService:     sign_up.NewService(deps),
 		clientDataService: client_data.NewService(deps),
-		signInRepo:        repository.NewSignIn(),
-		signUpRepo:        repository.NewSignUp(),
+		signInRepo:        deps.Repositories().SignIn,
+		signUpRepo:        deps.Repositories().SignUp,
 	}
 }
 
@@ -300,6 +301,9 @@ func (s *CookieSetter) RespondWithCookie(
 	_, authHeaderExists := r.Header["Authorization"]
 	if authHeaderExists || clientType.IsNative() {
 		w.Header().Set("Authorization", client.CookieValue.String)
+		if issued := sessions.IssuedRefreshTokenFromContext(ctx); issued != nil && apiErr == nil {
+			w.Header().Set(sessions.RefreshTokenHeader, issued.Token)
+		}
 
 		if apiErr != nil {
 			return nil, s.wrapper.WrapError(ctx, apiErr, client)
@@ -48,8 +48,8 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 		cache:          deps.Cache(),
 		db:             deps.DB(),
 		service:        NewService(deps),
-		devBrowserRepo: repository.NewDevBrowser(),
-		instanceRepo:   repository.NewInstances(),
+		devBrowserRepo: deps.Repositories().DevBrowser,
+		instanceRepo:   deps.Repositories().Instances,
 		gueClient:      deps.GueClient(),
 	}
 }
@@ -33,7 +33,7 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		cache:          deps.Cache(),
 		db:             deps.DB(),
-		devBrowserRepo: repository.NewDevBrowser(),
+		devBrowserRepo: deps.Repositories().DevBrowser,
 	}
 }
 
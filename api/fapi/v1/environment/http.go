@@ -4,16 +4,17 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
-	"clerk/utils/database"
+	"clerk/api/shared/environment"
+	"clerk/utils/clerk"
 )
 
 type HTTP struct {
 	service *Service
 }
 
-func NewHTTP(db database.Database) *HTTP {
+func NewHTTP(deps clerk.Deps, envCache *environment.Cache) *HTTP {
 	return &HTTP{
-		service: NewService(db),
+		service: NewService(deps, envCache),
 	}
 }
 
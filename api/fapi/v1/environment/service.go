@@ -6,7 +6,10 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/debug_logging"
 	"clerk/api/shared/environment"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/sentryenv"
 	"clerk/api/shared/sso"
 	"clerk/model"
@@ -18,8 +21,8 @@ import (
 	"clerk/pkg/oauth/provider"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
-	"clerk/utils/log"
 	"clerk/utils/url"
 
 	"github.com/volatiletech/null/v8"
@@ -29,7 +32,8 @@ type Service struct {
 	db database.Database
 
 	// services
-	environmentService *environment.Service
+	debugLoggingService *debug_logging.Service
+	environmentService  *environment.Service
 
 	// repositories
 	applicationOwnershipRepo *repository.ApplicationOwnerships
@@ -37,13 +41,14 @@ type Service struct {
 	imageRepo                *repository.Images
 }
 
-func NewService(db database.Database) *Service {
+func NewService(deps clerk.Deps, envCache *environment.Cache) *Service {
 	return &Service{
-		db:                       db,
-		environmentService:       environment.NewService(),
-		applicationOwnershipRepo: repository.NewApplicationOwnerships(),
-		devBrowserRepo:           repository.NewDevBrowser(),
-		imageRepo:                repository.NewImages(),
+		db:                       deps.DB(),
+		debugLoggingService:      debug_logging.NewService(deps),
+		environmentService:       environment.NewCachedService(envCache),
+		applicationOwnershipRepo: deps.Repositories().ApplicationOwnerships,
+		devBrowserRepo:           deps.Repositories().DevBrowser,
+		imageRepo:                deps.Repositories().Images,
 	}
 }
 
@@ -56,12 +61,14 @@ func (s *Service) SetEnvFromDomain(ctx context.Context) (context.Context, apierr
 		return ctx, apierror.Unexpected(err)
 	}
 
-	log.AddToLogLine(ctx, log.InstanceID, env.Instance.ID)
-	log.AddToLogLine(ctx, log.EnvironmentType, env.Instance.EnvironmentType)
-	log.AddToLogLine(ctx, log.DomainName, env.Domain.Name)
+	requestlog.Add(ctx, requestlog.InstanceID, env.Instance.ID)
+	requestlog.Add(ctx, requestlog.EnvironmentType, env.Instance.EnvironmentType)
+	requestlog.Add(ctx, requestlog.DomainName, env.Domain.Name)
+	s.debugLoggingService.WithDebugLogging(ctx, env.Instance)
 
 	sentryenv.EnrichScope(ctx, env)
-	return ctxenv.NewContext(ctx, env), nil
+	ctx = ctxenv.NewContext(ctx, env)
+	return phone_profiles.NewContext(ctx, env.Instance), nil
 }
 
 // Read returns the environment of an instance
@@ -51,11 +51,11 @@ func NewService(deps clerk.Deps) *Service {
 		identificationService: identifications.NewService(deps),
 		serializableService:   serializable.NewService(deps.Clock()),
 		userService:           users.NewService(deps),
-		authConfigRepo:        repository.NewAuthConfig(),
-		externalAccountRepo:   repository.NewExternalAccount(),
-		identificationRepo:    repository.NewIdentification(),
-		userRepo:              repository.NewUsers(),
-		verificationRepo:      repository.NewVerification(),
+		authConfigRepo:        deps.Repositories().AuthConfig,
+		externalAccountRepo:   deps.Repositories().ExternalAccount,
+		identificationRepo:    deps.Repositories().Identification,
+		userRepo:              deps.Repositories().Users,
+		verificationRepo:      deps.Repositories().Verification,
 	}
 }
 
@@ -249,6 +249,23 @@ func (s *Service) Create(ctx context.Context, exec database.Executor, ver *model
 // identification exists, a new one will be created, verified and linked with the external account identification
 // as the target identification.
 func (s Service) CreateAndLink(ctx context.Context, exec database.Executor, ver *model.Verification, ost *model.OauthStateToken, oauthUser *oauth.User, instance *model.Instance, userID *string, userSettings *usersettings.UserSettings) (*CreateResult, error) {
+	var user *model.User
+	var previousUser events.UserSnapshot
+	if userID != nil {
+		var err error
+		user, err = s.userRepo.QueryByIDAndInstance(ctx, exec, *userID, instance.ID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		} else if user == nil {
+			return nil, apierror.UserNotFound(*userID)
+		}
+
+		previousUser, err = s.serializableService.SnapshotUser(ctx, exec, userSettings, user)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	res, err := s.Create(ctx, exec, ver, ost, oauthUser, instance.ID, userID)
 	if err != nil {
 		return nil, err
@@ -259,22 +276,16 @@ func (s Service) CreateAndLink(ctx context.Context, exec database.Executor, ver
 	}
 	res.Identification = ident
 
-	if userID == nil {
+	if user == nil {
 		return res, nil
 	}
 
-	user, err := s.userRepo.QueryByIDAndInstance(ctx, exec, *userID, instance.ID)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	} else if user == nil {
-		return nil, apierror.UserNotFound(*userID)
-	}
 	updated, err := s.updateUserData(ctx, exec, user, oauthUser)
 	if err != nil {
 		return nil, err
 	}
 	if updated {
-		if err := s.sendUserUpdatedEvent(ctx, exec, instance, userSettings, user); err != nil {
+		if err := s.sendUserUpdatedEvent(ctx, exec, instance, userSettings, previousUser, user); err != nil {
 			return nil, err
 		}
 	}
@@ -371,6 +382,11 @@ func (s Service) Connect(ctx context.Context, exec database.Executor, ver *model
 // (e.g. scopes) and we will update the relevant columns (approved scopes, access token, refresh token etc) of
 // the existing external account.
 func (s *Service) Reauthorize(ctx context.Context, tx database.Tx, userSettings *usersettings.UserSettings, instance *model.Instance, user *model.User, ost *model.OauthStateToken, oauthUser *oauth.User) error {
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+	if err != nil {
+		return err
+	}
+
 	externalAccount, err := s.externalAccountRepo.QueryByIDAndInstance(ctx, tx, ost.SourceID, instance.ID)
 	if err != nil {
 		return err
@@ -416,7 +432,7 @@ func (s *Service) Reauthorize(ctx context.Context, tx database.Tx, userSettings
 		return err
 	}
 
-	return s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, user)
+	return s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user)
 }
 
 // CreateOrLinkEmailIdentification checks if we need to create any email identification after a successful OAuth flow,
@@ -585,6 +601,19 @@ func (s Service) Update(ctx context.Context, tx database.Tx, userSettings *users
 		return nil, err
 	}
 
+	user, err := s.userRepo.QueryByInstanceAndIdentificationID(ctx, tx, instance.ID, account.IdentificationID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, apierror.IdentificationNotFound(account.IdentificationID)
+	}
+
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+	if err != nil {
+		return nil, err
+	}
+
 	// The existing external account doesn't contain an email address, but the OAuth provider now provided us with one
 	// Along with the regular update flow, we should create the corresponding email address identification and
 	// link it with the existing OAuth identification
@@ -657,21 +686,13 @@ func (s Service) Update(ctx context.Context, tx database.Tx, userSettings *users
 		return nil, err
 	}
 
-	user, err := s.userRepo.QueryByInstanceAndIdentificationID(ctx, tx, instance.ID, account.IdentificationID)
-	if err != nil {
-		return nil, err
-	}
-	if user == nil {
-		return nil, apierror.IdentificationNotFound(account.IdentificationID)
-	}
-
 	userUpdated, err := s.updateUserData(ctx, tx, user, oauthUser)
 	if err != nil {
 		return nil, err
 	}
 
 	if userUpdated || scopesChanged {
-		if err := s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, user); err != nil {
+		if err := s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user); err != nil {
 			return nil, err
 		}
 	}
@@ -747,13 +768,14 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
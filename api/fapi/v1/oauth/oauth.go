@@ -19,6 +19,8 @@ import (
 	"clerk/api/shared/environment"
 	"clerk/api/shared/events"
 	"clerk/api/shared/identifications"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/restrictions"
 	"clerk/api/shared/saml"
 	"clerk/api/shared/sentryenv"
@@ -104,7 +106,7 @@ func New(deps clerk.Deps) *OAuth {
 		environmentService:     environment.NewService(),
 		eventService:           events.NewService(deps),
 		externalAccountService: external_account.NewService(deps),
-		restrictionService:     restrictions.NewService(deps.EmailQualityChecker()),
+		restrictionService:     restrictions.NewService(deps),
 		serializableService:    serializable.NewService(deps.Clock()),
 		signInService:          sign_in.NewService(deps),
 		signUpService:          sign_up.NewService(deps),
@@ -115,21 +117,21 @@ func New(deps clerk.Deps) *OAuth {
 		sessionService:         sessions.NewService(deps),
 		clientDataService:      client_data.NewService(deps),
 
-		accountTransfersRepo:    repository.NewAccountTransfers(),
-		allowlistRepo:           repository.NewAllowlist(),
-		domainRepo:              repository.NewDomain(),
-		enabledSSOProviderRepo:  repository.NewEnabledSSOProviders(),
-		externalAccountRepo:     repository.NewExternalAccount(),
-		identificationRepo:      repository.NewIdentification(),
-		instanceRepo:            repository.NewInstances(),
-		oauth1RequestTokensRepo: repository.NewOAuth1RequestTokens(),
-		oauthConfigRepo:         repository.NewOauthConfig(),
-		redirectUrlsRepo:        repository.NewRedirectUrls(),
+		accountTransfersRepo:    deps.Repositories().AccountTransfers,
+		allowlistRepo:           deps.Repositories().Allowlist,
+		domainRepo:              deps.Repositories().Domain,
+		enabledSSOProviderRepo:  deps.Repositories().EnabledSSOProviders,
+		externalAccountRepo:     deps.Repositories().ExternalAccount,
+		identificationRepo:      deps.Repositories().Identification,
+		instanceRepo:            deps.Repositories().Instances,
+		oauth1RequestTokensRepo: deps.Repositories().OAuth1RequestTokens,
+		oauthConfigRepo:         deps.Repositories().OauthConfig,
+		redirectUrlsRepo:        deps.Repositories().RedirectUrls,
 		samlService:             saml.New(),
-		signInRepo:              repository.NewSignIn(),
-		signUpRepo:              repository.NewSignUp(),
-		userRepo:                repository.NewUsers(),
-		verificationRepo:        repository.NewVerification(),
+		signInRepo:              deps.Repositories().SignIn,
+		signUpRepo:              deps.Repositories().SignUp,
+		userRepo:                deps.Repositories().Users,
+		verificationRepo:        deps.Repositories().Verification,
 	}
 }
 
@@ -190,13 +192,14 @@ func (o *OAuth) SetEnvironmentFromStateParam(_ http.ResponseWriter, r *http.Requ
 		return r.WithContext(ctx), apierror.Unexpected(err)
 	}
 
-	log.AddToLogLine(ctx, log.InstanceID, env.Instance.ID)
-	log.AddToLogLine(ctx, log.EnvironmentType, env.Instance.EnvironmentType)
-	log.AddToLogLine(ctx, log.DomainName, env.Domain.Name)
+	requestlog.Add(ctx, requestlog.InstanceID, env.Instance.ID)
+	requestlog.Add(ctx, requestlog.EnvironmentType, env.Instance.EnvironmentType)
+	requestlog.Add(ctx, requestlog.DomainName, env.Domain.Name)
 
 	sentryenv.EnrichScope(ctx, env)
 
 	ctx = ctxenv.NewContext(ctx, env)
+	ctx = phone_profiles.NewContext(ctx, env.Instance)
 
 	return r.WithContext(ctx), nil
 }
@@ -619,7 +622,10 @@ func (o *OAuth) Callback(w http.ResponseWriter, r *http.Request) (_ interface{},
 	}
 
 	if createdSession != nil {
-		if err := o.sessionService.Activate(ctx, env.Instance, createdSession); err != nil {
+		if err := o.sessionService.Activate(ctx, env, createdSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, apiErr
+			}
 			return nil, apierror.Unexpected(err)
 		}
 	}
@@ -837,7 +843,17 @@ func (o *OAuth) finishOauthForSignIn(
 
 	externalAccountExists := extAccIdent != nil
 
+	var previousUser events.UserSnapshot
 	if !externalAccountExists {
+		existingUser, err := o.userRepo.FindByIDAndInstance(ctx, tx, *existingUserID, env.Instance.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		previousUser, err = o.serializableService.SnapshotUser(ctx, tx, userSettings, existingUser)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		creationResult, err := o.externalAccountService.CreateAndLink(ctx, tx, ver, ost, oauthUser, env.Instance, existingUserID, userSettings)
 		if err != nil {
 			return nil, nil, err
@@ -894,7 +910,7 @@ func (o *OAuth) finishOauthForSignIn(
 	if readyToConvert {
 		// Trigger a 'user.updated' event only if a new external account has been created
 		if !externalAccountExists {
-			if err = o.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+			if err = o.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 				return nil, nil, fmt.Errorf("finishOauthForSignIn: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 			}
 		}
@@ -1182,6 +1198,15 @@ func (o OAuth) finishConnectOauthAccount(
 		return apierror.IdentifierNotAllowedAccess(oauthUser.EmailAddress)
 	}
 
+	connectingUser, err := o.userRepo.FindByID(ctx, tx, ost.SourceID)
+	if err != nil {
+		return err
+	}
+	previousUser, err := o.serializableService.SnapshotUser(ctx, tx, userSettings, connectingUser)
+	if err != nil {
+		return err
+	}
+
 	err = o.externalAccountService.Connect(ctx, tx, ver, ost, oauthUser, instance.ID, ost.SourceID, userSettings)
 	if err != nil {
 		return err
@@ -1198,7 +1223,7 @@ func (o OAuth) finishConnectOauthAccount(
 			return err
 		}
 	}
-	if err := o.sendUserUpdatedEvent(ctx, tx, instance, userSettings, user); err != nil {
+	if err := o.sendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user); err != nil {
 		return fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, instance.ID, err)
 	}
 
@@ -1501,13 +1526,14 @@ func (o OAuth) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := o.serializableService.ConvertUser(ctx, exec, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = o.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = o.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
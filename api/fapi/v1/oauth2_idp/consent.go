@@ -0,0 +1,155 @@
+package oauth2_idp
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/oauth_applications"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctx/oauth_idp_state"
+	"clerk/pkg/oauth2idp"
+	"clerk/utils/param"
+)
+
+// consentPath is the page of the Account Portal where users review the
+// scopes an OAuth application asks for.
+const consentPath = "/oauth-consent"
+
+// paramScope is the space separated list of scopes which the user consents
+// to.
+var paramScope = param.NewSingle(param.T.String, "scope", nil)
+
+// ConsentRedirectURL returns the URL of the consent screen when the signed
+// in user has to consent to the application before it's authorized, or an
+// empty string otherwise. The consent screen sends the user back to the
+// authorization request once they consent.
+func (s *Service) ConsentRedirectURL(ctx context.Context, form url.Values) (string, apierror.Error) {
+	state := oauth_idp_state.FromContext(ctx)
+	if state == nil || state.User == nil {
+		// the provider asks the user to sign in first
+		return "", nil
+	}
+
+	env := environment.FromContext(ctx)
+	clientID := form.Get("client_id")
+	oa, err := s.oauthApplicationsRepo.QueryByClientIDAndInstance(ctx, s.db, clientID, env.Instance.ID)
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	if oa == nil {
+		// invalid clients are rejected by the provider
+		return "", nil
+	}
+
+	requiresConsent, err := s.oauthApplicationService.RequiresConsent(ctx, s.db, oa, state.User.ID, strings.Fields(form.Get(paramScope.Name)))
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	if !requiresConsent {
+		return "", nil
+	}
+
+	query := url.Values{}
+	query.Set("client_id", clientID)
+	query.Set(paramScope.Name, form.Get(paramScope.Name))
+	query.Set("redirect_url", env.Domain.OAuthAuthorizeURL()+"?"+form.Encode())
+	return env.Domain.AccountsURL() + consentPath + "?" + query.Encode(), nil
+}
+
+// ReadConsent returns what the consent screen shows to the user about the
+// application and the scopes it asks for.
+func (s *Service) ReadConsent(ctx context.Context, userID, clientID, scope string) (*serialize.OAuthConsentResponse, apierror.Error) {
+	oa, apiErr := s.fetchApplication(ctx, clientID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	consent, err := s.oauthApplicationService.Consent(ctx, s.db, oa, userID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return consentResponse(oa, consent, scope), nil
+}
+
+// GrantConsent records that the user consents to the application accessing
+// the given scopes, which must all be scopes of the application.
+func (s *Service) GrantConsent(ctx context.Context, userID, clientID, scope string) (*serialize.OAuthConsentResponse, apierror.Error) {
+	oa, apiErr := s.fetchApplication(ctx, clientID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	scopes, err := oauth2idp.ParseScopes(scope, oa.Scopes)
+	if err != nil || scopes == "" {
+		return nil, apierror.FormInvalidParameterValueWithAllowed(paramScope.Name, scope, strings.Fields(oa.Scopes))
+	}
+
+	consent, err := s.oauthApplicationService.GrantConsent(ctx, s.db, oa, userID, strings.Fields(scopes))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return consentResponse(oa, consent, scopes), nil
+}
+
+// RevokeConsent deletes the consent of the user to the application. Tokens
+// which were already issued stay valid until they expire.
+func (s *Service) RevokeConsent(ctx context.Context, userID, clientID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	oa, apiErr := s.fetchApplication(ctx, clientID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	revoked, err := s.oauthApplicationService.RevokeConsent(ctx, s.db, oa, userID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if !revoked {
+		return nil, apierror.ResourceNotFound()
+	}
+	return serialize.DeletedObject(oa.ClientID, serialize.ObjectOAuthConsent), nil
+}
+
+func (s *Service) fetchApplication(ctx context.Context, clientID string) (*model.OAuthApplication, apierror.Error) {
+	env := environment.FromContext(ctx)
+	oa, err := s.oauthApplicationsRepo.QueryByClientIDAndInstance(ctx, s.db, clientID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if oa == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	return oa, nil
+}
+
+// consentResponse describes the requested scopes, or all the scopes of the
+// application if none were requested, and whether the user already granted
+// them.
+func consentResponse(oa *model.OAuthApplication, consent *model.OAuthApplicationConsent, scope string) *serialize.OAuthConsentResponse {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		requested = strings.Fields(oa.Scopes)
+	}
+
+	granted := map[string]bool{}
+	if consent != nil {
+		for _, grantedScope := range strings.Fields(consent.Scopes) {
+			granted[grantedScope] = true
+		}
+	}
+
+	scopes := make([]serialize.OAuthConsentScopeResponse, len(requested))
+	consentRequired := false
+	for i, requestedScope := range requested {
+		scopes[i] = serialize.OAuthConsentScopeResponse{
+			Scope:       requestedScope,
+			Description: oauth_applications.ScopeDescription(requestedScope),
+			Granted:     granted[requestedScope],
+		}
+		consentRequired = consentRequired || !granted[requestedScope]
+	}
+	return serialize.OAuthConsent(oa, scopes, oa.ConsentScreenEnabled && consentRequired)
+}
@@ -0,0 +1,92 @@
+package oauth2_idp
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsentResponse(t *testing.T) {
+	t.Parallel()
+
+	oa := &model.OAuthApplication{OauthApplication: &sqbmodel.OauthApplication{
+		ClientID:             "client_1",
+		Name:                 "Acme",
+		Scopes:               "openid profile email",
+		ConsentScreenEnabled: true,
+	}}
+	consent := &model.OAuthApplicationConsent{OauthApplicationConsent: &sqbmodel.OauthApplicationConsent{
+		Scopes: "openid profile",
+	}}
+
+	tests := []struct {
+		name            string
+		oa              *model.OAuthApplication
+		consent         *model.OAuthApplicationConsent
+		scope           string
+		wantGranted     map[string]bool
+		consentRequired bool
+	}{
+		{
+			name:            "no consent yet",
+			oa:              oa,
+			scope:           "openid email",
+			wantGranted:     map[string]bool{"openid": false, "email": false},
+			consentRequired: true,
+		},
+		{
+			name:            "all requested scopes granted",
+			oa:              oa,
+			consent:         consent,
+			scope:           "openid profile",
+			wantGranted:     map[string]bool{"openid": true, "profile": true},
+			consentRequired: false,
+		},
+		{
+			name:            "new scope requested",
+			oa:              oa,
+			consent:         consent,
+			scope:           "profile email",
+			wantGranted:     map[string]bool{"profile": true, "email": false},
+			consentRequired: true,
+		},
+		{
+			name:            "all application scopes when none are requested",
+			oa:              oa,
+			consent:         consent,
+			wantGranted:     map[string]bool{"openid": true, "profile": true, "email": false},
+			consentRequired: true,
+		},
+		{
+			name: "consent screen disabled",
+			oa: &model.OAuthApplication{OauthApplication: &sqbmodel.OauthApplication{
+				ClientID: "client_2",
+				Scopes:   "openid",
+			}},
+			scope:           "openid",
+			wantGranted:     map[string]bool{"openid": false},
+			consentRequired: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := consentResponse(tt.oa, tt.consent, tt.scope)
+			assert.Equal(t, tt.oa.ClientID, resp.ClientID)
+			assert.Equal(t, tt.consentRequired, resp.ConsentRequired)
+
+			granted := map[string]bool{}
+			for _, scope := range resp.Scopes {
+				assert.NotEmpty(t, scope.Description)
+				granted[scope.Scope] = scope.Granted
+			}
+			assert.Equal(t, tt.wantGranted, granted)
+		})
+	}
+}
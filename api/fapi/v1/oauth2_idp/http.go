@@ -4,21 +4,34 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/fapi/v1/wrapper"
+	"clerk/model"
+	"clerk/pkg/ctx/requesting_user"
+	"clerk/pkg/ctxkeys"
 	"clerk/pkg/oauth2idp"
 	"clerk/utils/clerk"
+	"clerk/utils/form"
+	"clerk/utils/param"
 	"clerk/utils/url"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type HTTP struct {
 	service  *Service
 	provider *oauth2idp.Provider
+	wrapper  *wrapper.Wrapper
 }
 
 func NewHTTP(deps clerk.Deps) *HTTP {
+	service := NewService(deps)
 	provider := oauth2idp.New(deps)
+	provider.Server.Manager = &idTokenManager{Manager: provider.Server.Manager, issuer: service}
+	provider.Server.SetExtensionFieldsHandler(IDTokenFields)
 	return &HTTP{
-		service:  NewService(deps),
+		service:  service,
 		provider: provider,
+		wrapper:  wrapper.NewWrapper(deps),
 	}
 }
 
@@ -50,6 +63,15 @@ func (h *HTTP) Authorize(w http.ResponseWriter, r *http.Request) (interface{}, a
 		r.Form.Set("scope", oauth2idp.FormattedDefaultScopes())
 	}
 
+	consentURL, apiErr := h.service.ConsentRedirectURL(r.Context(), r.Form)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if consentURL != "" {
+		http.Redirect(w, r, consentURL, http.StatusFound)
+		return nil, nil
+	}
+
 	err := h.provider.Server.HandleAuthorizeRequest(w, r)
 	if err != nil {
 		if apiErr, isAPIErr := apierror.As(err); isAPIErr {
@@ -74,3 +96,41 @@ func (h *HTTP) Token(w http.ResponseWriter, r *http.Request) (interface{}, apier
 func (h *HTTP) UserInfo(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	return h.service.UserInfo(r.Context())
 }
+
+// GET /v1/me/oauth/consents/{clientID}
+func (h *HTTP) ReadConsent(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	user := requesting_user.FromContext(ctx)
+	return h.service.ReadConsent(ctx, user.ID, chi.URLParam(r, "clientID"), r.URL.Query().Get(paramScope.Name))
+}
+
+// POST /v1/me/oauth/consents/{clientID}
+func (h *HTTP) GrantConsent(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	err := form.Check(r.Form, param.NewList(param.NewSet(paramScope), param.NewSet()))
+	if err != nil {
+		return nil, err
+	}
+
+	user := requesting_user.FromContext(ctx)
+	resp, err := h.service.GrantConsent(ctx, user.ID, chi.URLParam(r, "clientID"), *form.GetString(r.Form, paramScope.Name))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
+
+// DELETE /v1/me/oauth/consents/{clientID}
+func (h *HTTP) RevokeConsent(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	user := requesting_user.FromContext(ctx)
+	resp, err := h.service.RevokeConsent(ctx, user.ID, chi.URLParam(r, "clientID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, resp, client)
+}
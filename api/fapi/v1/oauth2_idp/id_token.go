@@ -0,0 +1,219 @@
+package oauth2_idp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"clerk/api/shared/oauth_applications"
+	"clerk/model"
+	"clerk/pkg/ctx/requestdomain"
+	"clerk/pkg/jwt"
+	"clerk/repository"
+
+	"github.com/go-oauth2/oauth2/v4"
+	"github.com/volatiletech/null/v8"
+)
+
+// idTokenLifetime is for how long ID tokens are valid. Applications are
+// expected to verify them right after the code exchange.
+const idTokenLifetime = time.Hour
+
+// paramNonce is the value which the application passes to the authorization
+// request, and expects back in the ID token to mitigate replays.
+const paramNonce = "nonce"
+
+// idTokenIssuer stores the nonces of authorization codes and signs the ID
+// tokens they're exchanged for. It's implemented by Service.
+type idTokenIssuer interface {
+	storeCodeNonce(ctx context.Context, code, nonce string) error
+	codeNonce(ctx context.Context, code string) (string, error)
+	signIDToken(ctx context.Context, clientID, userID string, scopes []string, nonce string) (string, error)
+}
+
+// idTokenManager wraps the manager of the provider, so that ID tokens are
+// issued along with the access tokens of OpenID Connect grants, within the
+// context of the token request. If the ID token can't be issued, the whole
+// token request fails, instead of returning a response without it.
+type idTokenManager struct {
+	oauth2.Manager
+	issuer idTokenIssuer
+}
+
+// idTokenInfo is an issued token which carries the ID token that's added to
+// the token response.
+type idTokenInfo struct {
+	oauth2.TokenInfo
+	idToken string
+}
+
+// GenerateAuthToken issues an authorization code, and stores the nonce of
+// the authorization request with it.
+func (m *idTokenManager) GenerateAuthToken(ctx context.Context, rt oauth2.ResponseType, tgr *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	ti, err := m.Manager.GenerateAuthToken(ctx, rt, tgr)
+	if err != nil || tgr.Request == nil {
+		return ti, err
+	}
+
+	nonce := tgr.Request.FormValue(paramNonce)
+	if nonce == "" || ti.GetCode() == "" {
+		return ti, nil
+	}
+	if err := m.issuer.storeCodeNonce(ctx, ti.GetCode(), nonce); err != nil {
+		return nil, err
+	}
+	return ti, nil
+}
+
+// GenerateAccessToken issues an access token, along with an ID token when
+// the openid scope was granted, which turns the OAuth 2.0 flow into an
+// OpenID Connect one.
+func (m *idTokenManager) GenerateAccessToken(ctx context.Context, gt oauth2.GrantType, tgr *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	var nonce string
+	if gt == oauth2.AuthorizationCode {
+		// the code is deleted once it's exchanged, so its nonce is read first
+		var err error
+		nonce, err = m.issuer.codeNonce(ctx, tgr.Code)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ti, err := m.Manager.GenerateAccessToken(ctx, gt, tgr)
+	if err != nil {
+		return ti, err
+	}
+	return m.withIDToken(ctx, ti, nonce)
+}
+
+// RefreshAccessToken refreshes an access token, along with its ID token when
+// the openid scope was granted.
+func (m *idTokenManager) RefreshAccessToken(ctx context.Context, tgr *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	ti, err := m.Manager.RefreshAccessToken(ctx, tgr)
+	if err != nil {
+		return ti, err
+	}
+	return m.withIDToken(ctx, ti, "")
+}
+
+func (m *idTokenManager) withIDToken(ctx context.Context, ti oauth2.TokenInfo, nonce string) (oauth2.TokenInfo, error) {
+	scopes := strings.Fields(ti.GetScope())
+	if !slices.Contains(scopes, oauth_applications.OpenIDScope) {
+		return ti, nil
+	}
+
+	idToken, err := m.issuer.signIDToken(ctx, ti.GetClientID(), ti.GetUserID(), scopes, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return idTokenInfo{TokenInfo: ti, idToken: idToken}, nil
+}
+
+// IDTokenFields adds the ID token issued by idTokenManager to the token
+// response.
+func IDTokenFields(ti oauth2.TokenInfo) map[string]interface{} {
+	info, ok := ti.(idTokenInfo)
+	if !ok || info.idToken == "" {
+		return nil
+	}
+	return map[string]interface{}{"id_token": info.idToken}
+}
+
+// storeCodeNonce stores the nonce with the authorization code it was
+// requested for.
+func (s *Service) storeCodeNonce(ctx context.Context, code, nonce string) error {
+	oat, err := s.fetchCode(ctx, code)
+	if err != nil {
+		return fmt.Errorf("oauth2_idp/storeCodeNonce: %w", err)
+	}
+	if oat == nil {
+		return fmt.Errorf("oauth2_idp/storeCodeNonce: authorization code not found")
+	}
+
+	oat.Nonce = null.StringFrom(nonce)
+	if err := s.oauthApplicationTokensRepo.UpdateNonce(ctx, s.db, oat); err != nil {
+		return fmt.Errorf("oauth2_idp/storeCodeNonce: updating code %s: %w", oat.ID, err)
+	}
+	return nil
+}
+
+// codeNonce returns the nonce stored with the authorization code, if any.
+// Invalid codes are left for the provider to reject.
+func (s *Service) codeNonce(ctx context.Context, code string) (string, error) {
+	oat, err := s.fetchCode(ctx, code)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/codeNonce: %w", err)
+	}
+	if oat == nil {
+		return "", nil
+	}
+	return oat.Nonce.String, nil
+}
+
+func (s *Service) fetchCode(ctx context.Context, code string) (*model.OAuthApplicationToken, error) {
+	domain := requestdomain.FromContext(ctx)
+	oat, err := s.oauthApplicationTokensRepo.QueryByTokenAndTypeAndInstance(ctx, s.db, code, repository.OAuthIDPTypeAuthorizationCode, domain.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching authorization code: %w", err)
+	}
+	return oat, nil
+}
+
+// signIDToken signs an ID token for the user with the current key of their
+// instance, carrying the claims of the granted scopes.
+func (s *Service) signIDToken(ctx context.Context, clientID, userID string, scopes []string, nonce string) (string, error) {
+	user, err := s.usersRepo.QueryByID(ctx, s.db, userID)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: fetching user %s: %w", userID, err)
+	}
+	if user == nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: user %s not found", userID)
+	}
+
+	instance, err := s.instanceRepo.FindByID(ctx, s.db, user.InstanceID)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: fetching instance %s: %w", user.InstanceID, err)
+	}
+	domain, err := s.domainRepo.FindByID(ctx, s.db, instance.ActiveDomainID)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: fetching domain of instance %s: %w", instance.ID, err)
+	}
+
+	info, err := s.userInfo(ctx, user)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: fetching profile of user %s: %w", user.ID, err)
+	}
+	info.PublicMetadata = json.RawMessage(user.PublicMetadata)
+	info.UnsafeMetadata = json.RawMessage(user.UnsafeMetadata)
+	info.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
+
+	claims := idTokenClaims(info, scopes, domain.FapiURL(), clientID, nonce, s.clock.Now().UTC())
+
+	signingKey, err := s.signingKeyService.Current(ctx, s.db, instance)
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: fetching signing key of instance %s: %w", instance.ID, err)
+	}
+
+	token, err := jwt.GenerateToken(signingKey.PrivateKey, claims, signingKey.Algorithm, jwt.WithKID(signingKey.KID))
+	if err != nil {
+		return "", fmt.Errorf("oauth2_idp/signIDToken: signing token for user %s: %w", user.ID, err)
+	}
+	return token, nil
+}
+
+// idTokenClaims returns the claims of an ID token issued by the issuer to
+// the client at the given time.
+func idTokenClaims(info model.OAuthUserInfo, scopes []string, issuer, clientID, nonce string, now time.Time) map[string]any {
+	claims := oauth_applications.Claims(info, scopes)
+	claims["iss"] = issuer
+	claims["aud"] = clientID
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(idTokenLifetime).Unix()
+	if nonce != "" {
+		claims[paramNonce] = nonce
+	}
+	return claims
+}
@@ -0,0 +1,137 @@
+package oauth2_idp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"clerk/model"
+
+	"github.com/go-oauth2/oauth2/v4"
+	"github.com/go-oauth2/oauth2/v4/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeManager issues the given token for any request.
+type fakeManager struct {
+	oauth2.Manager
+	token oauth2.TokenInfo
+}
+
+func (m *fakeManager) GenerateAuthToken(context.Context, oauth2.ResponseType, *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	return m.token, nil
+}
+
+func (m *fakeManager) GenerateAccessToken(context.Context, oauth2.GrantType, *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	return m.token, nil
+}
+
+func (m *fakeManager) RefreshAccessToken(context.Context, *oauth2.TokenGenerateRequest) (oauth2.TokenInfo, error) {
+	return m.token, nil
+}
+
+// fakeIssuer keeps nonces in memory and "signs" ID tokens as their nonce.
+type fakeIssuer struct {
+	nonces  map[string]string
+	signErr error
+}
+
+func (i *fakeIssuer) storeCodeNonce(_ context.Context, code, nonce string) error {
+	i.nonces[code] = nonce
+	return nil
+}
+
+func (i *fakeIssuer) codeNonce(_ context.Context, code string) (string, error) {
+	return i.nonces[code], nil
+}
+
+func (i *fakeIssuer) signIDToken(_ context.Context, _, _ string, _ []string, nonce string) (string, error) {
+	if i.signErr != nil {
+		return "", i.signErr
+	}
+	return "id_token:" + nonce, nil
+}
+
+func newToken(code, scope string) oauth2.TokenInfo {
+	token := models.NewToken()
+	token.SetClientID("client_1")
+	token.SetUserID("user_1")
+	token.SetCode(code)
+	token.SetScope(scope)
+	return token
+}
+
+func TestIDTokenManagerCarriesNonce(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	issuer := &fakeIssuer{nonces: map[string]string{}}
+	manager := &idTokenManager{
+		Manager: &fakeManager{token: newToken("code_1", "openid profile")},
+		issuer:  issuer,
+	}
+
+	authorizeRequest := &http.Request{Form: url.Values{paramNonce: {"n-0S6_WzA2Mj"}}}
+	_, err := manager.GenerateAuthToken(ctx, oauth2.Code, &oauth2.TokenGenerateRequest{Request: authorizeRequest})
+	require.NoError(t, err)
+	assert.Equal(t, "n-0S6_WzA2Mj", issuer.nonces["code_1"])
+
+	ti, err := manager.GenerateAccessToken(ctx, oauth2.AuthorizationCode, &oauth2.TokenGenerateRequest{Code: "code_1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id_token": "id_token:n-0S6_WzA2Mj"}, IDTokenFields(ti))
+}
+
+func TestIDTokenManagerWithoutOpenID(t *testing.T) {
+	t.Parallel()
+
+	manager := &idTokenManager{
+		Manager: &fakeManager{token: newToken("code_1", "profile email")},
+		issuer:  &fakeIssuer{nonces: map[string]string{}},
+	}
+
+	ti, err := manager.GenerateAccessToken(context.Background(), oauth2.AuthorizationCode, &oauth2.TokenGenerateRequest{Code: "code_1"})
+	require.NoError(t, err)
+	assert.Nil(t, IDTokenFields(ti))
+}
+
+func TestIDTokenManagerFailsWithoutIDToken(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	signErr := errors.New("no signing key")
+	manager := &idTokenManager{
+		Manager: &fakeManager{token: newToken("", "openid")},
+		issuer:  &fakeIssuer{nonces: map[string]string{}, signErr: signErr},
+	}
+
+	_, err := manager.GenerateAccessToken(ctx, oauth2.AuthorizationCode, &oauth2.TokenGenerateRequest{Code: "code_1"})
+	assert.ErrorIs(t, err, signErr)
+
+	_, err = manager.RefreshAccessToken(ctx, &oauth2.TokenGenerateRequest{Refresh: "refresh_1"})
+	assert.ErrorIs(t, err, signErr)
+}
+
+func TestIDTokenClaims(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	info := model.OAuthUserInfo{UserID: "user_1", Email: "jane@clerk.dev"}
+
+	claims := idTokenClaims(info, []string{"openid"}, "https://clerk.example.com", "client_1", "abc", now)
+	assert.Equal(t, map[string]any{
+		"sub":   "user_1",
+		"iss":   "https://clerk.example.com",
+		"aud":   "client_1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(idTokenLifetime).Unix(),
+		"nonce": "abc",
+	}, claims)
+
+	claims = idTokenClaims(info, []string{"openid", "email"}, "https://clerk.example.com", "client_1", "", now)
+	assert.NotContains(t, claims, "nonce")
+	assert.Equal(t, "jane@clerk.dev", claims["email"])
+}
@@ -9,6 +9,8 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/api/shared/client_data"
+	"clerk/api/shared/oauth_applications"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/user_profile"
 	"clerk/model"
 	"clerk/pkg/ctx/environment"
@@ -28,10 +30,15 @@ type Service struct {
 	clock clockwork.Clock
 
 	// services
-	userProfileService *user_profile.Service
-	clientDataService  *client_data.Service
+	userProfileService      *user_profile.Service
+	clientDataService       *client_data.Service
+	oauthApplicationService *oauth_applications.Service
+	signingKeyService       *signing_keys.Service
 
 	// repositories
+	domainRepo                 *repository.Domain
+	instanceRepo               *repository.Instances
+	oauthApplicationsRepo      *repository.OAuthApplications
 	oauthApplicationTokensRepo *repository.OAuthApplicationTokens
 	usersRepo                  *repository.Users
 }
@@ -42,8 +49,13 @@ func NewService(deps clerk.Deps) *Service {
 		clock:                      deps.Clock(),
 		userProfileService:         user_profile.NewService(deps.Clock()),
 		clientDataService:          client_data.NewService(deps),
-		oauthApplicationTokensRepo: repository.NewOAuthApplicationTokens(),
-		usersRepo:                  repository.NewUsers(),
+		oauthApplicationService:    oauth_applications.NewService(deps),
+		signingKeyService:          signing_keys.NewService(deps),
+		domainRepo:                 deps.Repositories().Domain,
+		instanceRepo:               deps.Repositories().Instances,
+		oauthApplicationsRepo:      deps.Repositories().OAuthApplications,
+		oauthApplicationTokensRepo: deps.Repositories().OAuthApplicationTokens,
+		usersRepo:                  deps.Repositories().Users,
 	}
 }
 
@@ -140,6 +152,26 @@ func (s *Service) UserInfo(ctx context.Context) (*serialize.OAuthUserInfoRespons
 		return nil, apierror.OAuthFetchUserInfoForbidden()
 	}
 
+	info, err := s.userInfo(ctx, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	if strings.Contains(state.Scopes, oauth2idp.PublicMetadataScope) {
+		info.PublicMetadata = json.RawMessage(user.PublicMetadata)
+		info.UnsafeMetadata = json.RawMessage(user.UnsafeMetadata)
+	}
+
+	if strings.Contains(state.Scopes, oauth2idp.PrivateMetadataScope) {
+		info.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
+	}
+
+	return serialize.OAuthUserInfo(info), nil
+}
+
+// userInfo collects the profile of the user which is shared with OAuth
+// applications, apart from the metadata which depend on the granted scopes.
+func (s *Service) userInfo(ctx context.Context, user *model.User) (model.OAuthUserInfo, error) {
 	info := model.OAuthUserInfo{
 		InstanceID: user.InstanceID,
 		FamilyName: user.LastName.String,
@@ -151,33 +183,23 @@ func (s *Service) UserInfo(ctx context.Context) (*serialize.OAuthUserInfoRespons
 
 	email, err := s.userProfileService.GetPrimaryEmailAddress(ctx, s.db, user)
 	if err != nil {
-		return nil, apierror.Unexpected(err)
+		return info, err
 	}
 	if email != nil {
 		info.Email = *email
 	}
 	emailVerified, err := s.userProfileService.HasVerifiedEmail(ctx, s.db, user.ID)
 	if err != nil {
-		return nil, apierror.Unexpected(err)
+		return info, err
 	}
 	info.EmailVerified = emailVerified
 
 	username, err := s.userProfileService.GetUsername(ctx, s.db, user)
 	if err != nil {
-		return nil, apierror.Unexpected(err)
+		return info, err
 	}
 	if username != nil {
 		info.Username = *username
 	}
-
-	if strings.Contains(state.Scopes, oauth2idp.PublicMetadataScope) {
-		info.PublicMetadata = json.RawMessage(user.PublicMetadata)
-		info.UnsafeMetadata = json.RawMessage(user.UnsafeMetadata)
-	}
-
-	if strings.Contains(state.Scopes, oauth2idp.PrivateMetadataScope) {
-		info.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
-	}
-
-	return serialize.OAuthUserInfo(info), nil
+	return info, nil
 }
@@ -63,13 +63,13 @@ func NewService(deps clerk.Deps) *Service {
 		organizationsService:               organizations.NewService(deps),
 		serializableService:                serializable.NewService(deps.Clock()),
 		emailQualityService:                deps.EmailQualityChecker(),
-		identificationRepo:                 repository.NewIdentification(),
-		organizationDomainRepo:             repository.NewOrganizationDomain(),
-		organizationDomainVerificationRepo: repository.NewOrganizationDomainVerification(),
-		organizationInvitationRepo:         repository.NewOrganizationInvitation(),
-		organizationSuggestionRepo:         repository.NewOrganizationSuggestion(),
-		organizationRepo:                   repository.NewOrganization(),
-		emailDomainReportsRepo:             repository.NewEmailDomainReport(),
+		identificationRepo:                 deps.Repositories().Identification,
+		organizationDomainRepo:             deps.Repositories().OrganizationDomain,
+		organizationDomainVerificationRepo: deps.Repositories().OrganizationDomainVerification,
+		organizationInvitationRepo:         deps.Repositories().OrganizationInvitation,
+		organizationSuggestionRepo:         deps.Repositories().OrganizationSuggestion,
+		organizationRepo:                   deps.Repositories().Organization,
+		emailDomainReportsRepo:             deps.Repositories().EmailDomainReport,
 	}
 }
 
@@ -83,7 +83,7 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 	reqUser := requesting_user.FromContext(ctx)
 
-	err := form.CheckWithPagination(r.Form, param.NewList(param.NewSet(), param.NewSet(param.Status)))
+	err := form.CheckWithPagination(r.Form, param.NewList(param.NewSet(), param.NewSet(param.Status, param.Cursor)))
 	if err != nil {
 		return nil, err
 	}
@@ -93,10 +93,16 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
+	keyset, err := pagination.KeysetFromRequest(r, paginationParams.Limit)
+	if err != nil {
+		return nil, err
+	}
+
 	params := ListParams{
 		OrganizationID:   chi.URLParam(r, "organizationID"),
 		RequestingUserID: reqUser.ID,
 		Statuses:         form.GetStringArray(r.Form, param.Status.Name),
+		Keyset:           keyset,
 	}
 	invitations, err := h.service.List(ctx, params, paginationParams)
 	if err != nil {
@@ -39,8 +39,8 @@ func NewService(deps clerk.Deps) *Service {
 		clock:                      deps.Clock(),
 		db:                         deps.DB(),
 		organizationsService:       organizations.NewService(deps),
-		organizationRepo:           repository.NewOrganization(),
-		organizationInvitationRepo: repository.NewOrganizationInvitation(),
+		organizationRepo:           deps.Repositories().Organization,
+		organizationInvitationRepo: deps.Repositories().OrganizationInvitation,
 	}
 }
 
@@ -114,6 +114,7 @@ type ListParams struct {
 	OrganizationID   string
 	RequestingUserID string
 	Statuses         []string
+	Keyset           *pagination.KeysetParams
 }
 
 func (params ListParams) validate() apierror.Error {
@@ -136,7 +137,13 @@ func (s *Service) List(ctx context.Context, params ListParams, paginationParams
 		return nil, apiErr
 	}
 
-	invitations, apiErr := s.organizationsService.ListInvitations(ctx, s.db, env.Instance.ID, params.OrganizationID, params.Statuses, paginationParams)
+	var invitations []*model.OrganizationInvitationSerializable
+	var apiErr apierror.Error
+	if params.Keyset != nil {
+		invitations, apiErr = s.organizationsService.ListInvitationsAfter(ctx, s.db, env.Instance.ID, params.OrganizationID, params.Statuses, *params.Keyset)
+	} else {
+		invitations, apiErr = s.organizationsService.ListInvitations(ctx, s.db, env.Instance.ID, params.OrganizationID, params.Statuses, paginationParams)
+	}
 	if apiErr != nil {
 		return nil, apiErr
 	}
@@ -151,6 +158,15 @@ func (s *Service) List(ctx context.Context, params ListParams, paginationParams
 		return nil, apierror.Unexpected(err)
 	}
 
+	if params.Keyset != nil {
+		var nextCursor *string
+		if len(invitations) > 0 {
+			last := invitations[len(invitations)-1]
+			nextCursor = params.Keyset.NextCursor(len(invitations), last.OrganizationInvitation.CreatedAt, last.OrganizationInvitation.ID)
+		}
+		return serialize.CursorPaginated(response, count, nextCursor), nil
+	}
+
 	return serialize.Paginated(response, count), nil
 }
 
@@ -32,6 +32,7 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	requestingUser := requesting_user.FromContext(ctx)
 
 	err := form.CheckWithPagination(r.Form, param.NewList(param.NewSet(), param.NewSet(param.Status)))
 	if err != nil {
@@ -44,8 +45,9 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	}
 
 	params := ListParams{
-		OrganizationID: chi.URLParam(r, "organizationID"),
-		Statuses:       form.GetStringArray(r.Form, param.Status.Name),
+		OrganizationID:   chi.URLParam(r, "organizationID"),
+		Statuses:         form.GetStringArray(r.Form, param.Status.Name),
+		RequestingUserID: requestingUser.ID,
 	}
 	response, err := h.service.List(ctx, params, paginationParams)
 	if err != nil {
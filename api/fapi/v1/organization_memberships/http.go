@@ -88,7 +88,7 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 	reqUser := requesting_user.FromContext(ctx)
 
-	err := form.CheckWithPagination(r.Form, param.NewList(param.NewSet(), param.NewSet(param.Roles, param.Paginated)))
+	err := form.CheckWithPagination(r.Form, param.NewList(param.NewSet(), param.NewSet(param.Roles, param.Paginated, param.Cursor)))
 	if err != nil {
 		return nil, err
 	}
@@ -98,11 +98,17 @@ func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 		return nil, err
 	}
 
+	keyset, err := pagination.KeysetFromRequest(r, paginationParams.Limit)
+	if err != nil {
+		return nil, err
+	}
+
 	members, err := h.service.List(ctx, ListMembershipsParams{
 		OrganizationID:   chi.URLParam(r, "organizationID"),
 		RequestingUserID: reqUser.ID,
 		Roles:            form.GetStringArray(r.Form, param.Roles.Name),
 		Paginated:        form.GetBool(r.Form, param.Paginated.Name),
+		Keyset:           keyset,
 	}, paginationParams)
 	if err != nil {
 		return nil, h.wrapper.WrapError(ctx, err, client)
@@ -32,7 +32,7 @@ func NewService(deps clerk.Deps) *Service {
 		db:                   deps.DB(),
 		organizationsService: organizations.NewService(deps),
 		orgDomainService:     orgdomain.NewService(deps.Clock()),
-		orgMembershipRepo:    repository.NewOrganizationMembership(),
+		orgMembershipRepo:    deps.Repositories().OrganizationMembership,
 	}
 }
 
@@ -92,6 +92,9 @@ type ListMembershipsParams struct {
 	OrganizationID   string
 	Roles            []string
 	Paginated        *bool
+	// Keyset switches to keyset pagination. The response is always
+	// paginated when it's set.
+	Keyset *pagination.KeysetParams
 }
 
 // List retrieves a list of all organization members for the
@@ -106,6 +109,7 @@ func (s *Service) List(ctx context.Context, params ListMembershipsParams, pagina
 	memberships, apiErr := s.organizationsService.ListMemberships(ctx, s.db, organizations.ListMembershipsParams{
 		OrganizationID: &params.OrganizationID,
 		Roles:          params.Roles,
+		Keyset:         params.Keyset,
 	}, paginationParams)
 	if apiErr != nil {
 		return nil, apiErr
@@ -116,12 +120,21 @@ func (s *Service) List(ctx context.Context, params ListMembershipsParams, pagina
 		response[i] = serialize.OrganizationMembership(ctx, membership)
 	}
 
-	if params.Paginated != nil && *params.Paginated {
+	if params.Keyset != nil || (params.Paginated != nil && *params.Paginated) {
 		count, err := s.orgMembershipRepo.CountByOrganizationAndRoles(ctx, s.db, params.OrganizationID, params.Roles)
 		if err != nil {
 			return nil, apierror.Unexpected(err)
 		}
 
+		if params.Keyset != nil {
+			var nextCursor *string
+			if len(memberships) > 0 {
+				last := memberships[len(memberships)-1]
+				nextCursor = params.Keyset.NextCursor(len(memberships), last.OrganizationMembership.CreatedAt, last.OrganizationMembership.ID)
+			}
+			return serialize.CursorPaginated(response, count, nextCursor), nil
+		}
+
 		return serialize.Paginated(response, count), nil
 	}
 
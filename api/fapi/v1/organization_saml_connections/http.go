@@ -0,0 +1,114 @@
+package organization_saml_connections
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/fapi/v1/wrapper"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/saml_connections"
+	"clerk/model"
+	"clerk/pkg/clerkhttp"
+	"clerk/pkg/ctxkeys"
+	"clerk/utils/clerk"
+	"clerk/utils/form"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+	wrapper *wrapper.Wrapper
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+		wrapper: wrapper.NewWrapper(deps),
+	}
+}
+
+// POST /v1/organizations/{organizationID}/saml_connections
+func (h *HTTP) Create(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	params := saml_connections.CreateParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	response, err := h.service.Create(ctx, chi.URLParam(r, "organizationID"), params)
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
+// GET /v1/organizations/{organizationID}/saml_connections
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.service.List(ctx, chi.URLParam(r, "organizationID"), saml_connections.ListParams{
+		Pagination: paginationParams,
+	})
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
+// GET /v1/organizations/{organizationID}/saml_connections/{samlConnectionID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	if err := form.CheckEmpty(r.Form); err != nil {
+		return nil, err
+	}
+
+	response, err := h.service.Read(ctx, chi.URLParam(r, "organizationID"), chi.URLParam(r, "samlConnectionID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
+// PATCH /v1/organizations/{organizationID}/saml_connections/{samlConnectionID}
+func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	params := saml_connections.UpdateParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	response, err := h.service.Update(ctx, chi.URLParam(r, "organizationID"), chi.URLParam(r, "samlConnectionID"), params)
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
+// DELETE /v1/organizations/{organizationID}/saml_connections/{samlConnectionID}
+func (h *HTTP) Delete(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	if err := form.CheckEmpty(r.Form); err != nil {
+		return nil, err
+	}
+
+	response, err := h.service.Delete(ctx, chi.URLParam(r, "organizationID"), chi.URLParam(r, "samlConnectionID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
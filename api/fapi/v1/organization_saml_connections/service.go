@@ -0,0 +1,50 @@
+package organization_saml_connections
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/saml_connections"
+	"clerk/pkg/ctx/requesting_user"
+	"clerk/utils/clerk"
+)
+
+// Service lets organization members with the permission to manage SAML
+// connections set up SSO for the verified domains of their organization.
+type Service struct {
+	samlConnectionsService *saml_connections.Service
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		samlConnectionsService: saml_connections.NewService(deps),
+	}
+}
+
+func (s *Service) Create(ctx context.Context, organizationID string, params saml_connections.CreateParams) (*serialize.SAMLConnectionResponse, apierror.Error) {
+	return s.samlConnectionsService.Create(ctx, owner(ctx, organizationID), params)
+}
+
+func (s *Service) Update(ctx context.Context, organizationID, samlConnectionID string, params saml_connections.UpdateParams) (*serialize.SAMLConnectionResponse, apierror.Error) {
+	return s.samlConnectionsService.Update(ctx, owner(ctx, organizationID), samlConnectionID, params)
+}
+
+func (s *Service) Read(ctx context.Context, organizationID, samlConnectionID string) (*serialize.SAMLConnectionResponse, apierror.Error) {
+	return s.samlConnectionsService.Read(ctx, owner(ctx, organizationID), samlConnectionID)
+}
+
+func (s *Service) List(ctx context.Context, organizationID string, params saml_connections.ListParams) (*serialize.PaginatedResponse, apierror.Error) {
+	return s.samlConnectionsService.List(ctx, owner(ctx, organizationID), params)
+}
+
+func (s *Service) Delete(ctx context.Context, organizationID, samlConnectionID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	return s.samlConnectionsService.Delete(ctx, owner(ctx, organizationID), samlConnectionID)
+}
+
+func owner(ctx context.Context, organizationID string) saml_connections.Owner {
+	return saml_connections.Owner{
+		OrganizationID:   organizationID,
+		RequestingUserID: requesting_user.FromContext(ctx).ID,
+	}
+}
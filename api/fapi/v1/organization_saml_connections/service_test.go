@@ -0,0 +1,24 @@
+package organization_saml_connections
+
+import (
+	"context"
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/requesting_user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOwner(t *testing.T) {
+	t.Parallel()
+
+	user := &model.User{User: &sqbmodel.User{ID: "user_1"}}
+	ctx := requesting_user.NewContext(context.Background(), user)
+
+	// connections are always managed on behalf of the requesting member
+	connectionOwner := owner(ctx, "org_1")
+	assert.Equal(t, "org_1", connectionOwner.OrganizationID)
+	assert.Equal(t, "user_1", connectionOwner.RequestingUserID)
+}
@@ -74,18 +74,6 @@ func (h *HTTP) EnsureOrganizationExists(_ http.ResponseWriter, r *http.Request)
 	return r, nil
 }
 
-// Middleware /v1/organizations/{organizationID}/membership_requests
-func (h *HTTP) EnsureMembersManagePermission(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
-	ctx := r.Context()
-	user := requesting_user.FromContext(ctx)
-	organizationID := chi.URLParam(r, "organizationID")
-
-	if err := h.service.EnsureMembersManagePermission(ctx, organizationID, user.ID); err != nil {
-		return r, err
-	}
-	return r, nil
-}
-
 // Middleware /v1/organizations/{organizationID}
 func (h *HTTP) EmitActiveOrganizationEventIfNeeded(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
 	organizationID := chi.URLParam(r, "organizationID")
@@ -185,6 +173,56 @@ func (h *HTTP) UpdateLogo(_ http.ResponseWriter, r *http.Request) (interface{},
 	return h.wrapper.WrapResponse(ctx, res, client)
 }
 
+// CreateLogoUpload handles requests to
+// POST /v1/organizations/{organizationID}/logo/uploads
+func (h *HTTP) CreateLogoUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	env := environment.FromContext(ctx)
+	user := requesting_user.FromContext(ctx)
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	pl := param.NewList(param.NewSet(param.ContentType), param.NewSet(param.Filename))
+	if err := form.Check(r.Form, pl); err != nil {
+		return nil, err
+	}
+
+	params := organizations.CreateLogoUploadParams{
+		OrganizationID: chi.URLParam(r, "organizationID"),
+		Filename:       r.Form.Get(param.Filename.Name),
+		ContentType:    r.Form.Get(param.ContentType.Name),
+		UploaderUserID: user.ID,
+	}
+	res, apiErr := h.service.CreateLogoUpload(ctx, params, env.Instance)
+	if apiErr != nil {
+		return nil, h.wrapper.WrapError(ctx, apiErr, client)
+	}
+	return h.wrapper.WrapResponse(ctx, res, client)
+}
+
+// CompleteLogoUpload handles requests to
+// POST /v1/organizations/{organizationID}/logo/uploads/{imageID}/complete
+func (h *HTTP) CompleteLogoUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	env := environment.FromContext(ctx)
+	user := requesting_user.FromContext(ctx)
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	if err := form.CheckEmpty(r.Form); err != nil {
+		return nil, err
+	}
+
+	params := organizations.UpdateLogoParams{
+		OrganizationID:  chi.URLParam(r, "organizationID"),
+		UploaderUserID:  user.ID,
+		UploadedImageID: chi.URLParam(r, "imageID"),
+	}
+	res, apiErr := h.service.UpdateLogo(ctx, params, env.Instance)
+	if apiErr != nil {
+		return nil, h.wrapper.WrapError(ctx, apiErr, client)
+	}
+	return h.wrapper.WrapResponse(ctx, res, client)
+}
+
 // DELETE /v1/organizations/{organizationID}/logo
 func (h *HTTP) DeleteLogo(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
@@ -43,10 +43,10 @@ func NewService(deps clerk.Deps) *Service {
 		db:                   deps.DB(),
 		organizationsService: organizations.NewService(deps),
 		orgLogosService:      organizations.NewLogosService(deps),
-		imageRepo:            repository.NewImages(),
-		orgRepo:              repository.NewOrganization(),
-		orgMemberRepo:        repository.NewOrganizationMembership(),
-		rolesRepo:            repository.NewRole(),
+		imageRepo:            deps.Repositories().Images,
+		orgRepo:              deps.Repositories().Organization,
+		orgMemberRepo:        deps.Repositories().OrganizationMembership,
+		rolesRepo:            deps.Repositories().Role,
 	}
 }
 
@@ -250,10 +250,6 @@ func (s *Service) EnsureOrganizationExists(ctx context.Context, organizationID s
 	return nil
 }
 
-func (s *Service) EnsureMembersManagePermission(ctx context.Context, organizationID, userID string) apierror.Error {
-	return s.organizationsService.EnsureHasAccess(ctx, s.db, organizationID, constants.PermissionMembersManage, userID)
-}
-
 func (s *Service) EmitActiveOrganizationEventIfNeeded(ctx context.Context, organizationID string) {
 	env := environment.FromContext(ctx)
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
@@ -298,6 +294,27 @@ func (s *Service) UpdateLogo(
 	return serialize.Organization(ctx, org), nil
 }
 
+// CreateLogoUpload returns a signed URL the new logo of the organization can
+// be uploaded to, directly to storage. The logo is set once the upload is
+// completed through UpdateLogo.
+func (s *Service) CreateLogoUpload(
+	ctx context.Context,
+	params organizations.CreateLogoUploadParams,
+	instance *model.Instance,
+) (*serialize.ImageUploadResponse, apierror.Error) {
+	apiErr := s.EnsureOrganizationExists(ctx, params.OrganizationID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	apiErr = s.organizationsService.EnsureHasAccess(ctx, s.db, params.OrganizationID, constants.PermissionOrgManage, params.UploaderUserID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return s.orgLogosService.CreateUpload(ctx, s.db, params, instance)
+}
+
 type DeleteLogoParams struct {
 	OrganizationID   string
 	RequestingUserID string
@@ -55,8 +55,8 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		deps:                  deps,
 		db:                    deps.DB(),
-		identificationRepo:    repository.NewIdentification(),
-		passkeyRepo:           repository.NewPasskey(),
+		identificationRepo:    deps.Repositories().Identification,
+		passkeyRepo:           deps.Repositories().Passkey,
 		identificationService: identifications.NewService(deps),
 		passkeyService:        passkeys.NewService(deps),
 		serializableService:   serializable.NewService(deps.Clock()),
@@ -87,60 +87,117 @@ func (s *Service) CreatePasskey(ctx context.Context, user *model.User, origin, p
 			return true, apierror.PasskeyQuotaExceeded(maxAllowedPasskeysPerUser)
 		}
 
-		response, apiErr := s.beginPasskeyRegistration(ctx, tx, env, &beginPasskeyRegistrationParams{
-			Origin: origin,
-			User:   user,
+		identification, err = s.registerPasskey(ctx, tx, env, &registerPasskeyParams{
+			User:        user,
+			UserID:      &user.ID,
+			Origin:      origin,
+			PasskeyName: passkeyName,
 		})
-		if apiErr != nil {
-			return true, apiErr
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
 		}
+		return nil, apierror.Unexpected(txErr)
+	}
 
-		ident, _, err := s.createPasskeyAndIdentification(ctx, tx, &createPasskeyParams{
-			UserID:     user.ID,
-			InstanceID: env.Instance.ID,
-			Name:       passkeyName,
-			Origin:     response.rpIDOrigin,
-		})
-		if err != nil {
-			return true, err
-		}
+	return s.toIdentificationResponse(ctx, identification)
+}
 
-		// prepare step happens along with the create step for passkeys
-		preparerParams := &strategies.PasskeyPreparerParams{
-			Identification: ident,
-			Creation:       response.creation,
-			Session:        response.sessionData,
-		}
-		preparer := strategies.NewPasskeyPreparer(s.deps, env, preparerParams)
+// PrepareSignUpRegistration initializes the passkey registration flow for a
+// sign-up. The user doesn't exist yet, so the passkey is registered for the
+// user ID which is reserved on the sign-up, and its identification is claimed
+// by the user once the sign-up completes.
+//
+// Identifications without a user aren't cleaned up along with the user's
+// unverified passkeys, so a previous registration of the sign-up which was
+// never completed is deleted here instead.
+func (s *Service) PrepareSignUpRegistration(ctx context.Context, tx database.Tx, env *model.Env, signUp *model.SignUp, origin, passkeyName string) (*model.Identification, error) {
+	if signUp.PasskeyID.Valid {
+		previous, err := s.identificationRepo.QueryByID(ctx, tx, signUp.PasskeyID.String)
 		if err != nil {
-			return true, err
+			return nil, err
 		}
-
-		verification, err := preparer.Prepare(ctx, tx)
-		if err != nil {
-			return true, err
+		if previous != nil {
+			if previous.IsVerified() {
+				return nil, apierror.VerificationAlreadyVerified()
+			}
+			// the passkey is deleted along with its identification
+			if err := s.identificationRepo.DeleteByID(ctx, tx, previous.ID); err != nil {
+				return nil, err
+			}
 		}
+	}
 
-		ident.VerificationID = null.StringFrom(verification.ID)
-		if err := s.identificationRepo.UpdateVerificationID(ctx, tx, ident); err != nil {
-			return true, err
-		}
+	return s.registerPasskey(ctx, tx, env, &registerPasskeyParams{
+		User:        SignUpWebAuthnUser(signUp),
+		Origin:      origin,
+		PasskeyName: passkeyName,
+	})
+}
 
-		identification = ident
-		return false, nil
+// SignUpWebAuthnUser returns the user that the passkey of the sign-up is
+// registered for.
+func SignUpWebAuthnUser(signUp *model.SignUp) *model.User {
+	return &model.User{User: &sqbmodel.User{
+		ID:         signUp.ReservedUserID.String,
+		InstanceID: signUp.InstanceID,
+	}}
+}
+
+type registerPasskeyParams struct {
+	User *model.User
+	// UserID is nil when the passkey is registered during sign-up
+	UserID      *string
+	Origin      string
+	PasskeyName string
+}
+
+// registerPasskey creates the passkey along with its identification and
+// prepares its verification, which carries the registration options.
+func (s *Service) registerPasskey(ctx context.Context, tx database.Tx, env *model.Env, params *registerPasskeyParams) (*model.Identification, error) {
+	response, apiErr := s.beginPasskeyRegistration(ctx, tx, env, &beginPasskeyRegistrationParams{
+		Origin: params.Origin,
+		User:   params.User,
 	})
-	if txErr != nil {
-		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
-			return nil, apiErr
-		}
-		return nil, apierror.Unexpected(txErr)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 
-	return s.toIdentificationResponse(ctx, identification)
+	ident, _, err := s.createPasskeyAndIdentification(ctx, tx, &createPasskeyParams{
+		UserID:     params.UserID,
+		InstanceID: env.Instance.ID,
+		Name:       params.PasskeyName,
+		Origin:     response.rpIDOrigin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// prepare step happens along with the create step for passkeys
+	preparerParams := &strategies.PasskeyPreparerParams{
+		Identification: ident,
+		Creation:       response.creation,
+		Session:        response.sessionData,
+	}
+	preparer := strategies.NewPasskeyPreparer(s.deps, env, preparerParams)
+
+	verification, err := preparer.Prepare(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	ident.VerificationID = null.StringFrom(verification.ID)
+	if err := s.identificationRepo.UpdateVerificationID(ctx, tx, ident); err != nil {
+		return nil, err
+	}
+
+	return ident, nil
 }
 
 type createPasskeyParams struct {
-	UserID     string
+	UserID     *string
 	InstanceID string
 	Name       string
 	Origin     string
@@ -149,15 +206,17 @@ type createPasskeyParams struct {
 func (s *Service) createPasskeyAndIdentification(ctx context.Context, tx database.Tx, params *createPasskeyParams) (*model.Identification, *model.Passkey, error) {
 	// delete the old unverified passkey identifications
 	// passkey registration cannot be completed later if user cancels or something goes wrong during attempt verification
-	err := s.identificationRepo.DeleteUnverifiedByUserAndType(ctx, tx, params.UserID, constants.ITPasskey)
-	if err != nil {
-		return nil, nil, err
+	if params.UserID != nil {
+		err := s.identificationRepo.DeleteUnverifiedByUserAndType(ctx, tx, *params.UserID, constants.ITPasskey)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// create identification for passkey
 	createIdentificationData := identifications.CreateIdentificationData{
 		InstanceID: params.InstanceID,
-		UserID:     &params.UserID,
+		UserID:     params.UserID,
 		Type:       constants.ITPasskey,
 	}
 	identification, err := s.identificationService.CreateIdentification(ctx, tx, createIdentificationData)
@@ -263,6 +322,11 @@ func (s *Service) UpdatePasskey(ctx context.Context, user *model.User, passkeyId
 
 	var identificationSerializable *model.IdentificationSerializable
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.sharedUsersService.SnapshotUser(ctx, tx, usersettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		passkeyIdent, performedUpdate, err := s.update(ctx, tx, user, passkeyIdentID, passkeyName)
 		if err != nil {
 			return true, err
@@ -270,7 +334,7 @@ func (s *Service) UpdatePasskey(ctx context.Context, user *model.User, passkeyId
 
 		// send user updated webhook if there was a change
 		if performedUpdate {
-			if _, err = s.sharedUsersService.SendUserUpdatedEvent(ctx, tx, env.Instance, usersettings, user); err != nil {
+			if _, err = s.sharedUsersService.SendUserUpdatedEvent(ctx, tx, env.Instance, usersettings, previousUser, user); err != nil {
 				return true, err
 			}
 		}
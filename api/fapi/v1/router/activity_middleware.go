@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/ctx/activity"
 	"clerk/pkg/transformers"
 	"clerk/utils/log"
@@ -17,7 +18,7 @@ func withSessionActivity(_ http.ResponseWriter, r *http.Request) (*http.Request,
 	sessionActivity := transformers.ToSessionActivity(r)
 	ctx = activity.NewContext(ctx, sessionActivity)
 
-	log.AddToLogLine(ctx, log.Activity, &log.DeviceActivity{
+	requestlog.Add(ctx, requestlog.Activity, &log.DeviceActivity{
 		BrowserName:    sessionActivity.BrowserName.String,
 		BrowserVersion: sessionActivity.BrowserVersion.String,
 		DeviceType:     sessionActivity.DeviceType.String,
@@ -27,7 +27,6 @@ import (
 	"clerk/pkg/sentry"
 	"clerk/pkg/set"
 	"clerk/pkg/versions"
-	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 	pkiutils "clerk/utils/pki"
@@ -403,5 +402,5 @@ func findDevBrowserFromRequest(ctx context.Context, deps clerk.Deps, r *http.Req
 			return &devBrowser, nil
 		}
 	}
-	return repository.NewDevBrowser().QueryByIDAndInstance(ctx, deps.DB(), claims.DevBrowserID, instance.ID)
+	return deps.Repositories().DevBrowser.QueryByIDAndInstance(ctx, deps.DB(), claims.DevBrowserID, instance.ID)
 }
@@ -19,7 +19,7 @@ func corsHandler(next http.Handler) http.Handler {
 		ctx := r.Context()
 		env := environment.FromContext(ctx)
 
-		exposedHeaders := []string{"Authorization", "x-country"}
+		exposedHeaders := []string{"Authorization", "x-country", "ETag"}
 
 		if env.Instance.IsDevelopmentOrStaging() {
 			clerkJSVersion := clerkjs_version.FromContext(ctx)
@@ -36,7 +36,7 @@ func corsHandler(next http.Handler) http.Handler {
 			},
 			AllowCredentials: true,
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization"},
+			AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", "If-None-Match"},
 			ExposedHeaders:   exposedHeaders,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		})
@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/requestlog"
 	"clerk/model"
 	"clerk/pkg/ctx/client_type"
 	"clerk/pkg/ctx/environment"
@@ -14,7 +15,6 @@ import (
 	clerkmaintenance "clerk/pkg/maintenance"
 	clerksentry "clerk/pkg/sentry"
 	"clerk/pkg/set"
-	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 )
@@ -72,7 +72,7 @@ func setDevBrowser(deps clerk.Deps) func(http.ResponseWriter, *http.Request) (*h
 		// At this point we expect a DevBrowser. Load it from the DB.
 		devBrowserID, _ := jwt["dev"].(string)
 
-		log.AddToLogLine(ctx, log.DevBrowserID, devBrowserID)
+		requestlog.Add(ctx, requestlog.DevBrowserID, devBrowserID)
 
 		var dvb *model.DevBrowser
 		if maintenance.FromContext(ctx) {
@@ -85,7 +85,7 @@ func setDevBrowser(deps clerk.Deps) func(http.ResponseWriter, *http.Request) (*h
 		}
 		if dvb == nil {
 			var err error
-			dvb, err = repository.NewDevBrowser().QueryByIDAndInstance(ctx, db, devBrowserID, env.Instance.ID)
+			dvb, err = deps.Repositories().DevBrowser.QueryByIDAndInstance(ctx, db, devBrowserID, env.Instance.ID)
 			if err != nil {
 				return r.WithContext(ctx), apierror.Unexpected(err)
 			}
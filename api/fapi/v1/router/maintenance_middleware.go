@@ -6,6 +6,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/pkg/clerkhttp"
+	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctx/maintenance"
 )
 
@@ -35,6 +36,21 @@ func checkRequestAllowedDuringMaintenance(_ http.ResponseWriter, r *http.Request
 	return r, apierror.SystemUnderMaintenance()
 }
 
+// checkRequestAllowedDuringInstanceMaintenance is the equivalent of
+// checkRequestAllowedDuringMaintenance for instances which were put under
+// maintenance on their own.
+func checkRequestAllowedDuringInstanceMaintenance(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+	env := environment.FromContext(r.Context())
+	if !env.Instance.MaintenanceMode {
+		return r, nil
+	}
+
+	if !clerkhttp.IsMutationMethod(r.Method) || mutationIsAllowedOnPath(r.URL.Path) {
+		return r, nil
+	}
+	return r, apierror.InstanceUnderMaintenance(env.Instance.MaintenanceBanner.String)
+}
+
 var allowedMutationOnPathSuffixes = []string{
 	"/touch",
 	"/tokens",
@@ -0,0 +1,59 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestCheckRequestAllowedDuringInstanceMaintenance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		maintenanceMode bool
+		method          string
+		path            string
+		wantBlocked     bool
+	}{
+		{name: "not under maintenance", method: http.MethodPost, path: "/v1/client/sign_ins", wantBlocked: false},
+		{name: "reads", maintenanceMode: true, method: http.MethodGet, path: "/v1/environment", wantBlocked: false},
+		{name: "session touch", maintenanceMode: true, method: http.MethodPost, path: "/v1/client/sessions/sess_1/touch", wantBlocked: false},
+		{name: "session tokens", maintenanceMode: true, method: http.MethodPost, path: "/v1/client/sessions/sess_1/tokens", wantBlocked: false},
+		{name: "dev browser", maintenanceMode: true, method: http.MethodPost, path: "/v1/dev_browser", wantBlocked: false},
+		{name: "sign ins", maintenanceMode: true, method: http.MethodPost, path: "/v1/client/sign_ins", wantBlocked: true},
+		{name: "user updates", maintenanceMode: true, method: http.MethodPatch, path: "/v1/me", wantBlocked: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{
+				MaintenanceMode:   tt.maintenanceMode,
+				MaintenanceBanner: null.StringFrom("Back in 5 minutes"),
+			}}}
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r = r.WithContext(environment.NewContext(context.Background(), env))
+
+			_, apiErr := checkRequestAllowedDuringInstanceMaintenance(httptest.NewRecorder(), r)
+			if !tt.wantBlocked {
+				assert.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			assert.Equal(t, apierror.InstanceMaintenanceModeCode, apiErr.Errors()[0].Code())
+			assert.Equal(t, "Back in 5 minutes", apiErr.Errors()[0].LongMessage())
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/sessions"
+	"clerk/pkg/ctx/client_type"
+)
+
+// issueRefreshTokens makes the sessions created by requests of native
+// clients which opted in to refresh tokens get one, which is returned along
+// with the response.
+func issueRefreshTokens(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+	ctx := r.Context()
+	if !client_type.FromContext(ctx).IsNative() || r.Header.Get(sessions.RefreshTokenHeader) == "" {
+		return r, nil
+	}
+	return r.WithContext(sessions.WithRefreshTokens(ctx)), nil
+}
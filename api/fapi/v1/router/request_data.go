@@ -4,30 +4,26 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/middleware"
+	"clerk/api/shared/requestlog"
 	"clerk/model"
 	"clerk/pkg/ctx/request_info"
-	"clerk/utils/log"
 )
 
 func setRequestInfo(_ http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
 	ctx := r.Context()
 
-	// set by Cloudflare
-	clientIP := r.Header.Get("True-Client-IP")
-	if clientIP == "" {
-		// set by our Cloudflare Worker and clerk_docker's nginx (local)
-		clientIP = r.Header.Get("X-Client-IP")
-	}
-
 	requestInfo := model.RequestInfo{
 		UserAgent:  r.Header.Get("User-Agent"),
 		RemoteAddr: r.RemoteAddr,
-		ClientIP:   clientIP,
+		ClientIP:   middleware.ClientIP(r),
 		Origin:     r.Header.Get("Origin"),
 		CFRay:      r.Header.Get("X-Visitor-CF-Ray"),
+		// set by our Cloudflare Worker
+		ASN: r.Header.Get("X-Client-ASN"),
 	}
 	newCtx := request_info.NewContext(ctx, &requestInfo)
-	log.AddToLogLine(ctx, log.RequestInfo, &requestInfo)
+	requestlog.Add(ctx, requestlog.RequestInfo, &requestInfo)
 
 	return r.WithContext(newCtx), nil
 }
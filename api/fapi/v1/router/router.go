@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"net/http"
 
+	"clerk/api/fapi/v1/account_links"
 	"clerk/api/fapi/v1/account_portal"
+	"clerk/api/fapi/v1/attestation"
 	"clerk/api/fapi/v1/billing"
 	"clerk/api/fapi/v1/certs"
 	"clerk/api/fapi/v1/clients"
@@ -20,19 +22,27 @@ import (
 	"clerk/api/fapi/v1/organization_invitations"
 	"clerk/api/fapi/v1/organization_membership_requests"
 	"clerk/api/fapi/v1/organization_memberships"
+	"clerk/api/fapi/v1/organization_saml_connections"
 	"clerk/api/fapi/v1/organizations"
 	"clerk/api/fapi/v1/passkeys"
 	"clerk/api/fapi/v1/root"
 	"clerk/api/fapi/v1/saml"
+	"clerk/api/fapi/v1/service_accounts"
 	"clerk/api/fapi/v1/sessions"
 	"clerk/api/fapi/v1/sign_in"
 	"clerk/api/fapi/v1/sign_up"
 	"clerk/api/fapi/v1/tickets"
 	"clerk/api/fapi/v1/tokens"
+	"clerk/api/fapi/v1/trusted_devices"
 	"clerk/api/fapi/v1/users"
 	"clerk/api/fapi/v1/verification"
 	"clerk/api/fapi/v1/well_known"
 	"clerk/api/middleware"
+	"clerk/api/middleware/pipeline"
+	shenvironment "clerk/api/shared/environment"
+	"clerk/api/shared/featureflags"
+	"clerk/api/shared/ratelimit"
+	"clerk/api/shared/requestlog"
 	"clerk/model"
 	apiVersioningMiddleware "clerk/pkg/apiversioning/middleware"
 	clerkbilling "clerk/pkg/billing"
@@ -43,22 +53,22 @@ import (
 	"clerk/pkg/usersettings/clerk/names"
 	"clerk/utils/clerk"
 
-	sentry "github.com/getsentry/sentry-go/http"
 	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/hostrouter"
-	chitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-chi/chi.v5"
 )
 
 // Router is responsible for request routing in client API
 type Router struct {
-	deps clerk.Deps
+	deps      clerk.Deps
+	rateLimit *ratelimit.Service
 
 	// handlers
 	common *handlers.Common
 
 	// services
+	accountLinks            *account_links.HTTP
 	accountPortal           *account_portal.HTTP
+	attestation             *attestation.HTTP
 	billing                 *billing.HTTP
 	certs                   *certs.HTTP
 	clients                 *clients.HTTP
@@ -74,14 +84,17 @@ type Router struct {
 	organizationDomains     *organization_domains.HTTP
 	organizationInvitations *organization_invitations.HTTP
 	organizationMemberships *organization_memberships.HTTP
+	orgSAMLConnections      *organization_saml_connections.HTTP
 	orgMembershipRequests   *organization_membership_requests.HTTP
 	passkeys                *passkeys.HTTP
 	saml                    *saml.HTTP
+	serviceAccounts         *service_accounts.HTTP
 	sessions                *sessions.HTTP
 	signIn                  *sign_in.HTTP
 	signUp                  *sign_up.HTTP
 	tickets                 *tickets.HTTP
 	tokens                  *tokens.HTTP
+	trustedDevices          *trusted_devices.HTTP
 	users                   *users.HTTP
 	verification            *verification.HTTP
 	wellknown               *well_known.HTTP
@@ -90,6 +103,7 @@ type Router struct {
 // New builds a new router
 func New(
 	deps clerk.Deps,
+	envCache *shenvironment.Cache,
 	captchaClientPool *turnstile.ClientPool,
 	common *handlers.Common,
 	billingConnector clerkbilling.Connector,
@@ -97,7 +111,10 @@ func New(
 ) *Router {
 	return &Router{
 		deps:                    deps,
+		rateLimit:               ratelimit.NewService(deps),
+		accountLinks:            account_links.NewHTTP(deps),
 		accountPortal:           account_portal.NewHTTP(deps),
+		attestation:             attestation.NewHTTP(deps),
 		billing:                 billing.NewHTTP(deps, billingConnector, paymentProvider),
 		certs:                   certs.NewHTTP(deps.DB()),
 		common:                  common,
@@ -106,22 +123,25 @@ func New(
 		debugging:               debugging.NewHTTP(),
 		devBrowser:              dev_browser.NewHTTP(deps),
 		domains:                 domain.NewHTTP(deps.DB()),
-		env:                     environment.NewHTTP(deps.DB()),
-		jwks:                    jwks.NewHTTP(),
+		env:                     environment.NewHTTP(deps, envCache),
+		jwks:                    jwks.NewHTTP(deps),
 		oauth:                   oauth.New(deps),
 		oauth2IDP:               oauth2_idp.NewHTTP(deps),
 		organizations:           organizations.NewHTTP(deps),
 		organizationDomains:     organization_domains.NewHTTP(deps),
 		organizationInvitations: organization_invitations.NewHTTP(deps),
 		organizationMemberships: organization_memberships.NewHTTP(deps),
+		orgSAMLConnections:      organization_saml_connections.NewHTTP(deps),
 		orgMembershipRequests:   organization_membership_requests.NewHTTP(deps),
 		passkeys:                passkeys.NewHTTP(deps),
 		saml:                    saml.NewHTTP(deps),
+		serviceAccounts:         service_accounts.NewHTTP(deps),
 		sessions:                sessions.NewHTTP(deps),
 		signIn:                  sign_in.NewHTTP(deps),
 		signUp:                  sign_up.NewHTTP(deps, captchaClientPool),
 		tickets:                 tickets.NewHTTP(deps),
 		tokens:                  tokens.NewHTTP(deps),
+		trustedDevices:          trusted_devices.NewHTTP(deps),
 		users:                   users.NewHTTP(deps),
 		verification:            verification.NewHTTP(deps),
 		wellknown:               well_known.NewHTTP(deps.DB()),
@@ -131,29 +151,32 @@ func New(
 // BuildRoutes returns a mux with all routes for the client API
 func (router *Router) BuildRoutes() *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Recover)
-	r.Use(clerkhttp.Middleware(robotsNoIndexMiddleware))
-
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
-		r.Use(chitrace.Middleware(chitrace.WithServiceName(cenv.Get(cenv.ClerkServiceIdentifier))))
-	}
-	// report panics to Sentry and re-panic. Also, populate a Sentry Hub
-	// into the context.
-	//
-	// NOTE: This must come after middleware.Recover and before
-	// middleware.SetTraceID
-	r.Use(sentry.New(sentry.Options{Repanic: true}).Handle)
-
-	r.Use(middleware.SetTraceID)
-	r.Use(clerkhttp.Middleware(middleware.SetMaintenanceAndRecoveryMode))
-	r.Use(middleware.SetResponseTypeToJSON)
-	r.Use(clerkhttp.Middleware(parseForm))
-	r.Use(clerkhttp.Middleware(validateCharSet))
-	r.Use(middleware.Log(func() sql.DBStats {
-		return router.deps.DB().Conn().Stats()
-	}))
-	r.Use(clerkhttp.Middleware(withSessionActivity))
-	r.Use(chimw.StripSlashes)
+	pipeline.Apply(r, pipeline.Policy{
+		TracerServiceName: cenv.Get(cenv.ClerkServiceIdentifier),
+		MaintenanceMode:   true,
+		DBStats: func() sql.DBStats {
+			return router.deps.DB().Conn().Stats()
+		},
+		LogSampling: requestlog.SamplingRules{
+			// only log a sample of session token requests, given the sheer
+			// amount of them and the fact that they don't contain any
+			// useful information
+			{
+				Method: http.MethodPost,
+				Route:  "/v1/client/sessions/{sessionID}/tokens",
+				Rate:   cenv.GetFloat64(cenv.ClerkSessionTokenLogSampling),
+			},
+		},
+		FeatureFlags: featureflags.NewService(router.deps).Flags,
+		BeforeLog: []pipeline.Middleware{
+			clerkhttp.Middleware(robotsNoIndexMiddleware),
+			clerkhttp.Middleware(parseForm),
+			clerkhttp.Middleware(validateCharSet),
+		},
+		BeforeRouting: []pipeline.Middleware{
+			clerkhttp.Middleware(withSessionActivity),
+		},
+	})
 
 	r.Method(http.MethodGet, "/", clerkhttp.Handler(root.Root))
 	r.Method(http.MethodGet, "/v1/health", router.common.Health())
@@ -218,6 +241,13 @@ func (router *Router) v1Router() chi.Router {
 			r.Use(clerkhttp.Middleware(router.oauth2IDP.SetUserFromAccessToken))
 			r.Method(http.MethodGet, "/oauth/userinfo", clerkhttp.Handler(router.oauth2IDP.UserInfo))
 		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(clerkhttp.Middleware(router.env.SetEnvFromDomain))
+			r.Use(clerkhttp.Middleware(middleware.EnsureEnvNotPendingDeletion))
+			r.Use(clerkhttp.Middleware(blockDuringMaintenance))
+			r.Method(http.MethodPost, "/oauth/service_accounts/token", clerkhttp.Handler(router.serviceAccounts.Token))
+		})
 	})
 
 	r.Route("/v1", func(r chi.Router) {
@@ -236,9 +266,11 @@ func (router *Router) v1Router() chi.Router {
 			r.Use(clerkhttp.Middleware(setRequestInfo))
 			r.Use(clerkhttp.Middleware(csrfCheck(router.deps.Clock())))
 			r.Use(clerkhttp.Middleware(setClientType))
+			r.Use(clerkhttp.Middleware(issueRefreshTokens))
 			r.Use(clerkhttp.Middleware(validateRequestOrigin))
 			r.Use(clerkhttp.Middleware(httpMethodPolyfill))
 			r.Use(clerkhttp.Middleware(checkRequestAllowedDuringMaintenance))
+			r.Use(clerkhttp.Middleware(checkRequestAllowedDuringInstanceMaintenance))
 			r.Use(clerkhttp.Middleware(logClerkJSVersion(router.deps.DB())))
 			r.Use(clerkhttp.Middleware(logClerkIOSSDKVersion(router.deps)))
 			r.Use(clerkhttp.Middleware(testingToken))
@@ -269,7 +301,7 @@ func (router *Router) v1Router() chi.Router {
 					r.Method(http.MethodGet, "/", clerkhttp.Handler(root.Root))
 
 					r.Route("/environment", func(r chi.Router) {
-						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.env.Read))
+						r.With(middleware.ETag).Method(http.MethodGet, "/", clerkhttp.Handler(router.env.Read))
 						r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.env.Update))
 					})
 
@@ -286,8 +318,12 @@ func (router *Router) v1Router() chi.Router {
 						r.Use(clerkhttp.Middleware(router.domains.EnsurePrimaryDomain))
 						r.Method(http.MethodGet, "/metadata/{samlConnectionID}.xml", clerkhttp.Handler(router.saml.Metadata))
 						r.Method(http.MethodPost, "/acs/{samlConnectionID}", clerkhttp.Handler(router.saml.AssertionConsumerService))
+						r.Method(http.MethodGet, "/logout/{samlConnectionID}", clerkhttp.Handler(router.saml.Logout))
+						r.Method(http.MethodPost, "/logout/{samlConnectionID}", clerkhttp.Handler(router.saml.Logout))
 					})
 
+					r.Method(http.MethodGet, "/primary_email_address_change/confirm", clerkhttp.Handler(router.users.ConfirmPrimaryEmailAddressChange))
+
 					r.Route("/tickets", func(r chi.Router) {
 						r.Use(clerkhttp.Middleware(fetchDevSessionIfNecessary(router.deps)))
 						r.Method(http.MethodGet, "/accept", clerkhttp.Handler(router.tickets.Accept))
@@ -303,11 +339,18 @@ func (router *Router) v1Router() chi.Router {
 					})
 
 					r.Route("/client", func(r chi.Router) {
+						// no ETag, since every response carries a fresh session token
 						r.Method(http.MethodGet, "/", clerkhttp.Handler(router.clients.Read))
-						r.Method(http.MethodPut, "/", clerkhttp.Handler(router.clients.Create))
-						r.Method(http.MethodPost, "/", clerkhttp.Handler(router.clients.Create))
+						r.With(middleware.ETag).Method(http.MethodGet, "/ping", clerkhttp.Handler(router.clients.Ping))
+						r.Group(func(r chi.Router) {
+							r.Use(clerkhttp.Middleware(router.attestation.VerifyDeviceAttestation))
+							r.Method(http.MethodPut, "/", clerkhttp.Handler(router.clients.Create))
+							r.Method(http.MethodPost, "/", clerkhttp.Handler(router.clients.Create))
+						})
 						r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.clients.Delete))
 
+						r.Method(http.MethodPost, "/attestation/challenge", clerkhttp.Handler(router.attestation.CreateChallenge))
+
 						r.Route("/sessions", func(r chi.Router) {
 							r.Route("/{sessionID}", func(r chi.Router) {
 								r.Group(func(r chi.Router) {
@@ -321,6 +364,7 @@ func (router *Router) v1Router() chi.Router {
 
 									r.Route("/tokens", func(r chi.Router) {
 										r.Method(http.MethodPost, "/", clerkhttp.Handler(router.tokens.CreateSessionToken))
+										r.Method(http.MethodPost, "/refresh", clerkhttp.Handler(router.tokens.RefreshSessionToken))
 										r.Method(http.MethodPost, "/{templateName}", clerkhttp.Handler(router.tokens.CreateFromTemplate))
 									})
 								})
@@ -329,8 +373,12 @@ func (router *Router) v1Router() chi.Router {
 
 						r.Route("/sign_ins", func(r chi.Router) {
 							r.Use(clerkhttp.Middleware(router.domains.EnsurePrimaryDomain))
+							r.Use(clerkhttp.Middleware(middleware.RateLimit(router.rateLimit, ratelimit.FAPI, "fapi_sign_ins")))
 							r.Use(clerkhttp.Middleware(validateUserSettings))
-							r.Method(http.MethodPost, "/", clerkhttp.Handler(router.signIn.Create))
+							r.Group(func(r chi.Router) {
+								r.Use(clerkhttp.Middleware(router.attestation.VerifyDeviceAttestation))
+								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.signIn.Create))
+							})
 
 							r.Route("/{signInID}", func(r chi.Router) {
 								r.Use(clerkhttp.Middleware(router.clients.VerifyRequestingClient))
@@ -355,8 +403,12 @@ func (router *Router) v1Router() chi.Router {
 
 						r.Route("/sign_ups", func(r chi.Router) {
 							r.Use(clerkhttp.Middleware(router.domains.EnsurePrimaryDomain))
+							r.Use(clerkhttp.Middleware(middleware.RateLimit(router.rateLimit, ratelimit.FAPI, "fapi_sign_ups")))
 							r.Use(clerkhttp.Middleware(validateUserSettings))
-							r.Method(http.MethodPost, "/", clerkhttp.Handler(router.signUp.Create))
+							r.Group(func(r chi.Router) {
+								r.Use(clerkhttp.Middleware(router.attestation.VerifyDeviceAttestation))
+								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.signUp.Create))
+							})
 
 							r.Route("/{signUpID}", func(r chi.Router) {
 								r.Use(clerkhttp.Middleware(router.clients.VerifyRequestingClient))
@@ -389,6 +441,10 @@ func (router *Router) v1Router() chi.Router {
 
 							r.Method(http.MethodPost, "/profile_image", clerkhttp.Handler(router.users.UpdateProfileImage))
 							r.Method(http.MethodDelete, "/profile_image", clerkhttp.Handler(router.users.DeleteProfileImage))
+							r.Method(http.MethodPost, "/profile_image/uploads", clerkhttp.Handler(router.users.CreateProfileImageUpload))
+							r.Method(http.MethodPost, "/profile_image/uploads/{imageID}/complete", clerkhttp.Handler(router.users.CompleteProfileImageUpload))
+							r.Method(http.MethodPost, "/legal_acceptance", clerkhttp.Handler(router.users.AcceptLegalDocuments))
+							r.Method(http.MethodDelete, "/pending_primary_email_address", clerkhttp.Handler(router.users.CancelPrimaryEmailAddressChange))
 
 							r.Group(func(r chi.Router) {
 								r.Use(clerkhttp.Middleware(middleware.EnabledInUserSettings(names.Password)))
@@ -401,10 +457,17 @@ func (router *Router) v1Router() chi.Router {
 								r.Method(http.MethodGet, "/active", clerkhttp.Handler(router.sessions.ListUserActiveSessions))
 
 								r.Route("/{sessionID}", func(r chi.Router) {
+									r.Method(http.MethodGet, "/activities", clerkhttp.Handler(router.sessions.ListSessionActivities))
 									r.Method(http.MethodPost, "/revoke", clerkhttp.Handler(router.sessions.Revoke))
 								})
 							})
 
+							r.Route("/oauth/consents/{clientID}", func(r chi.Router) {
+								r.Method(http.MethodGet, "/", clerkhttp.Handler(router.oauth2IDP.ReadConsent))
+								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.oauth2IDP.GrantConsent))
+								r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.oauth2IDP.RevokeConsent))
+							})
+
 							r.Route("/billing", func(r chi.Router) {
 								r.Use(clerkhttp.Middleware(router.billing.EnsureBillingAccountConnected))
 								r.Method(http.MethodGet, "/available_plans", clerkhttp.Handler(router.billing.GetAvailablePlansForUser))
@@ -464,6 +527,16 @@ func (router *Router) v1Router() chi.Router {
 								})
 							})
 
+							r.Route("/account_links", func(r chi.Router) {
+								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.accountLinks.Create))
+								r.Route("/{accountLinkID}", func(r chi.Router) {
+									r.Method(http.MethodGet, "/", clerkhttp.Handler(router.accountLinks.Read))
+									r.Method(http.MethodPost, "/attempt_verification", clerkhttp.Handler(router.accountLinks.AttemptVerification))
+									r.Method(http.MethodPost, "/complete", clerkhttp.Handler(router.accountLinks.Complete))
+									r.Method(http.MethodPost, "/cancel", clerkhttp.Handler(router.accountLinks.Cancel))
+								})
+							})
+
 							r.Route("/external_accounts", func(r chi.Router) {
 								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.users.ConnectOAuthAccount))
 
@@ -487,9 +560,15 @@ func (router *Router) v1Router() chi.Router {
 								r.Method(http.MethodPost, "/", clerkhttp.Handler(router.tokens.CreateForJWTService))
 							})
 
+							r.Route("/trusted_devices", func(r chi.Router) {
+								r.Method(http.MethodGet, "/", clerkhttp.Handler(router.trustedDevices.List))
+								r.Method(http.MethodDelete, "/{trustedDeviceID}", clerkhttp.Handler(router.trustedDevices.Revoke))
+							})
+
 							r.Route("/organization_invitations", func(r chi.Router) {
 								r.Method(http.MethodGet, "/", clerkhttp.Handler(router.users.ListOrganizationInvitations))
 								r.Method(http.MethodPost, "/{invitationID}/accept", clerkhttp.Handler(router.users.AcceptOrganizationInvitation))
+								r.Method(http.MethodPost, "/{invitationID}/prepare_verification", clerkhttp.Handler(router.users.PrepareOrganizationInvitationVerification))
 							})
 
 							r.Route("/organization_suggestions", func(r chi.Router) {
@@ -512,6 +591,8 @@ func (router *Router) v1Router() chi.Router {
 								r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.organizations.Delete))
 								r.Method(http.MethodPut, "/logo", clerkhttp.Handler(router.organizations.UpdateLogo))
 								r.Method(http.MethodDelete, "/logo", clerkhttp.Handler(router.organizations.DeleteLogo))
+								r.Method(http.MethodPost, "/logo/uploads", clerkhttp.Handler(router.organizations.CreateLogoUpload))
+								r.Method(http.MethodPost, "/logo/uploads/{imageID}/complete", clerkhttp.Handler(router.organizations.CompleteLogoUpload))
 
 								r.Group(func(r chi.Router) {
 									r.Use(clerkhttp.Middleware(router.organizations.EnsureOrganizationExists))
@@ -550,7 +631,6 @@ func (router *Router) v1Router() chi.Router {
 									})
 
 									r.Route("/membership_requests", func(r chi.Router) {
-										r.Use(clerkhttp.Middleware(router.organizations.EnsureMembersManagePermission))
 										r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgMembershipRequests.List))
 										r.Method(http.MethodPost, "/{requestID}/accept", clerkhttp.Handler(router.orgMembershipRequests.Accept))
 										r.Method(http.MethodPost, "/{requestID}/reject", clerkhttp.Handler(router.orgMembershipRequests.Reject))
@@ -570,6 +650,19 @@ func (router *Router) v1Router() chi.Router {
 										})
 									})
 
+									r.Route("/saml_connections", func(r chi.Router) {
+										// connections can only be set up for verified domains
+										r.Use(clerkhttp.Middleware(router.organizationDomains.EnsureDomainsEnabled))
+										r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgSAMLConnections.List))
+										r.Method(http.MethodPost, "/", clerkhttp.Handler(router.orgSAMLConnections.Create))
+
+										r.Route("/{samlConnectionID}", func(r chi.Router) {
+											r.Method(http.MethodGet, "/", clerkhttp.Handler(router.orgSAMLConnections.Read))
+											r.Method(http.MethodPatch, "/", clerkhttp.Handler(router.orgSAMLConnections.Update))
+											r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.orgSAMLConnections.Delete))
+										})
+									})
+
 									r.Route("/roles", func(r chi.Router) {
 										r.Method(http.MethodGet, "/", clerkhttp.Handler(router.organizations.ListOrganizationRoles))
 									})
@@ -4,8 +4,8 @@ import (
 	"net/http"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/ctx/clerk_testing_token"
-	"clerk/utils/log"
 	"clerk/utils/param"
 )
 
@@ -21,7 +21,7 @@ func testingToken(_ http.ResponseWriter, r *http.Request) (*http.Request, apierr
 		delete(r.Form, param.ClerkTestingToken)
 
 		r = r.WithContext(clerk_testing_token.NewContext(ctx, token))
-		log.AddToLogLine(ctx, log.ClerkTestingToken, token)
+		requestlog.Add(ctx, requestlog.ClerkTestingToken, token)
 	}
 
 	return r, nil
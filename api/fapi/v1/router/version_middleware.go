@@ -5,6 +5,7 @@ import (
 
 	"clerk/api/apierror"
 	event_service "clerk/api/shared/events"
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/ctx/clerkjs_version"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctx/iossdk_version"
@@ -38,7 +39,7 @@ func logClerkJSVersion(db database.Database) func(http.ResponseWriter, *http.Req
 
 		version := readVersion(r)
 		r = r.WithContext(clerkjs_version.NewContext(ctx, version))
-		log.AddToLogLine(ctx, log.ClerkJSVersion, version)
+		requestlog.Add(ctx, requestlog.ClerkJSVersion, version)
 
 		env := environment.FromContext(ctx)
 		instance := env.Instance
@@ -108,7 +109,7 @@ func logClerkIOSSDKVersion(deps clerk.Deps) func(http.ResponseWriter, *http.Requ
 			return r, nil
 		}
 		r = r.WithContext(iossdk_version.NewContext(ctx, version))
-		log.AddToLogLine(ctx, log.ClerkIOSSDKVersion, version)
+		requestlog.Add(ctx, requestlog.ClerkIOSSDKVersion, version)
 
 		env := environment.FromContext(ctx)
 		instance := env.Instance
@@ -19,6 +19,7 @@ import (
 	"clerk/api/fapi/v1/samlaccount"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/identifications"
+	"clerk/api/shared/orgdomain"
 	"clerk/api/shared/restrictions"
 	"clerk/api/shared/saml"
 	"clerk/api/shared/sessions"
@@ -62,6 +63,7 @@ type HTTP struct {
 
 	// services
 	clientService       *clients.Service
+	orgDomainService    *orgdomain.Service
 	restrictionService  *restrictions.Service
 	samlAccountService  *samlaccount.Service
 	samlService         *saml.SAML
@@ -89,7 +91,8 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 		clock:                deps.Clock(),
 		db:                   deps.DB(),
 		clientService:        clients.NewService(deps),
-		restrictionService:   restrictions.NewService(deps.EmailQualityChecker()),
+		orgDomainService:     orgdomain.NewService(deps.Clock()),
+		restrictionService:   restrictions.NewService(deps),
 		samlAccountService:   samlaccount.NewService(deps),
 		samlService:          saml.New(),
 		signInService:        sign_in.NewService(deps),
@@ -97,12 +100,12 @@ func NewHTTP(deps clerk.Deps) *HTTP {
 		userLockoutService:   userlockout.NewService(deps),
 		verificationService:  verifications.NewService(deps.Clock()),
 		sessionService:       sessions.NewService(deps),
-		accountTransfersRepo: repository.NewAccountTransfers(),
-		identificationRepo:   repository.NewIdentification(),
-		signInRepo:           repository.NewSignIn(),
-		signUpRepo:           repository.NewSignUp(),
-		userRepo:             repository.NewUsers(),
-		verificationRepo:     repository.NewVerification(),
+		accountTransfersRepo: deps.Repositories().AccountTransfers,
+		identificationRepo:   deps.Repositories().Identification,
+		signInRepo:           deps.Repositories().SignIn,
+		signUpRepo:           deps.Repositories().SignUp,
+		userRepo:             deps.Repositories().Users,
+		verificationRepo:     deps.Repositories().Verification,
 		clientDataService:    client_data.NewService(deps),
 	}
 }
@@ -191,6 +194,11 @@ func (s HTTP) AssertionConsumerService(w http.ResponseWriter, r *http.Request) (
 		return s.handleACSError(ctx, w, r, verification, relayStateToken, apiErr)
 	}
 
+	domainProvisioning, err := s.samlService.DomainProvisioningFromAssertion(ctx, s.db, assertion, samlConnection, samlUser.EmailAddress)
+	if err != nil {
+		return s.handleACSError(ctx, w, r, verification, relayStateToken, err)
+	}
+
 	// Check instance restrictions (Allowlist, Blocklist) based on user's data
 	apiErr = s.enforceInstanceRestrictions(
 		ctx,
@@ -253,6 +261,9 @@ func (s HTTP) AssertionConsumerService(w http.ResponseWriter, r *http.Request) (
 				return true, err
 			}
 
+			if err := s.provisionOrganizationDomains(ctx, tx, env, samlConnection, samlUser, createdSession, domainProvisioning); err != nil {
+				return true, err
+			}
 			return false, nil
 		case constants.OSTSignUp:
 			createdSession, err = s.finishFlowForSignUp(ctx, tx, env, client, signUp, verification, samlConnection, samlUser)
@@ -265,6 +276,9 @@ func (s HTTP) AssertionConsumerService(w http.ResponseWriter, r *http.Request) (
 				return true, err
 			}
 
+			if err := s.provisionOrganizationDomains(ctx, tx, env, samlConnection, samlUser, createdSession, domainProvisioning); err != nil {
+				return true, err
+			}
 			return false, nil
 		default:
 			return true, fmt.Errorf("SAMLRelayStateToken SourceType not implemented: %s", relayStateToken.SourceType)
@@ -283,7 +297,7 @@ func (s HTTP) AssertionConsumerService(w http.ResponseWriter, r *http.Request) (
 		return nil, nil
 	}
 
-	if err := s.sessionService.Activate(ctx, env.Instance, createdSession); err != nil {
+	if err := s.sessionService.Activate(ctx, env, createdSession); err != nil {
 		return s.handleACSError(ctx, w, r, verification, relayStateToken, err)
 	}
 
@@ -614,6 +628,32 @@ func (s HTTP) finishFlowForSignIn(ctx context.Context, tx database.Tx, env *mode
 	})
 }
 
+// provisionOrganizationDomains enrolls the user of the newly created session
+// to the organization which owns the connection, creating its domain if
+// needed.
+func (s HTTP) provisionOrganizationDomains(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	samlConnection *model.SAMLConnection,
+	samlUser *pkgsaml.User,
+	createdSession *model.Session,
+	domainProvisioning *saml.DomainProvisioning,
+) error {
+	if createdSession == nil || domainProvisioning == nil {
+		return nil
+	}
+
+	return s.orgDomainService.ProvisionFromSAML(ctx, tx, env.AuthConfig, orgdomain.ProvisionParams{
+		InstanceID:     env.Instance.ID,
+		UserID:         createdSession.UserID,
+		EmailAddress:   samlUser.EmailAddress,
+		Domain:         domainProvisioning.Domain,
+		OrganizationID: domainProvisioning.OrganizationID,
+		EnrollmentMode: samlConnection.OrganizationDomainEnrollmentMode.String,
+	})
+}
+
 // In case of a SAML IdP-initiated flow, we are using the ticket in order to complete the flow.
 // After validating and parsing the SAML response we received from the IdP provider and extract the
 // user attributes, we generate a ticket token and redirect to the FAPI /v1/tickets/accept endpoint
@@ -0,0 +1,204 @@
+package saml
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/saml"
+	"clerk/model"
+	"clerk/pkg/constants"
+	"clerk/pkg/ctx/environment"
+	pkgsaml "clerk/pkg/saml"
+
+	samlsp "github.com/crewjam/saml"
+	"github.com/go-chi/chi/v5"
+)
+
+// how long we wait for the IdP to respond to our LogoutRequest
+const logoutRelayStateTTL = 10 * time.Minute
+
+func logoutRelayStateKey(instanceID, relayState string) string {
+	return fmt.Sprintf("saml_logout:%s:%s", instanceID, relayState)
+}
+
+// GET|POST /v1/saml/logout/{samlConnectionID}
+//
+// The single logout endpoint of a SAML connection. It serves three purposes:
+//   - If there's a SAMLRequest, the IdP signs the user out, so we revoke all
+//     their sessions and respond with a LogoutResponse.
+//   - If there's a SAMLResponse, the IdP completed a logout we initiated, so
+//     we redirect back to the redirect_url of the logout.
+//   - Otherwise, the user signs out of the application, so we end their
+//     sessions on the requesting client and redirect them to the IdP, to
+//     sign out of it as well.
+func (s HTTP) Logout(w http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	ctx := r.Context()
+	connectionID := chi.URLParam(r, "samlConnectionID")
+	env := environment.FromContext(ctx)
+
+	sp, samlConnection, err := s.samlService.ServiceProviderForLogout(ctx, s.db, env, connectionID)
+	if errors.Is(err, saml.ErrConnectionNotFound) {
+		return nil, apierror.SAMLConnectionActiveNotFound(connectionID)
+	} else if errors.Is(err, saml.ErrLogoutNotSupported) {
+		return nil, apierror.SAMLLogoutNotSupported(connectionID)
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	var redirectURL string
+	var apiErr apierror.Error
+	switch {
+	case r.Form.Get("SAMLRequest") != "":
+		redirectURL, apiErr = s.handleIdpLogoutRequest(ctx, r, env, sp, samlConnection)
+	case r.Form.Get("SAMLResponse") != "":
+		redirectURL, apiErr = s.handleIdpLogoutResponse(ctx, r, env, sp)
+	default:
+		redirectURL, apiErr = s.startLogout(ctx, r, env, sp, samlConnection)
+	}
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+	return nil, nil
+}
+
+// handleIdpLogoutRequest revokes all the sessions of the user the IdP
+// LogoutRequest refers to, and returns the URL of our LogoutResponse.
+func (s HTTP) handleIdpLogoutRequest(ctx context.Context, r *http.Request, env *model.Env, sp *samlsp.ServiceProvider, samlConnection *model.SAMLConnection) (string, apierror.Error) {
+	logoutRequest, err := saml.ParseLogoutRequest(sp, r, s.clock.Now())
+	if err != nil {
+		return "", apierror.SAMLLogoutRequestInvalid(err)
+	}
+
+	samlAccount, err := s.samlAccountService.QueryForUser(ctx, s.db, samlConnection, &pkgsaml.User{
+		EmailAddress: emailFromNameID(&samlsp.Subject{NameID: logoutRequest.NameID}),
+	})
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+
+	// NOTE: we respond with success even if there's no such user, as there
+	// are no sessions to revoke either way.
+	if samlAccount != nil {
+		identification, err := s.identificationRepo.FindByIDAndInstance(ctx, s.db, samlAccount.IdentificationID, env.Instance.ID)
+		if err != nil {
+			return "", apierror.Unexpected(err)
+		}
+		if identification.UserID.Valid {
+			if apiErr := s.sessionService.RevokeAllForUser(ctx, env.Instance, identification.UserID.String); apiErr != nil {
+				return "", apiErr
+			}
+		}
+	}
+
+	responseURL, err := saml.LogoutResponseURL(sp, logoutRequest.ID, r.Form.Get("RelayState"))
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	return responseURL.String(), nil
+}
+
+// handleIdpLogoutResponse validates the IdP LogoutResponse to a logout we
+// started, and returns the redirect_url it was started with.
+func (s HTTP) handleIdpLogoutResponse(ctx context.Context, r *http.Request, env *model.Env, sp *samlsp.ServiceProvider) (string, apierror.Error) {
+	if err := saml.ValidateLogoutResponse(sp, r); err != nil {
+		return "", apierror.SAMLLogoutResponseInvalid(err)
+	}
+
+	relayState := r.Form.Get("RelayState")
+	if relayState == "" {
+		return "", apierror.SAMLResponseRelayStateMissing()
+	}
+
+	key := logoutRelayStateKey(env.Instance.ID, relayState)
+	var redirectURL string
+	if err := s.cache.Get(ctx, key, &redirectURL); err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	if redirectURL == "" {
+		return "", apierror.SAMLLogoutResponseInvalid(fmt.Errorf("saml: unknown logout relay state %s", relayState))
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	return redirectURL, nil
+}
+
+// startLogout ends the sessions of the requesting client which belong to
+// users of the connection, and returns the URL of our LogoutRequest to the
+// IdP.
+func (s HTTP) startLogout(ctx context.Context, r *http.Request, env *model.Env, sp *samlsp.ServiceProvider, samlConnection *model.SAMLConnection) (string, apierror.Error) {
+	redirectURL, err := url.Parse(r.Form.Get("redirect_url"))
+	if err != nil || redirectURL.String() == "" {
+		return "", apierror.FormInvalidParameterFormat("redirect_url", "Must be a valid url")
+	}
+	if apiErr := s.clientService.ValidateRedirectURL(ctx, redirectURL); apiErr != nil {
+		return "", apiErr
+	}
+
+	client, apiErr := s.clientService.GetClientFromContext(ctx)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	sessions, err := s.clientDataService.FindAllCurrentSessionsByClients(ctx, env.Instance.ID, []string{client.ID})
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+
+	var nameID string
+	for _, cdsSession := range sessions {
+		session := cdsSession.ToSessionModel()
+		if session.GetStatus(s.clock) != constants.SESSActive {
+			continue
+		}
+
+		identification, err := s.identificationRepo.QueryClaimedSAMLByUserAndConnection(ctx, s.db, session.UserID, samlConnection.ID)
+		if err != nil {
+			return "", apierror.Unexpected(err)
+		}
+		if identification == nil {
+			continue
+		}
+
+		if apiErr := s.sessionService.End(ctx, env.Instance, session); apiErr != nil {
+			return "", apiErr
+		}
+		nameID = identification.Identifier.String
+	}
+	if nameID == "" {
+		// none of the client's users signed in with the connection, so
+		// there's nothing to sign out of at the IdP
+		return redirectURL.String(), nil
+	}
+
+	relayState, err := generateLogoutRelayState()
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	if err := s.cache.Set(ctx, logoutRelayStateKey(env.Instance.ID, relayState), redirectURL.String(), logoutRelayStateTTL); err != nil {
+		return "", apierror.Unexpected(err)
+	}
+
+	requestURL, err := saml.LogoutRequestURL(sp, nameID, relayState)
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	return requestURL.String(), nil
+}
+
+func generateLogoutRelayState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -38,10 +38,10 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		eventService:        events.NewService(deps),
 		serializableService: serializable.NewService(deps.Clock()),
-		identificationRepo:  repository.NewIdentification(),
-		samlAccountRepo:     repository.NewSAMLAccount(),
-		userRepo:            repository.NewUsers(),
-		verificationRepo:    repository.NewVerification(),
+		identificationRepo:  deps.Repositories().Identification,
+		samlAccountRepo:     deps.Repositories().SAMLAccount,
+		userRepo:            deps.Repositories().Users,
+		verificationRepo:    deps.Repositories().Verification,
 	}
 }
 
@@ -165,6 +165,11 @@ func (s *Service) getOrCreateEmailIdentification(ctx context.Context, tx databas
 // 1. Update the saml account data (first name, last name, public metadata) if needed based on the data retrieved by the IdP provider
 // 2. Update the user's data (first name, last name, public metadata) if not already set based on the data retrieved by the IdP provider
 func (s *Service) Update(ctx context.Context, tx database.Tx, userSettings *usersettings.UserSettings, instance *model.Instance, user *model.User, samlAccount *model.SAMLAccount, samlUser *saml.User) error {
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+	if err != nil {
+		return err
+	}
+
 	samlAccountCols := make([]string, 0)
 
 	if samlUser.FirstName != nil && samlAccount.FirstName.String != *samlUser.FirstName {
@@ -222,7 +227,7 @@ func (s *Service) Update(ctx context.Context, tx database.Tx, userSettings *user
 		if err != nil {
 			return err
 		}
-		if err = s.eventService.UserUpdated(ctx, tx, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+		if err = s.eventService.UserUpdated(ctx, tx, instance, serialize.UserToServerAPI(ctx, userSerializable), previousUser); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,118 @@
+package service_accounts
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/ratelimit"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+)
+
+// tokenRateLimitRoute is the route of the buckets which limit the token
+// requests of each service account.
+const tokenRateLimitRoute = "fapi_service_account_tokens"
+
+type HTTP struct {
+	service          *Service
+	rateLimitService *ratelimit.Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service:          NewService(deps),
+		rateLimitService: ratelimit.NewService(deps),
+	}
+}
+
+// POST /oauth/service_accounts/token
+func (h *HTTP) Token(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	// Client credentials can be sent either with HTTP Basic authentication
+	// or in the request body.
+	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3.1
+	clientID, clientSecret, basicAuth := r.BasicAuth()
+	if !basicAuth {
+		clientID = r.Form.Get("client_id")
+		clientSecret = r.Form.Get("client_secret")
+	}
+
+	// Client secrets are compared with bcrypt, which is expensive on purpose,
+	// so the token requests of each service account are limited no matter
+	// which client IP they come from.
+	ctx := r.Context()
+	env := environment.FromContext(ctx)
+	result := h.rateLimitService.Allow(ctx, ratelimit.Key(env.Instance.ID, clientID, tokenRateLimitRoute), ratelimit.DefaultLimit(ratelimit.FAPI))
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return respond(w, r, nil, apierror.RateLimitExceeded(retryAfter), basicAuth)
+	}
+
+	response, apiErr := h.service.Token(ctx, TokenParams{
+		GrantType:    r.Form.Get("grant_type"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Origin:       r.Header.Get("Origin"),
+	})
+	return respond(w, r, response, apiErr, basicAuth)
+}
+
+// respond writes the token response, or the error as an OAuth2 error
+// response, since OAuth2 clients don't understand Clerk errors.
+// https://datatracker.ietf.org/doc/html/rfc6749#section-5
+func respond(w http.ResponseWriter, r *http.Request, response *serialize.ServiceAccountTokenResponse, apiErr apierror.Error, basicAuth bool) (interface{}, apierror.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	status := http.StatusOK
+	var body interface{} = response
+	if apiErr != nil {
+		status, body = toTokenError(apiErr)
+		// clients which authenticated with HTTP Basic authentication are
+		// told which scheme to use
+		if status == http.StatusUnauthorized && basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="service_accounts"`)
+		}
+	}
+
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Warning(r.Context(), "service_accounts/respond: writing response: %s", err)
+	}
+	return nil, nil
+}
+
+// tokenError is an OAuth2 error code, along with the status it's returned
+// with.
+type tokenError struct {
+	code   string
+	status int
+}
+
+// tokenErrors maps error codes to the OAuth2 errors of RFC 6749 section 5.2.
+// Rate limiting isn't covered by the RFC, so it's reported with the error
+// code which tells clients to retry later.
+var tokenErrors = map[string]tokenError{
+	apierror.ServiceAccountInvalidClientCode:      {code: "invalid_client", status: http.StatusUnauthorized},
+	apierror.ServiceAccountUnsupportedGrantCode:   {code: "unsupported_grant_type", status: http.StatusBadRequest},
+	apierror.ServiceAccountJWTTemplateMissingCode: {code: "unauthorized_client", status: http.StatusBadRequest},
+	apierror.RateLimitExceededCode:                {code: "temporarily_unavailable", status: http.StatusTooManyRequests},
+}
+
+// toTokenError returns the status and the OAuth2 error response of the
+// error. Unexpected errors are reported without a description, so that
+// nothing internal leaks.
+func toTokenError(apiErr apierror.Error) (int, *serialize.ServiceAccountTokenErrorResponse) {
+	if errs := apiErr.Errors(); len(errs) > 0 {
+		if tokenErr, ok := tokenErrors[errs[0].Code()]; ok {
+			return tokenErr.status, serialize.ServiceAccountTokenError(tokenErr.code, errs[0].LongMessage())
+		}
+	}
+	return http.StatusInternalServerError, serialize.ServiceAccountTokenError("server_error", "")
+}
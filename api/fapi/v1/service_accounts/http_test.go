@@ -0,0 +1,74 @@
+package service_accounts
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToTokenError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name       string
+		apiErr     apierror.Error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "invalid client", apiErr: apierror.ServiceAccountInvalidClient(), wantStatus: http.StatusUnauthorized, wantCode: "invalid_client"},
+		{name: "unsupported grant", apiErr: apierror.ServiceAccountUnsupportedGrant("password"), wantStatus: http.StatusBadRequest, wantCode: "unsupported_grant_type"},
+		{name: "template missing", apiErr: apierror.ServiceAccountJWTTemplateMissing(), wantStatus: http.StatusBadRequest, wantCode: "unauthorized_client"},
+		{name: "rate limited", apiErr: apierror.RateLimitExceeded(10), wantStatus: http.StatusTooManyRequests, wantCode: "temporarily_unavailable"},
+		{name: "unexpected", apiErr: apierror.Unexpected(errors.New("db is down")), wantStatus: http.StatusInternalServerError, wantCode: "server_error"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			status, response := toTokenError(tc.apiErr)
+			assert.Equal(t, tc.wantStatus, status)
+			assert.Equal(t, tc.wantCode, response.Error)
+			if tc.wantCode == "server_error" {
+				assert.Empty(t, response.ErrorDescription)
+			} else {
+				assert.NotEmpty(t, response.ErrorDescription)
+			}
+		})
+	}
+}
+
+func TestRespond(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/oauth/service_accounts/token", nil)
+
+	w := httptest.NewRecorder()
+	_, apiErr := respond(w, r, serialize.ServiceAccountToken("token", 60), nil, true)
+	require.Nil(t, apiErr)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"access_token":"token","token_type":"Bearer","expires_in":60}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	_, apiErr = respond(w, r, nil, apierror.ServiceAccountInvalidClient(), true)
+	require.Nil(t, apiErr)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "invalid_client", body["error"])
+	assert.NotContains(t, body, "errors")
+
+	w = httptest.NewRecorder()
+	_, _ = respond(w, r, nil, apierror.ServiceAccountInvalidClient(), false)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+}
@@ -0,0 +1,65 @@
+package service_accounts
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/service_accounts"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type Service struct {
+	db database.Database
+
+	// services
+	serviceAccountService *service_accounts.Service
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                    deps.DB(),
+		serviceAccountService: service_accounts.NewService(deps),
+	}
+}
+
+type TokenParams struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Origin       string
+}
+
+// Token issues an access token to the service account with the given client
+// credentials.
+func (s *Service) Token(ctx context.Context, params TokenParams) (*serialize.ServiceAccountTokenResponse, apierror.Error) {
+	if params.GrantType != service_accounts.GrantTypeClientCredentials {
+		return nil, apierror.ServiceAccountUnsupportedGrant(params.GrantType)
+	}
+
+	env := environment.FromContext(ctx)
+
+	var token *service_accounts.Token
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		serviceAccount, err := s.serviceAccountService.Authenticate(ctx, tx, env.Instance.ID, params.ClientID, params.ClientSecret)
+		if err != nil {
+			return true, err
+		}
+
+		token, err = s.serviceAccountService.IssueToken(ctx, tx, env, serviceAccount, params.Origin)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.ServiceAccountToken(token.AccessToken, token.ExpiresIn), nil
+}
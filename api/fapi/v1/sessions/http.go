@@ -6,6 +6,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/fapi/v1/cookies"
 	"clerk/api/fapi/v1/wrapper"
+	"clerk/api/shared/requestlog"
 	"clerk/model"
 	"clerk/pkg/ctx/activity"
 	"clerk/pkg/ctx/requesting_session"
@@ -13,7 +14,6 @@ import (
 	"clerk/pkg/ctxkeys"
 	"clerk/utils/clerk"
 	"clerk/utils/form"
-	"clerk/utils/log"
 	"clerk/utils/param"
 
 	"github.com/go-chi/chi/v5"
@@ -46,15 +46,15 @@ func (h *HTTP) SetRequestingSession(_ http.ResponseWriter, r *http.Request) (*ht
 		return nil, err
 	}
 
-	log.AddToLogLine(ctx, log.SessionID, session.ID)
-	log.AddToLogLine(ctx, log.UserID, session.UserID)
+	requestlog.Add(ctx, requestlog.SessionID, session.ID)
+	requestlog.Add(ctx, requestlog.UserID, session.UserID)
 
 	actorID, actorError := session.ActorID()
 	if actorError != nil {
 		return r.WithContext(ctx), apierror.Unexpected(actorError)
 	}
 	if actorID != nil {
-		log.AddToLogLine(ctx, log.ActorID, actorID)
+		requestlog.Add(ctx, requestlog.ActorID, actorID)
 	}
 
 	newCtx := requesting_session.NewContext(ctx, session)
@@ -152,3 +152,10 @@ func (h *HTTP) ListUserActiveSessions(_ http.ResponseWriter, r *http.Request) (i
 	user := requesting_user.FromContext(ctx)
 	return h.service.ListUserActiveSessions(r.Context(), user.ID)
 }
+
+// GET /v1/me/sessions/{sessionID}/activities
+func (h *HTTP) ListSessionActivities(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	user := requesting_user.FromContext(ctx)
+	return h.service.ListSessionActivities(ctx, user.ID, chi.URLParam(r, "sessionID"))
+}
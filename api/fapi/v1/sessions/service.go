@@ -11,10 +11,10 @@ import (
 	sharedcookies "clerk/api/shared/cookies"
 	"clerk/api/shared/events"
 	"clerk/api/shared/features"
+	"clerk/api/shared/serviceconfig"
 	"clerk/api/shared/sessions"
 	"clerk/model"
 	clerkbilling "clerk/pkg/billing"
-	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctx/maintenance"
@@ -60,11 +60,11 @@ func NewService(deps clerk.Deps) *Service {
 		eventService:               events.NewService(deps),
 		featureService:             features.NewService(deps.DB(), deps.GueClient()),
 		sessionService:             sessions.NewService(deps),
-		organizationRepo:           repository.NewOrganization(),
-		organizationMembershipRepo: repository.NewOrganizationMembership(),
-		subscriptionPlanRepo:       repository.NewSubscriptionPlans(),
-		userRepo:                   repository.NewUsers(),
-		sessionActivityRepo:        repository.NewSessionActivities(),
+		organizationRepo:           deps.Repositories().Organization,
+		organizationMembershipRepo: deps.Repositories().OrganizationMembership,
+		subscriptionPlanRepo:       deps.Repositories().SubscriptionPlans,
+		userRepo:                   deps.Repositories().Users,
+		sessionActivityRepo:        deps.Repositories().SessionActivities,
 		clientDataService:          client_data.NewService(deps),
 		sharedCookieService:        sharedcookies.NewService(deps),
 	}
@@ -103,7 +103,7 @@ func (s *Service) Touch(ctx context.Context, params TouchParams) (*serialize.Ses
 		return nil, apierror.SignedOut()
 	}
 
-	if maxRate := cenv.GetDurationInSeconds(cenv.ClerkMaxSessionTouchRateSeconds); maxRate > 0 {
+	if maxRate := serviceconfig.Live().SessionTouchRate.Get(); maxRate > 0 {
 		// Rate Limiting window check (maxRate = 1s):
 		// session.TouchedAt      -> 12:34:56.789
 		// session.TouchedAt + 1" -> 12:34:57.789
@@ -308,12 +308,10 @@ func (s *Service) ListUserActiveSessions(ctx context.Context, userID string) ([]
 	env := environment.FromContext(ctx)
 	requestingSession := requesting_session.FromContext(ctx)
 
-	subscriptionPlans, err := s.subscriptionPlanRepo.FindAllBySubscription(ctx, s.db, env.Subscription.ID)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
+	deviceTrackingEnabled, apiErr := s.isDeviceTrackingEnabled(ctx, env)
+	if apiErr != nil {
+		return nil, apiErr
 	}
-	unsupportedFeatures := clerkbilling.ValidateSupportedFeatures(set.New(clerkbilling.Features.DeviceTracking), env.Subscription, subscriptionPlans...)
-	deviceTrackingEnabled := env.Instance.HasAccessToAllFeatures() || len(unsupportedFeatures) == 0
 
 	var userSessions []*model.Session
 	if deviceTrackingEnabled {
@@ -417,3 +415,59 @@ func (s *Service) loadSessionFromCtx(ctx context.Context, sessionID string) (*mo
 	}
 	return session, nil
 }
+
+// ListSessionActivities returns the activity log of one of the sessions of
+// the given user, so that users can audit the devices and locations their
+// sessions were used from.
+func (s *Service) ListSessionActivities(ctx context.Context, userID, sessionID string) ([]*serialize.SessionActivityResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	deviceTrackingEnabled, apiErr := s.isDeviceTrackingEnabled(ctx, env)
+	if apiErr != nil {
+		return nil, apiErr
+	} else if !deviceTrackingEnabled {
+		return nil, apierror.UnsupportedSubscriptionPlanFeatures([]string{clerkbilling.Features.DeviceTracking})
+	}
+
+	userSessions, err := s.clientDataService.FindAllUserSessions(ctx, env.Instance.ID, userID, nil)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	ownsSession := false
+	for _, userSession := range userSessions {
+		if userSession.ID == sessionID {
+			ownsSession = true
+			break
+		}
+	}
+	if !ownsSession {
+		return nil, apierror.UnauthorizedActionForSession(sessionID)
+	}
+
+	activities, err := s.clientDataService.FindAllSessionActivities(ctx, env.Instance.ID, sessionID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.SessionActivityResponse, len(activities))
+	for i, activity := range activities {
+		responses[i] = serialize.SessionActivity(activity)
+	}
+	return responses, nil
+}
+
+// isDeviceTrackingEnabled reports whether the subscription of the instance
+// includes device tracking.
+func (s *Service) isDeviceTrackingEnabled(ctx context.Context, env *model.Env) (bool, apierror.Error) {
+	if env.Instance.HasAccessToAllFeatures() {
+		return true, nil
+	}
+
+	subscriptionPlans, err := s.subscriptionPlanRepo.FindAllBySubscription(ctx, s.db, env.Subscription.ID)
+	if err != nil {
+		return false, apierror.Unexpected(err)
+	}
+	unsupportedFeatures := clerkbilling.ValidateSupportedFeatures(set.New(clerkbilling.Features.DeviceTracking), env.Subscription, subscriptionPlans...)
+	return len(unsupportedFeatures) == 0, nil
+}
@@ -9,7 +9,9 @@ import (
 	"clerk/api/fapi/v1/cookies"
 	"clerk/api/fapi/v1/wrapper"
 	"clerk/api/serialize"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/sign_in"
+	"clerk/api/shared/trusted_devices"
 	"clerk/model"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctxkeys"
@@ -18,13 +20,17 @@ import (
 	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/form"
-	"clerk/utils/log"
 	"clerk/utils/param"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jonboulle/clockwork"
 )
 
+// Form parameters used in sign-in related HTTP requests.
+var (
+	paramTrustDevice = param.NewSingle(param.T.Bool, "trust_device", nil)
+)
+
 type HTTP struct {
 	db    database.Database
 	clock clockwork.Clock
@@ -159,8 +165,8 @@ func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (_ interface{}, re
 
 	// if there is a new client, add it to the logline so that it's included in our logs
 	if newClient != nil {
-		log.AddToLogLine(ctx, log.ClientID, newClient.ID)
-		log.AddToLogLine(ctx, log.RotatingToken, newClient.RotatingToken)
+		requestlog.Add(ctx, requestlog.ClientID, newClient.ID)
+		requestlog.Add(ctx, requestlog.RotatingToken, newClient.RotatingToken)
 	}
 
 	env := environment.FromContext(ctx)
@@ -315,7 +321,7 @@ func (h *HTTP) AttemptFirstFactor(w http.ResponseWriter, r *http.Request) (_ int
 		Origin:                     r.Header.Get("Origin"),
 	}
 
-	signIn, newClient, err := h.service.AttemptFirstFactor(ctx, attemptForm)
+	signIn, newClient, err := h.service.AttemptFirstFactor(ctx, attemptForm, trusted_devices.TokenFromRequest(r))
 	if err != nil {
 		return nil, err
 	}
@@ -389,7 +395,7 @@ func (h *HTTP) AttemptSecondFactor(w http.ResponseWriter, r *http.Request) (_ in
 	}()
 
 	reqParamsSet := param.NewSet(param.Strategy)
-	optParamsSet := param.NewSet(param.Code)
+	optParamsSet := param.NewSet(param.Code, paramTrustDevice)
 
 	pl := param.NewList(reqParamsSet, optParamsSet)
 	formErrs := form.Check(r.Form, pl)
@@ -407,6 +413,14 @@ func (h *HTTP) AttemptSecondFactor(w http.ResponseWriter, r *http.Request) (_ in
 		return nil, err
 	}
 
+	if trustDevice := form.GetBool(r.Form, paramTrustDevice.Name); newClient != nil && trustDevice != nil && *trustDevice {
+		token, expireAt, err := h.service.TrustDevice(ctx, signIn, r.UserAgent())
+		if err != nil {
+			return nil, err
+		}
+		http.SetCookie(w, cookies.NewTrustedDevice(ctx, token, expireAt))
+	}
+
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 	signInResponse, err := h.toResponse(ctx, signIn, userSettings)
@@ -9,17 +9,23 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/fapi/v1/clients"
 	"clerk/api/shared/client_data"
+	"clerk/api/shared/instance_metrics"
+	"clerk/api/shared/phone_profiles"
 	"clerk/api/shared/restrictions"
 	"clerk/api/shared/saml"
 	"clerk/api/shared/session_activities"
 	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_in"
+	"clerk/api/shared/sign_in_anomalies"
 	sharedstrategies "clerk/api/shared/strategies"
+	"clerk/api/shared/trusted_devices"
 	userlockout "clerk/api/shared/user_lockout"
+	"clerk/api/shared/user_profile"
 	"clerk/api/shared/users"
 	"clerk/api/shared/verifications"
 	"clerk/model"
 	"clerk/model/sqbmodel"
+	"clerk/pkg/anomaly"
 	"clerk/pkg/backup_codes"
 	"clerk/pkg/cenv"
 	"clerk/pkg/clerkerrors"
@@ -34,6 +40,7 @@ import (
 	"clerk/pkg/set"
 	cstrings "clerk/pkg/strings"
 	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/pkg/usersettings/clerk/names"
 	"clerk/pkg/usersettings/clerk/strategies"
 	usersettingsmodel "clerk/pkg/usersettings/model"
 	"clerk/repository"
@@ -51,13 +58,17 @@ type Service struct {
 	// services
 	clientService            *clients.Service
 	clientDataService        *client_data.Service
+	instanceMetricsService   *instance_metrics.Service
 	restrictionService       *restrictions.Service
 	signInService            *sign_in.Service
+	trustedDeviceService     *trusted_devices.Service
 	userLockoutService       *userlockout.Service
+	userProfileService       *user_profile.Service
 	userService              *users.Service
 	verificationService      *verifications.Service
 	sessionService           *sessions.Service
 	sessionActivitiesService *session_activities.Service
+	signInAnomaliesService   *sign_in_anomalies.Service
 
 	// repositories
 	accountTransferRepo *repository.AccountTransfers
@@ -70,20 +81,24 @@ type Service struct {
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		deps:                     deps,
-		restrictionService:       restrictions.NewService(deps.EmailQualityChecker()),
+		restrictionService:       restrictions.NewService(deps),
 		clientService:            clients.NewService(deps),
 		clientDataService:        client_data.NewService(deps),
+		instanceMetricsService:   instance_metrics.NewService(deps),
 		signInService:            sign_in.NewService(deps),
+		trustedDeviceService:     trusted_devices.NewService(deps),
 		userLockoutService:       userlockout.NewService(deps),
+		userProfileService:       user_profile.NewService(deps.Clock()),
 		userService:              users.NewService(deps),
 		verificationService:      verifications.NewService(deps.Clock()),
 		sessionService:           sessions.NewService(deps),
 		sessionActivitiesService: session_activities.NewService(),
-		accountTransferRepo:      repository.NewAccountTransfers(),
-		identificationRepo:       repository.NewIdentification(),
-		signInRepo:               repository.NewSignIn(),
-		userRepo:                 repository.NewUsers(),
-		verificationRepo:         repository.NewVerification(),
+		signInAnomaliesService:   sign_in_anomalies.NewService(deps),
+		accountTransferRepo:      deps.Repositories().AccountTransfers,
+		identificationRepo:       deps.Repositories().Identification,
+		signInRepo:               deps.Repositories().SignIn,
+		userRepo:                 deps.Repositories().Users,
+		verificationRepo:         deps.Repositories().Verification,
 	}
 }
 
@@ -207,12 +222,17 @@ func (s *Service) Create(ctx context.Context, signInForm SignInCreateForm) (*mod
 		}
 	}
 
+	if apiErr := s.restrictionService.EnforceIPRules(ctx, s.deps.DB(), env.Instance, restrictions.IPRestrictedSignIn); apiErr != nil {
+		return nil, nil, apiErr
+	}
+
 	// Identify step:
 	// Uses the identifier (if any) to find the corresponding attribute.
 	// If a strategy is used, use the strategy to find it, i.e. if the strategy is `email_code`
 	// we expect the identifier to be an email address and nothing else.
 	// Otherwise, we go over all the enabled attributes of the user settings and try to see
 	// in which attribute the given identifier can fit in.
+	normalizePhoneNumberIdentifier(env.Instance, &signInForm, userSettings)
 	strategy, formErrs := validateAndRetrieveStrategy(&signInForm, userSettings)
 	signInAttribute, identifierErr := validateAndRetrieveSignInAttribute(signInForm.Identifier, strategy, userSettings)
 	formErrs = apierror.Combine(formErrs, identifierErr)
@@ -398,8 +418,8 @@ func (s *Service) Create(ctx context.Context, signInForm SignInCreateForm) (*mod
 						return true, err
 					}
 
-					// Enforce instance restrictions only in the case of a SAML IdP-initiated or Google One Tap flow
-					if signIn.HasSAMLConnection() || strategy.Name() == constants.VSGoogleOneTap {
+					// Enforce instance restrictions only in the case of a SAML IdP-initiated, Google One Tap or native Apple flow
+					if signIn.HasSAMLConnection() || strategy.Name() == constants.VSGoogleOneTap || strategy.Name() == constants.VSOAuthTokenApple {
 						emailIdentification, err := s.identificationRepo.FindByID(ctx, tx, identification.TargetIdentificationID.String)
 						if err != nil {
 							return true, err
@@ -511,7 +531,10 @@ func (s *Service) Create(ctx context.Context, signInForm SignInCreateForm) (*mod
 	}
 
 	if newSessionCreated && newSession != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, nil, apiErr
+			}
 			return nil, nil, apierror.Unexpected(err)
 		}
 	}
@@ -551,6 +574,30 @@ func validateAndRetrieveStrategy(createForm *SignInCreateForm, userSettings *use
 	return strategy.(strategies.SignInStrategy), nil
 }
 
+// normalizePhoneNumberIdentifier converts an identifier which is a phone
+// number in national format to E.164, according to the instance's phone
+// number profile, so that it can be matched with the stored phone numbers.
+// Usernames can consist only of digits as well, so the identifier is only
+// treated as a phone number when usernames can't be used to sign in, or when
+// the phone_code strategy was requested.
+func normalizePhoneNumberIdentifier(instance *model.Instance, signInForm *SignInCreateForm, userSettings *usersettings.UserSettings) {
+	if signInForm.Identifier == nil {
+		return
+	}
+	phoneNumberAttribute := userSettings.GetAttribute(names.PhoneNumber).Base()
+	if !phoneNumberAttribute.Enabled || !phoneNumberAttribute.UsedForFirstFactor {
+		return
+	}
+	usernameAttribute := userSettings.GetAttribute(names.Username).Base()
+	isPhoneCode := signInForm.Strategy != nil && *signInForm.Strategy == constants.VSPhoneCode
+	if !isPhoneCode && usernameAttribute.Enabled && usernameAttribute.UsedForFirstFactor {
+		return
+	}
+
+	identifier := phone_profiles.ForInstance(instance).NormalizeIdentifier(*signInForm.Identifier)
+	signInForm.Identifier = &identifier
+}
+
 func validateAndRetrieveSignInAttribute(
 	identifier *string,
 	strategy strategies.SignInStrategy,
@@ -827,7 +874,10 @@ func (s *Service) ResetPassword(ctx context.Context, params ResetPasswordParams)
 	}
 
 	if newSessionCreated {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, nil, apiErr
+			}
 			return nil, nil, apierror.Unexpected(err)
 		}
 		return signIn, client, nil
@@ -909,8 +959,10 @@ func (s *Service) PrepareFirstFactor(ctx context.Context, prepareForm strategies
 	return signIn, nil
 }
 
-// AttemptFirstFactor attempts to verify the prepared first factor for the current sign-in
-func (s *Service) AttemptFirstFactor(ctx context.Context, attemptForm strategies.SignInAttemptForm) (*model.SignIn, *model.Client, apierror.Error) {
+// AttemptFirstFactor attempts to verify the prepared first factor for the current sign-in.
+// If the sign-in happens on a device the user has trusted, as proven by the
+// given trusted device token, the second factor is skipped.
+func (s *Service) AttemptFirstFactor(ctx context.Context, attemptForm strategies.SignInAttemptForm, trustedDeviceToken string) (*model.SignIn, *model.Client, apierror.Error) {
 	env := environment.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
@@ -966,9 +1018,10 @@ func (s *Service) AttemptFirstFactor(ctx context.Context, attemptForm strategies
 			user,
 		)
 
-		if errors.Is(err, sharedstrategies.ErrInvalidCode) {
-			return false, err
-		} else if errors.Is(err, sharedstrategies.ErrInvalidPassword) {
+		if errors.Is(err, sharedstrategies.ErrInvalidCode) || errors.Is(err, sharedstrategies.ErrInvalidPassword) {
+			if sign_in_anomalies.Enabled(userSettings) {
+				s.signInAnomaliesService.RecordFailure(ctx, env.Instance.ID, activity.FromContext(ctx).IPAddress.String)
+			}
 			return false, err
 		} else if errors.Is(err, sharedstrategies.ErrPwnedPassword) {
 			// Propagate this error to the client if they can perform a reset, otherwise ignore it
@@ -994,11 +1047,30 @@ func (s *Service) AttemptFirstFactor(ctx context.Context, attemptForm strategies
 			}
 		}
 
+		// Assess the attempt before it counts towards the sign in, so that
+		// blocked attempts leave the sign in as it was
+		anomalyAction, err := s.assessSignInAnomalies(ctx, tx, env, userSettings, signIn, user, verification)
+		if err != nil {
+			return true, err
+		}
+		if anomalyAction == anomaly.ActionBlock {
+			// keep the assessment and the event of the blocked attempt
+			return false, apierror.SignInBlockedByRisk()
+		}
+
 		// Attach the verification to the sign in
 		if err := s.signInService.AttachFirstFactorVerification(ctx, tx, signIn, verification.ID, true); err != nil {
 			return true, err
 		}
 
+		// Risky attempts have to go through the second factor, even on
+		// trusted devices
+		if anomalyAction != anomaly.ActionStepUp {
+			if err := s.skipSecondFactorOnTrustedDevice(ctx, tx, env, signIn, user, userSettings, trustedDeviceToken); err != nil {
+				return true, err
+			}
+		}
+
 		// Check if sign in can be converted and convert it to session
 		readyToConvert, err := s.signInService.IsReadyToConvert(ctx, tx, signIn, userSettings)
 		if err != nil {
@@ -1049,7 +1121,10 @@ func (s *Service) AttemptFirstFactor(ctx context.Context, attemptForm strategies
 	}
 
 	if newSessionCreated && newSession != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, nil, apiErr
+			}
 			return nil, nil, apierror.Unexpected(err)
 		}
 		return signIn, client, nil
@@ -1207,6 +1282,61 @@ func (s *Service) attachSecondFactorVerification(ctx context.Context, exec datab
 	return s.signInRepo.Update(ctx, exec, signIn, columns...)
 }
 
+// skipSecondFactorOnTrustedDevice marks the second factor of the sign-in as
+// verified, if the instance has trusted devices enabled, the user has
+// two-factor enabled and the given token belongs to one of their trusted
+// devices.
+func (s *Service) skipSecondFactorOnTrustedDevice(ctx context.Context, tx database.Tx, env *model.Env,
+	signIn *model.SignIn, user *model.User, userSettings *usersettings.UserSettings, trustedDeviceToken string) error {
+	if trustedDeviceToken == "" || user == nil || !trusted_devices.Enabled(env.AuthConfig) {
+		return nil
+	}
+
+	hasTwoFactorEnabled, err := s.userProfileService.HasTwoFactorEnabled(ctx, tx, userSettings, user.ID)
+	if err != nil {
+		return err
+	}
+	if !hasTwoFactorEnabled {
+		return nil
+	}
+
+	device, err := s.trustedDeviceService.VerifyForUser(ctx, tx, env.Instance, user.ID, trustedDeviceToken)
+	if err != nil || device == nil {
+		return err
+	}
+
+	verification, err := s.trustedDeviceService.CreateVerification(ctx, tx, device)
+	if err != nil {
+		return err
+	}
+	return s.attachSecondFactorVerification(ctx, tx, signIn, verification.ID, true)
+}
+
+// assessSignInAnomalies scores the first factor attempt of the user, if the
+// instance has enabled it, and returns what should happen to the attempt.
+//
+// NOTE: Stepping up means that the second factor can't be skipped. Users
+// without two-factor enabled have no second factor to step up to, so their
+// attempts are only ever blocked.
+func (s *Service) assessSignInAnomalies(ctx context.Context, tx database.Tx, env *model.Env, userSettings *usersettings.UserSettings,
+	signIn *model.SignIn, user *model.User, verification *model.Verification) (anomaly.Action, error) {
+	if user == nil || !sign_in_anomalies.Enabled(userSettings) {
+		return anomaly.ActionAllow, nil
+	}
+
+	deviceActivity := activity.FromContext(ctx)
+	assessment, err := s.signInAnomaliesService.Assess(ctx, tx, env.Instance, user, verification, deviceActivity)
+	if err != nil {
+		return "", err
+	}
+
+	action := sign_in_anomalies.ActionFor(userSettings, assessment)
+	if err := s.signInAnomaliesService.Notify(ctx, tx, env.Instance, signIn, user, deviceActivity, assessment, action); err != nil {
+		return "", err
+	}
+	return action, nil
+}
+
 // AttemptSecondFactor attempts to verify the prepare second factor for the current sign-in
 func (s *Service) AttemptSecondFactor(ctx context.Context, attemptForm strategies.SignInAttemptForm) (*model.SignIn, *model.Client, apierror.Error) {
 	env := environment.FromContext(ctx)
@@ -1300,7 +1430,10 @@ func (s *Service) AttemptSecondFactor(ctx context.Context, attemptForm strategie
 	}
 
 	if newSessionCreated && newSession != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, nil, apiErr
+			}
 			return nil, nil, apierror.Unexpected(err)
 		}
 		return signIn, client, nil
@@ -1309,6 +1442,39 @@ func (s *Service) AttemptSecondFactor(ctx context.Context, attemptForm strategie
 	return signIn, nil, nil
 }
 
+// TrustDevice trusts the device of a sign-in which has just completed its
+// second factor, so that subsequent sign-ins of the same user on the device
+// can skip it. It returns the token which the device should hold on to.
+func (s *Service) TrustDevice(ctx context.Context, signIn *model.SignIn, userAgent string) (string, time.Time, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if !trusted_devices.Enabled(env.AuthConfig) {
+		return "", time.Time{}, apierror.FeatureNotEnabled()
+	}
+	if !signIn.SecondFactorSuccessVerificationID.Valid || !signIn.IdentificationID.Valid {
+		return "", time.Time{}, apierror.InvalidClientStateForAction("Trust device", "The second factor of this Sign In Attempt hasn't been verified.")
+	}
+
+	var token string
+	var device *model.TrustedDevice
+	txErr := s.deps.DB().PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		user, err := s.userRepo.FindByIdentification(ctx, tx, signIn.IdentificationID.String)
+		if err != nil {
+			return true, err
+		}
+
+		device, token, err = s.trustedDeviceService.Trust(ctx, tx, env, user.ID, userAgent)
+		if err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		return "", time.Time{}, apierror.Unexpected(txErr)
+	}
+	return token, device.ExpireAt, nil
+}
+
 // EnsureUserNotLockedFromSignIn checks if user is locked if provided with a sign-in.
 func (s *Service) EnsureUserNotLockedFromSignIn(ctx context.Context, exec database.Executor, signIn *model.SignIn) apierror.Error {
 	env := environment.FromContext(ctx)
@@ -1365,6 +1531,11 @@ func (s *Service) attemptVerificationWithLockout(ctx context.Context,
 			if incErr != nil {
 				return verification, incErr
 			}
+
+			incErr = s.instanceMetricsService.Increment(ctx, tx, env.Instance.ID, instance_metrics.SignIns, instance_metrics.OutcomeFailed)
+			if incErr != nil {
+				return verification, incErr
+			}
 		}
 
 		return verification, err
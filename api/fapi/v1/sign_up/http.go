@@ -9,17 +9,20 @@ import (
 	"clerk/api/fapi/v1/cookies"
 	"clerk/api/fapi/v1/wrapper"
 	"clerk/api/serialize"
+	"clerk/api/shared/custom_fields"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/sign_up"
 	"clerk/model"
+	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctxkeys"
 	"clerk/pkg/externalapis/turnstile"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/pkg/usersettings/clerk/strategies"
+	clerkwebauthn "clerk/pkg/webauthn"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
 	"clerk/utils/form"
-	"clerk/utils/log"
 	"clerk/utils/param"
 
 	"github.com/go-chi/chi/v5"
@@ -73,6 +76,8 @@ func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (_ interface{}, re
 		PhoneNumber:               form.GetStringOrNil(r.Form, param.PhoneNumber.Name),
 		EmailAddressOrPhoneNumber: form.GetStringOrNil(r.Form, param.EmailAddressOrPhoneNumber.Name),
 		UnsafeMetadata:            form.GetJSON(r.Form, param.UnsafeMetadata.Name),
+		LegalAccepted:             form.GetBool(r.Form, param.LegalAccepted.Name),
+		CustomFields:              form.GetJSON(r.Form, custom_fields.ParamName),
 		Strategy:                  form.GetStringOrNil(r.Form, param.Strategy.Name),
 		RedirectURL:               form.GetStringOrNil(r.Form, param.RedirectURL.Name),
 		ActionCompleteRedirectURL: form.GetStringOrNil(r.Form, param.ActionCompleteRedirectURL.Name),
@@ -83,6 +88,8 @@ func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (_ interface{}, re
 		CaptchaToken:              form.GetStringOrNil(r.Form, param.CaptchaToken.Name),
 		CaptchaError:              form.GetStringOrNil(r.Form, param.CaptchaError.Name),
 		CaptchaWidgetType:         form.GetStringOrNil(r.Form, param.CaptchaWidgetType.Name),
+		AcceptLanguage:            r.Header.Get("Accept-Language"),
+		ClientFingerprint:         form.GetStringOrNil(r.Form, param.ClientFingerprint.Name),
 	}
 	signUp, client, newClientCreated, err := h.service.Create(ctx, createForm)
 	if err != nil {
@@ -91,8 +98,8 @@ func (h *HTTP) Create(w http.ResponseWriter, r *http.Request) (_ interface{}, re
 
 	// if new client, add it to the logline so that it's included in our logs
 	if newClientCreated {
-		log.AddToLogLine(ctx, log.ClientID, client.ID)
-		log.AddToLogLine(ctx, log.RotatingToken, client.RotatingToken)
+		requestlog.Add(ctx, requestlog.ClientID, client.ID)
+		requestlog.Add(ctx, requestlog.RotatingToken, client.RotatingToken)
 	}
 
 	signUpResponse, err := h.toResponse(ctx, signUp, userSettings)
@@ -153,6 +160,8 @@ func (h *HTTP) Update(w http.ResponseWriter, r *http.Request) (_ interface{}, re
 		PhoneNumber:               form.GetStringOrNil(r.Form, param.PhoneNumber.Name),
 		EmailAddressOrPhoneNumber: form.GetStringOrNil(r.Form, param.EmailAddressOrPhoneNumber.Name),
 		UnsafeMetadata:            form.GetJSON(r.Form, param.UnsafeMetadata.Name),
+		LegalAccepted:             form.GetBool(r.Form, param.LegalAccepted.Name),
+		CustomFields:              form.GetJSON(r.Form, custom_fields.ParamName),
 		Strategy:                  form.GetStringOrNil(r.Form, param.Strategy.Name),
 		RedirectURL:               form.GetStringOrNil(r.Form, param.RedirectURL.Name),
 		ActionCompleteRedirectURL: form.GetStringOrNil(r.Form, param.ActionCompleteRedirectURL.Name),
@@ -212,11 +221,17 @@ func (h *HTTP) PrepareVerification(_ http.ResponseWriter, r *http.Request) (inte
 		return nil, err
 	}
 
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = "https://" + r.Header.Get(constants.XOriginalHost)
+	}
+
 	prepareForm := &SignUpPrepareForm{
 		Strategy:                  r.Form.Get(param.Strategy.Name),
 		RedirectURL:               form.GetString(r.Form, param.RedirectURL.Name),
 		ActionCompleteRedirectURL: form.GetString(r.Form, param.ActionCompleteRedirectURL.Name),
-		Origin:                    r.Header.Get("Origin"),
+		Origin:                    origin,
+		PasskeyName:               clerkwebauthn.CreatePasskeyName(r.Header),
 	}
 	signUp, err := h.service.PrepareVerification(ctx, prepareForm)
 	if apierror.IsInternal(err) {
@@ -239,7 +254,7 @@ func (h *HTTP) AttemptVerification(w http.ResponseWriter, r *http.Request) (inte
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 
 	reqParams := param.NewSet(param.Strategy)
-	optParams := param.NewSet(param.Code, param.Web3Signature, param.Token)
+	optParams := param.NewSet(param.Code, param.Web3Signature, param.PublicKeyCredential, param.Token)
 
 	pl := param.NewList(reqParams, optParams)
 	err := form.Check(r.Form, pl)
@@ -252,6 +267,9 @@ func (h *HTTP) AttemptVerification(w http.ResponseWriter, r *http.Request) (inte
 		Code:          form.GetString(r.Form, param.Code.Name),
 		Web3Signature: form.GetString(r.Form, param.Web3Signature.Name),
 		Token:         form.GetStringOrNil(r.Form, param.Token.Name),
+
+		PasskeyPublicKeyCredential: form.GetString(r.Form, param.PublicKeyCredential.Name),
+		Origin:                     r.Header.Get("Origin"),
 	}
 
 	signUp, createdNewSession, err := h.service.AttemptVerification(ctx, attemptForm)
@@ -9,24 +9,36 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/fapi/v1/clients"
+	"clerk/api/fapi/v1/passkeys"
+	"clerk/api/shared/bot_detection"
 	"clerk/api/shared/client_data"
+	"clerk/api/shared/custom_fields"
+	"clerk/api/shared/legal"
+	"clerk/api/shared/password"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/restrictions"
 	"clerk/api/shared/session_activities"
 	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_up"
+	"clerk/api/shared/sign_up_funnel"
 	sharedstrategies "clerk/api/shared/strategies"
 	"clerk/api/shared/verifications"
 	"clerk/model"
 	"clerk/model/sqbmodel"
+	"clerk/pkg/botdetection"
+	"clerk/pkg/cenv"
 	"clerk/pkg/clerkerrors"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/activity"
 	"clerk/pkg/ctx/client_type"
 	"clerk/pkg/ctx/environment"
+	"clerk/pkg/ctx/request_info"
 	"clerk/pkg/ctx/requestingdevbrowser"
 	"clerk/pkg/ctxkeys"
 	"clerk/pkg/externalapis/segment"
 	"clerk/pkg/externalapis/turnstile"
 	"clerk/pkg/metadata"
+	"clerk/pkg/rand"
 	"clerk/pkg/segment/fapi"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/pkg/usersettings/clerk/names"
@@ -51,9 +63,13 @@ type Service struct {
 	captchaClientPool *turnstile.ClientPool
 
 	// services
+	botDetectionService      *bot_detection.Service
 	clientService            *clients.Service
 	clientDataService        *client_data.Service
+	passkeyService           *passkeys.Service
+	restrictionService       *restrictions.Service
 	signUpService            *sign_up.Service
+	signUpFunnelService      *sign_up_funnel.Service
 	verificationService      *verifications.Service
 	sessionService           *sessions.Service
 	sessionActivitiesService *session_activities.Service
@@ -62,6 +78,7 @@ type Service struct {
 	accountTransferRepo *repository.AccountTransfers
 	externalAccountRepo *repository.ExternalAccount
 	identificationRepo  *repository.Identification
+	passkeyRepo         *repository.Passkey
 	redirectUrlsRepo    *repository.RedirectUrls
 	verificationRepo    *repository.Verification
 	samlAccountRepo     *repository.SAMLAccount
@@ -75,20 +92,25 @@ func NewService(deps clerk.Deps, captchaClientPool *turnstile.ClientPool) *Servi
 		db:                       deps.DB(),
 		clock:                    deps.Clock(),
 		captchaClientPool:        captchaClientPool,
+		botDetectionService:      bot_detection.NewService(deps, captchaClientPool),
 		clientService:            clients.NewService(deps),
 		clientDataService:        client_data.NewService(deps),
+		passkeyService:           passkeys.NewService(deps),
+		restrictionService:       restrictions.NewService(deps),
 		signUpService:            sign_up.NewService(deps),
+		signUpFunnelService:      sign_up_funnel.NewService(deps),
 		verificationService:      verifications.NewService(deps.Clock()),
 		sessionService:           sessions.NewService(deps),
 		sessionActivitiesService: session_activities.NewService(),
-		accountTransferRepo:      repository.NewAccountTransfers(),
-		externalAccountRepo:      repository.NewExternalAccount(),
-		identificationRepo:       repository.NewIdentification(),
-		redirectUrlsRepo:         repository.NewRedirectUrls(),
-		verificationRepo:         repository.NewVerification(),
-		samlAccountRepo:          repository.NewSAMLAccount(),
-		signUpRepo:               repository.NewSignUp(),
-		signInRepo:               repository.NewSignIn(),
+		accountTransferRepo:      deps.Repositories().AccountTransfers,
+		externalAccountRepo:      deps.Repositories().ExternalAccount,
+		identificationRepo:       deps.Repositories().Identification,
+		passkeyRepo:              deps.Repositories().Passkey,
+		redirectUrlsRepo:         deps.Repositories().RedirectUrls,
+		verificationRepo:         deps.Repositories().Verification,
+		samlAccountRepo:          deps.Repositories().SAMLAccount,
+		signUpRepo:               deps.Repositories().SignUp,
+		signInRepo:               deps.Repositories().SignIn,
 	}
 }
 
@@ -112,6 +134,7 @@ func (s *Service) SetSignUp(ctx context.Context, signUpID string) (context.Conte
 		return ctx, apierror.SignUpNotFound(signUpID)
 	}
 	if signUp.Abandoned(s.clock) {
+		s.signUpFunnelService.Track(ctx, s.db, env.Instance, signUp, sign_up_funnel.StageAbandoned, "")
 		return ctx, apierror.SignUpNotFound(signUpID)
 	}
 
@@ -128,6 +151,8 @@ type SignUpForm struct {
 	PhoneNumber               *string
 	EmailAddressOrPhoneNumber *string
 	UnsafeMetadata            *[]byte
+	LegalAccepted             *bool
+	CustomFields              *[]byte
 	Strategy                  *string
 	RedirectURL               *string
 	ActionCompleteRedirectURL *string
@@ -138,6 +163,8 @@ type SignUpForm struct {
 	CaptchaToken              *string
 	CaptchaError              *string
 	CaptchaWidgetType         *string
+	AcceptLanguage            string
+	ClientFingerprint         *string
 }
 
 func (suf SignUpForm) toStrategiesSignUpPrepareForm(clientID string) strategies.SignUpPrepareForm {
@@ -174,17 +201,28 @@ func (s *Service) Create(ctx context.Context, createForm *SignUpForm) (*model.Si
 		}
 	}
 
+	if apiErr := s.restrictionService.EnforceIPRules(ctx, s.db, env.Instance, restrictions.IPRestrictedSignUp); apiErr != nil {
+		return nil, nil, false, apiErr
+	}
+
 	// Bot detection
-	apiErr := s.handleCaptcha(
-		ctx,
-		createForm.CaptchaToken,
-		createForm.CaptchaWidgetType,
-		createForm.CaptchaError,
-		createForm.Origin,
-		env.Instance,
-		userSettings.SignUp,
-		client_type.FromContext(ctx),
-	)
+	var botResult *botdetection.Result
+	var apiErr apierror.Error
+	botDetectionInput := s.toBotDetectionInput(ctx, env.Instance, createForm)
+	if bot_detection.Enabled(userSettings) {
+		botResult, apiErr = s.detectBot(ctx, env.Instance, userSettings, botDetectionInput, client_type.FromContext(ctx))
+	} else {
+		apiErr = s.handleCaptcha(
+			ctx,
+			createForm.CaptchaToken,
+			createForm.CaptchaWidgetType,
+			createForm.CaptchaError,
+			createForm.Origin,
+			env.Instance,
+			userSettings.SignUp,
+			client_type.FromContext(ctx),
+		)
+	}
 	if apiErr != nil {
 		return nil, nil, false, apiErr
 	}
@@ -198,6 +236,15 @@ func (s *Service) Create(ctx context.Context, createForm *SignUpForm) (*model.Si
 
 	newClientCreated := tmpClient != client
 
+	if botResult != nil {
+		signals := make([]string, len(botResult.Signals))
+		for i, signal := range botResult.Signals {
+			signals[i] = string(signal)
+		}
+		signUp.BotScore = null.IntFrom(botResult.Score)
+		signUp.BotSignals = signals
+	}
+
 	var attemptor sharedstrategies.Attemptor
 	var newSession *model.Session
 	newSessionCreated := false
@@ -236,6 +283,9 @@ func (s *Service) Create(ctx context.Context, createForm *SignUpForm) (*model.Si
 		if err := s.signUpRepo.Update(ctx, tx, signUp); err != nil {
 			return true, err
 		}
+		if len(signUp.IdentificationIDs()) > 0 {
+			s.signUpFunnelService.Track(ctx, tx, env.Instance, signUp, sign_up_funnel.StageIdentified, "")
+		}
 
 		// 3. Prepare/Attempt steps
 		if preparable, ok := strategies.ToSignUpPreparable(strategy); ok {
@@ -334,6 +384,10 @@ func (s *Service) Create(ctx context.Context, createForm *SignUpForm) (*model.Si
 		return signUp, client, newClientCreated, apierror.Unexpected(txErr)
 	}
 
+	if bot_detection.Enabled(userSettings) {
+		s.botDetectionService.RecordSignUp(ctx, env.Instance.ID, botDetectionInput.Fingerprint())
+	}
+
 	if env.Instance.IsDevelopment() {
 		fapi.EnqueueSegmentEvent(ctx, s.deps.GueClient(), fapi.SegmentParams{EventName: segment.APIFrontendUserCreated})
 		if newSessionCreated {
@@ -342,7 +396,10 @@ func (s *Service) Create(ctx context.Context, createForm *SignUpForm) (*model.Si
 	}
 
 	if newSessionCreated && newSession != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, nil, false, apiErr
+			}
 			return nil, nil, false, apierror.Unexpected(err)
 		}
 	}
@@ -359,6 +416,7 @@ func validateRequestAndPopulateSignUp(
 	signUp *model.SignUp,
 	createOrUpdateForm *SignUpForm) apierror.Error {
 	formErrors := convertEmailAddressOrPhoneNumber(userSettings, createOrUpdateForm)
+	formErrors = apierror.Combine(formErrors, applyPhoneNumberProfile(env.Instance, createOrUpdateForm))
 
 	signUpForm := usersettings.SignUpForm{
 		EmailAddress: createOrUpdateForm.EmailAddress,
@@ -382,17 +440,44 @@ func validateRequestAndPopulateSignUp(
 		formErrors = apierror.Combine(formErrors, apiErr)
 	}
 
+	// only passwords which are valid otherwise are looked up in breaches,
+	// the lookup involves an external API
+	if createOrUpdateForm.Password != nil && formErrors == nil {
+		formErrors = password.CheckPwned(ctx, sharedstrategies.HibpClient, userSettings.PasswordSettings, param.Password.Name, *createOrUpdateForm.Password)
+	}
+
 	// validate all other properties which are not
 	// user setting attributes, e.g. unsafe metadata
-	apiErr := validateAndUpdateNonAttributeProperties(createOrUpdateForm, signUp)
+	apiErr := validateAndUpdateNonAttributeProperties(deps.Clock(), userSettings, createOrUpdateForm, signUp)
 	formErrors = apierror.Combine(formErrors, apiErr)
 
 	return formErrors
 }
 
+// applyPhoneNumberProfile sanitizes the phone number of the form with the
+// instance's phone number profile.
+func applyPhoneNumberProfile(instance *model.Instance, signUpForm *SignUpForm) apierror.Error {
+	if signUpForm.PhoneNumber == nil || *signUpForm.PhoneNumber == "" {
+		return nil
+	}
+
+	phoneNumber, apiErr := phone_profiles.ForInstance(instance).Sanitize(*signUpForm.PhoneNumber, param.PhoneNumber.Name)
+	if apiErr != nil {
+		return apiErr
+	}
+
+	signUpForm.PhoneNumber = &phoneNumber
+	return nil
+}
+
 // validateAndUpdateNonAttributeProperties will validate all those fields which are not attributes
 // in user settings. For each of these, if it doesn't have any errors, it will be added to the sign up.
-func validateAndUpdateNonAttributeProperties(signUpForm *SignUpForm, signUp *model.SignUp) apierror.Error {
+func validateAndUpdateNonAttributeProperties(
+	clock clockwork.Clock,
+	userSettings *usersettings.UserSettings,
+	signUpForm *SignUpForm,
+	signUp *model.SignUp,
+) apierror.Error {
 	var formErrors apierror.Error
 	if signUpForm.Transfer != nil {
 		if !*signUpForm.Transfer {
@@ -408,6 +493,30 @@ func validateAndUpdateNonAttributeProperties(signUpForm *SignUpForm, signUp *mod
 		}
 	}
 
+	if signUpForm.LegalAccepted != nil {
+		if !legal.IsRequired(userSettings) {
+			formErrors = apierror.Combine(formErrors, apierror.FormUnknownParameter(param.LegalAccepted.Name))
+		} else if !*signUpForm.LegalAccepted {
+			formErrors = apierror.Combine(formErrors, apierror.FormInvalidParameterValue(param.LegalAccepted.Name, "false"))
+		} else {
+			// the acceptance always refers to the version the instance
+			// currently publishes
+			signUp.LegalAcceptedAt = null.TimeFrom(clock.Now().UTC())
+			signUp.LegalAcceptedVersion = null.StringFrom(legal.CurrentVersion(userSettings))
+		}
+	}
+
+	if signUpForm.CustomFields != nil {
+		fields := custom_fields.Fields(userSettings)
+		if len(fields) == 0 {
+			formErrors = apierror.Combine(formErrors, apierror.FormUnknownParameter(custom_fields.ParamName))
+		} else if values, apiErr := custom_fields.Apply(fields, *signUpForm.CustomFields, signUp.CustomFields); apiErr != nil {
+			formErrors = apierror.Combine(formErrors, apiErr)
+		} else {
+			signUp.CustomFields = values
+		}
+	}
+
 	return formErrors
 }
 
@@ -601,6 +710,7 @@ func (s *Service) createSignUp(
 		if err != nil {
 			return true, fmt.Errorf("sign-up/create: insert new sign-up %+v: %w", newSignUp, err)
 		}
+		s.signUpFunnelService.Track(ctx, tx, instance, newSignUp, sign_up_funnel.StageCreated, "")
 
 		signUp = newSignUp
 		return false, nil
@@ -642,6 +752,7 @@ func (s *Service) Update(ctx context.Context, updateForm *SignUpForm) (*model.Si
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 	signUp := sign_up.FromContext(ctx)
+	wasIdentified := len(signUp.IdentificationIDs()) > 0
 
 	var attemptor sharedstrategies.Attemptor
 	var newSession *model.Session
@@ -660,6 +771,9 @@ func (s *Service) Update(ctx context.Context, updateForm *SignUpForm) (*model.Si
 		if err := s.signUpRepo.Update(ctx, tx, signUp); err != nil {
 			return true, err
 		}
+		if !wasIdentified && len(signUp.IdentificationIDs()) > 0 {
+			s.signUpFunnelService.Track(ctx, tx, env.Instance, signUp, sign_up_funnel.StageIdentified, "")
+		}
 
 		if preparable, ok := strategies.ToSignUpPreparable(strategy); ok {
 			err := s.executeSignUpPreparableStrategy(ctx, tx, env, signUp, updateForm.toStrategiesSignUpPrepareForm(client.ID), preparable)
@@ -733,7 +847,10 @@ func (s *Service) Update(ctx context.Context, updateForm *SignUpForm) (*model.Si
 
 	if newSessionCreated {
 		if newSession != nil {
-			if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+			if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+				if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+					return nil, nil, apiErr
+				}
 				return nil, nil, apierror.Unexpected(err)
 			}
 		}
@@ -834,6 +951,7 @@ type SignUpPrepareForm struct {
 	RedirectURL               *string
 	ActionCompleteRedirectURL *string
 	Origin                    string
+	PasskeyName               string
 }
 
 func (suf SignUpPrepareForm) toStrategiesSignUpPrepareForm(clientID string) strategies.SignUpPrepareForm {
@@ -853,6 +971,10 @@ func (s *Service) PrepareVerification(ctx context.Context, prepareForm *SignUpPr
 	signUp := sign_up.FromContext(ctx)
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 
+	if prepareForm.Strategy == constants.VSPasskey {
+		return s.preparePasskeyVerification(ctx, env, userSettings, signUp, prepareForm)
+	}
+
 	strategy, apiErr := validatePreparableSignUpStrategy(userSettings, prepareForm.Strategy)
 	if apiErr != nil {
 		return nil, apiErr
@@ -892,6 +1014,100 @@ func validatePreparableSignUpStrategy(userSettings *usersettings.UserSettings, s
 	return selectedStrategy.(strategies.SignUpPreparable), nil
 }
 
+// validatePasskeySignUp checks whether a passkey can be registered during sign up.
+func validatePasskeySignUp(env *model.Env, userSettings *usersettings.UserSettings) apierror.Error {
+	if !cenv.ResourceHasAccess(cenv.FlagAllowPasskeysInstanceIDs, env.Instance.ID) {
+		return apierror.FeatureNotEnabled()
+	}
+	if !userSettings.GetAttribute(names.Passkey).Base().Enabled {
+		return apierror.FormInvalidParameterValue(param.Strategy.Name, constants.VSPasskey)
+	}
+	return nil
+}
+
+// preparePasskeyVerification starts the registration of a passkey for the current sign-up.
+// The passkey is registered for the user ID which is reserved on the sign-up, since the
+// user will only be created once the sign-up completes.
+func (s *Service) preparePasskeyVerification(
+	ctx context.Context,
+	env *model.Env,
+	userSettings *usersettings.UserSettings,
+	signUp *model.SignUp,
+	prepareForm *SignUpPrepareForm) (*model.SignUp, apierror.Error) {
+	if apiErr := validatePasskeySignUp(env, userSettings); apiErr != nil {
+		return nil, apiErr
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if !signUp.ReservedUserID.Valid {
+			signUp.ReservedUserID = null.StringFrom(rand.InternalClerkID(constants.IDPUser))
+		}
+
+		identification, err := s.passkeyService.PrepareSignUpRegistration(ctx, tx, env, signUp, prepareForm.Origin, prepareForm.PasskeyName)
+		if err != nil {
+			return true, err
+		}
+
+		signUp.PasskeyID = null.StringFrom(identification.ID)
+		err = s.signUpRepo.Update(ctx, tx, signUp,
+			sqbmodel.SignUpColumns.ReservedUserID,
+			sqbmodel.SignUpColumns.PasskeyID)
+		return err != nil, err
+	})
+	if txErr != nil {
+		if apiErr, ok := apierror.As(txErr); ok {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+	return signUp, nil
+}
+
+// createPasskeyAttemptor returns the attemptor which completes the registration of the
+// passkey of the current sign-up.
+func (s *Service) createPasskeyAttemptor(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	signUp *model.SignUp,
+	attemptForm strategies.SignUpAttemptForm) (sharedstrategies.Attemptor, apierror.Error) {
+	if attemptForm.PasskeyPublicKeyCredential == nil {
+		return nil, apierror.FormMissingParameter(param.PublicKeyCredential.Name)
+	}
+	if !signUp.PasskeyID.Valid {
+		return nil, apierror.VerificationMissing()
+	}
+
+	identification, err := s.identificationRepo.FindByID(ctx, tx, signUp.PasskeyID.String)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if !identification.VerificationID.Valid {
+		return nil, apierror.VerificationMissing()
+	}
+
+	verification, err := s.verificationRepo.FindByID(ctx, tx, identification.VerificationID.String)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	passkey, err := s.passkeyRepo.QueryByIdentificationID(ctx, tx, identification.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if passkey == nil {
+		return nil, apierror.PasskeyNotRegistered()
+	}
+
+	return sharedstrategies.NewPasskeyAttemptor(s.deps, env, verification, &sharedstrategies.PasskeyAttemptorParams{
+		PublicKeyCredential: *attemptForm.PasskeyPublicKeyCredential,
+		Origin:              attemptForm.Origin,
+		Passkey:             passkey,
+		User:                passkeys.SignUpWebAuthnUser(signUp),
+		SignUp:              signUp,
+	}), nil
+}
+
 // AttemptVerification attempts to verify the corresponding identification of the current sign-up,
 // using the given verification code.
 func (s *Service) AttemptVerification(ctx context.Context, attemptForm strategies.SignUpAttemptForm) (*model.SignUp, bool, apierror.Error) {
@@ -900,18 +1116,26 @@ func (s *Service) AttemptVerification(ctx context.Context, attemptForm strategie
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 	signUp := sign_up.FromContext(ctx)
 
-	// oauth strategies are not applicable to attempt verification step, check only verification strategies
-	attemptVerificationStrategies := userSettings.VerificationStrategies()
+	// passkeys are registered by the sign-up itself, so there's no strategy for them
+	var strategy strategies.SignUpAttemptable
+	if attemptForm.Strategy == constants.VSPasskey {
+		if apiErr := validatePasskeySignUp(env, userSettings); apiErr != nil {
+			return nil, false, apiErr
+		}
+	} else {
+		// oauth strategies are not applicable to attempt verification step, check only verification strategies
+		attemptVerificationStrategies := userSettings.VerificationStrategies()
 
-	if !attemptVerificationStrategies.Contains(attemptForm.Strategy) {
-		return nil, false, apierror.FormInvalidParameterValue(param.Strategy.Name, attemptForm.Strategy)
-	}
+		if !attemptVerificationStrategies.Contains(attemptForm.Strategy) {
+			return nil, false, apierror.FormInvalidParameterValue(param.Strategy.Name, attemptForm.Strategy)
+		}
 
-	selectedStrategy, strategyExists := strategies.GetStrategy(attemptForm.Strategy)
-	if !strategyExists || !strategies.IsAttemptableDuringSignUp(selectedStrategy) {
-		return nil, false, apierror.FormInvalidParameterValue(param.Strategy.Name, attemptForm.Strategy)
+		selectedStrategy, strategyExists := strategies.GetStrategy(attemptForm.Strategy)
+		if !strategyExists || !strategies.IsAttemptableDuringSignUp(selectedStrategy) {
+			return nil, false, apierror.FormInvalidParameterValue(param.Strategy.Name, attemptForm.Strategy)
+		}
+		strategy = selectedStrategy.(strategies.SignUpAttemptable)
 	}
-	strategy := selectedStrategy.(strategies.SignUpAttemptable)
 
 	var newSession *model.Session
 	newSessionCreated := false
@@ -919,7 +1143,11 @@ func (s *Service) AttemptVerification(ctx context.Context, attemptForm strategie
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		// Fetch attemptor for given strategy
 		var apiErr apierror.Error
-		attemptor, apiErr = strategy.CreateSignUpAttemptor(ctx, tx, s.deps, env, signUp, attemptForm)
+		if strategy == nil {
+			attemptor, apiErr = s.createPasskeyAttemptor(ctx, tx, env, signUp, attemptForm)
+		} else {
+			attemptor, apiErr = strategy.CreateSignUpAttemptor(ctx, tx, s.deps, env, signUp, attemptForm)
+		}
 		if apiErr != nil {
 			return true, apiErr
 		}
@@ -949,6 +1177,7 @@ func (s *Service) AttemptVerification(ctx context.Context, attemptForm strategie
 		if err := s.identificationRepo.UpdateStatus(ctx, tx, identification); err != nil {
 			return true, err
 		}
+		s.signUpFunnelService.Track(ctx, tx, env.Instance, signUp, sign_up_funnel.StageVerified, attemptForm.Strategy)
 
 		var externalAccount *model.ExternalAccount
 		// Sign up has an external account verification. Fetch the external account so we can fill in all user info
@@ -1004,7 +1233,10 @@ func (s *Service) AttemptVerification(ctx context.Context, attemptForm strategie
 	}
 
 	if newSessionCreated && newSession != nil {
-		if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+		if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+			if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+				return nil, false, apiErr
+			}
 			return nil, false, apierror.Unexpected(err)
 		}
 	}
@@ -1076,6 +1308,55 @@ func (s *Service) handleCaptcha(
 	return apierror.CaptchaInvalid()
 }
 
+// detectBot scores the sign-up with the bot detector of the instance and
+// returns an error if the sign-up should be challenged or blocked. If the
+// detector fails, the sign-up is let through without a score, the same way
+// CAPTCHA verification fails open.
+func (s *Service) detectBot(
+	ctx context.Context,
+	instance *model.Instance,
+	userSettings *usersettings.UserSettings,
+	input bot_detection.Input,
+	clientType client_type.ClientType,
+) (*botdetection.Result, apierror.Error) {
+	if bot_detection.RequiresChallenge(userSettings) && clientType.IsSet() && !clientType.IsBrowser() {
+		return nil, apierror.CaptchaUnsupportedByClient(instance.Communication.SupportEmail.Ptr())
+	}
+
+	result, err := s.botDetectionService.Score(ctx, userSettings, input)
+	if err != nil {
+		log.Warning(ctx, fmt.Errorf("bot-detection-error: %w", err))
+		return nil, nil
+	}
+
+	switch bot_detection.ActionFor(userSettings, result) {
+	case botdetection.ActionBlock:
+		return nil, apierror.SignUpBlockedAsBot()
+	case botdetection.ActionChallenge:
+		return nil, apierror.CaptchaRequired()
+	default:
+		return result, nil
+	}
+}
+
+func (s *Service) toBotDetectionInput(ctx context.Context, instance *model.Instance, createForm *SignUpForm) bot_detection.Input {
+	input := bot_detection.Input{
+		InstanceID:        instance.ID,
+		CaptchaToken:      null.StringFromPtr(createForm.CaptchaToken).String,
+		CaptchaWidgetType: createForm.CaptchaWidgetType,
+		RemoteIP:          activity.FromContext(ctx).IPAddress.String,
+		AcceptLanguage:    createForm.AcceptLanguage,
+		ClientFingerprint: null.StringFromPtr(createForm.ClientFingerprint).String,
+	}
+	if requestInfo := request_info.FromContext(ctx); requestInfo != nil {
+		input.UserAgent = requestInfo.UserAgent
+	}
+	if u, err := url.ParseRequestURI(createForm.Origin); err == nil {
+		input.Host = u.Host
+	}
+	return input
+}
+
 func (s *Service) resetClientSignup(ctx context.Context, instance *model.Instance, client *model.Client) error {
 	client.SignUpID = null.StringFromPtr(nil)
 	cdsClient := client_data.NewClientFromClientModel(client)
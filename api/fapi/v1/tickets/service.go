@@ -56,13 +56,13 @@ func NewService(deps clerk.Deps) *Service {
 		clientDataService:   client_data.NewService(deps),
 		organizationService: organizations.NewService(deps),
 		samlAccountService:  samlaccount.NewService(deps),
-		actorTokensRepo:     repository.NewActorToken(),
-		devBrowserRepo:      repository.NewDevBrowser(),
-		identificationsRepo: repository.NewIdentification(),
-		invitationsRepo:     repository.NewInvitations(),
-		orgInvitationsRepo:  repository.NewOrganizationInvitation(),
-		samlAccountRepo:     repository.NewSAMLAccount(),
-		samlConnectionRepo:  repository.NewSAMLConnection(),
+		actorTokensRepo:     deps.Repositories().ActorToken,
+		devBrowserRepo:      deps.Repositories().DevBrowser,
+		identificationsRepo: deps.Repositories().Identification,
+		invitationsRepo:     deps.Repositories().Invitations,
+		orgInvitationsRepo:  deps.Repositories().OrganizationInvitation,
+		samlAccountRepo:     deps.Repositories().SAMLAccount,
+		samlConnectionRepo:  deps.Repositories().SAMLConnection,
 	}
 }
 
@@ -226,10 +226,11 @@ func (s *Service) handleOrganizationInvitation(
 		// if invited user is the same that's making the request, add user to organization
 		if userIsLoggedIn && invitation.IsPending() {
 			_, err := s.organizationService.AcceptInvitation(ctx, tx, organizations.AcceptInvitationParams{
-				InvitationID: invitation.ID,
-				UserID:       identification.UserID.String,
-				Instance:     env.Instance,
-				Subscription: env.Subscription,
+				InvitationID:         invitation.ID,
+				UserID:               identification.UserID.String,
+				Instance:             env.Instance,
+				Subscription:         env.Subscription,
+				OrganizationSettings: env.AuthConfig.OrganizationSettings,
 			})
 			if err != nil {
 				return true, err
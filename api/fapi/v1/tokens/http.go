@@ -5,12 +5,9 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/model"
-	"clerk/pkg/cenv"
 	"clerk/pkg/ctx/requesting_user"
-	"clerk/pkg/sampling"
 	"clerk/utils/clerk"
 	"clerk/utils/form"
-	"clerk/utils/log"
 	"clerk/utils/param"
 
 	"github.com/go-chi/chi/v5"
@@ -45,20 +42,35 @@ func (h *HTTP) CreateSessionToken(_ http.ResponseWriter, r *http.Request) (inter
 	ctx := r.Context()
 	sessionID := chi.URLParam(r, "sessionID")
 
-	// only log a sample of these requests, given the sheer amount of them
-	// and the fact that they don't contain any useful information
-	if logLine, logLineExists := log.GetLogLine(ctx); logLineExists {
-		logLine.IsIncludedInLogs = sampling.IsIncluded(cenv.GetFloat64(cenv.ClerkSessionTokenLogSampling))
+	if err := h.service.EnsureRefreshToken(ctx, sessionID, form.GetString(r.Form, param.RefreshToken.Name)); err != nil {
+		return nil, err
 	}
 
 	return h.service.CreateSessionToken(ctx, sessionID)
 }
 
+// POST /v1/client/sessions/{sessionID}/tokens/refresh
+func (h *HTTP) RefreshSessionToken(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	sessionID := chi.URLParam(r, "sessionID")
+
+	err := form.Check(r.Form, param.NewList(param.NewSet(), param.NewSet(param.RefreshToken)))
+	if err != nil {
+		return nil, err
+	}
+
+	return h.service.RefreshSessionToken(ctx, sessionID, form.GetString(r.Form, param.RefreshToken.Name))
+}
+
 // POST /v1/client/sessions/{sessionID}/tokens/{templateName}
 func (h *HTTP) CreateFromTemplate(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	templateName := chi.URLParam(r, "templateName")
 	sessionID := chi.URLParam(r, "sessionID")
 
+	if err := h.service.EnsureRefreshToken(r.Context(), sessionID, form.GetString(r.Form, param.RefreshToken.Name)); err != nil {
+		return nil, err
+	}
+
 	// Support functionality of `POST /v1/me/tokens` for firebase until we deprecate the endpoint
 	if templateName == "integration_firebase" {
 		return h.service.CreateFromFirebase(r.Context(), sessionID)
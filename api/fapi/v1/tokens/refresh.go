@@ -0,0 +1,154 @@
+package tokens
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/client_data"
+	"clerk/api/shared/sessions"
+	"clerk/model"
+	"clerk/pkg/ctx/client_type"
+	"clerk/pkg/ctx/environment"
+	"clerk/pkg/rand"
+	"clerk/utils/log"
+	"clerk/utils/param"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// RefreshSessionToken creates a new session token for a native client, in
+// exchange for the refresh token of the session, which is then rotated.
+//
+// The first refresh token of a session is issued when the sign-in or sign-up
+// which creates the session completes, see sessions.WithRefreshTokens. From
+// then on, every request needs the latest refresh token. Presenting the one
+// before it means that the refresh token was copied to another device, so
+// all the sessions of the client are revoked.
+func (s *Service) RefreshSessionToken(ctx context.Context, sessionID string, refreshToken *string) (*serialize.RefreshedTokenResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	if !client_type.FromContext(ctx).IsNative() {
+		return nil, apierror.InvalidActionForSession(sessionID, "refresh")
+	}
+	session, apiErr := s.loadSessionFromCtx(ctx, sessionID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if !session.RefreshTokenDigest.Valid {
+		return nil, apierror.InvalidActionForSession(sessionID, "refresh")
+	}
+
+	if apiErr := s.verifyRefreshToken(ctx, env.Instance, session, refreshToken); apiErr != nil {
+		return nil, apiErr
+	}
+
+	// the session token is created before the rotation, so that a failure
+	// doesn't leave the client with a refresh token which was rotated out
+	sessionToken, apiErr := s.CreateSessionToken(ctx, sessionID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	newRefreshToken, err := rand.Token()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	// The rotation only succeeds if the refresh token wasn't rotated since it
+	// was verified. Otherwise, a concurrent request presented the same refresh
+	// token, which is reuse as well.
+	currentDigest := session.RefreshTokenDigest
+	cdsSession := client_data.NewSessionFromSessionModel(session)
+	cdsSession.PreviousRefreshTokenDigest = currentDigest
+	cdsSession.RefreshTokenDigest = null.StringFrom(sessions.RefreshTokenDigest(newRefreshToken))
+	err = s.clientDataService.RotateSessionRefreshToken(ctx, cdsSession.InstanceID, cdsSession.ClientID, cdsSession, currentDigest)
+	if errors.Is(err, client_data.ErrRefreshTokenRotated) {
+		return nil, s.handleRefreshTokenReuse(ctx, env.Instance, session)
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	cdsSession.CopyToSessionModel(session)
+
+	return serialize.RefreshedToken(sessionToken.JWT, newRefreshToken), nil
+}
+
+// EnsureRefreshToken verifies the refresh token presented along with a
+// request which creates a token for the given session, if the session
+// requires one, without rotating it.
+func (s *Service) EnsureRefreshToken(ctx context.Context, sessionID string, refreshToken *string) apierror.Error {
+	env := environment.FromContext(ctx)
+	session, apiErr := s.loadSessionFromCtx(ctx, sessionID)
+	if apiErr != nil {
+		return apiErr
+	}
+	if !sessions.RequiresRefreshToken(ctx, session) {
+		return nil
+	}
+	return s.verifyRefreshToken(ctx, env.Instance, session, refreshToken)
+}
+
+// verifyRefreshToken returns an error unless the refresh token is the
+// current one of the session. The sessions of the client are revoked if it's
+// the one before it.
+func (s *Service) verifyRefreshToken(ctx context.Context, instance *model.Instance, session *model.Session, refreshToken *string) apierror.Error {
+	switch checkRefreshToken(session, refreshToken) {
+	case refreshTokenMissing:
+		return apierror.FormMissingParameter(param.RefreshToken.Name)
+	case refreshTokenInvalid:
+		return apierror.RefreshTokenInvalid()
+	case refreshTokenReused:
+		return s.handleRefreshTokenReuse(ctx, instance, session)
+	}
+	return nil
+}
+
+type refreshTokenCheck int
+
+const (
+	refreshTokenValid refreshTokenCheck = iota
+	refreshTokenMissing
+	refreshTokenReused
+	refreshTokenInvalid
+)
+
+// checkRefreshToken compares the refresh token presented for the session
+// with the current and previous ones. No refresh token is valid for sessions
+// which were never issued one.
+func checkRefreshToken(session *model.Session, refreshToken *string) refreshTokenCheck {
+	if refreshToken == nil {
+		return refreshTokenMissing
+	}
+
+	digest := sessions.RefreshTokenDigest(*refreshToken)
+	switch {
+	case digestsEqual(digest, session.RefreshTokenDigest):
+		return refreshTokenValid
+	case digestsEqual(digest, session.PreviousRefreshTokenDigest):
+		return refreshTokenReused
+	default:
+		return refreshTokenInvalid
+	}
+}
+
+// handleRefreshTokenReuse revokes all the active sessions of the client of
+// the given session, as its refresh tokens can no longer be trusted.
+func (s *Service) handleRefreshTokenReuse(ctx context.Context, instance *model.Instance, session *model.Session) apierror.Error {
+	log.Warning(ctx, "tokens/refresh: refresh token reused for session %s, revoking the sessions of client %s", session.ID, session.ClientID)
+
+	cdsSessions, err := s.clientDataService.FindAllClientSessions(ctx, instance.ID, session.ClientID, client_data.SessionFilterActiveOnly())
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	for _, cdsSession := range cdsSessions {
+		if apiErr := s.sessionService.Revoke(ctx, instance, cdsSession.ToSessionModel()); apiErr != nil {
+			return apiErr
+		}
+	}
+	return apierror.RefreshTokenReused()
+}
+
+func digestsEqual(digest string, stored null.String) bool {
+	return stored.Valid && subtle.ConstantTimeCompare([]byte(digest), []byte(stored.String)) == 1
+}
@@ -0,0 +1,43 @@
+package tokens
+
+import (
+	"testing"
+
+	"clerk/api/shared/sessions"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestCheckRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	current, previous, other := "current", "previous", "other"
+	session := &model.Session{Session: &sqbmodel.Session{
+		RefreshTokenDigest:         null.StringFrom(sessions.RefreshTokenDigest(current)),
+		PreviousRefreshTokenDigest: null.StringFrom(sessions.RefreshTokenDigest(previous)),
+	}}
+
+	assert.Equal(t, refreshTokenValid, checkRefreshToken(session, &current))
+	assert.Equal(t, refreshTokenReused, checkRefreshToken(session, &previous))
+	assert.Equal(t, refreshTokenInvalid, checkRefreshToken(session, &other))
+	assert.Equal(t, refreshTokenMissing, checkRefreshToken(session, nil))
+
+	// the digests are stored, never the refresh tokens themselves
+	assert.Equal(t, refreshTokenInvalid, checkRefreshToken(session, &session.RefreshTokenDigest.String))
+}
+
+func TestCheckRefreshTokenWithoutRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	session := &model.Session{Session: &sqbmodel.Session{}}
+
+	// the first refresh token is issued with the session, never on a
+	// request without one
+	assert.Equal(t, refreshTokenMissing, checkRefreshToken(session, nil))
+
+	token := "token"
+	assert.Equal(t, refreshTokenInvalid, checkRefreshToken(session, &token))
+}
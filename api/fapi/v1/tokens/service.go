@@ -9,11 +9,15 @@ import (
 	"clerk/api/serialize"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/events"
+	"clerk/api/shared/instance_metrics"
 	"clerk/api/shared/jwt"
 	"clerk/api/shared/organizations"
+	"clerk/api/shared/sessions"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/token"
 	"clerk/model"
 	"clerk/pkg/auth"
+	"clerk/pkg/cache"
 	"clerk/pkg/ctx/environment"
 	"clerk/pkg/ctx/maintenance"
 	"clerk/pkg/ctx/request_info"
@@ -33,6 +37,7 @@ import (
 )
 
 type Service struct {
+	cache     cache.Cache
 	clock     clockwork.Clock
 	db        database.Database
 	gueClient *gue.Client
@@ -41,8 +46,10 @@ type Service struct {
 	eventService      *events.Service
 	jwtService        *jwt.Service
 	orgService        *organizations.Service
+	sessionService    *sessions.Service
 	tokenService      *token.Service
 	clientDataService *client_data.Service
+	signingKeyService *signing_keys.Service
 
 	// repositories
 	jwtServicesRepo *repository.JWTServices
@@ -51,16 +58,19 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
+		cache:             deps.Cache(),
 		clock:             deps.Clock(),
 		db:                deps.DB(),
 		gueClient:         deps.GueClient(),
 		eventService:      events.NewService(deps),
-		jwtService:        jwt.NewService(deps.Clock()),
+		jwtService:        jwt.NewService(deps),
 		orgService:        organizations.NewService(deps),
+		sessionService:    sessions.NewService(deps),
 		tokenService:      token.NewService(),
 		clientDataService: client_data.NewService(deps),
-		jwtServicesRepo:   repository.NewJWTServices(),
-		usersRepo:         repository.NewUsers(),
+		signingKeyService: signing_keys.NewService(deps),
+		jwtServicesRepo:   deps.Repositories().JWTServices,
+		usersRepo:         deps.Repositories().Users,
 	}
 }
 
@@ -197,6 +207,8 @@ func (s *Service) CreateSessionToken(ctx context.Context, sessionID string) (*se
 	newToken, err := token.GenerateSessionToken(
 		ctx,
 		s.clock,
+		s.cache,
+		s.signingKeyService,
 		s.db,
 		env,
 		session,
@@ -209,6 +221,9 @@ func (s *Service) CreateSessionToken(ctx context.Context, sessionID string) (*se
 		return nil, apierror.Unexpected(err)
 	}
 
+	// every token is counted, without any I/O on this path
+	instance_metrics.Count(env.Instance.ID, instance_metrics.TokensIssued, "", 1)
+
 	var eventSent bool
 	yesterday := s.clock.Now().UTC().Add(-24 * time.Hour)
 	if session.TokenCreatedEventSentAt.Time.Before(yesterday) {
@@ -0,0 +1,59 @@
+package trusted_devices
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/fapi/v1/wrapper"
+	"clerk/model"
+	"clerk/pkg/ctx/requesting_user"
+	"clerk/pkg/ctxkeys"
+	"clerk/utils/clerk"
+	"clerk/utils/form"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+	wrapper *wrapper.Wrapper
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+		wrapper: wrapper.NewWrapper(deps),
+	}
+}
+
+// GET /v1/me/trusted_devices
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	response, err := h.service.List(ctx, user)
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
+// DELETE /v1/me/trusted_devices/{trustedDeviceID}
+func (h *HTTP) Revoke(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	if formErrs := form.CheckEmpty(r.Form); formErrs != nil {
+		return nil, formErrs
+	}
+
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+
+	response, err := h.service.Revoke(ctx, user, chi.URLParam(r, "trustedDeviceID"))
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
@@ -0,0 +1,66 @@
+package trusted_devices
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/ctx/environment"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+)
+
+type Service struct {
+	clock clockwork.Clock
+	db    database.Database
+
+	// repositories
+	trustedDeviceRepo *repository.TrustedDevices
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:             deps.Clock(),
+		db:                deps.DB(),
+		trustedDeviceRepo: deps.Repositories().TrustedDevices,
+	}
+}
+
+// List returns the devices the user currently trusts.
+func (s *Service) List(ctx context.Context, user *model.User) ([]*serialize.TrustedDeviceResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	devices, err := s.trustedDeviceRepo.FindAllUnexpiredByUser(ctx, s.db, env.Instance.ID, user.ID, s.clock.Now().UTC())
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.TrustedDeviceResponse, len(devices))
+	for i, device := range devices {
+		responses[i] = serialize.TrustedDevice(device)
+	}
+	return responses, nil
+}
+
+// Revoke stops trusting the given device of the user, so that sign-ins on it
+// require the second factor again.
+func (s *Service) Revoke(ctx context.Context, user *model.User, trustedDeviceID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	device, err := s.trustedDeviceRepo.QueryByIDAndInstance(ctx, s.db, trustedDeviceID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if device == nil || device.UserID != user.ID {
+		return nil, apierror.TrustedDeviceNotFound(trustedDeviceID)
+	}
+
+	if err := s.trustedDeviceRepo.DeleteByID(ctx, s.db, device.ID); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.DeletedObject(device.ID, serialize.TrustedDeviceObjectName), nil
+}
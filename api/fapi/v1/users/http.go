@@ -76,6 +76,24 @@ func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (interface{}, apierr
 	return h.wrapper.WrapResponse(ctx, userResponse, client)
 }
 
+// POST /v1/me/legal_acceptance
+func (h *HTTP) AcceptLegalDocuments(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	if formErrs := form.CheckEmpty(r.Form); formErrs != nil {
+		return nil, formErrs
+	}
+
+	user := requesting_user.FromContext(ctx)
+	userResponse, err := h.userService.AcceptLegalDocuments(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.wrapper.WrapResponse(ctx, userResponse, client)
+}
+
 // POST /v1/me/profile_image
 func (h *HTTP) UpdateProfileImage(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
@@ -101,6 +119,52 @@ func (h *HTTP) UpdateProfileImage(_ http.ResponseWriter, r *http.Request) (inter
 	return h.wrapper.WrapResponse(ctx, res, client)
 }
 
+// POST /v1/me/profile_image/uploads
+func (h *HTTP) CreateProfileImageUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	user := requesting_user.FromContext(ctx)
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	pl := param.NewList(param.NewSet(param.ContentType), param.NewSet(param.Filename))
+	if err := form.Check(r.Form, pl); err != nil {
+		return nil, err
+	}
+
+	res, apiErr := h.sharedUserService.CreateProfileImageUpload(
+		ctx,
+		user.ID,
+		r.Form.Get(param.Filename.Name),
+		r.Form.Get(param.ContentType.Name),
+	)
+	if apiErr != nil {
+		return nil, h.wrapper.WrapError(ctx, apiErr, client)
+	}
+	return h.wrapper.WrapResponse(ctx, res, client)
+}
+
+// POST /v1/me/profile_image/uploads/{imageID}/complete
+func (h *HTTP) CompleteProfileImageUpload(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	user := requesting_user.FromContext(ctx)
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+
+	if err := form.CheckEmpty(r.Form); err != nil {
+		return nil, err
+	}
+
+	params := users.UpdateProfileImageParams{
+		UserID:          user.ID,
+		UploadedImageID: chi.URLParam(r, "imageID"),
+	}
+	res, apiErr := h.sharedUserService.UpdateProfileImage(ctx, params, env.Instance, userSettings)
+	if apiErr != nil {
+		return nil, h.wrapper.WrapError(ctx, apiErr, client)
+	}
+	return h.wrapper.WrapResponse(ctx, res, client)
+}
+
 // DELETE /v1/me/profile_image
 func (h *HTTP) DeleteProfileImage(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
@@ -115,6 +179,31 @@ func (h *HTTP) DeleteProfileImage(_ http.ResponseWriter, r *http.Request) (inter
 	return h.wrapper.WrapResponse(ctx, res, client)
 }
 
+// DELETE /v1/me/pending_primary_email_address
+func (h *HTTP) CancelPrimaryEmailAddressChange(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	user := requesting_user.FromContext(ctx)
+
+	res, err := h.userService.CancelPrimaryEmailAddressChange(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	return h.wrapper.WrapResponse(ctx, res, client)
+}
+
+// GET /v1/primary_email_address_change/confirm
+func (h *HTTP) ConfirmPrimaryEmailAddressChange(w http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	redirectURL, err := h.userService.ConfirmPrimaryEmailAddressChange(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		return nil, err
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusSeeOther)
+	return nil, nil
+}
+
 // PATCH /v1/me
 func (h *HTTP) Update(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
@@ -504,13 +593,14 @@ func (h *HTTP) CreateWeb3Wallet(_ http.ResponseWriter, r *http.Request) (interfa
 	ctx := r.Context()
 	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
 
-	err := form.Check(r.Form, param.NewList(param.NewSet(param.Web3Wallet), param.NewSet()))
+	err := form.Check(r.Form, param.NewList(param.NewSet(param.Web3Wallet), param.NewSet(param.ChainID)))
 	if err != nil {
 		return nil, err
 	}
 
 	web3Wallet := *form.GetString(r.Form, param.Web3Wallet.Name)
-	resp, err := h.userService.CreateWeb3Wallet(ctx, web3Wallet)
+	chainID := form.GetString(r.Form, param.ChainID.Name)
+	resp, err := h.userService.CreateWeb3Wallet(ctx, web3Wallet, chainID)
 	if err != nil {
 		return nil, h.wrapper.WrapError(ctx, err, client)
 	}
@@ -869,6 +959,29 @@ func (h *HTTP) AcceptOrganizationInvitation(_ http.ResponseWriter, r *http.Reque
 	return h.wrapper.WrapResponse(ctx, response, client)
 }
 
+// POST /v1/me/organization_invitations/{invitationID}/prepare_verification
+func (h *HTTP) PrepareOrganizationInvitationVerification(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
+	ctx := r.Context()
+	client := ctx.Value(ctxkeys.RequestingClient).(*model.Client)
+	user := requesting_user.FromContext(ctx)
+	invitationID := chi.URLParam(r, "invitationID")
+
+	pl := param.NewList(param.NewSet(param.Strategy), param.NewSet(param.RedirectURL))
+	if err := form.Check(r.Form, pl); err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+
+	prepareForm := strategies.VerificationPrepareForm{
+		Strategy:    r.Form.Get(param.Strategy.Name),
+		RedirectURL: form.GetString(r.Form, param.RedirectURL.Name),
+	}
+	response, err := h.userService.PrepareOrganizationInvitationVerification(ctx, user, invitationID, prepareForm)
+	if err != nil {
+		return nil, h.wrapper.WrapError(ctx, err, client)
+	}
+	return h.wrapper.WrapResponse(ctx, response, client)
+}
+
 // GET /v1/me/organization_suggestions
 func (h *HTTP) ListOrganizationSuggestions(_ http.ResponseWriter, r *http.Request) (interface{}, apierror.Error) {
 	ctx := r.Context()
@@ -12,11 +12,14 @@ import (
 	"clerk/api/shared/comms"
 	"clerk/api/shared/events"
 	"clerk/api/shared/identifications"
+	"clerk/api/shared/legal"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/orgdomain"
 	"clerk/api/shared/pagination"
 	"clerk/api/shared/password"
 	"clerk/api/shared/phone_numbers"
+	"clerk/api/shared/phone_profiles"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/restrictions"
 	"clerk/api/shared/serializable"
 	sharedstrategies "clerk/api/shared/strategies"
@@ -42,10 +45,10 @@ import (
 	"clerk/pkg/usersettings/clerk/names"
 	"clerk/pkg/usersettings/clerk/strategies"
 	usersettingsmodel "clerk/pkg/usersettings/model"
+	"clerk/pkg/web3"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
-	"clerk/utils/log"
 	"clerk/utils/param"
 	"clerk/utils/validate"
 
@@ -62,6 +65,7 @@ type Service struct {
 	commsService          *comms.Service
 	eventService          *events.Service
 	identificationService *identifications.Service
+	legalService          *legal.Service
 	orgDomainService      *orgdomain.Service
 	organizationService   *organizations.Service
 	passwordService       *password.Service
@@ -98,30 +102,31 @@ func NewService(deps clerk.Deps) *Service {
 		commsService:               comms.NewService(deps),
 		eventService:               events.NewService(deps),
 		identificationService:      identifications.NewService(deps),
+		legalService:               legal.NewService(),
 		orgDomainService:           orgdomain.NewService(deps.Clock()),
 		organizationService:        organizations.NewService(deps),
 		passwordService:            password.NewService(deps),
 		phoneNumbersService:        phone_numbers.NewService(deps),
-		restrictionService:         restrictions.NewService(deps.EmailQualityChecker()),
+		restrictionService:         restrictions.NewService(deps),
 		serializableService:        serializable.NewService(deps.Clock()),
 		userService:                users.NewService(deps),
 		userProfileService:         user_profile.NewService(deps.Clock()),
 		validatorService:           validators.NewService(),
 		verificationService:        verifications.NewService(deps.Clock()),
 		clientDataService:          client_data.NewService(deps),
-		backupCodeRepo:             repository.NewBackupCode(),
-		imageRepo:                  repository.NewImages(),
-		externalAccountRepo:        repository.NewExternalAccount(),
-		organizationRepo:           repository.NewOrganization(),
-		organizationMemberRepo:     repository.NewOrganizationMembership(),
-		orgMemberRequestRepo:       repository.NewOrganizationMembershipRequest(),
-		organizationInvitationRepo: repository.NewOrganizationInvitation(),
-		organizationSuggestionRepo: repository.NewOrganizationSuggestion(),
-		permissionRepo:             repository.NewPermission(),
-		totpRepo:                   repository.NewTOTP(),
-		userRepo:                   repository.NewUsers(),
-		identificationRepo:         repository.NewIdentification(),
-		verificationRepo:           repository.NewVerification(),
+		backupCodeRepo:             deps.Repositories().BackupCode,
+		imageRepo:                  deps.Repositories().Images,
+		externalAccountRepo:        deps.Repositories().ExternalAccount,
+		organizationRepo:           deps.Repositories().Organization,
+		organizationMemberRepo:     deps.Repositories().OrganizationMembership,
+		orgMemberRequestRepo:       deps.Repositories().OrganizationMembershipRequest,
+		organizationInvitationRepo: deps.Repositories().OrganizationInvitation,
+		organizationSuggestionRepo: deps.Repositories().OrganizationSuggestion,
+		permissionRepo:             deps.Repositories().Permission,
+		totpRepo:                   deps.Repositories().TOTP,
+		userRepo:                   deps.Repositories().Users,
+		identificationRepo:         deps.Repositories().Identification,
+		verificationRepo:           deps.Repositories().Verification,
 	}
 }
 
@@ -182,15 +187,15 @@ func (s *Service) SetRequestingUser(ctx context.Context, userSessionID *string)
 	ctx = requesting_session.NewContext(ctx, sess)
 	ctx = requesting_user.NewContext(ctx, user)
 
-	log.AddToLogLine(ctx, log.SessionID, sess.ID)
-	log.AddToLogLine(ctx, log.UserID, user.ID)
+	requestlog.Add(ctx, requestlog.SessionID, sess.ID)
+	requestlog.Add(ctx, requestlog.UserID, user.ID)
 
 	actorID, err := sess.ActorID()
 	if err != nil {
 		return ctx, apierror.Unexpected(err)
 	}
 	if actorID != nil {
-		log.AddToLogLine(ctx, log.ActorID, actorID)
+		requestlog.Add(ctx, requestlog.ActorID, actorID)
 	}
 
 	return ctx, nil
@@ -209,6 +214,38 @@ func (s *Service) Read(ctx context.Context, user *model.User) (*serialize.UserRe
 	return serialize.UserToClientAPI(ctx, userSerializable), nil
 }
 
+// AcceptLegalDocuments records that the user accepted the version of the
+// legal documents the instance currently publishes.
+func (s *Service) AcceptLegalDocuments(ctx context.Context, user *model.User) (*serialize.UserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	if !legal.IsRequired(userSettings) {
+		return nil, apierror.RequestInvalidForInstance()
+	}
+
+	var userSerializable *model.UserSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
+		err = s.legalService.RecordAcceptance(ctx, tx, user, legal.CurrentVersion(userSettings), s.clock.Now().UTC())
+		if err != nil {
+			return true, err
+		}
+
+		userSerializable, err = s.userService.SendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	return serialize.UserToClientAPI(ctx, userSerializable), nil
+}
+
 // DeleteProfileImage clears the users profile_image_url.
 func (s *Service) DeleteProfileImage(ctx context.Context, userID string) (*serialize.DeletedObjectResponse, apierror.Error) {
 	return s.userService.DeleteProfileImage(ctx, userID)
@@ -413,7 +450,7 @@ func (s *Service) CreatePhoneNumber(ctx context.Context, user *model.User, phone
 	}
 
 	var apiErr apierror.Error
-	phoneNumber, apiErr = phoneNumberAttribute.Sanitize(phoneNumber, param.PhoneNumber.Name)
+	phoneNumber, apiErr = phone_profiles.ForInstance(env.Instance).Sanitize(phoneNumber, param.PhoneNumber.Name)
 	if apiErr != nil {
 		return nil, apiErr
 	}
@@ -461,8 +498,9 @@ func (s *Service) CreatePhoneNumber(ctx context.Context, user *model.User, phone
 	return s.toIdentificationResponse(ctx, identification)
 }
 
-// CreateWeb3Wallet creates a new web3 wallet for given user
-func (s Service) CreateWeb3Wallet(ctx context.Context, web3Wallet string) (*serialize.Web3WalletResponse, apierror.Error) {
+// CreateWeb3Wallet creates a new web3 wallet for given user. The chain id
+// is optional, and defaults to the main chain of the wallet address.
+func (s Service) CreateWeb3Wallet(ctx context.Context, web3Wallet string, chainID *string) (*serialize.Web3WalletResponse, apierror.Error) {
 	env := environment.FromContext(ctx)
 	user := requesting_user.FromContext(ctx)
 	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
@@ -485,6 +523,13 @@ func (s Service) CreateWeb3Wallet(ctx context.Context, web3Wallet string) (*seri
 		return nil, apiErr
 	}
 
+	if chainID != nil {
+		chain, _, err := web3.ChainForID(*chainID)
+		if err != nil || !chain.ValidAddress(web3Wallet) {
+			return nil, apierror.FormInvalidParameterValue(param.ChainID.Name, *chainID)
+		}
+	}
+
 	exists, err := s.identificationRepo.ExistsByIdentifierAndUser(ctx, s.db, web3Wallet, constants.ITWeb3Wallet, user.ID)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
@@ -498,6 +543,7 @@ func (s Service) CreateWeb3Wallet(ctx context.Context, web3Wallet string) (*seri
 		UserID:     &user.ID,
 		Identifier: web3Wallet,
 		Type:       constants.ITWeb3Wallet,
+		ChainID:    chainID,
 	}
 	identification, apiErr := s.createIdentification(ctx, createIdentificationData, user, env.Instance, env.AuthConfig)
 	if apiErr != nil {
@@ -520,7 +566,11 @@ func (s *Service) UpdatePhoneNumber(ctx context.Context, user *model.User, phone
 	var phoneNumber *model.Identification
 	var backupCodes []string
 	var performedUpdate bool
-	var err error
+	previousUser, err := s.userService.SnapshotUser(ctx, s.db, userSettings, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		phoneNumber, backupCodes, performedUpdate, err = s.phoneNumbersService.UpdateForMFA(ctx, tx, user, phoneNumberID, updateForm)
 		if err != nil {
@@ -537,7 +587,7 @@ func (s *Service) UpdatePhoneNumber(ctx context.Context, user *model.User, phone
 
 	// send event if something changed
 	if performedUpdate {
-		if err := s.sendUserUpdatedEvent(ctx, s.db, env.Instance, userSettings, user); err != nil {
+		if err := s.sendUserUpdatedEvent(ctx, s.db, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, apierror.Unexpected(
 				fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err),
 			)
@@ -549,7 +599,7 @@ func (s *Service) UpdatePhoneNumber(ctx context.Context, user *model.User, phone
 		return nil, apierror.Unexpected(err)
 	}
 
-	return serialize.IdentificationPhoneNumberWithBackupCodes(identificationSerializable, backupCodes), nil
+	return serialize.IdentificationPhoneNumberWithBackupCodes(identificationSerializable, backupCodes, serialize.WithPhoneNumberProfile(phone_profiles.ForInstance(env.Instance))), nil
 }
 
 type ConnectOAuthAccountForm struct {
@@ -609,6 +659,11 @@ func (s *Service) ConnectOAuthAccount(ctx context.Context, params *ConnectOAuthA
 	var externalAccount *model.ExternalAccount
 	var verificationWithStatus *model.VerificationWithStatus
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, params.User)
+		if err != nil {
+			return true, err
+		}
+
 		// we must delete the old unverified oauth identifications (along with
 		// external account and verification). A user can only have a single
 		// unverified oauth identification per provider. Also, this protects the
@@ -673,7 +728,7 @@ func (s *Service) ConnectOAuthAccount(ctx context.Context, params *ConnectOAuthA
 
 		verificationWithStatus = &model.VerificationWithStatus{Verification: verification, Status: status}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, params.User); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, params.User); err != nil {
 			return true, fmt.Errorf("user/ConnectOAuthAccount: send user updated event for (%+v, %+v): %w", params.User, env.Instance.ID, err)
 		}
 
@@ -812,6 +867,11 @@ func (s *Service) PrepareVerification(
 
 	var identification *model.Identification
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		preparer, apiErr := strategy.CreateVerificationPreparer(ctx, tx, s.deps, env, prepareForm)
 		if apiErr != nil {
 			return true, apiErr
@@ -833,7 +893,7 @@ func (s *Service) PrepareVerification(
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/PrepareVerification: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -1003,6 +1063,11 @@ func (s *Service) AttemptTOTPVerification(ctx context.Context, user *model.User,
 	var attemptor sharedstrategies.Attemptor
 	var backupCodes []string
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		verification := &model.Verification{Verification: &sqbmodel.Verification{
 			InstanceID: env.Instance.ID,
 			Strategy:   constants.VSTOTP,
@@ -1034,7 +1099,7 @@ func (s *Service) AttemptTOTPVerification(ctx context.Context, user *model.User,
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/AttemptTOTPVerification: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -1061,6 +1126,11 @@ func (s *Service) DeleteTOTP(ctx context.Context, user *model.User) (*serialize.
 	}
 
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		if err = s.totpRepo.DeleteByUser(ctx, tx, user.ID); err != nil {
 			return true, err
 		}
@@ -1070,7 +1140,7 @@ func (s *Service) DeleteTOTP(ctx context.Context, user *model.User) (*serialize.
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/DeleteTOTP: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -1102,6 +1172,11 @@ func (s *Service) CreateBackupCodes(ctx context.Context, user *model.User) (*ser
 
 	var response *serialize.BackupCodeResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		newBackupCode, plainCodes, err := s.createBackupCodes(ctx, tx, user.ID, env.Instance.ID)
 		if err != nil {
 			return true, err
@@ -1112,7 +1187,7 @@ func (s *Service) CreateBackupCodes(ctx context.Context, user *model.User) (*ser
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/CreateBackupCodes: send user updated event for (%+v, %+v): %w", user, env.Instance.ID, err)
 		}
 
@@ -1133,7 +1208,7 @@ func (s *Service) toIdentificationResponse(ctx context.Context, ident *model.Ide
 		return nil, apierror.Unexpected(err)
 	}
 
-	response, err := serialize.Identification(identificationSerializable)
+	response, err := serialize.Identification(identificationSerializable, serialize.WithPhoneNumberProfile(phone_profiles.FromContext(ctx)))
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -1165,8 +1240,12 @@ func (s *Service) createIdentification(
 
 	var createdIdentification *model.Identification
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		var exists bool
-		var err error
 		if attribute.Base().VerifyAtSignUp {
 			exists, err = s.identificationRepo.ExistsVerifiedByIdentifierAndType(ctx, tx, createIdentificationData.Identifier, createIdentificationData.Type, instance.ID)
 		} else {
@@ -1188,7 +1267,7 @@ func (s *Service) createIdentification(
 			return true, err
 		}
 
-		if err = s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, user); err != nil {
+		if err = s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user); err != nil {
 			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", user, instance.ID, err)
 		}
 
@@ -1331,10 +1410,11 @@ func (s *Service) AcceptOrganizationInvitation(ctx context.Context, invitationID
 	var acceptedInvitation *model.OrganizationInvitationSerializable
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		acceptedInvitation, err = s.organizationService.AcceptInvitation(ctx, tx, organizations.AcceptInvitationParams{
-			InvitationID: invitationID,
-			UserID:       userID,
-			Instance:     env.Instance,
-			Subscription: env.Subscription,
+			InvitationID:         invitationID,
+			UserID:               userID,
+			Instance:             env.Instance,
+			Subscription:         env.Subscription,
+			OrganizationSettings: env.AuthConfig.OrganizationSettings,
 		})
 		if err != nil {
 			return true, err
@@ -1367,6 +1447,43 @@ func (s *Service) AcceptOrganizationInvitation(ctx context.Context, invitationID
 	return serialize.OrganizationInvitationMe(ctx, acceptedInvitation, organization), nil
 }
 
+// PrepareOrganizationInvitationVerification starts the verification of the
+// user's email address the organization invitation was sent to. Instances
+// which require a verified email address for accepting invitations need it
+// before the invitation can be accepted.
+func (s *Service) PrepareOrganizationInvitationVerification(
+	ctx context.Context,
+	user *model.User,
+	invitationID string,
+	prepareForm strategies.VerificationPrepareForm) (interface{}, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	invitation, err := s.organizationInvitationRepo.QueryByIDAndUser(ctx, s.db, invitationID, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if invitation == nil {
+		return nil, apierror.OrganizationInvitationNotFound(invitationID)
+	}
+	if !invitation.IsPending() {
+		return nil, apierror.OrganizationInvitationNotPending()
+	}
+
+	identification, err := s.identificationRepo.QueryByInstanceAndIdentifierAndUser(ctx, s.db, env.Instance.ID, invitation.EmailAddress, user.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if identification == nil || !identification.IsEmailAddress() {
+		return nil, apierror.OrganizationInvitationIdentificationNotExist()
+	}
+	if identification.IsVerified() {
+		return s.toIdentificationResponse(ctx, identification)
+	}
+
+	prepareForm.EmailAddressID = &identification.ID
+	return s.PrepareVerification(ctx, user, prepareForm)
+}
+
 type ListOrganizationSuggestionsParams struct {
 	UserID   string
 	Statuses []string
@@ -1514,6 +1631,9 @@ func (s *Service) cleanupBackupCodes(ctx context.Context, tx database.Executor,
 	return false, nil
 }
 
+// createBackupCodes generates a new set of backup codes for the user,
+// replacing the existing one if any. Usage starts over with the new set, but
+// regenerations and the last time a code was used are carried over.
 func (s *Service) createBackupCodes(ctx context.Context, tx database.Executor, userID, instanceID string) (*model.BackupCode, []string, error) {
 	plainCodes, hashedCodes, err := backup_codes.GenerateAndHash()
 	if err != nil {
@@ -1525,6 +1645,15 @@ func (s *Service) createBackupCodes(ctx context.Context, tx database.Executor, u
 		UserID:     userID,
 		Codes:      hashedCodes,
 	}}
+
+	existingBackupCode, err := s.backupCodeRepo.QueryByUser(ctx, tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if existingBackupCode != nil {
+		newBackupCode.RegeneratedCount = existingBackupCode.RegeneratedCount + 1
+		newBackupCode.LastUsedAt = existingBackupCode.LastUsedAt
+	}
 	if err = s.backupCodeRepo.Upsert(ctx, tx, newBackupCode); err != nil {
 		return nil, nil, err
 	}
@@ -1578,7 +1707,10 @@ func (params ChangePasswordParams) validate(ctx context.Context, passwordSetting
 		return apierror.FormUnknownParameter(param.CurrentPassword.Name)
 	}
 
-	return validate.Password(ctx, params.NewPassword, param.NewPassword.Name, passwordSettings)
+	if apiErr := validate.Password(ctx, params.NewPassword, param.NewPassword.Name, passwordSettings); apiErr != nil {
+		return apiErr
+	}
+	return password.CheckPwned(ctx, sharedstrategies.HibpClient, passwordSettings, param.NewPassword.Name, params.NewPassword)
 }
 
 func (s *Service) ChangePassword(ctx context.Context, params ChangePasswordParams) (*serialize.UserResponse, apierror.Error) {
@@ -1656,9 +1788,14 @@ func (s *Service) DeletePassword(ctx context.Context, params DeletePasswordParam
 
 	var response *serialize.UserResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.userService.SnapshotUser(ctx, tx, userSettings, params.User)
+		if err != nil {
+			return true, err
+		}
+
 		params.User.PasswordDigest = null.StringFromPtr(nil)
 		params.User.PasswordHasher = null.StringFromPtr(nil)
-		err := s.userRepo.UpdatePasswordDigestAndHasher(ctx, tx, params.User)
+		err = s.userRepo.UpdatePasswordDigestAndHasher(ctx, tx, params.User)
 		if err != nil {
 			return true, err
 		}
@@ -1675,7 +1812,7 @@ func (s *Service) DeletePassword(ctx context.Context, params DeletePasswordParam
 			return true, err
 		}
 
-		err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, params.User)
+		err = s.sendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, params.User)
 		if err != nil {
 			return true, fmt.Errorf("send user updated event for (%s, %s): %w", params.User.ID, env.Instance.ID, err)
 		}
@@ -1742,6 +1879,15 @@ func (s *Service) Update(ctx context.Context, params users.UpdateForm) (*seriali
 		return nil, apierror.UpdatingUserPasswordDeprecated()
 	}
 
+	// The primary email address changes once the current one confirms it.
+	if params.PrimaryEmailAddressID != nil && users.RequiresPrimaryEmailAddressChangeConfirmation(env, user, *params.PrimaryEmailAddressID) {
+		_, apiErr := s.userService.RequestPrimaryEmailAddressChange(ctx, env, user, *params.PrimaryEmailAddressID, userSettings)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		params.PrimaryEmailAddressID = nil
+	}
+
 	var serialized *serialize.UserResponse
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		// if primary email address is changed, notify the user.
@@ -1771,6 +1917,47 @@ func (s *Service) Update(ctx context.Context, params users.UpdateForm) (*seriali
 	return serialized, nil
 }
 
+// CancelPrimaryEmailAddressChange discards the pending primary email address
+// change of the user.
+func (s *Service) CancelPrimaryEmailAddressChange(ctx context.Context, user *model.User) (*serialize.UserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	var serialized *serialize.UserResponse
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.userService.CancelPrimaryEmailAddressChange(ctx, tx, user)
+		if err != nil {
+			return true, err
+		}
+
+		userSerializable, err := s.serializableService.ConvertUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+		serialized = serialize.UserToClientAPI(ctx, userSerializable)
+		return false, nil
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+	return serialized, nil
+}
+
+// ConfirmPrimaryEmailAddressChange switches the primary email address of the
+// user the token was issued for, and returns where to redirect to
+// afterwards.
+func (s *Service) ConfirmPrimaryEmailAddressChange(ctx context.Context, token string) (string, apierror.Error) {
+	env := environment.FromContext(ctx)
+	if token == "" {
+		return "", apierror.FormMissingParameter("token")
+	}
+
+	if _, apiErr := s.userService.ConfirmPrimaryEmailAddressChange(ctx, env, token); apiErr != nil {
+		return "", apiErr
+	}
+	return env.Domain.AccountsURL() + "/user", nil
+}
+
 func (s *Service) Delete(ctx context.Context, user *model.User) (*serialize.DeletedObjectResponse, apierror.Error) {
 	if !user.DeleteSelfEnabled {
 		return nil, apierror.UserDeleteSelfNotEnabled()
@@ -1785,13 +1972,14 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
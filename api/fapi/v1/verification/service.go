@@ -11,6 +11,7 @@ import (
 	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_in"
 	"clerk/api/shared/sign_up"
+	"clerk/api/shared/sign_up_funnel"
 	"clerk/api/shared/strategies"
 	"clerk/model"
 	"clerk/pkg/clerkerrors"
@@ -33,6 +34,7 @@ type Service struct {
 	identificationService *identifications.Service
 	signInService         *sign_in.Service
 	signUpService         *sign_up.Service
+	signUpFunnelService   *sign_up_funnel.Service
 	sessionService        *sessions.Service
 
 	// repositories
@@ -53,12 +55,13 @@ func NewService(deps clerk.Deps) *Service {
 		identificationService: identifications.NewService(deps),
 		signInService:         sign_in.NewService(deps),
 		signUpService:         sign_up.NewService(deps),
+		signUpFunnelService:   sign_up_funnel.NewService(deps),
 		sessionService:        sessions.NewService(deps),
-		identificationRepo:    repository.NewIdentification(),
-		signInRepo:            repository.NewSignIn(),
-		signUpRepo:            repository.NewSignUp(),
-		userRepo:              repository.NewUsers(),
-		verificationRepo:      repository.NewVerification(),
+		identificationRepo:    deps.Repositories().Identification,
+		signInRepo:            deps.Repositories().SignIn,
+		signUpRepo:            deps.Repositories().SignUp,
+		userRepo:              deps.Repositories().Users,
+		verificationRepo:      deps.Repositories().Verification,
 		clientDataService:     client_data.NewService(deps),
 	}
 }
@@ -158,6 +161,8 @@ func (s *Service) VerifyTokenClaims(ctx context.Context, claims strategies.Verif
 			if err := s.identificationRepo.UpdateStatus(ctx, tx, identification); err != nil {
 				return true, err
 			}
+			s.signUpFunnelService.Track(ctx, tx, env.Instance, signUp, sign_up_funnel.StageVerified, constants.VSEmailLink)
+
 			newSession, err = s.signUpService.FinalizeFlow(
 				ctx,
 				tx,
@@ -185,7 +190,10 @@ func (s *Service) VerifyTokenClaims(ctx context.Context, claims strategies.Verif
 		}
 
 		if newSession != nil {
-			if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+			if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+				if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+					return nil, nil, apiErr
+				}
 				return nil, nil, apierror.Unexpected(err)
 			}
 		}
@@ -321,7 +329,10 @@ func (s *Service) VerifyTokenClaims(ctx context.Context, claims strategies.Verif
 			return nil, nil, apierror.Unexpected(txErr)
 		}
 		if newSession != nil {
-			if err := s.sessionService.Activate(ctx, env.Instance, newSession); err != nil {
+			if err := s.sessionService.Activate(ctx, env, newSession); err != nil {
+				if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+					return nil, nil, apiErr
+				}
 				return nil, nil, apierror.Unexpected(err)
 			}
 		}
@@ -5,6 +5,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/oauth_applications"
 	"clerk/model"
 	"clerk/pkg/cenv"
 	"clerk/repository"
@@ -35,5 +36,5 @@ func (s *Service) AppleAppSiteAssociation(_ context.Context, instance *model.Ins
 }
 
 func (s *Service) OpenIDConfiguration(_ context.Context, domain *model.Domain) (interface{}, apierror.Error) {
-	return serialize.OpenIDConfiguration(domain), nil
+	return serialize.OpenIDConfiguration(domain, oauth_applications.SupportedScopes(), oauth_applications.SupportedClaims()), nil
 }
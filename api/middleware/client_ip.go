@@ -0,0 +1,15 @@
+package middleware
+
+import "net/http"
+
+// ClientIP returns the IP of the client which sent the request, as set by
+// the proxies in front of the APIs. It's empty if the request didn't go
+// through them.
+func ClientIP(r *http.Request) string {
+	// set by Cloudflare
+	if ip := r.Header.Get("True-Client-IP"); ip != "" {
+		return ip
+	}
+	// set by our Cloudflare Worker and clerk_docker's nginx (local)
+	return r.Header.Get("X-Client-IP")
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// ETag is a middleware for frequently polled endpoints, which tags
+// successful responses with a hash of their body. Clients that send the
+// tag back in If-None-Match get a 304 without a body when the response
+// hasn't changed since.
+//
+// The whole body is hashed, so the tag changes whenever any part of the
+// response does. It's only meant for responses which are the same across
+// requests, unlike e.g. the client, which carries a fresh session token
+// every time and would never match.
+func ETag(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rw := &etagResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		if rw.statusCode != http.StatusOK {
+			w.WriteHeader(rw.statusCode)
+			_, _ = w.Write(rw.body.Bytes())
+			return
+		}
+
+		etag := computeETag(rw.body.Bytes())
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(rw.body.Bytes())
+	})
+}
+
+// etagResponseWriter buffers the response, so that its tag can be computed
+// before anything is sent to the client.
+type etagResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *etagResponseWriter) WriteHeader(statusCode int) {
+	rw.statusCode = statusCode
+}
+
+func (rw *etagResponseWriter) Write(b []byte) (int, error) {
+	return rw.body.Write(b)
+}
+
+func computeETag(body []byte) string {
+	digest := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(digest[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header contains the given
+// tag. Weak tags are compared as if they were strong, as we only ever issue
+// strong ones.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	body := `{"object":"environment"}`
+	handler := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+
+	// the first request gets the full response, along with its tag
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/environment", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body, rec.Body.String())
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// sending the tag back short-circuits with a 304
+	req := httptest.NewRequest(http.MethodGet, "/v1/environment", nil)
+	req.Header.Set("If-None-Match", `"stale", W/`+etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+
+	// a stale tag gets the full response
+	req = httptest.NewRequest(http.MethodGet, "/v1/environment", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body, rec.Body.String())
+}
+
+func TestETag_SkipsUnsuccessfulResponses(t *testing.T) {
+	t.Parallel()
+
+	handler := ETag(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/client", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"errors":[]}`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("ETag"))
+}
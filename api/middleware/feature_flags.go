@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"clerk/pkg/featureflags"
+)
+
+// FeatureFlags makes the flags returned by load available to the handlers
+// of every request. They're only loaded if the request evaluates a flag,
+// and at most once, so that a request sees the same flags throughout.
+func FeatureFlags(load featureflags.Loader) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(featureflags.NewContext(r.Context(), load)))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/idempotency"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/log"
+)
+
+// IdempotencyStore claims idempotency keys and stores the responses of the
+// requests which claimed them. It's implemented by idempotency.Service.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, instanceID, key, requestHash string) (*idempotency.Record, error)
+	Complete(ctx context.Context, instanceID, key string, record idempotency.Record) error
+	Release(ctx context.Context, instanceID, key string) error
+}
+
+// Idempotency makes requests with an Idempotency-Key header safe to retry.
+// The response of the first request with a key is stored, and replayed for
+// every following request with the same key. Reusing a key for a different
+// request, or while the first one is still in progress, is an error. It must
+// run after the environment has been set on the context.
+//
+// Server errors and rate limited requests are not stored, so that they can
+// be retried with the same key. If the store is unavailable, requests are
+// performed as if they had no key.
+func Idempotency(store IdempotencyStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotency.Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			if len(key) > idempotency.MaxKeyLength {
+				writeAPIError(w, r, apierror.IdempotencyKeyInvalid(idempotency.MaxKeyLength))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeAPIError(w, r, apierror.InvalidRequestBody(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			instanceID := environment.FromContext(ctx).Instance.ID
+			requestHash := idempotency.RequestHash(r.Method, r.URL.RequestURI(), body)
+
+			record, err := store.Claim(ctx, instanceID, key, requestHash)
+			if err != nil {
+				log.Warning(ctx, "middleware/idempotency: %s", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if record != nil {
+				if record.RequestHash != requestHash {
+					writeAPIError(w, r, apierror.IdempotencyKeyReused())
+				} else if !record.Completed {
+					writeAPIError(w, r, apierror.IdempotencyRequestInProgress())
+				} else {
+					replayResponse(w, record)
+				}
+				return
+			}
+
+			// The outcome is stored even if the client went away, since that's
+			// when it retries with the same key.
+			storeCtx := context.WithoutCancel(ctx)
+
+			// A handler which panics releases the key, instead of holding it
+			// until the lock expires.
+			handled := false
+			defer func() {
+				if handled {
+					return
+				}
+				if err := store.Release(storeCtx, instanceID, key); err != nil {
+					log.Warning(ctx, "middleware/idempotency: %s", err)
+				}
+			}()
+
+			rw := &idempotencyResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			handled = true
+
+			if rw.statusCode >= http.StatusInternalServerError || rw.statusCode == http.StatusTooManyRequests {
+				err = store.Release(storeCtx, instanceID, key)
+			} else {
+				err = store.Complete(storeCtx, instanceID, key, idempotency.Record{
+					RequestHash: requestHash,
+					StatusCode:  rw.statusCode,
+					ContentType: w.Header().Get("Content-Type"),
+					Body:        rw.body.Bytes(),
+				})
+			}
+			if err != nil {
+				log.Warning(ctx, "middleware/idempotency: %s", err)
+			}
+		})
+	}
+}
+
+func replayResponse(w http.ResponseWriter, record *idempotency.Record) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.Header().Set(idempotency.ReplayedHeader, "true")
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+func writeAPIError(w http.ResponseWriter, r *http.Request, apiErr apierror.Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.HTTPCode())
+	_ = json.NewEncoder(w).Encode(apierror.ToResponse(r.Context(), apiErr))
+}
+
+// idempotencyResponseWriter passes the response through, while keeping a
+// copy of it so that it can be stored.
+type idempotencyResponseWriter struct {
+	http.ResponseWriter
+
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *idempotencyResponseWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.statusCode = statusCode
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *idempotencyResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"clerk/api/shared/idempotency"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/environment"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIdempotencyStore claims keys atomically, like the cache does.
+type fakeIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotency.Record
+	err     error
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{records: make(map[string]idempotency.Record)}
+}
+
+func (s *fakeIdempotencyStore) Claim(_ context.Context, instanceID, key, requestHash string) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if record, ok := s.records[instanceID+key]; ok {
+		return &record, nil
+	}
+	s.records[instanceID+key] = idempotency.Record{RequestHash: requestHash}
+	return nil, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(ctx context.Context, instanceID, key string, record idempotency.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record.Completed = true
+	s.records[instanceID+key] = record
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(ctx context.Context, instanceID, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, instanceID+key)
+	return nil
+}
+
+func idempotentRequest(key, body string) *http.Request {
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}}
+	req := httptest.NewRequest(http.MethodPost, "/v1/users", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	return req.WithContext(environment.NewContext(req.Context(), env))
+}
+
+func serve(handler http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	handler := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"user_1"}`))
+	}))
+
+	first := serve(handler, idempotentRequest("key_1", `{"first_name":"Jane"}`))
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+
+	retry := serve(handler, idempotentRequest("key_1", `{"first_name":"Jane"}`))
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, "application/json", retry.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"id":"user_1"}`, retry.Body.String())
+	assert.EqualValues(t, 1, calls.Load())
+
+	reused := serve(handler, idempotentRequest("key_1", `{"first_name":"John"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.EqualValues(t, 1, calls.Load())
+
+	// requests without a key are always performed
+	serve(handler, idempotentRequest("", `{"first_name":"Jane"}`))
+	serve(handler, idempotentRequest("", `{"first_name":"Jane"}`))
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestIdempotencyPerformsConcurrentRequestsOnce(t *testing.T) {
+	t.Parallel()
+
+	const requests = 10
+	var (
+		calls   atomic.Int32
+		started = make(chan struct{})
+		release = make(chan struct{})
+	)
+	handler := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- serve(handler, idempotentRequest("key_1", `{}`))
+	}()
+	<-started
+
+	var wg sync.WaitGroup
+	codes := make([]int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = serve(handler, idempotentRequest("key_1", `{}`)).Code
+		}(i)
+	}
+	wg.Wait()
+	close(release)
+
+	assert.Equal(t, http.StatusCreated, (<-first).Code)
+	for _, code := range codes {
+		assert.Equal(t, http.StatusConflict, code)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestIdempotencyReleasesKeyOnServerErrors(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	handler := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Equal(t, http.StatusInternalServerError, serve(handler, idempotentRequest("key_1", `{}`)).Code)
+	assert.Equal(t, http.StatusCreated, serve(handler, idempotentRequest("key_1", `{}`)).Code)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestIdempotencyStoresResponseOfCanceledRequests(t *testing.T) {
+	t.Parallel()
+
+	var (
+		calls  atomic.Int32
+		cancel context.CancelFunc
+	)
+	handler := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		// the client goes away before the response is stored
+		cancel()
+	}))
+
+	req := idempotentRequest("key_1", `{}`)
+	ctx, cancelRequest := context.WithCancel(req.Context())
+	cancel = cancelRequest
+	serve(handler, req.WithContext(ctx))
+
+	retry := serve(handler, idempotentRequest("key_1", `{}`))
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestIdempotencyReleasesKeyOnPanics(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	handler := Idempotency(newFakeIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	assert.Panics(t, func() { serve(handler, idempotentRequest("key_1", `{}`)) })
+	assert.Equal(t, http.StatusCreated, serve(handler, idempotentRequest("key_1", `{}`)).Code)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestIdempotencyInvalidKeyAndUnavailableStore(t *testing.T) {
+	t.Parallel()
+
+	store := newFakeIdempotencyStore()
+	var calls atomic.Int32
+	handler := Idempotency(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+
+	rec := serve(handler, idempotentRequest(strings.Repeat("k", idempotency.MaxKeyLength+1), `{}`))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Zero(t, calls.Load())
+
+	// requests are performed as if they had no key when the store is down
+	store.err = errors.New("connection refused")
+	rec = serve(handler, idempotentRequest("key_1", `{}`))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.EqualValues(t, 1, calls.Load())
+}
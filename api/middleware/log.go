@@ -3,33 +3,68 @@ package middleware
 import (
 	"database/sql"
 	"net/http"
+	"os"
 	"time"
 
+	"clerk/api/apierror"
+	"clerk/api/shared/requestlog"
 	"clerk/pkg/cenv"
 	"clerk/pkg/ctx/maintenance"
 	"clerk/pkg/sampling"
 	"clerk/utils/log"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
 )
 
-// Log injects a log.CanonicalLine in the context and also emits the log.
-func Log(dbStatsSnapshot func() sql.DBStats) func(next http.Handler) http.Handler {
+// Log injects a log.CanonicalLine and a requestlog.Entry in the context and
+// emits the structured request log line once the request has been served.
+// Requests are sampled according to the given rules.
+func Log(dbStatsSnapshot func() sql.DBStats, samplingRules requestlog.SamplingRules) func(next http.Handler) http.Handler {
 	dbStatsSampleRate := cenv.GetFloat64(cenv.ClerkDBStatsSampling)
+	logger := requestlog.NewLogger(os.Stdout, samplingRules)
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
+			startedAt := time.Now()
 
 			inspectableResponseWriter := log.NewLoggableResponseWriter(w)
+			responseWriter := chimw.NewWrapResponseWriter(inspectableResponseWriter, r.ProtoMajor)
+			// the canonical line carries the errors of the request, which
+			// are reported on the structured line
 			logLine := log.NewCanonicalLine(r, inspectableResponseWriter)
 
 			ctx = log.AddLogLineToContext(ctx, logLine)
+			ctx, _ = requestlog.NewContext(ctx)
 			if sampling.IsIncluded(dbStatsSampleRate) {
-				log.AddToLogLine(ctx, log.DBStats, dbStatsSnapshot())
+				requestlog.Add(ctx, requestlog.DBStats, dbStatsSnapshot())
 			}
-			log.AddToLogLine(ctx, log.MaintenanceMode, maintenance.FromContext(ctx))
-			next.ServeHTTP(inspectableResponseWriter, r.WithContext(ctx))
+			requestlog.Add(ctx, requestlog.MaintenanceMode, maintenance.FromContext(ctx))
+			next.ServeHTTP(responseWriter, r.WithContext(ctx))
 
-			logLine.FinishedAt = time.Now()
-			log.CanonicalLineEntry(ctx, logLine)
+			req := requestlog.Request{
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Status:     responseWriter.Status(),
+				Params:     r.Form,
+				StartedAt:  startedAt,
+				FinishedAt: time.Now(),
+			}
+			// The route pattern is only known once the request has been routed
+			if routeCtx := chi.RouteContext(ctx); routeCtx != nil {
+				req.Route = routeCtx.RoutePattern()
+			}
+			if req.Status == 0 {
+				// nothing was written, which net/http serves as a 200
+				req.Status = http.StatusOK
+			}
+			if apiErr, isAPIErr := apierror.As(logLine.RequestError); isAPIErr {
+				req.ErrorCode = apiErr.ErrorCode()
+			}
+
+			if err := logger.Log(ctx, req); err != nil {
+				log.Warning(ctx, "middleware/log: writing request log line: %s", err)
+			}
 		}
 		return http.HandlerFunc(fn)
 	}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"clerk/api/shared/requestlog"
 	"clerk/utils/log"
 )
 
@@ -17,13 +18,16 @@ func TestLogMiddleware(t *testing.T) {
 		if !logLineExists {
 			t.Error("Log line is missing")
 		}
+		if _, entryExists := requestlog.FromContext(r.Context()); !entryExists {
+			t.Error("Request log entry is missing")
+		}
 	})
 
 	logger := log.New()
 	ctx := log.NewContextWithLogger(context.Background(), logger)
 	handlerToTest := Log(func() sql.DBStats {
 		return sql.DBStats{}
-	})(nextHandler)
+	}, nil)(nextHandler)
 
 	req := httptest.NewRequest(http.MethodGet, "http://testing", nil)
 	req = req.WithContext(ctx)
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"errors"
+	"strings"
+
+	"clerk/pkg/set"
+
+	sdkhttp "github.com/clerk/clerk-sdk-go/v2/http"
+	"github.com/clerk/clerk-sdk-go/v2/jwks"
+)
+
+// ErrNoAuthorizedParties is returned when the configured list of authorized
+// parties is empty. An empty list skips the 'azp' check altogether, so it's
+// never the result of a missing configuration.
+var ErrNoAuthorizedParties = errors.New("pipeline: no authorized parties are configured")
+
+// AuthorizedParties parses a comma separated list of the origins which are
+// allowed to use the session tokens of an internal API. At least one origin
+// is required.
+func AuthorizedParties(value string) ([]string, error) {
+	parties := set.New[string]()
+	for _, party := range strings.Split(value, ",") {
+		if party = strings.TrimSpace(party); party != "" {
+			parties.Insert(party)
+		}
+	}
+	if len(parties.Array()) == 0 {
+		return nil, ErrNoAuthorizedParties
+	}
+	return parties.Array(), nil
+}
+
+// RequireSession verifies the session token in the Authorization header
+// of requests to internal APIs. When authorizedParties is empty, the
+// 'azp' claim of the token is not checked, so it must only be empty when
+// that's explicitly intended.
+func RequireSession(jwksClient *jwks.Client, authorizedParties []string) Middleware {
+	return sdkhttp.RequireHeaderAuthorization(
+		sdkhttp.JWKSClient(jwksClient),
+		sdkhttp.AuthorizedPartyMatches(authorizedParties...),
+	)
+}
@@ -0,0 +1,208 @@
+// Package pipeline assembles the middleware that every API router runs
+// before routing a request.
+//
+// All routers share the same ordered stages: panic recovery, tracing,
+// error reporting, trace IDs, response type, logging, query budgets, feature
+// flags and path normalization. A Policy describes what a router needs on top of those,
+// so that routers can't drift apart by wiring the common stages
+// differently.
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/middleware"
+	"clerk/api/shared/requestlog"
+	"clerk/api/shared/serviceconfig"
+	"clerk/pkg/cenv"
+	"clerk/pkg/clerkhttp"
+	"clerk/pkg/featureflags"
+	"clerk/pkg/querystats"
+	"clerk/utils/log"
+
+	sentry "github.com/getsentry/sentry-go/http"
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	chitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-chi/chi.v5"
+)
+
+// Middleware is a standard net/http middleware.
+type Middleware = func(http.Handler) http.Handler
+
+// Names of the stages of the pipeline, in the order they run.
+const (
+	StageRecover       = "recover"
+	StageTracing       = "tracing"
+	StageSentry        = "sentry"
+	StageTraceID       = "trace_id"
+	StageMaintenance   = "maintenance"
+	StageResponseType  = "response_type"
+	StageBeforeLog     = "before_log"
+	StageLog           = "log"
+	StageQueryBudget   = "query_budget"
+	StageFeatureFlags  = "feature_flags"
+	StageStripV1       = "strip_v1"
+	StageStripSlashes  = "strip_slashes"
+	StageBeforeRouting = "before_routing"
+)
+
+// Stage is a single named step of the pipeline.
+type Stage struct {
+	Name       string
+	Middleware Middleware
+}
+
+// Policy describes how a router customizes the pipeline.
+type Policy struct {
+	// TracerServiceName is the service name reported to Datadog. The
+	// default of the tracer is used when empty.
+	TracerServiceName string
+
+	// MaintenanceMode loads the maintenance and recovery modes of the
+	// server into the request context.
+	MaintenanceMode bool
+
+	// DBStats reports the statistics of the database connection pool on
+	// the request log line.
+	DBStats func() sql.DBStats
+
+	// LogSampling decides which requests are logged, per route. Rules
+	// configured through the environment are applied on top of them.
+	LogSampling requestlog.SamplingRules
+
+	// FeatureFlags loads the feature flags the handlers of the router
+	// evaluate. Flags are off on routers without one.
+	FeatureFlags featureflags.Loader
+
+	// BeforeLog runs right before the request is logged, e.g. to parse the
+	// request form, so that its outcome is part of the log line.
+	BeforeLog []Middleware
+
+	// StripV1 serves the routes of the router without the "/v1" prefix.
+	// It runs after logging, so that the true path of the request is
+	// logged.
+	StripV1 bool
+
+	// BeforeRouting runs last, right before the request is routed.
+	BeforeRouting []Middleware
+}
+
+// Stages returns the stages of the pipeline for the policy, in the order
+// they run. Stages the policy leaves out are omitted.
+func (p Policy) Stages() []Stage {
+	stages := []Stage{{Name: StageRecover, Middleware: middleware.Recover}}
+
+	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
+		var opts []chitrace.Option
+		if p.TracerServiceName != "" {
+			opts = append(opts, chitrace.WithServiceName(p.TracerServiceName))
+		}
+		stages = append(stages, Stage{Name: StageTracing, Middleware: chitrace.Middleware(opts...)})
+	}
+
+	// Report panics to Sentry and re-panic. Also, populate a Sentry Hub
+	// into the context, which is why it must come after middleware.Recover
+	// and before middleware.SetTraceID.
+	stages = append(stages,
+		Stage{Name: StageSentry, Middleware: sentry.New(sentry.Options{Repanic: true}).Handle},
+		Stage{Name: StageTraceID, Middleware: middleware.SetTraceID},
+	)
+
+	if p.MaintenanceMode {
+		stages = append(stages, Stage{Name: StageMaintenance, Middleware: clerkhttp.Middleware(middleware.SetMaintenanceAndRecoveryMode)})
+	}
+
+	stages = append(stages, Stage{Name: StageResponseType, Middleware: middleware.SetResponseTypeToJSON})
+	for _, mw := range p.BeforeLog {
+		stages = append(stages, Stage{Name: StageBeforeLog, Middleware: mw})
+	}
+
+	dbStats := p.DBStats
+	if dbStats == nil {
+		dbStats = func() sql.DBStats { return sql.DBStats{} }
+	}
+	stages = append(stages,
+		Stage{Name: StageLog, Middleware: middleware.Log(dbStats, p.logSamplingRules())},
+		Stage{Name: StageQueryBudget, Middleware: middleware.QueryBudget(queryBudget, cenv.IsEnabled(cenv.ClerkDBQueryBudgetWarningHeader))},
+	)
+
+	if p.FeatureFlags != nil {
+		stages = append(stages, Stage{Name: StageFeatureFlags, Middleware: middleware.FeatureFlags(p.FeatureFlags)})
+	}
+
+	if p.StripV1 {
+		stages = append(stages, Stage{Name: StageStripV1, Middleware: middleware.StripV1})
+	}
+	stages = append(stages, Stage{Name: StageStripSlashes, Middleware: chimw.StripSlashes})
+
+	for _, mw := range p.BeforeRouting {
+		stages = append(stages, Stage{Name: StageBeforeRouting, Middleware: mw})
+	}
+	return stages
+}
+
+// logSamplingRules returns the sampling rules of the policy, followed by
+// the ones configured through the environment. Invalid rules in the
+// environment are ignored, so that they can't take the APIs down.
+func (p Policy) logSamplingRules() requestlog.SamplingRules {
+	rules := append(requestlog.SamplingRules{}, p.LogSampling...)
+	configured, err := requestlog.ParseSamplingRules(cenv.Get(cenv.ClerkRequestLogSamplingRules))
+	if err != nil {
+		log.Warning(context.Background(), "pipeline: %s", err)
+		return rules
+	}
+	return append(rules, configured...)
+}
+
+// queryBudget returns the query budget of requests, which can be tuned
+// while the APIs are running.
+func queryBudget() querystats.Budget {
+	tunables := serviceconfig.Live()
+	return querystats.Budget{
+		Queries:  tunables.DBQueryBudget.Get(),
+		Duration: tunables.DBQueryBudgetDuration.Get(),
+	}
+}
+
+// Apply installs the pipeline of the policy on the router. It must be
+// called before any routes are registered.
+func Apply(r chi.Router, policy Policy) {
+	for _, stage := range policy.Stages() {
+		r.Use(stage.Middleware)
+	}
+}
+
+// Timeout aborts requests which take longer than timeout. The client gets
+// the same JSON error on every API, instead of the empty body of
+// http.TimeoutHandler.
+func Timeout(h http.Handler, timeout time.Duration) http.Handler {
+	// nolint:errchkjson
+	body, _ := json.Marshal(apierror.ToResponse(context.Background(), apierror.RequestTimeout()))
+	timeoutHandler := http.TimeoutHandler(h, timeout, string(body))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The headers of the wrapped handler replace this one, unless the
+		// request times out.
+		w.Header().Set("Content-Type", "application/json")
+		timeoutHandler.ServeHTTP(w, r)
+	})
+}
+
+// NewServer returns a server for the handler which listens on port, with
+// the timeouts shared by all APIs.
+func NewServer(port string, handler http.Handler) *http.Server {
+	ctxTimeout := cenv.GetInt(cenv.ContextTimeoutSeconds)
+	writeTimeout := ctxTimeout + 2 // write timeout should be longer than context timeout
+
+	return &http.Server{
+		Addr:         ":" + port,
+		Handler:      Timeout(handler, time.Duration(ctxTimeout)*time.Second),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: time.Duration(writeTimeout) * time.Second,
+	}
+}
@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/pkg/constants"
+	"clerk/pkg/featureflags"
+
+	"github.com/clerk/clerk-sdk-go/v2/jwks"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyStages(t *testing.T) {
+	t.Parallel()
+
+	noop := func(next http.Handler) http.Handler { return next }
+	noFlags := func(context.Context) (map[string]featureflags.Flag, error) { return nil, nil }
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{
+			name: "frontend api",
+			policy: Policy{
+				MaintenanceMode: true,
+				BeforeLog:       []Middleware{noop, noop, noop},
+				FeatureFlags:    noFlags,
+				BeforeRouting:   []Middleware{noop},
+			},
+			want: []string{
+				StageRecover, StageSentry, StageTraceID, StageMaintenance, StageResponseType,
+				StageBeforeLog, StageBeforeLog, StageBeforeLog, StageLog, StageQueryBudget, StageFeatureFlags,
+				StageStripSlashes, StageBeforeRouting,
+			},
+		},
+		{
+			name: "dashboard api",
+			policy: Policy{
+				MaintenanceMode: true,
+				StripV1:         true,
+				FeatureFlags:    noFlags,
+				BeforeRouting:   []Middleware{noop},
+			},
+			want: []string{
+				StageRecover, StageSentry, StageTraceID, StageMaintenance, StageResponseType,
+				StageLog, StageQueryBudget, StageFeatureFlags, StageStripV1, StageStripSlashes, StageBeforeRouting,
+			},
+		},
+		{
+			name:   "support api",
+			policy: Policy{StripV1: true},
+			want: []string{
+				StageRecover, StageSentry, StageTraceID, StageResponseType,
+				StageLog, StageQueryBudget, StageStripV1, StageStripSlashes,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var names []string
+			for _, stage := range tt.policy.Stages() {
+				names = append(names, stage.Name)
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Parallel()
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	rec := httptest.NewRecorder()
+	Timeout(slow, 10*time.Millisecond).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Errors, 1)
+	require.Equal(t, "request_timeout", body.Errors[0].Code)
+}
+
+func TestAuthorizedParties(t *testing.T) {
+	t.Parallel()
+
+	parties, err := AuthorizedParties(" https://dashboard.clerk.com,,https://support.clerk.com,https://dashboard.clerk.com ")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"https://dashboard.clerk.com", "https://support.clerk.com"}, parties)
+
+	// A missing configuration must never turn the 'azp' check off.
+	for _, value := range []string{"", " ", ", ,"} {
+		_, err := AuthorizedParties(value)
+		assert.ErrorIs(t, err, ErrNoAuthorizedParties)
+	}
+}
+
+// testServer serves a router through the stages of the pipeline which don't
+// depend on the environment, the session check of internal APIs and the
+// timeout of all servers.
+func testServer(t *testing.T) http.Handler {
+	t.Helper()
+
+	parties, err := AuthorizedParties("https://dashboard.clerk.com")
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	for _, stage := range (Policy{StripV1: true}).Stages() {
+		switch stage.Name {
+		case StageTracing, StageLog, StageQueryBudget:
+			continue
+		}
+		r.Use(stage.Middleware)
+	}
+
+	r.Get("/panic", func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+	r.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	r.Group(func(r chi.Router) {
+		r.Use(RequireSession(jwks.NewClient(&jwks.ClientConfig{}), parties))
+		r.Get("/protected", func(http.ResponseWriter, *http.Request) {
+			t.Error("the protected handler was reached without a session")
+		})
+	})
+	return Timeout(r, 50*time.Millisecond)
+}
+
+func TestPipelineRejectsRequestsWithoutSession(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	testServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/protected", nil))
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Header().Get(constants.ClerkTraceID))
+}
+
+func TestPipelineRecoversFromPanics(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	assert.NotPanics(t, func() {
+		testServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/panic/", nil))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.NotEmpty(t, rec.Header().Get(constants.ClerkTraceID))
+
+	var body struct {
+		Type string `json:"type"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "internal_clerk_error", body.Type)
+}
+
+func TestPipelineTimesOut(t *testing.T) {
+	t.Parallel()
+
+	rec := httptest.NewRecorder()
+	testServer(t).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/slow", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "request_timeout")
+}
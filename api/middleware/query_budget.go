@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"clerk/api/shared/requestlog"
+	"clerk/pkg/querystats"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// queryBudgetHeader is set on the responses of requests which exceeded
+// their query budget, with the number of queries they ran and the time they
+// spent on them.
+const queryBudgetHeader = "X-Clerk-DB-Query-Budget-Exceeded"
+
+// QueryBudget counts the database queries of every request and the time it
+// spends on them. The totals are attached to the Datadog span and the log
+// line of the request. Requests which exceed the budget also log their
+// slowest queries, and, if warn is set, respond with a warning header. The
+// budget is read at the start of every request, so it can change while the
+// server is running.
+//
+// NOTE: Should be added after the Log middleware, so that the totals are
+// part of the log line.
+func QueryBudget(budgetFunc func() querystats.Budget, warn bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			budget := budgetFunc()
+			ctx, recorder := querystats.NewContext(r.Context())
+			if warn {
+				w = &queryBudgetResponseWriter{ResponseWriter: w, budget: budget, recorder: recorder}
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			stats := recorder.Stats()
+			exceeded := budget.IsExceededBy(stats)
+			if span, ok := tracer.SpanFromContext(ctx); ok {
+				span.SetTag("db.query_count", stats.Count)
+				span.SetTag("db.query_duration_ms", stats.Duration.Milliseconds())
+				span.SetTag("db.query_budget_exceeded", exceeded)
+			}
+
+			fields := map[string]any{
+				"count":       stats.Count,
+				"duration_ms": stats.Duration.Milliseconds(),
+			}
+			if exceeded {
+				fields["budget_exceeded"] = true
+				slowest := make([]map[string]any, len(stats.Slowest))
+				for i, query := range stats.Slowest {
+					slowest[i] = map[string]any{
+						"query":       query.Query,
+						"duration_ms": query.Duration.Milliseconds(),
+					}
+				}
+				fields["slowest"] = slowest
+			}
+			requestlog.Add(ctx, requestlog.DBQueries, fields)
+		})
+	}
+}
+
+// queryBudgetResponseWriter sets the warning header right before the
+// response headers are written. Queries which run after that can't be
+// reflected in the header, but they're still logged.
+type queryBudgetResponseWriter struct {
+	http.ResponseWriter
+	budget      querystats.Budget
+	recorder    *querystats.Recorder
+	wroteHeader bool
+}
+
+func (w *queryBudgetResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		stats := w.recorder.Stats()
+		if w.budget.IsExceededBy(stats) {
+			w.Header().Set(queryBudgetHeader, fmt.Sprintf("queries=%d; duration_ms=%d", stats.Count, stats.Duration.Milliseconds()))
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *queryBudgetResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush it.
+func (w *queryBudgetResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
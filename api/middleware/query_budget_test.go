@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/api/shared/requestlog"
+	"clerk/pkg/querystats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBudget(t *testing.T) {
+	t.Parallel()
+
+	nextHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 3; i++ {
+			querystats.Record(r.Context(), "SELECT * FROM users", 10*time.Millisecond)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, entry := requestlog.NewContext(httptest.NewRequest(http.MethodGet, "http://testing", nil).Context())
+	req := httptest.NewRequest(http.MethodGet, "http://testing", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	QueryBudget(func() querystats.Budget { return querystats.Budget{Queries: 2} }, true)(nextHandler).ServeHTTP(rec, req)
+
+	assert.Equal(t, "queries=3; duration_ms=30", rec.Header().Get(queryBudgetHeader))
+	fields, ok := entry.Fields()[requestlog.DBQueries].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, 3, fields["count"])
+	assert.Equal(t, true, fields["budget_exceeded"])
+
+	// requests within their budget don't get the header
+	rec = httptest.NewRecorder()
+	QueryBudget(func() querystats.Budget { return querystats.Budget{Queries: 3} }, true)(nextHandler).ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get(queryBudgetHeader))
+}
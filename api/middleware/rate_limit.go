@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/ratelimit"
+	"clerk/pkg/ctx/environment"
+)
+
+// RateLimit limits the requests to the given route of the given API, per
+// instance and client IP. It must run after the environment has been set on
+// the context.
+func RateLimit(rateLimitService *ratelimit.Service, api ratelimit.API, route string) func(w http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+	return func(w http.ResponseWriter, r *http.Request) (*http.Request, apierror.Error) {
+		ctx := r.Context()
+		env := environment.FromContext(ctx)
+
+		key := ratelimit.Key(env.Instance.ID, rateLimitClientIP(r), route)
+		result := rateLimitService.Allow(ctx, key, ratelimit.LimitForInstance(env.Instance, api))
+		if result.Allowed {
+			return r, nil
+		}
+
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		return r, apierror.RateLimitExceeded(retryAfter)
+	}
+}
+
+// rateLimitClientIP returns the IP of the client, or of the peer the request
+// came from when it's not behind our proxies, e.g. in local development.
+func rateLimitClientIP(r *http.Request) string {
+	if ip := ClientIP(r); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
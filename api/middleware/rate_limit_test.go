@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitClientIP(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/v1/users", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+	assert.Equal(t, "10.0.0.1", rateLimitClientIP(r))
+	assert.Empty(t, ClientIP(r))
+
+	r.Header.Set("X-Client-IP", "5.6.7.8")
+	assert.Equal(t, "5.6.7.8", rateLimitClientIP(r))
+
+	// the header set by Cloudflare takes precedence
+	r.Header.Set("True-Client-IP", "1.2.3.4")
+	assert.Equal(t, "1.2.3.4", rateLimitClientIP(r))
+	assert.Equal(t, "1.2.3.4", ClientIP(r))
+}
@@ -1,22 +1,25 @@
 package main
 
 import (
-	"net/http"
+	"context"
+	"fmt"
 	"os"
-	"strings"
-	"time"
 
+	"clerk/api/middleware/pipeline"
 	"clerk/api/sapi/v1/router"
+	"clerk/api/shared/serviceconfig"
 	"clerk/pkg/billing"
 	"clerk/pkg/cenv"
 	"clerk/pkg/handlers"
+	"clerk/pkg/pubsub"
 	"clerk/pkg/sentry"
-	"clerk/pkg/set"
+	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/log"
 
 	"cloud.google.com/go/profiler"
 	sdk "github.com/clerk/clerk-sdk-go/v2"
+	"github.com/jonboulle/clockwork"
 	"github.com/stripe/stripe-go/v72"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
@@ -39,13 +42,18 @@ func main() {
 		panic(err)
 	}
 
+	cfg, err := serviceconfig.LoadSAPI()
+	if err != nil {
+		panic(err)
+	}
+
 	logger := log.New()
 	defer logger.Flush()
 
-	err := sentry.Init(
-		cenv.Get(cenv.SentryURL),
-		cenv.Get(cenv.ClerkEnv),
-		cenv.Get(cenv.SentryIgnoredStatusCodes),
+	err = sentry.Init(
+		cfg.SentryURL,
+		cfg.Env,
+		cfg.SentryIgnoredStatusCodes,
 	)
 	if err != nil {
 		logger.Error("failed starting Sentry: %s", err)
@@ -53,32 +61,37 @@ func main() {
 		defer sentry.Flush()
 	}
 
-	if cenv.IsEnabled(cenv.GoogleCloudProfiler) {
-		err = profiler.Start(profiler.Config{Service: cenv.Get(cenv.ClerkServiceIdentifier)})
+	if cfg.GoogleCloudProfiler {
+		err = profiler.Start(profiler.Config{Service: cfg.ServiceIdentifier})
 		if err != nil {
 			logger.Error("profiler: start: %s", err)
 		}
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDebugMode) {
+	if cfg.DebugMode {
 		boil.DebugMode = true
 		boil.DebugWriter = logger.Writer()
 	}
 
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
+	if cfg.DatadogTracer {
 		//GitCommitSHA is not a required env var, but if it's available use it
 		tracerOpts := []tracer.StartOption{
-			tracer.WithEnv(cenv.Get(cenv.ClerkEnv)),
-			tracer.WithService(cenv.Get(cenv.ClerkServiceIdentifier)),
+			tracer.WithEnv(cfg.Env),
+			tracer.WithService(cfg.ServiceIdentifier),
 		}
-		if cenv.IsSet(cenv.GitCommitSHA) {
-			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cenv.Get(cenv.GitCommitSHA)))
+		if cfg.GitCommitSHA != "" {
+			tracerOpts = append(tracerOpts, tracer.WithServiceVersion(cfg.GitCommitSHA))
 		}
 		tracer.Start(tracerOpts...)
 		defer tracer.Stop()
 	}
 
-	deps := clerk.NewDeps(logger)
+	// repositories are created once and shared by every service
+	repositories := repository.NewRegistry(clockwork.NewRealClock())
+
+	// the events topic carries the invalidations of the environments which
+	// FAPI caches
+	deps := clerk.NewDeps(logger, clerk.WithPubsubEventTopic(pubsub.EventsTopic()), clerk.WithRepositories(repositories))
 
 	defer func() {
 		err := deps.SegmentClient().Close()
@@ -93,16 +106,19 @@ func main() {
 	}()
 
 	commonHandlers := handlers.NewCommon(deps.DB())
-	authorizedParties := set.New(strings.Split(cenv.Get(cenv.ClerkSupportAZP), ",")...).Array()
+	authorizedParties, err := pipeline.AuthorizedParties(cfg.SupportAZP)
+	if err != nil {
+		panic(fmt.Errorf("%s: %w", cenv.ClerkSupportAZP, err))
+	}
 
 	// Initialize Stripe
-	stripe.Key = cenv.Get(cenv.StripeSecretKey)
+	stripe.Key = cfg.StripeSecretKey
 	paymentProvider := billing.NewStripePaymentProvider(deps.GueClient())
 
 	sdkClientConfig := &sdk.ClientConfig{
 		BackendConfig: sdk.BackendConfig{
-			URL: sdk.String(cenv.Get(cenv.ClerkServerAPI)),
-			Key: sdk.String(cenv.Get(cenv.ClerkSupportAPIKey)),
+			URL: sdk.String(cfg.ServerAPI),
+			Key: sdk.String(cfg.SupportAPIKey),
 			CustomRequestHeaders: &sdk.CustomRequestHeaders{
 				Application: "support.clerk.app",
 			},
@@ -118,16 +134,10 @@ func main() {
 	)
 
 	// Start the HTTP server.
-	port := cenv.Get(cenv.Port)
-	ctxTimeout := cenv.GetInt(cenv.ContextTimeoutSeconds)
-	writeTimeout := ctxTimeout + 2 // write timeout should be longer than context timeout
-
-	server := http.Server{
-		Addr:         ":" + port,
-		Handler:      http.TimeoutHandler(r.BuildRoutes(), time.Duration(ctxTimeout)*time.Second, ""),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: time.Duration(writeTimeout) * time.Second,
-	}
+	go serviceconfig.WatchTunables(context.Background())
+
+	port := cfg.Port
+	server := pipeline.NewServer(port, r.BuildRoutes())
 
 	logger.Info("Up on port %s (pid=%d)", port, os.Getpid())
 	logger.Fatal(server.ListenAndServe())
@@ -0,0 +1,48 @@
+package serialize
+
+import (
+	"clerk/model"
+	clerktime "clerk/pkg/time"
+)
+
+type BackfillResponse struct {
+	ID             string  `json:"id"`
+	Task           string  `json:"task"`
+	Status         string  `json:"status"`
+	BatchSize      int     `json:"batch_size"`
+	RowsPerSecond  int     `json:"rows_per_second"`
+	Cursor         string  `json:"cursor"`
+	ProcessedCount int64   `json:"processed_count"`
+	LastError      *string `json:"last_error"`
+	CompletedAt    *int64  `json:"completed_at"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+func Backfill(backfill *model.Backfill) *BackfillResponse {
+	response := &BackfillResponse{
+		ID:             backfill.ID,
+		Task:           backfill.Task,
+		Status:         backfill.Status,
+		BatchSize:      backfill.BatchSize,
+		RowsPerSecond:  backfill.RowsPerSecond,
+		Cursor:         backfill.Cursor,
+		ProcessedCount: backfill.ProcessedCount,
+		LastError:      backfill.LastError.Ptr(),
+		CreatedAt:      clerktime.UnixMilli(backfill.CreatedAt),
+		UpdatedAt:      clerktime.UnixMilli(backfill.UpdatedAt),
+	}
+	if backfill.CompletedAt.Valid {
+		completedAt := clerktime.UnixMilli(backfill.CompletedAt.Time)
+		response.CompletedAt = &completedAt
+	}
+	return response
+}
+
+type BackfillTasksResponse struct {
+	Tasks []string `json:"tasks"`
+}
+
+func BackfillTasks(tasks []string) *BackfillTasksResponse {
+	return &BackfillTasksResponse{Tasks: tasks}
+}
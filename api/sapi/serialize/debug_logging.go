@@ -0,0 +1,23 @@
+package serialize
+
+import (
+	"time"
+
+	clerktime "clerk/pkg/time"
+)
+
+type DebugLoggingResponse struct {
+	Enabled   bool   `json:"enabled"`
+	ExpiresAt *int64 `json:"expires_at"`
+}
+
+func DebugLogging(expiresAt *time.Time) *DebugLoggingResponse {
+	res := &DebugLoggingResponse{
+		Enabled: expiresAt != nil,
+	}
+	if expiresAt != nil {
+		expiresAtMillis := clerktime.UnixMilli(*expiresAt)
+		res.ExpiresAt = &expiresAtMillis
+	}
+	return res
+}
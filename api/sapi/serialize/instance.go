@@ -42,6 +42,8 @@ type InstanceResponse struct {
 	SMSSettings          SMSSettingsResponse          `json:"sms_settings"`
 	Subscription         SubscriptionResponse         `json:"subscription"`
 	ActiveDomain         ActiveDomainResponse         `json:"active_domain"`
+	MaintenanceMode      bool                         `json:"maintenance_mode"`
+	MaintenanceBanner    *string                      `json:"maintenance_banner"`
 }
 
 func Instance(instanceSerializable *serializable.Instance) *InstanceResponse {
@@ -76,6 +78,8 @@ func Instance(instanceSerializable *serializable.Instance) *InstanceResponse {
 			Name:     instanceSerializable.ActiveDomain.NameForDashboard(instanceSerializable.Env.Instance),
 			Deployed: instanceSerializable.IsActiveDomainDeployed,
 		},
+		MaintenanceMode:   instanceSerializable.Env.Instance.MaintenanceMode,
+		MaintenanceBanner: instanceSerializable.Env.Instance.MaintenanceBanner.Ptr(),
 	}
 }
 
@@ -0,0 +1,62 @@
+package backfills
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/clerkhttp"
+	"clerk/utils/clerk"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /backfills
+func (h *HTTP) List(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	paginationParams, err := pagination.NewFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.service.List(r.Context(), paginationParams)
+}
+
+// GET /backfills/tasks
+func (h *HTTP) ListTasks(_ http.ResponseWriter, _ *http.Request) (any, apierror.Error) {
+	return h.service.ListTasks(), nil
+}
+
+// POST /backfills
+func (h *HTTP) Start(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	params := StartParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.Start(r.Context(), params)
+}
+
+// GET /backfills/{backfillID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	return h.service.Read(r.Context(), chi.URLParam(r, "backfillID"))
+}
+
+// POST /backfills/{backfillID}/pause
+func (h *HTTP) Pause(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	return h.service.Pause(r.Context(), chi.URLParam(r, "backfillID"))
+}
+
+// POST /backfills/{backfillID}/resume
+func (h *HTTP) Resume(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	return h.service.Resume(r.Context(), chi.URLParam(r, "backfillID"))
+}
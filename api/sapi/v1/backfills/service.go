@@ -0,0 +1,114 @@
+package backfills
+
+import (
+	"context"
+	"slices"
+	"strconv"
+
+	"clerk/api/apierror"
+	"clerk/api/sapi/serialize"
+	apiserialize "clerk/api/serialize"
+	"clerk/api/shared/backfills"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/jobs/backfill"
+	"clerk/utils/clerk"
+)
+
+type Service struct {
+	backfillService *backfills.Service
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		backfillService: backfills.NewService(deps),
+	}
+}
+
+func (s *Service) List(ctx context.Context, params pagination.Params) (*apiserialize.PaginatedResponse, apierror.Error) {
+	records, totalCount, err := s.backfillService.List(ctx, params)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]any, len(records))
+	for i, record := range records {
+		responses[i] = serialize.Backfill(record)
+	}
+	return apiserialize.Paginated(responses, totalCount), nil
+}
+
+func (s *Service) ListTasks() *serialize.BackfillTasksResponse {
+	return serialize.BackfillTasks(s.backfillService.TaskNames())
+}
+
+type StartParams struct {
+	Task          string `json:"task"`
+	BatchSize     *int   `json:"batch_size"`
+	RowsPerSecond int    `json:"rows_per_second"`
+}
+
+func (p StartParams) validate(tasks []string) apierror.Error {
+	if !slices.Contains(tasks, p.Task) {
+		return apierror.FormInvalidParameterValueWithAllowed("task", p.Task, tasks)
+	}
+	if p.BatchSize != nil && (*p.BatchSize <= 0 || *p.BatchSize > backfill.MaxBatchSize) {
+		return apierror.FormInvalidParameterValue("batch_size", strconv.Itoa(*p.BatchSize))
+	}
+	if p.RowsPerSecond < 0 {
+		return apierror.FormInvalidParameterValue("rows_per_second", strconv.Itoa(p.RowsPerSecond))
+	}
+	return nil
+}
+
+// Start backfills the given task, at most at the given rate. Backfills are
+// unthrottled unless a rate is given.
+func (s *Service) Start(ctx context.Context, params StartParams) (*serialize.BackfillResponse, apierror.Error) {
+	if apiErr := params.validate(s.backfillService.TaskNames()); apiErr != nil {
+		return nil, apiErr
+	}
+
+	throttle := backfill.Throttle{
+		BatchSize:     backfill.DefaultBatchSize,
+		RowsPerSecond: params.RowsPerSecond,
+	}
+	if params.BatchSize != nil {
+		throttle.BatchSize = *params.BatchSize
+	}
+
+	record, err := s.backfillService.Start(ctx, params.Task, throttle)
+	if err != nil {
+		return nil, toAPIError(err)
+	}
+	return serialize.Backfill(record), nil
+}
+
+func (s *Service) Read(ctx context.Context, backfillID string) (*serialize.BackfillResponse, apierror.Error) {
+	record, err := s.backfillService.Read(ctx, backfillID)
+	if err != nil {
+		return nil, toAPIError(err)
+	}
+	return serialize.Backfill(record), nil
+}
+
+func (s *Service) Pause(ctx context.Context, backfillID string) (*serialize.BackfillResponse, apierror.Error) {
+	record, err := s.backfillService.Pause(ctx, backfillID)
+	if err != nil {
+		return nil, toAPIError(err)
+	}
+	return serialize.Backfill(record), nil
+}
+
+func (s *Service) Resume(ctx context.Context, backfillID string) (*serialize.BackfillResponse, apierror.Error) {
+	record, err := s.backfillService.Resume(ctx, backfillID)
+	if err != nil {
+		return nil, toAPIError(err)
+	}
+	return serialize.Backfill(record), nil
+}
+
+func toAPIError(err error) apierror.Error {
+	if apiErr, isAPIErr := apierror.As(err); isAPIErr {
+		return apiErr
+	}
+	return apierror.Unexpected(err)
+}
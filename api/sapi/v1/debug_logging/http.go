@@ -0,0 +1,36 @@
+package debug_logging
+
+import (
+	"net/http"
+
+	"clerk/api/apierror"
+	"clerk/pkg/clerkhttp"
+	"clerk/utils/clerk"
+)
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	return h.service.Read(r.Context())
+}
+
+func (h *HTTP) Enable(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	params := EnableParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.Enable(r.Context(), params)
+}
+
+func (h *HTTP) Disable(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	return h.service.Disable(r.Context())
+}
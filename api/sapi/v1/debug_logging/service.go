@@ -0,0 +1,84 @@
+package debug_logging
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/sapi/serialize"
+	"clerk/api/shared/debug_logging"
+	shenvironment "clerk/api/shared/environment"
+	"clerk/pkg/ctx/environment"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+type Service struct {
+	db database.Database
+
+	// services
+	debugLoggingService *debug_logging.Service
+	envInvalidator      *shenvironment.Invalidator
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                  deps.DB(),
+		debugLoggingService: debug_logging.NewService(deps),
+		envInvalidator:      shenvironment.NewInvalidator(deps),
+	}
+}
+
+func (s *Service) Read(ctx context.Context) (*serialize.DebugLoggingResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	return serialize.DebugLogging(s.debugLoggingService.ExpiresAt(env.Instance)), nil
+}
+
+type EnableParams struct {
+	DurationInSeconds int `json:"duration_in_seconds"`
+}
+
+func (p EnableParams) validate() apierror.Error {
+	window := time.Duration(p.DurationInSeconds) * time.Second
+	if window <= 0 || window > debug_logging.MaxWindow {
+		return apierror.FormInvalidParameterValue("duration_in_seconds", strconv.Itoa(p.DurationInSeconds))
+	}
+	return nil
+}
+
+func (s *Service) Enable(ctx context.Context, params EnableParams) (*serialize.DebugLoggingResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, apiErr
+	}
+
+	expiresAt, err := s.debugLoggingService.Enable(ctx, s.db, env.Instance, time.Duration(params.DurationInSeconds)*time.Second)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	s.invalidateEnvironment(ctx, env.Instance.ID)
+	return serialize.DebugLogging(&expiresAt), nil
+}
+
+func (s *Service) Disable(ctx context.Context) (*serialize.DebugLoggingResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if err := s.debugLoggingService.Disable(ctx, s.db, env.Instance); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	s.invalidateEnvironment(ctx, env.Instance.ID)
+	return serialize.DebugLogging(nil), nil
+}
+
+// invalidateEnvironment evicts the environment of the instance from the
+// FAPI caches, so that the debug logging window applies right away. Failing
+// to do so isn't fatal, the cached environment expires shortly anyway.
+func (s *Service) invalidateEnvironment(ctx context.Context, instanceID string) {
+	if err := s.envInvalidator.Invalidate(ctx, instanceID); err != nil {
+		log.Warning(ctx, "debugLogging: %s", err)
+	}
+}
@@ -29,9 +29,9 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		db:                        clerk.DB(),
 		domainService:             domains.NewService(deps),
-		serializableDomainService: serializable.NewDomainService(),
-		domainRepo:                repository.NewDomain(),
-		instanceRepo:              repository.NewInstances(),
+		serializableDomainService: serializable.NewDomainService(deps),
+		domainRepo:                deps.Repositories().Domain,
+		instanceRepo:              deps.Repositories().Instances,
 	}
 }
 
@@ -29,7 +29,7 @@ func NewService(deps clerk.Deps) *Service {
 		clock:                 deps.Clock(),
 		db:                    deps.DB(),
 		emailQualityChecker:   deps.EmailQualityChecker(),
-		emailDomainReportRepo: repository.NewEmailDomainReport(),
+		emailDomainReportRepo: deps.Repositories().EmailDomainReport,
 	}
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"clerk/api/apierror"
 	shenvironment "clerk/api/shared/environment"
+	"clerk/api/shared/phone_profiles"
 	"clerk/pkg/ctx/environment"
 	"clerk/utils/database"
 )
@@ -27,6 +28,9 @@ func (s *Service) LoadToContext(ctx context.Context, instanceID string) (context
 	env, err := s.environmentService.Load(ctx, s.db, instanceID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return ctx, apierror.ResourceNotFound()
+	} else if err != nil {
+		return ctx, apierror.Unexpected(err)
 	}
-	return environment.NewContext(ctx, env), nil
+	ctx = environment.NewContext(ctx, env)
+	return phone_profiles.NewContext(ctx, env.Instance), nil
 }
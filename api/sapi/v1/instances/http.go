@@ -51,6 +51,15 @@ func (h *HTTP) UpdateUserLimits(_ http.ResponseWriter, r *http.Request) (any, ap
 	return h.service.UpdateUserLimits(r.Context(), params)
 }
 
+func (h *HTTP) UpdateMaintenanceMode(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	params := UpdateMaintenanceModeParams{}
+	if err := clerkhttp.Decode(r, &params); err != nil {
+		return nil, err
+	}
+
+	return h.service.UpdateMaintenanceMode(r.Context(), params)
+}
+
 func (h *HTTP) PurgeCache(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
 	return h.service.PurgeCache(r.Context())
 }
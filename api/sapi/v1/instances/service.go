@@ -9,6 +9,7 @@ import (
 	"clerk/api/sapi/serialize"
 	"clerk/api/sapi/v1/serializable"
 	"clerk/api/shared/edgecache"
+	shinstances "clerk/api/shared/instances"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/ctx/environment"
@@ -23,8 +24,9 @@ type Service struct {
 	db        database.Database
 	gueClient *gue.Client
 
-	domainRepo      *repository.Domain
-	instanceService *serializable.InstanceService
+	domainRepo        *repository.Domain
+	instanceService   *serializable.InstanceService
+	shInstanceService *shinstances.Service
 
 	authConfigRepo *repository.AuthConfig
 	instanceRepo   *repository.Instances
@@ -32,11 +34,12 @@ type Service struct {
 
 func NewService(db database.Database, gueClient *gue.Client) *Service {
 	return &Service{
-		db:              db,
-		gueClient:       gueClient,
-		instanceService: serializable.NewInstanceService(db, gueClient),
-		authConfigRepo:  repository.NewAuthConfig(),
-		instanceRepo:    repository.NewInstances(),
+		db:                db,
+		gueClient:         gueClient,
+		instanceService:   serializable.NewInstanceService(db, gueClient),
+		shInstanceService: shinstances.NewService(db, gueClient),
+		authConfigRepo:    repository.NewAuthConfig(),
+		instanceRepo:      repository.NewInstances(),
 	}
 }
 
@@ -238,6 +241,34 @@ func (s *Service) UpdateUserLimits(ctx context.Context, params UpdateUserLimitsP
 	return serialize.Instance(serializableInstance), nil
 }
 
+type UpdateMaintenanceModeParams struct {
+	Enabled bool    `json:"enabled"`
+	Banner  *string `json:"banner"`
+}
+
+func (s *Service) UpdateMaintenanceMode(ctx context.Context, params UpdateMaintenanceModeParams) (*serialize.InstanceResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+
+	if apiErr := shinstances.ValidateMaintenanceBanner("banner", params.Banner); apiErr != nil {
+		return nil, apiErr
+	}
+
+	var serializableInstance *serializable.Instance
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		err := s.shInstanceService.UpdateMaintenanceMode(ctx, tx, env.Instance, params.Enabled, params.Banner)
+		if err != nil {
+			return true, err
+		}
+
+		serializableInstance, err = s.instanceService.ConvertToSerializable(ctx, tx, env)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+	return serialize.Instance(serializableInstance), nil
+}
+
 func (s *Service) PurgeCache(ctx context.Context) (any, apierror.Error) {
 	env := environment.FromContext(ctx)
 
@@ -4,26 +4,25 @@ import (
 	"database/sql"
 	"net/http"
 
-	"clerk/api/middleware"
+	"clerk/api/middleware/pipeline"
 	"clerk/api/sapi/v1/applications"
+	"clerk/api/sapi/v1/backfills"
+	"clerk/api/sapi/v1/debug_logging"
 	"clerk/api/sapi/v1/domains"
 	"clerk/api/sapi/v1/emaildomains"
 	"clerk/api/sapi/v1/environment"
+	"clerk/api/sapi/v1/impersonation_audits"
 	"clerk/api/sapi/v1/instances"
 	"clerk/api/sapi/v1/pricing"
+	"clerk/api/sapi/v1/users"
 	"clerk/pkg/billing"
-	"clerk/pkg/cenv"
 	"clerk/pkg/clerkhttp"
 	"clerk/pkg/handlers"
 	"clerk/utils/clerk"
 
 	sdk "github.com/clerk/clerk-sdk-go/v2"
-	sdkhttp "github.com/clerk/clerk-sdk-go/v2/http"
 	"github.com/clerk/clerk-sdk-go/v2/jwks"
-	sentry "github.com/getsentry/sentry-go/http"
 	"github.com/go-chi/chi/v5"
-	chimw "github.com/go-chi/chi/v5/middleware"
-	chitrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/go-chi/chi.v5"
 )
 
 type Router struct {
@@ -33,12 +32,16 @@ type Router struct {
 	jwksClient        *jwks.Client
 	sdkClientConfig   *sdk.ClientConfig
 
-	applications *applications.HTTP
-	domains      *domains.HTTP
-	emailQuality *emaildomains.HTTP
-	environment  *environment.HTTP
-	instances    *instances.HTTP
-	pricing      *pricing.HTTP
+	applications        *applications.HTTP
+	backfills           *backfills.HTTP
+	debugLogging        *debug_logging.HTTP
+	domains             *domains.HTTP
+	emailQuality        *emaildomains.HTTP
+	environment         *environment.HTTP
+	impersonationAudits *impersonation_audits.HTTP
+	instances           *instances.HTTP
+	pricing             *pricing.HTTP
+	users               *users.HTTP
 }
 
 // NewRouter initializes a new support router.
@@ -56,49 +59,52 @@ func NewRouter(
 		jwksClient:        jwks.NewClient(sdkClientConfig),
 		sdkClientConfig:   sdkClientConfig,
 
-		applications: applications.NewHTTP(deps.DB()),
-		domains:      domains.NewHTTP(deps),
-		emailQuality: emaildomains.NewHTTP(deps),
-		environment:  environment.NewHTTP(deps.DB()),
-		instances:    instances.NewHTTP(deps.DB(), deps.GueClient()),
-		pricing:      pricing.NewHTTP(deps.Clock(), deps.DB(), paymentProvider),
+		applications:        applications.NewHTTP(deps.DB()),
+		backfills:           backfills.NewHTTP(deps),
+		debugLogging:        debug_logging.NewHTTP(deps),
+		domains:             domains.NewHTTP(deps),
+		emailQuality:        emaildomains.NewHTTP(deps),
+		environment:         environment.NewHTTP(deps.DB()),
+		impersonationAudits: impersonation_audits.NewHTTP(deps),
+		instances:           instances.NewHTTP(deps.DB(), deps.GueClient()),
+		pricing:             pricing.NewHTTP(deps.Clock(), deps.DB(), paymentProvider),
+		users:               users.NewHTTP(deps),
 	}
 }
 
 // BuildRoutes builds a router for the dashboard
 func (router Router) BuildRoutes() *chi.Mux {
 	r := chi.NewRouter()
-	r.Use(middleware.Recover)
-
-	if cenv.IsEnabled(cenv.ClerkDatadogTracer) {
-		r.Use(chitrace.Middleware())
-	}
-
-	r.Use(sentry.New(sentry.Options{Repanic: true}).Handle)
-
-	r.Use(middleware.SetTraceID)
-	r.Use(middleware.SetResponseTypeToJSON)
-	r.Use(middleware.Log(func() sql.DBStats {
-		return router.deps.DB().Conn().Stats()
-	}))
-
-	// StripV1 is intentionally mounted after our observability middleware, so that the
-	// true path that the request is being routed to is logged.
-	r.Use(middleware.StripV1)
-
-	r.Use(chimw.StripSlashes)
+	pipeline.Apply(r, pipeline.Policy{
+		DBStats: func() sql.DBStats {
+			return router.deps.DB().Conn().Stats()
+		},
+		StripV1: true,
+	})
 
 	r.Method(http.MethodGet, "/health", router.common.Health())
 	r.Method(http.MethodHead, "/health", router.common.Health())
 
 	r.Route("/", func(r chi.Router) {
 		r.Use(corsHandler(router.authorizedParties))
-		r.Use(sdkhttp.RequireHeaderAuthorization(sdkhttp.JWKSClient(router.jwksClient), sdkhttp.AuthorizedPartyMatches(router.authorizedParties...)))
+		r.Use(pipeline.RequireSession(router.jwksClient, router.authorizedParties))
 
 		r.Route("/applications", func(r chi.Router) {
 			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.applications.GetApplications))
 			r.Method(http.MethodGet, "/{applicationID}", clerkhttp.Handler(router.applications.Read))
 			r.Method(http.MethodPatch, "/{applicationID}", clerkhttp.Handler(router.applications.Update))
+			r.Method(http.MethodGet, "/{applicationID}/users/search", clerkhttp.Handler(router.users.Search))
+		})
+
+		r.Route("/backfills", func(r chi.Router) {
+			r.Method(http.MethodGet, "/", clerkhttp.Handler(router.backfills.List))
+			r.Method(http.MethodPost, "/", clerkhttp.Handler(router.backfills.Start))
+			r.Method(http.MethodGet, "/tasks", clerkhttp.Handler(router.backfills.ListTasks))
+			r.Route("/{backfillID}", func(r chi.Router) {
+				r.Method(http.MethodGet, "/", clerkhttp.Handler(router.backfills.Read))
+				r.Method(http.MethodPost, "/pause", clerkhttp.Handler(router.backfills.Pause))
+				r.Method(http.MethodPost, "/resume", clerkhttp.Handler(router.backfills.Resume))
+			})
 		})
 
 		r.Route("/email_quality", func(r chi.Router) {
@@ -121,9 +127,18 @@ func (router Router) BuildRoutes() *chi.Mux {
 				r.Method(http.MethodPatch, "/user_limits", clerkhttp.Handler(router.instances.UpdateUserLimits))
 				r.Method(http.MethodPatch, "/organization_settings", clerkhttp.Handler(router.instances.UpdateOrganizationSettings))
 				r.Method(http.MethodPatch, "/sms_settings", clerkhttp.Handler(router.instances.UpdateSMSSettings))
+				r.Method(http.MethodPatch, "/maintenance", clerkhttp.Handler(router.instances.UpdateMaintenanceMode))
 				r.Method(http.MethodPost, "/purge_cache", clerkhttp.Handler(router.instances.PurgeCache))
 
+				r.Route("/debug_logging", func(r chi.Router) {
+					r.Method(http.MethodGet, "/", clerkhttp.Handler(router.debugLogging.Read))
+					r.Method(http.MethodPost, "/", clerkhttp.Handler(router.debugLogging.Enable))
+					r.Method(http.MethodDelete, "/", clerkhttp.Handler(router.debugLogging.Disable))
+				})
+
 				r.Method(http.MethodGet, "/domains", clerkhttp.Handler(router.domains.List))
+				r.Method(http.MethodGet, "/impersonation_audits", clerkhttp.Handler(router.impersonationAudits.List))
+				r.Method(http.MethodGet, "/users/{userID}", clerkhttp.Handler(router.users.Read))
 			})
 		})
 
@@ -0,0 +1,87 @@
+package users
+
+import (
+	"net/http"
+	"strconv"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/utils/clerk"
+
+	sdk "github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-chi/chi/v5"
+)
+
+// RevealPIIPermission is the permission of the support organization which
+// allows support members to see the personal information of users in
+// full.
+const RevealPIIPermission = "org:support:reveal_pii"
+
+// SearchUsersPermission is the permission of the support organization which
+// allows support members to search users across all the instances of an
+// application.
+const SearchUsersPermission = "org:support:search_users"
+
+type HTTP struct {
+	service *Service
+}
+
+func NewHTTP(deps clerk.Deps) *HTTP {
+	return &HTTP{
+		service: NewService(deps),
+	}
+}
+
+// GET /instances/{instanceID}/users/{userID}
+func (h *HTTP) Read(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	pii, apiErr := requestedPII(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	return h.service.Read(r.Context(), chi.URLParam(r, "userID"), pii)
+}
+
+// GET /applications/{applicationID}/users/search
+func (h *HTTP) Search(_ http.ResponseWriter, r *http.Request) (any, apierror.Error) {
+	claims, ok := sdk.SessionClaimsFromContext(r.Context())
+	if !ok || !claims.HasPermission(SearchUsersPermission) {
+		return nil, apierror.MissingOrganizationPermission(SearchUsersPermission)
+	}
+
+	pii, apiErr := requestedPII(r)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	params := SearchParams{
+		ApplicationID: chi.URLParam(r, "applicationID"),
+		EmailAddress:  r.URL.Query().Get("email_address"),
+		PhoneNumber:   r.URL.Query().Get("phone_number"),
+		ActorID:       claims.Subject,
+	}
+	return h.service.Search(r.Context(), params, pii)
+}
+
+// requestedPII returns whether the request asked to reveal the personal
+// information of users with the reveal_pii parameter. Only support members
+// with RevealPIIPermission may do so.
+func requestedPII(r *http.Request) (serialize.PII, apierror.Error) {
+	value := r.URL.Query().Get("reveal_pii")
+	if value == "" {
+		return serialize.PIIMasked, nil
+	}
+	reveal, err := strconv.ParseBool(value)
+	if err != nil {
+		return serialize.PIIMasked, apierror.FormInvalidParameterValue("reveal_pii", value)
+	}
+	if !reveal {
+		return serialize.PIIMasked, nil
+	}
+
+	claims, ok := sdk.SessionClaimsFromContext(r.Context())
+	if !ok || !claims.HasPermission(RevealPIIPermission) {
+		return serialize.PIIMasked, apierror.MissingOrganizationPermission(RevealPIIPermission)
+	}
+	return serialize.PIIRevealed, nil
+}
@@ -0,0 +1,147 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/user_search_audits"
+	"clerk/model"
+	usersettings "clerk/pkg/usersettings/clerk"
+)
+
+// maxSearchResults caps the users returned by a search. Identifiers are
+// unique per instance, so more matches than instances are never expected.
+const maxSearchResults = 50
+
+// SearchParams hold the identifier to search the users of an application
+// by. Exactly one of EmailAddress and PhoneNumber must be set.
+type SearchParams struct {
+	ApplicationID string
+	EmailAddress  string
+	PhoneNumber   string
+	// ActorID is the support member who made the search, for the audit log.
+	ActorID string
+}
+
+func (p SearchParams) identifier() (string, string, apierror.Error) {
+	emailAddress := strings.ToLower(strings.TrimSpace(p.EmailAddress))
+	phoneNumber := strings.ReplaceAll(strings.TrimSpace(p.PhoneNumber), " ", "")
+
+	switch {
+	case emailAddress != "" && phoneNumber != "":
+		return "", "", apierror.FormParameterNotAllowedIfAnotherParameterIsPresent("phone_number", "email_address")
+	case emailAddress != "":
+		return "email_address", emailAddress, nil
+	case phoneNumber != "":
+		return "phone_number", phoneNumber, nil
+	default:
+		return "", "", apierror.FormAtLeastOneOptionalParameterMissing("email_address", "phone_number")
+	}
+}
+
+// Search returns the users of all the instances of the application which
+// own the given email address or phone number. Every search is recorded in
+// the user search audits, along with the support member who made it, and
+// fails if it can't be.
+func (s *Service) Search(ctx context.Context, params SearchParams, pii serialize.PII) ([]*serialize.UserSearchResultResponse, apierror.Error) {
+	identifierType, identifier, apiErr := params.identifier()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	application, err := s.applicationRepo.QueryByID(ctx, s.db, params.ApplicationID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if application == nil {
+		return nil, apierror.ApplicationNotFound(params.ApplicationID)
+	}
+
+	instances, err := s.instanceRepo.FindAllByApplication(ctx, s.db, application.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	instancesByID := make(map[string]*model.Instance, len(instances))
+	instanceIDs := make([]string, len(instances))
+	for i, instance := range instances {
+		instancesByID[instance.ID] = instance
+		instanceIDs[i] = instance.ID
+	}
+
+	identifications, err := s.identificationRepo.FindAllClaimedByInstancesAndIdentifier(ctx, s.db, instanceIDs, identifier, maxSearchResults)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	// users are loaded and serialized per instance, as every instance has
+	// its own user settings
+	userIDsByInstance := make(map[string][]string)
+	for _, identification := range identifications {
+		userIDsByInstance[identification.InstanceID] = append(userIDsByInstance[identification.InstanceID], identification.UserID.String)
+	}
+	usersByID := make(map[string]*model.UserSerializable, len(identifications))
+	for instanceID, userIDs := range userIDsByInstance {
+		users, err := s.searchInstanceUsers(ctx, instancesByID[instanceID], userIDs)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		for _, user := range users {
+			usersByID[user.ID] = user
+		}
+	}
+
+	// results are in the order of the identifications
+	results := make([]*serialize.UserSearchResultResponse, 0, len(identifications))
+	for _, identification := range identifications {
+		user, ok := usersByID[identification.UserID.String]
+		if !ok {
+			continue
+		}
+		results = append(results, serialize.UserSearchResultToAdminAPI(ctx, instancesByID[identification.InstanceID], user, pii))
+	}
+
+	maskedIdentifier := serialize.MaskEmailAddress(identifier)
+	if identifierType == "phone_number" {
+		maskedIdentifier = serialize.MaskPhoneNumber(identifier)
+	}
+	err = s.userSearchAuditService.Record(ctx, s.db, user_search_audits.RecordParams{
+		ActorID:          params.ActorID,
+		ApplicationID:    application.ID,
+		IdentifierType:   identifierType,
+		MaskedIdentifier: maskedIdentifier,
+		PIIRevealed:      pii == serialize.PIIRevealed,
+		ResultCount:      len(results),
+	})
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return results, nil
+}
+
+// searchInstanceUsers returns the given users of the instance, serialized
+// with the user settings of the instance.
+func (s *Service) searchInstanceUsers(ctx context.Context, instance *model.Instance, userIDs []string) ([]*model.UserSerializable, error) {
+	users, err := s.userRepo.FindAllByInstanceAndIDs(ctx, s.db, instance.ID, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("sapi/users: fetching users of instance %s: %w", instance.ID, err)
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+
+	authConfig, err := s.authConfigRepo.FindByID(ctx, s.db, instance.ActiveAuthConfigID)
+	if err != nil {
+		return nil, fmt.Errorf("sapi/users: fetching auth config of instance %s: %w", instance.ID, err)
+	}
+	userSettings := usersettings.NewUserSettings(authConfig.UserSettings)
+
+	serializables, err := s.serializableService.ConvertUsers(ctx, s.db, userSettings, users)
+	if err != nil {
+		return nil, fmt.Errorf("sapi/users: serializing users of instance %s: %w", instance.ID, err)
+	}
+	return serializables, nil
+}
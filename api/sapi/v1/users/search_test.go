@@ -0,0 +1,27 @@
+package users
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchParamsIdentifier(t *testing.T) {
+	t.Parallel()
+
+	identifierType, identifier, apiErr := SearchParams{EmailAddress: " Jane@Example.com "}.identifier()
+	require.Nil(t, apiErr)
+	assert.Equal(t, "email_address", identifierType)
+	assert.Equal(t, "jane@example.com", identifier)
+
+	identifierType, identifier, apiErr = SearchParams{PhoneNumber: "+1 555 555 0199"}.identifier()
+	require.Nil(t, apiErr)
+	assert.Equal(t, "phone_number", identifierType)
+	assert.Equal(t, "+15555550199", identifier)
+
+	_, _, apiErr = SearchParams{}.identifier()
+	assert.NotNil(t, apiErr)
+	_, _, apiErr = SearchParams{EmailAddress: "jane@example.com", PhoneNumber: "+15555550199"}.identifier()
+	assert.NotNil(t, apiErr)
+}
@@ -0,0 +1,62 @@
+package users
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/serializable"
+	"clerk/api/shared/user_search_audits"
+	"clerk/pkg/ctx/environment"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type Service struct {
+	db database.Database
+
+	serializableService    *serializable.Service
+	userSearchAuditService *user_search_audits.Service
+
+	applicationRepo    *repository.Applications
+	authConfigRepo     *repository.AuthConfig
+	identificationRepo *repository.Identification
+	instanceRepo       *repository.Instances
+	userRepo           *repository.Users
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		db:                     deps.DB(),
+		serializableService:    serializable.NewService(deps.Clock()),
+		userSearchAuditService: user_search_audits.NewService(deps.Clock()),
+		applicationRepo:        deps.Repositories().Applications,
+		authConfigRepo:         deps.Repositories().AuthConfig,
+		identificationRepo:     deps.Repositories().Identification,
+		instanceRepo:           deps.Repositories().Instances,
+		userRepo:               deps.Repositories().Users,
+	}
+}
+
+// Read returns the user of the instance, with their personal information
+// masked unless pii is serialize.PIIRevealed.
+func (s *Service) Read(ctx context.Context, userID string, pii serialize.PII) (*serialize.UserResponse, apierror.Error) {
+	env := environment.FromContext(ctx)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	user, err := s.userRepo.QueryByIDAndInstance(ctx, s.db, userID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil {
+		return nil, apierror.UserNotFound(userID)
+	}
+
+	userSerializable, err := s.serializableService.ConvertUser(ctx, s.db, userSettings, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.UserToAdminAPI(ctx, userSerializable, pii), nil
+}
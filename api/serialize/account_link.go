@@ -0,0 +1,76 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const AccountLinkObjectName = "account_link"
+
+type AccountLinkResponse struct {
+	Object             string                      `json:"object"`
+	ID                 string                      `json:"id"`
+	Status             string                      `json:"status"`
+	SourceVerification *VerificationResponse       `json:"source_verification"`
+	TargetVerification *VerificationResponse       `json:"target_verification"`
+	Preview            *AccountLinkPreviewResponse `json:"preview"`
+	ExpireAt           int64                       `json:"expire_at"`
+	CreatedAt          int64                       `json:"created_at"`
+	UpdatedAt          int64                       `json:"updated_at"`
+}
+
+type AccountLinkPreviewResponse struct {
+	EmailAddresses         []string `json:"email_addresses"`
+	PhoneNumbers           []string `json:"phone_numbers"`
+	Web3Wallets            []string `json:"web3_wallets"`
+	ExternalAccounts       []string `json:"external_accounts"`
+	OrganizationIDs        []string `json:"organization_ids"`
+	SkippedOrganizationIDs []string `json:"skipped_organization_ids"`
+}
+
+type AccountLinkPreview struct {
+	EmailAddresses         []string
+	PhoneNumbers           []string
+	Web3Wallets            []string
+	ExternalAccounts       []string
+	OrganizationIDs        []string
+	SkippedOrganizationIDs []string
+}
+
+// AccountLink serializes an account link. The preview is only included once
+// both accounts have been verified, so that it cannot be used to enumerate
+// the identifiers of another user.
+func AccountLink(
+	accountLink *model.AccountLink,
+	status string,
+	sourceVerification, targetVerification *model.VerificationWithStatus,
+	preview *AccountLinkPreview,
+) *AccountLinkResponse {
+	response := &AccountLinkResponse{
+		Object:    AccountLinkObjectName,
+		ID:        accountLink.ID,
+		Status:    status,
+		ExpireAt:  time.UnixMilli(accountLink.ExpireAt),
+		CreatedAt: time.UnixMilli(accountLink.CreatedAt),
+		UpdatedAt: time.UnixMilli(accountLink.UpdatedAt),
+	}
+
+	if sourceVerification != nil {
+		response.SourceVerification = Verification(sourceVerification)
+	}
+	if targetVerification != nil {
+		response.TargetVerification = Verification(targetVerification)
+	}
+	if preview != nil {
+		response.Preview = &AccountLinkPreviewResponse{
+			EmailAddresses:         preview.EmailAddresses,
+			PhoneNumbers:           preview.PhoneNumbers,
+			Web3Wallets:            preview.Web3Wallets,
+			ExternalAccounts:       preview.ExternalAccounts,
+			OrganizationIDs:        preview.OrganizationIDs,
+			SkippedOrganizationIDs: preview.SkippedOrganizationIDs,
+		}
+	}
+
+	return response
+}
@@ -18,6 +18,7 @@ type ActorTokenResponse struct {
 	Token     string          `json:"token,omitempty"`
 	URL       string          `json:"url,omitempty"`
 	Status    string          `json:"status"`
+	Reason    *string         `json:"reason"`
 	CreatedAt int64           `json:"created_at"`
 	UpdatedAt int64           `json:"updated_at"`
 }
@@ -31,6 +32,7 @@ func ActorToken(actorToken *model.ActorToken, tokenURL *url.URL, token string) *
 		Token:     token,
 		URL:       tokenURL.String(),
 		Status:    actorToken.Status,
+		Reason:    actorToken.Reason.Ptr(),
 		CreatedAt: time.UnixMilli(actorToken.CreatedAt),
 		UpdatedAt: time.UnixMilli(actorToken.UpdatedAt),
 	}
@@ -0,0 +1,71 @@
+package serialize
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// PII decides whether the ...ToAdminAPI serializers, which are used by the
+// support API, reveal personal information in full. Values are masked
+// unless the support member has been granted access to them, and callers
+// must always state which one they need.
+type PII bool
+
+const (
+	PIIMasked   PII = false
+	PIIRevealed PII = true
+)
+
+// piiMask replaces the hidden part of masked values.
+const piiMask = "***"
+
+// MaskEmailAddress keeps the first character of the local part and the
+// domain of the email address, e.g. "j***@example.com".
+func MaskEmailAddress(emailAddress string) string {
+	at := strings.LastIndex(emailAddress, "@")
+	if at < 0 {
+		return maskValue(emailAddress)
+	}
+	return maskValue(emailAddress[:at]) + emailAddress[at:]
+}
+
+// MaskPhoneNumber keeps the leading plus sign and the last four digits of
+// the phone number, e.g. "+***0199".
+func MaskPhoneNumber(phoneNumber string) string {
+	const visibleDigits = 4
+	prefix := ""
+	if strings.HasPrefix(phoneNumber, "+") {
+		prefix, phoneNumber = "+", phoneNumber[1:]
+	}
+	if len(phoneNumber) <= visibleDigits {
+		return prefix + piiMask
+	}
+	return prefix + piiMask + phoneNumber[len(phoneNumber)-visibleDigits:]
+}
+
+// MaskWeb3Wallet keeps the first and last four characters of the wallet
+// address, which is enough to tell wallets apart, e.g. "0x12***abcd".
+func MaskWeb3Wallet(web3Wallet string) string {
+	const visibleChars = 4
+	if len(web3Wallet) <= 2*visibleChars {
+		return piiMask
+	}
+	return web3Wallet[:visibleChars] + piiMask + web3Wallet[len(web3Wallet)-visibleChars:]
+}
+
+// maskValue keeps only the first character of the value, e.g. "J***".
+func maskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	first, _ := utf8.DecodeRuneInString(value)
+	return string(first) + piiMask
+}
+
+func maskOptionalValue(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	masked := maskValue(*value)
+	return &masked
+}
@@ -0,0 +1,27 @@
+package serialize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskEmailAddress(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "j***@example.com", MaskEmailAddress("jane.doe@example.com"))
+	assert.Equal(t, "Ü***@example.com", MaskEmailAddress("Ülrich@example.com"))
+	assert.Equal(t, "n***", MaskEmailAddress("not-an-email"))
+}
+
+func TestMaskPhoneNumber(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "+***0199", MaskPhoneNumber("+15555550199"))
+	assert.Equal(t, "***0199", MaskPhoneNumber("5555550199"))
+	assert.Equal(t, "+***", MaskPhoneNumber("+123"))
+}
+
+func TestMaskWeb3Wallet(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "0x12***abcd", MaskWeb3Wallet("0x1234567890abcdef1234567890abcdef1234abcd"))
+	assert.Equal(t, "***", MaskWeb3Wallet("0x1234"))
+}
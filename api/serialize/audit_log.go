@@ -0,0 +1,38 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const AuditLogObjectName = "audit_log"
+
+type AuditLogResponse struct {
+	Object       string          `json:"object"`
+	ID           string          `json:"id"`
+	ActorID      string          `json:"actor_id"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	Path         string          `json:"path"`
+	Diff         json.RawMessage `json:"diff"`
+	Request      json.RawMessage `json:"request"`
+	OccurredAt   int64           `json:"occurred_at"`
+	CreatedAt    int64           `json:"created_at"`
+}
+
+func AuditLog(auditLog *model.InstanceAuditLog) *AuditLogResponse {
+	return &AuditLogResponse{
+		Object:       AuditLogObjectName,
+		ID:           auditLog.ID,
+		ActorID:      auditLog.ActorID,
+		Action:       auditLog.Action,
+		ResourceType: auditLog.ResourceType,
+		Path:         auditLog.Path,
+		Diff:         json.RawMessage(auditLog.Diff),
+		Request:      json.RawMessage(auditLog.Request),
+		OccurredAt:   time.UnixMilli(auditLog.OccurredAt),
+		CreatedAt:    time.UnixMilli(auditLog.CreatedAt),
+	}
+}
@@ -31,6 +31,10 @@ type AuthConfigResponse struct {
 	EnhancedEmailDeliverability        bool       `json:"enhanced_email_deliverability"`
 	TestMode                           bool       `json:"test_mode"`
 
+	// TrustedDevices is true if users can trust their devices to skip the
+	// second factor of subsequent sign-ins.
+	TrustedDevices bool `json:"trusted_devices"`
+
 	// CookielessDev is true if this is a development instance and should
 	// operate without cookies.
 	//
@@ -84,6 +88,7 @@ func AuthConfig(ac *model.AuthConfig, userSettings *usersettings.UserSettings, c
 		SingleSessionMode:                  ac.SessionSettings.SingleSessionMode,
 		EnhancedEmailDeliverability:        comm.EnhancedEmailDeliverability,
 		TestMode:                           ac.TestMode,
+		TrustedDevices:                     ac.UserSettings.SignIn.TrustedDevices.Enabled,
 		CookielessDev:                      ac.SessionSettings.URLBasedSessionSyncing,
 		URLBasedSessionSyncing:             ac.SessionSettings.URLBasedSessionSyncing,
 	}
@@ -107,6 +112,9 @@ type AuthConfigResponseServer struct {
 	ProgressiveSignUp           bool   `json:"progressive_sign_up"`
 	TestMode                    bool   `json:"test_mode"`
 	EnhancedEmailDeliverability bool   `json:"enhanced_email_deliverability"`
+	ReuseIdPEmailVerification   bool   `json:"reuse_idp_email_verification"`
+	TrustedDevices              bool   `json:"trusted_devices"`
+	TrustedDeviceLifetimeInDays int    `json:"trusted_device_lifetime_in_days"`
 }
 
 func AuthConfigToServerAPI(ac *model.AuthConfig, ins *model.Instance) *AuthConfigResponseServer {
@@ -117,6 +125,9 @@ func AuthConfigToServerAPI(ac *model.AuthConfig, ins *model.Instance) *AuthConfi
 		ProgressiveSignUp:           ac.UserSettings.SignUp.Progressive,
 		TestMode:                    ac.TestMode,
 		EnhancedEmailDeliverability: ins.Communication.EnhancedEmailDeliverability,
+		ReuseIdPEmailVerification:   ac.UserSettings.SignIn.ReuseIdPEmailVerification,
+		TrustedDevices:              ac.UserSettings.SignIn.TrustedDevices.Enabled,
+		TrustedDeviceLifetimeInDays: ac.UserSettings.SignIn.TrustedDevices.LifetimeInDays,
 	}
 }
 
@@ -8,19 +8,33 @@ import (
 const BackupCodeObjectName = "backup_code"
 
 type BackupCodeResponse struct {
-	Object    string   `json:"object"`
-	ID        string   `json:"id"`
-	Codes     []string `json:"codes"`
-	CreatedAt int64    `json:"created_at"`
-	UpdatedAt int64    `json:"updated_at"`
+	Object string `json:"object"`
+	ID     string `json:"id"`
+	// Codes are only included right after they're generated, since only
+	// their hashes are stored.
+	Codes            []string `json:"codes,omitempty"`
+	UsedCount        int      `json:"used_count"`
+	RemainingCount   int      `json:"remaining_count"`
+	RegeneratedCount int      `json:"regenerated_count"`
+	LastUsedAt       *int64   `json:"last_used_at"`
+	CreatedAt        int64    `json:"created_at"`
+	UpdatedAt        int64    `json:"updated_at"`
 }
 
 func BackupCode(bc *model.BackupCode, plainCodes []string) *BackupCodeResponse {
-	return &BackupCodeResponse{
-		Object:    BackupCodeObjectName,
-		ID:        bc.ID,
-		Codes:     plainCodes,
-		CreatedAt: time.UnixMilli(bc.CreatedAt),
-		UpdatedAt: time.UnixMilli(bc.UpdatedAt),
+	response := &BackupCodeResponse{
+		Object:           BackupCodeObjectName,
+		ID:               bc.ID,
+		Codes:            plainCodes,
+		UsedCount:        bc.UsedCount,
+		RemainingCount:   len(bc.Codes),
+		RegeneratedCount: bc.RegeneratedCount,
+		CreatedAt:        time.UnixMilli(bc.CreatedAt),
+		UpdatedAt:        time.UnixMilli(bc.UpdatedAt),
 	}
+	if bc.LastUsedAt.Valid {
+		lastUsedAt := time.UnixMilli(bc.LastUsedAt.Time)
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
 }
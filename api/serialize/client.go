@@ -19,6 +19,7 @@ type ClientResponseServerAPI struct {
 	SignInID            *string                  `json:"sign_in_id"`
 	SignUpID            *string                  `json:"sign_up_id"`
 	LastActiveSessionID *string                  `json:"last_active_session_id"`
+	AttestationStatus   *string                  `json:"attestation_status"`
 	CreatedAt           int64                    `json:"created_at"`
 	UpdatedAt           int64                    `json:"updated_at"`
 }
@@ -64,6 +65,10 @@ func ClientToServerAPI(clock clockwork.Clock, client *model.ClientWithSessionsFo
 		response.SignUpID = &client.SignUpID.String
 	}
 
+	if client.AttestationStatus.Valid {
+		response.AttestationStatus = &client.AttestationStatus.String
+	}
+
 	return &response
 }
 
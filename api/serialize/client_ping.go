@@ -0,0 +1,58 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// ObjectClientPing is the name for the lightweight client objects returned
+// to heartbeats.
+const ObjectClientPing = "client_ping"
+
+// ClientPingResponse is a client with only the status and lifetime of its
+// sessions. Unlike ClientResponseClientAPI, it leaves out the users of the
+// sessions and their organization memberships, which are what makes
+// serializing a full client expensive.
+type ClientPingResponse struct {
+	Object              string                 `json:"object"`
+	ID                  string                 `json:"id"`
+	LastActiveSessionID *string                `json:"last_active_session_id"`
+	Sessions            []*SessionPingResponse `json:"sessions"`
+	UpdatedAt           int64                  `json:"updated_at"`
+}
+
+type SessionPingResponse struct {
+	Object       string `json:"object"`
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	ExpireAt     int64  `json:"expire_at"`
+	AbandonAt    int64  `json:"abandon_at"`
+	LastActiveAt int64  `json:"last_active_at"`
+}
+
+func ClientPing(clock clockwork.Clock, client *model.Client, sessions []*model.Session, lastActiveSession *model.Session) *ClientPingResponse {
+	response := &ClientPingResponse{
+		Object:    ObjectClientPing,
+		ID:        client.ID,
+		Sessions:  make([]*SessionPingResponse, len(sessions)),
+		UpdatedAt: time.UnixMilli(client.UpdatedAt),
+	}
+
+	for i, session := range sessions {
+		response.Sessions[i] = &SessionPingResponse{
+			Object:       "session",
+			ID:           session.ID,
+			Status:       session.GetStatus(clock),
+			ExpireAt:     time.UnixMilli(session.ExpireAt),
+			AbandonAt:    time.UnixMilli(session.AbandonAt),
+			LastActiveAt: time.UnixMilli(session.TouchedAt),
+		}
+	}
+	if lastActiveSession != nil {
+		response.LastActiveSessionID = &lastActiveSession.ID
+	}
+
+	return response
+}
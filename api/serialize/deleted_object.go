@@ -5,6 +5,8 @@ type DeletedObjectResponse struct {
 	Slug    string `json:"slug,omitempty"`
 	Object  string `json:"object"`
 	Deleted bool   `json:"deleted"`
+	// PurgeAt is set for objects which can be restored until then
+	PurgeAt *int64 `json:"purge_at,omitempty"`
 }
 
 func DeletedObject(id, object string) *DeletedObjectResponse {
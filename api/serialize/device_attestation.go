@@ -0,0 +1,21 @@
+package serialize
+
+import (
+	"time"
+)
+
+const DeviceAttestationChallengeObjectName = "device_attestation_challenge"
+
+type DeviceAttestationChallengeResponse struct {
+	Object    string `json:"object"`
+	Challenge string `json:"challenge"`
+	ExpireAt  int64  `json:"expire_at"`
+}
+
+func DeviceAttestationChallenge(challenge string, expireAt time.Time) *DeviceAttestationChallengeResponse {
+	return &DeviceAttestationChallengeResponse{
+		Object:    DeviceAttestationChallengeObjectName,
+		Challenge: challenge,
+		ExpireAt:  expireAt.UTC().UnixMilli(),
+	}
+}
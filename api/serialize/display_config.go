@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"clerk/model"
+	"clerk/pkg/botdetection"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/externalapis/clerkimages"
@@ -52,6 +53,11 @@ type DisplayConfigResponse struct {
 	// 'smart' widget.
 	CaptchaPublicKeyInvisible *string `json:"captcha_public_key_invisible"`
 
+	// The bot detector that scores sign-ups, if enabled, and the hCaptcha
+	// sitekey when that's the one.
+	BotDetectionProvider *string `json:"bot_detection_provider"`
+	HCaptchaPublicKey    *string `json:"hcaptcha_public_key"`
+
 	GoogleOneTapClientID *string `json:"google_one_tap_client_id"`
 
 	HelpURL          *string `json:"help_url"`
@@ -171,7 +177,18 @@ func DisplayConfig(ctx context.Context, params DisplayConfigParams) *DisplayConf
 		res.FaviconImage = Image(params.AppImages.Favicon)
 	}
 
-	if params.Env.AuthConfig.UserSettings.SignUp.CaptchaEnabled {
+	botDetection := params.Env.AuthConfig.UserSettings.AttackProtection.BotDetection
+	if botDetection.Enabled {
+		res.BotDetectionProvider = &botDetection.Provider
+		if botDetection.Provider == botdetection.ProviderHCaptcha {
+			key := cenv.Get(cenv.HCaptchaSiteKey)
+			res.HCaptchaPublicKey = &key
+		}
+	}
+
+	// Turnstile widgets are rendered for the Turnstile detector too, and for
+	// the challenges of the heuristic one
+	if params.Env.AuthConfig.UserSettings.SignUp.CaptchaEnabled || (botDetection.Enabled && botDetection.Provider != botdetection.ProviderHCaptcha) {
 		key, err := usersettings.TurnstileSiteKey(params.Env.AuthConfig.UserSettings.SignUp.CaptchaWidgetType)
 		if err != nil {
 			sentryclerk.CaptureException(ctx, err)
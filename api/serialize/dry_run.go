@@ -0,0 +1,21 @@
+package serialize
+
+const ObjectDryRun = "dry_run"
+
+// DryRunResponse describes what a destructive request would have done.
+type DryRunResponse struct {
+	Object string `json:"object"`
+	// Response is what the request would have responded with
+	Response interface{} `json:"response"`
+	// Affected holds the number of resources that would be affected per
+	// resource type, including the ones which are removed in cascade
+	Affected map[string]int64 `json:"affected"`
+}
+
+func DryRun(response interface{}, affected map[string]int64) *DryRunResponse {
+	return &DryRunResponse{
+		Object:   ObjectDryRun,
+		Response: response,
+		Affected: affected,
+	}
+}
@@ -15,6 +15,7 @@ type EnvironmentResponse struct {
 	UserSettings         environmentUserSettings        `json:"user_settings"`
 	OrganizationSettings *organizationSettingsResponse  `json:"organization_settings"`
 	MaintenanceMode      bool                           `json:"maintenance_mode"`
+	MaintenanceBanner    *string                        `json:"maintenance_banner"`
 }
 
 // We need to include the allowed special characters in password settings, so
@@ -64,6 +65,16 @@ func Environment(ctx context.Context, env *model.Env, appImages *model.AppImages
 		}),
 		UserSettings:         userSettings(env),
 		OrganizationSettings: organizationSettings(env),
-		MaintenanceMode:      cenv.IsEnabled(cenv.ClerkMaintenanceMode),
+		MaintenanceMode:      cenv.IsEnabled(cenv.ClerkMaintenanceMode) || env.Instance.MaintenanceMode,
+		MaintenanceBanner:    maintenanceBanner(env.Instance),
 	}
 }
+
+// maintenanceBanner is the message to display while the instance is under
+// maintenance.
+func maintenanceBanner(instance *model.Instance) *string {
+	if !instance.MaintenanceMode {
+		return nil
+	}
+	return instance.MaintenanceBanner.Ptr()
+}
@@ -0,0 +1,32 @@
+package serialize
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestMaintenanceBanner(t *testing.T) {
+	t.Parallel()
+
+	instance := &model.Instance{Instance: &sqbmodel.Instance{
+		MaintenanceMode:   true,
+		MaintenanceBanner: null.StringFrom("Back in 5 minutes"),
+	}}
+	banner := maintenanceBanner(instance)
+	require.NotNil(t, banner)
+	assert.Equal(t, "Back in 5 minutes", *banner)
+
+	// the banner is only displayed while the instance is under maintenance
+	instance.MaintenanceMode = false
+	assert.Nil(t, maintenanceBanner(instance))
+
+	instance.MaintenanceMode = true
+	instance.MaintenanceBanner = null.StringFromPtr(nil)
+	assert.Nil(t, maintenanceBanner(instance))
+}
@@ -0,0 +1,27 @@
+package serialize
+
+import "clerk/api/apierror"
+
+const ErrorCatalogEntryObjectName = "error_catalog_entry"
+
+// ErrorCatalogEntryResponse describes an error the APIs respond with, so
+// that SDKs can map error codes to their own types.
+type ErrorCatalogEntryResponse struct {
+	Object       string `json:"object"`
+	Code         string `json:"code"`
+	HTTPStatus   int    `json:"http_status"`
+	ShortMessage string `json:"message"`
+	LongMessage  string `json:"long_message"`
+	DocURL       string `json:"doc_url"`
+}
+
+func ErrorCatalogEntry(entry apierror.CatalogEntry) *ErrorCatalogEntryResponse {
+	return &ErrorCatalogEntryResponse{
+		Object:       ErrorCatalogEntryObjectName,
+		Code:         entry.Code,
+		HTTPStatus:   entry.HTTPStatus,
+		ShortMessage: entry.ShortMessage,
+		LongMessage:  entry.LongMessage,
+		DocURL:       entry.DocURL(),
+	}
+}
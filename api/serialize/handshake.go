@@ -0,0 +1,17 @@
+package serialize
+
+const ObjectHandshake = "handshake"
+
+// HandshakeResponse is the payload of a verified handshake token, i.e. the
+// cookies the backend which received the token has to set.
+type HandshakeResponse struct {
+	Object    string   `json:"object"`
+	Handshake []string `json:"handshake"`
+}
+
+func Handshake(setCookieDirectives []string) *HandshakeResponse {
+	return &HandshakeResponse{
+		Object:    ObjectHandshake,
+		Handshake: setCookieDirectives,
+	}
+}
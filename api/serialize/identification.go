@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 
+	"clerk/api/shared/phone_profiles"
 	"clerk/model"
 	"clerk/pkg/constants"
 	"clerk/pkg/time"
@@ -38,6 +39,7 @@ type Web3WalletResponse struct {
 	ID           string                `json:"id"`
 	Object       string                `json:"object"`
 	Web3Wallet   string                `json:"web3_wallet"`
+	ChainID      *string               `json:"chain_id"`
 	Verification *VerificationResponse `json:"verification"`
 	CreatedAt    int64                 `json:"created_at"`
 	UpdatedAt    int64                 `json:"updated_at"`
@@ -55,12 +57,14 @@ type PasskeyResponse struct {
 	UpdatedAt    int64                 `json:"updated_at"`
 }
 
-func Identification(ident *model.IdentificationSerializable) (interface{}, error) {
+// Identification serializes the identification according to its type. The
+// options only apply to phone numbers.
+func Identification(ident *model.IdentificationSerializable, options ...PhoneNumberOption) (interface{}, error) {
 	switch ident.Type {
 	case constants.ITEmailAddress:
 		return IdentificationEmailAddress(ident), nil
 	case constants.ITPhoneNumber:
-		return IdentificationPhoneNumber(ident), nil
+		return IdentificationPhoneNumber(ident, options...), nil
 	case constants.ITWeb3Wallet:
 		return IdentificationWeb3Wallet(ident), nil
 	case constants.ITPasskey:
@@ -82,14 +86,14 @@ func emailAddressesForIdentifications(identifications []*model.IdentificationSer
 	return emails
 }
 
-func phoneNumbersForIdentifications(identifications []*model.IdentificationSerializable) []*PhoneNumberResponse {
+func phoneNumbersForIdentifications(identifications []*model.IdentificationSerializable, options ...PhoneNumberOption) []*PhoneNumberResponse {
 	phones := make([]*PhoneNumberResponse, len(identifications))
 
 	sort.Slice(identifications, func(i, j int) bool {
 		return identifications[j].CreatedAt.Before(identifications[i].CreatedAt)
 	})
 	for i, identification := range identifications {
-		phones[i] = IdentificationPhoneNumber(identification)
+		phones[i] = IdentificationPhoneNumber(identification, options...)
 	}
 	return phones
 }
@@ -142,7 +146,17 @@ func IdentificationEmailAddress(ident *model.IdentificationSerializable) *EmailA
 	return response
 }
 
-func IdentificationPhoneNumber(ident *model.IdentificationSerializable) *PhoneNumberResponse {
+type PhoneNumberOption func(*PhoneNumberResponse)
+
+// WithPhoneNumberProfile formats the phone number according to the given
+// phone number profile.
+func WithPhoneNumberProfile(profile phone_profiles.Profile) PhoneNumberOption {
+	return func(response *PhoneNumberResponse) {
+		response.PhoneNumber = profile.FormatNumber(response.PhoneNumber)
+	}
+}
+
+func IdentificationPhoneNumber(ident *model.IdentificationSerializable, options ...PhoneNumberOption) *PhoneNumberResponse {
 	response := &PhoneNumberResponse{
 		ID:                      ident.ID,
 		Object:                  "phone_number",
@@ -165,11 +179,15 @@ func IdentificationPhoneNumber(ident *model.IdentificationSerializable) *PhoneNu
 
 	response.LinkedTo = identificationLinkResponses
 
+	for _, option := range options {
+		option(response)
+	}
+
 	return response
 }
 
-func IdentificationPhoneNumberWithBackupCodes(ident *model.IdentificationSerializable, backupCodes []string) *PhoneNumberResponse {
-	response := IdentificationPhoneNumber(ident)
+func IdentificationPhoneNumberWithBackupCodes(ident *model.IdentificationSerializable, backupCodes []string, options ...PhoneNumberOption) *PhoneNumberResponse {
+	response := IdentificationPhoneNumber(ident, options...)
 	response.BackupCodes = backupCodes
 	return response
 }
@@ -179,6 +197,7 @@ func IdentificationWeb3Wallet(ident *model.IdentificationSerializable) *Web3Wall
 		ID:         ident.ID,
 		Object:     "web3_wallet",
 		Web3Wallet: *ident.Web3Wallet(),
+		ChainID:    ident.ChainID.Ptr(),
 		CreatedAt:  time.UnixMilli(ident.CreatedAt),
 		UpdatedAt:  time.UnixMilli(ident.UpdatedAt),
 	}
@@ -1,6 +1,9 @@
 package serialize
 
 import (
+	"net/http"
+	"time"
+
 	"clerk/model"
 )
 
@@ -25,3 +28,28 @@ func Image(image *model.Image) *ImageResponse {
 		PublicURL: image.GetCDNURL(),
 	}
 }
+
+const ObjectImageUpload = "image_upload"
+
+// ImageUploadResponse describes how to upload an image directly to storage.
+// The image must be sent with a PUT request to the URL, along with the given
+// headers, before the upload expires.
+type ImageUploadResponse struct {
+	Object    string            `json:"object"`
+	ImageID   string            `json:"image_id"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+	ExpireAt  int64             `json:"expire_at"`
+}
+
+func ImageUpload(image *model.Image, uploadURL string, expireAt time.Time) *ImageUploadResponse {
+	return &ImageUploadResponse{
+		Object:    ObjectImageUpload,
+		ImageID:   image.ID,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": image.FileType},
+		ExpireAt:  expireAt.UTC().UnixMilli(),
+	}
+}
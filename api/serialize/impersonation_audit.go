@@ -0,0 +1,49 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const ImpersonationAuditObjectName = "impersonation_audit"
+
+// ImpersonationAuditResponse is the audit record of an impersonation
+// session. EndedAt is when the session was ended, removed or revoked, and
+// is nil while it's active or once it expired at ExpireAt.
+type ImpersonationAuditResponse struct {
+	Object       string          `json:"object"`
+	ID           string          `json:"id"`
+	ActorTokenID string          `json:"actor_token_id"`
+	ActorID      string          `json:"actor_id"`
+	Actor        json.RawMessage `json:"actor"`
+	UserID       string          `json:"user_id"`
+	SessionID    string          `json:"session_id"`
+	Reason       *string         `json:"reason"`
+	StartedAt    int64           `json:"started_at"`
+	ExpireAt     int64           `json:"expire_at"`
+	EndedAt      *int64          `json:"ended_at"`
+	CreatedAt    int64           `json:"created_at"`
+}
+
+func ImpersonationAudit(audit *model.ImpersonationAudit) *ImpersonationAuditResponse {
+	response := &ImpersonationAuditResponse{
+		Object:       ImpersonationAuditObjectName,
+		ID:           audit.ID,
+		ActorTokenID: audit.ActorTokenID,
+		ActorID:      audit.ActorID,
+		Actor:        json.RawMessage(audit.Actor),
+		UserID:       audit.UserID,
+		SessionID:    audit.SessionID,
+		Reason:       audit.Reason.Ptr(),
+		StartedAt:    time.UnixMilli(audit.StartedAt),
+		ExpireAt:     time.UnixMilli(audit.ExpireAt),
+		CreatedAt:    time.UnixMilli(audit.CreatedAt),
+	}
+	if audit.EndedAt.Valid {
+		endedAt := time.UnixMilli(audit.EndedAt.Time)
+		response.EndedAt = &endedAt
+	}
+	return response
+}
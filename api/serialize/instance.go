@@ -3,6 +3,7 @@ package serialize
 import (
 	"context"
 
+	"clerk/api/shared/signing_keys"
 	"clerk/model"
 	"clerk/pkg/constants"
 	"clerk/pkg/time"
@@ -46,7 +47,9 @@ func MinimalInstanceDashboard(ins *model.Instance) *MinimalInstanceDashboardResp
 	}
 }
 
-func DemoDevInstance(instance *model.Instance, domain *model.Domain, keys *model.InstanceKey) *DemoDevInstanceResponse {
+// DemoDevInstance returns the keys of a demo instance, along with the public
+// key of the key it currently signs its tokens with.
+func DemoDevInstance(instance *model.Instance, domain *model.Domain, keys *model.InstanceKey, signingKey signing_keys.Key) *DemoDevInstanceResponse {
 	if !instance.IsDevelopmentOrStaging() {
 		panic("not a dev/stg instance")
 	}
@@ -55,7 +58,7 @@ func DemoDevInstance(instance *model.Instance, domain *model.Domain, keys *model
 		Object:             "demo_dev_instance",
 		FAPIKey:            instance.PublishableKey(domain),
 		BAPIKey:            keys.Secret,
-		JWTVerificationKey: instance.PublicKeyForEdge(),
+		JWTVerificationKey: signingKey.PublicKeyForEdge(),
 		AccountsURL:        domain.AccountsURL(),
 	}
 }
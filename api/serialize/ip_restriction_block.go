@@ -0,0 +1,36 @@
+package serialize
+
+const IPRestrictionBlockObjectName = "ip_restriction_block"
+
+type IPRestrictionBlockResponse struct {
+	Object    string  `json:"object"`
+	RuleID    string  `json:"rule_id"`
+	RuleType  string  `json:"rule_type"`
+	RuleValue string  `json:"rule_value"`
+	Attempt   string  `json:"attempt"`
+	IPAddress string  `json:"ip_address"`
+	ASN       *string `json:"asn"`
+	Country   *string `json:"country"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+// IPRestrictionBlock serializes an attempt which was blocked by an IP
+// restriction rule, along with where the attempt came from.
+func IPRestrictionBlock(ruleID, ruleType, ruleValue, attempt, ipAddress, asn, country string, createdAt int64) *IPRestrictionBlockResponse {
+	response := &IPRestrictionBlockResponse{
+		Object:    IPRestrictionBlockObjectName,
+		RuleID:    ruleID,
+		RuleType:  ruleType,
+		RuleValue: ruleValue,
+		Attempt:   attempt,
+		IPAddress: ipAddress,
+		CreatedAt: createdAt,
+	}
+	if asn != "" {
+		response.ASN = &asn
+	}
+	if country != "" {
+		response.Country = &country
+	}
+	return response
+}
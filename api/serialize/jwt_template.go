@@ -18,6 +18,8 @@ type JWTTemplateResponse struct {
 	AllowedClockSkew int             `json:"allowed_clock_skew"`
 	CustomSigningKey bool            `json:"custom_signing_key" logger:"omit"`
 	SigningAlgorithm string          `json:"signing_algorithm" logger:"omit"`
+	AllowedScopes    []string        `json:"allowed_scopes"`
+	AllowedClaims    []string        `json:"allowed_claims"`
 	CreatedAt        int64           `json:"created_at"`
 	UpdatedAt        int64           `json:"updated_at"`
 }
@@ -31,6 +33,8 @@ func JWTTemplate(t *model.JWTTemplate) *JWTTemplateResponse {
 		Lifetime:         t.Lifetime,
 		AllowedClockSkew: t.ClockSkew,
 		SigningAlgorithm: t.SigningAlgorithm,
+		AllowedScopes:    t.AllowedScopes,
+		AllowedClaims:    t.AllowedClaims,
 		CreatedAt:        time.UnixMilli(t.CreatedAt),
 		UpdatedAt:        time.UnixMilli(t.UpdatedAt),
 	}
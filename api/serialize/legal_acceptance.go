@@ -0,0 +1,26 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const LegalAcceptanceObjectName = "legal_acceptance"
+
+type LegalAcceptanceResponse struct {
+	Object          string `json:"object"`
+	ID              string `json:"id"`
+	UserID          string `json:"user_id"`
+	DocumentVersion string `json:"document_version"`
+	AcceptedAt      int64  `json:"accepted_at"`
+}
+
+func LegalAcceptance(acceptance *model.LegalAcceptance) *LegalAcceptanceResponse {
+	return &LegalAcceptanceResponse{
+		Object:          LegalAcceptanceObjectName,
+		ID:              acceptance.ID,
+		UserID:          acceptance.UserID,
+		DocumentVersion: acceptance.DocumentVersion,
+		AcceptedAt:      time.UnixMilli(acceptance.AcceptedAt),
+	}
+}
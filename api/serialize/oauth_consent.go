@@ -0,0 +1,29 @@
+package serialize
+
+import "clerk/model"
+
+const ObjectOAuthConsent = "oauth_consent"
+
+type OAuthConsentScopeResponse struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+	Granted     bool   `json:"granted"`
+}
+
+type OAuthConsentResponse struct {
+	Object               string                      `json:"object"`
+	ClientID             string                      `json:"client_id"`
+	OAuthApplicationName string                      `json:"oauth_application_name"`
+	Scopes               []OAuthConsentScopeResponse `json:"scopes"`
+	ConsentRequired      bool                        `json:"consent_required"`
+}
+
+func OAuthConsent(oa *model.OAuthApplication, scopes []OAuthConsentScopeResponse, consentRequired bool) *OAuthConsentResponse {
+	return &OAuthConsentResponse{
+		Object:               ObjectOAuthConsent,
+		ClientID:             oa.ClientID,
+		OAuthApplicationName: oa.Name,
+		Scopes:               scopes,
+		ConsentRequired:      consentRequired,
+	}
+}
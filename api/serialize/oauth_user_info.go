@@ -9,6 +9,7 @@ const ObjectOAuthUserInfo = "oauth_user_info"
 
 type OAuthUserInfoResponse struct {
 	Object          string          `json:"object"`
+	Sub             string          `json:"sub"`
 	InstanceID      string          `json:"instance_id"`
 	Email           string          `json:"email"`
 	EmailVerified   bool            `json:"email_verified"`
@@ -26,6 +27,7 @@ type OAuthUserInfoResponse struct {
 func OAuthUserInfo(info model.OAuthUserInfo) *OAuthUserInfoResponse {
 	return &OAuthUserInfoResponse{
 		Object:          ObjectOAuthUserInfo,
+		Sub:             info.UserID,
 		InstanceID:      info.InstanceID,
 		Email:           info.Email,
 		EmailVerified:   info.EmailVerified,
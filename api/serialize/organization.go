@@ -24,6 +24,8 @@ type OrganizationResponse struct {
 	HasImage                bool            `json:"has_image"`
 	MembersCount            *int            `json:"members_count,omitempty"`
 	PendingInvitationsCount *int            `json:"pending_invitations_count,omitempty"`
+	ParentOrganizationID    *string         `json:"parent_organization_id"`
+	ChildrenCount           *int            `json:"children_count,omitempty"`
 	MaxAllowedMemberships   int             `json:"max_allowed_memberships"`
 	AdminDeleteEnabled      bool            `json:"admin_delete_enabled"`
 	PublicMetadata          json.RawMessage `json:"public_metadata" logger:"omit"`
@@ -59,6 +61,7 @@ func Organization(ctx context.Context, org *model.Organization, options ...func(
 		PublicMetadata:        json.RawMessage(org.PublicMetadata),
 		MaxAllowedMemberships: org.MaxAllowedMemberships,
 		AdminDeleteEnabled:    org.AdminDeleteEnabled,
+		ParentOrganizationID:  org.ParentOrganizationID.Ptr(),
 		CreatedAt:             time.UnixMilli(org.CreatedAt),
 		UpdatedAt:             time.UnixMilli(org.UpdatedAt),
 	}
@@ -69,6 +72,17 @@ func Organization(ctx context.Context, org *model.Organization, options ...func(
 	return response
 }
 
+// DeletedOrganization is the response for a deleted organization, which
+// also carries the time it will be purged at, if it can still be restored.
+func DeletedOrganization(org *model.Organization) *DeletedObjectResponse {
+	response := DeletedObject(org.ID, ObjectOrganization)
+	if org.PurgeAt.Valid {
+		purgeAt := time.UnixMilli(org.PurgeAt.Time)
+		response.PurgeAt = &purgeAt
+	}
+	return response
+}
+
 // OrganizationBAPI returns the default serialization object for
 // the provided model.Organization, adding attributes that make
 // sense only to the backend API.
@@ -91,6 +105,12 @@ func WithPendingInvitationsCount(count int) func(*OrganizationResponse) {
 	}
 }
 
+func WithChildrenCount(count int) func(*OrganizationResponse) {
+	return func(response *OrganizationResponse) {
+		response.ChildrenCount = &count
+	}
+}
+
 func WithBillingPlan(planKey *string) func(*OrganizationResponse) {
 	return func(response *OrganizationResponse) {
 		response.BillingPlan = planKey
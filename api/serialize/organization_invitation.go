@@ -20,6 +20,7 @@ type OrganizationInvitationResponse struct {
 	Status                 string                          `json:"status,omitempty"`
 	PublicMetadata         json.RawMessage                 `json:"public_metadata" logger:"omit"`
 	PrivateMetadata        json.RawMessage                 `json:"private_metadata,omitempty" logger:"omit"`
+	ExpiresAt              *int64                          `json:"expires_at"`
 	CreatedAt              int64                           `json:"created_at"`
 	UpdatedAt              int64                           `json:"updated_at"`
 }
@@ -36,6 +37,11 @@ func OrganizationInvitation(invitation *model.OrganizationInvitationSerializable
 		UpdatedAt:      time.UnixMilli(invitation.UpdatedAt),
 	}
 
+	if invitation.ExpiresAt.Valid {
+		expiresAt := time.UnixMilli(invitation.ExpiresAt.Time)
+		response.ExpiresAt = &expiresAt
+	}
+
 	// For non-pending invitations, role might not exist as someone is able to delete it
 	if invitation.Role != nil {
 		response.Role = invitation.Role.Key
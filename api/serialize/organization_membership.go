@@ -33,15 +33,19 @@ type OrganizationMembershipResponse struct {
 }
 
 // OrganizationMembership converts a model.OrganizationMembership to
-// an OrganizationMembershipResponse.
+// an OrganizationMembershipResponse. The member and pending invitation
+// counts of the organization are omitted if they weren't loaded.
 func OrganizationMembership(ctx context.Context, membership *model.OrganizationMembershipSerializable) *OrganizationMembershipResponse {
+	options := []func(*OrganizationResponse){WithBillingPlan(membership.BillingPlan)}
+	if membership.MembersCount != nil {
+		options = append(options, WithMembersCount(*membership.MembersCount))
+	}
+	if membership.PendingInvitationsCount != nil {
+		options = append(options, WithPendingInvitationsCount(*membership.PendingInvitationsCount))
+	}
+
 	response := organizationMembership(membership)
-	response.Organization = Organization(
-		ctx,
-		&membership.Organization,
-		WithMembersCount(membership.MembersCount),
-		WithPendingInvitationsCount(membership.PendingInvitationsCount),
-		WithBillingPlan(membership.BillingPlan))
+	response.Organization = Organization(ctx, &membership.Organization, options...)
 	return response
 }
 
@@ -79,3 +83,25 @@ func organizationMembership(organizationMembership *model.OrganizationMembership
 
 	return &resp
 }
+
+// OrganizationMembershipRoleChangedResponse is the payload of the
+// organizationMembership.role_changed event.
+type OrganizationMembershipRoleChangedResponse struct {
+	*OrganizationMembershipResponse
+	PreviousRole *RoleResponse `json:"previous_role"`
+	NewRole      *RoleResponse `json:"new_role"`
+	ActingUserID *string       `json:"acting_user_id"`
+}
+
+func OrganizationMembershipRoleChanged(
+	membership *OrganizationMembershipResponse,
+	previousRole, newRole *RoleResponse,
+	actingUserID *string,
+) *OrganizationMembershipRoleChangedResponse {
+	return &OrganizationMembershipRoleChangedResponse{
+		OrganizationMembershipResponse: membership,
+		PreviousRole:                   previousRole,
+		NewRole:                        newRole,
+		ActingUserID:                   actingUserID,
+	}
+}
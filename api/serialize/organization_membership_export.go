@@ -0,0 +1,52 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectOrganizationMembershipExport is the name for organization
+// membership export objects.
+const ObjectOrganizationMembershipExport = "organization_membership_export"
+
+type OrganizationMembershipExportResponse struct {
+	Object         string  `json:"object"`
+	ID             string  `json:"id"`
+	OrganizationID string  `json:"organization_id"`
+	Status         string  `json:"status"`
+	RowCount       *int    `json:"row_count"`
+	DownloadURL    *string `json:"download_url"`
+	ExpireAt       *int64  `json:"expire_at"`
+	CompletedAt    *int64  `json:"completed_at"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+// OrganizationMembershipExport serializes the given membership export. Like
+// user exports, the download URL is signed for a limited time, so it's only
+// included along with its expiry once the export has completed.
+func OrganizationMembershipExport(export *model.OrganizationMembershipExport, downloadURL string, expireAt int64) *OrganizationMembershipExportResponse {
+	response := &OrganizationMembershipExportResponse{
+		Object:         ObjectOrganizationMembershipExport,
+		ID:             export.ID,
+		OrganizationID: export.OrganizationID,
+		Status:         export.Status,
+		CreatedAt:      time.UnixMilli(export.CreatedAt),
+		UpdatedAt:      time.UnixMilli(export.UpdatedAt),
+	}
+
+	if export.RowCount.Valid {
+		rowCount := export.RowCount.Int
+		response.RowCount = &rowCount
+	}
+	if export.CompletedAt.Valid {
+		completedAt := time.UnixMilli(export.CompletedAt.Time)
+		response.CompletedAt = &completedAt
+	}
+	if downloadURL != "" {
+		response.DownloadURL = &downloadURL
+		response.ExpireAt = &expireAt
+	}
+
+	return response
+}
@@ -0,0 +1,49 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationMembershipRoleChanged(t *testing.T) {
+	t.Parallel()
+
+	membership := &serialize.OrganizationMembershipResponse{Object: "organization_membership", ID: "orgmem_1", Role: "org:admin"}
+	previousRole := serialize.Role(
+		&model.Role{Role: &sqbmodel.Role{ID: "role_1", Key: "org:member"}},
+		model.Permissions{{Permission: &sqbmodel.Permission{ID: "perm_1", Key: "org:sys_profile:read"}}},
+	)
+	newRole := serialize.Role(&model.Role{Role: &sqbmodel.Role{ID: "role_2", Key: "org:admin"}}, nil)
+	actingUserID := "user_admin"
+
+	raw, err := json.Marshal(serialize.OrganizationMembershipRoleChanged(membership, previousRole, newRole, &actingUserID))
+	require.NoError(t, err)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(raw, &payload))
+
+	// the membership fields are inlined next to the roles
+	assert.Equal(t, "orgmem_1", payload["id"])
+	assert.Equal(t, "org:admin", payload["role"])
+	assert.Equal(t, "user_admin", payload["acting_user_id"])
+
+	previous := payload["previous_role"].(map[string]any)
+	assert.Equal(t, "org:member", previous["key"])
+	require.Len(t, previous["permissions"], 1)
+	next := payload["new_role"].(map[string]any)
+	assert.Equal(t, "org:admin", next["key"])
+	assert.Empty(t, next["permissions"])
+
+	// changes made through the backend API have no acting user
+	raw, err = json.Marshal(serialize.OrganizationMembershipRoleChanged(membership, previousRole, newRole, nil))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(raw, &payload))
+	assert.Contains(t, payload, "acting_user_id")
+	assert.Nil(t, payload["acting_user_id"])
+}
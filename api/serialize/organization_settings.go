@@ -7,29 +7,45 @@ import (
 const ObjectOrganizationSettings = "organization_settings"
 
 type OrganizationSettingsResponse struct {
-	Object                 string   `json:"object"`
-	Enabled                bool     `json:"enabled"`
-	MaxAllowedMemberships  int      `json:"max_allowed_memberships"`
-	MaxAllowedRoles        int      `json:"max_allowed_roles"`
-	MaxAllowedPermissions  int      `json:"max_allowed_permissions"`
-	CreatorRole            string   `json:"creator_role"`
-	AdminDeleteEnabled     bool     `json:"admin_delete_enabled"`
-	DomainsEnabled         bool     `json:"domains_enabled"`
-	DomainsEnrollmentModes []string `json:"domains_enrollment_modes"`
-	DomainsDefaultRole     string   `json:"domains_default_role"`
+	Object                            string   `json:"object"`
+	Enabled                           bool     `json:"enabled"`
+	MaxAllowedMemberships             int      `json:"max_allowed_memberships"`
+	MaxAllowedRoles                   int      `json:"max_allowed_roles"`
+	MaxAllowedPermissions             int      `json:"max_allowed_permissions"`
+	CreatorRole                       string   `json:"creator_role"`
+	AdminDeleteEnabled                bool     `json:"admin_delete_enabled"`
+	DomainsEnabled                    bool     `json:"domains_enabled"`
+	DomainsEnrollmentModes            []string `json:"domains_enrollment_modes"`
+	DomainsDefaultRole                string   `json:"domains_default_role"`
+	InvitationsRequireVerifiedEmail   bool     `json:"invitations_require_verified_email"`
+	InvitationsExpireInDays           *int     `json:"invitations_expire_in_days"`
+	DeletionGracePeriodDays           int      `json:"deletion_grace_period_days"`
+	MembersOnlyEnabled                bool     `json:"members_only_enabled"`
+	MembersOnlyAllowedOrganizationIDs []string `json:"members_only_allowed_organization_ids"`
+	HierarchyInheritDomains           bool     `json:"hierarchy_inherit_domains"`
+	HierarchyInheritRoles             bool     `json:"hierarchy_inherit_roles"`
+	HierarchyMembershipVisibility     string   `json:"hierarchy_membership_visibility"`
 }
 
 func OrganizationSettings(settings organizationsettings.OrganizationSettings) *OrganizationSettingsResponse {
 	return &OrganizationSettingsResponse{
-		Object:                 ObjectOrganizationSettings,
-		Enabled:                settings.Enabled,
-		MaxAllowedMemberships:  settings.MaxAllowedMemberships,
-		MaxAllowedRoles:        settings.MaxAllowedRoles,
-		MaxAllowedPermissions:  settings.MaxAllowedPermissions,
-		CreatorRole:            settings.CreatorRole,
-		AdminDeleteEnabled:     settings.Actions.AdminDelete,
-		DomainsEnabled:         settings.Domains.Enabled,
-		DomainsEnrollmentModes: settings.Domains.SortedEnrollmentModes(),
-		DomainsDefaultRole:     settings.Domains.DefaultRole,
+		Object:                            ObjectOrganizationSettings,
+		Enabled:                           settings.Enabled,
+		MaxAllowedMemberships:             settings.MaxAllowedMemberships,
+		MaxAllowedRoles:                   settings.MaxAllowedRoles,
+		MaxAllowedPermissions:             settings.MaxAllowedPermissions,
+		CreatorRole:                       settings.CreatorRole,
+		AdminDeleteEnabled:                settings.Actions.AdminDelete,
+		DomainsEnabled:                    settings.Domains.Enabled,
+		DomainsEnrollmentModes:            settings.Domains.SortedEnrollmentModes(),
+		DomainsDefaultRole:                settings.Domains.DefaultRole,
+		InvitationsRequireVerifiedEmail:   settings.Invitations.RequireVerifiedEmail,
+		InvitationsExpireInDays:           settings.Invitations.ExpiresInDays,
+		DeletionGracePeriodDays:           settings.Deletion.GracePeriodDays,
+		MembersOnlyEnabled:                settings.MembersOnly.Enabled,
+		MembersOnlyAllowedOrganizationIDs: settings.MembersOnly.AllowedOrganizationIDs,
+		HierarchyInheritDomains:           settings.Hierarchy.InheritDomains,
+		HierarchyInheritRoles:             settings.Hierarchy.InheritRoles,
+		HierarchyMembershipVisibility:     settings.Hierarchy.MembershipVisibility,
 	}
 }
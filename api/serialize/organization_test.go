@@ -0,0 +1,32 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestDeletedOrganization(t *testing.T) {
+	t.Parallel()
+
+	// organizations which are purged right away can't be restored
+	org := &model.Organization{Organization: &sqbmodel.Organization{ID: "org_1"}}
+	raw, err := json.Marshal(serialize.DeletedOrganization(org))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"org_1","object":"organization","deleted":true}`, string(raw))
+
+	purgeAt := time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC)
+	org.PurgeAt = null.TimeFrom(purgeAt)
+	response := serialize.DeletedOrganization(org)
+	assert.True(t, response.Deleted)
+	require.NotNil(t, response.PurgeAt)
+	assert.Equal(t, purgeAt.UnixMilli(), *response.PurgeAt)
+}
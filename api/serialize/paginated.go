@@ -3,6 +3,7 @@ package serialize
 type PaginatedResponse struct {
 	Data       []interface{} `json:"data"`
 	TotalCount int64         `json:"total_count"`
+	NextCursor *string       `json:"next_cursor,omitempty"`
 }
 
 func Paginated(data []interface{}, totalCount int64) *PaginatedResponse {
@@ -11,3 +12,13 @@ func Paginated(data []interface{}, totalCount int64) *PaginatedResponse {
 		TotalCount: totalCount,
 	}
 }
+
+// CursorPaginated is the same as Paginated, for results paginated with a
+// cursor. A nil nextCursor means there are no more results.
+func CursorPaginated(data []interface{}, totalCount int64, nextCursor *string) *PaginatedResponse {
+	return &PaginatedResponse{
+		Data:       data,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+	}
+}
@@ -0,0 +1,36 @@
+package serialize
+
+import (
+	"net/url"
+
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const PasswordResetLinkObjectName = "password_reset_link"
+
+type PasswordResetLinkResponse struct {
+	Object    string `json:"object"`
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	EmailSent bool   `json:"email_sent"`
+	ExpireAt  int64  `json:"expire_at"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// PasswordResetLink serializes the sign in token behind a password reset
+// link, along with the ticket and the URL to redeem it.
+func PasswordResetLink(signInToken *model.SignInToken, ticketURL *url.URL, ticket string, expireAt int64, emailSent bool) *PasswordResetLinkResponse {
+	return &PasswordResetLinkResponse{
+		Object:    PasswordResetLinkObjectName,
+		ID:        signInToken.ID,
+		UserID:    signInToken.UserID,
+		Token:     ticket,
+		URL:       ticketURL.String(),
+		EmailSent: emailSent,
+		ExpireAt:  expireAt,
+		CreatedAt: time.UnixMilli(signInToken.CreatedAt),
+	}
+}
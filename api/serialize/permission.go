@@ -8,18 +8,19 @@ import (
 const PermissionObjectName = "permission"
 
 type PermissionResponse struct {
-	Object      string `json:"object"`
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Key         string `json:"key"`
-	Description string `json:"description"`
-	Type        string `json:"type"`
-	CreatedAt   int64  `json:"created_at"`
-	UpdatedAt   int64  `json:"updated_at"`
+	Object       string `json:"object"`
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Key          string `json:"key"`
+	Description  string `json:"description"`
+	Type         string `json:"type"`
+	MembersCount *int   `json:"members_count,omitempty"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
 }
 
-func Permission(permission *model.Permission) *PermissionResponse {
-	return &PermissionResponse{
+func Permission(permission *model.Permission, options ...func(*PermissionResponse)) *PermissionResponse {
+	response := &PermissionResponse{
 		Object:      PermissionObjectName,
 		ID:          permission.ID,
 		Name:        permission.Name,
@@ -29,4 +30,17 @@ func Permission(permission *model.Permission) *PermissionResponse {
 		CreatedAt:   time.UnixMilli(permission.CreatedAt),
 		UpdatedAt:   time.UnixMilli(permission.UpdatedAt),
 	}
+
+	for _, option := range options {
+		option(response)
+	}
+	return response
+}
+
+// WithPermissionMembersCount sets the number of organization memberships
+// which are granted the permission through their role.
+func WithPermissionMembersCount(count int) func(*PermissionResponse) {
+	return func(response *PermissionResponse) {
+		response.MembersCount = &count
+	}
 }
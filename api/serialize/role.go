@@ -16,11 +16,12 @@ type RoleResponse struct {
 	Description       string                `json:"description"`
 	Permissions       []*PermissionResponse `json:"permissions"`
 	IsCreatorEligible bool                  `json:"is_creator_eligible"`
+	MembersCount      *int                  `json:"members_count,omitempty"`
 	CreatedAt         int64                 `json:"created_at"`
 	UpdatedAt         int64                 `json:"updated_at"`
 }
 
-func Role(role *model.Role, permissions model.Permissions) *RoleResponse {
+func Role(role *model.Role, permissions model.Permissions, options ...func(*RoleResponse)) *RoleResponse {
 	response := &RoleResponse{
 		Object:            RoleObjectName,
 		ID:                role.ID,
@@ -37,5 +38,17 @@ func Role(role *model.Role, permissions model.Permissions) *RoleResponse {
 		response.Permissions = append(response.Permissions, Permission(permission))
 	}
 
+	for _, option := range options {
+		option(response)
+	}
+
 	return response
 }
+
+// WithRoleMembersCount sets the number of organization memberships which
+// are assigned the role.
+func WithRoleMembersCount(count int) func(*RoleResponse) {
+	return func(response *RoleResponse) {
+		response.MembersCount = &count
+	}
+}
@@ -0,0 +1,56 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleMembersCount(t *testing.T) {
+	t.Parallel()
+
+	role := &model.Role{Role: &sqbmodel.Role{ID: "role_1", Key: "org:member"}}
+	permissions := model.Permissions{{Permission: &sqbmodel.Permission{ID: "perm_1", Key: "org:sys_profile:read"}}}
+
+	// the count is only included when requested
+	raw, err := json.Marshal(serialize.Role(role, permissions))
+	require.NoError(t, err)
+	assert.NotContains(t, decodeResponse(t, raw), "members_count")
+
+	// roles nobody is assigned report zero members, rather than none
+	raw, err = json.Marshal(serialize.Role(role, permissions, serialize.WithRoleMembersCount(0)))
+	require.NoError(t, err)
+	response := decodeResponse(t, raw)
+	assert.EqualValues(t, 0, response["members_count"])
+
+	// the permissions of the role don't carry a count of their own
+	permission := response["permissions"].([]any)[0].(map[string]any)
+	assert.NotContains(t, permission, "members_count")
+}
+
+func TestPermissionMembersCount(t *testing.T) {
+	t.Parallel()
+
+	permission := &model.Permission{Permission: &sqbmodel.Permission{ID: "perm_1", Key: "org:sys_profile:read"}}
+
+	raw, err := json.Marshal(serialize.Permission(permission))
+	require.NoError(t, err)
+	assert.NotContains(t, decodeResponse(t, raw), "members_count")
+
+	raw, err = json.Marshal(serialize.Permission(permission, serialize.WithPermissionMembersCount(3)))
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, decodeResponse(t, raw)["members_count"])
+}
+
+func decodeResponse(t *testing.T, raw []byte) map[string]any {
+	t.Helper()
+	var response map[string]any
+	require.NoError(t, json.Unmarshal(raw, &response))
+	return response
+}
@@ -0,0 +1,206 @@
+package serialize
+
+import (
+	"strconv"
+	"time"
+)
+
+// Schema URNs of the SCIM 2.0 resources and messages, see RFC 7643 and
+// RFC 7644.
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+const (
+	SCIMResourceTypeUser  = "User"
+	SCIMResourceTypeGroup = "Group"
+)
+
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location"`
+}
+
+type SCIMName struct {
+	GivenName  *string `json:"givenName,omitempty"`
+	FamilyName *string `json:"familyName,omitempty"`
+}
+
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary"`
+}
+
+type SCIMUserResponse struct {
+	Schemas    []string    `json:"schemas"`
+	ID         string      `json:"id"`
+	ExternalID *string     `json:"externalId,omitempty"`
+	UserName   string      `json:"userName"`
+	Name       SCIMName    `json:"name"`
+	Emails     []SCIMEmail `json:"emails"`
+	Active     bool        `json:"active"`
+	Meta       SCIMMeta    `json:"meta"`
+}
+
+// SCIMUser converts the server API payload of a user to a SCIM User
+// resource. The user name is the username of the user, or their primary
+// email address for instances without usernames.
+func SCIMUser(user *UserResponse, location string) *SCIMUserResponse {
+	response := &SCIMUserResponse{
+		Schemas:    []string{SCIMSchemaUser},
+		ID:         user.ID,
+		ExternalID: user.ExternalID,
+		Name: SCIMName{
+			GivenName:  user.FirstName,
+			FamilyName: user.LastName,
+		},
+		Emails: make([]SCIMEmail, len(user.EmailAddresses)),
+		Active: !user.Banned,
+		Meta: SCIMMeta{
+			ResourceType: SCIMResourceTypeUser,
+			Created:      scimTime(user.CreatedAt),
+			LastModified: scimTime(user.UpdatedAt),
+			Location:     location,
+		},
+	}
+
+	for i, email := range user.EmailAddresses {
+		primary := user.PrimaryEmailAddressID != nil && *user.PrimaryEmailAddressID == email.ID
+		response.Emails[i] = SCIMEmail{
+			Value:   email.EmailAddress,
+			Type:    "work",
+			Primary: primary,
+		}
+		if primary && user.Username == nil {
+			response.UserName = email.EmailAddress
+		}
+	}
+	if user.Username != nil {
+		response.UserName = *user.Username
+	}
+
+	return response
+}
+
+type SCIMGroupMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type SCIMGroupResponse struct {
+	Schemas     []string          `json:"schemas"`
+	ID          string            `json:"id"`
+	DisplayName string            `json:"displayName"`
+	Members     []SCIMGroupMember `json:"members"`
+	Meta        SCIMMeta          `json:"meta"`
+}
+
+// SCIMGroup converts the server API payload of an organization and its
+// members to a SCIM Group resource.
+func SCIMGroup(org *OrganizationResponse, members []SCIMGroupMember, location string) *SCIMGroupResponse {
+	if members == nil {
+		members = make([]SCIMGroupMember, 0)
+	}
+	return &SCIMGroupResponse{
+		Schemas:     []string{SCIMSchemaGroup},
+		ID:          org.ID,
+		DisplayName: org.Name,
+		Members:     members,
+		Meta: SCIMMeta{
+			ResourceType: SCIMResourceTypeGroup,
+			Created:      scimTime(org.CreatedAt),
+			LastModified: scimTime(org.UpdatedAt),
+			Location:     location,
+		},
+	}
+}
+
+type SCIMListResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int64         `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+// SCIMList wraps a page of SCIM resources. The start index is one-based.
+func SCIMList(resources []interface{}, totalResults int64, startIndex int) *SCIMListResponse {
+	return &SCIMListResponse{
+		Schemas:      []string{SCIMSchemaListResponse},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}
+}
+
+type SCIMErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func SCIMError(status int, scimType, detail string) *SCIMErrorResponse {
+	return &SCIMErrorResponse{
+		Schemas:  []string{SCIMSchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	}
+}
+
+type scimSupported struct {
+	Supported bool `json:"supported"`
+}
+
+type scimFilterSupported struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type scimAuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SCIMServiceProviderConfigResponse struct {
+	Schemas               []string                   `json:"schemas"`
+	Patch                 scimSupported              `json:"patch"`
+	Bulk                  scimSupported              `json:"bulk"`
+	Filter                scimFilterSupported        `json:"filter"`
+	ChangePassword        scimSupported              `json:"changePassword"`
+	Sort                  scimSupported              `json:"sort"`
+	ETag                  scimSupported              `json:"etag"`
+	AuthenticationSchemes []scimAuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// SCIMServiceProviderConfig describes the SCIM features which are
+// supported, so that clients can discover them.
+func SCIMServiceProviderConfig(maxResults int) *SCIMServiceProviderConfigResponse {
+	return &SCIMServiceProviderConfigResponse{
+		Schemas: []string{SCIMSchemaServiceProviderConfig},
+		Patch:   scimSupported{Supported: true},
+		Filter:  scimFilterSupported{Supported: true, MaxResults: maxResults},
+		AuthenticationSchemes: []scimAuthenticationScheme{
+			{
+				Type:        "oauthbearertoken",
+				Name:        "Bearer token",
+				Description: "Authentication with the secret key of the instance.",
+			},
+		},
+	}
+}
+
+func scimTime(unixMilli int64) string {
+	return time.UnixMilli(unixMilli).UTC().Format(time.RFC3339)
+}
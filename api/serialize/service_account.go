@@ -0,0 +1,88 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectServiceAccount is the name for service account objects.
+const ObjectServiceAccount = "service_account"
+
+// ServiceAccountResponse is the default serialization representation
+// for a service account object.
+type ServiceAccountResponse struct {
+	Object         string          `json:"object"`
+	ID             string          `json:"id"`
+	InstanceID     string          `json:"instance_id"`
+	Name           string          `json:"name"`
+	ClientID       string          `json:"client_id"`
+	ClientSecret   string          `json:"client_secret,omitempty" logger:"omit"`
+	JWTTemplateID  string          `json:"jwt_template_id"`
+	PublicMetadata json.RawMessage `json:"public_metadata" logger:"omit"`
+	TokenFetchURL  string          `json:"token_fetch_url"`
+	TokensIssued   int64           `json:"tokens_issued"`
+	LastUsedAt     *int64          `json:"last_used_at"`
+	CreatedAt      int64           `json:"created_at"`
+	UpdatedAt      int64           `json:"updated_at"`
+}
+
+// ServiceAccount will return a default serialization object for the provided
+// model.ServiceAccount. The client secret is only included right after it
+// was generated.
+func ServiceAccount(sa *model.ServiceAccount, domain *model.Domain) *ServiceAccountResponse {
+	response := &ServiceAccountResponse{
+		Object:         ObjectServiceAccount,
+		ID:             sa.ID,
+		InstanceID:     sa.InstanceID,
+		Name:           sa.Name,
+		ClientID:       sa.ClientID,
+		ClientSecret:   sa.ClientSecret,
+		JWTTemplateID:  sa.JWTTemplateID,
+		PublicMetadata: json.RawMessage(sa.PublicMetadata),
+		TokenFetchURL:  domain.ServiceAccountTokenURL(),
+		TokensIssued:   sa.TokensIssued,
+		CreatedAt:      time.UnixMilli(sa.CreatedAt),
+		UpdatedAt:      time.UnixMilli(sa.UpdatedAt),
+	}
+
+	if sa.LastUsedAt.Valid {
+		lastUsedAt := time.UnixMilli(sa.LastUsedAt.Time)
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
+
+// ServiceAccountTokenResponse is the OAuth2 access token response for the
+// client credentials grant.
+// https://datatracker.ietf.org/doc/html/rfc6749#section-5.1
+type ServiceAccountTokenResponse struct {
+	AccessToken string `json:"access_token" logger:"omit"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in,omitempty"`
+}
+
+func ServiceAccountToken(accessToken string, expiresIn int) *ServiceAccountTokenResponse {
+	return &ServiceAccountTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   expiresIn,
+	}
+}
+
+// ServiceAccountTokenErrorResponse is the OAuth2 error response of the
+// token endpoint.
+// https://datatracker.ietf.org/doc/html/rfc6749#section-5.2
+type ServiceAccountTokenErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+func ServiceAccountTokenError(code, description string) *ServiceAccountTokenErrorResponse {
+	return &ServiceAccountTokenErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	}
+}
@@ -40,7 +40,9 @@ type publicUserData struct {
 	ProfileImageURL string `json:"profile_image_url"`
 }
 
-type sessionActivityResponse struct {
+const ObjectSessionActivity = "session_activity"
+
+type SessionActivityResponse struct {
 	Object         string  `json:"object"`
 	ID             string  `json:"id"`
 	DeviceType     *string `json:"device_type,omitempty"`
@@ -50,6 +52,26 @@ type sessionActivityResponse struct {
 	IPAddress      *string `json:"ip_address,omitempty"`
 	City           *string `json:"city,omitempty"`
 	Country        *string `json:"country,omitempty"`
+	CreatedAt      int64   `json:"created_at"`
+	UpdatedAt      int64   `json:"updated_at"`
+}
+
+// SessionActivity serializes a single entry of the activity log of a
+// session, i.e. the device and location requests came from.
+func SessionActivity(activity *model.SessionActivity) *SessionActivityResponse {
+	return &SessionActivityResponse{
+		Object:         ObjectSessionActivity,
+		ID:             activity.ID,
+		DeviceType:     activity.DeviceType.Ptr(),
+		IsMobile:       activity.IsMobile,
+		BrowserName:    activity.BrowserName.Ptr(),
+		BrowserVersion: activity.BrowserVersion.Ptr(),
+		IPAddress:      activity.IPAddress.Ptr(),
+		City:           activity.City.Ptr(),
+		Country:        activity.Country.Ptr(),
+		CreatedAt:      time.UnixMilli(activity.CreatedAt),
+		UpdatedAt:      time.UnixMilli(activity.UpdatedAt),
+	}
 }
 
 type SessionServerResponse struct {
@@ -105,17 +127,7 @@ func SessionWithActivityToClientAPI(clock clockwork.Clock, session *model.Sessio
 	resp := sessionToClientAPI(clock, session.Session)
 
 	if session.LatestActivity != nil {
-		resp.LatestActivity = &sessionActivityResponse{
-			Object:         "session_activity",
-			ID:             session.LatestActivity.ID,
-			DeviceType:     session.LatestActivity.DeviceType.Ptr(),
-			IsMobile:       session.LatestActivity.IsMobile,
-			BrowserName:    session.LatestActivity.BrowserName.Ptr(),
-			BrowserVersion: session.LatestActivity.BrowserVersion.Ptr(),
-			IPAddress:      session.LatestActivity.IPAddress.Ptr(),
-			City:           session.LatestActivity.City.Ptr(),
-			Country:        session.LatestActivity.Country.Ptr(),
-		}
+		resp.LatestActivity = SessionActivity(session.LatestActivity)
 	}
 
 	return resp
@@ -1,6 +1,8 @@
 package serialize
 
 import (
+	"clerk/api/shared/flowstate"
+	"clerk/api/shared/legal"
 	"clerk/model"
 	"clerk/pkg/constants"
 	"clerk/pkg/oauth"
@@ -26,6 +28,12 @@ type SignInResponse struct {
 	UserData         *userData `json:"user_data" logger:"omit"`
 	CreatedSessionID *string   `json:"created_session_id"`
 	AbandonAt        int64     `json:"abandon_at"`
+
+	// LegalAcceptanceRequired is true when the user has not accepted the
+	// latest version of the instance's legal documents.
+	LegalAcceptanceRequired bool `json:"legal_acceptance_required"`
+
+	NextAction *flowstate.NextAction `json:"next_action"`
 }
 
 // userData is data that we expose during the sign-in process.
@@ -92,6 +100,10 @@ func SignIn(clock clockwork.Clock, signIn *model.SignInSerializable, userSetting
 		}
 	}
 
+	if signIn.User != nil {
+		signInResponse.LegalAcceptanceRequired = legal.NeedsAcceptance(userSettings, signIn.User.LegalAcceptedVersion)
+	}
+
 	// second factor
 	signInResponse.SupportedSecondFactors = signIn.SecondFactors
 
@@ -104,5 +116,14 @@ func SignIn(clock clockwork.Clock, signIn *model.SignInSerializable, userSetting
 		signInResponse.CreatedSessionID = &signIn.SignIn.CreatedSessionID.String
 	}
 
+	signInResponse.NextAction = flowstate.ForSignIn(flowstate.SignIn{
+		Status:                   status,
+		SupportedIdentifiers:     signInResponse.SupportedIdentifiers,
+		FirstFactors:             signIn.SupportedFirstFactors,
+		SecondFactors:            signIn.SecondFactors,
+		FirstFactorVerification:  signIn.FirstFactorVerification,
+		SecondFactorVerification: signIn.SecondFactorVerification,
+	})
+
 	return &signInResponse, nil
 }
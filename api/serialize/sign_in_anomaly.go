@@ -0,0 +1,49 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/anomaly"
+)
+
+const SignInAnomalyObjectName = "sign_in_anomaly"
+
+type SignInAnomalyResponse struct {
+	Object    string   `json:"object"`
+	SignInID  string   `json:"sign_in_id"`
+	UserID    string   `json:"user_id"`
+	Score     int      `json:"score"`
+	Signals   []string `json:"signals"`
+	Action    string   `json:"action"`
+	IPAddress *string  `json:"ip_address"`
+	City      *string  `json:"city"`
+	Country   *string  `json:"country"`
+	CreatedAt int64    `json:"created_at"`
+}
+
+// SignInAnomaly serializes the risk assessment of a sign-in attempt, along
+// with where the attempt came from.
+func SignInAnomaly(
+	signIn *model.SignIn,
+	userID string,
+	activity *model.SessionActivity,
+	assessment anomaly.Assessment,
+	action anomaly.Action,
+	createdAt int64,
+) *SignInAnomalyResponse {
+	signals := make([]string, len(assessment.Signals))
+	for i, signal := range assessment.Signals {
+		signals[i] = string(signal)
+	}
+	return &SignInAnomalyResponse{
+		Object:    SignInAnomalyObjectName,
+		SignInID:  signIn.ID,
+		UserID:    userID,
+		Score:     assessment.Score,
+		Signals:   signals,
+		Action:    string(action),
+		IPAddress: activity.IPAddress.Ptr(),
+		City:      activity.City.Ptr(),
+		Country:   activity.Country.Ptr(),
+		CreatedAt: createdAt,
+	}
+}
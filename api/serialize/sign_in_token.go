@@ -10,25 +10,41 @@ import (
 const SignInTokenObjectName = "sign_in_token"
 
 type SignInTokenResponse struct {
-	Object    string `json:"object"`
-	ID        string `json:"id"`
-	UserID    string `json:"user_id"`
-	Token     string `json:"token,omitempty"`
-	Status    string `json:"status"`
-	URL       string `json:"url,omitempty"`
-	CreatedAt int64  `json:"created_at"`
-	UpdatedAt int64  `json:"updated_at"`
+	Object      string  `json:"object"`
+	ID          string  `json:"id"`
+	UserID      string  `json:"user_id"`
+	Token       string  `json:"token,omitempty"`
+	Status      string  `json:"status"`
+	URL         string  `json:"url,omitempty"`
+	MaxUses     int     `json:"max_uses"`
+	UsesCount   int     `json:"uses_count"`
+	LastUsedAt  *int64  `json:"last_used_at"`
+	ExpireAt    *int64  `json:"expire_at"`
+	RedirectURL *string `json:"redirect_url"`
+	CreatedAt   int64   `json:"created_at"`
+	UpdatedAt   int64   `json:"updated_at"`
 }
 
 func SignInToken(signInToken *model.SignInToken, ticketURL *url.URL, ticket string) *SignInTokenResponse {
 	res := &SignInTokenResponse{
-		Object:    SignInTokenObjectName,
-		ID:        signInToken.ID,
-		UserID:    signInToken.UserID,
-		Token:     ticket,
-		Status:    signInToken.Status,
-		CreatedAt: time.UnixMilli(signInToken.CreatedAt),
-		UpdatedAt: time.UnixMilli(signInToken.UpdatedAt),
+		Object:      SignInTokenObjectName,
+		ID:          signInToken.ID,
+		UserID:      signInToken.UserID,
+		Token:       ticket,
+		Status:      signInToken.Status,
+		MaxUses:     signInToken.AllowedUses(),
+		UsesCount:   signInToken.UsesCount,
+		RedirectURL: signInToken.RedirectURL.Ptr(),
+		CreatedAt:   time.UnixMilli(signInToken.CreatedAt),
+		UpdatedAt:   time.UnixMilli(signInToken.UpdatedAt),
+	}
+	if signInToken.LastUsedAt.Valid {
+		lastUsedAt := time.UnixMilli(signInToken.LastUsedAt.Time)
+		res.LastUsedAt = &lastUsedAt
+	}
+	if signInToken.ExpireAt.Valid {
+		expireAt := time.UnixMilli(signInToken.ExpireAt.Time)
+		res.ExpireAt = &expireAt
 	}
 	if ticketURL != nil {
 		res.URL = ticketURL.String()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"clerk/api/shared/flowstate"
 	"clerk/model"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/clerkjs_version"
@@ -33,12 +34,15 @@ type SignUpResponse struct {
 	LastName         *string         `json:"last_name"`
 	UnsafeMetadata   json.RawMessage `json:"unsafe_metadata,omitempty" logger:"omit"`
 	PublicMetadata   json.RawMessage `json:"public_metadata,omitempty" logger:"omit"`
+	CustomFields     json.RawMessage `json:"custom_fields,omitempty" logger:"omit"`
 	CustomAction     bool            `json:"custom_action"`
 	ExternalID       *string         `json:"external_id"`
 	CreatedSessionID *string         `json:"created_session_id"`
 	CreatedUserID    *string         `json:"created_user_id"`
 	AbandonAt        int64           `json:"abandon_at"`
 
+	NextAction *flowstate.NextAction `json:"next_action"`
+
 	ExternalAccount interface{} `json:"external_account,omitempty"` // DX: Deprecated >= 3
 }
 
@@ -47,6 +51,7 @@ type SignUpVerifications struct {
 	PhoneNumber  *signUpVerificationResponse `json:"phone_number"`
 	// TODO: Can we use external account verifications for Oauth and web3 instead of having a fixed Web3Wallet field?
 	Web3Wallet      *signUpVerificationResponse `json:"web3_wallet"`
+	Passkey         *signUpVerificationResponse `json:"passkey"`
 	ExternalAccount *VerificationResponse       `json:"external_account"`
 }
 
@@ -95,6 +100,11 @@ func SignUp(ctx context.Context, clock clockwork.Clock, signup *model.SignUpSeri
 		signupResponse.Verifications.Web3Wallet = newSignUpVerificationResponse(verificationResponse)
 	}
 
+	if signup.PasskeyVerification != nil {
+		verificationResponse := Verification(signup.PasskeyVerification)
+		signupResponse.Verifications.Passkey = newSignUpVerificationResponse(verificationResponse)
+	}
+
 	if signup.ExternalAccountVerification != nil {
 		signupResponse.Verifications.ExternalAccount = Verification(signup.ExternalAccountVerification)
 	}
@@ -117,6 +127,21 @@ func SignUp(ctx context.Context, clock clockwork.Clock, signup *model.SignUpSeri
 		signupResponse.PublicMetadata = json.RawMessage(signup.PublicMetadata)
 	}
 
+	if signup.CustomFields != nil {
+		signupResponse.CustomFields = json.RawMessage(signup.CustomFields)
+	}
+
+	signupResponse.NextAction = flowstate.ForSignUp(flowstate.SignUp{
+		Status:           signupResponse.Status,
+		MissingFields:    signup.MissingFields,
+		UnverifiedFields: signup.UnverifiedFields,
+		Verifications: map[string]*model.VerificationWithStatus{
+			constants.ITEmailAddress: signup.EmailAddressVerification,
+			constants.ITPhoneNumber:  signup.PhoneNumberVerification,
+			constants.ITWeb3Wallet:   signup.Web3WalletVerification,
+		},
+	})
+
 	return &signupResponse, nil
 }
 
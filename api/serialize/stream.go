@@ -0,0 +1,79 @@
+package serialize
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"clerk/model"
+)
+
+// ArrayStream writes a JSON array to an io.Writer one element at a time,
+// so that large list responses don't have to be held in memory in their
+// entirety before being written out.
+//
+// Elements are written with Write and the array must be terminated with
+// Close. The resulting payload is the same as the one of encoding the
+// whole slice at once.
+type ArrayStream struct {
+	w       io.Writer
+	enc     *json.Encoder
+	started bool
+	closed  bool
+}
+
+func NewArrayStream(w io.Writer) *ArrayStream {
+	return &ArrayStream{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Started reports whether any bytes have been written to the underlying
+// writer. Once a stream has started, errors can no longer be reported to
+// the client with a different payload.
+func (s *ArrayStream) Started() bool {
+	return s.started
+}
+
+// Write appends v to the array.
+func (s *ArrayStream) Write(v interface{}) error {
+	sep := ","
+	if !s.started {
+		sep = "["
+	}
+	if _, err := io.WriteString(s.w, sep); err != nil {
+		return err
+	}
+	s.started = true
+	return s.enc.Encode(v)
+}
+
+// Close terminates the array. An empty array is written if no elements
+// were written to the stream.
+func (s *ArrayStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	closing := "]"
+	if !s.started {
+		closing = "[]"
+	}
+	s.started = true
+	_, err := io.WriteString(s.w, closing)
+	return err
+}
+
+// UsersToServerAPIStream writes the server API payload of each of the users
+// to the stream. The payload of every user is the same as the one of
+// UserToServerAPIWithOptions.
+func UsersToServerAPIStream(ctx context.Context, stream *ArrayStream, users []*model.UserSerializable, opts UserOptions) error {
+	for _, user := range users {
+		if err := stream.Write(UserToServerAPIWithOptions(ctx, user, opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package serialize_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"clerk/api/serialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrayStream(t *testing.T) {
+	t.Parallel()
+
+	type element struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	for _, elements := range [][]element{
+		{},
+		{{ID: "1", Name: "first"}},
+		{{ID: "1", Name: "first"}, {ID: "2", Name: "<second>"}, {ID: "3"}},
+	} {
+		var buf bytes.Buffer
+		stream := serialize.NewArrayStream(&buf)
+		for _, el := range elements {
+			require.NoError(t, stream.Write(el))
+		}
+		require.NoError(t, stream.Close())
+		assert.True(t, stream.Started())
+
+		want, err := json.Marshal(elements)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(want), buf.String())
+	}
+}
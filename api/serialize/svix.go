@@ -1,5 +1,11 @@
 package serialize
 
+import (
+	"clerk/model"
+	"clerk/pkg/externalapis/svix"
+	"clerk/pkg/time"
+)
+
 type SvixStatusResponse struct {
 	Enabled bool   `json:"enabled"`
 	SvixURL string `json:"svix_url"`
@@ -21,3 +27,116 @@ func SvixURL(url string) *SvixURLResponse {
 		SvixURL: url,
 	}
 }
+
+const (
+	WebhookEndpointObjectName       = "webhook_endpoint"
+	WebhookEndpointSecretObjectName = "webhook_endpoint_secret"
+)
+
+// WebhookEndpointHealth contains the number of recent message deliveries to
+// a webhook endpoint, grouped by outcome.
+type WebhookEndpointHealth struct {
+	Succeeded int
+	Failed    int
+	Pending   int
+}
+
+type WebhookEndpointHealthResponse struct {
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"`
+	Pending     int     `json:"pending"`
+	FailureRate float64 `json:"failure_rate"`
+}
+
+type WebhookEndpointResponse struct {
+	Object         string                        `json:"object"`
+	ID             string                        `json:"id"`
+	OrganizationID *string                       `json:"organization_id,omitempty"`
+	URL            string                        `json:"url"`
+	Description    string                        `json:"description"`
+	EventTypes     []string                      `json:"event_types"`
+	Disabled       bool                          `json:"disabled"`
+	Health         WebhookEndpointHealthResponse `json:"health"`
+	CreatedAt      int64                         `json:"created_at"`
+	UpdatedAt      int64                         `json:"updated_at"`
+}
+
+func WebhookEndpoint(endpoint *svix.Endpoint, health WebhookEndpointHealth) *WebhookEndpointResponse {
+	eventTypes := endpoint.FilterTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return &WebhookEndpointResponse{
+		Object:      WebhookEndpointObjectName,
+		ID:          endpoint.ID,
+		URL:         endpoint.URL,
+		Description: endpoint.Description,
+		EventTypes:  eventTypes,
+		Disabled:    endpoint.Disabled,
+		Health:      webhookEndpointHealth(health),
+		CreatedAt:   time.UnixMilli(endpoint.CreatedAt),
+		UpdatedAt:   time.UnixMilli(endpoint.UpdatedAt),
+	}
+}
+
+// NativeWebhookEndpoint serializes an endpoint of an instance which delivers
+// its webhooks natively, in the same shape as the Svix endpoints.
+func NativeWebhookEndpoint(endpoint *model.WebhookEndpoint, health WebhookEndpointHealth) *WebhookEndpointResponse {
+	eventTypes := []string(endpoint.EventTypes)
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	return &WebhookEndpointResponse{
+		Object:         WebhookEndpointObjectName,
+		ID:             endpoint.ID,
+		OrganizationID: endpoint.OrganizationID.Ptr(),
+		URL:            endpoint.URL,
+		Description:    endpoint.Description,
+		EventTypes:     eventTypes,
+		Disabled:       endpoint.Disabled,
+		Health:         webhookEndpointHealth(health),
+		CreatedAt:      time.UnixMilli(endpoint.CreatedAt),
+		UpdatedAt:      time.UnixMilli(endpoint.UpdatedAt),
+	}
+}
+
+// webhookEndpointHealth computes the failure rate out of the completed
+// deliveries. Pending deliveries are left out, since their outcome is not
+// known yet.
+func webhookEndpointHealth(health WebhookEndpointHealth) WebhookEndpointHealthResponse {
+	response := WebhookEndpointHealthResponse{
+		Succeeded: health.Succeeded,
+		Failed:    health.Failed,
+		Pending:   health.Pending,
+	}
+	if completed := health.Succeeded + health.Failed; completed > 0 {
+		response.FailureRate = float64(health.Failed) / float64(completed)
+	}
+	return response
+}
+
+type WebhookEndpointSecretResponse struct {
+	Object     string `json:"object"`
+	EndpointID string `json:"endpoint_id"`
+	Secret     string `json:"secret"`
+}
+
+func WebhookEndpointSecret(endpointID, secret string) *WebhookEndpointSecretResponse {
+	return &WebhookEndpointSecretResponse{
+		Object:     WebhookEndpointSecretObjectName,
+		EndpointID: endpointID,
+		Secret:     secret,
+	}
+}
+
+type WebhookEventTypesResponse struct {
+	EventTypes []string `json:"event_types"`
+}
+
+func WebhookEventTypes(eventTypes []string) *WebhookEventTypesResponse {
+	return &WebhookEventTypesResponse{
+		EventTypes: eventTypes,
+	}
+}
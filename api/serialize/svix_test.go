@@ -0,0 +1,39 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestNativeWebhookEndpoint(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	endpoint := &model.WebhookEndpoint{WebhookEndpoint: &sqbmodel.WebhookEndpoint{
+		ID:        "whe_1",
+		URL:       "https://example.com/webhooks",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+
+	// endpoints of the instance have no organization
+	raw, err := json.Marshal(serialize.NativeWebhookEndpoint(endpoint, serialize.WebhookEndpointHealth{}))
+	require.NoError(t, err)
+	response := decodeResponse(t, raw)
+	assert.NotContains(t, response, "organization_id")
+	assert.Equal(t, []any{}, response["event_types"])
+
+	endpoint.OrganizationID = null.StringFrom("org_1")
+	raw, err = json.Marshal(serialize.NativeWebhookEndpoint(endpoint, serialize.WebhookEndpointHealth{}))
+	require.NoError(t, err)
+	assert.Equal(t, "org_1", decodeResponse(t, raw)["organization_id"])
+}
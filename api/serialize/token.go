@@ -1,5 +1,7 @@
 package serialize
 
+import "time"
+
 type TokenResponse struct {
 	Object string `json:"object"`
 	JWT    string `json:"jwt" logger:"redact"`
@@ -11,3 +13,36 @@ func Token(jwt string) *TokenResponse {
 		JWT:    jwt,
 	}
 }
+
+type RefreshedTokenResponse struct {
+	*TokenResponse
+	RefreshToken string `json:"refresh_token" logger:"redact"`
+}
+
+// RefreshedToken is the response to a native session refresh. The refresh
+// token replaces the one used for the request, which can't be used again.
+func RefreshedToken(jwt, refreshToken string) *RefreshedTokenResponse {
+	return &RefreshedTokenResponse{
+		TokenResponse: Token(jwt),
+		RefreshToken:  refreshToken,
+	}
+}
+
+// ScopedTokenResponse is a machine-to-machine token, along with the scopes
+// it grants.
+type ScopedTokenResponse struct {
+	*TokenResponse
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expires_at"`
+}
+
+func ScopedToken(jwt string, scopes []string, expiresAt time.Time) *ScopedTokenResponse {
+	return &ScopedTokenResponse{
+		TokenResponse: &TokenResponse{
+			Object: "scoped_token",
+			JWT:    jwt,
+		},
+		Scopes:    scopes,
+		ExpiresAt: expiresAt.UnixMilli(),
+	}
+}
@@ -0,0 +1,34 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const TrustedDeviceObjectName = "trusted_device"
+
+type TrustedDeviceResponse struct {
+	Object     string `json:"object"`
+	ID         string `json:"id"`
+	UserAgent  string `json:"user_agent"`
+	LastUsedAt *int64 `json:"last_used_at"`
+	ExpireAt   int64  `json:"expire_at"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+func TrustedDevice(device *model.TrustedDevice) *TrustedDeviceResponse {
+	response := &TrustedDeviceResponse{
+		Object:    TrustedDeviceObjectName,
+		ID:        device.ID,
+		UserAgent: device.UserAgent,
+		ExpireAt:  time.UnixMilli(device.ExpireAt),
+		CreatedAt: time.UnixMilli(device.CreatedAt),
+	}
+
+	if device.LastUsedAt.Valid {
+		lastUsedAt := time.UnixMilli(device.LastUsedAt.Time)
+		response.LastUsedAt = &lastUsedAt
+	}
+
+	return response
+}
@@ -4,18 +4,79 @@ import (
 	"context"
 	"encoding/json"
 
+	"clerk/api/shared/phone_profiles"
 	"clerk/model"
+	"clerk/pkg/apiversioning"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/clerkjs_version"
 	"clerk/pkg/ctx/sdkversion"
 	"clerk/pkg/oauth"
 	"clerk/pkg/time"
+	"clerk/pkg/usersearch"
 	"clerk/pkg/versions"
 )
 
 const UserObjectName = "user"
 
+// UserSection is a part of the user response which is expensive to load
+// and serialize, and can be left out of it.
+type UserSection string
+
+const (
+	UserSectionExternalAccounts        UserSection = "external_accounts"
+	UserSectionOrganizationMemberships UserSection = "organization_memberships"
+	UserSectionSAMLAccounts            UserSection = "saml_accounts"
+	UserSectionWeb3Wallets             UserSection = "web3_wallets"
+)
+
+// UserSections are all the sections of the user response which can be
+// selected.
+var UserSections = []UserSection{
+	UserSectionExternalAccounts,
+	UserSectionOrganizationMemberships,
+	UserSectionSAMLAccounts,
+	UserSectionWeb3Wallets,
+}
+
+// UserOptions selects the sections which are part of a user response.
+// Sections which are left out are null in the response.
+type UserOptions struct {
+	ExternalAccounts        bool
+	OrganizationMemberships bool
+	SAMLAccounts            bool
+	Web3Wallets             bool
+
+	// SearchQuery is the query the user was found with, if any. The
+	// identifier of the user which matched it best is included as the
+	// search_match of the response.
+	SearchQuery string
+}
+
+// DefaultUserOptions are the sections of a user response, unless others are
+// requested. Organization memberships are only included on request, given
+// how many of them a user can have.
+var DefaultUserOptions = UserOptions{
+	ExternalAccounts: true,
+	SAMLAccounts:     true,
+	Web3Wallets:      true,
+}
+
+// With returns the options with section included.
+func (o UserOptions) With(section UserSection) UserOptions {
+	switch section {
+	case UserSectionExternalAccounts:
+		o.ExternalAccounts = true
+	case UserSectionOrganizationMemberships:
+		o.OrganizationMemberships = true
+	case UserSectionSAMLAccounts:
+		o.SAMLAccounts = true
+	case UserSectionWeb3Wallets:
+		o.Web3Wallets = true
+	}
+	return o
+}
+
 type UserResponse struct {
 	ID                            string                            `json:"id"`
 	Object                        string                            `json:"object"`
@@ -27,6 +88,8 @@ type UserResponse struct {
 	PrimaryEmailAddressID         *string                           `json:"primary_email_address_id"`
 	PrimaryPhoneNumberID          *string                           `json:"primary_phone_number_id"`
 	PrimaryWeb3WalletID           *string                           `json:"primary_web3_wallet_id"`
+	PendingPrimaryEmailAddressID  *string                           `json:"pending_primary_email_address_id"`
+	PendingPrimaryEmailExpireAt   *int64                            `json:"pending_primary_email_address_expire_at"`
 	PasswordEnabled               bool                              `json:"password_enabled"`
 	TwoFactorEnabled              bool                              `json:"two_factor_enabled"`
 	TOTPEnabled                   bool                              `json:"totp_enabled"`
@@ -47,13 +110,21 @@ type UserResponse struct {
 	Banned                        bool                              `json:"banned"`
 	Locked                        bool                              `json:"locked"`
 	LockoutExpiresInSeconds       *int64                            `json:"lockout_expires_in_seconds"`
+	LockedUntil                   *int64                            `json:"locked_until"`
 	VerificationAttemptsRemaining *int64                            `json:"verification_attempts_remaining"`
 	CreatedAt                     int64                             `json:"created_at"`
 	UpdatedAt                     int64                             `json:"updated_at"`
 	DeleteSelfEnabled             bool                              `json:"delete_self_enabled"`
 	CreateOrganizationEnabled     bool                              `json:"create_organization_enabled"`
 	LastActiveAt                  *int64                            `json:"last_active_at"`
+	LegalAcceptedAt               *int64                            `json:"legal_accepted_at"`
+	LegalAcceptedVersion          *string                           `json:"legal_accepted_version"`
 	BillingPlan                   *string                           `json:"plan,omitempty"`
+	SearchMatch                   *usersearch.Match                 `json:"search_match,omitempty"`
+	// Deleted users are only present until they're purged, at PurgeAt.
+	Deleted   bool   `json:"deleted,omitempty"`
+	DeletedAt *int64 `json:"deleted_at,omitempty"`
+	PurgeAt   *int64 `json:"purge_at,omitempty"`
 
 	// DEPRECATED: After 4.36.0
 	ProfileImageURL string `json:"profile_image_url"`
@@ -64,13 +135,81 @@ type sessionUserResponse struct {
 	OrganizationMemberships []*OrganizationMembershipResponse `json:"organization_memberships"`
 }
 
+// userServerAPIChanges are the changes of the BAPI user response across API
+// versions, oldest first.
+var userServerAPIChanges = NewResponsePipeline()
+
+// UserToServerAPIForVersion returns the BAPI user response in the shape of
+// the given API version, with the sections selected by opts.
+func UserToServerAPIForVersion(ctx context.Context, v apiversioning.Version, user *model.UserSerializable, opts UserOptions) any {
+	return userServerAPIChanges.Apply(ctx, v, UserToServerAPIWithOptions(ctx, user, opts))
+}
+
 func UserToServerAPI(ctx context.Context, user *model.UserSerializable) *UserResponse {
+	return UserToServerAPIWithOptions(ctx, user, DefaultUserOptions)
+}
+
+// UserToServerAPIWithOptions returns the BAPI user response with the
+// sections selected by opts. The organization memberships of the user need
+// to be loaded for them to be included.
+func UserToServerAPIWithOptions(ctx context.Context, user *model.UserSerializable, opts UserOptions) *UserResponse {
 	// For BAPI and Go-SDK version < 2, we must respond with the legacy payload to ensure backwards-compatibility
 	useLegacyExtAccount := useLegacyExtAccountForSDK(ctx)
 
-	response := userResponse(ctx, user, useLegacyExtAccount)
+	response := userResponse(ctx, user, useLegacyExtAccount, opts)
 	response.ID = user.ID
 	response.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
+
+	if opts.OrganizationMemberships {
+		response.OrganizationMemberships = make([]*OrganizationMembershipResponse, len(user.OrganizationMemberships))
+		for i, membership := range user.OrganizationMemberships {
+			response.OrganizationMemberships[i] = OrganizationMembershipBAPI(ctx, membership)
+			// the membership is part of the user response, which already has
+			// all user data
+			response.OrganizationMemberships[i].PublicUserData = nil
+		}
+	}
+
+	if opts.SearchQuery != "" {
+		response.SearchMatch = usersearch.BestMatch(usersearch.Terms(opts.SearchQuery), searchCandidates(response))
+	}
+	return response
+}
+
+// searchCandidates returns the identifiers of the user response which a
+// search query can match.
+func searchCandidates(response *UserResponse) []usersearch.Candidate {
+	var candidates []usersearch.Candidate
+	for _, emailAddress := range response.EmailAddresses {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldEmailAddress, Value: emailAddress.EmailAddress})
+	}
+	if response.Username != nil {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldUsername, Value: *response.Username})
+	}
+	for _, phoneNumber := range response.PhoneNumbers {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldPhoneNumber, Value: phoneNumber.PhoneNumber})
+	}
+	if response.FirstName != nil {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldFirstName, Value: *response.FirstName})
+	}
+	if response.LastName != nil {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldLastName, Value: *response.LastName})
+	}
+	for _, web3Wallet := range response.Web3Wallets {
+		candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldWeb3Wallet, Value: web3Wallet.Web3Wallet, Exact: true})
+	}
+	candidates = append(candidates, usersearch.Candidate{Field: usersearch.FieldUserID, Value: response.ID, Exact: true})
+	return candidates
+}
+
+// DeletedUser is the response for a deleted user, which also carries the
+// time it will be purged at, if it can still be restored.
+func DeletedUser(user *model.User) *DeletedObjectResponse {
+	response := DeletedObject(user.ID, UserObjectName)
+	if user.PurgeAt.Valid {
+		purgeAt := time.UnixMilli(user.PurgeAt.Time)
+		response.PurgeAt = &purgeAt
+	}
 	return response
 }
 
@@ -80,11 +219,11 @@ func UserToClientAPI(ctx context.Context, user *model.UserSerializable) *UserRes
 	clerkJSVersion := clerkjs_version.FromContext(ctx)
 	useLegacyExtAccount := versions.IsBefore(clerkJSVersion, "3.0.0", true)
 
-	return userResponse(ctx, user, useLegacyExtAccount)
+	return userResponse(ctx, user, useLegacyExtAccount, DefaultUserOptions)
 }
 
 func UserToDashboardAPI(ctx context.Context, user *model.UserSerializable) *UserResponse {
-	response := userResponse(ctx, user, false)
+	response := userResponse(ctx, user, false, DefaultUserOptions)
 	response.ID = user.ID
 	response.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
 
@@ -96,6 +235,94 @@ func UserToDashboardAPI(ctx context.Context, user *model.UserSerializable) *User
 	return response
 }
 
+// UserToAdminAPI returns the user in the shape of the Server API, for
+// support tooling. Unless pii is PIIRevealed, names, identifiers and
+// metadata are masked and external and SAML accounts, which carry the
+// personal information of the provider, are left out.
+func UserToAdminAPI(ctx context.Context, user *model.UserSerializable, pii PII) *UserResponse {
+	response := userResponse(ctx, user, false, DefaultUserOptions)
+	response.ID = user.ID
+
+	if user.PasswordLastUpdatedAt.Valid {
+		lastUpdated := time.UnixMilli(user.PasswordLastUpdatedAt.Time)
+		response.PasswordLastUpdatedAt = &lastUpdated
+	}
+
+	if pii == PIIRevealed {
+		response.PrivateMetadata = json.RawMessage(user.PrivateMetadata)
+		return response
+	}
+
+	response.FirstName = maskOptionalValue(response.FirstName)
+	response.LastName = maskOptionalValue(response.LastName)
+	response.Username = maskOptionalValue(response.Username)
+	for _, emailAddress := range response.EmailAddresses {
+		emailAddress.EmailAddress = MaskEmailAddress(emailAddress.EmailAddress)
+	}
+	for _, phoneNumber := range response.PhoneNumbers {
+		phoneNumber.PhoneNumber = MaskPhoneNumber(phoneNumber.PhoneNumber)
+	}
+	for _, web3Wallet := range response.Web3Wallets {
+		web3Wallet.Web3Wallet = MaskWeb3Wallet(web3Wallet.Web3Wallet)
+	}
+	response.ExternalAccounts = make([]interface{}, 0)
+	response.SAMLAccounts = make([]*SAMLAccountResponse, 0)
+	response.PublicMetadata = nil
+	response.UnsafeMetadata = nil
+
+	return response
+}
+
+// UserSearchResultResponse is the minimal projection of the UserToAdminAPI
+// response which the support user search returns for every match, enough
+// to tell which instance the user belongs to and the state of their
+// account.
+type UserSearchResultResponse struct {
+	Object          string   `json:"object"`
+	ID              string   `json:"id"`
+	InstanceID      string   `json:"instance_id"`
+	EnvironmentType string   `json:"environment_type"`
+	FirstName       *string  `json:"first_name"`
+	LastName        *string  `json:"last_name"`
+	EmailAddresses  []string `json:"email_addresses"`
+	PhoneNumbers    []string `json:"phone_numbers"`
+	Banned          bool     `json:"banned"`
+	Locked          bool     `json:"locked"`
+	LastSignInAt    *int64   `json:"last_sign_in_at"`
+	CreatedAt       int64    `json:"created_at"`
+}
+
+// UserSearchResultToAdminAPI returns the user found by the support user
+// search, with their personal information masked the same way as in
+// UserToAdminAPI.
+func UserSearchResultToAdminAPI(ctx context.Context, instance *model.Instance, user *model.UserSerializable, pii PII) *UserSearchResultResponse {
+	response := UserToAdminAPI(ctx, user, pii)
+
+	emailAddresses := make([]string, len(response.EmailAddresses))
+	for i, emailAddress := range response.EmailAddresses {
+		emailAddresses[i] = emailAddress.EmailAddress
+	}
+	phoneNumbers := make([]string, len(response.PhoneNumbers))
+	for i, phoneNumber := range response.PhoneNumbers {
+		phoneNumbers[i] = phoneNumber.PhoneNumber
+	}
+
+	return &UserSearchResultResponse{
+		Object:          response.Object,
+		ID:              response.ID,
+		InstanceID:      instance.ID,
+		EnvironmentType: instance.EnvironmentType,
+		FirstName:       response.FirstName,
+		LastName:        response.LastName,
+		EmailAddresses:  emailAddresses,
+		PhoneNumbers:    phoneNumbers,
+		Banned:          response.Banned,
+		Locked:          response.Locked,
+		LastSignInAt:    response.LastSignInAt,
+		CreatedAt:       response.CreatedAt,
+	}
+}
+
 func sessionUser(ctx context.Context, session *model.SessionWithUser) *sessionUserResponse {
 	memberships := make([]*OrganizationMembershipResponse, len(session.OrganizationMemberships))
 	for i, membership := range session.OrganizationMemberships {
@@ -111,7 +338,7 @@ func sessionUser(ctx context.Context, session *model.SessionWithUser) *sessionUs
 	}
 }
 
-func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyExtAccount bool) *UserResponse {
+func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyExtAccount bool, opts UserOptions) *UserResponse {
 	userResStruct := UserResponse{
 		ID:                            user.ID,
 		Object:                        UserObjectName,
@@ -125,8 +352,6 @@ func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyEx
 		TwoFactorEnabled:              user.TwoFactorEnabled,
 		TOTPEnabled:                   user.TOTPEnabled,
 		BackupCodeEnabled:             user.BackupCodeEnabled,
-		ExternalAccounts:              make([]interface{}, 0),
-		SAMLAccounts:                  make([]*SAMLAccountResponse, 0),
 		Banned:                        user.Banned,
 		Locked:                        user.Locked,
 		LockoutExpiresInSeconds:       user.LockoutExpiresInSeconds,
@@ -138,6 +363,11 @@ func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyEx
 		BillingPlan:                   user.BillingPlan,
 	}
 
+	if user.LockedUntil != nil {
+		lockedUntil := time.UnixMilli(*user.LockedUntil)
+		userResStruct.LockedUntil = &lockedUntil
+	}
+
 	if user.FirstName.Valid {
 		userResStruct.FirstName = &user.FirstName.String
 	}
@@ -150,6 +380,12 @@ func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyEx
 		userResStruct.PrimaryEmailAddressID = &user.PrimaryEmailAddressID.String
 	}
 
+	if user.PendingPrimaryEmailAddressID.Valid {
+		expireAt := time.UnixMilli(user.PendingPrimaryEmailAddressExpireAt.Time)
+		userResStruct.PendingPrimaryEmailAddressID = &user.PendingPrimaryEmailAddressID.String
+		userResStruct.PendingPrimaryEmailExpireAt = &expireAt
+	}
+
 	if user.PrimaryPhoneNumberID.Valid {
 		userResStruct.PrimaryPhoneNumberID = &user.PrimaryPhoneNumberID.String
 	}
@@ -176,14 +412,31 @@ func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyEx
 		userResStruct.LastActiveAt = &v
 	}
 
+	if user.LegalAcceptedAt.Valid {
+		v := time.UnixMilli(user.LegalAcceptedAt.Time)
+		userResStruct.LegalAcceptedAt = &v
+		userResStruct.LegalAcceptedVersion = user.LegalAcceptedVersion.Ptr()
+	}
+
+	if user.User.DeletedAt.Valid {
+		deletedAt := time.UnixMilli(user.User.DeletedAt.Time)
+		purgeAt := time.UnixMilli(user.User.PurgeAt.Time)
+		userResStruct.Deleted = true
+		userResStruct.DeletedAt = &deletedAt
+		userResStruct.PurgeAt = &purgeAt
+	}
+
 	// Email Addresses
 	userResStruct.EmailAddresses = emailAddressesForIdentifications(user.Identifications[constants.ITEmailAddress])
 
 	// Phone Numbers
-	userResStruct.PhoneNumbers = phoneNumbersForIdentifications(user.Identifications[constants.ITPhoneNumber])
+	userResStruct.PhoneNumbers = phoneNumbersForIdentifications(user.Identifications[constants.ITPhoneNumber],
+		WithPhoneNumberProfile(phone_profiles.FromContext(ctx)))
 
 	// Web3 Wallets
-	userResStruct.Web3Wallets = web3WalletsForIdentifications(user.Identifications[constants.ITWeb3Wallet])
+	if opts.Web3Wallets {
+		userResStruct.Web3Wallets = web3WalletsForIdentifications(user.Identifications[constants.ITWeb3Wallet])
+	}
 
 	// Passkeys
 	userResStruct.Passkeys = make([]*PasskeyResponse, 0)
@@ -192,25 +445,31 @@ func userResponse(ctx context.Context, user *model.UserSerializable, useLegacyEx
 	}
 
 	// External Accounts
-	externalAccountIdentifications := make([]*model.IdentificationSerializable, 0)
-	for _, provider := range oauth.Providers() {
-		externalAccountIdentifications = append(externalAccountIdentifications, user.Identifications[provider]...)
-	}
-
-	for _, identification := range externalAccountIdentifications {
-		if identification.ExternalAccount == nil {
-			continue
+	if opts.ExternalAccounts {
+		userResStruct.ExternalAccounts = make([]interface{}, 0)
+		externalAccountIdentifications := make([]*model.IdentificationSerializable, 0)
+		for _, provider := range oauth.Providers() {
+			externalAccountIdentifications = append(externalAccountIdentifications, user.Identifications[provider]...)
 		}
-		if useLegacyExtAccount {
-			userResStruct.ExternalAccounts = append(userResStruct.ExternalAccounts, externalAccountForIdentification(ctx, identification))
-		} else {
-			userResStruct.ExternalAccounts = append(userResStruct.ExternalAccounts, ExternalAccount(ctx, identification.ExternalAccount, identification.Verification))
+
+		for _, identification := range externalAccountIdentifications {
+			if identification.ExternalAccount == nil {
+				continue
+			}
+			if useLegacyExtAccount {
+				userResStruct.ExternalAccounts = append(userResStruct.ExternalAccounts, externalAccountForIdentification(ctx, identification))
+			} else {
+				userResStruct.ExternalAccounts = append(userResStruct.ExternalAccounts, ExternalAccount(ctx, identification.ExternalAccount, identification.Verification))
+			}
 		}
 	}
 
 	// SAML Accounts
-	for _, identification := range user.Identifications[constants.ITSAML] {
-		userResStruct.SAMLAccounts = append(userResStruct.SAMLAccounts, SAMLAccount(identification.SAMLAccountWithDeps, identification.Verification))
+	if opts.SAMLAccounts {
+		userResStruct.SAMLAccounts = make([]*SAMLAccountResponse, 0)
+		for _, identification := range user.Identifications[constants.ITSAML] {
+			userResStruct.SAMLAccounts = append(userResStruct.SAMLAccounts, SAMLAccount(identification.SAMLAccountWithDeps, identification.Verification))
+		}
 	}
 
 	return &userResStruct
@@ -0,0 +1,61 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectUserBulkImport is the name for user bulk import objects.
+const ObjectUserBulkImport = "user_bulk_import"
+
+// UserBulkImportRowError holds the errors which prevented the user at the
+// given row of a bulk import from being created. Rows are zero-based.
+type UserBulkImportRowError struct {
+	Row    int                      `json:"row"`
+	Errors []apierror.ErrorResponse `json:"errors"`
+}
+
+type UserBulkImportResponse struct {
+	Object         string                   `json:"object"`
+	ID             string                   `json:"id"`
+	Status         string                   `json:"status"`
+	TotalCount     int                      `json:"total_count"`
+	ProcessedCount int                      `json:"processed_count"`
+	SucceededCount int                      `json:"succeeded_count"`
+	FailedCount    int                      `json:"failed_count"`
+	Errors         []UserBulkImportRowError `json:"errors"`
+	CompletedAt    *int64                   `json:"completed_at"`
+	CreatedAt      int64                    `json:"created_at"`
+	UpdatedAt      int64                    `json:"updated_at"`
+}
+
+func UserBulkImport(bulkImport *model.UserBulkImport) (*UserBulkImportResponse, error) {
+	response := &UserBulkImportResponse{
+		Object:         ObjectUserBulkImport,
+		ID:             bulkImport.ID,
+		Status:         bulkImport.Status,
+		TotalCount:     bulkImport.TotalCount,
+		ProcessedCount: bulkImport.ProcessedCount,
+		SucceededCount: bulkImport.SucceededCount,
+		FailedCount:    bulkImport.FailedCount,
+		Errors:         make([]UserBulkImportRowError, 0),
+		CreatedAt:      time.UnixMilli(bulkImport.CreatedAt),
+		UpdatedAt:      time.UnixMilli(bulkImport.UpdatedAt),
+	}
+
+	if len(bulkImport.Errors) > 0 {
+		if err := json.Unmarshal(bulkImport.Errors, &response.Errors); err != nil {
+			return nil, err
+		}
+	}
+
+	if bulkImport.CompletedAt.Valid {
+		completedAt := time.UnixMilli(bulkImport.CompletedAt.Time)
+		response.CompletedAt = &completedAt
+	}
+
+	return response, nil
+}
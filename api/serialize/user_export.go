@@ -0,0 +1,57 @@
+package serialize
+
+import (
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+// ObjectUserExport is the name for user export objects.
+const ObjectUserExport = "user_export"
+
+type UserExportResponse struct {
+	Object      string  `json:"object"`
+	ID          string  `json:"id"`
+	UserID      string  `json:"user_id"`
+	Status      string  `json:"status"`
+	Format      string  `json:"format"`
+	DownloadURL *string `json:"download_url"`
+	ExpireAt    *int64  `json:"expire_at"`
+	CompletedAt *int64  `json:"completed_at"`
+	CreatedAt   int64   `json:"created_at"`
+	UpdatedAt   int64   `json:"updated_at"`
+}
+
+// UserExport serializes the given user export. The download URL is signed
+// for a limited time, so it's only included along with its expiry once the
+// export has completed.
+func UserExport(export *model.UserExport, downloadURL string, expireAt int64) *UserExportResponse {
+	response := &UserExportResponse{
+		Object:    ObjectUserExport,
+		ID:        export.ID,
+		UserID:    export.UserID,
+		Status:    export.Status,
+		Format:    export.Format,
+		CreatedAt: time.UnixMilli(export.CreatedAt),
+		UpdatedAt: time.UnixMilli(export.UpdatedAt),
+	}
+
+	if export.CompletedAt.Valid {
+		completedAt := time.UnixMilli(export.CompletedAt.Time)
+		response.CompletedAt = &completedAt
+	}
+	if downloadURL != "" {
+		response.DownloadURL = &downloadURL
+		response.ExpireAt = &expireAt
+	}
+
+	return response
+}
+
+// UserExportDocumentResponse is the content of a user export, i.e. all the
+// data that is kept about the user.
+type UserExportDocumentResponse struct {
+	User                    *UserResponse                     `json:"user"`
+	Sessions                []*SessionServerResponse          `json:"sessions"`
+	OrganizationMemberships []*OrganizationMembershipResponse `json:"organization_memberships"`
+	ExportedAt              int64                             `json:"exported_at"`
+}
@@ -0,0 +1,41 @@
+package serialize
+
+const UserMergeObjectName = "user_merge"
+
+type UserMergeResponse struct {
+	Object                    string        `json:"object"`
+	User                      *UserResponse `json:"user"`
+	MergedUserID              string        `json:"merged_user_id"`
+	IdentificationIDs         []string      `json:"identification_ids"`
+	ExternalAccountIDs        []string      `json:"external_account_ids"`
+	OrganizationMembershipIDs []string      `json:"organization_membership_ids"`
+	SkippedOrganizationIDs    []string      `json:"skipped_organization_ids"`
+	SessionIDs                []string      `json:"session_ids"`
+	PrimaryIdentifiers        []string      `json:"primary_identifiers"`
+}
+
+type UserMergeReport struct {
+	MergedUserID              string
+	IdentificationIDs         []string
+	ExternalAccountIDs        []string
+	OrganizationMembershipIDs []string
+	SkippedOrganizationIDs    []string
+	SessionIDs                []string
+	PrimaryIdentifiers        []string
+}
+
+// UserMerge serializes the report of a user merge, along with the user
+// everything was merged into.
+func UserMerge(user *UserResponse, report UserMergeReport) *UserMergeResponse {
+	return &UserMergeResponse{
+		Object:                    UserMergeObjectName,
+		User:                      user,
+		MergedUserID:              report.MergedUserID,
+		IdentificationIDs:         report.IdentificationIDs,
+		ExternalAccountIDs:        report.ExternalAccountIDs,
+		OrganizationMembershipIDs: report.OrganizationMembershipIDs,
+		SkippedOrganizationIDs:    report.SkippedOrganizationIDs,
+		SessionIDs:                report.SessionIDs,
+		PrimaryIdentifiers:        report.PrimaryIdentifiers,
+	}
+}
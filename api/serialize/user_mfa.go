@@ -0,0 +1,39 @@
+package serialize
+
+import (
+	"clerk/model"
+)
+
+const UserMFAObjectName = "user_mfa"
+
+type UserMFAResponse struct {
+	Object           string              `json:"object"`
+	UserID           string              `json:"user_id"`
+	TwoFactorEnabled bool                `json:"two_factor_enabled"`
+	PhoneCodeEnabled bool                `json:"phone_code_enabled"`
+	TOTPEnabled      bool                `json:"totp_enabled"`
+	BackupCodes      *BackupCodeResponse `json:"backup_codes"`
+}
+
+// UserMFA describes the second factors of the user, along with the usage of
+// their backup codes if they have any.
+type UserMFA struct {
+	UserID           string
+	PhoneCodeEnabled bool
+	TOTPEnabled      bool
+	BackupCode       *model.BackupCode
+}
+
+func UserMFAPosture(mfa UserMFA) *UserMFAResponse {
+	response := &UserMFAResponse{
+		Object:           UserMFAObjectName,
+		UserID:           mfa.UserID,
+		TwoFactorEnabled: mfa.PhoneCodeEnabled || mfa.TOTPEnabled,
+		PhoneCodeEnabled: mfa.PhoneCodeEnabled,
+		TOTPEnabled:      mfa.TOTPEnabled,
+	}
+	if mfa.BackupCode != nil {
+		response.BackupCodes = BackupCode(mfa.BackupCode, nil)
+	}
+	return response
+}
@@ -0,0 +1,73 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestBackupCode(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	backupCode := &model.BackupCode{BackupCode: &sqbmodel.BackupCode{
+		ID:               "bc_1",
+		Codes:            []string{"hash_1", "hash_2", "hash_3"},
+		UsedCount:        7,
+		RegeneratedCount: 1,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}}
+
+	// plain codes are only returned right after they're generated
+	raw, err := json.Marshal(serialize.BackupCode(backupCode, nil))
+	require.NoError(t, err)
+	response := decodeResponse(t, raw)
+	assert.NotContains(t, response, "codes")
+	assert.Equal(t, float64(7), response["used_count"])
+	assert.Equal(t, float64(3), response["remaining_count"])
+	assert.Equal(t, float64(1), response["regenerated_count"])
+	assert.Contains(t, response, "last_used_at")
+	assert.Nil(t, response["last_used_at"])
+
+	backupCode.LastUsedAt = null.TimeFrom(now.Add(time.Hour))
+	withCodes := serialize.BackupCode(backupCode, []string{"code_1", "code_2", "code_3"})
+	assert.Equal(t, []string{"code_1", "code_2", "code_3"}, withCodes.Codes)
+	require.NotNil(t, withCodes.LastUsedAt)
+	assert.Equal(t, now.Add(time.Hour).UnixMilli(), *withCodes.LastUsedAt)
+}
+
+func TestUserMFAPosture(t *testing.T) {
+	t.Parallel()
+
+	response := serialize.UserMFAPosture(serialize.UserMFA{UserID: "user_1"})
+	assert.Equal(t, serialize.UserMFAObjectName, response.Object)
+	assert.Equal(t, "user_1", response.UserID)
+	assert.False(t, response.TwoFactorEnabled)
+	assert.Nil(t, response.BackupCodes)
+
+	// backup codes alone don't enable two factor authentication
+	response = serialize.UserMFAPosture(serialize.UserMFA{
+		UserID:     "user_1",
+		BackupCode: &model.BackupCode{BackupCode: &sqbmodel.BackupCode{ID: "bc_1", Codes: []string{"hash_1"}}},
+	})
+	assert.False(t, response.TwoFactorEnabled)
+	require.NotNil(t, response.BackupCodes)
+	assert.Equal(t, 1, response.BackupCodes.RemainingCount)
+	assert.Nil(t, response.BackupCodes.Codes)
+
+	for _, mfa := range []serialize.UserMFA{{PhoneCodeEnabled: true}, {TOTPEnabled: true}} {
+		response = serialize.UserMFAPosture(mfa)
+		assert.True(t, response.TwoFactorEnabled)
+		assert.Equal(t, mfa.PhoneCodeEnabled, response.PhoneCodeEnabled)
+		assert.Equal(t, mfa.TOTPEnabled, response.TOTPEnabled)
+	}
+}
@@ -0,0 +1,34 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestDeletedUser(t *testing.T) {
+	t.Parallel()
+
+	// users deleted permanently can't be restored
+	user := &model.User{User: &sqbmodel.User{ID: "user_1"}}
+	raw, err := json.Marshal(serialize.DeletedUser(user))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"user_1","object":"user","deleted":true}`, string(raw))
+
+	// users which are retained can be restored until they're purged
+	purgeAt := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	user.DeletedAt = null.TimeFrom(purgeAt.AddDate(0, 0, -30))
+	user.PurgeAt = null.TimeFrom(purgeAt)
+	response := serialize.DeletedUser(user)
+	assert.True(t, response.Deleted)
+	require.NotNil(t, response.PurgeAt)
+	assert.Equal(t, purgeAt.UnixMilli(), *response.PurgeAt)
+}
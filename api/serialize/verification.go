@@ -15,6 +15,9 @@ type VerificationResponse struct {
 	ExpireAt         *int64 `json:"expire_at"`
 	VerifiedAtClient string `json:"verified_at_client,omitempty"`
 
+	// OTP codes
+	CooldownExpiresAt *int64 `json:"cooldown_expires_at,omitempty"`
+
 	// Web3, OAuth, SAML
 	Nonce *string `json:"nonce,omitempty"`
 
@@ -54,6 +57,10 @@ func Verification(verification *model.VerificationWithStatus) *VerificationRespo
 
 	case constants.VSEmailCode, constants.VSPhoneCode, constants.VSResetPasswordEmailCode, constants.VSResetPasswordPhoneCode:
 		response.Attempts = &verification.Attempts
+		if verification.CooldownExpiresAt.Valid {
+			cooldownExpiresAt := time.UnixMilli(verification.CooldownExpiresAt.Time)
+			response.CooldownExpiresAt = &cooldownExpiresAt
+		}
 
 	case constants.VSEmailLink:
 		response.VerifiedAtClient = verification.VerifiedAtClientID.String
@@ -0,0 +1,112 @@
+package serialize
+
+import (
+	"context"
+	"encoding/json"
+
+	"clerk/pkg/apiversioning"
+	"clerk/utils/log"
+)
+
+// ResponseChange is a backwards-incompatible change to a response, which
+// was introduced in an API version.
+//
+// Serializers always build responses in the shape of the latest version.
+// Requests pinned to an older version get the response downgraded, by
+// undoing the changes of every newer version, newest first. That way a
+// field can be added, removed or renamed in a new version without forking
+// the serializer.
+type ResponseChange struct {
+	// Version is the API version which introduced the change.
+	Version apiversioning.Version
+	// Description explains the change, for the changelog.
+	Description string
+	// Downgrade turns the fields of a response of Version into the fields
+	// of the same response in the version before it.
+	Downgrade func(fields map[string]any)
+}
+
+// ResponsePipeline holds the changes of a response across API versions.
+type ResponsePipeline struct {
+	// changes are ordered from newest to oldest version
+	changes []ResponseChange
+}
+
+// NewResponsePipeline returns a pipeline with the given changes, which must
+// be ordered from oldest to newest version, as they're declared.
+func NewResponsePipeline(changes ...ResponseChange) *ResponsePipeline {
+	pipeline := &ResponsePipeline{changes: make([]ResponseChange, len(changes))}
+	for i, change := range changes {
+		pipeline.changes[len(changes)-1-i] = change
+	}
+	return pipeline
+}
+
+// Apply returns the response in the shape of the given API version. The
+// response is returned as is, unless any of the changes is newer than the
+// version.
+func (p *ResponsePipeline) Apply(ctx context.Context, v apiversioning.Version, response any) any {
+	var pending []ResponseChange
+	for _, change := range p.changes {
+		if v.GTE(change.Version) {
+			break
+		}
+		pending = append(pending, change)
+	}
+	if len(pending) == 0 {
+		return response
+	}
+
+	fields, err := toFields(response)
+	if err != nil {
+		// responses are plain structs, so this should never happen; if it
+		// does, the latest shape is better than no response at all
+		log.Warning(ctx, "serialize: downgrading response to version %s: %s", v.GetName(), err)
+		return response
+	}
+	for _, change := range pending {
+		change.Downgrade(fields)
+	}
+	return fields
+}
+
+func toFields(response any) (map[string]any, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// AddedField downgrades a response by removing a field which was added in
+// the version of the change.
+func AddedField(name string) func(map[string]any) {
+	return func(fields map[string]any) {
+		delete(fields, name)
+	}
+}
+
+// RemovedField downgrades a response by restoring a field which was removed
+// in the version of the change, with the value it used to have.
+func RemovedField(name string, value func(fields map[string]any) any) func(map[string]any) {
+	return func(fields map[string]any) {
+		fields[name] = value(fields)
+	}
+}
+
+// RenamedField downgrades a response by restoring the previous name of a
+// field which was renamed in the version of the change.
+func RenamedField(from, to string) func(map[string]any) {
+	return func(fields map[string]any) {
+		value, ok := fields[to]
+		if !ok {
+			return
+		}
+		delete(fields, to)
+		fields[from] = value
+	}
+}
@@ -0,0 +1,40 @@
+package serialize_test
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/serialize"
+	"clerk/pkg/apiversioning"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponsePipeline_LatestVersion(t *testing.T) {
+	t.Parallel()
+
+	type response struct {
+		ID string `json:"id"`
+	}
+
+	pipeline := serialize.NewResponsePipeline(serialize.ResponseChange{
+		Version:   apiversioning.V20210205,
+		Downgrade: serialize.AddedField("id"),
+	})
+	latest := &response{ID: "user_1"}
+	assert.Same(t, latest, pipeline.Apply(context.Background(), apiversioning.V20210205, latest))
+}
+
+func TestResponseChanges(t *testing.T) {
+	t.Parallel()
+
+	fields := map[string]any{"id": "user_1", "image_url": "https://img", "banned": true}
+	for _, downgrade := range []func(map[string]any){
+		serialize.AddedField("banned"),
+		serialize.RenamedField("profile_image_url", "image_url"),
+		serialize.RemovedField("gender", func(map[string]any) any { return "" }),
+	} {
+		downgrade(fields)
+	}
+	assert.Equal(t, map[string]any{"id": "user_1", "profile_image_url": "https://img", "gender": ""}, fields)
+}
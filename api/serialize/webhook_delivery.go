@@ -0,0 +1,47 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/model"
+	"clerk/pkg/outbound"
+	"clerk/pkg/time"
+)
+
+const WebhookFailedDeliveryObjectName = "webhook_failed_delivery"
+
+type WebhookFailedDeliveryResponse struct {
+	Object         string          `json:"object"`
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload" logger:"omit"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastResponse   *string         `json:"last_response"`
+	CreatedAt      int64           `json:"created_at"`
+}
+
+// WebhookFailedDelivery serializes a native webhook delivery which ran out
+// of attempts. Deliveries which failed before responses were sanitized
+// stored them whole, so the response is sanitized again.
+func WebhookFailedDelivery(deadLetter *model.WebhookDeadLetter) *WebhookFailedDeliveryResponse {
+	var lastResponse *string
+	if deadLetter.LastResponse.Valid {
+		snippet := outbound.Sanitize(deadLetter.LastResponse.String)
+		lastResponse = &snippet
+	}
+	return &WebhookFailedDeliveryResponse{
+		Object:         WebhookFailedDeliveryObjectName,
+		ID:             deadLetter.ID,
+		EndpointID:     deadLetter.EndpointID,
+		EventID:        deadLetter.EventID,
+		EventType:      deadLetter.EventType,
+		Payload:        json.RawMessage(deadLetter.Payload),
+		Attempts:       deadLetter.Attempts,
+		LastStatusCode: deadLetter.LastStatusCode.Ptr(),
+		LastResponse:   lastResponse,
+		CreatedAt:      time.UnixMilli(deadLetter.CreatedAt),
+	}
+}
@@ -0,0 +1,79 @@
+package serialize
+
+import (
+	"encoding/json"
+
+	"clerk/model"
+	"clerk/pkg/time"
+)
+
+const (
+	WebhookEventObjectName       = "webhook_event"
+	WebhookEventReplayObjectName = "webhook_event_replay"
+)
+
+type WebhookEventResponse struct {
+	Object         string          `json:"object"`
+	ID             string          `json:"id"`
+	Type           string          `json:"type"`
+	OrganizationID *string         `json:"organization_id"`
+	Payload        json.RawMessage `json:"payload" logger:"omit"`
+	CreatedAt      int64           `json:"created_at"`
+}
+
+// WebhookEvent serializes a recorded event, along with the payload which
+// was delivered to webhooks for it.
+func WebhookEvent(event *model.WebhookEvent) *WebhookEventResponse {
+	return &WebhookEventResponse{
+		Object:         WebhookEventObjectName,
+		ID:             event.ID,
+		Type:           event.Type,
+		OrganizationID: event.OrganizationID.Ptr(),
+		Payload:        json.RawMessage(event.Payload),
+		CreatedAt:      time.UnixMilli(event.CreatedAt),
+	}
+}
+
+type WebhookEventReplayResponse struct {
+	Object      string   `json:"object"`
+	ID          string   `json:"id"`
+	Status      string   `json:"status"`
+	StartAt     *int64   `json:"start_at"`
+	EndAt       int64    `json:"end_at"`
+	EventTypes  []string `json:"event_types"`
+	EventCount  *int     `json:"event_count"`
+	CompletedAt *int64   `json:"completed_at"`
+	CreatedAt   int64    `json:"created_at"`
+}
+
+// WebhookEventReplay serializes a request to deliver the events of a time
+// range again. The number of replayed events is only known once the replay
+// has completed.
+func WebhookEventReplay(replay *model.WebhookEventReplay) *WebhookEventReplayResponse {
+	response := &WebhookEventReplayResponse{
+		Object:     WebhookEventReplayObjectName,
+		ID:         replay.ID,
+		Status:     replay.Status,
+		EndAt:      time.UnixMilli(replay.EndAt),
+		EventTypes: replay.EventTypes,
+		CreatedAt:  time.UnixMilli(replay.CreatedAt),
+	}
+
+	if replay.StartAt.Valid {
+		startAt := time.UnixMilli(replay.StartAt.Time)
+		response.StartAt = &startAt
+	}
+	if replay.EventCount.Valid {
+		eventCount := replay.EventCount.Int
+		response.EventCount = &eventCount
+	}
+	if replay.CompletedAt.Valid {
+		completedAt := time.UnixMilli(replay.CompletedAt.Time)
+		response.CompletedAt = &completedAt
+	}
+	if response.EventTypes == nil {
+		response.EventTypes = []string{}
+	}
+
+	return response
+}
@@ -0,0 +1,75 @@
+package serialize_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestWebhookEvent(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := &model.WebhookEvent{WebhookEvent: &sqbmodel.WebhookEvent{
+		ID:             "evt_1",
+		Type:           "organization.updated",
+		OrganizationID: null.StringFrom("org_1"),
+		Payload:        []byte(`{"data":{"id":"org_1"}}`),
+		CreatedAt:      now,
+	}}
+
+	// the payload is included as delivered, not as an encoded string
+	raw, err := json.Marshal(serialize.WebhookEvent(event))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"object": "webhook_event",
+		"id": "evt_1",
+		"type": "organization.updated",
+		"organization_id": "org_1",
+		"payload": {"data": {"id": "org_1"}},
+		"created_at": 1704110400000
+	}`, string(raw))
+}
+
+func TestWebhookEventReplay(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	replay := &model.WebhookEventReplay{WebhookEventReplay: &sqbmodel.WebhookEventReplay{
+		ID:        "whr_1",
+		Status:    "pending",
+		EndAt:     now,
+		CreatedAt: now,
+	}}
+
+	// open ranges and pending replays leave their fields null
+	response := serialize.WebhookEventReplay(replay)
+	assert.Equal(t, serialize.WebhookEventReplayObjectName, response.Object)
+	assert.Nil(t, response.StartAt)
+	assert.Equal(t, now.UnixMilli(), response.EndAt)
+	assert.Equal(t, []string{}, response.EventTypes)
+	assert.Nil(t, response.EventCount)
+	assert.Nil(t, response.CompletedAt)
+
+	replay.Status = "completed"
+	replay.StartAt = null.TimeFrom(now.Add(-time.Hour))
+	replay.EventTypes = []string{"user.created"}
+	replay.EventCount = null.IntFrom(42)
+	replay.CompletedAt = null.TimeFrom(now.Add(time.Minute))
+	response = serialize.WebhookEventReplay(replay)
+	require.NotNil(t, response.StartAt)
+	assert.Equal(t, now.Add(-time.Hour).UnixMilli(), *response.StartAt)
+	assert.Equal(t, []string{"user.created"}, response.EventTypes)
+	require.NotNil(t, response.EventCount)
+	assert.Equal(t, 42, *response.EventCount)
+	require.NotNil(t, response.CompletedAt)
+	assert.Equal(t, now.Add(time.Minute).UnixMilli(), *response.CompletedAt)
+}
@@ -89,13 +89,19 @@ type OpenIDConfigurationResponse struct {
 	GrantTypesSupported               []string `json:"grant_types_supported"`
 	ResponseModesSupported            []string `json:"response_modes_supported"`
 	ResponseTypesSupported            []string `json:"response_types_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
 	TokenEndpoint                     string   `json:"token_endpoint"`
 	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
 	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
 }
 
+// The scopes and claims are the ones the instance supports as an OpenID
+// Connect provider.
+//
 // https://www.jeremydaly.com/verifying-self-signed-jwt-tokens-with-aws-http-apis/
-func OpenIDConfiguration(domain *model.Domain) *OpenIDConfigurationResponse {
+func OpenIDConfiguration(domain *model.Domain, scopes, claims []string) *OpenIDConfigurationResponse {
 	return &OpenIDConfigurationResponse{
 		Issuer:                            domain.FapiURL(),
 		JwksURI:                           domain.JwksURL(),
@@ -105,6 +111,9 @@ func OpenIDConfiguration(domain *model.Domain) *OpenIDConfigurationResponse {
 		GrantTypesSupported:               []string{"authorization_code"},
 		ResponseModesSupported:            []string{"form_post"},
 		ResponseTypesSupported:            []string{"code"},
+		ScopesSupported:                   scopes,
+		ClaimsSupported:                   claims,
+		SubjectTypesSupported:             []string{"public"},
 		TokenEndpoint:                     domain.OAuthTokenURL(),
 		TokenEndpointAuthMethodsSupported: []string{"client_secret_post"},
 		UserInfoEndpoint:                  domain.OAuthUserInfoURL(),
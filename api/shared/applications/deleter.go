@@ -46,10 +46,10 @@ func NewDeleter(deps clerk.Deps) *Deleter {
 		gueClient:              deps.GueClient(),
 		domainService:          domains.NewService(deps),
 		edgeReplicationService: edgereplication.NewService(deps.GueClient(), cenv.GetBool(cenv.FlagReplicateInstanceToEdgeJobsEnabled)),
-		applicationRepo:        repository.NewApplications(),
-		domainRepo:             repository.NewDomain(),
-		instanceRepo:           repository.NewInstances(),
-		subscriptionRepo:       repository.NewSubscriptions(),
+		applicationRepo:        deps.Repositories().Applications,
+		domainRepo:             deps.Repositories().Domain,
+		instanceRepo:           deps.Repositories().Instances,
+		subscriptionRepo:       deps.Repositories().Subscriptions,
 	}
 }
 
@@ -0,0 +1,296 @@
+package attestation
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/pkg/cenv"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/jonboulle/clockwork"
+)
+
+// https://developer.apple.com/documentation/devicecheck/validating_apps_that_connect_to_your_server
+const appleAttestationFormat = "apple-appattest"
+
+var (
+	appleNonceExtensionOID = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}
+
+	appleAAGUIDProduction  = []byte("appattest\x00\x00\x00\x00\x00\x00\x00")
+	appleAAGUIDDevelopment = []byte("appattestdevelop")
+)
+
+type appleAttestationObject struct {
+	Format  string `cbor:"fmt"`
+	AttStmt struct {
+		X5C     [][]byte `cbor:"x5c"`
+		Receipt []byte   `cbor:"receipt"`
+	} `cbor:"attStmt"`
+	AuthData []byte `cbor:"authData"`
+}
+
+type appleAssertionObject struct {
+	Signature         []byte `cbor:"signature"`
+	AuthenticatorData []byte `cbor:"authenticatorData"`
+}
+
+// appleDeviceKey is the attested public key of a device, which is cached
+// so that subsequent requests only need to provide a cheap assertion.
+type appleDeviceKey struct {
+	PublicKey []byte `json:"public_key"`
+	Counter   uint32 `json:"counter"`
+}
+
+type appleAuthenticatorData struct {
+	rpIDHash     []byte
+	counter      uint32
+	aaguid       []byte
+	credentialID []byte
+}
+
+type appleVerifier struct {
+	cache cache.Cache
+	clock clockwork.Clock
+}
+
+func newAppleVerifier(cache cache.Cache, clock clockwork.Clock) *appleVerifier {
+	return &appleVerifier{
+		cache: cache,
+		clock: clock,
+	}
+}
+
+// verify validates an App Attest attestation the first time a key is seen
+// and an App Attest assertion for keys which have already been attested.
+func (v *appleVerifier) verify(ctx context.Context, instance *model.Instance, params VerifyParams) error {
+	if !instance.AppleAppID.Valid || instance.AppleAppID.String == "" {
+		return errNotConfigured
+	}
+	if params.KeyID == "" {
+		return newVerificationError("missing key identifier")
+	}
+
+	token, err := base64.StdEncoding.DecodeString(params.Token)
+	if err != nil {
+		return newVerificationError("token is not valid base64")
+	}
+
+	cacheKey := appleDeviceKeyCacheKey(instance.ID, params.KeyID)
+	var deviceKey appleDeviceKey
+	if err := v.cache.Get(ctx, cacheKey, &deviceKey); err != nil {
+		return fmt.Errorf("attestation/apple: fetching device key %s: %w", params.KeyID, err)
+	}
+
+	if len(deviceKey.PublicKey) == 0 {
+		deviceKey, err = v.verifyAttestation(instance.AppleAppID.String, instance.IsDevelopment(), params.KeyID, params.Challenge, token)
+	} else {
+		deviceKey, err = v.verifyAssertion(instance.AppleAppID.String, deviceKey, params.Challenge, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := v.cache.Set(ctx, cacheKey, deviceKey, deviceKeyTTL); err != nil {
+		return fmt.Errorf("attestation/apple: storing device key %s: %w", params.KeyID, err)
+	}
+	return nil
+}
+
+// verifyAttestation verifies the attestation of a new device key. Keys
+// generated by development builds of the app are only accepted by
+// development instances.
+func (v *appleVerifier) verifyAttestation(appID string, development bool, keyID, challenge string, token []byte) (appleDeviceKey, error) {
+	var attestation appleAttestationObject
+	if err := cbor.Unmarshal(token, &attestation); err != nil {
+		return appleDeviceKey{}, newVerificationError("malformed attestation object")
+	}
+	if attestation.Format != appleAttestationFormat {
+		return appleDeviceKey{}, newVerificationError("unexpected attestation format %q", attestation.Format)
+	}
+	if len(attestation.AttStmt.X5C) < 2 {
+		return appleDeviceKey{}, newVerificationError("missing certificate chain")
+	}
+
+	credCert, err := x509.ParseCertificate(attestation.AttStmt.X5C[0])
+	if err != nil {
+		return appleDeviceKey{}, newVerificationError("malformed credential certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, der := range attestation.AttStmt.X5C[1:] {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return appleDeviceKey{}, newVerificationError("malformed intermediate certificate")
+		}
+		intermediates.AddCert(cert)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(cenv.Get(cenv.AppleAppAttestRootCA))) {
+		return appleDeviceKey{}, fmt.Errorf("attestation/apple: invalid App Attest root certificate")
+	}
+	_, err = credCert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   v.clock.Now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return appleDeviceKey{}, newVerificationError("certificate chain is not trusted")
+	}
+
+	// The nonce extension must match SHA256(authData || SHA256(challenge))
+	clientDataHash := sha256.Sum256([]byte(challenge))
+	expectedNonce := sha256.Sum256(append(bytes.Clone(attestation.AuthData), clientDataHash[:]...))
+	nonce, err := appleCertificateNonce(credCert)
+	if err != nil || !bytes.Equal(nonce, expectedNonce[:]) {
+		return appleDeviceKey{}, newVerificationError("challenge mismatch")
+	}
+
+	publicKey, ok := credCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return appleDeviceKey{}, newVerificationError("unexpected credential public key type")
+	}
+	ecdhKey, err := publicKey.ECDH()
+	if err != nil {
+		return appleDeviceKey{}, newVerificationError("unexpected credential public key type")
+	}
+	publicKeyHash := sha256.Sum256(ecdhKey.Bytes())
+	if base64.StdEncoding.EncodeToString(publicKeyHash[:]) != keyID {
+		return appleDeviceKey{}, newVerificationError("key identifier mismatch")
+	}
+
+	authData, err := parseAppleAuthenticatorData(attestation.AuthData, true)
+	if err != nil {
+		return appleDeviceKey{}, err
+	}
+	appIDHash := sha256.Sum256([]byte(appID))
+	if !bytes.Equal(authData.rpIDHash, appIDHash[:]) {
+		return appleDeviceKey{}, newVerificationError("app identifier mismatch")
+	}
+	if authData.counter != 0 {
+		return appleDeviceKey{}, newVerificationError("unexpected counter")
+	}
+	if !appleAAGUIDAllowed(authData.aaguid, development) {
+		return appleDeviceKey{}, newVerificationError("unexpected environment")
+	}
+	if !bytes.Equal(authData.credentialID, publicKeyHash[:]) {
+		return appleDeviceKey{}, newVerificationError("credential identifier mismatch")
+	}
+
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return appleDeviceKey{}, fmt.Errorf("attestation/apple: marshaling public key: %w", err)
+	}
+	return appleDeviceKey{PublicKey: publicKeyDER, Counter: authData.counter}, nil
+}
+
+// appleAAGUIDAllowed returns whether a key attested in the App Attest
+// environment identified by aaguid is accepted.
+func appleAAGUIDAllowed(aaguid []byte, development bool) bool {
+	if bytes.Equal(aaguid, appleAAGUIDProduction) {
+		return true
+	}
+	return development && bytes.Equal(aaguid, appleAAGUIDDevelopment)
+}
+
+func (v *appleVerifier) verifyAssertion(appID string, deviceKey appleDeviceKey, challenge string, token []byte) (appleDeviceKey, error) {
+	var assertion appleAssertionObject
+	if err := cbor.Unmarshal(token, &assertion); err != nil {
+		return appleDeviceKey{}, newVerificationError("malformed assertion object")
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(deviceKey.PublicKey)
+	if err != nil {
+		return appleDeviceKey{}, fmt.Errorf("attestation/apple: parsing cached public key: %w", err)
+	}
+	publicKey, ok := parsedKey.(*ecdsa.PublicKey)
+	if !ok {
+		return appleDeviceKey{}, fmt.Errorf("attestation/apple: unexpected cached public key type %T", parsedKey)
+	}
+
+	clientDataHash := sha256.Sum256([]byte(challenge))
+	nonce := sha256.Sum256(append(bytes.Clone(assertion.AuthenticatorData), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	if !ecdsa.VerifyASN1(publicKey, digest[:], assertion.Signature) {
+		return appleDeviceKey{}, newVerificationError("invalid assertion signature")
+	}
+
+	authData, err := parseAppleAuthenticatorData(assertion.AuthenticatorData, false)
+	if err != nil {
+		return appleDeviceKey{}, err
+	}
+	appIDHash := sha256.Sum256([]byte(appID))
+	if !bytes.Equal(authData.rpIDHash, appIDHash[:]) {
+		return appleDeviceKey{}, newVerificationError("app identifier mismatch")
+	}
+	// The counter must always increase, otherwise the assertion is replayed.
+	if authData.counter <= deviceKey.Counter {
+		return appleDeviceKey{}, newVerificationError("assertion counter did not increase")
+	}
+
+	deviceKey.Counter = authData.counter
+	return deviceKey, nil
+}
+
+func appleCertificateNonce(cert *x509.Certificate) ([]byte, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(appleNonceExtensionOID) {
+			continue
+		}
+		var value struct {
+			Nonce []byte `asn1:"tag:1,explicit"`
+		}
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil {
+			return nil, err
+		}
+		return value.Nonce, nil
+	}
+	return nil, fmt.Errorf("attestation/apple: nonce extension not found")
+}
+
+// parseAppleAuthenticatorData parses the WebAuthn style authenticator data
+// which App Attest uses for both attestations and assertions.
+func parseAppleAuthenticatorData(data []byte, withCredential bool) (appleAuthenticatorData, error) {
+	const (
+		rpIDHashLength = 32
+		headerLength   = rpIDHashLength + 1 + 4
+		aaguidLength   = 16
+	)
+	if len(data) < headerLength {
+		return appleAuthenticatorData{}, newVerificationError("malformed authenticator data")
+	}
+
+	authData := appleAuthenticatorData{
+		rpIDHash: data[:rpIDHashLength],
+		counter:  binary.BigEndian.Uint32(data[rpIDHashLength+1 : headerLength]),
+	}
+	if !withCredential {
+		return authData, nil
+	}
+
+	rest := data[headerLength:]
+	if len(rest) < aaguidLength+2 {
+		return appleAuthenticatorData{}, newVerificationError("malformed authenticator data")
+	}
+	authData.aaguid = rest[:aaguidLength]
+	credentialIDLength := int(binary.BigEndian.Uint16(rest[aaguidLength : aaguidLength+2]))
+	rest = rest[aaguidLength+2:]
+	if len(rest) < credentialIDLength {
+		return appleAuthenticatorData{}, newVerificationError("malformed authenticator data")
+	}
+	authData.credentialID = rest[:credentialIDLength]
+	return authData, nil
+}
+
+func appleDeviceKeyCacheKey(instanceID, keyID string) string {
+	return fmt.Sprintf("attestation_apple_device_key:%s:%s", instanceID, keyID)
+}
@@ -0,0 +1,227 @@
+package attestation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/rand"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// Enforcement describes how strictly an instance enforces device
+// attestation for native clients.
+type Enforcement string
+
+const (
+	// EnforcementOff disables device attestation completely.
+	EnforcementOff Enforcement = "off"
+	// EnforcementMonitor verifies attestations when provided and records the
+	// outcome on the client, but never rejects a request.
+	EnforcementMonitor Enforcement = "monitor"
+	// EnforcementRequired rejects requests without a valid attestation.
+	EnforcementRequired Enforcement = "required"
+)
+
+var Enforcements = []string{
+	string(EnforcementOff),
+	string(EnforcementMonitor),
+	string(EnforcementRequired),
+}
+
+func IsValidEnforcement(val string) bool {
+	for _, enforcement := range Enforcements {
+		if val == enforcement {
+			return true
+		}
+	}
+	return false
+}
+
+// EnforcementForInstance returns the attestation enforcement level of the
+// given instance. Instances that never configured it have attestation off.
+func EnforcementForInstance(instance *model.Instance) Enforcement {
+	if !IsValidEnforcement(instance.AttestationEnforcement) {
+		return EnforcementOff
+	}
+	return Enforcement(instance.AttestationEnforcement)
+}
+
+// Status is the outcome of the device attestation, as stored on the client.
+type Status string
+
+const (
+	StatusVerified Status = "verified"
+	StatusFailed   Status = "failed"
+	StatusMissing  Status = "missing"
+)
+
+// Supported attestation platforms
+const (
+	PlatformApple  = "apple"
+	PlatformGoogle = "google"
+)
+
+const (
+	challengeTTL = 5 * time.Minute
+	deviceKeyTTL = 30 * 24 * time.Hour
+)
+
+// errNotConfigured is returned by verifiers when the instance lacks the
+// native application settings needed to verify an attestation.
+var errNotConfigured = errors.New("attestation: platform not configured")
+
+// verificationError describes why an attestation was rejected. The reason
+// is surfaced to the client, so it must not leak sensitive information.
+type verificationError struct {
+	reason string
+}
+
+func (e verificationError) Error() string {
+	return "attestation: " + e.reason
+}
+
+func newVerificationError(format string, args ...any) error {
+	return verificationError{reason: fmt.Sprintf(format, args...)}
+}
+
+type VerifyParams struct {
+	Platform  string
+	Token     string
+	KeyID     string
+	Challenge string
+}
+
+// challengeStore keeps the issued challenges until they're consumed. It's
+// implemented by cache.Cache.
+type challengeStore interface {
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// GetDel deletes the key, and reports whether it existed, atomically.
+	GetDel(ctx context.Context, key string) (bool, error)
+}
+
+type Service struct {
+	challenges challengeStore
+	clock      clockwork.Clock
+
+	apple  *appleVerifier
+	google *googleVerifier
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		challenges: deps.Cache(),
+		clock:      deps.Clock(),
+		apple:      newAppleVerifier(deps.Cache(), deps.Clock()),
+		google:     newGoogleVerifier(deps.Clock()),
+	}
+}
+
+// CreateChallenge generates a single-use challenge that native clients
+// must embed in their attestation. It returns the challenge along with its
+// expiration time.
+func (s *Service) CreateChallenge(ctx context.Context, instanceID string) (string, time.Time, error) {
+	challenge, err := rand.Token()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("attestation/createChallenge: generating token: %w", err)
+	}
+
+	if err := s.challenges.Set(ctx, challengeCacheKey(instanceID, challenge), true, challengeTTL); err != nil {
+		return "", time.Time{}, fmt.Errorf("attestation/createChallenge: storing challenge for instance %s: %w", instanceID, err)
+	}
+	return challenge, s.clock.Now().Add(challengeTTL), nil
+}
+
+// Verify checks the device attestation of the request against the
+// enforcement level of the instance. An empty status is returned when
+// attestation is off for the instance.
+func (s *Service) Verify(ctx context.Context, instance *model.Instance, params VerifyParams) (Status, apierror.Error) {
+	enforcement := EnforcementForInstance(instance)
+	if enforcement == EnforcementOff {
+		return "", nil
+	}
+
+	if params.Token == "" {
+		if enforcement == EnforcementRequired {
+			return "", apierror.DeviceAttestationRequired()
+		}
+		return StatusMissing, nil
+	}
+
+	validChallenge, err := s.consumeChallenge(ctx, instance.ID, params.Challenge)
+	if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+	if !validChallenge {
+		if enforcement == EnforcementRequired {
+			return "", apierror.DeviceAttestationChallengeInvalid()
+		}
+		return StatusFailed, nil
+	}
+
+	switch params.Platform {
+	case PlatformApple:
+		err = s.apple.verify(ctx, instance, params)
+	case PlatformGoogle:
+		err = s.google.verify(ctx, instance, params)
+	default:
+		err = newVerificationError("unsupported platform %q", params.Platform)
+	}
+
+	if errors.Is(err, errNotConfigured) {
+		return "", apierror.DeviceAttestationNotConfigured(params.Platform)
+	}
+
+	var verificationErr verificationError
+	if errors.As(err, &verificationErr) {
+		log.Warning(ctx, "attestation: %s attestation rejected for instance %s: %s", params.Platform, instance.ID, verificationErr.reason)
+		if enforcement == EnforcementRequired {
+			return "", apierror.DeviceAttestationFailed(params.Platform, verificationErr.reason)
+		}
+		return StatusFailed, nil
+	} else if err != nil {
+		return "", apierror.Unexpected(err)
+	}
+
+	return StatusVerified, nil
+}
+
+// consumeChallenge makes sure the challenge was issued for the instance and
+// removes it, so that it cannot be replayed. The challenge is checked and
+// removed in a single step, so that concurrent requests can't both use it.
+func (s *Service) consumeChallenge(ctx context.Context, instanceID, challenge string) (bool, error) {
+	if challenge == "" {
+		return false, nil
+	}
+
+	consumed, err := s.challenges.GetDel(ctx, challengeCacheKey(instanceID, challenge))
+	if err != nil {
+		return false, fmt.Errorf("attestation/consumeChallenge: consuming challenge for instance %s: %w", instanceID, err)
+	}
+	return consumed, nil
+}
+
+func challengeCacheKey(instanceID, challenge string) string {
+	return fmt.Sprintf("attestation_challenge:%s:%s", instanceID, challenge)
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the attestation status of
+// the current request.
+func NewContext(ctx context.Context, status Status) context.Context {
+	return context.WithValue(ctx, contextKey{}, status)
+}
+
+// FromContext returns the attestation status of the current request, if
+// any was recorded.
+func FromContext(ctx context.Context) (Status, bool) {
+	status, ok := ctx.Value(contextKey{}).(Status)
+	return status, ok && status != ""
+}
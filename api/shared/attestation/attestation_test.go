@@ -0,0 +1,160 @@
+package attestation
+
+import (
+	"context"
+	"encoding/base64"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnforcementForInstance(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		value    string
+		expected Enforcement
+	}{
+		{value: "", expected: EnforcementOff},
+		{value: "off", expected: EnforcementOff},
+		{value: "monitor", expected: EnforcementMonitor},
+		{value: "required", expected: EnforcementRequired},
+		{value: "unknown", expected: EnforcementOff},
+	} {
+		instance := &model.Instance{Instance: &sqbmodel.Instance{AttestationEnforcement: tc.value}}
+		assert.Equal(t, tc.expected, EnforcementForInstance(instance), tc.value)
+	}
+}
+
+func TestMatchesAnyFingerprint(t *testing.T) {
+	t.Parallel()
+
+	raw := []byte{0x14, 0x6d, 0xe9, 0x83, 0xc5, 0x73, 0x06, 0x50}
+	digest := base64.URLEncoding.EncodeToString(raw)
+
+	assert.True(t, matchesAnyFingerprint([]string{digest}, []string{"14:6D:E9:83:C5:73:06:50"}))
+	assert.True(t, matchesAnyFingerprint([]string{digest}, []string{"invalid", "146de983c5730650"}))
+	assert.False(t, matchesAnyFingerprint([]string{digest}, []string{"14:6D:E9:83:C5:73:06:51"}))
+	assert.False(t, matchesAnyFingerprint(nil, []string{"14:6D:E9:83:C5:73:06:50"}))
+}
+
+func TestParseAppleAuthenticatorData(t *testing.T) {
+	t.Parallel()
+
+	rpIDHash := make([]byte, 32)
+	rpIDHash[0] = 0xaa
+	data := append([]byte{}, rpIDHash...)
+	data = append(data, 0x40, 0x00, 0x00, 0x00, 0x07)
+	data = append(data, appleAAGUIDProduction...)
+	data = append(data, 0x00, 0x02, 0x01, 0x02)
+
+	authData, err := parseAppleAuthenticatorData(data, true)
+	require.NoError(t, err)
+	assert.Equal(t, rpIDHash, authData.rpIDHash)
+	assert.Equal(t, uint32(7), authData.counter)
+	assert.Equal(t, appleAAGUIDProduction, authData.aaguid)
+	assert.Equal(t, []byte{0x01, 0x02}, authData.credentialID)
+
+	_, err = parseAppleAuthenticatorData(data[:len(data)-1], true)
+	assert.Error(t, err)
+
+	_, err = parseAppleAuthenticatorData(data[:10], false)
+	assert.Error(t, err)
+}
+
+type fakeChallengeStore struct {
+	mu   sync.Mutex
+	keys map[string]bool
+}
+
+func (s *fakeChallengeStore) Set(_ context.Context, key string, _ interface{}, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key] = true
+	return nil
+}
+
+func (s *fakeChallengeStore) GetDel(_ context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existed := s.keys[key]
+	delete(s.keys, key)
+	return existed, nil
+}
+
+func TestAppleAAGUIDAllowed(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, appleAAGUIDAllowed(appleAAGUIDProduction, false))
+	assert.True(t, appleAAGUIDAllowed(appleAAGUIDProduction, true))
+	assert.False(t, appleAAGUIDAllowed(appleAAGUIDDevelopment, false))
+	assert.True(t, appleAAGUIDAllowed(appleAAGUIDDevelopment, true))
+	assert.False(t, appleAAGUIDAllowed([]byte("appattestunknown"), true))
+}
+
+func TestConsumeChallengeRejectsReplays(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	service := &Service{
+		challenges: &fakeChallengeStore{keys: make(map[string]bool)},
+		clock:      clockwork.NewFakeClock(),
+	}
+
+	challenge, _, err := service.CreateChallenge(ctx, "ins_1")
+	require.NoError(t, err)
+
+	// challenges are scoped to the instance they were issued for
+	valid, err := service.consumeChallenge(ctx, "ins_2", challenge)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = service.consumeChallenge(ctx, "ins_1", challenge)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = service.consumeChallenge(ctx, "ins_1", challenge)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = service.consumeChallenge(ctx, "ins_1", "")
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestConsumeChallengeConcurrently(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	service := &Service{
+		challenges: &fakeChallengeStore{keys: make(map[string]bool)},
+		clock:      clockwork.NewFakeClock(),
+	}
+	challenge, _, err := service.CreateChallenge(ctx, "ins_1")
+	require.NoError(t, err)
+
+	var (
+		wg       sync.WaitGroup
+		consumed atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if valid, err := service.consumeChallenge(ctx, "ins_1", challenge); err == nil && valid {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, consumed.Load())
+}
@@ -0,0 +1,195 @@
+package attestation
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"clerk/model"
+	"clerk/pkg/cenv"
+
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// https://developer.android.com/google/play/integrity/verdicts
+const (
+	playIntegrityScope         = "https://www.googleapis.com/auth/playintegrity"
+	playIntegrityDecodeURL     = "https://playintegrity.googleapis.com/v1/%s:decodeIntegrityToken"
+	playIntegrityMaxTokenAge   = 5 * time.Minute
+	playRecognizedVerdict      = "PLAY_RECOGNIZED"
+	meetsDeviceIntegrity       = "MEETS_DEVICE_INTEGRITY"
+	meetsStrongDeviceIntegrity = "MEETS_STRONG_INTEGRITY"
+)
+
+type androidTarget struct {
+	PackageName            string   `json:"package_name"`
+	Sha256CERTFingerprints []string `json:"sha256_cert_fingerprints"`
+}
+
+type playIntegrityPayload struct {
+	RequestDetails struct {
+		RequestPackageName string `json:"requestPackageName"`
+		Nonce              string `json:"nonce"`
+		TimestampMillis    string `json:"timestampMillis"`
+	} `json:"requestDetails"`
+	AppIntegrity struct {
+		AppRecognitionVerdict   string   `json:"appRecognitionVerdict"`
+		PackageName             string   `json:"packageName"`
+		CertificateSha256Digest []string `json:"certificateSha256Digest"`
+	} `json:"appIntegrity"`
+	DeviceIntegrity struct {
+		DeviceRecognitionVerdict []string `json:"deviceRecognitionVerdict"`
+	} `json:"deviceIntegrity"`
+}
+
+type googleVerifier struct {
+	clock clockwork.Clock
+
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+	httpClientErr  error
+}
+
+func newGoogleVerifier(clock clockwork.Clock) *googleVerifier {
+	return &googleVerifier{
+		clock: clock,
+	}
+}
+
+// verify decodes a Play Integrity token through the Google Play Integrity
+// API and checks its verdicts against the Android app of the instance.
+func (v *googleVerifier) verify(ctx context.Context, instance *model.Instance, params VerifyParams) error {
+	var target androidTarget
+	if len(instance.AndroidTarget) > 0 {
+		if err := json.Unmarshal(instance.AndroidTarget, &target); err != nil {
+			return fmt.Errorf("attestation/google: parsing android target of instance %s: %w", instance.ID, err)
+		}
+	}
+	if target.PackageName == "" {
+		return errNotConfigured
+	}
+
+	payload, err := v.decodeToken(ctx, target.PackageName, params.Token)
+	if err != nil {
+		return err
+	}
+
+	if payload.RequestDetails.RequestPackageName != target.PackageName {
+		return newVerificationError("package name mismatch")
+	}
+	if payload.RequestDetails.Nonce != playIntegrityNonce(params.Challenge) {
+		return newVerificationError("challenge mismatch")
+	}
+
+	var timestampMillis int64
+	if _, err := fmt.Sscan(payload.RequestDetails.TimestampMillis, &timestampMillis); err != nil {
+		return newVerificationError("malformed request timestamp")
+	}
+	if v.clock.Since(time.UnixMilli(timestampMillis)) > playIntegrityMaxTokenAge {
+		return newVerificationError("token has expired")
+	}
+
+	if payload.AppIntegrity.AppRecognitionVerdict != playRecognizedVerdict {
+		return newVerificationError("app is not recognized by Google Play")
+	}
+	if len(target.Sha256CERTFingerprints) > 0 && !matchesAnyFingerprint(payload.AppIntegrity.CertificateSha256Digest, target.Sha256CERTFingerprints) {
+		return newVerificationError("signing certificate mismatch")
+	}
+
+	verdicts := payload.DeviceIntegrity.DeviceRecognitionVerdict
+	if !slices.Contains(verdicts, meetsDeviceIntegrity) && !slices.Contains(verdicts, meetsStrongDeviceIntegrity) {
+		return newVerificationError("device does not meet integrity requirements")
+	}
+	return nil
+}
+
+func (v *googleVerifier) decodeToken(ctx context.Context, packageName, token string) (*playIntegrityPayload, error) {
+	client, err := v.client()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{"integrity_token": token})
+	if err != nil {
+		return nil, fmt.Errorf("attestation/google: marshaling request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf(playIntegrityDecodeURL, packageName), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("attestation/google: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("attestation/google: decoding integrity token: %w", err)
+	}
+	defer res.Body.Close()
+
+	// Google responds with 400 for tokens that are malformed or were issued
+	// for a different app.
+	if res.StatusCode == http.StatusBadRequest {
+		return nil, newVerificationError("integrity token is invalid")
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("attestation/google: unexpected status %d when decoding integrity token", res.StatusCode)
+	}
+
+	var response struct {
+		TokenPayloadExternal playIntegrityPayload `json:"tokenPayloadExternal"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("attestation/google: parsing response: %w", err)
+	}
+	return &response.TokenPayloadExternal, nil
+}
+
+// client lazily builds an HTTP client authenticated with the service account
+// which has access to the Play Integrity API.
+func (v *googleVerifier) client() (*http.Client, error) {
+	v.httpClientOnce.Do(func() {
+		credentials, err := google.CredentialsFromJSON(context.Background(), []byte(cenv.Get(cenv.GooglePlayIntegrityCredentials)), playIntegrityScope)
+		if err != nil {
+			v.httpClientErr = fmt.Errorf("attestation/google: loading credentials: %w", err)
+			return
+		}
+		client := oauth2.NewClient(context.Background(), credentials.TokenSource)
+		client.Timeout = 5 * time.Second
+		v.httpClient = client
+	})
+	return v.httpClient, v.httpClientErr
+}
+
+// playIntegrityNonce returns the nonce the Android SDK embeds in the
+// integrity token for the given challenge.
+func playIntegrityNonce(challenge string) string {
+	return base64.URLEncoding.EncodeToString([]byte(challenge))
+}
+
+// matchesAnyFingerprint compares the digests reported by Google, which are
+// base64 encoded, with the fingerprints configured for the instance, which
+// are colon separated hex strings.
+func matchesAnyFingerprint(digests, fingerprints []string) bool {
+	for _, fingerprint := range fingerprints {
+		raw, err := hex.DecodeString(strings.ReplaceAll(fingerprint, ":", ""))
+		if err != nil {
+			continue
+		}
+		encoded := base64.RawURLEncoding.EncodeToString(raw)
+		for _, digest := range digests {
+			if strings.TrimRight(digest, "=") == encoded {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,310 @@
+// Package backchannel_logout notifies the applications of an instance when
+// sessions are revoked, following the OpenID Connect Back-Channel Logout
+// specification.
+//
+// Instances can configure a list of backchannel logout URIs. Whenever a
+// session of the instance is revoked, either directly or because its user was
+// banned, a delivery is created for every URI and attempted by a job. Each
+// attempt posts a logout token, signed with the current key of the instance,
+// which identifies the user and the session that was revoked. Failed attempts
+// are retried a few times with exponential backoff.
+package backchannel_logout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/signing_keys"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/jobs"
+	"clerk/pkg/jwt"
+	"clerk/pkg/outbound"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// The statuses of a backchannel logout delivery.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// MaxURIs is the number of backchannel logout URIs an instance can have.
+const MaxURIs = 5
+
+const (
+	deliveryTimeout = 10 * time.Second
+	userAgent       = "Clerk-Backchannel-Logout/1.0"
+
+	// the user and session which are sent to URIs under test, so that
+	// receivers can't mistake them for real ones
+	testUserID    = "user_backchannel_logout_test"
+	testSessionID = "sess_backchannel_logout_test"
+)
+
+type Service struct {
+	clock      clockwork.Clock
+	db         database.Database
+	gueClient  *gue.Client
+	httpClient *http.Client
+
+	// services
+	signingKeyService *signing_keys.Service
+
+	// repositories
+	deliveryRepo *repository.BackchannelLogoutDeliveries
+	domainRepo   *repository.Domain
+	instanceRepo *repository.Instances
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:             deps.Clock(),
+		db:                deps.DB(),
+		gueClient:         deps.GueClient(),
+		httpClient:        outbound.NewClient(deliveryTimeout),
+		signingKeyService: signing_keys.NewService(deps),
+		deliveryRepo:      deps.Repositories().BackchannelLogoutDeliveries,
+		domainRepo:        deps.Repositories().Domain,
+		instanceRepo:      deps.Repositories().Instances,
+	}
+}
+
+// ValidateURIs checks that the given URIs can be used as backchannel logout
+// URIs. The specification requires them to be absolute and without a
+// fragment, and since logout tokens identify users they're only sent over
+// https.
+func ValidateURIs(param string, uris []string) apierror.Error {
+	if len(uris) > MaxURIs {
+		return apierror.FormInvalidParameterFormat(param, fmt.Sprintf("At most %d backchannel logout URIs are allowed.", MaxURIs))
+	}
+
+	seen := make(map[string]bool, len(uris))
+	for _, uri := range uris {
+		parsed, err := url.Parse(uri)
+		if err != nil || !parsed.IsAbs() || parsed.Host == "" {
+			return apierror.FormInvalidParameterFormat(param)
+		}
+		if parsed.Scheme != "https" {
+			return apierror.FormInvalidParameterFormat(param, "Backchannel logout URIs must use https.")
+		}
+		if strings.Contains(uri, "#") {
+			return apierror.FormInvalidParameterFormat(param, "Backchannel logout URIs must not contain a fragment.")
+		}
+		if seen[uri] {
+			return apierror.FormInvalidParameterFormat(param, "Backchannel logout URIs must be unique.")
+		}
+		seen[uri] = true
+	}
+	return nil
+}
+
+// UpdateURIs replaces the backchannel logout URIs of the instance. Pending
+// deliveries to URIs which were removed are dropped when they're next
+// attempted.
+func (s *Service) UpdateURIs(ctx context.Context, exec database.Executor, instance *model.Instance, uris []string) error {
+	instance.BackchannelLogoutURIs = uris
+	return s.instanceRepo.UpdateBackchannelLogoutURIs(ctx, exec, instance)
+}
+
+// Enqueue creates a delivery to every backchannel logout URI of the instance
+// for each of the given revoked sessions, and schedules their first attempt.
+func (s *Service) Enqueue(ctx context.Context, exec database.Executor, instance *model.Instance, sessions ...*model.Session) error {
+	for _, uri := range instance.BackchannelLogoutURIs {
+		for _, session := range sessions {
+			delivery := &model.BackchannelLogoutDelivery{BackchannelLogoutDelivery: &sqbmodel.BackchannelLogoutDelivery{
+				InstanceID: instance.ID,
+				SessionID:  session.ID,
+				UserID:     session.UserID,
+				URI:        uri,
+				Status:     DeliveryStatusPending,
+			}}
+			if err := s.deliveryRepo.Insert(ctx, exec, delivery); err != nil {
+				return fmt.Errorf("backchannel_logout/enqueue: creating delivery of session %s to %s: %w", session.ID, uri, err)
+			}
+			if err := s.schedule(ctx, exec, delivery, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Attempt sends the logout token of the given delivery to its URI. It's
+// invoked by the job scheduled for every attempt. Failed attempts are
+// retried until the delivery runs out of attempts, at which point it's
+// marked as failed.
+func (s *Service) Attempt(ctx context.Context, deliveryID string) error {
+	delivery, err := s.deliveryRepo.QueryByID(ctx, s.db, deliveryID)
+	if err != nil {
+		return fmt.Errorf("backchannel_logout/attempt: fetching delivery %s: %w", deliveryID, err)
+	}
+	if delivery == nil || delivery.Status != DeliveryStatusPending {
+		// nothing left to do, e.g. the job was retried after a success
+		return nil
+	}
+
+	instance, err := s.instanceRepo.FindByID(ctx, s.db, delivery.InstanceID)
+	if err != nil {
+		return fmt.Errorf("backchannel_logout/attempt: fetching instance %s: %w", delivery.InstanceID, err)
+	}
+	if !hasURI(instance, delivery.URI) {
+		// the URI was removed after the delivery was created
+		_, err := s.deliveryRepo.DeleteByID(ctx, s.db, delivery.ID)
+		return err
+	}
+
+	// the token is signed for every attempt, so that it's never stale
+	logoutToken, err := s.signLogoutToken(ctx, instance, logoutTokenParams{
+		ID:        delivery.ID,
+		UserID:    delivery.UserID,
+		SessionID: delivery.SessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("backchannel_logout/attempt: %w", err)
+	}
+	statusCode, sendErr := s.post(ctx, delivery.URI, logoutToken)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = null.TimeFrom(s.clock.Now().UTC())
+	delivery.LastStatusCode = null.NewInt(statusCode, statusCode != 0)
+	columns := []string{
+		sqbmodel.BackchannelLogoutDeliveryColumns.Attempts,
+		sqbmodel.BackchannelLogoutDeliveryColumns.LastAttemptAt,
+		sqbmodel.BackchannelLogoutDeliveryColumns.LastStatusCode,
+		sqbmodel.BackchannelLogoutDeliveryColumns.Status,
+	}
+
+	if sendErr == nil {
+		delivery.Status = DeliveryStatusSucceeded
+		return s.deliveryRepo.Update(ctx, s.db, delivery, columns...)
+	}
+	log.Warning(ctx, "backchannel_logout/attempt: attempt %d of delivery %s failed: %s", delivery.Attempts, delivery.ID, sendErr)
+
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		nextAttemptAt, retry := NextAttemptAt(delivery.Attempts, s.clock.Now())
+		if !retry {
+			delivery.Status = DeliveryStatusFailed
+		}
+		if err := s.deliveryRepo.Update(ctx, tx, delivery, columns...); err != nil {
+			return true, err
+		}
+		if !retry {
+			return false, nil
+		}
+		err := s.schedule(ctx, tx, delivery, &nextAttemptAt)
+		return err != nil, err
+	})
+}
+
+// TestResult is the outcome of sending a test logout token to a URI.
+// StatusCode is zero if the URI couldn't be reached. The response body is
+// never part of it, so that URIs can't be used to read responses from
+// arbitrary servers.
+type TestResult struct {
+	URI        string
+	StatusCode int
+	Err        error
+}
+
+// Test sends a logout token for a made up user and session to the given URI,
+// so that instances can check that their receiver accepts our logout tokens
+// before they configure it.
+func (s *Service) Test(ctx context.Context, instance *model.Instance, uri string) (*TestResult, error) {
+	logoutToken, err := s.signLogoutToken(ctx, instance, logoutTokenParams{
+		ID:        fmt.Sprintf("test_%d", s.clock.Now().UnixNano()),
+		UserID:    testUserID,
+		SessionID: testSessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statusCode, err := s.post(ctx, uri, logoutToken)
+	return &TestResult{URI: uri, StatusCode: statusCode, Err: err}, nil
+}
+
+// post sends the logout token to the URI. Receivers must respond with 200
+// once the session has been logged out, but any 2xx is accepted. Tokens are
+// only sent to public addresses, and redirects aren't followed.
+func (s *Service) post(ctx context.Context, uri, logoutToken string) (int, error) {
+	form := url.Values{"logout_token": {logoutToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, fmt.Errorf("backchannel_logout: creating request to %s: %w", uri, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", userAgent)
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("backchannel_logout: sending logout token to %s: %w", uri, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("backchannel_logout: unexpected status %d from %s", res.StatusCode, uri)
+	}
+	return res.StatusCode, nil
+}
+
+// signLogoutToken signs a logout token with the current key of the
+// instance, so that receivers can verify it with the JWKS of the instance.
+// The URIs are configured for the instance rather than an OAuth application,
+// so the audience is the instance itself.
+func (s *Service) signLogoutToken(ctx context.Context, instance *model.Instance, params logoutTokenParams) (string, error) {
+	domain, err := s.domainRepo.FindByID(ctx, s.db, instance.ActiveDomainID)
+	if err != nil {
+		return "", fmt.Errorf("backchannel_logout: fetching domain of instance %s: %w", instance.ID, err)
+	}
+	params.Issuer = domain.FapiURL()
+	params.Audience = instance.ID
+
+	signingKey, err := s.signingKeyService.Current(ctx, s.db, instance)
+	if err != nil {
+		return "", fmt.Errorf("backchannel_logout: fetching signing key of instance %s: %w", instance.ID, err)
+	}
+
+	token, err := jwt.GenerateToken(signingKey.PrivateKey, newLogoutClaims(params, s.clock.Now().UTC()), signingKey.Algorithm, jwt.WithKID(signingKey.KID))
+	if err != nil {
+		return "", fmt.Errorf("backchannel_logout: signing logout token %s: %w", params.ID, err)
+	}
+	return token, nil
+}
+
+// schedule enqueues the job which attempts the delivery, at the given time
+// or as soon as possible if it's nil.
+func (s *Service) schedule(ctx context.Context, exec database.Executor, delivery *model.BackchannelLogoutDelivery, runAt *time.Time) error {
+	opts := []jobs.JobOptionFunc{jobs.WithTxIfApplicable(exec)}
+	if runAt != nil {
+		opts = append(opts, jobs.WithRunAt(runAt))
+	}
+
+	err := jobs.DeliverBackchannelLogout(ctx, s.gueClient, jobs.DeliverBackchannelLogoutArgs{DeliveryID: delivery.ID}, opts...)
+	if err != nil {
+		return fmt.Errorf("backchannel_logout: scheduling delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
+
+func hasURI(instance *model.Instance, uri string) bool {
+	for _, configured := range instance.BackchannelLogoutURIs {
+		if configured == uri {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package backchannel_logout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/pkg/outbound"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogoutClaims(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	claims := newLogoutClaims(logoutTokenParams{
+		ID:        "bld_123",
+		Issuer:    "https://clerk.example.com",
+		Audience:  "ins_123",
+		UserID:    "user_123",
+		SessionID: "sess_123",
+	}, now)
+
+	raw, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, "bld_123", decoded["jti"])
+	assert.Equal(t, "https://clerk.example.com", decoded["iss"])
+	assert.Equal(t, "ins_123", decoded["aud"])
+	assert.Equal(t, "user_123", decoded["sub"])
+	assert.Equal(t, "sess_123", decoded["sid"])
+	assert.Equal(t, float64(now.Unix()), decoded["iat"])
+	assert.Equal(t, map[string]any{EventType: map[string]any{}}, decoded["events"])
+	assert.NotContains(t, decoded, "nonce")
+}
+
+func TestNextAttemptAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for attempts, want := range map[int]time.Duration{
+		1: 30 * time.Second,
+		2: time.Minute,
+		3: 2 * time.Minute,
+		5: 8 * time.Minute,
+	} {
+		next, retry := NextAttemptAt(attempts, now)
+		assert.True(t, retry)
+		assert.Equal(t, now.Add(want), next, "attempt %d", attempts)
+	}
+
+	_, retry := NextAttemptAt(MaxAttempts, now)
+	assert.False(t, retry)
+}
+
+func TestPostRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the logout token was sent to a loopback address")
+	}))
+	defer server.Close()
+
+	s := &Service{httpClient: outbound.NewClient(time.Second)}
+	statusCode, err := s.post(context.Background(), server.URL, "token")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, outbound.ErrForbiddenAddress))
+	assert.Zero(t, statusCode)
+}
@@ -0,0 +1,70 @@
+package backchannel_logout
+
+import (
+	"time"
+
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
+)
+
+// EventType is the member of the events claim which marks a token as a
+// logout token.
+const EventType = "http://schemas.openid.net/event/backchannel-logout"
+
+// logoutTokenLifetime is for how long a logout token is valid. Tokens are
+// signed again for every attempt, so it only has to cover a single request.
+const logoutTokenLifetime = 2 * time.Minute
+
+// logoutClaims are the claims of a logout token, as defined by the OpenID
+// Connect Back-Channel Logout specification. Logout tokens must never carry
+// a nonce, so that they can't be mistaken for ID tokens.
+type logoutClaims struct {
+	josejwt.Claims
+	SessionID string                    `json:"sid"`
+	Events    map[string]map[string]any `json:"events"`
+}
+
+type logoutTokenParams struct {
+	ID        string
+	Issuer    string
+	Audience  string
+	UserID    string
+	SessionID string
+}
+
+func newLogoutClaims(params logoutTokenParams, now time.Time) logoutClaims {
+	return logoutClaims{
+		Claims: josejwt.Claims{
+			ID:       params.ID,
+			Issuer:   params.Issuer,
+			Audience: josejwt.Audience{params.Audience},
+			Subject:  params.UserID,
+			IssuedAt: josejwt.NewNumericDate(now),
+			Expiry:   josejwt.NewNumericDate(now.Add(logoutTokenLifetime)),
+		},
+		SessionID: params.SessionID,
+		Events:    map[string]map[string]any{EventType: {}},
+	}
+}
+
+const (
+	// MaxAttempts is the number of times a logout token is sent before the
+	// delivery is given up on. Receivers are expected to reject tokens which
+	// were issued long ago, so deliveries aren't retried for long either.
+	MaxAttempts = 6
+
+	initialBackoff = 30 * time.Second
+)
+
+// NextAttemptAt returns when a delivery which failed the given number of
+// attempts should be retried, doubling the wait after every attempt. It
+// returns false once the delivery ran out of attempts.
+func NextAttemptAt(attempts int, now time.Time) (time.Time, bool) {
+	if attempts >= MaxAttempts {
+		return time.Time{}, false
+	}
+	backoff := initialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+	}
+	return now.Add(backoff), true
+}
@@ -0,0 +1,269 @@
+package backfills
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/jobs"
+	"clerk/pkg/jobs/backfill"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// The statuses of a backfill.
+const (
+	StatusRunning   = "running"
+	StatusPaused    = "paused"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+type Service struct {
+	clock     clockwork.Clock
+	db        database.Database
+	gueClient *gue.Client
+	tasks     *backfill.Registry
+
+	// repositories
+	backfillRepo *repository.Backfills
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:        deps.Clock(),
+		db:           deps.DB(),
+		gueClient:    deps.GueClient(),
+		tasks:        newTasks(deps),
+		backfillRepo: deps.Repositories().Backfills,
+	}
+}
+
+// TaskNames returns the names of the tasks which can be backfilled.
+func (s *Service) TaskNames() []string {
+	return s.tasks.Names()
+}
+
+// List returns a page of all the backfills, latest first.
+func (s *Service) List(ctx context.Context, params pagination.Params) ([]*model.Backfill, int64, error) {
+	backfills, err := s.backfillRepo.FindAll(ctx, s.db, params)
+	if err != nil {
+		return nil, 0, fmt.Errorf("backfills/list: %w", err)
+	}
+	totalCount, err := s.backfillRepo.Count(ctx, s.db)
+	if err != nil {
+		return nil, 0, fmt.Errorf("backfills/list: counting: %w", err)
+	}
+	return backfills, totalCount, nil
+}
+
+// Read returns the backfill with the given id.
+func (s *Service) Read(ctx context.Context, backfillID string) (*model.Backfill, error) {
+	record, err := s.backfillRepo.QueryByID(ctx, s.db, backfillID)
+	if err != nil {
+		return nil, fmt.Errorf("backfills/read: %s: %w", backfillID, err)
+	}
+	if record == nil {
+		return nil, apierror.BackfillNotFound(backfillID)
+	}
+	return record, nil
+}
+
+// Start creates a backfill of the given task and schedules its first step.
+// A task can only have one backfill which is running or paused at a time.
+func (s *Service) Start(ctx context.Context, taskName string, throttle backfill.Throttle) (*model.Backfill, error) {
+	if _, ok := s.tasks.Lookup(taskName); !ok {
+		return nil, fmt.Errorf("backfills/start: unknown task %s", taskName)
+	}
+	if err := throttle.Validate(); err != nil {
+		return nil, fmt.Errorf("backfills/start: %s: %w", taskName, err)
+	}
+
+	record := &model.Backfill{Backfill: &sqbmodel.Backfill{
+		Task:          taskName,
+		Status:        StatusRunning,
+		BatchSize:     throttle.BatchSize,
+		RowsPerSecond: throttle.RowsPerSecond,
+	}}
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		active, err := s.backfillRepo.QueryActiveByTask(ctx, tx, taskName)
+		if err != nil {
+			return true, err
+		}
+		if active != nil {
+			return true, apierror.BackfillAlreadyActive(taskName, active.ID)
+		}
+
+		if err := s.backfillRepo.Insert(ctx, tx, record); err != nil {
+			return true, err
+		}
+		err = s.scheduleStep(ctx, tx, record, nil)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, fmt.Errorf("backfills/start: %s: %w", taskName, txErr)
+	}
+
+	log.Info(ctx, "backfills: backfill %s of %s started", record.ID, taskName)
+	return record, nil
+}
+
+// Pause stops the backfill after its current batch. Its progress is kept,
+// so that it continues from the same row when it's resumed.
+func (s *Service) Pause(ctx context.Context, backfillID string) (*model.Backfill, error) {
+	return s.transition(ctx, backfillID, StatusPaused, StatusRunning)
+}
+
+// Resume continues a paused or failed backfill from its last checkpoint.
+func (s *Service) Resume(ctx context.Context, backfillID string) (*model.Backfill, error) {
+	return s.transition(ctx, backfillID, StatusRunning, StatusPaused, StatusFailed)
+}
+
+// transition moves the backfill to the given status, as long as it's in one
+// of the statuses it can move from.
+func (s *Service) transition(ctx context.Context, backfillID, to string, from ...string) (*model.Backfill, error) {
+	var record *model.Backfill
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var err error
+		record, err = s.backfillRepo.QueryByIDForUpdate(ctx, tx, backfillID)
+		if err != nil {
+			return true, err
+		}
+		if record == nil {
+			return true, apierror.BackfillNotFound(backfillID)
+		}
+		if !slices.Contains(from, record.Status) {
+			return true, apierror.BackfillInvalidStatus(backfillID, record.Status)
+		}
+
+		resuming := to == StatusRunning
+		record.Status = to
+		columns := []string{sqbmodel.BackfillColumns.Status}
+		if resuming {
+			// steps which were scheduled before the backfill was paused are
+			// left to find an outdated generation, so that only one chain of
+			// steps is ever running
+			record.Generation++
+			record.LastError = null.String{}
+			columns = append(columns, sqbmodel.BackfillColumns.Generation, sqbmodel.BackfillColumns.LastError)
+		}
+		if err := s.backfillRepo.Update(ctx, tx, record, columns...); err != nil {
+			return true, err
+		}
+		if !resuming {
+			return false, nil
+		}
+		err = s.scheduleStep(ctx, tx, record, nil)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, fmt.Errorf("backfills/transition: %s to %s: %w", backfillID, to, txErr)
+	}
+
+	log.Info(ctx, "backfills: backfill %s of %s is now %s", record.ID, record.Task, to)
+	return record, nil
+}
+
+// RunStep processes the next batch of the backfill and schedules the step
+// after it. It's invoked by the run_backfill_step job, which carries the
+// generation of the backfill it was scheduled for.
+func (s *Service) RunStep(ctx context.Context, backfillID string, generation int) error {
+	record, err := s.backfillRepo.QueryByID(ctx, s.db, backfillID)
+	if err != nil {
+		return fmt.Errorf("backfills/runStep: fetching %s: %w", backfillID, err)
+	}
+	if record == nil || record.Status != StatusRunning || record.Generation != generation {
+		return nil
+	}
+
+	task, ok := s.tasks.Lookup(record.Task)
+	if !ok {
+		// the task was removed while its backfill was still running
+		record.Status = StatusFailed
+		record.LastError = null.StringFrom("unknown task " + record.Task)
+		return s.backfillRepo.Update(ctx, s.db, record, sqbmodel.BackfillColumns.Status, sqbmodel.BackfillColumns.LastError)
+	}
+
+	checkpoint, delay, stepErr := backfill.Step(ctx, task, backfill.Throttle{
+		BatchSize:     record.BatchSize,
+		RowsPerSecond: record.RowsPerSecond,
+	}, backfill.Checkpoint{
+		Cursor:    record.Cursor,
+		Processed: record.ProcessedCount,
+	})
+	if stepErr != nil {
+		// the job is retried, and the error is shown until a step succeeds
+		record.LastError = null.StringFrom(stepErr.Error())
+		if err := s.backfillRepo.Update(ctx, s.db, record, sqbmodel.BackfillColumns.LastError); err != nil {
+			log.Warning(ctx, "backfills/runStep: recording error of %s: %s", record.ID, err)
+		}
+		return fmt.Errorf("backfills/runStep: %s: %w", record.ID, stepErr)
+	}
+
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		// the backfill might have been paused while the batch was
+		// processed, in which case the checkpoint is stored but no step
+		// is scheduled
+		current, err := s.backfillRepo.QueryByIDForUpdate(ctx, tx, record.ID)
+		if err != nil {
+			return true, err
+		}
+		if current == nil || current.Generation != generation {
+			// the backfill was resumed in the meantime, and its progress
+			// belongs to the steps of the new generation
+			return false, nil
+		}
+
+		current.Cursor = checkpoint.Cursor
+		current.ProcessedCount = checkpoint.Processed
+		current.LastError = null.String{}
+		columns := []string{
+			sqbmodel.BackfillColumns.Cursor,
+			sqbmodel.BackfillColumns.ProcessedCount,
+			sqbmodel.BackfillColumns.LastError,
+		}
+		stillRunning := current.Status == StatusRunning
+		if stillRunning && checkpoint.Done {
+			current.Status = StatusCompleted
+			current.CompletedAt = null.TimeFrom(s.clock.Now().UTC())
+			columns = append(columns, sqbmodel.BackfillColumns.Status, sqbmodel.BackfillColumns.CompletedAt)
+		}
+		if err := s.backfillRepo.Update(ctx, tx, current, columns...); err != nil {
+			return true, err
+		}
+
+		if !stillRunning || checkpoint.Done {
+			return false, nil
+		}
+		runAt := s.clock.Now().UTC().Add(delay)
+		err = s.scheduleStep(ctx, tx, current, &runAt)
+		return err != nil, err
+	})
+}
+
+func (s *Service) scheduleStep(ctx context.Context, tx database.Tx, record *model.Backfill, runAt *time.Time) error {
+	opts := []jobs.JobOptionFunc{jobs.WithTx(tx)}
+	if runAt != nil {
+		opts = append(opts, jobs.WithRunAt(runAt))
+	}
+
+	err := jobs.RunBackfillStep(ctx, s.gueClient, jobs.RunBackfillStepArgs{
+		BackfillID: record.ID,
+		Generation: record.Generation,
+	}, opts...)
+	if err != nil {
+		return fmt.Errorf("backfills: scheduling step of %s: %w", record.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+package backfills
+
+import (
+	"clerk/pkg/jobs/backfill"
+	"clerk/utils/clerk"
+)
+
+// newTasks returns the tasks which can be backfilled through SAPI. A task
+// is removed once its backfill has completed in every environment.
+func newTasks(_ clerk.Deps) *backfill.Registry {
+	return backfill.NewRegistry()
+}
@@ -25,10 +25,10 @@ type Service struct {
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		db:                      deps.DB(),
-		billingPlanRepo:         repository.NewBillingPlans(),
-		billingSubscriptionRepo: repository.NewBillingSubscriptions(),
-		organizationRepo:        repository.NewOrganization(),
-		userRepo:                repository.NewUsers(),
+		billingPlanRepo:         deps.Repositories().BillingPlans,
+		billingSubscriptionRepo: deps.Repositories().BillingSubscriptions,
+		organizationRepo:        deps.Repositories().Organization,
+		userRepo:                deps.Repositories().Users,
 	}
 }
 
@@ -0,0 +1,186 @@
+// Package bot_detection scores sign-ups by how likely they are to be made by
+// a bot, with the detector the instance chose.
+//
+// The detectors themselves live in pkg/botdetection. This package picks the
+// one of the instance, feeds it with the request and with the recent
+// sign-ups of the client fingerprint, and tells what should happen to the
+// sign-up according to the thresholds of the instance.
+package bot_detection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clerk/pkg/botdetection"
+	"clerk/pkg/cache"
+	"clerk/pkg/cenv"
+	"clerk/pkg/constants"
+	"clerk/pkg/externalapis/turnstile"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+)
+
+var ErrProviderNotConfigured = errors.New("bot_detection: provider not configured")
+
+type Service struct {
+	cache             cache.Cache
+	clock             clockwork.Clock
+	captchaClientPool *turnstile.ClientPool
+}
+
+func NewService(deps clerk.Deps, captchaClientPool *turnstile.ClientPool) *Service {
+	return &Service{
+		cache:             deps.Cache(),
+		clock:             deps.Clock(),
+		captchaClientPool: captchaClientPool,
+	}
+}
+
+// Enabled returns whether sign-ups of the instance are scored.
+func Enabled(userSettings *usersettings.UserSettings) bool {
+	return userSettings.AttackProtection.BotDetection.Enabled
+}
+
+// RequiresChallenge returns whether the provider of the instance scores
+// sign-ups by the challenge their client solved, which only browsers can do.
+func RequiresChallenge(userSettings *usersettings.UserSettings) bool {
+	return userSettings.AttackProtection.BotDetection.Provider != botdetection.ProviderHeuristic
+}
+
+// ActionFor returns what should happen to a sign-up with the given result,
+// according to the settings of the instance.
+func ActionFor(userSettings *usersettings.UserSettings, result *botdetection.Result) botdetection.Action {
+	settings := userSettings.AttackProtection.BotDetection
+	return result.Action(settings.ChallengeThreshold, settings.BlockThreshold)
+}
+
+// Input is what's known about a sign-up request.
+type Input struct {
+	InstanceID        string
+	CaptchaToken      string
+	CaptchaWidgetType *string
+	RemoteIP          string
+	UserAgent         string
+	AcceptLanguage    string
+	Host              string
+	// ClientFingerprint is the fingerprint the client computed for itself,
+	// if any.
+	ClientFingerprint string
+}
+
+// Fingerprint returns the fingerprint of the client which made the request.
+func (i Input) Fingerprint() string {
+	return botdetection.Fingerprint(i.RemoteIP, i.UserAgent, i.AcceptLanguage, i.ClientFingerprint)
+}
+
+// Score scores the sign-up request with the detector of the instance.
+//
+// With the heuristic detector, clients which are asked to solve a challenge
+// retry with a Turnstile token, which is verified so that they aren't asked
+// again.
+func (s *Service) Score(ctx context.Context, userSettings *usersettings.UserSettings, input Input) (*botdetection.Result, error) {
+	settings := userSettings.AttackProtection.BotDetection
+	detector, err := s.newDetector(settings.Provider, userSettings, input)
+	if err != nil {
+		return nil, err
+	}
+
+	request := botdetection.Request{
+		Token:          input.CaptchaToken,
+		RemoteIP:       input.RemoteIP,
+		UserAgent:      input.UserAgent,
+		AcceptLanguage: input.AcceptLanguage,
+		Host:           input.Host,
+		RecentSignUps:  s.recentSignUps(ctx, input.InstanceID, input.Fingerprint()),
+	}
+	result, err := detector.Score(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("bot_detection/score: scoring with %s: %w", detector.Name(), err)
+	}
+
+	if detector.Name() == botdetection.ProviderHeuristic && input.CaptchaToken != "" {
+		challenge, err := botdetection.NewTurnstile(s.turnstileVerifier(userSettings, input)).Score(ctx, request)
+		if err != nil {
+			return nil, fmt.Errorf("bot_detection/score: verifying challenge: %w", err)
+		}
+		result.Challenged = challenge.Challenged
+	}
+	return result, nil
+}
+
+// RecordSignUp keeps track of a sign-up made with the given fingerprint, so
+// that bursts of them can be detected.
+//
+// NOTE: sign-ups are kept in the cache and updated without locking, so a few
+// concurrent ones may go uncounted. That's fine, as bursts are large anyway.
+func (s *Service) RecordSignUp(ctx context.Context, instanceID, fingerprint string) {
+	key := signUpsKey(instanceID, fingerprint)
+	var signUps botdetection.SignUps
+	if err := s.cache.Get(ctx, key, &signUps); err != nil {
+		log.Warning(ctx, "bot_detection: fetching sign ups of %s: %s", fingerprint, err)
+		return
+	}
+	signUps.Record(s.clock.Now().UTC())
+	if err := s.cache.Set(ctx, key, signUps, botdetection.VelocityWindow); err != nil {
+		log.Warning(ctx, "bot_detection: storing sign ups of %s: %s", fingerprint, err)
+	}
+}
+
+func (s *Service) recentSignUps(ctx context.Context, instanceID, fingerprint string) int {
+	var signUps botdetection.SignUps
+	if err := s.cache.Get(ctx, signUpsKey(instanceID, fingerprint), &signUps); err != nil {
+		log.Warning(ctx, "bot_detection: fetching sign ups of %s: %s", fingerprint, err)
+		return 0
+	}
+	return signUps.Count(s.clock.Now().UTC())
+}
+
+func (s *Service) newDetector(name string, userSettings *usersettings.UserSettings, input Input) (botdetection.Detector, error) {
+	switch name {
+	case botdetection.ProviderTurnstile:
+		return botdetection.NewTurnstile(s.turnstileVerifier(userSettings, input)), nil
+	case botdetection.ProviderHCaptcha:
+		if !cenv.IsSet(cenv.HCaptchaSecretKey) {
+			return nil, ErrProviderNotConfigured
+		}
+		return botdetection.NewHCaptcha(cenv.Get(cenv.HCaptchaSiteKey), cenv.Get(cenv.HCaptchaSecretKey)), nil
+	case botdetection.ProviderHeuristic:
+		return botdetection.NewHeuristic(), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, name)
+	}
+}
+
+// turnstileVerifier verifies Turnstile tokens against the widget the client
+// rendered, falling back to the other widget if the client didn't say which
+// one it rendered.
+type turnstileVerifier struct {
+	pool       *turnstile.ClientPool
+	widgetType constants.TurnstileWidgetType
+	fallback   bool
+}
+
+func (s *Service) turnstileVerifier(userSettings *usersettings.UserSettings, input Input) *turnstileVerifier {
+	verifier := &turnstileVerifier{
+		pool:       s.captchaClientPool,
+		widgetType: userSettings.SignUp.CaptchaWidgetType,
+		fallback:   true,
+	}
+	if input.CaptchaWidgetType != nil && constants.TurnstileWidgetTypes.Contains(constants.TurnstileWidgetType(*input.CaptchaWidgetType)) {
+		verifier.widgetType = constants.TurnstileWidgetType(*input.CaptchaWidgetType)
+		verifier.fallback = false
+	}
+	return verifier
+}
+
+func (v *turnstileVerifier) Verify(ctx context.Context, host, token string) (bool, error) {
+	return v.pool.VerifyWithFallback(ctx, host, token, v.widgetType, v.fallback)
+}
+
+func signUpsKey(instanceID, fingerprint string) string {
+	return fmt.Sprintf("sign_up_fingerprints:%s:%s", instanceID, fingerprint)
+}
@@ -21,12 +21,12 @@ type DeleteCascader struct {
 func NewDeleteCascader(deps clerk.Deps) *DeleteCascader {
 	return &DeleteCascader{
 		db:                    deps.DB(),
-		signInRepo:            repository.NewSignIn(),
-		signUpRepo:            repository.NewSignUp(),
-		integrationsRepo:      repository.NewIntegrations(),
-		verificationsRepo:     repository.NewVerification(),
-		syncNoncesRepo:        repository.NewSyncNonces(),
-		sessionActivitiesRepo: repository.NewSessionActivities(),
+		signInRepo:            deps.Repositories().SignIn,
+		signUpRepo:            deps.Repositories().SignUp,
+		integrationsRepo:      deps.Repositories().Integrations,
+		verificationsRepo:     deps.Repositories().Verification,
+		syncNoncesRepo:        deps.Repositories().SyncNonces,
+		sessionActivitiesRepo: deps.Repositories().SessionActivities,
 	}
 }
 
@@ -51,6 +51,7 @@ func (e *edgeClientDatastore) CreateClient(ctx context.Context, instanceID strin
 		ToSignUpAccountTransferId: client.ToSignUpAccountTransferID.Ptr(),
 		PostponeCookieUpdate:      &client.PostponeCookieUpdate,
 		RotatingTokenNonce:        client.RotatingTokenNonce.Ptr(),
+		AttestationStatus:         client.AttestationStatus.Ptr(),
 	}
 
 	// Perform the request
@@ -147,6 +148,9 @@ func (e *edgeClientDatastore) UpdateClient(_ context.Context, instanceID string,
 
 		case ClientColumns.RotatingTokenNonce:
 			requestBody.RotatingTokenNonce = &client.RotatingTokenNonce
+
+		case ClientColumns.AttestationStatus:
+			requestBody.AttestationStatus = &client.AttestationStatus
 		}
 	}
 
@@ -424,6 +428,12 @@ func (e *edgeClientDatastore) UpdateSession(_ context.Context, instanceID, clien
 			} else {
 				requestBody.TokenCreatedEventSentAt = &null.String{}
 			}
+
+		case SessionColumns.RefreshTokenDigest:
+			requestBody.RefreshTokenDigest = &session.RefreshTokenDigest
+
+		case SessionColumns.PreviousRefreshTokenDigest:
+			requestBody.PreviousRefreshTokenDigest = &session.PreviousRefreshTokenDigest
 		}
 	}
 
@@ -455,6 +465,31 @@ func (e *edgeClientDatastore) UpdateSession(_ context.Context, instanceID, clien
 	return EdgeClientServiceSessionResponseToSession(response.Result, session)
 }
 
+func (e *edgeClientDatastore) RotateSessionRefreshToken(_ context.Context, instanceID, clientID string, session *Session, currentDigest null.String) error {
+	response, err := e.apiClient.UpdateSession(edge_client_service.UpdateSessionRequest{
+		InstanceId: instanceID,
+		ClientId:   clientID,
+		SessionId:  session.ID,
+		Data: edge_client_service.PatchInstancesInstanceIdClientsClientIdSessionsSessionIdBody{
+			RefreshTokenDigest:         &session.RefreshTokenDigest,
+			PreviousRefreshTokenDigest: &session.PreviousRefreshTokenDigest,
+			// the Edge Client Service only applies the update if the current
+			// refresh token digest still matches, or responds with a conflict
+			IfRefreshTokenDigest: &currentDigest,
+		},
+	})
+	if err != nil {
+		if errors.As(err, &edge_client_service.UpdateSession409{}) {
+			return ErrRefreshTokenRotated
+		}
+		if errors.As(err, &edge_client_service.UpdateSession404{}) {
+			return ErrNoRecords
+		}
+		return err
+	}
+	return EdgeClientServiceSessionResponseToSession(response.Result, session)
+}
+
 func (e *edgeClientDatastore) DeleteSession(_ context.Context, instanceID, clientID, sessionID string) error {
 	_, err := e.apiClient.DeleteSession(edge_client_service.DeleteSessionRequest{
 		InstanceId: instanceID,
@@ -19,6 +19,8 @@ import (
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
 )
 
 type postgresDataStore struct {
@@ -32,8 +34,8 @@ func newPostgresDatastore(deps clerk.Deps) DataStore {
 	return &postgresDataStore{
 		db:          deps.DB(),
 		cache:       deps.Cache(),
-		clientRepo:  repository.NewClients(),
-		sessionRepo: repository.NewSessions(deps.Clock()),
+		clientRepo:  deps.Repositories().Clients,
+		sessionRepo: deps.Repositories().Sessions,
 	}
 }
 
@@ -265,6 +267,35 @@ func (s *postgresDataStore) UpdateSession(ctx context.Context, _, _ string, sess
 	return nil
 }
 
+func (s *postgresDataStore) RotateSessionRefreshToken(ctx context.Context, _, _ string, session *Session, currentDigest null.String) error {
+	postgresSession := &model.Session{Session: &sqbmodel.Session{}}
+	session.CopyToSessionModel(postgresSession)
+
+	if maintenance.FromContext(ctx) {
+		// we are in maintenance mode, so the current refresh token is claimed
+		// in the cache instead, before storing the updated session there
+		claimed, err := s.cache.SetNX(ctx, maintenanceRefreshTokenKey(postgresSession.ID, currentDigest.String), true, time.Hour)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrRefreshTokenRotated
+		}
+		return s.UpdateSession(ctx, session.InstanceID, session.ClientID, session,
+			SessionColumns.RefreshTokenDigest, SessionColumns.PreviousRefreshTokenDigest)
+	}
+
+	rotated, err := s.sessionRepo.UpdateRefreshTokenDigestsIfCurrent(ctx, s.db, postgresSession, currentDigest)
+	if err != nil {
+		return err
+	}
+	if !rotated {
+		return ErrRefreshTokenRotated
+	}
+	session.CopyFromSessionModel(postgresSession)
+	return nil
+}
+
 func (s *postgresDataStore) DeleteSession(ctx context.Context, instanceID, clientID, sessionID string) error {
 	// Keeping a Transaction here to be able to handle cascading logic later
 	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
@@ -337,6 +368,10 @@ func maintenanceSessionKey(sessionID, instanceID string) string {
 	return fmt.Sprintf("maintenance:%s:%s", instanceID, sessionID)
 }
 
+func maintenanceRefreshTokenKey(sessionID, refreshTokenDigest string) string {
+	return fmt.Sprintf("maintenance:refresh_token:%s:%s", sessionID, refreshTokenDigest)
+}
+
 func maintenanceClientKey(clientID, instanceID string) string {
 	return fmt.Sprintf("maintenance:%s:%s", instanceID, clientID)
 }
@@ -5,6 +5,8 @@ import (
 
 	"clerk/pkg/cenv"
 	"clerk/utils/clerk"
+
+	"github.com/volatiletech/null/v8"
 )
 
 // IsEdgeID check if an entity ID belongs to Cloudflare or Postgres.
@@ -87,6 +89,10 @@ func (e *transitionDatastore) UpdateSession(ctx context.Context, instanceID, cli
 	return e.resolveDatastore(clientID).UpdateSession(ctx, instanceID, clientID, session, cols...)
 }
 
+func (e *transitionDatastore) RotateSessionRefreshToken(ctx context.Context, instanceID, clientID string, session *Session, currentDigest null.String) error {
+	return e.resolveDatastore(clientID).RotateSessionRefreshToken(ctx, instanceID, clientID, session, currentDigest)
+}
+
 func (e *transitionDatastore) DeleteSession(ctx context.Context, instanceID, clientID, sessionID string) error {
 	return e.resolveDatastore(clientID).DeleteSession(ctx, instanceID, clientID, sessionID)
 }
@@ -8,6 +8,10 @@ import (
 // ErrNoRecords is returned by find operations when there are no results.
 var ErrNoRecords = errors.New("client_data.Service: no records in result set")
 
+// ErrRefreshTokenRotated is returned when rotating the refresh token of a
+// session which was already rotated by another request.
+var ErrRefreshTokenRotated = errors.New("client_data.Service: refresh token was already rotated")
+
 // ErrConflict is returned by create / update operations when there is a
 // conflict with an existing resource in the database.
 func NewErrConflict(err error) ErrConflict {
@@ -0,0 +1,79 @@
+package client_data
+
+import (
+	"context"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/set"
+	"clerk/repository"
+	"clerk/utils/database"
+)
+
+var validSessionsOrderByFields = set.New(
+	sqbmodel.SessionColumns.CreatedAt,
+	sqbmodel.SessionColumns.UpdatedAt,
+	sqbmodel.SessionColumns.ExpireAt,
+	sqbmodel.SessionColumns.TouchedAt,
+)
+
+// ListSessionsParams narrow down and sort the sessions of an instance. Every
+// filter is optional.
+type ListSessionsParams struct {
+	UserID   *string
+	ClientID *string
+	Status   *string
+	// CreatedAtAfter and CreatedAtBefore only keep the sessions which were
+	// created within the range. Both ends are exclusive.
+	CreatedAtAfter  *time.Time
+	CreatedAtBefore *time.Time
+	// OrderBy is one of the sortable session columns, optionally prefixed
+	// with + or - for the direction. Sessions are listed latest first
+	// otherwise.
+	OrderBy *string
+}
+
+func (p ListSessionsParams) toMods() (repository.SessionsFindAllModifiers, apierror.Error) {
+	mods := repository.SessionsFindAllModifiers{
+		ClientID:        p.ClientID,
+		UserID:          p.UserID,
+		Status:          p.Status,
+		CreatedAtAfter:  p.CreatedAtAfter,
+		CreatedAtBefore: p.CreatedAtBefore,
+	}
+
+	if p.OrderBy != nil {
+		orderByField, err := repository.ConvertToOrderByField(*p.OrderBy, validSessionsOrderByFields)
+		if err != nil {
+			return mods, err
+		}
+		mods.OrderBy = &orderByField
+	}
+
+	return mods, nil
+}
+
+// ListSessions returns a page of the sessions of the instance which match
+// the params. The sessions are read from Postgres, where the filters on the
+// user, the client and the creation time are backed by indexes scoped to
+// the instance.
+func (s *Service) ListSessions(ctx context.Context, exec database.Executor, instanceID string, params ListSessionsParams, paginationParams pagination.Params) ([]*model.Session, error) {
+	mods, apiErr := params.toMods()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return s.sessionRepo.FindAllWithModifiers(ctx, exec, instanceID, mods, paginationParams)
+}
+
+// CountSessions returns the number of sessions of the instance which match
+// the params, regardless of pagination.
+func (s *Service) CountSessions(ctx context.Context, exec database.Executor, instanceID string, params ListSessionsParams) (int64, error) {
+	mods, apiErr := params.toMods()
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	return s.sessionRepo.CountByInstanceWithModifiers(ctx, exec, instanceID, mods)
+}
@@ -0,0 +1,44 @@
+package client_data
+
+import (
+	"testing"
+	"time"
+
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSessionsParamsToMods(t *testing.T) {
+	t.Parallel()
+
+	userID, status := "user_1", constants.SESSActive
+	createdAtAfter := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	params := ListSessionsParams{
+		UserID:         &userID,
+		Status:         &status,
+		CreatedAtAfter: &createdAtAfter,
+	}
+	mods, apiErr := params.toMods()
+	require.Nil(t, apiErr)
+	assert.Equal(t, &userID, mods.UserID)
+	assert.Nil(t, mods.ClientID)
+	assert.Equal(t, &status, mods.Status)
+	assert.Equal(t, &createdAtAfter, mods.CreatedAtAfter)
+	assert.Nil(t, mods.CreatedAtBefore)
+	// sessions are listed latest first, unless told otherwise
+	assert.Nil(t, mods.OrderBy)
+
+	for _, orderBy := range []string{"created_at", "-touched_at", "+expire_at"} {
+		orderBy := orderBy
+		mods, apiErr = ListSessionsParams{OrderBy: &orderBy}.toMods()
+		require.Nil(t, apiErr, orderBy)
+		assert.NotNil(t, mods.OrderBy, orderBy)
+	}
+
+	// only the sortable columns can be ordered by
+	orderBy := "-token"
+	_, apiErr = ListSessionsParams{OrderBy: &orderBy}.toMods()
+	assert.NotNil(t, apiErr)
+}
@@ -26,6 +26,7 @@ type Client struct {
 	UpdatedAt                 time.Time   `json:"updated_at"`
 	PostponeCookieUpdate      bool        `json:"postpone_cookie_update"`
 	RotatingTokenNonce        null.String `json:"rotating_token_nonce,omitempty"`
+	AttestationStatus         null.String `json:"attestation_status,omitempty"`
 }
 
 // NewClientFromClientModel creates a new Client from a model.Client instance
@@ -55,6 +56,7 @@ func (client *Client) CopyFromClientModel(pgClient *model.Client) {
 	client.UpdatedAt = pgClient.UpdatedAt
 	client.PostponeCookieUpdate = pgClient.PostponeCookieUpdate
 	client.RotatingTokenNonce = pgClient.RotatingTokenNonce
+	client.AttestationStatus = pgClient.AttestationStatus
 }
 
 // CopyToClientModel copies over a *Client values over to a *model.Client
@@ -75,6 +77,7 @@ func (client *Client) CopyToClientModel(pgClient *model.Client) {
 	pgClient.UpdatedAt = client.UpdatedAt
 	pgClient.PostponeCookieUpdate = client.PostponeCookieUpdate
 	pgClient.RotatingTokenNonce = client.RotatingTokenNonce
+	pgClient.AttestationStatus = client.AttestationStatus
 }
 
 // ToClientModel creates a new *model.Client and copies over the values
@@ -99,6 +102,7 @@ var ClientColumns = struct {
 	UpdatedAt                 string
 	PostponeCookieUpdate      string
 	RotatingTokenNonce        string
+	AttestationStatus         string
 }{
 	ID:                        "id",
 	InstanceID:                "instance_id",
@@ -113,6 +117,7 @@ var ClientColumns = struct {
 	UpdatedAt:                 "updated_at",
 	PostponeCookieUpdate:      "postpone_cookie_update",
 	RotatingTokenNonce:        "rotating_token_nonce",
+	AttestationStatus:         "attestation_status",
 }
 
 type Session struct {
@@ -134,6 +139,11 @@ type Session struct {
 	Actor                    null.JSON   `json:"actor,omitempty"`
 	TouchEventSentAt         null.Time   `json:"touch_event_sent_at,omitempty"`
 	TokenCreatedEventSentAt  null.Time   `json:"token_created_event_sent_at,omitempty"`
+	// RefreshTokenDigest is the digest of the current refresh token of a
+	// native session, and PreviousRefreshTokenDigest the digest of the one
+	// it replaced, which is kept to detect reuse.
+	RefreshTokenDigest         null.String `json:"refresh_token_digest,omitempty"`
+	PreviousRefreshTokenDigest null.String `json:"previous_refresh_token_digest,omitempty"`
 }
 
 // NewSessionFromSessionModel creates a new Session from a model.Session instance
@@ -168,6 +178,8 @@ func (session *Session) CopyFromSessionModel(pgSession *model.Session) {
 	session.Actor = pgSession.Actor
 	session.TouchEventSentAt = pgSession.TouchEventSentAt
 	session.TokenCreatedEventSentAt = pgSession.TokenCreatedEventSentAt
+	session.RefreshTokenDigest = pgSession.RefreshTokenDigest
+	session.PreviousRefreshTokenDigest = pgSession.PreviousRefreshTokenDigest
 }
 
 // CopyToSessionModel copies over a *Session values over to a *model.Session
@@ -193,6 +205,8 @@ func (session *Session) CopyToSessionModel(pgSession *model.Session) {
 	pgSession.Actor = session.Actor
 	pgSession.TouchEventSentAt = session.TouchEventSentAt
 	pgSession.TokenCreatedEventSentAt = session.TokenCreatedEventSentAt
+	pgSession.RefreshTokenDigest = session.RefreshTokenDigest
+	pgSession.PreviousRefreshTokenDigest = session.PreviousRefreshTokenDigest
 }
 
 // ToSessionModel creates a new *model.Session and copies over the values
@@ -222,6 +236,9 @@ var SessionColumns = struct {
 	Actor                    string
 	TouchEventSentAt         string
 	TokenCreatedEventSentAt  string
+
+	RefreshTokenDigest         string
+	PreviousRefreshTokenDigest string
 }{
 	ID:                       "id",
 	InstanceID:               "instance_id",
@@ -241,6 +258,9 @@ var SessionColumns = struct {
 	Actor:                    "actor",
 	TouchEventSentAt:         "touch_event_sent_at",
 	TokenCreatedEventSentAt:  "token_created_event_sent_at",
+
+	RefreshTokenDigest:         "refresh_token_digest",
+	PreviousRefreshTokenDigest: "previous_refresh_token_digest",
 }
 
 // ToSessionModels convert all the elements of a *Session slice to *model.Session
@@ -278,6 +298,7 @@ func EdgeClientServiceClientResponseToClient(resp edge_client_service.ClientResp
 	client.UpdatedAt = updatedAtTime
 	client.PostponeCookieUpdate = resp.PostponeCookieUpdate
 	client.RotatingTokenNonce = null.StringFromPtr(resp.RotatingTokenNonce)
+	client.AttestationStatus = null.StringFromPtr(resp.AttestationStatus)
 	return nil
 }
 
@@ -351,5 +372,7 @@ func EdgeClientServiceSessionResponseToSession(resp edge_client_service.SessionR
 	session.Status = string(resp.Status)
 	session.SessionInactivityTimeout = resp.SessionInactivityTimeout
 	session.ActiveOrganizationID = null.StringFromPtr(resp.ActiveOrganizationId)
+	session.RefreshTokenDigest = null.StringFromPtr(resp.RefreshTokenDigest)
+	session.PreviousRefreshTokenDigest = null.StringFromPtr(resp.PreviousRefreshTokenDigest)
 	return nil
 }
@@ -199,6 +199,8 @@ func TestEdgeClientServiceSessionResponseToSession(t *testing.T) {
 	actor := map[string]any{"sub": "user_admin"}
 	actorBytes, err := json.Marshal(actor)
 	require.NoError(t, err)
+	refreshTokenDigest := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	previousRefreshTokenDigest := "60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752"
 
 	// Create a mock response with everything set
 	everythingSetSessionResponse := edge_client_service.SessionResponse{
@@ -220,6 +222,9 @@ func TestEdgeClientServiceSessionResponseToSession(t *testing.T) {
 		TokenCreatedEventSentAt:  &tokenCreatedEventSentAtString,
 		CreatedAt:                createdAtString,
 		UpdatedAt:                updatedAtString,
+
+		RefreshTokenDigest:         &refreshTokenDigest,
+		PreviousRefreshTokenDigest: &previousRefreshTokenDigest,
 	}
 
 	// Copy it & test equality
@@ -245,6 +250,9 @@ func TestEdgeClientServiceSessionResponseToSession(t *testing.T) {
 		TokenCreatedEventSentAt:  null.TimeFrom(tokenCreatedEventSentAtTime),
 		CreatedAt:                createdAtTime,
 		UpdatedAt:                updatedAtTime,
+
+		RefreshTokenDigest:         null.StringFrom(refreshTokenDigest),
+		PreviousRefreshTokenDigest: null.StringFrom(previousRefreshTokenDigest),
 	}, session)
 
 	// Test the case where everything that can be nulled out is set as nil
@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 
+	"clerk/api/shared/session_activities"
+	"clerk/model"
 	"clerk/pkg/clerkerrors"
 	"clerk/pkg/constants"
+	"clerk/repository"
 	"clerk/utils/clerk"
+	"clerk/utils/database"
 
 	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 )
 
 type SessionFilterParams struct {
@@ -51,6 +56,11 @@ type DataStore interface {
 	CreateSession(ctx context.Context, instanceID, clientID string, session *Session) error
 	FindSession(ctx context.Context, instanceID, clientID, sessionID string) (*Session, error)
 	UpdateSession(ctx context.Context, instanceID, clientID string, session *Session, cols ...string) error
+	// RotateSessionRefreshToken updates the refresh token digests of the
+	// session, as long as its current refresh token digest is still the given
+	// one. Otherwise, the refresh token was rotated by another request and
+	// ErrRefreshTokenRotated is returned.
+	RotateSessionRefreshToken(ctx context.Context, instanceID, clientID string, session *Session, currentDigest null.String) error
 	DeleteSession(ctx context.Context, instanceID, clientID, sessionID string) error
 
 	// Sessions (Bulk Fetch)
@@ -75,8 +85,13 @@ type DataStore interface {
 // out to our provided DataStore with little to no code changes elsewhere.
 type Service struct {
 	dataStore
+	db       database.Database
 	clock    clockwork.Clock
 	cascader *DeleteCascader
+
+	sessionActivitiesService *session_activities.Service
+	sessionActivitiesRepo    *repository.SessionActivities
+	sessionRepo              *repository.Sessions
 }
 
 // dataStore is an unexported alias of the DataStore interface
@@ -87,9 +102,13 @@ type dataStore DataStore
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		dataStore: newTransitionDatastore(deps),
-		clock:     deps.Clock(),
-		cascader:  NewDeleteCascader(deps),
+		dataStore:                newTransitionDatastore(deps),
+		db:                       deps.DB(),
+		clock:                    deps.Clock(),
+		cascader:                 NewDeleteCascader(deps),
+		sessionActivitiesService: session_activities.NewService(),
+		sessionActivitiesRepo:    deps.Repositories().SessionActivities,
+		sessionRepo:              deps.Repositories().Sessions,
 	}
 }
 
@@ -122,6 +141,10 @@ func (s *Service) UpdateClientRotatingTokenNonce(ctx context.Context, instanceID
 	return s.UpdateClient(ctx, instanceID, client, ClientColumns.RotatingTokenNonce)
 }
 
+func (s *Service) UpdateClientAttestationStatus(ctx context.Context, instanceID string, client *Client) error {
+	return s.UpdateClient(ctx, instanceID, client, ClientColumns.AttestationStatus)
+}
+
 func (s *Service) UpdateClientCookieValue(ctx context.Context, instanceID string, client *Client) error {
 	return s.UpdateClient(ctx, instanceID, client, ClientColumns.CookieValue)
 }
@@ -161,6 +184,10 @@ func (s *Service) UpdateSessionReplacementSessionID(ctx context.Context, session
 	return s.UpdateSession(ctx, session.InstanceID, session.ClientID, session, SessionColumns.ReplacementSessionID)
 }
 
+func (s *Service) UpdateSessionUserID(ctx context.Context, session *Session) error {
+	return s.UpdateSession(ctx, session.InstanceID, session.ClientID, session, SessionColumns.UserID)
+}
+
 func (s *Service) UpdateClientToSignInAccountTransferID(ctx context.Context, client *Client) error {
 	return s.UpdateClient(ctx, client.InstanceID, client, ClientColumns.ToSignInAccountTransferID)
 }
@@ -293,6 +320,64 @@ func (s *Service) QuerySessionsLatestTouchedByUser(ctx context.Context, instance
 	return latestTouchedSession, nil
 }
 
+// RecordSessionActivity records the activity of a request on the session.
+// Sessions keep a log of activity entries, the latest of which is the one
+// the session points to. A new entry is added whenever the request comes
+// from a different device or location than the latest entry, otherwise the
+// latest entry is refreshed.
+func (s *Service) RecordSessionActivity(ctx context.Context, session *model.Session, activity *model.SessionActivity) error {
+	activity.SessionID = null.StringFrom(session.ID)
+
+	if session.SessionActivityID.Valid {
+		latest, err := s.sessionActivitiesRepo.QueryByID(ctx, s.db, session.SessionActivityID.String)
+		if err != nil {
+			return clerkerrors.WithStacktrace(
+				"client_data.Service: RecordSessionActivity (instance=%s session=%s): %w",
+				session.InstanceID, session.ID, err)
+		}
+		if latest != nil && isSameDevice(latest, activity) {
+			activity.ID = latest.ID
+			return s.sessionActivitiesRepo.Update(ctx, s.db, activity)
+		}
+	}
+
+	if err := s.sessionActivitiesService.CreateSessionActivity(ctx, s.db, session.InstanceID, activity); err != nil {
+		return err
+	}
+
+	cdsSession := NewSessionFromSessionModel(session)
+	cdsSession.SessionActivityID = null.StringFrom(activity.ID)
+	if err := s.UpdateSessionSessionActivityID(ctx, cdsSession); err != nil {
+		return err
+	}
+	cdsSession.CopyToSessionModel(session)
+	return nil
+}
+
+// FindAllSessionActivities returns the activity log of the session, latest
+// entries first.
+func (s *Service) FindAllSessionActivities(ctx context.Context, instanceID, sessionID string) ([]*model.SessionActivity, error) {
+	activities, err := s.sessionActivitiesRepo.FindAllByInstanceAndSessionID(ctx, s.db, instanceID, sessionID)
+	if err != nil {
+		return nil, clerkerrors.WithStacktrace(
+			"client_data.Service: FindAllSessionActivities (instance=%s session=%s): %w",
+			instanceID, sessionID, err)
+	}
+	return activities, nil
+}
+
+// isSameDevice reports whether two activities come from the same device and
+// location.
+func isSameDevice(a, b *model.SessionActivity) bool {
+	return a.IPAddress == b.IPAddress &&
+		a.BrowserName == b.BrowserName &&
+		a.BrowserVersion == b.BrowserVersion &&
+		a.DeviceType == b.DeviceType &&
+		a.IsMobile == b.IsMobile &&
+		a.City == b.City &&
+		a.Country == b.Country
+}
+
 func (s *Service) DeleteSession(ctx context.Context, instanceID, clientID, sessionID string) error {
 	if err := s.dataStore.DeleteSession(ctx, instanceID, clientID, sessionID); err != nil {
 		return err
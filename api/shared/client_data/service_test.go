@@ -0,0 +1,71 @@
+package client_data
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestIsSameDevice(t *testing.T) {
+	t.Parallel()
+
+	newActivity := func() *model.SessionActivity {
+		return &model.SessionActivity{SessionActivity: &sqbmodel.SessionActivity{
+			ID:             "sess_act_1",
+			SessionID:      null.StringFrom("sess_1"),
+			IPAddress:      null.StringFrom("192.0.2.1"),
+			BrowserName:    null.StringFrom("Firefox"),
+			BrowserVersion: null.StringFrom("120.0"),
+			DeviceType:     null.StringFrom("Macintosh"),
+			City:           null.StringFrom("Athens"),
+			Country:        null.StringFrom("GR"),
+		}}
+	}
+
+	for _, tt := range []struct {
+		name   string
+		change func(*model.SessionActivity)
+		same   bool
+	}{
+		{
+			name:   "identical",
+			change: func(*model.SessionActivity) {},
+			same:   true,
+		},
+		{
+			// the latest entry has an ID, the incoming one doesn't yet
+			name:   "different ID",
+			change: func(a *model.SessionActivity) { a.ID = "" },
+			same:   true,
+		},
+		{
+			name:   "different IP address",
+			change: func(a *model.SessionActivity) { a.IPAddress = null.StringFrom("192.0.2.2") },
+		},
+		{
+			name:   "different browser version",
+			change: func(a *model.SessionActivity) { a.BrowserVersion = null.StringFrom("121.0") },
+		},
+		{
+			name:   "mobile",
+			change: func(a *model.SessionActivity) { a.IsMobile = true },
+		},
+		{
+			name:   "unknown country",
+			change: func(a *model.SessionActivity) { a.Country = null.String{} },
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			latest, incoming := newActivity(), newActivity()
+			tt.change(incoming)
+			assert.Equal(t, tt.same, isSameDevice(latest, incoming))
+		})
+	}
+}
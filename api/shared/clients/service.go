@@ -11,9 +11,11 @@ import (
 	"clerk/api/shared/sessions"
 	"clerk/api/shared/sign_in"
 	"clerk/api/shared/sign_up"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/token"
 	"clerk/model"
 	"clerk/pkg/auth"
+	"clerk/pkg/cache"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/request_info"
@@ -26,6 +28,7 @@ import (
 )
 
 type Service struct {
+	cache cache.Cache
 	clock clockwork.Clock
 	db    database.Database
 
@@ -35,6 +38,7 @@ type Service struct {
 	signUpService     *sign_up.Service
 	tokenService      *token.Service
 	clientDataService *client_data.Service
+	signingKeyService *signing_keys.Service
 
 	// repositories
 	identificationRepo *repository.Identification
@@ -45,6 +49,7 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
+		cache:              deps.Cache(),
 		clock:              deps.Clock(),
 		db:                 deps.DB(),
 		sessionService:     sessions.NewService(deps),
@@ -52,10 +57,11 @@ func NewService(deps clerk.Deps) *Service {
 		signUpService:      sign_up.NewService(deps),
 		tokenService:       token.NewService(),
 		clientDataService:  client_data.NewService(deps),
-		identificationRepo: repository.NewIdentification(),
-		signInRepo:         repository.NewSignIn(),
-		signUpRepo:         repository.NewSignUp(),
-		userRepo:           repository.NewUsers(),
+		signingKeyService:  signing_keys.NewService(deps),
+		identificationRepo: deps.Repositories().Identification,
+		signInRepo:         deps.Repositories().SignIn,
+		signUpRepo:         deps.Repositories().SignUp,
+		userRepo:           deps.Repositories().Users,
 	}
 }
 
@@ -159,9 +165,17 @@ func (s *Service) ConvertToClientWithSessions(
 			lastTouched = sessionWithUser
 		}
 
+		// native clients get the tokens of sessions with refresh tokens in
+		// exchange for them only
+		if sessions.RequiresRefreshToken(ctx, sessionWithUser.Session) {
+			continue
+		}
+
 		clientWithSessions.CurrentSessions[i].Token, err = token.GenerateSessionToken(
 			ctx,
 			s.clock,
+			s.cache,
+			s.signingKeyService,
 			s.db,
 			env,
 			sessionWithUser.Session,
@@ -10,8 +10,10 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/shared/emails"
+	"clerk/api/shared/phone_lookup"
 	"clerk/api/shared/sms"
 	shtemplates "clerk/api/shared/templates"
+	"clerk/api/shared/whatsapp"
 	"clerk/model"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
@@ -27,9 +29,11 @@ type Service struct {
 	clock clockwork.Clock
 
 	// services
-	emailService *emails.Service
-	smsService   *sms.Service
-	templateSvc  *shtemplates.Service
+	emailService       *emails.Service
+	phoneLookupService *phone_lookup.Service
+	smsService         *sms.Service
+	templateSvc        *shtemplates.Service
+	whatsAppService    *whatsapp.Service
 
 	// repositories
 	identificationRepo *repository.Identification
@@ -42,14 +46,16 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		clock: deps.Clock(),
 
-		emailService: emails.NewService(deps),
-		smsService:   sms.NewService(deps),
-		templateSvc:  shtemplates.NewService(deps.Clock()),
+		emailService:       emails.NewService(deps),
+		phoneLookupService: phone_lookup.NewService(deps),
+		smsService:         sms.NewService(deps),
+		templateSvc:        shtemplates.NewService(deps.Clock()),
+		whatsAppService:    whatsapp.NewService(deps),
 
-		identificationRepo: repository.NewIdentification(),
-		signInRepo:         repository.NewSignIn(),
-		signUpRepo:         repository.NewSignUp(),
-		userRepo:           repository.NewUsers(),
+		identificationRepo: deps.Repositories().Identification,
+		signInRepo:         deps.Repositories().SignIn,
+		signUpRepo:         deps.Repositories().SignUp,
+		userRepo:           deps.Repositories().Users,
 	}
 }
 
@@ -308,6 +314,45 @@ func (s *Service) SendOrganizationJoinedEmail(ctx context.Context, tx database.T
 	return nil
 }
 
+type EmailOrganizationMembershipExportReady struct {
+	Organization *model.Organization
+	EmailAddress string
+	DownloadURL  string
+	ExpireAt     time.Time
+}
+
+func (s *Service) SendOrganizationMembershipExportReadyEmail(ctx context.Context, tx database.Tx, env *model.Env, params EmailOrganizationMembershipExportReady) error {
+	template, err := s.templateSvc.GetTemplate(ctx, tx, env.Instance.ID, constants.TTEmail, constants.OrganizationMembershipExportReadySlug)
+	if err != nil {
+		return err
+	}
+
+	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
+	if err != nil {
+		return fmt.Errorf("sendOrganizationMembershipExportReadyEmail: populating common email data for instance with id %s: %w", env.Instance.ID, err)
+	}
+
+	data := templates.OrganizationMembershipExportReadyEmailData{
+		CommonEmailData: commonEmailData,
+		Organization:    orgToOrganizationData(params.Organization),
+		DownloadURL:     params.DownloadURL,
+		ExpireAt:        params.ExpireAt,
+	}
+
+	fromEmailName := s.templateSvc.FromEmailName(template, env.Instance)
+	emailData, err := templates.RenderEmail(ctx, data, template, fromEmailName, nil, &params.EmailAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.emailService.Send(ctx, tx, emailData, env)
+	if err != nil {
+		return fmt.Errorf("sendOrganizationMembershipExportReadyEmail: sending email data %+v: %w", emailData, err)
+	}
+
+	return nil
+}
+
 type EmailOrganizationMembershipRequested struct {
 	Organization  *model.Organization
 	EmailAddress  string
@@ -506,6 +551,55 @@ func (s *Service) SendPasswordRemovedEmail(
 	return nil
 }
 
+type EmailMFAReset struct {
+	GreetingName        string
+	PrimaryEmailAddress string
+	// Method is the second factor which was reset, either totp or
+	// backup_code.
+	Method string
+}
+
+// SendMFAResetEmail notifies the user that one of their second factors was
+// reset on their behalf.
+func (s *Service) SendMFAResetEmail(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	params EmailMFAReset,
+) error {
+	template, err := s.templateSvc.GetTemplate(ctx, tx, env.Instance.ID, constants.TTEmail, constants.MFAResetSlug)
+	if err != nil {
+		return err
+	}
+
+	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
+	if err != nil {
+		return fmt.Errorf("sendMFAResetEmail: populating common email data for instance with id %s: %w",
+			env.Instance.ID, err)
+	}
+
+	data := templates.MFAResetEmailData{
+		CommonEmailData:     commonEmailData,
+		GreetingName:        params.GreetingName,
+		PrimaryEmailAddress: params.PrimaryEmailAddress,
+		Method:              params.Method,
+	}
+
+	fromEmailName := s.templateSvc.FromEmailName(template, env.Instance)
+	emailData, err := templates.RenderEmail(ctx, data, template, fromEmailName, nil, &params.PrimaryEmailAddress)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.emailService.Send(ctx, tx, emailData, env)
+	if err != nil {
+		return fmt.Errorf("sendMFAResetEmail: sending email data %+v: %w",
+			emailData, err)
+	}
+
+	return nil
+}
+
 type EmailPrimaryEmailAddressChanged struct {
 	PreviousEmailAddress string
 	NewEmailAddress      string
@@ -550,6 +644,150 @@ func (s *Service) SendPrimaryEmailAddressChangedEmail(
 	return nil
 }
 
+type EmailPrimaryEmailAddressChangeRequested struct {
+	PreviousEmailAddress string
+	NewEmailAddress      string
+	ConfirmURL           string
+	ExpireAt             time.Time
+}
+
+// SendPrimaryEmailAddressChangeRequestedEmail asks the owner of the current
+// primary email address to confirm that it's replaced by a new one.
+func (s *Service) SendPrimaryEmailAddressChangeRequestedEmail(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	params EmailPrimaryEmailAddressChangeRequested,
+) error {
+	template, err := s.templateSvc.GetTemplate(ctx, tx, env.Instance.ID, constants.TTEmail, constants.PrimaryEmailAddressChangeRequestedSlug)
+	if err != nil {
+		return err
+	}
+
+	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
+	if err != nil {
+		return fmt.Errorf("SendPrimaryEmailAddressChangeRequestedEmail: populating common email data for instance with id %s: %w", env.Instance.ID, err)
+	}
+
+	emailData, err := templates.RenderEmail(
+		ctx,
+		templates.PrimaryEmailAddressChangeRequestedEmailData{
+			CommonEmailData: commonEmailData,
+			NewEmailAddress: params.NewEmailAddress,
+			ConfirmURL:      params.ConfirmURL,
+			ExpireAt:        params.ExpireAt,
+		},
+		template,
+		s.templateSvc.FromEmailName(template, env.Instance),
+		nil,
+		&params.PreviousEmailAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.emailService.Send(ctx, tx, emailData, env)
+	if err != nil {
+		return fmt.Errorf("SendPrimaryEmailAddressChangeRequestedEmail: sending email data %+v: %w", emailData, err)
+	}
+
+	return nil
+}
+
+type EmailPasswordResetLink struct {
+	GreetingName        string
+	PrimaryEmailAddress string
+	ResetURL            string
+	ExpireAt            time.Time
+}
+
+// SendPasswordResetLinkEmail sends the user a link to set a new password,
+// which was created for them through BAPI.
+func (s *Service) SendPasswordResetLinkEmail(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	params EmailPasswordResetLink,
+) error {
+	template, err := s.templateSvc.GetTemplate(ctx, tx, env.Instance.ID, constants.TTEmail, constants.PasswordResetLinkSlug)
+	if err != nil {
+		return err
+	}
+
+	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
+	if err != nil {
+		return fmt.Errorf("SendPasswordResetLinkEmail: populating common email data for instance with id %s: %w", env.Instance.ID, err)
+	}
+
+	emailData, err := templates.RenderEmail(
+		ctx,
+		templates.PasswordResetLinkEmailData{
+			CommonEmailData: commonEmailData,
+			GreetingName:    params.GreetingName,
+			ResetURL:        params.ResetURL,
+			ExpireAt:        params.ExpireAt,
+		},
+		template,
+		s.templateSvc.FromEmailName(template, env.Instance),
+		nil,
+		&params.PrimaryEmailAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.emailService.Send(ctx, tx, emailData, env)
+	if err != nil {
+		return fmt.Errorf("SendPasswordResetLinkEmail: sending email data %+v: %w", emailData, err)
+	}
+
+	return nil
+}
+
+type EmailAccountsLinked struct {
+	EmailAddress     string
+	LinkedIdentifier string
+}
+
+func (s *Service) SendAccountsLinkedEmail(
+	ctx context.Context,
+	tx database.Tx,
+	env *model.Env,
+	params EmailAccountsLinked,
+) error {
+	template, err := s.templateSvc.GetTemplate(ctx, tx, env.Instance.ID, constants.TTEmail, constants.AccountsLinkedSlug)
+	if err != nil {
+		return err
+	}
+
+	commonEmailData, err := s.templateSvc.GetCommonEmailData(ctx, env)
+	if err != nil {
+		return fmt.Errorf("SendAccountsLinkedEmail: populating common email data for instance with id %s: %w", env.Instance.ID, err)
+	}
+
+	emailData, err := templates.RenderEmail(
+		ctx,
+		templates.AccountsLinkedEmailData{
+			CommonEmailData:  commonEmailData,
+			LinkedIdentifier: params.LinkedIdentifier,
+		},
+		template,
+		s.templateSvc.FromEmailName(template, env.Instance),
+		nil,
+		&params.EmailAddress,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.emailService.Send(ctx, tx, emailData, env)
+	if err != nil {
+		return fmt.Errorf("SendAccountsLinkedEmail: sending email data %+v: %w", emailData, err)
+	}
+
+	return nil
+}
+
 func (s *Service) SendResetPasswordCodeEmail(
 	ctx context.Context,
 	tx database.Tx,
@@ -634,7 +872,7 @@ func (s *Service) SendResetPasswordCodeSMS(
 
 	smsData.VerificationID = &verificationID
 
-	_, err = s.smsService.Send(ctx, tx, smsData, env)
+	err = s.sendCode(ctx, tx, smsData, code, env)
 	if err != nil {
 		return fmt.Errorf("sendResetPasswordCodeSMS: sending SMS data %+v: %w", smsData, err)
 	}
@@ -681,7 +919,7 @@ func (s *Service) SendVerificationCodeSMS(
 
 	smsData.VerificationID = &verificationID
 
-	_, err = s.smsService.Send(ctx, tx, smsData, env)
+	err = s.sendCode(ctx, tx, smsData, code, env)
 	if err != nil {
 		return fmt.Errorf("sendVerificationCodeSMS: sending SMS data %+v: %w", smsData, err)
 	}
@@ -689,6 +927,42 @@ func (s *Service) SendVerificationCodeSMS(
 	return nil
 }
 
+// sendCode sends the SMS with a one-time code, or a WhatsApp message with
+// the same code if the instance prefers it. The SMS is sent anyway if the
+// WhatsApp message can't be delivered.
+// Instances which look up phone numbers reject the ones their policy doesn't
+// allow before anything is sent.
+func (s *Service) sendCode(ctx context.Context, tx database.Tx, smsData *model.SMSMessageData, code string, env *model.Env) error {
+	if phoneNumber := recipientPhoneNumber(smsData); phoneNumber != "" {
+		if err := s.phoneLookupService.Check(ctx, env.Instance, phoneNumber); err != nil {
+			return err
+		}
+	}
+
+	if whatsapp.IsPreferred(env.Instance) {
+		_, err := s.whatsAppService.Send(ctx, tx, whatsapp.SendParams{
+			Code:     code,
+			Fallback: smsData,
+		}, env)
+		return err
+	}
+
+	_, err := s.smsService.Send(ctx, tx, smsData, env)
+	return err
+}
+
+func recipientPhoneNumber(smsData *model.SMSMessageData) string {
+	if smsData.Identification != nil {
+		if phoneNumber := smsData.Identification.PhoneNumber(); phoneNumber != nil {
+			return *phoneNumber
+		}
+	}
+	if smsData.ToPhoneNumber != nil {
+		return *smsData.ToPhoneNumber
+	}
+	return ""
+}
+
 func (s *Service) SendMagicLinkSignInEmail(
 	ctx context.Context,
 	tx database.Tx,
@@ -762,7 +1036,7 @@ func (s *Service) sendMagicLinkEmail(
 		DeviceActivityData:     deviceActivityData,
 		MagicLink:              link,
 		TTLMinutes:             fmt.Sprintf("%.0f", ttl.Minutes()),
-		IsSignIn:               sourceType == constants.OSTSignIn,
+		IsSignIn:               sourceType == constants.OSTSignIn || sourceType == constants.OSTSignInToken,
 	}
 
 	fromEmailName := s.templateSvc.FromEmailName(template, env.Instance)
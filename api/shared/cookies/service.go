@@ -39,7 +39,7 @@ func NewService(deps clerk.Deps) *Service {
 		clock:             deps.Clock(),
 		db:                deps.DB(),
 		clientDataService: client_data.NewService(deps),
-		devBrowserRepo:    repository.NewDevBrowser(),
+		devBrowserRepo:    deps.Repositories().DevBrowser,
 	}
 }
 
@@ -0,0 +1,260 @@
+// Package custom_fields handles the additional fields instances collect
+// during sign-up, on top of the user attributes.
+//
+// Instances describe the fields in the sign_up.custom_fields schema of their
+// user settings. The values are submitted with the custom_fields parameter
+// of sign-ups, validated against the schema and kept on the sign-up until
+// it's converted to a user, at which point they're copied into the public or
+// unsafe metadata of the user, under their key.
+package custom_fields
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"clerk/api/apierror"
+	clerkjson "clerk/pkg/json"
+	usersettings "clerk/pkg/usersettings/clerk"
+	usersettingsmodel "clerk/pkg/usersettings/model"
+
+	"github.com/volatiletech/sqlboiler/v4/types"
+)
+
+// The types of custom fields.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeSelect  = "select"
+)
+
+// The metadata of the user custom fields are copied into.
+const (
+	MetadataPublic = "public"
+	MetadataUnsafe = "unsafe"
+)
+
+const (
+	// ParamName is the sign-up parameter custom fields are submitted with.
+	ParamName = "custom_fields"
+
+	// MaxFields is the maximum number of custom fields of an instance.
+	MaxFields = 20
+
+	// MaxStringLength is the maximum length of string values.
+	MaxStringLength = 1000
+)
+
+var (
+	fieldTypes    = []string{TypeString, TypeNumber, TypeBoolean, TypeSelect}
+	metadataKinds = []string{MetadataPublic, MetadataUnsafe}
+
+	keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+)
+
+// Fields returns the custom fields the instance collects during sign-up.
+func Fields(userSettings *usersettings.UserSettings) []usersettingsmodel.SignUpCustomField {
+	return userSettings.SignUp.CustomFields
+}
+
+// RequirementName returns the name a custom field is reported with in the
+// required, optional and missing fields of sign-ups.
+func RequirementName(key string) string {
+	return ParamName + "." + key
+}
+
+// ValidateSchema returns an error if the custom fields have invalid or
+// duplicate keys, unknown types or metadata, or if select fields have no
+// options.
+func ValidateSchema(fields []usersettingsmodel.SignUpCustomField) error {
+	if len(fields) > MaxFields {
+		return fmt.Errorf("custom_fields: %d fields, more than %d", len(fields), MaxFields)
+	}
+
+	keys := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if !keyPattern.MatchString(field.Key) {
+			return fmt.Errorf("custom_fields: invalid key %q", field.Key)
+		}
+		if keys[field.Key] {
+			return fmt.Errorf("custom_fields: duplicate key %q", field.Key)
+		}
+		keys[field.Key] = true
+
+		if !slices.Contains(fieldTypes, field.Type) {
+			return fmt.Errorf("custom_fields: %s has unknown type %q", field.Key, field.Type)
+		}
+		if !slices.Contains(metadataKinds, field.Metadata) {
+			return fmt.Errorf("custom_fields: %s has unknown metadata %q", field.Key, field.Metadata)
+		}
+		if field.Type == TypeSelect && len(field.Options) == 0 {
+			return fmt.Errorf("custom_fields: select %s has no options", field.Key)
+		}
+		if field.Type != TypeSelect && len(field.Options) > 0 {
+			return fmt.Errorf("custom_fields: %s has options but isn't a select", field.Key)
+		}
+	}
+	return nil
+}
+
+// Apply validates the submitted values against the custom fields and
+// returns them merged into the values the sign-up already has. Null values
+// clear the field.
+func Apply(fields []usersettingsmodel.SignUpCustomField, submitted []byte, existing types.JSON) (types.JSON, apierror.Error) {
+	var values map[string]json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(submitted))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil || values == nil {
+		return nil, apierror.FormInvalidTypeParameter(ParamName, "object")
+	}
+
+	merged := make(map[string]json.RawMessage)
+	if len(existing) > 0 {
+		if err := json.Unmarshal(existing, &merged); err != nil {
+			return nil, apierror.Unexpected(fmt.Errorf("custom_fields/apply: decoding existing values: %w", err))
+		}
+	}
+
+	var formErrors apierror.Error
+	for key, value := range values {
+		field, ok := fieldByKey(fields, key)
+		if !ok {
+			formErrors = apierror.Combine(formErrors, apierror.FormUnknownParameter(RequirementName(key)))
+			continue
+		}
+		if string(value) == "null" {
+			delete(merged, key)
+			continue
+		}
+		if apiErr := validateValue(field, value); apiErr != nil {
+			formErrors = apierror.Combine(formErrors, apiErr)
+			continue
+		}
+		merged[key] = value
+	}
+	if formErrors != nil {
+		return nil, formErrors
+	}
+
+	encoded, err := json.Marshal(merged)
+	if err != nil {
+		return nil, apierror.Unexpected(fmt.Errorf("custom_fields/apply: encoding values: %w", err))
+	}
+	return encoded, nil
+}
+
+func validateValue(field usersettingsmodel.SignUpCustomField, value json.RawMessage) apierror.Error {
+	name := RequirementName(field.Key)
+	switch field.Type {
+	case TypeString, TypeSelect:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return apierror.FormInvalidTypeParameter(name, "string")
+		}
+		if field.Type == TypeSelect && !slices.Contains(field.Options, s) {
+			return apierror.FormInvalidParameterValueWithAllowed(name, s, field.Options)
+		}
+		if len(s) > MaxStringLength {
+			return apierror.FormParameterValueTooLarge(name, MaxStringLength)
+		}
+		if field.Required && s == "" {
+			return apierror.FormNilParameter(name)
+		}
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(string(value), 64); err != nil {
+			return apierror.FormInvalidTypeParameter(name, "number")
+		}
+	case TypeBoolean:
+		var b bool
+		if err := json.Unmarshal(value, &b); err != nil {
+			return apierror.FormInvalidTypeParameter(name, "boolean")
+		}
+	}
+	return nil
+}
+
+// Missing returns the requirement names of the required custom fields
+// which the sign-up has no value for.
+func Missing(fields []usersettingsmodel.SignUpCustomField, values types.JSON) []string {
+	provided := make(map[string]json.RawMessage)
+	if len(values) > 0 {
+		// values are only ever stored after being validated
+		_ = json.Unmarshal(values, &provided)
+	}
+
+	var missing []string
+	for _, field := range fields {
+		if _, ok := provided[field.Key]; field.Required && !ok {
+			missing = append(missing, RequirementName(field.Key))
+		}
+	}
+	return missing
+}
+
+// ToMetadata copies the values of the custom fields into the public and
+// unsafe metadata of a user, according to the metadata of each field. The
+// values take precedence over any metadata of the same key.
+func ToMetadata(fields []usersettingsmodel.SignUpCustomField, values, publicMetadata, unsafeMetadata types.JSON) (types.JSON, types.JSON, error) {
+	if len(values) == 0 {
+		return publicMetadata, unsafeMetadata, nil
+	}
+	var provided map[string]json.RawMessage
+	if err := json.Unmarshal(values, &provided); err != nil {
+		return nil, nil, fmt.Errorf("custom_fields/toMetadata: decoding values: %w", err)
+	}
+
+	public := make(map[string]json.RawMessage)
+	unsafe := make(map[string]json.RawMessage)
+	for _, field := range fields {
+		value, ok := provided[field.Key]
+		if !ok {
+			continue
+		}
+		if field.Metadata == MetadataPublic {
+			public[field.Key] = value
+		} else {
+			unsafe[field.Key] = value
+		}
+	}
+
+	mergedPublic, err := mergeInto(publicMetadata, public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("custom_fields/toMetadata: public metadata: %w", err)
+	}
+	mergedUnsafe, err := mergeInto(unsafeMetadata, unsafe)
+	if err != nil {
+		return nil, nil, fmt.Errorf("custom_fields/toMetadata: unsafe metadata: %w", err)
+	}
+	return mergedPublic, mergedUnsafe, nil
+}
+
+func mergeInto(metadata types.JSON, values map[string]json.RawMessage) (types.JSON, error) {
+	if len(values) == 0 {
+		return metadata, nil
+	}
+	patch, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		return patch, nil
+	}
+	merged, err := clerkjson.Patch(json.RawMessage(metadata), patch)
+	if err != nil {
+		return nil, err
+	}
+	return types.JSON(merged), nil
+}
+
+func fieldByKey(fields []usersettingsmodel.SignUpCustomField, key string) (usersettingsmodel.SignUpCustomField, bool) {
+	for _, field := range fields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return usersettingsmodel.SignUpCustomField{}, false
+}
@@ -0,0 +1,73 @@
+package custom_fields
+
+import (
+	"testing"
+
+	usersettingsmodel "clerk/pkg/usersettings/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/types"
+)
+
+var testFields = []usersettingsmodel.SignUpCustomField{
+	{Key: "company", Type: TypeString, Required: true, Metadata: MetadataPublic},
+	{Key: "employees", Type: TypeNumber, Metadata: MetadataUnsafe},
+	{Key: "newsletter", Type: TypeBoolean, Metadata: MetadataUnsafe},
+	{Key: "role", Type: TypeSelect, Options: []string{"engineer", "designer"}, Metadata: MetadataPublic},
+}
+
+func TestValidateSchema(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateSchema(testFields))
+
+	duplicate := append(testFields, usersettingsmodel.SignUpCustomField{Key: "company", Type: TypeString, Metadata: MetadataPublic})
+	assert.Error(t, ValidateSchema(duplicate))
+	assert.Error(t, ValidateSchema([]usersettingsmodel.SignUpCustomField{{Key: "Company", Type: TypeString, Metadata: MetadataPublic}}))
+	assert.Error(t, ValidateSchema([]usersettingsmodel.SignUpCustomField{{Key: "role", Type: TypeSelect, Metadata: MetadataPublic}}))
+	assert.Error(t, ValidateSchema([]usersettingsmodel.SignUpCustomField{{Key: "age", Type: "date", Metadata: MetadataPublic}}))
+	assert.Error(t, ValidateSchema([]usersettingsmodel.SignUpCustomField{{Key: "age", Type: TypeNumber, Metadata: "private"}}))
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	values, apiErr := Apply(testFields, []byte(`{"company": "Acme", "employees": 12}`), nil)
+	require.Nil(t, apiErr)
+	assert.JSONEq(t, `{"company": "Acme", "employees": 12}`, string(values))
+
+	// values are merged, and null clears them
+	values, apiErr = Apply(testFields, []byte(`{"employees": null, "role": "engineer"}`), values)
+	require.Nil(t, apiErr)
+	assert.JSONEq(t, `{"company": "Acme", "role": "engineer"}`, string(values))
+
+	_, apiErr = Apply(testFields, []byte(`{"role": "manager"}`), nil)
+	assert.NotNil(t, apiErr)
+	_, apiErr = Apply(testFields, []byte(`{"newsletter": "yes"}`), nil)
+	assert.NotNil(t, apiErr)
+	_, apiErr = Apply(testFields, []byte(`{"unknown": "value"}`), nil)
+	assert.NotNil(t, apiErr)
+	_, apiErr = Apply(testFields, []byte(`["Acme"]`), nil)
+	assert.NotNil(t, apiErr)
+}
+
+func TestMissing(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"custom_fields.company"}, Missing(testFields, nil))
+	assert.Empty(t, Missing(testFields, types.JSON(`{"company": "Acme"}`)))
+}
+
+func TestToMetadata(t *testing.T) {
+	t.Parallel()
+
+	public, unsafe, err := ToMetadata(testFields,
+		types.JSON(`{"company": "Acme", "newsletter": true}`),
+		types.JSON(`{"plan": "pro", "company": "Old"}`),
+		nil,
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"plan": "pro", "company": "Acme"}`, string(public))
+	assert.JSONEq(t, `{"newsletter": true}`, string(unsafe))
+}
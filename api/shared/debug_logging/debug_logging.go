@@ -0,0 +1,80 @@
+package debug_logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/shared/requestlog"
+	"clerk/model"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
+)
+
+// MaxWindow is the longest period debug logging can be enabled for in one
+// go. Debug logs are verbose, so they must never be left on by accident.
+const MaxWindow = 2 * time.Hour
+
+type Service struct {
+	clock clockwork.Clock
+
+	// repositories
+	instanceRepo *repository.Instances
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:        deps.Clock(),
+		instanceRepo: deps.Repositories().Instances,
+	}
+}
+
+// Enable turns on debug logging for all requests of the instance, for the
+// given window. It returns when debug logging will turn off again.
+//
+// The end of the window is stored on the instance, so that it's loaded along
+// with the environment of every request.
+func (s *Service) Enable(ctx context.Context, exec database.Executor, instance *model.Instance, window time.Duration) (time.Time, error) {
+	expiresAt := s.clock.Now().UTC().Add(window)
+	instance.DebugLoggingExpiresAt = null.TimeFrom(expiresAt)
+	if err := s.instanceRepo.UpdateDebugLoggingExpiresAt(ctx, exec, instance); err != nil {
+		return time.Time{}, fmt.Errorf("debugLogging/enable: instance %s: %w", instance.ID, err)
+	}
+	return expiresAt, nil
+}
+
+// Disable turns off debug logging for the instance before its window ends.
+func (s *Service) Disable(ctx context.Context, exec database.Executor, instance *model.Instance) error {
+	instance.DebugLoggingExpiresAt = null.TimeFromPtr(nil)
+	if err := s.instanceRepo.UpdateDebugLoggingExpiresAt(ctx, exec, instance); err != nil {
+		return fmt.Errorf("debugLogging/disable: instance %s: %w", instance.ID, err)
+	}
+	return nil
+}
+
+// ExpiresAt returns when debug logging turns off for the instance, or nil
+// if it is not enabled.
+func (s *Service) ExpiresAt(instance *model.Instance) *time.Time {
+	return expiresAt(instance, s.clock.Now())
+}
+
+// WithDebugLogging turns on debug logging for the current request, if it's
+// currently enabled for the instance. Debug messages are then recorded on
+// the request log line, which is always written for such requests.
+func (s *Service) WithDebugLogging(ctx context.Context, instance *model.Instance) {
+	if s.ExpiresAt(instance) != nil {
+		requestlog.EnableDebug(ctx)
+	}
+}
+
+func expiresAt(instance *model.Instance, now time.Time) *time.Time {
+	if !instance.DebugLoggingExpiresAt.Valid || !instance.DebugLoggingExpiresAt.Time.After(now) {
+		return nil
+	}
+	expiresAt := instance.DebugLoggingExpiresAt.Time.UTC()
+	return &expiresAt
+}
@@ -0,0 +1,50 @@
+package debug_logging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clerk/api/shared/requestlog"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestWithDebugLogging(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service := &Service{clock: clockwork.NewFakeClockAt(now)}
+
+	for _, tc := range []struct {
+		name      string
+		expiresAt null.Time
+		enabled   bool
+	}{
+		{name: "never enabled"},
+		{name: "within the window", expiresAt: null.TimeFrom(now.Add(time.Minute)), enabled: true},
+		{name: "window is over", expiresAt: null.TimeFrom(now.Add(-time.Minute))},
+		{name: "window ends now", expiresAt: null.TimeFrom(now)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			instance := &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1", DebugLoggingExpiresAt: tc.expiresAt}}
+			ctx, entry := requestlog.NewContext(context.Background())
+			service.WithDebugLogging(ctx, instance)
+
+			debug, _ := entry.Debug()
+			assert.Equal(t, tc.enabled, debug)
+			if tc.enabled {
+				assert.Equal(t, tc.expiresAt.Time, *service.ExpiresAt(instance))
+			} else {
+				assert.Nil(t, service.ExpiresAt(instance))
+			}
+		})
+	}
+}
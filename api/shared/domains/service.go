@@ -42,9 +42,9 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		clock:         deps.Clock(),
 		gueClient:     deps.GueClient(),
-		dnsChecksRepo: repository.NewDNSChecks(),
-		domainRepo:    repository.NewDomain(),
-		gueJobRepo:    repository.NewGueJobs(),
+		dnsChecksRepo: deps.Repositories().DNSChecks,
+		domainRepo:    deps.Repositories().Domain,
+		gueJobRepo:    deps.Repositories().GueJobs,
 		cnameChecker:  dnschecks.NewCNAMEChecker(deps.DNSResolver(), deps.CertCheckHostHealthHTTPClient(), deps.CloudflareIPRangeClient()),
 	}
 }
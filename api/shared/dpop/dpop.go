@@ -0,0 +1,199 @@
+// Package dpop verifies DPoP (Demonstrating Proof-of-Possession, RFC 9449)
+// proofs, which bind a secret key to a key pair held by its owner. A request
+// authenticated with a bound key must be accompanied by a proof signed by the
+// private key of the pair, so that a leaked secret key is not enough to
+// access the API.
+package dpop
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+const (
+	// HeaderName is the request header which carries the proof.
+	HeaderName = "DPoP"
+	// NonceHeaderName is the response header which carries the nonce the
+	// next proof must include.
+	NonceHeaderName = "DPoP-Nonce"
+
+	proofType = "dpop+jwt"
+
+	// MaxProofAge is how far the issued at time of a proof can be from the
+	// current time, in either direction.
+	MaxProofAge = time.Minute
+
+	// NonceLifetime is how long a nonce is valid for. A nonce issued right
+	// before it rotates stays valid for one more lifetime.
+	NonceLifetime = 5 * time.Minute
+)
+
+var (
+	ErrMissingProof     = errors.New("dpop: missing proof")
+	ErrInvalidProof     = errors.New("dpop: invalid proof")
+	ErrKeyMismatch      = errors.New("dpop: proof is not signed by the bound key")
+	ErrRequestMismatch  = errors.New("dpop: proof does not match the request")
+	ErrTokenMismatch    = errors.New("dpop: proof is not bound to the access token")
+	ErrProofExpired     = errors.New("dpop: proof issued at is outside the allowed window")
+	ErrInvalidPublicKey = errors.New("dpop: invalid public key")
+)
+
+// Proof contains the claims of a verified proof.
+type Proof struct {
+	ID       string `json:"jti"`
+	Method   string `json:"htm"`
+	URL      string `json:"htu"`
+	IssuedAt int64  `json:"iat"`
+	Nonce    string `json:"nonce"`
+	// AccessTokenHash is the hash of the access token the proof was sent
+	// with, i.e. the secret key.
+	AccessTokenHash string `json:"ath"`
+}
+
+// ParsePublicKey parses a public key in JWK format. Only asymmetric public
+// keys are accepted.
+func ParsePublicKey(raw string) (*jose.JSONWebKey, error) {
+	var key jose.JSONWebKey
+	if err := json.Unmarshal([]byte(raw), &key); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+	if !key.Valid() || !key.IsPublic() {
+		return nil, ErrInvalidPublicKey
+	}
+	return &key, nil
+}
+
+type VerifyParams struct {
+	// Method and URL of the request the proof was sent with.
+	Method string
+	URL    *url.URL
+	// PublicKey is the key the secret key is bound to.
+	PublicKey *jose.JSONWebKey
+	// AccessToken is the secret key the request was authenticated with.
+	// Proofs must carry its hash, so that they can't be used along with
+	// another key bound to the same key pair.
+	AccessToken string
+	Now         time.Time
+}
+
+// Verify parses the given proof and makes sure that it was signed by the
+// bound key for the given request, within the allowed window.
+//
+// The scheme of the request is not compared, as TLS is terminated before
+// requests reach us.
+func Verify(rawProof string, params VerifyParams) (*Proof, error) {
+	if rawProof == "" {
+		return nil, ErrMissingProof
+	}
+
+	jws, err := jose.ParseSigned(rawProof)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, ErrInvalidProof
+	}
+	header := jws.Signatures[0].Protected
+	if typ, _ := header.ExtraHeaders[jose.HeaderType].(string); typ != proofType {
+		return nil, fmt.Errorf("%w: unexpected type %q", ErrInvalidProof, typ)
+	}
+	if header.JSONWebKey == nil || !header.JSONWebKey.IsPublic() {
+		return nil, fmt.Errorf("%w: missing public key", ErrInvalidProof)
+	}
+
+	matches, err := sameKey(header.JSONWebKey, params.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if !matches {
+		return nil, ErrKeyMismatch
+	}
+
+	payload, err := jws.Verify(params.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyMismatch, err)
+	}
+
+	var proof Proof
+	if err := json.Unmarshal(payload, &proof); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProof, err)
+	}
+	if proof.ID == "" {
+		return nil, fmt.Errorf("%w: missing jti", ErrInvalidProof)
+	}
+
+	if !strings.EqualFold(proof.Method, params.Method) || !sameURL(proof.URL, params.URL) {
+		return nil, ErrRequestMismatch
+	}
+	if !hmac.Equal([]byte(proof.AccessTokenHash), []byte(AccessTokenHash(params.AccessToken))) {
+		return nil, ErrTokenMismatch
+	}
+
+	issuedAt := time.Unix(proof.IssuedAt, 0)
+	if issuedAt.Before(params.Now.Add(-MaxProofAge)) || issuedAt.After(params.Now.Add(MaxProofAge)) {
+		return nil, ErrProofExpired
+	}
+
+	return &proof, nil
+}
+
+// AccessTokenHash returns the hash of the access token which proofs must
+// include in their ath claim.
+func AccessTokenHash(accessToken string) string {
+	hash := sha256.Sum256([]byte(accessToken))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func sameKey(a, b *jose.JSONWebKey) (bool, error) {
+	thumbprintA, err := a.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return false, err
+	}
+	thumbprintB, err := b.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal(thumbprintA, thumbprintB), nil
+}
+
+func sameURL(rawProofURL string, requestURL *url.URL) bool {
+	proofURL, err := url.Parse(rawProofURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(proofURL.Host, requestURL.Host) && proofURL.Path == requestURL.Path
+}
+
+// Nonce returns the nonce proofs must include at the given time. Nonces are
+// derived from the secret of the bound key, so they don't need to be stored.
+func Nonce(secret string, at time.Time) string {
+	return nonceForWindow(secret, at.Unix()/int64(NonceLifetime.Seconds()))
+}
+
+// ValidNonce reports whether the nonce was issued for the current or the
+// previous window.
+func ValidNonce(secret, nonce string, at time.Time) bool {
+	if nonce == "" {
+		return false
+	}
+	window := at.Unix() / int64(NonceLifetime.Seconds())
+	return hmac.Equal([]byte(nonce), []byte(nonceForWindow(secret, window))) ||
+		hmac.Equal([]byte(nonce), []byte(nonceForWindow(secret, window-1)))
+}
+
+func nonceForWindow(secret string, window int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(window, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
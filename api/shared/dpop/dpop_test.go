@@ -0,0 +1,114 @@
+package dpop
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProof(t *testing.T, key *ecdsa.PrivateKey, claims Proof) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key}, &jose.SignerOptions{
+		EmbedJWK:     true,
+		ExtraHeaders: map[jose.HeaderKey]interface{}{jose.HeaderType: proofType},
+	})
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	jws, err := signer.Sign(payload)
+	require.NoError(t, err)
+	proof, err := jws.CompactSerialize()
+	require.NoError(t, err)
+	return proof
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	boundKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	requestURL, err := url.Parse("https://api.clerk.com/v1/users?limit=10")
+	require.NoError(t, err)
+	params := VerifyParams{
+		Method:      "GET",
+		URL:         requestURL,
+		PublicKey:   &jose.JSONWebKey{Key: boundKey.Public()},
+		AccessToken: "sk_test_secret",
+		Now:         now,
+	}
+	validClaims := Proof{
+		ID:              "jti",
+		Method:          "GET",
+		URL:             "https://api.clerk.com/v1/users",
+		IssuedAt:        now.Unix(),
+		AccessTokenHash: AccessTokenHash("sk_test_secret"),
+	}
+
+	proof, err := Verify(newProof(t, boundKey, validClaims), params)
+	require.NoError(t, err)
+	assert.Equal(t, "jti", proof.ID)
+
+	_, err = Verify("", params)
+	assert.ErrorIs(t, err, ErrMissingProof)
+
+	_, err = Verify(newProof(t, otherKey, validClaims), params)
+	assert.ErrorIs(t, err, ErrKeyMismatch)
+
+	claims := validClaims
+	claims.Method = "POST"
+	_, err = Verify(newProof(t, boundKey, claims), params)
+	assert.ErrorIs(t, err, ErrRequestMismatch)
+
+	claims = validClaims
+	claims.URL = "https://api.clerk.com/v1/organizations"
+	_, err = Verify(newProof(t, boundKey, claims), params)
+	assert.ErrorIs(t, err, ErrRequestMismatch)
+
+	claims = validClaims
+	claims.AccessTokenHash = AccessTokenHash("sk_test_other")
+	_, err = Verify(newProof(t, boundKey, claims), params)
+	assert.ErrorIs(t, err, ErrTokenMismatch)
+
+	claims = validClaims
+	claims.AccessTokenHash = ""
+	_, err = Verify(newProof(t, boundKey, claims), params)
+	assert.ErrorIs(t, err, ErrTokenMismatch)
+
+	claims = validClaims
+	claims.IssuedAt = now.Add(-2 * MaxProofAge).Unix()
+	_, err = Verify(newProof(t, boundKey, claims), params)
+	assert.ErrorIs(t, err, ErrProofExpired)
+}
+
+func TestAccessTokenHash(t *testing.T) {
+	t.Parallel()
+
+	// the example of RFC 9449, section 7.1
+	assert.Equal(t, "fUHyO2r2Z3DZ53EsNrWBb0xWXoaNy59IiKCAqksmQEo", AccessTokenHash("Kz~8mXK1EalYznwH-LC-1fBAo.4Ljp~zsPE_NeO.gxU"))
+}
+
+func TestValidNonce(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	nonce := Nonce("secret", now)
+
+	assert.True(t, ValidNonce("secret", nonce, now))
+	assert.True(t, ValidNonce("secret", nonce, now.Add(NonceLifetime)))
+	assert.False(t, ValidNonce("secret", nonce, now.Add(2*NonceLifetime)))
+	assert.False(t, ValidNonce("other", nonce, now))
+	assert.False(t, ValidNonce("secret", "", now))
+}
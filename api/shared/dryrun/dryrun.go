@@ -0,0 +1,30 @@
+// Package dryrun lets clients preview the outcome of destructive requests.
+// A dry run goes through the same validations and changes as the actual
+// request, but its transaction is rolled back and side effects outside of
+// the database are skipped.
+package dryrun
+
+import (
+	"net/http"
+	"strconv"
+
+	"clerk/api/apierror"
+)
+
+const param = "dry_run"
+
+// FromRequest reports whether the request asks for a dry run. Values other
+// than booleans are rejected, so that a typo can't turn a dry run into an
+// actual deletion.
+func FromRequest(r *http.Request) (bool, apierror.Error) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return false, nil
+	}
+
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, apierror.FormInvalidParameterValueWithAllowed(param, value, []string{"true", "false"})
+	}
+	return dryRun, nil
+}
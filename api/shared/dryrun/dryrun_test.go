@@ -0,0 +1,28 @@
+package dryrun
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromRequest(t *testing.T) {
+	t.Parallel()
+
+	for query, want := range map[string]bool{
+		"":               false,
+		"?dry_run=true":  true,
+		"?dry_run=1":     true,
+		"?dry_run=false": false,
+	} {
+		r := httptest.NewRequest("DELETE", "/v1/users/user_1"+query, nil)
+		dryRun, apiErr := FromRequest(r)
+		assert.Nil(t, apiErr, query)
+		assert.Equal(t, want, dryRun, query)
+	}
+
+	r := httptest.NewRequest("DELETE", "/v1/users/user_1?dry_run=yes", nil)
+	_, apiErr := FromRequest(r)
+	assert.NotNil(t, apiErr)
+}
@@ -11,6 +11,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/token"
 	"clerk/model"
 	"clerk/pkg/clerkerrors"
@@ -21,12 +22,14 @@ import (
 	"clerk/repository"
 	"clerk/utils/database"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/vgarvardt/gue/v2"
 )
 
 type Service struct {
 	Enabled bool
 
+	clock           clockwork.Clock
 	gueClient       *gue.Client
 	authConfigRepo  *repository.AuthConfig
 	jwtTemplateRepo *repository.JWTTemplate
@@ -36,6 +39,7 @@ type Service struct {
 func NewService(gueClient *gue.Client, enabled bool) *Service {
 	return &Service{
 		Enabled:         enabled,
+		clock:           clockwork.NewRealClock(),
 		gueClient:       gueClient,
 		authConfigRepo:  repository.NewAuthConfig(),
 		jwtTemplateRepo: repository.NewJWTTemplate(),
@@ -79,11 +83,15 @@ func (s *Service) SerializeInstance(ctx context.Context, db database.Executor, i
 		return nil, err
 	}
 
-	privateKey := instance.PrivateKey
+	signingKey, err := signing_keys.Load(ctx, db, s.clock, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey := signingKey.PrivateKey
 	// Skip conversion for EdDSA keys
-	if instance.KeyAlgorithm == string((keygen.RSA{}).ID()) {
-		var err error
-		privateKey, err = jwt.ConvertPKCS1ToPKCS8(instance.PrivateKey)
+	if signingKey.Algorithm == string((keygen.RSA{}).ID()) {
+		privateKey, err = jwt.ConvertPKCS1ToPKCS8(signingKey.PrivateKey)
 		if err != nil {
 			return nil, fmt.Errorf("private key conversion from PKCS1 to PKCS8 failed: id=%s err=%w", instance.ID, err)
 		}
@@ -107,9 +115,9 @@ func (s *Service) SerializeInstance(ctx context.Context, db database.Executor, i
 			},
 			SigningKeys: []edge_client_service.PutInstancesInstanceIdBodySigningKeysItem{
 				{
-					PublicKey:    instance.PublicKey,
+					PublicKey:    signingKey.PublicKey,
 					PrivateKey:   privateKey,
-					KeyAlgorithm: instance.KeyAlgorithm,
+					KeyAlgorithm: signingKey.Algorithm,
 				},
 			},
 			AllowedOrigins: &allowedOrigins,
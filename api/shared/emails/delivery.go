@@ -0,0 +1,229 @@
+package emails
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cenv"
+	"clerk/pkg/constants"
+	"clerk/pkg/emailprovider"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// failoverThreshold is the number of failures in a row after which a
+	// provider is skipped in favor of the others
+	failoverThreshold = 3
+	// failoverCooldown is how long a failing provider is skipped for
+	failoverCooldown = 5 * time.Minute
+)
+
+// providerHealth is shared by all the services of the process, so that every
+// email benefits from the failures the others ran into.
+var providerHealth = emailprovider.NewHealth(failoverThreshold, failoverCooldown)
+
+var ErrInvalidWebhookToken = errors.New("emails: invalid webhook token")
+
+// Deliver hands the queued email over to the provider of the instance, or
+// to one of the other configured providers if it's failing. It's run by the
+// send_email job, which is retried if no provider accepts the email.
+func (s *Service) Deliver(ctx context.Context, tx database.Tx, env *model.Env, emailID string) error {
+	email, err := s.emailsRepo.FindByIDAndInstance(ctx, tx, emailID, env.Instance.ID)
+	if err != nil {
+		return fmt.Errorf("email/deliver: fetching email %s: %w", emailID, err)
+	}
+	if email.Status != string(constants.EmailMessageStatusQueued) {
+		return nil
+	}
+
+	providers := configuredProviders(providerName(env.Instance))
+	if len(providers) == 0 {
+		return fmt.Errorf("email/deliver: %s: %w", emailID, emailprovider.ErrNoProviders)
+	}
+
+	msg := emailprovider.Message{
+		From:    email.FromEmailName + "@" + env.Domain.FromEmailDomainName(),
+		To:      email.ToEmailAddress,
+		Subject: email.Subject,
+		HTML:    email.Body,
+		Text:    email.BodyPlain.String,
+		EmailID: email.ID,
+	}
+	if email.ReplyToEmailName.Valid {
+		msg.ReplyTo = email.ReplyToEmailName.String + "@" + env.Domain.FromEmailDomainName()
+	}
+
+	provider, providerMessageID, err := emailprovider.Send(ctx, providerHealth, providers, msg)
+	if err != nil {
+		return fmt.Errorf("email/deliver: sending email %s: %w", emailID, err)
+	}
+	if provider != providers[0].Name() {
+		log.Warning(ctx, "email/deliver: email %s failed over from %s to %s", emailID, providers[0].Name(), provider)
+	}
+
+	email.Status = string(constants.EmailMessageStatusSent)
+	email.Provider = null.StringFrom(provider)
+	email.ProviderMessageID = null.StringFrom(providerMessageID)
+	if err := s.emailsRepo.Update(ctx, tx, email,
+		sqbmodel.EmailColumns.Status,
+		sqbmodel.EmailColumns.Provider,
+		sqbmodel.EmailColumns.ProviderMessageID,
+	); err != nil {
+		return fmt.Errorf("email/deliver: updating email %s: %w", emailID, err)
+	}
+	return nil
+}
+
+// RecordDeliveryStatus records the delivery events of a status webhook of
+// the provider against the emails they're about. The webhook is
+// authenticated by the token of the provider, which is part of the URL it's
+// configured with.
+func (s *Service) RecordDeliveryStatus(ctx context.Context, provider, token string, body []byte) error {
+	expected := webhookToken(provider)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return ErrInvalidWebhookToken
+	}
+
+	webhook, err := emailprovider.ParseWebhook(provider, body)
+	if err != nil {
+		return err
+	}
+	if webhook.SubscribeURL != "" {
+		return emailprovider.ConfirmSESSubscription(ctx, webhook.SubscribeURL)
+	}
+
+	for _, event := range webhook.Events {
+		if err := s.recordDeliveryEvent(ctx, provider, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) recordDeliveryEvent(ctx context.Context, provider string, event emailprovider.DeliveryEvent) error {
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		var email *model.Email
+		var err error
+		if event.EmailID != "" {
+			email, err = s.emailsRepo.QueryByIDForUpdate(ctx, tx, event.EmailID)
+		} else {
+			email, err = s.emailsRepo.QueryByProviderMessageIDForUpdate(ctx, tx, provider, event.ProviderMessageID)
+		}
+		if err != nil {
+			return true, fmt.Errorf("email/recordDeliveryEvent: fetching email for %s message %s: %w", provider, event.ProviderMessageID, err)
+		}
+		// providers keep sending events about emails which were sent
+		// before their records were cleaned up, or by another provider
+		// before the email failed over
+		if email == nil || email.Provider.String != provider {
+			log.Debug(ctx, "email/recordDeliveryEvent: ignoring %s event for unknown %s message %s", event.Status, provider, event.ProviderMessageID)
+			return false, nil
+		}
+		if !supersedes(event.Status, email.Status) {
+			return false, nil
+		}
+
+		email.Status = event.Status
+		email.DeliveryReason = null.NewString(event.Reason, event.Reason != "")
+		err = s.emailsRepo.Update(ctx, tx, email,
+			sqbmodel.EmailColumns.Status,
+			sqbmodel.EmailColumns.DeliveryReason,
+		)
+		if err != nil {
+			return true, fmt.Errorf("email/recordDeliveryEvent: updating email %s: %w", email.ID, err)
+		}
+		return false, nil
+	})
+}
+
+// statusOrder ranks the statuses of an email in the order they can follow
+// each other, so that events which arrive out of order don't move an email
+// back, e.g. from delivered to deferred.
+var statusOrder = map[string]int{
+	string(constants.EmailMessageStatusQueued): 0,
+	string(constants.EmailMessageStatusSent):   1,
+	emailprovider.StatusDeferred:               2,
+	emailprovider.StatusDelivered:              3,
+	emailprovider.StatusBounced:                3,
+	emailprovider.StatusDropped:                3,
+	emailprovider.StatusComplained:             4,
+}
+
+func supersedes(status, current string) bool {
+	if status == emailprovider.StatusDeferred && current == emailprovider.StatusDeferred {
+		// every retry of the provider may have a different reason
+		return true
+	}
+	return statusOrder[status] > statusOrder[current]
+}
+
+// providerName returns the provider the instance sends emails through,
+// SendGrid unless it chose another one.
+func providerName(instance *model.Instance) string {
+	if instance.Communication.EmailProvider.Valid {
+		return instance.Communication.EmailProvider.String
+	}
+	return emailprovider.ProviderSendGrid
+}
+
+// configuredProviders returns the providers which are configured, starting
+// with the preferred one. The rest are only used to fail over to.
+func configuredProviders(preferred string) []emailprovider.Provider {
+	names := []string{preferred}
+	for _, name := range emailprovider.Providers {
+		if name != preferred {
+			names = append(names, name)
+		}
+	}
+
+	var providers []emailprovider.Provider
+	for _, name := range names {
+		if provider := newProvider(name); provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+func newProvider(name string) emailprovider.Provider {
+	switch name {
+	case emailprovider.ProviderSendGrid:
+		if !cenv.IsSet(cenv.SendGridAPIKey) {
+			return nil
+		}
+		return emailprovider.NewSendGrid(cenv.Get(cenv.SendGridAPIKey))
+	case emailprovider.ProviderSES:
+		if !cenv.IsSet(cenv.SESAccessKeyID) {
+			return nil
+		}
+		return emailprovider.NewSES(cenv.Get(cenv.SESRegion), cenv.Get(cenv.SESAccessKeyID), cenv.Get(cenv.SESSecretAccessKey), cenv.Get(cenv.SESConfigurationSet))
+	case emailprovider.ProviderPostmark:
+		if !cenv.IsSet(cenv.PostmarkServerToken) {
+			return nil
+		}
+		return emailprovider.NewPostmark(cenv.Get(cenv.PostmarkServerToken))
+	default:
+		return nil
+	}
+}
+
+func webhookToken(provider string) string {
+	switch provider {
+	case emailprovider.ProviderSendGrid:
+		return cenv.Get(cenv.SendGridStatusWebhookToken)
+	case emailprovider.ProviderSES:
+		return cenv.Get(cenv.SESStatusWebhookToken)
+	case emailprovider.ProviderPostmark:
+		return cenv.Get(cenv.PostmarkStatusWebhookToken)
+	default:
+		return ""
+	}
+}
@@ -23,6 +23,7 @@ import (
 )
 
 type Service struct {
+	db        database.Database
 	gueClient *gue.Client
 
 	eventService *events.Service
@@ -31,9 +32,10 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
+		db:           deps.DB(),
 		gueClient:    deps.GueClient(),
 		eventService: events.NewService(deps),
-		emailsRepo:   repository.NewEmail(),
+		emailsRepo:   deps.Repositories().Email,
 	}
 }
 
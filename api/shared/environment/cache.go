@@ -0,0 +1,151 @@
+package environment
+
+import (
+	"sync"
+	"time"
+
+	"clerk/model"
+
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// cacheTTL bounds how long an environment can be served from memory, in
+	// case an invalidation message is lost.
+	cacheTTL = time.Minute
+
+	// maxCacheEntries bounds the memory used by the cache. Past it, new
+	// environments are loaded from the database without being cached.
+	maxCacheEntries = 50_000
+)
+
+// Cache keeps the environments of instances in memory, so that they don't
+// need to be loaded from the database on every request. Entries are evicted
+// when their instance settings change, see Invalidator.
+type Cache struct {
+	clock clockwork.Clock
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+	// generation changes on every invalidation, so that environments which
+	// were being loaded while an invalidation arrived are not cached.
+	generation uint64
+}
+
+type cacheEntry struct {
+	env       *model.Env
+	expiresAt time.Time
+}
+
+func NewCache(clock clockwork.Clock) *Cache {
+	return &Cache{
+		clock:   clock,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+// Get returns a copy of the cached environment of the instance, if any.
+func (c *Cache) Get(instanceID string) (*model.Env, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[instanceID]
+	c.mu.RUnlock()
+	if !ok || !c.clock.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return cloneEnv(entry.env), true
+}
+
+// Generation returns the current generation of the cache, which must be
+// passed to Set after loading an environment.
+func (c *Cache) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// Set caches a copy of the environment of the instance, unless there was an
+// invalidation since the given generation.
+func (c *Cache) Set(instanceID string, env *model.Env, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+
+	now := c.clock.Now()
+	if len(c.entries) >= maxCacheEntries {
+		c.pruneLocked(now)
+		if len(c.entries) >= maxCacheEntries {
+			return
+		}
+	}
+	c.entries[instanceID] = cacheEntry{
+		env:       cloneEnv(env),
+		expiresAt: now.Add(cacheTTL),
+	}
+}
+
+// Invalidate evicts the environment of the instance.
+func (c *Cache) Invalidate(instanceID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, instanceID)
+	c.generation++
+}
+
+func (c *Cache) pruneLocked(now time.Time) {
+	for instanceID, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, instanceID)
+		}
+	}
+}
+
+// cloneEnv copies the environment along with its models, so that changes
+// made while serving a request don't leak into the cache. Only the model
+// columns are copied, so relationships and JSON values must still be treated
+// as read-only.
+func cloneEnv(env *model.Env) *model.Env {
+	clone := *env
+	if env.Instance != nil {
+		instance := *env.Instance
+		if instance.Instance != nil {
+			columns := *instance.Instance
+			instance.Instance = &columns
+		}
+		clone.Instance = &instance
+	}
+	if env.AuthConfig != nil {
+		authConfig := *env.AuthConfig
+		if authConfig.AuthConfig != nil {
+			columns := *authConfig.AuthConfig
+			authConfig.AuthConfig = &columns
+		}
+		clone.AuthConfig = &authConfig
+	}
+	if env.Application != nil {
+		application := *env.Application
+		if application.Application != nil {
+			columns := *application.Application
+			application.Application = &columns
+		}
+		clone.Application = &application
+	}
+	if env.DisplayConfig != nil {
+		displayConfig := *env.DisplayConfig
+		if displayConfig.DisplayConfig != nil {
+			columns := *displayConfig.DisplayConfig
+			displayConfig.DisplayConfig = &columns
+		}
+		clone.DisplayConfig = &displayConfig
+	}
+	if env.Subscription != nil {
+		subscription := *env.Subscription
+		if subscription.Subscription != nil {
+			columns := *subscription.Subscription
+			subscription.Subscription = &columns
+		}
+		clone.Subscription = &subscription
+	}
+	return &clone
+}
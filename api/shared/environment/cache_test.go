@@ -0,0 +1,59 @@
+package environment
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	clock := clockwork.NewFakeClock()
+	cache := NewCache(clock)
+	env := &model.Env{
+		Instance:    &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}},
+		Application: &model.Application{Application: &sqbmodel.Application{ID: "app_1"}},
+	}
+
+	cache.Set("ins_1", env, cache.Generation())
+
+	cached, ok := cache.Get("ins_1")
+	require.True(t, ok)
+	assert.Equal(t, "ins_1", cached.Instance.ID)
+
+	// changes to the returned environment don't affect the cache
+	cached.Application.Demo = true
+	cached, ok = cache.Get("ins_1")
+	require.True(t, ok)
+	assert.False(t, cached.Application.Demo)
+
+	cache.Invalidate("ins_1")
+	_, ok = cache.Get("ins_1")
+	assert.False(t, ok)
+
+	cache.Set("ins_1", env, cache.Generation())
+	clock.Advance(cacheTTL + time.Second)
+	_, ok = cache.Get("ins_1")
+	assert.False(t, ok)
+}
+
+func TestCacheSetAfterInvalidation(t *testing.T) {
+	t.Parallel()
+
+	cache := NewCache(clockwork.NewFakeClock())
+
+	// an environment loaded before an invalidation is stale
+	generation := cache.Generation()
+	cache.Invalidate("ins_1")
+	cache.Set("ins_1", &model.Env{}, generation)
+
+	_, ok := cache.Get("ins_1")
+	assert.False(t, ok)
+}
@@ -0,0 +1,80 @@
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"clerk/utils/clerk"
+
+	"cloud.google.com/go/pubsub"
+)
+
+// InvalidatedEventTypeName is the type of the messages published on the
+// events topic when the environment of an instance changes. It's also set
+// as the eventTypeName attribute of the messages, so that subscriptions can
+// filter on it.
+const InvalidatedEventTypeName = "environment.invalidated"
+
+type invalidationMessage struct {
+	EventTypeName string `json:"eventTypeName"`
+	InstanceID    string `json:"instanceId"`
+}
+
+// Invalidator publishes invalidation messages for the environments cached by
+// FAPI.
+type Invalidator struct {
+	pubsubEventsTopic *pubsub.Topic
+}
+
+func NewInvalidator(deps clerk.Deps) *Invalidator {
+	return &Invalidator{
+		pubsubEventsTopic: deps.PubsubEventsTopic(),
+	}
+}
+
+// Invalidate notifies the subscribed caches that the environment of the
+// instance changed. It must be called after the changes are committed,
+// otherwise the stale environment might be loaded again.
+func (i *Invalidator) Invalidate(ctx context.Context, instanceID string) error {
+	if i.pubsubEventsTopic == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(invalidationMessage{
+		EventTypeName: InvalidatedEventTypeName,
+		InstanceID:    instanceID,
+	})
+	if err != nil {
+		return fmt.Errorf("environment/invalidate: marshalling message for %s: %w", instanceID, err)
+	}
+
+	result := i.pubsubEventsTopic.Publish(ctx, &pubsub.Message{
+		Data:       data,
+		Attributes: map[string]string{"eventTypeName": InvalidatedEventTypeName},
+	})
+	if _, err := result.Get(ctx); err != nil {
+		return fmt.Errorf("environment/invalidate: publishing message for %s: %w", instanceID, err)
+	}
+	return nil
+}
+
+// Receive evicts the environments of the instances in the invalidation
+// messages of the subscription. It blocks until the context is done or the
+// subscription fails.
+func (c *Cache) Receive(ctx context.Context, subscription *pubsub.Subscription) error {
+	return subscription.Receive(ctx, func(_ context.Context, msg *pubsub.Message) {
+		// invalidations are best effort, the TTL of the entries covers for
+		// the messages that can't be handled
+		msg.Ack()
+
+		var data invalidationMessage
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return
+		}
+		if data.EventTypeName != InvalidatedEventTypeName || data.InstanceID == "" {
+			return
+		}
+		c.Invalidate(data.InstanceID)
+	})
+}
@@ -10,6 +10,7 @@ import (
 )
 
 type Service struct {
+	cache   *Cache
 	envRepo *repository.Environment
 }
 
@@ -19,7 +20,33 @@ func NewService() *Service {
 	}
 }
 
+// NewCachedService returns a service which serves the environments loaded by
+// domain from the given cache.
+func NewCachedService(cache *Cache) *Service {
+	service := NewService()
+	service.cache = cache
+	return service
+}
+
 func (s *Service) LoadByDomain(ctx context.Context, exec database.Executor, domain *model.Domain) (*model.Env, error) {
+	if s.cache == nil {
+		return s.loadByDomain(ctx, exec, domain)
+	}
+
+	if env, ok := s.cache.Get(domain.InstanceID); ok {
+		env.Domain = domain
+		return env, nil
+	}
+	generation := s.cache.Generation()
+	env, err := s.loadByDomain(ctx, exec, domain)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(domain.InstanceID, env, generation)
+	return env, nil
+}
+
+func (s *Service) loadByDomain(ctx context.Context, exec database.Executor, domain *model.Domain) (*model.Env, error) {
 	env, err := s.envRepo.FindByInstanceIDWithoutDomain(ctx, exec, domain.InstanceID)
 	if err != nil {
 		return nil, fmt.Errorf("environment/load: by domain %s: %w", domain.ID, err)
@@ -47,6 +47,18 @@ func (s *Service) IOSActivityRegistered(
 	})
 }
 
+func (s *Service) IPRestrictionBlocked(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.IPRestrictionBlockResponse) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:  instance,
+		EventType: events.EventTypes.IPRestrictionBlocked,
+		Payload:   payload,
+	})
+}
+
 func (s *Service) OrganizationCreated(
 	ctx context.Context,
 	exec database.Executor,
@@ -151,6 +163,66 @@ func (s *Service) OrganizationInvitationRevoked(
 	})
 }
 
+// OrganizationInvitationExpired is triggered when a pending invitation
+// reaches its expiry without being acted upon, so it has no acting user.
+func (s *Service) OrganizationInvitationExpired(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationInvitationResponse) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationInvitationExpired,
+		Payload:        payload,
+		OrganizationID: &payload.OrganizationID,
+	})
+}
+
+func (s *Service) OrganizationInvitationResent(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationInvitationResponse,
+	userID string) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationInvitationResent,
+		Payload:        payload,
+		OrganizationID: &payload.OrganizationID,
+		UserID:         &userID,
+	})
+}
+
+func (s *Service) OrganizationMembershipRequestAccepted(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationMembershipRequestResponse,
+	userID string) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationMembershipRequestAccepted,
+		Payload:        payload,
+		OrganizationID: &payload.OrganizationID,
+		UserID:         &userID,
+	})
+}
+
+func (s *Service) OrganizationMembershipRequestRejected(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationMembershipRequestResponse,
+	userID string) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationMembershipRequestRejected,
+		Payload:        payload,
+		OrganizationID: &payload.OrganizationID,
+		UserID:         &userID,
+	})
+}
+
 func (s *Service) OrganizationMembershipCreated(
 	ctx context.Context,
 	exec database.Executor,
@@ -199,6 +271,35 @@ func (s *Service) OrganizationMembershipUpdated(
 	})
 }
 
+func (s *Service) OrganizationMembershipExportCompleted(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationMembershipExportResponse) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationMembershipExportCompleted,
+		Payload:        payload,
+		OrganizationID: &payload.OrganizationID,
+	})
+}
+
+func (s *Service) OrganizationMembershipRoleChanged(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.OrganizationMembershipRoleChangedResponse,
+	organizationID string,
+	userID string) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:       instance,
+		EventType:      events.EventTypes.OrganizationMembershipRoleChanged,
+		Payload:        payload,
+		OrganizationID: &organizationID,
+		UserID:         &userID,
+	})
+}
+
 func (s *Service) OrganizationDeleted(
 	ctx context.Context,
 	exec database.Executor,
@@ -253,6 +354,20 @@ func (s *Service) SAMLConnectionActivated(
 	})
 }
 
+func (s *Service) SignInAnomalyDetected(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	payload *serialize.SignInAnomalyResponse,
+	userID string) error {
+	return s.sendEvent(ctx, exec, sendEventParams{
+		Instance:  instance,
+		EventType: events.EventTypes.SignInAnomalyDetected,
+		Payload:   payload,
+		UserID:    &userID,
+	})
+}
+
 func (s *Service) SessionCreated(
 	ctx context.Context,
 	exec database.Executor,
@@ -412,16 +527,34 @@ func (s *Service) UserDeleted(
 	})
 }
 
+// UserUpdated sends a user.updated event, which lets consumers know which
+// fields of the user changed since previous, the snapshot of the user taken
+// before the update. No event is sent when nothing about the user actually
+// changed. A nil previous means the changes are unknown, in which case the
+// event carries no changed fields.
 func (s *Service) UserUpdated(
 	ctx context.Context,
 	exec database.Executor,
 	instance *model.Instance,
-	payload *serialize.UserResponse) error {
+	payload *serialize.UserResponse,
+	previous UserSnapshot) error {
+	var changedFields []string
+	if previous != nil {
+		var err error
+		changedFields, err = previous.ChangedFields(payload)
+		if err != nil {
+			return err
+		}
+		if IsNoOpUserUpdate(changedFields) {
+			return nil
+		}
+	}
 	return s.sendEvent(ctx, exec, sendEventParams{
-		Instance:  instance,
-		EventType: events.EventTypes.UserUpdated,
-		Payload:   payload,
-		UserID:    &payload.ID,
+		Instance:      instance,
+		EventType:     events.EventTypes.UserUpdated,
+		Payload:       payload,
+		UserID:        &payload.ID,
+		ChangedFields: changedFields,
 	})
 }
 
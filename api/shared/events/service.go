@@ -2,9 +2,12 @@ package events
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"clerk/api/shared/instance_metrics"
+	"clerk/api/shared/webhooks"
 	"clerk/model"
 	"clerk/pkg/cache"
 	"clerk/pkg/constants"
@@ -27,25 +30,36 @@ type Service struct {
 	cache             cache.Cache
 	gueClient         *gue.Client
 	pubsubEventsTopic *pubsub.Topic
-	organizationRepo  *repository.Organization
-	userRepo          *repository.Users
+
+	// services
+	instanceMetricsService *instance_metrics.Service
+	webhookDeliverer       *webhooks.Deliverer
+	webhookReplayer        *webhooks.Replayer
+
+	// repositories
+	organizationRepo *repository.Organization
+	userRepo         *repository.Users
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		clock:             deps.Clock(),
-		cache:             deps.Cache(),
-		gueClient:         deps.GueClient(),
-		pubsubEventsTopic: deps.PubsubEventsTopic(),
-		organizationRepo:  repository.NewOrganization(),
-		userRepo:          repository.NewUsers(),
+		clock:                  deps.Clock(),
+		cache:                  deps.Cache(),
+		gueClient:              deps.GueClient(),
+		pubsubEventsTopic:      deps.PubsubEventsTopic(),
+		instanceMetricsService: instance_metrics.NewService(deps),
+		webhookDeliverer:       webhooks.NewDeliverer(deps),
+		webhookReplayer:        webhooks.NewReplayer(deps),
+		organizationRepo:       deps.Repositories().Organization,
+		userRepo:               deps.Repositories().Users,
 	}
 }
 
 type svixEvent struct {
-	Object string      `json:"object"`
-	Type   string      `json:"type"`
-	Data   interface{} `json:"data"`
+	Object        string      `json:"object"`
+	Type          string      `json:"type"`
+	Data          interface{} `json:"data"`
+	ChangedFields []string    `json:"changed_fields,omitempty"`
 }
 
 type sendEventParams struct {
@@ -57,6 +71,10 @@ type sendEventParams struct {
 	OrganizationID   *string
 	SAMLConnectionID *string
 	ActorID          *string
+
+	// ChangedFields lists the payload fields which changed, for events
+	// that support it. It's nil when the changes are unknown.
+	ChangedFields []string
 }
 
 func (s *Service) sendEvent(
@@ -141,6 +159,7 @@ func (s *Service) sendEvent(
 		ActorID:          params.ActorID,
 		EventType:        params.EventType.Name,
 		Payload:          params.Payload,
+		ChangedFields:    params.ChangedFields,
 		Time:             eventTime,
 	}, jobs.WithTxIfApplicable(exec))
 	if err != nil {
@@ -151,7 +170,7 @@ func (s *Service) sendEvent(
 		return nil
 	}
 
-	return s.sendEventToWebhook(ctx, exec, eventID, params.Instance, params.EventType, params.Payload)
+	return s.sendEventToWebhook(ctx, exec, eventID, eventTime, params.Instance, params.OrganizationID, params.EventType, params.Payload, params.ChangedFields)
 }
 
 func (s *Service) registerActivity(ctx context.Context, exec database.Executor, params sendEventParams) error {
@@ -193,10 +212,37 @@ func (s *Service) sendEventToWebhook(
 	ctx context.Context,
 	exec database.Executor,
 	eventID string,
+	eventTime time.Time,
 	instance *model.Instance,
+	organizationID *string,
 	eventType events.EventType,
 	payload interface{},
+	changedFields []string,
 ) error {
+	webhookPayload := &svixEvent{
+		Object:        "event",
+		Type:          eventType.Name,
+		Data:          payload,
+		ChangedFields: changedFields,
+	}
+
+	body, err := json.Marshal(webhookPayload)
+	if err != nil {
+		return fmt.Errorf("events/send: marshalling payload of %s: %w", eventID, err)
+	}
+	// every event is recorded, so that the ones which are missed can be
+	// replayed
+	if err := s.webhookReplayer.Record(ctx, exec, instance, organizationID, eventID, eventType.Name, body, eventTime); err != nil {
+		return err
+	}
+
+	if webhooks.UsesNativeDelivery(instance) {
+		if err := s.webhookDeliverer.Enqueue(ctx, exec, instance, organizationID, eventID, eventType.Name, body); err != nil {
+			return err
+		}
+		return s.instanceMetricsService.Increment(ctx, exec, instance.ID, instance_metrics.WebhookDeliveries, eventType.Name)
+	}
+
 	if !instance.ShouldSendWebhook() {
 		return nil
 	}
@@ -205,11 +251,7 @@ func (s *Service) sendEventToWebhook(
 		InstanceID: instance.ID,
 		EventID:    eventID,
 		EventType:  eventType,
-		Payload: &svixEvent{
-			Object: "event",
-			Type:   eventType.Name,
-			Data:   payload,
-		},
+		Payload:    webhookPayload,
 	}
 
 	if tx, isTx := exec.(database.Tx); isTx {
@@ -223,5 +265,6 @@ func (s *Service) sendEventToWebhook(
 			return fmt.Errorf("events/send: enqueuing job %+v: %w", event, err)
 		}
 	}
-	return nil
+
+	return s.instanceMetricsService.Increment(ctx, exec, instance.ID, instance_metrics.WebhookDeliveries, eventType.Name)
 }
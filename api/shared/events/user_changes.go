@@ -0,0 +1,72 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"clerk/api/serialize"
+)
+
+// volatileFields change without any action on the user, e.g. on every
+// request of an active session or simply as time passes. Changes to them
+// alone don't make for an update which consumers need to know about.
+var volatileFields = map[string]bool{
+	"updated_at":                 true,
+	"last_active_at":             true,
+	"lockout_expires_in_seconds": true,
+}
+
+// UserSnapshot captures the user payload at a point in time. The payload
+// is encoded right away, because it shares memory with the user model,
+// which is mutated by updates.
+type UserSnapshot map[string]json.RawMessage
+
+func NewUserSnapshot(user *serialize.UserResponse) (UserSnapshot, error) {
+	raw, err := json.Marshal(user)
+	if err != nil {
+		return nil, fmt.Errorf("events/userSnapshot: marshaling user %s: %w", user.ID, err)
+	}
+
+	var snapshot UserSnapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("events/userSnapshot: unmarshaling user %s: %w", user.ID, err)
+	}
+	return snapshot, nil
+}
+
+// ChangedFields returns the names of the top-level user payload fields
+// which differ between the snapshot and current, in alphabetical order.
+func (previous UserSnapshot) ChangedFields(current *serialize.UserResponse) ([]string, error) {
+	currentFields, err := NewUserSnapshot(current)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make([]string, 0)
+	for name, value := range currentFields {
+		if previousValue, ok := previous[name]; !ok || !bytes.Equal(previousValue, value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := currentFields[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// IsNoOpUserUpdate reports whether the changed fields consist only of fields
+// that change on their own, which means nothing about the user actually
+// changed.
+func IsNoOpUserUpdate(changedFields []string) bool {
+	for _, field := range changedFields {
+		if !volatileFields[field] {
+			return false
+		}
+	}
+	return true
+}
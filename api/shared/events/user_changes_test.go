@@ -0,0 +1,67 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"clerk/api/serialize"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserSnapshot_ChangedFields(t *testing.T) {
+	t.Parallel()
+
+	firstName := "Jane"
+	otherFirstName := "John"
+	lastActiveAt := int64(1700000000000)
+
+	for _, tc := range []struct {
+		name     string
+		previous *serialize.UserResponse
+		current  *serialize.UserResponse
+		expected []string
+		noOp     bool
+	}{
+		{
+			name:     "nothing changed",
+			previous: &serialize.UserResponse{ID: "user_1", FirstName: &firstName},
+			current:  &serialize.UserResponse{ID: "user_1", FirstName: &firstName},
+			expected: []string{},
+			noOp:     true,
+		},
+		{
+			name:     "only volatile fields changed",
+			previous: &serialize.UserResponse{ID: "user_1", UpdatedAt: 1},
+			current:  &serialize.UserResponse{ID: "user_1", UpdatedAt: 2, LastActiveAt: &lastActiveAt},
+			expected: []string{"last_active_at", "updated_at"},
+			noOp:     true,
+		},
+		{
+			name:     "attributes changed",
+			previous: &serialize.UserResponse{ID: "user_1", FirstName: &firstName, UpdatedAt: 1},
+			current: &serialize.UserResponse{
+				ID:             "user_1",
+				FirstName:      &otherFirstName,
+				PublicMetadata: json.RawMessage(`{"plan":"pro"}`),
+				UpdatedAt:      2,
+			},
+			expected: []string{"first_name", "public_metadata", "updated_at"},
+		},
+		{
+			name:     "omitted fields count as changed",
+			previous: &serialize.UserResponse{ID: "user_1", UnsafeMetadata: json.RawMessage(`{}`)},
+			current:  &serialize.UserResponse{ID: "user_1"},
+			expected: []string{"unsafe_metadata"},
+		},
+	} {
+		snapshot, err := NewUserSnapshot(tc.previous)
+		require.NoError(t, err, tc.name)
+
+		changed, err := snapshot.ChangedFields(tc.current)
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, changed, tc.name)
+		assert.Equal(t, tc.noOp, IsNoOpUserUpdate(changed), tc.name)
+	}
+}
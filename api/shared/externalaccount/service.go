@@ -4,23 +4,38 @@ import (
 	"context"
 
 	"clerk/api/apierror"
+	"clerk/api/shared/environment"
 	"clerk/model"
 	"clerk/pkg/cenv"
 	"clerk/pkg/oauth"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
 )
 
 type Service struct {
-	identificationRepo *repository.Identification
-	verificationRepo   *repository.Verification
+	db    database.Database
+	clock clockwork.Clock
+
+	// services
+	envService *environment.Service
+
+	// repositories
+	externalAccountRepo *repository.ExternalAccount
+	identificationRepo  *repository.Identification
+	verificationRepo    *repository.Verification
 }
 
-func NewService(_ clerk.Deps) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		identificationRepo: repository.NewIdentification(),
-		verificationRepo:   repository.NewVerification(),
+		db:                  deps.DB(),
+		clock:               deps.Clock(),
+		envService:          environment.NewService(),
+		externalAccountRepo: deps.Repositories().ExternalAccount,
+		identificationRepo:  deps.Repositories().Identification,
+		verificationRepo:    deps.Repositories().Verification,
 	}
 }
 
@@ -0,0 +1,253 @@
+package externalaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"clerk/api/shared/sso"
+	"clerk/model"
+	"clerk/pkg/oauth"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrOAuthProviderNotEnabled = errors.New("externalaccount: oauth provider not enabled")
+	ErrMissingRefreshToken     = errors.New("externalaccount: missing refresh token")
+	ErrMissingAccessToken      = errors.New("externalaccount: provider response missing access token")
+)
+
+const (
+	// tokens handed out expire at least this long after they're returned,
+	// to reduce the chance of callers receiving an expired token due to
+	// clock skews. Note that package oauth2 already adds a 10s leeway.
+	tokenLeeway = 15 * time.Minute
+
+	// tokens which expire within this window are refreshed in the
+	// background. It's wider than tokenLeeway, so that tokens are refreshed
+	// before anyone asks for them.
+	backgroundRefreshWindow = 30 * time.Minute
+
+	// the maximum number of accounts refreshed in a single run
+	backgroundRefreshBatchSize = 500
+
+	// the maximum number of accounts refreshed at the same time, which
+	// bounds the connections and provider requests of a single run
+	backgroundRefreshConcurrency = 8
+)
+
+// FreshToken returns a valid access token for the OAuth 2.0 account, along
+// with the scopes it's valid for. If the access token of the account expires
+// within the given leeway, it's refreshed and the account is updated.
+//
+// It returns ErrMissingRefreshToken when the token has expired and can't be
+// refreshed, and the *oauth2.RetrieveError of the provider when refreshing
+// fails.
+func (s *Service) FreshToken(
+	ctx context.Context,
+	exec database.Executor,
+	authConfigID string,
+	account *model.ExternalAccount,
+	leeway time.Duration,
+) (string, []string, error) {
+	oauthConfig, err := sso.ActiveOauthConfigForProvider(ctx, exec, authConfigID, account.Provider)
+	if err != nil {
+		return "", nil, ErrOAuthProviderNotEnabled
+	}
+
+	currentToken := oauth2.Token{
+		AccessToken:  account.AccessToken,
+		RefreshToken: account.RefreshToken.String,
+
+		// if this is a zero value, the same token will be returned
+		Expiry: account.AccessTokenExpiration.Time,
+
+		// TODO(oauth): this should be persisted to and retrieved from
+		// the database instead. Otherwise it's not bullet-proof since
+		// some tokens may be of other types (e.g. MAC)
+		TokenType: "bearer",
+	}
+
+	if !currentToken.Expiry.IsZero() {
+		currentToken.Expiry = currentToken.Expiry.Add(-leeway)
+	}
+
+	// package oauth2 transparently refreshes the token if it's expired.
+	// However, there's not a convenient way to fetch the new token.
+	// See https://github.com/golang/oauth2/issues/84.
+	config := oauthConfig.ToConfig("", account.ApprovedScopes).OAuth2
+	src := config.TokenSource(ctx, &currentToken)
+
+	// this actually goes and refreshes the token, if necessary
+	newToken, err := src.Token()
+	if err != nil {
+		if currentToken.RefreshToken == "" {
+			account.LastFailedTokenRetrievalAt = null.TimeFrom(s.clock.Now().UTC())
+			if err = s.externalAccountRepo.UpdateLastFailedTokenRetrievalAt(ctx, exec, account); err != nil {
+				return "", nil, err
+			}
+			return "", nil, ErrMissingRefreshToken
+		}
+
+		if strings.Contains(err.Error(), "oauth2: server response missing access_token") {
+			return "", nil, ErrMissingAccessToken
+		}
+		return "", nil, err
+	}
+
+	if newToken.AccessToken != currentToken.AccessToken {
+		account.AccessToken = newToken.AccessToken
+
+		if newToken.Expiry.IsZero() {
+			account.AccessTokenExpiration = null.Time{}
+		} else {
+			account.AccessTokenExpiration = null.TimeFrom(newToken.Expiry.UTC())
+		}
+
+		if newToken.RefreshToken != "" {
+			account.RefreshToken = null.StringFrom(newToken.RefreshToken)
+		}
+
+		if err := s.externalAccountRepo.Update(ctx, exec, account); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return newToken.AccessToken, config.Scopes, nil
+}
+
+// FreshTokenForRequest is FreshToken with the leeway used for tokens that
+// are handed out to callers.
+//
+// The account is locked while its token is refreshed, so that concurrent
+// requests and the background refresh don't refresh it at the same time.
+// Providers which rotate refresh tokens would otherwise revoke the token
+// that one of them stores. The account is updated with the locked values.
+func (s *Service) FreshTokenForRequest(ctx context.Context, authConfigID string, account *model.ExternalAccount) (string, []string, error) {
+	var token string
+	var scopes []string
+	var freshErr error
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		locked, err := s.externalAccountRepo.QueryByIDForUpdate(ctx, tx, account.ID)
+		if err != nil {
+			return true, err
+		}
+		if locked == nil {
+			return true, fmt.Errorf("externalaccount/freshTokenForRequest: external account %s not found", account.ID)
+		}
+		account.ExternalAccount = locked.ExternalAccount
+
+		// failures are committed too, since they're recorded on the account
+		token, scopes, freshErr = s.FreshToken(ctx, tx, authConfigID, account, tokenLeeway)
+		return false, nil
+	})
+	if txErr != nil {
+		return "", nil, txErr
+	}
+	return token, scopes, freshErr
+}
+
+// RefreshExpiringTokens refreshes the access tokens of OAuth 2.0 accounts
+// which are about to expire, so that requests for them don't have to wait
+// for the provider, or fail because of it. It's run periodically by the
+// refresh_oauth_tokens job.
+//
+// Accounts are refreshed backgroundRefreshConcurrency at a time, each one in
+// its own transaction. Accounts which are locked, because a request is
+// refreshing them, are skipped.
+//
+// Failures are logged and don't stop the rest of the accounts from being
+// refreshed; the token is refreshed again on demand anyway. Accounts whose
+// refresh failed within the refresh window are skipped, so that a broken
+// account isn't retried on every run.
+func (s *Service) RefreshExpiringTokens(ctx context.Context) error {
+	now := s.clock.Now().UTC()
+	// accounts with a token expiring soon, which didn't fail recently
+	accounts, err := s.externalAccountRepo.FindAllWithAccessTokenExpiringBefore(
+		ctx, s.db, now.Add(backgroundRefreshWindow), now.Add(-backgroundRefreshWindow), backgroundRefreshBatchSize)
+	if err != nil {
+		return fmt.Errorf("externalaccount/refreshExpiringTokens: fetching expiring accounts: %w", err)
+	}
+
+	// the environments are loaded upfront, since they're shared by the
+	// accounts of the same instance
+	envs := make(map[string]*model.Env)
+	refreshable := make([]*model.ExternalAccount, 0, len(accounts))
+	for _, account := range accounts {
+		provider, err := oauth.GetProvider(account.Provider)
+		if err != nil || provider.IsOAuth1() || !account.HasRefreshToken() {
+			continue
+		}
+
+		if _, ok := envs[account.InstanceID]; !ok {
+			env, err := s.envService.Load(ctx, s.db, account.InstanceID)
+			if err != nil {
+				return fmt.Errorf("externalaccount/refreshExpiringTokens: loading environment of instance %s: %w", account.InstanceID, err)
+			}
+			envs[account.InstanceID] = env
+		}
+		refreshable = append(refreshable, account)
+	}
+
+	return forEachConcurrently(ctx, refreshable, backgroundRefreshConcurrency, func(ctx context.Context, account *model.ExternalAccount) error {
+		return s.refreshExpiringToken(ctx, envs[account.InstanceID], account.ID)
+	})
+}
+
+// refreshExpiringToken refreshes the token of the account, unless it's
+// locked or it was refreshed since it was listed.
+func (s *Service) refreshExpiringToken(ctx context.Context, env *model.Env, accountID string) error {
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		account, err := s.externalAccountRepo.QueryByIDForUpdateSkipLocked(ctx, tx, accountID)
+		if err != nil {
+			return true, fmt.Errorf("externalaccount/refreshExpiringToken: locking external account %s: %w", accountID, err)
+		}
+		now := s.clock.Now().UTC()
+		if account == nil || !expiresWithin(account, now, backgroundRefreshWindow) {
+			return false, nil
+		}
+
+		_, _, err = s.FreshToken(ctx, tx, env.AuthConfig.ID, account, backgroundRefreshWindow)
+		if err == nil {
+			return false, nil
+		}
+		log.Warning(ctx, "externalaccount/refreshExpiringTokens: refreshing token of external account %s: %s", account.ID, err)
+
+		account.LastFailedTokenRetrievalAt = null.TimeFrom(now)
+		if err := s.externalAccountRepo.UpdateLastFailedTokenRetrievalAt(ctx, tx, account); err != nil {
+			return true, fmt.Errorf("externalaccount/refreshExpiringToken: recording failure of external account %s: %w", account.ID, err)
+		}
+		return false, nil
+	})
+}
+
+// expiresWithin reports whether the access token of the account expires
+// within the given window.
+func expiresWithin(account *model.ExternalAccount, now time.Time, window time.Duration) bool {
+	return account.AccessTokenExpiration.Valid && account.AccessTokenExpiration.Time.Before(now.Add(window))
+}
+
+// forEachConcurrently calls fn for every account, with at most limit calls
+// running at the same time. It returns the first error, after which the
+// accounts which haven't started yet are skipped.
+func forEachConcurrently(ctx context.Context, accounts []*model.ExternalAccount, limit int, fn func(context.Context, *model.ExternalAccount) error) error {
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(limit)
+	for _, account := range accounts {
+		account := account
+		group.Go(func() error {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fn(ctx, account)
+		})
+	}
+	return group.Wait()
+}
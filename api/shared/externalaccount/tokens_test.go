@@ -0,0 +1,88 @@
+package externalaccount
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestExpiresWithin(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name       string
+		expiration null.Time
+		expected   bool
+	}{
+		{name: "never expires"},
+		{name: "expires within the window", expiration: null.TimeFrom(now.Add(10 * time.Minute)), expected: true},
+		{name: "already expired", expiration: null.TimeFrom(now.Add(-time.Minute)), expected: true},
+		{name: "refreshed since it was listed", expiration: null.TimeFrom(now.Add(time.Hour))},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			account := &model.ExternalAccount{ExternalAccount: &sqbmodel.ExternalAccount{AccessTokenExpiration: tc.expiration}}
+			assert.Equal(t, tc.expected, expiresWithin(account, now, backgroundRefreshWindow))
+		})
+	}
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	t.Parallel()
+
+	accounts := make([]*model.ExternalAccount, 50)
+	for i := range accounts {
+		accounts[i] = &model.ExternalAccount{ExternalAccount: &sqbmodel.ExternalAccount{}}
+	}
+
+	var mu sync.Mutex
+	var running, maxRunning int
+	var calls atomic.Int32
+	err := forEachConcurrently(context.Background(), accounts, 4, func(context.Context, *model.ExternalAccount) error {
+		calls.Add(1)
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(len(accounts)), calls.Load())
+	assert.LessOrEqual(t, maxRunning, 4)
+}
+
+func TestForEachConcurrentlyStopsOnError(t *testing.T) {
+	t.Parallel()
+
+	accounts := make([]*model.ExternalAccount, 50)
+	for i := range accounts {
+		accounts[i] = &model.ExternalAccount{ExternalAccount: &sqbmodel.ExternalAccount{}}
+	}
+
+	failure := errors.New("database is down")
+	var calls atomic.Int32
+	err := forEachConcurrently(context.Background(), accounts, 1, func(context.Context, *model.ExternalAccount) error {
+		calls.Add(1)
+		return failure
+	})
+	assert.ErrorIs(t, err, failure)
+	assert.Equal(t, int32(1), calls.Load())
+}
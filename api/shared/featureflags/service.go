@@ -0,0 +1,112 @@
+// Package featureflags stores the feature flags of the APIs and evaluates
+// them for the instance of the request.
+//
+// Handlers check a flag with Enabled, instead of reading an environment
+// variable, so that rollouts can be targeted and widened without a deploy.
+package featureflags
+
+import (
+	"context"
+	"time"
+
+	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/pkg/ctx/environment"
+	clerkfeatureflags "clerk/pkg/featureflags"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+// flagsCacheTTL is how long the flags are cached for. The cache is also
+// cleared whenever a flag changes, so this only bounds how stale flags can
+// get if that fails.
+const flagsCacheTTL = time.Minute
+
+const flagsCacheKey = "feature_flags"
+
+type cachedFlags struct {
+	// Loaded tells apart a deployment without flags from a cache miss.
+	Loaded bool                              `json:"loaded"`
+	Flags  map[string]clerkfeatureflags.Flag `json:"flags"`
+}
+
+type Service struct {
+	cache cache.Cache
+	db    database.Database
+
+	// repositories
+	featureFlagRepo *repository.FeatureFlags
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		cache:           deps.Cache(),
+		db:              deps.DB(),
+		featureFlagRepo: deps.Repositories().FeatureFlags,
+	}
+}
+
+// Flags returns all the flags, by key, from the cache if possible. It's
+// the loader the API pipelines evaluate flags against.
+func (s *Service) Flags(ctx context.Context) (map[string]clerkfeatureflags.Flag, error) {
+	var cached cachedFlags
+	if err := s.cache.Get(ctx, flagsCacheKey, &cached); err != nil {
+		log.Warning(ctx, "featureflags: fetching cached flags: %s", err)
+	} else if cached.Loaded {
+		return cached.Flags, nil
+	}
+
+	flags, err := s.featureFlagRepo.FindAll(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = cachedFlags{Loaded: true, Flags: make(map[string]clerkfeatureflags.Flag, len(flags))}
+	for _, flag := range flags {
+		cached.Flags[flag.Key] = ToFlag(flag)
+	}
+	if err := s.cache.Set(ctx, flagsCacheKey, cached, flagsCacheTTL); err != nil {
+		log.Warning(ctx, "featureflags: caching flags: %s", err)
+	}
+	return cached.Flags, nil
+}
+
+// ClearCache drops the cached flags, so that changes to them are picked up
+// by the next request.
+func (s *Service) ClearCache(ctx context.Context) {
+	if err := s.cache.Delete(ctx, flagsCacheKey); err != nil {
+		log.Warning(ctx, "featureflags: clearing cached flags: %s", err)
+	}
+}
+
+// ToFlag returns the stored flag in the form it's evaluated in.
+func ToFlag(flag *model.FeatureFlag) clerkfeatureflags.Flag {
+	return clerkfeatureflags.Flag{
+		Key:            flag.Key,
+		Enabled:        flag.Enabled,
+		InstanceIDs:    flag.InstanceIds,
+		ApplicationIDs: flag.ApplicationIds,
+		Percentage:     flag.Percentage,
+	}
+}
+
+// Enabled reports whether the flag with the given key is on for the
+// instance of the request. Flags are off on requests without an instance
+// and if they can't be loaded, so that an outage of the flags falls back to
+// the existing behavior.
+func Enabled(ctx context.Context, key string) bool {
+	var target clerkfeatureflags.Target
+	if env := environment.FromContext(ctx); env != nil && env.Instance != nil {
+		target.InstanceID = env.Instance.ID
+		target.ApplicationID = env.Instance.ApplicationID
+	}
+
+	enabled, err := clerkfeatureflags.Enabled(ctx, key, target)
+	if err != nil {
+		log.Warning(ctx, "featureflags: evaluating %s: %s", key, err)
+		return false
+	}
+	return enabled
+}
@@ -0,0 +1,157 @@
+// Package flowstate tells clients what they need to do next to move a
+// sign-in or a sign-up forward, so that SDKs don't have to derive it from
+// the status, the factors and the verifications of the attempt themselves.
+package flowstate
+
+import (
+	"clerk/model"
+	"clerk/pkg/constants"
+)
+
+const (
+	// ActionIdentify means that the sign-in needs an identifier.
+	ActionIdentify = "identify"
+	// ActionSelectFirstFactor means that a first factor needs to be picked
+	// among the supported strategies, and prepared if the strategy requires
+	// it.
+	ActionSelectFirstFactor = "select_first_factor"
+	// ActionAttemptFirstFactor means that the prepared first factor
+	// verification needs to be attempted.
+	ActionAttemptFirstFactor   = "attempt_first_factor"
+	ActionSelectSecondFactor   = "select_second_factor"
+	ActionAttemptSecondFactor  = "attempt_second_factor"
+	ActionResetPassword        = "reset_password"
+	ActionProvideMissingFields = "provide_missing_fields"
+	ActionPrepareVerification  = "prepare_verification"
+	ActionAttemptVerification  = "attempt_verification"
+)
+
+// NextAction is the next step of a sign-in or a sign-up.
+type NextAction struct {
+	Action string `json:"action"`
+	// SupportedStrategies are the strategies the step can be completed with
+	SupportedStrategies []string `json:"supported_strategies"`
+	// Verification is the path of the verification the step is about, in
+	// the sign-in or the sign-up response, e.g. first_factor_verification
+	Verification *string `json:"verification"`
+	// MissingFields are the fields that need to be provided, if any
+	MissingFields []string `json:"missing_fields,omitempty"`
+}
+
+// SignIn is what the next action of a sign-in is derived from.
+type SignIn struct {
+	Status                   string
+	SupportedIdentifiers     []string
+	FirstFactors             []model.SignInFactor
+	SecondFactors            []model.SignInFactor
+	FirstFactorVerification  *model.VerificationWithStatus
+	SecondFactorVerification *model.VerificationWithStatus
+}
+
+// ForSignIn returns the next action of the sign-in, or nil if there's none,
+// e.g. because it's complete.
+func ForSignIn(signIn SignIn) *NextAction {
+	switch signIn.Status {
+	case constants.SignInNeedsIdentifier:
+		return &NextAction{
+			Action:              ActionIdentify,
+			SupportedStrategies: nonNil(signIn.SupportedIdentifiers),
+		}
+	case constants.SignInNeedsFirstFactor:
+		return factorAction(signIn.FirstFactorVerification, signIn.FirstFactors,
+			ActionAttemptFirstFactor, ActionSelectFirstFactor, "first_factor_verification")
+	case constants.SignInNeedsSecondFactor:
+		return factorAction(signIn.SecondFactorVerification, signIn.SecondFactors,
+			ActionAttemptSecondFactor, ActionSelectSecondFactor, "second_factor_verification")
+	case constants.SignInNeedsNewPassword:
+		return &NextAction{
+			Action:              ActionResetPassword,
+			SupportedStrategies: []string{},
+		}
+	default:
+		return nil
+	}
+}
+
+// factorAction continues with the prepared verification, unless it can't
+// be attempted anymore, in which case any of the factors can be picked.
+func factorAction(verification *model.VerificationWithStatus, factors []model.SignInFactor, attemptAction, selectAction, path string) *NextAction {
+	if verification != nil && verification.Status == constants.VERUnverified {
+		return &NextAction{
+			Action:              attemptAction,
+			SupportedStrategies: []string{verification.Strategy},
+			Verification:        &path,
+		}
+	}
+
+	strategies := make([]string, 0, len(factors))
+	seen := make(map[string]bool, len(factors))
+	for _, factor := range factors {
+		if !seen[factor.Strategy] {
+			seen[factor.Strategy] = true
+			strategies = append(strategies, factor.Strategy)
+		}
+	}
+	return &NextAction{
+		Action:              selectAction,
+		SupportedStrategies: strategies,
+	}
+}
+
+// SignUp is what the next action of a sign-up is derived from.
+type SignUp struct {
+	Status           string
+	MissingFields    []string
+	UnverifiedFields []string
+	// Verifications holds the verifications of the sign-up by the field
+	// they verify, e.g. email_address
+	Verifications map[string]*model.VerificationWithStatus
+}
+
+// ForSignUp returns the next action of the sign-up, or nil if there's none.
+// Missing fields come first, as providing them may change which fields
+// need to be verified.
+func ForSignUp(signUp SignUp) *NextAction {
+	if signUp.Status != constants.SignUpMissingRequirements {
+		return nil
+	}
+
+	if len(signUp.MissingFields) > 0 {
+		return &NextAction{
+			Action:              ActionProvideMissingFields,
+			SupportedStrategies: []string{},
+			MissingFields:       signUp.MissingFields,
+		}
+	}
+
+	if len(signUp.UnverifiedFields) == 0 {
+		return nil
+	}
+	field := signUp.UnverifiedFields[0]
+	path := "verifications." + field
+	verification := signUp.Verifications[field]
+	if verification == nil {
+		return &NextAction{
+			Action:              ActionPrepareVerification,
+			SupportedStrategies: []string{},
+			Verification:        &path,
+		}
+	}
+
+	action := ActionPrepareVerification
+	if verification.Status == constants.VERUnverified {
+		action = ActionAttemptVerification
+	}
+	return &NextAction{
+		Action:              action,
+		SupportedStrategies: []string{verification.Strategy},
+		Verification:        &path,
+	}
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,105 @@
+package flowstate
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func verification(strategy, status string) *model.VerificationWithStatus {
+	return &model.VerificationWithStatus{
+		Verification: &model.Verification{Verification: &sqbmodel.Verification{Strategy: strategy}},
+		Status:       status,
+	}
+}
+
+func TestForSignIn(t *testing.T) {
+	t.Parallel()
+
+	firstFactorPath := "first_factor_verification"
+	factors := []model.SignInFactor{
+		{Strategy: constants.VSPassword},
+		{Strategy: constants.VSEmailCode},
+		{Strategy: constants.VSEmailCode},
+	}
+
+	for _, tc := range []struct {
+		name   string
+		signIn SignIn
+		want   *NextAction
+	}{
+		{
+			name:   "needs identifier",
+			signIn: SignIn{Status: constants.SignInNeedsIdentifier, SupportedIdentifiers: []string{"email_address"}},
+			want:   &NextAction{Action: ActionIdentify, SupportedStrategies: []string{"email_address"}},
+		},
+		{
+			name:   "first factor not prepared",
+			signIn: SignIn{Status: constants.SignInNeedsFirstFactor, FirstFactors: factors},
+			want:   &NextAction{Action: ActionSelectFirstFactor, SupportedStrategies: []string{constants.VSPassword, constants.VSEmailCode}},
+		},
+		{
+			name: "first factor prepared",
+			signIn: SignIn{
+				Status:                  constants.SignInNeedsFirstFactor,
+				FirstFactors:            factors,
+				FirstFactorVerification: verification(constants.VSEmailCode, constants.VERUnverified),
+			},
+			want: &NextAction{Action: ActionAttemptFirstFactor, SupportedStrategies: []string{constants.VSEmailCode}, Verification: &firstFactorPath},
+		},
+		{
+			name: "first factor expired",
+			signIn: SignIn{
+				Status:                  constants.SignInNeedsFirstFactor,
+				FirstFactors:            factors,
+				FirstFactorVerification: verification(constants.VSEmailCode, constants.VERExpired),
+			},
+			want: &NextAction{Action: ActionSelectFirstFactor, SupportedStrategies: []string{constants.VSPassword, constants.VSEmailCode}},
+		},
+		{
+			name:   "complete",
+			signIn: SignIn{Status: constants.SignInComplete},
+			want:   nil,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, ForSignIn(tc.signIn))
+		})
+	}
+}
+
+func TestForSignUp(t *testing.T) {
+	t.Parallel()
+
+	emailAddressPath := "verifications.email_address"
+
+	assert.Equal(t, &NextAction{
+		Action:              ActionProvideMissingFields,
+		SupportedStrategies: []string{},
+		MissingFields:       []string{"username"},
+	}, ForSignUp(SignUp{
+		Status:           constants.SignUpMissingRequirements,
+		MissingFields:    []string{"username"},
+		UnverifiedFields: []string{constants.ITEmailAddress},
+	}))
+
+	assert.Equal(t, &NextAction{
+		Action:              ActionAttemptVerification,
+		SupportedStrategies: []string{constants.VSEmailCode},
+		Verification:        &emailAddressPath,
+	}, ForSignUp(SignUp{
+		Status:           constants.SignUpMissingRequirements,
+		UnverifiedFields: []string{constants.ITEmailAddress},
+		Verifications: map[string]*model.VerificationWithStatus{
+			constants.ITEmailAddress: verification(constants.VSEmailCode, constants.VERUnverified),
+		},
+	}))
+
+	assert.Nil(t, ForSignUp(SignUp{Status: constants.SignUpComplete}))
+}
@@ -43,7 +43,7 @@ func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		clock:           deps.Clock(),
 		gueClient:       deps.GueClient(),
-		integrationRepo: repository.NewIntegrations(),
+		integrationRepo: deps.Repositories().Integrations,
 	}
 }
 
@@ -0,0 +1,126 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"clerk/pkg/cache"
+	"clerk/pkg/cenv"
+	"clerk/utils/clerk"
+)
+
+const (
+	// Header is the request header which carries the idempotency key.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader is set on the responses which were replayed from a
+	// previous request with the same key.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// MaxKeyLength is the maximum length of an idempotency key.
+	MaxKeyLength = 255
+
+	// responseTTL is how long the response of a request is stored, and so
+	// for how long it can be retried with the same key.
+	responseTTL = 24 * time.Hour
+
+	// lockMargin is how much longer a request holds its key than it can
+	// take, in case the process dies before storing or releasing it.
+	lockMargin = time.Minute
+)
+
+// Record is the stored state of an idempotency key. Records are created
+// in progress when the first request with the key starts, and completed with
+// its response once it's done.
+type Record struct {
+	RequestHash string `json:"request_hash"`
+	Completed   bool   `json:"completed"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Service stores the idempotency keys of the requests, along with their
+// responses, so that retries of a request replay its response instead of
+// performing it again. Keys are scoped to the instance.
+//
+// Keys are claimed atomically, so that out of concurrent requests with the
+// same key only one is performed.
+type Service struct {
+	cache cache.Cache
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		cache: deps.Cache(),
+	}
+}
+
+// Claim marks the key as in progress for the request with the given hash,
+// unless it was already used. It returns nil if the key was claimed, or the
+// record of the request which used it first otherwise.
+func (s *Service) Claim(ctx context.Context, instanceID, key, requestHash string) (*Record, error) {
+	cacheKey := cacheKey(instanceID, key)
+	claimed, err := s.cache.SetNX(ctx, cacheKey, Record{RequestHash: requestHash}, lockTTL())
+	if err != nil {
+		return nil, fmt.Errorf("idempotency/claim: storing key %s of instance %s: %w", key, instanceID, err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	var record Record
+	if err := s.cache.Get(ctx, cacheKey, &record); err != nil {
+		return nil, fmt.Errorf("idempotency/claim: fetching key %s of instance %s: %w", key, instanceID, err)
+	}
+	if record.RequestHash == "" {
+		// The key was released or expired right after the claim failed. It's
+		// reported as in progress, so that the request is retried.
+		record.RequestHash = requestHash
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request which holds the key.
+func (s *Service) Complete(ctx context.Context, instanceID, key string, record Record) error {
+	record.Completed = true
+	if err := s.cache.Set(ctx, cacheKey(instanceID, key), record, responseTTL); err != nil {
+		return fmt.Errorf("idempotency/complete: storing response of key %s of instance %s: %w", key, instanceID, err)
+	}
+	return nil
+}
+
+// Release frees the key without storing a response, so that the request
+// can be retried with it.
+func (s *Service) Release(ctx context.Context, instanceID, key string) error {
+	if err := s.cache.Delete(ctx, cacheKey(instanceID, key)); err != nil {
+		return fmt.Errorf("idempotency/release: deleting key %s of instance %s: %w", key, instanceID, err)
+	}
+	return nil
+}
+
+// RequestHash identifies a request by its method, path and body, so that a
+// key can't be reused for a different request.
+func RequestHash(method, requestURI string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(requestURI))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// lockTTL is how long a request holds its key while being processed. Requests
+// are aborted once their context times out, so the key outlives them and
+// can't be claimed by a retry while they're still running.
+func lockTTL() time.Duration {
+	return time.Duration(cenv.GetInt(cenv.ContextTimeoutSeconds))*time.Second + lockMargin
+}
+
+func cacheKey(instanceID, key string) string {
+	return "idempotency:" + instanceID + ":" + key
+}
@@ -0,0 +1,22 @@
+package idempotency
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestHash(t *testing.T) {
+	t.Parallel()
+
+	hash := RequestHash(http.MethodPost, "/v1/users", []byte(`{"first_name":"Jane"}`))
+	assert.Equal(t, hash, RequestHash(http.MethodPost, "/v1/users", []byte(`{"first_name":"Jane"}`)))
+
+	assert.NotEqual(t, hash, RequestHash(http.MethodPost, "/v1/users", []byte(`{"first_name":"John"}`)))
+	assert.NotEqual(t, hash, RequestHash(http.MethodPost, "/v1/invitations", []byte(`{"first_name":"Jane"}`)))
+	assert.NotEqual(t, hash, RequestHash(http.MethodPut, "/v1/users", []byte(`{"first_name":"Jane"}`)))
+
+	// the separators keep the parts from running into each other
+	assert.NotEqual(t, RequestHash("POST", "/v1/users", nil), RequestHash("POST/", "v1/users", nil))
+}
@@ -52,11 +52,11 @@ func NewService(deps clerk.Deps) *Service {
 		orgDomainService:    orgdomain.NewService(deps.Clock()),
 		serializableService: serializable.NewService(deps.Clock()),
 		sessionService:      sessions.NewService(deps),
-		identificationRepo:  repository.NewIdentification(),
-		userRepo:            repository.NewUsers(),
-		verificationRepo:    repository.NewVerification(),
-		orgInvitationRepo:   repository.NewOrganizationInvitation(),
-		orgSuggestionRepo:   repository.NewOrganizationSuggestion(),
+		identificationRepo:  deps.Repositories().Identification,
+		userRepo:            deps.Repositories().Users,
+		verificationRepo:    deps.Repositories().Verification,
+		orgInvitationRepo:   deps.Repositories().OrganizationInvitation,
+		orgSuggestionRepo:   deps.Repositories().OrganizationSuggestion,
 	}
 }
 
@@ -67,6 +67,8 @@ type CreateIdentificationData struct {
 	Type                   string
 	ReserveForSecondFactor bool
 	UserID                 *string
+	// ChainID is the CAIP-2 chain id of web3 wallets
+	ChainID *string
 }
 
 func (s *Service) CreateIdentification(
@@ -92,6 +94,7 @@ func (s *Service) CreateIdentification(
 		Identifier:              null.StringFrom(data.Identifier),
 		ReservedForSecondFactor: data.ReserveForSecondFactor,
 		UserID:                  null.StringFromPtr(data.UserID),
+		ChainID:                 null.StringFromPtr(data.ChainID),
 		Status:                  constants.ISNotSet,
 	}}
 
@@ -174,13 +177,18 @@ func (s *Service) Delete(
 		return nil, apierror.Unexpected(err)
 	}
 
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
 	// Delete identification and touch user.
 	if err := s.attemptDelete(ctx, tx, ident, user, userSettings, ins.ID); err != nil {
 		return nil, err
 	}
 
 	// Trigger a user.updated event
-	err = s.sendUserUpdatedEvent(ctx, tx, ins, userSettings, user)
+	err = s.sendUserUpdatedEvent(ctx, tx, ins, userSettings, previousUser, user)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
@@ -413,12 +421,17 @@ func (s *Service) FinalizeVerification(ctx context.Context, tx database.Tx, iden
 		return ErrIdentifierAlreadyExists
 	}
 
-	err = s.updateVerifiedIdentification(ctx, tx, ident)
+	user, err := s.userRepo.FindByID(ctx, tx, ident.UserID.String)
 	if err != nil {
 		return err
 	}
 
-	user, err := s.userRepo.FindByID(ctx, tx, ident.UserID.String)
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, user)
+	if err != nil {
+		return err
+	}
+
+	err = s.updateVerifiedIdentification(ctx, tx, ident)
 	if err != nil {
 		return err
 	}
@@ -428,14 +441,89 @@ func (s *Service) FinalizeVerification(ctx context.Context, tx database.Tx, iden
 		return err
 	}
 
-	// Trigger user.updated event
-	if err = s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, user); err != nil {
+	reused, err := s.reuseIdentityProviderVerification(ctx, tx, ident, instance.ID, userSettings)
+	if err != nil {
+		return fmt.Errorf("FinalizeVerification: reuse identity provider verification of %s: %w", ident.ID, err)
+	}
+
+	// Trigger user.updated event. When other identifications were verified
+	// along with this one, the changed fields include their accounts too.
+	err = s.sendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user)
+	if err != nil {
 		return fmt.Errorf("FinalizeVerification: send user updated event for (%+v, %+v): %w", user, instance.ID, err)
 	}
 
 	return nil
 }
 
+// reuseIdentityProviderVerification verifies the rest of the user's
+// unverified identifications for the same email address, if the instance
+// allows it and the email address was just verified by an identity provider,
+// i.e. a SAML IdP or an OAuth provider. An IdP which asserted the email
+// address proves ownership of it for every account which claims it.
+//
+// Only identifications of the same user are verified; the ones of other
+// users still need to be verified by their owners.
+func (s *Service) reuseIdentityProviderVerification(
+	ctx context.Context,
+	tx database.Tx,
+	ident *model.Identification,
+	instanceID string,
+	userSettings *usersettings.UserSettings,
+) ([]*model.Identification, error) {
+	if !userSettings.SignIn.ReuseIdPEmailVerification || !ident.IsEmailAddress() || !ident.VerificationID.Valid {
+		return nil, nil
+	}
+
+	verification, err := s.verificationRepo.FindByID(ctx, tx, ident.VerificationID.String)
+	if err != nil {
+		return nil, err
+	}
+	if !verifiedByIdentityProvider(verification) {
+		return nil, nil
+	}
+
+	idents, err := s.identificationRepo.FindAllByInstanceAndUser(ctx, tx, instanceID, ident.UserID.String)
+	if err != nil {
+		return nil, err
+	}
+
+	reused := unverifiedWithSameIdentifier(idents, ident)
+	for _, other := range reused {
+		if err := s.updateVerifiedIdentification(ctx, tx, other); err != nil {
+			return nil, err
+		}
+	}
+	return reused, nil
+}
+
+// verifiedByIdentityProvider reports whether the verification was completed
+// by a SAML IdP or an OAuth provider, instead of a code or a link.
+func verifiedByIdentityProvider(verification *model.Verification) bool {
+	if verification.Strategy == constants.VSSAML {
+		return true
+	}
+	providerID, _ := strings.CutPrefix(verification.Strategy, constants.StrategyFrom(""))
+	return oauth.ProviderExists(providerID)
+}
+
+// unverifiedWithSameIdentifier returns the identifications, other than ident,
+// which have the same identifier and are still unverified. Reserved
+// identifications are left out, they're restored separately.
+func unverifiedWithSameIdentifier(idents []*model.Identification, ident *model.Identification) []*model.Identification {
+	var matching []*model.Identification
+	for _, other := range idents {
+		if other.ID == ident.ID || other.IsVerified() || other.IsReserved() {
+			continue
+		}
+		if !other.Identifier.Valid || !strings.EqualFold(other.Identifier.String, ident.Identifier.String) {
+			continue
+		}
+		matching = append(matching, other)
+	}
+	return matching
+}
+
 func (s Service) RestoreUserReservedAndPrimaryIdentifications(ctx context.Context, exec database.Executor, ident *model.Identification, userSettings *usersettings.UserSettings, instanceID string, user *model.User) error {
 	var err error
 
@@ -643,6 +731,7 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User,
 ) error {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
@@ -650,7 +739,7 @@ func (s *Service) sendUserUpdatedEvent(
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
@@ -0,0 +1,46 @@
+package identifications
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestUnverifiedWithSameIdentifier(t *testing.T) {
+	t.Parallel()
+
+	newIdent := func(id, identType, identifier, status string) *model.Identification {
+		return &model.Identification{Identification: &sqbmodel.Identification{
+			ID:         id,
+			Type:       identType,
+			Identifier: null.StringFrom(identifier),
+			Status:     status,
+		}}
+	}
+
+	email := newIdent("idn_email", constants.ITEmailAddress, "jane@example.com", constants.ISVerified)
+	google := newIdent("idn_google", "oauth_google", "Jane@example.com", constants.ISNotSet)
+	saml := newIdent("idn_saml", constants.ITSAML, "jane@example.com", constants.ISVerified)
+	github := newIdent("idn_github", "oauth_github", "jane@other.com", constants.ISNotSet)
+	reserved := newIdent("idn_reserved", "oauth_gitlab", "jane@example.com", constants.ISReserved)
+
+	matching := unverifiedWithSameIdentifier([]*model.Identification{email, google, saml, github, reserved}, email)
+	assert.Equal(t, []*model.Identification{google}, matching)
+}
+
+func TestVerifiedByIdentityProvider(t *testing.T) {
+	t.Parallel()
+
+	newVerification := func(strategy string) *model.Verification {
+		return &model.Verification{Verification: &sqbmodel.Verification{Strategy: strategy}}
+	}
+
+	assert.True(t, verifiedByIdentityProvider(newVerification(constants.VSSAML)))
+	assert.True(t, verifiedByIdentityProvider(newVerification(constants.StrategyFrom("oauth_google"))))
+	assert.False(t, verifiedByIdentityProvider(newVerification("email_code")))
+}
@@ -22,18 +22,21 @@ import (
 	"clerk/repository"
 	"clerk/utils/database"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/volatiletech/null/v8"
 )
 
 type Service struct {
+	clock   clockwork.Clock
 	storage storage.ReadWriter
 
 	// repositories
 	imageRepo *repository.Images
 }
 
-func NewService(storageClient storage.ReadWriter) *Service {
+func NewService(clock clockwork.Clock, storageClient storage.ReadWriter) *Service {
 	return &Service{
+		clock:     clock,
 		storage:   storageClient,
 		imageRepo: repository.NewImages(),
 	}
@@ -67,17 +70,6 @@ func (s *Service) Create(
 	exec database.Executor,
 	params ImageParams,
 ) (*model.Image, apierror.Error) {
-	header := bytes.NewBuffer(nil)
-	_, err := io.CopyN(header, params.Src, 512)
-	if err != nil && !errors.Is(err, io.EOF) {
-		return nil, apierror.Unexpected(err)
-	}
-
-	fileType := http.DetectContentType(header.Bytes())
-	if !imgTypesRe.MatchString(fileType) {
-		return nil, apierror.ImageTypeNotSupported(fileType)
-	}
-
 	if params.ImageID == "" {
 		imageID := rand.InternalClerkID(constants.IDPImage)
 		params.ImageID = imageID
@@ -89,16 +81,9 @@ func (s *Service) Create(
 		return nil, apierror.Unexpected(err)
 	}
 
-	var netErr net.Error
-	size, err := s.uploadImage(ctx, path, header, limitreader.NewLimitStreamReadCloser(params.Src, maxImageSize))
-	if errors.Is(err, limitreader.ErrThresholdExceeded) {
-		return nil, apierror.ImageTooLarge()
-	} else if errors.As(err, &netErr) && netErr.Timeout() {
-		return nil, apierror.GatewayTimeout()
-	} else if err != nil {
-		return nil, apierror.Unexpected(err)
-	} else if size == 0 {
-		return nil, apierror.RequestWithoutImage()
+	fileType, size, apiErr := s.store(ctx, path, params.Src)
+	if apiErr != nil {
+		return nil, apiErr
 	}
 	image := &model.Image{Image: &sqbmodel.Image{
 		ID:                 params.ImageID,
@@ -118,6 +103,50 @@ func (s *Service) Create(
 	return image, nil
 }
 
+// store validates the image read from src and writes it to the given path.
+// The type of the image is sniffed from its content, never taken from what
+// the client claims.
+func (s *Service) store(ctx context.Context, path string, src io.ReadCloser) (string, int, apierror.Error) {
+	header := bytes.NewBuffer(nil)
+	_, err := io.CopyN(header, src, sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", 0, apierror.Unexpected(err)
+	}
+
+	fileType, apiErr := sniffImageType(header.Bytes())
+	if apiErr != nil {
+		return "", 0, apiErr
+	}
+
+	var netErr net.Error
+	size, err := s.uploadImage(ctx, path, header, limitreader.NewLimitStreamReadCloser(src, maxImageSize))
+	if errors.Is(err, limitreader.ErrThresholdExceeded) {
+		return "", 0, apierror.ImageTooLarge()
+	} else if errors.As(err, &netErr) && netErr.Timeout() {
+		return "", 0, apierror.GatewayTimeout()
+	} else if err != nil {
+		return "", 0, apierror.Unexpected(err)
+	} else if size == 0 {
+		return "", 0, apierror.RequestWithoutImage()
+	}
+	return fileType, size, nil
+}
+
+// sniffLen is how much of an image is read to detect its type.
+const sniffLen = 512
+
+// sniffImageType detects the type of an image from its first bytes.
+func sniffImageType(header []byte) (string, apierror.Error) {
+	if len(header) == 0 {
+		return "", apierror.RequestWithoutImage()
+	}
+	fileType := http.DetectContentType(header)
+	if !imgTypesRe.MatchString(fileType) {
+		return "", apierror.ImageTypeNotSupported(fileType)
+	}
+	return fileType, nil
+}
+
 func (s *Service) uploadImage(ctx context.Context, uploadPath string, header *bytes.Buffer, src io.ReadCloser) (int, error) {
 	size, err := s.storage.Write(ctx, uploadPath, io.MultiReader(header, src))
 	if err != nil {
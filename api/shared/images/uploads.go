@@ -0,0 +1,189 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/rand"
+	"clerk/pkg/storage"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Direct uploads let clients upload images straight to storage, instead of
+// streaming them through the API. Clients first request a signed upload URL,
+// which creates a pending image, then upload the image to it and finally
+// complete the upload, at which point the image is validated and can be used.
+//
+// Signed URLs point to a private path, which is never served. Completing an
+// upload reads the image from there, sniffs its type from its content and
+// copies it to its public path, which clients can't write to. That way the
+// image which was validated is the one that's served, even if the client
+// uploads again after completing.
+
+const (
+	// uploadURLTTL is how long clients have to upload an image once they get
+	// a signed upload URL.
+	uploadURLTTL = 15 * time.Minute
+
+	// pendingUploadTTL is how long clients have to complete an upload. Pending
+	// images are swept after that.
+	pendingUploadTTL = uploadURLTTL + time.Hour
+
+	// prefixPendingUpload is the private path signed upload URLs point to.
+	prefixPendingUpload = "pending_uploads"
+)
+
+type UploadParams struct {
+	Filename           string
+	ContentType        string
+	UploaderUserID     string
+	UsedByResourceType *string
+}
+
+// Upload is a pending image, along with the signed URL it can be uploaded to.
+type Upload struct {
+	Image     *model.Image
+	URL       string
+	ExpiresAt time.Time
+}
+
+// CreateUpload creates a pending image and signs a URL for uploading it
+// directly to storage. The signed URL only accepts the given content type,
+// but the type is checked again against the content of the image once the
+// upload is completed.
+func (s *Service) CreateUpload(ctx context.Context, exec database.Executor, params UploadParams) (*Upload, apierror.Error) {
+	if !imgTypesRe.MatchString(params.ContentType) {
+		return nil, apierror.ImageTypeNotSupported(params.ContentType)
+	}
+
+	imageID := rand.InternalClerkID(constants.IDPImage)
+	publicURL, err := s.storage.PublicURL(uploadPath(imageID, PrefixUploaded))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	expiresAt := s.clock.Now().UTC().Add(uploadURLTTL)
+	uploadURL, err := s.storage.SignedUploadURL(ctx, uploadPath(imageID, prefixPendingUpload), params.ContentType, expiresAt)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	image := &model.Image{Image: &sqbmodel.Image{
+		ID:                 imageID,
+		Name:               params.Filename,
+		PublicURL:          publicURL,
+		FileType:           params.ContentType,
+		UploaderUserID:     null.StringFrom(params.UploaderUserID),
+		UsedByResourceType: null.StringFromPtr(params.UsedByResourceType),
+		Pending:            true,
+	}}
+	if err := s.imageRepo.Insert(ctx, exec, image); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return &Upload{Image: image, URL: uploadURL, ExpiresAt: expiresAt}, nil
+}
+
+type CompleteUploadParams struct {
+	ImageID            string
+	UploaderUserID     string
+	UsedByResourceType *string
+}
+
+// CompleteUpload validates an image which was uploaded through a signed
+// upload URL, copies it to its public path and marks it as ready to use. Only
+// the uploader of the pending image can complete it, for the resource type it
+// was created for, until the pending image expires. The upload is removed
+// from its private path, whether it's valid or not.
+func (s *Service) CompleteUpload(ctx context.Context, exec database.Executor, params CompleteUploadParams) (*model.Image, apierror.Error) {
+	image, err := s.imageRepo.QueryByID(ctx, exec, params.ImageID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if image == nil || !image.Pending ||
+		isPendingUploadExpired(image, s.clock.Now()) ||
+		image.UploaderUserID != null.StringFrom(params.UploaderUserID) ||
+		image.UsedByResourceType != null.StringFromPtr(params.UsedByResourceType) {
+		return nil, apierror.ImageNotFound()
+	}
+
+	pendingPath := uploadPath(image.ID, prefixPendingUpload)
+	src, err := s.storage.Read(ctx, pendingPath)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, apierror.RequestWithoutImage()
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	defer func() {
+		if err := s.storage.Delete(ctx, pendingPath); err != nil {
+			log.Warning(ctx, "images/completeUpload: deleting pending upload %s: %s", pendingPath, err)
+		}
+	}()
+
+	// store closes src once the image is copied
+	fileType, size, apiErr := s.store(ctx, uploadPath(image.ID, PrefixUploaded), src)
+	if apiErr != nil {
+		_ = src.Close()
+		return nil, apiErr
+	}
+
+	image.Bytes = size
+	image.FileType = fileType
+	image.Pending = false
+	err = s.imageRepo.Update(ctx, exec, image,
+		sqbmodel.ImageColumns.Bytes,
+		sqbmodel.ImageColumns.FileType,
+		sqbmodel.ImageColumns.Pending,
+	)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return image, nil
+}
+
+// isPendingUploadExpired reports whether it's too late to complete the upload
+// of the pending image.
+func isPendingUploadExpired(image *model.Image, now time.Time) bool {
+	return !now.Before(image.CreatedAt.Add(pendingUploadTTL))
+}
+
+// SweepPendingUploads deletes up to limit pending images which were never
+// completed in time, along with anything that was uploaded for them. It
+// returns the number of images which were deleted. It's invoked by the
+// pending image uploads cleanup.
+func (s *Service) SweepPendingUploads(ctx context.Context, exec database.Executor, limit int) (int, error) {
+	images, err := s.imageRepo.FindAllPendingCreatedBefore(ctx, exec, s.clock.Now().UTC().Add(-pendingUploadTTL), limit)
+	if err != nil {
+		return 0, fmt.Errorf("images/sweepPendingUploads: fetching pending images: %w", err)
+	}
+
+	var swept int
+	for _, image := range images {
+		// the image is only deleted if it's still pending, so that an upload
+		// which completed in the meantime is kept
+		deleted, err := s.imageRepo.DeletePendingByID(ctx, exec, image.ID)
+		if err != nil {
+			return swept, fmt.Errorf("images/sweepPendingUploads: deleting image %s: %w", image.ID, err)
+		}
+		if deleted == 0 {
+			continue
+		}
+
+		for _, path := range []string{uploadPath(image.ID, prefixPendingUpload), uploadPath(image.ID, PrefixUploaded)} {
+			if err := s.storage.Delete(ctx, path); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+				log.Warning(ctx, "images/sweepPendingUploads: deleting %s: %s", path, err)
+			}
+		}
+		swept++
+	}
+	return swept, nil
+}
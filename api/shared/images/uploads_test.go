@@ -0,0 +1,58 @@
+package images
+
+import (
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffImageType(t *testing.T) {
+	t.Parallel()
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	fileType, apiErr := sniffImageType(png)
+	require.Nil(t, apiErr)
+	assert.Equal(t, "image/png", fileType)
+
+	fileType, apiErr = sniffImageType([]byte("GIF89a\x01\x00\x01\x00"))
+	require.Nil(t, apiErr)
+	assert.Equal(t, "image/gif", fileType)
+
+	// Content which claimed to be an image when the upload was requested is
+	// still rejected, whatever it claimed.
+	_, apiErr = sniffImageType([]byte("<html><script>alert(1)</script></html>"))
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.RequestBodyInvalidCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = sniffImageType([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.RequestBodyInvalidCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = sniffImageType(nil)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamMissingCode, apiErr.Errors()[0].Code())
+}
+
+func TestIsPendingUploadExpired(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	image := &model.Image{Image: &sqbmodel.Image{ID: "img_1", Pending: true, CreatedAt: createdAt}}
+
+	assert.False(t, isPendingUploadExpired(image, createdAt))
+	assert.False(t, isPendingUploadExpired(image, createdAt.Add(pendingUploadTTL-time.Second)))
+	assert.True(t, isPendingUploadExpired(image, createdAt.Add(pendingUploadTTL)))
+}
+
+func TestUploadPaths(t *testing.T) {
+	t.Parallel()
+
+	// Signed upload URLs must never point to the public path of the image.
+	assert.NotEqual(t, uploadPath("img_1", PrefixUploaded), uploadPath("img_1", prefixPendingUpload))
+}
@@ -0,0 +1,167 @@
+// Package impersonation_audits keeps a trail of the impersonation sessions
+// that actor tokens create, so that the actions of support staff can be
+// traced back to who performed them, on whose account and why.
+package impersonation_audits
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// auditStore is the subset of repository.ImpersonationAudits the service
+// needs.
+type auditStore interface {
+	Insert(ctx context.Context, exec database.Executor, audit *model.ImpersonationAudit) error
+	UpdateEndedAtBySessionIDs(ctx context.Context, exec database.Executor, sessionIDs []string, endedAt time.Time) error
+	FindAllByInstance(ctx context.Context, exec database.Executor, instanceID string, filters repository.ImpersonationAuditFilters, pagination pagination.Params) ([]*model.ImpersonationAudit, error)
+	CountByInstance(ctx context.Context, exec database.Executor, instanceID string, filters repository.ImpersonationAuditFilters) (int64, error)
+}
+
+type Service struct {
+	clock clockwork.Clock
+
+	// repositories
+	impersonationAuditRepo auditStore
+}
+
+func NewService(clock clockwork.Clock) *Service {
+	return &Service{
+		clock:                  clock,
+		impersonationAuditRepo: repository.NewImpersonationAudits(),
+	}
+}
+
+// Record persists the audit record of an impersonation session which was
+// just created with the given actor token.
+func (s *Service) Record(ctx context.Context, exec database.Executor, session *model.Session, actorToken *model.ActorToken) error {
+	audit, err := newAudit(session, actorToken)
+	if err != nil {
+		return fmt.Errorf("impersonation_audits/record: %w", err)
+	}
+	if err := s.impersonationAuditRepo.Insert(ctx, exec, audit); err != nil {
+		return fmt.Errorf("impersonation_audits/record: inserting audit for session %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+func newAudit(session *model.Session, actorToken *model.ActorToken) (*model.ImpersonationAudit, error) {
+	actorID, err := actorToken.ActorID()
+	if err != nil {
+		return nil, fmt.Errorf("reading actor of actor token %s: %w", actorToken.ID, err)
+	}
+
+	return &model.ImpersonationAudit{ImpersonationAudit: &sqbmodel.ImpersonationAudit{
+		InstanceID:   session.InstanceID,
+		ActorTokenID: actorToken.ID,
+		ActorID:      actorID,
+		Actor:        actorToken.Actor,
+		UserID:       session.UserID,
+		SessionID:    session.ID,
+		Reason:       actorToken.Reason,
+		StartedAt:    session.CreatedAt,
+		ExpireAt:     session.ExpireAt,
+	}}, nil
+}
+
+// RecordEnd marks the impersonation sessions among the given sessions as
+// ended now, because they were ended, removed or revoked. Sessions which
+// aren't impersonation sessions are ignored, and so are the ones which have
+// already ended, so that the first end is kept.
+func (s *Service) RecordEnd(ctx context.Context, exec database.Executor, sessions ...*model.Session) error {
+	sessionIDs := impersonationSessionIDs(sessions)
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	if err := s.impersonationAuditRepo.UpdateEndedAtBySessionIDs(ctx, exec, sessionIDs, s.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("impersonation_audits/recordEnd: updating audits of sessions %v: %w", sessionIDs, err)
+	}
+	return nil
+}
+
+func impersonationSessionIDs(sessions []*model.Session) []string {
+	var sessionIDs []string
+	for _, session := range sessions {
+		if session.HasActor() {
+			sessionIDs = append(sessionIDs, session.ID)
+		}
+	}
+	return sessionIDs
+}
+
+// ListParams filter the audit records of an instance.
+type ListParams struct {
+	ActorID    *string
+	UserID     *string
+	Pagination pagination.Params
+}
+
+// ListParamsFromRequest reads the filters and pagination of a request for
+// the audit records of an instance, from the actor_id, user_id, limit and
+// offset query parameters.
+func ListParamsFromRequest(r *http.Request) (ListParams, apierror.Error) {
+	paginationParams, apiErr := pagination.NewFromRequest(r)
+	if apiErr != nil {
+		return ListParams{}, apiErr
+	}
+	return ListParams{
+		ActorID:    queryParam(r, "actor_id"),
+		UserID:     queryParam(r, "user_id"),
+		Pagination: paginationParams,
+	}, nil
+}
+
+func queryParam(r *http.Request, name string) *string {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// List returns a page of the audit records of the instance which match the
+// given filters, most recent first, along with the total number of matching
+// records.
+func (s *Service) List(ctx context.Context, exec database.Executor, instanceID string, params ListParams) ([]*model.ImpersonationAudit, int64, error) {
+	filters := repository.ImpersonationAuditFilters{
+		ActorID: params.ActorID,
+		UserID:  params.UserID,
+	}
+
+	audits, err := s.impersonationAuditRepo.FindAllByInstance(ctx, exec, instanceID, filters, params.Pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("impersonation_audits/list: fetching audits of instance %s: %w", instanceID, err)
+	}
+
+	totalCount, err := s.impersonationAuditRepo.CountByInstance(ctx, exec, instanceID, filters)
+	if err != nil {
+		return nil, 0, fmt.Errorf("impersonation_audits/list: counting audits of instance %s: %w", instanceID, err)
+	}
+	return audits, totalCount, nil
+}
+
+// ListResponse is List, serialized as the paginated response of the
+// impersonation audits endpoints of the dashboard and support APIs.
+func (s *Service) ListResponse(ctx context.Context, exec database.Executor, instanceID string, params ListParams) (*serialize.PaginatedResponse, apierror.Error) {
+	audits, totalCount, err := s.List(ctx, exec, instanceID, params)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]any, len(audits))
+	for i, audit := range audits {
+		responses[i] = serialize.ImpersonationAudit(audit)
+	}
+	return serialize.Paginated(responses, totalCount), nil
+}
@@ -0,0 +1,134 @@
+package impersonation_audits
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/types"
+)
+
+type fakeAuditStore struct {
+	inserted        []*model.ImpersonationAudit
+	endedSessionIDs []string
+	endedAt         time.Time
+	audits          []*model.ImpersonationAudit
+	filters         repository.ImpersonationAuditFilters
+}
+
+func (f *fakeAuditStore) Insert(_ context.Context, _ database.Executor, audit *model.ImpersonationAudit) error {
+	f.inserted = append(f.inserted, audit)
+	return nil
+}
+
+func (f *fakeAuditStore) UpdateEndedAtBySessionIDs(_ context.Context, _ database.Executor, sessionIDs []string, endedAt time.Time) error {
+	f.endedSessionIDs = append(f.endedSessionIDs, sessionIDs...)
+	f.endedAt = endedAt
+	return nil
+}
+
+func (f *fakeAuditStore) FindAllByInstance(_ context.Context, _ database.Executor, _ string, filters repository.ImpersonationAuditFilters, _ pagination.Params) ([]*model.ImpersonationAudit, error) {
+	f.filters = filters
+	return f.audits, nil
+}
+
+func (f *fakeAuditStore) CountByInstance(_ context.Context, _ database.Executor, _ string, _ repository.ImpersonationAuditFilters) (int64, error) {
+	return int64(len(f.audits)), nil
+}
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeAuditStore{}
+	service := &Service{clock: clockwork.NewFakeClockAt(now), impersonationAuditRepo: store}
+
+	session := &model.Session{Session: &sqbmodel.Session{
+		ID:         "sess_1",
+		InstanceID: "ins_1",
+		UserID:     "user_1",
+		Actor:      null.JSONFrom([]byte(`{"sub":"user_support"}`)),
+		CreatedAt:  now,
+		ExpireAt:   now.Add(time.Hour),
+	}}
+	actorToken := &model.ActorToken{ActorToken: &sqbmodel.ActorToken{
+		ID:     "act_1",
+		Actor:  types.JSON(`{"sub":"user_support"}`),
+		Reason: null.StringFrom("ticket 42"),
+	}}
+
+	require.NoError(t, service.Record(context.Background(), nil, session, actorToken))
+	require.Len(t, store.inserted, 1)
+	audit := store.inserted[0]
+	assert.Equal(t, "ins_1", audit.InstanceID)
+	assert.Equal(t, "act_1", audit.ActorTokenID)
+	assert.Equal(t, "user_support", audit.ActorID)
+	assert.Equal(t, "user_1", audit.UserID)
+	assert.Equal(t, "sess_1", audit.SessionID)
+	assert.Equal(t, "ticket 42", audit.Reason.String)
+	assert.Equal(t, now, audit.StartedAt)
+	assert.Equal(t, now.Add(time.Hour), audit.ExpireAt)
+	assert.False(t, audit.EndedAt.Valid)
+}
+
+func TestRecordEnd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeAuditStore{}
+	service := &Service{clock: clockwork.NewFakeClockAt(now), impersonationAuditRepo: store}
+
+	impersonated := &model.Session{Session: &sqbmodel.Session{ID: "sess_1", Actor: null.JSONFrom([]byte(`{"sub":"user_support"}`))}}
+	regular := &model.Session{Session: &sqbmodel.Session{ID: "sess_2"}}
+
+	require.NoError(t, service.RecordEnd(context.Background(), nil, impersonated, regular))
+	assert.Equal(t, []string{"sess_1"}, store.endedSessionIDs)
+	assert.Equal(t, now, store.endedAt)
+
+	// sessions which aren't impersonation sessions don't touch the audits
+	store.endedSessionIDs = nil
+	require.NoError(t, service.RecordEnd(context.Background(), nil, regular))
+	assert.Empty(t, store.endedSessionIDs)
+}
+
+func TestListResponse(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := &fakeAuditStore{audits: []*model.ImpersonationAudit{
+		{ImpersonationAudit: &sqbmodel.ImpersonationAudit{ID: "imp_1", EndedAt: null.TimeFrom(now)}},
+		{ImpersonationAudit: &sqbmodel.ImpersonationAudit{ID: "imp_2"}},
+	}}
+	service := &Service{clock: clockwork.NewFakeClockAt(now), impersonationAuditRepo: store}
+
+	r := httptest.NewRequest("GET", "/instances/ins_1/impersonation_audits?actor_id=user_support&limit=5", nil)
+	params, apiErr := ListParamsFromRequest(r)
+	require.Nil(t, apiErr)
+	require.NotNil(t, params.ActorID)
+	assert.Equal(t, "user_support", *params.ActorID)
+	assert.Nil(t, params.UserID)
+	assert.Equal(t, 5, params.Pagination.Limit)
+
+	response, apiErr := service.ListResponse(context.Background(), nil, "ins_1", params)
+	require.Nil(t, apiErr)
+	assert.Equal(t, "user_support", *store.filters.ActorID)
+	assert.Equal(t, int64(2), response.TotalCount)
+	require.Len(t, response.Data, 2)
+
+	ended := response.Data[0].(*serialize.ImpersonationAuditResponse)
+	require.NotNil(t, ended.EndedAt)
+	assert.Equal(t, now.UnixMilli(), *ended.EndedAt)
+	assert.Nil(t, response.Data[1].(*serialize.ImpersonationAuditResponse).EndedAt)
+}
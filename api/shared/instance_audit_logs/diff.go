@@ -0,0 +1,124 @@
+package instance_audit_logs
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"clerk/model"
+)
+
+// fields which change on every write, without being part of the change
+var volatileFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+}
+
+// Snapshot returns the configuration of the instance of the environment,
+// as a JSON document to compute the diff of a change from. Values of the
+// instance, its auth config, display config and domain are included,
+// keyed by their column names.
+func Snapshot(env *model.Env) []byte {
+	if env == nil {
+		return nil
+	}
+
+	document := map[string]any{}
+	if env.Instance != nil {
+		document["instance"] = env.Instance.Instance
+	}
+	if env.AuthConfig != nil {
+		document["auth_config"] = env.AuthConfig.AuthConfig
+	}
+	if env.DisplayConfig != nil {
+		document["display_config"] = env.DisplayConfig.DisplayConfig
+	}
+	if env.Domain != nil {
+		document["domain"] = env.Domain.Domain
+	}
+	snapshot, err := json.Marshal(document)
+	if err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// Change is the value of a field before and after a change.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff returns the fields which differ between the two JSON documents,
+// keyed by their dotted path, e.g. "auth_config.session_settings.lifetime",
+// along with their values before and after. Objects are compared field by
+// field, at any depth, while arrays are compared as a whole.
+//
+// Values of sensitive fields are redacted, so the diff only tells that they
+// changed. Documents which aren't valid JSON have no diff, as there's no
+// telling what changed.
+func Diff(before, after []byte) json.RawMessage {
+	var beforeDocument, afterDocument any
+	if json.Unmarshal(before, &beforeDocument) != nil || json.Unmarshal(after, &afterDocument) != nil {
+		return json.RawMessage("{}")
+	}
+
+	beforeFields := map[string]any{}
+	flatten("", beforeDocument, beforeFields)
+	afterFields := map[string]any{}
+	flatten("", afterDocument, afterFields)
+
+	changes := map[string]Change{}
+	for path, value := range beforeFields {
+		if afterValue, ok := afterFields[path]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[path] = change(path, value, afterFields[path])
+		}
+	}
+	for path, value := range afterFields {
+		if _, ok := beforeFields[path]; !ok {
+			changes[path] = change(path, nil, value)
+		}
+	}
+
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return diff
+}
+
+func flatten(path string, value any, fields map[string]any) {
+	object, ok := value.(map[string]any)
+	if !ok || len(object) == 0 {
+		if path != "" {
+			fields[path] = value
+		}
+		return
+	}
+	for key, field := range object {
+		if volatileFields[key] {
+			continue
+		}
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		flatten(fieldPath, field, fields)
+	}
+}
+
+func change(path string, before, after any) Change {
+	for _, segment := range strings.Split(path, ".") {
+		if !isSensitiveField(segment) {
+			continue
+		}
+		if before != nil {
+			before = RedactedValue
+		}
+		if after != nil {
+			after = RedactedValue
+		}
+		break
+	}
+	return Change{Before: before, After: after}
+}
@@ -0,0 +1,73 @@
+package instance_audit_logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "no changes",
+			before:   `{"auth_config":{"session_settings":{"lifetime":3600}}}`,
+			after:    `{"auth_config":{"session_settings":{"lifetime":3600}}}`,
+			expected: `{}`,
+		},
+		{
+			name:   "nested changes",
+			before: `{"auth_config":{"session_settings":{"lifetime":3600,"inactivity_timeout":0},"allowed_origins":["a"]}}`,
+			after:  `{"auth_config":{"session_settings":{"lifetime":7200,"inactivity_timeout":0},"allowed_origins":["a","b"]}}`,
+			expected: `{
+				"auth_config.session_settings.lifetime":{"before":3600,"after":7200},
+				"auth_config.allowed_origins":{"before":["a"],"after":["a","b"]}
+			}`,
+		},
+		{
+			name:   "added and removed fields",
+			before: `{"instance":{"home_url":"https://example.com"}}`,
+			after:  `{"instance":{"support_email":"help@example.com"}}`,
+			expected: `{
+				"instance.home_url":{"before":"https://example.com","after":null},
+				"instance.support_email":{"before":null,"after":"help@example.com"}
+			}`,
+		},
+		{
+			name:     "volatile fields",
+			before:   `{"instance":{"updated_at":"2024-01-01T00:00:00Z"}}`,
+			after:    `{"instance":{"updated_at":"2024-01-02T00:00:00Z"}}`,
+			expected: `{}`,
+		},
+		{
+			name:     "sensitive fields",
+			before:   `{"auth_config":{"oauth":{"client_secret":"foo","client_id":"bar"}}}`,
+			after:    `{"auth_config":{"oauth":{"client_secret":"baz","client_id":"bar"}}}`,
+			expected: `{"auth_config.oauth.client_secret":{"before":"[REDACTED]","after":"[REDACTED]"}}`,
+		},
+		{
+			name:     "sensitive object",
+			before:   `{"instance":{"credentials":{"user":"foo"}}}`,
+			after:    `{"instance":{"credentials":{"user":"bar"}}}`,
+			expected: `{"instance.credentials.user":{"before":"[REDACTED]","after":"[REDACTED]"}}`,
+		},
+		{
+			name:     "invalid JSON",
+			before:   `{"instance":{}}`,
+			after:    ``,
+			expected: `{}`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.JSONEq(t, tc.expected, string(Diff([]byte(tc.before), []byte(tc.after))))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Package instance_audit_logs keeps a trail of the changes made to the
+// configuration of instances through the dashboard, so that it's possible
+// to tell who changed what and when.
+package instance_audit_logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// RedactedValue replaces the values of sensitive fields in the diff.
+const RedactedValue = "[REDACTED]"
+
+// fields whose name contains any of these, ignoring case and separators,
+// are never stored, e.g. the client_secret of an OAuth configuration or the
+// apiKey of an integration
+var sensitiveFields = []string{"secret", "password", "key", "token", "credential"}
+
+type Service struct {
+	clock clockwork.Clock
+
+	// repositories
+	instanceAuditLogRepo *repository.InstanceAuditLogs
+}
+
+func NewService(clock clockwork.Clock) *Service {
+	return &Service{
+		clock:                clock,
+		instanceAuditLogRepo: repository.NewInstanceAuditLogs(),
+	}
+}
+
+// RecordParams describe a change made to the configuration of an instance.
+type RecordParams struct {
+	InstanceID string
+	// ActorID is the dashboard user who made the change.
+	ActorID string
+	// Action is the method and route of the request, e.g.
+	// "PATCH /instances/{instanceID}/user_settings".
+	Action string
+	// ResourceType is the kind of configuration that was changed, e.g.
+	// "user_settings" or "domains".
+	ResourceType string
+	// Path is the path the request was made to, which identifies the
+	// changed resource.
+	Path string
+	// Before and After are the snapshots of the configuration of the
+	// instance before and after the change, which the diff is computed
+	// from. See Snapshot.
+	Before []byte
+	After  []byte
+	// Request is the body of the request which made the change, for
+	// changes to configuration which isn't part of the snapshots.
+	Request []byte
+}
+
+// Record persists the audit log of a change to the configuration of an
+// instance, along with the diff of its snapshots. Sensitive values of the
+// diff and the request are redacted before they're stored.
+func (s *Service) Record(ctx context.Context, exec database.Executor, params RecordParams) error {
+	auditLog := &model.InstanceAuditLog{InstanceAuditLog: &sqbmodel.InstanceAuditLog{
+		InstanceID:   params.InstanceID,
+		ActorID:      params.ActorID,
+		Action:       params.Action,
+		ResourceType: params.ResourceType,
+		Path:         params.Path,
+		Diff:         Diff(params.Before, params.After),
+		Request:      Redact(params.Request),
+		OccurredAt:   s.clock.Now().UTC(),
+	}}
+	if err := s.instanceAuditLogRepo.Insert(ctx, exec, auditLog); err != nil {
+		return fmt.Errorf("instance_audit_logs/record: inserting audit log for instance %s: %w", params.InstanceID, err)
+	}
+	return nil
+}
+
+// ListParams filter the audit logs of an instance.
+type ListParams struct {
+	ActorID      *string
+	ResourceType *string
+	Pagination   pagination.Params
+}
+
+// List returns a page of the audit logs of the instance which match the
+// given filters, most recent first, along with the total number of matching
+// logs.
+func (s *Service) List(ctx context.Context, exec database.Executor, instanceID string, params ListParams) ([]*model.InstanceAuditLog, int64, error) {
+	filters := repository.InstanceAuditLogFilters{
+		ActorID:      params.ActorID,
+		ResourceType: params.ResourceType,
+	}
+
+	auditLogs, err := s.instanceAuditLogRepo.FindAllByInstance(ctx, exec, instanceID, filters, params.Pagination)
+	if err != nil {
+		return nil, 0, fmt.Errorf("instance_audit_logs/list: fetching audit logs of instance %s: %w", instanceID, err)
+	}
+
+	totalCount, err := s.instanceAuditLogRepo.CountByInstance(ctx, exec, instanceID, filters)
+	if err != nil {
+		return nil, 0, fmt.Errorf("instance_audit_logs/list: counting audit logs of instance %s: %w", instanceID, err)
+	}
+	return auditLogs, totalCount, nil
+}
+
+// Redact returns the given JSON document with the values of its sensitive
+// fields replaced, at any depth. Documents which aren't valid JSON are
+// dropped altogether, as there's no telling what they contain.
+func Redact(diff []byte) json.RawMessage {
+	if len(diff) == 0 {
+		return json.RawMessage("{}")
+	}
+
+	var document any
+	if err := json.Unmarshal(diff, &document); err != nil {
+		return json.RawMessage("{}")
+	}
+	redacted, err := json.Marshal(redactValue(document))
+	if err != nil {
+		return json.RawMessage("{}")
+	}
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = RedactedValue
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("_", "", "-", "").Replace(name)
+	for _, sensitive := range sensitiveFields {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package instance_audit_logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		diff     string
+		expected string
+	}{
+		{
+			name:     "empty",
+			diff:     "",
+			expected: `{}`,
+		},
+		{
+			name:     "invalid JSON",
+			diff:     `client_secret=foo`,
+			expected: `{}`,
+		},
+		{
+			name:     "nothing sensitive",
+			diff:     `{"enabled":true,"name":"foo"}`,
+			expected: `{"enabled":true,"name":"foo"}`,
+		},
+		{
+			name:     "nested sensitive fields",
+			diff:     `{"client_id":"foo","client_secret":"bar","providers":[{"API_Token":"baz","enabled":false}]}`,
+			expected: `{"client_id":"foo","client_secret":"[REDACTED]","providers":[{"API_Token":"[REDACTED]","enabled":false}]}`,
+		},
+		{
+			name:     "keys and credentials",
+			diff:     `{"api_key":"foo","apiKey":"bar","signing-key":"baz","credentials":{"user":"qux"},"key":"quux"}`,
+			expected: `{"api_key":"[REDACTED]","apiKey":"[REDACTED]","signing-key":"[REDACTED]","credentials":"[REDACTED]","key":"[REDACTED]"}`,
+		},
+		{
+			name:     "sensitive object",
+			diff:     `{"private_key":{"pem":"foo"}}`,
+			expected: `{"private_key":"[REDACTED]"}`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.JSONEq(t, tc.expected, string(Redact([]byte(tc.diff))))
+		})
+	}
+}
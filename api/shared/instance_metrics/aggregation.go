@@ -0,0 +1,107 @@
+package instance_metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clerk/pkg/jobs"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/vgarvardt/gue/v2"
+)
+
+// FlushInterval is how often the counts aggregated in memory are flushed to
+// the counters of their instances.
+const FlushInterval = 10 * time.Second
+
+// pendingCounts is shared by all services of the process, so that counts
+// are flushed once per FlushInterval no matter where they're recorded.
+var pendingCounts = newAggregation()
+
+type counterKey struct {
+	instanceID string
+	name       string
+	label      string
+}
+
+// aggregation sums up counts in memory, until they're flushed.
+type aggregation struct {
+	mu     sync.Mutex
+	counts map[counterKey]int64
+}
+
+func newAggregation() *aggregation {
+	return &aggregation{counts: map[counterKey]int64{}}
+}
+
+func (a *aggregation) add(key counterKey, n int64) {
+	if n <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.counts[key] += n
+}
+
+// take returns the counts aggregated so far and starts over.
+func (a *aggregation) take() map[counterKey]int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	counts := a.counts
+	a.counts = map[counterKey]int64{}
+	return counts
+}
+
+// Count increases the given counter of the instance by n, without any I/O.
+// Counts are summed up in memory and flushed by Flush every FlushInterval,
+// which makes it suitable for paths as hot as issuing session tokens.
+//
+// Counts which haven't been flushed when the process exits are lost, so
+// counters which must be exact use Increment instead.
+func Count(instanceID, counter, label string, n int64) {
+	pendingCounts.add(counterKey{instanceID: instanceID, name: counter, label: label}, n)
+}
+
+// Flush enqueues a single job for every counter that was counted since the
+// previous flush. Counts whose job can't be enqueued are counted again, so
+// that the next flush retries them.
+func Flush(ctx context.Context, gueClient *gue.Client) {
+	flush(ctx, pendingCounts, func(args jobs.IncrementInstanceMetricArgs) error {
+		return jobs.IncrementInstanceMetric(ctx, gueClient, args)
+	})
+}
+
+func flush(ctx context.Context, a *aggregation, enqueue func(jobs.IncrementInstanceMetricArgs) error) {
+	for key, n := range a.take() {
+		args := jobs.IncrementInstanceMetricArgs{
+			InstanceID: key.instanceID,
+			Name:       key.name,
+			Label:      key.label,
+			By:         n,
+		}
+		if err := enqueue(args); err != nil {
+			log.Warning(ctx, "instanceMetrics/flush: enqueuing job %+v: %s", args, err)
+			a.add(key, n)
+		}
+	}
+}
+
+// RunFlusher flushes the aggregated counts every FlushInterval, until ctx
+// is done. It's meant to run in its own goroutine for the lifetime of the
+// process.
+func RunFlusher(ctx context.Context, deps clerk.Deps) {
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			Flush(context.Background(), deps.GueClient())
+			return
+		case <-ticker.C:
+			Flush(ctx, deps.GueClient())
+		}
+	}
+}
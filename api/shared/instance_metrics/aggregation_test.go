@@ -0,0 +1,70 @@
+package instance_metrics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"clerk/pkg/jobs"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregationFlush(t *testing.T) {
+	t.Parallel()
+
+	a := newAggregation()
+	tokens := counterKey{instanceID: "ins_1", name: TokensIssued}
+	signIns := counterKey{instanceID: "ins_1", name: SignIns, label: OutcomeComplete}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.add(tokens, 1)
+		}()
+	}
+	wg.Wait()
+	a.add(signIns, 2)
+	a.add(signIns, 0)
+
+	var enqueued []jobs.IncrementInstanceMetricArgs
+	flush(context.Background(), a, func(args jobs.IncrementInstanceMetricArgs) error {
+		enqueued = append(enqueued, args)
+		return nil
+	})
+	assert.ElementsMatch(t, []jobs.IncrementInstanceMetricArgs{
+		{InstanceID: "ins_1", Name: TokensIssued, By: 100},
+		{InstanceID: "ins_1", Name: SignIns, Label: OutcomeComplete, By: 2},
+	}, enqueued)
+
+	// nothing is flushed twice
+	enqueued = nil
+	flush(context.Background(), a, func(args jobs.IncrementInstanceMetricArgs) error {
+		enqueued = append(enqueued, args)
+		return nil
+	})
+	assert.Empty(t, enqueued)
+}
+
+func TestAggregationFlushRetries(t *testing.T) {
+	t.Parallel()
+
+	a := newAggregation()
+	key := counterKey{instanceID: "ins_1", name: TokensIssued}
+	a.add(key, 3)
+
+	flush(context.Background(), a, func(jobs.IncrementInstanceMetricArgs) error {
+		return errors.New("database is down")
+	})
+	a.add(key, 1)
+
+	var enqueued []jobs.IncrementInstanceMetricArgs
+	flush(context.Background(), a, func(args jobs.IncrementInstanceMetricArgs) error {
+		enqueued = append(enqueued, args)
+		return nil
+	})
+	assert.Equal(t, []jobs.IncrementInstanceMetricArgs{{InstanceID: "ins_1", Name: TokensIssued, By: 4}}, enqueued)
+}
@@ -0,0 +1,170 @@
+package instance_metrics
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/pkg/jobs"
+	"clerk/pkg/rand"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// Counters exported to customers. Each counter is stored per instance and
+// label value, so labels must only take values from a bounded set.
+const (
+	SignIns           = "clerk_sign_ins_total"
+	TokensIssued      = "clerk_tokens_issued_total"
+	WebhookDeliveries = "clerk_webhook_deliveries_total"
+)
+
+// Label values for the SignIns counter
+const (
+	OutcomeComplete = "complete"
+	OutcomeFailed   = "failed"
+)
+
+// ActiveSessions is the gauge of sessions which are currently active.
+const ActiveSessions = "clerk_active_sessions"
+
+// Prometheus is expected to scrape every 15 seconds or so. Anything more
+// frequent than this is rejected.
+const minScrapeInterval = 10 * time.Second
+
+type Service struct {
+	cache     cache.Cache
+	clock     clockwork.Clock
+	gueClient *gue.Client
+
+	// repositories
+	counterRepo  *repository.InstanceMetricCounters
+	instanceRepo *repository.Instances
+	sessionRepo  *repository.Sessions
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		cache:        deps.Cache(),
+		clock:        deps.Clock(),
+		gueClient:    deps.GueClient(),
+		counterRepo:  deps.Repositories().InstanceMetricCounters,
+		instanceRepo: deps.Repositories().Instances,
+		sessionRepo:  deps.Repositories().Sessions,
+	}
+}
+
+// Increment increases the given counter of the instance by one. Counters
+// are aggregated asynchronously, so that hot paths never contend on the
+// same row. When exec is a transaction, the increment only happens if it
+// commits.
+//
+// Every increment enqueues a job, so paths which run on every request count
+// with Count instead.
+func (s *Service) Increment(ctx context.Context, exec database.Executor, instanceID, counter, label string) error {
+	args := jobs.IncrementInstanceMetricArgs{
+		InstanceID: instanceID,
+		Name:       counter,
+		Label:      label,
+		By:         1,
+	}
+	if err := jobs.IncrementInstanceMetric(ctx, s.gueClient, args, jobs.WithTxIfApplicable(exec)); err != nil {
+		return fmt.Errorf("instanceMetrics/increment: enqueuing job %+v: %w", args, err)
+	}
+	return nil
+}
+
+// Export renders all the metrics of the instance in the Prometheus text
+// exposition format.
+func (s *Service) Export(ctx context.Context, exec database.Executor, instanceID string) (string, error) {
+	counters, err := s.counterRepo.FindAllByInstance(ctx, exec, instanceID)
+	if err != nil {
+		return "", fmt.Errorf("instanceMetrics/export: fetching counters for instance %s: %w", instanceID, err)
+	}
+
+	activeSessions, err := s.sessionRepo.CountActiveByInstance(ctx, exec, instanceID, s.clock.Now().UTC())
+	if err != nil {
+		return "", fmt.Errorf("instanceMetrics/export: counting active sessions for instance %s: %w", instanceID, err)
+	}
+
+	samples := make([]sample, 0, len(counters)+1)
+	for _, counter := range counters {
+		samples = append(samples, sample{
+			name:  counter.Name,
+			label: counter.Label,
+			value: counter.Value,
+		})
+	}
+	samples = append(samples, sample{name: ActiveSessions, value: activeSessions})
+
+	return render(instanceID, samples), nil
+}
+
+// CreateToken generates a new metrics token for the instance, replacing any
+// previous one. Only a digest of the token is stored, so the token can
+// only be retrieved once.
+func (s *Service) CreateToken(ctx context.Context, exec database.Executor, instance *model.Instance) (string, error) {
+	token, err := rand.Token()
+	if err != nil {
+		return "", fmt.Errorf("instanceMetrics/createToken: generating token: %w", err)
+	}
+
+	instance.MetricsTokenDigest = null.StringFrom(tokenDigest(token))
+	if err := s.instanceRepo.UpdateMetricsTokenDigest(ctx, exec, instance); err != nil {
+		return "", fmt.Errorf("instanceMetrics/createToken: updating instance %s: %w", instance.ID, err)
+	}
+	return token, nil
+}
+
+// RevokeToken removes the metrics token of the instance, which disables the
+// metrics endpoint.
+func (s *Service) RevokeToken(ctx context.Context, exec database.Executor, instance *model.Instance) error {
+	instance.MetricsTokenDigest = null.StringFromPtr(nil)
+	if err := s.instanceRepo.UpdateMetricsTokenDigest(ctx, exec, instance); err != nil {
+		return fmt.Errorf("instanceMetrics/revokeToken: updating instance %s: %w", instance.ID, err)
+	}
+	return nil
+}
+
+// VerifyToken reports whether the given token is the metrics token of the
+// instance.
+func VerifyToken(instance *model.Instance, token string) bool {
+	if !instance.MetricsTokenDigest.Valid || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(instance.MetricsTokenDigest.String), []byte(tokenDigest(token))) == 1
+}
+
+// CheckRateLimit makes sure the metrics of the instance are not scraped more
+// often than once every minScrapeInterval.
+func (s *Service) CheckRateLimit(ctx context.Context, instanceID string) apierror.Error {
+	key := "instance_metrics_scrape:" + instanceID
+	exists, err := s.cache.Exists(ctx, key)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if exists {
+		return apierror.TooManyRequests()
+	}
+
+	if err := s.cache.Set(ctx, key, true, minScrapeInterval); err != nil {
+		return apierror.Unexpected(err)
+	}
+	return nil
+}
+
+func tokenDigest(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,87 @@
+package instance_metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type metricDescription struct {
+	help       string
+	metricType string
+	labelName  string
+}
+
+var descriptions = map[string]metricDescription{
+	SignIns: {
+		help:       "Sign-in attempts by outcome.",
+		metricType: "counter",
+		labelName:  "outcome",
+	},
+	TokensIssued: {
+		help:       "Session tokens issued.",
+		metricType: "counter",
+	},
+	WebhookDeliveries: {
+		help:       "Webhook deliveries by event type.",
+		metricType: "counter",
+		labelName:  "event_type",
+	},
+	ActiveSessions: {
+		help:       "Sessions which are currently active.",
+		metricType: "gauge",
+	},
+}
+
+type sample struct {
+	name  string
+	label string
+	value int64
+}
+
+// render formats the samples in the Prometheus text exposition format.
+// Samples of unknown metrics are skipped, so that we never expose
+// something we haven't described.
+//
+// https://prometheus.io/docs/instrumenting/exposition_formats/#text-based-format
+func render(instanceID string, samples []sample) string {
+	byName := make(map[string][]sample)
+	for _, s := range samples {
+		if _, ok := descriptions[s.name]; !ok {
+			continue
+		}
+		byName[s.name] = append(byName[s.name], s)
+	}
+
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		description := descriptions[name]
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, description.help)
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, description.metricType)
+
+		metricSamples := byName[name]
+		sort.Slice(metricSamples, func(i, j int) bool {
+			return metricSamples[i].label < metricSamples[j].label
+		})
+		for _, s := range metricSamples {
+			labels := fmt.Sprintf(`instance_id="%s"`, escapeLabelValue(instanceID))
+			if description.labelName != "" && s.label != "" {
+				labels += fmt.Sprintf(`,%s="%s"`, description.labelName, escapeLabelValue(s.label))
+			}
+			fmt.Fprintf(&b, "%s{%s} %d\n", name, labels, s.value)
+		}
+	}
+	return b.String()
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
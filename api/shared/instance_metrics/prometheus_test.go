@@ -0,0 +1,51 @@
+package instance_metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		samples  []sample
+		expected string
+	}{
+		{
+			name:     "no samples",
+			expected: "",
+		},
+		{
+			name: "labels are sorted and unknown metrics are skipped",
+			samples: []sample{
+				{name: SignIns, label: OutcomeFailed, value: 2},
+				{name: "clerk_unknown_total", value: 7},
+				{name: SignIns, label: OutcomeComplete, value: 5},
+				{name: ActiveSessions, value: 3},
+			},
+			expected: `# HELP clerk_active_sessions Sessions which are currently active.
+# TYPE clerk_active_sessions gauge
+clerk_active_sessions{instance_id="ins_1"} 3
+# HELP clerk_sign_ins_total Sign-in attempts by outcome.
+# TYPE clerk_sign_ins_total counter
+clerk_sign_ins_total{instance_id="ins_1",outcome="complete"} 5
+clerk_sign_ins_total{instance_id="ins_1",outcome="failed"} 2
+`,
+		},
+		{
+			name: "label values are escaped",
+			samples: []sample{
+				{name: WebhookDeliveries, label: `user."created"`, value: 1},
+			},
+			expected: `# HELP clerk_webhook_deliveries_total Webhook deliveries by event type.
+# TYPE clerk_webhook_deliveries_total counter
+clerk_webhook_deliveries_total{instance_id="ins_1",event_type="user.\"created\""} 1
+`,
+		},
+	} {
+		assert.Equal(t, tc.expected, render("ins_1", tc.samples), tc.name)
+	}
+}
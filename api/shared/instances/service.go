@@ -3,8 +3,11 @@ package instances
 import (
 	"context"
 	"encoding/json"
+	"unicode/utf8"
 
+	"clerk/api/apierror"
 	"clerk/api/shared/domains"
+	"clerk/api/shared/edgecache"
 	"clerk/api/shared/edgereplication"
 	"clerk/model"
 	"clerk/pkg/cenv"
@@ -19,6 +22,7 @@ import (
 
 type Service struct {
 	db                     database.Database
+	gueClient              *gue.Client
 	domainRepo             *repository.Domain
 	dnsChecksRepo          *repository.DNSChecks
 	proxyChecksRepo        *repository.ProxyCheck
@@ -29,6 +33,7 @@ type Service struct {
 func NewService(db database.Database, gueClient *gue.Client) *Service {
 	return &Service{
 		db:                     db,
+		gueClient:              gueClient,
 		domainRepo:             repository.NewDomain(),
 		dnsChecksRepo:          repository.NewDNSChecks(),
 		proxyChecksRepo:        repository.NewProxyCheck(),
@@ -119,3 +124,35 @@ func (s *Service) UpdateSessionTokenTemplateID(ctx context.Context, tx database.
 	}
 	return s.edgeReplicationService.EnqueuePutInstance(ctx, tx, instance.ID)
 }
+
+// MaxMaintenanceBannerLength is the maximum length of the message which is
+// displayed while an instance is under maintenance.
+const MaxMaintenanceBannerLength = 280
+
+// ValidateMaintenanceBanner checks the message to display while an instance
+// is under maintenance.
+func ValidateMaintenanceBanner(param string, banner *string) apierror.Error {
+	if banner != nil && utf8.RuneCountInString(*banner) > MaxMaintenanceBannerLength {
+		return apierror.FormParameterMaxLengthExceeded(param, MaxMaintenanceBannerLength)
+	}
+	return nil
+}
+
+// UpdateMaintenanceMode turns the maintenance mode of the instance on or off.
+// While it's on, FAPI rejects all mutations apart from the ones needed to
+// keep existing sessions alive, and the environment carries the banner.
+func (s *Service) UpdateMaintenanceMode(ctx context.Context, tx database.Tx, instance *model.Instance, enabled bool, banner *string) error {
+	instance.MaintenanceMode = enabled
+	instance.MaintenanceBanner = null.StringFromPtr(banner)
+	if !enabled {
+		instance.MaintenanceBanner = null.StringFromPtr(nil)
+	}
+	if err := s.instanceRepo.UpdateMaintenanceMode(ctx, tx, instance); err != nil {
+		return err
+	}
+	if err := s.edgeReplicationService.EnqueuePutInstance(ctx, tx, instance.ID); err != nil {
+		return err
+	}
+	// the environment is cached at edge and includes the banner
+	return edgecache.PurgeFapiEnvironment(ctx, s.gueClient, tx, instance.ID)
+}
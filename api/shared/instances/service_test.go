@@ -0,0 +1,25 @@
+package instances
+
+import (
+	"strings"
+	"testing"
+
+	"clerk/api/apierror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateMaintenanceBanner(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, ValidateMaintenanceBanner("banner", nil))
+
+	banner := strings.Repeat("é", MaxMaintenanceBannerLength)
+	assert.Nil(t, ValidateMaintenanceBanner("banner", &banner), "length is counted in characters")
+
+	banner += "!"
+	apiErr := ValidateMaintenanceBanner("banner", &banner)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParameterMaxLengthExceededCode, apiErr.Errors()[0].Code())
+}
@@ -28,7 +28,7 @@ type Service struct {
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
 		clock:           deps.Clock(),
-		invitationsRepo: repository.NewInvitations(),
+		invitationsRepo: deps.Repositories().Invitations,
 	}
 }
 
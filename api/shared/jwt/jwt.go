@@ -5,15 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"clerk/api/shared/jwt_template"
+	"clerk/api/shared/signing_keys"
 	"clerk/api/shared/token"
 	"clerk/model"
 	"clerk/pkg/jwt"
 	"clerk/pkg/jwt_services"
 	"clerk/pkg/jwt_services/vendors"
+	"clerk/pkg/scopedtokens"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 
 	"github.com/jonboulle/clockwork"
@@ -34,18 +38,22 @@ var (
 type Service struct {
 	clock clockwork.Clock
 
+	// services
+	signingKeyService *signing_keys.Service
+
 	// repositories
 	jwtTemplatesRepo   *repository.JWTTemplate
 	orgMembershipsRepo *repository.OrganizationMembership
 	userRepo           *repository.Users
 }
 
-func NewService(clock clockwork.Clock) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		clock:              clock,
-		jwtTemplatesRepo:   repository.NewJWTTemplate(),
-		orgMembershipsRepo: repository.NewOrganizationMembership(),
-		userRepo:           repository.NewUsers(),
+		clock:              deps.Clock(),
+		signingKeyService:  signing_keys.NewService(deps),
+		jwtTemplatesRepo:   deps.Repositories().JWTTemplate,
+		orgMembershipsRepo: deps.Repositories().OrganizationMembership,
+		userRepo:           deps.Repositories().Users,
 	}
 }
 
@@ -111,20 +119,105 @@ func (s Service) CreateFromTemplate(ctx context.Context, exec database.Executor,
 		return "", fmt.Errorf("shared/CreateFromTemplate: executing jwt_template: %w", err)
 	}
 
-	privateKey := params.Env.Instance.PrivateKey
-	if jwtTemplate.SigningKey.Valid {
-		privateKey = jwtTemplate.SigningKey.String
+	token, err := s.sign(ctx, exec, params.Env, jwtTemplate, claims)
+	if err != nil {
+		return "", fmt.Errorf("shared/CreateFromTemplate: %w", err)
+	}
+
+	return token, nil
+}
+
+type CreateForServiceAccountParams struct {
+	Env            *model.Env
+	ServiceAccount *model.ServiceAccount
+	JWTTemplate    *model.JWTTemplate
+	Origin         string
+}
+
+// CreateForServiceAccount mints a token for the provided service account and
+// based on the provided jwt template.
+func (s Service) CreateForServiceAccount(ctx context.Context, exec database.Executor, params CreateForServiceAccountParams) (string, error) {
+	tmpl, err := jwt_template.New(exec, s.clock, jwt_template.Data{
+		UserSettings:   usersettings.NewUserSettings(params.Env.AuthConfig.UserSettings),
+		JWTTmpl:        params.JWTTemplate,
+		ServiceAccount: params.ServiceAccount,
+		Issuer:         params.Env.Domain.FapiURL(),
+		Origin:         params.Origin,
+	})
+	if err != nil {
+		return "", fmt.Errorf("shared/CreateForServiceAccount: jwt_template constructor: %w", err)
+	}
+
+	claims, err := tmpl.Execute(ctx)
+	if err != nil {
+		return "", fmt.Errorf("shared/CreateForServiceAccount: executing jwt_template: %w", err)
+	}
+
+	token, err := s.sign(ctx, exec, params.Env, params.JWTTemplate, claims)
+	if err != nil {
+		return "", fmt.Errorf("shared/CreateForServiceAccount: %w", err)
+	}
+
+	return token, nil
+}
+
+type CreateScopedParams struct {
+	Env         *model.Env
+	JWTTemplate *model.JWTTemplate
+	Scopes      []string
+	Claims      map[string]any
+	Lifetime    time.Duration
+}
+
+// CreateScoped mints a machine-to-machine token which carries the provided
+// scopes and claims. Only the signing settings and the allowed clock skew of
+// the jwt template are used, its claims are not, since there is no user or
+// session to resolve them for. The scopes and claims are expected to be
+// checked against the allowlist of the template already.
+func (s Service) CreateScoped(ctx context.Context, exec database.Executor, params CreateScopedParams) (string, time.Time, error) {
+	now := s.clock.Now().UTC()
+	expiresAt := now.Add(params.Lifetime)
+
+	claims := make(map[string]any, len(params.Claims)+5)
+	for claim, value := range params.Claims {
+		claims[claim] = value
+	}
+	claims["iss"] = params.Env.Domain.FapiURL()
+	claims["iat"] = now.Unix()
+	claims["exp"] = expiresAt.Unix()
+	if params.JWTTemplate.ClockSkew > 0 {
+		claims["nbf"] = now.Add(-time.Duration(params.JWTTemplate.ClockSkew) * time.Second).Unix()
 	}
+	claims[scopedtokens.ClaimScope] = scopedtokens.FormatScopes(params.Scopes)
 
-	generateTokenOptions := []jwt.GenerateTokenOption{jwt.WithCategory(token.GetTemplateCategory(params.Env.AuthConfig))}
-	// Include the KID claim only if the instance's key will be used
-	if !jwtTemplate.SigningKey.Valid {
-		generateTokenOptions = append(generateTokenOptions, jwt.WithKID(params.Env.Instance.ID))
+	token, err := s.sign(ctx, exec, params.Env, params.JWTTemplate, claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("shared/CreateScoped: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// sign signs the claims with the custom signing key of the jwt template, or
+// with the current key of the instance if the template doesn't have one.
+func (s Service) sign(ctx context.Context, exec database.Executor, env *model.Env, jwtTemplate *model.JWTTemplate, claims map[string]any) (string, error) {
+	var privateKey string
+	generateTokenOptions := []jwt.GenerateTokenOption{jwt.WithCategory(token.GetTemplateCategory(env.AuthConfig))}
+	if jwtTemplate.SigningKey.Valid {
+		privateKey = jwtTemplate.SigningKey.String
+	} else {
+		// Include the KID claim only if the instance's key will be used
+		signingKey, err := s.signingKeyService.Current(ctx, exec, env.Instance)
+		if err != nil {
+			return "", fmt.Errorf("fetching signing key: %w", err)
+		}
+		privateKey = signingKey.PrivateKey
+		generateTokenOptions = append(generateTokenOptions, jwt.WithKID(signingKey.KID))
 	}
 
 	token, err := jwt.GenerateToken(privateKey, claims, jwtTemplate.SigningAlgorithm, generateTokenOptions...)
 	if err != nil {
-		return "", fmt.Errorf("shared/CreateFromTemplate: generating token %w", err)
+		return "", fmt.Errorf("generating token %w", err)
 	}
 
 	return token, nil
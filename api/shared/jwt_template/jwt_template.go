@@ -42,6 +42,10 @@ const (
 	expressionEnd   = "}}"
 )
 
+// SubjectTypeServiceAccount is the value of the 'sub_type' claim for tokens
+// issued to service accounts. Tokens issued to users don't carry the claim.
+const SubjectTypeServiceAccount = "service_account"
+
 // ErrReservedAud depicts an attempt to set the 'aud' claim to 'clerk',
 // which is a reserved value for session tokens.
 var ErrReservedAud = errors.New("reserved value for 'aud' claim")
@@ -56,6 +60,7 @@ type Template struct {
 
 	clock          clockwork.Clock
 	user           *model.User
+	serviceAccount *model.ServiceAccount
 	orgMemberships model.OrganizationMembershipsWithRole
 	metadata       json.RawMessage
 
@@ -98,6 +103,14 @@ type Data struct {
 	Issuer              string
 	Origin              string
 	SessionActor        json.RawMessage
+
+	// ServiceAccount is set instead of User for tokens issued to service
+	// accounts. Only the service account shortcodes are available for them.
+	ServiceAccount *model.ServiceAccount
+
+	// ResolvePermissions returns all the permission keys of the role in the
+	// active organization. Defaults to QueryPermissions.
+	ResolvePermissions PermissionsResolver
 }
 
 func New(exec database.Executor, clock clockwork.Clock, data Data) (*Template, error) {
@@ -105,6 +118,7 @@ func New(exec database.Executor, clock clockwork.Clock, data Data) (*Template, e
 		randSrc:        rand.Reader,
 		clock:          clock,
 		user:           data.User,
+		serviceAccount: data.ServiceAccount,
 		orgMemberships: data.OrgMemberships,
 		exp:            time.Second * time.Duration(data.JWTTmpl.Lifetime),
 		nbfClockSkew:   time.Second * time.Duration(data.JWTTmpl.ClockSkew),
@@ -117,7 +131,7 @@ func New(exec database.Executor, clock clockwork.Clock, data Data) (*Template, e
 		return nil, err
 	}
 
-	t.metadata, err = populateTemplateMetadata(data.User, data.ActiveOrgMembership, data.SessionActor)
+	t.metadata, err = populateTemplateMetadata(data.User, data.ServiceAccount, data.ActiveOrgMembership, data.SessionActor)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +172,12 @@ func (t *Template) Execute(ctx context.Context) (map[string]any, error) {
 		return nil, ErrReservedAud
 	}
 
-	t.result["sub"] = t.user.ID
+	if t.serviceAccount != nil {
+		t.result["sub"] = t.serviceAccount.ID
+		t.result["sub_type"] = SubjectTypeServiceAccount
+	} else {
+		t.result["sub"] = t.user.ID
+	}
 	t.result["iat"] = t.clock.Now().Unix()
 	t.result["iss"] = t.issuer
 
@@ -185,10 +204,23 @@ func (t *Template) Execute(ctx context.Context) (map[string]any, error) {
 }
 
 func (t *Template) populateShortcodes(exec database.Executor, clock clockwork.Clock, data *Data) {
+	if data.ServiceAccount != nil {
+		t.registerShortcodes([]shortcode{
+			shortcodes.NewServiceAccountID(data.ServiceAccount),
+			shortcodes.NewServiceAccountName(data.ServiceAccount),
+		})
+		return
+	}
+
 	user := data.User
 	activeOrgMembership := data.ActiveOrgMembership
 
-	availableShortcodes := []shortcode{
+	resolvePermissions := data.ResolvePermissions
+	if resolvePermissions == nil {
+		resolvePermissions = QueryPermissions(exec)
+	}
+
+	t.registerShortcodes([]shortcode{
 		shortcodes.NewUserID(user),
 		shortcodes.NewUserExternalID(user),
 		shortcodes.NewUserFirstName(user),
@@ -209,13 +241,18 @@ func (t *Template) populateShortcodes(exec database.Executor, clock clockwork.Cl
 		shortcodes.NewUserTwoFactorEnabled(exec, clock, user, data.UserSettings),
 		shortcodes.NewOrgID(activeOrgMembership),
 		shortcodes.NewOrgRole(activeOrgMembership),
+		shortcodes.NewOrgRoleName(activeOrgMembership),
 		shortcodes.NewOrgName(activeOrgMembership),
 		shortcodes.NewOrgSlug(activeOrgMembership),
 		shortcodes.NewOrgImageURL(activeOrgMembership),
 		shortcodes.NewOrgHasImage(activeOrgMembership),
+		shortcodes.NewOrgMembershipID(activeOrgMembership),
 		shortcodes.NewOrgMembershipPermissions(activeOrgMembership),
-	}
+		shortcodes.NewOrgMembershipAllPermissions(activeOrgMembership, resolvePermissions),
+	})
+}
 
+func (t *Template) registerShortcodes(availableShortcodes []shortcode) {
 	t.shortcodes = make(map[string]shortcode, len(availableShortcodes))
 
 	for _, s := range availableShortcodes {
@@ -364,15 +401,16 @@ func (t *Template) substituteNonInterpolatedCoalescedShortcodes(ctx context.Cont
 
 // metadata shortcodes
 var (
-	userMetadataReMatch          = regexp.MustCompile(`^{{user\.(public|unsafe)_metadata(\.\w+)*}}$`).MatchString
-	orgMetadataReMatch           = regexp.MustCompile(`^{{org\.public_metadata(\.\w+)*}}$`).MatchString
-	orgMembershipMetadataReMatch = regexp.MustCompile(`^{{org_membership\.public_metadata(\.\w+)*}}$`).MatchString
-	sessionActorReMatch          = regexp.MustCompile(`^{{session\.actor(\.\w+)*}}$`).MatchString
+	userMetadataReMatch           = regexp.MustCompile(`^{{user\.(public|unsafe)_metadata(\.\w+)*}}$`).MatchString
+	orgMetadataReMatch            = regexp.MustCompile(`^{{org\.public_metadata(\.\w+)*}}$`).MatchString
+	orgMembershipMetadataReMatch  = regexp.MustCompile(`^{{org_membership\.public_metadata(\.\w+)*}}$`).MatchString
+	sessionActorReMatch           = regexp.MustCompile(`^{{session\.actor(\.\w+)*}}$`).MatchString
+	serviceAccountMetadataReMatch = regexp.MustCompile(`^{{service_account\.public_metadata(\.\w+)*}}$`).MatchString
 )
 
 // e.g. "{{user.public_metadata.foo}}"
 func (t *Template) substituteExactMetadataShortcodes(s string) (any, bool) {
-	if !(userMetadataReMatch(s) || orgMetadataReMatch(s) || orgMembershipMetadataReMatch(s) || sessionActorReMatch(s)) {
+	if !(userMetadataReMatch(s) || orgMetadataReMatch(s) || orgMembershipMetadataReMatch(s) || sessionActorReMatch(s) || serviceAccountMetadataReMatch(s)) {
 		return s, false
 	}
 
@@ -415,6 +453,7 @@ func (t *Template) substituteInterpolatedShortcodes(ctx context.Context, input s
 
 func populateTemplateMetadata(
 	user *model.User,
+	serviceAccount *model.ServiceAccount,
 	activeOrgMembership *model.OrganizationMembershipWithDeps,
 	actor json.RawMessage,
 ) (json.RawMessage, error) {
@@ -432,10 +471,24 @@ func populateTemplateMetadata(
 		Session struct {
 			Actor json.RawMessage `json:"actor,omitempty"`
 		} `json:"session,omitempty"`
+		ServiceAccount struct {
+			Public types.JSON `json:"public_metadata"`
+		} `json:"service_account"`
 	}{}
 
-	m.User.Public = user.PublicMetadata
-	m.User.Unsafe = user.UnsafeMetadata
+	// Initialize the user and service account metadata to empty objects, as
+	// tokens are issued either for a user or for a service account
+	m.User.Public = []byte("{}")
+	m.User.Unsafe = []byte("{}")
+	m.ServiceAccount.Public = []byte("{}")
+
+	if user != nil {
+		m.User.Public = user.PublicMetadata
+		m.User.Unsafe = user.UnsafeMetadata
+	}
+	if serviceAccount != nil {
+		m.ServiceAccount.Public = serviceAccount.PublicMetadata
+	}
 
 	// Initialize the below metadata to empty object in case an active org membership doesn't exist
 	// to avoid json.Marshal error
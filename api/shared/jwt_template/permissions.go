@@ -0,0 +1,82 @@
+package jwt_template
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/repository"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+// permissionsCacheTTL is how long the permissions of the active organization
+// role are cached for each session. Session tokens are refreshed about every
+// minute, so changes to the permissions of a role reach the tokens of the
+// existing sessions after at most this long.
+const permissionsCacheTTL = 5 * time.Minute
+
+// PermissionsResolver returns the keys of all the permissions of the given
+// organization role, both system and custom ones.
+type PermissionsResolver func(ctx context.Context, role *model.Role) ([]string, error)
+
+// QueryPermissions resolves the permissions of a role from the database.
+func QueryPermissions(exec database.Executor) PermissionsResolver {
+	permissionRepo := repository.NewPermission()
+	return func(ctx context.Context, role *model.Role) ([]string, error) {
+		permissions, err := permissionRepo.FindAllByRole(ctx, exec, role.ID)
+		if err != nil {
+			return nil, fmt.Errorf("jwt_template: fetching permissions of role %s: %w", role.ID, err)
+		}
+
+		keys := make([]string, len(permissions))
+		for i, permission := range permissions {
+			keys[i] = permission.Key
+		}
+		return keys, nil
+	}
+}
+
+type cachedPermissions struct {
+	// Loaded tells apart a role without permissions from a cache miss.
+	Loaded bool     `json:"loaded"`
+	Keys   []string `json:"keys"`
+}
+
+// CachedPermissions wraps resolve so that the permissions of the active
+// organization role of the given session are resolved once, instead of on
+// every token refresh. The role is part of the cache key, so switching the
+// active organization or changing the role of the member takes effect right
+// away.
+//
+// The cache is only an optimization; resolve is used whenever it's
+// unavailable.
+func CachedPermissions(c cache.Cache, sessionID string, resolve PermissionsResolver) PermissionsResolver {
+	return func(ctx context.Context, role *model.Role) ([]string, error) {
+		key := permissionsCacheKey(sessionID, role.ID)
+
+		var cached cachedPermissions
+		if err := c.Get(ctx, key, &cached); err != nil {
+			log.Warning(ctx, "jwt_template: fetching cached permissions of session %s: %s", sessionID, err)
+		} else if cached.Loaded {
+			return cached.Keys, nil
+		}
+
+		keys, err := resolve(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+
+		cached = cachedPermissions{Loaded: true, Keys: keys}
+		if err := c.Set(ctx, key, cached, permissionsCacheTTL); err != nil {
+			log.Warning(ctx, "jwt_template: caching permissions of session %s: %s", sessionID, err)
+		}
+		return keys, nil
+	}
+}
+
+func permissionsCacheKey(sessionID, roleID string) string {
+	return "jwt_template:permissions:" + sessionID + ":" + roleID
+}
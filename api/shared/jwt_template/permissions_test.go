@@ -0,0 +1,113 @@
+package jwt_template
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cache"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCache keeps values in memory, or fails every read if told to.
+type fakeCache struct {
+	cache.Cache
+	values  map[string][]byte
+	ttls    map[string]time.Duration
+	readErr error
+}
+
+func (c *fakeCache) Get(_ context.Context, key string, value any) error {
+	if c.readErr != nil {
+		return c.readErr
+	}
+	raw, ok := c.values[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(raw, value)
+}
+
+func (c *fakeCache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.values[key] = raw
+	c.ttls[key] = ttl
+	return nil
+}
+
+// countingResolver returns the given permissions for every role, and counts
+// how many times each role was resolved.
+func countingResolver(keys []string, calls map[string]int) PermissionsResolver {
+	return func(_ context.Context, role *model.Role) ([]string, error) {
+		calls[role.ID]++
+		return keys, nil
+	}
+}
+
+func TestCachedPermissions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c := &fakeCache{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	calls := map[string]int{}
+	resolve := CachedPermissions(c, "sess_1", countingResolver([]string{"org:sys_profile:read", "org:invoices:read"}, calls))
+	admin := &model.Role{Role: &sqbmodel.Role{ID: "role_admin"}}
+	member := &model.Role{Role: &sqbmodel.Role{ID: "role_member"}}
+
+	for i := 0; i < 2; i++ {
+		keys, err := resolve(ctx, admin)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"org:sys_profile:read", "org:invoices:read"}, keys)
+	}
+	assert.Equal(t, 1, calls["role_admin"])
+	assert.Equal(t, permissionsCacheTTL, c.ttls[permissionsCacheKey("sess_1", "role_admin")])
+
+	// a change of role isn't served from the cache of the previous one
+	_, err := resolve(ctx, member)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls["role_member"])
+}
+
+func TestCachedPermissionsWithoutPermissions(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// roles without permissions are cached too
+	c := &fakeCache{values: map[string][]byte{}, ttls: map[string]time.Duration{}}
+	calls := map[string]int{}
+	resolve := CachedPermissions(c, "sess_1", countingResolver([]string{}, calls))
+	role := &model.Role{Role: &sqbmodel.Role{ID: "role_viewer"}}
+
+	for i := 0; i < 2; i++ {
+		keys, err := resolve(ctx, role)
+		require.NoError(t, err)
+		assert.Empty(t, keys)
+	}
+	assert.Equal(t, 1, calls["role_viewer"])
+}
+
+func TestCachedPermissionsCacheUnavailable(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c := &fakeCache{values: map[string][]byte{}, ttls: map[string]time.Duration{}, readErr: errors.New("connection refused")}
+	calls := map[string]int{}
+	resolve := CachedPermissions(c, "sess_1", countingResolver([]string{"org:sys_profile:read"}, calls))
+	role := &model.Role{Role: &sqbmodel.Role{ID: "role_admin"}}
+
+	for i := 0; i < 2; i++ {
+		keys, err := resolve(ctx, role)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"org:sys_profile:read"}, keys)
+	}
+	assert.Equal(t, 2, calls["role_admin"])
+}
@@ -0,0 +1,54 @@
+package jwt_template
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteForServiceAccount(t *testing.T) {
+	t.Parallel()
+
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	serviceAccount := &model.ServiceAccount{ServiceAccount: &sqbmodel.ServiceAccount{
+		ID:             "sa_1",
+		Name:           "billing-worker",
+		PublicMetadata: []byte(`{"team":"billing"}`),
+	}}
+	jwtTemplate := &model.JWTTemplate{JWTTemplate: &sqbmodel.JWTTemplate{
+		Claims: []byte(`{
+			"name": "{{service_account.name}}",
+			"team": "{{service_account.public_metadata.team}}",
+			"email": "{{user.primary_email_address}}",
+			"sub": "overridden",
+			"sub_type": "user"
+		}`),
+		Lifetime: 60,
+	}}
+
+	tmpl, err := New(nil, clock, Data{
+		JWTTmpl:        jwtTemplate,
+		ServiceAccount: serviceAccount,
+		Issuer:         "https://clerk.example.com",
+	})
+	require.NoError(t, err)
+
+	claims, err := tmpl.Execute(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "sa_1", claims["sub"])
+	assert.Equal(t, SubjectTypeServiceAccount, claims["sub_type"])
+	assert.Equal(t, "billing-worker", claims["name"])
+	assert.Equal(t, "billing", claims["team"])
+	// user shortcodes aren't available to service accounts
+	assert.Equal(t, "{{user.primary_email_address}}", claims["email"])
+	assert.Equal(t, "https://clerk.example.com", claims["iss"])
+	assert.Equal(t, clock.Now().Add(time.Minute).Unix(), claims["exp"])
+}
@@ -0,0 +1,35 @@
+package shortcodes
+
+import (
+	"context"
+
+	"clerk/model"
+)
+
+// OrgMembershipAllPermissions substitutes the keys of all permissions of the
+// role in the active organization, both system and custom ones. Unlike the
+// custom ones, system permissions don't come with the membership, so they're
+// resolved only when the shortcode is used.
+type OrgMembershipAllPermissions struct {
+	activeOrgMembership *model.OrganizationMembershipWithDeps
+	resolve             func(context.Context, *model.Role) ([]string, error)
+}
+
+func NewOrgMembershipAllPermissions(m *model.OrganizationMembershipWithDeps, resolve func(context.Context, *model.Role) ([]string, error)) *OrgMembershipAllPermissions {
+	return &OrgMembershipAllPermissions{
+		activeOrgMembership: m,
+		resolve:             resolve,
+	}
+}
+
+func (s *OrgMembershipAllPermissions) Identifier() string {
+	return "org_membership.all_permissions"
+}
+
+func (s *OrgMembershipAllPermissions) Substitute(ctx context.Context) (any, error) {
+	if s.activeOrgMembership == nil {
+		return nil, nil
+	}
+
+	return s.resolve(ctx, s.activeOrgMembership.Role)
+}
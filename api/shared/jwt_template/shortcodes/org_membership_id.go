@@ -0,0 +1,29 @@
+package shortcodes
+
+import (
+	"context"
+
+	"clerk/model"
+)
+
+type OrgMembershipID struct {
+	activeOrgMembership *model.OrganizationMembershipWithDeps
+}
+
+func NewOrgMembershipID(m *model.OrganizationMembershipWithDeps) *OrgMembershipID {
+	return &OrgMembershipID{
+		activeOrgMembership: m,
+	}
+}
+
+func (s *OrgMembershipID) Identifier() string {
+	return "org_membership.id"
+}
+
+func (s *OrgMembershipID) Substitute(_ context.Context) (any, error) {
+	if s.activeOrgMembership == nil {
+		return nil, nil
+	}
+
+	return s.activeOrgMembership.ID, nil
+}
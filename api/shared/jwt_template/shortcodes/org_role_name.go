@@ -0,0 +1,29 @@
+package shortcodes
+
+import (
+	"context"
+
+	"clerk/model"
+)
+
+type OrgRoleName struct {
+	activeOrgMembership *model.OrganizationMembershipWithDeps
+}
+
+func NewOrgRoleName(m *model.OrganizationMembershipWithDeps) *OrgRoleName {
+	return &OrgRoleName{
+		activeOrgMembership: m,
+	}
+}
+
+func (s *OrgRoleName) Identifier() string {
+	return "org.role_name"
+}
+
+func (s *OrgRoleName) Substitute(_ context.Context) (any, error) {
+	if s.activeOrgMembership == nil {
+		return nil, nil
+	}
+
+	return s.activeOrgMembership.Role.Name, nil
+}
@@ -0,0 +1,25 @@
+package shortcodes
+
+import (
+	"context"
+
+	"clerk/model"
+)
+
+type ServiceAccountID struct {
+	serviceAccount *model.ServiceAccount
+}
+
+func NewServiceAccountID(sa *model.ServiceAccount) *ServiceAccountID {
+	return &ServiceAccountID{
+		serviceAccount: sa,
+	}
+}
+
+func (s *ServiceAccountID) Identifier() string {
+	return "service_account.id"
+}
+
+func (s *ServiceAccountID) Substitute(_ context.Context) (any, error) {
+	return s.serviceAccount.ID, nil
+}
@@ -0,0 +1,25 @@
+package shortcodes
+
+import (
+	"context"
+
+	"clerk/model"
+)
+
+type ServiceAccountName struct {
+	serviceAccount *model.ServiceAccount
+}
+
+func NewServiceAccountName(sa *model.ServiceAccount) *ServiceAccountName {
+	return &ServiceAccountName{
+		serviceAccount: sa,
+	}
+}
+
+func (s *ServiceAccountName) Identifier() string {
+	return "service_account.name"
+}
+
+func (s *ServiceAccountName) Substitute(_ context.Context) (any, error) {
+	return s.serviceAccount.Name, nil
+}
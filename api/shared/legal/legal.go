@@ -0,0 +1,85 @@
+package legal
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// RequirementName is the sign-up requirement which is fulfilled once the
+// legal documents of the instance are accepted.
+const RequirementName = "legal_accepted"
+
+// IsRequired reports whether the instance requires users to accept its
+// legal documents.
+func IsRequired(userSettings *usersettings.UserSettings) bool {
+	return userSettings.SignUp.LegalConsent.Enabled && userSettings.SignUp.LegalConsent.Version != ""
+}
+
+// CurrentVersion returns the version of the legal documents users have to
+// accept.
+func CurrentVersion(userSettings *usersettings.UserSettings) string {
+	return userSettings.SignUp.LegalConsent.Version
+}
+
+// NeedsAcceptance reports whether someone who accepted the given version of
+// the legal documents needs to accept them again, because the instance
+// published a new version since then.
+func NeedsAcceptance(userSettings *usersettings.UserSettings, acceptedVersion null.String) bool {
+	if !IsRequired(userSettings) {
+		return false
+	}
+	return !acceptedVersion.Valid || acceptedVersion.String != CurrentVersion(userSettings)
+}
+
+type Service struct {
+	legalAcceptanceRepo *repository.LegalAcceptances
+	userRepo            *repository.Users
+}
+
+func NewService() *Service {
+	return &Service{
+		legalAcceptanceRepo: repository.NewLegalAcceptances(),
+		userRepo:            repository.NewUsers(),
+	}
+}
+
+// RecordAcceptance stores the latest accepted version on the user and keeps
+// a record of the acceptance in the user's history.
+func (s *Service) RecordAcceptance(ctx context.Context, tx database.Tx, user *model.User, version string, acceptedAt time.Time) error {
+	user.LegalAcceptedAt = null.TimeFrom(acceptedAt)
+	user.LegalAcceptedVersion = null.StringFrom(version)
+	if err := s.userRepo.UpdateLegalAcceptance(ctx, tx, user); err != nil {
+		return fmt.Errorf("legal/recordAcceptance: updating user %s: %w", user.ID, err)
+	}
+
+	acceptance := &model.LegalAcceptance{LegalAcceptance: &sqbmodel.LegalAcceptance{
+		InstanceID:      user.InstanceID,
+		UserID:          user.ID,
+		DocumentVersion: version,
+		AcceptedAt:      acceptedAt,
+	}}
+	if err := s.legalAcceptanceRepo.Insert(ctx, tx, acceptance); err != nil {
+		return fmt.Errorf("legal/recordAcceptance: inserting acceptance of version %s for user %s: %w", version, user.ID, err)
+	}
+	return nil
+}
+
+// History returns all the acceptances of the user, most recent first. The
+// acceptances are scoped to the instance of the user, so callers must have
+// loaded the user for their own instance.
+func (s *Service) History(ctx context.Context, exec database.Executor, user *model.User) ([]*model.LegalAcceptance, error) {
+	acceptances, err := s.legalAcceptanceRepo.FindAllByInstanceAndUserOrderByAcceptedAtDesc(ctx, exec, user.InstanceID, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("legal/history: fetching acceptances of user %s: %w", user.ID, err)
+	}
+	return acceptances, nil
+}
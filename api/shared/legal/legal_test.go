@@ -0,0 +1,63 @@
+package legal
+
+import (
+	"testing"
+
+	usersettings "clerk/pkg/usersettings/clerk"
+	usersettingsmodel "clerk/pkg/usersettings/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func legalConsentSettings(enabled bool, version string) *usersettings.UserSettings {
+	return usersettings.NewUserSettings(usersettingsmodel.UserSettings{
+		SignUp: usersettingsmodel.SignUp{
+			LegalConsent: usersettingsmodel.LegalConsent{Enabled: enabled, Version: version},
+		},
+	})
+}
+
+func TestNeedsAcceptance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings *usersettings.UserSettings
+		accepted null.String
+		want     bool
+	}{
+		{
+			name:     "not required",
+			settings: legalConsentSettings(false, "2024-01"),
+		},
+		{
+			name:     "required without a version",
+			settings: legalConsentSettings(true, ""),
+		},
+		{
+			name:     "never accepted",
+			settings: legalConsentSettings(true, "2024-01"),
+			want:     true,
+		},
+		{
+			name:     "accepted an older version",
+			settings: legalConsentSettings(true, "2024-06"),
+			accepted: null.StringFrom("2024-01"),
+			want:     true,
+		},
+		{
+			name:     "accepted the current version",
+			settings: legalConsentSettings(true, "2024-06"),
+			accepted: null.StringFrom("2024-06"),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.want, NeedsAcceptance(tt.settings, tt.accepted))
+		})
+	}
+}
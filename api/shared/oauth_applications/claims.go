@@ -0,0 +1,102 @@
+package oauth_applications
+
+import (
+	"encoding/json"
+	"strings"
+
+	"clerk/model"
+	"clerk/pkg/oauth2idp"
+)
+
+// The standard OpenID Connect scopes. Metadata scopes are specific to
+// Clerk and defined along with the provider.
+const (
+	OpenIDScope  = "openid"
+	ProfileScope = "profile"
+	EmailScope   = "email"
+)
+
+// scopeClaims lists the claims about the user that each scope gives access
+// to, following the standard claims of OpenID Connect.
+//
+// https://openid.net/specs/openid-connect-core-1_0.html#ScopeClaims
+var scopeClaims = map[string][]string{
+	OpenIDScope:                    {"sub"},
+	ProfileScope:                   {"name", "given_name", "family_name", "preferred_username", "picture"},
+	EmailScope:                     {"email", "email_verified"},
+	oauth2idp.PublicMetadataScope:  {"public_metadata", "unsafe_metadata"},
+	oauth2idp.PrivateMetadataScope: {"private_metadata"},
+}
+
+// scopeDescriptions are shown to users on the consent screen.
+var scopeDescriptions = map[string]string{
+	OpenIDScope:                    "Verify your identity",
+	ProfileScope:                   "View your name, username and profile image",
+	EmailScope:                     "View your email address",
+	oauth2idp.PublicMetadataScope:  "View the public metadata of your account",
+	oauth2idp.PrivateMetadataScope: "View the private metadata of your account",
+}
+
+// ScopeDescription returns what the scope gives access to, as shown on the
+// consent screen, or the scope itself if it has no description.
+func ScopeDescription(scope string) string {
+	if description, ok := scopeDescriptions[scope]; ok {
+		return description
+	}
+	return scope
+}
+
+// SupportedScopes returns the scopes that map to claims, as advertised in
+// the discovery document of the instance.
+func SupportedScopes() []string {
+	return []string{OpenIDScope, ProfileScope, EmailScope, oauth2idp.PublicMetadataScope, oauth2idp.PrivateMetadataScope}
+}
+
+// SupportedClaims returns all the claims the scopes give access to.
+func SupportedClaims() []string {
+	var claims []string
+	for _, scope := range SupportedScopes() {
+		claims = append(claims, scopeClaims[scope]...)
+	}
+	return claims
+}
+
+// Claims returns the claims about the user that the given scopes give
+// access to. The subject is always included, since it identifies the user.
+func Claims(info model.OAuthUserInfo, scopes []string) map[string]any {
+	values := map[string]any{
+		"sub":                info.UserID,
+		"name":               strings.TrimSpace(info.Name),
+		"given_name":         info.GivenName,
+		"family_name":        info.FamilyName,
+		"preferred_username": info.Username,
+		"picture":            info.Picture,
+		"email":              info.Email,
+		"email_verified":     info.EmailVerified,
+		"public_metadata":    info.PublicMetadata,
+		"unsafe_metadata":    info.UnsafeMetadata,
+		"private_metadata":   info.PrivateMetadata,
+	}
+
+	claims := map[string]any{"sub": info.UserID}
+	for _, scope := range scopes {
+		for _, claim := range scopeClaims[scope] {
+			if value, ok := values[claim]; ok && !isEmptyClaim(value) {
+				claims[claim] = value
+			}
+		}
+	}
+	return claims
+}
+
+// isEmptyClaim reports whether the value is missing, in which case the
+// claim is omitted rather than sent empty.
+func isEmptyClaim(value any) bool {
+	switch v := value.(type) {
+	case string:
+		return v == ""
+	case json.RawMessage:
+		return len(v) == 0
+	}
+	return false
+}
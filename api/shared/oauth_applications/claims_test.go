@@ -0,0 +1,53 @@
+package oauth_applications
+
+import (
+	"encoding/json"
+	"testing"
+
+	"clerk/model"
+	"clerk/pkg/oauth2idp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaims(t *testing.T) {
+	t.Parallel()
+
+	info := model.OAuthUserInfo{
+		UserID:         "user_123",
+		GivenName:      "Jane",
+		FamilyName:     "Doe",
+		Name:           "Jane Doe",
+		Email:          "jane@example.com",
+		EmailVerified:  true,
+		PublicMetadata: json.RawMessage(`{"plan":"pro"}`),
+	}
+
+	assert.Equal(t, map[string]any{"sub": "user_123"}, Claims(info, []string{OpenIDScope}))
+	assert.Equal(t, map[string]any{
+		"sub":            "user_123",
+		"email":          "jane@example.com",
+		"email_verified": true,
+	}, Claims(info, []string{OpenIDScope, EmailScope}))
+
+	// missing values are omitted
+	claims := Claims(info, []string{ProfileScope, oauth2idp.PublicMetadataScope})
+	assert.Equal(t, "Jane Doe", claims["name"])
+	assert.NotContains(t, claims, "picture")
+	assert.NotContains(t, claims, "preferred_username")
+	assert.Equal(t, json.RawMessage(`{"plan":"pro"}`), claims["public_metadata"])
+	assert.NotContains(t, claims, "unsafe_metadata")
+	assert.NotContains(t, claims, "private_metadata")
+}
+
+func TestConsentScopes(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, coversScopes("openid profile email", []string{"email", "openid"}))
+	assert.True(t, coversScopes("openid", nil))
+	assert.False(t, coversScopes("openid profile", []string{"openid", "email"}))
+	assert.False(t, coversScopes("", []string{"openid"}))
+
+	assert.Equal(t, "openid profile email", mergeScopes("openid profile", []string{"email", "openid"}))
+	assert.Equal(t, "openid", mergeScopes("", []string{"openid"}))
+}
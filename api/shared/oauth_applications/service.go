@@ -0,0 +1,245 @@
+// Package oauth_applications manages the OAuth applications of an instance,
+// which let third-party apps use the instance as their OpenID Connect
+// identity provider, and the consent users give to them.
+package oauth_applications
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/hash"
+	"clerk/pkg/oauth2idp"
+	"clerk/pkg/rand"
+	"clerk/pkg/set"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+)
+
+type Service struct {
+	// repositories
+	oauthApplicationsRepo *repository.OAuthApplications
+	consentsRepo          *repository.OAuthApplicationConsents
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		oauthApplicationsRepo: deps.Repositories().OAuthApplications,
+		consentsRepo:          deps.Repositories().OAuthApplicationConsents,
+	}
+}
+
+// ValidateCallbackURL checks that the callback URL of an application is an
+// absolute URL.
+func ValidateCallbackURL(param, callbackURL string) apierror.Error {
+	parsed, err := url.ParseRequestURI(callbackURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return apierror.FormInvalidTypeParameter(param, "valid url")
+	}
+	return nil
+}
+
+// ValidateScopes checks that the space separated scopes are all supported.
+func ValidateScopes(param, scopes string) apierror.Error {
+	if _, err := oauth2idp.ParseScopes(scopes, oauth2idp.FormattedAvailableScopes()); err != nil {
+		return apierror.FormInvalidParameterValueWithAllowed(param, scopes, oauth2idp.AvailableScopes.Array())
+	}
+	return nil
+}
+
+// CreateParams are the attributes of a new application. They are expected
+// to be validated already.
+type CreateParams struct {
+	Name                 string
+	CallbackURL          string
+	Scopes               string
+	Public               bool
+	ConsentScreenEnabled bool
+}
+
+// Create registers a new application for the instance, along with its
+// client credentials. The client secret is only available on the returned
+// application, since only its hash is stored.
+func (s *Service) Create(ctx context.Context, exec database.Executor, instanceID string, params CreateParams) (*model.OAuthApplication, error) {
+	clientID, err := rand.AlphanumExtended(16)
+	if err != nil {
+		return nil, fmt.Errorf("oauth_applications/create: generating client id: %w", err)
+	}
+	clientSecret, clientSecretHash, err := generateClientSecret()
+	if err != nil {
+		return nil, fmt.Errorf("oauth_applications/create: %w", err)
+	}
+	scopes, _ := oauth2idp.ParseScopes(params.Scopes, oauth2idp.FormattedAvailableScopes())
+
+	oauthApplication := &model.OAuthApplication{
+		OauthApplication: &sqbmodel.OauthApplication{
+			InstanceID:           instanceID,
+			Name:                 params.Name,
+			ClientID:             clientID,
+			ClientSecretHash:     clientSecretHash,
+			CallbackURL:          params.CallbackURL,
+			Scopes:               scopes,
+			Public:               params.Public,
+			ConsentScreenEnabled: params.ConsentScreenEnabled,
+		},
+		ClientSecret: clientSecret,
+	}
+	if err := s.oauthApplicationsRepo.Insert(ctx, exec, oauthApplication); err != nil {
+		return nil, fmt.Errorf("oauth_applications/create: inserting application for instance %s: %w", instanceID, err)
+	}
+	return oauthApplication, nil
+}
+
+// UpdateParams are the attributes of an application to change. Empty
+// strings and nil values leave the attribute as it is.
+type UpdateParams struct {
+	Name                 string
+	CallbackURL          string
+	Scopes               string
+	ConsentScreenEnabled *bool
+}
+
+// Update changes the given attributes of the application.
+func (s *Service) Update(ctx context.Context, exec database.Executor, oa *model.OAuthApplication, params UpdateParams) error {
+	updatedColumns := []string{}
+
+	if params.Name != "" {
+		oa.Name = params.Name
+		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.Name)
+	}
+	if params.CallbackURL != "" {
+		oa.CallbackURL = params.CallbackURL
+		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.CallbackURL)
+	}
+	if params.Scopes != "" {
+		oa.Scopes, _ = oauth2idp.ParseScopes(params.Scopes, oauth2idp.FormattedAvailableScopes())
+		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.Scopes)
+	}
+	if params.ConsentScreenEnabled != nil {
+		oa.ConsentScreenEnabled = *params.ConsentScreenEnabled
+		updatedColumns = append(updatedColumns, sqbmodel.OauthApplicationColumns.ConsentScreenEnabled)
+	}
+
+	if err := s.oauthApplicationsRepo.Update(ctx, exec, oa, updatedColumns...); err != nil {
+		return fmt.Errorf("oauth_applications/update: updating application %s: %w", oa.ID, err)
+	}
+	return nil
+}
+
+// RotateSecret replaces the client secret of the application. The new
+// secret is only available on the application until it's reloaded.
+func (s *Service) RotateSecret(ctx context.Context, exec database.Executor, oa *model.OAuthApplication) error {
+	clientSecret, clientSecretHash, err := generateClientSecret()
+	if err != nil {
+		return fmt.Errorf("oauth_applications/rotateSecret: %w", err)
+	}
+	oa.ClientSecretHash = clientSecretHash
+	oa.ClientSecret = clientSecret
+
+	if err := s.oauthApplicationsRepo.Update(ctx, exec, oa, sqbmodel.OauthApplicationColumns.ClientSecretHash); err != nil {
+		return fmt.Errorf("oauth_applications/rotateSecret: updating application %s: %w", oa.ID, err)
+	}
+	return nil
+}
+
+func generateClientSecret() (string, string, error) {
+	clientSecret, err := rand.AlphanumExtended(32)
+	if err != nil {
+		return "", "", fmt.Errorf("generating client secret: %w", err)
+	}
+	clientSecretHash, err := hash.GenerateBcryptHash(clientSecret)
+	if err != nil {
+		return "", "", fmt.Errorf("hashing client secret: %w", err)
+	}
+	return clientSecret, clientSecretHash, nil
+}
+
+// RequiresConsent reports whether the user has to consent before the
+// application is authorized with the given scopes. Applications without a
+// consent screen are trusted by the instance, so they never do.
+func (s *Service) RequiresConsent(ctx context.Context, exec database.Executor, oa *model.OAuthApplication, userID string, scopes []string) (bool, error) {
+	if !oa.ConsentScreenEnabled {
+		return false, nil
+	}
+
+	consent, err := s.Consent(ctx, exec, oa, userID)
+	if err != nil {
+		return false, err
+	}
+	return consent == nil || !coversScopes(consent.Scopes, scopes), nil
+}
+
+// Consent returns the consent of the user to the application, or nil if
+// they never gave one.
+func (s *Service) Consent(ctx context.Context, exec database.Executor, oa *model.OAuthApplication, userID string) (*model.OAuthApplicationConsent, error) {
+	consent, err := s.consentsRepo.QueryByApplicationAndUser(ctx, exec, oa.ID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("oauth_applications/consent: fetching consent of user %s to %s: %w", userID, oa.ID, err)
+	}
+	return consent, nil
+}
+
+// GrantConsent records that the user consents to the application accessing
+// the given scopes, in addition to the ones they consented to before.
+func (s *Service) GrantConsent(ctx context.Context, exec database.Executor, oa *model.OAuthApplication, userID string, scopes []string) (*model.OAuthApplicationConsent, error) {
+	consent, err := s.Consent(ctx, exec, oa, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if consent == nil {
+		consent = &model.OAuthApplicationConsent{OauthApplicationConsent: &sqbmodel.OauthApplicationConsent{
+			InstanceID:         oa.InstanceID,
+			OauthApplicationID: oa.ID,
+			UserID:             userID,
+		}}
+	}
+	consent.Scopes = mergeScopes(consent.Scopes, scopes)
+
+	if err := s.consentsRepo.Upsert(ctx, exec, consent); err != nil {
+		return nil, fmt.Errorf("oauth_applications/grantConsent: storing consent of user %s to %s: %w", userID, oa.ID, err)
+	}
+	return consent, nil
+}
+
+// RevokeConsent deletes the consent of the user to the application, so that
+// they're asked again the next time they authorize it. It reports whether
+// there was a consent to revoke.
+func (s *Service) RevokeConsent(ctx context.Context, exec database.Executor, oa *model.OAuthApplication, userID string) (bool, error) {
+	rowsAff, err := s.consentsRepo.DeleteByApplicationAndUser(ctx, exec, oa.ID, userID)
+	if err != nil {
+		return false, fmt.Errorf("oauth_applications/revokeConsent: deleting consent of user %s to %s: %w", userID, oa.ID, err)
+	}
+	return rowsAff > 0, nil
+}
+
+// coversScopes reports whether all the requested scopes are among the
+// space separated granted ones.
+func coversScopes(granted string, requested []string) bool {
+	grantedScopes := set.New(strings.Fields(granted)...)
+	for _, scope := range requested {
+		if !grantedScopes.Contains(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeScopes adds the scopes which are missing to the space separated
+// existing ones, keeping their order.
+func mergeScopes(existing string, scopes []string) string {
+	merged := strings.Fields(existing)
+	seen := set.New(merged...)
+	for _, scope := range scopes {
+		if !seen.Contains(scope) {
+			seen.Insert(scope)
+			merged = append(merged, scope)
+		}
+	}
+	return strings.Join(merged, " ")
+}
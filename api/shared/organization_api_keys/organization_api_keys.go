@@ -0,0 +1,86 @@
+// Package organization_api_keys handles the secrets of organization API keys.
+//
+// Organization API keys authenticate Backend API requests just like instance
+// secret keys do, but they can only access the endpoints of the organization
+// they were issued for. Only a digest of each secret is stored, so secrets
+// are revealed once, when they're issued or rotated.
+package organization_api_keys
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"clerk/model"
+	"clerk/pkg/rand"
+)
+
+// SecretPrefix makes organization API keys easy to tell apart from instance
+// secret keys.
+const SecretPrefix = "org_sk_"
+
+// the number of trailing characters of a secret which are kept, so that
+// users can recognize their keys
+const secretHintLength = 4
+
+// lastUsedInterval is how often the last use of a key is recorded. Keys
+// authenticate every request of their integration, so recording each use
+// would mean a write for each request.
+const lastUsedInterval = 5 * time.Minute
+
+// IsSecret returns whether the given bearer token is an organization API key.
+func IsSecret(token string) bool {
+	return strings.HasPrefix(token, SecretPrefix)
+}
+
+// GenerateSecret returns a new secret, along with its digest which is stored
+// in its place and a hint that can be shown to users.
+func GenerateSecret() (secret string, digest string, hint string, err error) {
+	random, err := rand.AlphanumExtended(48)
+	if err != nil {
+		return "", "", "", fmt.Errorf("organization_api_keys: generating secret: %w", err)
+	}
+	secret = SecretPrefix + random
+	return secret, Digest(secret), secret[len(secret)-secretHintLength:], nil
+}
+
+// Digest returns the digest of the given secret, which is used to look up
+// the key it belongs to.
+func Digest(secret string) string {
+	digest := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(digest[:])
+}
+
+// AllowsPath returns whether a request to the given Backend API path can be
+// authenticated with the key. Organization API keys can only access the
+// organization they were issued for and the resources nested under it.
+func AllowsPath(key *model.OrganizationAPIKey, path string) bool {
+	organizationPath := "/v1/organizations/" + key.OrganizationID
+	path = strings.TrimSuffix(path, "/")
+	return path == organizationPath || strings.HasPrefix(path, organizationPath+"/")
+}
+
+// ShouldRecordUse returns whether a use of the key at the given time should
+// be recorded, i.e. if it was never used, or its last recorded use is older
+// than lastUsedInterval.
+func ShouldRecordUse(key *model.OrganizationAPIKey, now time.Time) bool {
+	return !key.LastUsedAt.Valid || now.Sub(key.LastUsedAt.Time) >= lastUsedInterval
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the organization API key
+// the current request was authenticated with.
+func NewContext(ctx context.Context, key *model.OrganizationAPIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// FromContext returns the organization API key the current request was
+// authenticated with, if any.
+func FromContext(ctx context.Context) (*model.OrganizationAPIKey, bool) {
+	key, ok := ctx.Value(contextKey{}).(*model.OrganizationAPIKey)
+	return key, ok && key != nil
+}
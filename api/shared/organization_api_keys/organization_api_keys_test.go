@@ -0,0 +1,57 @@
+package organization_api_keys
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestGenerateSecret(t *testing.T) {
+	t.Parallel()
+
+	secret, digest, hint, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.True(t, IsSecret(secret))
+	assert.Equal(t, Digest(secret), digest)
+	assert.True(t, strings.HasSuffix(secret, hint))
+	assert.NotContains(t, digest, secret)
+}
+
+func TestAllowsPath(t *testing.T) {
+	t.Parallel()
+
+	key := &model.OrganizationAPIKey{OrganizationAPIKey: &sqbmodel.OrganizationAPIKey{OrganizationID: "org_1"}}
+	for path, allowed := range map[string]bool{
+		"/v1/organizations/org_1":                     true,
+		"/v1/organizations/org_1/":                    true,
+		"/v1/organizations/org_1/memberships":         true,
+		"/v1/organizations/org_1/invitations/orginv_": true,
+		"/v1/organizations/org_12":                    false,
+		"/v1/organizations/org_2/memberships":         false,
+		"/v1/organizations":                           false,
+		"/v1/users":                                   false,
+	} {
+		assert.Equal(t, allowed, AllowsPath(key, path), path)
+	}
+}
+
+func TestShouldRecordUse(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	key := &model.OrganizationAPIKey{OrganizationAPIKey: &sqbmodel.OrganizationAPIKey{}}
+	assert.True(t, ShouldRecordUse(key, now), "keys which were never used")
+
+	key.LastUsedAt = null.TimeFrom(now.Add(-lastUsedInterval + time.Second))
+	assert.False(t, ShouldRecordUse(key, now))
+
+	key.LastUsedAt = null.TimeFrom(now.Add(-lastUsedInterval))
+	assert.True(t, ShouldRecordUse(key, now))
+}
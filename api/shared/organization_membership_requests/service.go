@@ -0,0 +1,256 @@
+package organization_membership_requests
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/comms"
+	"clerk/api/shared/events"
+	"clerk/api/shared/organizations"
+	"clerk/api/shared/pagination"
+	"clerk/api/shared/serializable"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/param"
+
+	"github.com/volatiletech/null/v8"
+)
+
+type Service struct {
+	// services
+	commsService         *comms.Service
+	eventsService        *events.Service
+	organizationsService *organizations.Service
+	serializableService  *serializable.Service
+
+	// repositories
+	organizationRepo     *repository.Organization
+	orgMemberRequestRepo *repository.OrganizationMembershipRequest
+	orgSuggestionRepo    *repository.OrganizationSuggestion
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		commsService:         comms.NewService(deps),
+		eventsService:        events.NewService(deps),
+		organizationsService: organizations.NewService(deps),
+		serializableService:  serializable.NewService(deps.Clock()),
+		organizationRepo:     deps.Repositories().Organization,
+		orgMemberRequestRepo: deps.Repositories().OrganizationMembershipRequest,
+		orgSuggestionRepo:    deps.Repositories().OrganizationSuggestion,
+	}
+}
+
+type ListParams struct {
+	OrganizationID string
+	Statuses       []string
+	// RequestingUserID is the member listing the requests. It's empty for
+	// requests listed on behalf of the instance, e.g. through BAPI.
+	RequestingUserID string
+}
+
+func (params ListParams) validate() apierror.Error {
+	for _, status := range params.Statuses {
+		if !constants.OrganizationMembershipRequestStatuses.Contains(status) {
+			return apierror.FormInvalidParameterValueWithAllowed(param.Status.Name, status, constants.OrganizationMembershipRequestStatuses.Array())
+		}
+	}
+	return nil
+}
+
+// List returns the membership requests of the organization with the given
+// statuses, along with their total count.
+func (s *Service) List(
+	ctx context.Context,
+	exec database.Executor,
+	params ListParams,
+	paginationParams pagination.Params,
+) ([]*model.OrganizationMembershipRequestSerializable, int64, apierror.Error) {
+	if apiErr := params.validate(); apiErr != nil {
+		return nil, 0, apiErr
+	}
+	if apiErr := s.ensureCanManageMembers(ctx, exec, params.OrganizationID, params.RequestingUserID); apiErr != nil {
+		return nil, 0, apiErr
+	}
+
+	membershipRequests, err := s.orgMemberRequestRepo.FindAllByOrganizationAndStatus(ctx, exec, params.OrganizationID, params.Statuses, paginationParams)
+	if err != nil {
+		return nil, 0, apierror.Unexpected(err)
+	}
+
+	serializables := make([]*model.OrganizationMembershipRequestSerializable, len(membershipRequests))
+	for i, membershipRequest := range membershipRequests {
+		serializables[i], err = s.serializableService.ConvertOrganizationMembershipRequest(ctx, exec, membershipRequest)
+		if err != nil {
+			return nil, 0, apierror.Unexpected(err)
+		}
+	}
+
+	count, err := s.orgMemberRequestRepo.CountByOrganizationAndStatus(ctx, exec, params.OrganizationID, params.Statuses)
+	if err != nil {
+		return nil, 0, apierror.Unexpected(err)
+	}
+	return serializables, count, nil
+}
+
+type ReviewParams struct {
+	OrganizationID string
+	RequestID      string
+	// RequestingUserID is the member reviewing the request. It's empty for
+	// requests reviewed on behalf of the instance, e.g. through BAPI.
+	RequestingUserID string
+}
+
+// Accept approves the pending membership request, which adds the requesting
+// user to the organization with the default role for domains, and triggers
+// an organizationMembershipRequest.accepted event.
+func (s *Service) Accept(ctx context.Context, tx database.Tx, env *model.Env, params ReviewParams) (*model.OrganizationMembershipRequestSerializable, apierror.Error) {
+	if apiErr := s.ensureCanManageMembers(ctx, tx, params.OrganizationID, params.RequestingUserID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	membershipRequest, apiErr := s.findPending(ctx, tx, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	organization, err := s.organizationRepo.QueryByIDAndInstance(ctx, tx, params.OrganizationID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if organization == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	membership, apiErr := s.organizationsService.CreateMembership(ctx, tx, organizations.CreateMembershipParams{
+		OrganizationID:   params.OrganizationID,
+		UserID:           membershipRequest.UserID,
+		Role:             env.AuthConfig.OrganizationSettings.Domains.DefaultRole,
+		RequestingUserID: params.RequestingUserID,
+		Instance:         env.Instance,
+		Subscription:     env.Subscription,
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	membershipRequest.ApprovedBy = null.NewString(params.RequestingUserID, params.RequestingUserID != "")
+	membershipRequest.OrganizationMembershipID = null.StringFrom(membership.OrganizationMembership.ID)
+	membershipRequest.Status = constants.StatusAccepted
+	if err := s.orgMemberRequestRepo.Update(ctx, tx, membershipRequest,
+		sqbmodel.OrganizationMembershipRequestColumns.ApprovedBy,
+		sqbmodel.OrganizationMembershipRequestColumns.OrganizationMembershipID,
+		sqbmodel.OrganizationMembershipRequestColumns.Status,
+	); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	// update corresponding organization suggestion status to `completed`
+	suggestion, apiErr := s.updateAcceptedOrganizationSuggestionStatus(ctx, tx, membershipRequest.OrganizationSuggestionID, constants.StatusCompleted)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	emailParams := comms.EmailOrganizationJoined{
+		Organization: organization,
+		EmailAddress: suggestion.EmailAddress,
+	}
+	if err = s.commsService.SendOrganizationJoinedEmail(ctx, tx, env, emailParams); err != nil {
+		return nil, apierror.Unexpected(fmt.Errorf("membershipRequests/accept: sending organization joined email failed: %w", err))
+	}
+
+	membershipRequestSerializable, err := s.serializableService.ConvertOrganizationMembershipRequest(ctx, tx, membershipRequest)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	err = s.eventsService.OrganizationMembershipRequestAccepted(ctx, tx, env.Instance,
+		serialize.OrganizationMembershipRequest(membershipRequestSerializable), membershipRequest.UserID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return membershipRequestSerializable, nil
+}
+
+// Reject rejects the pending membership request and triggers an
+// organizationMembershipRequest.rejected event.
+func (s *Service) Reject(ctx context.Context, tx database.Tx, env *model.Env, params ReviewParams) (*model.OrganizationMembershipRequestSerializable, apierror.Error) {
+	if apiErr := s.ensureCanManageMembers(ctx, tx, params.OrganizationID, params.RequestingUserID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	membershipRequest, apiErr := s.findPending(ctx, tx, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	// update membership request status to `rejected`
+	membershipRequest.Status = constants.StatusRejected
+	if err := s.orgMemberRequestRepo.UpdateStatus(ctx, tx, membershipRequest); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	// update corresponding organization suggestion status to `completed`
+	_, apiErr = s.updateAcceptedOrganizationSuggestionStatus(ctx, tx, membershipRequest.OrganizationSuggestionID, constants.StatusCompleted)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	membershipRequestSerializable, err := s.serializableService.ConvertOrganizationMembershipRequest(ctx, tx, membershipRequest)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	err = s.eventsService.OrganizationMembershipRequestRejected(ctx, tx, env.Instance,
+		serialize.OrganizationMembershipRequest(membershipRequestSerializable), membershipRequest.UserID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return membershipRequestSerializable, nil
+}
+
+// ensureCanManageMembers makes sure that the requesting user, if any, can
+// manage the members of the organization.
+func (s *Service) ensureCanManageMembers(ctx context.Context, exec database.Executor, organizationID, requestingUserID string) apierror.Error {
+	if requestingUserID == "" {
+		return nil
+	}
+	return s.organizationsService.EnsureHasAccess(ctx, exec, organizationID, constants.PermissionMembersManage, requestingUserID)
+}
+
+func (s *Service) findPending(ctx context.Context, exec database.Executor, params ReviewParams) (*model.OrganizationMembershipRequest, apierror.Error) {
+	membershipRequest, err := s.orgMemberRequestRepo.QueryPendingByOrganizationAndID(ctx, exec, params.OrganizationID, params.RequestID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if membershipRequest == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+	return membershipRequest, nil
+}
+
+func (s *Service) updateAcceptedOrganizationSuggestionStatus(ctx context.Context, tx database.Tx, organizationSuggestionID, newStatus string) (*model.OrganizationSuggestion, apierror.Error) {
+	suggestion, err := s.orgSuggestionRepo.QueryAcceptedByID(ctx, tx, organizationSuggestionID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if suggestion == nil {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	suggestion.Status = newStatus
+	err = s.orgSuggestionRepo.UpdateStatus(ctx, tx, suggestion)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	return suggestion, nil
+}
@@ -0,0 +1,50 @@
+package organization_membership_requests
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/pagination"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, ListParams{OrganizationID: "org_1"}.validate())
+	assert.Nil(t, ListParams{
+		OrganizationID: "org_1",
+		Statuses:       []string{constants.StatusPending, constants.StatusAccepted, constants.StatusRejected},
+	}.validate())
+
+	apiErr := ListParams{
+		OrganizationID: "org_1",
+		Statuses:       []string{constants.StatusPending, "unknown"},
+	}.validate()
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestListInvalidStatus(t *testing.T) {
+	t.Parallel()
+
+	// statuses are validated before anything is queried
+	_, _, apiErr := (&Service{}).List(context.Background(), nil, ListParams{
+		OrganizationID: "org_1",
+		Statuses:       []string{"unknown"},
+	}, pagination.Params{})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestEnsureCanManageMembersOnBehalfOfInstance(t *testing.T) {
+	t.Parallel()
+
+	// requests which are reviewed on behalf of the instance don't need the
+	// permissions of a member
+	assert.Nil(t, (&Service{}).ensureCanManageMembers(context.Background(), nil, "org_1", ""))
+}
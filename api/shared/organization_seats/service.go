@@ -0,0 +1,237 @@
+package organization_seats
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/model"
+	clerkbilling "clerk/pkg/billing"
+	"clerk/pkg/constants"
+	"clerk/pkg/externalapis/segment"
+	"clerk/pkg/jobs"
+	sentryclerk "clerk/pkg/sentry"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stripe/stripe-go/v72"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// Seats is the seat usage of an organization at a point in time.
+type Seats struct {
+	OrganizationID string
+
+	// Used is the number of active members of the organization.
+	Used int64
+
+	// Limit is the maximum number of members the organization is allowed
+	// to have. Zero means there's no limit.
+	Limit int
+
+	// BilledQuantity is the seat quantity of the Stripe subscription of the
+	// organization. It's nil for organizations which aren't billed per seat.
+	BilledQuantity *int64
+
+	// LimitExceededAt is when the organization went over its seat limit, or
+	// null if it's within it.
+	LimitExceededAt null.Time
+
+	subscriptionItemID string
+}
+
+// LimitExceeded reports whether the organization has more active members
+// than its seat limit allows.
+func (s *Seats) LimitExceeded() bool {
+	return limitExceeded(s.Used, s.Limit)
+}
+
+func limitExceeded(used int64, limit int) bool {
+	return limit > 0 && used > int64(limit)
+}
+
+// limitCrossingEvent returns the segment event to send when the seat usage
+// of an organization moves across its limit, or an empty string if it
+// stayed on the same side.
+func limitCrossingEvent(wasExceeded bool, used int64, limit int) string {
+	isExceeded := limitExceeded(used, limit)
+	switch {
+	case isExceeded && !wasExceeded:
+		return segment.APIBackendBillingSeatLimitExceeded
+	case !isExceeded && wasExceeded:
+		return segment.APIBackendBillingSeatLimitRestored
+	default:
+		return ""
+	}
+}
+
+type Service struct {
+	clock           clockwork.Clock
+	db              database.Database
+	gueClient       *gue.Client
+	paymentProvider clerkbilling.PaymentProvider
+
+	// repositories
+	organizationRepo           *repository.Organization
+	organizationMembershipRepo *repository.OrganizationMembership
+	subscriptionRepo           *repository.Subscriptions
+	subscriptionMetricRepo     *repository.SubscriptionMetrics
+}
+
+func NewService(deps clerk.Deps, paymentProvider clerkbilling.PaymentProvider) *Service {
+	return &Service{
+		clock:                      deps.Clock(),
+		db:                         deps.DB(),
+		gueClient:                  deps.GueClient(),
+		paymentProvider:            paymentProvider,
+		organizationRepo:           deps.Repositories().Organization,
+		organizationMembershipRepo: deps.Repositories().OrganizationMembership,
+		subscriptionRepo:           deps.Repositories().Subscriptions,
+		subscriptionMetricRepo:     deps.Repositories().SubscriptionMetrics,
+	}
+}
+
+// Read returns the current seat usage of the organization, along with the
+// seat quantity its Stripe subscription is billed for.
+func (s *Service) Read(ctx context.Context, exec database.Executor, organization *model.Organization) (*Seats, error) {
+	used, err := s.organizationMembershipRepo.CountByOrganization(ctx, exec, organization.ID)
+	if err != nil {
+		return nil, fmt.Errorf("organizationSeats/read: counting members of %s: %w", organization.ID, err)
+	}
+
+	seats := &Seats{
+		OrganizationID:  organization.ID,
+		Used:            used,
+		Limit:           organization.MaxAllowedMemberships,
+		LimitExceededAt: organization.SeatLimitExceededAt,
+	}
+
+	subscriptionItem, err := s.seatsSubscriptionItem(ctx, exec, organization.ID)
+	if err != nil {
+		return nil, fmt.Errorf("organizationSeats/read: fetching seats subscription item of %s: %w", organization.ID, err)
+	}
+	if subscriptionItem != nil {
+		seats.subscriptionItemID = subscriptionItem.ID
+		seats.BilledQuantity = &subscriptionItem.Quantity
+	}
+	return seats, nil
+}
+
+// Reconcile brings the seat quantity of the Stripe subscription of the
+// organization in line with its active members, and sends an event when
+// the organization went over or back under its seat limit since the last
+// reconciliation. It is run daily by the reconcile_organization_seats job.
+func (s *Service) Reconcile(ctx context.Context, organizationID string) error {
+	organization, err := s.organizationRepo.QueryByID(ctx, s.db, organizationID)
+	if err != nil {
+		return fmt.Errorf("organizationSeats/reconcile: fetching organization %s: %w", organizationID, err)
+	}
+	if organization == nil {
+		// deleted since the job was enqueued
+		return nil
+	}
+
+	seats, err := s.Read(ctx, s.db, organization)
+	if err != nil {
+		return err
+	}
+
+	if seats.BilledQuantity != nil && *seats.BilledQuantity != seats.Used {
+		err := s.paymentProvider.UpdateSubscriptionItemQuantity(ctx, seats.subscriptionItemID, seats.Used)
+		if err != nil {
+			return fmt.Errorf("organizationSeats/reconcile: updating seat quantity of %s from %d to %d: %w",
+				organization.ID, *seats.BilledQuantity, seats.Used, err)
+		}
+	}
+
+	event := limitCrossingEvent(organization.SeatLimitExceededAt.Valid, seats.Used, seats.Limit)
+	if event == "" {
+		return nil
+	}
+
+	if seats.LimitExceeded() {
+		organization.SeatLimitExceededAt = null.TimeFrom(s.clock.Now().UTC())
+	} else {
+		organization.SeatLimitExceededAt = null.TimeFromPtr(nil)
+	}
+	if err := s.organizationRepo.UpdateSeatLimitExceededAt(ctx, s.db, organization); err != nil {
+		return fmt.Errorf("organizationSeats/reconcile: updating organization %s: %w", organization.ID, err)
+	}
+
+	err = jobs.SegmentEnqueueEvent(ctx, s.gueClient, jobs.SegmentArgs{
+		Event: event,
+		Properties: map[string]any{
+			"surface":        "API",
+			"location":       "Dashboard",
+			"organizationId": organization.ID,
+			"seatsUsed":      seats.Used,
+			"seatLimit":      seats.Limit,
+		},
+	})
+	if err != nil {
+		sentryclerk.CaptureException(ctx, fmt.Errorf("enqueue segment job: %w", err))
+	}
+	return nil
+}
+
+// EnqueueReconciliations enqueues a reconcile_organization_seats job for
+// every organization which has a subscription. It is run once a day.
+func (s *Service) EnqueueReconciliations(ctx context.Context) error {
+	organizationIDs, err := s.subscriptionRepo.FindAllResourceIDsByResourceType(ctx, s.db, constants.OrganizationResource)
+	if err != nil {
+		return fmt.Errorf("organizationSeats/enqueueReconciliations: fetching organizations: %w", err)
+	}
+
+	for _, organizationID := range organizationIDs {
+		err := jobs.ReconcileOrganizationSeats(ctx, s.gueClient, jobs.ReconcileOrganizationSeatsArgs{
+			OrganizationID: organizationID,
+		})
+		if err != nil {
+			return fmt.Errorf("organizationSeats/enqueueReconciliations: enqueueing job for %s: %w", organizationID, err)
+		}
+	}
+	return nil
+}
+
+// seatsSubscriptionItem returns the item of the Stripe subscription of the
+// organization which bills for seats, or nil if there's none.
+func (s *Service) seatsSubscriptionItem(ctx context.Context, exec database.Executor, organizationID string) (*stripe.SubscriptionItem, error) {
+	subscription, err := s.subscriptionRepo.QueryByResourceID(ctx, exec, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil || !subscription.StripeSubscriptionID.Valid {
+		return nil, nil
+	}
+
+	subscriptionMetrics, err := s.subscriptionMetricRepo.FindAllBySubscriptionID(ctx, exec, subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+	var itemID string
+	for _, subscriptionMetric := range subscriptionMetrics {
+		if subscriptionMetric.Metric == clerkbilling.PriceTypes.Seats {
+			itemID = subscriptionMetric.StripeSubscriptionItemID
+			break
+		}
+	}
+	if itemID == "" {
+		return nil, nil
+	}
+
+	stripeSubscription, err := s.paymentProvider.FetchSubscription(subscription.StripeSubscriptionID.String)
+	if err != nil {
+		return nil, err
+	}
+	if stripeSubscription.Items == nil {
+		return nil, nil
+	}
+	for _, item := range stripeSubscription.Items.Data {
+		if item.ID == itemID {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,33 @@
+package organization_seats
+
+import (
+	"testing"
+
+	"clerk/pkg/externalapis/segment"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimitCrossingEvent(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name        string
+		wasExceeded bool
+		used        int64
+		limit       int
+		want        string
+	}{
+		{name: "unlimited", used: 100, limit: 0, want: ""},
+		{name: "within limit", used: 5, limit: 5, want: ""},
+		{name: "goes over limit", used: 6, limit: 5, want: segment.APIBackendBillingSeatLimitExceeded},
+		{name: "stays over limit", wasExceeded: true, used: 7, limit: 5, want: ""},
+		{name: "back under limit", wasExceeded: true, used: 5, limit: 5, want: segment.APIBackendBillingSeatLimitRestored},
+		{name: "limit removed", wasExceeded: true, used: 7, limit: 0, want: segment.APIBackendBillingSeatLimitRestored},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, limitCrossingEvent(tc.wasExceeded, tc.used, tc.limit))
+		})
+	}
+}
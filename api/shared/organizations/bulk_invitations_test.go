@@ -0,0 +1,52 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPendingInvitationsBulkSize(t *testing.T) {
+	t.Parallel()
+
+	tooMany := make([]string, constants.MaxBulkSize+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("orginv_%d", i)
+	}
+
+	for _, tt := range []struct {
+		name          string
+		invitationIDs []string
+		wantCode      string
+	}{
+		{
+			name:     "no invitations",
+			wantCode: apierror.FormParamMissingCode,
+		},
+		{
+			name:          "too many invitations",
+			invitationIDs: tooMany,
+			wantCode:      apierror.BulkSizeExceededCode,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// the size is checked before anything is read, or revoked
+			_, apiErr := (&Service{}).findPendingInvitations(context.Background(), nil, BulkInvitationsParams{
+				OrganizationID:   "org_1",
+				InvitationIDs:    tt.invitationIDs,
+				RequestingUserID: "user_1",
+			})
+			require.NotNil(t, apiErr)
+			assert.Equal(t, tt.wantCode, apiErr.Errors()[0].Code())
+		})
+	}
+}
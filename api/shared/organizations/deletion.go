@@ -0,0 +1,112 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/constants"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// MaxDeletionGracePeriodDays is the longest a deleted organization can stay
+// pending deletion before it's purged.
+const MaxDeletionGracePeriodDays = 90
+
+// markPendingDeletion marks the organization as deleted now, to be purged
+// once the grace period is over.
+func markPendingDeletion(org *model.Organization, now time.Time, gracePeriodDays int) {
+	org.DeletedAt = null.TimeFrom(now)
+	org.PurgeAt = null.TimeFrom(now.AddDate(0, 0, gracePeriodDays))
+}
+
+// isPurgeDue tells whether the grace period of the organization which is
+// pending deletion is over.
+func isPurgeDue(org *model.Organization, now time.Time) bool {
+	return !org.PurgeAt.Time.After(now)
+}
+
+// purge deletes the given organization permanently. This is also when the
+// irreversible parts of the deletion happen, i.e. the cancellation of the
+// Stripe subscriptions of the applications it owns and the cleanup of its
+// logo.
+func (s *Service) purge(ctx context.Context, tx database.Tx, application *model.Application, org *model.Organization) error {
+	if application.Type == string(constants.RTSystem) {
+		// We schedule the soft-delete instead of doing it in place, because soft-deletion also
+		// involves Stripe cancellation, which is an action that cannot be reverted, if the
+		// transaction fails.
+		err := s.applicationDeleter.ScheduleSoftDeleteOfOwnedApplications(ctx, tx, org.ID, constants.OrganizationResource)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := s.organizationsRepo.DeleteByID(ctx, tx, org.ID); err != nil {
+		return fmt.Errorf("organizations/purge: delete organization %s: %w", org.ID, err)
+	}
+
+	if org.LogoPublicURL.Valid {
+		err := s.EnqueueCleanupImageJob(ctx, tx, org.LogoPublicURL.String)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge permanently deletes an organization which is pending deletion, once
+// its grace period has expired. Organizations which were restored or purged
+// in the meantime are skipped. It's invoked by the
+// purge_deleted_organizations job.
+func (s *Service) Purge(ctx context.Context, organizationID string) error {
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		org, err := s.organizationsRepo.QueryDeletedByIDForUpdate(ctx, tx, organizationID)
+		if err != nil {
+			return true, fmt.Errorf("organizations/purge: query deleted organization by id %s: %w", organizationID, err)
+		}
+		if org == nil || !isPurgeDue(org, s.clock.Now().UTC()) {
+			return false, nil
+		}
+
+		application, err := s.applicationRepo.FindByInstanceID(ctx, tx, org.InstanceID)
+		if err != nil {
+			return true, fmt.Errorf("organizations/purge: find application of instance %s: %w", org.InstanceID, err)
+		}
+
+		if err := s.purge(ctx, tx, application, org); err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+// Restore brings back an organization which is pending deletion, as long as
+// it hasn't been purged yet. The organization comes back as it was, with its
+// memberships, invitations and domains. An organization which was deleted
+// because its last member left comes back without any members.
+func (s *Service) Restore(ctx context.Context, tx database.Tx, env *model.Env, organizationID string) (*model.Organization, apierror.Error) {
+	org, err := s.organizationsRepo.QueryDeletedByIDAndInstanceForUpdate(ctx, tx, organizationID, env.Instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if org == nil {
+		return nil, apierror.OrganizationNotFound()
+	}
+
+	org.DeletedAt = null.TimeFromPtr(nil)
+	org.PurgeAt = null.TimeFromPtr(nil)
+	if err := s.organizationsRepo.UpdateDeletedAt(ctx, tx, org); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	err = s.eventsService.OrganizationUpdated(ctx, tx, env.Instance, serialize.OrganizationBAPI(ctx, org), nil)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return org, nil
+}
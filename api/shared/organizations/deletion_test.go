@@ -0,0 +1,36 @@
+package organizations
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarkPendingDeletion(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+	org := &model.Organization{Organization: &sqbmodel.Organization{ID: "org_1"}}
+
+	markPendingDeletion(org, now, 7)
+	assert.Equal(t, now, org.DeletedAt.Time)
+	assert.Equal(t, time.Date(2024, 2, 6, 12, 0, 0, 0, time.UTC), org.PurgeAt.Time)
+}
+
+func TestIsPurgeDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 30, 12, 0, 0, 0, time.UTC)
+	org := &model.Organization{Organization: &sqbmodel.Organization{ID: "org_1"}}
+	markPendingDeletion(org, now, 7)
+
+	// organizations can be restored until their grace period is over
+	assert.False(t, isPurgeDue(org, now))
+	assert.False(t, isPurgeDue(org, now.AddDate(0, 0, 7).Add(-time.Second)))
+	assert.True(t, isPurgeDue(org, now.AddDate(0, 0, 7)))
+	assert.True(t, isPurgeDue(org, now.AddDate(0, 0, 8)))
+}
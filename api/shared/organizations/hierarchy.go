@@ -0,0 +1,133 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/constants"
+	"clerk/pkg/set"
+	"clerk/utils/database"
+)
+
+// Organizations can optionally reference a parent organization, forming an
+// org-chart style hierarchy. Depending on the organization settings of the
+// instance, members of an ancestor organization inherit their role in its
+// descendants, or can at least see their members, and verified domains of an
+// organization also enroll users to its descendants.
+
+// maxHierarchyDepth is the maximum number of levels of nested organizations,
+// including the root one.
+const maxHierarchyDepth = 10
+
+const paramParentOrganizationID = "parent_organization_id"
+
+// ValidateParent makes sure that the organization with parentID can be the
+// parent of the given organization. The parent must belong to the same
+// instance, the organization can't be one of the ancestors of the parent, and
+// the hierarchy can't get deeper than maxHierarchyDepth.
+func (s *Service) ValidateParent(ctx context.Context, exec database.Executor, org *model.Organization, parentID string) apierror.Error {
+	parent, err := s.organizationsRepo.QueryByIDAndInstance(ctx, exec, parentID, org.InstanceID)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	if parent == nil {
+		return apierror.OrganizationParentNotFound(paramParentOrganizationID)
+	}
+
+	ancestors, err := s.Ancestors(ctx, exec, parent)
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	return validateAncestry(org.ID, append([]*model.Organization{parent}, ancestors...))
+}
+
+// validateAncestry checks the would-be ancestors of the organization with
+// the given id, closest first.
+func validateAncestry(organizationID string, ancestors []*model.Organization) apierror.Error {
+	for _, ancestor := range ancestors {
+		if ancestor.ID == organizationID {
+			return apierror.OrganizationHierarchyCycle(paramParentOrganizationID)
+		}
+	}
+	if len(ancestors)+1 > maxHierarchyDepth {
+		return apierror.OrganizationHierarchyTooDeep(paramParentOrganizationID, maxHierarchyDepth)
+	}
+	return nil
+}
+
+// Ancestors returns the ancestors of the organization, starting with its
+// parent. The walk stops after maxHierarchyDepth levels, so that it
+// terminates even if the hierarchy somehow contains a cycle.
+func (s *Service) Ancestors(ctx context.Context, exec database.Executor, org *model.Organization) ([]*model.Organization, error) {
+	var ancestors []*model.Organization
+	visited := set.New(org.ID)
+	for parentID := org.ParentOrganizationID; parentID.Valid && len(ancestors) < maxHierarchyDepth; {
+		if visited.Contains(parentID.String) {
+			break
+		}
+		parent, err := s.organizationsRepo.QueryByIDAndInstance(ctx, exec, parentID.String, org.InstanceID)
+		if err != nil {
+			return nil, fmt.Errorf("organizations/ancestors: fetching parent %s of organization %s: %w", parentID.String, org.ID, err)
+		}
+		if parent == nil {
+			break
+		}
+		ancestors = append(ancestors, parent)
+		visited.Insert(parent.ID)
+		parentID = parent.ParentOrganizationID
+	}
+	return ancestors, nil
+}
+
+// hasInheritedAccess reports whether the user, who isn't a member of the
+// organization, is granted any of the permissions through their membership in
+// the closest of its ancestors they're a member of. Whether this is allowed
+// depends on the organization settings of the instance.
+func (s *Service) hasInheritedAccess(ctx context.Context, exec database.Executor, organizationID, userID string, permissions ...string) (bool, error) {
+	org, err := s.organizationsRepo.FindByID(ctx, exec, organizationID)
+	if err != nil {
+		return false, err
+	}
+	if !org.ParentOrganizationID.Valid {
+		return false, nil
+	}
+
+	authConfig, err := s.authConfigRepo.FindByInstanceActiveAuthConfigID(ctx, exec, org.InstanceID)
+	if err != nil {
+		return false, err
+	}
+	hierarchySettings := authConfig.OrganizationSettings.Hierarchy
+	if !hierarchySettings.InheritRoles && hierarchySettings.MembershipVisibility != constants.OrganizationMembershipVisibilityHierarchy {
+		return false, nil
+	}
+
+	ancestors, err := s.Ancestors(ctx, exec, org)
+	if err != nil {
+		return false, err
+	}
+	for _, ancestor := range ancestors {
+		member, err := s.organizationMembershipsRepo.QueryByOrganizationAndUserWithPermissions(ctx, exec, ancestor.ID, userID)
+		if err != nil {
+			return false, err
+		}
+		if member == nil {
+			continue
+		}
+
+		memberPermissions := set.New(member.PermissionKeys...)
+		for _, permission := range permissions {
+			if !memberPermissions.Contains(permission) {
+				continue
+			}
+			// Without role inheritance, members of ancestors can only see
+			// the members of descendants.
+			if hierarchySettings.InheritRoles || permission == constants.PermissionMembersRead {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,42 @@
+package organizations
+
+import (
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAncestry(t *testing.T) {
+	t.Parallel()
+
+	org := func(id string) *model.Organization {
+		return &model.Organization{Organization: &sqbmodel.Organization{ID: id}}
+	}
+
+	assert.Nil(t, validateAncestry("org_1", []*model.Organization{org("org_2"), org("org_3")}))
+
+	// an organization can't be its own parent
+	apiErr := validateAncestry("org_1", []*model.Organization{org("org_1")})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.OrganizationHierarchyCycleCode, apiErr.ErrorCode())
+
+	// nor the parent of one of its ancestors
+	apiErr = validateAncestry("org_1", []*model.Organization{org("org_2"), org("org_3"), org("org_1")})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.OrganizationHierarchyCycleCode, apiErr.ErrorCode())
+
+	ancestors := make([]*model.Organization, maxHierarchyDepth-1)
+	for i := range ancestors {
+		ancestors[i] = org("org_ancestor")
+	}
+	assert.Nil(t, validateAncestry("org_1", ancestors))
+
+	apiErr = validateAncestry("org_1", append(ancestors, org("org_root")))
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.OrganizationHierarchyTooDeepCode, apiErr.ErrorCode())
+}
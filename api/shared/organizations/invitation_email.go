@@ -0,0 +1,46 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/organizationsettings"
+	"clerk/utils/database"
+)
+
+// EnsureVerifiedInvitationEmail makes sure that the user owns a verified
+// email address matching the one the invitation was sent to, in case the
+// organization settings of the instance require it.
+func (s *Service) EnsureVerifiedInvitationEmail(
+	ctx context.Context,
+	exec database.Executor,
+	orgSettings organizationsettings.OrganizationSettings,
+	invitation *model.OrganizationInvitation,
+	userID, instanceID string,
+) error {
+	if !orgSettings.Invitations.RequireVerifiedEmail {
+		return nil
+	}
+
+	identification, err := s.identificationsRepo.QueryByInstanceAndIdentifierAndUser(ctx, exec, instanceID, invitation.EmailAddress, userID)
+	if err != nil {
+		return fmt.Errorf("organizations/ensureVerifiedInvitationEmail: retrieving identification %s of user %s: %w",
+			invitation.EmailAddress, userID, err)
+	}
+	if apiErr := checkVerifiedInvitationEmail(invitation, identification); apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
+// checkVerifiedInvitationEmail checks that the identification of the user
+// which matches the email address of the invitation, if any, is a verified
+// email address.
+func checkVerifiedInvitationEmail(invitation *model.OrganizationInvitation, identification *model.Identification) apierror.Error {
+	if identification == nil || !identification.IsEmailAddress() || !identification.IsVerified() {
+		return apierror.OrganizationInvitationEmailNotVerified(invitation.EmailAddress)
+	}
+	return nil
+}
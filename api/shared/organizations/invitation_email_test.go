@@ -0,0 +1,47 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/organizationsettings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureVerifiedInvitationEmail_NotRequired(t *testing.T) {
+	t.Parallel()
+
+	// without the setting, invitations are accepted without looking up the
+	// identifications of the user at all
+	service := &Service{}
+	invitation := &model.OrganizationInvitation{OrganizationInvitation: &sqbmodel.OrganizationInvitation{EmailAddress: "jane@example.com"}}
+	assert.NoError(t, service.EnsureVerifiedInvitationEmail(context.Background(), nil, organizationsettings.OrganizationSettings{}, invitation, "user_1", "ins_1"))
+}
+
+func TestCheckVerifiedInvitationEmail(t *testing.T) {
+	t.Parallel()
+
+	invitation := &model.OrganizationInvitation{OrganizationInvitation: &sqbmodel.OrganizationInvitation{EmailAddress: "jane@example.com"}}
+	identification := func(identType, status string) *model.Identification {
+		return &model.Identification{Identification: &sqbmodel.Identification{Type: identType, Status: status}}
+	}
+
+	assert.Nil(t, checkVerifiedInvitationEmail(invitation, identification(constants.ITEmailAddress, constants.ISVerified)))
+
+	for name, ident := range map[string]*model.Identification{
+		"no matching identification": nil,
+		"unverified email address":   identification(constants.ITEmailAddress, constants.ISNotSet),
+		"reserved email address":     identification(constants.ITEmailAddress, constants.ISReserved),
+		"other identification type":  identification(constants.ITPhoneNumber, constants.ISVerified),
+	} {
+		apiErr := checkVerifiedInvitationEmail(invitation, ident)
+		require.NotNil(t, apiErr, name)
+		assert.Equal(t, apierror.OrganizationInvitationEmailNotVerifiedCode, apiErr.ErrorCode(), name)
+	}
+}
@@ -0,0 +1,139 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/organizationsettings"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// MaxInvitationExpiresInDays is the longest an organization invitation can
+// stay pending before it expires.
+const MaxInvitationExpiresInDays = 365
+
+// invitationExpiresAt returns when an invitation which is sent at the given
+// time expires. Invitations never expire unless the instance has set how
+// long they last.
+func invitationExpiresAt(orgSettings organizationsettings.OrganizationSettings, sentAt time.Time) null.Time {
+	expiresInDays := orgSettings.Invitations.ExpiresInDays
+	if expiresInDays == nil || *expiresInDays <= 0 {
+		return null.Time{}
+	}
+	return null.TimeFrom(sentAt.Add(time.Duration(*expiresInDays) * 24 * time.Hour))
+}
+
+// IsInvitationExpired reports whether the invitation can no longer be
+// accepted because it expired. Pending invitations are expired as soon as
+// their expiry has passed, even if they haven't been swept yet.
+func IsInvitationExpired(invitation *model.OrganizationInvitation, now time.Time) bool {
+	if invitation.Status == constants.StatusExpired {
+		return true
+	}
+	return invitation.IsPending() && invitation.ExpiresAt.Valid && !now.Before(invitation.ExpiresAt.Time)
+}
+
+type ExtendInvitationParams struct {
+	OrganizationID string
+	InvitationID   string
+	// ExpiresInDays is counted from now. The instance setting is used when
+	// it's omitted.
+	ExpiresInDays *int
+}
+
+// ExtendInvitation pushes back the expiry of a pending invitation. The
+// invitation can be extended even if its expiry has already passed, as long
+// as it hasn't been swept yet.
+func (s *Service) ExtendInvitation(
+	ctx context.Context,
+	exec database.Executor,
+	params ExtendInvitationParams,
+	orgSettings organizationsettings.OrganizationSettings,
+) (*model.OrganizationInvitationSerializable, apierror.Error) {
+	expiresInDays := params.ExpiresInDays
+	if expiresInDays == nil {
+		expiresInDays = orgSettings.Invitations.ExpiresInDays
+	}
+	if expiresInDays == nil || *expiresInDays <= 0 {
+		return nil, apierror.FormMissingParameter("expires_in_days")
+	}
+
+	invitation, err := s.organizationInvitationsRepo.QueryByIDAndOrganizationID(ctx, exec, params.InvitationID, params.OrganizationID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	} else if invitation == nil || !invitation.IsPending() {
+		return nil, apierror.OrganizationInvitationNotPending()
+	}
+
+	invitation.ExpiresAt = null.TimeFrom(s.clock.Now().UTC().Add(time.Duration(*expiresInDays) * 24 * time.Hour))
+	err = s.organizationInvitationsRepo.Update(ctx, exec, invitation, sqbmodel.OrganizationInvitationColumns.ExpiresAt)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	invitationSerializable, err := s.convertOrganizationInvitation(ctx, exec, invitation)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return invitationSerializable, nil
+}
+
+// ExpireInvitations moves up to limit pending invitations whose expiry has
+// passed to the expired status, and triggers an
+// organizationInvitation.expired event for each one. It returns the number
+// of invitations which expired. It's invoked by the
+// expire_organization_invitations job.
+func (s *Service) ExpireInvitations(ctx context.Context, limit int) (int, error) {
+	var expired int
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		invitations, err := s.organizationInvitationsRepo.FindAllPendingExpiredBeforeForUpdate(ctx, tx, s.clock.Now().UTC(), limit)
+		if err != nil {
+			return true, err
+		}
+
+		instances := make(map[string]*model.Instance)
+		for _, invitation := range invitations {
+			instance, ok := instances[invitation.InstanceID]
+			if !ok {
+				instance, err = s.instanceRepo.FindByID(ctx, tx, invitation.InstanceID)
+				if err != nil {
+					return true, fmt.Errorf("fetching instance %s: %w", invitation.InstanceID, err)
+				}
+				instances[invitation.InstanceID] = instance
+			}
+
+			invitation.Status = constants.StatusExpired
+			if err := s.organizationInvitationsRepo.UpdateStatus(ctx, tx, invitation); err != nil {
+				return true, fmt.Errorf("changing status of invitation %s to %s: %w", invitation.ID, constants.StatusExpired, err)
+			}
+
+			invitationSerializable, err := s.convertOrganizationInvitation(ctx, tx, invitation)
+			if err != nil {
+				return true, err
+			}
+			err = s.eventsService.OrganizationInvitationExpired(ctx, tx, instance, serialize.OrganizationInvitationBAPI(invitationSerializable))
+			if err != nil {
+				return true, fmt.Errorf("sending organization invitation expired event for %s: %w", invitation.ID, err)
+			}
+		}
+		expired = len(invitations)
+		return false, nil
+	})
+	if txErr != nil {
+		return 0, fmt.Errorf("organizations/expireInvitations: %w", txErr)
+	}
+
+	if expired > 0 {
+		log.Info(ctx, "organizations: %d invitations expired", expired)
+	}
+	return expired, nil
+}
@@ -0,0 +1,46 @@
+package organizations
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/organizationsettings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestInvitationExpiresAt(t *testing.T) {
+	t.Parallel()
+
+	sentAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	settings := organizationsettings.OrganizationSettings{}
+	assert.False(t, invitationExpiresAt(settings, sentAt).Valid)
+
+	expiresInDays := 7
+	settings.Invitations.ExpiresInDays = &expiresInDays
+	assert.Equal(t, null.TimeFrom(sentAt.AddDate(0, 0, 7)), invitationExpiresAt(settings, sentAt))
+}
+
+func TestIsInvitationExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	invitation := func(status string, expiresAt null.Time) *model.OrganizationInvitation {
+		return &model.OrganizationInvitation{OrganizationInvitation: &sqbmodel.OrganizationInvitation{
+			Status:    status,
+			ExpiresAt: expiresAt,
+		}}
+	}
+
+	assert.False(t, IsInvitationExpired(invitation(constants.StatusPending, null.Time{}), now))
+	assert.False(t, IsInvitationExpired(invitation(constants.StatusPending, null.TimeFrom(now.Add(time.Hour))), now))
+	// pending invitations expire before they're swept
+	assert.True(t, IsInvitationExpired(invitation(constants.StatusPending, null.TimeFrom(now)), now))
+	assert.True(t, IsInvitationExpired(invitation(constants.StatusExpired, null.TimeFrom(now.Add(-time.Hour))), now))
+	// accepted invitations stay accepted
+	assert.False(t, IsInvitationExpired(invitation(constants.StatusAccepted, null.TimeFrom(now.Add(-time.Hour))), now))
+}
@@ -0,0 +1,75 @@
+package organizations
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/pkg/organizationsettings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureMembersOnlyAccess_Disabled(t *testing.T) {
+	t.Parallel()
+
+	// without the setting, sessions are allowed without looking up the
+	// memberships of the user at all
+	service := &Service{}
+	settings := organizationsettings.OrganizationSettings{Enabled: true}
+	assert.NoError(t, service.EnsureMembersOnlyAccess(context.Background(), nil, settings, "user_1"))
+
+	// the setting has no effect while organizations are disabled
+	settings = organizationsettings.OrganizationSettings{}
+	settings.MembersOnly.Enabled = true
+	assert.NoError(t, service.EnsureMembersOnlyAccess(context.Background(), nil, settings, "user_1"))
+}
+
+func TestCheckMembersOnlyAccess(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                   string
+		organizationIDs        []string
+		allowedOrganizationIDs []string
+		allowed                bool
+	}{
+		{
+			name:            "member of any organization",
+			organizationIDs: []string{"org_1"},
+			allowed:         true,
+		},
+		{
+			name:                   "member of an allowed organization",
+			organizationIDs:        []string{"org_1", "org_2"},
+			allowedOrganizationIDs: []string{"org_2", "org_3"},
+			allowed:                true,
+		},
+		{
+			name:                   "member of other organizations",
+			organizationIDs:        []string{"org_1"},
+			allowedOrganizationIDs: []string{"org_2"},
+		},
+		{
+			name: "not a member",
+		},
+		{
+			name:                   "not a member with allowed organizations",
+			allowedOrganizationIDs: []string{"org_2"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiErr := checkMembersOnlyAccess(tt.organizationIDs, tt.allowedOrganizationIDs)
+			if tt.allowed {
+				assert.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			assert.Equal(t, apierror.OrganizationMembershipRequiredCode, apiErr.ErrorCode())
+		})
+	}
+}
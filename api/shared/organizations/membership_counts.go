@@ -0,0 +1,141 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/model"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+)
+
+// MembershipCounts are the number of members and pending invitations of a
+// set of organizations, counted all at once.
+type MembershipCounts struct {
+	members            map[string]int64
+	pendingInvitations map[string]int64
+}
+
+// of returns the counts of the given organization. Organizations without
+// members or pending invitations have no rows in the grouped counts, so
+// they're counted as zero.
+func (c *MembershipCounts) of(organizationID string) (membersCount, pendingInvitationsCount *int) {
+	members := int(c.members[organizationID])
+	pendingInvitations := int(c.pendingInvitations[organizationID])
+	return &members, &pendingInvitations
+}
+
+// CountMemberships counts the members and pending invitations of the given
+// organizations with two queries, instead of two per membership.
+func (s *Service) CountMemberships(ctx context.Context, exec database.Executor, organizationIDs ...string) (*MembershipCounts, error) {
+	members, err := s.organizationMembershipsRepo.CountGroupedByOrganization(ctx, exec, organizationIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("organizations/countMemberships: cannot count memberships of organizations %v: %w", organizationIDs, err)
+	}
+	pendingInvitations, err := s.organizationInvitationsRepo.CountPendingNonOrgDomainGroupedByOrganization(ctx, exec, organizationIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("organizations/countMemberships: cannot count pending invitations of organizations %v: %w", organizationIDs, err)
+	}
+	return &MembershipCounts{members: members, pendingInvitations: pendingInvitations}, nil
+}
+
+// ConvertOption customizes how memberships are converted to serializables.
+type ConvertOption func(*convertOptions)
+
+type convertOptions struct {
+	skipCounts   bool
+	counts       *MembershipCounts
+	userSettings map[string]*usersettings.UserSettings
+}
+
+// WithoutCounts leaves the member and pending invitation counts of the
+// organization out, for callers which don't serialize them.
+func WithoutCounts() ConvertOption {
+	return func(opts *convertOptions) {
+		opts.skipCounts = true
+	}
+}
+
+// WithCounts takes the member and pending invitation counts of the
+// organization from the ones precomputed with CountMemberships.
+func WithCounts(counts *MembershipCounts) ConvertOption {
+	return func(opts *convertOptions) {
+		opts.counts = counts
+	}
+}
+
+// WithUserSettings takes the user settings of the instance from the ones
+// already loaded, instead of loading them for each membership.
+func WithUserSettings(instanceID string, userSettings *usersettings.UserSettings) ConvertOption {
+	return func(opts *convertOptions) {
+		if opts.userSettings == nil {
+			opts.userSettings = make(map[string]*usersettings.UserSettings)
+		}
+		opts.userSettings[instanceID] = userSettings
+	}
+}
+
+func newConvertOptions(opts []ConvertOption) convertOptions {
+	var options convertOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// ConvertAllToSerializable converts a list of memberships. The counts of
+// their organizations are computed in bulk, unless WithoutCounts is given,
+// and the user settings of their instances are loaded once.
+func (s *Service) ConvertAllToSerializable(
+	ctx context.Context,
+	exec database.Executor,
+	orgMemberships []*model.OrganizationMembershipWithDeps,
+	opts ...ConvertOption,
+) ([]*model.OrganizationMembershipSerializable, error) {
+	options := newConvertOptions(opts)
+	if !options.skipCounts && options.counts == nil && len(orgMemberships) > 0 {
+		organizationIDs := make([]string, 0, len(orgMemberships))
+		seen := make(map[string]bool, len(orgMemberships))
+		for _, orgMembership := range orgMemberships {
+			if !seen[orgMembership.OrganizationID] {
+				seen[orgMembership.OrganizationID] = true
+				organizationIDs = append(organizationIDs, orgMembership.OrganizationID)
+			}
+		}
+
+		counts, err := s.CountMemberships(ctx, exec, organizationIDs...)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithCounts(counts))
+	}
+
+	for _, orgMembership := range orgMemberships {
+		// only memberships with a user are identified
+		if orgMembership.User.User == nil {
+			continue
+		}
+		instanceID := orgMembership.Organization.InstanceID
+		if _, loaded := options.userSettings[instanceID]; loaded {
+			continue
+		}
+
+		userSettings, err := s.instanceUserSettings(ctx, exec, instanceID)
+		if err != nil {
+			return nil, err
+		}
+		opt := WithUserSettings(instanceID, userSettings)
+		opt(&options)
+		opts = append(opts, opt)
+	}
+
+	serializables := make([]*model.OrganizationMembershipSerializable, len(orgMemberships))
+	for i, orgMembership := range orgMemberships {
+		var err error
+		serializables[i], err = s.ConvertToSerializable(ctx, exec, orgMembership, opts...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return serializables, nil
+}
@@ -0,0 +1,41 @@
+package organizations
+
+import (
+	"testing"
+
+	usersettings "clerk/pkg/usersettings/clerk"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMembershipCountsOf(t *testing.T) {
+	t.Parallel()
+
+	counts := &MembershipCounts{
+		members:            map[string]int64{"org_1": 3},
+		pendingInvitations: map[string]int64{"org_2": 1},
+	}
+
+	members, pendingInvitations := counts.of("org_1")
+	assert.Equal(t, 3, *members)
+	assert.Equal(t, 0, *pendingInvitations)
+
+	members, pendingInvitations = counts.of("org_2")
+	assert.Equal(t, 0, *members)
+	assert.Equal(t, 1, *pendingInvitations)
+}
+
+func TestNewConvertOptions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, convertOptions{}, newConvertOptions(nil))
+	assert.True(t, newConvertOptions([]ConvertOption{WithoutCounts()}).skipCounts)
+
+	counts := &MembershipCounts{}
+	assert.Same(t, counts, newConvertOptions([]ConvertOption{WithCounts(counts)}).counts)
+
+	userSettings := &usersettings.UserSettings{}
+	options := newConvertOptions([]ConvertOption{WithUserSettings("ins_1", userSettings), WithoutCounts()})
+	assert.Same(t, userSettings, options.userSettings["ins_1"])
+	assert.NotContains(t, options.userSettings, "ins_2")
+}
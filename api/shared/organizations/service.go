@@ -60,6 +60,7 @@ var systemPermissions = []struct {
 	{Name: "Manage members", Key: constants.PermissionMembersManage, Description: "Permission to manage the members of an organization."},
 	{Name: "Read domains", Key: constants.PermissionDomainsRead, Description: "Permission to read the domains of an organization."},
 	{Name: "Manage domains", Key: constants.PermissionDomainsManage, Description: "Permission to manage the domains of an organization."},
+	{Name: "Manage SAML connections", Key: constants.PermissionSAMLConnectionsManage, Description: "Permission to manage the SAML connections of an organization."},
 }
 
 var rolePermissionAssociation = map[string][]string{
@@ -81,10 +82,14 @@ type Service struct {
 	userProfileService  *user_profile.Service
 
 	// repositories
+	applicationRepo             *repository.Applications
+	authConfigRepo              *repository.AuthConfig
 	billingPlanRepo             *repository.BillingPlans
 	billingSubscriptionRepo     *repository.BillingSubscriptions
 	identificationsRepo         *repository.Identification
+	instanceRepo                *repository.Instances
 	organizationsRepo           *repository.Organization
+	organizationDomainsRepo     *repository.OrganizationDomain
 	organizationInvitationsRepo *repository.OrganizationInvitation
 	organizationMembershipsRepo *repository.OrganizationMembership
 	permissionRepo              *repository.Permission
@@ -104,20 +109,24 @@ func NewService(deps clerk.Deps) *Service {
 		applicationDeleter:          applications.NewDeleter(deps),
 		comms:                       comms.NewService(deps),
 		eventsService:               events.NewService(deps),
-		restrictionsService:         restrictions.NewService(deps.EmailQualityChecker()),
+		restrictionsService:         restrictions.NewService(deps),
 		userProfileService:          user_profile.NewService(deps.Clock()),
-		identificationsRepo:         repository.NewIdentification(),
-		organizationsRepo:           repository.NewOrganization(),
-		organizationInvitationsRepo: repository.NewOrganizationInvitation(),
-		organizationMembershipsRepo: repository.NewOrganizationMembership(),
-		permissionRepo:              repository.NewPermission(),
-		roleRepo:                    repository.NewRole(),
-		rolePermissionRepo:          repository.NewRolePermission(),
-		subscriptionPlanRepo:        repository.NewSubscriptionPlans(),
-		userRepo:                    repository.NewUsers(),
+		applicationRepo:             deps.Repositories().Applications,
+		authConfigRepo:              deps.Repositories().AuthConfig,
+		identificationsRepo:         deps.Repositories().Identification,
+		instanceRepo:                deps.Repositories().Instances,
+		organizationsRepo:           deps.Repositories().Organization,
+		organizationDomainsRepo:     deps.Repositories().OrganizationDomain,
+		organizationInvitationsRepo: deps.Repositories().OrganizationInvitation,
+		organizationMembershipsRepo: deps.Repositories().OrganizationMembership,
+		permissionRepo:              deps.Repositories().Permission,
+		roleRepo:                    deps.Repositories().Role,
+		rolePermissionRepo:          deps.Repositories().RolePermission,
+		subscriptionPlanRepo:        deps.Repositories().SubscriptionPlans,
+		userRepo:                    deps.Repositories().Users,
 		clientDataService:           client_data.NewService(deps),
-		billingPlanRepo:             repository.NewBillingPlans(),
-		billingSubscriptionRepo:     repository.NewBillingSubscriptions(),
+		billingPlanRepo:             deps.Repositories().BillingPlans,
+		billingSubscriptionRepo:     deps.Repositories().BillingSubscriptions,
 	}
 }
 
@@ -150,6 +159,13 @@ func (s *Service) Create(ctx context.Context, tx database.Tx, params CreateParam
 	params.Organization.AdminDeleteEnabled = params.OrganizationSettings.Actions.AdminDelete
 	params.Organization.Name = strings.TrimSpace(params.Organization.Name)
 
+	if params.Organization.ParentOrganizationID.Valid {
+		apiErr = s.ValidateParent(ctx, tx, params.Organization, params.Organization.ParentOrganizationID.String)
+		if apiErr != nil {
+			return apiErr
+		}
+	}
+
 	return s.createOrg(ctx, tx, createOrgParams{
 		org:            params.Organization,
 		instance:       params.Instance,
@@ -175,34 +191,26 @@ type DeleteParams struct {
 	RequestingUserID *string
 }
 
-// Delete deletes the organization with the given organization id,
-// enqueues a background job to delete its logo if present and sends
+// Delete deletes the organization with the given organization id and sends
 // the appropriate webhook event message.
+//
+// If the instance has a deletion grace period, the organization is only
+// marked as pending deletion and can be restored until it's purged.
+// Otherwise it's purged right away.
 func (s *Service) Delete(ctx context.Context, tx database.Tx, params DeleteParams) (*serialize.DeletedObjectResponse, error) {
 	org := params.Organization
 
-	if params.Env.Application.Type == string(constants.RTSystem) {
-		// We schedule the soft-delete instead of doing it in place, because soft-deletion also
-		// involves Stripe cancellation, which is an action that cannot be reverted, if the
-		// transaction fails.
-		err := s.applicationDeleter.ScheduleSoftDeleteOfOwnedApplications(ctx, tx, org.ID, constants.OrganizationResource)
-		if err != nil {
-			return nil, err
+	gracePeriodDays := params.Env.AuthConfig.OrganizationSettings.Deletion.GracePeriodDays
+	if gracePeriodDays > 0 {
+		markPendingDeletion(org, s.clock.Now().UTC(), gracePeriodDays)
+		if err := s.organizationsRepo.UpdateDeletedAt(ctx, tx, org); err != nil {
+			return nil, fmt.Errorf("organizations/delete: mark organization %s as pending deletion: %w", org.ID, err)
 		}
-	}
-
-	if err := s.organizationsRepo.DeleteByID(ctx, tx, org.ID); err != nil {
+	} else if err := s.purge(ctx, tx, params.Env.Application, org); err != nil {
 		return nil, err
 	}
 
-	if org.LogoPublicURL.Valid {
-		err := s.EnqueueCleanupImageJob(ctx, tx, org.LogoPublicURL.String)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	response := serialize.DeletedObject(org.ID, serialize.ObjectOrganization)
+	response := serialize.DeletedOrganization(org)
 
 	err := s.eventsService.OrganizationDeleted(ctx, tx, params.Env.Instance, response, params.RequestingUserID)
 	if err != nil {
@@ -211,6 +219,28 @@ func (s *Service) Delete(ctx context.Context, tx database.Tx, params DeleteParam
 	return response, nil
 }
 
+// CountDeletedWithOrganization returns the number of resources which are
+// deleted along with the organization, per resource type.
+func (s *Service) CountDeletedWithOrganization(ctx context.Context, exec database.Executor, organizationID string) (map[string]int64, error) {
+	memberships, err := s.organizationMembershipsRepo.CountByOrganization(ctx, exec, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := s.organizationInvitationsRepo.CountPendingNonOrgDomainByOrganization(ctx, exec, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	domains, err := s.organizationDomainsRepo.CountByOrganization(ctx, exec, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]int64{
+		"organization_memberships": memberships,
+		"organization_invitations": invitations,
+		"organization_domains":     domains,
+	}, nil
+}
+
 // orgsQuotaAvailableForInstance checks whether the instance has reached the maximum organization
 // creation quota. This hard limit only applied for non-production instances that are on a free
 // plan.
@@ -299,6 +329,7 @@ type UpdateParams struct {
 	RequestingUserID      string
 	PublicMetadata        *json.RawMessage    `json:"public_metadata" form:"public_metadata"`
 	PrivateMetadata       *json.RawMessage    `json:"private_metadata" form:"private_metadata"`
+	ParentOrganizationID  *string             `json:"parent_organization_id" form:"parent_organization_id"`
 	Instance              *model.Instance     `json:"-"`
 	Subscription          *model.Subscription `json:"-"`
 }
@@ -357,6 +388,17 @@ func (s *Service) Update(ctx context.Context, tx database.Tx, params UpdateParam
 	if params.PublicMetadata != nil {
 		organization.PublicMetadata = types.JSON(*params.PublicMetadata)
 	}
+	if params.ParentOrganizationID != nil {
+		if *params.ParentOrganizationID == "" {
+			organization.ParentOrganizationID = null.String{}
+		} else {
+			apiErr := s.ValidateParent(ctx, tx, organization, *params.ParentOrganizationID)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			organization.ParentOrganizationID = null.StringFrom(*params.ParentOrganizationID)
+		}
+	}
 
 	if !params.Instance.HasAccessToAllFeatures() {
 		plans, err := s.subscriptionPlanRepo.FindAllBySubscription(ctx, tx, params.Subscription.ID)
@@ -643,6 +685,14 @@ type ListMembershipsParams struct {
 	OrganizationID *string
 	UserID         *string
 	Roles          []string
+	// Keyset switches to keyset pagination, which guarantees that no
+	// memberships are skipped or repeated across pages, even when members
+	// are added or removed in between. The offset pagination options are
+	// ignored when it's set.
+	Keyset *pagination.KeysetParams
+	// SkipCounts leaves the member and pending invitation counts of the
+	// organizations out, for callers which don't serialize them.
+	SkipCounts bool
 }
 
 func (params ListMembershipsParams) validate() apierror.Error {
@@ -668,18 +718,25 @@ func (s *Service) ListMemberships(
 	}
 
 	// Retrieve all members
-	orgMemberships, err := s.organizationMembershipsRepo.FindAllByUserOrganizationAndRole(ctx, exec, params.UserID, params.OrganizationID, params.Roles, paginationParams)
+	var orgMemberships []*model.OrganizationMembershipWithDeps
+	var err error
+	if params.Keyset != nil {
+		orgMemberships, err = s.organizationMembershipsRepo.FindAllByUserOrganizationAndRoleAfter(ctx, exec, params.UserID, params.OrganizationID, params.Roles, *params.Keyset)
+	} else {
+		orgMemberships, err = s.organizationMembershipsRepo.FindAllByUserOrganizationAndRole(ctx, exec, params.UserID, params.OrganizationID, params.Roles, paginationParams)
+	}
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
 	// Serialize results
-	res := make([]*model.OrganizationMembershipSerializable, len(orgMemberships))
-	for i, orgMembership := range orgMemberships {
-		res[i], err = s.ConvertToSerializable(ctx, exec, orgMembership)
-		if err != nil {
-			return nil, apierror.Unexpected(err)
-		}
+	var opts []ConvertOption
+	if params.SkipCounts {
+		opts = append(opts, WithoutCounts())
+	}
+	res, err := s.ConvertAllToSerializable(ctx, exec, orgMemberships, opts...)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
 	}
 	return res, nil
 }
@@ -709,11 +766,17 @@ func (s *Service) UpdateMembership(ctx context.Context, tx database.Tx, params U
 		return nil, apierror.OrganizationRoleNotFound(param.Role.Name)
 	}
 
+	var previousRole *model.Role
 	if orgMembership.RoleID != role.ID {
 		// Check at least one other member has the required system permissions
 		if err := s.EnsureAtLeastOneWithMinimumSystemPermissions(ctx, tx, orgMembership, params.UserID); err != nil {
 			return nil, err
 		}
+
+		previousRole, err = s.roleRepo.QueryByIDAndInstance(ctx, tx, orgMembership.RoleID, params.Instance.ID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
 	}
 
 	orgMembership.RoleID = role.ID
@@ -741,9 +804,52 @@ func (s *Service) UpdateMembership(ctx context.Context, tx database.Tx, params U
 		return nil, apierror.Unexpected(err)
 	}
 
+	// The previous role might have been deleted in the meantime, in which
+	// case there's nothing meaningful to report as the previous role.
+	if previousRole != nil {
+		err = s.sendMembershipRoleChangedEvent(ctx, tx, params, serializable, previousRole, role)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
 	return serializable, nil
 }
 
+// sendMembershipRoleChangedEvent emits an organizationMembership.role_changed
+// event which, unlike organizationMembership.updated, contains both the
+// previous and the new role of the membership, as well as the user who
+// performed the change, if any.
+func (s *Service) sendMembershipRoleChangedEvent(
+	ctx context.Context,
+	tx database.Tx,
+	params UpdateMembershipParams,
+	membership *model.OrganizationMembershipSerializable,
+	previousRole, newRole *model.Role,
+) error {
+	previousPermissions, err := s.permissionRepo.FindAllByRole(ctx, tx, previousRole.ID)
+	if err != nil {
+		return fmt.Errorf("organizations/sendMembershipRoleChangedEvent: fetching permissions for role %s: %w", previousRole.ID, err)
+	}
+	newPermissions, err := s.permissionRepo.FindAllByRole(ctx, tx, newRole.ID)
+	if err != nil {
+		return fmt.Errorf("organizations/sendMembershipRoleChangedEvent: fetching permissions for role %s: %w", newRole.ID, err)
+	}
+
+	var actingUserID *string
+	if params.RequestingUserID != "" {
+		actingUserID = &params.RequestingUserID
+	}
+
+	payload := serialize.OrganizationMembershipRoleChanged(
+		serialize.OrganizationMembership(ctx, membership),
+		serialize.Role(previousRole, previousPermissions),
+		serialize.Role(newRole, newPermissions),
+		actingUserID,
+	)
+	return s.eventsService.OrganizationMembershipRoleChanged(ctx, tx, params.Instance, payload, params.OrganizationID, params.UserID)
+}
+
 func (s *Service) EnsureAtLeastOneWithMinimumSystemPermissions(
 	ctx context.Context,
 	exec database.Executor,
@@ -778,10 +884,11 @@ func (s *Service) EnsureMinimumSystemPermissions(permissions []*model.Permission
 }
 
 type AcceptInvitationParams struct {
-	InvitationID string
-	UserID       string
-	Instance     *model.Instance
-	Subscription *model.Subscription
+	InvitationID         string
+	UserID               string
+	Instance             *model.Instance
+	Subscription         *model.Subscription
+	OrganizationSettings organizationsettings.OrganizationSettings
 }
 
 func (s *Service) AcceptInvitation(ctx context.Context, tx database.Tx, params AcceptInvitationParams) (*model.OrganizationInvitationSerializable, error) {
@@ -791,6 +898,14 @@ func (s *Service) AcceptInvitation(ctx context.Context, tx database.Tx, params A
 			params.InvitationID, err)
 	}
 
+	if IsInvitationExpired(invitation, s.clock.Now().UTC()) {
+		return nil, apierror.OrganizationInvitationExpired()
+	}
+
+	if err := s.EnsureVerifiedInvitationEmail(ctx, tx, params.OrganizationSettings, invitation, params.UserID, params.Instance.ID); err != nil {
+		return nil, err
+	}
+
 	role, err := s.roleRepo.QueryByIDAndInstance(ctx, tx, invitation.RoleID.String, params.Instance.ID)
 	if err != nil {
 		return nil, fmt.Errorf("organizations/acceptInvitation: retrieving organization role with id %s: %w", invitation.RoleID.String, err)
@@ -862,6 +977,44 @@ func (s *Service) AcceptInvitation(ctx context.Context, tx database.Tx, params A
 	return invitationSerializable, nil
 }
 
+// EnsureMembersOnlyAccess makes sure that the user is allowed to have a
+// session, in case the instance only allows members of its organizations to
+// sign in. When a set of allowed organizations is configured, the user needs
+// to be a member of one of them, otherwise any membership will do.
+func (s *Service) EnsureMembersOnlyAccess(ctx context.Context, exec database.Executor, orgSettings organizationsettings.OrganizationSettings, userID string) error {
+	if !orgSettings.Enabled || !orgSettings.MembersOnly.Enabled {
+		return nil
+	}
+
+	memberships, err := s.organizationMembershipsRepo.FindAllByUser(ctx, exec, userID)
+	if err != nil {
+		return fmt.Errorf("organizations/ensureMembersOnlyAccess: retrieving memberships of user %s: %w", userID, err)
+	}
+
+	organizationIDs := make([]string, len(memberships))
+	for i, membership := range memberships {
+		organizationIDs[i] = membership.OrganizationID
+	}
+	if apiErr := checkMembersOnlyAccess(organizationIDs, orgSettings.MembersOnly.AllowedOrganizationIDs); apiErr != nil {
+		return apiErr
+	}
+	return nil
+}
+
+// checkMembersOnlyAccess checks that a user who is a member of the given
+// organizations is allowed to sign in, i.e. that any of them is among the
+// allowed organizations, or that there's at least one if any organization
+// is allowed.
+func checkMembersOnlyAccess(organizationIDs, allowedOrganizationIDs []string) apierror.Error {
+	allowed := set.New[string](allowedOrganizationIDs...)
+	for _, organizationID := range organizationIDs {
+		if allowed.IsEmpty() || allowed.Contains(organizationID) {
+			return nil
+		}
+	}
+	return apierror.OrganizationMembershipRequired()
+}
+
 // CreateInvitationParams contains everything you need to create a single organization invitation,
 // and create and send organization_invitation.created events and
 // the email to the invited user.
@@ -1026,6 +1179,7 @@ func (s *Service) CreateAndSendInvitations(
 					EmailAddress:   emailAddress,
 					OrganizationID: organizationID,
 					Status:         constants.StatusPending,
+					ExpiresAt:      invitationExpiresAt(env.AuthConfig.OrganizationSettings, s.clock.Now().UTC()),
 				},
 			}
 			newInvitationCreated = true
@@ -1064,32 +1218,8 @@ func (s *Service) CreateAndSendInvitations(
 			}
 		}
 
-		claims := ticket.Claims{
-			InstanceID:     invitation.InstanceID,
-			SourceType:     constants.OSTOrganizationInvitation,
-			SourceID:       invitation.ID,
-			OrganizationID: &organizationID,
-			RedirectURL:    p.RedirectURL,
-		}
-		accessToken, err := ticket.Generate(claims, env.Instance, s.clock)
-		if err != nil {
-			return nil, fmt.Errorf("generating access token for claims %+v: %w", claims, err)
-		}
-
-		fapiURL := env.Domain.FapiURL()
-		clerkJSVersion := clerkjs_version.FromContext(ctx)
-		actionLink, err := createInvitationLink(accessToken, fapiURL, clerkJSVersion)
-		if err != nil {
-			return nil, fmt.Errorf("creating invitation link for %s: %w", fapiURL, err)
-		}
-
-		if err := s.comms.SendOrganizationInvitationEmail(ctx, tx, env, comms.EmailOrganizationInvitation{
-			Organization: organization,
-			Invitation:   invitation,
-			InviterName:  p.InviterName,
-			ActionURL:    actionLink,
-		}); err != nil {
-			return nil, fmt.Errorf("orgInvitations/create: sending org invitation email to %s: %w", invitation.EmailAddress, err)
+		if err := s.sendInvitationEmail(ctx, tx, env, organization, invitation, p.InviterName, p.RedirectURL); err != nil {
+			return nil, fmt.Errorf("orgInvitations/create: %w", err)
 		}
 
 		invitations[i] = invitationSerializable
@@ -1097,6 +1227,47 @@ func (s *Service) CreateAndSendInvitations(
 	return invitations, nil
 }
 
+// sendInvitationEmail sends the email of a pending invitation, which links
+// to a newly generated invitation ticket.
+func (s *Service) sendInvitationEmail(
+	ctx context.Context,
+	exec database.Executor,
+	env *model.Env,
+	organization *model.Organization,
+	invitation *model.OrganizationInvitation,
+	inviterName string,
+	redirectURL *string,
+) error {
+	claims := ticket.Claims{
+		InstanceID:     invitation.InstanceID,
+		SourceType:     constants.OSTOrganizationInvitation,
+		SourceID:       invitation.ID,
+		OrganizationID: &invitation.OrganizationID,
+		RedirectURL:    redirectURL,
+	}
+	accessToken, err := ticket.Generate(claims, env.Instance, s.clock)
+	if err != nil {
+		return fmt.Errorf("generating access token for claims %+v: %w", claims, err)
+	}
+
+	fapiURL := env.Domain.FapiURL()
+	clerkJSVersion := clerkjs_version.FromContext(ctx)
+	actionLink, err := createInvitationLink(accessToken, fapiURL, clerkJSVersion)
+	if err != nil {
+		return fmt.Errorf("creating invitation link for %s: %w", fapiURL, err)
+	}
+
+	if err := s.comms.SendOrganizationInvitationEmail(ctx, exec, env, comms.EmailOrganizationInvitation{
+		Organization: organization,
+		Invitation:   invitation,
+		InviterName:  inviterName,
+		ActionURL:    actionLink,
+	}); err != nil {
+		return fmt.Errorf("sending org invitation email to %s: %w", invitation.EmailAddress, err)
+	}
+	return nil
+}
+
 func (s *Service) validateCreateInvitationParams(ctx context.Context, tx database.Tx, params CreateInvitationsParams, instanceID string) apierror.Error {
 	var errors apierror.Error
 	for _, p := range params {
@@ -1219,7 +1390,21 @@ func (s *Service) ListInvitations(ctx context.Context, exec database.Executor, i
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
+	return s.toInvitationsSerializable(ctx, exec, instanceID, invitations)
+}
+
+// ListInvitationsAfter is the same as ListInvitations, but uses keyset
+// pagination, so that no invitations are skipped or repeated across pages
+// when invitations are created or removed in between.
+func (s *Service) ListInvitationsAfter(ctx context.Context, exec database.Executor, instanceID, organizationID string, statuses []string, keyset pagination.KeysetParams) ([]*model.OrganizationInvitationSerializable, apierror.Error) {
+	invitations, err := s.organizationInvitationsRepo.FindAllNonOrgDomainByOrganizationAndStatusAfter(ctx, exec, organizationID, statuses, keyset)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return s.toInvitationsSerializable(ctx, exec, instanceID, invitations)
+}
 
+func (s *Service) toInvitationsSerializable(ctx context.Context, exec database.Executor, instanceID string, invitations []*model.OrganizationInvitation) ([]*model.OrganizationInvitationSerializable, apierror.Error) {
 	roleIDs := set.New[string]()
 	for _, invitation := range invitations {
 		if invitation.RoleID.Valid {
@@ -1349,10 +1534,131 @@ func (s *Service) RevokeInvitation(
 	return invitationSerializable, nil
 }
 
+type BulkInvitationsParams struct {
+	InvitationIDs    []string
+	OrganizationID   string
+	RequestingUserID string
+}
+
+// findPendingInvitations returns the given invitations of the organization,
+// after checking that the requesting user can manage them and that they're
+// all pending.
+func (s *Service) findPendingInvitations(ctx context.Context, exec database.Executor, params BulkInvitationsParams) ([]*model.OrganizationInvitation, apierror.Error) {
+	if len(params.InvitationIDs) == 0 {
+		return nil, apierror.FormMissingParameter("invitation_ids")
+	}
+	if len(params.InvitationIDs) > constants.MaxBulkSize {
+		return nil, apierror.BulkSizeExceeded()
+	}
+
+	if apiErr := s.EnsureHasAccess(ctx, exec, params.OrganizationID, constants.PermissionMembersManage, params.RequestingUserID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	invitationIDs := set.New(params.InvitationIDs...)
+	invitations, err := s.organizationInvitationsRepo.FindAllByOrganizationAndIDs(ctx, exec, params.OrganizationID, invitationIDs.Array())
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if len(invitations) != invitationIDs.Count() {
+		return nil, apierror.OrganizationInvitationNotPending()
+	}
+	for _, invitation := range invitations {
+		if !invitation.IsPending() {
+			return nil, apierror.OrganizationInvitationNotPending()
+		}
+	}
+	return invitations, nil
+}
+
+// RevokeInvitations revokes all the given invitations, or none of them if
+// any of them isn't pending, and triggers an organizationInvitation.revoked
+// event for each one.
+func (s *Service) RevokeInvitations(
+	ctx context.Context,
+	tx database.Tx,
+	params BulkInvitationsParams,
+	instance *model.Instance,
+) ([]*model.OrganizationInvitationSerializable, apierror.Error) {
+	invitations, apiErr := s.findPendingInvitations(ctx, tx, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	invitationsSerializable := make([]*model.OrganizationInvitationSerializable, len(invitations))
+	for i, invitation := range invitations {
+		invitation.Status = constants.StatusRevoked
+		if err := s.organizationInvitationsRepo.UpdateStatus(ctx, tx, invitation); err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+		invitationSerializable, err := s.convertOrganizationInvitation(ctx, tx, invitation)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+		err = s.eventsService.OrganizationInvitationRevoked(ctx, tx, instance, serialize.OrganizationInvitationBAPI(invitationSerializable), params.RequestingUserID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		invitationsSerializable[i] = invitationSerializable
+	}
+	return invitationsSerializable, nil
+}
+
+// ResendInvitations sends the emails of all the given invitations again,
+// or of none of them if any of them isn't pending, and triggers an
+// organizationInvitation.resent event for each one.
+func (s *Service) ResendInvitations(
+	ctx context.Context,
+	tx database.Tx,
+	params BulkInvitationsParams,
+	redirectURL *string,
+	env *model.Env,
+) ([]*model.OrganizationInvitationSerializable, apierror.Error) {
+	invitations, apiErr := s.findPendingInvitations(ctx, tx, params)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	organization, err := s.organizationsRepo.FindByID(ctx, tx, params.OrganizationID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	requestingUser, err := s.userRepo.FindByID(ctx, tx, params.RequestingUserID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	invitationsSerializable := make([]*model.OrganizationInvitationSerializable, len(invitations))
+	for i, invitation := range invitations {
+		if err := s.sendInvitationEmail(ctx, tx, env, organization, invitation, requestingUser.Name(), redirectURL); err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+		invitationSerializable, err := s.convertOrganizationInvitation(ctx, tx, invitation)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+
+		err = s.eventsService.OrganizationInvitationResent(ctx, tx, env.Instance, serialize.OrganizationInvitationBAPI(invitationSerializable), params.RequestingUserID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		invitationsSerializable[i] = invitationSerializable
+	}
+	return invitationsSerializable, nil
+}
+
+// ConvertToSerializable converts a single membership. The counts of its
+// organization are queried, unless they're precomputed with WithCounts or
+// left out with WithoutCounts. Lists of memberships should go through
+// ConvertAllToSerializable instead.
 func (s *Service) ConvertToSerializable(
 	ctx context.Context,
 	exec database.Executor,
 	orgMembership *model.OrganizationMembershipWithDeps,
+	opts ...ConvertOption,
 ) (*model.OrganizationMembershipSerializable, error) {
 	serializable := model.OrganizationMembershipSerializable{
 		OrganizationMembership: orgMembership.OrganizationMembership,
@@ -1361,25 +1667,25 @@ func (s *Service) ConvertToSerializable(
 		PermissionKeys:         orgMembership.PermissionKeys,
 	}
 
-	membersCount, err := s.organizationMembershipsRepo.CountByOrganization(ctx, exec, orgMembership.OrganizationID)
-	if err != nil {
-		return nil, fmt.Errorf("organizations/convertToSerializable: cannot get count of memberships for organization %s: %w",
-			orgMembership.OrganizationID, err)
-	}
-	serializable.MembersCount = int(membersCount)
-
-	pendingInvitationsCount, err := s.organizationInvitationsRepo.CountPendingNonOrgDomainByOrganization(ctx, exec, orgMembership.OrganizationID)
-	if err != nil {
-		return nil, fmt.Errorf("organizations/convertToSerializable: cannot get count of pending invitations for organization %s: %w",
-			orgMembership.OrganizationID, err)
+	options := newConvertOptions(opts)
+	switch {
+	case options.skipCounts:
+	case options.counts != nil:
+		serializable.MembersCount, serializable.PendingInvitationsCount = options.counts.of(orgMembership.OrganizationID)
+	default:
+		counts, err := s.CountMemberships(ctx, exec, orgMembership.OrganizationID)
+		if err != nil {
+			return nil, fmt.Errorf("organizations/convertToSerializable: %w", err)
+		}
+		serializable.MembersCount, serializable.PendingInvitationsCount = counts.of(orgMembership.OrganizationID)
 	}
-	serializable.PendingInvitationsCount = int(pendingInvitationsCount)
 
 	// if user doesn't exist, omit the user, image url, and identifier fields
 	if orgMembership.User.User == nil {
 		return &serializable, nil
 	}
 
+	var err error
 	serializable.User = orgMembership.User
 	serializable.ProfileImageURL, _ = s.userProfileService.GetProfileImageURL(&orgMembership.User)
 	serializable.ImageURL, err = s.userProfileService.GetImageURL(&orgMembership.User)
@@ -1388,39 +1694,19 @@ func (s *Service) ConvertToSerializable(
 			orgMembership.User.ID, err)
 	}
 
-	// Find and set the member's identification
-	var identificationID string
-	if orgMembership.User.PrimaryEmailAddressID.Valid {
-		identificationID = orgMembership.User.PrimaryEmailAddressID.String
-	} else if orgMembership.User.PrimaryPhoneNumberID.Valid {
-		identificationID = orgMembership.User.PrimaryPhoneNumberID.String
-	} else if orgMembership.User.PrimaryWeb3WalletID.Valid {
-		identificationID = orgMembership.User.PrimaryWeb3WalletID.String
-	}
-
-	var identification *model.Identification
-	if identificationID != "" {
-		identification, err = s.identificationsRepo.QueryByID(ctx, exec, identificationID)
-	} else {
-		identification, err = s.identificationsRepo.QueryByTypeAndUser(ctx, exec, constants.ITUsername, orgMembership.UserID)
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("organizations/convertToSerializable: cannot get identification with id %s: %w", identificationID, err)
-	}
-
-	if identification != nil && identification.TargetIdentificationID.Valid {
-		// this is an OAuth identification, so we need to find the connected identification which
-		// contains the actual identifier
-		ident, err := s.identificationsRepo.FindByID(ctx, exec, identification.TargetIdentificationID.String)
+	// Find and set the member's identification, in the order the instance
+	// prefers identifiers
+	userSettings, ok := options.userSettings[orgMembership.Organization.InstanceID]
+	if !ok {
+		userSettings, err = s.instanceUserSettings(ctx, exec, orgMembership.Organization.InstanceID)
 		if err != nil {
-			return nil, fmt.Errorf("organizations/convertToSerializable: cannot get target identification with id %s: %w",
-				identification.TargetIdentificationID.String, err)
+			return nil, fmt.Errorf("organizations/convertToSerializable: %w", err)
 		}
-		identification = ident
 	}
-	if identification != nil {
-		serializable.Identifier = identification.Identifier.String
+
+	serializable.Identifier, err = s.MemberIdentifier(ctx, exec, userSettings, orgMembership.User.User)
+	if err != nil {
+		return nil, fmt.Errorf("organizations/convertToSerializable: %w", err)
 	}
 
 	if orgMembership.Organization.BillingSubscriptionID.Valid {
@@ -1436,6 +1722,38 @@ func (s *Service) ConvertToSerializable(
 	return &serializable, nil
 }
 
+func (s *Service) instanceUserSettings(ctx context.Context, exec database.Executor, instanceID string) (*usersettings.UserSettings, error) {
+	authConfig, err := s.authConfigRepo.FindByInstanceActiveAuthConfigID(ctx, exec, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get auth config of instance %s: %w", instanceID, err)
+	}
+	return usersettings.NewUserSettings(authConfig.UserSettings), nil
+}
+
+// MemberIdentifier returns the identifier of the user, in the order the
+// instance prefers identifiers, or an empty string if the user has none.
+func (s *Service) MemberIdentifier(ctx context.Context, exec database.Executor, userSettings *usersettings.UserSettings, user *model.User) (string, error) {
+	identification, err := s.userProfileService.GetPreferredIdentification(ctx, exec, user, user_profile.IdentifierPriority(userSettings))
+	if err != nil {
+		return "", fmt.Errorf("cannot get identification of user %s: %w", user.ID, err)
+	}
+
+	if identification != nil && identification.TargetIdentificationID.Valid {
+		// this is an OAuth identification, so we need to find the connected identification which
+		// contains the actual identifier
+		target, err := s.identificationsRepo.FindByID(ctx, exec, identification.TargetIdentificationID.String)
+		if err != nil {
+			return "", fmt.Errorf("cannot get target identification with id %s: %w",
+				identification.TargetIdentificationID.String, err)
+		}
+		identification = target
+	}
+	if identification == nil {
+		return "", nil
+	}
+	return identification.Identifier.String, nil
+}
+
 func (s *Service) convertOrganizationInvitation(ctx context.Context, exec database.Executor, invitation *model.OrganizationInvitation) (*model.OrganizationInvitationSerializable, error) {
 	var role *model.Role
 	if invitation.RoleID.Valid {
@@ -1459,7 +1777,24 @@ func (s *Service) EnsureHasAccess(ctx context.Context, exec database.Executor, o
 		return apierror.Unexpected(err)
 	}
 	if len(orgMembers) != len(userIDs) {
-		return apierror.NotAMemberInOrganization()
+		// Users who aren't members may still have access through an ancestor
+		// of the organization.
+		memberUserIDs := set.New[string]()
+		for _, member := range orgMembers {
+			memberUserIDs.Insert(member.UserID)
+		}
+		for _, userID := range userIDs {
+			if memberUserIDs.Contains(userID) {
+				continue
+			}
+			inherited, err := s.hasInheritedAccess(ctx, exec, organizationID, userID, permission)
+			if err != nil {
+				return apierror.Unexpected(err)
+			}
+			if !inherited {
+				return apierror.NotAMemberInOrganization()
+			}
+		}
 	}
 
 	for _, member := range orgMembers {
@@ -1478,7 +1813,14 @@ func (s *Service) EnsureHasAccessAny(ctx context.Context, exec database.Executor
 		return apierror.Unexpected(err)
 	}
 	if orgMember == nil {
-		return apierror.NotAMemberInOrganization()
+		inherited, err := s.hasInheritedAccess(ctx, exec, organizationID, userID, permissions...)
+		if err != nil {
+			return apierror.Unexpected(err)
+		}
+		if !inherited {
+			return apierror.NotAMemberInOrganization()
+		}
+		return nil
 	}
 
 	memberPermissions := set.New(orgMember.PermissionKeys...)
@@ -1615,10 +1957,10 @@ type LogosService struct {
 
 func NewLogosService(deps clerk.Deps) *LogosService {
 	return &LogosService{
-		imagesSvc:         images.NewService(deps.StorageClient()),
+		imagesSvc:         images.NewService(deps.Clock(), deps.StorageClient()),
 		eventsSvc:         events.NewService(deps),
 		organizationsSvc:  NewService(deps),
-		organizationsRepo: repository.NewOrganization(),
+		organizationsRepo: deps.Repositories().Organization,
 	}
 }
 
@@ -1627,6 +1969,10 @@ type UpdateLogoParams struct {
 	Image          io.ReadCloser
 	Filename       string
 	UploaderUserID string
+
+	// UploadedImageID is the logo uploaded directly to storage, if a signed
+	// upload URL was used instead of sending the Image.
+	UploadedImageID string
 }
 
 func (s *LogosService) Update(ctx context.Context, tx database.Tx, params UpdateLogoParams, instance *model.Instance) (*model.Organization, apierror.Error) {
@@ -1638,17 +1984,27 @@ func (s *LogosService) Update(ctx context.Context, tx database.Tx, params Update
 		return nil, apierror.ResourceNotFound()
 	}
 
-	img, apiErr := s.imagesSvc.Create(
-		ctx,
-		tx,
-		images.ImageParams{
-			Filename:           params.Filename,
-			Prefix:             images.PrefixUploaded,
-			Src:                params.Image,
+	var img *model.Image
+	var apiErr apierror.Error
+	if params.UploadedImageID != "" {
+		img, apiErr = s.imagesSvc.CompleteUpload(ctx, tx, images.CompleteUploadParams{
+			ImageID:            params.UploadedImageID,
 			UploaderUserID:     params.UploaderUserID,
 			UsedByResourceType: clerkstrings.ToPtr(constants.OrganizationResource),
-		},
-	)
+		})
+	} else {
+		img, apiErr = s.imagesSvc.Create(
+			ctx,
+			tx,
+			images.ImageParams{
+				Filename:           params.Filename,
+				Prefix:             images.PrefixUploaded,
+				Src:                params.Image,
+				UploaderUserID:     params.UploaderUserID,
+				UsedByResourceType: clerkstrings.ToPtr(constants.OrganizationResource),
+			},
+		)
+	}
 	if apiErr != nil {
 		return nil, apiErr
 	}
@@ -1674,6 +2030,36 @@ func (s *LogosService) Update(ctx context.Context, tx database.Tx, params Update
 	return org, nil
 }
 
+type CreateLogoUploadParams struct {
+	OrganizationID string
+	Filename       string
+	ContentType    string
+	UploaderUserID string
+}
+
+// CreateUpload returns a signed URL the new logo of the organization can be
+// uploaded to, directly to storage. Once uploaded, the logo is set with Update.
+func (s *LogosService) CreateUpload(ctx context.Context, exec database.Executor, params CreateLogoUploadParams, instance *model.Instance) (*serialize.ImageUploadResponse, apierror.Error) {
+	exists, err := s.organizationsRepo.ExistsByIDAndInstance(ctx, exec, params.OrganizationID, instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if !exists {
+		return nil, apierror.ResourceNotFound()
+	}
+
+	upload, apiErr := s.imagesSvc.CreateUpload(ctx, exec, images.UploadParams{
+		Filename:           params.Filename,
+		ContentType:        params.ContentType,
+		UploaderUserID:     params.UploaderUserID,
+		UsedByResourceType: clerkstrings.ToPtr(constants.OrganizationResource),
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.ImageUpload(upload.Image, upload.URL, upload.ExpiresAt), nil
+}
+
 type DeleteLogoParams struct {
 	Organization *model.Organization
 	Instance     *model.Instance
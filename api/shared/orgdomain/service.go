@@ -23,6 +23,7 @@ type Service struct {
 	orgDomainVerificationRepo *repository.OrganizationDomainVerification
 	orgInvitationRepo         *repository.OrganizationInvitation
 	orgMembershipRepo         *repository.OrganizationMembership
+	orgRepo                   *repository.Organization
 	orgSuggestionRepo         *repository.OrganizationSuggestion
 	roleRepo                  *repository.Role
 }
@@ -34,6 +35,7 @@ func NewService(clock clockwork.Clock) *Service {
 		orgDomainVerificationRepo: repository.NewOrganizationDomainVerification(),
 		orgInvitationRepo:         repository.NewOrganizationInvitation(),
 		orgMembershipRepo:         repository.NewOrganizationMembership(),
+		orgRepo:                   repository.NewOrganization(),
 		orgSuggestionRepo:         repository.NewOrganizationSuggestion(),
 		roleRepo:                  repository.NewRole(),
 	}
@@ -54,7 +56,105 @@ func (s *Service) CreateInvitationsSuggestionsForUserEmail(ctx context.Context,
 		return nil
 	}
 
-	exists, err := s.orgMembershipRepo.ExistsByOrganizationAndUser(ctx, tx, orgDomain.OrganizationID, userID)
+	if err := s.enroll(ctx, tx, authConfig, orgDomain, orgDomain.OrganizationID, emailAddress, userID); err != nil {
+		return err
+	}
+	if !authConfig.OrganizationSettings.Hierarchy.InheritDomains {
+		return nil
+	}
+
+	// Organizations nested under the one which owns the domain inherit it
+	descendantIDs, err := s.descendantIDs(ctx, tx, orgDomain.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("orgdomain/createInvitationsSuggestionsForUserEmail: %w", err)
+	}
+	for _, organizationID := range descendantIDs {
+		if err := s.enroll(ctx, tx, authConfig, orgDomain, organizationID, emailAddress, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxHierarchyDepth bounds how many levels of nested organizations inherit
+// a domain.
+const maxHierarchyDepth = 10
+
+// descendantIDs returns the ids of all the organizations nested under the
+// organization with the given id, level by level.
+func (s *Service) descendantIDs(ctx context.Context, exec database.Executor, organizationID string) ([]string, error) {
+	var descendantIDs []string
+	parentIDs := []string{organizationID}
+	for depth := 0; len(parentIDs) > 0 && depth < maxHierarchyDepth; depth++ {
+		children, err := s.orgRepo.FindAllByParents(ctx, exec, parentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("descendantIDs: fetching children of organizations %v: %w", parentIDs, err)
+		}
+		childIDs := make([]string, len(children))
+		for i, child := range children {
+			childIDs[i] = child.ID
+		}
+		descendantIDs = append(descendantIDs, childIDs...)
+		parentIDs = childIDs
+	}
+	return descendantIDs, nil
+}
+
+// ProvisionParams describe the organization domain an IdP vouched for,
+// along with the user who signed in through it.
+type ProvisionParams struct {
+	InstanceID     string
+	UserID         string
+	EmailAddress   string
+	Domain         string
+	OrganizationID string
+	// EnrollmentMode applies to the organization domain if it's created.
+	EnrollmentMode string
+}
+
+// ProvisionFromSAML creates the organization domain which the IdP vouched
+// for, if the organization doesn't have it yet, and enrolls the user
+// according to its enrollment mode.
+//
+// An assertion never verifies a domain. Domains are created unverified, and
+// users are only enrolled once the organization has verified the domain
+// like any other.
+func (s *Service) ProvisionFromSAML(ctx context.Context, tx database.Tx, authConfig *model.AuthConfig, params ProvisionParams) error {
+	if !authConfig.IsOrganizationDomainsEnabled() {
+		return nil
+	}
+
+	orgDomain, err := s.orgDomainRepo.QueryByOrganizationAndName(ctx, tx, params.OrganizationID, params.Domain)
+	if err != nil {
+		return fmt.Errorf("orgdomain/provisionFromSAML: fetching domain %s of organization %s: %w", params.Domain, params.OrganizationID, err)
+	}
+
+	if orgDomain == nil {
+		orgDomain = &model.OrganizationDomain{OrganizationDomain: &sqbmodel.OrganizationDomain{
+			InstanceID:     params.InstanceID,
+			OrganizationID: params.OrganizationID,
+			Name:           params.Domain,
+			EnrollmentMode: params.EnrollmentMode,
+		}}
+		if err := s.orgDomainRepo.Insert(ctx, tx, orgDomain); err != nil {
+			return fmt.Errorf("orgdomain/provisionFromSAML: creating domain %s for organization %s: %w", params.Domain, params.OrganizationID, err)
+		}
+	}
+
+	if !orgDomain.Verified {
+		return nil
+	}
+	if err := s.enroll(ctx, tx, authConfig, orgDomain, params.OrganizationID, params.EmailAddress, params.UserID); err != nil {
+		return fmt.Errorf("orgdomain/provisionFromSAML: enrolling user %s to organization %s: %w", params.UserID, params.OrganizationID, err)
+	}
+	return nil
+}
+
+// enroll invites the user to the given organization, or suggests it to them,
+// depending on the enrollment mode of the domain. The organization is either
+// the one of the domain, or one which inherits it.
+func (s *Service) enroll(ctx context.Context, tx database.Tx, authConfig *model.AuthConfig, orgDomain *model.OrganizationDomain, organizationID, emailAddress, userID string) error {
+	exists, err := s.orgMembershipRepo.ExistsByOrganizationAndUser(ctx, tx, organizationID, userID)
 	if err != nil {
 		return err
 	}
@@ -66,7 +166,7 @@ func (s *Service) CreateInvitationsSuggestionsForUserEmail(ctx context.Context,
 	case constants.EnrollmentModeManualInvitation:
 		return nil
 	case constants.EnrollmentModeAutomaticInvitation:
-		exists, err := s.orgInvitationRepo.ExistsPendingByOrganizationAndEmail(ctx, tx, orgDomain.OrganizationID, emailAddress)
+		exists, err := s.orgInvitationRepo.ExistsPendingByOrganizationAndEmail(ctx, tx, organizationID, emailAddress)
 		if err != nil {
 			return err
 		}
@@ -74,7 +174,7 @@ func (s *Service) CreateInvitationsSuggestionsForUserEmail(ctx context.Context,
 			return nil
 		}
 
-		defaultInvitationRole, err := s.roleRepo.FindByKeyAndInstance(ctx, tx, authConfig.OrganizationSettings.Domains.DefaultRole, instanceID)
+		defaultInvitationRole, err := s.roleRepo.FindByKeyAndInstance(ctx, tx, authConfig.OrganizationSettings.Domains.DefaultRole, orgDomain.InstanceID)
 		if err != nil {
 			return err
 		}
@@ -83,7 +183,7 @@ func (s *Service) CreateInvitationsSuggestionsForUserEmail(ctx context.Context,
 			InstanceID:           orgDomain.InstanceID,
 			EmailAddress:         emailAddress,
 			Status:               constants.StatusPending,
-			OrganizationID:       orgDomain.OrganizationID,
+			OrganizationID:       organizationID,
 			UserID:               null.StringFrom(userID),
 			OrganizationDomainID: null.StringFrom(orgDomain.ID),
 			RoleID:               null.StringFrom(defaultInvitationRole.ID),
@@ -92,7 +192,7 @@ func (s *Service) CreateInvitationsSuggestionsForUserEmail(ctx context.Context,
 	case constants.EnrollmentModeAutomaticSuggestion:
 		suggestion := &model.OrganizationSuggestion{OrganizationSuggestion: &sqbmodel.OrganizationSuggestion{
 			InstanceID:           orgDomain.InstanceID,
-			OrganizationID:       orgDomain.OrganizationID,
+			OrganizationID:       organizationID,
 			UserID:               userID,
 			OrganizationDomainID: null.StringFrom(orgDomain.ID),
 			Status:               constants.StatusPending,
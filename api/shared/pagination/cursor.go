@@ -0,0 +1,121 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/utils/param"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+const cursorParam = "cursor"
+
+var errInvalidCursor = errors.New("pagination: invalid cursor")
+
+// Cursor points right after the last row of a page, when rows are ordered
+// by (created_at, id). Unlike offsets, cursors are not affected by rows
+// which are inserted or deleted while a client pages through the results.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// Encode returns the opaque representation of the cursor which is handed
+// out to clients.
+func (c Cursor) Encode() string {
+	// Postgres keeps timestamps with microsecond precision, anything
+	// coarser could skip rows created within the same millisecond.
+	raw := strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + ":" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), ":")
+	if !found || id == "" {
+		return nil, errInvalidCursor
+	}
+	createdAtMicro, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	return &Cursor{
+		CreatedAt: time.UnixMicro(createdAtMicro).UTC(),
+		ID:        id,
+	}, nil
+}
+
+// KeysetParams paginates results in a stable (created_at, id) order,
+// continuing after the given cursor.
+type KeysetParams struct {
+	Limit int
+	// After is nil for the first page
+	After *Cursor
+}
+
+// KeysetFromRequest returns the keyset pagination parameters of the
+// request, or nil when the request uses offset pagination. Clients opt in
+// by passing the cursor parameter, which is left empty for the first page.
+// The cursor can't be combined with an offset.
+func KeysetFromRequest(r *http.Request, limit int) (*KeysetParams, apierror.Error) {
+	query := r.URL.Query()
+	if !query.Has(cursorParam) {
+		return nil, nil
+	}
+	if query.Has(param.Offset.Name) {
+		return nil, apierror.FormParameterNotAllowedIfAnotherParameterIsPresent(param.Offset.Name, cursorParam)
+	}
+
+	params := &KeysetParams{Limit: limit}
+	if encoded := query.Get(cursorParam); encoded != "" {
+		cursor, err := DecodeCursor(encoded)
+		if err != nil {
+			return nil, apierror.FormInvalidParameterValue(cursorParam, encoded)
+		}
+		params.After = cursor
+	}
+	return params, nil
+}
+
+// ToQueryMods returns the query modifiers which apply the keyset
+// pagination to the given table.
+func (p KeysetParams) ToQueryMods(table string) []qm.QueryMod {
+	queryMods := []qm.QueryMod{}
+
+	if p.After != nil {
+		queryMods = append(queryMods, qm.Where(
+			fmt.Sprintf("(%[1]s.created_at, %[1]s.id) > (?, ?)", table),
+			p.After.CreatedAt, p.After.ID,
+		))
+	}
+
+	queryMods = append(queryMods, qm.OrderBy(fmt.Sprintf("%[1]s.created_at ASC, %[1]s.id ASC", table)))
+
+	if p.Limit != 0 {
+		queryMods = append(queryMods, qm.Limit(p.Limit))
+	}
+
+	return queryMods
+}
+
+// NextCursor returns the cursor for the page following the given one, or
+// nil if the page is the last one.
+func (p KeysetParams) NextCursor(pageSize int, lastCreatedAt time.Time, lastID string) *string {
+	if pageSize == 0 || pageSize < p.Limit {
+		return nil
+	}
+	next := Cursor{CreatedAt: lastCreatedAt, ID: lastID}.Encode()
+	return &next
+}
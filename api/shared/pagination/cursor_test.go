@@ -0,0 +1,114 @@
+package pagination
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	cursor := Cursor{
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        "orgmem_123",
+	}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, *decoded)
+
+	for _, invalid := range []string{"not base64!", "bm9jb2xvbg", "YWJjOmlk", "MTIzOg"} {
+		_, err := DecodeCursor(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestKeysetFromRequest(t *testing.T) {
+	t.Parallel()
+
+	after := Cursor{CreatedAt: time.UnixMicro(1700000000000000).UTC(), ID: "orgmem_1"}
+
+	testCases := []struct {
+		url      string
+		expected *KeysetParams
+		hasErr   bool
+	}{
+		{
+			url:      "/v1/memberships",
+			expected: nil,
+		},
+		{
+			url:      "/v1/memberships?cursor=",
+			expected: &KeysetParams{Limit: 10},
+		},
+		{
+			url:      "/v1/memberships?cursor=" + after.Encode(),
+			expected: &KeysetParams{Limit: 10, After: &after},
+		},
+		{
+			url:    "/v1/memberships?cursor=lol",
+			hasErr: true,
+		},
+		{
+			url:    "/v1/memberships?cursor=&offset=10",
+			hasErr: true,
+		},
+		{
+			url:      "/v1/memberships?offset=10",
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		require.NoError(t, err)
+
+		params, apiErr := KeysetFromRequest(req, 10)
+		if tc.hasErr {
+			require.Error(t, apiErr, tc.url)
+			continue
+		}
+		require.NoError(t, apiErr, tc.url)
+		assert.Equal(t, tc.expected, params, tc.url)
+	}
+}
+
+func TestKeysetParams_ToQueryMods(t *testing.T) {
+	t.Parallel()
+
+	after := Cursor{CreatedAt: time.UnixMicro(1700000000000000).UTC(), ID: "orgmem_1"}
+
+	params := KeysetParams{Limit: 20}
+	assert.Equal(t, []qm.QueryMod{
+		qm.OrderBy("organization_memberships.created_at ASC, organization_memberships.id ASC"),
+		qm.Limit(20),
+	}, params.ToQueryMods("organization_memberships"))
+
+	params.After = &after
+	assert.Equal(t, []qm.QueryMod{
+		qm.Where("(organization_memberships.created_at, organization_memberships.id) > (?, ?)", after.CreatedAt, after.ID),
+		qm.OrderBy("organization_memberships.created_at ASC, organization_memberships.id ASC"),
+		qm.Limit(20),
+	}, params.ToQueryMods("organization_memberships"))
+}
+
+func TestKeysetParams_NextCursor(t *testing.T) {
+	t.Parallel()
+
+	params := KeysetParams{Limit: 2}
+	lastCreatedAt := time.UnixMicro(1700000000000000).UTC()
+
+	assert.Nil(t, params.NextCursor(0, time.Time{}, ""))
+	assert.Nil(t, params.NextCursor(1, lastCreatedAt, "orgmem_1"))
+
+	next := params.NextCursor(2, lastCreatedAt, "orgmem_2")
+	require.NotNil(t, next)
+	decoded, err := DecodeCursor(*next)
+	require.NoError(t, err)
+	assert.Equal(t, Cursor{CreatedAt: lastCreatedAt, ID: "orgmem_2"}, *decoded)
+}
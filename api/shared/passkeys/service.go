@@ -24,8 +24,8 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		identificationRepo: repository.NewIdentification(),
-		passkeyRepo:        repository.NewPasskey(),
+		identificationRepo: deps.Repositories().Identification,
+		passkeyRepo:        deps.Repositories().Passkey,
 		commsService:       comms.NewService(deps),
 		userProfileService: user_profile.NewService(deps.Clock()),
 	}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/pkg/externalapis/hibp"
+	usersettingsmodel "clerk/pkg/usersettings/model"
+	"clerk/utils/log"
+)
+
+// CheckPwned applies the pwned password policy of the instance to a password
+// that is being set, e.g. on sign-up or on a password change. Pwned
+// passwords are rejected, unless the instance only wants to be warned about
+// them. Passwords are accepted when the check itself fails, so that an
+// outage of Have I Been Pwned doesn't prevent users from signing up.
+func CheckPwned(ctx context.Context, client hibp.Client, settings usersettingsmodel.PasswordSettings, paramName, password string) apierror.Error {
+	if settings.DisableHIBP {
+		return nil
+	}
+
+	pwned, err := client.CheckIfPasswordPwned(ctx, password)
+	if err != nil {
+		log.Warning(ctx, "password/checkPwned: %s", err)
+		return nil
+	}
+	if !pwned {
+		return nil
+	}
+
+	if settings.BreachPolicy == hibp.PolicyWarn {
+		log.Info(ctx, "password/checkPwned: accepted password found in a data breach")
+		return nil
+	}
+	return apierror.FormPwnedPassword(paramName, false)
+}
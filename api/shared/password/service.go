@@ -11,6 +11,7 @@ import (
 	"clerk/api/shared/events"
 	"clerk/api/shared/serializable"
 	"clerk/api/shared/sessions"
+	"clerk/api/shared/trusted_devices"
 	"clerk/api/shared/user_profile"
 	"clerk/model"
 	usersettings "clerk/pkg/usersettings/clerk"
@@ -22,24 +23,26 @@ import (
 )
 
 type Service struct {
-	commService         *comms.Service
-	eventService        *events.Service
-	sessionService      *sessions.Service
-	serializableService *serializable.Service
-	userProfileService  *user_profile.Service
-	clientDataService   *client_data.Service
-	userRepo            *repository.Users
+	commService          *comms.Service
+	eventService         *events.Service
+	sessionService       *sessions.Service
+	serializableService  *serializable.Service
+	trustedDeviceService *trusted_devices.Service
+	userProfileService   *user_profile.Service
+	clientDataService    *client_data.Service
+	userRepo             *repository.Users
 }
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		commService:         comms.NewService(deps),
-		eventService:        events.NewService(deps),
-		sessionService:      sessions.NewService(deps),
-		serializableService: serializable.NewService(deps.Clock()),
-		userProfileService:  user_profile.NewService(deps.Clock()),
-		clientDataService:   client_data.NewService(deps),
-		userRepo:            repository.NewUsers(),
+		commService:          comms.NewService(deps),
+		eventService:         events.NewService(deps),
+		sessionService:       sessions.NewService(deps),
+		serializableService:  serializable.NewService(deps.Clock()),
+		trustedDeviceService: trusted_devices.NewService(deps),
+		userProfileService:   user_profile.NewService(deps.Clock()),
+		clientDataService:    client_data.NewService(deps),
+		userRepo:             deps.Repositories().Users,
 	}
 }
 
@@ -53,15 +56,28 @@ type ChangeUserPasswordParams struct {
 }
 
 func (s *Service) ChangeUserPassword(ctx context.Context, tx database.Tx, params ChangeUserPasswordParams) error {
+	userSettings := usersettings.NewUserSettings(params.Env.AuthConfig.UserSettings)
+	previousUser, err := s.serializableService.SnapshotUser(ctx, tx, userSettings, params.User)
+	if err != nil {
+		return fmt.Errorf("changeUserPassword: snapshot user %s: %w", params.User.ID, err)
+	}
+
 	params.User.PasswordDigest = null.StringFrom(params.PasswordDigest)
 	params.User.PasswordHasher = null.StringFrom(params.PasswordHasher)
 
-	err := s.userRepo.UpdatePasswordDigestAndHasher(ctx, tx, params.User)
+	err = s.userRepo.UpdatePasswordDigestAndHasher(ctx, tx, params.User)
 	if err != nil {
 		return fmt.Errorf("changeUserPassword: updating password digest and hasher for user %s: %w",
 			params.User.ID, err)
 	}
 
+	// devices trusted with the old password shouldn't skip the second
+	// factor of whoever signs in with the new one
+	err = s.trustedDeviceService.RevokeAllForUser(ctx, tx, params.Env.Instance.ID, params.User.ID)
+	if err != nil {
+		return fmt.Errorf("changeUserPassword: %w", err)
+	}
+
 	if params.SignOutOfOtherSessions && params.RequestingSessionID != nil {
 		// Note that the following operation happens outside the database transaction.
 		// This is also desirable in order to reduce chance of race conditions due to transaction isolation.
@@ -78,11 +94,11 @@ func (s *Service) ChangeUserPassword(ctx context.Context, tx database.Tx, params
 			params.User.ID, err)
 	}
 
-	userSerializable, err := s.serializableService.ConvertUser(ctx, tx, usersettings.NewUserSettings(params.Env.AuthConfig.UserSettings), params.User)
+	userSerializable, err := s.serializableService.ConvertUser(ctx, tx, userSettings, params.User)
 	if err != nil {
 		return err
 	}
-	if err = s.eventService.UserUpdated(ctx, tx, params.Env.Instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, tx, params.Env.Instance, serialize.UserToServerAPI(ctx, userSerializable), previousUser); err != nil {
 		return fmt.Errorf("changeUserPassword: send user updated event for (%s, %s): %w",
 			params.User.ID, params.Env.Instance.ID, err)
 	}
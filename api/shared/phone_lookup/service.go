@@ -0,0 +1,125 @@
+// Package phone_lookup checks phone numbers with a carrier lookup provider
+// before one-time codes are sent to them, for instances which opted in.
+//
+// Numbers which the provider finds unreachable, or whose line type is
+// blocked by the instance, e.g. VoIP numbers which are commonly used for
+// SMS pumping, are rejected. Lookups are cached, since the carrier of a
+// number rarely changes and every lookup is billed. If the provider fails,
+// the number is let through, so that an outage of the provider never keeps
+// users from receiving their codes.
+package phone_lookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/pkg/cenv"
+	"clerk/pkg/externalapis/phonelookup"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+)
+
+// cacheTTL is how long the result of a lookup is reused for.
+const cacheTTL = 24 * time.Hour
+
+var ErrProviderNotConfigured = errors.New("phone_lookup: provider not configured")
+
+// Providers are the lookup providers instances can choose from.
+var Providers = []string{phonelookup.ProviderTwilio, phonelookup.ProviderTelesign}
+
+type Service struct {
+	cache cache.Cache
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		cache: deps.Cache(),
+	}
+}
+
+// IsEnabled returns whether the instance looks up phone numbers before
+// sending one-time codes to them.
+func IsEnabled(instance *model.Instance) bool {
+	return instance.Communication.PhoneLookupProvider.Valid
+}
+
+// Check looks up the phone number with the provider of the instance and
+// returns apierror.PhoneNumberRejected if one-time codes shouldn't be sent
+// to it.
+func (s *Service) Check(ctx context.Context, instance *model.Instance, phoneNumber string) error {
+	if !IsEnabled(instance) {
+		return nil
+	}
+
+	result, err := s.lookup(ctx, instance.Communication.PhoneLookupProvider.String, phoneNumber)
+	if err != nil {
+		log.Warning(ctx, "phone_lookup: looking up phone number for instance %s, letting it through: %s", instance.ID, err)
+		return nil
+	}
+
+	if !result.Valid || slices.Contains(instance.Communication.PhoneLookupBlockedLineTypes, result.LineType) {
+		log.Info(ctx, "phone_lookup: rejected phone number of line type %s (valid: %t) for instance %s", result.LineType, result.Valid, instance.ID)
+		return apierror.PhoneNumberRejected()
+	}
+	return nil
+}
+
+type cachedResult struct {
+	// Loaded tells apart a cached result from a cache miss.
+	Loaded bool               `json:"loaded"`
+	Result phonelookup.Result `json:"result"`
+}
+
+// lookup returns the result of looking up the phone number with the given
+// provider, from the cache if it was looked up recently.
+func (s *Service) lookup(ctx context.Context, providerName, phoneNumber string) (*phonelookup.Result, error) {
+	key := cacheKey(providerName, phoneNumber)
+	var cached cachedResult
+	if err := s.cache.Get(ctx, key, &cached); err != nil {
+		log.Warning(ctx, "phone_lookup: fetching cached lookup: %s", err)
+	} else if cached.Loaded {
+		return &cached.Result, nil
+	}
+
+	provider, err := newProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	result, err := provider.Lookup(ctx, phoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("phone_lookup: looking up phone number with %s: %w", providerName, err)
+	}
+
+	cached = cachedResult{Loaded: true, Result: *result}
+	if err := s.cache.Set(ctx, key, cached, cacheTTL); err != nil {
+		log.Warning(ctx, "phone_lookup: caching lookup: %s", err)
+	}
+	return result, nil
+}
+
+func cacheKey(providerName, phoneNumber string) string {
+	return "phone_lookup:" + providerName + ":" + phoneNumber
+}
+
+func newProvider(name string) (phonelookup.Provider, error) {
+	switch name {
+	case phonelookup.ProviderTwilio:
+		if !cenv.IsSet(cenv.TwilioAccountSID) {
+			return nil, ErrProviderNotConfigured
+		}
+		return phonelookup.NewTwilio(cenv.Get(cenv.TwilioAccountSID), cenv.Get(cenv.TwilioAuthToken)), nil
+	case phonelookup.ProviderTelesign:
+		if !cenv.IsSet(cenv.TelesignCustomerID) {
+			return nil, ErrProviderNotConfigured
+		}
+		return phonelookup.NewTelesign(cenv.Get(cenv.TelesignCustomerID), cenv.Get(cenv.TelesignAPIKey)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, name)
+	}
+}
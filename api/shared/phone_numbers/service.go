@@ -45,8 +45,8 @@ func NewService(deps clerk.Deps) *Service {
 		serializableService:   serializable.NewService(deps.Clock()),
 		usersService:          users.NewService(deps),
 		userProfileService:    user_profile.NewService(deps.Clock()),
-		backupCodesRepo:       repository.NewBackupCode(),
-		identificationsRepo:   repository.NewIdentification(),
+		backupCodesRepo:       deps.Repositories().BackupCode,
+		identificationsRepo:   deps.Repositories().Identification,
 	}
 }
 
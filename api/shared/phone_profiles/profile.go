@@ -0,0 +1,209 @@
+// Package phone_profiles implements per-instance phone number validation
+// profiles. A profile decides which countries phone numbers may belong to,
+// which region national-format input is assumed to be in and how phone
+// numbers are formatted when serialized.
+package phone_profiles
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	cphonenumber "clerk/pkg/phonenumber"
+
+	"github.com/dongri/phonenumber"
+)
+
+const (
+	// FormatE164 formats phone numbers according to the E.164 international
+	// standard, e.g. +15555550100. This is the default.
+	FormatE164 = "e164"
+	// FormatNational formats phone numbers using only their national
+	// significant number, e.g. 5555550100.
+	FormatNational = "national"
+)
+
+// Formats contains all supported serialization formats.
+var Formats = []string{FormatE164, FormatNational}
+
+// IsValidFormat returns true if format is a supported serialization format.
+func IsValidFormat(format string) bool {
+	return slices.Contains(Formats, format)
+}
+
+// Profile is the phone number validation profile of an instance.
+type Profile struct {
+	// AllowedCountries contains the ISO 3166-1 alpha-2 codes of the
+	// countries phone numbers are allowed from. Empty means all countries.
+	AllowedCountries []string
+	// DefaultRegion is the ISO 3166-1 alpha-2 code of the region used to
+	// interpret phone numbers that are not in international format.
+	DefaultRegion string
+	// Format is the serialization format, one of Formats.
+	Format string
+}
+
+// ForInstance returns the phone number validation profile configured for
+// the given instance. Instances that never configured a profile get the
+// default one, which accepts E.164 input from all countries and serializes
+// in E.164.
+func ForInstance(instance *model.Instance) Profile {
+	if instance == nil {
+		return Profile{Format: FormatE164}
+	}
+
+	profile := Profile{
+		AllowedCountries: instance.Communication.PhoneNumberAllowedCountries,
+		DefaultRegion:    instance.Communication.PhoneNumberDefaultRegion.String,
+		Format:           instance.Communication.PhoneNumberFormat.String,
+	}
+	if !IsValidFormat(profile.Format) {
+		profile.Format = FormatE164
+	}
+	return profile
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx which carries the phone number validation
+// profile of the instance of the current request.
+func NewContext(ctx context.Context, instance *model.Instance) context.Context {
+	return context.WithValue(ctx, contextKey{}, ForInstance(instance))
+}
+
+// FromContext returns the phone number validation profile carried by ctx.
+// Contexts without one, e.g. those of background jobs, get the default
+// profile, which leaves phone numbers in E.164.
+func FromContext(ctx context.Context) Profile {
+	profile, ok := ctx.Value(contextKey{}).(Profile)
+	if !ok {
+		return ForInstance(nil)
+	}
+	return profile
+}
+
+// Sanitize is the phone number attribute sanitizer of the instance. Phone
+// numbers which are not in international format are converted to E.164,
+// interpreting them in the profile's default region, and are then sanitized
+// like any phone number. The result must belong to one of the countries
+// allowed by the profile. Every phone number that's about to be stored
+// should go through it.
+func (p Profile) Sanitize(phoneNumber, paramName string) (string, apierror.Error) {
+	phoneNumber, apiErr := p.normalize(phoneNumber, paramName)
+	if apiErr != nil {
+		return "", apiErr
+	}
+
+	sanitized, err := cphonenumber.Sanitize(phoneNumber)
+	if err != nil {
+		return "", apierror.FormInvalidPhoneNumber(paramName)
+	}
+	if apiErr := p.validate(sanitized, paramName); apiErr != nil {
+		return "", apiErr
+	}
+	return sanitized, nil
+}
+
+// NormalizeIdentifier converts an identifier which is a phone number in
+// national format to E.164, so that it can be matched against the stored
+// phone numbers. Any other identifier is returned unchanged. Unlike
+// Sanitize, the allowed countries aren't enforced, so that phone numbers
+// stored before the profile was configured can still be used.
+func (p Profile) NormalizeIdentifier(identifier string) string {
+	if !isNationalPhoneNumber(identifier) {
+		return identifier
+	}
+	phoneNumber, apiErr := p.normalize(digitsOnly(identifier), "")
+	if apiErr != nil {
+		return identifier
+	}
+	return phoneNumber
+}
+
+// normalize converts phone numbers that are not in international format
+// to E.164, interpreting them using the profile's default region. Phone
+// numbers already in international format, or any input when there is no
+// default region, are returned unchanged so that they're sanitized as
+// before.
+func (p Profile) normalize(phoneNumber, paramName string) (string, apierror.Error) {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if phoneNumber == "" || strings.HasPrefix(phoneNumber, "+") || p.DefaultRegion == "" {
+		return phoneNumber, nil
+	}
+
+	parsed := phonenumber.ParseWithLandLine(phoneNumber, p.DefaultRegion)
+	if parsed == "" {
+		return "", apierror.FormInvalidPhoneNumber(paramName)
+	}
+	return "+" + parsed, nil
+}
+
+// validate checks that the given E.164 phone number belongs to one of the
+// countries allowed by the profile.
+func (p Profile) validate(phoneNumber, paramName string) apierror.Error {
+	if !p.IsAllowed(phoneNumber) {
+		return apierror.FormPhoneNumberCountryNotAllowed(paramName, p.AllowedCountries)
+	}
+	return nil
+}
+
+// IsAllowed returns true if the given phone number, in international
+// format, belongs to one of the allowed countries of the profile.
+func (p Profile) IsAllowed(phoneNumber string) bool {
+	if len(p.AllowedCountries) == 0 {
+		return true
+	}
+
+	iso3166 := phonenumber.GetISO3166ByNumber(digitsOnly(phoneNumber), true)
+	for _, country := range p.AllowedCountries {
+		if strings.EqualFold(country, iso3166.Alpha2) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatNumber formats the given E.164 phone number according to the profile.
+// Phone numbers are stored in E.164, but values stored before the profile
+// was introduced might not be parseable. These are returned unchanged, as
+// are numbers outside the profile's default region when the national
+// format is used, since they cannot be dialed without a country code.
+func (p Profile) FormatNumber(phoneNumber string) string {
+	if p.Format != FormatNational || p.DefaultRegion == "" {
+		return phoneNumber
+	}
+
+	digits := strings.TrimPrefix(phoneNumber, "+")
+	iso3166 := phonenumber.GetISO3166ByNumber(digits, true)
+	if iso3166.CountryCode == "" || !strings.EqualFold(iso3166.Alpha2, p.DefaultRegion) {
+		return phoneNumber
+	}
+	return strings.TrimPrefix(digits, iso3166.CountryCode)
+}
+
+// isNationalPhoneNumber returns true if s consists only of digits and the
+// characters phone numbers are usually written with, without the leading +
+// of the international format.
+func isNationalPhoneNumber(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || digitsOnly(s) == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && !strings.ContainsRune(" -().", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func digitsOnly(phoneNumber string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, phoneNumber)
+}
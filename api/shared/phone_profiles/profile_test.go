@@ -0,0 +1,88 @@
+package phone_profiles
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfile_Normalize(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		profile Profile
+		input   string
+		want    string
+		wantErr bool
+		message string
+	}{
+		{Profile{}, "+306912345678", "+306912345678", false, "international format without default region"},
+		{Profile{DefaultRegion: "GR"}, "+15555550100", "+15555550100", false, "international format with default region"},
+		{Profile{}, "6912345678", "6912345678", false, "national format without default region is left to the sanitizer"},
+		{Profile{DefaultRegion: "GR"}, "6912345678", "+306912345678", false, "national format with default region"},
+		{Profile{DefaultRegion: "GR"}, "12", "", true, "invalid national format"},
+		{Profile{DefaultRegion: "GR"}, "", "", false, "empty"},
+	} {
+		got, apiErr := tc.profile.normalize(tc.input, "phone_number")
+		assert.Equal(t, tc.wantErr, apiErr != nil, tc.message)
+		assert.Equal(t, tc.want, got, tc.message)
+	}
+}
+
+func TestProfile_Validate(t *testing.T) {
+	t.Parallel()
+	profile := Profile{AllowedCountries: []string{"GR", "CY"}}
+
+	assert.Nil(t, profile.validate("+306912345678", "phone_number"))
+	assert.NotNil(t, profile.validate("+15555550100", "phone_number"))
+	assert.Nil(t, Profile{}.validate("+15555550100", "phone_number"))
+}
+
+func TestProfile_NormalizeIdentifier(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		profile Profile
+		input   string
+		want    string
+		message string
+	}{
+		{Profile{DefaultRegion: "GR"}, "6912345678", "+306912345678", "national format"},
+		{Profile{DefaultRegion: "GR"}, "691 234 5678", "+306912345678", "national format with spaces"},
+		{Profile{DefaultRegion: "GR"}, "+15555550100", "+15555550100", "international format"},
+		{Profile{}, "6912345678", "6912345678", "no default region"},
+		{Profile{DefaultRegion: "GR"}, "jane@example.com", "jane@example.com", "email address"},
+		{Profile{DefaultRegion: "GR"}, "jane123", "jane123", "username"},
+		{Profile{DefaultRegion: "GR"}, "12", "12", "invalid phone number"},
+	} {
+		assert.Equal(t, tc.want, tc.profile.NormalizeIdentifier(tc.input), tc.message)
+	}
+}
+
+func TestProfile_FormatNumber(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		profile Profile
+		input   string
+		want    string
+		message string
+	}{
+		{Profile{Format: FormatE164, DefaultRegion: "GR"}, "+306912345678", "+306912345678", "E.164 format"},
+		{Profile{Format: FormatNational, DefaultRegion: "GR"}, "+306912345678", "6912345678", "national format in default region"},
+		{Profile{Format: FormatNational, DefaultRegion: "GR"}, "+15555550100", "+15555550100", "national format outside default region"},
+		{Profile{Format: FormatNational}, "+306912345678", "+306912345678", "national format without default region"},
+		{Profile{Format: FormatNational, DefaultRegion: "GR"}, "not-a-number", "not-a-number", "unparseable stored value"},
+	} {
+		assert.Equal(t, tc.want, tc.profile.FormatNumber(tc.input), tc.message)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, Profile{Format: FormatE164}, FromContext(context.Background()))
+	assert.Equal(t, Profile{Format: FormatE164}, FromContext(NewContext(context.Background(), nil)))
+
+	national := Profile{Format: FormatNational, DefaultRegion: "GR"}
+	ctx := context.WithValue(context.Background(), contextKey{}, national)
+	assert.Equal(t, national, FromContext(ctx))
+}
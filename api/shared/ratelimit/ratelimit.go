@@ -0,0 +1,179 @@
+// Package ratelimit implements per-instance rate limiting with token buckets.
+//
+// Every bucket is identified by a key, usually made of the instance, the
+// client IP and the route. A bucket holds up to Limit.Requests tokens and
+// refills at a steady rate of Limit.Requests every Limit.Period, so clients
+// can send a burst of Limit.Requests requests, and then one request every
+// Limit.Period/Limit.Requests. Unlike fixed windows, this doesn't allow
+// twice the limit around the start of a window.
+//
+// Buckets are kept in the cache using the generic cell rate algorithm
+// (GCRA), which stores a single timestamp per bucket: the theoretical
+// arrival time of the next request if requests arrived at the refill rate.
+// It's updated by a single script, so that buckets are shared across
+// servers and concurrent requests can't exceed the limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/shared/serviceconfig"
+	"clerk/model"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// API is the API whose requests are limited. Every API has its own default
+// limit.
+type API string
+
+const (
+	BAPI API = "bapi"
+	FAPI API = "fapi"
+)
+
+// Limit allows Requests requests in every Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled returns whether requests should be limited at all.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// DefaultLimit returns the limit which applies to the requests of the given
+// API, for instances without an override. It can be tuned while the APIs are
+// running.
+func DefaultLimit(api API) Limit {
+	tunables := serviceconfig.Live()
+	if api == FAPI {
+		return Limit{
+			Requests: tunables.FAPIRateLimitRequests.Get(),
+			Period:   tunables.FAPIRateLimitPeriod.Get(),
+		}
+	}
+	return Limit{
+		Requests: tunables.BAPIRateLimitRequests.Get(),
+		Period:   tunables.BAPIRateLimitPeriod.Get(),
+	}
+}
+
+// LimitForInstance returns the limit of the given instance for the given
+// API, which is the default limit unless the instance overrides it.
+//
+// Overrides only apply to BAPI, whose traffic grows with the backend of the
+// instance. FAPI limits protect the sign in and sign up flows of every
+// instance from abuse, so they're the same for everyone.
+func LimitForInstance(instance *model.Instance, api API) Limit {
+	limit := DefaultLimit(api)
+	if api != BAPI {
+		return limit
+	}
+	if instance.RateLimitRequests.Valid {
+		limit.Requests = instance.RateLimitRequests.Int
+	}
+	if instance.RateLimitPeriodSeconds.Valid {
+		limit.Period = time.Duration(instance.RateLimitPeriodSeconds.Int) * time.Second
+	}
+	return limit
+}
+
+// Result is the outcome of counting a request.
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long to wait until the bucket has a token for the
+	// next request. It's zero when the request is allowed.
+	RetryAfter time.Duration
+}
+
+// gcraScript takes a token from the bucket KEYS[1], if it has one. Times are
+// in microseconds: ARGV[1] is the current time, ARGV[2] the time it takes to
+// refill one token and ARGV[3] the period of the limit. It returns zero when
+// the request is allowed, and how long until it would be otherwise.
+const gcraScript = `
+local now = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call("GET", KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - period
+if now < allow_at then
+	return allow_at - now
+end
+
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return 0
+`
+
+// scripter runs Lua scripts atomically. It's implemented by cache.Cache.
+type scripter interface {
+	// Eval runs the script with the given keys and arguments, and returns
+	// its result.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+type Service struct {
+	scripter scripter
+	clock    clockwork.Clock
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		scripter: deps.Cache(),
+		clock:    deps.Clock(),
+	}
+}
+
+// Allow takes a token for a request from the bucket with the given key.
+//
+// If the cache is unavailable, requests are allowed, since rate limiting must
+// never take the APIs down.
+func (s *Service) Allow(ctx context.Context, key string, limit Limit) Result {
+	if !limit.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	now := s.clock.Now().UnixMicro()
+	res, err := s.scripter.Eval(ctx, gcraScript, []string{"rate_limit:" + key},
+		now, refillInterval(limit).Microseconds(), limit.Period.Microseconds())
+	if err != nil {
+		log.Warning(ctx, "ratelimit: taking token from bucket %s: %s", key, err)
+		return Result{Allowed: true}
+	}
+	retryAfter, ok := res.(int64)
+	if !ok {
+		log.Warning(ctx, "ratelimit: unexpected result %v for bucket %s", res, key)
+		return Result{Allowed: true}
+	}
+	if retryAfter <= 0 {
+		return Result{Allowed: true}
+	}
+	return Result{RetryAfter: time.Duration(retryAfter) * time.Microsecond}
+}
+
+// Key returns the key of the bucket for the given instance, client IP and
+// route.
+func Key(instanceID, clientIP, route string) string {
+	return fmt.Sprintf("%s:%s:%s", instanceID, clientIP, route)
+}
+
+// refillInterval returns how long it takes for the bucket to refill one
+// token. It's at least a microsecond, the resolution buckets are kept in.
+func refillInterval(limit Limit) time.Duration {
+	interval := limit.Period / time.Duration(limit.Requests)
+	if interval < time.Microsecond {
+		return time.Microsecond
+	}
+	return interval
+}
@@ -0,0 +1,155 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScripter runs gcraScript the way the cache would, atomically.
+type fakeScripter struct {
+	mu   sync.Mutex
+	tats map[string]int64
+	err  error
+}
+
+func (c *fakeScripter) Eval(_ context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if script != gcraScript {
+		return nil, errors.New("unexpected script")
+	}
+
+	now, interval, period := args[0].(int64), args[1].(int64), args[2].(int64)
+	tat, ok := c.tats[keys[0]]
+	if !ok || tat < now {
+		tat = now
+	}
+	newTAT := tat + interval
+	if allowAt := newTAT - period; now < allowAt {
+		return allowAt - now, nil
+	}
+	c.tats[keys[0]] = newTAT
+	return int64(0), nil
+}
+
+func TestAllow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	service := &Service{scripter: &fakeScripter{tats: make(map[string]int64)}, clock: clock}
+	limit := Limit{Requests: 4, Period: 10 * time.Second}
+
+	// a full bucket allows a burst of the whole limit
+	for i := 0; i < limit.Requests; i++ {
+		assert.True(t, service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed, "request %d", i)
+	}
+	result := service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 2500*time.Millisecond, result.RetryAfter)
+
+	// other keys have their own buckets
+	assert.True(t, service.Allow(ctx, "ins_1:5.6.7.8:bapi", limit).Allowed)
+
+	// the bucket refills one token at a time, rather than all at once
+	clock.Advance(time.Second)
+	result = service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1500*time.Millisecond, result.RetryAfter)
+
+	clock.Advance(1500 * time.Millisecond)
+	assert.True(t, service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed)
+	assert.False(t, service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed)
+
+	// and is full again after a whole period
+	clock.Advance(limit.Period)
+	for i := 0; i < limit.Requests; i++ {
+		assert.True(t, service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed, "request %d", i)
+	}
+	assert.False(t, service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed)
+}
+
+func TestAllowAcrossWindows(t *testing.T) {
+	t.Parallel()
+
+	// a fixed window would allow the whole limit at the end of a window and
+	// again at the start of the next one
+	ctx := context.Background()
+	clock := clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 9, 0, time.UTC))
+	service := &Service{scripter: &fakeScripter{tats: make(map[string]int64)}, clock: clock}
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+
+	allowed := 0
+	for i := 0; i < 2*limit.Requests; i++ {
+		if service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed {
+			allowed++
+		}
+		clock.Advance(100 * time.Millisecond)
+	}
+	assert.Equal(t, limit.Requests+1, allowed)
+}
+
+func TestAllowConcurrently(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	service := &Service{
+		scripter: &fakeScripter{tats: make(map[string]int64)},
+		clock:    clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	limit := Limit{Requests: 5, Period: time.Minute}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if service.Allow(ctx, "ins_1:1.2.3.4:bapi", limit).Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, limit.Requests, allowed)
+}
+
+func TestAllowWithoutCache(t *testing.T) {
+	t.Parallel()
+
+	service := &Service{
+		scripter: &fakeScripter{err: errors.New("connection refused")},
+		clock:    clockwork.NewFakeClock(),
+	}
+	assert.True(t, service.Allow(context.Background(), "ins_1:1.2.3.4:bapi", Limit{Requests: 1, Period: time.Second}).Allowed)
+}
+
+func TestRefillInterval(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 2500*time.Millisecond, refillInterval(Limit{Requests: 4, Period: 10 * time.Second}))
+	assert.Equal(t, time.Microsecond, refillInterval(Limit{Requests: 10_000_000, Period: time.Second}))
+}
+
+func TestLimitEnabled(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Limit{Requests: 10, Period: time.Second}.Enabled())
+	assert.False(t, Limit{Requests: 0, Period: time.Second}.Enabled())
+	assert.False(t, Limit{Requests: 10}.Enabled())
+}
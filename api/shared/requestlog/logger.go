@@ -0,0 +1,96 @@
+package requestlog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"clerk/pkg/ctx/trace"
+	"clerk/pkg/sampling"
+)
+
+// Request describes a served request, as it's written to the logs.
+type Request struct {
+	Method     string
+	Path       string
+	Route      string
+	Status     int
+	ErrorCode  string
+	Params     url.Values
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// Logger writes request log lines as JSON, one per line.
+type Logger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	rules SamplingRules
+}
+
+func NewLogger(out io.Writer, rules SamplingRules) *Logger {
+	return &Logger{
+		out:   out,
+		rules: rules,
+	}
+}
+
+// Log writes the log line of the request in ctx, along with the fields of
+// its entry. Requests are left out according to the sampling rules of their
+// route, unless they failed with a server error or have debug logging
+// enabled.
+func (l *Logger) Log(ctx context.Context, req Request) error {
+	entry, hasEntry := FromContext(ctx)
+	var debug bool
+	var debugMessages []string
+	if hasEntry {
+		debug, debugMessages = entry.Debug()
+	}
+
+	if req.Status < http.StatusInternalServerError && !debug && !sampling.IsIncluded(l.rules.Rate(req.Method, req.Route)) {
+		return nil
+	}
+
+	line := map[string]any{}
+	if hasEntry {
+		for key, value := range entry.Fields() {
+			line[key] = redactField(key, value)
+		}
+	}
+	if len(debugMessages) > 0 {
+		redacted := make([]string, len(debugMessages))
+		for i, message := range debugMessages {
+			redacted[i] = redactString(message)
+		}
+		line[DebugMessages] = redacted
+	}
+	// the request attributes take precedence over any fields of the same
+	// name
+	line["time"] = req.FinishedAt.UTC().Format(time.RFC3339Nano)
+	line["trace_id"] = trace.FromContext(ctx)
+	line["method"] = req.Method
+	line["path"] = redactString(req.Path)
+	line["route"] = req.Route
+	line["status"] = req.Status
+	line["duration_ms"] = req.FinishedAt.Sub(req.StartedAt).Milliseconds()
+	if req.ErrorCode != "" {
+		line["error_code"] = req.ErrorCode
+	}
+	if len(req.Params) > 0 {
+		line["params"] = redactValues(req.Params)
+	}
+
+	raw, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.out.Write(append(raw, '\n'))
+	return err
+}
@@ -0,0 +1,120 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the values, or parts of values, which must not be
+// logged.
+const Redacted = "[REDACTED]"
+
+// sensitiveKeyParts are the parts of field and parameter names whose values
+// are always redacted in full.
+var sensitiveKeyParts = []string{
+	"authorization",
+	"cookie",
+	"password",
+	"secret",
+	"token",
+}
+
+// sensitivePatterns match personal information and credentials inside
+// otherwise harmless values.
+var sensitivePatterns = []*regexp.Regexp{
+	// email addresses
+	regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	// JWTs
+	regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`),
+	// API keys
+	regexp.MustCompile(`\b(?:sk|ak)_(?:live|test)_[A-Za-z0-9]+`),
+	// phone numbers in international format
+	regexp.MustCompile(`\+[0-9][0-9 ()\-.]{6,}[0-9]`),
+}
+
+// Redact returns a copy of value, in the form it's written to the logs, with
+// the values of sensitive keys and any email addresses, phone numbers and
+// tokens replaced by Redacted.
+//
+// Values other than strings, numbers, booleans, maps and slices are
+// converted through their JSON representation first, so that their fields
+// are redacted too.
+func Redact(value any) any {
+	switch v := value.(type) {
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64:
+		return v
+	case string:
+		return redactString(v)
+	case url.Values:
+		return redactValues(v)
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, value := range v {
+			redacted[key] = redactField(key, value)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, value := range v {
+			redacted[i] = Redact(value)
+		}
+		return redacted
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		// a value we can't inspect is a value we can't log
+		return Redacted
+	}
+	var decoded any
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return Redacted
+	}
+	return Redact(decoded)
+}
+
+func redactField(key string, value any) any {
+	if value != nil && isSensitiveKey(key) {
+		return Redacted
+	}
+	return Redact(value)
+}
+
+func redactValues(values url.Values) map[string]any {
+	redacted := make(map[string]any, len(values))
+	for key, vals := range values {
+		if isSensitiveKey(key) {
+			redacted[key] = Redacted
+			continue
+		}
+		if len(vals) == 1 {
+			redacted[key] = redactString(vals[0])
+			continue
+		}
+		redactedVals := make([]any, len(vals))
+		for i, val := range vals {
+			redactedVals[i] = redactString(val)
+		}
+		redacted[key] = redactedVals
+	}
+	return redacted
+}
+
+func redactString(s string) string {
+	for _, pattern := range sensitivePatterns {
+		s = pattern.ReplaceAllString(s, Redacted)
+	}
+	return s
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Package requestlog emits a structured JSON log line for every request
+// served by the APIs.
+//
+// Handlers and middleware attach fields to the log line of the current
+// request with Add. Once the request is served, the line is written along
+// with the method, route, status and parameters of the request, unless the
+// sampling rules of its route leave it out.
+//
+// Every value is redacted before it's written, so that email addresses,
+// phone numbers and tokens never end up in the logs, regardless of which
+// field or parameter they were logged under.
+//
+// Requests with debug logging enabled are never sampled out, and carry the
+// messages recorded with Debugf on their line.
+package requestlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// The fields which are attached to request log lines.
+const (
+	ActorID            = "actor_id"
+	Activity           = "activity"
+	ClerkIOSSDKVersion = "clerk_ios_sdk_version"
+	ClerkJSVersion     = "clerk_js_version"
+	ClerkSDKVersion    = "clerk_sdk_version"
+	ClerkTestingToken  = "clerk_testing_token"
+	ClientID           = "client_id"
+	DBQueries          = "db_queries"
+	DBStats            = "db_stats"
+	DebugLogging       = "debug_logging"
+	DebugMessages      = "debug_messages"
+	DevBrowserID       = "dev_browser_id"
+	DomainName         = "domain_name"
+	EnvironmentType    = "environment_type"
+	InstanceID         = "instance_id"
+	MaintenanceMode    = "maintenance_mode"
+	RequestInfo        = "request_info"
+	RotatingToken      = "rotating_token"
+	SessionID          = "session_id"
+	UserID             = "user_id"
+)
+
+// Entry holds the fields attached to the log line of a single request. It's
+// safe for concurrent use.
+type Entry struct {
+	mu            sync.Mutex
+	fields        map[string]any
+	debug         bool
+	debugMessages []string
+}
+
+func newEntry() *Entry {
+	return &Entry{fields: make(map[string]any)}
+}
+
+// Fields returns a copy of the fields attached to the entry.
+func (e *Entry) Fields() map[string]any {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fields := make(map[string]any, len(e.fields))
+	for key, value := range e.fields {
+		fields[key] = value
+	}
+	return fields
+}
+
+// Debug returns whether debug logging is enabled for the request, along
+// with the debug messages recorded so far.
+func (e *Entry) Debug() (bool, []string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.debug, append([]string(nil), e.debugMessages...)
+}
+
+func (e *Entry) set(key string, value any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fields[key] = value
+}
+
+type entryKey struct{}
+
+// NewContext returns a copy of ctx with a new, empty entry, along with the
+// entry itself.
+func NewContext(ctx context.Context) (context.Context, *Entry) {
+	entry := newEntry()
+	return context.WithValue(ctx, entryKey{}, entry), entry
+}
+
+// FromContext returns the entry of the request in ctx, if any.
+func FromContext(ctx context.Context) (*Entry, bool) {
+	entry, ok := ctx.Value(entryKey{}).(*Entry)
+	return entry, ok
+}
+
+// Add attaches the field to the log line of the request in ctx. Values are
+// redacted when the line is written, so they can be added as is. Adding a
+// field which is already set replaces its value.
+func Add(ctx context.Context, key string, value any) {
+	if entry, ok := FromContext(ctx); ok {
+		entry.set(key, value)
+	}
+}
+
+// EnableDebug turns on debug logging for the request in ctx.
+func EnableDebug(ctx context.Context) {
+	entry, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	entry.debug = true
+	entry.fields[DebugLogging] = true
+}
+
+// Debugf records a debug message on the log line of the request in ctx, if
+// debug logging is enabled for it. Like every other value, messages are
+// redacted when the line is written.
+func Debugf(ctx context.Context, format string, args ...any) {
+	entry, ok := FromContext(ctx)
+	if !ok {
+		return
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if entry.debug {
+		entry.debugMessages = append(entry.debugMessages, fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,83 @@
+package requestlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SamplingRule sets the share of the requests to a route which are logged.
+type SamplingRule struct {
+	// Method is the HTTP method of the requests the rule applies to. The
+	// rule applies to all methods when empty.
+	Method string `json:"method,omitempty"`
+
+	// Route is the route pattern the rule applies to, e.g.
+	// "/v1/client/sessions/{sessionID}/tokens".
+	Route string `json:"route"`
+
+	// Rate is the share of the requests which are logged, from 0 to 1.
+	Rate float64 `json:"rate"`
+}
+
+// SamplingRules decide which requests are logged. Requests which match no
+// rule are always logged.
+type SamplingRules []SamplingRule
+
+// ParseSamplingRules parses rules from their JSON representation, e.g.
+// `[{"method":"GET","route":"/v1/environment","rate":0.1}]`. An empty string
+// results in no rules.
+func ParseSamplingRules(s string) (SamplingRules, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var rules SamplingRules
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil, fmt.Errorf("requestlog: parsing sampling rules: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.Route == "" {
+			return nil, fmt.Errorf("requestlog: sampling rule without route")
+		}
+		if rule.Rate < 0 || rule.Rate > 1 {
+			return nil, fmt.Errorf("requestlog: sampling rate %v of route %s is out of range", rule.Rate, rule.Route)
+		}
+	}
+	return rules, nil
+}
+
+// Rate returns the share of the requests with the given method and route
+// pattern which are logged. Later rules take precedence, so that rules can
+// be overridden by appending to them.
+func (rules SamplingRules) Rate(method, route string) float64 {
+	route = normalizeRoute(route)
+	rate := 1.0
+	for _, rule := range rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if normalizeRoute(rule.Route) == route {
+			rate = rule.Rate
+		}
+	}
+	return rate
+}
+
+// normalizeRoute drops the trailing slashes and wildcards which chi adds to
+// the patterns of routes declared in sub-routers, so that
+// "/v1/client/sessions/{sessionID}/tokens/" matches
+// "/v1/client/sessions/{sessionID}/tokens".
+func normalizeRoute(route string) string {
+	for {
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(route, "/*"), "/")
+		if trimmed == route {
+			break
+		}
+		route = trimmed
+	}
+	if route == "" {
+		return "/"
+	}
+	return route
+}
@@ -0,0 +1,201 @@
+package restrictions
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/ctx/activity"
+	"clerk/pkg/ctx/request_info"
+	"clerk/pkg/set"
+	clerktime "clerk/pkg/time"
+	"clerk/utils/database"
+	"clerk/utils/log"
+)
+
+// The types of IP restriction rules. Each rule blocks requests coming from
+// either a range of IP addresses, an autonomous system or a country.
+const (
+	IPRuleTypeCIDR    = "cidr"
+	IPRuleTypeASN     = "asn"
+	IPRuleTypeCountry = "country"
+)
+
+var IPRuleTypes = set.New(IPRuleTypeCIDR, IPRuleTypeASN, IPRuleTypeCountry)
+
+// The attempts IP restriction rules are enforced on.
+const (
+	IPRestrictedSignIn = "sign_in"
+	IPRestrictedSignUp = "sign_up"
+)
+
+// ipRulesCacheTTL is how long the rules of an instance are cached for. The
+// cache is also cleared whenever the rules change, so this only bounds how
+// stale rules can get if that fails.
+const ipRulesCacheTTL = 10 * time.Minute
+
+// IPRule is a rule which blocks requests, in the form it's evaluated and
+// cached in.
+type IPRule struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type cachedIPRules struct {
+	// Loaded tells apart an instance without rules from a cache miss.
+	Loaded bool     `json:"loaded"`
+	Rules  []IPRule `json:"rules"`
+}
+
+// IPSubject is where a request comes from.
+type IPSubject struct {
+	IPAddress string
+	ASN       string
+	Country   string
+}
+
+// NormalizeIPRuleValue validates the value of a rule of the given type and
+// returns it in the form it's evaluated in. CIDR ranges are masked, so that
+// single IP addresses are accepted too, ASNs are stored without the "AS"
+// prefix and countries as upper case ISO 3166-1 alpha-2 codes.
+func NormalizeIPRuleValue(ruleType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch ruleType {
+	case IPRuleTypeCIDR:
+		if addr, err := netip.ParseAddr(value); err == nil {
+			return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return "", fmt.Errorf("restrictions: invalid cidr %q: %w", value, err)
+		}
+		return prefix.Masked().String(), nil
+	case IPRuleTypeASN:
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(value), "AS"), 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("restrictions: invalid asn %q: %w", value, err)
+		}
+		return strconv.FormatUint(asn, 10), nil
+	case IPRuleTypeCountry:
+		if len(value) != 2 || strings.Trim(strings.ToUpper(value), "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+			return "", fmt.Errorf("restrictions: invalid country code %q", value)
+		}
+		return strings.ToUpper(value), nil
+	default:
+		return "", fmt.Errorf("restrictions: unknown ip rule type %q", ruleType)
+	}
+}
+
+// EnforceIPRules blocks the request in context if it comes from an IP
+// address, autonomous system or country which the instance has blocked.
+// Blocked attempts are recorded as events of the instance.
+//
+// Rules are not enforced when the origin of the request is unknown, and
+// the request is let through if the rules can't be loaded.
+func (s *Service) EnforceIPRules(ctx context.Context, exec database.Executor, instance *model.Instance, attempt string) apierror.Error {
+	subject := ipSubjectFromContext(ctx)
+	if subject.IPAddress == "" {
+		return nil
+	}
+
+	rules, err := s.ipRules(ctx, exec, instance.ID)
+	if err != nil {
+		log.Warning(ctx, "restrictions: loading ip rules of instance %s: %s", instance.ID, err)
+		return nil
+	}
+
+	rule := matchIPRules(rules, subject)
+	if rule == nil {
+		return nil
+	}
+
+	payload := serialize.IPRestrictionBlock(rule.ID, rule.Type, rule.Value, attempt, subject.IPAddress, subject.ASN, subject.Country, clerktime.UnixMilli(s.clock.Now().UTC()))
+	if err := s.eventsService.IPRestrictionBlocked(ctx, exec, instance, payload); err != nil {
+		// the attempt is blocked regardless
+		log.Warning(ctx, "restrictions: recording ip block for rule %s: %s", rule.ID, err)
+	}
+	return apierror.NetworkNotAllowedAccess()
+}
+
+// ClearIPRulesCache drops the cached rules of the given instance, so that
+// changes to them take effect right away.
+func (s *Service) ClearIPRulesCache(ctx context.Context, instanceID string) {
+	if err := s.cache.Delete(ctx, ipRulesCacheKey(instanceID)); err != nil {
+		log.Warning(ctx, "restrictions: clearing cached ip rules of instance %s: %s", instanceID, err)
+	}
+}
+
+// ipRules returns the rules of the given instance, from the cache if
+// possible.
+func (s *Service) ipRules(ctx context.Context, exec database.Executor, instanceID string) ([]IPRule, error) {
+	key := ipRulesCacheKey(instanceID)
+
+	var cached cachedIPRules
+	if err := s.cache.Get(ctx, key, &cached); err != nil {
+		log.Warning(ctx, "restrictions: fetching cached ip rules of instance %s: %s", instanceID, err)
+	} else if cached.Loaded {
+		return cached.Rules, nil
+	}
+
+	rules, err := s.ipRestrictionRuleRepo.FindAllByInstance(ctx, exec, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	cached = cachedIPRules{Loaded: true, Rules: make([]IPRule, len(rules))}
+	for i, rule := range rules {
+		cached.Rules[i] = IPRule{ID: rule.ID, Type: rule.RuleType, Value: rule.Value}
+	}
+	if err := s.cache.Set(ctx, key, cached, ipRulesCacheTTL); err != nil {
+		log.Warning(ctx, "restrictions: caching ip rules of instance %s: %s", instanceID, err)
+	}
+	return cached.Rules, nil
+}
+
+// matchIPRules returns the first of the rules which blocks the given
+// subject, or nil if there's none.
+func matchIPRules(rules []IPRule, subject IPSubject) *IPRule {
+	addr, addrErr := netip.ParseAddr(subject.IPAddress)
+	for i, rule := range rules {
+		var matches bool
+		switch rule.Type {
+		case IPRuleTypeCIDR:
+			prefix, err := netip.ParsePrefix(rule.Value)
+			matches = err == nil && addrErr == nil && prefix.Contains(addr.Unmap())
+		case IPRuleTypeASN:
+			matches = subject.ASN != "" && strings.TrimPrefix(strings.ToUpper(subject.ASN), "AS") == rule.Value
+		case IPRuleTypeCountry:
+			matches = subject.Country != "" && strings.EqualFold(subject.Country, rule.Value)
+		}
+		if matches {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func ipSubjectFromContext(ctx context.Context) IPSubject {
+	var subject IPSubject
+	if deviceActivity := activity.FromContext(ctx); deviceActivity != nil {
+		subject.IPAddress = deviceActivity.IPAddress.String
+		subject.Country = deviceActivity.Country.String
+	}
+	if requestInfo := request_info.FromContext(ctx); requestInfo != nil {
+		subject.ASN = requestInfo.ASN
+		if subject.IPAddress == "" {
+			subject.IPAddress = requestInfo.ClientIP
+		}
+	}
+	return subject
+}
+
+func ipRulesCacheKey(instanceID string) string {
+	return "ip_rules:" + instanceID
+}
@@ -0,0 +1,73 @@
+package restrictions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeIPRuleValue(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		ruleType string
+		value    string
+		want     string
+	}{
+		{IPRuleTypeCIDR, "10.1.2.3/8", "10.0.0.0/8"},
+		{IPRuleTypeCIDR, "192.168.1.1", "192.168.1.1/32"},
+		{IPRuleTypeCIDR, "2001:db8::1/32", "2001:db8::/32"},
+		{IPRuleTypeASN, "AS13335", "13335"},
+		{IPRuleTypeASN, " 13335 ", "13335"},
+		{IPRuleTypeCountry, "gr", "GR"},
+	} {
+		got, err := NormalizeIPRuleValue(tc.ruleType, tc.value)
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.want, got)
+	}
+
+	for _, tc := range []struct {
+		ruleType string
+		value    string
+	}{
+		{IPRuleTypeCIDR, "10.0.0.0/33"},
+		{IPRuleTypeCIDR, "example.com"},
+		{IPRuleTypeASN, "AS-1"},
+		{IPRuleTypeASN, "4294967296"},
+		{IPRuleTypeCountry, "GRC"},
+		{IPRuleTypeCountry, "1A"},
+		{"city", "Athens"},
+	} {
+		_, err := NormalizeIPRuleValue(tc.ruleType, tc.value)
+		assert.Error(t, err, tc.value)
+	}
+}
+
+func TestMatchIPRules(t *testing.T) {
+	t.Parallel()
+	rules := []IPRule{
+		{ID: "cidr", Type: IPRuleTypeCIDR, Value: "10.0.0.0/8"},
+		{ID: "asn", Type: IPRuleTypeASN, Value: "13335"},
+		{ID: "country", Type: IPRuleTypeCountry, Value: "KP"},
+	}
+
+	for _, tc := range []struct {
+		subject IPSubject
+		want    string
+	}{
+		{IPSubject{IPAddress: "10.20.30.40"}, "cidr"},
+		{IPSubject{IPAddress: "::ffff:10.20.30.40"}, "cidr"},
+		{IPSubject{IPAddress: "1.1.1.1", ASN: "AS13335"}, "asn"},
+		{IPSubject{IPAddress: "175.45.176.1", Country: "kp"}, "country"},
+		{IPSubject{IPAddress: "8.8.8.8", ASN: "15169", Country: "US"}, ""},
+		{IPSubject{IPAddress: "not an ip"}, ""},
+	} {
+		rule := matchIPRules(rules, tc.subject)
+		if tc.want == "" {
+			assert.Nil(t, rule, tc.subject.IPAddress)
+			continue
+		}
+		require.NotNil(t, rule, tc.subject.IPAddress)
+		assert.Equal(t, tc.want, rule.ID)
+	}
+}
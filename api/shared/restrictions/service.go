@@ -6,28 +6,45 @@ import (
 	"fmt"
 
 	"clerk/api/shared/emailquality"
+	"clerk/api/shared/events"
+	"clerk/pkg/cache"
 	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	"clerk/pkg/emailaddress"
 	"clerk/pkg/sentry"
 	usersettingsmodel "clerk/pkg/usersettings/model"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
 )
 
 type Service struct {
-	allowlistRepo       *repository.Allowlist
-	blocklistRepo       *repository.Blocklist
-	identificationRepo  *repository.Identification
+	cache               cache.Cache
+	clock               clockwork.Clock
 	emailQualityChecker *emailquality.EmailQuality
+
+	// services
+	eventsService *events.Service
+
+	// repositories
+	allowlistRepo         *repository.Allowlist
+	blocklistRepo         *repository.Blocklist
+	identificationRepo    *repository.Identification
+	ipRestrictionRuleRepo *repository.IPRestrictionRules
 }
 
-func NewService(emailQualityChecker *emailquality.EmailQuality) *Service {
+func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		allowlistRepo:       repository.NewAllowlist(),
-		blocklistRepo:       repository.NewBlocklist(),
-		identificationRepo:  repository.NewIdentification(),
-		emailQualityChecker: emailQualityChecker,
+		cache:                 deps.Cache(),
+		clock:                 deps.Clock(),
+		emailQualityChecker:   deps.EmailQualityChecker(),
+		eventsService:         events.NewService(deps),
+		allowlistRepo:         deps.Repositories().Allowlist,
+		blocklistRepo:         deps.Repositories().Blocklist,
+		identificationRepo:    deps.Repositories().Identification,
+		ipRestrictionRuleRepo: deps.Repositories().IPRestrictionRules,
 	}
 }
 
@@ -0,0 +1,248 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"clerk/model"
+	"clerk/utils/database"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+var ErrLogoutNotSupported = errors.New("saml_connection doesn't support single logout")
+
+// the maximum size of an inflated logout request we're willing to read
+const maxLogoutRequestSize = 64 * 1024
+
+// The signature algorithms IdPs may sign HTTP-Redirect binding messages with.
+var redirectSignatureAlgorithms = map[string]crypto.Hash{
+	dsig.RSASHA1SignatureMethod:   crypto.SHA1,
+	dsig.RSASHA256SignatureMethod: crypto.SHA256,
+	dsig.RSASHA512SignatureMethod: crypto.SHA512,
+}
+
+// ServiceProviderForLogout returns the service provider of an active
+// connection, configured to exchange single logout messages with its IdP.
+func (s *SAML) ServiceProviderForLogout(ctx context.Context, exec database.Executor, env *model.Env, connectionID string) (*saml.ServiceProvider, *model.SAMLConnection, error) {
+	sp, conn, err := s.ServiceProviderForActiveConnection(ctx, exec, env, connectionID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !conn.IdpSloURL.Valid || conn.IdpSloURL.String == "" ||
+		sp.IDPMetadata == nil || len(sp.IDPMetadata.IDPSSODescriptors) == 0 {
+		return nil, nil, ErrLogoutNotSupported
+	}
+
+	sloURL, err := url.Parse(conn.SLOURL(env.Domain))
+	if err != nil {
+		return nil, nil, err
+	}
+	sp.SloURL = *sloURL
+	sp.IDPMetadata.IDPSSODescriptors[0].SingleLogoutServices = []saml.Endpoint{
+		{
+			Binding:  saml.HTTPRedirectBinding,
+			Location: conn.IdpSloURL.String,
+		},
+	}
+
+	return sp, conn, nil
+}
+
+// LogoutRequestURL returns the IdP URL to redirect the user to, in order to
+// sign them out of the IdP as well.
+func LogoutRequestURL(sp *saml.ServiceProvider, nameID, relayState string) (*url.URL, error) {
+	return sp.MakeRedirectLogoutRequest(nameID, relayState)
+}
+
+// LogoutResponseURL returns the IdP URL to redirect the user to, in order to
+// respond to a LogoutRequest of the IdP.
+func LogoutResponseURL(sp *saml.ServiceProvider, logoutRequestID, relayState string) (*url.URL, error) {
+	return sp.MakeRedirectLogoutResponse(logoutRequestID, relayState)
+}
+
+// ValidateLogoutResponse validates the LogoutResponse the IdP sent back after
+// a LogoutRequest of ours.
+func ValidateLogoutResponse(sp *saml.ServiceProvider, r *http.Request) error {
+	return sp.ValidateLogoutResponseRequest(r)
+}
+
+// ParseLogoutRequest parses and validates a LogoutRequest sent by the IdP,
+// with either the HTTP-Redirect or the HTTP-POST binding.
+//
+// NOTE: this is security-critical, as a LogoutRequest revokes the sessions
+// of the user it refers to. Unsigned requests are always rejected.
+func ParseLogoutRequest(sp *saml.ServiceProvider, r *http.Request, now time.Time) (*saml.LogoutRequest, error) {
+	certs, err := idpCertificates(sp)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(r.Form.Get("SAMLRequest"))
+	if err != nil {
+		return nil, fmt.Errorf("saml: decoding logout request: %w", err)
+	}
+
+	if r.Method == http.MethodGet {
+		if err := verifyRedirectSignature(r.URL.RawQuery, "SAMLRequest", certs); err != nil {
+			return nil, err
+		}
+		raw, err = io.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(raw)), maxLogoutRequestSize))
+		if err != nil {
+			return nil, fmt.Errorf("saml: inflating logout request: %w", err)
+		}
+	} else {
+		raw, err = verifyXMLSignature(raw, certs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var logoutRequest saml.LogoutRequest
+	if err := xml.Unmarshal(raw, &logoutRequest); err != nil {
+		return nil, fmt.Errorf("saml: unmarshalling logout request: %w", err)
+	}
+
+	if err := validateLogoutRequest(sp, &logoutRequest, now); err != nil {
+		return nil, err
+	}
+	return &logoutRequest, nil
+}
+
+func validateLogoutRequest(sp *saml.ServiceProvider, logoutRequest *saml.LogoutRequest, now time.Time) error {
+	if logoutRequest.Issuer == nil || logoutRequest.Issuer.Value != sp.IDPMetadata.EntityID {
+		return fmt.Errorf("saml: logout request %s not issued by %s", logoutRequest.ID, sp.IDPMetadata.EntityID)
+	}
+	if logoutRequest.Destination != "" && logoutRequest.Destination != sp.SloURL.String() {
+		return fmt.Errorf("saml: logout request %s destination %s doesn't match %s", logoutRequest.ID, logoutRequest.Destination, sp.SloURL.String())
+	}
+	if logoutRequest.IssueInstant.After(now.Add(saml.MaxClockSkew)) {
+		return fmt.Errorf("saml: logout request %s issued in the future", logoutRequest.ID)
+	}
+	if logoutRequest.IssueInstant.Add(saml.MaxIssueDelay).Before(now) {
+		return fmt.Errorf("saml: logout request %s expired", logoutRequest.ID)
+	}
+	if logoutRequest.NotOnOrAfter != nil && !now.Before(*logoutRequest.NotOnOrAfter) {
+		return fmt.Errorf("saml: logout request %s expired", logoutRequest.ID)
+	}
+	if logoutRequest.NameID == nil || logoutRequest.NameID.Value == "" {
+		return fmt.Errorf("saml: logout request %s has no NameID", logoutRequest.ID)
+	}
+	return nil
+}
+
+// verifyRedirectSignature verifies the signature of an HTTP-Redirect binding
+// message, which is computed over the URL-encoded query parameters, as they
+// were sent by the IdP.
+func verifyRedirectSignature(rawQuery, messageParam string, certs []*x509.Certificate) error {
+	values := make(map[string]string)
+	for _, part := range strings.Split(rawQuery, "&") {
+		key, value, _ := strings.Cut(part, "=")
+		values[key] = value
+	}
+	if values["Signature"] == "" || values["SigAlg"] == "" {
+		return fmt.Errorf("saml: %s is not signed", messageParam)
+	}
+
+	signed := messageParam + "=" + values[messageParam]
+	if relayState, ok := values["RelayState"]; ok {
+		signed += "&RelayState=" + relayState
+	}
+	signed += "&SigAlg=" + values["SigAlg"]
+
+	sigAlg, err := url.QueryUnescape(values["SigAlg"])
+	if err != nil {
+		return fmt.Errorf("saml: decoding SigAlg: %w", err)
+	}
+	hash, ok := redirectSignatureAlgorithms[sigAlg]
+	if !ok {
+		return fmt.Errorf("saml: unsupported signature algorithm %s", sigAlg)
+	}
+
+	encodedSignature, err := url.QueryUnescape(values["Signature"])
+	if err != nil {
+		return fmt.Errorf("saml: decoding Signature: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("saml: decoding Signature: %w", err)
+	}
+
+	digest := hash.New()
+	digest.Write([]byte(signed))
+	for _, cert := range certs {
+		publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if rsa.VerifyPKCS1v15(publicKey, hash, digest.Sum(nil), signature) == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("saml: invalid %s signature", messageParam)
+}
+
+// verifyXMLSignature verifies the enveloped signature of an HTTP-POST binding
+// message and returns the signed element only, so that nothing outside of the
+// signature is ever trusted.
+func verifyXMLSignature(raw []byte, certs []*x509.Certificate) ([]byte, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(raw); err != nil {
+		return nil, fmt.Errorf("saml: parsing logout request: %w", err)
+	}
+	if doc.Root() == nil {
+		return nil, fmt.Errorf("saml: empty logout request")
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	validationContext.IdAttribute = "ID"
+	signed, err := validationContext.Validate(doc.Root())
+	if err != nil {
+		return nil, fmt.Errorf("saml: invalid logout request signature: %w", err)
+	}
+
+	signedDoc := etree.NewDocument()
+	signedDoc.SetRoot(signed.Copy())
+	return signedDoc.WriteToBytes()
+}
+
+func idpCertificates(sp *saml.ServiceProvider) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, keyDescriptor := range sp.IDPMetadata.IDPSSODescriptors[0].KeyDescriptors {
+		if keyDescriptor.Use != "" && keyDescriptor.Use != "signing" {
+			continue
+		}
+		for _, x509Certificate := range keyDescriptor.KeyInfo.X509Data.X509Certificates {
+			data := strings.Join(strings.Fields(x509Certificate.Data), "")
+			der, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return nil, fmt.Errorf("saml: decoding IdP certificate: %w", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("saml: parsing IdP certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("saml: no IdP signing certificate")
+	}
+	return certs, nil
+}
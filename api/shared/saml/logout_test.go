@@ -0,0 +1,192 @@
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const logoutTestIdPEntityID = "https://idp.example.com/metadata"
+
+// logoutTestServiceProvider returns a service provider which trusts the
+// signing certificate of the given IdP key.
+func logoutTestServiceProvider(t *testing.T, key *rsa.PrivateKey) *saml.ServiceProvider {
+	t.Helper()
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	sloURL, err := url.Parse("https://clerk.example.com/v1/saml/slo/samlc_1")
+	require.NoError(t, err)
+
+	return &saml.ServiceProvider{
+		SloURL: *sloURL,
+		IDPMetadata: &saml.EntityDescriptor{
+			EntityID: logoutTestIdPEntityID,
+			IDPSSODescriptors: []saml.IDPSSODescriptor{{
+				SSODescriptor: saml.SSODescriptor{RoleDescriptor: saml.RoleDescriptor{
+					KeyDescriptors: []saml.KeyDescriptor{{
+						Use: "signing",
+						KeyInfo: saml.KeyInfo{X509Data: saml.X509Data{
+							X509Certificates: []saml.X509Certificate{{Data: base64.StdEncoding.EncodeToString(der)}},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+}
+
+func logoutTestRequest(t *testing.T, issuer string, issueInstant time.Time) []byte {
+	t.Helper()
+
+	logoutRequest := saml.LogoutRequest{
+		ID:           "id-1",
+		Version:      "2.0",
+		IssueInstant: issueInstant,
+		Destination:  "https://clerk.example.com/v1/saml/slo/samlc_1",
+		Issuer:       &saml.Issuer{Value: issuer},
+		NameID:       &saml.NameID{Value: "user@example.com"},
+	}
+	doc := etree.NewDocument()
+	doc.SetRoot(logoutRequest.Element())
+	raw, err := doc.WriteToBytes()
+	require.NoError(t, err)
+	return raw
+}
+
+// redirectLogoutRequest encodes the logout request with the HTTP-Redirect
+// binding, signed with the given key, if any.
+func redirectLogoutRequest(t *testing.T, logoutRequest []byte, key *rsa.PrivateKey) *http.Request {
+	t.Helper()
+
+	var deflated bytes.Buffer
+	writer, err := flate.NewWriter(&deflated, flate.BestCompression)
+	require.NoError(t, err)
+	_, err = writer.Write(logoutRequest)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(deflated.Bytes())) +
+		"&RelayState=state" +
+		"&SigAlg=" + url.QueryEscape(dsig.RSASHA256SignatureMethod)
+	if key != nil {
+		digest := sha256.Sum256([]byte(query))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/saml/slo/samlc_1?"+query, nil)
+	require.NoError(t, r.ParseForm())
+	return r
+}
+
+func TestParseLogoutRequest(t *testing.T) {
+	t.Parallel()
+
+	idpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	sp := logoutTestServiceProvider(t, idpKey)
+	now := time.Now().UTC()
+
+	logoutRequest, err := ParseLogoutRequest(sp, redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, now), idpKey), now)
+	require.NoError(t, err)
+	assert.Equal(t, "id-1", logoutRequest.ID)
+	assert.Equal(t, "user@example.com", logoutRequest.NameID.Value)
+
+	for _, tt := range []struct {
+		name    string
+		r       *http.Request
+		wantErr string
+	}{
+		{
+			name:    "unsigned",
+			r:       redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, now), nil),
+			wantErr: "is not signed",
+		},
+		{
+			name:    "signed by another key",
+			r:       redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, now), otherKey),
+			wantErr: "invalid SAMLRequest signature",
+		},
+		{
+			name:    "issued by another IdP",
+			r:       redirectLogoutRequest(t, logoutTestRequest(t, "https://evil.example.com/metadata", now), idpKey),
+			wantErr: "not issued by",
+		},
+		{
+			name:    "expired",
+			r:       redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, now.Add(-time.Hour)), idpKey),
+			wantErr: "expired",
+		},
+		{
+			name:    "issued in the future",
+			r:       redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, now.Add(time.Hour)), idpKey),
+			wantErr: "issued in the future",
+		},
+		{
+			name: "unsigned HTTP-POST binding",
+			r: func() *http.Request {
+				form := url.Values{"SAMLRequest": {base64.StdEncoding.EncodeToString(logoutTestRequest(t, logoutTestIdPEntityID, now))}}
+				r := httptest.NewRequest(http.MethodPost, "/v1/saml/slo/samlc_1", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				require.NoError(t, r.ParseForm())
+				return r
+			}(),
+			wantErr: "invalid logout request signature",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseLogoutRequest(sp, tt.r, now)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyRedirectSignatureTamperedRelayState(t *testing.T) {
+	t.Parallel()
+
+	idpKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	sp := logoutTestServiceProvider(t, idpKey)
+	certs, err := idpCertificates(sp)
+	require.NoError(t, err)
+
+	r := redirectLogoutRequest(t, logoutTestRequest(t, logoutTestIdPEntityID, time.Now()), idpKey)
+	require.NoError(t, verifyRedirectSignature(r.URL.RawQuery, "SAMLRequest", certs))
+
+	// the relay state is covered by the signature as well
+	tampered := strings.Replace(r.URL.RawQuery, "RelayState=state", "RelayState=other", 1)
+	assert.Error(t, verifyRedirectSignature(tampered, "SAMLRequest", certs))
+}
@@ -0,0 +1,93 @@
+package saml
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"clerk/model"
+	"clerk/utils/database"
+
+	"github.com/crewjam/saml"
+)
+
+// DomainProvisioning is what an IdP asserts about the organization a user
+// belongs to, which is used to provision an organization domain for them.
+type DomainProvisioning struct {
+	// Domain is the corporate domain the IdP vouches for.
+	Domain string
+	// OrganizationID is the organization which owns the connection.
+	OrganizationID string
+}
+
+// DomainProvisioningFromAssertion returns the organization domain
+// provisioning of the assertion, if the connection has it enabled.
+//
+// Only connections owned by an organization provision domains, and only for
+// that organization, when the groups of the assertion include its slug. An
+// IdP is trusted for the organization which configured it, never for other
+// organizations of the instance.
+//
+// The IdP is also only trusted for the domain of the connection, or any of
+// its subdomains if the connection allows them, and only if the email
+// address of the user belongs to it. Anything else is ignored, so that an
+// IdP can't claim domains it doesn't own.
+func (s *SAML) DomainProvisioningFromAssertion(ctx context.Context, exec database.Executor, assertion *saml.Assertion, connection *model.SAMLConnection, emailAddress string) (*DomainProvisioning, error) {
+	if !connection.OrganizationID.Valid || !connection.OrganizationDomainEnrollmentMode.Valid {
+		return nil, nil
+	}
+	mapping := connection.AttributeMapping
+	if mapping.OrganizationDomain == "" || mapping.OrganizationGroups == "" {
+		return nil, nil
+	}
+
+	domain, groups := provisioningAttributes(assertion, mapping.OrganizationDomain, mapping.OrganizationGroups)
+	if len(groups) == 0 || !isConnectionDomain(connection, domain) || samlDomain(emailAddress) != domain {
+		return nil, nil
+	}
+
+	organization, err := s.organizationRepo.QueryByIDAndInstance(ctx, exec, connection.OrganizationID.String, connection.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("saml/domainProvisioning: fetching organization of connection %s: %w", connection.ID, err)
+	}
+	if organization == nil || !slices.Contains(groups, organization.Slug) {
+		return nil, nil
+	}
+
+	return &DomainProvisioning{Domain: domain, OrganizationID: organization.ID}, nil
+}
+
+// provisioningAttributes returns the domain and the groups which the
+// assertion carries in the given attributes.
+func provisioningAttributes(assertion *saml.Assertion, domainAttribute, groupsAttribute string) (string, []string) {
+	var (
+		domain string
+		groups []string
+	)
+	for _, statement := range assertion.AttributeStatements {
+		for _, attribute := range statement.Attributes {
+			switch attribute.Name {
+			case domainAttribute:
+				if len(attribute.Values) > 0 {
+					domain = strings.ToLower(strings.TrimSpace(attribute.Values[0].Value))
+				}
+			case groupsAttribute:
+				for _, value := range attribute.Values {
+					if group := strings.TrimSpace(value.Value); group != "" {
+						groups = append(groups, group)
+					}
+				}
+			}
+		}
+	}
+	return domain, groups
+}
+
+func isConnectionDomain(connection *model.SAMLConnection, domain string) bool {
+	if domain == "" {
+		return false
+	}
+	return domain == connection.Domain ||
+		(connection.AllowSubdomains && strings.HasSuffix(domain, "."+connection.Domain))
+}
@@ -0,0 +1,77 @@
+package saml
+
+import (
+	"context"
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/crewjam/saml"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func provisioningAssertion(domain string, groups ...string) *saml.Assertion {
+	groupValues := make([]saml.AttributeValue, len(groups))
+	for i, group := range groups {
+		groupValues[i] = saml.AttributeValue{Value: group}
+	}
+	return &saml.Assertion{AttributeStatements: []saml.AttributeStatement{{
+		Attributes: []saml.Attribute{
+			{Name: "org_domain", Values: []saml.AttributeValue{{Value: domain}}},
+			{Name: "org_groups", Values: groupValues},
+		},
+	}}}
+}
+
+func TestProvisioningAttributes(t *testing.T) {
+	t.Parallel()
+
+	domain, groups := provisioningAttributes(provisioningAssertion(" Example.COM ", "acme", " ", " globex "), "org_domain", "org_groups")
+	assert.Equal(t, "example.com", domain)
+	assert.Equal(t, []string{"acme", "globex"}, groups)
+
+	domain, groups = provisioningAttributes(provisioningAssertion("example.com", "acme"), "other_domain", "other_groups")
+	assert.Empty(t, domain)
+	assert.Empty(t, groups)
+}
+
+func TestIsConnectionDomain(t *testing.T) {
+	t.Parallel()
+
+	connection := &model.SAMLConnection{SamlConnection: &sqbmodel.SamlConnection{Domain: "example.com"}}
+	assert.True(t, isConnectionDomain(connection, "example.com"))
+	assert.False(t, isConnectionDomain(connection, "eu.example.com"))
+	assert.False(t, isConnectionDomain(connection, "attacker.com"))
+	assert.False(t, isConnectionDomain(connection, ""))
+
+	connection.AllowSubdomains = true
+	assert.True(t, isConnectionDomain(connection, "eu.example.com"))
+	assert.False(t, isConnectionDomain(connection, "notexample.com"))
+}
+
+func TestDomainProvisioningFromAssertionRequiresOrganizationOwnedConnection(t *testing.T) {
+	t.Parallel()
+
+	// The repositories are left out, since connections which aren't owned by
+	// an organization never look any organization up.
+	s := &SAML{}
+	connection := &model.SAMLConnection{SamlConnection: &sqbmodel.SamlConnection{
+		ID:                               "samlc_1",
+		InstanceID:                       "ins_1",
+		Domain:                           "example.com",
+		OrganizationDomainEnrollmentMode: null.StringFrom("automatic_invitation"),
+	}}
+
+	provisioning, err := s.DomainProvisioningFromAssertion(context.Background(), nil, provisioningAssertion("example.com", "acme"), connection, "jane@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, provisioning)
+
+	connection.OrganizationID = null.StringFrom("org_1")
+	connection.OrganizationDomainEnrollmentMode = null.String{}
+	provisioning, err = s.DomainProvisioningFromAssertion(context.Background(), nil, provisioningAssertion("example.com", "acme"), connection, "jane@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, provisioning)
+}
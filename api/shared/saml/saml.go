@@ -33,16 +33,21 @@ var (
 type IDPMetadata struct {
 	EntityID    string
 	SSOURL      *string
+	SLOURL      *string
 	Certificate *string
 }
 
 type SAML struct {
-	samlConnectionRepo *repository.SAMLConnection
+	organizationRepo       *repository.Organization
+	organizationDomainRepo *repository.OrganizationDomain
+	samlConnectionRepo     *repository.SAMLConnection
 }
 
 func New() *SAML {
 	return &SAML{
-		samlConnectionRepo: repository.NewSAMLConnection(),
+		organizationRepo:       repository.NewOrganization(),
+		organizationDomainRepo: repository.NewOrganizationDomain(),
+		samlConnectionRepo:     repository.NewSAMLConnection(),
 	}
 }
 
@@ -105,6 +110,16 @@ func (s *SAML) ActiveConnectionForEmail(ctx context.Context, exec database.Execu
 		return connection, nil
 	}
 
+	// Organizations can use their connection for any of their verified
+	// domains, not only the one the connection was created with
+	connection, err = s.activeOrganizationConnectionForDomain(ctx, exec, instanceID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if connection != nil {
+		return connection, nil
+	}
+
 	// If not found, get the eTLD+1 email address domain and try to find an active connection that allows subdomains
 	eTLDPlusOne, err := psl.Domain(domain)
 	if err != nil {
@@ -118,6 +133,17 @@ func (s *SAML) ActiveConnectionForEmail(ctx context.Context, exec database.Execu
 	return s.samlConnectionRepo.QueryActiveByInstanceAndDomainAndAllowSubdomains(ctx, exec, instanceID, eTLDPlusOne)
 }
 
+func (s *SAML) activeOrganizationConnectionForDomain(ctx context.Context, exec database.Executor, instanceID, domain string) (*model.SAMLConnection, error) {
+	orgDomain, err := s.organizationDomainRepo.QueryVerifiedByInstanceAndName(ctx, exec, instanceID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if orgDomain == nil {
+		return nil, nil
+	}
+	return s.samlConnectionRepo.QueryActiveByInstanceAndOrganization(ctx, exec, instanceID, orgDomain.OrganizationID)
+}
+
 func (s *SAML) FetchMetadataForIDP(ctx context.Context, metadataRawURL string) (*IDPMetadata, error) {
 	metadataURL, err := url.ParseRequestURI(metadataRawURL)
 	if err != nil {
@@ -154,6 +180,14 @@ func populateIDPMetadata(data *saml.EntityDescriptor) *IDPMetadata {
 		}
 	}
 
+	if len(data.IDPSSODescriptors) > 0 {
+		for i, sloService := range data.IDPSSODescriptors[0].SingleLogoutServices {
+			if sloService.Binding == saml.HTTPRedirectBinding {
+				metadata.SLOURL = &data.IDPSSODescriptors[0].SingleLogoutServices[i].Location
+			}
+		}
+	}
+
 	if len(data.IDPSSODescriptors) > 0 && len(data.IDPSSODescriptors[0].KeyDescriptors) > 0 &&
 		len(data.IDPSSODescriptors[0].KeyDescriptors[0].KeyInfo.X509Data.X509Certificates) > 0 {
 		metadata.Certificate = &data.IDPSSODescriptors[0].KeyDescriptors[0].KeyInfo.X509Data.X509Certificates[0].Data
@@ -0,0 +1,60 @@
+package saml_connections
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/apierror"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwner(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, Owner{}.isOrganization())
+	assert.True(t, Owner{OrganizationID: "org_1"}.isOrganization())
+	assert.True(t, Owner{OrganizationID: "org_1", RequestingUserID: "user_1"}.isOrganization())
+}
+
+func TestChecksOnBehalfOfInstance(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// operations without a requesting user are performed on behalf of the
+	// instance, which doesn't need permissions or verified domains
+	service := &Service{}
+	for _, owner := range []Owner{{}, {OrganizationID: "org_1"}} {
+		assert.Nil(t, service.ensureCanManage(ctx, owner))
+		assert.Nil(t, service.ensureOwnedDomain(ctx, "ins_1", owner, "example.com"))
+	}
+}
+
+func TestProcessIDPConfigurationMetadataURL(t *testing.T) {
+	t.Parallel()
+
+	// organization members can't make our servers fetch arbitrary URLs
+	metadataURL := "https://idp.example.com/metadata"
+	params := &IdpConfigurationParams{IdpMetadataURL: &metadataURL}
+	owner := Owner{OrganizationID: "org_1", RequestingUserID: "user_1"}
+	apiErr := (&Service{}).processIDPConfiguration(context.Background(), owner, params)
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamUnknownCode, apiErr.Errors()[0].Code())
+
+	// without metadata, the IdP configuration is kept as is
+	entityID := "https://idp.example.com"
+	params = &IdpConfigurationParams{IdpEntityID: &entityID}
+	require.Nil(t, (&Service{}).processIDPConfiguration(context.Background(), owner, params))
+	assert.Equal(t, entityID, *params.IdpEntityID)
+}
+
+func TestCreateParamsSanitize(t *testing.T) {
+	t.Parallel()
+
+	certificate := "-----BEGIN CERTIFICATE-----\r\nMIIB\nAAAA\n-----END CERTIFICATE-----\n"
+	params := &CreateParams{Domain: "Example.COM", IdpConfigurationParams: IdpConfigurationParams{IdpCertificate: &certificate}}
+	params.sanitize()
+	assert.Equal(t, "example.com", params.Domain)
+	assert.Equal(t, "MIIBAAAA", *params.IdpCertificate)
+}
@@ -3,24 +3,48 @@ package serializable
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"clerk/api/apierror"
 	"clerk/model"
+	"clerk/pkg/cache"
+	"clerk/pkg/jobs"
+	sentryclerk "clerk/pkg/sentry"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+)
+
+// domainChecksCacheTTL is for how long the checks of a domain are kept in
+// the cache. Entries older than domainChecksRefreshAfter are still served,
+// but a job is enqueued to refresh them in the background.
+const (
+	domainChecksCacheTTL     = 5 * time.Minute
+	domainChecksRefreshAfter = 15 * time.Second
 )
 
 type DomainService struct {
+	cache     cache.Cache
+	clock     clockwork.Clock
+	gueClient *gue.Client
+
 	domainRepo     *repository.Domain
 	dnsChecksRepo  *repository.DNSChecks
 	proxyCheckRepo *repository.ProxyCheck
 }
 
-func NewDomainService() *DomainService {
+func NewDomainService(deps clerk.Deps) *DomainService {
 	return &DomainService{
-		domainRepo:     repository.NewDomain(),
-		dnsChecksRepo:  repository.NewDNSChecks(),
-		proxyCheckRepo: repository.NewProxyCheck(),
+		cache:          deps.Cache(),
+		clock:          deps.Clock(),
+		gueClient:      deps.GueClient(),
+		domainRepo:     deps.Repositories().Domain,
+		dnsChecksRepo:  deps.Repositories().DNSChecks,
+		proxyCheckRepo: deps.Repositories().ProxyCheck,
 	}
 }
 
@@ -32,54 +56,187 @@ type Domain struct {
 	ProxyCheck *model.ProxyCheck
 }
 
-func (s *DomainService) ConvertToSerializables(ctx context.Context, exec database.Executor, instance *model.Instance, domains []*model.Domain) ([]*Domain, error) {
-	domainIDs := make([]string, len(domains))
-	for i, dmn := range domains {
-		domainIDs[i] = dmn.ID
-	}
+// domainChecks are the DNS and proxy checks of a domain, as they're stored
+// in the cache.
+type domainChecks struct {
+	DNSCheck    *model.DNSCheck     `json:"dns_check"`
+	ProxyChecks []*model.ProxyCheck `json:"proxy_checks"`
+	CachedAt    time.Time           `json:"cached_at"`
+}
 
-	// For production instances, we'll need the DNS checks
-	// associated with the domains.
-	dnsChecksForDomains := map[string]*model.DNSCheck{}
-	if instance.IsProduction() {
-		dnsChecks, err := s.dnsChecksRepo.FindAllByDomainIDs(ctx, exec, domainIDs)
-		if err != nil {
-			return nil, apierror.Unexpected(err)
-		}
-		for _, dc := range dnsChecks {
-			dnsChecksForDomains[dc.DomainID] = dc
-		}
-	}
+func domainChecksCacheKey(domainID string) string {
+	return "domain_checks:" + domainID
+}
+
+func domainChecksRefreshCacheKey(domainID string) string {
+	return "domain_checks_refresh:" + domainID
+}
 
-	// We'll need the proxy checks associated with the domains.
-	// We'll add all the proxy checks we can find for the domains, even if
-	// they reference another proxy URL than the current one on the domain.
-	// Going through our proxy checks store guarantees that we'll get back
-	// the correct proxy check later, when we retrieve it from the store for
-	// each domain.
-	proxyChecks, err := s.proxyCheckRepo.FindAllByDomainIDs(ctx, exec, domainIDs)
+func (s *DomainService) ConvertToSerializables(ctx context.Context, exec database.Executor, instance *model.Instance, domains []*model.Domain) ([]*Domain, error) {
+	checksForDomains, err := s.loadChecks(ctx, exec, domains)
 	if err != nil {
 		return nil, apierror.Unexpected(err)
 	}
-	proxyChecksForDomains := newProxyChecksStore()
-	proxyChecksForDomains.Add(proxyChecks...)
 
 	var serializableDomains []*Domain
 	for _, domain := range domains {
+		checks := checksForDomains[domain.ID]
+
+		// Only production instances need the DNS checks associated with
+		// the domains.
+		var dnsCheck *model.DNSCheck
+		if instance.IsProduction() {
+			dnsCheck = checks.DNSCheck
+		}
+
+		// Going through our proxy checks store guarantees that we'll get
+		// back the proxy check for the current proxy URL of the domain,
+		// even though there might be checks for previous ones too.
+		proxyChecksForDomain := newProxyChecksStore()
+		proxyChecksForDomain.Add(checks.ProxyChecks...)
+
 		serializableDomains = append(serializableDomains, &Domain{
 			Domain:     domain,
 			Instance:   instance,
-			DNSCheck:   dnsChecksForDomains[domain.ID],
-			ProxyCheck: proxyChecksForDomains.Get(domain.ID, domain.ProxyURL.String),
+			DNSCheck:   dnsCheck,
+			ProxyCheck: proxyChecksForDomain.Get(domain.ID, domain.ProxyURL.String),
 		})
 	}
 
 	return serializableDomains, nil
 }
 
+// RefreshChecks loads the checks of the given domains from the database into
+// the cache. It is run by the refresh_domain_checks job.
+func (s *DomainService) RefreshChecks(ctx context.Context, exec database.Executor, domainIDs []string) error {
+	_, err := s.fetchChecks(ctx, exec, domainIDs)
+	return err
+}
+
+// InvalidateChecks drops the cached checks of the domain, e.g. because a
+// new check was just started for it.
+func (s *DomainService) InvalidateChecks(ctx context.Context, domainID string) error {
+	return s.cache.Delete(ctx, domainChecksCacheKey(domainID))
+}
+
+// loadChecks returns the checks of the given domains, keyed by domain ID.
+// Checks are served from the cache when possible, so that listing domains
+// doesn't query the checks tables on every request. The ones which aren't
+// cached are fetched right away, while the ones which have been cached for a
+// while are refreshed in the background.
+func (s *DomainService) loadChecks(ctx context.Context, exec database.Executor, domains []*model.Domain) (map[string]*domainChecks, error) {
+	checksForDomains := make(map[string]*domainChecks, len(domains))
+	var missingDomainIDs, staleDomainIDs []string
+	for _, domain := range domains {
+		var checks domainChecks
+		if err := s.cache.Get(ctx, domainChecksCacheKey(domain.ID), &checks); err != nil {
+			log.Warning(ctx, "serializable/domain: fetching cached checks of %s: %s", domain.ID, err)
+		}
+		if checks.CachedAt.IsZero() {
+			missingDomainIDs = append(missingDomainIDs, domain.ID)
+			continue
+		}
+
+		checksForDomains[domain.ID] = &checks
+		if s.clock.Since(checks.CachedAt) > domainChecksRefreshAfter {
+			staleDomainIDs = append(staleDomainIDs, domain.ID)
+		}
+	}
+
+	if len(missingDomainIDs) > 0 {
+		fetched, err := s.fetchChecks(ctx, exec, missingDomainIDs)
+		if err != nil {
+			return nil, err
+		}
+		for domainID, checks := range fetched {
+			checksForDomains[domainID] = checks
+		}
+	}
+
+	s.scheduleRefresh(ctx, staleDomainIDs)
+	return checksForDomains, nil
+}
+
+// fetchChecks fetches the DNS and proxy checks of the given domains from the
+// database, and stores them in the cache.
+func (s *DomainService) fetchChecks(ctx context.Context, exec database.Executor, domainIDs []string) (map[string]*domainChecks, error) {
+	var dnsChecks []*model.DNSCheck
+	var proxyChecks []*model.ProxyCheck
+	err := concurrently(ctx, exec,
+		func(ctx context.Context) error {
+			var err error
+			dnsChecks, err = s.dnsChecksRepo.FindAllByDomainIDs(ctx, exec, domainIDs)
+			return err
+		},
+		func(ctx context.Context) error {
+			var err error
+			proxyChecks, err = s.proxyCheckRepo.FindAllByDomainIDs(ctx, exec, domainIDs)
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().UTC()
+	checksForDomains := make(map[string]*domainChecks, len(domainIDs))
+	for _, domainID := range domainIDs {
+		checksForDomains[domainID] = &domainChecks{CachedAt: now}
+	}
+	for _, dnsCheck := range dnsChecks {
+		if checks, ok := checksForDomains[dnsCheck.DomainID]; ok {
+			checks.DNSCheck = dnsCheck
+		}
+	}
+	for _, proxyCheck := range proxyChecks {
+		if checks, ok := checksForDomains[proxyCheck.DomainID]; ok {
+			checks.ProxyChecks = append(checks.ProxyChecks, proxyCheck)
+		}
+	}
+
+	for domainID, checks := range checksForDomains {
+		if err := s.cache.Set(ctx, domainChecksCacheKey(domainID), checks, domainChecksCacheTTL); err != nil {
+			log.Warning(ctx, "serializable/domain: caching checks of %s: %s", domainID, err)
+		}
+	}
+	return checksForDomains, nil
+}
+
+// scheduleRefresh enqueues a refresh_domain_checks job for the given
+// domains, unless one was enqueued for them recently.
+func (s *DomainService) scheduleRefresh(ctx context.Context, domainIDs []string) {
+	var refreshDomainIDs []string
+	for _, domainID := range domainIDs {
+		key := domainChecksRefreshCacheKey(domainID)
+		scheduled, err := s.cache.Exists(ctx, key)
+		if err != nil {
+			log.Warning(ctx, "serializable/domain: checking refresh of %s: %s", domainID, err)
+			continue
+		}
+		if scheduled {
+			continue
+		}
+		if err := s.cache.Set(ctx, key, true, domainChecksRefreshAfter); err != nil {
+			log.Warning(ctx, "serializable/domain: marking refresh of %s: %s", domainID, err)
+			continue
+		}
+		refreshDomainIDs = append(refreshDomainIDs, domainID)
+	}
+	if len(refreshDomainIDs) == 0 {
+		return
+	}
+
+	err := jobs.RefreshDomainChecks(ctx, s.gueClient, jobs.RefreshDomainChecksArgs{
+		DomainIDs: refreshDomainIDs,
+	})
+	if err != nil {
+		sentryclerk.CaptureException(ctx, fmt.Errorf("serializable/domain: enqueueing refresh of %v: %w", refreshDomainIDs, err))
+	}
+}
+
 // Groups proxy checks by domain ID.
 // This type exists only to aid in joining domains with their current
-// proxy checks. It's used in the List endpoint.
+// proxy checks.
 type proxyChecksStore struct {
 	store map[string]*model.ProxyCheck
 }
@@ -0,0 +1,147 @@
+package serializable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cache"
+	"clerk/pkg/constants"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+// fakeCache keeps values in memory.
+type fakeCache struct {
+	cache.Cache
+	values map[string][]byte
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{values: map[string][]byte{}}
+}
+
+func (c *fakeCache) Get(_ context.Context, key string, value any) error {
+	raw, ok := c.values[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(raw, value)
+}
+
+func (c *fakeCache) Set(_ context.Context, key string, value any, _ time.Duration) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.values[key] = raw
+	return nil
+}
+
+func (c *fakeCache) Exists(_ context.Context, key string) (bool, error) {
+	_, ok := c.values[key]
+	return ok, nil
+}
+
+func (c *fakeCache) Delete(_ context.Context, key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+func TestConvertToSerializablesFromCache(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newFakeCache()
+	require.NoError(t, c.Set(ctx, domainChecksCacheKey("dmn_1"), &domainChecks{
+		DNSCheck: &model.DNSCheck{DNSCheck: &sqbmodel.DNSCheck{ID: "dns_1", DomainID: "dmn_1"}},
+		ProxyChecks: []*model.ProxyCheck{
+			{ProxyCheck: &sqbmodel.ProxyCheck{ID: "proxy_old", DomainID: "dmn_1", ProxyURL: "https://old.example.com/__clerk"}},
+			{ProxyCheck: &sqbmodel.ProxyCheck{ID: "proxy_1", DomainID: "dmn_1", ProxyURL: "https://example.com/__clerk"}},
+		},
+		CachedAt: now,
+	}, domainChecksCacheTTL))
+
+	// the checks were just cached, so neither the database nor the
+	// background refresh are needed
+	service := &DomainService{cache: c, clock: clockwork.NewFakeClockAt(now.Add(time.Second))}
+	domain := &model.Domain{Domain: &sqbmodel.Domain{ID: "dmn_1", ProxyURL: null.StringFrom("https://example.com/__clerk")}}
+
+	production := &model.Instance{Instance: &sqbmodel.Instance{EnvironmentType: string(constants.ETProduction)}}
+	serializables, err := service.ConvertToSerializables(ctx, nil, production, []*model.Domain{domain})
+	require.NoError(t, err)
+	require.Len(t, serializables, 1)
+	require.NotNil(t, serializables[0].DNSCheck)
+	assert.Equal(t, "dns_1", serializables[0].DNSCheck.ID)
+	require.NotNil(t, serializables[0].ProxyCheck)
+	assert.Equal(t, "proxy_1", serializables[0].ProxyCheck.ID)
+
+	// only production instances get the DNS checks
+	development := &model.Instance{Instance: &sqbmodel.Instance{EnvironmentType: string(constants.ETDevelopment)}}
+	serializables, err = service.ConvertToSerializables(ctx, nil, development, []*model.Domain{domain})
+	require.NoError(t, err)
+	require.Len(t, serializables, 1)
+	assert.Nil(t, serializables[0].DNSCheck)
+	assert.Equal(t, "proxy_1", serializables[0].ProxyCheck.ID)
+}
+
+func TestLoadChecksStale(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newFakeCache()
+	require.NoError(t, c.Set(ctx, domainChecksCacheKey("dmn_1"), &domainChecks{
+		DNSCheck: &model.DNSCheck{DNSCheck: &sqbmodel.DNSCheck{ID: "dns_1", DomainID: "dmn_1"}},
+		CachedAt: now,
+	}, domainChecksCacheTTL))
+	// a refresh was enqueued already, so no other job is
+	require.NoError(t, c.Set(ctx, domainChecksRefreshCacheKey("dmn_1"), true, domainChecksRefreshAfter))
+
+	service := &DomainService{cache: c, clock: clockwork.NewFakeClockAt(now.Add(domainChecksRefreshAfter + time.Second))}
+	domain := &model.Domain{Domain: &sqbmodel.Domain{ID: "dmn_1"}}
+
+	// stale checks are still served until they're refreshed
+	checks, err := service.loadChecks(ctx, nil, []*model.Domain{domain})
+	require.NoError(t, err)
+	require.Contains(t, checks, "dmn_1")
+	assert.Equal(t, "dns_1", checks["dmn_1"].DNSCheck.ID)
+}
+
+func TestInvalidateChecks(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	c := newFakeCache()
+	require.NoError(t, c.Set(ctx, domainChecksCacheKey("dmn_1"), &domainChecks{CachedAt: time.Now()}, domainChecksCacheTTL))
+	require.NoError(t, c.Set(ctx, domainChecksCacheKey("dmn_2"), &domainChecks{CachedAt: time.Now()}, domainChecksCacheTTL))
+
+	service := &DomainService{cache: c}
+	require.NoError(t, service.InvalidateChecks(ctx, "dmn_1"))
+	assert.NotContains(t, c.values, domainChecksCacheKey("dmn_1"))
+	assert.Contains(t, c.values, domainChecksCacheKey("dmn_2"))
+}
+
+func TestProxyChecksStore(t *testing.T) {
+	t.Parallel()
+
+	store := newProxyChecksStore()
+	store.Add(
+		&model.ProxyCheck{ProxyCheck: &sqbmodel.ProxyCheck{ID: "proxy_1", DomainID: "dmn_1", ProxyURL: "https://example.com/__clerk"}},
+		&model.ProxyCheck{ProxyCheck: &sqbmodel.ProxyCheck{ID: "proxy_2", DomainID: "dmn_2", ProxyURL: "https://example.com/__clerk"}},
+		// checks without a domain or proxy URL are ignored
+		&model.ProxyCheck{ProxyCheck: &sqbmodel.ProxyCheck{ID: "proxy_3", DomainID: "dmn_3"}},
+	)
+
+	assert.Equal(t, "proxy_1", store.Get("dmn_1", "https://example.com/__clerk").ID)
+	assert.Equal(t, "proxy_2", store.Get("dmn_2", "https://example.com/__clerk").ID)
+	assert.Nil(t, store.Get("dmn_1", "https://other.example.com/__clerk"))
+	assert.Nil(t, store.Get("dmn_3", ""))
+}
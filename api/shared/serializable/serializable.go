@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 
+	"clerk/api/serialize"
+	"clerk/api/shared/events"
 	"clerk/api/shared/orgdomain"
 	"clerk/api/shared/user_profile"
 	"clerk/api/shared/verifications"
@@ -15,6 +17,7 @@ import (
 	"clerk/utils/database"
 
 	"github.com/jonboulle/clockwork"
+	"golang.org/x/sync/errgroup"
 )
 
 type Service struct {
@@ -64,6 +67,12 @@ func NewService(clock clockwork.Clock) *Service {
 }
 
 func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, userSettings *usersettings.UserSettings, users []*model.User) ([]*model.UserSerializable, error) {
+	return s.ConvertUsersWithOptions(ctx, exec, userSettings, users, serialize.DefaultUserOptions)
+}
+
+// ConvertUsersWithOptions is like ConvertUsers, but only loads the records
+// of the sections of the user response which opts selects.
+func (s *Service) ConvertUsersWithOptions(ctx context.Context, exec database.Executor, userSettings *usersettings.UserSettings, users []*model.User, opts serialize.UserOptions) ([]*model.UserSerializable, error) {
 	if len(users) == 0 {
 		return []*model.UserSerializable{}, nil
 	}
@@ -76,60 +85,9 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, user
 	// Fetch everything we will need up-front.
 	// This is done to avoid the N+1 queries that would be required
 	// if we were building for each user separately.
-	allIdentifications, identificationsByUser, err := s.fetchAllIdentificationsByUser(ctx, exec, userIDs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all identifications for users %v: %w",
-			users, err)
-	}
-
-	parentIdentificationsByIdentification, err := s.fetchAllParentIdentificationsByIdentification(ctx, exec, allIdentifications)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all parent identifications for identifications %v: %w",
-			allIdentifications, err)
-	}
-
-	externalAccountsByIdentification, err := s.fetchAllExternalAccountsByIdentification(ctx, exec, allIdentifications)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all external accounts for %v: %w",
-			allIdentifications, err)
-	}
-
-	samlAccountsByIdentification, err := s.fetchAllSAMLAccountsByIdentification(ctx, exec, allIdentifications)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all saml accounts for %v: %w", allIdentifications, err)
-	}
-
-	verificationsByIdentification, err := s.fetchAllVerificationsByIdentification(ctx, exec, allIdentifications)
+	deps, err := s.fetchUserDeps(ctx, exec, userSettings, users, userIDs, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all verifications for %v: %w",
-			allIdentifications, err)
-	}
-
-	passkeysByIdentification, err := s.fetchAllPasskeysByIdentification(ctx, exec, allIdentifications)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch all passkeys for %v: %w",
-			allIdentifications, err)
-	}
-
-	totpsByUser := make(map[string]*model.TOTP)
-	if userSettings.SecondFactors().Contains(constants.VSTOTP) {
-		totpsByUser, err = s.fetchAllTOTPsByUser(ctx, exec, userIDs)
-		if err != nil {
-			return nil, fmt.Errorf("fetch totps by users %v: %w", userIDs, err)
-		}
-	}
-
-	backupCodesByUser := make(map[string]*model.BackupCode)
-	if userSettings.SecondFactors().Contains(constants.VSBackupCode) {
-		backupCodesByUser, err = s.fetchAllBackupCodesByUser(ctx, exec, userIDs)
-		if err != nil {
-			return nil, fmt.Errorf("fetch backup codes for users %v: %w", userIDs, err)
-		}
-	}
-
-	userPlanKeyByUser, err := s.fetchAllUserPlanKeysByUser(ctx, exec, users)
-	if err != nil {
-		return nil, fmt.Errorf("fetching user plan keys for users %v: %w", userIDs, err)
+		return nil, err
 	}
 
 	// build user serializables
@@ -146,12 +104,12 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, user
 
 		// Build identification serializables
 		userSerializable.Identifications = s.createIdentificationSerializable(
-			identificationsByUser[user.ID],
-			verificationsByIdentification,
-			externalAccountsByIdentification,
-			samlAccountsByIdentification,
-			parentIdentificationsByIdentification,
-			passkeysByIdentification,
+			deps.identificationsByUser[user.ID],
+			deps.verificationsByIdentification,
+			deps.externalAccountsByIdentification,
+			deps.samlAccountsByIdentification,
+			deps.parentIdentificationsByIdentification,
+			deps.passkeysByIdentification,
 		)
 
 		// Assign the username identification's value as the username
@@ -160,8 +118,8 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, user
 			userSerializable.Username = usernames[0].Username()
 		}
 
-		_, userSerializable.TOTPEnabled = totpsByUser[user.ID]
-		_, userSerializable.BackupCodeEnabled = backupCodesByUser[user.ID]
+		_, userSerializable.TOTPEnabled = deps.totpsByUser[user.ID]
+		_, userSerializable.BackupCodeEnabled = deps.backupCodesByUser[user.ID]
 
 		// Check if 2FA is enabled
 		if userSerializable.TOTPEnabled {
@@ -181,10 +139,12 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, user
 		if userLockoutStatus.LockoutExpiresIn != nil {
 			lockoutExpiresInSeconds := int64(userLockoutStatus.LockoutExpiresIn.Seconds())
 			userSerializable.LockoutExpiresInSeconds = &lockoutExpiresInSeconds
+			lockedUntil := s.clock.Now().UTC().Add(*userLockoutStatus.LockoutExpiresIn)
+			userSerializable.LockedUntil = &lockedUntil
 		}
 		userSerializable.VerificationAttemptsRemaining = userLockoutStatus.VerificationAttemptsRemaining
 
-		if plan, ok := userPlanKeyByUser[user.ID]; ok {
+		if plan, ok := deps.userPlanKeyByUser[user.ID]; ok {
 			userSerializable.BillingPlan = &plan
 		}
 
@@ -194,6 +154,155 @@ func (s *Service) ConvertUsers(ctx context.Context, exec database.Executor, user
 	return result, nil
 }
 
+// userDeps are the records needed to serialize a batch of users.
+type userDeps struct {
+	identificationsByUser                 map[string][]*model.Identification
+	parentIdentificationsByIdentification map[string][]*model.Identification
+	externalAccountsByIdentification      map[string]*model.ExternalAccount
+	samlAccountsByIdentification          map[string]*model.SAMLAccountWithDeps
+	verificationsByIdentification         map[string]*model.Verification
+	passkeysByIdentification              map[string]*model.Passkey
+	totpsByUser                           map[string]*model.TOTP
+	backupCodesByUser                     map[string]*model.BackupCode
+	userPlanKeyByUser                     map[string]string
+}
+
+// fetchUserDeps fetches the records needed to serialize the users, with one
+// query per kind of record regardless of the number of users. Queries which
+// don't depend on each other run concurrently.
+func (s *Service) fetchUserDeps(
+	ctx context.Context,
+	exec database.Executor,
+	userSettings *usersettings.UserSettings,
+	users []*model.User,
+	userIDs []string,
+	opts serialize.UserOptions,
+) (*userDeps, error) {
+	deps := &userDeps{
+		totpsByUser:       make(map[string]*model.TOTP),
+		backupCodesByUser: make(map[string]*model.BackupCode),
+	}
+
+	// every function sets different fields of deps, so they don't need to
+	// be synchronized
+	err := concurrently(ctx, exec,
+		func(ctx context.Context) error {
+			allIdentifications, identificationsByUser, err := s.fetchAllIdentificationsByUser(ctx, exec, userIDs)
+			if err != nil {
+				return fmt.Errorf("failed to fetch all identifications for users %v: %w", userIDs, err)
+			}
+			deps.identificationsByUser = identificationsByUser
+
+			return concurrently(ctx, exec,
+				func(ctx context.Context) error {
+					parentIdentificationsByIdentification, err := s.fetchAllParentIdentificationsByIdentification(ctx, exec, allIdentifications)
+					if err != nil {
+						return fmt.Errorf("failed to fetch all parent identifications for identifications %v: %w", allIdentifications, err)
+					}
+					deps.parentIdentificationsByIdentification = parentIdentificationsByIdentification
+					return nil
+				},
+				func(ctx context.Context) error {
+					if !opts.ExternalAccounts {
+						return nil
+					}
+					externalAccountsByIdentification, err := s.fetchAllExternalAccountsByIdentification(ctx, exec, allIdentifications)
+					if err != nil {
+						return fmt.Errorf("failed to fetch all external accounts for %v: %w", allIdentifications, err)
+					}
+					deps.externalAccountsByIdentification = externalAccountsByIdentification
+					return nil
+				},
+				func(ctx context.Context) error {
+					if !opts.SAMLAccounts {
+						return nil
+					}
+					samlAccountsByIdentification, err := s.fetchAllSAMLAccountsByIdentification(ctx, exec, allIdentifications)
+					if err != nil {
+						return fmt.Errorf("failed to fetch all saml accounts for %v: %w", allIdentifications, err)
+					}
+					deps.samlAccountsByIdentification = samlAccountsByIdentification
+					return nil
+				},
+				func(ctx context.Context) error {
+					verificationsByIdentification, err := s.fetchAllVerificationsByIdentification(ctx, exec, allIdentifications)
+					if err != nil {
+						return fmt.Errorf("failed to fetch all verifications for %v: %w", allIdentifications, err)
+					}
+					deps.verificationsByIdentification = verificationsByIdentification
+					return nil
+				},
+				func(ctx context.Context) error {
+					passkeysByIdentification, err := s.fetchAllPasskeysByIdentification(ctx, exec, allIdentifications)
+					if err != nil {
+						return fmt.Errorf("failed to fetch all passkeys for %v: %w", allIdentifications, err)
+					}
+					deps.passkeysByIdentification = passkeysByIdentification
+					return nil
+				},
+			)
+		},
+		func(ctx context.Context) error {
+			if !userSettings.SecondFactors().Contains(constants.VSTOTP) {
+				return nil
+			}
+			totpsByUser, err := s.fetchAllTOTPsByUser(ctx, exec, userIDs)
+			if err != nil {
+				return fmt.Errorf("fetch totps by users %v: %w", userIDs, err)
+			}
+			deps.totpsByUser = totpsByUser
+			return nil
+		},
+		func(ctx context.Context) error {
+			if !userSettings.SecondFactors().Contains(constants.VSBackupCode) {
+				return nil
+			}
+			backupCodesByUser, err := s.fetchAllBackupCodesByUser(ctx, exec, userIDs)
+			if err != nil {
+				return fmt.Errorf("fetch backup codes for users %v: %w", userIDs, err)
+			}
+			deps.backupCodesByUser = backupCodesByUser
+			return nil
+		},
+		func(ctx context.Context) error {
+			userPlanKeyByUser, err := s.fetchAllUserPlanKeysByUser(ctx, exec, users)
+			if err != nil {
+				return fmt.Errorf("fetching user plan keys for users %v: %w", userIDs, err)
+			}
+			deps.userPlanKeyByUser = userPlanKeyByUser
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return deps, nil
+}
+
+// concurrently runs the given functions concurrently and returns the first
+// error, if any. Transactions are bound to a single connection, which can't
+// run queries concurrently, so the functions run one after the other when
+// exec is a transaction.
+func concurrently(ctx context.Context, exec database.Executor, fns ...func(ctx context.Context) error) error {
+	if _, isTx := exec.(database.Tx); isTx {
+		for _, fn := range fns {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+	for _, fn := range fns {
+		fn := fn
+		group.Go(func() error {
+			return fn(ctx)
+		})
+	}
+	return group.Wait()
+}
+
 func (s *Service) fetchAllIdentificationsByUser(
 	ctx context.Context,
 	exec database.Executor,
@@ -438,6 +547,17 @@ func (s *Service) ConvertUser(ctx context.Context, exec database.Executor, userS
 	return userSerializables[0], nil
 }
 
+// SnapshotUser captures the user payload as it is before an update, so
+// that the user.updated event sent after it carries the fields which
+// changed. See events.Service.UserUpdated.
+func (s *Service) SnapshotUser(ctx context.Context, exec database.Executor, userSettings *usersettings.UserSettings, user *model.User) (events.UserSnapshot, error) {
+	userSerializable, err := s.ConvertUser(ctx, exec, userSettings, user)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot user %s: %w", user.ID, err)
+	}
+	return events.NewUserSnapshot(serialize.UserToServerAPI(ctx, userSerializable))
+}
+
 func (s *Service) ConvertIdentification(ctx context.Context, exec database.Executor, ident *model.Identification) (*model.IdentificationSerializable, error) {
 	identSerializable := &model.IdentificationSerializable{
 		Identification: ident,
@@ -0,0 +1,69 @@
+package serializable
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"clerk/utils/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx is a transaction which is never used to run queries.
+type fakeTx struct {
+	database.Tx
+}
+
+func TestConcurrently(t *testing.T) {
+	t.Parallel()
+
+	// both functions only return once the other one has started, which
+	// means they run at the same time
+	var started sync.WaitGroup
+	started.Add(2)
+	waitForBoth := func(context.Context) error {
+		started.Done()
+		bothStarted := make(chan struct{})
+		go func() {
+			started.Wait()
+			close(bothStarted)
+		}()
+		select {
+		case <-bothStarted:
+			return nil
+		case <-time.After(time.Second):
+			return errors.New("functions didn't run concurrently")
+		}
+	}
+	require.NoError(t, concurrently(context.Background(), nil, waitForBoth, waitForBoth))
+
+	// the first error is returned, and cancels the rest
+	errFailed := errors.New("query failed")
+	err := concurrently(context.Background(), nil,
+		func(context.Context) error { return errFailed },
+		func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	)
+	assert.ErrorIs(t, err, errFailed)
+}
+
+func TestConcurrentlyInTransaction(t *testing.T) {
+	t.Parallel()
+
+	// transactions run the functions one after the other, until one fails
+	var ran []int
+	errFailed := errors.New("query failed")
+	err := concurrently(context.Background(), fakeTx{},
+		func(context.Context) error { ran = append(ran, 1); return nil },
+		func(context.Context) error { ran = append(ran, 2); return errFailed },
+		func(context.Context) error { ran = append(ran, 3); return nil },
+	)
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, []int{1, 2}, ran)
+}
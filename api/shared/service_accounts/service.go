@@ -0,0 +1,151 @@
+package service_accounts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/jwt"
+	"clerk/model"
+	"clerk/pkg/hash"
+	"clerk/pkg/rand"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// GrantTypeClientCredentials is the only OAuth2 grant type service accounts
+// can use to obtain tokens.
+// https://datatracker.ietf.org/doc/html/rfc6749#section-4.4
+const GrantTypeClientCredentials = "client_credentials"
+
+// clientIDPrefix makes service account client IDs easy to tell apart from
+// the client IDs of OAuth applications.
+const clientIDPrefix = "sa_"
+
+// serviceAccountStore looks up service accounts and records their usage.
+// It's implemented by repository.ServiceAccounts.
+type serviceAccountStore interface {
+	QueryByClientIDAndInstance(ctx context.Context, exec database.Executor, clientID, instanceID string) (*model.ServiceAccount, error)
+	IncrementTokensIssued(ctx context.Context, exec database.Executor, serviceAccountID string, usedAt time.Time) error
+}
+
+// jwtTemplateFinder looks up the JWT templates of service accounts. It's
+// implemented by repository.JWTTemplate.
+type jwtTemplateFinder interface {
+	QueryByIDAndInstance(ctx context.Context, exec database.Executor, id, instanceID string) (*model.JWTTemplate, error)
+}
+
+// tokenMinter signs the tokens of service accounts. It's implemented by
+// jwt.Service.
+type tokenMinter interface {
+	CreateForServiceAccount(ctx context.Context, exec database.Executor, params jwt.CreateForServiceAccountParams) (string, error)
+}
+
+type Service struct {
+	clock clockwork.Clock
+
+	// services
+	jwtService tokenMinter
+
+	// repositories
+	jwtTemplateRepo     jwtTemplateFinder
+	serviceAccountsRepo serviceAccountStore
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:               deps.Clock(),
+		jwtService:          jwt.NewService(deps),
+		jwtTemplateRepo:     deps.Repositories().JWTTemplate,
+		serviceAccountsRepo: deps.Repositories().ServiceAccounts,
+	}
+}
+
+// GenerateClientID returns a new client ID for a service account.
+func GenerateClientID() (string, error) {
+	clientID, err := rand.AlphanumExtended(24)
+	if err != nil {
+		return "", fmt.Errorf("service_accounts: generating client id: %w", err)
+	}
+	return clientIDPrefix + clientID, nil
+}
+
+// GenerateClientSecret returns a new client secret for a service account,
+// along with the hash which is stored in its place.
+func GenerateClientSecret() (string, string, error) {
+	clientSecret, err := rand.AlphanumExtended(48)
+	if err != nil {
+		return "", "", fmt.Errorf("service_accounts: generating client secret: %w", err)
+	}
+	clientSecretHash, err := hash.GenerateBcryptHash(clientSecret)
+	if err != nil {
+		return "", "", fmt.Errorf("service_accounts: hashing client secret: %w", err)
+	}
+	return clientSecret, clientSecretHash, nil
+}
+
+// Authenticate returns the service account of the instance with the given
+// client credentials.
+func (s *Service) Authenticate(ctx context.Context, exec database.Executor, instanceID, clientID, clientSecret string) (*model.ServiceAccount, error) {
+	if clientID == "" || clientSecret == "" {
+		return nil, apierror.ServiceAccountInvalidClient()
+	}
+
+	serviceAccount, err := s.serviceAccountsRepo.QueryByClientIDAndInstance(ctx, exec, clientID, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("service_accounts/authenticate: retrieving service account with client id %s: %w", clientID, err)
+	}
+	if serviceAccount == nil {
+		return nil, apierror.ServiceAccountInvalidClient()
+	}
+
+	matches, err := hash.Compare(hash.Bcrypt, clientSecret, serviceAccount.ClientSecretHash)
+	if err != nil {
+		return nil, fmt.Errorf("service_accounts/authenticate: comparing client secret of service account %s: %w", serviceAccount.ID, err)
+	}
+	if !matches {
+		return nil, apierror.ServiceAccountInvalidClient()
+	}
+	return serviceAccount, nil
+}
+
+// Token is an access token issued to a service account.
+type Token struct {
+	AccessToken string
+	// ExpiresIn is the lifetime of the token in seconds.
+	ExpiresIn int
+}
+
+// IssueToken mints a token for the service account using its JWT template
+// and records the usage of the service account.
+func (s *Service) IssueToken(ctx context.Context, tx database.Tx, env *model.Env, serviceAccount *model.ServiceAccount, origin string) (*Token, error) {
+	jwtTemplate, err := s.jwtTemplateRepo.QueryByIDAndInstance(ctx, tx, serviceAccount.JWTTemplateID, env.Instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("service_accounts/issueToken: retrieving jwt template %s: %w", serviceAccount.JWTTemplateID, err)
+	}
+	if jwtTemplate == nil {
+		return nil, apierror.ServiceAccountJWTTemplateMissing()
+	}
+
+	accessToken, err := s.jwtService.CreateForServiceAccount(ctx, tx, jwt.CreateForServiceAccountParams{
+		Env:            env,
+		ServiceAccount: serviceAccount,
+		JWTTemplate:    jwtTemplate,
+		Origin:         origin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service_accounts/issueToken: creating token for service account %s: %w", serviceAccount.ID, err)
+	}
+
+	if err := s.serviceAccountsRepo.IncrementTokensIssued(ctx, tx, serviceAccount.ID, s.clock.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("service_accounts/issueToken: recording usage of service account %s: %w", serviceAccount.ID, err)
+	}
+
+	return &Token{
+		AccessToken: accessToken,
+		ExpiresIn:   jwtTemplate.Lifetime,
+	}, nil
+}
@@ -0,0 +1,175 @@
+package service_accounts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/jwt"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeServiceAccountStore struct {
+	serviceAccounts map[string]*model.ServiceAccount
+	tokensIssued    map[string]int
+	usedAt          time.Time
+}
+
+func (f *fakeServiceAccountStore) QueryByClientIDAndInstance(_ context.Context, _ database.Executor, clientID, instanceID string) (*model.ServiceAccount, error) {
+	serviceAccount, ok := f.serviceAccounts[clientID]
+	if !ok || serviceAccount.InstanceID != instanceID {
+		return nil, nil
+	}
+	return serviceAccount, nil
+}
+
+func (f *fakeServiceAccountStore) IncrementTokensIssued(_ context.Context, _ database.Executor, serviceAccountID string, usedAt time.Time) error {
+	f.tokensIssued[serviceAccountID]++
+	f.usedAt = usedAt
+	return nil
+}
+
+type fakeJWTTemplateFinder map[string]*model.JWTTemplate
+
+func (f fakeJWTTemplateFinder) QueryByIDAndInstance(_ context.Context, _ database.Executor, id, instanceID string) (*model.JWTTemplate, error) {
+	jwtTemplate, ok := f[id]
+	if !ok || jwtTemplate.InstanceID != instanceID {
+		return nil, nil
+	}
+	return jwtTemplate, nil
+}
+
+type fakeTokenMinter struct {
+	err error
+}
+
+func (f fakeTokenMinter) CreateForServiceAccount(_ context.Context, _ database.Executor, params jwt.CreateForServiceAccountParams) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	return "token_for_" + params.ServiceAccount.ID + "_with_" + params.JWTTemplate.ID, nil
+}
+
+func newTestService(t *testing.T) (*Service, *fakeServiceAccountStore, string) {
+	t.Helper()
+
+	clientSecret, clientSecretHash, err := GenerateClientSecret()
+	require.NoError(t, err)
+
+	store := &fakeServiceAccountStore{
+		serviceAccounts: map[string]*model.ServiceAccount{
+			"sa_client": {ServiceAccount: &sqbmodel.ServiceAccount{
+				ID:               "sa_1",
+				InstanceID:       "ins_1",
+				ClientID:         "sa_client",
+				ClientSecretHash: clientSecretHash,
+				JWTTemplateID:    "jtmp_1",
+			}},
+			"sa_orphan": {ServiceAccount: &sqbmodel.ServiceAccount{
+				ID:               "sa_2",
+				InstanceID:       "ins_1",
+				ClientID:         "sa_orphan",
+				ClientSecretHash: clientSecretHash,
+				JWTTemplateID:    "jtmp_deleted",
+			}},
+		},
+		tokensIssued: make(map[string]int),
+	}
+	templates := fakeJWTTemplateFinder{
+		"jtmp_1": {JWTTemplate: &sqbmodel.JWTTemplate{ID: "jtmp_1", InstanceID: "ins_1", Lifetime: 300}},
+	}
+
+	return &Service{
+		clock:               clockwork.NewFakeClockAt(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+		jwtService:          fakeTokenMinter{},
+		jwtTemplateRepo:     templates,
+		serviceAccountsRepo: store,
+	}, store, clientSecret
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	service, _, clientSecret := newTestService(t)
+
+	for _, tc := range []struct {
+		name         string
+		instanceID   string
+		clientID     string
+		clientSecret string
+		wantID       string
+	}{
+		{name: "valid credentials", instanceID: "ins_1", clientID: "sa_client", clientSecret: clientSecret, wantID: "sa_1"},
+		{name: "missing client id", instanceID: "ins_1", clientSecret: clientSecret},
+		{name: "missing client secret", instanceID: "ins_1", clientID: "sa_client"},
+		{name: "unknown client id", instanceID: "ins_1", clientID: "sa_unknown", clientSecret: clientSecret},
+		{name: "wrong client secret", instanceID: "ins_1", clientID: "sa_client", clientSecret: clientSecret + "x"},
+		{name: "other instance", instanceID: "ins_2", clientID: "sa_client", clientSecret: clientSecret},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			serviceAccount, err := service.Authenticate(context.Background(), nil, tc.instanceID, tc.clientID, tc.clientSecret)
+			if tc.wantID == "" {
+				apiErr, isAPIErr := apierror.As(err)
+				require.True(t, isAPIErr)
+				assert.True(t, apiErr.IsTypeOf(apierror.ServiceAccountInvalidClientCode))
+				assert.Nil(t, serviceAccount)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantID, serviceAccount.ID)
+		})
+	}
+}
+
+func TestIssueToken(t *testing.T) {
+	t.Parallel()
+
+	service, store, _ := newTestService(t)
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}}
+
+	token, err := service.IssueToken(context.Background(), nil, env, store.serviceAccounts["sa_client"], "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "token_for_sa_1_with_jtmp_1", token.AccessToken)
+	assert.Equal(t, 300, token.ExpiresIn)
+	assert.Equal(t, 1, store.tokensIssued["sa_1"])
+	assert.Equal(t, service.clock.Now().UTC(), store.usedAt)
+}
+
+func TestIssueTokenWithoutJWTTemplate(t *testing.T) {
+	t.Parallel()
+
+	service, store, _ := newTestService(t)
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}}
+
+	_, err := service.IssueToken(context.Background(), nil, env, store.serviceAccounts["sa_orphan"], "")
+	apiErr, isAPIErr := apierror.As(err)
+	require.True(t, isAPIErr)
+	assert.True(t, apiErr.IsTypeOf(apierror.ServiceAccountJWTTemplateMissingCode))
+	assert.Zero(t, store.tokensIssued["sa_2"])
+}
+
+func TestIssueTokenMintingFailure(t *testing.T) {
+	t.Parallel()
+
+	service, store, _ := newTestService(t)
+	service.jwtService = fakeTokenMinter{err: errors.New("no signing key")}
+	env := &model.Env{Instance: &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}}
+
+	_, err := service.IssueToken(context.Background(), nil, env, store.serviceAccounts["sa_client"], "")
+	require.Error(t, err)
+	_, isAPIErr := apierror.As(err)
+	assert.False(t, isAPIErr)
+	assert.Zero(t, store.tokensIssued["sa_1"])
+}
@@ -0,0 +1,155 @@
+// Package serviceconfig holds the typed configuration of the APIs. Each API
+// loads its configuration once, at startup, and refuses to start if any
+// value is missing or invalid.
+//
+// Values are still read through cenv, so the environment variables, their
+// defaults and the required variables of each API stay the same.
+package serviceconfig
+
+import (
+	"clerk/pkg/cenv"
+	"clerk/pkg/config"
+)
+
+// source reads the configuration from the environment, through cenv.
+var source = config.SourceFunc(func(key string) (string, bool) {
+	value := cenv.Get(key)
+	return value, value != "" || cenv.IsSet(key)
+})
+
+// Common is the configuration shared by all APIs.
+type Common struct {
+	Env               string
+	ServiceIdentifier string
+	Port              string
+	// GitCommitSHA is the version the service is reported with, if known.
+	GitCommitSHA string
+
+	SentryURL                string
+	SentryIgnoredStatusCodes string
+
+	GoogleCloudProfiler bool
+	DatadogTracer       bool
+	DebugMode           bool
+}
+
+func loadCommon(l *config.Loader) Common {
+	return Common{
+		Env:                      l.String(cenv.ClerkEnv),
+		ServiceIdentifier:        l.OptionalString(cenv.ClerkServiceIdentifier, ""),
+		Port:                     l.String(cenv.Port),
+		GitCommitSHA:             l.OptionalString(cenv.GitCommitSHA, ""),
+		SentryURL:                l.OptionalString(cenv.SentryURL, ""),
+		SentryIgnoredStatusCodes: l.OptionalString(cenv.SentryIgnoredStatusCodes, ""),
+		GoogleCloudProfiler:      l.Bool(cenv.GoogleCloudProfiler, false),
+		DatadogTracer:            l.Bool(cenv.ClerkDatadogTracer, false),
+		DebugMode:                l.Bool(cenv.ClerkDebugMode, false),
+	}
+}
+
+// BAPI is the configuration of the Backend API.
+type BAPI struct {
+	Common
+	GoogleStorageBucket    string
+	ServerAPI              string
+	StripeSecretKey        string
+	BillingStripeSecretKey string
+	SvixAPIToken           string
+}
+
+func LoadBAPI() (*BAPI, error) {
+	l := config.NewLoader(source)
+	cfg := &BAPI{
+		Common:                 loadCommon(l),
+		GoogleStorageBucket:    l.String(cenv.GoogleStorageBucket),
+		ServerAPI:              l.String(cenv.ClerkServerAPI),
+		StripeSecretKey:        l.OptionalString(cenv.StripeSecretKey, ""),
+		BillingStripeSecretKey: l.OptionalString(cenv.BillingStripeSecretKey, ""),
+		SvixAPIToken:           l.OptionalString(cenv.SvixAPIToken, ""),
+	}
+	return cfg, validate(l)
+}
+
+// FAPI is the configuration of the Frontend API.
+type FAPI struct {
+	Common
+	GoogleStorageBucket                   string
+	BillingStripeSecretKey                string
+	CloudflareTurnstileSecretKeyInvisible string
+	CloudflareTurnstileSecretKeyManaged   string
+}
+
+func LoadFAPI() (*FAPI, error) {
+	l := config.NewLoader(source)
+	cfg := &FAPI{
+		Common:                                loadCommon(l),
+		GoogleStorageBucket:                   l.String(cenv.GoogleStorageBucket),
+		BillingStripeSecretKey:                l.String(cenv.BillingStripeSecretKey),
+		CloudflareTurnstileSecretKeyInvisible: l.String(cenv.CloudflareTurnstileSecretKeyInvisible),
+		CloudflareTurnstileSecretKeyManaged:   l.String(cenv.CloudflareTurnstileSecretKeyManaged),
+	}
+	return cfg, validate(l)
+}
+
+// DAPI is the configuration of the Dashboard API.
+type DAPI struct {
+	Common
+	GoogleStorageBucket    string
+	ServerAPI              string
+	StripeSecretKey        string
+	BillingStripeSecretKey string
+	SvixAPIToken           string
+	ImageServiceAPIKey     string
+	ImageServiceURL        string
+	// DashboardAZP lists the origins the dashboard is served from.
+	DashboardAZP    string
+	DashboardAPIKey string
+}
+
+func LoadDAPI() (*DAPI, error) {
+	l := config.NewLoader(source)
+	cfg := &DAPI{
+		Common:                 loadCommon(l),
+		GoogleStorageBucket:    l.String(cenv.GoogleStorageBucket),
+		ServerAPI:              l.String(cenv.ClerkServerAPI),
+		StripeSecretKey:        l.OptionalString(cenv.StripeSecretKey, ""),
+		BillingStripeSecretKey: l.String(cenv.BillingStripeSecretKey),
+		SvixAPIToken:           l.OptionalString(cenv.SvixAPIToken, ""),
+		ImageServiceAPIKey:     l.OptionalString(cenv.ClerkImageServiceAPIKey, ""),
+		ImageServiceURL:        l.OptionalString(cenv.ClerkImageServiceURL, ""),
+		DashboardAZP:           l.OptionalString(cenv.ClerkDashboardAZP, ""),
+		DashboardAPIKey:        l.OptionalString(cenv.ClerkDashboardAPIKey, ""),
+	}
+	return cfg, validate(l)
+}
+
+// SAPI is the configuration of the Support API.
+type SAPI struct {
+	Common
+	ServerAPI       string
+	StripeSecretKey string
+	// SupportAZP lists the origins the support dashboard is served from.
+	SupportAZP    string
+	SupportAPIKey string
+}
+
+func LoadSAPI() (*SAPI, error) {
+	l := config.NewLoader(source)
+	cfg := &SAPI{
+		Common:          loadCommon(l),
+		ServerAPI:       l.String(cenv.ClerkServerAPI),
+		StripeSecretKey: l.String(cenv.StripeSecretKey),
+		SupportAZP:      l.String(cenv.ClerkSupportAZP),
+		SupportAPIKey:   l.String(cenv.ClerkSupportAPIKey),
+	}
+	return cfg, validate(l)
+}
+
+// validate returns the problems with the configuration of the API, along
+// with the ones of the tunables, which are loaded on first use.
+func validate(l *config.Loader) error {
+	if err := l.Err(); err != nil {
+		return err
+	}
+	return tunablesErr()
+}
@@ -0,0 +1,104 @@
+package serviceconfig
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"clerk/pkg/cenv"
+	"clerk/pkg/config"
+	"clerk/utils/log"
+)
+
+// Tunables are the settings which can be changed while the APIs are running,
+// without a deploy. They start with the value of their environment variable
+// and are reloaded from the file in cenv.ClerkTunablesFile, under the same
+// keys, if one is set.
+type Tunables struct {
+	// BAPIRateLimitRequests and BAPIRateLimitPeriod are the default rate
+	// limit of instances on BAPI, and FAPIRateLimitRequests and
+	// FAPIRateLimitPeriod the rate limit of the FAPI routes which are limited.
+	BAPIRateLimitRequests *config.Tunable[int]
+	BAPIRateLimitPeriod   *config.Tunable[time.Duration]
+	FAPIRateLimitRequests *config.Tunable[int]
+	FAPIRateLimitPeriod   *config.Tunable[time.Duration]
+
+	// SessionTouchRate is the shortest interval between two touches of a
+	// session which are persisted.
+	SessionTouchRate *config.Tunable[time.Duration]
+
+	// DBQueryBudget and DBQueryBudgetDuration are the number of queries and
+	// the time spent on them which requests are expected to stay within.
+	DBQueryBudget         *config.Tunable[int]
+	DBQueryBudgetDuration *config.Tunable[time.Duration]
+
+	reloader *config.Reloader
+}
+
+var (
+	tunables     *Tunables
+	tunablesErrs error
+	tunablesOnce sync.Once
+)
+
+// Live returns the tunables of the process, loading them on first use.
+func Live() *Tunables {
+	tunablesOnce.Do(func() {
+		tunables, tunablesErrs = loadTunables(source)
+	})
+	return tunables
+}
+
+func tunablesErr() error {
+	Live()
+	return tunablesErrs
+}
+
+func loadTunables(source config.Source) (*Tunables, error) {
+	t := &Tunables{
+		BAPIRateLimitRequests: config.NewTunable(0),
+		BAPIRateLimitPeriod:   config.NewTunable(time.Duration(0)),
+		FAPIRateLimitRequests: config.NewTunable(0),
+		FAPIRateLimitPeriod:   config.NewTunable(time.Duration(0)),
+		SessionTouchRate:      config.NewTunable(time.Duration(0)),
+		DBQueryBudget:         config.NewTunable(0),
+		DBQueryBudgetDuration: config.NewTunable(time.Duration(0)),
+		reloader:              config.NewReloader(),
+	}
+	t.reloader.Int(cenv.BAPIRateLimitRequests, t.BAPIRateLimitRequests, config.Range[int]{Min: 0, Max: 100_000})
+	t.reloader.Duration(cenv.BAPIRateLimitPeriodInSeconds, t.BAPIRateLimitPeriod, time.Second, config.Range[time.Duration]{Min: 0, Max: time.Hour})
+	t.reloader.Int(cenv.FAPIRateLimitRequests, t.FAPIRateLimitRequests, config.Range[int]{Min: 0, Max: 100_000})
+	t.reloader.Duration(cenv.FAPIRateLimitPeriodInSeconds, t.FAPIRateLimitPeriod, time.Second, config.Range[time.Duration]{Min: 0, Max: time.Hour})
+	t.reloader.Duration(cenv.ClerkMaxSessionTouchRateSeconds, t.SessionTouchRate, time.Second, config.Range[time.Duration]{Min: 0, Max: time.Hour})
+	t.reloader.Int(cenv.ClerkDBQueryBudget, t.DBQueryBudget, config.Range[int]{Min: 0, Max: 10_000})
+	t.reloader.Duration(cenv.ClerkDBQueryBudgetMs, t.DBQueryBudgetDuration, time.Millisecond, config.Range[time.Duration]{Min: 0, Max: time.Minute})
+
+	return t, t.reloader.Reload(source)
+}
+
+// defaultReloadInterval is how often the tunables file is read, unless
+// cenv.ClerkTunablesReloadIntervalSeconds says otherwise.
+const defaultReloadInterval = 30 * time.Second
+
+// WatchTunables keeps reloading the tunables from the file in
+// cenv.ClerkTunablesFile until the context is done. It does nothing if no
+// file is set. Invalid files are logged and ignored, so that a bad change
+// can't take the APIs down.
+func WatchTunables(ctx context.Context) {
+	path := cenv.Get(cenv.ClerkTunablesFile)
+	if path == "" {
+		return
+	}
+
+	interval := defaultReloadInterval
+	if seconds := cenv.GetInt(cenv.ClerkTunablesReloadIntervalSeconds); seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	load := func() (config.Source, error) {
+		return config.FileSource(path)
+	}
+	Live().reloader.Watch(ctx, interval, load, func(err error) {
+		log.Warning(ctx, "serviceconfig: reloading tunables from %s: %s", path, err)
+	})
+}
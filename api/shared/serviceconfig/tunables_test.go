@@ -0,0 +1,38 @@
+package serviceconfig
+
+import (
+	"testing"
+	"time"
+
+	"clerk/pkg/cenv"
+	"clerk/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTunables(t *testing.T) {
+	t.Parallel()
+
+	tunables, err := loadTunables(config.MapSource{
+		cenv.BAPIRateLimitRequests:        "100",
+		cenv.BAPIRateLimitPeriodInSeconds: "10",
+		cenv.FAPIRateLimitRequests:        "20",
+		cenv.ClerkDBQueryBudgetMs:         "250",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 100, tunables.BAPIRateLimitRequests.Get())
+	assert.Equal(t, 10*time.Second, tunables.BAPIRateLimitPeriod.Get())
+	assert.Equal(t, 20, tunables.FAPIRateLimitRequests.Get())
+	assert.Zero(t, tunables.FAPIRateLimitPeriod.Get())
+	assert.Equal(t, 250*time.Millisecond, tunables.DBQueryBudgetDuration.Get())
+	assert.Zero(t, tunables.SessionTouchRate.Get())
+
+	// out of range values are rejected and the previous values are kept
+	err = tunables.reloader.Reload(config.MapSource{
+		cenv.BAPIRateLimitRequests: "200",
+		cenv.ClerkDBQueryBudget:    "-1",
+	})
+	require.Error(t, err)
+	assert.Equal(t, 100, tunables.BAPIRateLimitRequests.Get())
+}
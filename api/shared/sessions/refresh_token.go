@@ -0,0 +1,58 @@
+package sessions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"clerk/model"
+	"clerk/pkg/ctx/client_type"
+)
+
+// RefreshTokenHeader is the header native clients which support refresh
+// tokens send to opt in to them. The refresh token of a session created by
+// the request is returned in the same header.
+const RefreshTokenHeader = "Clerk-Refresh-Token"
+
+type issuedRefreshTokenKey struct{}
+
+// IssuedRefreshToken is the refresh token issued to the session created
+// while handling a request, until it's handed to the native client which
+// made the request.
+type IssuedRefreshToken struct {
+	SessionID string
+	Token     string
+}
+
+// WithRefreshTokens returns a context in which the sessions that are created
+// are issued a refresh token, which can then be read with
+// IssuedRefreshTokenFromContext.
+func WithRefreshTokens(ctx context.Context) context.Context {
+	return context.WithValue(ctx, issuedRefreshTokenKey{}, &IssuedRefreshToken{})
+}
+
+// IssuedRefreshTokenFromContext returns the refresh token issued to the
+// session created with the given context, or nil if none was issued.
+func IssuedRefreshTokenFromContext(ctx context.Context) *IssuedRefreshToken {
+	issued, ok := ctx.Value(issuedRefreshTokenKey{}).(*IssuedRefreshToken)
+	if !ok || issued.Token == "" {
+		return nil
+	}
+	return issued
+}
+
+// RequiresRefreshToken returns whether session tokens can only be created
+// for the given session in exchange for its refresh token. Once a session
+// has been issued a refresh token, it's the only credential native clients
+// can get tokens for the session with, so that its reuse detection can't be
+// bypassed with the client token alone.
+func RequiresRefreshToken(ctx context.Context, session *model.Session) bool {
+	return client_type.FromContext(ctx).IsNative() && session.RefreshTokenDigest.Valid
+}
+
+// RefreshTokenDigest is what's stored for a refresh token, so that a leaked
+// database can't be used to refresh sessions.
+func RefreshTokenDigest(refreshToken string) string {
+	digest := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(digest[:])
+}
@@ -0,0 +1,48 @@
+package sessions
+
+import (
+	"context"
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/ctx/client_type"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestIssuedRefreshTokenFromContext(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, IssuedRefreshTokenFromContext(context.Background()))
+
+	// nothing was issued yet
+	ctx := WithRefreshTokens(context.Background())
+	assert.Nil(t, IssuedRefreshTokenFromContext(ctx))
+
+	issued := ctx.Value(issuedRefreshTokenKey{}).(*IssuedRefreshToken)
+	*issued = IssuedRefreshToken{SessionID: "sess_1", Token: "token"}
+
+	got := IssuedRefreshTokenFromContext(ctx)
+	require.NotNil(t, got)
+	assert.Equal(t, "sess_1", got.SessionID)
+	assert.Equal(t, "token", got.Token)
+}
+
+func TestRequiresRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	native := client_type.NewContext(context.Background(), client_type.Native)
+	browser := client_type.NewContext(context.Background(), client_type.Browser)
+
+	withRefreshToken := &model.Session{Session: &sqbmodel.Session{
+		RefreshTokenDigest: null.StringFrom(RefreshTokenDigest("token")),
+	}}
+	withoutRefreshToken := &model.Session{Session: &sqbmodel.Session{}}
+
+	assert.True(t, RequiresRefreshToken(native, withRefreshToken))
+	assert.False(t, RequiresRefreshToken(native, withoutRefreshToken))
+	assert.False(t, RequiresRefreshToken(browser, withRefreshToken))
+}
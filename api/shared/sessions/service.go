@@ -7,18 +7,21 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
+	"clerk/api/shared/backchannel_logout"
 	"clerk/api/shared/billing"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/events"
 	"clerk/api/shared/gamp"
+	"clerk/api/shared/impersonation_audits"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/serializable"
-	"clerk/api/shared/session_activities"
+	"clerk/api/shared/user_profile"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/clerkerrors"
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/maintenance"
+	"clerk/pkg/rand"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
 	"clerk/utils/clerk"
@@ -36,17 +39,20 @@ type Service struct {
 	db        database.Database
 
 	// services
-	billingService           *billing.Service
-	eventService             *events.Service
-	gampService              *gamp.Service
-	orgService               *organizations.Service
-	serializableService      *serializable.Service
-	clientDataService        *client_data.Service
-	sessionActivitiesService *session_activities.Service
+	backchannelLogoutService  *backchannel_logout.Service
+	billingService            *billing.Service
+	eventService              *events.Service
+	gampService               *gamp.Service
+	impersonationAuditService *impersonation_audits.Service
+	orgService                *organizations.Service
+	serializableService       *serializable.Service
+	clientDataService         *client_data.Service
+	userProfileService        *user_profile.Service
 
 	// repositories
 	actorTokenRepo        *repository.ActorToken
 	identificationRepo    *repository.Identification
+	instanceRepo          *repository.Instances
 	integrationRepo       *repository.Integrations
 	orgMembershipRepo     *repository.OrganizationMembership
 	sessionRepo           *repository.Sessions
@@ -58,25 +64,28 @@ type Service struct {
 
 func NewService(deps clerk.Deps) *Service {
 	return &Service{
-		clock:                    deps.Clock(),
-		gueClient:                deps.GueClient(),
-		db:                       deps.DB(),
-		billingService:           billing.NewService(deps),
-		eventService:             events.NewService(deps),
-		gampService:              gamp.NewService(deps),
-		orgService:               organizations.NewService(deps),
-		serializableService:      serializable.NewService(deps.Clock()),
-		actorTokenRepo:           repository.NewActorToken(),
-		identificationRepo:       repository.NewIdentification(),
-		integrationRepo:          repository.NewIntegrations(),
-		orgMembershipRepo:        repository.NewOrganizationMembership(),
-		sessionRepo:              repository.NewSessions(deps.Clock()),
-		sessionActivitiesRepo:    repository.NewSessionActivities(),
-		signInRepo:               repository.NewSignIn(),
-		signUpRepo:               repository.NewSignUp(),
-		userRepo:                 repository.NewUsers(),
-		clientDataService:        client_data.NewService(deps),
-		sessionActivitiesService: session_activities.NewService(),
+		clock:                     deps.Clock(),
+		gueClient:                 deps.GueClient(),
+		db:                        deps.DB(),
+		backchannelLogoutService:  backchannel_logout.NewService(deps),
+		billingService:            billing.NewService(deps),
+		eventService:              events.NewService(deps),
+		gampService:               gamp.NewService(deps),
+		impersonationAuditService: impersonation_audits.NewService(deps.Clock()),
+		orgService:                organizations.NewService(deps),
+		serializableService:       serializable.NewService(deps.Clock()),
+		actorTokenRepo:            deps.Repositories().ActorToken,
+		identificationRepo:        deps.Repositories().Identification,
+		instanceRepo:              deps.Repositories().Instances,
+		integrationRepo:           deps.Repositories().Integrations,
+		orgMembershipRepo:         deps.Repositories().OrganizationMembership,
+		sessionRepo:               deps.Repositories().Sessions,
+		sessionActivitiesRepo:     deps.Repositories().SessionActivities,
+		signInRepo:                deps.Repositories().SignIn,
+		signUpRepo:                deps.Repositories().SignUp,
+		userRepo:                  deps.Repositories().Users,
+		clientDataService:         client_data.NewService(deps),
+		userProfileService:        user_profile.NewService(deps.Clock()),
 	}
 }
 
@@ -131,8 +140,22 @@ func (s *Service) Create(
 		SessionActivityID:        null.StringFromPtr(params.ActivityID),
 	}}
 
+	// native clients which opted in to refresh tokens get the first one of
+	// the session right away, so that the client token alone is never enough
+	// to get one
+	issuedRefreshToken, issueRefreshToken := ctx.Value(issuedRefreshTokenKey{}).(*IssuedRefreshToken)
+	var refreshToken string
+	if issueRefreshToken {
+		refreshToken, err = rand.Token()
+		if err != nil {
+			return nil, fmt.Errorf("sessions/create: generating refresh token: %w", err)
+		}
+		session.RefreshTokenDigest = null.StringFrom(RefreshTokenDigest(refreshToken))
+	}
+
+	var actorToken *model.ActorToken
 	if params.ActorTokenID != nil {
-		actorToken, err := s.actorTokenRepo.FindByID(ctx, exec, *params.ActorTokenID)
+		actorToken, err = s.actorTokenRepo.FindByID(ctx, exec, *params.ActorTokenID)
 		if err != nil {
 			return nil, fmt.Errorf("sessions/create: looking for actor token %s: %w", *params.ActorTokenID, err)
 		}
@@ -148,6 +171,16 @@ func (s *Service) Create(
 	}
 	cdsSession.CopyToSessionModel(session)
 
+	if issueRefreshToken {
+		*issuedRefreshToken = IssuedRefreshToken{SessionID: session.ID, Token: refreshToken}
+	}
+
+	if actorToken != nil {
+		if err := s.impersonationAuditService.Record(ctx, exec, session, actorToken); err != nil {
+			return nil, fmt.Errorf("sessions/create: %w", err)
+		}
+	}
+
 	// Update user's last_sign_in_at timestamp if not impersonation session
 	if !session.HasActor() {
 		if err := s.userRepo.UpdateLastSignInAtByID(ctx, exec, params.User.ID, session.CreatedAt); err != nil {
@@ -180,19 +213,25 @@ func (s *Service) Create(
 	return session, nil
 }
 
-func (s *Service) Activate(ctx context.Context, instance *model.Instance, session *model.Session) error {
+// Activate marks the given pending session as active. Members only
+// instances refuse to activate sessions of users who aren't members of their
+// organizations, in which case an apierror.Error is returned.
+func (s *Service) Activate(ctx context.Context, env *model.Env, session *model.Session) error {
 	if session.Status != constants.SESSPendingActivation {
 		return clerkerrors.WithStacktrace("invalid session status: %s", session.Status)
 	}
+	if err := s.orgService.EnsureMembersOnlyAccess(ctx, s.db, env.AuthConfig.OrganizationSettings, session.UserID); err != nil {
+		return err
+	}
 	cdsSession := client_data.NewSessionFromSessionModel(session)
 	cdsSession.Status = constants.SESSActive
 	if err := s.clientDataService.UpdateSessionStatus(ctx, cdsSession); err != nil {
 		return err
 	}
 	cdsSession.CopyToSessionModel(session)
-	if err := s.eventService.SessionCreated(ctx, s.db, instance, session); err != nil {
+	if err := s.eventService.SessionCreated(ctx, s.db, env.Instance, session); err != nil {
 		return fmt.Errorf("sessions/activate: send session created event for %+v in instance %s: %w",
-			session, instance.ID, err)
+			session, env.Instance.ID, err)
 	}
 	return nil
 }
@@ -206,6 +245,10 @@ func (s *Service) End(ctx context.Context, instance *model.Instance, session *mo
 	}
 	cdsSession.CopyToSessionModel(session)
 
+	if err := s.impersonationAuditService.RecordEnd(ctx, s.db, session); err != nil {
+		return apierror.Unexpected(err)
+	}
+
 	err := s.eventService.SessionEnded(ctx, s.db, instance, serialize.SessionToServerAPI(s.clock, session))
 	if err != nil {
 		return apierror.Unexpected(err)
@@ -224,6 +267,10 @@ func (s *Service) Remove(ctx context.Context, instance *model.Instance, session
 	}
 	cdsSession.CopyToSessionModel(session)
 
+	if err := s.impersonationAuditService.RecordEnd(ctx, s.db, session); err != nil {
+		return apierror.Unexpected(err)
+	}
+
 	err = s.eventService.SessionRemoved(ctx, s.db, instance, serialize.SessionToServerAPI(s.clock, session))
 	if err != nil {
 		return apierror.Unexpected(err)
@@ -242,11 +289,19 @@ func (s *Service) Revoke(ctx context.Context, instance *model.Instance, session
 	}
 	cdsSession.CopyToSessionModel(session)
 
+	if err := s.impersonationAuditService.RecordEnd(ctx, s.db, session); err != nil {
+		return apierror.Unexpected(err)
+	}
+
 	err = s.eventService.SessionRevoked(ctx, s.db, instance, serialize.SessionToServerAPI(s.clock, session))
 	if err != nil {
 		return apierror.Unexpected(err)
 	}
 
+	if err := s.backchannelLogoutService.Enqueue(ctx, s.db, instance, session); err != nil {
+		return apierror.Unexpected(err)
+	}
+
 	return nil
 }
 
@@ -259,22 +314,53 @@ func (s *Service) RemoveAllWithoutClientTouch(ctx context.Context, sessions []*m
 		}
 		cdsSession.CopyToSessionModel(session)
 	}
+	if err := s.impersonationAuditService.RecordEnd(ctx, s.db, sessions...); err != nil {
+		return fmt.Errorf("RemoveAllWithoutClientTouch: %w", err)
+	}
 	return nil
 }
 
 // RevokeAllForUserID marks all active sessions as revoked for a user with userID.
-// No event is triggered.
+// No event is triggered, but the backchannel logout URIs of the instance are
+// still notified.
 func (s *Service) RevokeAllForUserID(ctx context.Context, instanceID, userID string) error {
 	activeUserSessions, err := s.clientDataService.FindAllUserSessions(ctx, instanceID, userID, client_data.SessionFilterActiveOnly())
 	if err != nil {
 		return err
 	}
+	if len(activeUserSessions) == 0 {
+		return nil
+	}
+
 	for _, session := range activeUserSessions {
 		session.Status = constants.SESSRevoked
 		if err := s.clientDataService.UpdateSessionStatus(ctx, session); err != nil {
 			return err
 		}
 	}
+	if err := s.impersonationAuditService.RecordEnd(ctx, s.db, client_data.ToSessionModels(activeUserSessions)...); err != nil {
+		return err
+	}
+
+	instance, err := s.instanceRepo.FindByID(ctx, s.db, instanceID)
+	if err != nil {
+		return err
+	}
+	return s.backchannelLogoutService.Enqueue(ctx, s.db, instance, client_data.ToSessionModels(activeUserSessions)...)
+}
+
+// RevokeAllForUser marks all active sessions of the user as revoked, sending
+// a session revoked event for each one of them.
+func (s *Service) RevokeAllForUser(ctx context.Context, instance *model.Instance, userID string) apierror.Error {
+	activeUserSessions, err := s.clientDataService.FindAllUserSessions(ctx, instance.ID, userID, client_data.SessionFilterActiveOnly())
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+	for _, cdsSession := range activeUserSessions {
+		if apiErr := s.Revoke(ctx, instance, cdsSession.ToSessionModel()); apiErr != nil {
+			return apiErr
+		}
+	}
 	return nil
 }
 
@@ -311,36 +397,12 @@ func (s *Service) Touch(ctx context.Context, params TouchParams) error {
 		return nil
 	}
 
-	if !params.Session.SessionActivityID.Valid {
-		// no session activity registered, create a new one
-		params.Activity.SessionID = null.StringFrom(params.Session.ID)
-		err := s.sessionActivitiesService.CreateSessionActivity(ctx, s.db, params.Session.InstanceID, params.Activity)
-		if err != nil {
-			return err
-		}
-
-		sessionActivityID := params.Activity.ID
-
-		// update session with the new activity id
-		cdsSession.SessionActivityID = null.StringFrom(sessionActivityID)
-		err = s.clientDataService.UpdateSession(ctx, params.Session.InstanceID, params.Session.ClientID,
-			cdsSession, client_data.SessionColumns.SessionActivityID)
-		if err != nil {
-			return err
-		}
-		cdsSession.CopyToSessionModel(params.Session)
+	if params.Session.SessionActivityID.Valid && maintenance.FromContext(ctx) {
+		// the session already has an activity, which can wait until
+		// maintenance is over
 		return nil
 	}
-
-	if !maintenance.FromContext(ctx) {
-		// there is a session activity already associated with this session, so we need to update it
-		params.Activity.ID = params.Session.SessionActivityID.String
-		params.Activity.SessionID = null.StringFrom(params.Session.ID)
-		if err := s.sessionActivitiesRepo.Update(ctx, s.db, params.Activity); err != nil {
-			return err
-		}
-	}
-	return nil
+	return s.clientDataService.RecordSessionActivity(ctx, params.Session, params.Activity)
 }
 
 func (s *Service) ConvertToSessionWithUser(ctx context.Context, instance *model.Instance, userSettings *usersettings.UserSettings, session *model.Session, authConfig *model.AuthConfig) (*model.SessionWithUser, apierror.Error) {
@@ -407,7 +469,7 @@ func (s *Service) ConvertToSessionWithUser(ctx context.Context, instance *model.
 
 		if identification == nil {
 			// identification of current session does not exist (maybe deleted), use user's alternative identification (primary or username)
-			identification, err = s.fetchUserAlternativeIdentification(ctx, sessionWithUser.User.User)
+			identification, err = s.fetchUserAlternativeIdentification(ctx, userSettings, sessionWithUser.User.User)
 			if err != nil {
 				return nil, apierror.Unexpected(
 					fmt.Errorf("convertToSessionWithUser: retrieving alternative identification of user %s: %w", sessionWithUser.User.ID, err),
@@ -445,12 +507,9 @@ func (s *Service) ConvertToSessionWithUser(ctx context.Context, instance *model.
 			return nil, apierror.Unexpected(fmt.Errorf("convertToSessionWithUser: retrieving org memberships for user %s: %w", sessionWithUser.User.ID, err))
 		}
 
-		serializableMemberships := make([]*model.OrganizationMembershipSerializable, len(memberships))
-		for i, membership := range memberships {
-			serializableMemberships[i], err = s.orgService.ConvertToSerializable(ctx, s.db, membership)
-			if err != nil {
-				return nil, apierror.Unexpected(fmt.Errorf("convertToSessionWithUser: converting membership %s to serializable: %w", membership.ID, err))
-			}
+		serializableMemberships, err := s.orgService.ConvertAllToSerializable(ctx, s.db, memberships)
+		if err != nil {
+			return nil, apierror.Unexpected(fmt.Errorf("convertToSessionWithUser: converting memberships of user %s to serializable: %w", sessionWithUser.User.ID, err))
 		}
 		sessionWithUser.OrganizationMemberships = serializableMemberships
 	}
@@ -458,19 +517,8 @@ func (s *Service) ConvertToSessionWithUser(ctx context.Context, instance *model.
 	return &sessionWithUser, nil
 }
 
-func (s *Service) fetchUserAlternativeIdentification(ctx context.Context, user *model.User) (*model.Identification, error) {
-	var identificationID string
-	if user.PrimaryEmailAddressID.Valid {
-		identificationID = user.PrimaryEmailAddressID.String
-	} else if user.PrimaryPhoneNumberID.Valid {
-		identificationID = user.PrimaryPhoneNumberID.String
-	} else if user.PrimaryWeb3WalletID.Valid {
-		identificationID = user.PrimaryWeb3WalletID.String
-	}
-	if identificationID != "" {
-		return s.identificationRepo.QueryByID(ctx, s.db, identificationID)
-	}
-	return s.identificationRepo.QueryByTypeAndUser(ctx, s.db, constants.ITUsername, user.ID)
+func (s *Service) fetchUserAlternativeIdentification(ctx context.Context, userSettings *usersettings.UserSettings, user *model.User) (*model.Identification, error) {
+	return s.userProfileService.GetPreferredIdentification(ctx, s.db, user, user_profile.IdentifierPriority(userSettings))
 }
 
 func (s *Service) signInIdentificationID(ctx context.Context, sessionID string) (string, error) {
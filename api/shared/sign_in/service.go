@@ -9,6 +9,7 @@ import (
 	"clerk/api/shared/cookies"
 	"clerk/api/shared/externalaccount"
 	"clerk/api/shared/identifications"
+	"clerk/api/shared/instance_metrics"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/password"
 	"clerk/api/shared/serializable"
@@ -47,6 +48,7 @@ type Service struct {
 	cookieService          *cookies.Service
 	externalAccountService *externalaccount.Service
 	identificationService  *identifications.Service
+	instanceMetricsService *instance_metrics.Service
 	organizationService    *organizations.Service
 	passwordService        *password.Service
 	serializableService    *serializable.Service
@@ -79,6 +81,7 @@ func NewService(deps clerk.Deps) *Service {
 		cookieService:               cookies.NewService(deps),
 		externalAccountService:      externalaccount.NewService(deps),
 		identificationService:       identifications.NewService(deps),
+		instanceMetricsService:      instance_metrics.NewService(deps),
 		organizationService:         organizations.NewService(deps),
 		passwordService:             password.NewService(deps),
 		serializableService:         serializable.NewService(deps.Clock()),
@@ -87,18 +90,18 @@ func NewService(deps clerk.Deps) *Service {
 		userProfileService:          user_profile.NewService(deps.Clock()),
 		verificationService:         verifications.NewService(deps.Clock()),
 		clientDataService:           client_data.NewService(deps),
-		actorTokenRepo:              repository.NewActorToken(),
-		backupCodeRepo:              repository.NewBackupCode(),
-		dailySuccessfulSignInRepo:   repository.NewDailySuccessfulSignIns(),
-		externalAccountRepo:         repository.NewExternalAccount(),
-		identificationRepo:          repository.NewIdentification(),
-		invitationRepo:              repository.NewInvitations(),
-		organizationInvitationsRepo: repository.NewOrganizationInvitation(),
-		organizationMembershipsRepo: repository.NewOrganizationMembership(),
-		signInRepo:                  repository.NewSignIn(),
-		totpRepo:                    repository.NewTOTP(),
-		userRepo:                    repository.NewUsers(),
-		verificationRepo:            repository.NewVerification(),
+		actorTokenRepo:              deps.Repositories().ActorToken,
+		backupCodeRepo:              deps.Repositories().BackupCode,
+		dailySuccessfulSignInRepo:   deps.Repositories().DailySuccessfulSignIns,
+		externalAccountRepo:         deps.Repositories().ExternalAccount,
+		identificationRepo:          deps.Repositories().Identification,
+		invitationRepo:              deps.Repositories().Invitations,
+		organizationInvitationsRepo: deps.Repositories().OrganizationInvitation,
+		organizationMembershipsRepo: deps.Repositories().OrganizationMembership,
+		signInRepo:                  deps.Repositories().SignIn,
+		totpRepo:                    deps.Repositories().TOTP,
+		userRepo:                    deps.Repositories().Users,
+		verificationRepo:            deps.Repositories().Verification,
 	}
 }
 
@@ -263,10 +266,11 @@ func (s *Service) ConvertToSession(ctx context.Context, tx database.Tx, params C
 	var activeOrganizationID *string
 	if params.SignIn.OrganizationInvitationID.Valid {
 		invitation, err := s.organizationService.AcceptInvitation(ctx, tx, organizations.AcceptInvitationParams{
-			InvitationID: params.SignIn.OrganizationInvitationID.String,
-			UserID:       params.User.ID,
-			Instance:     params.Env.Instance,
-			Subscription: params.Env.Subscription,
+			InvitationID:         params.SignIn.OrganizationInvitationID.String,
+			UserID:               params.User.ID,
+			Instance:             params.Env.Instance,
+			Subscription:         params.Env.Subscription,
+			OrganizationSettings: params.Env.AuthConfig.OrganizationSettings,
 		})
 		if err != nil {
 			return nil, err
@@ -290,6 +294,11 @@ func (s *Service) ConvertToSession(ctx context.Context, tx database.Tx, params C
 		}
 	}
 
+	// members only instances don't allow sessions for users outside their organizations
+	if err := s.organizationService.EnsureMembersOnlyAccess(ctx, tx, params.Env.AuthConfig.OrganizationSettings, params.User.ID); err != nil {
+		return nil, err
+	}
+
 	// create session
 	newUserSession, err := s.sessionService.Create(ctx, tx, sessions.CreateParams{
 		AuthConfig:           params.Env.AuthConfig,
@@ -333,6 +342,11 @@ func (s *Service) ConvertToSession(ctx context.Context, tx database.Tx, params C
 		return nil, err
 	}
 
+	err = s.instanceMetricsService.Increment(ctx, tx, params.Env.Instance.ID, instance_metrics.SignIns, instance_metrics.OutcomeComplete)
+	if err != nil {
+		return nil, err
+	}
+
 	if params.SignIn.NewPasswordDigest.Valid {
 		// user went through reset password flow
 		err := s.passwordService.ChangeUserPassword(ctx, tx, password.ChangeUserPasswordParams{
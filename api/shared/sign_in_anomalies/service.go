@@ -0,0 +1,211 @@
+// Package sign_in_anomalies assesses the risk of sign-in attempts, based on
+// the session activity of the user and on the failed attempts made from the
+// same IP address.
+//
+// The scoring itself lives in pkg/anomaly. This package gathers its inputs,
+// keeps the assessment on the verification of the attempt and lets the
+// instance know about anomalous attempts through the
+// signIn.anomaly_detected event.
+package sign_in_anomalies
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/api/serialize"
+	"clerk/api/shared/client_data"
+	"clerk/api/shared/events"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/anomaly"
+	"clerk/pkg/cache"
+	clerktime "clerk/pkg/time"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
+)
+
+// only sessions touched within this period count towards the history of the
+// user
+const historyWindow = 90 * 24 * time.Hour
+
+type Service struct {
+	cache cache.Cache
+	clock clockwork.Clock
+
+	// services
+	clientDataService *client_data.Service
+	eventsService     *events.Service
+
+	// repositories
+	sessionActivityRepo *repository.SessionActivities
+	verificationRepo    *repository.Verification
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		cache:               deps.Cache(),
+		clock:               deps.Clock(),
+		clientDataService:   client_data.NewService(deps),
+		eventsService:       events.NewService(deps),
+		sessionActivityRepo: deps.Repositories().SessionActivities,
+		verificationRepo:    deps.Repositories().Verification,
+	}
+}
+
+// Enabled returns whether sign-in attempts of the instance are assessed.
+func Enabled(userSettings *usersettings.UserSettings) bool {
+	return userSettings.AttackProtection.SignInAnomalies.Enabled
+}
+
+// ActionFor returns what should happen to a sign-in attempt with the given
+// assessment, according to the settings of the instance.
+func ActionFor(userSettings *usersettings.UserSettings, assessment anomaly.Assessment) anomaly.Action {
+	settings := userSettings.AttackProtection.SignInAnomalies
+	return assessment.Action(settings.StepUpThreshold, settings.BlockThreshold)
+}
+
+// Assess scores the sign-in attempt of the user, made from the given
+// activity, and keeps the outcome on the verification of the attempt.
+func (s *Service) Assess(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	user *model.User,
+	verification *model.Verification,
+	activity *model.SessionActivity,
+) (anomaly.Assessment, error) {
+	history, err := s.userHistory(ctx, exec, instance.ID, user.ID)
+	if err != nil {
+		return anomaly.Assessment{}, fmt.Errorf("sign_in_anomalies/assess: loading history of user %s: %w", user.ID, err)
+	}
+
+	attempt := anomaly.Attempt{
+		Location:       toLocation(activity, s.clock.Now().UTC()),
+		RecentFailures: s.recentFailures(ctx, instance.ID, activity.IPAddress.String),
+	}
+	assessment := anomaly.Score(attempt, history)
+
+	signals := make([]string, len(assessment.Signals))
+	for i, signal := range assessment.Signals {
+		signals[i] = string(signal)
+	}
+	verification.RiskScore = null.IntFrom(assessment.Score)
+	verification.RiskSignals = signals
+	err = s.verificationRepo.Update(ctx, exec, verification,
+		sqbmodel.VerificationColumns.RiskScore,
+		sqbmodel.VerificationColumns.RiskSignals,
+	)
+	if err != nil {
+		return anomaly.Assessment{}, fmt.Errorf("sign_in_anomalies/assess: updating verification %s: %w", verification.ID, err)
+	}
+	return assessment, nil
+}
+
+// Notify triggers a signIn.anomaly_detected event for an attempt with at
+// least one anomaly.
+func (s *Service) Notify(
+	ctx context.Context,
+	exec database.Executor,
+	instance *model.Instance,
+	signIn *model.SignIn,
+	user *model.User,
+	activity *model.SessionActivity,
+	assessment anomaly.Assessment,
+	action anomaly.Action,
+) error {
+	if len(assessment.Signals) == 0 {
+		return nil
+	}
+	payload := serialize.SignInAnomaly(signIn, user.ID, activity, assessment, action, clerktime.UnixMilli(s.clock.Now().UTC()))
+	if err := s.eventsService.SignInAnomalyDetected(ctx, exec, instance, payload, user.ID); err != nil {
+		return fmt.Errorf("sign_in_anomalies/notify: sending event for sign in %s: %w", signIn.ID, err)
+	}
+	return nil
+}
+
+// RecordFailure keeps track of a failed sign-in attempt from the given IP
+// address, so that bursts of them can be detected.
+//
+// NOTE: failures are kept in the cache and updated without locking, so a few
+// concurrent ones may go uncounted. That's fine, as bursts are large anyway.
+func (s *Service) RecordFailure(ctx context.Context, instanceID, ipAddress string) {
+	if ipAddress == "" {
+		return
+	}
+
+	key := failuresKey(instanceID, ipAddress)
+	var failures anomaly.Failures
+	if err := s.cache.Get(ctx, key, &failures); err != nil {
+		log.Warning(ctx, "sign_in_anomalies: fetching failures of %s: %s", ipAddress, err)
+		return
+	}
+	failures.Record(s.clock.Now().UTC())
+	if err := s.cache.Set(ctx, key, failures, anomaly.BurstWindow); err != nil {
+		log.Warning(ctx, "sign_in_anomalies: storing failures of %s: %s", ipAddress, err)
+	}
+}
+
+func (s *Service) recentFailures(ctx context.Context, instanceID, ipAddress string) int {
+	if ipAddress == "" {
+		return 0
+	}
+
+	var failures anomaly.Failures
+	if err := s.cache.Get(ctx, failuresKey(instanceID, ipAddress), &failures); err != nil {
+		log.Warning(ctx, "sign_in_anomalies: fetching failures of %s: %s", ipAddress, err)
+		return 0
+	}
+	return failures.Count(s.clock.Now().UTC())
+}
+
+// userHistory returns the locations of the latest activity of each recent
+// session of the user.
+func (s *Service) userHistory(ctx context.Context, exec database.Executor, instanceID, userID string) ([]anomaly.Location, error) {
+	sessions, err := s.clientDataService.FindAllUserSessions(ctx, instanceID, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	since := s.clock.Now().UTC().Add(-historyWindow)
+	activityIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if !session.SessionActivityID.Valid || session.TouchedAt.Before(since) {
+			continue
+		}
+		activityIDs = append(activityIDs, session.SessionActivityID.String)
+	}
+	if len(activityIDs) == 0 {
+		return nil, nil
+	}
+
+	activities, err := s.sessionActivityRepo.FindAllByIDs(ctx, exec, activityIDs)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]anomaly.Location, len(activities))
+	for i, activity := range activities {
+		history[i] = toLocation(activity, activity.UpdatedAt)
+	}
+	return history, nil
+}
+
+func toLocation(activity *model.SessionActivity, at time.Time) anomaly.Location {
+	return anomaly.Location{
+		IPAddress: activity.IPAddress.String,
+		Country:   activity.Country.String,
+		Latitude:  activity.Latitude.Ptr(),
+		Longitude: activity.Longitude.Ptr(),
+		At:        at,
+	}
+}
+
+func failuresKey(instanceID, ipAddress string) string {
+	return fmt.Sprintf("sign_in_failures:%s:%s", instanceID, ipAddress)
+}
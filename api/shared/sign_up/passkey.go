@@ -0,0 +1,66 @@
+package sign_up
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// passkeyAction is what happens to the passkey identification of a sign-up
+// once the sign-up converts to a user.
+type passkeyAction int
+
+const (
+	// passkeyKept leaves the identification as it is, because there is
+	// none or it already belongs to the user.
+	passkeyKept passkeyAction = iota
+	// passkeyClaimed links the identification, and thus the passkey, to
+	// the user.
+	passkeyClaimed
+	// passkeyDiscarded deletes the identification of a registration which
+	// was never completed, along with its passkey.
+	passkeyDiscarded
+)
+
+func passkeyActionFor(identification *model.Identification) passkeyAction {
+	switch {
+	case identification == nil || identification.UserID.Valid:
+		return passkeyKept
+	case identification.IsVerified():
+		return passkeyClaimed
+	default:
+		return passkeyDiscarded
+	}
+}
+
+// claimPasskey links the passkey which was registered during the sign-up to
+// the user it converted to. Passkeys are bound to the reserved user ID,
+// which the user was created with, so only the identification is updated.
+func (s *Service) claimPasskey(ctx context.Context, tx database.Tx, signUp *model.SignUp, user *model.User) error {
+	if !signUp.PasskeyID.Valid {
+		return nil
+	}
+
+	identification, err := s.identificationRepo.QueryByID(ctx, tx, signUp.PasskeyID.String)
+	if err != nil {
+		return fmt.Errorf("sign-up/claimPasskey: fetching identification %s: %w", signUp.PasskeyID.String, err)
+	}
+
+	switch passkeyActionFor(identification) {
+	case passkeyClaimed:
+		identification.UserID = null.StringFrom(user.ID)
+		if err := s.identificationRepo.Update(ctx, tx, identification, sqbmodel.IdentificationColumns.UserID); err != nil {
+			return fmt.Errorf("sign-up/claimPasskey: linking identification %s to user %s: %w", identification.ID, user.ID, err)
+		}
+	case passkeyDiscarded:
+		if err := s.identificationRepo.DeleteByID(ctx, tx, identification.ID); err != nil {
+			return fmt.Errorf("sign-up/claimPasskey: deleting identification %s: %w", identification.ID, err)
+		}
+	}
+	return nil
+}
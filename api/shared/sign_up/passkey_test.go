@@ -0,0 +1,42 @@
+package sign_up
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestPasskeyActionFor(t *testing.T) {
+	t.Parallel()
+
+	passkey := func(status string, userID null.String) *model.Identification {
+		return &model.Identification{Identification: &sqbmodel.Identification{
+			ID:     "idn_1",
+			Type:   constants.ITPasskey,
+			Status: status,
+			UserID: userID,
+		}}
+	}
+
+	for _, tc := range []struct {
+		name           string
+		identification *model.Identification
+		want           passkeyAction
+	}{
+		{name: "no registration", want: passkeyKept},
+		{name: "verified", identification: passkey(constants.ISVerified, null.String{}), want: passkeyClaimed},
+		{name: "not verified", identification: passkey(constants.ISNotSet, null.String{}), want: passkeyDiscarded},
+		{name: "already claimed", identification: passkey(constants.ISVerified, null.StringFrom("user_1")), want: passkeyKept},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, passkeyActionFor(tc.identification))
+		})
+	}
+}
@@ -9,16 +9,19 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/shared/client_data"
 	"clerk/api/shared/cookies"
+	"clerk/api/shared/custom_fields"
 	"clerk/api/shared/events"
 	"clerk/api/shared/externalaccount"
 	"clerk/api/shared/gamp"
 	"clerk/api/shared/identifications"
 	"clerk/api/shared/images"
+	"clerk/api/shared/legal"
 	"clerk/api/shared/organizations"
 	"clerk/api/shared/orgdomain"
 	"clerk/api/shared/restrictions"
 	"clerk/api/shared/serializable"
 	"clerk/api/shared/sessions"
+	"clerk/api/shared/sign_up_funnel"
 	"clerk/api/shared/users"
 	"clerk/api/shared/validators"
 	"clerk/api/shared/verifications"
@@ -58,11 +61,13 @@ type Service struct {
 	gampService            *gamp.Service
 	externalAccountService *externalaccount.Service
 	identificationService  *identifications.Service
+	legalService           *legal.Service
 	orgDomainService       *orgdomain.Service
 	organizationService    *organizations.Service
 	restrictionService     *restrictions.Service
 	serializableService    *serializable.Service
 	sessionService         *sessions.Service
+	signUpFunnelService    *sign_up_funnel.Service
 	userService            *users.CreateService
 	validatorService       *validators.Service
 	verificationService    *verifications.Service
@@ -89,23 +94,25 @@ func NewService(deps clerk.Deps) *Service {
 		gampService:            gamp.NewService(deps),
 		externalAccountService: externalaccount.NewService(deps),
 		identificationService:  identifications.NewService(deps),
+		legalService:           legal.NewService(),
 		orgDomainService:       orgdomain.NewService(deps.Clock()),
 		organizationService:    organizations.NewService(deps),
-		imageService:           images.NewService(deps.StorageClient()),
-		restrictionService:     restrictions.NewService(deps.EmailQualityChecker()),
+		imageService:           images.NewService(deps.Clock(), deps.StorageClient()),
+		restrictionService:     restrictions.NewService(deps),
 		serializableService:    serializable.NewService(deps.Clock()),
 		sessionService:         sessions.NewService(deps),
-		userService:            users.NewCreateService(deps.Clock()),
+		signUpFunnelService:    sign_up_funnel.NewService(deps),
+		userService:            users.NewCreateService(deps),
 		clientDataService:      client_data.NewService(deps),
 		validatorService:       validators.NewService(),
 		verificationService:    verifications.NewService(deps.Clock()),
-		dailySuccessfulSignUps: repository.NewDailySuccessfulSignUps(),
-		identificationRepo:     repository.NewIdentification(),
-		invitationRepo:         repository.NewInvitations(),
-		orgInvitationRepo:      repository.NewOrganizationInvitation(),
-		signUpRepo:             repository.NewSignUp(),
-		userRepo:               repository.NewUsers(),
-		verificationRepo:       repository.NewVerification(),
+		dailySuccessfulSignUps: deps.Repositories().DailySuccessfulSignUps,
+		identificationRepo:     deps.Repositories().Identification,
+		invitationRepo:         deps.Repositories().Invitations,
+		orgInvitationRepo:      deps.Repositories().OrganizationInvitation,
+		signUpRepo:             deps.Repositories().SignUp,
+		userRepo:               deps.Repositories().Users,
+		verificationRepo:       deps.Repositories().Verification,
 	}
 }
 
@@ -155,6 +162,9 @@ func (s *Service) convertToUser(
 	}
 
 	user := &model.User{User: &sqbmodel.User{
+		// set only when a passkey was registered during the sign-up, since
+		// the passkey is bound to the ID of the user
+		ID:             signUp.ReservedUserID.String,
 		InstanceID:     env.Instance.ID,
 		PasswordDigest: signUp.PasswordDigest,
 		PasswordHasher: signUp.PasswordHasher,
@@ -172,6 +182,14 @@ func (s *Service) convertToUser(
 		user.PublicMetadata = signUp.PublicMetadata
 	}
 
+	// custom fields end up in the metadata of the user, so that they're
+	// available wherever the metadata is
+	user.PublicMetadata, user.UnsafeMetadata, err = custom_fields.ToMetadata(
+		custom_fields.Fields(userSettings), signUp.CustomFields, user.PublicMetadata, user.UnsafeMetadata)
+	if err != nil {
+		return nil, fmt.Errorf("sign-up/convertToUser: copying custom fields of %s: %w", signUp.ID, err)
+	}
+
 	if externalAccount != nil && externalAccount.AvatarURL != "" {
 		user.ProfileImagePublicURL = null.StringFrom(externalAccount.AvatarURL)
 	}
@@ -190,14 +208,22 @@ func (s *Service) convertToUser(
 		return nil, err
 	}
 
+	if signUp.LegalAcceptedVersion.Valid {
+		err = s.legalService.RecordAcceptance(ctx, tx, user, signUp.LegalAcceptedVersion.String, signUp.LegalAcceptedAt.Time)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// add user in organization if an organization invitation was used
 	var activeOrganizationID *string
 	if signUp.OrganizationInvitationID.Valid {
 		invitation, err := s.organizationService.AcceptInvitation(ctx, tx, organizations.AcceptInvitationParams{
-			InvitationID: signUp.OrganizationInvitationID.String,
-			UserID:       user.ID,
-			Instance:     env.Instance,
-			Subscription: env.Subscription,
+			InvitationID:         signUp.OrganizationInvitationID.String,
+			UserID:               user.ID,
+			Instance:             env.Instance,
+			Subscription:         env.Subscription,
+			OrganizationSettings: env.AuthConfig.OrganizationSettings,
 		})
 		if err != nil {
 			return nil, err
@@ -218,6 +244,12 @@ func (s *Service) convertToUser(
 		}
 	}
 
+	// members only instances don't allow sessions for users outside their
+	// organizations, unless the sign-up made the user a member of one
+	if err := s.organizationService.EnsureMembersOnlyAccess(ctx, tx, env.AuthConfig.OrganizationSettings, user.ID); err != nil {
+		return nil, err
+	}
+
 	// create session
 	userSession, err := s.sessionService.Create(ctx, tx, sessions.CreateParams{
 		AuthConfig:           env.AuthConfig,
@@ -251,6 +283,7 @@ func (s *Service) convertToUser(
 	if err != nil {
 		return nil, err
 	}
+	s.signUpFunnelService.Track(ctx, tx, env.Instance, signUp, sign_up_funnel.StageConverted, "")
 
 	// if we have an external account, verify that the correct verification is attached to it.
 	// if not, swap it out.
@@ -310,6 +343,10 @@ func (s *Service) convertToUser(
 		}
 	}
 
+	if err := s.claimPasskey(ctx, tx, signUp, user); err != nil {
+		return nil, err
+	}
+
 	// find possible unverified identifications
 	allIdentIDs := make([]string, 0)
 	if signUp.EmailAddressID.Valid {
@@ -598,6 +635,7 @@ func (s Service) checkProgressiveStatus(ctx context.Context, exec database.Execu
 		hasVerifiedExternalAccount      bool
 		hasVerifiedWeb3Account          bool
 		hasVerifiedSAMLAccount          bool
+		hasVerifiedPasskey              bool
 	)
 
 	identificationTypes := set.New[string]()
@@ -616,6 +654,10 @@ func (s Service) checkProgressiveStatus(ctx context.Context, exec database.Execu
 			hasVerifiedSAMLAccount = true
 		}
 
+		if identification.IsPasskey() {
+			hasVerifiedPasskey = true
+		}
+
 		identificationTypes.Insert(identification.Type)
 	}
 
@@ -627,6 +669,24 @@ func (s Service) checkProgressiveStatus(ctx context.Context, exec database.Execu
 		}
 	}
 
+	if legal.IsRequired(userSettings) {
+		requiredFields.Insert(legal.RequirementName)
+
+		if legal.NeedsAcceptance(userSettings, signUp.LegalAcceptedVersion) {
+			missingFields.Insert(legal.RequirementName)
+		}
+	}
+
+	customFields := custom_fields.Fields(userSettings)
+	for _, field := range customFields {
+		if field.Required {
+			requiredFields.Insert(custom_fields.RequirementName(field.Key))
+		} else {
+			optionalFields.Insert(custom_fields.RequirementName(field.Key))
+		}
+	}
+	missingFields.Insert(custom_fields.Missing(customFields, signUp.CustomFields)...)
+
 	if signUp.SamlConnectionID.Valid {
 		requiredFields.Insert(names.SAML)
 
@@ -670,6 +730,20 @@ func (s Service) checkProgressiveStatus(ctx context.Context, exec database.Execu
 		}
 	}
 
+	// passkeys are registered during the sign-up instead of being submitted
+	// with it, so a passkey only counts as provided once it's verified
+	if passkey := userSettings.GetAttribute(names.Passkey); passkey.Base().Enabled {
+		if passkey.Base().Required {
+			requiredFields.Insert(constants.ITPasskey)
+
+			if !hasVerifiedPasskey {
+				missingFields.Insert(constants.ITPasskey)
+			}
+		} else {
+			optionalFields.Insert(constants.ITPasskey)
+		}
+	}
+
 	for _, social := range userSettings.EnabledSocial() {
 		if social.Required {
 			requiredFields.Insert(social.Strategy)
@@ -718,14 +792,14 @@ func (s Service) checkProgressiveStatus(ctx context.Context, exec database.Execu
 	// From now on we handle some special cases...
 	//
 
-	// Special case #1: When signing up with OAuth, we treat passwords as
-	// non-required, even if they are marked as required by the instance
-	// settings.
+	// Special case #1: When signing up with OAuth, Web3, SAML or a passkey,
+	// we treat passwords as non-required, even if they are marked as required
+	// by the instance settings.
 	//
 	// If we ever want to support really required passwords (i.e.
 	// you're asked to pick a password before the sign up flow can finish),
 	// we'll have to revisit this.
-	if hasVerifiedExternalAccount || hasVerifiedWeb3Account || hasVerifiedSAMLAccount {
+	if hasVerifiedExternalAccount || hasVerifiedWeb3Account || hasVerifiedSAMLAccount || hasVerifiedPasskey {
 		missingFields.Remove(string(names.Password))
 	}
 
@@ -790,6 +864,7 @@ func (s *Service) checkStatus(ctx context.Context, exec database.Executor, signU
 	verifiedIdentifierSet := set.New[string]()
 	satisfiedIdentificationRequirements := false
 	hasExternalAccountIdentifications := false
+	hasPasskeyIdentification := false
 
 	verifiedIdentifications, err := s.identificationRepo.FindAllVerifiedWithLinkedByID(ctx, exec, signUp.IdentificationIDs()...)
 	if err != nil {
@@ -801,6 +876,10 @@ func (s *Service) checkStatus(ctx context.Context, exec database.Executor, signU
 			hasExternalAccountIdentifications = true
 		}
 
+		if ident.IsPasskey() {
+			hasPasskeyIdentification = true
+		}
+
 		if requirementsSet.Contains(ident.Type) {
 			satisfiedIdentificationRequirements = true
 		}
@@ -853,15 +932,44 @@ func (s *Service) checkStatus(ctx context.Context, exec database.Executor, signU
 		}
 	}
 
+	passkey := userSettings.GetAttribute(names.Passkey)
+	if passkey.Base().Enabled {
+		s.addAttributeToStatus(&status, constants.ITPasskey, passkey)
+		if passkey.Base().Required && !hasPasskeyIdentification {
+			status.MissingFields = append(status.MissingFields, constants.ITPasskey)
+			status.MissingRequirements = append(status.MissingRequirements, constants.ITPasskey)
+		}
+	}
+
 	password := userSettings.GetAttribute(names.Password)
 	s.addAttributeToStatus(&status, param.Password.Name, password)
 	if password.Base().Required {
-		if !signUp.PasswordDigest.Valid && !hasExternalAccountIdentifications {
+		if !signUp.PasswordDigest.Valid && !hasExternalAccountIdentifications && !hasPasskeyIdentification {
 			status.MissingFields = append(status.MissingFields, param.Password.Name)
 			status.MissingRequirements = append(status.MissingRequirements, constants.ACAPassword)
 		}
 	}
 
+	if legal.IsRequired(userSettings) {
+		status.RequiredFields = append(status.RequiredFields, legal.RequirementName)
+		if legal.NeedsAcceptance(userSettings, signUp.LegalAcceptedVersion) {
+			status.MissingFields = append(status.MissingFields, legal.RequirementName)
+			status.MissingRequirements = append(status.MissingRequirements, legal.RequirementName)
+		}
+	}
+
+	customFields := custom_fields.Fields(userSettings)
+	for _, field := range customFields {
+		if field.Required {
+			status.RequiredFields = append(status.RequiredFields, custom_fields.RequirementName(field.Key))
+		} else {
+			status.OptionalFields = append(status.OptionalFields, custom_fields.RequirementName(field.Key))
+		}
+	}
+	missingCustomFields := custom_fields.Missing(customFields, signUp.CustomFields)
+	status.MissingFields = append(status.MissingFields, missingCustomFields...)
+	status.MissingRequirements = append(status.MissingRequirements, missingCustomFields...)
+
 	if !satisfiedIdentificationRequirements {
 		status.MissingRequirements = append(status.MissingRequirements, requirements...)
 	}
@@ -979,6 +1087,22 @@ func (s *Service) ConvertToSerializable(
 		}
 	}
 
+	if signUp.PasskeyID.Valid {
+		identification, err := s.identificationRepo.QueryByID(ctx, exec, signUp.PasskeyID.String)
+		if err != nil {
+			return nil, fmt.Errorf("signUp/convertToSerializable: find passkey identification %s for %+v: %w",
+				signUp.PasskeyID.String, signUp, err)
+		}
+		if identification != nil && identification.VerificationID.Valid {
+			signUpSerializable.PasskeyVerification, err = s.verificationService.VerificationWithStatus(
+				ctx, exec, identification.VerificationID.String)
+			if err != nil {
+				return nil, fmt.Errorf("signUp/convertToSerializable: find verification for identification %+v: %w",
+					identification, err)
+			}
+		}
+	}
+
 	if signUp.ExternalAccountVerificationID.Valid {
 		signUpSerializable.ExternalAccountVerification, err = s.verificationService.VerificationWithStatus(
 			ctx, exec, signUp.ExternalAccountVerificationID.String)
@@ -0,0 +1,141 @@
+// Package sign_up_funnel tracks how sign-ups progress, from their creation
+// to the creation of their user.
+//
+// Every time a sign-up reaches a stage of the funnel, an event is sent to
+// Segment. The funnel of every instance is also aggregated daily, from the
+// sign-ups themselves, for the dashboard.
+package sign_up_funnel
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/externalapis/segment"
+	"clerk/pkg/jobs"
+	sentryclerk "clerk/pkg/sentry"
+	clerktime "clerk/pkg/time"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+)
+
+// The stages of the funnel, in the order sign-ups go through them.
+// Abandoned sign-ups are the ones which were never converted.
+const (
+	StageCreated    = "created"
+	StageIdentified = "identified"
+	StageVerified   = "verified"
+	StageConverted  = "converted"
+	StageAbandoned  = "abandoned"
+)
+
+// reaggregatedDays is how many past days are aggregated every day. Sign-ups
+// which are still in progress at the end of their day are counted as
+// abandoned only once they expire, so days are aggregated again until all
+// of their sign-ups have either been converted or abandoned.
+const reaggregatedDays = 3
+
+type Service struct {
+	clock     clockwork.Clock
+	db        database.Database
+	gueClient *gue.Client
+
+	// repositories
+	signUpRepo       *repository.SignUp
+	funnelMetricRepo *repository.SignUpFunnelMetrics
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:            deps.Clock(),
+		db:               deps.DB(),
+		gueClient:        deps.GueClient(),
+		signUpRepo:       deps.Repositories().SignUp,
+		funnelMetricRepo: deps.Repositories().SignUpFunnelMetrics,
+	}
+}
+
+// Track sends an event to Segment for the sign-up reaching the given stage,
+// along with the strategy which got it there, if any. When exec is a
+// transaction, the event is only sent if it commits. Failures are reported
+// but never returned, so that analytics can't fail a sign-up.
+func (s *Service) Track(ctx context.Context, exec database.Executor, instance *model.Instance, signUp *model.SignUp, stage, strategy string) {
+	err := jobs.SegmentEnqueueEvent(ctx, s.gueClient, jobs.SegmentArgs{
+		Event:         segment.APIFrontendSignUpFunnelStageReached,
+		ApplicationID: instance.ApplicationID,
+		Properties:    eventProperties(instance, signUp, stage, strategy, s.clock.Now().UTC()),
+	}, jobs.WithTxIfApplicable(exec))
+	if err != nil {
+		sentryclerk.CaptureException(ctx, fmt.Errorf("signUpFunnel/track: enqueuing %s event for sign-up %s: %w", stage, signUp.ID, err))
+	}
+}
+
+// eventProperties returns the properties of the event of a stage. All
+// stages share the same properties, so that the funnel can be built from
+// a single event.
+func eventProperties(instance *model.Instance, signUp *model.SignUp, stage, strategy string, now time.Time) map[string]any {
+	properties := map[string]any{
+		"surface":             "API",
+		"location":            "Frontend",
+		"stage":               stage,
+		"applicationId":       instance.ApplicationID,
+		"instanceId":          instance.ID,
+		"environmentType":     instance.EnvironmentType,
+		"signUpId":            signUp.ID,
+		"secondsSinceCreated": int64(now.Sub(signUp.CreatedAt).Seconds()),
+	}
+	if strategy != "" {
+		properties["strategy"] = strategy
+	}
+	return properties
+}
+
+// EnqueueAggregations enqueues a job which aggregates the funnel of the
+// given day, for each of the last reaggregatedDays days. It is run once a
+// day.
+func (s *Service) EnqueueAggregations(ctx context.Context) error {
+	today := clerktime.DateTrunc("day", s.clock.Now().UTC())
+	for i := 1; i <= reaggregatedDays; i++ {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		err := jobs.AggregateSignUpFunnel(ctx, s.gueClient, jobs.AggregateSignUpFunnelArgs{Day: day})
+		if err != nil {
+			return fmt.Errorf("signUpFunnel/enqueueAggregations: enqueuing aggregation of %s: %w", day, err)
+		}
+	}
+	return nil
+}
+
+// AggregateDay counts the sign-ups created on the given day by the stage
+// they reached, for every instance which had any, and stores the counts.
+// A sign-up is counted in every stage it went through, so the counts only
+// decrease along the funnel.
+func (s *Service) AggregateDay(ctx context.Context, day time.Time) error {
+	from := clerktime.DateTrunc("day", day.UTC())
+	counts, err := s.signUpRepo.CountFunnelByInstance(ctx, s.db, from, from.AddDate(0, 0, 1), s.clock.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("signUpFunnel/aggregateDay: counting sign-ups of %s: %w", from.Format("2006-01-02"), err)
+	}
+
+	for _, count := range counts {
+		metric := &model.SignUpFunnelMetric{SignUpFunnelMetric: &sqbmodel.SignUpFunnelMetric{
+			InstanceID: count.InstanceID,
+			Day:        from,
+			Created:    count.Created,
+			Identified: count.Identified,
+			Verified:   count.Verified,
+			Converted:  count.Converted,
+			Abandoned:  count.Abandoned,
+		}}
+		if err := s.funnelMetricRepo.Upsert(ctx, s.db, metric); err != nil {
+			return fmt.Errorf("signUpFunnel/aggregateDay: storing funnel of instance %s for %s: %w",
+				count.InstanceID, from.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package sign_up_funnel
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventProperties(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	instance := &model.Instance{Instance: &sqbmodel.Instance{
+		ID:              "ins_1",
+		ApplicationID:   "app_1",
+		EnvironmentType: "production",
+	}}
+	signUp := &model.SignUp{SignUp: &sqbmodel.SignUp{ID: "sua_1", CreatedAt: createdAt}}
+
+	properties := eventProperties(instance, signUp, StageVerified, "email_code", createdAt.Add(90*time.Second))
+	assert.Equal(t, map[string]any{
+		"surface":             "API",
+		"location":            "Frontend",
+		"stage":               StageVerified,
+		"applicationId":       "app_1",
+		"instanceId":          "ins_1",
+		"environmentType":     "production",
+		"signUpId":            "sua_1",
+		"secondsSinceCreated": int64(90),
+		"strategy":            "email_code",
+	}, properties)
+
+	properties = eventProperties(instance, signUp, StageCreated, "", createdAt)
+	assert.NotContains(t, properties, "strategy")
+}
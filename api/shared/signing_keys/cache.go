@@ -0,0 +1,68 @@
+package signing_keys
+
+import (
+	"sync"
+	"time"
+
+	"clerk/model"
+)
+
+// CurrentKeyCacheTTL is for how long the keys of an instance are cached
+// before Current reads them again. It's kept short, since a revoked key is
+// removed from the JWKS right away and tokens it keeps signing until then
+// fail verification.
+const CurrentKeyCacheTTL = 10 * time.Second
+
+// currentKeysCache is shared by all services of the process, so that the
+// keys of an instance are read once per CurrentKeyCacheTTL.
+var currentKeysCache = newKeyCache(CurrentKeyCacheTTL)
+
+// keyCache keeps the signing keys of instances in memory. The current key is
+// picked from the cached keys on every call, so pending keys are activated
+// on time even while they're cached.
+type keyCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]keyCacheEntry
+	sweptAt time.Time
+}
+
+type keyCacheEntry struct {
+	keys      []*model.InstanceSigningKey
+	expiresAt time.Time
+}
+
+func newKeyCache(ttl time.Duration) *keyCache {
+	return &keyCache{ttl: ttl, entries: map[string]keyCacheEntry{}}
+}
+
+// get returns the cached keys of the instance, or loads and caches them if
+// they're missing or expired. Errors aren't cached.
+func (c *keyCache) get(instanceID string, now time.Time, load func() ([]*model.InstanceSigningKey, error)) ([]*model.InstanceSigningKey, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[instanceID]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.keys, nil
+	}
+
+	keys, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now.Sub(c.sweptAt) >= c.ttl {
+		// drop the keys of instances which stopped signing tokens
+		for id, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		c.sweptAt = now
+	}
+	c.entries[instanceID] = keyCacheEntry{keys: keys, expiresAt: now.Add(c.ttl)}
+	return keys, nil
+}
@@ -0,0 +1,64 @@
+package signing_keys
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newKeyCache(10 * time.Second)
+
+	loads := 0
+	keys := []*model.InstanceSigningKey{{InstanceSigningKey: &sqbmodel.InstanceSigningKey{ID: "key_1"}}}
+	load := func() ([]*model.InstanceSigningKey, error) {
+		loads++
+		return keys, nil
+	}
+
+	got, err := cache.get("ins_1", now, load)
+	require.NoError(t, err)
+	assert.Equal(t, keys, got)
+
+	// cached until the TTL passes
+	_, err = cache.get("ins_1", now.Add(9*time.Second), load)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loads)
+
+	// instances are cached separately
+	_, err = cache.get("ins_2", now, load)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	_, err = cache.get("ins_1", now.Add(10*time.Second), load)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads)
+}
+
+func TestKeyCacheDoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newKeyCache(10 * time.Second)
+
+	loadErr := errors.New("connection refused")
+	_, err := cache.get("ins_1", now, func() ([]*model.InstanceSigningKey, error) {
+		return nil, loadErr
+	})
+	assert.ErrorIs(t, err, loadErr)
+
+	got, err := cache.get("ins_1", now, func() ([]*model.InstanceSigningKey, error) {
+		return nil, nil
+	})
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
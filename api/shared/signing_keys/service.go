@@ -0,0 +1,343 @@
+// Package signing_keys manages the keys instances sign their tokens with.
+//
+// Every instance is created with a key pair, stored on the instance itself.
+// Rotating the keys of an instance creates a new key pair, which is published
+// in the JWKS right away but is only used to sign tokens after an activation
+// delay, so that cached copies of the JWKS are refreshed before tokens signed
+// with it show up. The keys it replaces expire after a grace period, so that
+// tokens already signed with them can still be verified. A key can also be
+// revoked, which removes it from the JWKS immediately.
+//
+// Instances whose keys have never been rotated keep using the key pair of the
+// instance, with the instance ID as the key ID. The first rotation brings
+// that key pair under management, so that it expires like any other.
+package signing_keys
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/generate"
+	"clerk/pkg/jobs"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	pkiutils "clerk/utils/pki"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+const (
+	// ActivationDelay is how long a new key is published before it's used
+	// to sign tokens.
+	ActivationDelay = time.Hour
+	// ExpirationGracePeriod is how long a rotated out key is published for,
+	// after the key which replaced it has been activated.
+	ExpirationGracePeriod = 24 * time.Hour
+)
+
+var (
+	ErrKeyNotFound    = errors.New("signing_keys: key not found")
+	ErrLastKey        = errors.New("signing_keys: cannot revoke the only key")
+	ErrAlreadyRevoked = errors.New("signing_keys: key already revoked")
+)
+
+// Key is a key pair tokens can be signed with.
+type Key struct {
+	KID        string
+	PrivateKey string
+	PublicKey  string
+	Algorithm  string
+}
+
+// PublicKeyForEdge returns the public key in the format the edge verifies
+// tokens with.
+func (k Key) PublicKeyForEdge() string {
+	instance := &model.Instance{Instance: &sqbmodel.Instance{PublicKey: k.PublicKey}}
+	return instance.PublicKeyForEdge()
+}
+
+type Service struct {
+	clock     clockwork.Clock
+	gueClient *gue.Client
+
+	// currentKeys caches the keys of instances for Current, which is called
+	// for every token that's signed
+	currentKeys *keyCache
+
+	// repositories
+	signingKeyRepo *repository.InstanceSigningKeys
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:          deps.Clock(),
+		gueClient:      deps.GueClient(),
+		currentKeys:    currentKeysCache,
+		signingKeyRepo: deps.Repositories().InstanceSigningKeys,
+	}
+}
+
+// List returns the keys of the given instance which haven't expired or been
+// revoked, newest first.
+func (s *Service) List(ctx context.Context, exec database.Executor, instanceID string) ([]*model.InstanceSigningKey, error) {
+	keys, err := s.signingKeyRepo.FindAllByInstance(ctx, exec, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys: fetching keys of instance %s: %w", instanceID, err)
+	}
+	return published(keys, s.clock.Now().UTC()), nil
+}
+
+// Current returns the key tokens of the given instance should be signed
+// with, which is the newest active one. The keys of the instance are cached
+// for CurrentKeyCacheTTL, so a revoked key can keep signing tokens for that
+// long.
+func (s *Service) Current(ctx context.Context, exec database.Executor, instance *model.Instance) (Key, error) {
+	now := s.clock.Now().UTC()
+	keys, err := s.currentKeys.get(instance.ID, now, func() ([]*model.InstanceSigningKey, error) {
+		return s.signingKeyRepo.FindAllByInstance(ctx, exec, instance.ID)
+	})
+	if err != nil {
+		return Key{}, fmt.Errorf("signing_keys: fetching keys of instance %s: %w", instance.ID, err)
+	}
+	return currentOrInstanceKey(keys, instance, now), nil
+}
+
+// Load returns the current key of the given instance like Current, but
+// always reads the keys from the database. It's meant for callers which must
+// reflect a rotation right away, e.g. the replication to the edge.
+func Load(ctx context.Context, exec database.Executor, clock clockwork.Clock, instance *model.Instance) (Key, error) {
+	keys, err := repository.NewInstanceSigningKeys().FindAllByInstance(ctx, exec, instance.ID)
+	if err != nil {
+		return Key{}, fmt.Errorf("signing_keys: fetching keys of instance %s: %w", instance.ID, err)
+	}
+	return currentOrInstanceKey(keys, instance, clock.Now().UTC()), nil
+}
+
+// Verifiable returns the keys tokens of the given instance can be verified
+// with, newest first. That's the key pair of the instance if its keys have
+// never been rotated.
+func (s *Service) Verifiable(ctx context.Context, exec database.Executor, instance *model.Instance) ([]Key, error) {
+	keys, err := s.signingKeyRepo.FindAllByInstance(ctx, exec, instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys: fetching keys of instance %s: %w", instance.ID, err)
+	}
+
+	if len(keys) == 0 {
+		return []Key{instanceKey(instance)}, nil
+	}
+	verifiable := []Key{}
+	for _, key := range published(keys, s.clock.Now().UTC()) {
+		verifiable = append(verifiable, toKey(key))
+	}
+	return verifiable, nil
+}
+
+// JWKS returns the public keys tokens of the given instance can be verified
+// with.
+func (s *Service) JWKS(ctx context.Context, exec database.Executor, instance *model.Instance) (*jose.JSONWebKeySet, error) {
+	toPublish, err := s.Verifiable(ctx, exec, instance)
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := &jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(toPublish))}
+	for _, key := range toPublish {
+		publicKey, err := pkiutils.LoadPublicKey([]byte(key.PublicKey))
+		if err != nil {
+			return nil, fmt.Errorf("signing_keys: loading public key %s: %w", key.KID, err)
+		}
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{
+			Key:       publicKey,
+			KeyID:     key.KID,
+			Algorithm: key.Algorithm,
+			Use:       "sig",
+		})
+	}
+	return jwks, nil
+}
+
+// Rotate creates a new key for the given instance, which will be used to
+// sign its tokens after ActivationDelay. The keys it replaces expire
+// ExpirationGracePeriod after that, and the tokens which depend on them are
+// signed again with the new key in the background.
+func (s *Service) Rotate(ctx context.Context, tx database.Tx, instance *model.Instance) (*model.InstanceSigningKey, error) {
+	now := s.clock.Now().UTC()
+
+	keys, err := s.signingKeyRepo.FindAllByInstance(ctx, tx, instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys/rotate: fetching keys of instance %s: %w", instance.ID, err)
+	}
+	if len(keys) == 0 {
+		legacyKey, err := s.manageInstanceKey(ctx, tx, instance)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, legacyKey)
+	}
+
+	newKey, err := generate.InstanceSigningKey(ctx, tx, instance, now.Add(ActivationDelay))
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys/rotate: generating key for instance %s: %w", instance.ID, err)
+	}
+
+	expiresAt := newKey.ActiveFrom.Add(ExpirationGracePeriod)
+	for _, key := range published(keys, now) {
+		if key.ExpiresAt.Valid && key.ExpiresAt.Time.Before(expiresAt) {
+			continue
+		}
+		key.ExpiresAt = null.TimeFrom(expiresAt)
+		if err := s.signingKeyRepo.UpdateExpiresAt(ctx, tx, key); err != nil {
+			return nil, fmt.Errorf("signing_keys/rotate: expiring key %s: %w", key.ID, err)
+		}
+	}
+
+	err = jobs.ResignInstanceTokens(ctx, s.gueClient, jobs.ResignInstanceTokensArgs{
+		InstanceID:   instance.ID,
+		SigningKeyID: newKey.ID,
+		NotBefore:    newKey.ActiveFrom,
+	}, jobs.WithTx(tx))
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys/rotate: scheduling re-signing of tokens of instance %s: %w", instance.ID, err)
+	}
+	return newKey, nil
+}
+
+// Revoke revokes the given key, which is removed from the JWKS right away.
+// Tokens signed with it are signed again with the current key in the
+// background. If the key is the only active one, the key which replaces it is
+// activated right away. The only key of an instance can't be revoked, rotate
+// it first.
+func (s *Service) Revoke(ctx context.Context, tx database.Tx, instance *model.Instance, keyID string) (*model.InstanceSigningKey, error) {
+	now := s.clock.Now().UTC()
+
+	keys, err := s.signingKeyRepo.FindAllByInstance(ctx, tx, instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys/revoke: fetching keys of instance %s: %w", instance.ID, err)
+	}
+
+	var (
+		key       *model.InstanceSigningKey
+		remaining = make([]*model.InstanceSigningKey, 0, len(keys))
+	)
+	for _, k := range keys {
+		if k.ID == keyID {
+			key = k
+		} else {
+			remaining = append(remaining, k)
+		}
+	}
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	if key.RevokedAt.Valid {
+		return nil, ErrAlreadyRevoked
+	}
+	if current(remaining, now) == nil {
+		// a compromised key can't wait for the activation delay of the
+		// key which replaces it, so the latter is activated right away
+		pending := published(remaining, now)
+		if len(pending) == 0 {
+			return nil, ErrLastKey
+		}
+		pending[0].ActiveFrom = now
+		if err := s.signingKeyRepo.UpdateActiveFrom(ctx, tx, pending[0]); err != nil {
+			return nil, fmt.Errorf("signing_keys/revoke: activating key %s: %w", pending[0].ID, err)
+		}
+	}
+
+	key.RevokedAt = null.TimeFrom(now)
+	if err := s.signingKeyRepo.UpdateRevokedAt(ctx, tx, key); err != nil {
+		return nil, fmt.Errorf("signing_keys/revoke: revoking key %s: %w", key.ID, err)
+	}
+
+	err = jobs.ResignInstanceTokens(ctx, s.gueClient, jobs.ResignInstanceTokensArgs{
+		InstanceID:          instance.ID,
+		RevokedSigningKeyID: key.ID,
+	}, jobs.WithTx(tx))
+	if err != nil {
+		return nil, fmt.Errorf("signing_keys/revoke: scheduling re-signing of tokens of instance %s: %w", instance.ID, err)
+	}
+	return key, nil
+}
+
+// manageInstanceKey stores the key pair of the instance as one of its signing
+// keys, keeping the instance ID as its key ID, so that tokens already signed
+// with it can still be verified.
+func (s *Service) manageInstanceKey(ctx context.Context, tx database.Tx, instance *model.Instance) (*model.InstanceSigningKey, error) {
+	key := &model.InstanceSigningKey{InstanceSigningKey: &sqbmodel.InstanceSigningKey{
+		InstanceID: instance.ID,
+		Kid:        instance.ID,
+		PrivateKey: instance.PrivateKey,
+		PublicKey:  instance.PublicKey,
+		Algorithm:  instance.KeyAlgorithm,
+		ActiveFrom: instance.CreatedAt,
+	}}
+	if err := s.signingKeyRepo.Insert(ctx, tx, key); err != nil {
+		return nil, fmt.Errorf("signing_keys: storing key of instance %s: %w", instance.ID, err)
+	}
+	return key, nil
+}
+
+// published returns the keys which haven't expired or been revoked at the
+// given time, newest first.
+func published(keys []*model.InstanceSigningKey, now time.Time) []*model.InstanceSigningKey {
+	result := make([]*model.InstanceSigningKey, 0, len(keys))
+	for _, key := range keys {
+		if key.RevokedAt.Valid || (key.ExpiresAt.Valid && !key.ExpiresAt.Time.After(now)) {
+			continue
+		}
+		result = append(result, key)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].ActiveFrom.After(result[j].ActiveFrom)
+	})
+	return result
+}
+
+// current returns the newest of the published keys which is active at the
+// given time, or nil if there's none.
+func current(keys []*model.InstanceSigningKey, now time.Time) *model.InstanceSigningKey {
+	for _, key := range published(keys, now) {
+		if !key.ActiveFrom.After(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// currentOrInstanceKey returns the current of the keys, or the key pair of
+// the instance if its keys have never been rotated.
+func currentOrInstanceKey(keys []*model.InstanceSigningKey, instance *model.Instance, now time.Time) Key {
+	key := current(keys, now)
+	if key == nil {
+		return instanceKey(instance)
+	}
+	return toKey(key)
+}
+
+func instanceKey(instance *model.Instance) Key {
+	return Key{
+		KID:        instance.ID,
+		PrivateKey: instance.PrivateKey,
+		PublicKey:  instance.PublicKey,
+		Algorithm:  instance.KeyAlgorithm,
+	}
+}
+
+func toKey(key *model.InstanceSigningKey) Key {
+	return Key{
+		KID:        key.Kid,
+		PrivateKey: key.PrivateKey,
+		PublicKey:  key.PublicKey,
+		Algorithm:  key.Algorithm,
+	}
+}
@@ -0,0 +1,40 @@
+package signing_keys
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestCurrent(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newKey := func(id string, activeFrom time.Time) *model.InstanceSigningKey {
+		return &model.InstanceSigningKey{InstanceSigningKey: &sqbmodel.InstanceSigningKey{
+			ID:         id,
+			ActiveFrom: activeFrom,
+		}}
+	}
+
+	old := newKey("old", now.Add(-48*time.Hour))
+	active := newKey("active", now.Add(-time.Hour))
+	pending := newKey("pending", now.Add(time.Hour))
+	keys := []*model.InstanceSigningKey{old, pending, active}
+
+	// the newest active key signs, while pending keys are already published
+	assert.Equal(t, active, current(keys, now))
+	assert.Equal(t, []*model.InstanceSigningKey{pending, active, old}, published(keys, now))
+
+	// expired and revoked keys are neither published nor used
+	old.ExpiresAt = null.TimeFrom(now)
+	active.RevokedAt = null.TimeFrom(now)
+	assert.Equal(t, []*model.InstanceSigningKey{pending}, published(keys, now))
+	assert.Nil(t, current(keys, now))
+	assert.Equal(t, pending, current(keys, pending.ActiveFrom))
+}
@@ -45,9 +45,9 @@ func NewService(deps clerk.Deps) *Service {
 		clock:              deps.Clock(),
 		gueClient:          deps.GueClient(),
 		eventService:       events.NewService(deps),
-		smsCountryTierRepo: repository.NewSMSCountryTiers(),
-		smsMessageRepo:     repository.NewSMSMessage(),
-		subscriptionRepo:   repository.NewSubscriptions(),
+		smsCountryTierRepo: deps.Repositories().SMSCountryTiers,
+		smsMessageRepo:     deps.Repositories().SMSMessage,
+		subscriptionRepo:   deps.Repositories().Subscriptions,
 	}
 }
 
@@ -5,9 +5,14 @@ import (
 	"clerk/pkg/web3/provider"
 )
 
-// RegisterWeb3Providers enables our currently supported web3 providers
+// RegisterWeb3Providers enables our currently supported web3 providers,
+// along with the chains their wallets can sign in with
 func RegisterWeb3Providers() {
 	web3.RegisterProviders(
 		provider.MetaMask{},
 	)
+	web3.RegisterChains(
+		web3.Ethereum{},
+		web3.Solana{},
+	)
 }
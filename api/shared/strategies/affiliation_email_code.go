@@ -40,8 +40,8 @@ func NewAffiliationEmailCodePreparer(deps clerk.Deps, env *model.Env, orgDomain
 		organizationDomain:        orgDomain,
 		emailAddress:              emailAddress,
 		commsService:              comms.NewService(deps),
-		orgDomainRepo:             repository.NewOrganizationDomain(),
-		orgDomainVerificationRepo: repository.NewOrganizationDomainVerification(),
+		orgDomainRepo:             deps.Repositories().OrganizationDomain,
+		orgDomainVerificationRepo: deps.Repositories().OrganizationDomainVerification,
 	}
 }
 
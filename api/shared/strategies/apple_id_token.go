@@ -0,0 +1,180 @@
+package strategies
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/constants"
+	"clerk/pkg/jwks"
+	"clerk/pkg/jwt"
+	"clerk/pkg/oauth"
+	"clerk/pkg/oauth/provider"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	appleIDTokenJWKSURL = "https://appleid.apple.com/auth/keys"
+	appleIDTokenIssuer  = "https://appleid.apple.com"
+)
+
+var errAppleIDTokenNonceMismatch = errors.New("apple id token: nonce mismatch")
+
+// AppleIDTokenAttemptor signs in or signs up a user with the identity token
+// returned by Sign in with Apple on iOS, so that native apps don't have to
+// go through the OAuth redirect flow.
+type AppleIDTokenAttemptor struct {
+	idTokenFlow
+	clock clockwork.Clock
+	token string
+	// nonce is the raw nonce the app generated for the authorization
+	// request, whose SHA-256 hash Apple puts in the token.
+	nonce string
+
+	// Apple includes the name of the user only in the response of the
+	// first authorization, and never in the token itself, so the app has to
+	// pass it along.
+	firstName string
+	lastName  string
+}
+
+func NewAppleIDTokenAttemptor(deps clerk.Deps, env *model.Env, token, nonce, firstName, lastName string, signIn *model.SignIn, signUp *model.SignUp) AppleIDTokenAttemptor {
+	return AppleIDTokenAttemptor{
+		idTokenFlow: newIDTokenFlow(deps, env, signIn, signUp),
+		clock:       deps.Clock(),
+		token:       token,
+		nonce:       nonce,
+		firstName:   firstName,
+		lastName:    lastName,
+	}
+}
+
+func (v AppleIDTokenAttemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Verification, error) {
+	oauthConfig, err := v.activeOauthConfig(ctx, tx, provider.AppleID())
+	if err != nil {
+		return nil, fmt.Errorf("apple_id_token/attempt: %w", err)
+	}
+
+	tokenClaims, err := parseAppleIDToken(ctx, v.clock, v.token, appleIDTokenAudiences(v.env.Instance, oauthConfig))
+	if err != nil {
+		return nil, fmt.Errorf("apple_id_token/attempt: parse token: %w", err)
+	}
+
+	if err := tokenClaims.verifyNonce(v.nonce); err != nil {
+		return nil, fmt.Errorf("apple_id_token/attempt: %w", err)
+	}
+
+	oauthUser := tokenClaims.toOAuthUser()
+	oauthUser.FirstName = v.firstName
+	oauthUser.LastName = v.lastName
+
+	// Apple identity tokens don't have a jti claim, so the token is
+	// identified by its hash instead
+	tokenHash := sha256.Sum256([]byte(v.token))
+
+	verification, err := v.complete(ctx, tx, idTokenFlowParams{
+		Strategy:    constants.VSOAuthTokenApple,
+		Token:       v.token,
+		Nonce:       hex.EncodeToString(tokenHash[:]),
+		OauthConfig: oauthConfig,
+		OAuthUser:   oauthUser,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("apple_id_token/attempt: %w", err)
+	}
+	return verification, nil
+}
+
+func (AppleIDTokenAttemptor) ToAPIError(err error) apierror.Error {
+	var oauthConfigMissingErr clerkerrors.OAuthConfigMissing
+	if errors.Is(err, errSignUpUserAlreadyExists) {
+		return apierror.IdentificationExists(provider.AppleID(), nil)
+	} else if errors.Is(err, errSignInUserNotExists) {
+		return apierror.ExternalAccountNotFound()
+	} else if errors.Is(err, errIDTokenAlreadyUsed) {
+		return apierror.InvalidAuthorization()
+	} else if errors.Is(err, jwt.ErrInvalidTokenFormat) || errors.Is(err, jwt.ErrTokenExpired) || errors.Is(err, jwt.ErrInvalidSignature) || errors.Is(err, jwt.ErrClaimsValidationFailed) || errors.Is(err, errAppleIDTokenNonceMismatch) {
+		return apierror.AppleIDTokenInvalid()
+	} else if errors.Is(err, errActiveSAMLConnectionExists) {
+		return apierror.SAMLEmailAddressDomainReserved()
+	} else if errors.As(err, &oauthConfigMissingErr) {
+		return apierror.OAuthConfigMissing(oauthConfigMissingErr.Provider)
+	}
+	return apierror.Unexpected(err)
+}
+
+// appleIDTokenClaims are the claims of an Apple identity token.
+// See https://developer.apple.com/documentation/sign_in_with_apple/sign_in_with_apple_rest_api/authenticating_users_with_sign_in_with_apple
+type appleIDTokenClaims struct {
+	josejwt.Claims
+	Email string `json:"email"`
+	Nonce string `json:"nonce"`
+	// Apple sends boolean claims either as booleans or as strings
+	EmailVerified any `json:"email_verified"`
+}
+
+func (c *appleIDTokenClaims) toOAuthUser() *oauth.User {
+	return &oauth.User{
+		ProviderID:           provider.AppleID(),
+		ProviderUserID:       c.Subject,
+		EmailAddress:         c.Email,
+		EmailAddressVerified: c.EmailVerified == true || c.EmailVerified == "true",
+	}
+}
+
+// verifyNonce checks that the token was issued for the authorization request
+// of the given raw nonce, so that a token intercepted from another app, or
+// another sign-in, can't be replayed. Apple puts the SHA-256 hash of the
+// raw nonce in the token, hex encoded.
+func (c *appleIDTokenClaims) verifyNonce(rawNonce string) error {
+	if rawNonce == "" || c.Nonce == "" {
+		return errAppleIDTokenNonceMismatch
+	}
+	hash := sha256.Sum256([]byte(rawNonce))
+	if subtle.ConstantTimeCompare([]byte(c.Nonce), []byte(hex.EncodeToString(hash[:]))) != 1 {
+		return errAppleIDTokenNonceMismatch
+	}
+	return nil
+}
+
+// appleIDTokenAudiences returns the audiences an identity token of the
+// instance may be issued for. Tokens obtained on the web are issued for the
+// Services ID of the OAuth configuration, whereas tokens obtained natively
+// are issued for the bundle ID of the iOS app.
+func appleIDTokenAudiences(instance *model.Instance, oauthConfig *model.OauthConfig) []string {
+	audiences := []string{oauthConfig.ClientID}
+	// the app ID is in the form of <team ID>.<bundle ID>
+	if _, bundleID, ok := strings.Cut(instance.AppleAppID.String, "."); ok && bundleID != "" {
+		audiences = append(audiences, bundleID)
+	}
+	return audiences
+}
+
+func parseAppleIDToken(ctx context.Context, clock clockwork.Clock, token string, audiences []string) (*appleIDTokenClaims, error) {
+	jwks, err := jwks.Fetch(ctx, appleIDTokenJWKSURL)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, audience := range audiences {
+		tokenClaims := &appleIDTokenClaims{}
+		err = jwt.VerifyOpenIDToken(token, jwks, tokenClaims, appleIDTokenIssuer, audience, clock.Now().UTC())
+		if err == nil {
+			return tokenClaims, nil
+		} else if !errors.Is(err, jwt.ErrClaimsValidationFailed) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
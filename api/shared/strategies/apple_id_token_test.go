@@ -0,0 +1,115 @@
+package strategies
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/jwt"
+	"clerk/pkg/oauth/provider"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestAppleIDTokenClaims_VerifyNonce(t *testing.T) {
+	t.Parallel()
+
+	hash := sha256.Sum256([]byte("raw-nonce"))
+	hashedNonce := hex.EncodeToString(hash[:])
+
+	tests := []struct {
+		name       string
+		tokenNonce string
+		rawNonce   string
+		wantErr    bool
+	}{
+		{name: "matching nonce", tokenNonce: hashedNonce, rawNonce: "raw-nonce"},
+		{name: "other nonce", tokenNonce: hashedNonce, rawNonce: "other-nonce", wantErr: true},
+		{name: "hashed nonce instead of raw", tokenNonce: hashedNonce, rawNonce: hashedNonce, wantErr: true},
+		{name: "no nonce passed", tokenNonce: hashedNonce, wantErr: true},
+		{name: "token without nonce", rawNonce: "raw-nonce", wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			claims := &appleIDTokenClaims{Nonce: tc.tokenNonce}
+			err := claims.verifyNonce(tc.rawNonce)
+			if tc.wantErr {
+				assert.ErrorIs(t, err, errAppleIDTokenNonceMismatch)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAppleIDTokenClaims_ToOAuthUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		emailVerified any
+		want          bool
+	}{
+		{emailVerified: true, want: true},
+		{emailVerified: "true", want: true},
+		{emailVerified: false, want: false},
+		{emailVerified: "false", want: false},
+		{emailVerified: nil, want: false},
+	} {
+		claims := &appleIDTokenClaims{Email: "jane@example.com", EmailVerified: tc.emailVerified}
+		claims.Subject = "001234.abcdef"
+
+		oauthUser := claims.toOAuthUser()
+		assert.Equal(t, provider.AppleID(), oauthUser.ProviderID)
+		assert.Equal(t, "001234.abcdef", oauthUser.ProviderUserID)
+		assert.Equal(t, "jane@example.com", oauthUser.EmailAddress)
+		assert.Equal(t, tc.want, oauthUser.EmailAddressVerified, "email_verified %v", tc.emailVerified)
+	}
+}
+
+func TestAppleIDTokenAudiences(t *testing.T) {
+	t.Parallel()
+
+	oauthConfig := &model.OauthConfig{OauthConfig: &sqbmodel.OauthConfig{ClientID: "com.example.web"}}
+
+	for _, tc := range []struct {
+		name       string
+		appleAppID null.String
+		want       []string
+	}{
+		{name: "no iOS app", want: []string{"com.example.web"}},
+		{name: "iOS app", appleAppID: null.StringFrom("TEAMID.com.example.app"), want: []string{"com.example.web", "com.example.app"}},
+		{name: "app ID without bundle ID", appleAppID: null.StringFrom("TEAMID"), want: []string{"com.example.web"}},
+	} {
+		instance := &model.Instance{Instance: &sqbmodel.Instance{AppleAppID: tc.appleAppID}}
+		assert.Equal(t, tc.want, appleIDTokenAudiences(instance, oauthConfig), tc.name)
+	}
+}
+
+func TestAppleIDTokenAttemptor_ToAPIError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		err  error
+		code string
+	}{
+		{err: errSignUpUserAlreadyExists, code: apierror.ExternalAccountExistsCode},
+		{err: errSignInUserNotExists, code: apierror.ExternalAccountNotFoundCode},
+		{err: errIDTokenAlreadyUsed, code: apierror.AuthorizationInvalidCode},
+		{err: fmt.Errorf("apple_id_token/attempt: parse token: %w", jwt.ErrTokenExpired), code: apierror.AppleIDTokenInvalidCode},
+		{err: fmt.Errorf("apple_id_token/attempt: %w", errAppleIDTokenNonceMismatch), code: apierror.AppleIDTokenInvalidCode},
+		{err: errActiveSAMLConnectionExists, code: apierror.SAMLEmailAddressDomainReservedCode},
+		{err: fmt.Errorf("apple_id_token/attempt: %w", clerkerrors.NewOAuthConfigMissing(provider.AppleID())), code: apierror.OAuthConfigMissingCode},
+		{err: errors.New("database is down"), code: apierror.InternalClerkErrorCode},
+	} {
+		apiErr := AppleIDTokenAttemptor{}.ToAPIError(tc.err)
+		assert.True(t, apiErr.IsTypeOf(tc.code), "%s: got %s", tc.err, apiErr.ErrorCode())
+	}
+}
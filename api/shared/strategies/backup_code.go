@@ -13,9 +13,13 @@ import (
 	"clerk/repository"
 	"clerk/utils/database"
 	"clerk/utils/param"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 )
 
 type BackupCodeAttemptor struct {
+	clock      clockwork.Clock
 	backupCode *model.BackupCode
 	env        *model.Env
 
@@ -26,6 +30,7 @@ type BackupCodeAttemptor struct {
 }
 
 type BackupCodeAttemptorParams struct {
+	Clock        clockwork.Clock
 	Env          *model.Env
 	BackupCode   *model.BackupCode
 	ProvidedCode string
@@ -33,6 +38,7 @@ type BackupCodeAttemptorParams struct {
 
 func NewBackupCodeAttemptor(params BackupCodeAttemptorParams) BackupCodeAttemptor {
 	return BackupCodeAttemptor{
+		clock:            params.Clock,
 		env:              params.Env,
 		backupCode:       params.BackupCode,
 		providedCode:     params.ProvidedCode,
@@ -54,17 +60,47 @@ func (v BackupCodeAttemptor) Attempt(ctx context.Context, tx database.Tx) (*mode
 		return nil, fmt.Errorf("backup_code/attempt: inserting new verification %+v: %w", verification, err)
 	}
 
-	updatedCodes, err := backup_codes.Consume(currentBackupCode.Codes, v.providedCode)
-	if err != nil {
-		return nil, fmt.Errorf("backup_code/attempt: consuming backup code: %w", err)
+	if err := ConsumeBackupCode(ctx, tx, v.clock, v.backupCodeRepo, currentBackupCode, v.providedCode); err != nil {
+		return nil, fmt.Errorf("backup_code/attempt: %w", err)
 	}
 
-	currentBackupCode.Codes = updatedCodes
-	if err = v.backupCodeRepo.UpdateCodes(ctx, tx, currentBackupCode); err != nil {
-		return nil, fmt.Errorf("backup_code/attempt: updating backup codes: %w", err)
+	return verification, nil
+}
+
+// backupCodeUpdater is the subset of repository.BackupCode needed to record
+// the usage of backup codes.
+type backupCodeUpdater interface {
+	Update(ctx context.Context, exec database.Executor, backupCode *model.BackupCode, columns ...string) error
+}
+
+// ConsumeBackupCode removes the provided code from the backup codes of the
+// user and records its usage. It returns backup_codes.ErrInvalidCode if the
+// code is not one of the remaining backup codes.
+func ConsumeBackupCode(
+	ctx context.Context,
+	exec database.Executor,
+	clock clockwork.Clock,
+	backupCodeRepo backupCodeUpdater,
+	backupCode *model.BackupCode,
+	providedCode string,
+) error {
+	updatedCodes, err := backup_codes.Consume(backupCode.Codes, providedCode)
+	if err != nil {
+		return fmt.Errorf("consuming backup code: %w", err)
 	}
 
-	return verification, nil
+	backupCode.Codes = updatedCodes
+	backupCode.UsedCount++
+	backupCode.LastUsedAt = null.TimeFrom(clock.Now().UTC())
+	err = backupCodeRepo.Update(ctx, exec, backupCode,
+		sqbmodel.BackupCodeColumns.Codes,
+		sqbmodel.BackupCodeColumns.UsedCount,
+		sqbmodel.BackupCodeColumns.LastUsedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("updating backup codes: %w", err)
+	}
+	return nil
 }
 
 func (BackupCodeAttemptor) ToAPIError(err error) apierror.Error {
@@ -0,0 +1,64 @@
+package strategies
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/backup_codes"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+type fakeBackupCodeUpdater struct {
+	columns []string
+}
+
+func (f *fakeBackupCodeUpdater) Update(_ context.Context, _ database.Executor, _ *model.BackupCode, columns ...string) error {
+	f.columns = columns
+	return nil
+}
+
+func TestConsumeBackupCode(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	plainCodes, hashedCodes, err := backup_codes.GenerateAndHash()
+	require.NoError(t, err)
+	require.NotEmpty(t, plainCodes)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := clockwork.NewFakeClockAt(now)
+	repo := &fakeBackupCodeUpdater{}
+	backupCode := &model.BackupCode{BackupCode: &sqbmodel.BackupCode{
+		ID:               "bc_1",
+		Codes:            hashedCodes,
+		RegeneratedCount: 2,
+	}}
+
+	require.NoError(t, ConsumeBackupCode(ctx, nil, clock, repo, backupCode, plainCodes[0]))
+	assert.Len(t, backupCode.Codes, len(hashedCodes)-1)
+	assert.Equal(t, 1, backupCode.UsedCount)
+	assert.Equal(t, null.TimeFrom(now), backupCode.LastUsedAt)
+	assert.Equal(t, 2, backupCode.RegeneratedCount)
+	assert.ElementsMatch(t, []string{
+		sqbmodel.BackupCodeColumns.Codes,
+		sqbmodel.BackupCodeColumns.UsedCount,
+		sqbmodel.BackupCodeColumns.LastUsedAt,
+	}, repo.columns)
+
+	// codes can only be used once, and invalid codes don't count as usage
+	repo.columns = nil
+	clock.Advance(time.Hour)
+	err = ConsumeBackupCode(ctx, nil, clock, repo, backupCode, plainCodes[0])
+	assert.ErrorIs(t, err, backup_codes.ErrInvalidCode)
+	assert.Equal(t, 1, backupCode.UsedCount)
+	assert.Equal(t, null.TimeFrom(now), backupCode.LastUsedAt)
+	assert.Nil(t, repo.columns)
+}
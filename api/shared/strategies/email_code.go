@@ -40,7 +40,7 @@ func NewEmailCodePreparer(deps clerk.Deps, env *model.Env, identification *model
 		sourceType:       sourceType,
 		sourceID:         sourceID,
 		commsService:     comms.NewService(deps),
-		verificationRepo: repository.NewVerification(),
+		verificationRepo: deps.Repositories().Verification,
 	}
 }
 
@@ -87,21 +87,23 @@ type EmailCodeAttemptor struct {
 	code         string
 	verification *model.Verification
 
+	otpThrottle         *OTPThrottle
 	verificationService *verifications.Service
 	verificationRepo    *repository.Verification
 }
 
-func NewEmailCodeAttemptor(clock clockwork.Clock, verification *model.Verification, code string) EmailCodeAttemptor {
+func NewEmailCodeAttemptor(deps clerk.Deps, verification *model.Verification, code string) EmailCodeAttemptor {
 	return EmailCodeAttemptor{
 		code:                code,
 		verification:        verification,
-		verificationService: verifications.NewService(clock),
-		verificationRepo:    repository.NewVerification(),
+		otpThrottle:         NewOTPThrottle(deps),
+		verificationService: verifications.NewService(deps.Clock()),
+		verificationRepo:    deps.Repositories().Verification,
 	}
 }
 
 func (v EmailCodeAttemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Verification, error) {
-	err := attemptOTPCode(ctx, tx, v.verificationService, v.verification, v.code, v.verificationRepo)
+	err := attemptOTPCode(ctx, tx, v.verificationService, v.otpThrottle, v.verification, v.code, v.verificationRepo)
 	return v.verification, err
 }
 
@@ -3,6 +3,7 @@ package strategies
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"clerk/api/apierror"
 )
@@ -29,14 +30,31 @@ func NewUnknownStatusError(status string) UnknownStatusError {
 	return UnknownStatusError{status}
 }
 
+// CooldownError is returned when an OTP code is attempted for an
+// identification which is in a cooldown, after too many failed attempts.
+type CooldownError struct {
+	ExpiresAt time.Time
+}
+
+func (err *CooldownError) Error() string {
+	return fmt.Sprintf("verification: cooldown until %s", err.ExpiresAt.Format(time.RFC3339))
+}
+
+func NewCooldownError(expiresAt time.Time) *CooldownError {
+	return &CooldownError{ExpiresAt: expiresAt}
+}
+
 // toAPIErrors provides a simple mapping between a
 // verifications related error and our apierror.APIErrors
 func toAPIErrors(err error) apierror.Error {
 	var unknownStatusErr *UnknownStatusError
+	var cooldownErr *CooldownError
 	if apiErr, isAPIErr := apierror.As(err); isAPIErr {
 		return apiErr
 	} else if errors.As(err, &unknownStatusErr) {
 		return apierror.VerificationUnknownStatus(unknownStatusErr.Status)
+	} else if errors.As(err, &cooldownErr) {
+		return apierror.VerificationCooldown(cooldownErr.ExpiresAt)
 	} else if errors.Is(err, ErrFailed) {
 		return apierror.VerificationFailed()
 	} else if errors.Is(err, ErrExpired) {
@@ -0,0 +1,188 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clerk/api/fapi/v1/external_account"
+	"clerk/api/shared/saml"
+	"clerk/api/shared/sso"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/constants"
+	"clerk/pkg/oauth"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+var (
+	errSignInUserNotExists        = errors.New("sign in: user not exists")
+	errSignUpUserAlreadyExists    = errors.New("sign up: user already exists")
+	errIDTokenAlreadyUsed         = errors.New("id token already used")
+	errActiveSAMLConnectionExists = errors.New("an active saml connection exists for email address domain")
+)
+
+// idTokenFlow signs in or signs up a user with an OpenID token that was
+// issued to the client directly by the provider, e.g. by Google One Tap or
+// by Sign in with Apple on iOS, instead of going through the OAuth redirect
+// flow.
+type idTokenFlow struct {
+	env    *model.Env
+	signIn *model.SignIn
+	signUp *model.SignUp
+
+	externalAccountService *external_account.Service
+
+	identificationRepo *repository.Identification
+	signInRepo         *repository.SignIn
+	signUpRepo         *repository.SignUp
+	verificationRepo   *repository.Verification
+}
+
+func newIDTokenFlow(deps clerk.Deps, env *model.Env, signIn *model.SignIn, signUp *model.SignUp) idTokenFlow {
+	return idTokenFlow{
+		env:                    env,
+		signIn:                 signIn,
+		signUp:                 signUp,
+		externalAccountService: external_account.NewService(deps),
+		identificationRepo:     deps.Repositories().Identification,
+		signInRepo:             deps.Repositories().SignIn,
+		signUpRepo:             deps.Repositories().SignUp,
+		verificationRepo:       deps.Repositories().Verification,
+	}
+}
+
+// activeOauthConfig returns the active OAuth configuration of the provider
+// which issued the token. Its client ID is the audience of tokens issued on
+// the web.
+func (f idTokenFlow) activeOauthConfig(ctx context.Context, exec database.Executor, providerID string) (*model.OauthConfig, error) {
+	oauthConfig, err := sso.ActiveOauthConfigForProvider(ctx, exec, f.env.AuthConfig.ID, providerID)
+	if err != nil {
+		return nil, fmt.Errorf("find active oauth config: %w", err)
+	}
+	if oauthConfig == nil {
+		return nil, clerkerrors.NewOAuthConfigMissing(providerID)
+	}
+	return oauthConfig, nil
+}
+
+type idTokenFlowParams struct {
+	Strategy string
+	Token    string
+	// Nonce identifies the token, so that it can only be used once.
+	Nonce       string
+	OauthConfig *model.OauthConfig
+	OAuthUser   *oauth.User
+}
+
+// complete creates the verification of the already validated token, and
+// links the external account of the token to the sign in or sign up.
+func (f idTokenFlow) complete(ctx context.Context, tx database.Tx, params idTokenFlowParams) (*model.Verification, error) {
+	userSettings := usersettings.NewUserSettings(f.env.AuthConfig.UserSettings)
+	oauthUser := params.OAuthUser
+
+	ost := &model.OauthStateToken{
+		OauthConfigID:  params.OauthConfig.ID,
+		ScopesReturned: params.OauthConfig.DefaultScopes,
+	}
+
+	samlConnectionExists, err := f.activeSAMLConnectionExistsForEmail(ctx, tx, oauthUser.EmailAddress)
+	if err != nil {
+		return nil, fmt.Errorf("check if active saml connection exists for email: %w", err)
+	}
+	if samlConnectionExists {
+		return nil, fmt.Errorf("active saml connection exists for email: %w", errActiveSAMLConnectionExists)
+	}
+
+	externalAccountIdentification, err := f.identificationRepo.QueryLatestClaimedByInstanceAndTypeAndProviderUserID(ctx, tx, f.env.Instance.ID, oauthUser.ProviderUserID, oauthUser.ProviderID)
+	if err != nil {
+		return nil, fmt.Errorf("query external account identification: %w", err)
+	}
+
+	emailIdentification, err := f.identificationRepo.QueryClaimedVerifiedOrReservedByInstanceAndIdentifierAndTypePrioritizingVerified(ctx, tx, f.env.Instance.ID, oauthUser.EmailAddress, constants.ITEmailAddress)
+	if err != nil {
+		return nil, fmt.Errorf("query email identification: %w", err)
+	}
+
+	userExists := externalAccountIdentification != nil || emailIdentification != nil
+	if err := f.checkUserExists(userExists); err != nil {
+		return nil, err
+	}
+
+	verification := &model.Verification{Verification: &sqbmodel.Verification{
+		InstanceID: f.env.Instance.ID,
+		Strategy:   params.Strategy,
+		Attempts:   1,
+		Token:      null.StringFrom(params.Token),
+		Nonce:      null.StringFrom(params.Nonce),
+	}}
+	if err := f.verificationRepo.Insert(ctx, tx, verification); err != nil {
+		if clerkerrors.IsUniqueConstraintViolation(err, clerkerrors.UniqueVerificationNonce) {
+			return nil, errIDTokenAlreadyUsed
+		}
+		return nil, fmt.Errorf("insert verification: %w", err)
+	}
+
+	if f.signIn != nil {
+		if externalAccountIdentification == nil {
+			// Complete the flow with account linking
+			creationResult, err := f.externalAccountService.CreateAndLink(ctx, tx, verification, ost, oauthUser, f.env.Instance, emailIdentification.UserID.Ptr(), userSettings)
+			if err != nil {
+				return nil, fmt.Errorf("create and link external account with email identification: %w", err)
+			}
+
+			externalAccountIdentification = creationResult.Identification
+		} else {
+			if _, err := f.externalAccountService.Update(ctx, tx, userSettings, ost, oauthUser, f.env.Instance); err != nil {
+				return nil, fmt.Errorf("update external account and user: %w", err)
+			}
+		}
+
+		f.signIn.IdentificationID = null.StringFrom(externalAccountIdentification.ID)
+		if err := f.signInRepo.UpdateIdentificationID(ctx, tx, f.signIn); err != nil {
+			return nil, fmt.Errorf("update sign in with identification: %w", err)
+		}
+	} else if f.signUp != nil {
+		result, err := f.externalAccountService.CreateAndLink(ctx, tx, verification, ost, oauthUser, f.env.Instance, nil, userSettings)
+		if err != nil {
+			return nil, fmt.Errorf("create and link external account and identifications: %w", err)
+		}
+
+		f.signUp.SuccessfulExternalAccountIdentificationID = null.StringFrom(result.Identification.ID)
+		if err := f.signUpRepo.UpdateSuccessfulExternalAccountIdentificationID(ctx, tx, f.signUp); err != nil {
+			return nil, fmt.Errorf("update sign up with successful external account identification: %w", err)
+		}
+	}
+
+	return verification, nil
+}
+
+// checkUserExists makes sure that a sign in is for an existing user and that
+// a sign up isn't.
+func (f idTokenFlow) checkUserExists(userExists bool) error {
+	if f.signIn != nil && !userExists {
+		return errSignInUserNotExists
+	} else if f.signUp != nil && userExists {
+		return errSignUpUserAlreadyExists
+	}
+	return nil
+}
+
+func (f idTokenFlow) activeSAMLConnectionExistsForEmail(ctx context.Context, exec database.Executor, emailAddress string) (bool, error) {
+	if !f.env.AuthConfig.UserSettings.SAML.Enabled {
+		return false, nil
+	}
+
+	samlConnection, err := saml.New().ActiveConnectionForEmail(ctx, exec, f.env.Instance.ID, emailAddress)
+	if err != nil {
+		return false, err
+	}
+
+	return samlConnection != nil, nil
+}
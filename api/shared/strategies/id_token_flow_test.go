@@ -0,0 +1,47 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/pkg/clerkerrors"
+	"clerk/pkg/jwt"
+	"clerk/pkg/oauth/provider"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDTokenFlow_CheckUserExists(t *testing.T) {
+	t.Parallel()
+
+	signIn := idTokenFlow{signIn: &model.SignIn{}}
+	assert.NoError(t, signIn.checkUserExists(true))
+	assert.ErrorIs(t, signIn.checkUserExists(false), errSignInUserNotExists)
+
+	signUp := idTokenFlow{signUp: &model.SignUp{}}
+	assert.NoError(t, signUp.checkUserExists(false))
+	assert.ErrorIs(t, signUp.checkUserExists(true), errSignUpUserAlreadyExists)
+}
+
+func TestGoogleOneTapAttemptor_ToAPIError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		err  error
+		code string
+	}{
+		{err: errSignUpUserAlreadyExists, code: apierror.ExternalAccountExistsCode},
+		{err: errSignInUserNotExists, code: apierror.ExternalAccountNotFoundCode},
+		{err: errIDTokenAlreadyUsed, code: apierror.AuthorizationInvalidCode},
+		{err: fmt.Errorf("google_one_tap/attempt: parse token: %w", jwt.ErrInvalidSignature), code: apierror.GoogleOneTapTokenInvalidCode},
+		{err: errActiveSAMLConnectionExists, code: apierror.SAMLEmailAddressDomainReservedCode},
+		{err: fmt.Errorf("google_one_tap/attempt: %w", clerkerrors.NewOAuthConfigMissing(provider.GoogleID())), code: apierror.OAuthConfigMissingCode},
+		{err: errors.New("database is down"), code: apierror.InternalClerkErrorCode},
+	} {
+		apiErr := GoogleOneTapAttemptor{}.ToAPIError(tc.err)
+		assert.True(t, apiErr.IsTypeOf(tc.code), "%s: got %s", tc.err, apiErr.ErrorCode())
+	}
+}
@@ -0,0 +1,143 @@
+package strategies
+
+import (
+	"context"
+	"time"
+
+	"clerk/pkg/cache"
+	"clerk/utils/clerk"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// otpFreeAttempts is the number of failed attempts allowed for an
+	// identification before delays kick in.
+	otpFreeAttempts = 3
+	// otpBaseDelay is the delay after the first failed attempt past the
+	// free ones, which doubles with every failed attempt after that, up to
+	// otpMaxDelay.
+	otpBaseDelay = 2 * time.Second
+	otpMaxDelay  = 5 * time.Minute
+	// otpMaxFailures is the number of failed attempts which puts the
+	// identification in a cooldown of otpCooldown.
+	otpMaxFailures = 10
+	otpCooldown    = 30 * time.Minute
+	// otpFailureWindow is how long failed attempts are remembered for,
+	// after the identification can be attempted again.
+	otpFailureWindow = time.Hour
+)
+
+// OTPThrottle protects OTP codes from brute-force attacks, by delaying
+// attempts for the same identification progressively after a number of
+// failed ones, and by putting the identification in a cooldown after too many
+// of them.
+//
+// Failed attempts are tracked per identification and not per verification,
+// so that preparing a new verification doesn't reset them. The state is kept
+// in the cache, so that it's shared across servers. Just like rate limiting,
+// attempts are allowed if the cache is unavailable.
+type OTPThrottle struct {
+	cache cache.Cache
+	clock clockwork.Clock
+}
+
+func NewOTPThrottle(deps clerk.Deps) *OTPThrottle {
+	return &OTPThrottle{
+		cache: deps.Cache(),
+		clock: deps.Clock(),
+	}
+}
+
+type otpThrottleState struct {
+	Failures    int       `json:"failures"`
+	LockedUntil time.Time `json:"locked_until"`
+}
+
+// Check returns the time until which the given identification can't be
+// attempted. The time is zero if the identification can be attempted.
+func (t *OTPThrottle) Check(ctx context.Context, identificationID string) time.Time {
+	var state otpThrottleState
+	if err := t.cache.Get(ctx, otpThrottleKey(identificationID), &state); err != nil {
+		log.Warning(ctx, "otp throttle: fetching state of %s: %s", identificationID, err)
+		return time.Time{}
+	}
+	if !state.LockedUntil.After(t.clock.Now().UTC()) {
+		return time.Time{}
+	}
+	return state.LockedUntil
+}
+
+// RecordFailure records a failed attempt for the given identification and
+// returns the time until which it can't be attempted again, which is zero if
+// it can be attempted right away.
+//
+// NOTE: reading and writing the state is not atomic, so concurrent attempts
+// may occasionally be recorded as one.
+func (t *OTPThrottle) RecordFailure(ctx context.Context, identificationID string) time.Time {
+	key := otpThrottleKey(identificationID)
+	var state otpThrottleState
+	if err := t.cache.Get(ctx, key, &state); err != nil {
+		log.Warning(ctx, "otp throttle: fetching state of %s: %s", identificationID, err)
+		return time.Time{}
+	}
+
+	now := t.clock.Now().UTC()
+	state.record(now)
+
+	ttl := otpFailureWindow
+	if state.LockedUntil.After(now) {
+		ttl += state.LockedUntil.Sub(now)
+	}
+	if err := t.cache.Set(ctx, key, state, ttl); err != nil {
+		log.Warning(ctx, "otp throttle: storing state of %s: %s", identificationID, err)
+	}
+
+	if !state.LockedUntil.After(now) {
+		return time.Time{}
+	}
+	return state.LockedUntil
+}
+
+// Reset forgets the failed attempts of the given identification, after a
+// successful one.
+func (t *OTPThrottle) Reset(ctx context.Context, identificationID string) {
+	if err := t.cache.Delete(ctx, otpThrottleKey(identificationID)); err != nil {
+		log.Warning(ctx, "otp throttle: resetting state of %s: %s", identificationID, err)
+	}
+}
+
+// record counts a failed attempt at the given time and locks the state for
+// as long as the next attempt has to wait.
+func (s *otpThrottleState) record(now time.Time) {
+	s.Failures++
+	if s.Failures >= otpMaxFailures {
+		// the count starts over once the cooldown is over, with delays
+		// kicking in right away since the free attempts are used up
+		s.Failures = otpFreeAttempts
+		s.LockedUntil = now.Add(otpCooldown)
+		return
+	}
+	s.LockedUntil = now.Add(otpDelay(s.Failures))
+}
+
+// otpDelay returns how long to wait after the given number of failed
+// attempts.
+func otpDelay(failures int) time.Duration {
+	if failures <= otpFreeAttempts {
+		return 0
+	}
+	delay := otpBaseDelay
+	for i := otpFreeAttempts + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= otpMaxDelay {
+			return otpMaxDelay
+		}
+	}
+	return delay
+}
+
+func otpThrottleKey(identificationID string) string {
+	return "otp_throttle:" + identificationID
+}
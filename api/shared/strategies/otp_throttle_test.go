@@ -0,0 +1,54 @@
+package strategies
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTPDelay(t *testing.T) {
+	t.Parallel()
+
+	for failures, expected := range map[int]time.Duration{
+		0: 0,
+		1: 0,
+		3: 0,
+		4: 2 * time.Second,
+		5: 4 * time.Second,
+		6: 8 * time.Second,
+		9: 64 * time.Second,
+		// capped
+		20: 5 * time.Minute,
+	} {
+		assert.Equal(t, expected, otpDelay(failures), "failures %d", failures)
+	}
+}
+
+func TestOTPThrottleStateRecord(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var state otpThrottleState
+
+	// the free attempts don't lock the state
+	for i := 0; i < otpFreeAttempts; i++ {
+		state.record(now)
+		assert.Equal(t, now, state.LockedUntil, "attempt %d", i)
+	}
+
+	state.record(now)
+	assert.Equal(t, now.Add(otpBaseDelay), state.LockedUntil)
+
+	// too many failures lock the state for the cooldown, after which delays
+	// kick in right away
+	for state.Failures < otpMaxFailures-1 {
+		state.record(now)
+	}
+	state.record(now)
+	assert.Equal(t, now.Add(otpCooldown), state.LockedUntil)
+
+	after := state.LockedUntil
+	state.record(after)
+	assert.Equal(t, after.Add(otpBaseDelay), state.LockedUntil)
+}
@@ -70,7 +70,7 @@ func NewPasskeyPreparer(deps clerk.Deps, env *model.Env, params *PasskeyPreparer
 		clock:            deps.Clock(),
 		env:              env,
 		identification:   params.Identification,
-		verificationRepo: repository.NewVerification(),
+		verificationRepo: deps.Repositories().Verification,
 		passkeySerice:    passkeys.NewService(deps),
 		creation:         params.Creation,
 		session:          params.Session,
@@ -205,6 +205,9 @@ type PasskeyAttemptorParams struct {
 	// registration
 	Passkey *model.Passkey
 	User    *model.User
+	// SignUp is set when the passkey is registered during sign-up, in which
+	// case User is the user the sign-up will create.
+	SignUp *model.SignUp
 
 	// authentication
 	SignIn *model.SignIn
@@ -230,6 +233,8 @@ type PasskeyAttemptor struct {
 	publicKeyCredential string
 	passkey             *model.Passkey
 	origin              string
+	user                *model.User
+	signUp              *model.SignUp
 	signIn              *model.SignIn
 }
 
@@ -240,18 +245,20 @@ func NewPasskeyAttemptor(deps clerk.Deps, env *model.Env, verification *model.Ve
 		env:                      env,
 		verification:             verification,
 		eventService:             events.NewService(deps),
-		identificationRepo:       repository.NewIdentification(),
-		passkeyAuthenticatorRepo: repository.NewPasskeyAuthenticator(),
-		passkeyRepo:              repository.NewPasskey(),
+		identificationRepo:       deps.Repositories().Identification,
+		passkeyAuthenticatorRepo: deps.Repositories().PasskeyAuthenticator,
+		passkeyRepo:              deps.Repositories().Passkey,
 		passkeyService:           passkeys.NewService(deps),
 		serializableService:      serializable.NewService(deps.Clock()),
-		signInRepo:               repository.NewSignIn(),
-		userRepo:                 repository.NewUsers(),
-		verificationRepo:         repository.NewVerification(),
+		signInRepo:               deps.Repositories().SignIn,
+		userRepo:                 deps.Repositories().Users,
+		verificationRepo:         deps.Repositories().Verification,
 		verificationService:      verifications.NewService(deps.Clock()),
 		publicKeyCredential:      params.PublicKeyCredential,
 		passkey:                  params.Passkey,
 		origin:                   params.Origin,
+		user:                     params.User,
+		signUp:                   params.SignUp,
 		signIn:                   params.SignIn,
 	}
 }
@@ -296,6 +303,25 @@ func (v PasskeyAttemptor) attemptRegistration(ctx context.Context, tx database.T
 		return nil, apiErr
 	}
 
+	// the user of a sign-up doesn't exist yet, the user.created event
+	// will include the passkey once the sign-up completes
+	var user *model.User
+	var previousUser events.UserSnapshot
+	if v.signUp == nil {
+		user, err = v.userRepo.QueryByInstanceAndIdentificationID(ctx, tx, v.env.Instance.ID, v.passkey.IdentificationID)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		if user == nil {
+			return nil, apierror.IdentificationNotFound(v.passkey.IdentificationID)
+		}
+
+		previousUser, err = v.serializableService.SnapshotUser(ctx, tx, usersettings.NewUserSettings(v.env.AuthConfig.UserSettings), user)
+		if err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+	}
+
 	// store the credential info the database on successful registration
 	var transports []string
 	for _, t := range credential.Transport {
@@ -331,17 +357,13 @@ func (v PasskeyAttemptor) attemptRegistration(ctx context.Context, tx database.T
 		return nil, apierror.Unexpected(err)
 	}
 
-	user, err := v.userRepo.QueryByInstanceAndIdentificationID(ctx, tx, v.env.Instance.ID, v.passkey.IdentificationID)
-	if err != nil {
-		return nil, apierror.Unexpected(err)
-	}
 	if user == nil {
-		return nil, apierror.IdentificationNotFound(v.passkey.IdentificationID)
+		return v.verification, nil
 	}
 
 	// send user updated webhook
 	usersettings := usersettings.NewUserSettings(v.env.AuthConfig.UserSettings)
-	if err = v.sendUserUpdatedEvent(ctx, tx, v.env.Instance, usersettings, user); err != nil {
+	if err = v.sendUserUpdatedEvent(ctx, tx, v.env.Instance, usersettings, previousUser, user); err != nil {
 		return nil, apierror.Unexpected(err)
 	}
 
@@ -496,7 +518,10 @@ func (v PasskeyAttemptor) finishPasskeyRegistration(ctx context.Context, tx data
 	}
 
 	// convert to webauthn user
-	user := requesting_user.FromContext(ctx)
+	user := v.user
+	if user == nil {
+		user = requesting_user.FromContext(ctx)
+	}
 	webAuthnUser, err := v.passkeyService.GetWebAuthnUser(ctx, tx, v.env.Instance.ID, user)
 	if err != nil {
 		return nil, "", apierror.Unexpected(err)
@@ -536,13 +561,14 @@ func (v PasskeyAttemptor) sendUserUpdatedEvent(
 	tx database.Tx,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User) error {
 	userSerializable, err := v.serializableService.ConvertUser(ctx, tx, userSettings, user)
 	if err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = v.eventService.UserUpdated(ctx, tx, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = v.eventService.UserUpdated(ctx, tx, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 	return nil
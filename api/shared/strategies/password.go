@@ -16,6 +16,7 @@ import (
 	"clerk/utils/database"
 	"clerk/utils/param"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/volatiletech/null/v8"
 )
 
@@ -26,7 +27,9 @@ var (
 )
 
 func init() {
-	HibpClient = hibp.NewClient()
+	// the cache is shared by all the checks, e.g. the sign-up and the
+	// creation of its user
+	HibpClient = hibp.NewCachingClient(hibp.NewClient(), clockwork.NewRealClock())
 }
 
 type PasswordAttemptor struct {
@@ -39,7 +39,7 @@ func NewPhoneCodePreparer(deps clerk.Deps, env *model.Env, identification *model
 		sourceType:       sourceType,
 		sourceID:         sourceID,
 		commsService:     comms.NewService(deps),
-		verificationRepo: repository.NewVerification(),
+		verificationRepo: deps.Repositories().Verification,
 	}
 }
 
@@ -85,21 +85,23 @@ type PhoneCodeAttemptor struct {
 	code         string
 	verification *model.Verification
 
+	otpThrottle         *OTPThrottle
 	verificationService *verifications.Service
 	verificationRepo    *repository.Verification
 }
 
-func NewPhoneCodeAttemptor(clock clockwork.Clock, verification *model.Verification, code string) PhoneCodeAttemptor {
+func NewPhoneCodeAttemptor(deps clerk.Deps, verification *model.Verification, code string) PhoneCodeAttemptor {
 	return PhoneCodeAttemptor{
 		code:                code,
 		verification:        verification,
-		verificationService: verifications.NewService(clock),
-		verificationRepo:    repository.NewVerification(),
+		otpThrottle:         NewOTPThrottle(deps),
+		verificationService: verifications.NewService(deps.Clock()),
+		verificationRepo:    deps.Repositories().Verification,
 	}
 }
 
 func (v PhoneCodeAttemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Verification, error) {
-	err := attemptOTPCode(ctx, tx, v.verificationService, v.verification, v.code, v.verificationRepo)
+	err := attemptOTPCode(ctx, tx, v.verificationService, v.otpThrottle, v.verification, v.code, v.verificationRepo)
 	return v.verification, err
 }
 
@@ -7,6 +7,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/shared/comms"
+	"clerk/api/shared/password"
 	"clerk/api/shared/user_profile"
 	"clerk/api/shared/verifications"
 	"clerk/model"
@@ -48,7 +49,7 @@ func NewResetPasswordCodePreparer(deps clerk.Deps, env *model.Env, identificatio
 		sourceID:           sourceID,
 		commsService:       comms.NewService(deps),
 		userProfileService: user_profile.NewService(deps.Clock()),
-		verificationRepo:   repository.NewVerification(),
+		verificationRepo:   deps.Repositories().Verification,
 	}
 }
 
@@ -113,6 +114,7 @@ type ResetPasswordCodeAttemptor struct {
 	signIn           *model.SignIn
 	verification     *model.Verification
 
+	otpThrottle         *OTPThrottle
 	verificationService *verifications.Service
 
 	signInRepo       *repository.SignIn
@@ -120,7 +122,7 @@ type ResetPasswordCodeAttemptor struct {
 }
 
 func NewResetPasswordCodeAttemptor(
-	clock clockwork.Clock,
+	deps clerk.Deps,
 	signIn *model.SignIn,
 	verification *model.Verification,
 	code string,
@@ -132,14 +134,15 @@ func NewResetPasswordCodeAttemptor(
 		passwordSettings:    passwordSettings,
 		signIn:              signIn,
 		verification:        verification,
-		verificationService: verifications.NewService(clock),
+		otpThrottle:         NewOTPThrottle(deps),
+		verificationService: verifications.NewService(deps.Clock()),
 		signInRepo:          repository.NewSignIn(),
 		verificationRepo:    repository.NewVerification(),
 	}
 }
 
 func (r ResetPasswordCodeAttemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Verification, error) {
-	err := attemptOTPCode(ctx, tx, r.verificationService, r.verification, r.code, r.verificationRepo)
+	err := attemptOTPCode(ctx, tx, r.verificationService, r.otpThrottle, r.verification, r.code, r.verificationRepo)
 	if err != nil {
 		return r.verification, err
 	}
@@ -156,6 +159,10 @@ func (r ResetPasswordCodeAttemptor) Attempt(ctx context.Context, tx database.Tx)
 		if apiErr != nil {
 			return r.verification, apiErr
 		}
+		apiErr = password.CheckPwned(ctx, HibpClient, r.passwordSettings, param.Password.Name, *r.newPassword)
+		if apiErr != nil {
+			return r.verification, apiErr
+		}
 
 		passwordDigest, err := hash.GenerateBcryptHash(*r.newPassword)
 		if err != nil {
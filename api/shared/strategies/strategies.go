@@ -106,6 +106,7 @@ func attemptOTPCode(
 	ctx context.Context,
 	tx database.Tx,
 	verificationService *verifications.Service,
+	otpThrottle *OTPThrottle,
 	verification *model.Verification,
 	code string,
 	verificationRepo *repository.Verification) error {
@@ -113,11 +114,30 @@ func attemptOTPCode(
 		return err
 	}
 
+	identificationID := verification.IdentificationID.String
+	if verification.IdentificationID.Valid {
+		if lockedUntil := otpThrottle.Check(ctx, identificationID); !lockedUntil.IsZero() {
+			return NewCooldownError(lockedUntil)
+		}
+	}
+
 	isCodeValid := isOtpCodeValid(verification.Token, code)
 	if err := logVerificationAttempt(ctx, tx, verificationRepo, verification, isCodeValid); err != nil {
 		return err
 	}
 
+	if verification.IdentificationID.Valid {
+		var lockedUntil time.Time
+		if isCodeValid {
+			otpThrottle.Reset(ctx, identificationID)
+		} else {
+			lockedUntil = otpThrottle.RecordFailure(ctx, identificationID)
+		}
+		if err := updateCooldownExpiresAt(ctx, tx, verificationRepo, verification, lockedUntil); err != nil {
+			return err
+		}
+	}
+
 	if !isCodeValid {
 		return ErrInvalidCode
 	}
@@ -125,6 +145,17 @@ func attemptOTPCode(
 	return nil
 }
 
+// updateCooldownExpiresAt stores until when the identification of the
+// verification can't be attempted, so that clients know how long to wait.
+func updateCooldownExpiresAt(ctx context.Context, tx database.Tx, repo *repository.Verification, ver *model.Verification, lockedUntil time.Time) error {
+	cooldownExpiresAt := null.NewTime(lockedUntil, !lockedUntil.IsZero())
+	if cooldownExpiresAt.Valid == ver.CooldownExpiresAt.Valid && cooldownExpiresAt.Time.Equal(ver.CooldownExpiresAt.Time) {
+		return nil
+	}
+	ver.CooldownExpiresAt = cooldownExpiresAt
+	return repo.Update(ctx, tx, ver, sqbmodel.VerificationColumns.CooldownExpiresAt)
+}
+
 func isOtpCodeValid(token null.String, code string) bool {
 	if !token.Valid {
 		return false
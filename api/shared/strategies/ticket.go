@@ -8,6 +8,7 @@ import (
 
 	"clerk/api/apierror"
 	"clerk/api/fapi/v1/samlaccount"
+	"clerk/api/shared/organizations"
 	"clerk/model"
 	"clerk/model/sqbmodel"
 	"clerk/pkg/cache"
@@ -64,17 +65,17 @@ func NewTicketAttemptor(deps clerk.Deps, env *model.Env, params TicketAttemptorP
 		signIn:                 params.SignIn,
 		signUp:                 params.SignUp,
 		samlAccountService:     samlaccount.NewService(deps),
-		identificationRepo:     repository.NewIdentification(),
-		actorTokenRepo:         repository.NewActorToken(),
-		instanceInvitationRepo: repository.NewInvitations(),
-		orgInvitationRepo:      repository.NewOrganizationInvitation(),
-		orgRepo:                repository.NewOrganization(),
-		samlConnectionRepo:     repository.NewSAMLConnection(),
-		signInRepo:             repository.NewSignIn(),
-		signInTokenRepo:        repository.NewSignInToken(),
-		signUpRepo:             repository.NewSignUp(),
-		userRepo:               repository.NewUsers(),
-		verificationRepo:       repository.NewVerification(),
+		identificationRepo:     deps.Repositories().Identification,
+		actorTokenRepo:         deps.Repositories().ActorToken,
+		instanceInvitationRepo: deps.Repositories().Invitations,
+		orgInvitationRepo:      deps.Repositories().OrganizationInvitation,
+		orgRepo:                deps.Repositories().Organization,
+		samlConnectionRepo:     deps.Repositories().SAMLConnection,
+		signInRepo:             deps.Repositories().SignIn,
+		signInTokenRepo:        deps.Repositories().SignInToken,
+		signUpRepo:             deps.Repositories().SignUp,
+		userRepo:               deps.Repositories().Users,
+		verificationRepo:       deps.Repositories().Verification,
 	}
 }
 
@@ -96,7 +97,7 @@ func (a TicketAttemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Ve
 		return a.handleInstanceInvitation(ctx, tx, claims)
 	case constants.OSTOrganizationInvitation:
 		return a.handleOrganizationInvitation(ctx, tx, claims)
-	case constants.OSTSignInToken:
+	case constants.OSTSignInToken, constants.OSTPasswordResetToken:
 		return a.handleSignInToken(ctx, tx, claims)
 	case constants.OSTSAMLIdpInitiated:
 		return a.handleSAMLIdPInitiated(ctx, tx, claims)
@@ -243,6 +244,11 @@ func (a TicketAttemptor) handleOrganizationInvitation(
 			invitation.ID, ErrOrganizationInvitationAlreadyAccepted)
 	}
 
+	if organizations.IsInvitationExpired(invitation, a.clock.Now().UTC()) {
+		return nil, fmt.Errorf("ticket/attempt: invitation %s has expired: %w",
+			invitation.ID, ErrOrganizationInvitationExpired)
+	}
+
 	identification, err := a.identificationRepo.QueryClaimedVerifiedByInstanceAndIdentifierAndType(ctx, tx, invitation.InstanceID, invitation.EmailAddress, constants.ITEmailAddress)
 	if err != nil {
 		return nil, fmt.Errorf("ticket/attempt: retrieving identification for %s in instance %s: %w",
@@ -330,7 +336,9 @@ func (a TicketAttemptor) handleSignInToken(
 	ctx context.Context,
 	tx database.Tx,
 	claims ticket.Claims) (*model.Verification, error) {
-	signInToken, err := a.signInTokenRepo.QueryByID(ctx, tx, claims.SourceID)
+	// The token is locked until the transaction is done, so that concurrent
+	// attempts can't use it more times than it allows.
+	signInToken, err := a.signInTokenRepo.QueryByIDForUpdate(ctx, tx, claims.SourceID)
 	if err != nil {
 		return nil, err
 	}
@@ -339,8 +347,10 @@ func (a TicketAttemptor) handleSignInToken(
 		return nil, ErrSignInTokenNotFound
 	} else if signInToken.Status == constants.StatusRevoked {
 		return nil, ErrSignInTokenRevoked
-	} else if signInToken.Status == constants.StatusAccepted {
-		return nil, ErrSignInTokenAlreadyAccepted
+	}
+
+	if err := useSignInToken(signInToken, a.clock.Now().UTC()); err != nil {
+		return nil, err
 	}
 
 	if a.signIn == nil {
@@ -382,27 +392,57 @@ func (a TicketAttemptor) handleSignInToken(
 			identificationID, verification.ID, err)
 	}
 
-	a.signIn.IdentificationID = null.StringFrom(identificationID)
-	a.signIn.FirstFactorCurrentVerificationID = null.StringFromPtr(nil)
-	a.signIn.FirstFactorSuccessVerificationID = null.StringFrom(verification.ID)
-
-	err = a.signInRepo.Update(
-		ctx, tx, a.signIn,
-		sqbmodel.SignInColumns.IdentificationID,
-		sqbmodel.SignInColumns.FirstFactorCurrentVerificationID,
-		sqbmodel.SignInColumns.FirstFactorSuccessVerificationID)
+	columns := verifySignInWithToken(a.signIn, identificationID, verification.ID, claims.SourceType)
+	err = a.signInRepo.Update(ctx, tx, a.signIn, columns...)
 	if err != nil {
 		return nil, err
 	}
 
-	signInToken.Status = constants.StatusAccepted
-	if err := a.signInTokenRepo.UpdateStatus(ctx, tx, signInToken); err != nil {
+	if err := a.signInTokenRepo.UpdateUsage(ctx, tx, signInToken); err != nil {
 		return nil, err
 	}
 
 	return verification, nil
 }
 
+// verifySignInWithToken completes the first factor of the sign in with the
+// verification of a sign in token, and returns the columns of the sign in
+// which changed.
+func verifySignInWithToken(signIn *model.SignIn, identificationID, verificationID, sourceType string) []string {
+	signIn.IdentificationID = null.StringFrom(identificationID)
+	signIn.FirstFactorCurrentVerificationID = null.StringFromPtr(nil)
+	signIn.FirstFactorSuccessVerificationID = null.StringFrom(verificationID)
+	columns := []string{
+		sqbmodel.SignInColumns.IdentificationID,
+		sqbmodel.SignInColumns.FirstFactorCurrentVerificationID,
+		sqbmodel.SignInColumns.FirstFactorSuccessVerificationID,
+	}
+
+	// Password reset links are sign in tokens as well, but the sign in can
+	// only be completed once the user has set a new password.
+	if sourceType == constants.OSTPasswordResetToken {
+		signIn.RequiresNewPassword = true
+		columns = append(columns, sqbmodel.SignInColumns.RequiresNewPassword)
+	}
+	return columns
+}
+
+// useSignInToken records a use of the sign in token. Sign in tokens can be
+// used as many times as they allow, and are accepted once they've been used
+// up.
+func useSignInToken(signInToken *model.SignInToken, now time.Time) error {
+	if signInToken.Status == constants.StatusAccepted || signInToken.UsesCount >= signInToken.AllowedUses() {
+		return ErrSignInTokenAlreadyAccepted
+	}
+
+	signInToken.UsesCount++
+	signInToken.LastUsedAt = null.TimeFrom(now)
+	if signInToken.UsesCount >= signInToken.AllowedUses() {
+		signInToken.Status = constants.StatusAccepted
+	}
+	return nil
+}
+
 func (a TicketAttemptor) handleActorToken(
 	ctx context.Context,
 	tx database.Tx,
@@ -654,6 +694,8 @@ func (TicketAttemptor) ToAPIError(err error) apierror.Error {
 		return apierror.OrganizationInvitationRevoked()
 	} else if errors.Is(err, ErrOrganizationInvitationAlreadyAccepted) {
 		return apierror.OrganizationInvitationAlreadyAccepted()
+	} else if errors.Is(err, ErrOrganizationInvitationExpired) {
+		return apierror.OrganizationInvitationExpired()
 	} else if errors.Is(err, ErrOrganizationInvitationIdentificationNotFound) {
 		return apierror.OrganizationInvitationIdentificationNotExist()
 	} else if errors.Is(err, ErrOrganizationInvitationIdentificationAlreadyExists) {
@@ -704,6 +746,7 @@ var (
 	ErrOrganizationInvitationIdentificationAlreadyExists = errors.New("organizationInvitation: refers to an existing identifier")
 	ErrOrganizationInvitationRevoked                     = errors.New("organizationInvitation: revoked")
 	ErrOrganizationInvitationAlreadyAccepted             = errors.New("organizationInvitation: already accepted")
+	ErrOrganizationInvitationExpired                     = errors.New("organizationInvitation: expired")
 	ErrOrganizationInvitationNotFound                    = errors.New("organizationInvitation: doesn't exist")
 	ErrOrganizationInvitationToDeletedOrganization       = errors.New("organizationInvitation: deleted organization")
 
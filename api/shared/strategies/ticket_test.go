@@ -0,0 +1,73 @@
+package strategies
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestUseSignInToken(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	signInToken := &model.SignInToken{SignInToken: &sqbmodel.SignInToken{
+		Status:  constants.StatusPending,
+		MaxUses: 3,
+	}}
+
+	for i := 1; i < 3; i++ {
+		require.NoError(t, useSignInToken(signInToken, now))
+		assert.Equal(t, i, signInToken.UsesCount)
+		assert.Equal(t, constants.StatusPending, signInToken.Status)
+	}
+
+	// the last allowed use accepts the token
+	require.NoError(t, useSignInToken(signInToken, now))
+	assert.Equal(t, 3, signInToken.UsesCount)
+	assert.Equal(t, constants.StatusAccepted, signInToken.Status)
+	assert.Equal(t, now, signInToken.LastUsedAt.Time)
+
+	assert.ErrorIs(t, useSignInToken(signInToken, now), ErrSignInTokenAlreadyAccepted)
+	assert.Equal(t, 3, signInToken.UsesCount)
+
+	// tokens which were used up are rejected, even if still pending
+	usedUp := &model.SignInToken{SignInToken: &sqbmodel.SignInToken{
+		Status:    constants.StatusPending,
+		MaxUses:   2,
+		UsesCount: 2,
+	}}
+	assert.ErrorIs(t, useSignInToken(usedUp, now), ErrSignInTokenAlreadyAccepted)
+	assert.Equal(t, 2, usedUp.UsesCount)
+}
+
+func TestVerifySignInWithToken(t *testing.T) {
+	t.Parallel()
+
+	signIn := &model.SignIn{SignIn: &sqbmodel.SignIn{
+		FirstFactorCurrentVerificationID: null.StringFrom("ver_current"),
+	}}
+	columns := verifySignInWithToken(signIn, "idn_1", "ver_1", constants.OSTSignInToken)
+	assert.Equal(t, "idn_1", signIn.IdentificationID.String)
+	assert.False(t, signIn.FirstFactorCurrentVerificationID.Valid)
+	assert.Equal(t, "ver_1", signIn.FirstFactorSuccessVerificationID.String)
+	assert.False(t, signIn.RequiresNewPassword)
+	assert.NotContains(t, columns, sqbmodel.SignInColumns.RequiresNewPassword)
+
+	// password reset links can only complete the sign in with a new password
+	signIn = &model.SignIn{SignIn: &sqbmodel.SignIn{}}
+	columns = verifySignInWithToken(signIn, "idn_1", "ver_1", constants.OSTPasswordResetToken)
+	assert.True(t, signIn.RequiresNewPassword)
+	assert.Equal(t, []string{
+		sqbmodel.SignInColumns.IdentificationID,
+		sqbmodel.SignInColumns.FirstFactorCurrentVerificationID,
+		sqbmodel.SignInColumns.FirstFactorSuccessVerificationID,
+		sqbmodel.SignInColumns.RequiresNewPassword,
+	}, columns)
+}
@@ -4,21 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"time"
 
 	"clerk/api/apierror"
 	"clerk/api/shared/verifications"
 	"clerk/model"
+	"clerk/model/sqbmodel"
 	"clerk/pkg/constants"
-	"clerk/pkg/rand"
+	"clerk/pkg/ctx/request_info"
+	"clerk/pkg/web3"
 	"clerk/repository"
 	"clerk/utils/database"
 	"clerk/utils/param"
 
-	"github.com/ethereum/go-ethereum/accounts"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
 )
 
 type Web3WalletPreparer struct {
@@ -44,16 +46,20 @@ func (p Web3WalletPreparer) Identification() *model.Identification {
 	return p.web3Wallet
 }
 
+// Prepare creates a verification whose nonce is a sign-in message, which the
+// wallet has to sign in order to attempt the verification. Messages follow
+// EIP-4361, for the chain the wallet was last verified on, or the default
+// chain of its address.
 func (p Web3WalletPreparer) Prepare(ctx context.Context, tx database.Tx) (*model.Verification, error) {
-	nonce, err := rand.Token()
+	message, err := p.signInMessage(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("Web3Wallet/prepare: creating verification nonce: %w", err)
+		return nil, fmt.Errorf("Web3Wallet/prepare: %w", err)
 	}
 
 	verification, err := createVerification(ctx, tx, p.clock, &createVerificationParams{
 		instanceID:       p.env.Instance.ID,
 		strategy:         constants.VSWeb3MetamaskSignature,
-		nonce:            &nonce,
+		nonce:            &message,
 		identificationID: &p.web3Wallet.ID,
 	})
 	if err != nil {
@@ -63,10 +69,80 @@ func (p Web3WalletPreparer) Prepare(ctx context.Context, tx database.Tx) (*model
 	return verification, nil
 }
 
+func (p Web3WalletPreparer) signInMessage(ctx context.Context) (string, error) {
+	address := p.web3Wallet.Identifier.String
+	chain, ok := web3.ChainForAddress(address)
+	if !ok {
+		return "", fmt.Errorf("no supported chain for web3 wallet %s", p.web3Wallet.ID)
+	}
+	chainID := chain.DefaultChainID()
+	if p.web3Wallet.ChainID.Valid {
+		chainID = p.web3Wallet.ChainID.String
+	}
+	chain, chainReference, err := web3.ChainForID(chainID)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := web3.GenerateSIWENonce()
+	if err != nil {
+		return "", err
+	}
+
+	var origin string
+	if requestInfo := request_info.FromContext(ctx); requestInfo != nil {
+		origin = requestInfo.Origin
+	}
+	domain, uri := siweDomainAndURI(p.env, origin)
+
+	issuedAt := p.clock.Now().UTC()
+	expiresAt := issuedAt.Add(time.Second * time.Duration(constants.ExpiryTimeTransactional))
+	message := &web3.SIWEMessage{
+		Chain:          chain,
+		Domain:         domain,
+		Address:        address,
+		URI:            uri,
+		Version:        web3.SIWEVersion,
+		ChainReference: chainReference,
+		Nonce:          nonce,
+		IssuedAt:       issuedAt,
+		ExpirationTime: &expiresAt,
+	}
+	return message.String(), nil
+}
+
+// siweDomainAndURI returns the domain and URI the sign-in message is bound
+// to. Wallets warn their users about phishing when the domain doesn't match
+// the origin which requests the signature, so the origin of the request is
+// used, but only if it's one the instance trusts. Otherwise, anyone could get
+// messages for their own site which sign users in to the instance.
+func siweDomainAndURI(env *model.Env, origin string) (string, string) {
+	domain := env.Domain.Name
+	uri := "https://" + domain
+	if origin == "" {
+		return domain, uri
+	}
+
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return domain, uri
+	}
+
+	trusted := parsed.Hostname() == env.Domain.Name ||
+		(env.Instance.HomeOrigin.Valid && env.Instance.HomeOrigin.String == origin) ||
+		slices.Contains(env.Instance.AllowedOrigins, origin)
+	if !trusted {
+		return domain, uri
+	}
+	return parsed.Host, origin
+}
+
 type Web3Attemptor struct {
-	env *model.Env
+	clock clockwork.Clock
+	env   *model.Env
 
 	verificationService *verifications.Service
+	identificationRepo  *repository.Identification
 	verificationRepo    *repository.Verification
 
 	identification *model.Identification
@@ -81,8 +157,10 @@ func NewWeb3Attemptor(
 	web3Signature string,
 ) Web3Attemptor {
 	return Web3Attemptor{
+		clock:               clock,
 		env:                 env,
 		verificationService: verifications.NewService(clock),
+		identificationRepo:  repository.NewIdentification(),
 		verificationRepo:    repository.NewVerification(),
 		identification:      identification,
 		verification:        verification,
@@ -95,7 +173,10 @@ func (a Web3Attemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Veri
 		return a.verification, err
 	}
 
-	isSignatureValid := verifySig(a.identification.Identifier.String, a.web3Signature, a.verification.Nonce.String)
+	chainID, isSignatureValid, err := a.verifySignature()
+	if err != nil {
+		return a.verification, err
+	}
 	if err := logVerificationAttempt(ctx, tx, a.verificationRepo, a.verification, isSignatureValid); err != nil {
 		return a.verification, err
 	}
@@ -104,50 +185,43 @@ func (a Web3Attemptor) Attempt(ctx context.Context, tx database.Tx) (*model.Veri
 		return nil, ErrInvalidWeb3Signature
 	}
 
-	return a.verification, nil
-}
-
-func (Web3Attemptor) ToAPIError(err error) apierror.Error {
-	if errors.Is(err, ErrInvalidWeb3Signature) {
-		return apierror.FormIncorrectSignature(param.Web3Signature.Name)
+	// Remember the chain the wallet signed in with
+	if chainID != "" && a.identification.ChainID.String != chainID {
+		a.identification.ChainID = null.StringFrom(chainID)
+		if err := a.identificationRepo.Update(ctx, tx, a.identification, sqbmodel.IdentificationColumns.ChainID); err != nil {
+			return a.verification, fmt.Errorf("Web3Wallet/attempt: updating chain id of %s: %w", a.identification.ID, err)
+		}
 	}
 
-	return toAPIErrors(err)
+	return a.verification, nil
 }
 
-// https://gist.github.com/dcb9/385631846097e1f59e3cba3b1d42f3ed#file-eth_sign_verify-go
-func verifySig(web3Wallet, web3Signature, nonce string) bool {
-	fromAddr := common.HexToAddress(web3Wallet)
+// verifySignature verifies the signature of the sign-in message of the
+// verification, and returns the CAIP-2 id of the chain it was signed for.
+func (a Web3Attemptor) verifySignature() (string, bool, error) {
+	address := a.identification.Identifier.String
+	message := a.verification.Nonce.String
 
-	sig, err := hexutil.Decode(web3Signature)
-	if err != nil {
-		// safe to ignore the error, we just care that the signature is not valid
-		return false
-	}
-
-	// EcRecover returns the address for the account that was used to create the signature.
-	// Note, this function is compatible with eth_sign and personal_sign. As such it recovers
-	// the address of:
-	// hash = keccak256("\x19Ethereum Signed Message:\n"${message length}${message})
-	// addr = ecrecover(hash, signature)
-	//
-	// Note, the signature must conform to the secp256k1 curve R, S and V values, where
-	// the V value must be be 27 or 28 for legacy reasons.
-	//
-	// https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_ecRecover
-	//
-	// Original code: https://github.com/ethereum/go-ethereum/blob/55599ee95d4151a2502465e0afc7c47bd1acba77/internal/ethapi/api.go#L442
-	if sig[64] != 27 && sig[64] != 28 {
-		return false
-	}
-	sig[64] -= 27
-
-	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(nonce)), sig)
+	if !web3.IsSIWEMessage(message) {
+		// Verifications prepared before sign-in messages were introduced
+		// only carry a nonce, which Ethereum wallets sign as it is.
+		return "", web3.Ethereum{}.VerifySignature(address, message, a.web3Signature), nil
+	}
+
+	siwe, err := web3.ParseSIWEMessage(message)
 	if err != nil {
-		return false
+		return "", false, fmt.Errorf("Web3Wallet/attempt: parsing sign-in message of verification %s: %w", a.verification.ID, err)
 	}
+	if !siwe.Chain.SameAddress(siwe.Address, address) || siwe.Validate(a.clock.Now().UTC()) != nil {
+		return "", false, nil
+	}
+	return siwe.ChainID(), siwe.Chain.VerifySignature(address, message, a.web3Signature), nil
+}
 
-	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
+func (Web3Attemptor) ToAPIError(err error) apierror.Error {
+	if errors.Is(err, ErrInvalidWeb3Signature) {
+		return apierror.FormIncorrectSignature(param.Web3Signature.Name)
+	}
 
-	return fromAddr == recoveredAddr
+	return toAPIErrors(err)
 }
@@ -0,0 +1,83 @@
+package strategies
+
+import (
+	"testing"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestSIWEDomainAndURI(t *testing.T) {
+	t.Parallel()
+
+	env := &model.Env{
+		Instance: &model.Instance{Instance: &sqbmodel.Instance{
+			HomeOrigin:     null.StringFrom("http://localhost:3000"),
+			AllowedOrigins: []string{"chrome-extension://abcdef", "https://app.example.org"},
+		}},
+		Domain: &model.Domain{Domain: &sqbmodel.Domain{Name: "example.com"}},
+	}
+
+	tests := []struct {
+		name       string
+		origin     string
+		wantDomain string
+		wantURI    string
+	}{
+		{
+			name:       "no origin",
+			wantDomain: "example.com",
+			wantURI:    "https://example.com",
+		},
+		{
+			name:       "instance domain",
+			origin:     "https://example.com",
+			wantDomain: "example.com",
+			wantURI:    "https://example.com",
+		},
+		{
+			name:       "home origin",
+			origin:     "http://localhost:3000",
+			wantDomain: "localhost:3000",
+			wantURI:    "http://localhost:3000",
+		},
+		{
+			name:       "allowed origin",
+			origin:     "https://app.example.org",
+			wantDomain: "app.example.org",
+			wantURI:    "https://app.example.org",
+		},
+		{
+			name:       "untrusted origin",
+			origin:     "https://evil.example.net",
+			wantDomain: "example.com",
+			wantURI:    "https://example.com",
+		},
+		{
+			name:       "untrusted subdomain",
+			origin:     "https://evil.example.com",
+			wantDomain: "example.com",
+			wantURI:    "https://example.com",
+		},
+		{
+			name:       "malformed origin",
+			origin:     "null",
+			wantDomain: "example.com",
+			wantURI:    "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			domain, uri := siweDomainAndURI(env, tt.origin)
+			assert.Equal(t, tt.wantDomain, domain)
+			assert.Equal(t, tt.wantURI, uri)
+		})
+	}
+}
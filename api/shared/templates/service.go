@@ -22,6 +22,7 @@ type Service struct {
 
 	identificationRepo *repository.Identification
 	signInRepo         *repository.SignIn
+	signInTokenRepo    *repository.SignInToken
 	signUpRepo         *repository.SignUp
 	templateRepo       *repository.Templates
 	userRepo           *repository.Users
@@ -34,6 +35,7 @@ func NewService(clock clockwork.Clock) *Service {
 
 		identificationRepo: repository.NewIdentification(),
 		signInRepo:         repository.NewSignIn(),
+		signInTokenRepo:    repository.NewSignInToken(),
 		signUpRepo:         repository.NewSignUp(),
 		templateRepo:       repository.NewTemplates(),
 		userRepo:           repository.NewUsers(),
@@ -166,6 +168,21 @@ func (s *Service) GetCommonVerificationData(
 			return data, err
 		}
 
+		data.User = templates.UserToTemplateData(user)
+	case constants.OSTSignInToken:
+		signInToken, err := s.signInTokenRepo.QueryByIDAndInstance(ctx, exec, sourceID, instanceID)
+		if err != nil {
+			return data, err
+		}
+		if signInToken == nil {
+			return data, fmt.Errorf("GetCommonVerificationData: sign in token %s not found", sourceID)
+		}
+
+		user, err := s.userRepo.FindByIDAndInstance(ctx, exec, signInToken.UserID, instanceID)
+		if err != nil {
+			return data, err
+		}
+
 		data.User = templates.UserToTemplateData(user)
 	case constants.OSTUser:
 		identification, err := s.identificationRepo.FindByIDAndInstance(ctx, exec, sourceID, instanceID)
@@ -5,8 +5,10 @@ import (
 	"errors"
 
 	"clerk/api/shared/jwt_template"
+	"clerk/api/shared/signing_keys"
 	"clerk/model"
 	"clerk/pkg/auth"
+	"clerk/pkg/cache"
 	"clerk/pkg/cenv"
 	"clerk/pkg/jwt"
 	usersettings "clerk/pkg/usersettings/clerk"
@@ -27,6 +29,8 @@ var ErrUserNotFound = errors.New("user not found")
 func GenerateSessionToken(
 	ctx context.Context,
 	clock clockwork.Clock,
+	c cache.Cache,
+	signingKeys *signing_keys.Service,
 	exec database.Executor,
 	env *model.Env,
 	session *model.Session,
@@ -63,6 +67,16 @@ func GenerateSessionToken(
 		return "", err
 	}
 
+	signingKey, err := signingKeys.Current(ctx, exec, env.Instance)
+	if err != nil {
+		return "", err
+	}
+	params.SigningKey = auth.SigningKey{
+		KID:        signingKey.KID,
+		PrivateKey: signingKey.PrivateKey,
+		Algorithm:  signingKey.Algorithm,
+	}
+
 	// apply configured custom claims, if any
 	if env.Instance.CustomSessionTokenTemplate() {
 		templateID := env.Instance.SessionTokenTemplateID.String
@@ -74,6 +88,9 @@ func GenerateSessionToken(
 			Issuer:         issuer,
 			Origin:         origin,
 			SessionActor:   session.Actor.JSON,
+			// avoid querying the permissions of the active organization
+			// role on every token refresh
+			ResolvePermissions: jwt_template.CachedPermissions(c, session.ID, jwt_template.QueryPermissions(exec)),
 		}
 
 		var err error
@@ -0,0 +1,192 @@
+// Package trusted_devices lets users skip the second factor of their sign-ins
+// on devices they've trusted, on instances which opted in.
+//
+// A device is trusted when the user completes a second factor verification
+// and opts to. The device then receives a token, signed with the instance
+// key, which identifies the trusted device record and its user. Subsequent
+// sign-ins of the same user that present the token skip the second factor,
+// until the record expires or is revoked, either by the user or because
+// their password or second factors were reset.
+package trusted_devices
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cenv"
+	"clerk/pkg/constants"
+	"clerk/pkg/jwt"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	pkiutils "clerk/utils/pki"
+
+	josejwt "github.com/go-jose/go-jose/v3/jwt"
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/null/v8"
+)
+
+// CookieName is the name of the FAPI cookie which carries the token of the
+// trusted device.
+const CookieName = "__clerk_trusted_device"
+
+// the lifetime of a trusted device, unless configured otherwise
+const defaultLifetimeInDays = 30
+
+// MaxLifetimeInDays is the longest lifetime instances can configure for
+// their trusted devices.
+const MaxLifetimeInDays = 365
+
+// the longest device name we keep, as it's derived from the user agent
+const maxNameLength = 255
+
+// Enabled returns whether the instance of the given auth config lets users
+// trust their devices.
+func Enabled(authConfig *model.AuthConfig) bool {
+	return authConfig.UserSettings.SignIn.TrustedDevices.Enabled
+}
+
+// Lifetime returns for how long a device stays trusted on the instance of
+// the given auth config. Instances which didn't configure it use the default
+// of the environment.
+func Lifetime(authConfig *model.AuthConfig) time.Duration {
+	days := authConfig.UserSettings.SignIn.TrustedDevices.LifetimeInDays
+	if days <= 0 {
+		days = cenv.GetInt(cenv.TrustedDeviceLifetimeInDays)
+	}
+	if days <= 0 {
+		days = defaultLifetimeInDays
+	}
+	return time.Duration(min(days, MaxLifetimeInDays)) * 24 * time.Hour
+}
+
+type claims struct {
+	josejwt.Claims
+	InstanceID string `json:"iid"`
+}
+
+type Service struct {
+	clock clockwork.Clock
+
+	// repositories
+	trustedDeviceRepo *repository.TrustedDevices
+	verificationRepo  *repository.Verification
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:             deps.Clock(),
+		trustedDeviceRepo: deps.Repositories().TrustedDevices,
+		verificationRepo:  deps.Repositories().Verification,
+	}
+}
+
+// Trust records a new trusted device for the user and returns the token the
+// device should present on subsequent sign-ins.
+func (s *Service) Trust(ctx context.Context, exec database.Executor, env *model.Env, userID, userAgent string) (*model.TrustedDevice, string, error) {
+	instance := env.Instance
+	now := s.clock.Now().UTC()
+	if len(userAgent) > maxNameLength {
+		userAgent = userAgent[:maxNameLength]
+	}
+
+	device := &model.TrustedDevice{TrustedDevice: &sqbmodel.TrustedDevice{
+		InstanceID: instance.ID,
+		UserID:     userID,
+		UserAgent:  userAgent,
+		ExpireAt:   now.Add(Lifetime(env.AuthConfig)),
+	}}
+	if err := s.trustedDeviceRepo.Insert(ctx, exec, device); err != nil {
+		return nil, "", fmt.Errorf("trusted_devices/trust: inserting trusted device for user %s: %w", userID, err)
+	}
+
+	token, err := jwt.GenerateToken(instance.PrivateKey, claims{
+		Claims: josejwt.Claims{
+			ID:       device.ID,
+			Subject:  userID,
+			IssuedAt: josejwt.NewNumericDate(now),
+			Expiry:   josejwt.NewNumericDate(device.ExpireAt),
+		},
+		InstanceID: instance.ID,
+	}, instance.KeyAlgorithm)
+	if err != nil {
+		return nil, "", fmt.Errorf("trusted_devices/trust: signing token of device %s: %w", device.ID, err)
+	}
+	return device, token, nil
+}
+
+// VerifyForUser returns the trusted device of the given token, if the token
+// is valid, belongs to the given user and the device is still trusted. It
+// returns nil otherwise, since an invalid token only means that the second
+// factor can't be skipped.
+func (s *Service) VerifyForUser(ctx context.Context, exec database.Executor, instance *model.Instance, userID, token string) (*model.TrustedDevice, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	publicKey, err := pkiutils.LoadPublicKey([]byte(instance.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("trusted_devices/verify: loading public key of instance %s: %w", instance.ID, err)
+	}
+
+	var deviceClaims claims
+	if err := jwt.Verify(token, publicKey, &deviceClaims, s.clock, instance.KeyAlgorithm); err != nil {
+		return nil, nil
+	}
+	if deviceClaims.InstanceID != instance.ID || deviceClaims.Subject != userID {
+		return nil, nil
+	}
+
+	device, err := s.trustedDeviceRepo.QueryByIDAndInstance(ctx, exec, deviceClaims.ID, instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("trusted_devices/verify: fetching trusted device %s: %w", deviceClaims.ID, err)
+	}
+	if device == nil || device.UserID != userID || !s.clock.Now().Before(device.ExpireAt) {
+		return nil, nil
+	}
+
+	device.LastUsedAt = null.TimeFrom(s.clock.Now().UTC())
+	if err := s.trustedDeviceRepo.Update(ctx, exec, device, sqbmodel.TrustedDeviceColumns.LastUsedAt); err != nil {
+		return nil, fmt.Errorf("trusted_devices/verify: updating last use of trusted device %s: %w", device.ID, err)
+	}
+	return device, nil
+}
+
+// RevokeAllForUser stops trusting all the devices of the user, e.g. because
+// their password or second factors were reset, so that the next sign-in on
+// any device requires the second factor again.
+func (s *Service) RevokeAllForUser(ctx context.Context, exec database.Executor, instanceID, userID string) error {
+	if err := s.trustedDeviceRepo.DeleteAllByInstanceAndUser(ctx, exec, instanceID, userID); err != nil {
+		return fmt.Errorf("trusted_devices/revokeAllForUser: deleting trusted devices of user %s: %w", userID, err)
+	}
+	return nil
+}
+
+// CreateVerification creates the verification which stands in for the
+// second factor of a sign-in on a trusted device.
+func (s *Service) CreateVerification(ctx context.Context, exec database.Executor, device *model.TrustedDevice) (*model.Verification, error) {
+	verification := &model.Verification{Verification: &sqbmodel.Verification{
+		InstanceID: device.InstanceID,
+		Strategy:   constants.VSTrustedDevice,
+		Attempts:   1,
+		Nonce:      null.StringFrom(device.ID),
+	}}
+	if err := s.verificationRepo.Insert(ctx, exec, verification); err != nil {
+		return nil, fmt.Errorf("trusted_devices/createVerification: inserting verification for device %s: %w", device.ID, err)
+	}
+	return verification, nil
+}
+
+// TokenFromRequest returns the trusted device token sent with the request,
+// if any.
+func TokenFromRequest(r *http.Request) string {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
@@ -0,0 +1,36 @@
+package trusted_devices
+
+import (
+	"testing"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func authConfigWithTrustedDevices(enabled bool, lifetimeInDays int) *model.AuthConfig {
+	authConfig := &model.AuthConfig{AuthConfig: &sqbmodel.AuthConfig{}}
+	authConfig.UserSettings.SignIn.TrustedDevices.Enabled = enabled
+	authConfig.UserSettings.SignIn.TrustedDevices.LifetimeInDays = lifetimeInDays
+	return authConfig
+}
+
+func TestEnabled(t *testing.T) {
+	t.Parallel()
+
+	// instances have to opt in
+	assert.False(t, Enabled(&model.AuthConfig{AuthConfig: &sqbmodel.AuthConfig{}}))
+	assert.False(t, Enabled(authConfigWithTrustedDevices(false, 7)))
+	assert.True(t, Enabled(authConfigWithTrustedDevices(true, 0)))
+}
+
+func TestLifetime(t *testing.T) {
+	t.Parallel()
+
+	day := 24 * time.Hour
+	assert.Equal(t, 7*day, Lifetime(authConfigWithTrustedDevices(true, 7)))
+	assert.Equal(t, MaxLifetimeInDays*day, Lifetime(authConfigWithTrustedDevices(true, 1000)))
+	assert.Equal(t, defaultLifetimeInDays*day, Lifetime(authConfigWithTrustedDevices(true, 0)))
+}
@@ -7,13 +7,13 @@ import (
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/api/shared/events"
+	"clerk/api/shared/requestlog"
 	"clerk/api/shared/serializable"
 	"clerk/model"
 	usersettings "clerk/pkg/usersettings/clerk"
 	"clerk/repository"
 	"clerk/utils/clerk"
 	"clerk/utils/database"
-	"clerk/utils/log"
 
 	"github.com/jonboulle/clockwork"
 	"github.com/volatiletech/null/v8"
@@ -36,47 +36,55 @@ func NewService(deps clerk.Deps) *Service {
 		clock:               deps.Clock(),
 		eventService:        events.NewService(deps),
 		serializableService: serializable.NewService(deps.Clock()),
-		identificationRepo:  repository.NewIdentification(),
-		userRepo:            repository.NewUsers(),
+		identificationRepo:  deps.Repositories().Identification,
+		userRepo:            deps.Repositories().Users,
 	}
 }
 
 // Lock marks the provided user as locked by setting their locked_at timestamp to now.
 func (s *Service) Lock(ctx context.Context, exec database.Executor, env *model.Env, user *model.User) (*model.UserSerializable, error) {
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+	previousUser, err := s.serializableService.SnapshotUser(ctx, exec, userSettings, user)
+	if err != nil {
+		return nil, fmt.Errorf("user_lockout/lock: snapshot user %s: %w", user, err)
+	}
+
 	user.LockedAt = null.TimeFrom(s.clock.Now().UTC())
 
-	err := s.userRepo.UpdateLockedAt(ctx, exec, user)
+	err = s.userRepo.UpdateLockedAt(ctx, exec, user)
 	if err != nil {
 		return nil, fmt.Errorf("user_lockout/lock: lock user %s: %w", user, err)
 	}
 
-	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
-
-	userSerializable, err := s.sendUserUpdatedEvent(ctx, exec, env.Instance, userSettings, user)
+	userSerializable, err := s.sendUserUpdatedEvent(ctx, exec, env.Instance, userSettings, previousUser, user)
 	if err != nil {
 		return nil, fmt.Errorf("user_lockout/lock: send user updated event for (%s, %s): %w", user, env.Instance.ID, err)
 	}
 
-	log.Debug(ctx, "Locking user", user.ID)
+	requestlog.Debugf(ctx, "user_lockout/lock: locked user %s", user.ID)
 
 	return userSerializable, nil
 }
 
 // Unlock clears a user's locked_at timestamp and resets their failed_attempts to 0.
 func (s *Service) Unlock(ctx context.Context, exec database.Executor, env *model.Env, user *model.User) (*model.UserSerializable, error) {
-	err := s.userRepo.Unlock(ctx, exec, user)
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+	previousUser, err := s.serializableService.SnapshotUser(ctx, exec, userSettings, user)
 	if err != nil {
-		return nil, fmt.Errorf("user_lockout/reset: unlock user %s: %w", user, err)
+		return nil, fmt.Errorf("user_lockout/reset: snapshot user %s: %w", user, err)
 	}
 
-	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+	err = s.userRepo.Unlock(ctx, exec, user)
+	if err != nil {
+		return nil, fmt.Errorf("user_lockout/reset: unlock user %s: %w", user, err)
+	}
 
-	userSerializable, err := s.sendUserUpdatedEvent(ctx, exec, env.Instance, userSettings, user)
+	userSerializable, err := s.sendUserUpdatedEvent(ctx, exec, env.Instance, userSettings, previousUser, user)
 	if err != nil {
 		return nil, fmt.Errorf("user_lockout/reset: send user updated event for (%s, %s): %w", user, env.Instance.ID, err)
 	}
 
-	log.Debug(ctx, "Resetting user lockout fields for user", user.ID)
+	requestlog.Debugf(ctx, "user_lockout/unlock: reset lockout of user %s", user.ID)
 
 	return userSerializable, nil
 }
@@ -154,6 +162,7 @@ func (s *Service) sendUserUpdatedEvent(
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User,
 ) (*model.UserSerializable, error) {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
@@ -161,7 +170,7 @@ func (s *Service) sendUserUpdatedEvent(
 		return nil, fmt.Errorf("user_lockout/sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return nil, fmt.Errorf("user_lockout/sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
 
@@ -0,0 +1,93 @@
+package user_profile
+
+import (
+	"context"
+	"slices"
+
+	"clerk/model"
+	"clerk/pkg/constants"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+)
+
+// DefaultIdentifierPriority is the order in which the identifier of a user
+// is picked when the instance doesn't specify one.
+var DefaultIdentifierPriority = []string{
+	constants.ITEmailAddress,
+	constants.ITPhoneNumber,
+	constants.ITWeb3Wallet,
+	constants.ITUsername,
+}
+
+// IdentifierPriority returns the order in which the instance prefers to
+// identify its users, e.g. in organization memberships.
+func IdentifierPriority(userSettings *usersettings.UserSettings) []string {
+	if len(userSettings.IdentifierPriority) == 0 {
+		return DefaultIdentifierPriority
+	}
+	return userSettings.IdentifierPriority
+}
+
+// IsValidIdentifierPriority reports whether priority only contains
+// identifier types, each one of them at most once.
+func IsValidIdentifierPriority(priority []string) bool {
+	for i, identifierType := range priority {
+		if !slices.Contains(DefaultIdentifierPriority, identifierType) {
+			return false
+		}
+		if slices.Contains(priority[:i], identifierType) {
+			return false
+		}
+	}
+	return true
+}
+
+// withFallbacks returns the priority followed by the default identifier
+// types it leaves out, in their default order, so that users who have none
+// of the configured identifiers are still identified.
+func withFallbacks(priority []string) []string {
+	all := slices.Clone(priority)
+	for _, identifierType := range DefaultIdentifierPriority {
+		if !slices.Contains(all, identifierType) {
+			all = append(all, identifierType)
+		}
+	}
+	return all
+}
+
+// GetPreferredIdentification returns the first identification of the user
+// in the given priority order, falling back to the identifier types the
+// priority leaves out. For email addresses, phone numbers and web3 wallets
+// only the primary one is considered. It returns nil if the user has none
+// of them.
+func (s *Service) GetPreferredIdentification(ctx context.Context, exec database.Executor, user *model.User, priority []string) (*model.Identification, error) {
+	for _, identifierType := range withFallbacks(priority) {
+		var identification *model.Identification
+		var err error
+
+		switch identifierType {
+		case constants.ITEmailAddress:
+			identification, err = s.queryPrimaryIdentification(ctx, exec, user.PrimaryEmailAddressID.Ptr())
+		case constants.ITPhoneNumber:
+			identification, err = s.queryPrimaryIdentification(ctx, exec, user.PrimaryPhoneNumberID.Ptr())
+		case constants.ITWeb3Wallet:
+			identification, err = s.queryPrimaryIdentification(ctx, exec, user.PrimaryWeb3WalletID.Ptr())
+		case constants.ITUsername:
+			identification, err = s.GetUsernameIdentification(ctx, exec, user)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if identification != nil {
+			return identification, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) queryPrimaryIdentification(ctx context.Context, exec database.Executor, identificationID *string) (*model.Identification, error) {
+	if identificationID == nil {
+		return nil, nil
+	}
+	return s.identificationRepo.QueryByID(ctx, exec, *identificationID)
+}
@@ -0,0 +1,44 @@
+package user_profile
+
+import (
+	"testing"
+
+	"clerk/pkg/constants"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidIdentifierPriority(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		priority []string
+		valid    bool
+	}{
+		{priority: nil, valid: true},
+		{priority: DefaultIdentifierPriority, valid: true},
+		{priority: []string{constants.ITUsername, constants.ITEmailAddress}, valid: true},
+		{priority: []string{constants.ITUsername, constants.ITUsername}, valid: false},
+		{priority: []string{"oauth_google"}, valid: false},
+	} {
+		assert.Equal(t, tc.valid, IsValidIdentifierPriority(tc.priority), tc.priority)
+	}
+}
+
+func TestWithFallbacks(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, DefaultIdentifierPriority, withFallbacks(nil))
+	assert.Equal(t, DefaultIdentifierPriority, withFallbacks(DefaultIdentifierPriority))
+	assert.Equal(t,
+		[]string{constants.ITUsername, constants.ITPhoneNumber, constants.ITEmailAddress, constants.ITWeb3Wallet},
+		withFallbacks([]string{constants.ITUsername, constants.ITPhoneNumber}),
+	)
+
+	// the configured priority is left untouched
+	priority := make([]string, 1, 4)
+	priority[0] = constants.ITUsername
+	withFallbacks(priority)
+	assert.Equal(t, []string{constants.ITUsername}, priority)
+	assert.Empty(t, priority[:2][1])
+}
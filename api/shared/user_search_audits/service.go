@@ -0,0 +1,72 @@
+// Package user_search_audits keeps a trail of the searches support staff
+// make for users across the instances of an application, so that every
+// lookup of a user's personal information can be traced back to who made
+// it.
+package user_search_audits
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/repository"
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+)
+
+type Service struct {
+	clock clockwork.Clock
+
+	// repositories
+	userSearchAuditRepo *repository.UserSearchAudits
+}
+
+func NewService(clock clockwork.Clock) *Service {
+	return &Service{
+		clock:               clock,
+		userSearchAuditRepo: repository.NewUserSearchAudits(),
+	}
+}
+
+// RecordParams describe a search for users made by a support member.
+type RecordParams struct {
+	// ActorID is the support member who made the search.
+	ActorID       string
+	ApplicationID string
+	// IdentifierType is the type of the identifier users were searched by,
+	// e.g. "email_address".
+	IdentifierType string
+	// MaskedIdentifier is the identifier users were searched by, masked,
+	// so that the audit trail doesn't become a copy of personal
+	// information.
+	MaskedIdentifier string
+	// PIIRevealed is whether the personal information of the users found
+	// was returned in full.
+	PIIRevealed bool
+	ResultCount int
+}
+
+// Record persists the audit record of a search for users.
+func (s *Service) Record(ctx context.Context, exec database.Executor, params RecordParams) error {
+	audit := newAudit(params, s.clock.Now().UTC())
+	if err := s.userSearchAuditRepo.Insert(ctx, exec, audit); err != nil {
+		return fmt.Errorf("user_search_audits/record: inserting audit of search by %s in application %s: %w",
+			params.ActorID, params.ApplicationID, err)
+	}
+	return nil
+}
+
+func newAudit(params RecordParams, occurredAt time.Time) *model.UserSearchAudit {
+	return &model.UserSearchAudit{UserSearchAudit: &sqbmodel.UserSearchAudit{
+		ActorID:          params.ActorID,
+		ApplicationID:    params.ApplicationID,
+		IdentifierType:   params.IdentifierType,
+		MaskedIdentifier: params.MaskedIdentifier,
+		PiiRevealed:      params.PIIRevealed,
+		ResultCount:      params.ResultCount,
+		OccurredAt:       occurredAt,
+	}}
+}
@@ -0,0 +1,30 @@
+package user_search_audits
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAudit(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	audit := newAudit(RecordParams{
+		ActorID:          "user_support",
+		ApplicationID:    "app_1",
+		IdentifierType:   "email_address",
+		MaskedIdentifier: "j***@example.com",
+		PIIRevealed:      true,
+		ResultCount:      2,
+	}, now)
+
+	assert.Equal(t, "user_support", audit.ActorID)
+	assert.Equal(t, "app_1", audit.ApplicationID)
+	assert.Equal(t, "email_address", audit.IdentifierType)
+	assert.Equal(t, "j***@example.com", audit.MaskedIdentifier)
+	assert.True(t, audit.PiiRevealed)
+	assert.Equal(t, 2, audit.ResultCount)
+	assert.Equal(t, now, audit.OccurredAt)
+}
@@ -10,6 +10,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/model"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 )
 
@@ -19,10 +20,10 @@ type CreateService struct {
 	userRepo *repository.Users
 }
 
-func NewCreateService(clock clockwork.Clock) *CreateService {
+func NewCreateService(deps clerk.Deps) *CreateService {
 	return &CreateService{
-		clock:    clock,
-		userRepo: repository.NewUsers(),
+		clock:    deps.Clock(),
+		userRepo: deps.Repositories().Users,
 	}
 }
 
@@ -0,0 +1,219 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/constants"
+	cevents "clerk/pkg/events"
+	"clerk/pkg/jobs"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// MergeService moves everything a user owns over to another user of the
+// same instance. The user which is merged away is left empty and is
+// expected to be deleted by the caller.
+type MergeService struct {
+	identificationRepo *repository.Identification
+	orgMembershipRepo  *repository.OrganizationMembership
+	userRepo           *repository.Users
+}
+
+func NewMergeService(deps clerk.Deps) *MergeService {
+	return &MergeService{
+		identificationRepo: deps.Repositories().Identification,
+		orgMembershipRepo:  deps.Repositories().OrganizationMembership,
+		userRepo:           deps.Repositories().Users,
+	}
+}
+
+// MergePreview describes what will be moved from one user to another.
+type MergePreview struct {
+	EmailAddresses   []string
+	PhoneNumbers     []string
+	Web3Wallets      []string
+	ExternalAccounts []string
+	// OrganizationIDs contains the organizations the source user will join.
+	OrganizationIDs []string
+	// SkippedOrganizationIDs contains the organizations both users are members
+	// of. The membership of the user which is merged away is dropped.
+	SkippedOrganizationIDs []string
+}
+
+// Preview returns what a merge of user `from` into user `into` would move,
+// without changing anything.
+func (s *MergeService) Preview(ctx context.Context, exec database.Executor, into, from *model.User) (*MergePreview, error) {
+	identifications, err := s.identificationRepo.FindAllByUsers(ctx, exec, []string{from.ID})
+	if err != nil {
+		return nil, fmt.Errorf("users/merge: fetching identifications of user %s: %w", from.ID, err)
+	}
+
+	preview := &MergePreview{}
+	for _, ident := range identifications {
+		if !ident.Identifier.Valid {
+			continue
+		}
+		switch {
+		case ident.IsEmailAddress():
+			preview.EmailAddresses = append(preview.EmailAddresses, ident.Identifier.String)
+		case ident.IsPhoneNumber():
+			preview.PhoneNumbers = append(preview.PhoneNumbers, ident.Identifier.String)
+		case ident.Type == constants.ITWeb3Wallet:
+			preview.Web3Wallets = append(preview.Web3Wallets, ident.Identifier.String)
+		case ident.IsOAuth():
+			preview.ExternalAccounts = append(preview.ExternalAccounts, ident.Type)
+		}
+	}
+
+	memberships, err := s.orgMembershipRepo.FindAllByUser(ctx, exec, from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("users/merge: fetching organization memberships of user %s: %w", from.ID, err)
+	}
+	for _, membership := range memberships {
+		isMember, err := s.orgMembershipRepo.ExistsByOrganizationAndUser(ctx, exec, membership.OrganizationID, into.ID)
+		if err != nil {
+			return nil, fmt.Errorf("users/merge: checking membership of user %s in organization %s: %w", into.ID, membership.OrganizationID, err)
+		}
+		if isMember {
+			preview.SkippedOrganizationIDs = append(preview.SkippedOrganizationIDs, membership.OrganizationID)
+		} else {
+			preview.OrganizationIDs = append(preview.OrganizationIDs, membership.OrganizationID)
+		}
+	}
+
+	return preview, nil
+}
+
+// MergeResult describes what a merge moved from one user to another.
+type MergeResult struct {
+	IdentificationIDs []string
+	// ExternalAccountIDs contains the external accounts which moved along
+	// with their identifications.
+	ExternalAccountIDs        []string
+	OrganizationMembershipIDs []string
+	// SkippedOrganizationIDs contains the organizations both users were
+	// members of. The membership of the user which is merged away is dropped.
+	SkippedOrganizationIDs []string
+	// PrimaryIdentifiers contains the primary identifiers of `into` which
+	// were missing and were taken over from `from`.
+	PrimaryIdentifiers []string
+}
+
+// Merge moves the identifications, and thus the external accounts, and the
+// organization memberships of user `from` over to user `into`.
+// The primary identifiers of `into` are kept intact, the ones it lacks are
+// taken over from `from`. A username is only moved if `into` has none.
+func (s *MergeService) Merge(ctx context.Context, tx database.Tx, into, from *model.User) (*MergeResult, error) {
+	result := &MergeResult{}
+
+	identifications, err := s.identificationRepo.FindAllByUsers(ctx, tx, []string{from.ID})
+	if err != nil {
+		return nil, fmt.Errorf("users/merge: fetching identifications of user %s: %w", from.ID, err)
+	}
+	for _, ident := range identifications {
+		if ident.Type == constants.ITUsername && into.UsernameID.Valid {
+			// A user can only have one username. Leave it behind, it will
+			// be removed along with the user which is merged away.
+			continue
+		}
+
+		ident.UserID = null.StringFrom(into.ID)
+		if err := s.identificationRepo.UpdateUserID(ctx, tx, ident); err != nil {
+			return nil, fmt.Errorf("users/merge: moving identification %s to user %s: %w", ident.ID, into.ID, err)
+		}
+		result.IdentificationIDs = append(result.IdentificationIDs, ident.ID)
+		if ident.ExternalAccountID.Valid {
+			result.ExternalAccountIDs = append(result.ExternalAccountIDs, ident.ExternalAccountID.String)
+		}
+	}
+
+	if err := s.takeOverPrimaryIdentifiers(ctx, tx, into, from, result); err != nil {
+		return nil, err
+	}
+
+	memberships, err := s.orgMembershipRepo.FindAllByUser(ctx, tx, from.ID)
+	if err != nil {
+		return nil, fmt.Errorf("users/merge: fetching organization memberships of user %s: %w", from.ID, err)
+	}
+	for _, membership := range memberships {
+		isMember, err := s.orgMembershipRepo.ExistsByOrganizationAndUser(ctx, tx, membership.OrganizationID, into.ID)
+		if err != nil {
+			return nil, fmt.Errorf("users/merge: checking membership of user %s in organization %s: %w", into.ID, membership.OrganizationID, err)
+		}
+		if isMember {
+			// Leave the duplicate membership behind, it will be removed
+			// along with the user which is merged away.
+			result.SkippedOrganizationIDs = append(result.SkippedOrganizationIDs, membership.OrganizationID)
+			continue
+		}
+
+		membership.UserID = into.ID
+		if err := s.orgMembershipRepo.UpdateUserID(ctx, tx, membership); err != nil {
+			return nil, fmt.Errorf("users/merge: moving organization membership %s to user %s: %w", membership.ID, into.ID, err)
+		}
+		result.OrganizationMembershipIDs = append(result.OrganizationMembershipIDs, membership.ID)
+	}
+
+	return result, nil
+}
+
+func (s *MergeService) takeOverPrimaryIdentifiers(ctx context.Context, tx database.Tx, into, from *model.User, result *MergeResult) error {
+	if !into.PrimaryEmailAddressID.Valid && from.PrimaryEmailAddressID.Valid {
+		into.PrimaryEmailAddressID = from.PrimaryEmailAddressID
+		if err := s.userRepo.UpdatePrimaryEmailAddressID(ctx, tx, into); err != nil {
+			return fmt.Errorf("users/merge: updating primary email address of user %s: %w", into.ID, err)
+		}
+		result.PrimaryIdentifiers = append(result.PrimaryIdentifiers, constants.ITEmailAddress)
+	}
+	if !into.PrimaryPhoneNumberID.Valid && from.PrimaryPhoneNumberID.Valid {
+		into.PrimaryPhoneNumberID = from.PrimaryPhoneNumberID
+		if err := s.userRepo.UpdatePrimaryPhoneNumberID(ctx, tx, into); err != nil {
+			return fmt.Errorf("users/merge: updating primary phone number of user %s: %w", into.ID, err)
+		}
+		result.PrimaryIdentifiers = append(result.PrimaryIdentifiers, constants.ITPhoneNumber)
+	}
+	if !into.PrimaryWeb3WalletID.Valid && from.PrimaryWeb3WalletID.Valid {
+		into.PrimaryWeb3WalletID = from.PrimaryWeb3WalletID
+		if err := s.userRepo.UpdatePrimaryWeb3WalletID(ctx, tx, into); err != nil {
+			return fmt.Errorf("users/merge: updating primary web3 wallet of user %s: %w", into.ID, err)
+		}
+		result.PrimaryIdentifiers = append(result.PrimaryIdentifiers, constants.ITWeb3Wallet)
+	}
+	if !into.UsernameID.Valid && from.UsernameID.Valid {
+		into.UsernameID = from.UsernameID
+		if err := s.userRepo.UpdateUsernameID(ctx, tx, into); err != nil {
+			return fmt.Errorf("users/merge: updating username of user %s: %w", into.ID, err)
+		}
+		result.PrimaryIdentifiers = append(result.PrimaryIdentifiers, constants.ITUsername)
+	}
+	return nil
+}
+
+// DeleteMerged deletes the user which was merged away, within the
+// transaction of the merge, so that a failure leaves both users as they
+// were. Its sessions are deleted in the background once the transaction is
+// committed.
+func (s *Service) DeleteMerged(ctx context.Context, tx database.Tx, env *model.Env, user *model.User) error {
+	if err := s.purge(ctx, tx, env.Application, user); err != nil {
+		return err
+	}
+
+	deleted := serialize.DeletedUser(user)
+	if err := s.eventService.UserDeleted(ctx, tx, env.Instance, deleted); err != nil {
+		return fmt.Errorf("users/deleteMerged: send event %s for user %s: %w", cevents.EventTypes.UserDeleted, user.ID, err)
+	}
+
+	err := jobs.DeleteUserSessions(ctx, s.gueClient,
+		jobs.DeleteUserSessionsArgs{UserID: user.ID, InstanceID: env.Instance.ID},
+		jobs.WithTx(tx))
+	if err != nil {
+		return fmt.Errorf("users/deleteMerged: scheduling deletion of sessions of user %s: %w", user.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package users
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/shared/comms"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/rand"
+	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/utils/database"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// PrimaryEmailAddressChangeLifetime is for how long a requested primary
+// email address change can be confirmed.
+const PrimaryEmailAddressChangeLifetime = 24 * time.Hour
+
+// PrimaryEmailAddressChangeConfirmPath is the FAPI path which confirms a
+// primary email address change. The link to it is sent to the previous
+// primary email address.
+const PrimaryEmailAddressChangeConfirmPath = "/v1/primary_email_address_change/confirm"
+
+// RequiresPrimaryEmailAddressChangeConfirmation reports whether changing the
+// primary email address of the user must first be confirmed from the
+// current one. Users without a primary email address have nobody to
+// confirm with, so the change happens right away.
+func RequiresPrimaryEmailAddressChangeConfirmation(env *model.Env, user *model.User, emailAddressID string) bool {
+	return env.AuthConfig.ExperimentalSettings.ConfirmPrimaryEmailAddressChange &&
+		user.PrimaryEmailAddressID.Valid &&
+		user.PrimaryEmailAddressID.String != emailAddressID
+}
+
+// RequestPrimaryEmailAddressChange stores emailAddressID as the pending
+// primary email address of the user, and emails a confirmation link to the
+// current primary email address. The primary email address changes once the
+// link is followed, see ConfirmPrimaryEmailAddressChange.
+func (s *Service) RequestPrimaryEmailAddressChange(
+	ctx context.Context,
+	env *model.Env,
+	user *model.User,
+	emailAddressID string,
+	userSettings *usersettings.UserSettings,
+) (*model.User, apierror.Error) {
+	token, err := rand.Token()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	expireAt := s.clock.Now().UTC().Add(PrimaryEmailAddressChangeLifetime)
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		apiErr := s.validateUpdateForm(ctx, tx, user, &UpdateForm{PrimaryEmailAddressID: &emailAddressID}, env.Instance.ID, userSettings)
+		if apiErr != nil {
+			return true, apiErr
+		}
+
+		previousEmailAddress, err := s.userProfileService.GetPrimaryEmailAddress(ctx, tx, user)
+		if err != nil {
+			return true, err
+		}
+		newEmailAddress, err := s.identificationRepo.QueryByIDAndUser(ctx, tx, env.Instance.ID, emailAddressID, user.ID)
+		if err != nil {
+			return true, err
+		}
+
+		user.PendingPrimaryEmailAddressID = null.StringFrom(emailAddressID)
+		user.PendingPrimaryEmailAddressTokenDigest = null.StringFrom(primaryEmailAddressChangeTokenDigest(token))
+		user.PendingPrimaryEmailAddressExpireAt = null.TimeFrom(expireAt)
+		if err := s.userRepo.Update(ctx, tx, user, pendingPrimaryEmailAddressColumns...); err != nil {
+			return true, err
+		}
+
+		if previousEmailAddress == nil || newEmailAddress == nil {
+			return false, nil
+		}
+		err = s.commsService.SendPrimaryEmailAddressChangeRequestedEmail(ctx, tx, env, comms.EmailPrimaryEmailAddressChangeRequested{
+			PreviousEmailAddress: *previousEmailAddress,
+			NewEmailAddress:      newEmailAddress.Identifier.String,
+			ConfirmURL:           primaryEmailAddressChangeConfirmURL(env.Domain.FapiURL(), token),
+			ExpireAt:             expireAt,
+		})
+		if err != nil {
+			return true, fmt.Errorf("user/requestPrimaryEmailAddressChange: sending confirmation email (user=%s): %w", user.ID, err)
+		}
+		return false, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, apiErr
+		}
+		return nil, apierror.Unexpected(txErr)
+	}
+	return user, nil
+}
+
+// ConfirmPrimaryEmailAddressChange makes the pending primary email address
+// of the user the token was issued for their primary one.
+func (s *Service) ConfirmPrimaryEmailAddressChange(ctx context.Context, env *model.Env, token string) (*model.User, apierror.Error) {
+	user, err := s.userRepo.QueryByPendingPrimaryEmailAddressTokenDigest(ctx, s.db, env.Instance.ID, primaryEmailAddressChangeTokenDigest(token))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if user == nil || !user.PendingPrimaryEmailAddressID.Valid {
+		return nil, apierror.PrimaryEmailAddressChangeInvalid()
+	}
+
+	if s.clock.Now().UTC().After(user.PendingPrimaryEmailAddressExpireAt.Time) {
+		clearPendingPrimaryEmailAddress(user)
+		if err := s.userRepo.Update(ctx, s.db, user, pendingPrimaryEmailAddressColumns...); err != nil {
+			return nil, apierror.Unexpected(err)
+		}
+		return nil, apierror.PrimaryEmailAddressChangeExpired()
+	}
+
+	// Updating the primary email address also clears the pending one, see
+	// updateUserAndGetColumns.
+	pendingEmailAddressID := user.PendingPrimaryEmailAddressID.String
+	return s.Update(ctx, env, user.ID, &UpdateForm{
+		PrimaryEmailAddressID:     &pendingEmailAddressID,
+		PrimaryEmailAddressNotify: true,
+	}, env.Instance, usersettings.NewUserSettings(env.AuthConfig.UserSettings))
+}
+
+// CancelPrimaryEmailAddressChange discards the pending primary email
+// address of the user, if there's one.
+func (s *Service) CancelPrimaryEmailAddressChange(ctx context.Context, exec database.Executor, user *model.User) error {
+	if !user.PendingPrimaryEmailAddressID.Valid {
+		return nil
+	}
+	clearPendingPrimaryEmailAddress(user)
+	return s.userRepo.Update(ctx, exec, user, pendingPrimaryEmailAddressColumns...)
+}
+
+// pendingPrimaryEmailAddressColumns are the columns which hold the pending
+// primary email address change of a user.
+var pendingPrimaryEmailAddressColumns = []string{
+	sqbmodel.UserColumns.PendingPrimaryEmailAddressID,
+	sqbmodel.UserColumns.PendingPrimaryEmailAddressTokenDigest,
+	sqbmodel.UserColumns.PendingPrimaryEmailAddressExpireAt,
+}
+
+func clearPendingPrimaryEmailAddress(user *model.User) {
+	user.PendingPrimaryEmailAddressID = null.StringFromPtr(nil)
+	user.PendingPrimaryEmailAddressTokenDigest = null.StringFromPtr(nil)
+	user.PendingPrimaryEmailAddressExpireAt = null.TimeFromPtr(nil)
+}
+
+// primaryEmailAddressChangeTokenDigest is what's stored for a confirmation
+// token, so that a leaked database can't be used to confirm changes.
+func primaryEmailAddressChangeTokenDigest(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+func primaryEmailAddressChangeConfirmURL(fapiURL, token string) string {
+	query := url.Values{}
+	query.Set("token", token)
+	return fapiURL + PrimaryEmailAddressChangeConfirmPath + "?" + query.Encode()
+}
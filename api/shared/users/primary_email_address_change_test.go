@@ -0,0 +1,31 @@
+package users
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrimaryEmailAddressChangeConfirmURL(t *testing.T) {
+	t.Parallel()
+
+	confirmURL := primaryEmailAddressChangeConfirmURL("https://clerk.example.com", "a+b/c=")
+
+	parsed, err := url.Parse(confirmURL)
+	require.NoError(t, err)
+	assert.Equal(t, "clerk.example.com", parsed.Host)
+	assert.Equal(t, PrimaryEmailAddressChangeConfirmPath, parsed.Path)
+	assert.Equal(t, "a+b/c=", parsed.Query().Get("token"))
+}
+
+func TestPrimaryEmailAddressChangeTokenDigest(t *testing.T) {
+	t.Parallel()
+
+	digest := primaryEmailAddressChangeTokenDigest("token")
+	assert.Equal(t, digest, primaryEmailAddressChangeTokenDigest("token"))
+	assert.NotEqual(t, digest, primaryEmailAddressChangeTokenDigest("other"))
+	assert.NotContains(t, digest, "token")
+	assert.Len(t, digest, 64)
+}
@@ -18,8 +18,10 @@ import (
 	"clerk/api/shared/events"
 	"clerk/api/shared/identifications"
 	"clerk/api/shared/images"
+	"clerk/api/shared/password"
 	"clerk/api/shared/serializable"
 	"clerk/api/shared/sessions"
+	"clerk/api/shared/trusted_devices"
 	"clerk/api/shared/user_profile"
 	"clerk/api/shared/validators"
 	"clerk/model"
@@ -30,6 +32,7 @@ import (
 	"clerk/pkg/constants"
 	"clerk/pkg/ctx/environment"
 	cevents "clerk/pkg/events"
+	"clerk/pkg/externalapis/hibp"
 	"clerk/pkg/hash"
 	"clerk/pkg/jobs"
 	clerkjson "clerk/pkg/json"
@@ -53,9 +56,10 @@ import (
 )
 
 type Service struct {
-	clock     clockwork.Clock
-	db        database.Database
-	gueClient *gue.Client
+	clock      clockwork.Clock
+	db         database.Database
+	gueClient  *gue.Client
+	hibpClient hibp.Client
 
 	// services
 	applicationDeleter    *applications.Deleter
@@ -66,6 +70,7 @@ type Service struct {
 	imageService          *images.Service
 	sessionService        *sessions.Service
 	serializableService   *serializable.Service
+	trustedDeviceService  *trusted_devices.Service
 	userProfileService    *user_profile.Service
 	clientDataService     *client_data.Service
 
@@ -74,6 +79,7 @@ type Service struct {
 	backupCodeRepo     *repository.BackupCode
 	identificationRepo *repository.Identification
 	imagesRepo         *repository.Images
+	orgMembershipRepo  *repository.OrganizationMembership
 	signInRepo         *repository.SignIn
 	totpRepo           *repository.TOTP
 	userRepo           *repository.Users
@@ -84,23 +90,26 @@ func NewService(deps clerk.Deps) *Service {
 		clock:                 deps.Clock(),
 		db:                    deps.DB(),
 		gueClient:             deps.GueClient(),
+		hibpClient:            hibp.NewClient(),
 		applicationDeleter:    applications.NewDeleter(deps),
 		commsService:          comms.NewService(deps),
 		eventService:          events.NewService(deps),
 		identificationService: identifications.NewService(deps),
 		validatorService:      validators.NewService(),
-		imageService:          images.NewService(deps.StorageClient()),
+		imageService:          images.NewService(deps.Clock(), deps.StorageClient()),
 		sessionService:        sessions.NewService(deps),
 		serializableService:   serializable.NewService(deps.Clock()),
+		trustedDeviceService:  trusted_devices.NewService(deps),
 		userProfileService:    user_profile.NewService(deps.Clock()),
 		clientDataService:     client_data.NewService(deps),
-		applicationRepo:       repository.NewApplications(),
-		backupCodeRepo:        repository.NewBackupCode(),
-		identificationRepo:    repository.NewIdentification(),
-		imagesRepo:            repository.NewImages(),
-		signInRepo:            repository.NewSignIn(),
-		totpRepo:              repository.NewTOTP(),
-		userRepo:              repository.NewUsers(),
+		applicationRepo:       deps.Repositories().Applications,
+		backupCodeRepo:        deps.Repositories().BackupCode,
+		identificationRepo:    deps.Repositories().Identification,
+		imagesRepo:            deps.Repositories().Images,
+		orgMembershipRepo:     deps.Repositories().OrganizationMembership,
+		signInRepo:            deps.Repositories().SignIn,
+		totpRepo:              deps.Repositories().TOTP,
+		userRepo:              deps.Repositories().Users,
 	}
 }
 
@@ -184,6 +193,13 @@ func (s *Service) Update(
 			return true, apiErr
 		}
 
+		// Keep the user as it was before the update, so that we can tell
+		// which of its fields actually changed.
+		previousUser, err := s.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		var updateCols []string
 
 		identification, err := s.updateUsername(ctx, tx, updateForm.Username, user, instance, userSettings)
@@ -217,6 +233,14 @@ func (s *Service) Update(
 			return true, err
 		}
 
+		// Devices trusted with the previous password can no longer skip the
+		// second factor.
+		if updateForm.PasswordDigest != nil {
+			if err := s.trustedDeviceService.RevokeAllForUser(ctx, tx, instance.ID, updatedUser.ID); err != nil {
+				return true, err
+			}
+		}
+
 		// Password was updated and we need to revoke all other sessions for the user.
 		if updateForm.PasswordDigest != nil && updateForm.SignOutOfOtherSessions {
 			if err := s.sessionService.RevokeAllForUserID(ctx, instance.ID, updatedUser.ID); err != nil {
@@ -224,7 +248,7 @@ func (s *Service) Update(
 			}
 		}
 
-		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, updatedUser)
+		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, updatedUser)
 		if err != nil {
 			return true, fmt.Errorf("user/update: send user updated event for (%+v, %+v): %w", updatedUser, instance.ID, err)
 		}
@@ -251,13 +275,29 @@ func (s *Service) Update(
 	return updatedUser, nil
 }
 
-// SendUserUpdatedEvent sends a user.updated event
-// It returns the serialized user payload so that the caller can use it in the response if necessary
+// SnapshotUser captures the user payload as it is before an update, to
+// pass to SendUserUpdatedEvent once the update is done.
+func (s *Service) SnapshotUser(
+	ctx context.Context,
+	exec database.Executor,
+	userSettings *usersettings.UserSettings,
+	user *model.User,
+) (events.UserSnapshot, error) {
+	return s.serializableService.SnapshotUser(ctx, exec, userSettings, user)
+}
+
+// SendUserUpdatedEvent sends a user.updated event, which carries the fields
+// of the user that changed since previous, as taken by SnapshotUser before
+// the update. No event is sent if nothing about the user actually changed.
+//
+// It returns the serialized user payload so that the caller can use it in
+// the response if necessary.
 func (s *Service) SendUserUpdatedEvent(
 	ctx context.Context,
 	exec database.Executor,
 	instance *model.Instance,
 	userSettings *usersettings.UserSettings,
+	previous events.UserSnapshot,
 	user *model.User,
 ) (*model.UserSerializable, error) {
 	userSerializable, err := s.serializableService.ConvertUser(ctx, exec, userSettings, user)
@@ -265,10 +305,9 @@ func (s *Service) SendUserUpdatedEvent(
 		return nil, fmt.Errorf("sendUserUpdatedEvent: serializing user %+v: %w", user, err)
 	}
 
-	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable)); err != nil {
+	if err = s.eventService.UserUpdated(ctx, exec, instance, serialize.UserToServerAPI(ctx, userSerializable), previous); err != nil {
 		return nil, fmt.Errorf("sendUserUpdatedEvent: send user updated event for user %s: %w", user.ID, err)
 	}
-
 	return userSerializable, nil
 }
 
@@ -293,6 +332,13 @@ func (s *Service) updateUserAndGetColumns(user *model.User, updateForm *UpdateFo
 	if updateForm.PrimaryEmailAddressID != nil {
 		user.PrimaryEmailAddressID = null.StringFromPtr(updateForm.PrimaryEmailAddressID)
 		updateCols = append(updateCols, sqbmodel.UserColumns.PrimaryEmailAddressID)
+
+		// a primary email address change which is still pending is
+		// superseded by this one
+		if user.PendingPrimaryEmailAddressID.Valid {
+			clearPendingPrimaryEmailAddress(user)
+			updateCols = append(updateCols, pendingPrimaryEmailAddressColumns...)
+		}
 	}
 
 	if updateForm.Username.IsSet {
@@ -472,6 +518,9 @@ func (s *Service) validateUpdateForm(
 	if updateForm.Password != nil {
 		if !updateForm.SkipPasswordChecks {
 			apiErr := validate.Password(ctx, *updateForm.Password, param.Password.Name, userSettings.PasswordSettings)
+			if apiErr == nil {
+				apiErr = password.CheckPwned(ctx, s.hibpClient, userSettings.PasswordSettings, param.Password.Name, *updateForm.Password)
+			}
 			if apiErr != nil {
 				formErrs = apierror.Combine(formErrs, apiErr)
 			}
@@ -545,10 +594,15 @@ type UpdateProfileImageParams struct {
 	Filename string
 	Data     io.ReadCloser
 	UserID   string
+
+	// UploadedImageID is the image the user uploaded directly to storage,
+	// if they used a signed upload URL instead of sending the Data.
+	UploadedImageID string
 }
 
 // UpdateProfileImage updates the user with userID profile
-// image with the provided file.
+// image with the provided file, or with the image they uploaded
+// directly to storage.
 // It will save the image in the database and associate it
 // with the user.
 func (s *Service) UpdateProfileImage(
@@ -560,17 +614,25 @@ func (s *Service) UpdateProfileImage(
 	var img *model.Image
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		var apiErr apierror.Error
-		img, apiErr = s.imageService.Create(
-			ctx,
-			tx,
-			images.ImageParams{
-				Filename:           params.Filename,
-				Prefix:             images.PrefixUploaded,
-				Src:                params.Data,
+		if params.UploadedImageID != "" {
+			img, apiErr = s.imageService.CompleteUpload(ctx, tx, images.CompleteUploadParams{
+				ImageID:            params.UploadedImageID,
 				UploaderUserID:     params.UserID,
 				UsedByResourceType: clerkstrings.ToPtr(constants.UserResource),
-			},
-		)
+			})
+		} else {
+			img, apiErr = s.imageService.Create(
+				ctx,
+				tx,
+				images.ImageParams{
+					Filename:           params.Filename,
+					Prefix:             images.PrefixUploaded,
+					Src:                params.Data,
+					UploaderUserID:     params.UserID,
+					UsedByResourceType: clerkstrings.ToPtr(constants.UserResource),
+				},
+			)
+		}
 		if apiErr != nil {
 			return true, apiErr
 		}
@@ -580,6 +642,11 @@ func (s *Service) UpdateProfileImage(
 			return true, err
 		}
 
+		previousUser, err := s.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		if user.ProfileImagePublicURL.Valid {
 			err := s.EnqueueCleanupImageJob(ctx, tx, user.ProfileImagePublicURL.String)
 			if err != nil {
@@ -593,7 +660,7 @@ func (s *Service) UpdateProfileImage(
 			return true, err
 		}
 
-		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, user)
+		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user)
 		if err != nil {
 			return true, fmt.Errorf("user/updateProfileImage: send user updated event for (%+v, %+v): %w",
 				user, instance, err)
@@ -611,14 +678,66 @@ func (s *Service) UpdateProfileImage(
 	return serialize.Image(img), nil
 }
 
-// Delete deletes the given user.
+// CreateProfileImageUpload returns a signed URL the user can upload their new
+// profile image to, directly to storage. Once uploaded, the image is set with
+// UpdateProfileImage.
+func (s *Service) CreateProfileImageUpload(ctx context.Context, userID, filename, contentType string) (*serialize.ImageUploadResponse, apierror.Error) {
+	upload, apiErr := s.imageService.CreateUpload(ctx, s.db, images.UploadParams{
+		Filename:           filename,
+		ContentType:        contentType,
+		UploaderUserID:     userID,
+		UsedByResourceType: clerkstrings.ToPtr(constants.UserResource),
+	})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.ImageUpload(upload.Image, upload.URL, upload.ExpiresAt), nil
+}
+
+// Delete deletes the given user. If the instance retains deleted users, the
+// user is only marked as deleted and can be restored until its retention
+// window expires, after which it's purged. Otherwise, the user is deleted
+// permanently.
 func (s *Service) Delete(ctx context.Context, env *model.Env, userID string) (*serialize.DeletedObjectResponse, apierror.Error) {
-	// Delete all sessions
-	if err := s.sessionService.DeleteUserSessions(ctx, env.Instance.ID, userID); err != nil {
-		return nil, apierror.Unexpected(err)
+	deleted, _, apiErr := s.delete(ctx, env, userID, env.Instance.UserDeletionRetentionDays, false)
+	return deleted, apiErr
+}
+
+// DeleteDryRun goes through the deletion of the given user without
+// deleting anything, and returns what would have been deleted.
+func (s *Service) DeleteDryRun(ctx context.Context, env *model.Env, userID string) (*serialize.DryRunResponse, apierror.Error) {
+	deleted, affected, apiErr := s.delete(ctx, env, userID, env.Instance.UserDeletionRetentionDays, true)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return serialize.DryRun(deleted, affected), nil
+}
+
+// DeletePermanently deletes the given user, regardless of the retention
+// window of the instance.
+func (s *Service) DeletePermanently(ctx context.Context, env *model.Env, userID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	deleted, _, apiErr := s.delete(ctx, env, userID, 0, false)
+	return deleted, apiErr
+}
+
+// delete deletes the given user and returns the number of resources that
+// are deleted along with it. On dry runs, the changes are rolled back and
+// the sessions of the user are left as they are.
+func (s *Service) delete(ctx context.Context, env *model.Env, userID string, retentionDays int, dryRun bool) (*serialize.DeletedObjectResponse, map[string]int64, apierror.Error) {
+	// Sessions live outside the database, so they can't be rolled back
+	sessions, err := s.clientDataService.FindAllUserSessions(ctx, env.Instance.ID, userID, nil)
+	if err != nil {
+		return nil, nil, apierror.Unexpected(err)
+	}
+	if !dryRun {
+		// Delete all sessions
+		if err := s.sessionService.DeleteUserSessions(ctx, env.Instance.ID, userID); err != nil {
+			return nil, nil, apierror.Unexpected(err)
+		}
 	}
 
 	var deleted *serialize.DeletedObjectResponse
+	affected := map[string]int64{"sessions": int64(len(sessions))}
 
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
 		user, err := s.userRepo.QueryByID(ctx, tx, userID)
@@ -629,34 +748,23 @@ func (s *Service) Delete(ctx context.Context, env *model.Env, userID string) (*s
 			return true, apierror.UserNotFound(userID)
 		}
 
-		if env.Application.Type == string(constants.RTSystem) {
-			// Schedule a soft-delete for all applications that the user being deleted owns.
-			// We schedule the soft-delete instead of doing it in place, because soft-deletion also
-			// involves Stripe cancellation, which is an action that cannot be reverted, if the
-			// transaction fails.
-			err := s.applicationDeleter.ScheduleSoftDeleteOfOwnedApplications(ctx, tx, user.ID, constants.UserResource)
-			if err != nil {
-				return true, err
-			}
-		}
-
-		rowsDeleted, err := s.userRepo.DeleteByID(ctx, tx, user.ID)
-		if err != nil {
-			return true, fmt.Errorf("shared/users: delete user %s: %w", user.ID, err)
-		}
-		if rowsDeleted == 0 {
-			return true, apierror.UserNotFound(userID)
+		if err := s.countDeletedWithUser(ctx, tx, user.ID, affected); err != nil {
+			return true, err
 		}
 
-		deleted = serialize.DeletedObject(user.ID, serialize.UserObjectName)
-
-		if user.ProfileImagePublicURL.Valid {
-			err := s.EnqueueCleanupImageJob(ctx, tx, user.ProfileImagePublicURL.String)
-			if err != nil {
-				return true, apierror.Unexpected(err)
+		if retentionDays > 0 {
+			now := s.clock.Now().UTC()
+			user.DeletedAt = null.TimeFrom(now)
+			user.PurgeAt = null.TimeFrom(now.AddDate(0, 0, retentionDays))
+			if err := s.userRepo.UpdateDeletedAt(ctx, tx, user); err != nil {
+				return true, fmt.Errorf("shared/users: mark user %s as deleted: %w", user.ID, err)
 			}
+		} else if err := s.purge(ctx, tx, env.Application, user); err != nil {
+			return true, err
 		}
 
+		deleted = serialize.DeletedUser(user)
+
 		if err := s.eventService.UserDeleted(ctx, tx, env.Instance, deleted); err != nil {
 			return true, fmt.Errorf("shared/users: send event %s for instance %+v with payload %+v: %w",
 				cevents.EventTypes.UserDeleted, env.Instance.ID, deleted, err)
@@ -671,6 +779,120 @@ func (s *Service) Delete(ctx context.Context, env *model.Env, userID string) (*s
 			return true, err
 		}
 
+		return dryRun, nil
+	})
+	if txErr != nil {
+		if apiErr, isAPIErr := apierror.As(txErr); isAPIErr {
+			return nil, nil, apiErr
+		}
+		return nil, nil, apierror.Unexpected(txErr)
+	}
+
+	return deleted, affected, nil
+}
+
+// countDeletedWithUser adds the number of resources which are deleted
+// along with the user to affected.
+func (s *Service) countDeletedWithUser(ctx context.Context, exec database.Executor, userID string, affected map[string]int64) error {
+	identifications, err := s.identificationRepo.FindAllByUsers(ctx, exec, []string{userID})
+	if err != nil {
+		return fmt.Errorf("shared/users: find identifications of user %s: %w", userID, err)
+	}
+	affected["identifications"] = int64(len(identifications))
+
+	memberships, err := s.orgMembershipRepo.CountByUser(ctx, exec, userID)
+	if err != nil {
+		return fmt.Errorf("shared/users: count organization memberships of user %s: %w", userID, err)
+	}
+	affected["organization_memberships"] = memberships
+	return nil
+}
+
+// purge deletes the given user permanently, along with the resources it owns.
+func (s *Service) purge(ctx context.Context, tx database.Tx, application *model.Application, user *model.User) error {
+	if application.Type == string(constants.RTSystem) {
+		// Schedule a soft-delete for all applications that the user being deleted owns.
+		// We schedule the soft-delete instead of doing it in place, because soft-deletion also
+		// involves Stripe cancellation, which is an action that cannot be reverted, if the
+		// transaction fails.
+		err := s.applicationDeleter.ScheduleSoftDeleteOfOwnedApplications(ctx, tx, user.ID, constants.UserResource)
+		if err != nil {
+			return err
+		}
+	}
+
+	rowsDeleted, err := s.userRepo.DeleteByID(ctx, tx, user.ID)
+	if err != nil {
+		return fmt.Errorf("shared/users: delete user %s: %w", user.ID, err)
+	}
+	if rowsDeleted == 0 {
+		return apierror.UserNotFound(user.ID)
+	}
+
+	if user.ProfileImagePublicURL.Valid {
+		err := s.EnqueueCleanupImageJob(ctx, tx, user.ProfileImagePublicURL.String)
+		if err != nil {
+			return apierror.Unexpected(err)
+		}
+	}
+	return nil
+}
+
+// Purge permanently deletes a deleted user, once its retention window has
+// expired. Users which were restored or purged in the meantime are skipped.
+func (s *Service) Purge(ctx context.Context, userID string) error {
+	return s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		user, err := s.userRepo.QueryDeletedByIDForUpdate(ctx, tx, userID)
+		if err != nil {
+			return true, fmt.Errorf("shared/users: query deleted user by id %s: %w", userID, err)
+		}
+		if user == nil || user.PurgeAt.Time.After(s.clock.Now().UTC()) {
+			return false, nil
+		}
+
+		application, err := s.applicationRepo.FindByInstanceID(ctx, tx, user.InstanceID)
+		if err != nil {
+			return true, fmt.Errorf("shared/users: find application of instance %s: %w", user.InstanceID, err)
+		}
+
+		if err := s.purge(ctx, tx, application, user); err != nil {
+			return true, err
+		}
+		return false, nil
+	})
+}
+
+// Restore restores a deleted user which hasn't been purged yet. The user
+// comes back as it was, except for its sessions, which were revoked when it
+// was deleted.
+func (s *Service) Restore(ctx context.Context, env *model.Env, userID string) (*model.UserSerializable, apierror.Error) {
+	userSettings := usersettings.NewUserSettings(env.AuthConfig.UserSettings)
+
+	var userSerializable *model.UserSerializable
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		user, err := s.userRepo.QueryDeletedByIDAndInstanceForUpdate(ctx, tx, userID, env.Instance.ID)
+		if err != nil {
+			return true, err
+		}
+		if user == nil {
+			return true, apierror.UserNotFound(userID)
+		}
+
+		previousUser, err := s.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
+		user.DeletedAt = null.TimeFromPtr(nil)
+		user.PurgeAt = null.TimeFromPtr(nil)
+		if err := s.userRepo.UpdateDeletedAt(ctx, tx, user); err != nil {
+			return true, err
+		}
+
+		userSerializable, err = s.SendUserUpdatedEvent(ctx, tx, env.Instance, userSettings, previousUser, user)
+		if err != nil {
+			return true, err
+		}
 		return false, nil
 	})
 	if txErr != nil {
@@ -680,7 +902,7 @@ func (s *Service) Delete(ctx context.Context, env *model.Env, userID string) (*s
 		return nil, apierror.Unexpected(txErr)
 	}
 
-	return deleted, nil
+	return userSerializable, nil
 }
 
 // DeleteProfileImage clears the users profile_image_url.
@@ -709,6 +931,11 @@ func (s *Service) DeleteProfileImage(ctx context.Context, userID string) (*seria
 	}
 
 	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		previousUser, err := s.SnapshotUser(ctx, tx, userSettings, user)
+		if err != nil {
+			return true, err
+		}
+
 		err = s.EnqueueCleanupImageJob(ctx, tx, user.ProfileImagePublicURL.String)
 		if err != nil {
 			return true, err
@@ -720,7 +947,7 @@ func (s *Service) DeleteProfileImage(ctx context.Context, userID string) (*seria
 			return true, err
 		}
 
-		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, user)
+		_, err = s.SendUserUpdatedEvent(ctx, tx, instance, userSettings, previousUser, user)
 		if err != nil {
 			return true, fmt.Errorf("user/deleteProfileImage: send user updated event for (%+v, %+v): %w", user, instance.ID, err)
 		}
@@ -7,6 +7,7 @@ import (
 	"clerk/api/apierror"
 	"clerk/model"
 	"clerk/pkg/constants"
+	"clerk/pkg/web3"
 	"clerk/repository"
 	"clerk/utils/database"
 	"clerk/utils/param"
@@ -77,8 +78,8 @@ func (s *Service) ValidateUsername(ctx context.Context, exec database.Executor,
 
 // ValidateWeb3Wallet checks whether the given web3 wallet address is valid and unique in the context of the given instance
 func (s *Service) ValidateWeb3Wallet(ctx context.Context, exec database.Executor, web3Wallet string, instanceID string) (apierror.Error, error) {
-	if err := validate.Web3Wallet(web3Wallet, param.Web3Wallet.Name); err != nil {
-		return err, nil
+	if _, ok := web3.ChainForAddress(web3Wallet); !ok {
+		return apierror.FormInvalidWeb3WalletAddress(param.Web3Wallet.Name), nil
 	}
 
 	return s.validateUniqueness(ctx, exec, instanceID, web3Wallet, constants.ITWeb3Wallet, false, param.Web3Wallet.Name)
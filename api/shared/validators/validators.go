@@ -2,9 +2,13 @@ package validators
 
 import (
 	"clerk/api/apierror"
+	"clerk/api/shared/custom_fields"
+	"clerk/api/shared/user_profile"
 	"clerk/model"
+	"clerk/pkg/cenv"
 	"clerk/pkg/constants"
 	usersettings "clerk/pkg/usersettings/clerk"
+	"clerk/pkg/usersettings/clerk/names"
 )
 
 // ValidateEnhancedEmailDeliverability returns an error if enhanced email
@@ -32,3 +36,47 @@ func ValidateCAPTCHASetting(instance *model.Instance, settings *usersettings.Use
 
 	return nil
 }
+
+// ValidateLegalConsentSetting returns an error if legal consent is required
+// during sign-up, but there is no document version for users to accept.
+func ValidateLegalConsentSetting(settings *usersettings.UserSettings) apierror.Error {
+	if settings.SignUp.LegalConsent.Enabled && settings.SignUp.LegalConsent.Version == "" {
+		return apierror.InvalidUserSettings()
+	}
+	return nil
+}
+
+// ValidateSignUpCustomFields returns an error if the schema of the custom
+// fields collected during sign-up is invalid.
+func ValidateSignUpCustomFields(settings *usersettings.UserSettings) apierror.Error {
+	if err := custom_fields.ValidateSchema(custom_fields.Fields(settings)); err != nil {
+		return apierror.InvalidUserSettings()
+	}
+	return nil
+}
+
+// ValidateIdentifierPriority returns an error if the identifier priority
+// contains unknown identifier types or lists any of them more than once.
+func ValidateIdentifierPriority(settings *usersettings.UserSettings) apierror.Error {
+	if !user_profile.IsValidIdentifierPriority(settings.IdentifierPriority) {
+		return apierror.InvalidUserSettings()
+	}
+	return nil
+}
+
+// ValidatePasskeySetting returns an error if passkeys are enabled for an
+// instance which doesn't have access to them, or if they are required
+// during sign-up without being usable to sign in.
+func ValidatePasskeySetting(instance *model.Instance, settings *usersettings.UserSettings) apierror.Error {
+	passkey := settings.GetAttribute(names.Passkey).Base()
+	if !passkey.Enabled {
+		return nil
+	}
+	if !cenv.ResourceHasAccess(cenv.FlagAllowPasskeysInstanceIDs, instance.ID) {
+		return apierror.FeatureNotEnabled()
+	}
+	if passkey.Required && !passkey.UsedForFirstFactor {
+		return apierror.InvalidUserSettings()
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/jobs"
+	pkgwebhooks "clerk/pkg/webhooks"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// The statuses of a native webhook delivery.
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusSucceeded = "succeeded"
+	DeliveryStatusFailed    = "failed"
+)
+
+// UsesNativeDelivery returns whether the instance delivers its webhooks
+// natively instead of through Svix.
+func UsesNativeDelivery(instance *model.Instance) bool {
+	return instance.WebhookProvider == pkgwebhooks.ProviderNative
+}
+
+// Deliverer delivers the webhooks of the instances which use native delivery.
+// Every event results in one delivery per subscribed endpoint, which is
+// attempted by a job and retried with exponential backoff. Deliveries which
+// run out of attempts are moved to the dead-letter table, from where they can
+// be replayed.
+type Deliverer struct {
+	clock     clockwork.Clock
+	db        database.Database
+	gueClient *gue.Client
+	client    *pkgwebhooks.Client
+
+	// repositories
+	webhookDeadLetterRepo *repository.WebhookDeadLetters
+	webhookDeliveryRepo   *repository.WebhookDeliveries
+	webhookEndpointRepo   *repository.WebhookEndpoints
+}
+
+func NewDeliverer(deps clerk.Deps) *Deliverer {
+	return &Deliverer{
+		clock:                 deps.Clock(),
+		db:                    deps.DB(),
+		gueClient:             deps.GueClient(),
+		client:                pkgwebhooks.NewClient(),
+		webhookDeadLetterRepo: deps.Repositories().WebhookDeadLetters,
+		webhookDeliveryRepo:   deps.Repositories().WebhookDeliveries,
+		webhookEndpointRepo:   deps.Repositories().WebhookEndpoints,
+	}
+}
+
+// Enqueue creates a delivery of the event for every enabled endpoint which
+// subscribes to its type, and schedules their first attempt. Events are fanned
+// out to the instance-level endpoints and, for events of an organization, to
+// the endpoints of that organization as well.
+func (d *Deliverer) Enqueue(ctx context.Context, exec database.Executor, instance *model.Instance, organizationID *string, eventID, eventType string, payload []byte) error {
+	endpoints, err := d.webhookEndpointRepo.FindAllEnabledByInstanceAndEventType(ctx, exec, instance.ID, eventType)
+	if err != nil {
+		return fmt.Errorf("webhooks/enqueue: finding endpoints of instance %s for %s: %w", instance.ID, eventType, err)
+	}
+	// organization endpoints are only subscribed to the events of their own
+	// organization, which are handled below
+	endpoints = slices.DeleteFunc(endpoints, func(endpoint *model.WebhookEndpoint) bool {
+		return endpoint.OrganizationID.Valid
+	})
+
+	if organizationID != nil {
+		organizationEndpoints, err := d.webhookEndpointRepo.FindAllEnabledByInstanceAndOrganizationAndEventType(ctx, exec, instance.ID, *organizationID, eventType)
+		if err != nil {
+			return fmt.Errorf("webhooks/enqueue: finding endpoints of organization %s for %s: %w", *organizationID, eventType, err)
+		}
+		endpoints = append(endpoints, organizationEndpoints...)
+	}
+
+	for _, endpoint := range endpoints {
+		delivery := &model.WebhookDelivery{WebhookDelivery: &sqbmodel.WebhookDelivery{
+			InstanceID: instance.ID,
+			EndpointID: endpoint.ID,
+			EventID:    eventID,
+			EventType:  eventType,
+			Payload:    payload,
+			Status:     DeliveryStatusPending,
+		}}
+		if err := d.insertAndSchedule(ctx, exec, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Attempt delivers the given delivery to its endpoint. It's invoked by the
+// job scheduled for every attempt. Failed attempts are retried until the
+// delivery runs out of attempts, at which point it's dead-lettered.
+func (d *Deliverer) Attempt(ctx context.Context, deliveryID string) error {
+	delivery, err := d.webhookDeliveryRepo.QueryByID(ctx, d.db, deliveryID)
+	if err != nil {
+		return fmt.Errorf("webhooks/attempt: fetching delivery %s: %w", deliveryID, err)
+	}
+	if delivery == nil || delivery.Status != DeliveryStatusPending {
+		// nothing left to do, e.g. the job was retried after a success
+		return nil
+	}
+
+	endpoint, err := d.webhookEndpointRepo.QueryByIDAndInstance(ctx, d.db, delivery.EndpointID, delivery.InstanceID)
+	if err != nil {
+		return fmt.Errorf("webhooks/attempt: fetching endpoint %s: %w", delivery.EndpointID, err)
+	}
+	if endpoint == nil || endpoint.Disabled {
+		// the endpoint was removed or disabled after the delivery was created
+		_, err := d.webhookDeliveryRepo.DeleteByID(ctx, d.db, delivery.ID)
+		return err
+	}
+
+	result, deliveryErr := d.client.Deliver(ctx, endpoint.URL, endpoint.Secret, pkgwebhooks.Message{
+		ID:        delivery.EventID,
+		Timestamp: d.clock.Now(),
+		Payload:   delivery.Payload,
+	})
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = null.TimeFrom(d.clock.Now().UTC())
+	if result != nil {
+		delivery.LastStatusCode = null.IntFrom(result.StatusCode)
+		delivery.LastResponse = null.StringFrom(result.Response)
+	}
+	columns := []string{
+		sqbmodel.WebhookDeliveryColumns.Attempts,
+		sqbmodel.WebhookDeliveryColumns.LastAttemptAt,
+		sqbmodel.WebhookDeliveryColumns.LastStatusCode,
+		sqbmodel.WebhookDeliveryColumns.LastResponse,
+		sqbmodel.WebhookDeliveryColumns.Status,
+	}
+
+	if deliveryErr == nil {
+		delivery.Status = DeliveryStatusSucceeded
+		return d.webhookDeliveryRepo.Update(ctx, d.db, delivery, columns...)
+	}
+	log.Warning(ctx, "webhooks/attempt: attempt %d of delivery %s failed: %s", delivery.Attempts, delivery.ID, deliveryErr)
+
+	return d.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		nextAttemptAt, retry := pkgwebhooks.NextAttemptAt(delivery.Attempts, d.clock.Now())
+		if retry {
+			if err := d.webhookDeliveryRepo.Update(ctx, tx, delivery, columns...); err != nil {
+				return true, err
+			}
+			err := d.schedule(ctx, tx, delivery, &nextAttemptAt)
+			return err != nil, err
+		}
+
+		delivery.Status = DeliveryStatusFailed
+		if err := d.webhookDeliveryRepo.Update(ctx, tx, delivery, columns...); err != nil {
+			return true, err
+		}
+		err := d.webhookDeadLetterRepo.Insert(ctx, tx, &model.WebhookDeadLetter{WebhookDeadLetter: &sqbmodel.WebhookDeadLetter{
+			InstanceID:     delivery.InstanceID,
+			DeliveryID:     delivery.ID,
+			EndpointID:     delivery.EndpointID,
+			EventID:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Payload:        delivery.Payload,
+			Attempts:       delivery.Attempts,
+			LastStatusCode: delivery.LastStatusCode,
+			LastResponse:   delivery.LastResponse,
+		}})
+		return err != nil, err
+	})
+}
+
+// Replay creates a new delivery out of a dead-lettered one and schedules it,
+// removing it from the dead-letter table.
+func (d *Deliverer) Replay(ctx context.Context, tx database.Tx, deadLetter *model.WebhookDeadLetter) (*model.WebhookDelivery, error) {
+	delivery := &model.WebhookDelivery{WebhookDelivery: &sqbmodel.WebhookDelivery{
+		InstanceID: deadLetter.InstanceID,
+		EndpointID: deadLetter.EndpointID,
+		EventID:    deadLetter.EventID,
+		EventType:  deadLetter.EventType,
+		Payload:    deadLetter.Payload,
+		Status:     DeliveryStatusPending,
+	}}
+	if err := d.insertAndSchedule(ctx, tx, delivery); err != nil {
+		return nil, err
+	}
+
+	if _, err := d.webhookDeadLetterRepo.DeleteByID(ctx, tx, deadLetter.ID); err != nil {
+		return nil, fmt.Errorf("webhooks/replay: deleting dead letter %s: %w", deadLetter.ID, err)
+	}
+	return delivery, nil
+}
+
+func (d *Deliverer) insertAndSchedule(ctx context.Context, exec database.Executor, delivery *model.WebhookDelivery) error {
+	if err := d.webhookDeliveryRepo.Insert(ctx, exec, delivery); err != nil {
+		return fmt.Errorf("webhooks: creating delivery of %s to endpoint %s: %w", delivery.EventID, delivery.EndpointID, err)
+	}
+	return d.schedule(ctx, exec, delivery, nil)
+}
+
+// schedule enqueues the job which attempts the delivery, at the given time
+// or as soon as possible if it's nil.
+func (d *Deliverer) schedule(ctx context.Context, exec database.Executor, delivery *model.WebhookDelivery, runAt *time.Time) error {
+	opts := []jobs.JobOptionFunc{jobs.WithTxIfApplicable(exec)}
+	if runAt != nil {
+		opts = append(opts, jobs.WithRunAt(runAt))
+	}
+
+	err := jobs.DeliverWebhook(ctx, d.gueClient, jobs.DeliverWebhookArgs{DeliveryID: delivery.ID}, opts...)
+	if err != nil {
+		return fmt.Errorf("webhooks: scheduling delivery %s: %w", delivery.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+	"clerk/pkg/events"
+	"clerk/pkg/externalapis/svix"
+	"clerk/pkg/outbound"
+	"clerk/pkg/rand"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	endpointChallengeTimeout = 5 * time.Second
+	endpointChallengeType    = "endpoint.challenge"
+
+	// Svix has no way to fetch the stats of many endpoints at once, so the
+	// stats of listed endpoints are fetched with this many requests at a time
+	endpointStatsConcurrency = 5
+
+	// the maximum size of the challenge response we're willing to read
+	endpointChallengeMaxResponseSize = 4096
+)
+
+// eventTypeCatalog contains all the event types instances can subscribe
+// their webhook endpoints to. Internal event types are never delivered to
+// webhooks, so they are left out.
+var eventTypeCatalog = []events.EventType{
+	events.EventTypes.EmailCreated,
+	events.EventTypes.OrganizationCreated,
+	events.EventTypes.OrganizationDeleted,
+	events.EventTypes.OrganizationUpdated,
+	events.EventTypes.OrganizationDomainCreated,
+	events.EventTypes.OrganizationDomainDeleted,
+	events.EventTypes.OrganizationDomainUpdated,
+	events.EventTypes.OrganizationInvitationAccepted,
+	events.EventTypes.OrganizationInvitationCreated,
+	events.EventTypes.OrganizationInvitationExpired,
+	events.EventTypes.OrganizationInvitationRevoked,
+	events.EventTypes.OrganizationMembershipCreated,
+	events.EventTypes.OrganizationMembershipDeleted,
+	events.EventTypes.OrganizationMembershipRoleChanged,
+	events.EventTypes.OrganizationMembershipUpdated,
+	events.EventTypes.PermissionCreated,
+	events.EventTypes.PermissionDeleted,
+	events.EventTypes.PermissionUpdated,
+	events.EventTypes.RoleCreated,
+	events.EventTypes.RoleDeleted,
+	events.EventTypes.RoleUpdated,
+	events.EventTypes.SessionCreated,
+	events.EventTypes.SessionEnded,
+	events.EventTypes.SessionRemoved,
+	events.EventTypes.SessionRevoked,
+	events.EventTypes.SMSCreated,
+	events.EventTypes.UserCreated,
+	events.EventTypes.UserDeleted,
+	events.EventTypes.UserUpdated,
+}
+
+// organizationEventTypeCatalog contains the event types organizations can
+// subscribe their own webhook endpoints to. These are the events which concern
+// a single organization, so that its endpoints never receive the data of other
+// organizations or users.
+var organizationEventTypeCatalog = []events.EventType{
+	events.EventTypes.OrganizationDeleted,
+	events.EventTypes.OrganizationUpdated,
+	events.EventTypes.OrganizationDomainCreated,
+	events.EventTypes.OrganizationDomainDeleted,
+	events.EventTypes.OrganizationDomainUpdated,
+	events.EventTypes.OrganizationInvitationAccepted,
+	events.EventTypes.OrganizationInvitationCreated,
+	events.EventTypes.OrganizationInvitationExpired,
+	events.EventTypes.OrganizationInvitationRevoked,
+	events.EventTypes.OrganizationMembershipCreated,
+	events.EventTypes.OrganizationMembershipDeleted,
+	events.EventTypes.OrganizationMembershipRoleChanged,
+	events.EventTypes.OrganizationMembershipUpdated,
+}
+
+// EventTypeNames returns the names of all the event types webhook endpoints
+// can subscribe to.
+func EventTypeNames() []string {
+	names := make([]string, 0, len(eventTypeCatalog))
+	for _, eventType := range eventTypeCatalog {
+		if eventType.Internal {
+			continue
+		}
+		names = append(names, eventType.Name)
+	}
+	return names
+}
+
+// eventTypeByName returns the event type of the catalog with the given name.
+func eventTypeByName(name string) (events.EventType, bool) {
+	for _, eventType := range eventTypeCatalog {
+		if eventType.Name == name {
+			return eventType, true
+		}
+	}
+	return events.EventType{}, false
+}
+
+// ValidateEventTypes makes sure all the given event types are part of the
+// catalog.
+func ValidateEventTypes(param string, eventTypes []string) apierror.Error {
+	allowed := EventTypeNames()
+	for _, eventType := range eventTypes {
+		if !slices.Contains(allowed, eventType) {
+			return apierror.FormInvalidParameterValueWithAllowed(param, eventType, allowed)
+		}
+	}
+	return nil
+}
+
+// OrganizationEventTypeNames returns the names of the event types the webhook
+// endpoints of organizations can subscribe to.
+func OrganizationEventTypeNames() []string {
+	names := make([]string, len(organizationEventTypeCatalog))
+	for i, eventType := range organizationEventTypeCatalog {
+		names[i] = eventType.Name
+	}
+	return names
+}
+
+// ValidateOrganizationEventTypes makes sure all the given event types are
+// part of the organization catalog.
+func ValidateOrganizationEventTypes(param string, eventTypes []string) apierror.Error {
+	allowed := OrganizationEventTypeNames()
+	for _, eventType := range eventTypes {
+		if !slices.Contains(allowed, eventType) {
+			return apierror.FormInvalidParameterValueWithAllowed(param, eventType, allowed)
+		}
+	}
+	return nil
+}
+
+type EndpointParams struct {
+	URL         string
+	Description string
+	EventTypes  []string
+	Disabled    bool
+}
+
+// ListEndpoints returns all the webhook endpoints of the given instance.
+func (s *Service) ListEndpoints(ctx context.Context, instance *model.Instance) ([]*serialize.WebhookEndpointResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.listNativeEndpoints(ctx, instance, null.String{})
+	}
+
+	if !instance.IsSvixEnabled() {
+		return nil, apierror.SvixAppMissing()
+	}
+
+	endpoints, err := s.svixClient.ListEndpoints(ctx, instance.SvixAppID.String)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses, err := s.toEndpointResponses(ctx, instance, endpoints)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return responses, nil
+}
+
+// ReadEndpoint returns the webhook endpoint with the given id, along with
+// its recent delivery health.
+func (s *Service) ReadEndpoint(ctx context.Context, instance *model.Instance, endpointID string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.readNativeEndpoint(ctx, instance, null.String{}, endpointID)
+	}
+
+	endpoint, apiErr := s.fetchEndpoint(ctx, instance, endpointID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	response, err := s.toEndpointResponse(ctx, instance, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+// CreateEndpoint verifies that the given URL responds to a challenge and
+// registers it as a webhook endpoint of the instance.
+func (s *Service) CreateEndpoint(ctx context.Context, instance *model.Instance, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.createNativeEndpoint(ctx, instance, null.String{}, params, urlParam)
+	}
+
+	if !instance.IsSvixEnabled() {
+		return nil, apierror.SvixAppMissing()
+	}
+
+	if apiErr := s.VerifyEndpointURL(ctx, params.URL, urlParam); apiErr != nil {
+		return nil, apiErr
+	}
+
+	endpoint, err := s.svixClient.CreateEndpoint(ctx, instance.SvixAppID.String, toEndpointIn(params))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	response, err := s.toEndpointResponse(ctx, instance, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+// UpdateEndpoint replaces the configuration of a webhook endpoint. The URL
+// is verified again only if it changed.
+func (s *Service) UpdateEndpoint(ctx context.Context, instance *model.Instance, endpointID string, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.updateNativeEndpoint(ctx, instance, null.String{}, endpointID, params, urlParam)
+	}
+
+	existing, apiErr := s.fetchEndpoint(ctx, instance, endpointID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if existing.URL != params.URL {
+		if apiErr := s.VerifyEndpointURL(ctx, params.URL, urlParam); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	endpoint, err := s.svixClient.UpdateEndpoint(ctx, instance.SvixAppID.String, endpointID, toEndpointIn(params))
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	response, err := s.toEndpointResponse(ctx, instance, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+// DeleteEndpoint removes the webhook endpoint with the given id.
+func (s *Service) DeleteEndpoint(ctx context.Context, instance *model.Instance, endpointID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.deleteNativeEndpoint(ctx, instance, null.String{}, endpointID)
+	}
+
+	if _, apiErr := s.fetchEndpoint(ctx, instance, endpointID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if err := s.svixClient.DeleteEndpoint(ctx, instance.SvixAppID.String, endpointID); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.DeletedObject(endpointID, serialize.WebhookEndpointObjectName), nil
+}
+
+// ReadEndpointSecret returns the signing secret of a webhook endpoint. The
+// endpoint is always looked up in the Svix app of the instance first, so
+// that secrets of endpoints owned by other instances are never exposed.
+func (s *Service) ReadEndpointSecret(ctx context.Context, instance *model.Instance, endpointID string) (*serialize.WebhookEndpointSecretResponse, apierror.Error) {
+	if UsesNativeDelivery(instance) {
+		return s.readNativeEndpointSecret(ctx, instance, null.String{}, endpointID)
+	}
+
+	if _, apiErr := s.fetchEndpoint(ctx, instance, endpointID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	secret, err := s.svixClient.GetEndpointSecret(ctx, instance.SvixAppID.String, endpointID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	log.Info(ctx, "webhooks: signing secret of endpoint %s retrieved for instance %s", endpointID, instance.ID)
+	return serialize.WebhookEndpointSecret(endpointID, secret), nil
+}
+
+// VerifyEndpointURL sends a challenge to the given URL and expects it to be
+// echoed back, to make sure the URL is reachable and is meant to receive
+// webhooks before we start delivering to it. The challenge is only sent to
+// public addresses and redirects aren't followed, so that endpoint URLs can't
+// be used to probe our own network.
+func (s *Service) VerifyEndpointURL(ctx context.Context, endpointURL, param string) apierror.Error {
+	parsed, err := url.Parse(endpointURL)
+	if err != nil || parsed.Host == "" {
+		return apierror.FormInvalidParameterFormat(param)
+	}
+	if parsed.Scheme != "https" {
+		return apierror.FormInvalidParameterFormat(param, "Webhook endpoint URLs must use https.")
+	}
+
+	challenge, err := rand.Token()
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"type":      endpointChallengeType,
+		"challenge": challenge,
+	})
+	if err != nil {
+		return apierror.Unexpected(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL, bytes.NewReader(body))
+	if err != nil {
+		return apierror.FormInvalidParameterFormat(param)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := s.challengeClient.Do(req)
+	if errors.Is(err, outbound.ErrForbiddenAddress) {
+		return apierror.SvixEndpointVerificationFailed(param, "the URL doesn't resolve to a public address")
+	} else if err != nil {
+		return apierror.SvixEndpointVerificationFailed(param, "the URL is unreachable")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 && res.StatusCode < 400 {
+		return apierror.SvixEndpointVerificationFailed(param, "redirects are not followed")
+	} else if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return apierror.SvixEndpointVerificationFailed(param, fmt.Sprintf("unexpected status %d", res.StatusCode))
+	}
+
+	var response struct {
+		Challenge string `json:"challenge"`
+	}
+	err = json.NewDecoder(io.LimitReader(res.Body, endpointChallengeMaxResponseSize)).Decode(&response)
+	if err != nil || response.Challenge != challenge {
+		return apierror.SvixEndpointVerificationFailed(param, "the challenge was not echoed back")
+	}
+	return nil
+}
+
+func (s *Service) fetchEndpoint(ctx context.Context, instance *model.Instance, endpointID string) (*svix.Endpoint, apierror.Error) {
+	if !instance.IsSvixEnabled() {
+		return nil, apierror.SvixAppMissing()
+	}
+
+	endpoint, err := s.svixClient.GetEndpoint(ctx, instance.SvixAppID.String, endpointID)
+	if errors.Is(err, svix.ErrNotFound) {
+		return nil, apierror.SvixEndpointNotFound()
+	} else if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return endpoint, nil
+}
+
+func (s *Service) toEndpointResponse(ctx context.Context, instance *model.Instance, endpoint *svix.Endpoint) (*serialize.WebhookEndpointResponse, error) {
+	stats, err := s.svixClient.GetEndpointStats(ctx, instance.SvixAppID.String, endpoint.ID)
+	if err != nil {
+		return nil, fmt.Errorf("webhooks: fetching stats of endpoint %s: %w", endpoint.ID, err)
+	}
+
+	return serialize.WebhookEndpoint(endpoint, serialize.WebhookEndpointHealth{
+		Succeeded: stats.Success,
+		Failed:    stats.Fail,
+		Pending:   stats.Pending + stats.Sending,
+	}), nil
+}
+
+// toEndpointResponses serializes the endpoints along with their stats, which
+// are fetched concurrently.
+func (s *Service) toEndpointResponses(ctx context.Context, instance *model.Instance, endpoints []*svix.Endpoint) ([]*serialize.WebhookEndpointResponse, error) {
+	responses := make([]*serialize.WebhookEndpointResponse, len(endpoints))
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(endpointStatsConcurrency)
+	for i, endpoint := range endpoints {
+		i, endpoint := i, endpoint
+		group.Go(func() error {
+			response, err := s.toEndpointResponse(ctx, instance, endpoint)
+			responses[i] = response
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+func toEndpointIn(params EndpointParams) *svix.EndpointIn {
+	return &svix.EndpointIn{
+		URL:         params.URL,
+		Description: params.Description,
+		FilterTypes: params.EventTypes,
+		Disabled:    params.Disabled,
+	}
+}
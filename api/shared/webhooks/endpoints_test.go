@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/outbound"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func challengeServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *Service) {
+	t.Helper()
+
+	server := httptest.NewTLSServer(handler)
+	t.Cleanup(server.Close)
+
+	// The test server is on a loopback address, which the outbound client
+	// refuses, so its own client is used instead, without redirects.
+	client := server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return server, &Service{challengeClient: client}
+}
+
+func echoChallenge(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	_ = json.NewEncoder(w).Encode(map[string]string{"challenge": body["challenge"]})
+}
+
+func TestVerifyEndpointURL(t *testing.T) {
+	t.Parallel()
+
+	server, s := challengeServer(t, echoChallenge)
+	assert.Nil(t, s.VerifyEndpointURL(context.Background(), server.URL, "url"))
+}
+
+func TestVerifyEndpointURLRejects(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		url        func(server *httptest.Server) string
+		wantReason string
+	}{
+		{
+			name:       "plain http",
+			handler:    echoChallenge,
+			url:        func(server *httptest.Server) string { return "http://" + server.Listener.Addr().String() },
+			wantReason: "must use https",
+		},
+		{
+			name: "challenge not echoed",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"challenge":"something else"}`))
+			},
+			wantReason: "the challenge was not echoed back",
+		},
+		{
+			name: "unexpected status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantReason: "unexpected status 500",
+		},
+		{
+			name: "redirect",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/redirect" {
+					http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+					return
+				}
+				echoChallenge(w, r)
+			},
+			url:        func(server *httptest.Server) string { return server.URL + "/redirect" },
+			wantReason: "redirects are not followed",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, s := challengeServer(t, tt.handler)
+			endpointURL := server.URL
+			if tt.url != nil {
+				endpointURL = tt.url(server)
+			}
+
+			apiErr := s.VerifyEndpointURL(context.Background(), endpointURL, "url")
+			require.NotNil(t, apiErr)
+			assert.Contains(t, apiErr.Errors()[0].LongMessage(), tt.wantReason)
+		})
+	}
+}
+
+func TestVerifyEndpointURLRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+
+	server, _ := challengeServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the challenge was sent to a loopback address")
+	})
+	s := &Service{challengeClient: outbound.NewClient(time.Second)}
+
+	apiErr := s.VerifyEndpointURL(context.Background(), server.URL, "url")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SvixEndpointVerificationFailedCode, apiErr.Errors()[0].Code())
+	assert.Contains(t, apiErr.Errors()[0].LongMessage(), "public address")
+}
+
+func TestNativeEndpointResponse(t *testing.T) {
+	t.Parallel()
+
+	endpoint := &model.WebhookEndpoint{WebhookEndpoint: &sqbmodel.WebhookEndpoint{ID: "whe_1"}}
+
+	response := nativeEndpointResponse(endpoint, map[string]int{
+		DeliveryStatusSucceeded: 6,
+		DeliveryStatusFailed:    2,
+		DeliveryStatusPending:   1,
+	})
+	assert.Equal(t, 6, response.Health.Succeeded)
+	assert.Equal(t, 2, response.Health.Failed)
+	assert.Equal(t, 1, response.Health.Pending)
+	assert.InDelta(t, 0.25, response.Health.FailureRate, 0.0001)
+
+	// endpoints without deliveries are missing from the batched counts
+	response = nativeEndpointResponse(endpoint, nil)
+	assert.Zero(t, response.Health.Succeeded)
+	assert.Zero(t, response.Health.FailureRate)
+}
@@ -0,0 +1,254 @@
+package webhooks
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	pkgwebhooks "clerk/pkg/webhooks"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// EnableNativeDelivery switches the instance to native webhook delivery.
+// Events are no longer sent to its Svix app, if any, and are delivered to
+// the endpoints registered through the native endpoints API instead.
+func (s *Service) EnableNativeDelivery(ctx context.Context, tx database.Tx, instance *model.Instance) error {
+	if UsesNativeDelivery(instance) {
+		return apierror.NativeWebhooksAlreadyEnabled()
+	}
+
+	instance.WebhookProvider = pkgwebhooks.ProviderNative
+	return s.instanceRepo.UpdateWebhookProvider(ctx, tx, instance)
+}
+
+// DisableNativeDelivery switches the instance back to delivering its
+// webhooks through Svix. Pending native deliveries are dropped when they're
+// next attempted.
+func (s *Service) DisableNativeDelivery(ctx context.Context, tx database.Tx, instance *model.Instance) error {
+	if !UsesNativeDelivery(instance) {
+		return apierror.NativeWebhooksNotEnabled()
+	}
+
+	instance.WebhookProvider = pkgwebhooks.ProviderSvix
+	if err := s.instanceRepo.UpdateWebhookProvider(ctx, tx, instance); err != nil {
+		return err
+	}
+	_, err := s.webhookDeliveryRepo.DeletePendingByInstance(ctx, tx, instance.ID)
+	return err
+}
+
+// ListFailedDeliveries returns the deliveries of the instance which ran out
+// of attempts, latest first.
+func (s *Service) ListFailedDeliveries(ctx context.Context, instance *model.Instance, params pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+
+	deadLetters, err := s.webhookDeadLetterRepo.FindAllByInstance(ctx, s.db, instance.ID, params)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	totalCount, err := s.webhookDeadLetterRepo.CountByInstance(ctx, s.db, instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]interface{}, len(deadLetters))
+	for i, deadLetter := range deadLetters {
+		responses[i] = serialize.WebhookFailedDelivery(deadLetter)
+	}
+	return serialize.Paginated(responses, totalCount), nil
+}
+
+// ReplayFailedDelivery schedules a failed delivery again, with a fresh set
+// of attempts.
+func (s *Service) ReplayFailedDelivery(ctx context.Context, instance *model.Instance, deadLetterID string) (*serialize.WebhookFailedDeliveryResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+
+	deadLetter, err := s.webhookDeadLetterRepo.QueryByIDAndInstance(ctx, s.db, deadLetterID, instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if deadLetter == nil {
+		return nil, apierror.WebhookFailedDeliveryNotFound()
+	}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		_, err := s.deliverer.Replay(ctx, tx, deadLetter)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	log.Info(ctx, "webhooks: failed delivery %s of event %s replayed for instance %s", deadLetter.ID, deadLetter.EventID, instance.ID)
+	return serialize.WebhookFailedDelivery(deadLetter), nil
+}
+
+// listNativeEndpoints returns the endpoints owned by the given organization,
+// or the instance-level endpoints if organizationID is null.
+func (s *Service) listNativeEndpoints(ctx context.Context, instance *model.Instance, organizationID null.String) ([]*serialize.WebhookEndpointResponse, apierror.Error) {
+	var endpoints []*model.WebhookEndpoint
+	var err error
+	if organizationID.Valid {
+		endpoints, err = s.webhookEndpointRepo.FindAllByInstanceAndOrganization(ctx, s.db, instance.ID, organizationID.String)
+	} else {
+		endpoints, err = s.webhookEndpointRepo.FindAllByInstanceWithoutOrganization(ctx, s.db, instance.ID)
+	}
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	endpointIDs := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		endpointIDs[i] = endpoint.ID
+	}
+	countsByEndpoint, err := s.webhookDeliveryRepo.CountByEndpointsGroupedByStatus(ctx, s.db, endpointIDs)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]*serialize.WebhookEndpointResponse, len(endpoints))
+	for i, endpoint := range endpoints {
+		responses[i] = nativeEndpointResponse(endpoint, countsByEndpoint[endpoint.ID])
+	}
+	return responses, nil
+}
+
+func (s *Service) readNativeEndpoint(ctx context.Context, instance *model.Instance, organizationID null.String, endpointID string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	endpoint, apiErr := s.fetchNativeEndpoint(ctx, instance, organizationID, endpointID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	response, err := s.toNativeEndpointResponse(ctx, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+func (s *Service) createNativeEndpoint(ctx context.Context, instance *model.Instance, organizationID null.String, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if apiErr := s.VerifyEndpointURL(ctx, params.URL, urlParam); apiErr != nil {
+		return nil, apiErr
+	}
+
+	secret, err := pkgwebhooks.GenerateSecret()
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	endpoint := &model.WebhookEndpoint{WebhookEndpoint: &sqbmodel.WebhookEndpoint{
+		InstanceID:     instance.ID,
+		OrganizationID: organizationID,
+		URL:            params.URL,
+		Description:    params.Description,
+		EventTypes:     params.EventTypes,
+		Disabled:       params.Disabled,
+		Secret:         secret,
+	}}
+	if err := s.webhookEndpointRepo.Insert(ctx, s.db, endpoint); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	response, err := s.toNativeEndpointResponse(ctx, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+func (s *Service) updateNativeEndpoint(ctx context.Context, instance *model.Instance, organizationID null.String, endpointID string, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	endpoint, apiErr := s.fetchNativeEndpoint(ctx, instance, organizationID, endpointID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	if endpoint.URL != params.URL {
+		if apiErr := s.VerifyEndpointURL(ctx, params.URL, urlParam); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+
+	endpoint.URL = params.URL
+	endpoint.Description = params.Description
+	endpoint.EventTypes = params.EventTypes
+	endpoint.Disabled = params.Disabled
+	err := s.webhookEndpointRepo.Update(ctx, s.db, endpoint,
+		sqbmodel.WebhookEndpointColumns.URL,
+		sqbmodel.WebhookEndpointColumns.Description,
+		sqbmodel.WebhookEndpointColumns.EventTypes,
+		sqbmodel.WebhookEndpointColumns.Disabled,
+	)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	response, err := s.toNativeEndpointResponse(ctx, endpoint)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return response, nil
+}
+
+func (s *Service) deleteNativeEndpoint(ctx context.Context, instance *model.Instance, organizationID null.String, endpointID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	if _, apiErr := s.fetchNativeEndpoint(ctx, instance, organizationID, endpointID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	if _, err := s.webhookEndpointRepo.DeleteByIDAndInstance(ctx, s.db, endpointID, instance.ID); err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	return serialize.DeletedObject(endpointID, serialize.WebhookEndpointObjectName), nil
+}
+
+func (s *Service) readNativeEndpointSecret(ctx context.Context, instance *model.Instance, organizationID null.String, endpointID string) (*serialize.WebhookEndpointSecretResponse, apierror.Error) {
+	endpoint, apiErr := s.fetchNativeEndpoint(ctx, instance, organizationID, endpointID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	log.Info(ctx, "webhooks: signing secret of endpoint %s retrieved for instance %s", endpointID, instance.ID)
+	return serialize.WebhookEndpointSecret(endpointID, endpoint.Secret), nil
+}
+
+// fetchNativeEndpoint returns the endpoint with the given id, as long as it's
+// owned by the given organization, or is an instance-level endpoint if
+// organizationID is null.
+func (s *Service) fetchNativeEndpoint(ctx context.Context, instance *model.Instance, organizationID null.String, endpointID string) (*model.WebhookEndpoint, apierror.Error) {
+	endpoint, err := s.webhookEndpointRepo.QueryByIDAndInstance(ctx, s.db, endpointID, instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if endpoint == nil || endpoint.OrganizationID != organizationID {
+		return nil, apierror.SvixEndpointNotFound()
+	}
+	return endpoint, nil
+}
+
+func (s *Service) toNativeEndpointResponse(ctx context.Context, endpoint *model.WebhookEndpoint) (*serialize.WebhookEndpointResponse, error) {
+	countsByStatus, err := s.webhookDeliveryRepo.CountByEndpointGroupedByStatus(ctx, s.db, endpoint.ID)
+	if err != nil {
+		return nil, err
+	}
+	return nativeEndpointResponse(endpoint, countsByStatus), nil
+}
+
+// nativeEndpointResponse serializes the endpoint along with the counts of
+// its deliveries, grouped by status.
+func nativeEndpointResponse(endpoint *model.WebhookEndpoint, countsByStatus map[string]int) *serialize.WebhookEndpointResponse {
+	return serialize.NativeWebhookEndpoint(endpoint, serialize.WebhookEndpointHealth{
+		Succeeded: countsByStatus[DeliveryStatusSucceeded],
+		Failed:    countsByStatus[DeliveryStatusFailed],
+		Pending:   countsByStatus[DeliveryStatusPending],
+	})
+}
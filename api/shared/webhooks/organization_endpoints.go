@@ -0,0 +1,71 @@
+package webhooks
+
+import (
+	"context"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/model"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Organization endpoints are webhook endpoints owned by a single organization,
+// which only receive the events of that organization. They're only supported
+// for instances which deliver their webhooks natively, since Svix apps are
+// scoped to the whole instance.
+
+// ListOrganizationEndpoints returns all the webhook endpoints of the given
+// organization.
+func (s *Service) ListOrganizationEndpoints(ctx context.Context, instance *model.Instance, organizationID string) ([]*serialize.WebhookEndpointResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.listNativeEndpoints(ctx, instance, null.StringFrom(organizationID))
+}
+
+// ReadOrganizationEndpoint returns the webhook endpoint of the organization
+// with the given id, along with its recent delivery health.
+func (s *Service) ReadOrganizationEndpoint(ctx context.Context, instance *model.Instance, organizationID, endpointID string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.readNativeEndpoint(ctx, instance, null.StringFrom(organizationID), endpointID)
+}
+
+// CreateOrganizationEndpoint registers a new webhook endpoint for the
+// organization. The event types of the params must be part of the
+// organization catalog, see ValidateOrganizationEventTypes.
+func (s *Service) CreateOrganizationEndpoint(ctx context.Context, instance *model.Instance, organizationID string, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.createNativeEndpoint(ctx, instance, null.StringFrom(organizationID), params, urlParam)
+}
+
+// UpdateOrganizationEndpoint updates the webhook endpoint of the organization
+// with the given id.
+func (s *Service) UpdateOrganizationEndpoint(ctx context.Context, instance *model.Instance, organizationID, endpointID string, params EndpointParams, urlParam string) (*serialize.WebhookEndpointResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.updateNativeEndpoint(ctx, instance, null.StringFrom(organizationID), endpointID, params, urlParam)
+}
+
+// DeleteOrganizationEndpoint removes the webhook endpoint of the organization
+// with the given id.
+func (s *Service) DeleteOrganizationEndpoint(ctx context.Context, instance *model.Instance, organizationID, endpointID string) (*serialize.DeletedObjectResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.deleteNativeEndpoint(ctx, instance, null.StringFrom(organizationID), endpointID)
+}
+
+// ReadOrganizationEndpointSecret returns the signing secret of the webhook
+// endpoint of the organization with the given id.
+func (s *Service) ReadOrganizationEndpointSecret(ctx context.Context, instance *model.Instance, organizationID, endpointID string) (*serialize.WebhookEndpointSecretResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) {
+		return nil, apierror.NativeWebhooksNotEnabled()
+	}
+	return s.readNativeEndpointSecret(ctx, instance, null.StringFrom(organizationID), endpointID)
+}
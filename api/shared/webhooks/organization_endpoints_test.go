@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationEventTypeNames(t *testing.T) {
+	t.Parallel()
+
+	// organizations can only subscribe to a subset of the instance catalog
+	names := OrganizationEventTypeNames()
+	require.NotEmpty(t, names)
+	assert.Subset(t, EventTypeNames(), names)
+	assert.Contains(t, names, events.EventTypes.OrganizationUpdated.Name)
+	assert.NotContains(t, names, events.EventTypes.UserCreated.Name)
+}
+
+func TestValidateOrganizationEventTypes(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, ValidateOrganizationEventTypes("event_types", nil))
+	assert.Nil(t, ValidateOrganizationEventTypes("event_types", []string{
+		events.EventTypes.OrganizationUpdated.Name,
+		events.EventTypes.OrganizationMembershipCreated.Name,
+	}))
+
+	// events which concern users outside of the organization are rejected
+	apiErr := ValidateOrganizationEventTypes("event_types", []string{
+		events.EventTypes.OrganizationUpdated.Name,
+		events.EventTypes.UserCreated.Name,
+	})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.FormParamValueInvalidCode, apiErr.Errors()[0].Code())
+}
+
+func TestOrganizationEndpointsRequireNativeDelivery(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	// Svix apps are scoped to the whole instance
+	service := &Service{}
+	instance := &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}
+
+	_, apiErr := service.ListOrganizationEndpoints(ctx, instance, "org_1")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.NativeWebhooksNotEnabledCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = service.CreateOrganizationEndpoint(ctx, instance, "org_1", EndpointParams{URL: "https://example.com/webhooks"}, "url")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.NativeWebhooksNotEnabledCode, apiErr.Errors()[0].Code())
+
+	_, apiErr = service.ReadOrganizationEndpointSecret(ctx, instance, "org_1", "whe_1")
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.NativeWebhooksNotEnabledCode, apiErr.Errors()[0].Code())
+}
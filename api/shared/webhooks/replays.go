@@ -0,0 +1,290 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/api/serialize"
+	"clerk/api/shared/pagination"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/jobs"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// EventRetention is for how long emitted events are kept around, and thus
+// how far back they can be replayed.
+const EventRetention = 30 * 24 * time.Hour
+
+// replayEventsPageSize is the number of events that are loaded at once
+// while replaying.
+const replayEventsPageSize = 500
+
+// The statuses of an event replay.
+const (
+	ReplayStatusPending   = "pending"
+	ReplayStatusCompleted = "completed"
+)
+
+// ReplayParams select the events to deliver again. Zero times leave the
+// range open on that side.
+type ReplayParams struct {
+	StartAt    time.Time
+	EndAt      time.Time
+	EventTypes []string
+}
+
+// Validate makes sure the range is in order and within the retention
+// window, and that all the event types are part of the catalog.
+func (p ReplayParams) Validate(now time.Time, startAtParam, endAtParam, eventTypesParam string) apierror.Error {
+	if !p.StartAt.IsZero() && p.StartAt.Before(now.Add(-EventRetention)) {
+		return apierror.WebhookReplayRangeInvalid(startAtParam, fmt.Sprintf("events are only kept for %d days", int(EventRetention.Hours()/24)))
+	}
+	if !p.StartAt.IsZero() && !p.EndAt.IsZero() && !p.StartAt.Before(p.EndAt) {
+		return apierror.WebhookReplayRangeInvalid(endAtParam, "the end of the range must be after its start")
+	}
+	return ValidateEventTypes(eventTypesParam, p.EventTypes)
+}
+
+func (p ReplayParams) toModifiers() repository.WebhookEventsFindAllModifiers {
+	return repository.WebhookEventsFindAllModifiers{
+		CreatedAfter:  p.StartAt,
+		CreatedBefore: p.EndAt,
+		EventTypes:    p.EventTypes,
+	}
+}
+
+// Replayer keeps a log of the events which are sent to webhooks, so that
+// the ones an instance missed can be delivered again. Every replay leaves a
+// marker per event it delivers, which makes retries of the replay job
+// skip the events that were already delivered.
+type Replayer struct {
+	clock     clockwork.Clock
+	db        database.Database
+	gueClient *gue.Client
+	deliverer *Deliverer
+
+	// repositories
+	instanceRepo                 *repository.Instances
+	webhookEventRepo             *repository.WebhookEvents
+	webhookEventReplayRepo       *repository.WebhookEventReplays
+	webhookEventReplayMarkerRepo *repository.WebhookEventReplayMarkers
+}
+
+func NewReplayer(deps clerk.Deps) *Replayer {
+	return &Replayer{
+		clock:                        deps.Clock(),
+		db:                           deps.DB(),
+		gueClient:                    deps.GueClient(),
+		deliverer:                    NewDeliverer(deps),
+		instanceRepo:                 deps.Repositories().Instances,
+		webhookEventRepo:             deps.Repositories().WebhookEvents,
+		webhookEventReplayRepo:       deps.Repositories().WebhookEventReplays,
+		webhookEventReplayMarkerRepo: deps.Repositories().WebhookEventReplayMarkers,
+	}
+}
+
+// Record stores an event which is sent to webhooks, along with the payload
+// that's delivered for it. Events are recorded whether the instance has
+// webhooks set up or not, so that they can be replayed once it does.
+func (r *Replayer) Record(ctx context.Context, exec database.Executor, instance *model.Instance, organizationID *string, eventID, eventType string, payload []byte, occurredAt time.Time) error {
+	event := &model.WebhookEvent{WebhookEvent: &sqbmodel.WebhookEvent{
+		ID:             eventID,
+		InstanceID:     instance.ID,
+		OrganizationID: null.StringFromPtr(organizationID),
+		Type:           eventType,
+		Payload:        payload,
+		CreatedAt:      occurredAt,
+	}}
+	if err := r.webhookEventRepo.Insert(ctx, exec, event); err != nil {
+		return fmt.Errorf("webhooks/record: storing event %s: %w", eventID, err)
+	}
+	return nil
+}
+
+// Run delivers the events of the replay again, through whichever provider
+// the instance uses now. It's invoked by the replay_webhook_events job.
+func (r *Replayer) Run(ctx context.Context, replayID string) error {
+	replay, err := r.webhookEventReplayRepo.QueryByID(ctx, r.db, replayID)
+	if err != nil {
+		return fmt.Errorf("webhooks/replay: fetching replay %s: %w", replayID, err)
+	}
+	if replay == nil || replay.Status != ReplayStatusPending {
+		return nil
+	}
+
+	instance, err := r.instanceRepo.FindByID(ctx, r.db, replay.InstanceID)
+	if err != nil {
+		return fmt.Errorf("webhooks/replay: fetching instance %s: %w", replay.InstanceID, err)
+	}
+
+	params := ReplayParams{
+		StartAt:    replay.StartAt.Time,
+		EndAt:      replay.EndAt,
+		EventTypes: replay.EventTypes,
+	}
+	// keyset pagination, so that events which are recorded or cleaned up
+	// during the replay don't shift the pages
+	keyset := pagination.KeysetParams{Limit: replayEventsPageSize}
+	for {
+		events, err := r.webhookEventRepo.FindAllByInstanceWithModifiersAfter(ctx, r.db, instance.ID, params.toModifiers(), keyset)
+		if err != nil {
+			return fmt.Errorf("webhooks/replay: fetching events of replay %s: %w", replay.ID, err)
+		}
+
+		for _, event := range events {
+			if err := r.replayEvent(ctx, instance, replay, event); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < replayEventsPageSize {
+			break
+		}
+		last := events[len(events)-1]
+		keyset.After = &pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	eventCount, err := r.webhookEventReplayMarkerRepo.CountByReplay(ctx, r.db, replay.ID)
+	if err != nil {
+		return fmt.Errorf("webhooks/replay: counting events of replay %s: %w", replay.ID, err)
+	}
+
+	replay.Status = ReplayStatusCompleted
+	replay.EventCount = null.IntFrom(int(eventCount))
+	replay.CompletedAt = null.TimeFrom(r.clock.Now().UTC())
+	return r.webhookEventReplayRepo.Update(ctx, r.db, replay,
+		sqbmodel.WebhookEventReplayColumns.Status,
+		sqbmodel.WebhookEventReplayColumns.EventCount,
+		sqbmodel.WebhookEventReplayColumns.CompletedAt,
+	)
+}
+
+// replayEvent delivers the event again, unless the replay already did.
+// The marker is inserted in the same transaction as the delivery is
+// enqueued, so that the event is delivered exactly once per replay.
+func (r *Replayer) replayEvent(ctx context.Context, instance *model.Instance, replay *model.WebhookEventReplay, event *model.WebhookEvent) error {
+	txErr := r.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		marked, err := r.webhookEventReplayMarkerRepo.InsertIfMissing(ctx, tx, &model.WebhookEventReplayMarker{WebhookEventReplayMarker: &sqbmodel.WebhookEventReplayMarker{
+			ReplayID: replay.ID,
+			EventID:  event.ID,
+		}})
+		if err != nil || !marked {
+			return err != nil, err
+		}
+
+		err = r.redeliver(ctx, tx, instance, event)
+		return err != nil, err
+	})
+	if txErr != nil {
+		return fmt.Errorf("webhooks/replay: replaying event %s of replay %s: %w", event.ID, replay.ID, txErr)
+	}
+	return nil
+}
+
+// redeliver enqueues the delivery of the event with its original id, so
+// that receivers can recognize events they already processed.
+func (r *Replayer) redeliver(ctx context.Context, tx database.Tx, instance *model.Instance, event *model.WebhookEvent) error {
+	if UsesNativeDelivery(instance) {
+		return r.deliverer.Enqueue(ctx, tx, instance, event.OrganizationID.Ptr(), event.ID, event.Type, event.Payload)
+	}
+
+	if !instance.ShouldSendWebhook() {
+		return nil
+	}
+
+	eventType, ok := eventTypeByName(event.Type)
+	if !ok {
+		// the event type was removed from the catalog after the event
+		// was recorded
+		log.Warning(ctx, "webhooks/replay: skipping event %s of unknown type %s", event.ID, event.Type)
+		return nil
+	}
+	return jobs.DispatchWebhookEvent(ctx, r.gueClient, jobs.WebhookEventArgs{
+		InstanceID: instance.ID,
+		EventID:    event.ID,
+		EventType:  eventType,
+		Payload:    json.RawMessage(event.Payload),
+	}, jobs.WithTx(tx))
+}
+
+// ListEvents returns the recorded events of the instance which match the
+// given filters, latest first.
+func (s *Service) ListEvents(ctx context.Context, instance *model.Instance, params ReplayParams, paginationParams pagination.Params) (*serialize.PaginatedResponse, apierror.Error) {
+	events, err := s.webhookEventRepo.FindAllByInstanceWithModifiers(ctx, s.db, instance.ID, params.toModifiers(), paginationParams)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	totalCount, err := s.webhookEventRepo.CountByInstanceWithModifiers(ctx, s.db, instance.ID, params.toModifiers())
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+
+	responses := make([]interface{}, len(events))
+	for i, event := range events {
+		responses[i] = serialize.WebhookEvent(event)
+	}
+	return serialize.Paginated(responses, totalCount), nil
+}
+
+// ReplayEvents enqueues the job which delivers the recorded events of the
+// instance in the given range again. The range is closed at the time of
+// the request if it's left open, so that the replay doesn't pick up events
+// which are delivered as usual.
+func (s *Service) ReplayEvents(ctx context.Context, instance *model.Instance, params ReplayParams) (*serialize.WebhookEventReplayResponse, apierror.Error) {
+	if !UsesNativeDelivery(instance) && !instance.IsSvixEnabled() {
+		return nil, apierror.SvixAppMissing()
+	}
+
+	endAt := params.EndAt
+	if endAt.IsZero() {
+		endAt = s.clock.Now().UTC()
+	}
+	replay := &model.WebhookEventReplay{WebhookEventReplay: &sqbmodel.WebhookEventReplay{
+		InstanceID: instance.ID,
+		Status:     ReplayStatusPending,
+		StartAt:    null.NewTime(params.StartAt, !params.StartAt.IsZero()),
+		EndAt:      endAt,
+		EventTypes: params.EventTypes,
+	}}
+
+	txErr := s.db.PerformTx(ctx, func(tx database.Tx) (bool, error) {
+		if err := s.webhookEventReplayRepo.Insert(ctx, tx, replay); err != nil {
+			return true, err
+		}
+
+		err := jobs.ReplayWebhookEvents(ctx, s.gueClient, jobs.ReplayWebhookEventsArgs{
+			WebhookEventReplayID: replay.ID,
+		}, jobs.WithTx(tx))
+		return err != nil, err
+	})
+	if txErr != nil {
+		return nil, apierror.Unexpected(txErr)
+	}
+
+	log.Info(ctx, "webhooks: replay %s of events until %s requested for instance %s", replay.ID, endAt, instance.ID)
+	return serialize.WebhookEventReplay(replay), nil
+}
+
+// ReadEventReplay returns the progress of an event replay of the instance.
+func (s *Service) ReadEventReplay(ctx context.Context, instance *model.Instance, replayID string) (*serialize.WebhookEventReplayResponse, apierror.Error) {
+	replay, err := s.webhookEventReplayRepo.QueryByIDAndInstance(ctx, s.db, replayID, instance.ID)
+	if err != nil {
+		return nil, apierror.Unexpected(err)
+	}
+	if replay == nil {
+		return nil, apierror.WebhookEventReplayNotFound()
+	}
+	return serialize.WebhookEventReplay(replay), nil
+}
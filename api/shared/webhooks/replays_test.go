@@ -0,0 +1,73 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clerk/api/apierror"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/events"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplayParamsValidate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		params   ReplayParams
+		wantCode string
+	}{
+		{name: "open range", params: ReplayParams{}},
+		{name: "within retention", params: ReplayParams{StartAt: now.Add(-EventRetention + time.Minute), EndAt: now}},
+		{name: "known event types", params: ReplayParams{EventTypes: []string{events.EventTypes.UserCreated.Name}}},
+		{name: "beyond retention", params: ReplayParams{StartAt: now.Add(-EventRetention - time.Minute)}, wantCode: apierror.WebhookReplayRangeInvalidCode},
+		{name: "end before start", params: ReplayParams{StartAt: now.Add(-time.Hour), EndAt: now.Add(-2 * time.Hour)}, wantCode: apierror.WebhookReplayRangeInvalidCode},
+		{name: "empty range", params: ReplayParams{StartAt: now.Add(-time.Hour), EndAt: now.Add(-time.Hour)}, wantCode: apierror.WebhookReplayRangeInvalidCode},
+		{name: "unknown event type", params: ReplayParams{EventTypes: []string{"unknown.event"}}, wantCode: apierror.FormParamValueInvalidCode},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			apiErr := tt.params.Validate(now, "start_at", "end_at", "event_types")
+			if tt.wantCode == "" {
+				assert.Nil(t, apiErr)
+				return
+			}
+			require.NotNil(t, apiErr)
+			assert.Equal(t, tt.wantCode, apiErr.Errors()[0].Code())
+		})
+	}
+}
+
+func TestReplayParamsToModifiers(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	params := ReplayParams{
+		StartAt:    now.Add(-time.Hour),
+		EndAt:      now,
+		EventTypes: []string{events.EventTypes.UserCreated.Name},
+	}
+	mods := params.toModifiers()
+	assert.Equal(t, now.Add(-time.Hour), mods.CreatedAfter)
+	assert.Equal(t, now, mods.CreatedBefore)
+	assert.Equal(t, []string{events.EventTypes.UserCreated.Name}, mods.EventTypes)
+}
+
+func TestReplayEventsWithoutWebhooks(t *testing.T) {
+	t.Parallel()
+
+	// instances without a webhook provider have nothing to replay events to
+	instance := &model.Instance{Instance: &sqbmodel.Instance{ID: "ins_1"}}
+	_, apiErr := (&Service{}).ReplayEvents(context.Background(), instance, ReplayParams{})
+	require.NotNil(t, apiErr)
+	assert.Equal(t, apierror.SvixAppMissingCode, apiErr.Errors()[0].Code())
+}
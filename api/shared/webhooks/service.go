@@ -3,34 +3,61 @@ package webhooks
 import (
 	"context"
 	"fmt"
+	"net/http"
 
 	"clerk/api/apierror"
 	"clerk/api/serialize"
 	"clerk/model"
 	"clerk/pkg/externalapis/svix"
+	"clerk/pkg/outbound"
 	"clerk/repository"
+	"clerk/utils/clerk"
 	"clerk/utils/database"
 
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
 	"github.com/volatiletech/null/v8"
 )
 
 type Service struct {
+	clock      clockwork.Clock
+	db         database.Database
+	gueClient  *gue.Client
 	svixClient *svix.Client
+	deliverer  *Deliverer
+
+	// used to send verification challenges to webhook endpoints
+	challengeClient *http.Client
 
 	// repositories
-	applicationRepo      *repository.Applications
-	instanceRepo         *repository.Instances
-	subscriptionRepo     *repository.Subscriptions
-	subscriptionPlanRepo *repository.SubscriptionPlans
+	applicationRepo        *repository.Applications
+	instanceRepo           *repository.Instances
+	subscriptionRepo       *repository.Subscriptions
+	subscriptionPlanRepo   *repository.SubscriptionPlans
+	webhookDeadLetterRepo  *repository.WebhookDeadLetters
+	webhookDeliveryRepo    *repository.WebhookDeliveries
+	webhookEndpointRepo    *repository.WebhookEndpoints
+	webhookEventRepo       *repository.WebhookEvents
+	webhookEventReplayRepo *repository.WebhookEventReplays
 }
 
-func NewService(svixClient *svix.Client) *Service {
+func NewService(deps clerk.Deps, svixClient *svix.Client) *Service {
 	return &Service{
-		svixClient:           svixClient,
-		applicationRepo:      repository.NewApplications(),
-		instanceRepo:         repository.NewInstances(),
-		subscriptionRepo:     repository.NewSubscriptions(),
-		subscriptionPlanRepo: repository.NewSubscriptionPlans(),
+		clock:                  deps.Clock(),
+		db:                     deps.DB(),
+		gueClient:              deps.GueClient(),
+		svixClient:             svixClient,
+		deliverer:              NewDeliverer(deps),
+		challengeClient:        outbound.NewClient(endpointChallengeTimeout),
+		applicationRepo:        deps.Repositories().Applications,
+		instanceRepo:           deps.Repositories().Instances,
+		subscriptionRepo:       deps.Repositories().Subscriptions,
+		subscriptionPlanRepo:   deps.Repositories().SubscriptionPlans,
+		webhookDeadLetterRepo:  deps.Repositories().WebhookDeadLetters,
+		webhookDeliveryRepo:    deps.Repositories().WebhookDeliveries,
+		webhookEndpointRepo:    deps.Repositories().WebhookEndpoints,
+		webhookEventRepo:       deps.Repositories().WebhookEvents,
+		webhookEventReplayRepo: deps.Repositories().WebhookEventReplays,
 	}
 }
 
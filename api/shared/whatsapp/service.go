@@ -0,0 +1,260 @@
+// Package whatsapp delivers one-time codes over WhatsApp, for instances
+// which prefer it to SMS.
+//
+// Messages are queued along with the SMS they replace. If WhatsApp fails to
+// accept a message, or doesn't get to it within FallbackTimeout, the SMS is
+// sent instead, so that users always receive their code.
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"clerk/api/shared/sms"
+	"clerk/model"
+	"clerk/model/sqbmodel"
+	"clerk/pkg/cenv"
+	"clerk/pkg/jobs"
+	clerkwhatsapp "clerk/pkg/whatsapp"
+	"clerk/repository"
+	"clerk/utils/clerk"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/vgarvardt/gue/v2"
+	"github.com/volatiletech/null/v8"
+)
+
+// Channels over which instances can send one-time codes to phone numbers.
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Statuses of a WhatsApp message.
+const (
+	StatusQueued     = "queued"
+	StatusSent       = "sent"
+	StatusFailed     = "failed"
+	StatusFallenBack = "fallen_back"
+)
+
+// FallbackTimeout is how long a message may stay queued before the SMS is
+// sent instead.
+const FallbackTimeout = 30 * time.Second
+
+// defaultLanguage is the language of the template of instances which
+// haven't set one
+const defaultLanguage = "en_US"
+
+var ErrProviderNotConfigured = errors.New("whatsapp: provider not configured")
+
+type Service struct {
+	clock     clockwork.Clock
+	gueClient *gue.Client
+
+	// services
+	smsService *sms.Service
+
+	// repositories
+	identificationRepo  *repository.Identification
+	whatsAppMessageRepo *repository.WhatsAppMessages
+}
+
+func NewService(deps clerk.Deps) *Service {
+	return &Service{
+		clock:               deps.Clock(),
+		gueClient:           deps.GueClient(),
+		smsService:          sms.NewService(deps),
+		identificationRepo:  deps.Repositories().Identification,
+		whatsAppMessageRepo: deps.Repositories().WhatsAppMessages,
+	}
+}
+
+// IsPreferred returns whether the instance sends one-time codes over
+// WhatsApp instead of SMS. A template is required, since WhatsApp doesn't
+// allow free-form messages.
+func IsPreferred(instance *model.Instance) bool {
+	return instance.Communication.PhoneCodeChannel.String == ChannelWhatsApp &&
+		instance.Communication.WhatsAppTemplate.Valid
+}
+
+// SendParams describe a one-time code to send over WhatsApp.
+type SendParams struct {
+	Code string
+	// Fallback is the SMS which is sent if the WhatsApp message can't be.
+	Fallback *model.SMSMessageData
+}
+
+// Send queues a WhatsApp message with the one-time code of the SMS it
+// replaces, along with the check which falls back to the SMS on timeout.
+func (s *Service) Send(ctx context.Context, tx database.Tx, params SendParams, env *model.Env) (*model.WhatsAppMessage, error) {
+	communication := env.Instance.Communication
+	msg := &model.WhatsAppMessage{WhatsAppMessage: &sqbmodel.WhatsAppMessage{
+		InstanceID:     env.Instance.ID,
+		Slug:           null.StringFromPtr(params.Fallback.Slug),
+		VerificationID: null.StringFromPtr(params.Fallback.VerificationID),
+		Provider:       providerName(env.Instance),
+		Template:       communication.WhatsAppTemplate.String,
+		Language:       communication.WhatsAppTemplateLanguage.String,
+		Code:           params.Code,
+		Status:         StatusQueued,
+		FallbackSMS:    params.Fallback.Message,
+	}}
+	if msg.Language == "" {
+		msg.Language = defaultLanguage
+	}
+
+	if params.Fallback.Identification != nil {
+		phoneNumber := params.Fallback.Identification.PhoneNumber()
+		if phoneNumber == nil {
+			return nil, fmt.Errorf("whatsapp/send: expected phone number identification, found %s instead", params.Fallback.Identification.Type)
+		}
+		msg.PhoneNumberID = null.StringFrom(params.Fallback.Identification.ID)
+		msg.UserID = params.Fallback.Identification.UserID
+		msg.ToPhoneNumber = *phoneNumber
+	} else if params.Fallback.ToPhoneNumber != nil {
+		msg.ToPhoneNumber = *params.Fallback.ToPhoneNumber
+	}
+
+	if err := s.whatsAppMessageRepo.Insert(ctx, tx, msg); err != nil {
+		return nil, fmt.Errorf("whatsapp/send: inserting message to %s: %w", msg.ToPhoneNumber, err)
+	}
+
+	err := jobs.SendWhatsApp(ctx, s.gueClient, jobs.SendWhatsAppArgs{
+		InstanceID: msg.InstanceID,
+		MessageID:  msg.ID,
+	}, jobs.WithTx(tx))
+	if err != nil {
+		return nil, fmt.Errorf("whatsapp/send: enqueuing message %s: %w", msg.ID, err)
+	}
+
+	fallbackAt := s.clock.Now().UTC().Add(FallbackTimeout)
+	err = jobs.FallbackWhatsAppToSMS(ctx, s.gueClient, jobs.FallbackWhatsAppToSMSArgs{
+		InstanceID: msg.InstanceID,
+		MessageID:  msg.ID,
+	}, jobs.WithTx(tx), jobs.WithRunAt(&fallbackAt))
+	if err != nil {
+		return nil, fmt.Errorf("whatsapp/send: enqueuing fallback of message %s: %w", msg.ID, err)
+	}
+	return msg, nil
+}
+
+// Deliver hands the queued message over to the provider of the instance.
+// It's run by the send_whatsapp job. If the provider rejects the message,
+// the SMS is sent right away instead of waiting for the timeout.
+func (s *Service) Deliver(ctx context.Context, tx database.Tx, env *model.Env, messageID string) error {
+	msg, err := s.whatsAppMessageRepo.FindByIDAndInstance(ctx, tx, messageID, env.Instance.ID)
+	if err != nil {
+		return fmt.Errorf("whatsapp/deliver: fetching message %s: %w", messageID, err)
+	}
+	if msg.Status != StatusQueued {
+		// the timeout expired before we got to it
+		return nil
+	}
+
+	providerMessageID, err := s.send(ctx, msg)
+	if err != nil {
+		log.Warning(ctx, "whatsapp/deliver: sending message %s through %s, falling back to SMS: %s", messageID, msg.Provider, err)
+		msg.FailureReason = null.StringFrom(err.Error())
+		return s.fallBack(ctx, tx, env, msg)
+	}
+
+	msg.Status = StatusSent
+	msg.ProviderMessageID = null.StringFrom(providerMessageID)
+	if err := s.whatsAppMessageRepo.Update(ctx, tx, msg,
+		sqbmodel.WhatsAppMessageColumns.Status,
+		sqbmodel.WhatsAppMessageColumns.ProviderMessageID,
+	); err != nil {
+		return fmt.Errorf("whatsapp/deliver: updating message %s: %w", messageID, err)
+	}
+	return nil
+}
+
+func (s *Service) send(ctx context.Context, msg *model.WhatsAppMessage) (string, error) {
+	provider, err := newProvider(msg.Provider)
+	if err != nil {
+		return "", err
+	}
+	return provider.Send(ctx, clerkwhatsapp.Message{
+		To:         msg.ToPhoneNumber,
+		Template:   msg.Template,
+		Language:   msg.Language,
+		Parameters: []string{msg.Code},
+		CopyCode:   msg.Code,
+	})
+}
+
+// FallbackToSMS sends the SMS of a message which wasn't accepted by the
+// provider within FallbackTimeout. It's run by the
+// fallback_whatsapp_to_sms job.
+func (s *Service) FallbackToSMS(ctx context.Context, tx database.Tx, env *model.Env, messageID string) error {
+	msg, err := s.whatsAppMessageRepo.FindByIDAndInstance(ctx, tx, messageID, env.Instance.ID)
+	if err != nil {
+		return fmt.Errorf("whatsapp/fallbackToSMS: fetching message %s: %w", messageID, err)
+	}
+	if msg.Status != StatusQueued && msg.Status != StatusFailed {
+		return nil
+	}
+	return s.fallBack(ctx, tx, env, msg)
+}
+
+func (s *Service) fallBack(ctx context.Context, tx database.Tx, env *model.Env, msg *model.WhatsAppMessage) error {
+	smsData := &model.SMSMessageData{
+		Message:          msg.FallbackSMS,
+		Slug:             msg.Slug.Ptr(),
+		VerificationID:   msg.VerificationID.Ptr(),
+		ToPhoneNumber:    &msg.ToPhoneNumber,
+		DeliveredByClerk: true,
+	}
+	if msg.PhoneNumberID.Valid {
+		identification, err := s.identificationRepo.QueryByID(ctx, tx, msg.PhoneNumberID.String)
+		if err != nil {
+			return fmt.Errorf("whatsapp/fallBack: fetching phone number %s: %w", msg.PhoneNumberID.String, err)
+		}
+		// the phone number may have been deleted since
+		smsData.Identification = identification
+	}
+
+	if _, err := s.smsService.Send(ctx, tx, smsData, env); err != nil {
+		return fmt.Errorf("whatsapp/fallBack: sending SMS for message %s: %w", msg.ID, err)
+	}
+
+	msg.Status = StatusFallenBack
+	if err := s.whatsAppMessageRepo.Update(ctx, tx, msg,
+		sqbmodel.WhatsAppMessageColumns.Status,
+		sqbmodel.WhatsAppMessageColumns.FailureReason,
+	); err != nil {
+		return fmt.Errorf("whatsapp/fallBack: updating message %s: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// providerName returns the provider the instance sends messages through,
+// Twilio unless it chose Meta.
+func providerName(instance *model.Instance) string {
+	if instance.Communication.WhatsAppProvider.String == clerkwhatsapp.ProviderMeta {
+		return clerkwhatsapp.ProviderMeta
+	}
+	return clerkwhatsapp.ProviderTwilio
+}
+
+func newProvider(name string) (clerkwhatsapp.Provider, error) {
+	switch name {
+	case clerkwhatsapp.ProviderTwilio:
+		if !cenv.IsSet(cenv.TwilioWhatsAppFromNumber) {
+			return nil, ErrProviderNotConfigured
+		}
+		return clerkwhatsapp.NewTwilio(cenv.Get(cenv.TwilioAccountSID), cenv.Get(cenv.TwilioAuthToken), cenv.Get(cenv.TwilioWhatsAppFromNumber)), nil
+	case clerkwhatsapp.ProviderMeta:
+		if !cenv.IsSet(cenv.MetaWhatsAppPhoneNumberID) {
+			return nil, ErrProviderNotConfigured
+		}
+		return clerkwhatsapp.NewMeta(cenv.Get(cenv.MetaWhatsAppPhoneNumberID), cenv.Get(cenv.MetaWhatsAppAccessToken)), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotConfigured, name)
+	}
+}
@@ -0,0 +1,228 @@
+// Package anomaly scores sign-in attempts by how much they deviate from the
+// previous activity of the user.
+//
+// An attempt is compared against the locations the user recently signed in
+// from and against the failed attempts recently made from the same IP
+// address. Each anomaly found contributes to a risk score from 0 to 100,
+// which instances can act upon by requiring a second factor or by blocking
+// the sign-in altogether.
+package anomaly
+
+import (
+	"math"
+	"time"
+)
+
+// Signal is an anomaly detected on a sign-in attempt.
+type Signal string
+
+const (
+	// SignalNewCountry means that the user never signed in from the country
+	// of the attempt before.
+	SignalNewCountry Signal = "new_country"
+	// SignalImpossibleTravel means that the user would have to travel faster
+	// than an airplane to make the attempt after their latest sign-in.
+	SignalImpossibleTravel Signal = "impossible_travel"
+	// SignalCredentialStuffing means that many sign-ins failed from the IP
+	// address of the attempt in a short period of time.
+	SignalCredentialStuffing Signal = "credential_stuffing"
+)
+
+// weights of each signal in the risk score
+var weights = map[Signal]int{
+	SignalNewCountry:         30,
+	SignalImpossibleTravel:   50,
+	SignalCredentialStuffing: 40,
+}
+
+const (
+	// MaxScore is the score of the riskiest attempts.
+	MaxScore = 100
+
+	// MaxTravelSpeedKmh is the fastest we expect a user to travel between
+	// two sign-ins, roughly the cruise speed of an airliner.
+	MaxTravelSpeedKmh = 1000
+
+	// MinTravelDistanceKm is the distance under which two sign-ins are
+	// considered to happen at the same place, since geolocating an IP
+	// address isn't precise.
+	MinTravelDistanceKm = 100
+
+	// BurstThreshold is the number of failed sign-ins from an IP address,
+	// within BurstWindow, after which attempts from it are considered to be
+	// credential stuffing.
+	BurstThreshold = 10
+
+	// BurstWindow is the period over which failed sign-ins are counted.
+	BurstWindow = 10 * time.Minute
+
+	earthRadiusKm = 6371
+)
+
+// Location is where and when a sign-in happened. Coordinates are optional,
+// as the IP address may not be geolocated.
+type Location struct {
+	IPAddress string
+	Country   string
+	Latitude  *float64
+	Longitude *float64
+	At        time.Time
+}
+
+func (l Location) hasCoordinates() bool {
+	return l.Latitude != nil && l.Longitude != nil
+}
+
+// Attempt is a sign-in attempt to be scored.
+type Attempt struct {
+	Location
+	// RecentFailures is the number of failed sign-ins from the IP address
+	// of the attempt within BurstWindow.
+	RecentFailures int
+}
+
+// Action is what should happen to a sign-in attempt, given its score.
+type Action string
+
+const (
+	ActionAllow  Action = "allow"
+	ActionStepUp Action = "step_up"
+	ActionBlock  Action = "block"
+)
+
+// Assessment is the outcome of scoring a sign-in attempt.
+type Assessment struct {
+	Score   int
+	Signals []Signal
+}
+
+// Action returns what should happen to the attempt given the thresholds of
+// the instance. A zero threshold disables the respective action.
+func (a Assessment) Action(stepUpThreshold, blockThreshold int) Action {
+	switch {
+	case blockThreshold > 0 && a.Score >= blockThreshold:
+		return ActionBlock
+	case stepUpThreshold > 0 && a.Score >= stepUpThreshold:
+		return ActionStepUp
+	default:
+		return ActionAllow
+	}
+}
+
+// Score assesses the attempt against the previous sign-ins of the user, in
+// any order. Users without any previous sign-ins can't have anomalous
+// locations, so only credential stuffing applies to them.
+func Score(attempt Attempt, history []Location) Assessment {
+	var assessment Assessment
+	if isNewCountry(attempt.Location, history) {
+		assessment.add(SignalNewCountry)
+	}
+	if isImpossibleTravel(attempt.Location, history) {
+		assessment.add(SignalImpossibleTravel)
+	}
+	if attempt.RecentFailures >= BurstThreshold {
+		assessment.add(SignalCredentialStuffing)
+	}
+	return assessment
+}
+
+func (a *Assessment) add(signal Signal) {
+	a.Signals = append(a.Signals, signal)
+	a.Score += weights[signal]
+	if a.Score > MaxScore {
+		a.Score = MaxScore
+	}
+}
+
+func isNewCountry(attempt Location, history []Location) bool {
+	if attempt.Country == "" {
+		return false
+	}
+	known := false
+	for _, location := range history {
+		if location.Country == "" {
+			continue
+		}
+		if location.Country == attempt.Country {
+			return false
+		}
+		known = true
+	}
+	return known
+}
+
+// isImpossibleTravel compares the attempt with the latest previous sign-in
+// that has coordinates.
+func isImpossibleTravel(attempt Location, history []Location) bool {
+	if !attempt.hasCoordinates() {
+		return false
+	}
+
+	var latest *Location
+	for i := range history {
+		location := &history[i]
+		if !location.hasCoordinates() || location.At.After(attempt.At) {
+			continue
+		}
+		if latest == nil || location.At.After(latest.At) {
+			latest = location
+		}
+	}
+	if latest == nil {
+		return false
+	}
+
+	distance := DistanceKm(*latest, attempt)
+	if distance < MinTravelDistanceKm {
+		return false
+	}
+	hours := attempt.At.Sub(latest.At).Hours()
+	return hours <= 0 || distance/hours > MaxTravelSpeedKmh
+}
+
+// DistanceKm returns the great-circle distance between two locations with
+// coordinates.
+func DistanceKm(a, b Location) float64 {
+	lat1, lat2 := radians(*a.Latitude), radians(*b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(*b.Longitude - *a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// Failures keeps the times of the recent failed sign-ins from an IP address.
+type Failures struct {
+	Times []time.Time `json:"times"`
+}
+
+// Record adds a failed sign-in at the given time, and forgets the ones
+// outside of BurstWindow.
+func (f *Failures) Record(now time.Time) {
+	f.prune(now)
+	// there's no point in keeping more failures than needed to detect a burst
+	if len(f.Times) >= BurstThreshold {
+		f.Times = f.Times[len(f.Times)-BurstThreshold+1:]
+	}
+	f.Times = append(f.Times, now)
+}
+
+// Count returns the number of failed sign-ins within BurstWindow.
+func (f *Failures) Count(now time.Time) int {
+	f.prune(now)
+	return len(f.Times)
+}
+
+func (f *Failures) prune(now time.Time) {
+	cutoff := now.Add(-BurstWindow)
+	i := 0
+	for i < len(f.Times) && !f.Times[i].After(cutoff) {
+		i++
+	}
+	f.Times = f.Times[i:]
+}
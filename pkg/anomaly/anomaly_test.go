@@ -0,0 +1,102 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func coordinates(lat, lon float64) (*float64, *float64) {
+	return &lat, &lon
+}
+
+func TestScore(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	athensLat, athensLon := coordinates(37.98, 23.73)
+	londonLat, londonLon := coordinates(51.51, -0.13)
+	piraeusLat, piraeusLon := coordinates(37.94, 23.65)
+
+	history := []Location{
+		{Country: "GR", Latitude: athensLat, Longitude: athensLon, At: now.Add(-time.Hour)},
+		{Country: "GR", At: now.Add(-48 * time.Hour)},
+	}
+
+	tests := []struct {
+		name    string
+		attempt Attempt
+		history []Location
+		want    Assessment
+	}{
+		{
+			name:    "no history",
+			attempt: Attempt{Location: Location{Country: "GB", Latitude: londonLat, Longitude: londonLon, At: now}},
+			want:    Assessment{},
+		},
+		{
+			name:    "same place",
+			attempt: Attempt{Location: Location{Country: "GR", Latitude: piraeusLat, Longitude: piraeusLon, At: now}},
+			history: history,
+			want:    Assessment{},
+		},
+		{
+			name:    "new country without coordinates",
+			attempt: Attempt{Location: Location{Country: "GB", At: now}},
+			history: history,
+			want:    Assessment{Score: 30, Signals: []Signal{SignalNewCountry}},
+		},
+		{
+			name:    "impossible travel",
+			attempt: Attempt{Location: Location{Country: "GB", Latitude: londonLat, Longitude: londonLon, At: now}},
+			history: history,
+			want:    Assessment{Score: 80, Signals: []Signal{SignalNewCountry, SignalImpossibleTravel}},
+		},
+		{
+			name:    "possible travel",
+			attempt: Attempt{Location: Location{Country: "GB", Latitude: londonLat, Longitude: londonLon, At: now.Add(5 * time.Hour)}},
+			history: history,
+			want:    Assessment{Score: 30, Signals: []Signal{SignalNewCountry}},
+		},
+		{
+			name: "everything",
+			attempt: Attempt{
+				Location:       Location{Country: "GB", Latitude: londonLat, Longitude: londonLon, At: now},
+				RecentFailures: BurstThreshold,
+			},
+			history: history,
+			want:    Assessment{Score: MaxScore, Signals: []Signal{SignalNewCountry, SignalImpossibleTravel, SignalCredentialStuffing}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tc.want, Score(tc.attempt, tc.history))
+		})
+	}
+}
+
+func TestAssessment_Action(t *testing.T) {
+	t.Parallel()
+
+	assessment := Assessment{Score: 50}
+	assert.Equal(t, ActionAllow, assessment.Action(0, 0))
+	assert.Equal(t, ActionAllow, assessment.Action(60, 90))
+	assert.Equal(t, ActionStepUp, assessment.Action(50, 90))
+	assert.Equal(t, ActionBlock, assessment.Action(30, 50))
+	assert.Equal(t, ActionBlock, assessment.Action(0, 40))
+}
+
+func TestFailures(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var failures Failures
+	for i := 0; i < 2*BurstThreshold; i++ {
+		failures.Record(now.Add(time.Duration(i) * time.Second))
+	}
+	assert.Equal(t, BurstThreshold, failures.Count(now.Add(time.Minute)))
+	assert.Equal(t, 0, failures.Count(now.Add(BurstWindow+time.Minute)))
+}
@@ -0,0 +1,152 @@
+// Package botdetection scores sign-up requests by how likely they are to come
+// from a bot.
+//
+// Detectors are pluggable. Challenge providers, like Turnstile or hCaptcha,
+// score a request by verifying the token of the challenge the client solved,
+// while the heuristic detector scores it by looking at the request itself
+// and at how many sign-ups were recently made with the same client
+// fingerprint. All of them result in a score from 0 to 100, which instances
+// can act upon by challenging or blocking the sign-up.
+package botdetection
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderTurnstile = "turnstile"
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderHeuristic = "heuristic"
+
+	// MaxScore is the score of requests which surely come from a bot.
+	MaxScore = 100
+
+	requestTimeout = 5 * time.Second
+
+	// the maximum size of the provider response we read
+	maxResponseSize = 64 * 1024
+)
+
+// Providers are the detectors instances can choose from.
+var Providers = []string{ProviderTurnstile, ProviderHCaptcha, ProviderHeuristic}
+
+// Signal is a sign of automation detected on a request.
+type Signal string
+
+const (
+	// SignalChallengeFailed means that the client didn't solve the challenge
+	// of the provider, or sent an invalid token for it.
+	SignalChallengeFailed Signal = "challenge_failed"
+	// SignalChallengeScore means that the provider solved the challenge, but
+	// scored the request as risky.
+	SignalChallengeScore Signal = "challenge_score"
+	// SignalMissingUserAgent means that the request had no user agent.
+	SignalMissingUserAgent Signal = "missing_user_agent"
+	// SignalAutomationUserAgent means that the user agent belongs to an HTTP
+	// library or a headless browser.
+	SignalAutomationUserAgent Signal = "automation_user_agent"
+	// SignalMissingAcceptLanguage means that the request had no
+	// Accept-Language header, which browsers always send.
+	SignalMissingAcceptLanguage Signal = "missing_accept_language"
+	// SignalFingerprintVelocity means that many sign-ups were made with the
+	// same client fingerprint in a short period of time.
+	SignalFingerprintVelocity Signal = "fingerprint_velocity"
+)
+
+// ErrUnexpectedStatus is returned when a provider fails to verify a request.
+var ErrUnexpectedStatus = errors.New("botdetection: unexpected status")
+
+// Request is a sign-up request to be scored.
+type Request struct {
+	// Token is the token of the challenge the client solved, if any.
+	Token          string
+	RemoteIP       string
+	UserAgent      string
+	AcceptLanguage string
+	// Host is the host the request was made from, as challenges are bound to
+	// it.
+	Host string
+	// RecentSignUps is the number of sign-ups made with the fingerprint of
+	// the client within VelocityWindow.
+	RecentSignUps int
+}
+
+// Result is the outcome of scoring a request.
+type Result struct {
+	Score   int
+	Signals []Signal
+	// Challenged is true for requests whose client solved a challenge.
+	Challenged bool
+}
+
+func (r *Result) add(signal Signal, weight int) {
+	r.Signals = append(r.Signals, signal)
+	r.Score += weight
+	if r.Score > MaxScore {
+		r.Score = MaxScore
+	}
+}
+
+// Action is what should happen to a sign-up, given its score.
+type Action string
+
+const (
+	ActionAllow     Action = "allow"
+	ActionChallenge Action = "challenge"
+	ActionBlock     Action = "block"
+)
+
+// Action returns what should happen to the sign-up given the thresholds of
+// the instance. A zero threshold disables the respective action. Requests
+// which already solved a challenge aren't challenged again.
+func (r Result) Action(challengeThreshold, blockThreshold int) Action {
+	switch {
+	case blockThreshold > 0 && r.Score >= blockThreshold:
+		return ActionBlock
+	case challengeThreshold > 0 && r.Score >= challengeThreshold && !r.Challenged:
+		return ActionChallenge
+	default:
+		return ActionAllow
+	}
+}
+
+// Detector scores requests.
+type Detector interface {
+	Name() string
+	Score(ctx context.Context, request Request) (*Result, error)
+}
+
+// Fingerprint identifies the client of a request across sign-ups. The
+// client may send a fingerprint of its own, e.g. computed from the canvas or
+// the fonts of the browser, which is combined with what the request tells
+// about it.
+func Fingerprint(remoteIP, userAgent, acceptLanguage, clientFingerprint string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{remoteIP, userAgent, acceptLanguage, clientFingerprint}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Error is returned when the provider fails to verify a request.
+type Error struct {
+	Provider   string
+	StatusCode int
+	Response   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %d from %s: %s", ErrUnexpectedStatus, e.StatusCode, e.Provider, e.Response)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
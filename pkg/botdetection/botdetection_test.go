@@ -0,0 +1,142 @@
+package botdetection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const browserUserAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func TestHeuristicScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request Request
+		want    *Result
+	}{
+		{
+			name:    "browser",
+			request: Request{UserAgent: browserUserAgent, AcceptLanguage: "en-US,en;q=0.9"},
+			want:    &Result{},
+		},
+		{
+			name:    "missing user agent",
+			request: Request{AcceptLanguage: "en-US"},
+			want:    &Result{Score: 60, Signals: []Signal{SignalMissingUserAgent}},
+		},
+		{
+			name:    "http library",
+			request: Request{UserAgent: "python-requests/2.31.0"},
+			want:    &Result{Score: 80, Signals: []Signal{SignalAutomationUserAgent, SignalMissingAcceptLanguage}},
+		},
+		{
+			name:    "headless browser",
+			request: Request{UserAgent: "Mozilla/5.0 HeadlessChrome/120.0.0.0", AcceptLanguage: "en-US"},
+			want:    &Result{Score: 60, Signals: []Signal{SignalAutomationUserAgent}},
+		},
+		{
+			name:    "fingerprint velocity",
+			request: Request{UserAgent: browserUserAgent, AcceptLanguage: "en-US", RecentSignUps: VelocityThreshold},
+			want:    &Result{Score: 50, Signals: []Signal{SignalFingerprintVelocity}},
+		},
+		{
+			name:    "capped",
+			request: Request{UserAgent: "curl/8.4.0", RecentSignUps: VelocityThreshold + 1},
+			want:    &Result{Score: MaxScore, Signals: []Signal{SignalAutomationUserAgent, SignalMissingAcceptLanguage, SignalFingerprintVelocity}},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewHeuristic().Score(context.Background(), tc.request)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestResultAction(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ActionAllow, Result{Score: 40}.Action(50, 80))
+	assert.Equal(t, ActionChallenge, Result{Score: 50}.Action(50, 80))
+	assert.Equal(t, ActionAllow, Result{Score: 50, Challenged: true}.Action(50, 80))
+	assert.Equal(t, ActionBlock, Result{Score: 80}.Action(50, 80))
+	assert.Equal(t, ActionBlock, Result{Score: 100, Challenged: true}.Action(50, 80))
+	assert.Equal(t, ActionAllow, Result{Score: 100}.Action(0, 0))
+}
+
+func TestSignUps(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var signUps SignUps
+	signUps.Record(now.Add(-2 * VelocityWindow))
+	for i := 0; i < VelocityThreshold+2; i++ {
+		signUps.Record(now.Add(-time.Duration(VelocityThreshold+2-i) * time.Minute))
+	}
+	assert.Equal(t, VelocityThreshold, signUps.Count(now))
+	assert.Equal(t, 0, signUps.Count(now.Add(VelocityWindow)))
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := Fingerprint("1.2.3.4", browserUserAgent, "en-US", "")
+	assert.Len(t, fingerprint, 64)
+	assert.Equal(t, fingerprint, Fingerprint("1.2.3.4", browserUserAgent, "en-US", ""))
+	assert.NotEqual(t, fingerprint, Fingerprint("1.2.3.4", browserUserAgent, "en-US", "canvas"))
+}
+
+func TestHCaptchaScore(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/siteverify", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "sitekey", r.PostForm.Get("sitekey"))
+		assert.Equal(t, "1.2.3.4", r.PostForm.Get("remoteip"))
+
+		switch r.PostForm.Get("response") {
+		case "human":
+			_, _ = w.Write([]byte(`{"success": true, "hostname": "example.com", "score": 0.1}`))
+		case "elsewhere":
+			_, _ = w.Write([]byte(`{"success": true, "hostname": "evil.com"}`))
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	hCaptcha := NewHCaptcha("sitekey", "secret")
+	hCaptcha.baseURL = server.URL
+	request := Request{RemoteIP: "1.2.3.4", Host: "example.com"}
+
+	request.Token = "human"
+	result, err := hCaptcha.Score(context.Background(), request)
+	require.NoError(t, err)
+	assert.Equal(t, &Result{Score: 10, Signals: []Signal{SignalChallengeScore}, Challenged: true}, result)
+
+	failed := &Result{Score: MaxScore, Signals: []Signal{SignalChallengeFailed}}
+	for _, token := range []string{"", "elsewhere", "invalid"} {
+		request.Token = token
+		result, err = hCaptcha.Score(context.Background(), request)
+		require.NoError(t, err)
+		assert.Equal(t, failed, result, token)
+	}
+
+	request.Token = "broken"
+	_, err = hCaptcha.Score(context.Background(), request)
+	assert.ErrorIs(t, err, ErrUnexpectedStatus)
+}
@@ -0,0 +1,96 @@
+package botdetection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const hCaptchaBaseURL = "https://api.hcaptcha.com"
+
+// HCaptcha verifies the tokens of hCaptcha challenges.
+type HCaptcha struct {
+	baseURL    string
+	httpClient *http.Client
+
+	siteKey   string
+	secretKey string
+}
+
+func NewHCaptcha(siteKey, secretKey string) *HCaptcha {
+	return &HCaptcha{
+		baseURL:    hCaptchaBaseURL,
+		httpClient: newHTTPClient(),
+		siteKey:    siteKey,
+		secretKey:  secretKey,
+	}
+}
+
+func (*HCaptcha) Name() string {
+	return ProviderHCaptcha
+}
+
+// Score verifies the token of the request. Requests without a valid token
+// get the maximum score. Otherwise, the score is the risk score of hCaptcha
+// Enterprise, if there's one.
+func (h *HCaptcha) Score(ctx context.Context, request Request) (*Result, error) {
+	result := &Result{}
+	if request.Token == "" {
+		result.add(SignalChallengeFailed, MaxScore)
+		return result, nil
+	}
+
+	form := url.Values{}
+	form.Set("secret", h.secretKey)
+	form.Set("sitekey", h.siteKey)
+	form.Set("response", request.Token)
+	if request.RemoteIP != "" {
+		form.Set("remoteip", request.RemoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+"/siteverify", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("botdetection/hcaptcha: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("botdetection/hcaptcha: verifying token: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, &Error{Provider: ProviderHCaptcha, StatusCode: res.StatusCode, Response: string(body)}
+	}
+
+	var response struct {
+		Success  bool   `json:"success"`
+		Hostname string `json:"hostname"`
+		// Score is only returned by hCaptcha Enterprise, from 0 for humans
+		// to 1 for bots.
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("botdetection/hcaptcha: decoding response: %w", err)
+	}
+
+	// a token solved on another host was most likely relayed by a bot
+	if !response.Success || (request.Host != "" && response.Hostname != "" && response.Hostname != request.Host) {
+		result.add(SignalChallengeFailed, MaxScore)
+		return result, nil
+	}
+
+	result.Challenged = true
+	if response.Score != nil {
+		if score := int(math.Round(*response.Score * MaxScore)); score > 0 {
+			result.add(SignalChallengeScore, score)
+		}
+	}
+	return result, nil
+}
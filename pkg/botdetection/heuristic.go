@@ -0,0 +1,113 @@
+package botdetection
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// weights of each heuristic signal in the score
+var weights = map[Signal]int{
+	SignalMissingUserAgent:      60,
+	SignalAutomationUserAgent:   60,
+	SignalMissingAcceptLanguage: 20,
+	SignalFingerprintVelocity:   50,
+}
+
+const (
+	// VelocityThreshold is the number of sign-ups with the same fingerprint,
+	// within VelocityWindow, after which more of them are considered to be
+	// automated.
+	VelocityThreshold = 5
+
+	// VelocityWindow is the period over which sign-ups are counted.
+	VelocityWindow = time.Hour
+)
+
+// automationUserAgents are substrings of the user agents of HTTP libraries
+// and headless browsers, in lowercase.
+var automationUserAgents = []string{
+	"curl/",
+	"wget/",
+	"python-requests",
+	"python-urllib",
+	"aiohttp",
+	"go-http-client",
+	"okhttp",
+	"axios/",
+	"node-fetch",
+	"headlesschrome",
+	"phantomjs",
+	"selenium",
+	"puppeteer",
+	"playwright",
+}
+
+// Heuristic scores requests without a challenge, so it works for any
+// client.
+type Heuristic struct{}
+
+func NewHeuristic() *Heuristic {
+	return &Heuristic{}
+}
+
+func (*Heuristic) Name() string {
+	return ProviderHeuristic
+}
+
+func (*Heuristic) Score(_ context.Context, request Request) (*Result, error) {
+	result := &Result{}
+	userAgent := strings.ToLower(strings.TrimSpace(request.UserAgent))
+	if userAgent == "" {
+		result.add(SignalMissingUserAgent, weights[SignalMissingUserAgent])
+	} else if isAutomationUserAgent(userAgent) {
+		result.add(SignalAutomationUserAgent, weights[SignalAutomationUserAgent])
+	}
+	if strings.TrimSpace(request.AcceptLanguage) == "" {
+		result.add(SignalMissingAcceptLanguage, weights[SignalMissingAcceptLanguage])
+	}
+	if request.RecentSignUps >= VelocityThreshold {
+		result.add(SignalFingerprintVelocity, weights[SignalFingerprintVelocity])
+	}
+	return result, nil
+}
+
+func isAutomationUserAgent(userAgent string) bool {
+	for _, automation := range automationUserAgents {
+		if strings.Contains(userAgent, automation) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignUps keeps the times of the recent sign-ups with a fingerprint.
+type SignUps struct {
+	Times []time.Time `json:"times"`
+}
+
+// Record adds a sign-up at the given time, and forgets the ones outside of
+// VelocityWindow.
+func (s *SignUps) Record(now time.Time) {
+	s.prune(now)
+	// there's no point in keeping more sign-ups than needed to detect a burst
+	if len(s.Times) >= VelocityThreshold {
+		s.Times = s.Times[len(s.Times)-VelocityThreshold+1:]
+	}
+	s.Times = append(s.Times, now)
+}
+
+// Count returns the number of sign-ups within VelocityWindow.
+func (s *SignUps) Count(now time.Time) int {
+	s.prune(now)
+	return len(s.Times)
+}
+
+func (s *SignUps) prune(now time.Time) {
+	cutoff := now.Add(-VelocityWindow)
+	i := 0
+	for i < len(s.Times) && !s.Times[i].After(cutoff) {
+		i++
+	}
+	s.Times = s.Times[i:]
+}
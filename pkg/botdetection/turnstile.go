@@ -0,0 +1,47 @@
+package botdetection
+
+import (
+	"context"
+)
+
+// TurnstileVerifier verifies the token of a Turnstile challenge solved on the
+// given host.
+type TurnstileVerifier interface {
+	Verify(ctx context.Context, host, token string) (bool, error)
+}
+
+// Turnstile verifies the tokens of Cloudflare Turnstile challenges. The
+// verification itself is left to the verifier, as it depends on the widget
+// the client rendered.
+type Turnstile struct {
+	verifier TurnstileVerifier
+}
+
+func NewTurnstile(verifier TurnstileVerifier) *Turnstile {
+	return &Turnstile{verifier: verifier}
+}
+
+func (*Turnstile) Name() string {
+	return ProviderTurnstile
+}
+
+// Score verifies the token of the request. Turnstile doesn't score requests,
+// so they're either verified or get the maximum score.
+func (t *Turnstile) Score(ctx context.Context, request Request) (*Result, error) {
+	result := &Result{}
+	if request.Token == "" {
+		result.add(SignalChallengeFailed, MaxScore)
+		return result, nil
+	}
+
+	ok, err := t.verifier.Verify(ctx, request.Host, request.Token)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		result.add(SignalChallengeFailed, MaxScore)
+		return result, nil
+	}
+	result.Challenged = true
+	return result, nil
+}
@@ -0,0 +1,187 @@
+// Package config loads configuration values into typed fields and validates
+// them, so that services fail at startup on a bad value instead of
+// misbehaving once it's used.
+//
+// Values are read from a Source by key. A Loader converts them to the type
+// of the field, checks them against the allowed range and collects every
+// problem, so that all of them are reported at once.
+//
+// A few settings can also be changed while the service is running. These
+// are kept in Tunables, which the Reloader updates from a Source whenever
+// it changes.
+package config
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Source looks up configuration values by key.
+type Source interface {
+	Lookup(key string) (string, bool)
+}
+
+// SourceFunc adapts a function to a Source.
+type SourceFunc func(key string) (string, bool)
+
+func (f SourceFunc) Lookup(key string) (string, bool) {
+	return f(key)
+}
+
+// MapSource is a Source backed by a map.
+type MapSource map[string]string
+
+func (s MapSource) Lookup(key string) (string, bool) {
+	value, ok := s[key]
+	return value, ok
+}
+
+// FileSource reads a Source from a JSON file, which holds an object of keys
+// to values. Values can be strings, numbers or booleans.
+func FileSource(path string) (MapSource, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: reading %s: %w", path, err)
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(content, &values); err != nil {
+		return nil, fmt.Errorf("config: decoding %s: %w", path, err)
+	}
+
+	source := make(MapSource, len(values))
+	for key, value := range values {
+		switch v := value.(type) {
+		case string:
+			source[key] = v
+		case float64:
+			source[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			source[key] = strconv.FormatBool(v)
+		default:
+			return nil, fmt.Errorf("config: %s in %s is neither a string, a number nor a boolean", key, path)
+		}
+	}
+	return source, nil
+}
+
+// Range limits numeric values to [Min, Max]. The zero Range allows any
+// value.
+type Range[T cmp.Ordered] struct {
+	Min T
+	Max T
+}
+
+func (r Range[T]) check(key string, value T) error {
+	var zero T
+	if r.Min == zero && r.Max == zero {
+		return nil
+	}
+	if value < r.Min || value > r.Max {
+		return fmt.Errorf("config: %s is %v, must be between %v and %v", key, value, r.Min, r.Max)
+	}
+	return nil
+}
+
+// Loader reads typed values from a Source. Values which are missing get
+// their default, while values which are invalid also get their default and
+// are reported by Err.
+type Loader struct {
+	source Source
+	errs   []error
+}
+
+func NewLoader(source Source) *Loader {
+	return &Loader{source: source}
+}
+
+// Err returns the problems with all the values loaded so far, or nil if
+// there were none.
+func (l *Loader) Err() error {
+	return errors.Join(l.errs...)
+}
+
+func (l *Loader) fail(err error) {
+	l.errs = append(l.errs, err)
+}
+
+func (l *Loader) lookup(key string) (string, bool) {
+	value, ok := l.source.Lookup(key)
+	return value, ok && value != ""
+}
+
+// String returns the value of a key which is required.
+func (l *Loader) String(key string) string {
+	value, ok := l.lookup(key)
+	if !ok {
+		l.fail(fmt.Errorf("config: %s is required", key))
+	}
+	return value
+}
+
+// OptionalString returns the value of the key, or def if it's missing.
+func (l *Loader) OptionalString(key, def string) string {
+	if value, ok := l.lookup(key); ok {
+		return value
+	}
+	return def
+}
+
+// Bool returns the value of the key as a boolean, or def if it's missing.
+func (l *Loader) Bool(key string, def bool) bool {
+	value, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail(fmt.Errorf("config: %s is %q, must be a boolean", key, value))
+		return def
+	}
+	return parsed
+}
+
+// Int returns the value of the key as an integer within r, or def if it's
+// missing.
+func (l *Loader) Int(key string, def int, r Range[int]) int {
+	value, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(fmt.Errorf("config: %s is %q, must be an integer", key, value))
+		return def
+	}
+	if err := r.check(key, parsed); err != nil {
+		l.fail(err)
+		return def
+	}
+	return parsed
+}
+
+// Duration returns the value of the key, which is an integer number of
+// units, as a duration within r, or def if it's missing. E.g. a key which
+// holds seconds is loaded with time.Second as its unit.
+func (l *Loader) Duration(key string, def, unit time.Duration, r Range[time.Duration]) time.Duration {
+	value, ok := l.lookup(key)
+	if !ok {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(fmt.Errorf("config: %s is %q, must be an integer", key, value))
+		return def
+	}
+	duration := time.Duration(parsed) * unit
+	if err := r.check(key, duration); err != nil {
+		l.fail(err)
+		return def
+	}
+	return duration
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+
+	loader := NewLoader(MapSource{
+		"PORT":            "8080",
+		"DEBUG":           "true",
+		"TIMEOUT_SECONDS": "30",
+		"EMPTY":           "",
+	})
+	assert.Equal(t, "8080", loader.String("PORT"))
+	assert.Equal(t, "fallback", loader.OptionalString("EMPTY", "fallback"))
+	assert.True(t, loader.Bool("DEBUG", false))
+	assert.Equal(t, 8080, loader.Int("PORT", 0, Range[int]{Min: 1, Max: 65535}))
+	assert.Equal(t, 30*time.Second, loader.Duration("TIMEOUT_SECONDS", 0, time.Second, Range[time.Duration]{}))
+	assert.Equal(t, 5, loader.Int("MISSING", 5, Range[int]{}))
+	require.NoError(t, loader.Err())
+}
+
+func TestLoaderReportsEveryProblem(t *testing.T) {
+	t.Parallel()
+
+	loader := NewLoader(MapSource{
+		"DEBUG":           "maybe",
+		"TIMEOUT_SECONDS": "120",
+	})
+	assert.Equal(t, "", loader.String("PORT"))
+	assert.False(t, loader.Bool("DEBUG", false))
+	// invalid values get their default
+	assert.Equal(t, 10*time.Second, loader.Duration("TIMEOUT_SECONDS", 10*time.Second, time.Second, Range[time.Duration]{Max: time.Minute}))
+
+	err := loader.Err()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "PORT is required")
+	assert.ErrorContains(t, err, "DEBUG is \"maybe\"")
+	assert.ErrorContains(t, err, "TIMEOUT_SECONDS is 2m0s")
+}
+
+func TestReloader(t *testing.T) {
+	t.Parallel()
+
+	requests := NewTunable(100)
+	period := NewTunable(10 * time.Second)
+	reloader := NewReloader()
+	reloader.Int("RATE_LIMIT_REQUESTS", requests, Range[int]{Max: 1000})
+	reloader.Duration("RATE_LIMIT_PERIOD_SECONDS", period, time.Second, Range[time.Duration]{Max: time.Hour})
+	assert.Equal(t, []string{"RATE_LIMIT_PERIOD_SECONDS", "RATE_LIMIT_REQUESTS"}, reloader.Keys())
+
+	// missing keys keep their value, unregistered keys are ignored
+	require.NoError(t, reloader.Reload(MapSource{"RATE_LIMIT_REQUESTS": "200", "PORT": "9090"}))
+	assert.Equal(t, 200, requests.Get())
+	assert.Equal(t, 10*time.Second, period.Get())
+
+	// nothing applies if any value is invalid
+	err := reloader.Reload(MapSource{"RATE_LIMIT_REQUESTS": "300", "RATE_LIMIT_PERIOD_SECONDS": "-1"})
+	require.Error(t, err)
+	assert.Equal(t, 200, requests.Get())
+	assert.Equal(t, 10*time.Second, period.Get())
+}
+
+func TestFileSource(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "tunables.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"RATE_LIMIT_REQUESTS": 200, "DEBUG": true, "NAME": "api"}`), 0o600))
+
+	source, err := FileSource(path)
+	require.NoError(t, err)
+	assert.Equal(t, MapSource{"RATE_LIMIT_REQUESTS": "200", "DEBUG": "true", "NAME": "api"}, source)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"LIMITS": [1, 2]}`), 0o600))
+	_, err = FileSource(path)
+	assert.Error(t, err)
+}
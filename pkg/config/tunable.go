@@ -0,0 +1,123 @@
+package config
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tunable holds a setting which can change while the service is running.
+// It's safe for concurrent use, and readers always get a whole value.
+type Tunable[T any] struct {
+	value atomic.Pointer[T]
+}
+
+func NewTunable[T any](value T) *Tunable[T] {
+	t := &Tunable[T]{}
+	t.value.Store(&value)
+	return t
+}
+
+// Get returns the current value of the setting.
+func (t *Tunable[T]) Get() T {
+	return *t.value.Load()
+}
+
+func (t *Tunable[T]) set(value T) {
+	t.value.Store(&value)
+}
+
+// Reloader updates a whitelist of Tunables from a Source. Only the
+// registered keys are ever reloaded, every other setting keeps the value it
+// was loaded with at startup.
+type Reloader struct {
+	mu      sync.Mutex
+	loaders map[string]func(l *Loader) func()
+}
+
+func NewReloader() *Reloader {
+	return &Reloader{loaders: make(map[string]func(l *Loader) func())}
+}
+
+// Int registers an integer tunable under key.
+func (r *Reloader) Int(key string, t *Tunable[int], rng Range[int]) {
+	r.register(key, func(l *Loader) func() {
+		value := l.Int(key, t.Get(), rng)
+		return func() { t.set(value) }
+	})
+}
+
+// Duration registers a duration tunable under key, whose value is an
+// integer number of units.
+func (r *Reloader) Duration(key string, t *Tunable[time.Duration], unit time.Duration, rng Range[time.Duration]) {
+	r.register(key, func(l *Loader) func() {
+		value := l.Duration(key, t.Get(), unit, rng)
+		return func() { t.set(value) }
+	})
+}
+
+func (r *Reloader) register(key string, load func(l *Loader) func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loaders[key] = load
+}
+
+// Keys returns the registered keys, sorted.
+func (r *Reloader) Keys() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(r.loaders))
+	for key := range r.loaders {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Reload updates the registered tunables from the source. Keys which are
+// missing from the source keep their current value. If any value is
+// invalid, none of them is updated and the problems are returned, so that a
+// bad change never applies halfway.
+func (r *Reloader) Reload(source Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loader := NewLoader(source)
+	updates := make([]func(), 0, len(r.loaders))
+	for _, load := range r.loaders {
+		updates = append(updates, load(loader))
+	}
+	if err := loader.Err(); err != nil {
+		return err
+	}
+	for _, update := range updates {
+		update()
+	}
+	return nil
+}
+
+// Watch reloads the tunables from the source returned by load every
+// interval, until the context is done. Problems with loading the source or
+// with its values are passed to onError and the tunables keep their current
+// values.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, load func() (Source, error), onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			source, err := load()
+			if err == nil {
+				err = r.Reload(source)
+			}
+			if err != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package emailprovider sends transactional emails through SendGrid, Amazon
+// SES or Postmark, and normalizes the delivery status webhooks of each one.
+//
+// Every message carries the ID of the email it was created for, so that
+// delivery events can be matched to it even before the provider message ID
+// has been recorded.
+package emailprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderSendGrid = "sendgrid"
+	ProviderSES      = "ses"
+	ProviderPostmark = "postmark"
+
+	requestTimeout = 10 * time.Second
+
+	// the maximum size of the provider response we keep for debugging
+	maxResponseSize = 1024
+)
+
+// Providers are all the providers emails can be sent through.
+var Providers = []string{ProviderSendGrid, ProviderSES, ProviderPostmark}
+
+// ErrUnexpectedStatus is returned when a provider doesn't accept a message.
+var ErrUnexpectedStatus = errors.New("emailprovider: unexpected status")
+
+// Message is an email to a single recipient.
+type Message struct {
+	// From is the sender, optionally with a display name, e.g.
+	// "Acme <notifications@acme.com>".
+	From    string
+	To      string
+	ReplyTo string
+	Subject string
+	HTML    string
+	// Text is the plain text alternative of the body, if any.
+	Text string
+	// EmailID is the ID of the email record the message is sent for. It's
+	// attached to the message as metadata and echoed back in its delivery
+	// events.
+	EmailID string
+}
+
+// Provider delivers emails.
+type Provider interface {
+	Name() string
+	// Send hands the message over to the provider and returns the ID the
+	// provider assigned to it. The message has been accepted, not
+	// necessarily delivered, once Send returns.
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Error is returned when the provider rejects a message.
+type Error struct {
+	Provider   string
+	StatusCode int
+	Response   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %d from %s: %s", ErrUnexpectedStatus, e.StatusCode, e.Provider, e.Response)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// IsPermanent reports whether the provider rejected the message itself,
+// e.g. because of an invalid recipient, in which case sending it through
+// another provider won't help.
+func (e *Error) IsPermanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusUnauthorized &&
+		e.StatusCode != http.StatusForbidden &&
+		e.StatusCode != http.StatusTooManyRequests
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
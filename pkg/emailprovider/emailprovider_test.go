@@ -0,0 +1,247 @@
+package emailprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMessage = Message{
+	From:    "Acme <notifications@acme.com>",
+	To:      "user@example.com",
+	Subject: "Your code",
+	HTML:    "<p>123456</p>",
+	Text:    "123456",
+	EmailID: "ema_123",
+}
+
+func TestSendGridSend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v3/mail/send", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+
+		var message map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		expected := `{
+			"personalizations": [{"to": [{"email": "user@example.com"}]}],
+			"from": {"email": "notifications@acme.com", "name": "Acme"},
+			"subject": "Your code",
+			"content": [
+				{"type": "text/plain", "value": "123456"},
+				{"type": "text/html", "value": "<p>123456</p>"}
+			],
+			"custom_args": {"email_id": "ema_123"}
+		}`
+		actual, err := json.Marshal(message)
+		require.NoError(t, err)
+		assert.JSONEq(t, expected, string(actual))
+
+		w.Header().Set("X-Message-Id", "sg123")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sendGrid := NewSendGrid("key")
+	sendGrid.baseURL = server.URL
+
+	id, err := sendGrid.Send(context.Background(), testMessage)
+	require.NoError(t, err)
+	assert.Equal(t, "sg123", id)
+}
+
+func TestPostmarkSend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/email", r.URL.Path)
+		assert.Equal(t, "token", r.Header.Get("X-Postmark-Server-Token"))
+
+		var message map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		assert.Equal(t, "Acme <notifications@acme.com>", message["From"])
+		assert.Equal(t, map[string]any{"email_id": "ema_123"}, message["Metadata"])
+
+		_, _ = w.Write([]byte(`{"ErrorCode":0,"MessageID":"pm123"}`))
+	}))
+	defer server.Close()
+
+	postmark := NewPostmark("token")
+	postmark.baseURL = server.URL
+
+	id, err := postmark.Send(context.Background(), testMessage)
+	require.NoError(t, err)
+	assert.Equal(t, "pm123", id)
+}
+
+func TestSESSend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/email/outbound-emails", r.URL.Path)
+		assert.Equal(t, "20240101T000000Z", r.Header.Get("X-Amz-Date"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKID/20240101/us-east-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature="))
+
+		var message map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		assert.Equal(t, "events", message["ConfigurationSetName"])
+		assert.Equal(t, []any{map[string]any{"Name": "email_id", "Value": "ema_123"}}, message["EmailTags"])
+
+		_, _ = w.Write([]byte(`{"MessageId":"ses123"}`))
+	}))
+	defer server.Close()
+
+	ses := NewSES("us-east-1", "AKID", "secret", "events")
+	ses.baseURL = server.URL
+	ses.now = func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) }
+
+	id, err := ses.Send(context.Background(), testMessage)
+	require.NoError(t, err)
+	assert.Equal(t, "ses123", id)
+}
+
+func TestSignV4(t *testing.T) {
+	t.Parallel()
+
+	// the example of the AWS Signature Version 4 documentation
+	req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	signV4(req, nil, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC),
+		"us-east-1", "iam", "AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	assert.Equal(t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+		req.Header.Get("Authorization"))
+}
+
+type fakeProvider struct {
+	name string
+	err  error
+	sent int
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Send(context.Context, Message) (string, error) {
+	p.sent++
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.name + "-id", nil
+}
+
+func TestSendFailsOver(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	health := NewHealth(2, time.Minute)
+	health.now = func() time.Time { return now }
+
+	primary := &fakeProvider{name: ProviderSendGrid, err: &Error{Provider: ProviderSendGrid, StatusCode: http.StatusServiceUnavailable}}
+	secondary := &fakeProvider{name: ProviderPostmark}
+	providers := []Provider{primary, secondary}
+
+	for i := 0; i < 2; i++ {
+		name, id, err := Send(context.Background(), health, providers, testMessage)
+		require.NoError(t, err)
+		assert.Equal(t, ProviderPostmark, name)
+		assert.Equal(t, "postmark-id", id)
+	}
+	assert.Equal(t, 2, primary.sent)
+	assert.False(t, health.IsHealthy(ProviderSendGrid))
+
+	// the unhealthy provider is skipped during its cooldown
+	_, _, err := Send(context.Background(), health, providers, testMessage)
+	require.NoError(t, err)
+	assert.Equal(t, 2, primary.sent)
+
+	now = now.Add(time.Minute)
+	assert.True(t, health.IsHealthy(ProviderSendGrid))
+}
+
+func TestSendStopsOnPermanentErrors(t *testing.T) {
+	t.Parallel()
+
+	health := NewHealth(1, time.Minute)
+	primary := &fakeProvider{name: ProviderSendGrid, err: &Error{Provider: ProviderSendGrid, StatusCode: http.StatusBadRequest}}
+	secondary := &fakeProvider{name: ProviderPostmark}
+
+	_, _, err := Send(context.Background(), health, []Provider{primary, secondary}, testMessage)
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+	assert.Equal(t, 0, secondary.sent)
+	// the message was at fault, not the provider
+	assert.True(t, health.IsHealthy(ProviderSendGrid))
+}
+
+func TestParseWebhook(t *testing.T) {
+	t.Parallel()
+
+	webhook, err := ParseWebhook(ProviderSendGrid, []byte(`[
+		{"event": "processed", "sg_message_id": "sg123.filter0001", "email_id": "ema_123", "timestamp": 1704067200},
+		{"event": "bounce", "sg_message_id": "sg123.filter0001", "email_id": "ema_123", "reason": "550 no such user", "timestamp": 1704067200}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []DeliveryEvent{{
+		ProviderMessageID: "sg123",
+		EmailID:           "ema_123",
+		Status:            StatusBounced,
+		Reason:            "550 no such user",
+		OccurredAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, webhook.Events)
+
+	webhook, err = ParseWebhook(ProviderPostmark, []byte(`{
+		"RecordType": "Delivery",
+		"MessageID": "pm123",
+		"Metadata": {"email_id": "ema_123"},
+		"DeliveredAt": "2024-01-01T00:00:00Z"
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, []DeliveryEvent{{
+		ProviderMessageID: "pm123",
+		EmailID:           "ema_123",
+		Status:            StatusDelivered,
+		OccurredAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, webhook.Events)
+
+	event, err := json.Marshal(map[string]any{
+		"eventType": "Complaint",
+		"mail":      map[string]any{"messageId": "ses123", "tags": map[string][]string{"email_id": {"ema_123"}}},
+		"complaint": map[string]any{"timestamp": "2024-01-01T00:00:00Z"},
+	})
+	require.NoError(t, err)
+	notification, err := json.Marshal(map[string]string{"Type": "Notification", "Message": string(event)})
+	require.NoError(t, err)
+	webhook, err = ParseWebhook(ProviderSES, notification)
+	require.NoError(t, err)
+	assert.Equal(t, []DeliveryEvent{{
+		ProviderMessageID: "ses123",
+		EmailID:           "ema_123",
+		Status:            StatusComplained,
+		OccurredAt:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}}, webhook.Events)
+
+	webhook, err = ParseWebhook(ProviderSES, []byte(`{"Type": "SubscriptionConfirmation", "SubscribeURL": "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`))
+	require.NoError(t, err)
+	assert.Empty(t, webhook.Events)
+	assert.Equal(t, "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", webhook.SubscribeURL)
+}
+
+func TestConfirmSESSubscriptionRejectsOtherHosts(t *testing.T) {
+	t.Parallel()
+
+	err := ConfirmSESSubscription(context.Background(), "https://example.com/?Action=ConfirmSubscription")
+	assert.True(t, errors.Is(err, ErrInvalidSubscriptionURL))
+}
@@ -0,0 +1,111 @@
+package emailprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoProviders is returned when there's no provider to send a message
+// through.
+var ErrNoProviders = errors.New("emailprovider: no providers")
+
+// Health keeps track of the providers which are failing. A provider which
+// fails threshold times in a row is considered unhealthy for the cooldown
+// which follows its last failure, and is skipped in favor of the healthy
+// ones in the meantime.
+//
+// Health is local to the process, so that a provider outage is detected
+// without any coordination, at the cost of every process detecting it on its
+// own.
+type Health struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures map[string]int
+	failedAt map[string]time.Time
+}
+
+func NewHealth(threshold int, cooldown time.Duration) *Health {
+	return &Health{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		failures:  make(map[string]int),
+		failedAt:  make(map[string]time.Time),
+	}
+}
+
+// IsHealthy reports whether messages should be sent through the provider.
+func (h *Health) IsHealthy(provider string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failures[provider] < h.threshold {
+		return true
+	}
+	return h.now().Sub(h.failedAt[provider]) >= h.cooldown
+}
+
+// RecordSuccess marks the provider as healthy again.
+func (h *Health) RecordSuccess(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.failures, provider)
+	delete(h.failedAt, provider)
+}
+
+// RecordFailure counts a failure of the provider towards the threshold.
+func (h *Health) RecordFailure(provider string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[provider]++
+	h.failedAt[provider] = h.now()
+}
+
+// Send hands the message over to the first provider which accepts it, and
+// returns its name along with the ID it assigned to the message.
+//
+// Providers are tried in the given order, healthy ones first. Unhealthy
+// providers are only tried as a last resort, so that a message is still sent
+// if they've recovered before their cooldown is over. Messages which are
+// rejected by a provider for being invalid aren't sent through the others.
+func Send(ctx context.Context, health *Health, providers []Provider, msg Message) (string, string, error) {
+	if len(providers) == 0 {
+		return "", "", ErrNoProviders
+	}
+
+	ordered := make([]Provider, 0, len(providers))
+	var unhealthy []Provider
+	for _, provider := range providers {
+		if health.IsHealthy(provider.Name()) {
+			ordered = append(ordered, provider)
+		} else {
+			unhealthy = append(unhealthy, provider)
+		}
+	}
+	ordered = append(ordered, unhealthy...)
+
+	var errs []error
+	for _, provider := range ordered {
+		providerMessageID, err := provider.Send(ctx, msg)
+		if err == nil {
+			health.RecordSuccess(provider.Name())
+			return provider.Name(), providerMessageID, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+
+		var providerErr *Error
+		if errors.As(err, &providerErr) && providerErr.IsPermanent() {
+			break
+		}
+		health.RecordFailure(provider.Name())
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", "", errors.Join(errs...)
+}
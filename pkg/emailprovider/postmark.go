@@ -0,0 +1,87 @@
+package emailprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const postmarkBaseURL = "https://api.postmarkapp.com"
+
+// Postmark sends emails through the Email API of Postmark, on the
+// transactional message stream of the server.
+type Postmark struct {
+	baseURL    string
+	httpClient *http.Client
+
+	serverToken string
+}
+
+func NewPostmark(serverToken string) *Postmark {
+	return &Postmark{
+		baseURL:     postmarkBaseURL,
+		httpClient:  newHTTPClient(),
+		serverToken: serverToken,
+	}
+}
+
+func (*Postmark) Name() string {
+	return ProviderPostmark
+}
+
+type postmarkMessage struct {
+	From          string            `json:"From"`
+	To            string            `json:"To"`
+	ReplyTo       string            `json:"ReplyTo,omitempty"`
+	Subject       string            `json:"Subject"`
+	HTMLBody      string            `json:"HtmlBody"`
+	TextBody      string            `json:"TextBody,omitempty"`
+	MessageStream string            `json:"MessageStream"`
+	Metadata      map[string]string `json:"Metadata"`
+}
+
+func (p *Postmark) Send(ctx context.Context, msg Message) (string, error) {
+	body, err := json.Marshal(postmarkMessage{
+		From:          msg.From,
+		To:            msg.To,
+		ReplyTo:       msg.ReplyTo,
+		Subject:       msg.Subject,
+		HTMLBody:      msg.HTML,
+		TextBody:      msg.Text,
+		MessageStream: "outbound",
+		// metadata is echoed back in every webhook of the message
+		Metadata: map[string]string{"email_id": msg.EmailID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/postmark: encoding message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/email", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/postmark: creating request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Postmark-Server-Token", p.serverToken)
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/postmark: sending message: %w", err)
+	}
+	defer res.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", &Error{Provider: ProviderPostmark, StatusCode: res.StatusCode, Response: string(response)}
+	}
+
+	var accepted struct {
+		MessageID string `json:"MessageID"`
+	}
+	if err := json.Unmarshal(response, &accepted); err != nil {
+		return "", fmt.Errorf("emailprovider/postmark: decoding response: %w", err)
+	}
+	return accepted.MessageID, nil
+}
@@ -0,0 +1,109 @@
+package emailprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+)
+
+const sendGridBaseURL = "https://api.sendgrid.com"
+
+// SendGrid sends emails through the v3 Mail Send API of SendGrid.
+type SendGrid struct {
+	baseURL    string
+	httpClient *http.Client
+
+	apiKey string
+}
+
+func NewSendGrid(apiKey string) *SendGrid {
+	return &SendGrid{
+		baseURL:    sendGridBaseURL,
+		httpClient: newHTTPClient(),
+		apiKey:     apiKey,
+	}
+}
+
+func (*SendGrid) Name() string {
+	return ProviderSendGrid
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (s *SendGrid) Send(ctx context.Context, msg Message) (string, error) {
+	from, err := parseAddress(msg.From)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/sendgrid: %w", err)
+	}
+
+	payload := map[string]any{
+		"personalizations": []map[string]any{{
+			"to": []sendGridAddress{{Email: msg.To}},
+		}},
+		"from":    sendGridAddress{Email: from.Address, Name: from.Name},
+		"subject": msg.Subject,
+		// SendGrid requires the plain text part to come first
+		"content": sendGridContents(msg),
+		// custom args are echoed back in every event of the message
+		"custom_args": map[string]string{"email_id": msg.EmailID},
+	}
+	if msg.ReplyTo != "" {
+		replyTo, err := parseAddress(msg.ReplyTo)
+		if err != nil {
+			return "", fmt.Errorf("emailprovider/sendgrid: %w", err)
+		}
+		payload["reply_to"] = sendGridAddress{Email: replyTo.Address, Name: replyTo.Name}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/sendgrid: encoding message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/sendgrid: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/sendgrid: sending message: %w", err)
+	}
+	defer res.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", &Error{Provider: ProviderSendGrid, StatusCode: res.StatusCode, Response: string(response)}
+	}
+	// the message ID is only returned as a header
+	return res.Header.Get("X-Message-Id"), nil
+}
+
+func sendGridContents(msg Message) []sendGridContent {
+	var contents []sendGridContent
+	if msg.Text != "" {
+		contents = append(contents, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	return append(contents, sendGridContent{Type: "text/html", Value: msg.HTML})
+}
+
+func parseAddress(address string) (*mail.Address, error) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return nil, fmt.Errorf("parsing address %q: %w", address, err)
+	}
+	return parsed, nil
+}
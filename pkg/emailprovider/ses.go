@@ -0,0 +1,189 @@
+package emailprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SES sends emails through the v2 API of Amazon SES, with requests signed
+// by the access key of an IAM user which is allowed to ses:SendEmail.
+type SES struct {
+	baseURL    string
+	httpClient *http.Client
+	now        func() time.Time
+
+	region          string
+	accessKeyID     string
+	secretAccessKey string
+	// configurationSet publishes the delivery events of the messages to
+	// the SNS topic the status webhook is subscribed to
+	configurationSet string
+}
+
+func NewSES(region, accessKeyID, secretAccessKey, configurationSet string) *SES {
+	return &SES{
+		baseURL:          fmt.Sprintf("https://email.%s.amazonaws.com", region),
+		httpClient:       newHTTPClient(),
+		now:              time.Now,
+		region:           region,
+		accessKeyID:      accessKeyID,
+		secretAccessKey:  secretAccessKey,
+		configurationSet: configurationSet,
+	}
+}
+
+func (*SES) Name() string {
+	return ProviderSES
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesTag struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
+}
+
+func (s *SES) Send(ctx context.Context, msg Message) (string, error) {
+	body := map[string]*sesContent{"Html": {Data: msg.HTML, Charset: "UTF-8"}}
+	if msg.Text != "" {
+		body["Text"] = &sesContent{Data: msg.Text, Charset: "UTF-8"}
+	}
+	payload := map[string]any{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string][]string{"ToAddresses": {msg.To}},
+		"Content": map[string]any{
+			"Simple": map[string]any{
+				"Subject": sesContent{Data: msg.Subject, Charset: "UTF-8"},
+				"Body":    body,
+			},
+		},
+		// tags are included in every event of the message
+		"EmailTags": []sesTag{{Name: "email_id", Value: msg.EmailID}},
+	}
+	if msg.ReplyTo != "" {
+		payload["ReplyToAddresses"] = []string{msg.ReplyTo}
+	}
+	if s.configurationSet != "" {
+		payload["ConfigurationSetName"] = s.configurationSet
+	}
+
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/ses: encoding message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/v2/email/outbound-emails", bytes.NewReader(encoded))
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/ses: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signV4(req, encoded, s.now().UTC(), s.region, "ses", s.accessKeyID, s.secretAccessKey)
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("emailprovider/ses: sending message: %w", err)
+	}
+	defer res.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", &Error{Provider: ProviderSES, StatusCode: res.StatusCode, Response: string(response)}
+	}
+
+	var accepted struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.Unmarshal(response, &accepted); err != nil {
+		return "", fmt.Errorf("emailprovider/ses: decoding response: %w", err)
+	}
+	return accepted.MessageID, nil
+}
+
+// signV4 signs the request with AWS Signature Version 4. The Content-Type,
+// Host and X-Amz-Date headers are signed, along with the payload.
+func signV4(req *http.Request, payload []byte, now time.Time, region, service, accessKeyID, secretAccessKey string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{
+		"host":       host,
+		"x-amz-date": amzDate,
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// AWS expects spaces in the query to be encoded as %20
+	query := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		sha256Hex(payload),
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,262 @@
+package emailprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// The delivery statuses providers report through their webhooks.
+const (
+	StatusDelivered  = "delivered"
+	StatusDeferred   = "deferred"
+	StatusBounced    = "bounced"
+	StatusDropped    = "dropped"
+	StatusComplained = "complained"
+)
+
+var (
+	ErrUnknownProvider        = errors.New("emailprovider: unknown provider")
+	ErrInvalidSubscriptionURL = errors.New("emailprovider: invalid SNS subscription URL")
+)
+
+// DeliveryEvent is a change in the delivery status of a message, as
+// reported by its provider.
+type DeliveryEvent struct {
+	ProviderMessageID string
+	// EmailID is the ID of the email record the message was sent for. It's
+	// empty for messages which weren't sent with one.
+	EmailID string
+	Status  string
+	// Reason explains bounces, drops and deferrals, if the provider
+	// explains them.
+	Reason string
+	// OccurredAt is zero if the provider didn't report it.
+	OccurredAt time.Time
+}
+
+// Webhook is a delivery status webhook of a provider.
+type Webhook struct {
+	Events []DeliveryEvent
+	// SubscribeURL is set when SNS asks to confirm the subscription of the
+	// webhook to the topic SES publishes events to, instead of notifying an
+	// event.
+	SubscribeURL string
+}
+
+// ParseWebhook normalizes the body of a delivery status webhook of the
+// provider. Events which don't affect delivery, e.g. opens and clicks, are
+// left out.
+func ParseWebhook(provider string, body []byte) (*Webhook, error) {
+	switch provider {
+	case ProviderSendGrid:
+		return parseSendGridWebhook(body)
+	case ProviderPostmark:
+		return parsePostmarkWebhook(body)
+	case ProviderSES:
+		return parseSESWebhook(body)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, provider)
+	}
+}
+
+var sendGridStatuses = map[string]string{
+	"delivered":  StatusDelivered,
+	"deferred":   StatusDeferred,
+	"bounce":     StatusBounced,
+	"dropped":    StatusDropped,
+	"spamreport": StatusComplained,
+}
+
+func parseSendGridWebhook(body []byte) (*Webhook, error) {
+	var events []struct {
+		Event       string `json:"event"`
+		SGMessageID string `json:"sg_message_id"`
+		EmailID     string `json:"email_id"`
+		Reason      string `json:"reason"`
+		Response    string `json:"response"`
+		Timestamp   int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		return nil, fmt.Errorf("emailprovider/sendgrid: decoding webhook: %w", err)
+	}
+
+	webhook := &Webhook{}
+	for _, event := range events {
+		status, ok := sendGridStatuses[event.Event]
+		if !ok {
+			continue
+		}
+		reason := event.Reason
+		if reason == "" {
+			reason = event.Response
+		}
+		// the ID returned when the message was sent is the prefix of the
+		// one in its events
+		providerMessageID, _, _ := strings.Cut(event.SGMessageID, ".")
+		webhook.Events = append(webhook.Events, DeliveryEvent{
+			ProviderMessageID: providerMessageID,
+			EmailID:           event.EmailID,
+			Status:            status,
+			Reason:            reason,
+			OccurredAt:        time.Unix(event.Timestamp, 0).UTC(),
+		})
+	}
+	return webhook, nil
+}
+
+func parsePostmarkWebhook(body []byte) (*Webhook, error) {
+	var event struct {
+		RecordType  string            `json:"RecordType"`
+		MessageID   string            `json:"MessageID"`
+		Metadata    map[string]string `json:"Metadata"`
+		Type        string            `json:"Type"`
+		Description string            `json:"Description"`
+		DeliveredAt time.Time         `json:"DeliveredAt"`
+		BouncedAt   time.Time         `json:"BouncedAt"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("emailprovider/postmark: decoding webhook: %w", err)
+	}
+
+	deliveryEvent := DeliveryEvent{
+		ProviderMessageID: event.MessageID,
+		EmailID:           event.Metadata["email_id"],
+	}
+	switch event.RecordType {
+	case "Delivery":
+		deliveryEvent.Status = StatusDelivered
+		deliveryEvent.OccurredAt = event.DeliveredAt
+	case "Bounce":
+		deliveryEvent.Status = StatusBounced
+		// soft bounces are retried by Postmark
+		if event.Type == "SoftBounce" || event.Type == "Transient" {
+			deliveryEvent.Status = StatusDeferred
+		}
+		deliveryEvent.Reason = event.Description
+		deliveryEvent.OccurredAt = event.BouncedAt
+	case "SpamComplaint":
+		deliveryEvent.Status = StatusComplained
+		deliveryEvent.OccurredAt = event.BouncedAt
+	default:
+		return &Webhook{}, nil
+	}
+	deliveryEvent.OccurredAt = deliveryEvent.OccurredAt.UTC()
+	return &Webhook{Events: []DeliveryEvent{deliveryEvent}}, nil
+}
+
+func parseSESWebhook(body []byte) (*Webhook, error) {
+	var notification struct {
+		Type         string `json:"Type"`
+		Message      string `json:"Message"`
+		SubscribeURL string `json:"SubscribeURL"`
+	}
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, fmt.Errorf("emailprovider/ses: decoding notification: %w", err)
+	}
+	switch notification.Type {
+	case "SubscriptionConfirmation":
+		return &Webhook{SubscribeURL: notification.SubscribeURL}, nil
+	case "Notification":
+	default:
+		return &Webhook{}, nil
+	}
+
+	var event struct {
+		EventType string `json:"eventType"`
+		Mail      struct {
+			MessageID string              `json:"messageId"`
+			Tags      map[string][]string `json:"tags"`
+		} `json:"mail"`
+		Delivery struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"delivery"`
+		Bounce struct {
+			BounceType        string    `json:"bounceType"`
+			BounceSubType     string    `json:"bounceSubType"`
+			Timestamp         time.Time `json:"timestamp"`
+			BouncedRecipients []struct {
+				DiagnosticCode string `json:"diagnosticCode"`
+			} `json:"bouncedRecipients"`
+		} `json:"bounce"`
+		Complaint struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"complaint"`
+		Reject struct {
+			Reason string `json:"reason"`
+		} `json:"reject"`
+		DeliveryDelay struct {
+			DelayType string    `json:"delayType"`
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"deliveryDelay"`
+	}
+	if err := json.Unmarshal([]byte(notification.Message), &event); err != nil {
+		return nil, fmt.Errorf("emailprovider/ses: decoding event: %w", err)
+	}
+
+	deliveryEvent := DeliveryEvent{ProviderMessageID: event.Mail.MessageID}
+	if emailIDs := event.Mail.Tags["email_id"]; len(emailIDs) > 0 {
+		deliveryEvent.EmailID = emailIDs[0]
+	}
+	switch event.EventType {
+	case "Delivery":
+		deliveryEvent.Status = StatusDelivered
+		deliveryEvent.OccurredAt = event.Delivery.Timestamp
+	case "Bounce":
+		deliveryEvent.Status = StatusBounced
+		if event.Bounce.BounceType == "Transient" {
+			deliveryEvent.Status = StatusDeferred
+		}
+		deliveryEvent.Reason = event.Bounce.BounceSubType
+		if len(event.Bounce.BouncedRecipients) > 0 && event.Bounce.BouncedRecipients[0].DiagnosticCode != "" {
+			deliveryEvent.Reason = event.Bounce.BouncedRecipients[0].DiagnosticCode
+		}
+		deliveryEvent.OccurredAt = event.Bounce.Timestamp
+	case "Complaint":
+		deliveryEvent.Status = StatusComplained
+		deliveryEvent.OccurredAt = event.Complaint.Timestamp
+	case "Reject":
+		// SES doesn't timestamp rejections, which happen right as the
+		// message is sent
+		deliveryEvent.Status = StatusDropped
+		deliveryEvent.Reason = event.Reject.Reason
+	case "DeliveryDelay":
+		deliveryEvent.Status = StatusDeferred
+		deliveryEvent.Reason = event.DeliveryDelay.DelayType
+		deliveryEvent.OccurredAt = event.DeliveryDelay.Timestamp
+	default:
+		return &Webhook{}, nil
+	}
+	deliveryEvent.OccurredAt = deliveryEvent.OccurredAt.UTC()
+	return &Webhook{Events: []DeliveryEvent{deliveryEvent}}, nil
+}
+
+// ConfirmSESSubscription confirms the subscription of the status webhook to
+// the SNS topic SES publishes delivery events to. Only SNS URLs are
+// followed.
+func ConfirmSESSubscription(ctx context.Context, subscribeURL string) error {
+	parsed, err := url.Parse(subscribeURL)
+	if err != nil || parsed.Scheme != "https" ||
+		!strings.HasPrefix(parsed.Hostname(), "sns.") || !strings.HasSuffix(parsed.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("%w: %s", ErrInvalidSubscriptionURL, subscribeURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return fmt.Errorf("emailprovider/ses: creating subscription request: %w", err)
+	}
+	res, err := newHTTPClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("emailprovider/ses: confirming subscription: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &Error{Provider: ProviderSES, StatusCode: res.StatusCode}
+	}
+	return nil
+}
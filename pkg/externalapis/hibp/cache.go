@@ -0,0 +1,105 @@
+package hibp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+const (
+	// notPwnedTTL bounds how long a password is considered safe without
+	// asking Have I Been Pwned again, as it might appear in a new breach.
+	notPwnedTTL = 24 * time.Hour
+
+	// maxCacheEntries bounds the memory used by the cache. Past it, results
+	// aren't cached.
+	maxCacheEntries = 100_000
+)
+
+// CachingClient is a Client which remembers the passwords which weren't
+// pwned for a while, since the same password is usually checked several
+// times in a row, e.g. when it's entered during a sign-up and then when the
+// user is created. Pwned passwords aren't cached, as they're rejected or
+// reported anyway.
+type CachingClient struct {
+	Client
+
+	clock clockwork.Clock
+
+	// cacheKey keys the hashes of the cached passwords, so that the cache
+	// can't be used to look them up in a precomputed table
+	cacheKey []byte
+
+	mu       sync.Mutex
+	notPwned map[string]time.Time
+}
+
+// NewCachingClient wraps client with a cache of the passwords it found safe.
+func NewCachingClient(client Client, clock clockwork.Clock) *CachingClient {
+	cacheKey := make([]byte, 32)
+	if _, err := rand.Read(cacheKey); err != nil {
+		panic(fmt.Sprintf("hibp: generating cache key: %s", err))
+	}
+	return &CachingClient{
+		Client:   client,
+		clock:    clock,
+		cacheKey: cacheKey,
+		notPwned: make(map[string]time.Time),
+	}
+}
+
+// CheckIfPasswordPwned returns whether the password appeared in a known data
+// breach, asking the wrapped client only if it wasn't found safe recently.
+func (c *CachingClient) CheckIfPasswordPwned(ctx context.Context, password string) (bool, error) {
+	cacheKey := c.cacheKeyFor(password)
+	if c.cachedNotPwned(cacheKey) {
+		return false, nil
+	}
+
+	pwned, err := c.Client.CheckIfPasswordPwned(ctx, password)
+	if err != nil {
+		return false, err
+	}
+	if !pwned {
+		c.cacheNotPwned(cacheKey)
+	}
+	return pwned, nil
+}
+
+func (c *CachingClient) cacheKeyFor(password string) string {
+	mac := hmac.New(sha256.New, c.cacheKey)
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *CachingClient) cachedNotPwned(cacheKey string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt, ok := c.notPwned[cacheKey]
+	return ok && c.clock.Now().Before(expiresAt)
+}
+
+func (c *CachingClient) cacheNotPwned(cacheKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if len(c.notPwned) >= maxCacheEntries {
+		for key, expiresAt := range c.notPwned {
+			if !now.Before(expiresAt) {
+				delete(c.notPwned, key)
+			}
+		}
+		if len(c.notPwned) >= maxCacheEntries {
+			return
+		}
+	}
+	c.notPwned[cacheKey] = now.Add(notPwnedTTL)
+}
@@ -0,0 +1,62 @@
+package hibp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	Client
+	pwned map[string]bool
+	err   error
+	calls int
+}
+
+func (f *fakeClient) CheckIfPasswordPwned(_ context.Context, password string) (bool, error) {
+	f.calls++
+	return f.pwned[password], f.err
+}
+
+func TestCachingClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	clock := clockwork.NewFakeClock()
+	fake := &fakeClient{pwned: map[string]bool{"password": true}}
+	client := NewCachingClient(fake, clock)
+
+	// pwned passwords are always checked again
+	for i := 0; i < 2; i++ {
+		pwned, err := client.CheckIfPasswordPwned(ctx, "password")
+		require.NoError(t, err)
+		assert.True(t, pwned)
+	}
+	assert.Equal(t, 2, fake.calls)
+
+	// safe passwords are cached, until they expire
+	for i := 0; i < 2; i++ {
+		pwned, err := client.CheckIfPasswordPwned(ctx, "correct horse battery staple")
+		require.NoError(t, err)
+		assert.False(t, pwned)
+	}
+	assert.Equal(t, 3, fake.calls)
+
+	clock.Advance(notPwnedTTL)
+	_, err := client.CheckIfPasswordPwned(ctx, "correct horse battery staple")
+	require.NoError(t, err)
+	assert.Equal(t, 4, fake.calls)
+
+	// failures aren't cached
+	fake.err = errors.New("connection refused")
+	_, err = client.CheckIfPasswordPwned(ctx, "Tr0ub4dor&3")
+	require.Error(t, err)
+	fake.err = nil
+	_, err = client.CheckIfPasswordPwned(ctx, "Tr0ub4dor&3")
+	require.NoError(t, err)
+	assert.Equal(t, 6, fake.calls)
+}
@@ -0,0 +1,13 @@
+package hibp
+
+const (
+	// PolicyBlock rejects pwned passwords.
+	PolicyBlock = "block"
+	// PolicyWarn accepts pwned passwords, but reports them.
+	PolicyWarn = "warn"
+)
+
+// Policies are the policies instances can choose from for the pwned
+// passwords their users set. An empty policy blocks them, like it did
+// before instances could choose.
+var Policies = []string{PolicyBlock, PolicyWarn}
@@ -0,0 +1,93 @@
+// Package phonelookup looks up phone numbers with a carrier lookup provider,
+// either Twilio Lookup or Telesign, to tell whether they can be reached and
+// which kind of line they belong to.
+//
+// Providers classify lines in their own terms, which are normalized to the
+// line types of this package, so that instance policies don't depend on the
+// provider they use.
+package phonelookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderTwilio   = "twilio"
+	ProviderTelesign = "telesign"
+
+	requestTimeout = 5 * time.Second
+
+	// the maximum size of the provider response we read
+	maxResponseSize = 64 * 1024
+)
+
+// The kinds of line a phone number can belong to.
+const (
+	LineTypeMobile    = "mobile"
+	LineTypeLandline  = "landline"
+	LineTypeVoIP      = "voip"
+	LineTypeTollFree  = "toll_free"
+	LineTypePremium   = "premium"
+	LineTypePager     = "pager"
+	LineTypeVoicemail = "voicemail"
+	LineTypeUnknown   = "unknown"
+)
+
+// LineTypes are all the line types a lookup can result in.
+var LineTypes = []string{
+	LineTypeMobile,
+	LineTypeLandline,
+	LineTypeVoIP,
+	LineTypeTollFree,
+	LineTypePremium,
+	LineTypePager,
+	LineTypeVoicemail,
+	LineTypeUnknown,
+}
+
+// ErrUnexpectedStatus is returned when a provider fails to look up a phone
+// number.
+var ErrUnexpectedStatus = errors.New("phonelookup: unexpected status")
+
+// Result is what a provider knows about a phone number.
+type Result struct {
+	// Valid is false for phone numbers which don't exist or can't be
+	// reached.
+	Valid       bool   `json:"valid"`
+	LineType    string `json:"line_type"`
+	CarrierName string `json:"carrier_name"`
+	// CountryCode is the ISO 3166-1 alpha-2 code of the country the phone
+	// number belongs to.
+	CountryCode string `json:"country_code"`
+}
+
+// Provider looks up phone numbers.
+type Provider interface {
+	Name() string
+	// Lookup returns what the provider knows about the phone number, which
+	// is in E.164 format.
+	Lookup(ctx context.Context, phoneNumber string) (*Result, error)
+}
+
+// Error is returned when the provider fails to look up a phone number.
+type Error struct {
+	Provider   string
+	StatusCode int
+	Response   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %d from %s: %s", ErrUnexpectedStatus, e.StatusCode, e.Provider, e.Response)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
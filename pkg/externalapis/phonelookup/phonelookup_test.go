@@ -0,0 +1,98 @@
+package phonelookup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioLookup(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/PhoneNumbers/+15552223333", r.URL.Path)
+		assert.Equal(t, "line_type_intelligence", r.URL.Query().Get("Fields"))
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", password)
+
+		_, _ = w.Write([]byte(`{
+			"phone_number": "+15552223333",
+			"valid": true,
+			"country_code": "US",
+			"line_type_intelligence": {"type": "nonFixedVoip", "carrier_name": "Google (Grand Central) - SVR"}
+		}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "secret")
+	twilio.baseURL = server.URL
+
+	result, err := twilio.Lookup(context.Background(), "+15552223333")
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Valid:       true,
+		LineType:    LineTypeVoIP,
+		CarrierName: "Google (Grand Central) - SVR",
+		CountryCode: "US",
+	}, result)
+}
+
+func TestTelesignLookup(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/phoneid/15552223333", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "customer", user)
+		assert.Equal(t, "key", password)
+
+		_, _ = w.Write([]byte(`{
+			"phone_type": {"code": "2", "description": "MOBILE"},
+			"carrier": {"name": "T-Mobile USA"},
+			"location": {"country": {"iso2": "US"}}
+		}`))
+	}))
+	defer server.Close()
+
+	telesign := NewTelesign("customer", "key")
+	telesign.baseURL = server.URL
+
+	result, err := telesign.Lookup(context.Background(), "+15552223333")
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Valid:       true,
+		LineType:    LineTypeMobile,
+		CarrierName: "T-Mobile USA",
+		CountryCode: "US",
+	}, result)
+}
+
+func TestLookupUnexpectedStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":20003}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "wrong")
+	twilio.baseURL = server.URL
+
+	_, err := twilio.Lookup(context.Background(), "+15552223333")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnexpectedStatus))
+
+	var lookupErr *Error
+	require.ErrorAs(t, err, &lookupErr)
+	assert.Equal(t, http.StatusUnauthorized, lookupErr.StatusCode)
+}
@@ -0,0 +1,101 @@
+package phonelookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const telesignBaseURL = "https://rest-ww.telesign.com"
+
+// telesignLineTypes maps the phone types of Telesign Phone ID to ours.
+var telesignLineTypes = map[string]string{
+	"MOBILE":     LineTypeMobile,
+	"PREPAID":    LineTypeMobile,
+	"FIXED_LINE": LineTypeLandline,
+	"PAYPHONE":   LineTypeLandline,
+	"VOIP":       LineTypeVoIP,
+	"TOLL_FREE":  LineTypeTollFree,
+	"PREMIUM":    LineTypePremium,
+	"PAGER":      LineTypePager,
+	"VOICEMAIL":  LineTypeVoicemail,
+}
+
+// telesignInvalidPhoneType is the phone type of numbers which don't exist.
+const telesignInvalidPhoneType = "INVALID"
+
+// Telesign looks up phone numbers with Telesign Phone ID.
+type Telesign struct {
+	baseURL    string
+	httpClient *http.Client
+
+	customerID string
+	apiKey     string
+}
+
+func NewTelesign(customerID, apiKey string) *Telesign {
+	return &Telesign{
+		baseURL:    telesignBaseURL,
+		httpClient: newHTTPClient(),
+		customerID: customerID,
+		apiKey:     apiKey,
+	}
+}
+
+func (*Telesign) Name() string {
+	return ProviderTelesign
+}
+
+func (t *Telesign) Lookup(ctx context.Context, phoneNumber string) (*Result, error) {
+	// Telesign expects the phone number without the leading plus sign
+	endpoint := fmt.Sprintf("%s/v1/phoneid/%s", t.baseURL, url.PathEscape(strings.TrimPrefix(phoneNumber, "+")))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader("{}"))
+	if err != nil {
+		return nil, fmt.Errorf("phonelookup/telesign: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(t.customerID, t.apiKey)
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("phonelookup/telesign: looking up phone number: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, &Error{Provider: ProviderTelesign, StatusCode: res.StatusCode, Response: string(body)}
+	}
+
+	var response struct {
+		PhoneType struct {
+			Description string `json:"description"`
+		} `json:"phone_type"`
+		Carrier struct {
+			Name string `json:"name"`
+		} `json:"carrier"`
+		Location struct {
+			Country struct {
+				ISO2 string `json:"iso2"`
+			} `json:"country"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("phonelookup/telesign: decoding response: %w", err)
+	}
+
+	result := &Result{
+		Valid:       response.PhoneType.Description != telesignInvalidPhoneType,
+		LineType:    LineTypeUnknown,
+		CarrierName: response.Carrier.Name,
+		CountryCode: response.Location.Country.ISO2,
+	}
+	if lineType, ok := telesignLineTypes[response.PhoneType.Description]; ok {
+		result.LineType = lineType
+	}
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package phonelookup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const twilioBaseURL = "https://lookups.twilio.com"
+
+// twilioLineTypes maps the line types of Twilio Line Type Intelligence to
+// ours.
+var twilioLineTypes = map[string]string{
+	"mobile":       LineTypeMobile,
+	"landline":     LineTypeLandline,
+	"fixedVoip":    LineTypeVoIP,
+	"nonFixedVoip": LineTypeVoIP,
+	"tollFree":     LineTypeTollFree,
+	"premium":      LineTypePremium,
+	"sharedCost":   LineTypePremium,
+	"pager":        LineTypePager,
+	"voicemail":    LineTypeVoicemail,
+}
+
+// Twilio looks up phone numbers with Twilio Lookup v2 and its Line Type
+// Intelligence package.
+type Twilio struct {
+	baseURL    string
+	httpClient *http.Client
+
+	accountSID string
+	authToken  string
+}
+
+func NewTwilio(accountSID, authToken string) *Twilio {
+	return &Twilio{
+		baseURL:    twilioBaseURL,
+		httpClient: newHTTPClient(),
+		accountSID: accountSID,
+		authToken:  authToken,
+	}
+}
+
+func (*Twilio) Name() string {
+	return ProviderTwilio
+}
+
+func (t *Twilio) Lookup(ctx context.Context, phoneNumber string) (*Result, error) {
+	endpoint := fmt.Sprintf("%s/v2/PhoneNumbers/%s?Fields=line_type_intelligence", t.baseURL, url.PathEscape(phoneNumber))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("phonelookup/twilio: creating request: %w", err)
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("phonelookup/twilio: looking up phone number: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, &Error{Provider: ProviderTwilio, StatusCode: res.StatusCode, Response: string(body)}
+	}
+
+	var response struct {
+		Valid                bool   `json:"valid"`
+		CountryCode          string `json:"country_code"`
+		LineTypeIntelligence *struct {
+			Type        string `json:"type"`
+			CarrierName string `json:"carrier_name"`
+		} `json:"line_type_intelligence"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("phonelookup/twilio: decoding response: %w", err)
+	}
+
+	result := &Result{
+		Valid:       response.Valid,
+		LineType:    LineTypeUnknown,
+		CountryCode: response.CountryCode,
+	}
+	if response.LineTypeIntelligence != nil {
+		if lineType, ok := twilioLineTypes[response.LineTypeIntelligence.Type]; ok {
+			result.LineType = lineType
+		}
+		result.CarrierName = response.LineTypeIntelligence.CarrierName
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+// Package featureflags evaluates feature flags for gradual rollouts.
+//
+// A flag is on for an instance if it's enabled and the instance or its
+// application is targeted explicitly, or if it falls within the rollout
+// percentage of the flag. Percentages are bucketed by application, so all
+// the instances of an application get the same answer, and the same
+// applications stay in as the percentage grows.
+//
+// Flags are evaluated against a snapshot which is loaded at most once per
+// request, so a request sees a consistent set of flags even if they change
+// while it's served.
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// Flag is a feature flag along with its targeting rules.
+type Flag struct {
+	Key     string `json:"key"`
+	Enabled bool   `json:"enabled"`
+	// InstanceIDs and ApplicationIDs always get the flag, regardless of the
+	// percentage.
+	InstanceIDs    []string `json:"instance_ids"`
+	ApplicationIDs []string `json:"application_ids"`
+	// Percentage is the share of the rest of the applications which get the
+	// flag, from 0 to 100.
+	Percentage int `json:"percentage"`
+}
+
+// Target is who a flag is evaluated for.
+type Target struct {
+	InstanceID    string
+	ApplicationID string
+}
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+var (
+	ErrInvalidKey        = errors.New("featureflags: keys must be lower case letters, digits and underscores")
+	ErrInvalidPercentage = errors.New("featureflags: percentages must be between 0 and 100")
+)
+
+// Validate returns ErrInvalidKey or ErrInvalidPercentage if the flag has an
+// invalid key or percentage.
+func (f Flag) Validate() error {
+	if !keyPattern.MatchString(f.Key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, f.Key)
+	}
+	if f.Percentage < 0 || f.Percentage > 100 {
+		return fmt.Errorf("%w: %s has %d", ErrInvalidPercentage, f.Key, f.Percentage)
+	}
+	return nil
+}
+
+// Evaluate reports whether the flag is on for the target.
+func (f Flag) Evaluate(target Target) bool {
+	if !f.Enabled {
+		return false
+	}
+	if target.InstanceID != "" && slices.Contains(f.InstanceIDs, target.InstanceID) {
+		return true
+	}
+	if target.ApplicationID != "" && slices.Contains(f.ApplicationIDs, target.ApplicationID) {
+		return true
+	}
+	if f.Percentage <= 0 {
+		return false
+	}
+	if f.Percentage >= 100 {
+		return true
+	}
+
+	subject := target.ApplicationID
+	if subject == "" {
+		subject = target.InstanceID
+	}
+	if subject == "" {
+		return false
+	}
+	return bucket(f.Key, subject) < f.Percentage
+}
+
+// bucket places the subject in one of 100 buckets. The key is part of the
+// hash, so that the same applications aren't always the first to get every
+// flag.
+func bucket(key, subject string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key + ":" + subject))
+	return int(h.Sum32() % 100)
+}
+
+// Loader returns all the flags, by key.
+type Loader func(ctx context.Context) (map[string]Flag, error)
+
+// snapshot holds the flags of a request, which are loaded on first use.
+type snapshot struct {
+	load Loader
+
+	once  sync.Once
+	flags map[string]Flag
+	err   error
+}
+
+func (s *snapshot) get(ctx context.Context) (map[string]Flag, error) {
+	s.once.Do(func() {
+		s.flags, s.err = s.load(ctx)
+	})
+	return s.flags, s.err
+}
+
+type contextKey struct{}
+
+// NewContext returns a context which evaluates flags against the ones
+// returned by load. They're loaded the first time a flag is evaluated, and
+// only once.
+func NewContext(ctx context.Context, load Loader) context.Context {
+	return context.WithValue(ctx, contextKey{}, &snapshot{load: load})
+}
+
+// Enabled reports whether the flag with the given key is on for the target.
+// Flags which don't exist, or can't be loaded, are off, and so is every
+// flag on contexts without flags.
+func Enabled(ctx context.Context, key string, target Target) (bool, error) {
+	s, ok := ctx.Value(contextKey{}).(*snapshot)
+	if !ok {
+		return false, nil
+	}
+	flags, err := s.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	flag, ok := flags[key]
+	if !ok {
+		return false, nil
+	}
+	return flag.Evaluate(target), nil
+}
@@ -0,0 +1,93 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Flag{Key: "new_sign_in_flow", Percentage: 50}.Validate())
+	assert.ErrorIs(t, Flag{Key: "New-Flow"}.Validate(), ErrInvalidKey)
+	assert.ErrorIs(t, Flag{Key: "new_flow", Percentage: 101}.Validate(), ErrInvalidPercentage)
+}
+
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	flag := Flag{
+		Key:            "new_flow",
+		Enabled:        true,
+		InstanceIDs:    []string{"ins_1"},
+		ApplicationIDs: []string{"app_2"},
+	}
+	assert.True(t, flag.Evaluate(Target{InstanceID: "ins_1", ApplicationID: "app_1"}))
+	assert.True(t, flag.Evaluate(Target{InstanceID: "ins_3", ApplicationID: "app_2"}))
+	assert.False(t, flag.Evaluate(Target{InstanceID: "ins_3", ApplicationID: "app_3"}))
+
+	flag.Percentage = 100
+	assert.True(t, flag.Evaluate(Target{InstanceID: "ins_3", ApplicationID: "app_3"}))
+
+	flag.Enabled = false
+	assert.False(t, flag.Evaluate(Target{InstanceID: "ins_1", ApplicationID: "app_2"}))
+}
+
+func TestEvaluatePercentage(t *testing.T) {
+	t.Parallel()
+
+	flag := Flag{Key: "new_flow", Enabled: true, Percentage: 30}
+	var on int
+	for i := 0; i < 1000; i++ {
+		target := Target{ApplicationID: fmt.Sprintf("app_%d", i)}
+		if flag.Evaluate(target) {
+			on++
+			// instances of the same application get the same answer
+			target.InstanceID = "ins_other"
+			assert.True(t, flag.Evaluate(target))
+		}
+	}
+	assert.InDelta(t, 300, on, 60)
+
+	// applications which have the flag keep it as the percentage grows
+	for i := 0; i < 1000; i++ {
+		target := Target{ApplicationID: fmt.Sprintf("app_%d", i)}
+		if flag.Evaluate(target) {
+			assert.True(t, Flag{Key: "new_flow", Enabled: true, Percentage: 60}.Evaluate(target))
+		}
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	t.Parallel()
+
+	target := Target{InstanceID: "ins_1"}
+	enabled, err := Enabled(context.Background(), "new_flow", target)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	var loads int
+	ctx := NewContext(context.Background(), func(context.Context) (map[string]Flag, error) {
+		loads++
+		return map[string]Flag{"new_flow": {Key: "new_flow", Enabled: true, InstanceIDs: []string{"ins_1"}}}, nil
+	})
+	enabled, err = Enabled(ctx, "new_flow", target)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	enabled, err = Enabled(ctx, "missing", target)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.Equal(t, 1, loads)
+
+	ctx = NewContext(context.Background(), func(context.Context) (map[string]Flag, error) {
+		return nil, errors.New("unavailable")
+	})
+	enabled, err = Enabled(ctx, "new_flow", target)
+	assert.Error(t, err)
+	assert.False(t, enabled)
+}
@@ -0,0 +1,95 @@
+// Package handshakebinding binds handshake tokens to the device they were
+// issued to.
+//
+// A handshake token carries the cookies of a freshly synced client, so
+// anyone who gets hold of one, e.g. from a leaked redirect URL, can replay
+// it on another device and get its session. Bound tokens carry a hash of the
+// user agent of the request they were issued for. Backends which receive the
+// token verify it through the Backend API, which checks the hash against the
+// user agent of the request which brought the token with Verify, and rejects
+// tokens coming from another device.
+//
+// Whether tokens are bound is an instance-level setting. Tokens aren't bound
+// to TLS fingerprints, as the APIs only see the fingerprints in headers that
+// clients can set themselves.
+package handshakebinding
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+)
+
+// The strictness settings of an instance. Tokens are unbound unless an
+// instance opts in.
+const (
+	// StrictnessOff leaves tokens unbound.
+	StrictnessOff = "off"
+	// StrictnessUserAgent binds tokens to the user agent of the client.
+	StrictnessUserAgent = "user_agent"
+)
+
+// Strictnesses are all the strictness settings, from the most lenient to
+// the strictest.
+var Strictnesses = []string{StrictnessOff, StrictnessUserAgent}
+
+// ClaimBinding is the claim of the handshake token which holds its binding.
+const ClaimBinding = "binding"
+
+var ErrUserAgentMismatch = errors.New("handshakebinding: token was issued to another user agent")
+
+// Client is what identifies the device a request comes from.
+type Client struct {
+	UserAgent string
+}
+
+// Binding is the claim of a bound token. It holds the hashes of what
+// identifies the device the token was issued to, so that the token doesn't
+// disclose them.
+type Binding struct {
+	UserAgent string `json:"ua,omitempty"`
+}
+
+// New returns the binding of a token issued to the client, for the given
+// strictness, or nil if the token shouldn't be bound. Unknown strictness
+// settings leave tokens unbound.
+func New(strictness string, client Client) *Binding {
+	var binding Binding
+	switch strictness {
+	case StrictnessUserAgent:
+		binding.UserAgent = hash(client.UserAgent)
+	}
+
+	if binding == (Binding{}) {
+		return nil
+	}
+	return &binding
+}
+
+// Verify returns an error if the request the token was received with comes
+// from another client than the one the token was issued to. Unbound tokens
+// are never rejected.
+func (b *Binding) Verify(client Client) error {
+	if b == nil {
+		return nil
+	}
+	if b.UserAgent != "" && !equal(b.UserAgent, hash(client.UserAgent)) {
+		return ErrUserAgentMismatch
+	}
+	return nil
+}
+
+// hash returns the hash of the value, or an empty string if there's no
+// value, so that unknown values aren't bound.
+func hash(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,41 @@
+package handshakebinding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	client := Client{UserAgent: "Mozilla/5.0"}
+
+	assert.Nil(t, New(StrictnessOff, client))
+	assert.Nil(t, New("", client))
+	assert.Nil(t, New("unknown", client))
+	assert.Nil(t, New("tls_fingerprint", client))
+
+	binding := New(StrictnessUserAgent, client)
+	require.NotNil(t, binding)
+	assert.NotEmpty(t, binding.UserAgent)
+	assert.NotEqual(t, client.UserAgent, binding.UserAgent)
+
+	// Unknown user agents aren't bound.
+	assert.Nil(t, New(StrictnessUserAgent, Client{}))
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	client := Client{UserAgent: "Mozilla/5.0"}
+
+	var unbound *Binding
+	assert.NoError(t, unbound.Verify(Client{UserAgent: "curl/8.0"}))
+
+	binding := New(StrictnessUserAgent, client)
+	assert.NoError(t, binding.Verify(client))
+	assert.ErrorIs(t, binding.Verify(Client{UserAgent: "curl/8.0"}), ErrUserAgentMismatch)
+	assert.ErrorIs(t, binding.Verify(Client{}), ErrUserAgentMismatch)
+}
@@ -0,0 +1,139 @@
+// Package backfill runs data migrations in the background, one batch at a
+// time, so that they can go through tables of any size while the API keeps
+// serving traffic.
+//
+// A backfill walks through its rows in a stable order and checkpoints the
+// cursor of the last row it processed after every batch. It can be paused
+// between any two batches and picks up from the checkpoint when resumed. The
+// pace is throttled to a number of rows per second, by delaying the next batch
+// by as long as it takes for the rate to catch up.
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// The bounds of the batch size of a backfill.
+const (
+	DefaultBatchSize = 100
+	MaxBatchSize     = 10000
+)
+
+// Task is a data migration which can be backfilled.
+//
+// Tasks must be idempotent. A batch is processed again if the backfill stops
+// after processing it but before its checkpoint is stored.
+type Task interface {
+	// Name identifies the task, e.g. recompute_canonical_identifiers.
+	Name() string
+
+	// Process migrates up to limit rows after the given cursor, in a stable
+	// order, and returns the cursor of the last one along with the number of
+	// rows it processed. The cursor is empty for the first batch. The task
+	// is done once a batch processes fewer rows than the limit.
+	Process(ctx context.Context, cursor string, limit int) (next string, processed int, err error)
+}
+
+// Registry holds the tasks which can be backfilled, by name.
+type Registry struct {
+	tasks map[string]Task
+}
+
+// NewRegistry returns a registry of the given tasks. It panics if two of
+// them share a name, since they couldn't be told apart.
+func NewRegistry(tasks ...Task) *Registry {
+	registry := &Registry{tasks: make(map[string]Task, len(tasks))}
+	for _, task := range tasks {
+		if _, exists := registry.tasks[task.Name()]; exists {
+			panic(fmt.Sprintf("backfill: task %s registered twice", task.Name()))
+		}
+		registry.tasks[task.Name()] = task
+	}
+	return registry
+}
+
+// Lookup returns the task with the given name.
+func (r *Registry) Lookup(name string) (Task, bool) {
+	task, ok := r.tasks[name]
+	return task, ok
+}
+
+// Names returns the names of all the registered tasks, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.tasks))
+	for name := range r.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Throttle controls the pace of a backfill.
+type Throttle struct {
+	// BatchSize is the number of rows processed in every step.
+	BatchSize int
+	// RowsPerSecond caps the rate at which rows are processed. Zero leaves
+	// the backfill unthrottled.
+	RowsPerSecond int
+}
+
+// Validate makes sure the throttle is within bounds.
+func (t Throttle) Validate() error {
+	if t.BatchSize <= 0 || t.BatchSize > MaxBatchSize {
+		return fmt.Errorf("backfill: batch size must be between 1 and %d, got %d", MaxBatchSize, t.BatchSize)
+	}
+	if t.RowsPerSecond < 0 {
+		return fmt.Errorf("backfill: rows per second can't be negative, got %d", t.RowsPerSecond)
+	}
+	return nil
+}
+
+// Delay returns how long to wait before the next batch, after processing the
+// given number of rows in the given time, so that the backfill stays within
+// its rate.
+func (t Throttle) Delay(processed int, elapsed time.Duration) time.Duration {
+	if t.RowsPerSecond == 0 || processed == 0 {
+		return 0
+	}
+	budget := time.Duration(processed) * time.Second / time.Duration(t.RowsPerSecond)
+	if elapsed >= budget {
+		return 0
+	}
+	return budget - elapsed
+}
+
+// Checkpoint is the progress of a backfill.
+type Checkpoint struct {
+	// Cursor is the cursor of the last row which was processed, or empty if
+	// no row was processed yet.
+	Cursor string
+	// Processed is the number of rows processed so far.
+	Processed int64
+	// Done is whether all the rows were processed.
+	Done bool
+}
+
+// Step processes the batch which follows the checkpoint. It returns the new
+// checkpoint, to be stored before the next step, and how long to wait before
+// running it. The checkpoint is returned unchanged if the batch fails.
+func Step(ctx context.Context, task Task, throttle Throttle, checkpoint Checkpoint) (Checkpoint, time.Duration, error) {
+	if checkpoint.Done {
+		return checkpoint, 0, nil
+	}
+
+	start := time.Now()
+	next, processed, err := task.Process(ctx, checkpoint.Cursor, throttle.BatchSize)
+	if err != nil {
+		return checkpoint, 0, fmt.Errorf("backfill: processing batch of %s after %q: %w", task.Name(), checkpoint.Cursor, err)
+	}
+
+	if processed > 0 {
+		checkpoint.Cursor = next
+		checkpoint.Processed += int64(processed)
+	}
+	checkpoint.Done = processed < throttle.BatchSize
+	return checkpoint, throttle.Delay(processed, time.Since(start)), nil
+}
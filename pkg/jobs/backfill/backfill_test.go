@@ -0,0 +1,92 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingTask processes the rows 1..total, using their number as the
+// cursor.
+type countingTask struct {
+	total int
+	err   error
+}
+
+func (t countingTask) Name() string {
+	return "counting"
+}
+
+func (t countingTask) Process(_ context.Context, cursor string, limit int) (string, int, error) {
+	if t.err != nil {
+		return "", 0, t.err
+	}
+	last := 0
+	if cursor != "" {
+		last, _ = strconv.Atoi(cursor)
+	}
+	processed := min(limit, t.total-last)
+	return strconv.Itoa(last + processed), processed, nil
+}
+
+func TestStep(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	task := countingTask{total: 5}
+	throttle := Throttle{BatchSize: 2}
+
+	checkpoint := Checkpoint{}
+	var err error
+	for range 3 {
+		checkpoint, _, err = Step(ctx, task, throttle, checkpoint)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, Checkpoint{Cursor: "5", Processed: 5, Done: true}, checkpoint)
+
+	// a full last batch takes one more, empty, step to finish
+	checkpoint, _, err = Step(ctx, countingTask{total: 4}, throttle, Checkpoint{Cursor: "4", Processed: 4})
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Cursor: "4", Processed: 4, Done: true}, checkpoint)
+
+	failed := countingTask{err: errors.New("boom")}
+	checkpoint, _, err = Step(ctx, failed, throttle, Checkpoint{Cursor: "2", Processed: 2})
+	assert.Error(t, err)
+	assert.Equal(t, Checkpoint{Cursor: "2", Processed: 2}, checkpoint)
+}
+
+func TestThrottle(t *testing.T) {
+	t.Parallel()
+
+	throttle := Throttle{BatchSize: 100, RowsPerSecond: 50}
+	assert.NoError(t, throttle.Validate())
+	assert.Equal(t, 2*time.Second, throttle.Delay(100, 0))
+	assert.Equal(t, 500*time.Millisecond, throttle.Delay(100, 1500*time.Millisecond))
+	assert.Zero(t, throttle.Delay(100, 3*time.Second))
+	assert.Zero(t, throttle.Delay(0, 0))
+	assert.Zero(t, Throttle{BatchSize: 100}.Delay(100, 0))
+
+	assert.Error(t, Throttle{}.Validate())
+	assert.Error(t, Throttle{BatchSize: MaxBatchSize + 1}.Validate())
+	assert.Error(t, Throttle{BatchSize: 10, RowsPerSecond: -1}.Validate())
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(countingTask{})
+	task, ok := registry.Lookup("counting")
+	assert.True(t, ok)
+	assert.Equal(t, "counting", task.Name())
+	assert.Equal(t, []string{"counting"}, registry.Names())
+
+	_, ok = registry.Lookup("missing")
+	assert.False(t, ok)
+
+	assert.Panics(t, func() { NewRegistry(countingTask{}, countingTask{}) })
+}
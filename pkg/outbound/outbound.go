@@ -0,0 +1,143 @@
+// Package outbound sends requests to URLs which customers configure, such as
+// webhook endpoints and backchannel logout URIs.
+//
+// These URLs can't be trusted to point to the internet. A customer could
+// configure one which resolves to our own network, e.g. the metadata service
+// of the cloud provider, and read the response back through the API. Clients
+// of this package refuse to connect to any address which isn't public. The
+// check happens after DNS resolution, right before connecting, so that a
+// hostname can't resolve to a public address when it's validated and to a
+// private one when it's used. Redirects are never followed, since they could
+// point anywhere.
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+)
+
+// MaxSnippetSize is the maximum size of a response snippet, in bytes.
+const MaxSnippetSize = 256
+
+const (
+	dialTimeout         = 5 * time.Second
+	tlsHandshakeTimeout = 5 * time.Second
+)
+
+// ErrForbiddenAddress is returned when a URL resolves to an address which
+// isn't public.
+var ErrForbiddenAddress = errors.New("outbound: address is not public")
+
+// nonPublicPrefixes are the ranges which aren't covered by the netip.Addr
+// predicates, but still aren't reachable on the internet, or could be
+// translated to addresses which aren't.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+}
+
+// IsPublic reports whether the address is reachable on the internet.
+// IPv4-mapped IPv6 addresses are checked as the IPv4 address they map.
+func IsPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsUnspecified() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient returns an HTTP client which only connects to public addresses
+// and doesn't follow redirects. Redirect responses are returned as is.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, IsPublic)
+}
+
+func newClient(timeout time.Duration, allowed func(netip.Addr) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: dialTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !allowed(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// a proxy would connect on our behalf, bypassing the dialer
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: tlsHandshakeTimeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Snippet reads the start of a response body and returns it sanitized, so
+// that it can be kept for debugging and shown to customers.
+func Snippet(body io.Reader) string {
+	raw, _ := io.ReadAll(io.LimitReader(body, MaxSnippetSize+1))
+	return Sanitize(string(raw))
+}
+
+// Sanitize truncates the text to MaxSnippetSize bytes and replaces invalid
+// UTF-8 and control characters with spaces. Runs of whitespace are collapsed,
+// so that snippets fit on a line.
+func Sanitize(text string) string {
+	truncated := len(text) > MaxSnippetSize
+	if truncated {
+		// a character cut in half is invalid UTF-8, which is replaced below
+		text = text[:MaxSnippetSize]
+	}
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range strings.ToValidUTF8(text, " ") {
+		if !unicode.IsPrint(r) {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+
+	snippet := strings.Join(strings.Fields(b.String()), " ")
+	if truncated {
+		snippet += "…"
+	}
+	return snippet
+}
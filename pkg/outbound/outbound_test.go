@@ -0,0 +1,100 @@
+package outbound
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	t.Parallel()
+
+	for _, addr := range []string{
+		"8.8.8.8",
+		"104.16.0.1",
+		"2606:4700::1",
+	} {
+		assert.True(t, IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+
+	for _, addr := range []string{
+		"127.0.0.1",
+		"10.0.0.1",
+		"172.16.5.4",
+		"192.168.1.1",
+		"169.254.169.254",
+		"0.0.0.0",
+		"0.1.2.3",
+		"100.100.100.200",
+		"224.0.0.1",
+		"255.255.255.255",
+		"::",
+		"::1",
+		"fd00:ec2::254",
+		"fe80::1",
+		"::ffff:127.0.0.1",
+		"::ffff:169.254.169.254",
+		"64:ff9b::a9fe:a9fe",
+		"2002:a9fe:a9fe::",
+	} {
+		assert.False(t, IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestNewClientRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Get(server.URL)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrForbiddenAddress))
+	assert.False(t, called)
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	t.Parallel()
+
+	followed := false
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		followed = true
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// loopback is allowed here, so that the test server can be reached
+	client := newClient(time.Second, func(netip.Addr) bool { return true })
+	res, err := client.Get(server.URL + "/redirect")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusFound, res.StatusCode)
+	assert.False(t, followed)
+}
+
+func TestSnippet(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", Snippet(strings.NewReader("")))
+	assert.Equal(t, "ok", Snippet(strings.NewReader("ok\n")))
+	assert.Equal(t, "line one line two [31m", Snippet(strings.NewReader("line one\r\n\tline two\x00\x1b[31m")))
+	assert.Equal(t, "bad utf8", Snippet(strings.NewReader("bad\xffutf8")))
+
+	long := Snippet(strings.NewReader(strings.Repeat("a", MaxSnippetSize-1) + "é" + strings.Repeat("b", 1000)))
+	assert.Equal(t, strings.Repeat("a", MaxSnippetSize-1)+"…", long)
+}
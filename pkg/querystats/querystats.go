@@ -0,0 +1,151 @@
+// Package querystats counts the database queries a request runs and the
+// time it spends on them, so that requests which run more queries than
+// they should, e.g. because of N+1 patterns, can be spotted.
+//
+// A Recorder is attached to the context of every request. Executors wrapped
+// with Wrap record each query they run against the recorder of the context
+// it's run with. Queries run outside of a request aren't recorded.
+package querystats
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxSlowQueries is the number of slowest queries a recorder keeps
+	maxSlowQueries = 5
+	// maxQueryLength is the length slow queries are truncated to
+	maxQueryLength = 512
+)
+
+// Query is a query which was run, along with how long it took.
+type Query struct {
+	Query    string        `json:"query"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Stats summarize the queries of a request.
+type Stats struct {
+	Count    int
+	Duration time.Duration
+	// Slowest are the slowest queries of the request, slowest first.
+	Slowest []Query
+}
+
+// Budget is the number of queries and the time a request may spend on them
+// before it's considered to run too many. Zero values are unlimited.
+type Budget struct {
+	Queries  int
+	Duration time.Duration
+}
+
+// IsExceededBy reports whether the stats go over the budget.
+func (b Budget) IsExceededBy(stats Stats) bool {
+	return (b.Queries > 0 && stats.Count > b.Queries) ||
+		(b.Duration > 0 && stats.Duration > b.Duration)
+}
+
+// Recorder accumulates the queries of a single request. It's safe for
+// concurrent use, since requests may run queries in parallel.
+type Recorder struct {
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Record adds a query which took the given time to the recorder.
+func (r *Recorder) Record(query string, took time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stats.Count++
+	r.stats.Duration += took
+
+	if len(r.stats.Slowest) == maxSlowQueries && took <= r.stats.Slowest[maxSlowQueries-1].Duration {
+		return
+	}
+	if len(query) > maxQueryLength {
+		query = query[:maxQueryLength]
+	}
+	r.stats.Slowest = append(r.stats.Slowest, Query{Query: query, Duration: took})
+	sort.SliceStable(r.stats.Slowest, func(i, j int) bool {
+		return r.stats.Slowest[i].Duration > r.stats.Slowest[j].Duration
+	})
+	if len(r.stats.Slowest) > maxSlowQueries {
+		r.stats.Slowest = r.stats.Slowest[:maxSlowQueries]
+	}
+}
+
+// Stats returns a copy of the stats recorded so far.
+func (r *Recorder) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	stats.Slowest = append([]Query(nil), r.stats.Slowest...)
+	return stats
+}
+
+type recorderKey struct{}
+
+// NewContext returns a copy of ctx with a new, empty recorder, along with
+// the recorder itself.
+func NewContext(ctx context.Context) (context.Context, *Recorder) {
+	recorder := &Recorder{}
+	return context.WithValue(ctx, recorderKey{}, recorder), recorder
+}
+
+// FromContext returns the recorder of the request in ctx, if any.
+func FromContext(ctx context.Context) (*Recorder, bool) {
+	recorder, ok := ctx.Value(recorderKey{}).(*Recorder)
+	return recorder, ok
+}
+
+// Record adds a query which took the given time to the recorder of ctx, if
+// any.
+func Record(ctx context.Context, query string, took time.Duration) {
+	if recorder, ok := FromContext(ctx); ok {
+		recorder.Record(query, took)
+	}
+}
+
+// Executor runs queries with a context. It's the part of the executors of
+// database/sql, and of the ones built on top of them, that is instrumented.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Wrap returns an executor which records every query it runs against the
+// recorder of the context of the query. The time of QueryContext only
+// covers running the query, not reading its rows.
+func Wrap(exec Executor) Executor {
+	return &executor{exec: exec, now: time.Now}
+}
+
+type executor struct {
+	exec Executor
+	now  func() time.Time
+}
+
+func (e *executor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	startedAt := e.now()
+	defer func() { Record(ctx, query, e.now().Sub(startedAt)) }()
+	return e.exec.ExecContext(ctx, query, args...)
+}
+
+func (e *executor) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	startedAt := e.now()
+	defer func() { Record(ctx, query, e.now().Sub(startedAt)) }()
+	return e.exec.QueryContext(ctx, query, args...)
+}
+
+func (e *executor) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	startedAt := e.now()
+	defer func() { Record(ctx, query, e.now().Sub(startedAt)) }()
+	return e.exec.QueryRowContext(ctx, query, args...)
+}
@@ -0,0 +1,72 @@
+package querystats
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderKeepsSlowestQueries(t *testing.T) {
+	t.Parallel()
+
+	recorder := &Recorder{}
+	for i := 1; i <= maxSlowQueries+2; i++ {
+		recorder.Record(fmt.Sprintf("query %d", i), time.Duration(i)*time.Millisecond)
+	}
+
+	stats := recorder.Stats()
+	assert.Equal(t, maxSlowQueries+2, stats.Count)
+	assert.Equal(t, 28*time.Millisecond, stats.Duration)
+	assert.Len(t, stats.Slowest, maxSlowQueries)
+	assert.Equal(t, Query{Query: "query 7", Duration: 7 * time.Millisecond}, stats.Slowest[0])
+	assert.Equal(t, Query{Query: "query 3", Duration: 3 * time.Millisecond}, stats.Slowest[maxSlowQueries-1])
+}
+
+func TestBudgetIsExceededBy(t *testing.T) {
+	t.Parallel()
+
+	stats := Stats{Count: 20, Duration: 100 * time.Millisecond}
+	assert.False(t, Budget{}.IsExceededBy(stats))
+	assert.False(t, Budget{Queries: 20, Duration: 100 * time.Millisecond}.IsExceededBy(stats))
+	assert.True(t, Budget{Queries: 19}.IsExceededBy(stats))
+	assert.True(t, Budget{Duration: 99 * time.Millisecond}.IsExceededBy(stats))
+}
+
+type fakeExecutor struct{}
+
+func (fakeExecutor) ExecContext(context.Context, string, ...any) (sql.Result, error) {
+	return nil, nil
+}
+
+func (fakeExecutor) QueryContext(context.Context, string, ...any) (*sql.Rows, error) {
+	return nil, nil
+}
+
+func (fakeExecutor) QueryRowContext(context.Context, string, ...any) *sql.Row {
+	return nil
+}
+
+func TestWrapRecordsQueriesOfContext(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	exec := &executor{exec: fakeExecutor{}, now: func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}}
+
+	ctx, recorder := NewContext(context.Background())
+	_, _ = exec.ExecContext(ctx, "UPDATE users SET updated_at = now()")
+	_, _ = exec.QueryContext(ctx, "SELECT * FROM users")
+	_ = exec.QueryRowContext(ctx, "SELECT count(*) FROM users")
+	// queries outside of a request aren't recorded anywhere
+	_, _ = exec.QueryContext(context.Background(), "SELECT * FROM users")
+
+	stats := recorder.Stats()
+	assert.Equal(t, 3, stats.Count)
+	assert.Equal(t, 3*time.Millisecond, stats.Duration)
+}
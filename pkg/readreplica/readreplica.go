@@ -0,0 +1,157 @@
+// Package readreplica routes read-only queries to a read replica of the
+// database.
+//
+// Database wraps the primary and adds ReadReplica, which returns the
+// executor read-only queries should run on. Routing is behind the
+// ClerkReadReplicaRouting flag, so that it can be turned off without a
+// deploy. Even when it's on, queries only go to the replica while it's
+// caught up with the primary. The lag of the replica is measured in the
+// background by Run, and reads fall back to the primary as soon as it falls
+// behind, can't be measured, or hasn't been measured in a while, so that
+// callers never see data that's noticeably stale.
+//
+// Only queries which can tolerate a replica that's up to MaxLag behind
+// belong on the replica, e.g. list endpoints and the lookups which serialize
+// their results. Anything which is read in order to be written, or right
+// after it was written, must stay on the primary.
+package readreplica
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"clerk/pkg/cenv"
+	"clerk/utils/database"
+	"clerk/utils/log"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+)
+
+const (
+	// MaxLag is how far behind the primary the replica can be while reads
+	// are routed to it.
+	MaxLag = 5 * time.Second
+
+	// lagCheckInterval is how often Run measures the lag.
+	lagCheckInterval = 5 * time.Second
+
+	// lagCheckTimeout bounds a single measurement, so that a replica which
+	// hangs doesn't hold up the next one.
+	lagCheckTimeout = time.Second
+
+	// staleAfter is how long a measurement is trusted for. Reads go back to
+	// the primary if Run stops reporting, e.g. because it's stuck.
+	staleAfter = 3 * lagCheckInterval
+)
+
+// lagQuery returns the lag of the replica in seconds. A replica which has
+// replayed everything it received isn't lagging, even if the primary hasn't
+// written anything in a while.
+const lagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// Database is the primary database, along with its read replica. All the
+// methods of database.Database run on the primary. A single Database is
+// meant to be shared by the whole process, with Run measuring the lag of
+// the replica in the background.
+type Database struct {
+	database.Database
+
+	clock   clockwork.Clock
+	replica database.Database
+
+	// hooks, which are replaced in tests
+	enabled    func() bool
+	measureLag func(ctx context.Context) (time.Duration, error)
+
+	caughtUp  atomic.Bool
+	checkedAt atomic.Int64
+}
+
+// New returns the primary database along with its replica. Reads always go
+// to the primary when replica is nil.
+func New(clock clockwork.Clock, primary, replica database.Database) *Database {
+	return &Database{
+		Database: primary,
+		clock:    clock,
+		replica:  replica,
+		enabled: func() bool {
+			return replica != nil && cenv.IsEnabled(cenv.ClerkReadReplicaRouting)
+		},
+		measureLag: func(ctx context.Context) (time.Duration, error) {
+			return measureLag(ctx, replica)
+		},
+	}
+}
+
+// ReadReplica returns the executor read-only queries should run on right
+// now. It never blocks on the replica; it's the replica only if the last
+// measurement of Run found it caught up.
+func (db *Database) ReadReplica() database.Executor {
+	if db.useReplica() {
+		return db.replica
+	}
+	return db.Database
+}
+
+func (db *Database) useReplica() bool {
+	if !db.enabled() || !db.caughtUp.Load() {
+		return false
+	}
+	checkedAt := time.Unix(0, db.checkedAt.Load())
+	return db.clock.Since(checkedAt) < staleAfter
+}
+
+// Run measures the lag of the replica every lagCheckInterval, until ctx is
+// done. It's meant to run in its own goroutine for the lifetime of the
+// process. Reads go to the primary once it returns.
+func (db *Database) Run(ctx context.Context) {
+	ticker := db.clock.NewTicker(lagCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		db.check(ctx)
+		select {
+		case <-ctx.Done():
+			db.caughtUp.Store(false)
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
+
+// check measures the lag of the replica and records whether reads can go to
+// it. The replica isn't queried at all while routing is off.
+func (db *Database) check(ctx context.Context) {
+	if !db.enabled() {
+		db.caughtUp.Store(false)
+		return
+	}
+
+	lag, err := db.measureLag(ctx)
+	caughtUp := err == nil && lag <= MaxLag
+	db.checkedAt.Store(db.clock.Now().UnixNano())
+	db.caughtUp.Store(caughtUp)
+
+	if err != nil {
+		log.Warning(ctx, "readreplica: measuring replica lag, reading from the primary: %s", err)
+	} else if !caughtUp {
+		log.Warning(ctx, "readreplica: replica is %s behind, reading from the primary", lag)
+	}
+}
+
+func measureLag(ctx context.Context, replica database.Executor) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, lagCheckTimeout)
+	defer cancel()
+
+	var seconds float64
+	if err := queries.Raw(lagQuery).QueryRowContext(ctx, replica).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("readreplica: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
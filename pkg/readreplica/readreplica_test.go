@@ -0,0 +1,115 @@
+package readreplica
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clerk/utils/database"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDB struct {
+	database.Database
+	name string
+}
+
+type fakeLag struct {
+	lag   atomic.Int64
+	err   error
+	calls atomic.Int32
+}
+
+func (f *fakeLag) measure(context.Context) (time.Duration, error) {
+	f.calls.Add(1)
+	return time.Duration(f.lag.Load()), f.err
+}
+
+func testDatabase(clock clockwork.Clock, enabled bool, lag *fakeLag) *Database {
+	return &Database{
+		Database:   &fakeDB{name: "primary"},
+		clock:      clock,
+		replica:    &fakeDB{name: "replica"},
+		enabled:    func() bool { return enabled },
+		measureLag: lag.measure,
+	}
+}
+
+func TestReadReplica(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	lag := &fakeLag{}
+	db := testDatabase(clockwork.NewFakeClock(), false, lag)
+	db.check(ctx)
+	assert.Same(t, db.Database, db.ReadReplica())
+	assert.Zero(t, lag.calls.Load(), "the lag isn't measured while routing is off")
+
+	db = testDatabase(clockwork.NewFakeClock(), true, &fakeLag{})
+	assert.Same(t, db.Database, db.ReadReplica(), "reads stay on the primary until the lag is measured")
+	db.check(ctx)
+	assert.Same(t, db.replica, db.ReadReplica())
+
+	lag = &fakeLag{}
+	lag.lag.Store(int64(MaxLag + time.Millisecond))
+	db = testDatabase(clockwork.NewFakeClock(), true, lag)
+	db.check(ctx)
+	assert.Same(t, db.Database, db.ReadReplica())
+
+	db = testDatabase(clockwork.NewFakeClock(), true, &fakeLag{err: errors.New("connection refused")})
+	db.check(ctx)
+	assert.Same(t, db.Database, db.ReadReplica())
+}
+
+func TestReadReplicaStaleMeasurement(t *testing.T) {
+	t.Parallel()
+
+	clock := clockwork.NewFakeClock()
+	db := testDatabase(clock, true, &fakeLag{})
+	db.check(context.Background())
+	assert.Same(t, db.replica, db.ReadReplica())
+
+	clock.Advance(staleAfter - time.Millisecond)
+	assert.Same(t, db.replica, db.ReadReplica())
+	clock.Advance(time.Millisecond)
+	assert.Same(t, db.Database, db.ReadReplica())
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := clockwork.NewFakeClock()
+	lag := &fakeLag{}
+	db := testDatabase(clock, true, lag)
+
+	done := make(chan struct{})
+	go func() {
+		db.Run(ctx)
+		close(done)
+	}()
+
+	// the lag is measured right away
+	assert.Eventually(t, func() bool { return db.ReadReplica() == db.replica }, time.Second, time.Millisecond)
+	clock.BlockUntil(1)
+
+	// the replica falls behind, which is noticed on the next measurement
+	lag.lag.Store(int64(time.Minute))
+	clock.Advance(lagCheckInterval)
+	assert.Eventually(t, func() bool { return lag.calls.Load() == 2 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return db.ReadReplica() == db.Database }, time.Second, time.Millisecond)
+
+	// and reads go back to it once it catches up
+	lag.lag.Store(0)
+	clock.Advance(lagCheckInterval)
+	assert.Eventually(t, func() bool { return db.ReadReplica() == db.replica }, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+	assert.Same(t, db.Database, db.ReadReplica())
+}
@@ -0,0 +1,220 @@
+// Package scopedtokens restricts and checks the scopes of machine-to-machine
+// tokens.
+//
+// Instances mint scoped tokens from a JWT template which lists the scopes and
+// custom claims tokens may carry, so a backend can hand another service a
+// short-lived token which only grants what the service needs, instead of the
+// secret key of the instance. The scopes travel in the space-delimited
+// "scope" claim of RFC 9068.
+//
+// Services which receive scoped tokens check them with RequireScopes.
+package scopedtokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ClaimScope is the claim which holds the scopes of a token.
+const ClaimScope = "scope"
+
+// MaxLifetime is how long scoped tokens can live for. They're meant to be
+// requested right before they're used, so they're never long-lived, even if
+// their template is.
+const MaxLifetime = time.Hour
+
+// reservedClaims are set by the issuer, so callers can't request them.
+var reservedClaims = []string{"iss", "sub", "aud", "exp", "iat", "nbf", "jti", "azp", ClaimScope}
+
+var (
+	ErrNoScopes          = errors.New("scopedtokens: at least one scope is required")
+	ErrInvalidScope      = errors.New("scopedtokens: scopes must be printable characters without spaces, quotes or backslashes")
+	ErrScopeNotAllowed   = errors.New("scopedtokens: scope is not allowed by the template")
+	ErrReservedClaim     = errors.New("scopedtokens: claim is reserved")
+	ErrClaimNotAllowed   = errors.New("scopedtokens: claim is not allowed by the template")
+	ErrInvalidLifetime   = errors.New("scopedtokens: lifetime must be positive and at most an hour")
+	ErrMissingToken      = errors.New("scopedtokens: missing bearer token")
+	ErrInsufficientScope = errors.New("scopedtokens: token is missing required scopes")
+)
+
+// RejectionError names the scope or claim an allowlist rejected. It wraps
+// one of the Err* values of the package.
+type RejectionError struct {
+	Err   error
+	Value string
+}
+
+func (e *RejectionError) Error() string {
+	return fmt.Sprintf("%s: %q", e.Err, e.Value)
+}
+
+func (e *RejectionError) Unwrap() error {
+	return e.Err
+}
+
+func reject(err error, value string) error {
+	return &RejectionError{Err: err, Value: value}
+}
+
+// Allowlist is what the tokens of a template may carry.
+type Allowlist struct {
+	Scopes []string
+	Claims []string
+}
+
+// Request is what the caller asks a token to carry.
+type Request struct {
+	Scopes []string
+	Claims map[string]any
+}
+
+// Validate returns a RejectionError if the allowlist has invalid scopes or
+// reserved claims, which no request could ever get.
+func (a Allowlist) Validate() error {
+	for _, scope := range a.Scopes {
+		if !validScope(scope) {
+			return reject(ErrInvalidScope, scope)
+		}
+	}
+	for _, claim := range a.Claims {
+		if slices.Contains(reservedClaims, claim) {
+			return reject(ErrReservedClaim, claim)
+		}
+	}
+	return nil
+}
+
+// Check returns an error if the request asks for scopes or claims which the
+// allowlist doesn't have, or for a reserved claim. Scopes and claims which
+// are rejected are returned as a RejectionError.
+func (a Allowlist) Check(req Request) error {
+	if len(req.Scopes) == 0 {
+		return ErrNoScopes
+	}
+	for _, scope := range req.Scopes {
+		if !validScope(scope) {
+			return reject(ErrInvalidScope, scope)
+		}
+		if !slices.Contains(a.Scopes, scope) {
+			return reject(ErrScopeNotAllowed, scope)
+		}
+	}
+	for claim := range req.Claims {
+		if slices.Contains(reservedClaims, claim) {
+			return reject(ErrReservedClaim, claim)
+		}
+		if !slices.Contains(a.Claims, claim) {
+			return reject(ErrClaimNotAllowed, claim)
+		}
+	}
+	return nil
+}
+
+// validScope reports whether the scope is a scope-token of RFC 6749.
+// https://datatracker.ietf.org/doc/html/rfc6749#section-3.3
+func validScope(scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for _, r := range scope {
+		if r <= ' ' || r > '~' || r == '"' || r == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// Lifetime returns how long a token should live for, given the lifetime the
+// caller asked for, if any, and the one of the template. Tokens live as long
+// as their template by default, and never longer than MaxLifetime.
+func Lifetime(requested *time.Duration, template time.Duration) (time.Duration, error) {
+	lifetime := min(template, MaxLifetime)
+	if requested == nil {
+		return lifetime, nil
+	}
+	if *requested <= 0 || *requested > lifetime {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidLifetime, *requested)
+	}
+	return *requested, nil
+}
+
+// FormatScopes returns the value of the scope claim for the scopes. Scopes
+// are sorted and deduplicated, so that the same scopes always yield the same
+// claim.
+func FormatScopes(scopes []string) string {
+	sorted := slices.Clone(scopes)
+	slices.Sort(sorted)
+	return strings.Join(slices.Compact(sorted), " ")
+}
+
+// ParseScopes returns the scopes of a scope claim.
+func ParseScopes(claim string) []string {
+	return strings.Fields(claim)
+}
+
+// Verifier checks the signature and the expiration of a token and returns its
+// claims. Services usually verify tokens against the JWKS of the instance.
+type Verifier func(ctx context.Context, token string) (map[string]any, error)
+
+type contextKey struct{}
+
+// ClaimsFromContext returns the claims of the token which was checked by
+// RequireScopes.
+func ClaimsFromContext(ctx context.Context) (map[string]any, bool) {
+	claims, ok := ctx.Value(contextKey{}).(map[string]any)
+	return claims, ok
+}
+
+// RequireScopes returns a middleware which only lets requests through if
+// their bearer token is verified by verify and has all the required scopes.
+// Requests without a valid token get a 401, and requests whose token lacks
+// scopes a 403, along with the WWW-Authenticate header of RFC 6750.
+// https://datatracker.ietf.org/doc/html/rfc6750#section-3
+func RequireScopes(verify Verifier, required ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, err := checkRequest(r, verify, required)
+			switch {
+			case errors.Is(err, ErrInsufficientScope):
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(required, " ")))
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			case errors.Is(err, ErrMissingToken):
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			case err != nil:
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+		})
+	}
+}
+
+func checkRequest(r *http.Request, verify Verifier, required []string) (map[string]any, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims, err := verify(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, _ := claims[ClaimScope].(string)
+	granted := ParseScopes(scope)
+	for _, scope := range required {
+		if !slices.Contains(granted, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientScope, scope)
+		}
+	}
+	return claims, nil
+}
@@ -0,0 +1,128 @@
+package scopedtokens
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowlistValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, Allowlist{Scopes: []string{"orders:read"}, Claims: []string{"tenant"}}.Validate())
+	assert.ErrorIs(t, Allowlist{Scopes: []string{"orders read"}}.Validate(), ErrInvalidScope)
+	assert.ErrorIs(t, Allowlist{Claims: []string{"exp"}}.Validate(), ErrReservedClaim)
+}
+
+func TestAllowlistCheck(t *testing.T) {
+	t.Parallel()
+
+	allowlist := Allowlist{
+		Scopes: []string{"orders:read", "orders:write"},
+		Claims: []string{"tenant"},
+	}
+
+	assert.NoError(t, allowlist.Check(Request{
+		Scopes: []string{"orders:read"},
+		Claims: map[string]any{"tenant": "acme"},
+	}))
+	assert.ErrorIs(t, allowlist.Check(Request{}), ErrNoScopes)
+	assert.ErrorIs(t, allowlist.Check(Request{Scopes: []string{"orders read"}}), ErrInvalidScope)
+	err := allowlist.Check(Request{Scopes: []string{"orders:read", "users:read"}})
+	assert.ErrorIs(t, err, ErrScopeNotAllowed)
+	var rejection *RejectionError
+	require.ErrorAs(t, err, &rejection)
+	assert.Equal(t, "users:read", rejection.Value)
+	assert.ErrorIs(t, allowlist.Check(Request{
+		Scopes: []string{"orders:read"},
+		Claims: map[string]any{"sub": "user_1"},
+	}), ErrReservedClaim)
+	assert.ErrorIs(t, allowlist.Check(Request{
+		Scopes: []string{"orders:read"},
+		Claims: map[string]any{"role": "admin"},
+	}), ErrClaimNotAllowed)
+}
+
+func TestLifetime(t *testing.T) {
+	t.Parallel()
+
+	lifetime, err := Lifetime(nil, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, lifetime)
+
+	lifetime, err = Lifetime(nil, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, MaxLifetime, lifetime)
+
+	requested := 5 * time.Minute
+	lifetime, err = Lifetime(&requested, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, requested, lifetime)
+
+	requested = 20 * time.Minute
+	_, err = Lifetime(&requested, 10*time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidLifetime)
+
+	requested = 0
+	_, err = Lifetime(&requested, 10*time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidLifetime)
+}
+
+func TestFormatScopes(t *testing.T) {
+	t.Parallel()
+
+	claim := FormatScopes([]string{"orders:write", "orders:read", "orders:write"})
+	assert.Equal(t, "orders:read orders:write", claim)
+	assert.Equal(t, []string{"orders:read", "orders:write"}, ParseScopes(claim))
+}
+
+func TestRequireScopes(t *testing.T) {
+	t.Parallel()
+
+	verify := func(_ context.Context, token string) (map[string]any, error) {
+		switch token {
+		case "reader":
+			return map[string]any{ClaimScope: "orders:read"}, nil
+		case "writer":
+			return map[string]any{ClaimScope: "orders:read orders:write"}, nil
+		}
+		return nil, errors.New("invalid signature")
+	}
+	handler := RequireScopes(verify, "orders:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "orders:read orders:write", claims[ClaimScope])
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("Bearer writer")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serve("Bearer reader")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="orders:write"`, w.Header().Get("WWW-Authenticate"))
+
+	w = serve("Bearer forged")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Bearer error="invalid_token"`, w.Header().Get("WWW-Authenticate"))
+
+	w = serve("")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+}
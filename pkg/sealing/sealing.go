@@ -0,0 +1,71 @@
+// Package sealing encrypts payloads which carry secrets while they're
+// stored, e.g. the passwords of users waiting to be imported.
+//
+// Payloads are sealed with AES-256-GCM. Additional data, such as the id of
+// the instance a payload belongs to, binds a sealed payload to its owner, so
+// that it can't be opened as another one's.
+package sealing
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of sealing keys, in bytes.
+const KeySize = 32
+
+var (
+	ErrInvalidKey    = errors.New("sealing: key must be 32 bytes, base64 encoded")
+	ErrInvalidSealed = errors.New("sealing: sealed payload is malformed or was tampered with")
+)
+
+// Key is a key which seals and opens payloads.
+type Key struct {
+	aead cipher.AEAD
+}
+
+// ParseKey returns the key encoded in base64.
+func ParseKey(encoded string) (*Key, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("sealing: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("sealing: %w", err)
+	}
+	return &Key{aead: aead}, nil
+}
+
+// Seal encrypts the payload, and returns it along with the random nonce it
+// was encrypted with, as base64.
+func (k *Key) Seal(payload, additionalData []byte) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("sealing: generating nonce: %w", err)
+	}
+	sealed := k.aead.Seal(nonce, nonce, payload, additionalData)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a payload returned by Seal, given the same additional data.
+func (k *Key) Open(sealed string, additionalData []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < k.aead.NonceSize() {
+		return nil, ErrInvalidSealed
+	}
+	nonce, ciphertext := raw[:k.aead.NonceSize()], raw[k.aead.NonceSize():]
+	payload, err := k.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrInvalidSealed
+	}
+	return payload, nil
+}
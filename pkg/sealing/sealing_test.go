@@ -0,0 +1,69 @@
+package sealing
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(t *testing.T, b byte) *Key {
+	t.Helper()
+	key, err := ParseKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), KeySize))))
+	require.NoError(t, err)
+	return key
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseKey("not base64!")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = ParseKey(base64.StdEncoding.EncodeToString([]byte("too short")))
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestSealOpen(t *testing.T) {
+	t.Parallel()
+
+	key := testKey(t, 'a')
+	payload := []byte(`[{"password":"hunter22"}]`)
+
+	sealed, err := key.Seal(payload, []byte("ins_1"))
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "hunter22")
+
+	opened, err := key.Open(sealed, []byte("ins_1"))
+	require.NoError(t, err)
+	assert.Equal(t, payload, opened)
+
+	// Nonces are random, so the same payload never seals the same way.
+	again, err := key.Seal(payload, []byte("ins_1"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+}
+
+func TestOpenRejects(t *testing.T) {
+	t.Parallel()
+
+	key := testKey(t, 'a')
+	sealed, err := key.Seal([]byte("secret"), []byte("ins_1"))
+	require.NoError(t, err)
+
+	_, err = key.Open(sealed, []byte("ins_2"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+
+	_, err = testKey(t, 'b').Open(sealed, []byte("ins_1"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	require.NoError(t, err)
+	raw[len(raw)-1] ^= 1
+	_, err = key.Open(base64.StdEncoding.EncodeToString(raw), []byte("ins_1"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+
+	_, err = key.Open("AA==", []byte("ins_1"))
+	assert.ErrorIs(t, err, ErrInvalidSealed)
+}
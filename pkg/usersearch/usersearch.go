@@ -0,0 +1,167 @@
+// Package usersearch turns user search queries into Postgres full-text
+// queries, and finds which identifier of a user a query matched.
+//
+// Users are searched over their names, email addresses, username and phone
+// numbers. Every term of a query has to match the start of a word of one of
+// them, so that results narrow down as the query is typed, e.g. "jane exa"
+// matches jane@example.com. User IDs and web3 wallets only match the query
+// as a whole.
+package usersearch
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The fields of a user which are searched, in the order their matches are
+// preferred when highlighting.
+const (
+	FieldEmailAddress = "email_address"
+	FieldUsername     = "username"
+	FieldPhoneNumber  = "phone_number"
+	FieldFirstName    = "first_name"
+	FieldLastName     = "last_name"
+	FieldWeb3Wallet   = "web3_wallet"
+	FieldUserID       = "id"
+)
+
+var fieldPriority = map[string]int{
+	FieldEmailAddress: 0,
+	FieldUsername:     1,
+	FieldPhoneNumber:  2,
+	FieldFirstName:    3,
+	FieldLastName:     4,
+	FieldWeb3Wallet:   5,
+	FieldUserID:       6,
+}
+
+// maxTerms caps the terms of a query, so that a single query can't make
+// the full-text query arbitrarily expensive.
+const maxTerms = 8
+
+// Terms returns the lower-cased terms of the query. Characters which are
+// neither letters nor digits separate terms, except for the ones which are
+// commonly part of identifiers, so that email addresses and phone numbers
+// stay in one piece.
+func Terms(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("@.+-_", r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		// Punctuation on its own, e.g. a stray "-", doesn't match anything.
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, field)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// TSQuery returns the text of a tsquery which matches documents having all
+// the terms as word prefixes. Terms are quoted, so that they can't inject
+// tsquery operators. It's meant to be passed to to_tsquery('simple', ...).
+func TSQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = "'" + strings.ReplaceAll(term, "'", "''") + "':*"
+	}
+	return strings.Join(quoted, " & ")
+}
+
+// Candidate is an identifier of a user which a query can match. Exact
+// candidates only match a query which is the whole value.
+type Candidate struct {
+	Field string
+	Value string
+	Exact bool
+}
+
+// Highlight is the part of a value which matched a term, as offsets in
+// characters, i.e. runes, with End being exclusive.
+type Highlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Match is the identifier which matched a query best, along with the parts
+// of it which matched.
+type Match struct {
+	Field      string      `json:"field"`
+	Value      string      `json:"value"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// BestMatch returns the candidate which the most terms match, preferring
+// fields in the order of their constants, or nil if no term matches any
+// candidate.
+func BestMatch(terms []string, candidates []Candidate) *Match {
+	var best *Match
+	for _, candidate := range candidates {
+		var highlights []Highlight
+		if candidate.Exact {
+			highlights = highlightExact(terms, candidate.Value)
+		} else {
+			highlights = highlight(terms, candidate.Value)
+		}
+		if len(highlights) == 0 {
+			continue
+		}
+		if best != nil && !better(len(highlights), candidate.Field, len(best.Highlights), best.Field) {
+			continue
+		}
+		best = &Match{Field: candidate.Field, Value: candidate.Value, Highlights: highlights}
+	}
+	return best
+}
+
+func better(matches int, field string, bestMatches int, bestField string) bool {
+	if matches != bestMatches {
+		return matches > bestMatches
+	}
+	return fieldPriority[field] < fieldPriority[bestField]
+}
+
+// highlight returns the first occurrence of every term in the value, sorted
+// by position. Occurrences which overlap an earlier one are merged into it.
+func highlight(terms []string, value string) []Highlight {
+	lower := strings.ToLower(value)
+
+	var highlights []Highlight
+	for _, term := range terms {
+		i := strings.Index(lower, term)
+		if i < 0 {
+			continue
+		}
+		start := utf8.RuneCountInString(lower[:i])
+		highlights = insert(highlights, Highlight{Start: start, End: start + utf8.RuneCountInString(term)})
+	}
+	return highlights
+}
+
+// highlightExact returns the whole value if it's the only term, ignoring
+// case.
+func highlightExact(terms []string, value string) []Highlight {
+	if len(terms) != 1 || terms[0] != strings.ToLower(value) {
+		return nil
+	}
+	return []Highlight{{Start: 0, End: utf8.RuneCountInString(value)}}
+}
+
+func insert(highlights []Highlight, h Highlight) []Highlight {
+	for i, existing := range highlights {
+		if h.End < existing.Start {
+			return append(highlights[:i], append([]Highlight{h}, highlights[i:]...)...)
+		}
+		if h.Start <= existing.End {
+			highlights[i] = Highlight{Start: min(h.Start, existing.Start), End: max(h.End, existing.End)}
+			return highlights
+		}
+	}
+	return append(highlights, h)
+}
@@ -0,0 +1,93 @@
+package usersearch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerms(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"jane", "doe"}, Terms("  Jane DOE "))
+	assert.Equal(t, []string{"jane@example.com", "+1555"}, Terms("jane@example.com, +1555"))
+	assert.Equal(t, []string{"o", "brien"}, Terms("o'brien"))
+	assert.Equal(t, []string{"jane"}, Terms("jane - & | !"))
+	assert.Empty(t, Terms("-- ()"))
+	assert.Len(t, Terms("a b c d e f g h i j"), maxTerms)
+}
+
+func TestTSQuery(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "'jane':* & 'doe':*", TSQuery([]string{"jane", "doe"}))
+	assert.Equal(t, "'it''s':*", TSQuery([]string{"it's"}))
+}
+
+func TestBestMatch(t *testing.T) {
+	t.Parallel()
+
+	candidates := []Candidate{
+		{Field: FieldEmailAddress, Value: "jane.doe@example.com"},
+		{Field: FieldUsername, Value: "janed"},
+		{Field: FieldFirstName, Value: "Jane"},
+		{Field: FieldLastName, Value: "Doe"},
+	}
+
+	match := BestMatch(Terms("doe jane"), candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, &Match{
+		Field:      FieldEmailAddress,
+		Value:      "jane.doe@example.com",
+		Highlights: []Highlight{{Start: 0, End: 4}, {Start: 5, End: 8}},
+	}, match)
+
+	// Fields which match as many terms are preferred in order.
+	match = BestMatch(Terms("JANE"), candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, FieldEmailAddress, match.Field)
+
+	match = BestMatch(Terms("janed"), candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, FieldUsername, match.Field)
+	assert.Equal(t, []Highlight{{Start: 0, End: 5}}, match.Highlights)
+
+	assert.Nil(t, BestMatch(Terms("smith"), candidates))
+}
+
+func TestBestMatchExact(t *testing.T) {
+	t.Parallel()
+
+	candidates := []Candidate{
+		{Field: FieldFirstName, Value: "Jane"},
+		{Field: FieldWeb3Wallet, Value: "0xAbC123", Exact: true},
+		{Field: FieldUserID, Value: "user_2abc", Exact: true},
+	}
+
+	match := BestMatch(Terms("0xabc123"), candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, &Match{Field: FieldWeb3Wallet, Value: "0xAbC123", Highlights: []Highlight{{Start: 0, End: 8}}}, match)
+
+	match = BestMatch(Terms("user_2abc"), candidates)
+	require.NotNil(t, match)
+	assert.Equal(t, FieldUserID, match.Field)
+
+	// Exact candidates don't match parts of their value.
+	assert.Nil(t, BestMatch(Terms("0xabc"), candidates))
+	assert.Nil(t, BestMatch(Terms("user"), candidates))
+}
+
+func TestBestMatchHighlights(t *testing.T) {
+	t.Parallel()
+
+	// Offsets are in characters, not bytes.
+	match := BestMatch([]string{"zoë", "ller"}, []Candidate{{Field: FieldLastName, Value: "Zoë Müller"}})
+	require.NotNil(t, match)
+	assert.Equal(t, []Highlight{{Start: 0, End: 3}, {Start: 6, End: 10}}, match.Highlights)
+
+	// Overlapping occurrences are merged.
+	match = BestMatch([]string{"ann", "anna"}, []Candidate{{Field: FieldFirstName, Value: "Annabel"}})
+	require.NotNil(t, match)
+	assert.Equal(t, []Highlight{{Start: 0, End: 4}}, match.Highlights)
+}
@@ -0,0 +1,96 @@
+package web3
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Chain verifies wallets and signatures of a blockchain. Chains are
+// identified by their CAIP-2 namespace, e.g. "eip155" for Ethereum and the
+// EVM compatible chains.
+//
+// See https://github.com/ChainAgnostic/CAIPs/blob/main/CAIPs/caip-2.md
+type Chain interface {
+	// Namespace is the CAIP-2 namespace of the chain.
+	Namespace() string
+
+	// Name is the name of the chain, as shown in sign-in messages.
+	Name() string
+
+	// DefaultChainID is the CAIP-2 chain id which is used when a wallet
+	// doesn't specify one.
+	DefaultChainID() string
+
+	// ValidAddress reports whether the address is a valid wallet address.
+	ValidAddress(address string) bool
+
+	// SameAddress reports whether both addresses point to the same wallet.
+	SameAddress(a, b string) bool
+
+	// VerifySignature reports whether the signature of the message was
+	// produced by the wallet with the given address.
+	VerifySignature(address, message, signature string) bool
+}
+
+var (
+	chainsMu sync.RWMutex
+	chains   []Chain
+)
+
+// RegisterChains enables the given chains. Addresses are matched against
+// the chains in the order they were registered.
+func RegisterChains(newChains ...Chain) {
+	chainsMu.Lock()
+	defer chainsMu.Unlock()
+	for _, chain := range newChains {
+		if _, ok := chainByNamespace(chain.Namespace()); ok {
+			continue
+		}
+		chains = append(chains, chain)
+	}
+}
+
+// ChainByNamespace returns the registered chain with the given CAIP-2
+// namespace.
+func ChainByNamespace(namespace string) (Chain, bool) {
+	chainsMu.RLock()
+	defer chainsMu.RUnlock()
+	return chainByNamespace(namespace)
+}
+
+func chainByNamespace(namespace string) (Chain, bool) {
+	for _, chain := range chains {
+		if chain.Namespace() == namespace {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
+// ChainForAddress returns the first registered chain which accepts the
+// given wallet address.
+func ChainForAddress(address string) (Chain, bool) {
+	chainsMu.RLock()
+	defer chainsMu.RUnlock()
+	for _, chain := range chains {
+		if chain.ValidAddress(address) {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
+// ChainForID returns the registered chain of the given CAIP-2 chain id,
+// along with the reference part of the id, e.g. "1" for "eip155:1".
+func ChainForID(chainID string) (Chain, string, error) {
+	namespace, reference, ok := strings.Cut(chainID, ":")
+	if !ok || namespace == "" || reference == "" {
+		return nil, "", fmt.Errorf("web3: invalid chain id %q", chainID)
+	}
+	chain, ok := ChainByNamespace(namespace)
+	if !ok {
+		return nil, "", fmt.Errorf("web3: unsupported chain %q", chainID)
+	}
+	return chain, reference, nil
+}
@@ -0,0 +1,75 @@
+package web3
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Ethereum supports Ethereum and the EVM compatible chains, which share
+// their addresses and signatures.
+type Ethereum struct{}
+
+var _ Chain = Ethereum{}
+
+func (Ethereum) Namespace() string {
+	return "eip155"
+}
+
+func (Ethereum) Name() string {
+	return "Ethereum"
+}
+
+func (Ethereum) DefaultChainID() string {
+	return "eip155:1"
+}
+
+func (Ethereum) ValidAddress(address string) bool {
+	return strings.HasPrefix(address, "0x") && common.IsHexAddress(address)
+}
+
+func (Ethereum) SameAddress(a, b string) bool {
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
+
+// VerifySignature verifies personal_sign signatures of the message.
+//
+// https://gist.github.com/dcb9/385631846097e1f59e3cba3b1d42f3ed#file-eth_sign_verify-go
+func (Ethereum) VerifySignature(address, message, signature string) bool {
+	fromAddr := common.HexToAddress(address)
+
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != crypto.SignatureLength {
+		// safe to ignore the error, we just care that the signature is not valid
+		return false
+	}
+
+	// EcRecover returns the address for the account that was used to create the signature.
+	// Note, this function is compatible with eth_sign and personal_sign. As such it recovers
+	// the address of:
+	// hash = keccak256("\x19Ethereum Signed Message:\n"${message length}${message})
+	// addr = ecrecover(hash, signature)
+	//
+	// Note, the signature must conform to the secp256k1 curve R, S and V values, where
+	// the V value must be be 27 or 28 for legacy reasons.
+	//
+	// https://github.com/ethereum/go-ethereum/wiki/Management-APIs#personal_ecRecover
+	//
+	// Original code: https://github.com/ethereum/go-ethereum/blob/55599ee95d4151a2502465e0afc7c47bd1acba77/internal/ethapi/api.go#L442
+	if sig[64] != 27 && sig[64] != 28 {
+		return false
+	}
+	sig[64] -= 27
+
+	pubKey, err := crypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return false
+	}
+
+	recoveredAddr := crypto.PubkeyToAddress(*pubKey)
+
+	return fromAddr == recoveredAddr
+}
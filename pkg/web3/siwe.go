@@ -0,0 +1,256 @@
+package web3
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// SIWEMessage is a Sign-In with Ethereum message, as specified by EIP-4361.
+// Other chains use the same format, as generalized by CAIP-122, with their
+// own name in the header of the message.
+//
+// https://eips.ethereum.org/EIPS/eip-4361
+// https://github.com/ChainAgnostic/CAIPs/blob/main/CAIPs/caip-122.md
+type SIWEMessage struct {
+	Chain     Chain
+	Domain    string
+	Address   string
+	Statement string
+	URI       string
+	Version   string
+	// ChainReference is the reference part of the CAIP-2 chain id, e.g.
+	// "1" for the Ethereum mainnet.
+	ChainReference string
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// SIWEVersion is the only version of the message format.
+const SIWEVersion = "1"
+
+var (
+	ErrInvalidSIWEMessage = errors.New("web3: invalid sign-in message")
+	ErrSIWEMessageExpired = errors.New("web3: sign-in message expired")
+	ErrSIWEMessageNotYet  = errors.New("web3: sign-in message not valid yet")
+)
+
+const (
+	siweHeaderSuffix = " account:"
+	siweURI          = "URI: "
+	siweVersion      = "Version: "
+	siweChainID      = "Chain ID: "
+	siweNonce        = "Nonce: "
+	siweIssuedAt     = "Issued At: "
+	siweExpiration   = "Expiration Time: "
+	siweNotBefore    = "Not Before: "
+	siweRequestID    = "Request ID: "
+	siweResources    = "Resources:"
+)
+
+// ChainID returns the CAIP-2 chain id of the message, e.g. "eip155:1".
+func (m *SIWEMessage) ChainID() string {
+	return m.Chain.Namespace() + ":" + m.ChainReference
+}
+
+// String formats the message as it is signed by the wallet.
+func (m *SIWEMessage) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s wants you to sign in with your %s%s\n", m.Domain, m.Chain.Name(), siweHeaderSuffix)
+	b.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(siweURI + m.URI + "\n")
+	b.WriteString(siweVersion + m.Version + "\n")
+	b.WriteString(siweChainID + m.ChainReference + "\n")
+	b.WriteString(siweNonce + m.Nonce + "\n")
+	b.WriteString(siweIssuedAt + formatSIWETime(m.IssuedAt))
+	if m.ExpirationTime != nil {
+		b.WriteString("\n" + siweExpiration + formatSIWETime(*m.ExpirationTime))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\n" + siweNotBefore + formatSIWETime(*m.NotBefore))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\n" + siweRequestID + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\n" + siweResources)
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+	return b.String()
+}
+
+// Validate checks that the message is valid at the given time.
+func (m *SIWEMessage) Validate(now time.Time) error {
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return ErrSIWEMessageExpired
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return ErrSIWEMessageNotYet
+	}
+	return nil
+}
+
+// IsSIWEMessage reports whether the text looks like a sign-in message, as
+// opposed to a plain nonce.
+func IsSIWEMessage(text string) bool {
+	header, _, _ := strings.Cut(text, "\n")
+	return strings.Contains(header, " wants you to sign in with your ") && strings.HasSuffix(header, siweHeaderSuffix)
+}
+
+// ParseSIWEMessage parses a sign-in message of one of the registered
+// chains.
+func ParseSIWEMessage(text string) (*SIWEMessage, error) {
+	lines := strings.Split(text, "\n")
+	if len(lines) < 9 {
+		return nil, ErrInvalidSIWEMessage
+	}
+
+	domain, chainName, ok := strings.Cut(lines[0], " wants you to sign in with your ")
+	if !ok || domain == "" || !strings.HasSuffix(chainName, siweHeaderSuffix) {
+		return nil, ErrInvalidSIWEMessage
+	}
+	chain, ok := chainByName(strings.TrimSuffix(chainName, siweHeaderSuffix))
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported chain %s", ErrInvalidSIWEMessage, chainName)
+	}
+
+	m := &SIWEMessage{Chain: chain, Domain: domain, Address: lines[1]}
+	if !chain.ValidAddress(m.Address) || lines[2] != "" {
+		return nil, ErrInvalidSIWEMessage
+	}
+
+	rest := lines[3:]
+	if rest[0] != "" {
+		m.Statement = rest[0]
+		rest = rest[1:]
+	}
+	if len(rest) == 0 || rest[0] != "" {
+		return nil, ErrInvalidSIWEMessage
+	}
+	rest = rest[1:]
+
+	p := siweParser{lines: rest}
+	m.URI = p.required(siweURI)
+	m.Version = p.required(siweVersion)
+	m.ChainReference = p.required(siweChainID)
+	m.Nonce = p.required(siweNonce)
+	m.IssuedAt = p.requiredTime(siweIssuedAt)
+	m.ExpirationTime = p.optionalTime(siweExpiration)
+	m.NotBefore = p.optionalTime(siweNotBefore)
+	m.RequestID = p.optional(siweRequestID)
+	m.Resources = p.resources()
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(p.lines) > 0 || m.Version != SIWEVersion || len(m.Nonce) < 8 {
+		return nil, ErrInvalidSIWEMessage
+	}
+	return m, nil
+}
+
+// siweParser consumes the fields of a sign-in message, which must appear in
+// the order of the specification.
+type siweParser struct {
+	lines []string
+	err   error
+}
+
+func (p *siweParser) optional(tag string) string {
+	if p.err != nil || len(p.lines) == 0 || !strings.HasPrefix(p.lines[0], tag) {
+		return ""
+	}
+	value := strings.TrimPrefix(p.lines[0], tag)
+	p.lines = p.lines[1:]
+	return value
+}
+
+func (p *siweParser) required(tag string) string {
+	value := p.optional(tag)
+	if value == "" && p.err == nil {
+		p.err = fmt.Errorf("%w: missing %s", ErrInvalidSIWEMessage, strings.TrimSuffix(tag, ": "))
+	}
+	return value
+}
+
+func (p *siweParser) optionalTime(tag string) *time.Time {
+	value := p.optional(tag)
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		p.err = fmt.Errorf("%w: invalid %s", ErrInvalidSIWEMessage, strings.TrimSuffix(tag, ": "))
+		return nil
+	}
+	return &t
+}
+
+func (p *siweParser) requiredTime(tag string) time.Time {
+	t := p.optionalTime(tag)
+	if t == nil {
+		if p.err == nil {
+			p.err = fmt.Errorf("%w: missing %s", ErrInvalidSIWEMessage, strings.TrimSuffix(tag, ": "))
+		}
+		return time.Time{}
+	}
+	return *t
+}
+
+func (p *siweParser) resources() []string {
+	if p.err != nil || len(p.lines) == 0 || p.lines[0] != siweResources {
+		return nil
+	}
+	p.lines = p.lines[1:]
+
+	var resources []string
+	for len(p.lines) > 0 && strings.HasPrefix(p.lines[0], "- ") {
+		resources = append(resources, strings.TrimPrefix(p.lines[0], "- "))
+		p.lines = p.lines[1:]
+	}
+	return resources
+}
+
+func formatSIWETime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func chainByName(name string) (Chain, bool) {
+	chainsMu.RLock()
+	defer chainsMu.RUnlock()
+	for _, chain := range chains {
+		if chain.Name() == name {
+			return chain, true
+		}
+	}
+	return nil, false
+}
+
+const siweNonceAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// GenerateSIWENonce returns a random alphanumeric nonce, as required by the
+// message format.
+func GenerateSIWENonce() (string, error) {
+	nonce := make([]byte, 24)
+	max := big.NewInt(int64(len(siweNonceAlphabet)))
+	for i := range nonce {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("web3: generating nonce: %w", err)
+		}
+		nonce[i] = siweNonceAlphabet[n.Int64()]
+	}
+	return string(nonce), nil
+}
@@ -0,0 +1,108 @@
+package web3
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSIWEMessage(t *testing.T) {
+	RegisterChains(Solana{})
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+	address := encodeBase58(publicKey)
+
+	issuedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expiresAt := issuedAt.Add(10 * time.Minute)
+	message := &SIWEMessage{
+		Chain:          Solana{},
+		Domain:         "example.com",
+		Address:        address,
+		Statement:      "Sign in to Example",
+		URI:            "https://example.com",
+		Version:        SIWEVersion,
+		ChainReference: "5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp",
+		Nonce:          "abcdefgh12345678",
+		IssuedAt:       issuedAt,
+		ExpirationTime: &expiresAt,
+		Resources:      []string{"https://example.com/terms"},
+	}
+
+	text := message.String()
+	assert.Equal(t, "example.com wants you to sign in with your Solana account:\n"+
+		address+"\n"+
+		"\n"+
+		"Sign in to Example\n"+
+		"\n"+
+		"URI: https://example.com\n"+
+		"Version: 1\n"+
+		"Chain ID: 5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp\n"+
+		"Nonce: abcdefgh12345678\n"+
+		"Issued At: 2024-01-02T03:04:05Z\n"+
+		"Expiration Time: 2024-01-02T03:14:05Z\n"+
+		"Resources:\n"+
+		"- https://example.com/terms", text)
+	assert.True(t, IsSIWEMessage(text))
+	assert.False(t, IsSIWEMessage("abcdefgh12345678"))
+
+	parsed, err := ParseSIWEMessage(text)
+	require.NoError(t, err)
+	assert.Equal(t, message, parsed)
+	assert.Equal(t, "solana:5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp", parsed.ChainID())
+
+	assert.NoError(t, parsed.Validate(issuedAt))
+	assert.ErrorIs(t, parsed.Validate(expiresAt), ErrSIWEMessageExpired)
+
+	// without a statement
+	message.Statement = ""
+	parsed, err = ParseSIWEMessage(message.String())
+	require.NoError(t, err)
+	assert.Equal(t, message, parsed)
+
+	// unsupported chains and malformed messages are rejected
+	_, err = ParseSIWEMessage("example.com wants you to sign in with your Bitcoin account:\n" + text[len("example.com wants you to sign in with your Solana account:\n"):])
+	assert.ErrorIs(t, err, ErrInvalidSIWEMessage)
+	_, err = ParseSIWEMessage(text + "\nunexpected")
+	assert.ErrorIs(t, err, ErrInvalidSIWEMessage)
+
+	signature := ed25519.Sign(privateKey, []byte(text))
+	assert.True(t, Solana{}.VerifySignature(address, text, encodeBase58(signature)))
+	assert.True(t, Solana{}.VerifySignature(address, text, "0x"+hex.EncodeToString(signature)))
+	assert.False(t, Solana{}.VerifySignature(address, text+" ", encodeBase58(signature)))
+}
+
+func TestGenerateSIWENonce(t *testing.T) {
+	t.Parallel()
+
+	nonce, err := GenerateSIWENonce()
+	require.NoError(t, err)
+	assert.Len(t, nonce, 24)
+	for _, r := range nonce {
+		assert.Contains(t, siweNonceAlphabet, string(r))
+	}
+}
+
+func encodeBase58(data []byte) string {
+	value := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var encoded []byte
+	for value.Sign() > 0 {
+		value.DivMod(value, radix, mod)
+		encoded = append([]byte{base58Alphabet[mod.Int64()]}, encoded...)
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append([]byte{base58Alphabet[0]}, encoded...)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,92 @@
+package web3
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Solana supports wallets of the Solana chain, whose addresses are base58
+// encoded ed25519 public keys.
+type Solana struct{}
+
+var _ Chain = Solana{}
+
+func (Solana) Namespace() string {
+	return "solana"
+}
+
+func (Solana) Name() string {
+	return "Solana"
+}
+
+// DefaultChainID is the CAIP-2 id of the Solana mainnet, which is derived
+// from its genesis hash.
+func (Solana) DefaultChainID() string {
+	return "solana:5eykt4UsFv8P8NJdTREpY1vzqKqZKvdp"
+}
+
+func (Solana) ValidAddress(address string) bool {
+	publicKey, err := decodeBase58(address)
+	return err == nil && len(publicKey) == ed25519.PublicKeySize
+}
+
+// SameAddress compares addresses as they are, since base58 is case
+// sensitive.
+func (Solana) SameAddress(a, b string) bool {
+	return a == b
+}
+
+// VerifySignature verifies ed25519 signatures of the message, as produced by
+// the signMessage method of Solana wallets. The signature can be base58 or
+// hex encoded.
+func (Solana) VerifySignature(address, message, signature string) bool {
+	publicKey, err := decodeBase58(address)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return false
+	}
+
+	var sig []byte
+	if hexSig, found := strings.CutPrefix(signature, "0x"); found {
+		sig, err = hex.DecodeString(hexSig)
+	} else {
+		sig, err = decodeBase58(signature)
+	}
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+
+	return ed25519.Verify(publicKey, []byte(message), sig)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var errInvalidBase58 = errors.New("web3: invalid base58 string")
+
+// decodeBase58 decodes strings in the Bitcoin base58 alphabet, which
+// Solana uses for addresses and signatures.
+func decodeBase58(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errInvalidBase58
+	}
+
+	value := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		digit := strings.IndexRune(base58Alphabet, r)
+		if digit < 0 {
+			return nil, errInvalidBase58
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(digit)))
+	}
+
+	// Leading zero bytes are encoded as leading '1's
+	leadingZeros := 0
+	for leadingZeros < len(s) && s[leadingZeros] == base58Alphabet[0] {
+		leadingZeros++
+	}
+	return append(make([]byte, leadingZeros), value.Bytes()...), nil
+}
@@ -0,0 +1,75 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"clerk/pkg/outbound"
+)
+
+const (
+	deliveryTimeout = 15 * time.Second
+	userAgent       = "Clerk-Webhooks/1.0"
+)
+
+// ErrUnexpectedStatus is returned when an endpoint doesn't respond with a
+// 2xx status.
+var ErrUnexpectedStatus = errors.New("webhooks: unexpected status")
+
+// Result is the outcome of a delivery attempt. StatusCode is zero if the
+// endpoint couldn't be reached. Response is a sanitized snippet of the
+// response body, which is kept for debugging.
+type Result struct {
+	StatusCode int
+	Response   string
+}
+
+// Client delivers signed messages to webhook endpoints. Endpoint URLs are
+// configured by customers, so messages are only delivered to public
+// addresses and redirects aren't followed.
+type Client struct {
+	httpClient *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{
+		httpClient: outbound.NewClient(deliveryTimeout),
+	}
+}
+
+// Deliver posts the message to the endpoint URL, signed with the endpoint
+// secret. Any response other than 2xx is an error, so that the message is
+// retried.
+func (c *Client) Deliver(ctx context.Context, url, secret string, msg Message) (*Result, error) {
+	signature, err := Sign(secret, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg.Payload))
+	if err != nil {
+		return nil, fmt.Errorf("webhooks: creating request to %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderID, msg.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(msg.Timestamp.Unix(), 10))
+	req.Header.Set(HeaderSignature, signature)
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return &Result{}, fmt.Errorf("webhooks: delivering message %s: %w", msg.ID, err)
+	}
+	defer res.Body.Close()
+
+	result := &Result{StatusCode: res.StatusCode, Response: outbound.Snippet(res.Body)}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return result, fmt.Errorf("%w %d for message %s", ErrUnexpectedStatus, res.StatusCode, msg.ID)
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clerk/pkg/outbound"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	// The example of the Standard Webhooks specification.
+	signature, err := Sign("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", Message{
+		ID:        "msg_p5jXN8AQM9LWM0D4loKWxJek",
+		Timestamp: time.Unix(1614265330, 0),
+		Payload:   []byte(`{"test": 2432232314}`),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", signature)
+
+	_, err = Sign("whsec_not base64", Message{})
+	assert.Error(t, err)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	t.Parallel()
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	_, err = Sign(secret, Message{ID: "msg_123", Timestamp: time.Now()})
+	assert.NoError(t, err)
+}
+
+func TestNextAttemptAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		attempts int
+		want     time.Duration
+		retry    bool
+	}{
+		{attempts: 1, want: time.Minute, retry: true},
+		{attempts: 2, want: 2 * time.Minute, retry: true},
+		{attempts: 3, want: 4 * time.Minute, retry: true},
+		{attempts: 9, want: 256 * time.Minute, retry: true},
+		{attempts: MaxAttempts, retry: false},
+	} {
+		next, retry := NextAttemptAt(tc.attempts, now)
+		assert.Equal(t, tc.retry, retry, "attempts %d", tc.attempts)
+		if tc.retry {
+			assert.Equal(t, now.Add(tc.want), next, "attempts %d", tc.attempts)
+		}
+	}
+
+	assert.Equal(t, maxBackoff, Backoff(100))
+}
+
+func TestDeliverRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the message was delivered to a loopback address")
+	}))
+	defer server.Close()
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	result, err := NewClient().Deliver(context.Background(), server.URL, secret, Message{
+		ID:        "msg_1",
+		Timestamp: time.Now(),
+		Payload:   []byte(`{}`),
+	})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, outbound.ErrForbiddenAddress))
+	assert.Zero(t, result.StatusCode)
+}
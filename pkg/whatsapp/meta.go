@@ -0,0 +1,132 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const metaBaseURL = "https://graph.facebook.com/v19.0"
+
+// Meta sends messages through the WhatsApp Cloud API of Meta, with templates
+// created in the WhatsApp Manager.
+type Meta struct {
+	baseURL    string
+	httpClient *http.Client
+
+	// phoneNumberID is the ID of the WhatsApp Business phone number that
+	// sends the messages
+	phoneNumberID string
+	accessToken   string
+}
+
+func NewMeta(phoneNumberID, accessToken string) *Meta {
+	return &Meta{
+		baseURL:       metaBaseURL,
+		httpClient:    newHTTPClient(),
+		phoneNumberID: phoneNumberID,
+		accessToken:   accessToken,
+	}
+}
+
+func (*Meta) Name() string {
+	return ProviderMeta
+}
+
+type metaParameter struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type metaComponent struct {
+	Type       string          `json:"type"`
+	SubType    string          `json:"sub_type,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Parameters []metaParameter `json:"parameters"`
+}
+
+type metaTemplate struct {
+	Name     string `json:"name"`
+	Language struct {
+		Code string `json:"code"`
+	} `json:"language"`
+	Components []metaComponent `json:"components,omitempty"`
+}
+
+type metaMessage struct {
+	MessagingProduct string       `json:"messaging_product"`
+	To               string       `json:"to"`
+	Type             string       `json:"type"`
+	Template         metaTemplate `json:"template"`
+}
+
+func (m *Meta) Send(ctx context.Context, msg Message) (string, error) {
+	payload, err := json.Marshal(newMetaMessage(msg))
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/meta: encoding message: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/messages", m.baseURL, m.phoneNumberID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/meta: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.accessToken)
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/meta: sending message: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", &Error{Provider: ProviderMeta, StatusCode: res.StatusCode, Response: string(body)}
+	}
+
+	var response struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("whatsapp/meta: decoding response: %w", err)
+	}
+	if len(response.Messages) == 0 {
+		return "", fmt.Errorf("whatsapp/meta: response without message: %s", body)
+	}
+	return response.Messages[0].ID, nil
+}
+
+func newMetaMessage(msg Message) metaMessage {
+	message := metaMessage{
+		MessagingProduct: "whatsapp",
+		To:               msg.To,
+		Type:             "template",
+		Template:         metaTemplate{Name: msg.Template},
+	}
+	message.Template.Language.Code = msg.Language
+
+	if len(msg.Parameters) > 0 {
+		body := metaComponent{Type: "body", Parameters: make([]metaParameter, len(msg.Parameters))}
+		for i, parameter := range msg.Parameters {
+			body.Parameters[i] = metaParameter{Type: "text", Text: parameter}
+		}
+		message.Template.Components = append(message.Template.Components, body)
+	}
+	if msg.CopyCode != "" {
+		// authentication templates have a button which copies the code,
+		// and it has to be filled in as well
+		message.Template.Components = append(message.Template.Components, metaComponent{
+			Type:       "button",
+			SubType:    "url",
+			Index:      "0",
+			Parameters: []metaParameter{{Type: "text", Text: msg.CopyCode}},
+		})
+	}
+	return message
+}
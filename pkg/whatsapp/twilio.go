@@ -0,0 +1,85 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const twilioBaseURL = "https://api.twilio.com"
+
+// Twilio sends messages through the Programmable Messaging API of Twilio,
+// with templates created in its Content Template Builder.
+type Twilio struct {
+	baseURL    string
+	httpClient *http.Client
+
+	accountSID string
+	authToken  string
+	// from is the WhatsApp sender of the account, in E.164 format
+	from string
+}
+
+func NewTwilio(accountSID, authToken, from string) *Twilio {
+	return &Twilio{
+		baseURL:    twilioBaseURL,
+		httpClient: newHTTPClient(),
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       from,
+	}
+}
+
+func (*Twilio) Name() string {
+	return ProviderTwilio
+}
+
+func (t *Twilio) Send(ctx context.Context, msg Message) (string, error) {
+	// Twilio numbers the placeholders of templates starting from 1
+	variables := make(map[string]string, len(msg.Parameters))
+	for i, parameter := range msg.Parameters {
+		variables[strconv.Itoa(i+1)] = parameter
+	}
+	contentVariables, err := json.Marshal(variables)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/twilio: encoding parameters: %w", err)
+	}
+
+	form := url.Values{
+		"From":             {"whatsapp:" + t.from},
+		"To":               {"whatsapp:" + msg.To},
+		"ContentSid":       {msg.Template},
+		"ContentVariables": {string(contentVariables)},
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", t.baseURL, t.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/twilio: creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.accountSID, t.authToken)
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("whatsapp/twilio: sending message: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return "", &Error{Provider: ProviderTwilio, StatusCode: res.StatusCode, Response: string(body)}
+	}
+
+	var response struct {
+		SID string `json:"sid"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("whatsapp/twilio: decoding response: %w", err)
+	}
+	return response.SID, nil
+}
@@ -0,0 +1,72 @@
+// Package whatsapp sends template messages over WhatsApp, through either
+// Twilio or the Meta Cloud API.
+//
+// WhatsApp only allows businesses to start a conversation with a template
+// which was approved beforehand, so messages reference a template by name
+// and only fill in its parameters, e.g. the one-time code.
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	ProviderTwilio = "twilio"
+	ProviderMeta   = "meta"
+
+	requestTimeout = 10 * time.Second
+
+	// the maximum size of the provider response we keep for debugging
+	maxResponseSize = 1024
+)
+
+// ErrUnexpectedStatus is returned when a provider doesn't accept a message.
+var ErrUnexpectedStatus = errors.New("whatsapp: unexpected status")
+
+// Message is a template message to a phone number.
+type Message struct {
+	// To is the phone number of the recipient, in E.164 format.
+	To string
+	// Template identifies the approved template: its name for the Meta
+	// Cloud API, or its content SID for Twilio.
+	Template string
+	// Language is the language code of the template, e.g. "en_US".
+	Language string
+	// Parameters fill in the placeholders of the template body, in order.
+	Parameters []string
+	// CopyCode is the code of the copy-code button of authentication
+	// templates, if any.
+	CopyCode string
+}
+
+// Provider delivers messages to WhatsApp.
+type Provider interface {
+	Name() string
+	// Send hands the message over to the provider and returns the ID the
+	// provider assigned to it. The message has been accepted, not
+	// necessarily delivered, once Send returns.
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Error is returned when the provider rejects a message.
+type Error struct {
+	Provider   string
+	StatusCode int
+	Response   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s %d from %s: %s", ErrUnexpectedStatus, e.StatusCode, e.Provider, e.Response)
+}
+
+func (e *Error) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
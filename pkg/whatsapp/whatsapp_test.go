@@ -0,0 +1,111 @@
+package whatsapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioSend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", user)
+		assert.Equal(t, "secret", password)
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "whatsapp:+15550001111", r.PostForm.Get("From"))
+		assert.Equal(t, "whatsapp:+15552223333", r.PostForm.Get("To"))
+		assert.Equal(t, "HX123", r.PostForm.Get("ContentSid"))
+		assert.JSONEq(t, `{"1":"123456"}`, r.PostForm.Get("ContentVariables"))
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"sid":"SM123"}`))
+	}))
+	defer server.Close()
+
+	twilio := NewTwilio("AC123", "secret", "+15550001111")
+	twilio.baseURL = server.URL
+
+	id, err := twilio.Send(context.Background(), Message{
+		To:         "+15552223333",
+		Template:   "HX123",
+		Parameters: []string{"123456"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "SM123", id)
+}
+
+func TestMetaSend(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/100200/messages", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		var message map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+		expected := `{
+			"messaging_product": "whatsapp",
+			"to": "+15552223333",
+			"type": "template",
+			"template": {
+				"name": "verification_code",
+				"language": {"code": "en_US"},
+				"components": [
+					{"type": "body", "parameters": [{"type": "text", "text": "123456"}]},
+					{"type": "button", "sub_type": "url", "index": "0", "parameters": [{"type": "text", "text": "123456"}]}
+				]
+			}
+		}`
+		actual, err := json.Marshal(message)
+		require.NoError(t, err)
+		assert.JSONEq(t, expected, string(actual))
+
+		_, _ = w.Write([]byte(`{"messaging_product":"whatsapp","messages":[{"id":"wamid.123"}]}`))
+	}))
+	defer server.Close()
+
+	meta := NewMeta("100200", "token")
+	meta.baseURL = server.URL
+
+	id, err := meta.Send(context.Background(), Message{
+		To:         "+15552223333",
+		Template:   "verification_code",
+		Language:   "en_US",
+		Parameters: []string{"123456"},
+		CopyCode:   "123456",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "wamid.123", id)
+}
+
+func TestSendRejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"Template name does not exist"}}`))
+	}))
+	defer server.Close()
+
+	meta := NewMeta("100200", "token")
+	meta.baseURL = server.URL
+
+	_, err := meta.Send(context.Background(), Message{To: "+15552223333", Template: "missing"})
+	require.ErrorIs(t, err, ErrUnexpectedStatus)
+
+	var providerErr *Error
+	require.True(t, errors.As(err, &providerErr))
+	assert.Equal(t, ProviderMeta, providerErr.Provider)
+	assert.Equal(t, http.StatusBadRequest, providerErr.StatusCode)
+}
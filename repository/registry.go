@@ -0,0 +1,219 @@
+package repository
+
+import "github.com/jonboulle/clockwork"
+
+// Registry holds a single instance of each repository. It's created once at
+// startup and handed to services through clerk.Deps, so that service
+// constructors don't have to create their own repositories and tests can
+// replace any of them in a single place.
+//
+// Only the constructors which take clerk.Deps get their repositories from
+// the registry. The ones which take narrower dependencies, like a clock or a
+// database, still create their own, as do the attemptors and preparers of
+// the verification strategies; moving them over means changing the
+// signatures of all their callers.
+type Registry struct {
+	AccountLinks                   *AccountLinks
+	AccountPortal                  *AccountPortal
+	AccountTransfers               *AccountTransfers
+	ActorToken                     *ActorToken
+	Allowlist                      *Allowlist
+	ApplicationIntegrations        *ApplicationIntegrations
+	ApplicationOwnerships          *ApplicationOwnerships
+	Applications                   *Applications
+	AuthConfig                     *AuthConfig
+	BackchannelLogoutDeliveries    *BackchannelLogoutDeliveries
+	Backfills                      *Backfills
+	BackupCode                     *BackupCode
+	BillingAccounts                *BillingAccounts
+	BillingCheckoutSession         *BillingCheckoutSession
+	BillingOAuthStateToken         *BillingOAuthStateToken
+	BillingPlans                   *BillingPlans
+	BillingSubscriptions           *BillingSubscriptions
+	Blocklist                      *Blocklist
+	Clients                        *Clients
+	DNSChecks                      *DNSChecks
+	DailyAggregations              *DailyAggregations
+	DailySuccessfulSignIns         *DailySuccessfulSignIns
+	DailySuccessfulSignUps         *DailySuccessfulSignUps
+	DailyUniqueActiveUsers         *DailyUniqueActiveUsers
+	DevBrowser                     *DevBrowser
+	DisplayConfig                  *DisplayConfig
+	Domain                         *Domain
+	Email                          *Email
+	EmailDomainReport              *EmailDomainReport
+	EnabledSSOProviders            *EnabledSSOProviders
+	Environment                    *Environment
+	ExternalAccount                *ExternalAccount
+	GueJobs                        *GueJobs
+	IPRestrictionRules             *IPRestrictionRules
+	Identification                 *Identification
+	Images                         *Images
+	ImpersonationAudits            *ImpersonationAudits
+	InstanceAuditLogs              *InstanceAuditLogs
+	InstanceKeys                   *InstanceKeys
+	InstanceMetricCounters         *InstanceMetricCounters
+	InstanceSigningKeys            *InstanceSigningKeys
+	Instances                      *Instances
+	Integrations                   *Integrations
+	Invitations                    *Invitations
+	JWTServices                    *JWTServices
+	JWTTemplate                    *JWTTemplate
+	LegalAcceptances               *LegalAcceptances
+	OAuth1RequestTokens            *OAuth1RequestTokens
+	OAuthApplicationConsents       *OAuthApplicationConsents
+	OAuthApplicationTokens         *OAuthApplicationTokens
+	OAuthApplications              *OAuthApplications
+	OauthConfig                    *OauthConfig
+	Organization                   *Organization
+	OrganizationAPIKeys            *OrganizationAPIKeys
+	OrganizationDomain             *OrganizationDomain
+	OrganizationDomainVerification *OrganizationDomainVerification
+	OrganizationInvitation         *OrganizationInvitation
+	OrganizationMembership         *OrganizationMembership
+	OrganizationMembershipExports  *OrganizationMembershipExports
+	OrganizationMembershipRequest  *OrganizationMembershipRequest
+	OrganizationSuggestion         *OrganizationSuggestion
+	Passkey                        *Passkey
+	PasskeyAuthenticator           *PasskeyAuthenticator
+	Permission                     *Permission
+	ProxyCheck                     *ProxyCheck
+	RedirectUrls                   *RedirectUrls
+	Role                           *Role
+	RolePermission                 *RolePermission
+	SAMLAccount                    *SAMLAccount
+	SAMLConnection                 *SAMLConnection
+	SMSCountryTiers                *SMSCountryTiers
+	SMSMessage                     *SMSMessage
+	ServiceAccounts                *ServiceAccounts
+	SessionActivities              *SessionActivities
+	Sessions                       *Sessions
+	SignIn                         *SignIn
+	SignInToken                    *SignInToken
+	SignUp                         *SignUp
+	StripeUsageReports             *StripeUsageReports
+	SubscriptionMetrics            *SubscriptionMetrics
+	SubscriptionPlans              *SubscriptionPlans
+	SubscriptionPrices             *SubscriptionPrices
+	SubscriptionProduct            *SubscriptionProduct
+	Subscriptions                  *Subscriptions
+	SyncNonces                     *SyncNonces
+	TOTP                           *TOTP
+	Templates                      *Templates
+	TrustedDevices                 *TrustedDevices
+	UserBulkImports                *UserBulkImports
+	UserExports                    *UserExports
+	Users                          *Users
+	Verification                   *Verification
+	WebhookDeadLetters             *WebhookDeadLetters
+	WebhookDeliveries              *WebhookDeliveries
+	WebhookEndpoints               *WebhookEndpoints
+	WebhookEventReplayMarkers      *WebhookEventReplayMarkers
+	WebhookEventReplays            *WebhookEventReplays
+	WebhookEvents                  *WebhookEvents
+	WhatsAppMessages               *WhatsAppMessages
+}
+
+func NewRegistry(clock clockwork.Clock) *Registry {
+	return &Registry{
+		AccountLinks:                   NewAccountLinks(),
+		AccountPortal:                  NewAccountPortal(),
+		AccountTransfers:               NewAccountTransfers(),
+		ActorToken:                     NewActorToken(),
+		Allowlist:                      NewAllowlist(),
+		ApplicationIntegrations:        NewApplicationIntegrations(),
+		ApplicationOwnerships:          NewApplicationOwnerships(),
+		Applications:                   NewApplications(),
+		AuthConfig:                     NewAuthConfig(),
+		BackchannelLogoutDeliveries:    NewBackchannelLogoutDeliveries(),
+		Backfills:                      NewBackfills(),
+		BackupCode:                     NewBackupCode(),
+		BillingAccounts:                NewBillingAccounts(),
+		BillingCheckoutSession:         NewBillingCheckoutSession(),
+		BillingOAuthStateToken:         NewBillingOAuthStateToken(),
+		BillingPlans:                   NewBillingPlans(),
+		BillingSubscriptions:           NewBillingSubscriptions(),
+		Blocklist:                      NewBlocklist(),
+		Clients:                        NewClients(),
+		DNSChecks:                      NewDNSChecks(),
+		DailyAggregations:              NewDailyAggregations(),
+		DailySuccessfulSignIns:         NewDailySuccessfulSignIns(),
+		DailySuccessfulSignUps:         NewDailySuccessfulSignUps(),
+		DailyUniqueActiveUsers:         NewDailyUniqueActiveUsers(),
+		DevBrowser:                     NewDevBrowser(),
+		DisplayConfig:                  NewDisplayConfig(),
+		Domain:                         NewDomain(),
+		Email:                          NewEmail(),
+		EmailDomainReport:              NewEmailDomainReport(),
+		EnabledSSOProviders:            NewEnabledSSOProviders(),
+		Environment:                    NewEnvironment(),
+		ExternalAccount:                NewExternalAccount(),
+		GueJobs:                        NewGueJobs(),
+		IPRestrictionRules:             NewIPRestrictionRules(),
+		Identification:                 NewIdentification(),
+		Images:                         NewImages(),
+		ImpersonationAudits:            NewImpersonationAudits(),
+		InstanceAuditLogs:              NewInstanceAuditLogs(),
+		InstanceKeys:                   NewInstanceKeys(),
+		InstanceMetricCounters:         NewInstanceMetricCounters(),
+		InstanceSigningKeys:            NewInstanceSigningKeys(),
+		Instances:                      NewInstances(),
+		Integrations:                   NewIntegrations(),
+		Invitations:                    NewInvitations(),
+		JWTServices:                    NewJWTServices(),
+		JWTTemplate:                    NewJWTTemplate(),
+		LegalAcceptances:               NewLegalAcceptances(),
+		OAuth1RequestTokens:            NewOAuth1RequestTokens(),
+		OAuthApplicationConsents:       NewOAuthApplicationConsents(),
+		OAuthApplicationTokens:         NewOAuthApplicationTokens(),
+		OAuthApplications:              NewOAuthApplications(),
+		OauthConfig:                    NewOauthConfig(),
+		Organization:                   NewOrganization(),
+		OrganizationAPIKeys:            NewOrganizationAPIKeys(),
+		OrganizationDomain:             NewOrganizationDomain(),
+		OrganizationDomainVerification: NewOrganizationDomainVerification(),
+		OrganizationInvitation:         NewOrganizationInvitation(),
+		OrganizationMembership:         NewOrganizationMembership(),
+		OrganizationMembershipExports:  NewOrganizationMembershipExports(),
+		OrganizationMembershipRequest:  NewOrganizationMembershipRequest(),
+		OrganizationSuggestion:         NewOrganizationSuggestion(),
+		Passkey:                        NewPasskey(),
+		PasskeyAuthenticator:           NewPasskeyAuthenticator(),
+		Permission:                     NewPermission(),
+		ProxyCheck:                     NewProxyCheck(),
+		RedirectUrls:                   NewRedirectUrls(),
+		Role:                           NewRole(),
+		RolePermission:                 NewRolePermission(),
+		SAMLAccount:                    NewSAMLAccount(),
+		SAMLConnection:                 NewSAMLConnection(),
+		SMSCountryTiers:                NewSMSCountryTiers(),
+		SMSMessage:                     NewSMSMessage(),
+		ServiceAccounts:                NewServiceAccounts(),
+		SessionActivities:              NewSessionActivities(),
+		Sessions:                       NewSessions(clock),
+		SignIn:                         NewSignIn(),
+		SignInToken:                    NewSignInToken(),
+		SignUp:                         NewSignUp(),
+		StripeUsageReports:             NewStripeUsageReports(),
+		SubscriptionMetrics:            NewSubscriptionMetrics(),
+		SubscriptionPlans:              NewSubscriptionPlans(),
+		SubscriptionPrices:             NewSubscriptionPrices(),
+		SubscriptionProduct:            NewSubscriptionProduct(),
+		Subscriptions:                  NewSubscriptions(),
+		SyncNonces:                     NewSyncNonces(),
+		TOTP:                           NewTOTP(),
+		Templates:                      NewTemplates(),
+		TrustedDevices:                 NewTrustedDevices(),
+		UserBulkImports:                NewUserBulkImports(),
+		UserExports:                    NewUserExports(),
+		Users:                          NewUsers(),
+		Verification:                   NewVerification(),
+		WebhookDeadLetters:             NewWebhookDeadLetters(),
+		WebhookDeliveries:              NewWebhookDeliveries(),
+		WebhookEndpoints:               NewWebhookEndpoints(),
+		WebhookEventReplayMarkers:      NewWebhookEventReplayMarkers(),
+		WebhookEventReplays:            NewWebhookEventReplays(),
+		WebhookEvents:                  NewWebhookEvents(),
+		WhatsAppMessages:               NewWhatsAppMessages(),
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry(clockwork.NewFakeClock())
+
+	// every repository is created, so that services never get a nil one
+	value := reflect.ValueOf(registry).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		assert.False(t, value.Field(i).IsNil(), "repository %s is not set", value.Type().Field(i).Name)
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"clerk/model/sqbmodel"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// metadataFilterColumns are the user metadata columns which can be filtered
+// on. Both have a GIN index with jsonb_path_ops, which serves containment
+// queries on any key.
+var metadataFilterColumns = map[string]bool{
+	sqbmodel.UserColumns.PublicMetadata: true,
+	sqbmodel.UserColumns.UnsafeMetadata: true,
+}
+
+// MetadataFilter matches users whose metadata column contains any of the
+// given JSON documents, e.g. {"plan":"pro"}.
+type MetadataFilter struct {
+	Column    string
+	Documents []string
+}
+
+// metadataFilterMods returns the query mods which match users for all the
+// given filters, as JSONB containment conditions on the users table.
+func metadataFilterMods(filters []MetadataFilter) ([]qm.QueryMod, error) {
+	mods := make([]qm.QueryMod, 0, len(filters))
+	for _, filter := range filters {
+		if !metadataFilterColumns[filter.Column] {
+			return nil, fmt.Errorf("repository/metadataFilterMods: cannot filter on column %q", filter.Column)
+		}
+		if len(filter.Documents) == 0 {
+			continue
+		}
+
+		conditions := make([]string, len(filter.Documents))
+		args := make([]interface{}, len(filter.Documents))
+		for i, document := range filter.Documents {
+			conditions[i] = fmt.Sprintf("%s.%s @> ?::jsonb", sqbmodel.TableNames.Users, filter.Column)
+			args[i] = document
+		}
+		mods = append(mods, qm.Where("("+strings.Join(conditions, " OR ")+")", args...))
+	}
+	return mods, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"fmt"
+
+	"clerk/model/sqbmodel"
+	"clerk/pkg/constants"
+	"clerk/pkg/usersearch"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// The search columns of the users table. search_vector is the tsvector of
+// the names, email addresses, username and phone numbers of the user, and
+// search_text is the same identifiers as plain text. Both are kept up to
+// date by triggers on users and identifications, and have a GIN index,
+// with gin_trgm_ops for search_text.
+const (
+	userSearchVectorColumn = "search_vector"
+	userSearchTextColumn   = "search_text"
+)
+
+// userSearchMods returns the query mods which match users for the given
+// search query. Users match if every term of the query is a word prefix of
+// their identifiers, or if the query is similar enough to them, so that
+// typos still find the user. Users whose ID or one of whose web3 wallets is
+// the query itself always match, since neither is part of the search
+// columns.
+//
+// When rank is set, users are ordered by how well they match the query,
+// best first, and then by creation, so that pages are stable. Exact matches
+// come before everything else. It's meant for queries which aren't
+// explicitly ordered.
+func userSearchMods(query string, rank bool) []qm.QueryMod {
+	exact := fmt.Sprintf(
+		"(%[1]s.%[2]s = ? OR EXISTS (SELECT 1 FROM %[3]s WHERE %[3]s.%[4]s = %[1]s.%[2]s AND %[3]s.%[5]s = ? AND lower(%[3]s.%[6]s) = lower(?)))",
+		sqbmodel.TableNames.Users, sqbmodel.UserColumns.ID,
+		sqbmodel.TableNames.Identifications, sqbmodel.IdentificationColumns.UserID,
+		sqbmodel.IdentificationColumns.Type, sqbmodel.IdentificationColumns.Identifier,
+	)
+	exactArgs := []interface{}{query, constants.ITWeb3Wallet, query}
+
+	terms := usersearch.Terms(query)
+	if len(terms) == 0 {
+		// A query of punctuation only can't match any identifier of the
+		// search columns.
+		return []qm.QueryMod{qm.Where(exact, exactArgs...)}
+	}
+	tsquery := usersearch.TSQuery(terms)
+
+	vector := fmt.Sprintf("%s.%s", sqbmodel.TableNames.Users, userSearchVectorColumn)
+	text := fmt.Sprintf("%s.%s", sqbmodel.TableNames.Users, userSearchTextColumn)
+	mods := []qm.QueryMod{
+		qm.Where(
+			fmt.Sprintf("(%s @@ to_tsquery('simple', ?) OR %s %% ? OR %s)", vector, text, exact),
+			append([]interface{}{tsquery, query}, exactArgs...)...,
+		),
+	}
+	if rank {
+		mods = append(mods, qm.OrderBy(
+			fmt.Sprintf("%s DESC, ts_rank(%s, to_tsquery('simple', ?)) + similarity(%s, ?) DESC, %s.%s DESC",
+				exact, vector, text, sqbmodel.TableNames.Users, sqbmodel.UserColumns.CreatedAt),
+			append(exactArgs, tsquery, query)...,
+		))
+	}
+	return mods
+}